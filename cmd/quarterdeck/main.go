@@ -8,6 +8,7 @@ import (
 	"strings"
 	"syscall"
 	"text/tabwriter"
+	"time"
 
 	"github.com/joho/godotenv"
 	confire "github.com/rotationalio/confire/usage"
@@ -18,8 +19,9 @@ import (
 	"go.rtnl.ai/quarterdeck/pkg/config"
 	"go.rtnl.ai/quarterdeck/pkg/errors"
 	"go.rtnl.ai/quarterdeck/pkg/server"
-	"go.rtnl.ai/quarterdeck/pkg/store/v1"
-	"go.rtnl.ai/quarterdeck/pkg/store/v1/models"
+	"go.rtnl.ai/quarterdeck/pkg/store/migrate"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
 	"go.rtnl.ai/ulid"
 	"go.rtnl.ai/x/randstr"
 	"golang.org/x/term"
@@ -30,6 +32,19 @@ var (
 	conf config.Config
 )
 
+// Flags shared by the commands that operate directly on a v1 or v2 database.
+var (
+	dbFlag = &cli.StringFlag{
+		Name:    "db",
+		Aliases: []string{"d"},
+		Usage:   "dsn of the database (uses the configured database by default)",
+	}
+	v1Flag = &cli.BoolFlag{
+		Name:  "v1",
+		Usage: "the database uses the v1 sqlite schema",
+	}
+)
+
 func main() {
 	// If a dotenv file exists, load it for configuration
 	godotenv.Load()
@@ -80,6 +95,141 @@ func main() {
 				},
 			},
 		},
+		{
+			Name:     "keys",
+			Usage:    "manage the rotation of the token signing keys stored in the database",
+			Category: "service",
+			Subcommands: []*cli.Command{
+				{
+					Name:   "list",
+					Usage:  "list the signing keys and their lifecycle state",
+					Before: openDB,
+					Action: listKeys,
+					After:  closeDB,
+				},
+				{
+					Name:   "rotate",
+					Usage:  "publish a new signing key that becomes active after the publish window",
+					Before: openDB,
+					Action: rotateKeys,
+					After:  closeDB,
+					Flags: []cli.Flag{
+						&cli.BoolFlag{
+							Name:    "immediate",
+							Aliases: []string{"i"},
+							Usage:   "activate the new key now (e.g. if the active key is compromised)",
+						},
+					},
+				},
+				{
+					Name:      "retire",
+					Usage:     "retire a next or retiring key so that it is no longer published",
+					ArgsUsage: "kid",
+					Before:    openDB,
+					Action:    retireKey,
+					After:     closeDB,
+				},
+			},
+		},
+		{
+			Name:     "migrate-store",
+			Usage:    "copy users, roles, permissions, api keys, and oidc clients from a v1 database into a v2 database",
+			Category: "service",
+			Action:   migrateStore,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:     "source",
+					Aliases:  []string{"s"},
+					Required: true,
+					Usage:    "dsn of the v1 sqlite database to copy from (it is only read)",
+				},
+				&cli.StringFlag{
+					Name:     "target",
+					Aliases:  []string{"t"},
+					Required: true,
+					Usage:    "dsn of the v2 sqlite or postgres database to copy into",
+				},
+				&cli.BoolFlag{
+					Name:    "dry-run",
+					Aliases: []string{"d"},
+					Usage:   "report the rows that would be copied without writing to the target",
+				},
+				&cli.IntFlag{
+					Name:    "batch-size",
+					Aliases: []string{"b"},
+					Usage:   "number of rows to write to the target in each transaction",
+					Value:   migrate.DefaultBatchSize,
+				},
+			},
+		},
+		{
+			Name:     "backup",
+			Usage:    "write a consistent snapshot of the database to a file while the server is online",
+			Category: "service",
+			Action:   backup,
+			Flags: []cli.Flag{
+				dbFlag,
+				v1Flag,
+				&cli.StringFlag{
+					Name:     "out",
+					Aliases:  []string{"o"},
+					Required: true,
+					Usage:    "path to write the backup to (must not already exist)",
+				},
+			},
+		},
+		{
+			Name:     "restore",
+			Usage:    "replace the database with a backup (the server must be stopped)",
+			Category: "service",
+			Action:   restore,
+			Flags: []cli.Flag{
+				dbFlag,
+				v1Flag,
+				&cli.StringFlag{
+					Name:     "in",
+					Aliases:  []string{"i"},
+					Required: true,
+					Usage:    "path of the backup to restore",
+				},
+			},
+		},
+		{
+			Name:     "export",
+			Usage:    "export users, roles, permissions, api keys, oidc clients, passkeys, webhooks, and audit events as versioned ndjson",
+			Category: "service",
+			Action:   exportStore,
+			Flags: []cli.Flag{
+				dbFlag,
+				v1Flag,
+				&cli.StringFlag{
+					Name:    "out",
+					Aliases: []string{"o"},
+					Usage:   "path to write the export to (stdout by default)",
+				},
+			},
+		},
+		{
+			Name:     "import",
+			Usage:    "import an export into the database, inserting or updating rows",
+			Category: "service",
+			Action:   importStore,
+			Flags: []cli.Flag{
+				dbFlag,
+				v1Flag,
+				&cli.StringFlag{
+					Name:     "in",
+					Aliases:  []string{"i"},
+					Required: true,
+					Usage:    "path of the export to import",
+				},
+				&cli.BoolFlag{
+					Name:    "dry-run",
+					Aliases: []string{"D"},
+					Usage:   "validate the export and report the rows that would be written",
+				},
+			},
+		},
 		{
 			Name:     "createuser",
 			Usage:    "create a new user to access Quarterdeck with",
@@ -127,6 +277,22 @@ func main() {
 				},
 			},
 		},
+		{
+			Name:     "unlockuser",
+			Usage:    "clear the failed login attempts of a user that has been locked out",
+			Category: "users",
+			Before:   openDB,
+			Action:   unlockUser,
+			After:    closeDB,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:     "email",
+					Aliases:  []string{"e"},
+					Required: true,
+					Usage:    "email address of user to unlock",
+				},
+			},
+		},
 	}
 
 	if err := app.Run(os.Args); err != nil {
@@ -189,6 +355,194 @@ func mkkey(c *cli.Context) (err error) {
 	return nil
 }
 
+func listKeys(c *cli.Context) (err error) {
+	var keys []*models.SigningKey
+	if keys, err = db.ListSigningKeys(c.Context); err != nil {
+		return cli.Exit(err, 1)
+	}
+
+	tabs := tabwriter.NewWriter(os.Stdout, 1, 0, 4, ' ', 0)
+	fmt.Fprintln(tabs, "KID\tSTATE\tACTIVATES\tRETIRES")
+	for _, key := range keys {
+		retires := "-"
+		if key.Retires.Valid {
+			retires = key.Retires.Time.Format(time.RFC3339)
+		}
+		fmt.Fprintf(tabs, "%s\t%s\t%s\t%s\n", key.ID, key.State, key.Activates.Format(time.RFC3339), retires)
+	}
+	tabs.Flush()
+	return nil
+}
+
+func rotateKeys(c *cli.Context) (err error) {
+	var keys *auth.KeyManager
+	if keys, err = keyManager(); err != nil {
+		return cli.Exit(err, 1)
+	}
+
+	var key *models.SigningKey
+	if key, err = keys.Rotate(c.Context, c.Bool("immediate")); err != nil {
+		if errors.Is(err, errors.ErrRotationPending) {
+			return cli.Exit("a signing key rotation is already scheduled", 1)
+		}
+		return cli.Exit(err, 1)
+	}
+
+	fmt.Printf("signing key %s is %s and activates at %s\n", key.ID, key.State, key.Activates.Format(time.RFC3339))
+	return nil
+}
+
+func retireKey(c *cli.Context) (err error) {
+	if c.NArg() != 1 {
+		return cli.Exit("specify the kid of the signing key to retire", 1)
+	}
+
+	var keyID ulid.ULID
+	if keyID, err = ulid.Parse(c.Args().First()); err != nil {
+		return cli.Exit(fmt.Errorf("could not parse kid: %w", err), 1)
+	}
+
+	var keys *auth.KeyManager
+	if keys, err = keyManager(); err != nil {
+		return cli.Exit(err, 1)
+	}
+
+	if err = keys.Retire(c.Context, keyID); err != nil {
+		return cli.Exit(err, 1)
+	}
+
+	fmt.Printf("signing key %s has been retired\n", keyID)
+	return nil
+}
+
+func migrateStore(c *cli.Context) (err error) {
+	var m *migrate.Migrator
+	opts := migrate.Options{DryRun: c.Bool("dry-run"), BatchSize: c.Int("batch-size")}
+	if opts.Secrets, err = secretCipher(); err != nil {
+		return cli.Exit(err, 1)
+	}
+
+	if m, err = migrate.Open(c.String("source"), c.String("target"), opts); err != nil {
+		return cli.Exit(err, 1)
+	}
+	defer m.Close()
+
+	var report *migrate.Report
+	if report, err = m.Migrate(c.Context); err != nil {
+		return cli.Exit(err, 1)
+	}
+	report.Print(os.Stdout)
+
+	// Nothing was written to the target so there is nothing to verify.
+	if opts.DryRun {
+		fmt.Println("\ndry run: no rows were written to the target database")
+		return nil
+	}
+
+	// Print the verification report even if it fails so the mismatched tables are shown.
+	fmt.Println()
+	report, err = m.Verify(c.Context)
+	if report != nil {
+		report.Print(os.Stdout)
+	}
+
+	if err != nil {
+		return cli.Exit(err, 1)
+	}
+
+	fmt.Println("\nmigration complete: all tables match the source database")
+	return nil
+}
+
+func backup(c *cli.Context) (err error) {
+	var s *migrate.DB
+	if s, err = openMigrateDB(c); err != nil {
+		return cli.Exit(err, 1)
+	}
+	defer s.Close()
+
+	if err = migrate.Backup(c.Context, s, c.String("out")); err != nil {
+		return cli.Exit(err, 1)
+	}
+
+	fmt.Printf("backed up %s database to %s\n", s.Provider(), c.String("out"))
+	return nil
+}
+
+func restore(c *cli.Context) (err error) {
+	var uri string
+	if uri, err = databaseURL(c); err != nil {
+		return cli.Exit(err, 1)
+	}
+
+	if err = migrate.Restore(c.Context, uri, databaseVersion(c), c.String("in")); err != nil {
+		return cli.Exit(err, 1)
+	}
+
+	fmt.Printf("restored database from %s\n", c.String("in"))
+	return nil
+}
+
+func exportStore(c *cli.Context) (err error) {
+	var s *migrate.DB
+	if s, err = openMigrateDB(c); err != nil {
+		return cli.Exit(err, 1)
+	}
+	defer s.Close()
+
+	// The report is printed to stderr so that the export can be written to stdout.
+	out := os.Stdout
+	if path := c.String("out"); path != "" {
+		if out, err = os.Create(path); err != nil {
+			return cli.Exit(err, 1)
+		}
+		defer out.Close()
+	}
+
+	var secrets migrate.SecretCipher
+	if secrets, err = secretCipher(); err != nil {
+		return cli.Exit(err, 1)
+	}
+
+	var report *migrate.Report
+	if report, err = migrate.Export(c.Context, s, out, secrets); err != nil {
+		return cli.Exit(err, 1)
+	}
+
+	report.Print(os.Stderr)
+	return nil
+}
+
+func importStore(c *cli.Context) (err error) {
+	var s *migrate.DB
+	if s, err = openMigrateDB(c); err != nil {
+		return cli.Exit(err, 1)
+	}
+	defer s.Close()
+
+	var in *os.File
+	if in, err = os.Open(c.String("in")); err != nil {
+		return cli.Exit(err, 1)
+	}
+	defer in.Close()
+
+	var secrets migrate.SecretCipher
+	if secrets, err = secretCipher(); err != nil {
+		return cli.Exit(err, 1)
+	}
+
+	var report *migrate.Report
+	if report, err = migrate.Import(c.Context, s, in, c.Bool("dry-run"), secrets); err != nil {
+		return cli.Exit(err, 1)
+	}
+	report.Print(os.Stdout)
+
+	if report.DryRun {
+		fmt.Println("\ndry run: no rows were written to the database")
+	}
+	return nil
+}
+
 //===========================================================================
 // User Commands
 //===========================================================================
@@ -196,7 +550,7 @@ func mkkey(c *cli.Context) (err error) {
 func createUser(c *cli.Context) (err error) {
 	// Lookup the role by name in the database
 	var (
-		roles     []*models.Role
+		roles     []models.Role
 		roleNames []string
 	)
 
@@ -207,14 +561,14 @@ func createUser(c *cli.Context) (err error) {
 		}
 
 		var role *models.Role
-		if role, err = db.RetrieveRole(c.Context, roleName); err != nil {
+		if role, err = db.RetrieveRoleByTitle(c.Context, roleName); err != nil {
 			if errors.Is(err, errors.ErrNotFound) {
 				return cli.Exit(fmt.Errorf("role %q does not exist", roleName), 1)
 			}
 			return cli.Exit(err, 1)
 		}
 
-		roles = append(roles, role)
+		roles = append(roles, *role)
 		roleNames = append(roleNames, role.Title)
 	}
 
@@ -223,10 +577,9 @@ func createUser(c *cli.Context) (err error) {
 		Name:          sql.NullString{Valid: c.String("name") != "", String: c.String("name")},
 		Email:         c.String("email"),
 		EmailVerified: true,
+		Roles:         roles,
 	}
 
-	user.SetRoles(roles)
-
 	var password string
 	if password, err = inputPassword(); err != nil {
 		return cli.Exit(err, 1)
@@ -236,7 +589,7 @@ func createUser(c *cli.Context) (err error) {
 		return cli.Exit(err, 1)
 	}
 
-	if err = db.CreateUser(c.Context, user); err != nil {
+	if user, err = db.CreateUser(c.Context, user); err != nil {
 		return cli.Exit(err, 1)
 	}
 
@@ -247,7 +600,7 @@ func createUser(c *cli.Context) (err error) {
 func resetPassword(c *cli.Context) (err error) {
 	// Retrieve the user by email
 	var user *models.User
-	if user, err = db.RetrieveUser(c.Context, c.String("email")); err != nil {
+	if user, err = db.RetrieveUserByEmail(c.Context, c.String("email")); err != nil {
 		if errors.Is(err, errors.ErrNotFound) {
 			return cli.Exit(fmt.Errorf("user with email %q does not exist", c.String("email")), 1)
 		}
@@ -289,6 +642,17 @@ func resetPassword(c *cli.Context) (err error) {
 	return nil
 }
 
+func unlockUser(c *cli.Context) (err error) {
+	// Lockouts are tracked by email address whether or not the user exists.
+	email := c.String("email")
+	if err = db.ResetLockout(c.Context, models.LockoutUser, email); err != nil {
+		return cli.Exit(err, 1)
+	}
+
+	fmt.Printf("failed login attempts for %q have been cleared\n", email)
+	return nil
+}
+
 //===========================================================================
 // Action Helpers
 //===========================================================================
@@ -305,6 +669,16 @@ func openDB(c *cli.Context) (err error) {
 	return nil
 }
 
+// Returns a key manager for the database opened by openDB; the keys are synced into an
+// issuer that is discarded since the server replicas pick up rotations themselves.
+func keyManager() (_ *auth.KeyManager, err error) {
+	var issuer *auth.Issuer
+	if issuer, err = auth.NewIssuer(conf.Auth); err != nil {
+		return nil, err
+	}
+	return auth.NewKeyManager(conf.Auth, issuer, db)
+}
+
 func closeDB(c *cli.Context) error {
 	if db != nil {
 		if err := db.Close(); err != nil {
@@ -314,6 +688,53 @@ func closeDB(c *cli.Context) error {
 	return nil
 }
 
+// Returns the dsn from the db flag or from the configuration if the flag is not set.
+func databaseURL(c *cli.Context) (_ string, err error) {
+	if uri := c.String("db"); uri != "" {
+		return uri, nil
+	}
+
+	if conf, err = config.New(); err != nil {
+		return "", err
+	}
+	return conf.Database.URL, nil
+}
+
+func databaseVersion(c *cli.Context) migrate.Version {
+	if c.Bool("v1") {
+		return migrate.V1
+	}
+	return migrate.V2
+}
+
+// TOTP secrets are sealed with the key encryption key of the v2 server when they are
+// migrated or exported from a v1 database; if the key is not configured no cipher is
+// returned and copying a TOTP secret fails.
+func secretCipher() (_ migrate.SecretCipher, err error) {
+	if conf, err = config.New(); err != nil {
+		return nil, err
+	}
+
+	kek := conf.Auth.GetKeyEncryptionKey()
+	if len(kek) == 0 {
+		return nil, nil
+	}
+
+	var cipher *auth.KeyCipher
+	if cipher, err = auth.NewKeyCipher(kek); err != nil {
+		return nil, err
+	}
+	return cipher, nil
+}
+
+func openMigrateDB(c *cli.Context) (_ *migrate.DB, err error) {
+	var uri string
+	if uri, err = databaseURL(c); err != nil {
+		return nil, err
+	}
+	return migrate.OpenDB(uri, databaseVersion(c), false)
+}
+
 func inputPassword() (_ string, err error) {
 	fmt.Print("Enter password: ")
 	password, err := term.ReadPassword(int(syscall.Stdin))
@@ -2,9 +2,6 @@ package api
 
 import (
 	"context"
-
-	"go.rtnl.ai/quarterdeck/pkg/store/v1/models"
-	"go.rtnl.ai/ulid"
 )
 
 //===========================================================================
@@ -33,22 +30,18 @@ type Reply struct {
 type PageQuery struct {
 	PageSize      int    `json:"page_size,omitempty" url:"page_size,omitempty" form:"page_size"`
 	NextPageToken string `json:"next_page_token,omitempty" url:"next_page_token,omitempty" form:"next_page_token"`
+	PrevPageToken string `json:"prev_page_token,omitempty" url:"prev_page_token,omitempty" form:"prev_page_token"`
 }
 
-// PageModel returns a store models.Page for list calls. Nil receiver returns nil.
-func (q *PageQuery) PageModel() *models.Page {
-	if q == nil {
-		return nil
-	}
-	out := &models.Page{
-		PageSize: uint32(q.PageSize),
-	}
-	if q.NextPageToken != "" {
-		if next, err := ulid.Parse(q.NextPageToken); err == nil {
-			out.NextPageID = next
-		}
+// DefaultPageSize is the number of results returned if the page size is not specified.
+const DefaultPageSize = 50
+
+// Size returns the requested page size or the default page size if it is not set.
+func (q *PageQuery) Size() int {
+	if q == nil || q.PageSize <= 0 {
+		return DefaultPageSize
 	}
-	return out
+	return q.PageSize
 }
 
 type Page struct {
@@ -4,7 +4,8 @@ import (
 	"database/sql"
 	"time"
 
-	"go.rtnl.ai/quarterdeck/pkg/store/v1/models"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/tidal"
 	"go.rtnl.ai/ulid"
 )
 
@@ -14,6 +15,7 @@ type APIKey struct {
 	ClientID    string     `json:"client_id"`
 	Secret      string     `json:"secret,omitempty"`
 	CreatedBy   ulid.ULID  `json:"created_by,omitempty"`
+	OrgID       ulid.ULID  `json:"org_id,omitempty"`
 	LastSeen    *time.Time `json:"last_seen,omitempty"`
 	Permissions []string   `json:"permissions"`
 	Created     time.Time  `json:"created,omitempty"`
@@ -25,13 +27,35 @@ type APIKeyList struct {
 	APIKeys []*APIKey `json:"apikeys"`
 }
 
+// APIKeyPageQuery filters API keys by creator, a search of their description and client
+// ID, whether they have been revoked, and the time they were last seen. Revoked keys are
+// not listed unless revoked is set. Keys are sorted by the sort field, most recently
+// created first by default.
+type APIKeyPageQuery struct {
+	PageQuery
+	Search         string    `json:"search,omitempty" url:"search,omitempty" form:"search"`
+	CreatedBy      string    `json:"created_by,omitempty" url:"created_by,omitempty" form:"created_by"`
+	Revoked        *bool     `json:"revoked,omitempty" url:"revoked,omitempty" form:"revoked"`
+	LastSeenAfter  time.Time `json:"last_seen_after,omitempty" url:"last_seen_after,omitempty" form:"last_seen_after" time_format:"2006-01-02T15:04:05Z07:00"`
+	LastSeenBefore time.Time `json:"last_seen_before,omitempty" url:"last_seen_before,omitempty" form:"last_seen_before" time_format:"2006-01-02T15:04:05Z07:00"`
+	Sort           string    `json:"sort,omitempty" url:"sort,omitempty" form:"sort"`
+}
+
+// APIKeySortFields are the fields that a list of API keys can be sorted by.
+var APIKeySortFields = []SortField{
+	{Name: "created", Column: "created"},
+	{Name: "modified", Column: "modified"},
+	{Name: "client_id", Column: "client_id"},
+}
+
 func NewAPIKey(model *models.APIKey) (out *APIKey, err error) {
 	out = &APIKey{
 		ID:          model.ID,
 		Description: model.Description.String,
 		ClientID:    model.ClientID,
 		CreatedBy:   model.CreatedBy,
-		Permissions: model.Permissions(),
+		OrgID:       model.OrgID.ULID,
+		Permissions: models.PermissionTitles(model.Permissions),
 		Created:     model.Created,
 		Modified:    model.Modified,
 	}
@@ -43,13 +67,13 @@ func NewAPIKey(model *models.APIKey) (out *APIKey, err error) {
 	return out, nil
 }
 
-func NewAPIKeyList(page *models.APIKeyList) (out *APIKeyList, err error) {
+func NewAPIKeyList(keys []*models.APIKey) (out *APIKeyList, err error) {
 	out = &APIKeyList{
 		Page:    &Page{},
-		APIKeys: make([]*APIKey, 0, len(page.APIKeys)),
+		APIKeys: make([]*APIKey, 0, len(keys)),
 	}
 
-	for _, model := range page.APIKeys {
+	for _, model := range keys {
 		var key *APIKey
 		if key, err = NewAPIKey(model); err != nil {
 			return nil, err
@@ -85,6 +109,10 @@ func (k *APIKey) Validate() (err error) {
 		err = ValidationError(err, ReadOnlyField("created_by"))
 	}
 
+	if !k.OrgID.IsZero() {
+		err = ValidationError(err, ReadOnlyField("org_id"))
+	}
+
 	if !k.Created.IsZero() {
 		err = ValidationError(err, ReadOnlyField("created"))
 	}
@@ -99,7 +127,7 @@ func (k *APIKey) Validate() (err error) {
 
 func (k *APIKey) Model() (model *models.APIKey, err error) {
 	model = &models.APIKey{
-		Model: models.Model{
+		BaseModel: tidal.BaseModel{
 			ID:       k.ID,
 			Created:  k.Created,
 			Modified: k.Modified,
@@ -107,6 +135,7 @@ func (k *APIKey) Model() (model *models.APIKey, err error) {
 		Description: sql.NullString{String: k.Description, Valid: k.Description != ""},
 		ClientID:    k.ClientID,
 		CreatedBy:   k.CreatedBy,
+		OrgID:       ulid.NullULID{ULID: k.OrgID, Valid: !k.OrgID.IsZero()},
 	}
 
 	if k.LastSeen != nil {
@@ -114,8 +143,61 @@ func (k *APIKey) Model() (model *models.APIKey, err error) {
 	}
 
 	if len(k.Permissions) > 0 {
-		model.SetPermissions(k.Permissions)
+		model.Permissions = make([]models.Permission, 0, len(k.Permissions))
+		for _, title := range k.Permissions {
+			model.Permissions = append(model.Permissions, models.Permission{Title: title})
+		}
 	}
 
 	return model, nil
 }
+
+// Cursor validates the query and returns the cursor for the requested page of API keys.
+// If orgID is not zero only the keys in the organization are listed.
+func (q *APIKeyPageQuery) Cursor(orgID ulid.ULID) (cursor *Cursor, err error) {
+	cursor, err = NewCursor(&q.PageQuery, "api_keys", q.Sort, "-created", APIKeySortFields)
+
+	var createdBy ulid.ULID
+	if q.CreatedBy != "" {
+		var perr error
+		if createdBy, perr = ulid.Parse(q.CreatedBy); perr != nil {
+			err = ValidationError(err, IncorrectField("created_by", "must be a valid ulid"))
+		}
+	}
+
+	if !q.LastSeenAfter.IsZero() && !q.LastSeenBefore.IsZero() && !q.LastSeenBefore.After(q.LastSeenAfter) {
+		err = ValidationError(err, IncorrectField("last_seen_before", "must be after last_seen_after"))
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if q.Revoked != nil && *q.Revoked {
+		cursor.Where("revoked IS NOT NULL")
+	} else {
+		cursor.Where("revoked IS NULL")
+	}
+
+	if !orgID.IsZero() {
+		cursor.Where("organization_id = :org_id", sql.Named("org_id", orgID))
+	}
+
+	if !createdBy.IsZero() {
+		cursor.Where("created_by = :created_by", sql.Named("created_by", createdBy))
+	}
+
+	if q.Search != "" {
+		cursor.Where(`LOWER(COALESCE(description, '') || ' ' || client_id) LIKE :search ESCAPE '\'`, sql.Named("search", likePattern(q.Search)))
+	}
+
+	if !q.LastSeenAfter.IsZero() {
+		cursor.Where("last_seen >= :last_seen_after", sql.Named("last_seen_after", q.LastSeenAfter))
+	}
+
+	if !q.LastSeenBefore.IsZero() {
+		cursor.Where("last_seen < :last_seen_before", sql.Named("last_seen_before", q.LastSeenBefore))
+	}
+
+	return cursor, nil
+}
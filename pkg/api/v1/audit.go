@@ -0,0 +1,187 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/tidal"
+	"go.rtnl.ai/ulid"
+)
+
+type AuditEvent struct {
+	ID             ulid.ULID       `json:"id"`
+	ActorType      string          `json:"actor_type,omitempty"`
+	ActorID        *ulid.ULID      `json:"actor_id,omitempty"`
+	OrganizationID *ulid.ULID      `json:"organization_id,omitempty"`
+	Action         string          `json:"action"`
+	SubjectType    string          `json:"subject_type"`
+	SubjectID      string          `json:"subject_id"`
+	ClientIP       string          `json:"client_ip,omitempty"`
+	UserAgent      string          `json:"user_agent,omitempty"`
+	RequestID      string          `json:"request_id,omitempty"`
+	Diff           json.RawMessage `json:"diff,omitempty"`
+	Created        time.Time       `json:"created"`
+}
+
+type AuditEventList struct {
+	Page   *Page         `json:"page"`
+	Events []*AuditEvent `json:"events"`
+}
+
+// AuditEventQuery filters the audit log by actor, subject, action, and time range.
+// Events are returned most recent first; the next page token of the returned page
+// fetches the next (older) page of events.
+type AuditEventQuery struct {
+	PageQuery
+	ActorID     string    `json:"actor_id,omitempty" url:"actor_id,omitempty" form:"actor_id"`
+	SubjectType string    `json:"subject_type,omitempty" url:"subject_type,omitempty" form:"subject_type"`
+	SubjectID   string    `json:"subject_id,omitempty" url:"subject_id,omitempty" form:"subject_id"`
+	Action      string    `json:"action,omitempty" url:"action,omitempty" form:"action"`
+	Since       time.Time `json:"since,omitempty" url:"since,omitempty" form:"since" time_format:"2006-01-02T15:04:05Z07:00"`
+	Until       time.Time `json:"until,omitempty" url:"until,omitempty" form:"until" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+func NewAuditEvent(model *models.AuditEvent) (out *AuditEvent, err error) {
+	out = &AuditEvent{
+		ID:          model.ID,
+		ActorType:   model.ActorType.String,
+		Action:      model.Action,
+		SubjectType: model.SubjectType,
+		SubjectID:   model.SubjectID,
+		ClientIP:    model.ClientIP.String,
+		UserAgent:   model.UserAgent.String,
+		RequestID:   model.RequestID.String,
+		Created:     model.Created,
+	}
+
+	if model.ActorID.Valid {
+		out.ActorID = &model.ActorID.ULID
+	}
+
+	if model.OrganizationID.Valid {
+		out.OrganizationID = &model.OrganizationID.ULID
+	}
+
+	if model.Diff.Valid {
+		out.Diff = json.RawMessage(model.Diff.String)
+	}
+
+	return out, nil
+}
+
+// NewAuditEventList converts a page of events to an API list. The events should be
+// listed with the filter from the query, which fetches one more event than the page
+// size so that the next page token is only set if there are more events to fetch.
+func NewAuditEventList(events []*models.AuditEvent, pageSize int) (out *AuditEventList, err error) {
+	out = &AuditEventList{
+		Page:   &Page{PageSize: pageSize},
+		Events: make([]*AuditEvent, 0, min(len(events), pageSize)),
+	}
+
+	if len(events) > pageSize {
+		events = events[:pageSize]
+		out.Page.NextPageToken = events[pageSize-1].ID.String()
+	}
+
+	for _, model := range events {
+		var event *AuditEvent
+		if event, err = NewAuditEvent(model); err != nil {
+			return nil, err
+		}
+		out.Events = append(out.Events, event)
+	}
+
+	return out, nil
+}
+
+func (q *AuditEventQuery) Validate() (err error) {
+	if q.ActorID != "" {
+		if _, perr := ulid.Parse(q.ActorID); perr != nil {
+			err = ValidationError(err, IncorrectField("actor_id", "must be a valid ulid"))
+		}
+	}
+
+	if q.NextPageToken != "" {
+		if _, perr := ulid.Parse(q.NextPageToken); perr != nil {
+			err = ValidationError(err, IncorrectField("next_page_token", "invalid page token"))
+		}
+	}
+
+	if !q.Since.IsZero() && !q.Until.IsZero() && !q.Until.After(q.Since) {
+		err = ValidationError(err, IncorrectField("until", "must be after since"))
+	}
+
+	return err
+}
+
+// Filter returns the list filter for a page of audit events, most recent first. The
+// filter fetches one more event than the page size to determine if there is a next page.
+// If orgID is not zero only the events recorded in the organization are listed.
+func (q *AuditEventQuery) Filter(orgID ulid.ULID) (filter *tidal.CustomFilter, err error) {
+	var (
+		where = make([]string, 0, 8)
+		args  = make([]sql.NamedArg, 0, 9)
+	)
+
+	if !orgID.IsZero() {
+		where = append(where, "organization_id = :org_id")
+		args = append(args, sql.Named("org_id", orgID))
+	}
+
+	if q.NextPageToken != "" {
+		var next ulid.ULID
+		if next, err = ulid.Parse(q.NextPageToken); err != nil {
+			return nil, err
+		}
+		where = append(where, "id < :next_page_id")
+		args = append(args, sql.Named("next_page_id", next))
+	}
+
+	if q.ActorID != "" {
+		var actorID ulid.ULID
+		if actorID, err = ulid.Parse(q.ActorID); err != nil {
+			return nil, err
+		}
+		where = append(where, "actor_id = :actor_id")
+		args = append(args, sql.Named("actor_id", actorID))
+	}
+
+	if q.SubjectType != "" {
+		where = append(where, "subject_type = :subject_type")
+		args = append(args, sql.Named("subject_type", q.SubjectType))
+	}
+
+	if q.SubjectID != "" {
+		where = append(where, "subject_id = :subject_id")
+		args = append(args, sql.Named("subject_id", q.SubjectID))
+	}
+
+	if q.Action != "" {
+		where = append(where, "action = :action")
+		args = append(args, sql.Named("action", q.Action))
+	}
+
+	if !q.Since.IsZero() {
+		where = append(where, "created >= :since")
+		args = append(args, sql.Named("since", q.Since))
+	}
+
+	if !q.Until.IsZero() {
+		where = append(where, "created < :until")
+		args = append(args, sql.Named("until", q.Until))
+	}
+
+	var sb strings.Builder
+	if len(where) > 0 {
+		sb.WriteString("WHERE ")
+		sb.WriteString(strings.Join(where, " AND "))
+		sb.WriteString(" ")
+	}
+	sb.WriteString("ORDER BY id DESC LIMIT :limit")
+	args = append(args, sql.Named("limit", q.Size()+1))
+
+	return &tidal.CustomFilter{SQL: sb.String(), Args: args}, nil
+}
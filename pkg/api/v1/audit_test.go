@@ -0,0 +1,130 @@
+package api_test
+
+import (
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/quarterdeck/pkg/api/v1"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/tidal"
+	"go.rtnl.ai/ulid"
+)
+
+func TestAuditEventQuery(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		actorID := ulid.MakeSecure()
+		next := ulid.MakeSecure()
+		since := time.Now().Add(-1 * time.Hour)
+
+		q := &api.AuditEventQuery{
+			PageQuery:   api.PageQuery{PageSize: 10, NextPageToken: next.String()},
+			ActorID:     actorID.String(),
+			SubjectType: models.AuditAPIKey,
+			Action:      models.AuditDelete,
+			Since:       since,
+		}
+		require.NoError(t, q.Validate())
+
+		filter, err := q.Filter(ulid.Zero)
+		require.NoError(t, err)
+		require.Equal(t, "WHERE id < :next_page_id AND actor_id = :actor_id AND subject_type = :subject_type AND action = :action AND created >= :since ORDER BY id DESC LIMIT :limit", filter.SQL)
+		require.Equal(t, []sql.NamedArg{
+			sql.Named("next_page_id", next),
+			sql.Named("actor_id", actorID),
+			sql.Named("subject_type", models.AuditAPIKey),
+			sql.Named("action", models.AuditDelete),
+			sql.Named("since", since),
+			sql.Named("limit", 11),
+		}, filter.Args)
+	})
+
+	t.Run("DefaultPageSize", func(t *testing.T) {
+		filter, err := (&api.AuditEventQuery{}).Filter(ulid.Zero)
+		require.NoError(t, err)
+		require.Equal(t, "ORDER BY id DESC LIMIT :limit", filter.SQL)
+		require.Equal(t, []sql.NamedArg{sql.Named("limit", api.DefaultPageSize+1)}, filter.Args)
+	})
+
+	t.Run("Organization", func(t *testing.T) {
+		orgID := ulid.MakeSecure()
+		filter, err := (&api.AuditEventQuery{Action: models.AuditUpdate}).Filter(orgID)
+		require.NoError(t, err)
+		require.Equal(t, "WHERE organization_id = :org_id AND action = :action ORDER BY id DESC LIMIT :limit", filter.SQL)
+		require.Equal(t, []sql.NamedArg{
+			sql.Named("org_id", orgID),
+			sql.Named("action", models.AuditUpdate),
+			sql.Named("limit", api.DefaultPageSize+1),
+		}, filter.Args)
+	})
+
+	t.Run("InvalidActorID", func(t *testing.T) {
+		q := &api.AuditEventQuery{ActorID: "notaulid"}
+		assertSingleValidationError(t, q.Validate(), "invalid field actor_id: must be a valid ulid", nil)
+	})
+
+	t.Run("InvalidPageToken", func(t *testing.T) {
+		q := &api.AuditEventQuery{PageQuery: api.PageQuery{NextPageToken: "notaulid"}}
+		assertSingleValidationError(t, q.Validate(), "invalid field next_page_token: invalid page token", nil)
+	})
+
+	t.Run("InvalidTimeRange", func(t *testing.T) {
+		now := time.Now()
+		q := &api.AuditEventQuery{Since: now, Until: now.Add(-1 * time.Minute)}
+		assertSingleValidationError(t, q.Validate(), "invalid field until: must be after since", nil)
+	})
+}
+
+func TestNewAuditEventList(t *testing.T) {
+	actorID := ulid.MakeSecure()
+	events := []*models.AuditEvent{
+		{
+			BaseModel:   tidal.BaseModel{ID: ulid.MakeSecure(), Created: time.Now()},
+			ActorType:   sql.NullString{String: models.AuditUser, Valid: true},
+			ActorID:     ulid.NullULID{ULID: actorID, Valid: true},
+			Action:      models.AuditUpdate,
+			SubjectType: models.AuditAPIKey,
+			SubjectID:   ulid.MakeSecure().String(),
+			Diff:        sql.NullString{String: `{"description":{"from":"foo","to":"bar"}}`, Valid: true},
+		},
+		{
+			BaseModel:   tidal.BaseModel{ID: ulid.MakeSecure(), Created: time.Now()},
+			Action:      models.AuditLoginFailed,
+			SubjectType: models.AuditUser,
+			SubjectID:   actorID.String(),
+		},
+		{
+			BaseModel:   tidal.BaseModel{ID: ulid.MakeSecure(), Created: time.Now()},
+			Action:      models.AuditLogin,
+			SubjectType: models.AuditUser,
+			SubjectID:   actorID.String(),
+		},
+	}
+
+	// The extra event fetched by the filter sets the next page token but is not returned.
+	out, err := api.NewAuditEventList(events, 2)
+	require.NoError(t, err)
+	require.Equal(t, events[1].ID.String(), out.Page.NextPageToken)
+	require.Equal(t, 2, out.Page.PageSize)
+	require.Len(t, out.Events, 2)
+	require.Equal(t, &actorID, out.Events[0].ActorID)
+	require.Nil(t, out.Events[1].ActorID)
+
+	// The diff is embedded in the JSON rather than encoded as a string.
+	data, err := json.Marshal(out.Events[0])
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"diff":{"description":{"from":"foo","to":"bar"}}`)
+
+	data, err = json.Marshal(out.Events[1])
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "actor_id")
+	require.NotContains(t, string(data), "diff")
+
+	// The last page does not have a next page token.
+	out, err = api.NewAuditEventList(events[:2], 2)
+	require.NoError(t, err)
+	require.Empty(t, out.Page.NextPageToken)
+	require.Len(t, out.Events, 2)
+}
@@ -10,11 +10,14 @@ type LoginRequest struct {
 	Password   string `json:"password"`
 	AutoLogout bool   `json:"auto_logout,omitempty"` // Optional flag to omit refresh token and persistent session
 	Next       string `json:"next,omitempty"`        // Optional redirect URL after login
+	ClientID   string `json:"client_id,omitempty"`   // Optional audience of the ID token, by default the issuer's audience
+	Nonce      string `json:"nonce,omitempty"`       // Optional nonce to include in the ID token
 }
 
 type LoginReply struct {
 	AccessToken  string    `json:"access_token"`
 	RefreshToken string    `json:"refresh_token,omitempty"`
+	IDToken      string    `json:"id_token,omitempty"`
 	LastLogin    time.Time `json:"last_login,omitempty"`
 }
 
@@ -33,6 +36,12 @@ type ResetPasswordRequest struct {
 	Email string `json:"email"`
 }
 
+// ResendVerifyEmailRequest asks for a new email verification link to be sent to the
+// email address if it belongs to a user who has not yet verified it.
+type ResendVerifyEmailRequest struct {
+	Email string `json:"email"`
+}
+
 type ResetPasswordChangeRequest struct {
 	URLVerification
 	Password string `json:"password"`
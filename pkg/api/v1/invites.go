@@ -0,0 +1,84 @@
+package api
+
+import (
+	"strings"
+	"time"
+
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/ulid"
+)
+
+// Invite describes an invitation to join Quarterdeck that was emailed to a user when
+// they were created and has not yet been accepted or revoked.
+type Invite struct {
+	ID         ulid.ULID  `json:"id"`
+	UserID     ulid.ULID  `json:"user_id"`
+	Email      string     `json:"email"`
+	Name       string     `json:"name,omitempty"`
+	Expiration time.Time  `json:"expiration"`
+	Expired    bool       `json:"expired"`
+	SentOn     *time.Time `json:"sent_on,omitempty"`
+	Created    time.Time  `json:"created"`
+}
+
+type InviteList struct {
+	Invites []*Invite `json:"invites"`
+}
+
+// AcceptInviteRequest allows an invited user to set their name and password. The
+// verification token is read from a cookie set by the accept invite page.
+type AcceptInviteRequest struct {
+	URLVerification
+	Name     string `json:"name"`
+	Password string `json:"password"`
+	Confirm  string `json:"confirm"`
+}
+
+// NewInvite converts a team invite token into an API invite; the user is optional and
+// is used to add the name of the invitee.
+func NewInvite(token *models.VeroToken, user *models.User) (out *Invite, err error) {
+	out = &Invite{
+		ID:         token.ID,
+		UserID:     token.ResourceID.ULID,
+		Email:      token.Email,
+		Expiration: token.Expiration,
+		Expired:    token.IsExpired(),
+		Created:    token.Created,
+	}
+
+	if token.SentOn.Valid {
+		out.SentOn = &token.SentOn.Time
+	}
+
+	if user != nil {
+		out.Name = user.Name.String
+	}
+
+	return out, nil
+}
+
+// Validates an accept invite request, returning an error if the token is invalid, the
+// name is missing, or if the passwords are insecure or don't match.
+func (r *AcceptInviteRequest) Validate() (err error) {
+	// Validate the verification token
+	if err = r.URLVerification.Validate(); err != nil {
+		return err
+	}
+
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" {
+		err = ValidationError(err, MissingField("name"))
+	}
+
+	// Confirm the two entered passwords are valid and match
+	password := ProfilePassword{
+		Current:  "ignored",
+		Password: r.Password,
+		Confirm:  r.Confirm,
+	}
+	if verr, ok := password.Validate().(ValidationErrors); ok {
+		err = ValidationError(err, verr...)
+	}
+
+	return err
+}
@@ -0,0 +1,80 @@
+package api_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	. "go.rtnl.ai/quarterdeck/pkg/api/v1"
+	"go.rtnl.ai/quarterdeck/pkg/enum"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/tidal"
+	"go.rtnl.ai/ulid"
+	"go.rtnl.ai/x/vero"
+)
+
+func TestNewInvite(t *testing.T) {
+	userID := ulid.MakeSecure()
+	token := &models.VeroToken{
+		BaseModel:  tidal.BaseModel{ID: ulid.MakeSecure(), Created: time.Now()},
+		TokenType:  enum.TokenTypeTeamInvite,
+		ResourceID: ulid.NullULID{Valid: true, ULID: userID},
+		Email:      "invite@example.com",
+		Expiration: time.Now().Add(-time.Hour),
+	}
+
+	out, err := NewInvite(token, nil)
+	require.NoError(t, err)
+	require.Equal(t, token.ID, out.ID)
+	require.Equal(t, userID, out.UserID)
+	require.True(t, out.Expired)
+	require.Nil(t, out.SentOn)
+	require.Empty(t, out.Name)
+
+	token.SentOn = sql.NullTime{Valid: true, Time: time.Now()}
+	user := &models.User{Name: sql.NullString{Valid: true, String: "Jane Doe"}}
+
+	out, err = NewInvite(token, user)
+	require.NoError(t, err)
+	require.NotNil(t, out.SentOn)
+	require.Equal(t, "Jane Doe", out.Name)
+}
+
+func TestAcceptInviteRequestValidate(t *testing.T) {
+	id := ulid.MakeSecure()
+	verification, err := vero.New(id[:], time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	token, _, err := verification.Sign()
+	require.NoError(t, err)
+
+	t.Run("Valid", func(t *testing.T) {
+		req := &AcceptInviteRequest{
+			URLVerification: URLVerification{Token: token.String()},
+			Name:            " Jane Doe ",
+			Password:        "Supersecretsquirrel42!",
+			Confirm:         "Supersecretsquirrel42!",
+		}
+		require.NoError(t, req.Validate())
+		require.Equal(t, "Jane Doe", req.Name)
+		require.Equal(t, id, req.RecordULID())
+	})
+
+	t.Run("MissingToken", func(t *testing.T) {
+		req := &AcceptInviteRequest{Name: "Jane Doe", Password: "Supersecretsquirrel42!", Confirm: "Supersecretsquirrel42!"}
+		require.EqualError(t, req.Validate(), "missing token: this field is required")
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		req := &AcceptInviteRequest{
+			URLVerification: URLVerification{Token: token.String()},
+			Password:        "Supersecretsquirrel42!",
+			Confirm:         "notthesame",
+		}
+		err := req.Validate()
+		require.ErrorContains(t, err, "2 validation errors occurred")
+		require.ErrorContains(t, err, "missing name: this field is required")
+		require.ErrorContains(t, err, "invalid field confirm: does not match the password")
+	})
+}
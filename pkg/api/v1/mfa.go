@@ -0,0 +1,67 @@
+package api
+
+import (
+	"strings"
+)
+
+// MFAChallenge is returned from the login endpoint instead of the access and refresh
+// tokens when the user has multi-factor authentication enabled. The MFA token must be
+// submitted to the MFA login endpoint along with a TOTP or recovery code within a few
+// minutes to complete the login.
+type MFAChallenge struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+}
+
+// MFALoginRequest completes the second step of a login for users with MFA enabled.
+type MFALoginRequest struct {
+	MFAToken     string `json:"mfa_token"`
+	Code         string `json:"code,omitempty"`
+	RecoveryCode string `json:"recovery_code,omitempty"`
+}
+
+// MFACodeRequest is used to confirm TOTP enrollment, to disable MFA, or to regenerate
+// recovery codes; a recovery code may be used in place of a TOTP code except when
+// confirming enrollment.
+type MFACodeRequest struct {
+	Code         string `json:"code,omitempty"`
+	RecoveryCode string `json:"recovery_code,omitempty"`
+}
+
+// TOTPEnrollment contains the secret that must be added to the user's authenticator
+// app, either by scanning the QR code or by manually entering the secret.
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URL    string `json:"url"`
+	QRCode string `json:"qr_code"`
+}
+
+// RecoveryCodes are returned only once, when they are generated; only the hashes of
+// the codes are stored so they cannot be displayed again.
+type RecoveryCodes struct {
+	Codes []string `json:"recovery_codes"`
+}
+
+func (r *MFALoginRequest) Validate() (err error) {
+	r.MFAToken = strings.TrimSpace(r.MFAToken)
+	if r.MFAToken == "" {
+		err = ValidationError(err, MissingField("mfa_token"))
+	}
+
+	return validateMFACode(err, r.Code, r.RecoveryCode)
+}
+
+func (r *MFACodeRequest) Validate() (err error) {
+	return validateMFACode(nil, r.Code, r.RecoveryCode)
+}
+
+func validateMFACode(err error, code, recoveryCode string) error {
+	code, recoveryCode = strings.TrimSpace(code), strings.TrimSpace(recoveryCode)
+	switch {
+	case code == "" && recoveryCode == "":
+		err = ValidationError(err, OneOfMissing("code", "recovery_code"))
+	case code != "" && recoveryCode != "":
+		err = ValidationError(err, OneOfTooMany("code", "recovery_code"))
+	}
+	return err
+}
@@ -0,0 +1,44 @@
+package api_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	. "go.rtnl.ai/quarterdeck/pkg/api/v1"
+)
+
+func TestValidateMFALoginRequest(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		tests := []*MFALoginRequest{
+			{MFAToken: "token", Code: "123456"},
+			{MFAToken: "token", RecoveryCode: "abcde-fghij"},
+		}
+
+		for i, req := range tests {
+			require.NoError(t, req.Validate(), "test case %d failed", i)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		tests := []struct {
+			req *MFALoginRequest
+			err string
+		}{
+			{&MFALoginRequest{Code: "123456"}, "missing mfa_token: this field is required"},
+			{&MFALoginRequest{MFAToken: "  "}, "missing mfa_token"},
+			{&MFALoginRequest{MFAToken: "token"}, "missing one of"},
+			{&MFALoginRequest{MFAToken: "token", Code: "123456", RecoveryCode: "abcde-fghij"}, "specify only one of"},
+		}
+
+		for i, tc := range tests {
+			require.ErrorContains(t, tc.req.Validate(), tc.err, "test case %d failed", i)
+		}
+	})
+}
+
+func TestValidateMFACodeRequest(t *testing.T) {
+	require.NoError(t, (&MFACodeRequest{Code: "123456"}).Validate())
+	require.NoError(t, (&MFACodeRequest{RecoveryCode: "abcde-fghij"}).Validate())
+	require.ErrorContains(t, (&MFACodeRequest{Code: " "}).Validate(), "missing one of")
+	require.ErrorContains(t, (&MFACodeRequest{Code: "123456", RecoveryCode: "abcde-fghij"}).Validate(), "specify only one of")
+}
@@ -0,0 +1,283 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// OAuth2 response and grant types supported by Quarterdeck.
+const (
+	ResponseTypeCode           = "code"
+	GrantTypeAuthorizationCode = "authorization_code"
+	GrantTypeClientCredentials = "client_credentials"
+	GrantTypeRefreshToken      = "refresh_token"
+	TokenTypeBearer            = "Bearer"
+	ScopeOpenID                = "openid"
+)
+
+// OAuth2 error codes as defined by RFC 6749 Sections 4.1.2.1 and 5.2.
+const (
+	OAuthInvalidRequest          = "invalid_request"
+	OAuthInvalidClient           = "invalid_client"
+	OAuthInvalidGrant            = "invalid_grant"
+	OAuthInvalidScope            = "invalid_scope"
+	OAuthUnauthorizedClient      = "unauthorized_client"
+	OAuthUnsupportedGrantType    = "unsupported_grant_type"
+	OAuthUnsupportedResponseType = "unsupported_response_type"
+	OAuthAccessDenied            = "access_denied"
+	OAuthServerError             = "server_error"
+)
+
+//===========================================================================
+// Authorization Endpoint
+//===========================================================================
+
+// AuthorizeRequest is the query of an OAuth2 authorization code request made by a
+// relying party redirecting the user's browser to Quarterdeck.
+// See: https://datatracker.ietf.org/doc/html/rfc6749#section-4.1.1
+type AuthorizeRequest struct {
+	ResponseType        string `json:"response_type" url:"response_type" form:"response_type"`
+	ClientID            string `json:"client_id" url:"client_id" form:"client_id"`
+	RedirectURI         string `json:"redirect_uri" url:"redirect_uri" form:"redirect_uri"`
+	Scope               string `json:"scope,omitempty" url:"scope,omitempty" form:"scope"`
+	State               string `json:"state,omitempty" url:"state,omitempty" form:"state"`
+	Nonce               string `json:"nonce,omitempty" url:"nonce,omitempty" form:"nonce"`
+	CodeChallenge       string `json:"code_challenge,omitempty" url:"code_challenge,omitempty" form:"code_challenge"`
+	CodeChallengeMethod string `json:"code_challenge_method,omitempty" url:"code_challenge_method,omitempty" form:"code_challenge_method"`
+}
+
+// Validate the authorization request after the client and redirect URI have been
+// verified. The returned error is suitable for redirecting back to the client.
+func (r *AuthorizeRequest) Validate() *OAuthError {
+	if r.ResponseType != ResponseTypeCode {
+		return &OAuthError{Code: OAuthUnsupportedResponseType, Description: "only the authorization code flow is supported"}
+	}
+
+	if r.CodeChallengeMethod != "" && r.CodeChallenge == "" {
+		return &OAuthError{Code: OAuthInvalidRequest, Description: "code_challenge is required when code_challenge_method is specified"}
+	}
+
+	if r.CodeChallenge != "" {
+		if r.CodeChallengeMethod == "" {
+			// RFC 7636 Section 4.3: defaults to plain if not present in the request.
+			r.CodeChallengeMethod = "plain"
+		}
+
+		if r.CodeChallengeMethod != "S256" && r.CodeChallengeMethod != "plain" {
+			return &OAuthError{Code: OAuthInvalidRequest, Description: "transform algorithm not supported"}
+		}
+	}
+
+	return nil
+}
+
+// Scopes returns the requested scopes as a list.
+func (r *AuthorizeRequest) Scopes() []string {
+	return strings.Fields(r.Scope)
+}
+
+// HasScope returns true if the specified scope was requested.
+func (r *AuthorizeRequest) HasScope(scope string) bool {
+	return slices.Contains(r.Scopes(), scope)
+}
+
+// Redirect returns the location to redirect the user agent to with the specified
+// query parameters added to the registered redirect URI.
+func (r *AuthorizeRequest) Redirect(params url.Values) (string, error) {
+	uri, err := url.Parse(r.RedirectURI)
+	if err != nil {
+		return "", err
+	}
+
+	if r.State != "" {
+		params.Set("state", r.State)
+	}
+
+	query := uri.Query()
+	for key, vals := range params {
+		for _, val := range vals {
+			query.Add(key, val)
+		}
+	}
+
+	uri.RawQuery = query.Encode()
+	return uri.String(), nil
+}
+
+//===========================================================================
+// Token Endpoint
+//===========================================================================
+
+// TokenRequest is the form-encoded body of a request to the OAuth2 token endpoint.
+// Client credentials may either be specified in the body (client_secret_post) or by
+// using HTTP Basic authentication (client_secret_basic).
+// See: https://datatracker.ietf.org/doc/html/rfc6749#section-4.1.3
+type TokenRequest struct {
+	GrantType    string `json:"grant_type" form:"grant_type"`
+	Code         string `json:"code,omitempty" form:"code"`
+	RedirectURI  string `json:"redirect_uri,omitempty" form:"redirect_uri"`
+	ClientID     string `json:"client_id,omitempty" form:"client_id"`
+	ClientSecret string `json:"client_secret,omitempty" form:"client_secret"`
+	CodeVerifier string `json:"code_verifier,omitempty" form:"code_verifier"`
+	Scope        string `json:"scope,omitempty" form:"scope"`
+}
+
+// Validate the token request for the authorization code and client credentials grants.
+func (r *TokenRequest) Validate() *OAuthError {
+	r.GrantType = strings.TrimSpace(r.GrantType)
+	switch r.GrantType {
+	case "":
+		return &OAuthError{Code: OAuthInvalidRequest, Description: "missing grant_type"}
+	case GrantTypeAuthorizationCode:
+		if r.Code == "" {
+			return &OAuthError{Code: OAuthInvalidRequest, Description: "missing code"}
+		}
+
+		if r.RedirectURI == "" {
+			return &OAuthError{Code: OAuthInvalidRequest, Description: "missing redirect_uri"}
+		}
+	case GrantTypeClientCredentials:
+		// Only confidential clients can use the client credentials grant.
+		if r.ClientSecret == "" {
+			return &OAuthError{Code: OAuthInvalidClient, Description: "missing client_secret"}
+		}
+	default:
+		return &OAuthError{Code: OAuthUnsupportedGrantType, Description: "grant type is not supported"}
+	}
+
+	if r.ClientID == "" {
+		return &OAuthError{Code: OAuthInvalidClient, Description: "missing client_id"}
+	}
+
+	return nil
+}
+
+// Scopes returns the requested scopes as a list.
+func (r *TokenRequest) Scopes() []string {
+	return strings.Fields(r.Scope)
+}
+
+// TokenReply is the successful response from the token endpoint.
+// See: https://datatracker.ietf.org/doc/html/rfc6749#section-5.1
+type TokenReply struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+//===========================================================================
+// OAuth2 Errors
+//===========================================================================
+
+// OAuthError is an error response as defined by RFC 6749; these errors are returned
+// from the token endpoint as JSON or appended to the redirect URI from the
+// authorization endpoint.
+type OAuthError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+func (e *OAuthError) Error() string {
+	if e.Description != "" {
+		return e.Code + ": " + e.Description
+	}
+	return e.Code
+}
+
+// StatusCode returns the HTTP status code to respond with for the error; client
+// authentication failures are 401 and all other errors are 400.
+func (e *OAuthError) StatusCode() int {
+	if e.Code == OAuthInvalidClient {
+		return http.StatusUnauthorized
+	}
+	return http.StatusBadRequest
+}
+
+// Params returns the error as query parameters to append to a redirect URI.
+func (e *OAuthError) Params() url.Values {
+	params := url.Values{}
+	params.Set("error", e.Code)
+	if e.Description != "" {
+		params.Set("error_description", e.Description)
+	}
+	return params
+}
+
+//===========================================================================
+// Introspection and Revocation Endpoints
+//===========================================================================
+
+// IntrospectionRequest is the form-encoded body of a request to the token
+// introspection endpoint. The caller must authenticate with the credentials of an OIDC
+// client or an API key, either in the body or using HTTP Basic authentication.
+// See: https://datatracker.ietf.org/doc/html/rfc7662#section-2.1
+type IntrospectionRequest struct {
+	Token         string `json:"token" form:"token"`
+	TokenTypeHint string `json:"token_type_hint,omitempty" form:"token_type_hint"`
+	ClientID      string `json:"client_id,omitempty" form:"client_id"`
+	ClientSecret  string `json:"client_secret,omitempty" form:"client_secret"`
+}
+
+// IntrospectionReply describes the state of the token; if the token is not active then
+// only the active field is returned.
+// See: https://datatracker.ietf.org/doc/html/rfc7662#section-2.2
+type IntrospectionReply struct {
+	Active    bool     `json:"active"`
+	Scope     string   `json:"scope,omitempty"`
+	ClientID  string   `json:"client_id,omitempty"`
+	Username  string   `json:"username,omitempty"`
+	TokenType string   `json:"token_type,omitempty"`
+	ExpiresAt int64    `json:"exp,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+	NotBefore int64    `json:"nbf,omitempty"`
+	Subject   string   `json:"sub,omitempty"`
+	Audience  []string `json:"aud,omitempty"`
+	Issuer    string   `json:"iss,omitempty"`
+	JWTID     string   `json:"jti,omitempty"`
+}
+
+// RevocationRequest is the form-encoded body of a request to the token revocation
+// endpoint. The caller must authenticate in the same manner as for introspection to
+// revoke tokens issued to a client; first-party tokens issued by the login endpoint are
+// not issued to a client so they can be revoked without client credentials.
+// See: https://datatracker.ietf.org/doc/html/rfc7009#section-2.1
+type RevocationRequest struct {
+	Token         string `json:"token" form:"token"`
+	TokenTypeHint string `json:"token_type_hint,omitempty" form:"token_type_hint"`
+	ClientID      string `json:"client_id,omitempty" form:"client_id"`
+	ClientSecret  string `json:"client_secret,omitempty" form:"client_secret"`
+}
+
+// Validate the introspection request.
+func (r *IntrospectionRequest) Validate() *OAuthError {
+	return validateTokenClient(r.Token, r.ClientID, r.ClientSecret)
+}
+
+// Validate the revocation request; client credentials are optional but if a client ID
+// is sent then it must be authenticated.
+func (r *RevocationRequest) Validate() *OAuthError {
+	if r.ClientID == "" && r.ClientSecret == "" {
+		if strings.TrimSpace(r.Token) == "" {
+			return &OAuthError{Code: OAuthInvalidRequest, Description: "missing token"}
+		}
+		return nil
+	}
+	return validateTokenClient(r.Token, r.ClientID, r.ClientSecret)
+}
+
+func validateTokenClient(token, clientID, clientSecret string) *OAuthError {
+	if clientID == "" || clientSecret == "" {
+		return &OAuthError{Code: OAuthInvalidClient, Description: "client authentication is required"}
+	}
+
+	if strings.TrimSpace(token) == "" {
+		return &OAuthError{Code: OAuthInvalidRequest, Description: "missing token"}
+	}
+
+	return nil
+}
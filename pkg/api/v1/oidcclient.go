@@ -7,7 +7,9 @@ import (
 	"net/url"
 	"time"
 
-	"go.rtnl.ai/quarterdeck/pkg/store/v1/models"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/tidal"
+	"go.rtnl.ai/tidal/fields"
 	"go.rtnl.ai/ulid"
 )
 
@@ -23,6 +25,7 @@ type OIDCClient struct {
 	ClientID     string    `json:"client_id,omitempty"`
 	Secret       string    `json:"secret,omitempty"`
 	CreatedBy    ulid.ULID `json:"created_by,omitempty"`
+	OrgID        ulid.ULID `json:"org_id,omitempty"`
 	Created      time.Time `json:"created,omitempty"`
 	Modified     time.Time `json:"modified,omitempty"`
 }
@@ -32,6 +35,23 @@ type OIDCClientList struct {
 	OIDCClients []*OIDCClient `json:"oidc_clients"`
 }
 
+// OIDCClientPageQuery filters OIDC clients by creator and a search of their client name
+// and client ID. Clients are sorted by the sort field, most recently created first by
+// default.
+type OIDCClientPageQuery struct {
+	PageQuery
+	Search    string `json:"search,omitempty" url:"search,omitempty" form:"search"`
+	CreatedBy string `json:"created_by,omitempty" url:"created_by,omitempty" form:"created_by"`
+	Sort      string `json:"sort,omitempty" url:"sort,omitempty" form:"sort"`
+}
+
+// OIDCClientSortFields are the fields that a list of OIDC clients can be sorted by.
+var OIDCClientSortFields = []SortField{
+	{Name: "created", Column: "created"},
+	{Name: "modified", Column: "modified"},
+	{Name: "client_name", Column: "client_name"},
+}
+
 // NewOIDCClient converts a store model to an API DTO. Secret is never set on
 // the returned client.
 func NewOIDCClient(model *models.OIDCClient) (out *OIDCClient, err error) {
@@ -41,6 +61,7 @@ func NewOIDCClient(model *models.OIDCClient) (out *OIDCClient, err error) {
 		RedirectURIs: model.RedirectURIs,
 		ClientID:     model.ClientID,
 		CreatedBy:    model.CreatedBy,
+		OrgID:        model.OrgID.ULID,
 		Created:      model.Created,
 		Modified:     model.Modified,
 	}
@@ -64,8 +85,8 @@ func NewOIDCClient(model *models.OIDCClient) (out *OIDCClient, err error) {
 	if len(model.Contacts) > 0 {
 		out.Contacts = make([]string, 0, len(model.Contacts))
 		for _, c := range model.Contacts {
-			if c.Valid {
-				out.Contacts = append(out.Contacts, c.String)
+			if c != "" {
+				out.Contacts = append(out.Contacts, c)
 			}
 		}
 	}
@@ -73,20 +94,14 @@ func NewOIDCClient(model *models.OIDCClient) (out *OIDCClient, err error) {
 	return out, nil
 }
 
-// NewOIDCClientList converts a store model list to an API list.
-func NewOIDCClientList(list *models.OIDCClientList) (out *OIDCClientList, err error) {
+// NewOIDCClientList converts store models to an API list.
+func NewOIDCClientList(clients []*models.OIDCClient) (out *OIDCClientList, err error) {
 	out = &OIDCClientList{
 		Page:        &Page{},
-		OIDCClients: make([]*OIDCClient, 0, len(list.OIDCClients)),
-	}
-
-	if list.Page != nil {
-		out.Page.PrevPageToken = list.Page.PrevPageID.String()
-		out.Page.NextPageToken = list.Page.NextPageID.String()
-		out.Page.PageSize = int(list.Page.PageSize)
+		OIDCClients: make([]*OIDCClient, 0, len(clients)),
 	}
 
-	for _, model := range list.OIDCClients {
+	for _, model := range clients {
 		var client *OIDCClient
 		if client, err = NewOIDCClient(model); err != nil {
 			return nil, err
@@ -118,6 +133,10 @@ func (o *OIDCClient) Validate(create bool) (err error) {
 		err = ValidationError(err, ReadOnlyField("created_by"))
 	}
 
+	if !o.OrgID.IsZero() {
+		err = ValidationError(err, ReadOnlyField("org_id"))
+	}
+
 	if !o.Created.IsZero() {
 		err = ValidationError(err, ReadOnlyField("created"))
 	}
@@ -196,12 +215,13 @@ func validateURI(field, raw string) error {
 // Model converts the API DTO to a store model for create/update. ID must be set by caller when updating.
 func (o *OIDCClient) Model() (model *models.OIDCClient, err error) {
 	model = &models.OIDCClient{
-		Model:        models.Model{ID: o.ID, Created: o.Created, Modified: o.Modified},
+		BaseModel:    tidal.BaseModel{ID: o.ID, Created: o.Created, Modified: o.Modified},
 		ClientName:   o.ClientName,
 		RedirectURIs: o.RedirectURIs,
 		ClientID:     o.ClientID,
 		Secret:       o.Secret,
 		CreatedBy:    o.CreatedBy,
+		OrgID:        ulid.NullULID{ULID: o.OrgID, Valid: !o.OrgID.IsZero()},
 	}
 
 	if o.ClientURI != nil && *o.ClientURI != "" {
@@ -217,11 +237,45 @@ func (o *OIDCClient) Model() (model *models.OIDCClient, err error) {
 		model.TOSURI = sql.NullString{String: *o.TOSURI, Valid: true}
 	}
 	if len(o.Contacts) > 0 {
-		model.Contacts = make([]sql.NullString, len(o.Contacts))
-		for i, c := range o.Contacts {
-			model.Contacts[i] = sql.NullString{String: c, Valid: c != ""}
+		model.Contacts = make(fields.StringArray, 0, len(o.Contacts))
+		for _, c := range o.Contacts {
+			if c != "" {
+				model.Contacts = append(model.Contacts, c)
+			}
 		}
 	}
 
 	return model, nil
 }
+
+// Cursor validates the query and returns the cursor for the requested page of clients.
+// If orgID is not zero only the clients in the organization are listed.
+func (q *OIDCClientPageQuery) Cursor(orgID ulid.ULID) (cursor *Cursor, err error) {
+	cursor, err = NewCursor(&q.PageQuery, "oidc_clients", q.Sort, "-created", OIDCClientSortFields)
+
+	var createdBy ulid.ULID
+	if q.CreatedBy != "" {
+		var perr error
+		if createdBy, perr = ulid.Parse(q.CreatedBy); perr != nil {
+			err = ValidationError(err, IncorrectField("created_by", "must be a valid ulid"))
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !orgID.IsZero() {
+		cursor.Where("organization_id = :org_id", sql.Named("org_id", orgID))
+	}
+
+	if !createdBy.IsZero() {
+		cursor.Where("created_by = :created_by", sql.Named("created_by", createdBy))
+	}
+
+	if q.Search != "" {
+		cursor.Where(`LOWER(client_name || ' ' || client_id) LIKE :search ESCAPE '\'`, sql.Named("search", likePattern(q.Search)))
+	}
+
+	return cursor, nil
+}
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"database/sql"
+	"net/mail"
+	"strings"
+	"time"
+
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/tidal"
+	"go.rtnl.ai/ulid"
+)
+
+type Organization struct {
+	ID            ulid.ULID `json:"id,omitempty"`
+	Name          string    `json:"name"`
+	StreetAddress string    `json:"street_address,omitempty"`
+	HomepageURI   string    `json:"homepage_uri,omitempty"`
+	SupportEmail  string    `json:"support_email,omitempty"`
+	Created       time.Time `json:"created,omitempty"`
+	Modified      time.Time `json:"modified,omitempty"`
+}
+
+type OrganizationList struct {
+	Page          *Page           `json:"page"`
+	Organizations []*Organization `json:"organizations"`
+}
+
+// OrganizationPageQuery sorts organizations by the sort field, most recently created
+// first by default.
+type OrganizationPageQuery struct {
+	PageQuery
+	Sort string `json:"sort,omitempty" url:"sort,omitempty" form:"sort"`
+}
+
+// OrganizationSortFields are the fields that a list of organizations can be sorted by.
+var OrganizationSortFields = []SortField{
+	{Name: "created", Column: "created"},
+	{Name: "modified", Column: "modified"},
+	{Name: "name", Column: "name"},
+}
+
+// OrganizationMemberList contains the members of an organization; the roles and
+// permissions of each member are only the ones they have in the organization.
+type OrganizationMemberList struct {
+	Page    *Page   `json:"page"`
+	Members []*User `json:"members"`
+}
+
+func NewOrganization(model *models.Organization) (out *Organization, err error) {
+	out = &Organization{
+		ID:            model.ID,
+		Name:          model.Name,
+		StreetAddress: model.StreetAddress.String,
+		HomepageURI:   model.HomepageURI.String,
+		SupportEmail:  model.SupportEmail.String,
+		Created:       model.Created,
+		Modified:      model.Modified,
+	}
+	return out, nil
+}
+
+func NewOrganizationList(orgs []*models.Organization) (out *OrganizationList, err error) {
+	out = &OrganizationList{
+		Page:          &Page{},
+		Organizations: make([]*Organization, 0, len(orgs)),
+	}
+
+	for _, model := range orgs {
+		var org *Organization
+		if org, err = NewOrganization(model); err != nil {
+			return nil, err
+		}
+		out.Organizations = append(out.Organizations, org)
+	}
+
+	return out, nil
+}
+
+func NewOrganizationMemberList(members []*models.User) (out *OrganizationMemberList, err error) {
+	out = &OrganizationMemberList{
+		Page:    &Page{},
+		Members: make([]*User, 0, len(members)),
+	}
+
+	for _, model := range members {
+		var member *User
+		if member, err = NewUser(model); err != nil {
+			return nil, err
+		}
+		out.Members = append(out.Members, member)
+	}
+
+	return out, nil
+}
+
+func (o *Organization) Validate() (err error) {
+	if !o.ID.IsZero() {
+		err = ValidationError(err, ReadOnlyField("id"))
+	}
+
+	o.Name = strings.TrimSpace(o.Name)
+	if o.Name == "" {
+		err = ValidationError(err, MissingField("name"))
+	}
+
+	if o.HomepageURI != "" {
+		if perr := validateURI("homepage_uri", o.HomepageURI); perr != nil {
+			err = ValidationError(err, IncorrectField("homepage_uri", perr.Error()))
+		}
+	}
+
+	if o.SupportEmail != "" {
+		if _, perr := mail.ParseAddress(o.SupportEmail); perr != nil {
+			err = ValidationError(err, IncorrectField("support_email", perr.Error()))
+		}
+	}
+
+	if !o.Created.IsZero() {
+		err = ValidationError(err, ReadOnlyField("created"))
+	}
+
+	if !o.Modified.IsZero() {
+		err = ValidationError(err, ReadOnlyField("modified"))
+	}
+
+	return err
+}
+
+func (o *Organization) Model() (model *models.Organization, err error) {
+	model = &models.Organization{
+		BaseModel: tidal.BaseModel{
+			ID:       o.ID,
+			Created:  o.Created,
+			Modified: o.Modified,
+		},
+		Name:          o.Name,
+		StreetAddress: sql.NullString{String: o.StreetAddress, Valid: o.StreetAddress != ""},
+		HomepageURI:   sql.NullString{String: o.HomepageURI, Valid: o.HomepageURI != ""},
+		SupportEmail:  sql.NullString{String: o.SupportEmail, Valid: o.SupportEmail != ""},
+	}
+	return model, nil
+}
+
+// Cursor validates the query and returns the cursor for the requested page of
+// organizations. If orgID is not zero only that organization is listed.
+func (q *OrganizationPageQuery) Cursor(orgID ulid.ULID) (cursor *Cursor, err error) {
+	if cursor, err = NewCursor(&q.PageQuery, "organizations", q.Sort, "-created", OrganizationSortFields); err != nil {
+		return nil, err
+	}
+
+	if !orgID.IsZero() {
+		cursor.Where("id = :org_id", sql.Named("org_id", orgID))
+	}
+
+	return cursor, nil
+}
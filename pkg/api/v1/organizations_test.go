@@ -0,0 +1,83 @@
+package api_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/quarterdeck/pkg/api/v1"
+	"go.rtnl.ai/ulid"
+)
+
+func TestOrganizationValidate(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		org := &api.Organization{
+			Name:         " Acme Corporation ",
+			HomepageURI:  "https://acme.example.com",
+			SupportEmail: "support@acme.example.com",
+		}
+		require.NoError(t, org.Validate())
+		require.Equal(t, "Acme Corporation", org.Name, "expected name to be trimmed")
+	})
+
+	t.Run("IDNotZero", func(t *testing.T) {
+		org := &api.Organization{ID: ulid.Make(), Name: "Acme Corporation"}
+		assertSingleValidationError(t, org.Validate(), "read-only field id: this field cannot be written by the user", nil)
+	})
+
+	t.Run("MissingName", func(t *testing.T) {
+		org := &api.Organization{Name: "  "}
+		assertSingleValidationError(t, org.Validate(), "missing name: this field is required", nil)
+	})
+
+	t.Run("InvalidHomepage", func(t *testing.T) {
+		org := &api.Organization{Name: "Acme Corporation", HomepageURI: "acme.example.com"}
+		assertSingleValidationError(t, org.Validate(), "", []string{"invalid field homepage_uri"})
+	})
+
+	t.Run("InvalidSupportEmail", func(t *testing.T) {
+		org := &api.Organization{Name: "Acme Corporation", SupportEmail: "support"}
+		assertSingleValidationError(t, org.Validate(), "", []string{"invalid field support_email"})
+	})
+
+	t.Run("CreatedSet", func(t *testing.T) {
+		org := &api.Organization{Name: "Acme Corporation", Created: time.Now()}
+		assertSingleValidationError(t, org.Validate(), "read-only field created: this field cannot be written by the user", nil)
+	})
+}
+
+func TestOrganizationModel(t *testing.T) {
+	org := &api.Organization{Name: "Globex", SupportEmail: "help@globex.example.com"}
+	model, err := org.Model()
+	require.NoError(t, err)
+	require.Equal(t, "Globex", model.Name)
+	require.False(t, model.StreetAddress.Valid)
+	require.False(t, model.HomepageURI.Valid)
+	require.True(t, model.SupportEmail.Valid)
+
+	out, err := api.NewOrganization(model)
+	require.NoError(t, err)
+	require.Equal(t, org, out)
+}
+
+func TestOrganizationPageQuery(t *testing.T) {
+	t.Run("Default", func(t *testing.T) {
+		cursor, err := (&api.OrganizationPageQuery{}).Cursor(ulid.Zero)
+		require.NoError(t, err)
+		require.Equal(t, "ORDER BY created DESC, id DESC LIMIT :limit", cursor.Filter().SQL)
+	})
+
+	t.Run("Organization", func(t *testing.T) {
+		orgID := ulid.MakeSecure()
+		cursor, err := (&api.OrganizationPageQuery{Sort: "name"}).Cursor(orgID)
+		require.NoError(t, err)
+
+		filter := cursor.Filter()
+		require.Equal(t, "WHERE id = :org_id ORDER BY name ASC, id ASC LIMIT :limit", filter.SQL)
+		require.Equal(t, []sql.NamedArg{
+			sql.Named("org_id", orgID),
+			sql.Named("limit", api.DefaultPageSize+1),
+		}, filter.Args)
+	})
+}
@@ -0,0 +1,232 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"slices"
+	"strings"
+
+	"go.rtnl.ai/tidal"
+	"go.rtnl.ai/ulid"
+)
+
+//===========================================================================
+// Sorting
+//===========================================================================
+
+// SortField is a column that a list can be sorted by. Only columns that cannot be null
+// are sortable so that every row has a position in the keyset used for pagination.
+type SortField struct {
+	Name   string // name of the field in the sort query parameter
+	Column string // column in the database that is sorted
+}
+
+// Sort is the order of a list; rows with the same sort value are ordered by ID.
+type Sort struct {
+	Field *SortField
+	Desc  bool
+}
+
+// ParseSort parses a sort query parameter such as "email" or "-created" (descending)
+// into one of the sortable fields of the list. The default is used if in is empty.
+func ParseSort(in, defaultSort string, fields []SortField) (*Sort, bool) {
+	if in = strings.TrimSpace(in); in == "" {
+		in = defaultSort
+	}
+
+	sort := &Sort{}
+	if strings.HasPrefix(in, "-") {
+		sort.Desc = true
+		in = in[1:]
+	}
+
+	for i := range fields {
+		if fields[i].Name == in {
+			sort.Field = &fields[i]
+			return sort, true
+		}
+	}
+	return nil, false
+}
+
+// String returns the sort as it is specified in the sort query parameter.
+func (s *Sort) String() string {
+	if s.Desc {
+		return "-" + s.Field.Name
+	}
+	return s.Field.Name
+}
+
+//===========================================================================
+// Page Tokens
+//===========================================================================
+
+// PageToken is the row in a sorted list that a next or previous page starts after.
+// Tokens are encoded as opaque strings that are only valid with the same sort.
+type PageToken struct {
+	Sort string    `json:"s"`
+	ID   ulid.ULID `json:"i"`
+	Prev bool      `json:"p,omitempty"`
+}
+
+// Encode the page token as an opaque URL-safe string.
+func (t *PageToken) Encode() string {
+	data, _ := json.Marshal(t)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// ParsePageToken decodes a page token returned by Encode.
+func ParsePageToken(token string) (_ *PageToken, err error) {
+	var data []byte
+	if data, err = base64.RawURLEncoding.DecodeString(token); err != nil {
+		return nil, err
+	}
+
+	out := &PageToken{}
+	if err = json.Unmarshal(data, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+//===========================================================================
+// Cursor Pagination
+//===========================================================================
+
+// Cursor is a validated page query for a sorted list. It builds the list filter for the
+// requested page and sets the page tokens from the rows that are returned.
+type Cursor struct {
+	Sort  *Sort
+	Token *PageToken
+	Size  int
+	table string
+	where []string
+	args  []sql.NamedArg
+}
+
+// NewCursor parses the sort and the page token from the query for a list of the rows
+// in table. The next and previous page tokens cannot both be set and the token must
+// have been issued for the same sort.
+func NewCursor(q *PageQuery, table, sort, defaultSort string, fields []SortField) (c *Cursor, err error) {
+	c = &Cursor{Size: q.Size(), table: table}
+
+	var ok bool
+	if c.Sort, ok = ParseSort(sort, defaultSort, fields); !ok {
+		names := make([]string, 0, len(fields))
+		for _, field := range fields {
+			names = append(names, field.Name)
+		}
+		err = ValidationError(err, IncorrectField("sort", "must be one of "+strings.Join(names, ", ")+" optionally prefixed by -"))
+	}
+
+	if q.PageSize < 0 {
+		err = ValidationError(err, IncorrectField("page_size", "must be a positive integer"))
+	}
+
+	switch {
+	case q.NextPageToken != "" && q.PrevPageToken != "":
+		err = ValidationError(err, IncorrectField("prev_page_token", "cannot be specified with next_page_token"))
+	case q.NextPageToken != "":
+		c.Token, err = c.parseToken("next_page_token", q.NextPageToken, false, err)
+	case q.PrevPageToken != "":
+		c.Token, err = c.parseToken("prev_page_token", q.PrevPageToken, true, err)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Cursor) parseToken(field, token string, prev bool, verr error) (*PageToken, error) {
+	out, err := ParsePageToken(token)
+	if err != nil || out.Prev != prev || out.ID.IsZero() || (c.Sort != nil && out.Sort != c.Sort.String()) {
+		return nil, ValidationError(verr, IncorrectField(field, "invalid page token"))
+	}
+	return out, verr
+}
+
+// Where adds a condition to the filter that every listed row must match.
+func (c *Cursor) Where(clause string, args ...sql.NamedArg) {
+	c.where = append(c.where, clause)
+	c.args = append(c.args, args...)
+}
+
+// Filter returns the list filter for the page. Rows are fetched after the row of the
+// page token (or before it, in reverse order, for a previous page token) and one more
+// row than the page size is fetched to determine if there is another page. The sort
+// value of the token row is selected by the database rather than stored in the token
+// so that values are always compared in the format the database stores them in; if
+// the token row has been deleted no rows are returned.
+func (c *Cursor) Filter() *tidal.CustomFilter {
+	where := slices.Clone(c.where)
+	args := slices.Clone(c.args)
+
+	// Previous pages are fetched in the reverse of the sort order starting from the
+	// first row on the current page and are reversed again by Paginate.
+	desc := c.Sort.Desc
+	if c.Token != nil && c.Token.Prev {
+		desc = !desc
+	}
+
+	op, order := ">", "ASC"
+	if desc {
+		op, order = "<", "DESC"
+	}
+
+	if c.Token != nil {
+		where = append(where, "("+c.Sort.Field.Column+", id) "+op+" (SELECT "+c.Sort.Field.Column+", id FROM "+c.table+" WHERE id = :cursor_id)")
+		args = append(args, sql.Named("cursor_id", c.Token.ID))
+	}
+
+	var sb strings.Builder
+	if len(where) > 0 {
+		sb.WriteString("WHERE ")
+		sb.WriteString(strings.Join(where, " AND "))
+		sb.WriteString(" ")
+	}
+
+	sb.WriteString("ORDER BY " + c.Sort.Field.Column + " " + order + ", id " + order + " LIMIT :limit")
+	args = append(args, sql.Named("limit", c.Size+1))
+	return &tidal.CustomFilter{SQL: sb.String(), Args: args}
+}
+
+// Paginate trims the rows fetched with the filter to the page size, restores the sort
+// order of a previous page, and returns the page with the tokens to fetch the pages
+// before and after it. The id function returns the ID of a row.
+func Paginate[M any](c *Cursor, rows []M, id func(M) ulid.ULID) ([]M, *Page) {
+	prev := c.Token != nil && c.Token.Prev
+	more := len(rows) > c.Size
+	if more {
+		rows = rows[:c.Size]
+	}
+
+	if prev {
+		slices.Reverse(rows)
+	}
+
+	page := &Page{PageSize: c.Size}
+	if len(rows) == 0 {
+		return rows, page
+	}
+
+	// There are rows after this page if more were fetched or if this page was fetched
+	// from a previous page token; and vice versa for the rows before this page.
+	if more || prev {
+		page.NextPageToken = (&PageToken{Sort: c.Sort.String(), ID: id(rows[len(rows)-1])}).Encode()
+	}
+
+	if (prev && more) || (!prev && c.Token != nil) {
+		page.PrevPageToken = (&PageToken{Sort: c.Sort.String(), ID: id(rows[0]), Prev: true}).Encode()
+	}
+
+	return rows, page
+}
+
+// Returns the search term as a case-insensitive LIKE pattern that matches substrings.
+func likePattern(search string) string {
+	search = strings.ToLower(strings.TrimSpace(search))
+	search = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(search)
+	return "%" + search + "%"
+}
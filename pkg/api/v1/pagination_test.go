@@ -0,0 +1,128 @@
+package api_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/quarterdeck/pkg/api/v1"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/tidal"
+	"go.rtnl.ai/ulid"
+)
+
+func TestPageToken(t *testing.T) {
+	token := &api.PageToken{Sort: "-created", ID: ulid.MakeSecure(), Prev: true}
+	out, err := api.ParsePageToken(token.Encode())
+	require.NoError(t, err)
+	require.Equal(t, token, out)
+
+	_, err = api.ParsePageToken("not a token")
+	require.Error(t, err)
+}
+
+func TestNewCursor(t *testing.T) {
+	fields := api.UserSortFields
+	next := &api.PageToken{Sort: "email", ID: ulid.MakeSecure()}
+	prev := &api.PageToken{Sort: "email", ID: ulid.MakeSecure(), Prev: true}
+
+	t.Run("Default", func(t *testing.T) {
+		cursor, err := api.NewCursor(&api.PageQuery{}, "users", "", "-created", fields)
+		require.NoError(t, err)
+		require.Equal(t, "-created", cursor.Sort.String())
+		require.Equal(t, api.DefaultPageSize, cursor.Size)
+		require.Nil(t, cursor.Token)
+
+		filter := cursor.Filter()
+		require.Equal(t, "ORDER BY created DESC, id DESC LIMIT :limit", filter.SQL)
+		require.Equal(t, []sql.NamedArg{sql.Named("limit", api.DefaultPageSize+1)}, filter.Args)
+	})
+
+	t.Run("Next", func(t *testing.T) {
+		cursor, err := api.NewCursor(&api.PageQuery{PageSize: 2, NextPageToken: next.Encode()}, "users", "email", "-created", fields)
+		require.NoError(t, err)
+		cursor.Where("status <> 'deleted'")
+
+		filter := cursor.Filter()
+		require.Equal(t, "WHERE status <> 'deleted' AND (email, id) > (SELECT email, id FROM users WHERE id = :cursor_id) ORDER BY email ASC, id ASC LIMIT :limit", filter.SQL)
+		require.Equal(t, []sql.NamedArg{
+			sql.Named("cursor_id", next.ID),
+			sql.Named("limit", 3),
+		}, filter.Args)
+	})
+
+	t.Run("Prev", func(t *testing.T) {
+		cursor, err := api.NewCursor(&api.PageQuery{PageSize: 2, PrevPageToken: prev.Encode()}, "users", "email", "-created", fields)
+		require.NoError(t, err)
+		require.Equal(t, "WHERE (email, id) < (SELECT email, id FROM users WHERE id = :cursor_id) ORDER BY email DESC, id DESC LIMIT :limit", cursor.Filter().SQL)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		tests := []struct {
+			query *api.PageQuery
+			sort  string
+			err   string
+		}{
+			{&api.PageQuery{}, "password", "invalid field sort: must be one of created, modified, email optionally prefixed by -"},
+			{&api.PageQuery{PageSize: -1}, "", "invalid field page_size: must be a positive integer"},
+			{&api.PageQuery{NextPageToken: "notatoken"}, "email", "invalid field next_page_token: invalid page token"},
+			{&api.PageQuery{NextPageToken: next.Encode()}, "-email", "invalid field next_page_token: invalid page token"},
+			{&api.PageQuery{NextPageToken: prev.Encode()}, "email", "invalid field next_page_token: invalid page token"},
+			{&api.PageQuery{PrevPageToken: next.Encode()}, "email", "invalid field prev_page_token: invalid page token"},
+			{&api.PageQuery{NextPageToken: next.Encode()}, "created", "invalid field next_page_token: invalid page token"},
+			{&api.PageQuery{NextPageToken: next.Encode(), PrevPageToken: prev.Encode()}, "email", "invalid field prev_page_token: cannot be specified with next_page_token"},
+		}
+
+		for i, tc := range tests {
+			_, err := api.NewCursor(tc.query, "users", tc.sort, "-created", fields)
+			require.EqualError(t, err, tc.err, "test case %d failed", i)
+		}
+	})
+}
+
+func TestPaginate(t *testing.T) {
+	users := make([]*models.User, 5)
+	for i := range users {
+		users[i] = &models.User{BaseModel: tidal.BaseModel{ID: ulid.MakeSecure()}, Email: string(rune('a'+i)) + "@example.com"}
+	}
+
+	cursor, err := api.NewCursor(&api.PageQuery{PageSize: 2}, "users", "email", "", api.UserSortFields)
+	require.NoError(t, err)
+
+	// First page: three rows are fetched so there is a next page but no previous page.
+	rows, page := api.Paginate(cursor, users[:3], userID)
+	require.Equal(t, users[:2], rows)
+	require.Equal(t, 2, page.PageSize)
+	require.Empty(t, page.PrevPageToken)
+	require.NotEmpty(t, page.NextPageToken)
+
+	token, err := api.ParsePageToken(page.NextPageToken)
+	require.NoError(t, err)
+	require.Equal(t, &api.PageToken{Sort: "email", ID: users[1].ID}, token)
+
+	// Last page: fewer rows than the page size so there is no next page.
+	cursor, err = api.NewCursor(&api.PageQuery{PageSize: 2, NextPageToken: page.NextPageToken}, "users", "email", "", api.UserSortFields)
+	require.NoError(t, err)
+
+	rows, page = api.Paginate(cursor, users[4:], userID)
+	require.Equal(t, users[4:], rows)
+	require.Empty(t, page.NextPageToken)
+	require.NotEmpty(t, page.PrevPageToken)
+
+	// Previous page: the rows are fetched in reverse order and are restored.
+	cursor, err = api.NewCursor(&api.PageQuery{PageSize: 2, PrevPageToken: page.PrevPageToken}, "users", "email", "", api.UserSortFields)
+	require.NoError(t, err)
+
+	rows, page = api.Paginate(cursor, []*models.User{users[3], users[2], users[1]}, userID)
+	require.Equal(t, users[2:4], rows)
+	require.NotEmpty(t, page.NextPageToken)
+	require.NotEmpty(t, page.PrevPageToken)
+
+	token, err = api.ParsePageToken(page.PrevPageToken)
+	require.NoError(t, err)
+	require.Equal(t, &api.PageToken{Sort: "email", ID: users[2].ID, Prev: true}, token)
+}
+
+func userID(u *models.User) ulid.ULID {
+	return u.ID
+}
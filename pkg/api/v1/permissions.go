@@ -1,17 +1,269 @@
 package api
 
-import "go.rtnl.ai/quarterdeck/pkg/store/v1/models"
+import (
+	"strings"
+	"time"
+
+	"go.rtnl.ai/quarterdeck/pkg/auth/permissions"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+)
 
 type Role struct {
-	ID    int    `json:"id,omitempty"`
-	Title string `json:"title,omitempty"`
+	ID          int       `json:"id,omitempty"`
+	Title       string    `json:"title,omitempty"`
+	Description string    `json:"description,omitempty"`
+	IsDefault   bool      `json:"is_default,omitempty"`
+	Permissions []string  `json:"permissions,omitempty"`
+	Created     time.Time `json:"created,omitempty"`
+	Modified    time.Time `json:"modified,omitempty"`
+}
+
+type RoleList struct {
+	Page  *Page   `json:"page"`
+	Roles []*Role `json:"roles"`
+}
+
+type Permission struct {
+	ID          int       `json:"id,omitempty"`
+	Title       string    `json:"title"`
+	Description string    `json:"description,omitempty"`
+	Namespace   string    `json:"namespace,omitempty"`
+	Protected   bool      `json:"protected,omitempty"`
+	Created     time.Time `json:"created,omitempty"`
+	Modified    time.Time `json:"modified,omitempty"`
+}
+
+type PermissionList struct {
+	Page        *Page         `json:"page"`
+	Permissions []*Permission `json:"permissions"`
+}
+
+// RolePermission is used to add a permission to a role by its title.
+type RolePermission struct {
+	Permission string `json:"permission"`
+}
+
+// UserRoles replaces all of the roles assigned to a user with the roles identified by
+// their titles. An empty list removes all roles from the user.
+type UserRoles struct {
+	Roles []string `json:"roles"`
+}
+
+//===========================================================================
+// Roles
+//===========================================================================
+
+func NewRole(model *models.Role) (out *Role, err error) {
+	out = &Role{
+		ID:          int(model.ID),
+		Title:       model.Title,
+		Description: model.Description,
+		IsDefault:   model.IsDefault,
+		Created:     model.Created,
+		Modified:    model.Modified,
+	}
+
+	// Permissions are only included if they were loaded with the role.
+	if model.Permissions != nil {
+		out.Permissions = models.PermissionTitles(model.Permissions)
+	}
+
+	return out, nil
+}
+
+func NewRoleList(roles []*models.Role) (out *RoleList, err error) {
+	out = &RoleList{
+		Page:  &Page{},
+		Roles: make([]*Role, 0, len(roles)),
+	}
+
+	for _, model := range roles {
+		var role *Role
+		if role, err = NewRole(model); err != nil {
+			return nil, err
+		}
+		out.Roles = append(out.Roles, role)
+	}
+
+	return out, nil
+}
+
+func (r *Role) Validate() (err error) {
+	if r.ID != 0 {
+		err = ValidationError(err, ReadOnlyField("id"))
+	}
+
+	r.Title = strings.TrimSpace(r.Title)
+	if r.Title == "" {
+		err = ValidationError(err, MissingField("title"))
+	} else if strings.ContainsAny(r.Title, " \t\n") {
+		err = ValidationError(err, IncorrectField("title", "must not contain whitespace"))
+	}
+
+	for _, permission := range r.Permissions {
+		if strings.TrimSpace(permission) == "" {
+			err = ValidationError(err, IncorrectField("permissions", "must not contain empty permissions"))
+			break
+		}
+	}
+
+	if !r.Created.IsZero() {
+		err = ValidationError(err, ReadOnlyField("created"))
+	}
+
+	if !r.Modified.IsZero() {
+		err = ValidationError(err, ReadOnlyField("modified"))
+	}
+
+	return err
 }
 
 func (r *Role) Model() (model *models.Role) {
 	model = &models.Role{
-		ID:    int64(r.ID),
-		Title: r.Title,
+		ID:          int64(r.ID),
+		Title:       r.Title,
+		Description: r.Description,
+		IsDefault:   r.IsDefault,
+		Created:     r.Created,
+		Modified:    r.Modified,
+	}
+
+	if len(r.Permissions) > 0 {
+		model.Permissions = make([]models.Permission, 0, len(r.Permissions))
+		for _, title := range r.Permissions {
+			model.Permissions = append(model.Permissions, models.Permission{Title: title})
+		}
 	}
 
 	return model
 }
+
+// HasPermission returns true if the permission with the specified title is one of the
+// permissions of the role (only if the permissions were loaded with the role).
+func (r *Role) HasPermission(title string) bool {
+	for _, permission := range r.Permissions {
+		if permission == title {
+			return true
+		}
+	}
+	return false
+}
+
+//===========================================================================
+// Permissions
+//===========================================================================
+
+func NewPermission(model *models.Permission) (out *Permission, err error) {
+	out = &Permission{
+		ID:          int(model.ID),
+		Title:       model.Title,
+		Description: model.Description,
+		Namespace:   model.Namespace,
+		Protected:   model.Protected,
+		Created:     model.Created,
+		Modified:    model.Modified,
+	}
+	return out, nil
+}
+
+func NewPermissionList(list []*models.Permission) (out *PermissionList, err error) {
+	out = &PermissionList{
+		Page:        &Page{},
+		Permissions: make([]*Permission, 0, len(list)),
+	}
+
+	for _, model := range list {
+		var permission *Permission
+		if permission, err = NewPermission(model); err != nil {
+			return nil, err
+		}
+		out.Permissions = append(out.Permissions, permission)
+	}
+
+	return out, nil
+}
+
+func (p *Permission) Validate() (err error) {
+	if p.ID != 0 {
+		err = ValidationError(err, ReadOnlyField("id"))
+	}
+
+	var title permissions.Permission
+	if p.Title = strings.TrimSpace(p.Title); p.Title == "" {
+		err = ValidationError(err, MissingField("title"))
+	} else if parsed, perr := permissions.Parse(p.Title); perr != nil {
+		err = ValidationError(err, IncorrectField("title", "must be a lowercase resource:action pair"))
+	} else {
+		title = parsed
+		p.Title = title.String()
+	}
+
+	// Custom permissions must be namespaced by the application or OIDC client that
+	// owns them and their title must be prefixed by the namespace so that they cannot
+	// be mistaken for the permissions of another application or for the built-in
+	// permissions; the quarterdeck namespace is reserved for the built-in permissions.
+	if p.Namespace = strings.TrimSpace(p.Namespace); p.Namespace == "" {
+		err = ValidationError(err, MissingField("namespace"))
+	} else if nerr := permissions.ValidateNamespace(p.Namespace); nerr != nil {
+		err = ValidationError(err, IncorrectField("namespace", "must be an application name or oidc client id and cannot be quarterdeck"))
+	} else if title != permissions.Unknown && !title.InNamespace(p.Namespace) {
+		err = ValidationError(err, IncorrectField("title", "must be prefixed by the namespace, e.g. "+strings.ToLower(p.Namespace)+":action"))
+	}
+
+	if p.Protected {
+		err = ValidationError(err, ReadOnlyField("protected"))
+	}
+
+	if !p.Created.IsZero() {
+		err = ValidationError(err, ReadOnlyField("created"))
+	}
+
+	if !p.Modified.IsZero() {
+		err = ValidationError(err, ReadOnlyField("modified"))
+	}
+
+	return err
+}
+
+func (p *Permission) Model() (model *models.Permission) {
+	return &models.Permission{
+		ID:          int64(p.ID),
+		Title:       p.Title,
+		Description: p.Description,
+		Namespace:   p.Namespace,
+		Created:     p.Created,
+		Modified:    p.Modified,
+	}
+}
+
+//===========================================================================
+// Role Assignments
+//===========================================================================
+
+func (r *RolePermission) Validate() (err error) {
+	r.Permission = strings.TrimSpace(r.Permission)
+	if r.Permission == "" {
+		err = ValidationError(err, MissingField("permission"))
+	}
+	return err
+}
+
+func (u *UserRoles) Validate() (err error) {
+	seen := make(map[string]struct{}, len(u.Roles))
+	for i, role := range u.Roles {
+		role = strings.TrimSpace(role)
+		if role == "" {
+			err = ValidationError(err, IncorrectField("roles", "must not contain empty role titles"))
+			break
+		}
+
+		if _, ok := seen[role]; ok {
+			err = ValidationError(err, IncorrectField("roles", "must not contain duplicate roles"))
+			break
+		}
+
+		seen[role] = struct{}{}
+		u.Roles[i] = role
+	}
+	return err
+}
@@ -0,0 +1,64 @@
+package api_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/quarterdeck/pkg/api/v1"
+)
+
+func TestPermissionValidate(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		p := &api.Permission{Title: " Reports:Export ", Namespace: "reports"}
+		require.NoError(t, p.Validate())
+		require.Equal(t, "reports:export", p.Title, "expected title to be normalized")
+	})
+
+	t.Run("IDNotZero", func(t *testing.T) {
+		p := &api.Permission{ID: 4, Title: "reports:export", Namespace: "reports"}
+		assertSingleValidationError(t, p.Validate(), "read-only field id: this field cannot be written by the user", nil)
+	})
+
+	t.Run("MissingTitle", func(t *testing.T) {
+		p := &api.Permission{Namespace: "reports"}
+		assertSingleValidationError(t, p.Validate(), "missing title: this field is required", nil)
+	})
+
+	t.Run("InvalidTitle", func(t *testing.T) {
+		for _, title := range []string{"reports", "reports export", ":export", "reports:", "reports:export!"} {
+			p := &api.Permission{Title: title, Namespace: "reports"}
+			assertSingleValidationError(t, p.Validate(), "", []string{"invalid field title"})
+		}
+	})
+
+	t.Run("MissingNamespace", func(t *testing.T) {
+		p := &api.Permission{Title: "reports:export"}
+		assertSingleValidationError(t, p.Validate(), "missing namespace: this field is required", nil)
+	})
+
+	t.Run("ReservedNamespace", func(t *testing.T) {
+		p := &api.Permission{Title: "reports:export", Namespace: "Quarterdeck"}
+		assertSingleValidationError(t, p.Validate(), "", []string{"invalid field namespace"})
+	})
+
+	t.Run("NamespacePrefix", func(t *testing.T) {
+		p := &api.Permission{Title: "billing:export", Namespace: "reports"}
+		assertSingleValidationError(t, p.Validate(), "invalid field title: must be prefixed by the namespace, e.g. reports:action", nil)
+	})
+
+	t.Run("BuiltinTitle", func(t *testing.T) {
+		p := &api.Permission{Title: "users:view", Namespace: "users"}
+		assertSingleValidationError(t, p.Validate(), "", []string{"invalid field title"})
+	})
+
+	t.Run("ProtectedSet", func(t *testing.T) {
+		p := &api.Permission{Title: "reports:export", Namespace: "reports", Protected: true}
+		assertSingleValidationError(t, p.Validate(), "read-only field protected: this field cannot be written by the user", nil)
+	})
+
+	t.Run("CreatedSet", func(t *testing.T) {
+		p := &api.Permission{Title: "reports:export", Namespace: "reports", Created: time.Now()}
+		assertSingleValidationError(t, p.Validate(), "read-only field created: this field cannot be written by the user", nil)
+	})
+}
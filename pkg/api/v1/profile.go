@@ -1,6 +1,8 @@
 package api
 
 import (
+	"net/mail"
+
 	"go.rtnl.ai/quarterdeck/pkg/auth/passwords"
 )
 
@@ -39,3 +41,21 @@ func (p *ProfilePassword) Validate() (err error) {
 
 	return err
 }
+
+// Model for a change email request; the email is the new email address of the user
+// which is only applied once the user confirms it from the emailed link.
+type ProfileEmail struct {
+	Email string `json:"email,omitempty"`
+}
+
+// Validate ensures that the new email address is a valid email address. Note that
+// this method does not check if the email address is already in use.
+func (p *ProfileEmail) Validate() (err error) {
+	if p.Email == "" {
+		err = ValidationError(err, MissingField("email"))
+	} else if _, perr := mail.ParseAddress(p.Email); perr != nil {
+		err = ValidationError(err, IncorrectField("email", perr.Error()))
+	}
+
+	return err
+}
@@ -33,3 +33,22 @@ func TestProfilePasswordValidate(t *testing.T) {
 		}
 	})
 }
+
+func TestProfileEmailValidate(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		in := &api.ProfileEmail{Email: "jannel@example.com"}
+		require.NoError(t, in.Validate())
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		tests := []*api.ProfileEmail{
+			{Email: ""},
+			{Email: "notanemail"},
+			{Email: "jannel@"},
+		}
+
+		for i, tc := range tests {
+			require.Error(t, tc.Validate(), "test case %d failed", i)
+		}
+	})
+}
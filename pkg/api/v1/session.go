@@ -0,0 +1,60 @@
+package api
+
+import (
+	"time"
+
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/ulid"
+)
+
+// Session describes a login of a user that has not been revoked or expired.
+type Session struct {
+	ID          ulid.ULID  `json:"id"`
+	UserID      ulid.ULID  `json:"user_id"`
+	UserAgent   string     `json:"user_agent,omitempty"`
+	ClientIP    string     `json:"client_ip,omitempty"`
+	LastRefresh *time.Time `json:"last_refresh,omitempty"`
+	Expiration  time.Time  `json:"expiration"`
+	Current     bool       `json:"current"`
+	Created     time.Time  `json:"created"`
+}
+
+type SessionList struct {
+	Sessions []*Session `json:"sessions"`
+}
+
+// NewSession converts a session model into an API session; current is the jti of the
+// requester's access token so that the requester's own session can be identified.
+func NewSession(model *models.Session, current string) (out *Session, err error) {
+	out = &Session{
+		ID:         model.ID,
+		UserID:     model.UserID,
+		UserAgent:  model.UserAgent.String,
+		ClientIP:   model.ClientIP.String,
+		Expiration: model.Expiration,
+		Current:    current != "" && model.JTI.String() == current,
+		Created:    model.Created,
+	}
+
+	if model.LastRefresh.Valid {
+		out.LastRefresh = &model.LastRefresh.Time
+	}
+
+	return out, nil
+}
+
+func NewSessionList(sessions []*models.Session, current string) (out *SessionList, err error) {
+	out = &SessionList{
+		Sessions: make([]*Session, 0, len(sessions)),
+	}
+
+	for _, model := range sessions {
+		var session *Session
+		if session, err = NewSession(model, current); err != nil {
+			return nil, err
+		}
+		out.Sessions = append(out.Sessions, session)
+	}
+
+	return out, nil
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"time"
+
+	"go.rtnl.ai/quarterdeck/pkg/enum"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/ulid"
+)
+
+// SigningKey describes a key used to sign the tokens issued by Quarterdeck; the ID is
+// the kid of the key in the JWKS. Key material is never returned by the API.
+type SigningKey struct {
+	ID          ulid.ULID     `json:"id"`
+	State       enum.KeyState `json:"state"`
+	Published   bool          `json:"published"`
+	Activates   time.Time     `json:"activates"`
+	Deactivated *time.Time    `json:"deactivated,omitempty"`
+	Retires     *time.Time    `json:"retires,omitempty"`
+	Created     time.Time     `json:"created"`
+	Modified    time.Time     `json:"modified"`
+}
+
+type SigningKeyList struct {
+	SigningKeys []*SigningKey `json:"signing_keys"`
+}
+
+// RotateSigningKey requests a new signing key; if immediate is true the new key signs
+// tokens right away instead of after the JWKS publish window.
+type RotateSigningKey struct {
+	Immediate bool `json:"immediate"`
+}
+
+func NewSigningKey(model *models.SigningKey) (out *SigningKey, err error) {
+	out = &SigningKey{
+		ID:        model.ID,
+		State:     model.State,
+		Published: model.IsPublished(),
+		Activates: model.Activates,
+		Created:   model.Created,
+		Modified:  model.Modified,
+	}
+
+	if model.Deactivated.Valid {
+		out.Deactivated = &model.Deactivated.Time
+	}
+
+	if model.Retires.Valid {
+		out.Retires = &model.Retires.Time
+	}
+
+	return out, nil
+}
+
+func NewSigningKeyList(keys []*models.SigningKey) (out *SigningKeyList, err error) {
+	out = &SigningKeyList{
+		SigningKeys: make([]*SigningKey, 0, len(keys)),
+	}
+
+	for _, model := range keys {
+		var key *SigningKey
+		if key, err = NewSigningKey(model); err != nil {
+			return nil, err
+		}
+		out.SigningKeys = append(out.SigningKeys, key)
+	}
+
+	return out, nil
+}
@@ -2,30 +2,36 @@ package api
 
 import (
 	"database/sql"
-	"errors"
 	"net/mail"
 	"time"
 
-	qde "go.rtnl.ai/quarterdeck/pkg/errors"
-	"go.rtnl.ai/quarterdeck/pkg/store/v1/models"
+	"go.rtnl.ai/quarterdeck/pkg/enum"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/tidal"
 	"go.rtnl.ai/ulid"
 )
 
 type User struct {
-	ID          ulid.ULID `json:"id,omitempty"`
-	Name        string    `json:"name,omitempty"`
-	Email       string    `json:"email"`
-	Avatar      string    `json:"avatar,omitempty"`
-	LastLogin   time.Time `json:"last_login,omitempty"`
-	Roles       []*Role   `json:"roles"`
-	Permissions []string  `json:"permissions"`
-	Created     time.Time `json:"created,omitempty"`
-	Modified    time.Time `json:"modified,omitempty"`
+	ID          ulid.ULID       `json:"id,omitempty"`
+	Name        string          `json:"name,omitempty"`
+	Email       string          `json:"email"`
+	Avatar      string          `json:"avatar,omitempty"`
+	Status      enum.UserStatus `json:"status,omitempty"`
+	LastLogin   time.Time       `json:"last_login,omitempty"`
+	Roles       []*Role         `json:"roles"`
+	Permissions []string        `json:"permissions"`
+	Deleted     time.Time       `json:"deleted,omitempty"`
+	Created     time.Time       `json:"created,omitempty"`
+	Modified    time.Time       `json:"modified,omitempty"`
+}
+
+type UserStatusRequest struct {
+	Status enum.UserStatus `json:"status"`
 }
 
 type UserList struct {
-	Page  *UserPage
-	Users []*User
+	Page  *UserPage `json:"page"`
+	Users []*User   `json:"users"`
 }
 
 type UserPage struct {
@@ -33,9 +39,25 @@ type UserPage struct {
 	Role string `json:"role,omitempty"`
 }
 
+// UserPageQuery filters users by role, status, a search of their name and email, and
+// the time they last logged in. Deleted users are only listed when filtered by the
+// deleted status. Users are sorted by the sort field, most recently created first by
+// default.
 type UserPageQuery struct {
 	PageQuery
-	Role string `json:"role,omitempty"`
+	Role           string    `json:"role,omitempty" url:"role,omitempty" form:"role"`
+	Status         string    `json:"status,omitempty" url:"status,omitempty" form:"status"`
+	Search         string    `json:"search,omitempty" url:"search,omitempty" form:"search"`
+	LastSeenAfter  time.Time `json:"last_seen_after,omitempty" url:"last_seen_after,omitempty" form:"last_seen_after" time_format:"2006-01-02T15:04:05Z07:00"`
+	LastSeenBefore time.Time `json:"last_seen_before,omitempty" url:"last_seen_before,omitempty" form:"last_seen_before" time_format:"2006-01-02T15:04:05Z07:00"`
+	Sort           string    `json:"sort,omitempty" url:"sort,omitempty" form:"sort"`
+}
+
+// UserSortFields are the fields that a list of users can be sorted by.
+var UserSortFields = []SortField{
+	{Name: "created", Column: "created"},
+	{Name: "modified", Column: "modified"},
+	{Name: "email", Column: "email"},
 }
 
 func NewUser(model *models.User) (out *User, err error) {
@@ -43,20 +65,14 @@ func NewUser(model *models.User) (out *User, err error) {
 		ID:          model.ID,
 		Email:       model.Email,
 		Avatar:      model.Gravatar(),
-		Permissions: model.Permissions(),
+		Status:      model.Status,
+		Permissions: models.PermissionTitles(model.Permissions),
 		Created:     model.Created,
 		Modified:    model.Modified,
 	}
 
-	var roles []*models.Role
-	if roles, err = model.Roles(); err != nil {
-		if !errors.Is(err, qde.ErrMissingAssociation) {
-			return nil, err
-		}
-		roles = []*models.Role{}
-	}
-	out.Roles = make([]*Role, 0, len(roles))
-	for _, role := range roles {
+	out.Roles = make([]*Role, 0, len(model.Roles))
+	for _, role := range model.Roles {
 		out.Roles = append(out.Roles, &Role{
 			ID:    int(role.ID),
 			Title: role.Title,
@@ -71,19 +87,20 @@ func NewUser(model *models.User) (out *User, err error) {
 		out.LastLogin = model.LastLogin.Time
 	}
 
+	if model.Deleted.Valid {
+		out.Deleted = model.Deleted.Time
+	}
+
 	return out, nil
 }
 
-func NewUserList(list *models.UserList) (out *UserList, err error) {
+// NewUserList converts the users to an API list; the page is set by the caller.
+func NewUserList(users []*models.User) (out *UserList, err error) {
 	out = &UserList{
-		Users: make([]*User, 0, len(list.Users)),
-	}
-
-	if out.Page, err = NewUserPage(list.Page); err != nil {
-		return nil, err
+		Users: make([]*User, 0, len(users)),
 	}
 
-	for _, modelUser := range list.Users {
+	for _, modelUser := range users {
 		var user *User
 		if user, err = NewUser(modelUser); err != nil {
 			return nil, err
@@ -94,19 +111,6 @@ func NewUserList(list *models.UserList) (out *UserList, err error) {
 	return out, nil
 }
 
-func NewUserPage(page *models.UserPage) (out *UserPage, err error) {
-	out = &UserPage{
-		Page: Page{
-			NextPageToken: page.NextPageID.String(),
-			PrevPageToken: page.PrevPageID.String(),
-			PageSize:      int(page.PageSize),
-		},
-		Role: page.Role,
-	}
-
-	return out, nil
-}
-
 func (u *User) Validate() (err error) {
 	if !u.ID.IsZero() {
 		err = ValidationError(err, ReadOnlyField("id"))
@@ -120,6 +124,10 @@ func (u *User) Validate() (err error) {
 		}
 	}
 
+	if u.Status != enum.UserStatusUnknown {
+		err = ValidationError(err, ReadOnlyField("status"))
+	}
+
 	if !u.LastLogin.IsZero() {
 		err = ValidationError(err, ReadOnlyField("last_login"))
 	}
@@ -130,6 +138,10 @@ func (u *User) Validate() (err error) {
 		err = ValidationError(err, ReadOnlyField("permissions"))
 	}
 
+	if !u.Deleted.IsZero() {
+		err = ValidationError(err, ReadOnlyField("deleted"))
+	}
+
 	if !u.Created.IsZero() {
 		err = ValidationError(err, ReadOnlyField("created"))
 	}
@@ -143,51 +155,92 @@ func (u *User) Validate() (err error) {
 
 func (u *User) Model() (model *models.User, err error) {
 	model = &models.User{
-		Model: models.Model{ID: u.ID},
-		Name:  sql.NullString{Valid: u.Name != "", String: u.Name},
-		Email: u.Email,
+		BaseModel: tidal.BaseModel{ID: u.ID},
+		Name:      sql.NullString{Valid: u.Name != "", String: u.Name},
+		Email:     u.Email,
 	}
 
-	modelRoles := make([]*models.Role, 0, len(u.Roles))
+	model.Roles = make([]models.Role, 0, len(u.Roles))
 	for _, role := range u.Roles {
-		modelRoles = append(modelRoles, role.Model())
+		model.Roles = append(model.Roles, *role.Model())
 	}
-	model.SetRoles(modelRoles)
 
 	return model, nil
 }
 
-func (p *UserPage) Model() (model *models.UserPage, err error) {
-	var next, prev ulid.ULID
+// Filter returns the list filter for all of the users with the role of the page (or
+// all users if the role is not set), most recently created first. Deleted users are
+// excluded. The filter is not paginated; use UserPageQuery to list a page of users.
+func (p *UserPage) Filter() tidal.ListFilter {
+	filter := &tidal.CustomFilter{SQL: "WHERE status <> 'deleted' ORDER BY created DESC"}
+	if p.Role != "" {
+		filter.SQL = "WHERE status <> 'deleted' AND id IN (SELECT ur.user_id FROM user_roles ur JOIN roles r ON ur.role_id = r.id WHERE LOWER(r.title) = LOWER(:role)) ORDER BY created DESC"
+		filter.Args = []sql.NamedArg{sql.Named("role", p.Role)}
+	}
+	return filter
+}
 
-	if next, err = ulid.Parse(p.NextPageToken); err != nil {
-		return nil, err
+// Cursor validates the query and returns the cursor for the requested page of users.
+// If orgID is not zero only the members of the organization are listed.
+func (q *UserPageQuery) Cursor(orgID ulid.ULID) (cursor *Cursor, err error) {
+	cursor, err = NewCursor(&q.PageQuery, "users", q.Sort, "-created", UserSortFields)
+
+	status, perr := enum.ParseUserStatus(q.Status)
+	if perr != nil {
+		err = ValidationError(err, IncorrectField("status", "must be one of active, suspended, deactivated, or deleted"))
+	}
+
+	if !q.LastSeenAfter.IsZero() && !q.LastSeenBefore.IsZero() && !q.LastSeenBefore.After(q.LastSeenAfter) {
+		err = ValidationError(err, IncorrectField("last_seen_before", "must be after last_seen_after"))
 	}
 
-	if prev, err = ulid.Parse(p.PrevPageToken); err != nil {
+	if err != nil {
 		return nil, err
 	}
 
-	model = &models.UserPage{
-		Page: models.Page{
-			NextPageID: next,
-			PrevPageID: prev,
-			PageSize:   uint32(p.PageSize),
-		},
-		Role: p.Role,
+	if status == enum.UserStatusUnknown {
+		cursor.Where("status <> 'deleted'")
+	} else {
+		cursor.Where("status = :status", sql.Named("status", status.String()))
 	}
 
-	return model, nil
-}
+	if !orgID.IsZero() {
+		cursor.Where("id IN (SELECT user_id FROM organization_members WHERE organization_id = :org_id)", sql.Named("org_id", orgID))
+	}
 
-func (p *UserPageQuery) UserPage() (page *UserPage) {
-	page = &UserPage{
-		Page: Page{
-			NextPageToken: p.NextPageToken,
-			PageSize:      p.PageSize,
-		},
-		Role: p.Role,
+	if q.Role != "" {
+		cursor.Where("id IN (SELECT ur.user_id FROM user_roles ur JOIN roles r ON ur.role_id = r.id WHERE LOWER(r.title) = LOWER(:role))", sql.Named("role", q.Role))
 	}
 
-	return page
+	if q.Search != "" {
+		cursor.Where(`LOWER(COALESCE(name, '') || ' ' || email) LIKE :search ESCAPE '\'`, sql.Named("search", likePattern(q.Search)))
+	}
+
+	if !q.LastSeenAfter.IsZero() {
+		cursor.Where("last_login >= :last_seen_after", sql.Named("last_seen_after", q.LastSeenAfter))
+	}
+
+	if !q.LastSeenBefore.IsZero() {
+		cursor.Where("last_login < :last_seen_before", sql.Named("last_seen_before", q.LastSeenBefore))
+	}
+
+	return cursor, nil
+}
+
+// UserPage returns the page of the listed users with the role filter of the query.
+func (q *UserPageQuery) UserPage(page *Page) *UserPage {
+	return &UserPage{Page: *page, Role: q.Role}
+}
+
+// Validate ensures that the status can be set by an administrator; users are deleted
+// with the delete user endpoint so that they can be restored within the retention window.
+func (r *UserStatusRequest) Validate() (err error) {
+	switch r.Status {
+	case enum.UserStatusActive, enum.UserStatusSuspended, enum.UserStatusDeactivated:
+		return nil
+	case enum.UserStatusUnknown:
+		return ValidationError(err, MissingField("status"))
+	default:
+		return ValidationError(err, IncorrectField("status", "must be one of active, suspended, or deactivated"))
+	}
 }
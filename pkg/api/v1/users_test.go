@@ -7,7 +7,9 @@ import (
 
 	"github.com/stretchr/testify/require"
 	"go.rtnl.ai/quarterdeck/pkg/api/v1"
-	"go.rtnl.ai/quarterdeck/pkg/store/v1/models"
+	"go.rtnl.ai/quarterdeck/pkg/enum"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/tidal"
 	"go.rtnl.ai/ulid"
 )
 
@@ -16,7 +18,7 @@ func TestNewUser(t *testing.T) {
 	now := time.Now()
 
 	modelUser := &models.User{
-		Model: models.Model{
+		BaseModel: tidal.BaseModel{
 			ID:       id,
 			Created:  now.Add(-2 * time.Hour),
 			Modified: now.Add(-1 * time.Hour),
@@ -26,11 +28,13 @@ func TestNewUser(t *testing.T) {
 		Password:      "not_a_valid_derived_key",
 		LastLogin:     sql.NullTime{Valid: true, Time: now},
 		EmailVerified: true,
+		Status:        enum.UserStatusDeleted,
+		Deleted:       sql.NullTime{Valid: true, Time: now},
+		Roles: []models.Role{
+			{ID: 123, Title: "role", Description: "description is not used"},
+		},
+		Permissions: []models.Permission{{Title: "one"}, {Title: "two"}},
 	}
-	modelUser.SetRoles([]*models.Role{
-		{ID: 123, Title: "role", Description: "description is not used"},
-	})
-	modelUser.SetPermissions([]string{"one", "two"})
 
 	apiUser, err := api.NewUser(modelUser)
 	require.NoError(t, err)
@@ -41,8 +45,10 @@ func TestNewUser(t *testing.T) {
 	require.Equal(t, modelUser.Name.String, apiUser.Name)
 	require.Equal(t, modelUser.Email, apiUser.Email)
 	require.Equal(t, modelUser.LastLogin.Time, apiUser.LastLogin)
+	require.Equal(t, enum.UserStatusDeleted, apiUser.Status)
+	require.Equal(t, modelUser.Deleted.Time, apiUser.Deleted)
 	require.Equal(t, []*api.Role{{ID: 123, Title: "role"}}, apiUser.Roles)
-	require.Equal(t, apiUser.Permissions, modelUser.Permissions())
+	require.Equal(t, []string{"one", "two"}, apiUser.Permissions)
 }
 
 func TestValidateUser(t *testing.T) {
@@ -181,6 +187,15 @@ func TestValidateUser(t *testing.T) {
 		require.EqualError(t, user.Validate(), api.ReadOnlyField("last_login").Error())
 	})
 
+	t.Run("InvalidNonZeroStatus", func(t *testing.T) {
+		user := &api.User{
+			Email:  "user@example.com",
+			Status: enum.UserStatusSuspended,
+		}
+
+		require.EqualError(t, user.Validate(), api.ReadOnlyField("status").Error())
+	})
+
 	t.Run("InvalidNonZeroPermissions", func(t *testing.T) {
 		user := &api.User{
 			Email:       "user@example.com",
@@ -231,3 +246,68 @@ func TestValidateUser(t *testing.T) {
 		}
 	})
 }
+
+func TestValidateUserStatusRequest(t *testing.T) {
+	for _, status := range []enum.UserStatus{enum.UserStatusActive, enum.UserStatusSuspended, enum.UserStatusDeactivated} {
+		req := &api.UserStatusRequest{Status: status}
+		require.NoErrorf(t, req.Validate(), "expected %s to be valid", status)
+	}
+
+	req := &api.UserStatusRequest{}
+	require.EqualError(t, req.Validate(), api.MissingField("status").Error())
+
+	req = &api.UserStatusRequest{Status: enum.UserStatusDeleted}
+	require.Error(t, req.Validate())
+}
+
+func TestUserPageQuery(t *testing.T) {
+	t.Run("Filters", func(t *testing.T) {
+		after := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		q := &api.UserPageQuery{
+			Role:          "Admin",
+			Status:        "suspended",
+			Search:        "Jane_",
+			LastSeenAfter: after,
+			Sort:          "email",
+		}
+
+		cursor, err := q.Cursor(ulid.Zero)
+		require.NoError(t, err)
+
+		filter := cursor.Filter()
+		require.Equal(t, `WHERE status = :status AND id IN (SELECT ur.user_id FROM user_roles ur JOIN roles r ON ur.role_id = r.id WHERE LOWER(r.title) = LOWER(:role)) AND LOWER(COALESCE(name, '') || ' ' || email) LIKE :search ESCAPE '\' AND last_login >= :last_seen_after ORDER BY email ASC, id ASC LIMIT :limit`, filter.SQL)
+		require.Equal(t, []sql.NamedArg{
+			sql.Named("status", "suspended"),
+			sql.Named("role", "Admin"),
+			sql.Named("search", `%jane\_%`),
+			sql.Named("last_seen_after", after),
+			sql.Named("limit", api.DefaultPageSize+1),
+		}, filter.Args)
+	})
+
+	t.Run("Default", func(t *testing.T) {
+		cursor, err := (&api.UserPageQuery{}).Cursor(ulid.Zero)
+		require.NoError(t, err)
+		require.Equal(t, "WHERE status <> 'deleted' ORDER BY created DESC, id DESC LIMIT :limit", cursor.Filter().SQL)
+	})
+
+	t.Run("Organization", func(t *testing.T) {
+		orgID := ulid.MakeSecure()
+		cursor, err := (&api.UserPageQuery{}).Cursor(orgID)
+		require.NoError(t, err)
+
+		filter := cursor.Filter()
+		require.Equal(t, "WHERE status <> 'deleted' AND id IN (SELECT user_id FROM organization_members WHERE organization_id = :org_id) ORDER BY created DESC, id DESC LIMIT :limit", filter.SQL)
+		require.Equal(t, []sql.NamedArg{
+			sql.Named("org_id", orgID),
+			sql.Named("limit", api.DefaultPageSize+1),
+		}, filter.Args)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		now := time.Now()
+		q := &api.UserPageQuery{Status: "banned", LastSeenAfter: now, LastSeenBefore: now.Add(-time.Hour), Sort: "name"}
+		_, err := q.Cursor(ulid.Zero)
+		require.ErrorContains(t, err, "3 validation errors occurred")
+	})
+}
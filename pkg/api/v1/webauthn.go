@@ -0,0 +1,89 @@
+package api
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/ulid"
+)
+
+const maxPasskeyNameLength = 64
+
+// WebAuthnCredential describes a passkey registered by a user; the public key and the
+// authenticator-assigned credential ID are never returned.
+type WebAuthnCredential struct {
+	ID         ulid.ULID  `json:"id"`
+	Name       string     `json:"name,omitempty"`
+	Transports []string   `json:"transports,omitempty"`
+	Synced     bool       `json:"synced"` // the passkey is backed up to the user's account (e.g. a password manager)
+	LastUsed   *time.Time `json:"last_used,omitempty"`
+	Created    time.Time  `json:"created"`
+}
+
+type WebAuthnCredentialList struct {
+	Credentials []*WebAuthnCredential `json:"credentials"`
+}
+
+// PasskeyRegistration completes the registration of a new passkey. The credential is
+// the JSON-encoded PublicKeyCredential returned by navigator.credentials.create().
+type PasskeyRegistration struct {
+	Name       string          `json:"name,omitempty"`
+	Credential json.RawMessage `json:"credential"`
+}
+
+// PasskeyLoginRequest starts a passwordless login; the optional parameters have the
+// same meaning as in the [LoginRequest] and are carried through to the finish step.
+type PasskeyLoginRequest struct {
+	Next     string `json:"next,omitempty"`
+	ClientID string `json:"client_id,omitempty"`
+	Nonce    string `json:"nonce,omitempty"`
+}
+
+// NewWebAuthnCredential converts a credential model into an API credential. The
+// backup state is bit 4 of the authenticator data flags.
+func NewWebAuthnCredential(model *models.WebAuthnCredential) (out *WebAuthnCredential, err error) {
+	out = &WebAuthnCredential{
+		ID:         model.ID,
+		Name:       model.Name.String,
+		Transports: model.Transports,
+		Synced:     model.Flags&0x10 != 0,
+		Created:    model.Created,
+	}
+
+	if model.LastUsed.Valid {
+		out.LastUsed = &model.LastUsed.Time
+	}
+
+	return out, nil
+}
+
+func NewWebAuthnCredentialList(credentials []*models.WebAuthnCredential) (out *WebAuthnCredentialList, err error) {
+	out = &WebAuthnCredentialList{
+		Credentials: make([]*WebAuthnCredential, 0, len(credentials)),
+	}
+
+	for _, model := range credentials {
+		var credential *WebAuthnCredential
+		if credential, err = NewWebAuthnCredential(model); err != nil {
+			return nil, err
+		}
+		out.Credentials = append(out.Credentials, credential)
+	}
+
+	return out, nil
+}
+
+func (r *PasskeyRegistration) Validate() (err error) {
+	r.Name = strings.TrimSpace(r.Name)
+	if len(r.Name) > maxPasskeyNameLength {
+		err = ValidationError(err, IncorrectField("name", "passkey name is too long"))
+	}
+
+	if len(r.Credential) == 0 || string(r.Credential) == "null" {
+		err = ValidationError(err, MissingField("credential"))
+	}
+
+	return err
+}
@@ -0,0 +1,63 @@
+package api_test
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	. "go.rtnl.ai/quarterdeck/pkg/api/v1"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/tidal"
+	"go.rtnl.ai/ulid"
+)
+
+func TestValidatePasskeyRegistration(t *testing.T) {
+	req := &PasskeyRegistration{Name: "  YubiKey ", Credential: json.RawMessage(`{"id":"abc"}`)}
+	require.NoError(t, req.Validate())
+	require.Equal(t, "YubiKey", req.Name, "the name should be trimmed")
+
+	tests := []struct {
+		req *PasskeyRegistration
+		err string
+	}{
+		{&PasskeyRegistration{Name: "YubiKey"}, "missing credential"},
+		{&PasskeyRegistration{Credential: json.RawMessage("null")}, "missing credential"},
+		{&PasskeyRegistration{Name: strings.Repeat("a", 65), Credential: json.RawMessage(`{}`)}, "passkey name is too long"},
+	}
+
+	for i, tc := range tests {
+		require.ErrorContains(t, tc.req.Validate(), tc.err, "test case %d failed", i)
+	}
+}
+
+func TestNewWebAuthnCredential(t *testing.T) {
+	model := &models.WebAuthnCredential{
+		BaseModel:    tidal.BaseModel{ID: ulid.Make(), Created: time.Now()},
+		Name:         sql.NullString{String: "iCloud Keychain", Valid: true},
+		CredentialID: []byte("credential"),
+		PublicKey:    []byte("public-key"),
+		Transports:   []string{"internal", "hybrid"},
+		Flags:        0x1d,
+	}
+
+	out, err := NewWebAuthnCredential(model)
+	require.NoError(t, err)
+	require.Equal(t, model.ID, out.ID)
+	require.Equal(t, "iCloud Keychain", out.Name)
+	require.True(t, out.Synced)
+	require.Nil(t, out.LastUsed)
+
+	data, err := json.Marshal(out)
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "public", "the public key should not be returned")
+
+	model.Flags = 0x05
+	model.LastUsed = sql.NullTime{Time: time.Now(), Valid: true}
+	out, err = NewWebAuthnCredential(model)
+	require.NoError(t, err)
+	require.False(t, out.Synced)
+	require.NotNil(t, out.LastUsed)
+}
@@ -0,0 +1,226 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+
+	"go.rtnl.ai/quarterdeck/pkg/enum"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/tidal"
+	"go.rtnl.ai/ulid"
+)
+
+// Webhook subscribes an endpoint to one or more events. The secret is used to verify
+// the signature of each delivery; it is generated by Quarterdeck and only returned
+// when the webhook is created. New webhooks are active unless active is false.
+type Webhook struct {
+	ID          ulid.ULID           `json:"id,omitempty"`
+	URL         string              `json:"url"`
+	Description string              `json:"description,omitempty"`
+	Events      []enum.WebhookEvent `json:"events"`
+	Secret      string              `json:"secret,omitempty"`
+	Active      *bool               `json:"active,omitempty"`
+	Created     time.Time           `json:"created,omitempty"`
+	Modified    time.Time           `json:"modified,omitempty"`
+}
+
+type WebhookList struct {
+	Page     *Page      `json:"page"`
+	Webhooks []*Webhook `json:"webhooks"`
+}
+
+// WebhookPageQuery filters webhooks by whether they are active. Webhooks are sorted by
+// the sort field, most recently created first by default.
+type WebhookPageQuery struct {
+	PageQuery
+	Active *bool  `json:"active,omitempty" url:"active,omitempty" form:"active"`
+	Sort   string `json:"sort,omitempty" url:"sort,omitempty" form:"sort"`
+}
+
+// WebhookSortFields are the fields that a list of webhooks can be sorted by.
+var WebhookSortFields = []SortField{
+	{Name: "created", Column: "created"},
+	{Name: "modified", Column: "modified"},
+	{Name: "url", Column: "url"},
+}
+
+// WebhookDelivery is an entry in the delivery log of a webhook. Redeliveries of an
+// event are new deliveries with the same event ID.
+type WebhookDelivery struct {
+	ID           ulid.ULID           `json:"id"`
+	WebhookID    ulid.ULID           `json:"webhook_id"`
+	EventID      ulid.ULID           `json:"event_id"`
+	Event        enum.WebhookEvent   `json:"event"`
+	Payload      json.RawMessage     `json:"payload,omitempty"`
+	Status       enum.DeliveryStatus `json:"status"`
+	Attempts     int                 `json:"attempts"`
+	NextAttempt  time.Time           `json:"next_attempt,omitempty"`
+	LastAttempt  time.Time           `json:"last_attempt,omitempty"`
+	ResponseCode int                 `json:"response_code,omitempty"`
+	Error        string              `json:"error,omitempty"`
+	Delivered    time.Time           `json:"delivered,omitempty"`
+	Created      time.Time           `json:"created"`
+	Modified     time.Time           `json:"modified"`
+}
+
+type WebhookDeliveryList struct {
+	Page       *Page              `json:"page"`
+	Deliveries []*WebhookDelivery `json:"deliveries"`
+}
+
+// NewWebhook converts the model into an API response; the secret is never included.
+func NewWebhook(model *models.Webhook) (out *Webhook, err error) {
+	active := model.Active
+	out = &Webhook{
+		ID:          model.ID,
+		URL:         model.URL,
+		Description: model.Description.String,
+		Events:      model.Events,
+		Active:      &active,
+		Created:     model.Created,
+		Modified:    model.Modified,
+	}
+
+	if out.Events == nil {
+		out.Events = make([]enum.WebhookEvent, 0)
+	}
+	return out, nil
+}
+
+func NewWebhookList(webhooks []*models.Webhook) (out *WebhookList, err error) {
+	out = &WebhookList{
+		Page:     &Page{},
+		Webhooks: make([]*Webhook, 0, len(webhooks)),
+	}
+
+	for _, model := range webhooks {
+		var webhook *Webhook
+		if webhook, err = NewWebhook(model); err != nil {
+			return nil, err
+		}
+		out.Webhooks = append(out.Webhooks, webhook)
+	}
+
+	return out, nil
+}
+
+func NewWebhookDelivery(model *models.WebhookDelivery) (out *WebhookDelivery, err error) {
+	out = &WebhookDelivery{
+		ID:           model.ID,
+		WebhookID:    model.WebhookID,
+		EventID:      model.EventID,
+		Event:        model.Event,
+		Status:       model.Status,
+		Attempts:     model.Attempts,
+		NextAttempt:  model.NextAttempt.Time,
+		LastAttempt:  model.LastAttempt.Time,
+		ResponseCode: int(model.ResponseCode.Int64),
+		Error:        model.Error.String,
+		Delivered:    model.Delivered.Time,
+		Created:      model.Created,
+		Modified:     model.Modified,
+	}
+
+	if len(model.Payload) > 0 && json.Valid(model.Payload) {
+		out.Payload = json.RawMessage(model.Payload)
+	}
+	return out, nil
+}
+
+func NewWebhookDeliveryList(deliveries []*models.WebhookDelivery) (out *WebhookDeliveryList, err error) {
+	out = &WebhookDeliveryList{
+		Page:       &Page{},
+		Deliveries: make([]*WebhookDelivery, 0, len(deliveries)),
+	}
+
+	for _, model := range deliveries {
+		var delivery *WebhookDelivery
+		if delivery, err = NewWebhookDelivery(model); err != nil {
+			return nil, err
+		}
+		out.Deliveries = append(out.Deliveries, delivery)
+	}
+
+	return out, nil
+}
+
+// Validate the webhook for create and update requests; duplicate events are removed.
+func (w *Webhook) Validate() (err error) {
+	if !w.ID.IsZero() {
+		err = ValidationError(err, ReadOnlyField("id"))
+	}
+
+	w.URL = strings.TrimSpace(w.URL)
+	if w.URL == "" {
+		err = ValidationError(err, MissingField("url"))
+	} else if perr := validateURI("url", w.URL); perr != nil {
+		err = ValidationError(err, IncorrectField("url", perr.Error()))
+	}
+
+	w.Description = strings.TrimSpace(w.Description)
+
+	if len(w.Events) == 0 {
+		err = ValidationError(err, MissingField("events"))
+	}
+
+	events := make([]enum.WebhookEvent, 0, len(w.Events))
+	for _, event := range w.Events {
+		if event == enum.WebhookEventUnknown {
+			err = ValidationError(err, IncorrectField("events", "unknown webhook event"))
+			continue
+		}
+
+		if !slices.Contains(events, event) {
+			events = append(events, event)
+		}
+	}
+	w.Events = events
+
+	if w.Secret != "" {
+		err = ValidationError(err, ReadOnlyField("secret"))
+	}
+
+	if !w.Created.IsZero() {
+		err = ValidationError(err, ReadOnlyField("created"))
+	}
+
+	if !w.Modified.IsZero() {
+		err = ValidationError(err, ReadOnlyField("modified"))
+	}
+
+	return err
+}
+
+// Model converts the webhook into a model for create or update; if active is not
+// specified then the webhook is active.
+func (w *Webhook) Model() (model *models.Webhook, err error) {
+	model = &models.Webhook{
+		BaseModel: tidal.BaseModel{
+			ID:       w.ID,
+			Created:  w.Created,
+			Modified: w.Modified,
+		},
+		URL:         w.URL,
+		Description: sql.NullString{String: w.Description, Valid: w.Description != ""},
+		Events:      w.Events,
+		Secret:      w.Secret,
+		Active:      w.Active == nil || *w.Active,
+	}
+	return model, nil
+}
+
+// Cursor validates the query and returns the cursor for the requested page of webhooks.
+func (q *WebhookPageQuery) Cursor() (cursor *Cursor, err error) {
+	if cursor, err = NewCursor(&q.PageQuery, "webhooks", q.Sort, "-created", WebhookSortFields); err != nil {
+		return nil, err
+	}
+
+	if q.Active != nil {
+		cursor.Where("active = :active", sql.Named("active", *q.Active))
+	}
+
+	return cursor, nil
+}
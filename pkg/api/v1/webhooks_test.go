@@ -0,0 +1,154 @@
+package api_test
+
+import (
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/quarterdeck/pkg/api/v1"
+	"go.rtnl.ai/quarterdeck/pkg/enum"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/tidal"
+	"go.rtnl.ai/ulid"
+)
+
+func TestWebhookValidate(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		webhook := &api.Webhook{
+			URL:         " https://example.com/webhooks ",
+			Description: " User sync ",
+			Events:      []enum.WebhookEvent{enum.WebhookEventUserCreated, enum.WebhookEventUserDeleted, enum.WebhookEventUserCreated},
+		}
+		require.NoError(t, webhook.Validate())
+		require.Equal(t, "https://example.com/webhooks", webhook.URL, "expected url to be trimmed")
+		require.Equal(t, "User sync", webhook.Description, "expected description to be trimmed")
+		require.Equal(t, []enum.WebhookEvent{enum.WebhookEventUserCreated, enum.WebhookEventUserDeleted}, webhook.Events, "expected duplicate events to be removed")
+	})
+
+	t.Run("IDNotZero", func(t *testing.T) {
+		webhook := &api.Webhook{ID: ulid.Make(), URL: "https://example.com/webhooks", Events: []enum.WebhookEvent{enum.WebhookEventLogin}}
+		assertSingleValidationError(t, webhook.Validate(), "read-only field id: this field cannot be written by the user", nil)
+	})
+
+	t.Run("MissingURL", func(t *testing.T) {
+		webhook := &api.Webhook{Events: []enum.WebhookEvent{enum.WebhookEventLogin}}
+		assertSingleValidationError(t, webhook.Validate(), "missing url: this field is required", nil)
+	})
+
+	t.Run("InvalidURL", func(t *testing.T) {
+		webhook := &api.Webhook{URL: "ftp://example.com/webhooks", Events: []enum.WebhookEvent{enum.WebhookEventLogin}}
+		assertSingleValidationError(t, webhook.Validate(), "", []string{"invalid field url"})
+	})
+
+	t.Run("MissingEvents", func(t *testing.T) {
+		webhook := &api.Webhook{URL: "https://example.com/webhooks"}
+		assertSingleValidationError(t, webhook.Validate(), "missing events: this field is required", nil)
+	})
+
+	t.Run("UnknownEvent", func(t *testing.T) {
+		webhook := &api.Webhook{URL: "https://example.com/webhooks", Events: []enum.WebhookEvent{enum.WebhookEventUnknown}}
+		assertSingleValidationError(t, webhook.Validate(), "invalid field events: unknown webhook event", nil)
+	})
+
+	t.Run("SecretSet", func(t *testing.T) {
+		webhook := &api.Webhook{URL: "https://example.com/webhooks", Events: []enum.WebhookEvent{enum.WebhookEventLogin}, Secret: "supersecretsquirrel"}
+		assertSingleValidationError(t, webhook.Validate(), "read-only field secret: this field cannot be written by the user", nil)
+	})
+
+	t.Run("ModifiedSet", func(t *testing.T) {
+		webhook := &api.Webhook{URL: "https://example.com/webhooks", Events: []enum.WebhookEvent{enum.WebhookEventLogin}, Modified: time.Now()}
+		assertSingleValidationError(t, webhook.Validate(), "read-only field modified: this field cannot be written by the user", nil)
+	})
+
+	t.Run("UnmarshalUnknownEvent", func(t *testing.T) {
+		webhook := &api.Webhook{}
+		require.Error(t, json.Unmarshal([]byte(`{"url":"https://example.com","events":["user.exploded"]}`), webhook))
+	})
+}
+
+func TestWebhookModel(t *testing.T) {
+	t.Run("ActiveByDefault", func(t *testing.T) {
+		webhook := &api.Webhook{URL: "https://example.com/webhooks", Events: []enum.WebhookEvent{enum.WebhookEventLogin}}
+		model, err := webhook.Model()
+		require.NoError(t, err)
+		require.True(t, model.Active)
+		require.False(t, model.Description.Valid)
+
+		out, err := api.NewWebhook(model)
+		require.NoError(t, err)
+		require.True(t, *out.Active)
+		require.Equal(t, webhook.URL, out.URL)
+		require.Equal(t, webhook.Events, out.Events)
+	})
+
+	t.Run("Inactive", func(t *testing.T) {
+		active := false
+		webhook := &api.Webhook{URL: "https://example.com/webhooks", Events: []enum.WebhookEvent{enum.WebhookEventLogin}, Active: &active}
+		model, err := webhook.Model()
+		require.NoError(t, err)
+		require.False(t, model.Active)
+	})
+
+	t.Run("SecretNotReturned", func(t *testing.T) {
+		model := &models.Webhook{URL: "https://example.com/webhooks", Secret: "supersecretsquirrel"}
+		out, err := api.NewWebhook(model)
+		require.NoError(t, err)
+		require.Empty(t, out.Secret)
+		require.NotNil(t, out.Events)
+	})
+}
+
+func TestNewWebhookDelivery(t *testing.T) {
+	model := &models.WebhookDelivery{
+		BaseModel:    tidal.BaseModel{ID: ulid.Make(), Created: time.Now(), Modified: time.Now()},
+		WebhookID:    ulid.Make(),
+		EventID:      ulid.Make(),
+		Event:        enum.WebhookEventAPIKeyRevoked,
+		Payload:      []byte(`{"event":"apikey.revoked"}`),
+		Status:       enum.DeliveryStatusPending,
+		Attempts:     2,
+		NextAttempt:  sql.NullTime{Valid: true, Time: time.Now()},
+		ResponseCode: sql.NullInt64{Valid: true, Int64: 503},
+		Error:        sql.NullString{Valid: true, String: "service unavailable"},
+	}
+
+	out, err := api.NewWebhookDelivery(model)
+	require.NoError(t, err)
+	require.Equal(t, model.WebhookID, out.WebhookID)
+	require.Equal(t, 503, out.ResponseCode)
+	require.Equal(t, "service unavailable", out.Error)
+	require.True(t, out.Delivered.IsZero())
+	require.JSONEq(t, `{"event":"apikey.revoked"}`, string(out.Payload))
+
+	list, err := api.NewWebhookDeliveryList([]*models.WebhookDelivery{model})
+	require.NoError(t, err)
+	require.Len(t, list.Deliveries, 1)
+}
+
+func TestWebhookPageQuery(t *testing.T) {
+	t.Run("Active", func(t *testing.T) {
+		active := false
+		cursor, err := (&api.WebhookPageQuery{Active: &active, Sort: "url"}).Cursor()
+		require.NoError(t, err)
+
+		filter := cursor.Filter()
+		require.Equal(t, "WHERE active = :active ORDER BY url ASC, id ASC LIMIT :limit", filter.SQL)
+		require.Equal(t, []sql.NamedArg{
+			sql.Named("active", false),
+			sql.Named("limit", api.DefaultPageSize+1),
+		}, filter.Args)
+	})
+
+	t.Run("Default", func(t *testing.T) {
+		cursor, err := (&api.WebhookPageQuery{}).Cursor()
+		require.NoError(t, err)
+		require.Equal(t, "ORDER BY created DESC, id DESC LIMIT :limit", cursor.Filter().SQL)
+	})
+
+	t.Run("InvalidSort", func(t *testing.T) {
+		_, err := (&api.WebhookPageQuery{Sort: "secret"}).Cursor()
+		require.Error(t, err)
+	})
+}
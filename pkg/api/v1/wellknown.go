@@ -14,10 +14,12 @@ type OpenIDConfiguration struct {
 	JWKSURI                       string   `json:"jwks_uri"`
 	RegistrationEP                string   `json:"registration_endpoint"`
 	RevocationEP                  string   `json:"revocation_endpoint"`
+	IntrospectionEP               string   `json:"introspection_endpoint"`
 	ScopesSupported               []string `json:"scopes_supported"`
 	ResponseTypesSupported        []string `json:"response_types_supported"`
 	CodeChallengeMethodsSupported []string `json:"code_challenge_methods_supported"`
 	ResponseModesSupported        []string `json:"response_modes_supported"`
+	GrantTypesSupported           []string `json:"grant_types_supported"`
 	SubjectTypesSupported         []string `json:"subject_types_supported"`
 	IDTokenSigningAlgValues       []string `json:"id_token_signing_alg_values_supported"`
 	TokenEndpointAuthMethods      []string `json:"token_endpoint_auth_methods_supported"`
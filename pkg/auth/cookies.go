@@ -19,9 +19,17 @@ const (
 	AccessTokenCookie        = "access_token"
 	RefreshTokenCookie       = "refresh_token"
 	ResetPasswordTokenCookie = "reset_password_token"
+	InviteTokenCookie        = "invite_token"
+	VerifyEmailTokenCookie   = "verify_email_token"
+	ChangeEmailTokenCookie   = "change_email_token"
+	RevertEmailTokenCookie   = "revert_email_token"
 
 	CookieMaxAgeBuffer          = 600 * time.Second
-	ResetPasswordTokenCookieTTL = 900 * time.Second // 15 minutes; same as [server.resetPasswordTokenTTL]
+	ResetPasswordTokenCookieTTL = 900 * time.Second  // 15 minutes; same as [server.resetPasswordTokenTTL]
+	InviteTokenCookieTTL        = 48 * time.Hour     // same as [server.welcomeEmailTokenTTL]
+	VerifyEmailTokenCookieTTL   = 1 * time.Hour      // same as [server.verifyEmailTokenTTL]
+	ChangeEmailTokenCookieTTL   = 24 * time.Hour     // same as [server.changeEmailTokenTTL]
+	RevertEmailTokenCookieTTL   = 7 * 24 * time.Hour // same as [server.revertEmailTokenTTL]
 
 	localhost = "localhost"
 	localTLD  = ".local"
@@ -125,6 +133,50 @@ func ClearResetPasswordTokenCookie(c *gin.Context, domain string) {
 	ClearSecureCookie(c, ResetPasswordTokenCookie, domain, false)
 }
 
+//=============================================================================
+// Team Invite Token Cookies
+//=============================================================================
+
+func SetInviteTokenCookie(c *gin.Context, token, domain string) {
+	SetSecureCookie(c, InviteTokenCookie, token, int(InviteTokenCookieTTL.Seconds()), domain, false)
+}
+
+func ClearInviteTokenCookie(c *gin.Context, domain string) {
+	ClearSecureCookie(c, InviteTokenCookie, domain, false)
+}
+
+//=============================================================================
+// Verify Email Token Cookies
+//=============================================================================
+
+func SetVerifyEmailTokenCookie(c *gin.Context, token, domain string) {
+	SetSecureCookie(c, VerifyEmailTokenCookie, token, int(VerifyEmailTokenCookieTTL.Seconds()), domain, false)
+}
+
+func ClearVerifyEmailTokenCookie(c *gin.Context, domain string) {
+	ClearSecureCookie(c, VerifyEmailTokenCookie, domain, false)
+}
+
+//=============================================================================
+// Change Email Token Cookies
+//=============================================================================
+
+func SetChangeEmailTokenCookie(c *gin.Context, token, domain string) {
+	SetSecureCookie(c, ChangeEmailTokenCookie, token, int(ChangeEmailTokenCookieTTL.Seconds()), domain, false)
+}
+
+func ClearChangeEmailTokenCookie(c *gin.Context, domain string) {
+	ClearSecureCookie(c, ChangeEmailTokenCookie, domain, false)
+}
+
+func SetRevertEmailTokenCookie(c *gin.Context, token, domain string) {
+	SetSecureCookie(c, RevertEmailTokenCookie, token, int(RevertEmailTokenCookieTTL.Seconds()), domain, false)
+}
+
+func ClearRevertEmailTokenCookie(c *gin.Context, domain string) {
+	ClearSecureCookie(c, RevertEmailTokenCookie, domain, false)
+}
+
 //=============================================================================
 // Helpers
 //=============================================================================
@@ -0,0 +1,64 @@
+package auth_test
+
+import (
+	"context"
+
+	"go.rtnl.ai/gimlet/auth"
+	. "go.rtnl.ai/quarterdeck/pkg/auth"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/ulid"
+)
+
+// denylist is a simple in-memory denylist for testing the issuer.
+type denylist map[ulid.ULID]struct{}
+
+func (d denylist) IsTokenRevoked(_ context.Context, jti ulid.ULID) (bool, error) {
+	_, ok := d[jti]
+	return ok, nil
+}
+
+// brokenDenylist always returns an error to test that the issuer fails closed.
+type brokenDenylist struct{}
+
+func (brokenDenylist) IsTokenRevoked(context.Context, ulid.ULID) (bool, error) {
+	return false, errors.ErrInternal
+}
+
+func (s *TokenTestSuite) TestDenylist() {
+	require := s.Require()
+	conf := s.AuthConfig()
+	conf.TokenOverlap = -1 * conf.AccessTokenTTL
+
+	tm, err := s.NewIssuer(conf)
+	require.NoError(err, "could not initialize token manager")
+
+	creds := &auth.Claims{Email: "kate@example.com"}
+	creds.SetSubjectID(auth.SubjectUser, ulid.Make())
+
+	accessToken, refreshToken, err := tm.CreateTokens(creds, ulid.Zero)
+	require.NoError(err)
+
+	// Without a denylist the tokens are valid
+	claims, err := tm.Verify(accessToken)
+	require.NoError(err)
+
+	deny := make(denylist)
+	tm.SetDenylist(deny)
+
+	_, err = tm.Verify(accessToken)
+	require.NoError(err, "token should be valid when not in the denylist")
+
+	// Revoking the jti revokes both the access and refresh tokens
+	deny[ulid.MustParse(claims.ID)] = struct{}{}
+
+	_, err = tm.Verify(accessToken)
+	require.ErrorIs(err, errors.ErrTokenRevoked)
+
+	_, err = tm.Verify(refreshToken)
+	require.ErrorIs(err, errors.ErrTokenRevoked)
+
+	// If the denylist cannot be checked the token should not be verified
+	tm.SetDenylist(brokenDenylist{})
+	_, err = tm.Verify(accessToken)
+	require.ErrorIs(err, errors.ErrInternal)
+}
@@ -36,7 +36,16 @@ func (tm *Issuer) Verify(tks string) (claims *auth.Claims, err error) {
 
 	var ok bool
 	if claims, ok = token.Claims.(*auth.Claims); ok && token.Valid {
-		// TODO: add claims specific validation here if needed.
+		// Reject tokens that have been revoked before they expired.
+		var revoked bool
+		if revoked, err = tm.Revoked(claims); err != nil {
+			return nil, err
+		}
+
+		if revoked {
+			return nil, errors.ErrTokenRevoked
+		}
+
 		return claims, nil
 	}
 
@@ -83,37 +92,37 @@ var _ cache.Expirer = (*Issuer)(nil)
 var _ cache.CacheController = (*Issuer)(nil)
 
 func (tm *Issuer) ETag() string {
-	return tm.publicKeys.ETag()
+	return tm.jwks().ETag()
 }
 
 func (tm *Issuer) ComputeETag(data []byte) {
-	tm.publicKeys.ComputeETag(data)
+	tm.jwks().ComputeETag(data)
 }
 
 func (tm *Issuer) SetETag(s string) {
-	tm.publicKeys.SetETag(s)
+	tm.jwks().SetETag(s)
 }
 
 func (tm *Issuer) LastModified() time.Time {
-	return tm.publicKeys.LastModified()
+	return tm.jwks().LastModified()
 }
 
 func (tm *Issuer) Expires() time.Time {
-	return tm.publicKeys.Expires()
+	return tm.jwks().Expires()
 }
 
 func (tm *Issuer) Modified(t time.Time, d any) {
-	tm.publicKeys.Modified(t, d)
+	tm.jwks().Modified(t, d)
 }
 
 func (tm *Issuer) Directives() string {
-	return tm.publicKeys.Directives()
+	return tm.jwks().Directives()
 }
 
 func (tm *Issuer) SetMaxAge(v any) {
-	tm.publicKeys.SetMaxAge(v)
+	tm.jwks().SetMaxAge(v)
 }
 
 func (tm *Issuer) SetSMaxAge(v any) {
-	tm.publicKeys.SetSMaxAge(v)
+	tm.jwks().SetSMaxAge(v)
 }
@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.rtnl.ai/gimlet/auth"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+)
+
+// IDTokenClaims are the claims of an OpenID Connect ID token. Unlike access tokens, ID
+// tokens are intended for a specific relying party (the audience is the client_id of
+// the OIDC client) and describe the authentication event rather than authorizing
+// access to resources.
+// See: https://openid.net/specs/openid-connect-core-1_0.html#IDToken
+type IDTokenClaims struct {
+	jwt.RegisteredClaims
+	AuthTime      *jwt.NumericDate `json:"auth_time,omitempty"`
+	Nonce         string           `json:"nonce,omitempty"`
+	AccessHash    string           `json:"at_hash,omitempty"`
+	Name          string           `json:"name,omitempty"`
+	Email         string           `json:"email,omitempty"`
+	EmailVerified bool             `json:"email_verified"`
+}
+
+// IDTokenOptions describe the authentication event and the relying party that the ID
+// token is being issued to.
+type IDTokenOptions struct {
+	ClientID      string    // The audience of the ID token; if empty the issuer's audience is used
+	Nonce         string    // The nonce sent by the client on the authorization request
+	AuthTime      time.Time // When the user actively authenticated; if zero the current time is used
+	EmailVerified bool      // Whether the user's email address has been verified
+}
+
+// CreateIDToken creates and signs an OpenID Connect ID token for the subject of the
+// claims. If an access token is supplied, the at_hash claim is computed so that the
+// relying party can verify the access token was issued alongside the ID token.
+func (tm *Issuer) CreateIDToken(claims *auth.Claims, accessToken string, opts IDTokenOptions) (_ string, err error) {
+	if claims == nil || claims.Subject == "" {
+		return "", errors.ErrUnparsableClaims
+	}
+
+	audience := jwt.ClaimStrings(tm.conf.Audience)
+	if opts.ClientID != "" {
+		audience = jwt.ClaimStrings{opts.ClientID}
+	}
+
+	now := time.Now()
+	if opts.AuthTime.IsZero() {
+		opts.AuthTime = now
+	}
+
+	idClaims := &IDTokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        secureULID().String(),
+			Subject:   claims.Subject,
+			Audience:  audience,
+			Issuer:    tm.conf.Issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(tm.conf.AccessTokenTTL)),
+		},
+		AuthTime:      jwt.NewNumericDate(opts.AuthTime),
+		Nonce:         opts.Nonce,
+		Name:          claims.Name,
+		Email:         claims.Email,
+		EmailVerified: opts.EmailVerified,
+	}
+
+	if accessToken != "" {
+		idClaims.AccessHash = AccessTokenHash(accessToken)
+	}
+
+	return tm.Sign(jwt.NewWithClaims(signingMethod, idClaims))
+}
+
+// ParseIDToken verifies the signature and the time-based claims of an ID token issued
+// by Quarterdeck. The audience is not verified since it is specific to the client.
+func (tm *Issuer) ParseIDToken(tks string) (claims *IDTokenClaims, err error) {
+	parser := jwt.NewParser(
+		jwt.WithValidMethods([]string{signingMethod.Alg()}),
+		jwt.WithIssuer(tm.conf.Issuer),
+		jwt.WithIssuedAt(),
+	)
+
+	claims = &IDTokenClaims{}
+	if _, err = parser.ParseWithClaims(tks, claims, tm.GetKey); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// AccessTokenHash computes the at_hash claim for an access token: the base64url
+// encoding of the left-most half of the hash of the token. The hash algorithm must
+// match the signing algorithm; for EdDSA with Ed25519 keys this is SHA-512.
+// See: https://openid.net/specs/openid-connect-core-1_0.html#CodeIDToken
+func AccessTokenHash(accessToken string) string {
+	sum := sha512.Sum512([]byte(accessToken))
+	return base64.RawURLEncoding.EncodeToString(sum[:len(sum)/2])
+}
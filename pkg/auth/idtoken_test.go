@@ -0,0 +1,84 @@
+package auth_test
+
+import (
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.rtnl.ai/gimlet/auth"
+	. "go.rtnl.ai/quarterdeck/pkg/auth"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/ulid"
+)
+
+func (s *TokenTestSuite) TestIDToken() {
+	require := s.Require()
+	conf := s.AuthConfig()
+
+	tm, err := s.NewIssuer(conf)
+	require.NoError(err, "could not initialize token manager")
+
+	creds := &auth.Claims{
+		Email: "kate@example.com",
+		Name:  "Kate Holland",
+	}
+	creds.SetSubjectID(auth.SubjectUser, ulid.Make())
+
+	accessToken, _, err := tm.CreateTokens(creds, ulid.Zero)
+	require.NoError(err)
+
+	s.Run("ClientAudience", func() {
+		authTime := time.Now().Add(-10 * time.Minute).Truncate(time.Second)
+		opts := IDTokenOptions{
+			ClientID:      "cid",
+			Nonce:         "n-0S6_WzA2Mj",
+			AuthTime:      authTime,
+			EmailVerified: true,
+		}
+
+		tks, err := tm.CreateIDToken(creds, accessToken, opts)
+		require.NoError(err, "could not create id token")
+
+		claims, err := tm.ParseIDToken(tks)
+		require.NoError(err, "could not parse id token")
+		require.Equal(jwt.ClaimStrings{"cid"}, claims.Audience)
+		require.Equal(creds.Subject, claims.Subject)
+		require.Equal("http://localhost:3001", claims.Issuer)
+		require.Equal("n-0S6_WzA2Mj", claims.Nonce)
+		require.True(claims.AuthTime.Equal(authTime))
+		require.Equal(AccessTokenHash(accessToken), claims.AccessHash)
+		require.Equal(creds.Email, claims.Email)
+		require.Equal(creds.Name, claims.Name)
+		require.True(claims.EmailVerified)
+	})
+
+	s.Run("DefaultAudience", func() {
+		tks, err := tm.CreateIDToken(creds, "", IDTokenOptions{})
+		require.NoError(err, "could not create id token")
+
+		claims, err := tm.ParseIDToken(tks)
+		require.NoError(err, "could not parse id token")
+		require.Equal(jwt.ClaimStrings{"http://localhost:3000"}, claims.Audience)
+		require.Empty(claims.AccessHash)
+		require.Empty(claims.Nonce)
+		require.False(claims.EmailVerified)
+		require.NotNil(claims.AuthTime)
+	})
+
+	s.Run("NoSubject", func() {
+		_, err := tm.CreateIDToken(&auth.Claims{}, accessToken, IDTokenOptions{})
+		require.ErrorIs(err, errors.ErrUnparsableClaims)
+	})
+}
+
+func (s *TokenTestSuite) TestAccessTokenHash() {
+	// at_hash is the left-most 256 bits of the SHA-512 hash, base64url encoded.
+	hash := AccessTokenHash("jHkWEdUXMU1BwAsC4vtUsZwnNvTIxEl0z9K3vx5KF0Y")
+	s.Require().Len(hash, 43)
+
+	// Known answer vectors computed independently of the implementation.
+	s.Require().Equal("q7nS86GgvvFaZkzALLWqJYaJIKw2wCDAVfCAsm5CrBM", hash)
+	s.Require().Equal("HoQu6nRkzy58Av9mefwscD6jz3dCbR1ilSN3YAXgfng", AccessTokenHash("a different access token"))
+
+	s.Require().Equal(hash, AccessTokenHash("jHkWEdUXMU1BwAsC4vtUsZwnNvTIxEl0z9K3vx5KF0Y"))
+	s.Require().NotEqual(hash, AccessTokenHash("a different access token"))
+}
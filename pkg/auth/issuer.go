@@ -10,7 +10,6 @@ import (
 	"sync"
 	"time"
 
-	jose "github.com/go-jose/go-jose/v4"
 	"github.com/golang-jwt/jwt/v5"
 	"go.rtnl.ai/gimlet/auth"
 	"go.rtnl.ai/quarterdeck/pkg/config"
@@ -29,19 +28,33 @@ var (
 
 // Global constants that should not be changed except between major versions.
 const (
-	refreshPath = "/v1/reauthenticate"
-	keyUse      = "sig"
+	refreshPath     = "/v1/reauthenticate"
+	keyUse          = "sig"
+	denylistTimeout = 5 * time.Second
 )
 
+// Issuer creates and verifies the JWT tokens issued by Quarterdeck. The signing keys
+// of the issuer are managed by a [KeyManager] that replaces them whenever keys are
+// rotated, so the keys are guarded by a mutex and may change between calls.
 type Issuer struct {
+	sync.RWMutex
 	conf            config.AuthConfig
 	keyID           ulid.ULID
 	key             crypto.PrivateKey
 	publicKeys      *JWKS
 	refreshAudience string
 	loginURL        *redirect.LoginURL
+	denylist        Denylist
 }
 
+// Denylist is used by the issuer to check if a token has been revoked before it has
+// expired (e.g. via the OAuth2 revocation endpoint). The jti of the token is checked.
+type Denylist interface {
+	IsTokenRevoked(context.Context, ulid.ULID) (bool, error)
+}
+
+// NewIssuer creates an issuer without any signing keys; keys must be added with AddKey
+// or loaded from the database by a [KeyManager] before tokens can be signed.
 func NewIssuer(conf config.AuthConfig) (_ *Issuer, err error) {
 	// Validate the issuer configuration
 	if err = conf.Validate(); err != nil {
@@ -50,42 +63,10 @@ func NewIssuer(conf config.AuthConfig) (_ *Issuer, err error) {
 
 	issuer := &Issuer{
 		conf:       conf,
-		publicKeys: &JWKS{JSONWebKeySet: jose.JSONWebKeySet{Keys: make([]jose.JSONWebKey, 0, len(conf.Keys))}},
+		publicKeys: NewJWKS(conf.KeyPublishWindow),
 		loginURL:   redirect.MustLogin(conf.LoginURL),
 	}
 
-	// Load the specified keys from the filesystem.
-	for kid, path := range conf.Keys {
-		var keyID ulid.ULID
-		if keyID, err = ulid.Parse(kid); err != nil {
-			return nil, errors.Fmt("could not parse %s as a key id: %w", kid, err)
-		}
-
-		var keypair SigningKey
-		if keypair, err = LoadKeys(path); err != nil {
-			return nil, err
-		}
-
-		if err = issuer.AddKey(keyID, keypair); err != nil {
-			return nil, errors.Fmt("could not add key %s: %w", kid, err)
-		}
-	}
-
-	// If we have no keys, generate one for use (e.g. for testing or simple deployment)
-	if issuer.key == nil {
-		var keypair SigningKey
-		if keypair, err = GenerateKeys(); err != nil {
-			return nil, err
-		}
-
-		if err = issuer.AddKey(secureULID(), keypair); err != nil {
-			return nil, errors.Fmt("could not add generated key: %w", err)
-		}
-
-		rlog.WarnAttrs(context.Background(), "generated volatile claims issuer rsa key",
-			slog.String("keyID", issuer.keyID.String()))
-	}
-
 	return issuer, nil
 }
 
@@ -108,11 +89,39 @@ func (tm *Issuer) Parse(tks string) (claims *auth.Claims, err error) {
 }
 
 func (tm *Issuer) Sign(token *jwt.Token) (tks string, err error) {
-	token.Header["kid"] = tm.keyID.String()
-	return token.SignedString(tm.key)
+	tm.RLock()
+	keyID, key := tm.keyID, tm.key
+	tm.RUnlock()
+
+	if key == nil {
+		return "", errors.ErrNoSigningKeys
+	}
+
+	token.Header["kid"] = keyID.String()
+	return token.SignedString(key)
+}
+
+// quarterdeckClaims adds the org and amr claims to access and refresh tokens without
+// modifying the claims that are used by the gimlet authentication middleware.
+type quarterdeckClaims struct {
+	*auth.Claims
+	Org         string   `json:"org,omitempty"`
+	AuthMethods []string `json:"amr,omitempty"`
 }
 
-func (tm *Issuer) CreateAccessToken(claims *auth.Claims) (_ *jwt.Token, err error) {
+// Wraps the claims if the org or amr claims need to be added to the token.
+func newClaims(claims *auth.Claims, org string, amr []string) jwt.Claims {
+	if org != "" || len(amr) > 0 {
+		return &quarterdeckClaims{Claims: claims, Org: org, AuthMethods: amr}
+	}
+	return claims
+}
+
+// CreateAccessToken creates an access token for the claims. If the org is not zero it
+// is added to the token as the org claim so that the token is scoped to that
+// organization; if authentication methods are specified then they are added to the
+// token as the amr claim.
+func (tm *Issuer) CreateAccessToken(claims *auth.Claims, org ulid.ULID, amr ...string) (_ *jwt.Token, err error) {
 	now := time.Now()
 	sub := claims.RegisteredClaims.Subject
 
@@ -126,12 +135,27 @@ func (tm *Issuer) CreateAccessToken(claims *auth.Claims) (_ *jwt.Token, err erro
 		ExpiresAt: jwt.NewNumericDate(now.Add(tm.conf.AccessTokenTTL)),
 	}
 
-	return jwt.NewWithClaims(signingMethod, claims), nil
+	var orgID string
+	if !org.IsZero() {
+		orgID = org.String()
+	}
+
+	return jwt.NewWithClaims(signingMethod, newClaims(claims, orgID, amr)), nil
 }
 
 func (tm *Issuer) CreateRefreshToken(accessToken *jwt.Token) (_ *jwt.Token, err error) {
-	accessClaims, ok := accessToken.Claims.(*auth.Claims)
-	if !ok {
+	var (
+		accessClaims *auth.Claims
+		org          string
+		amr          []string
+	)
+
+	switch tc := accessToken.Claims.(type) {
+	case *auth.Claims:
+		accessClaims = tc
+	case *quarterdeckClaims:
+		accessClaims, org, amr = tc.Claims, tc.Org, tc.AuthMethods
+	default:
 		return nil, errors.ErrUnparsableClaims
 	}
 
@@ -150,14 +174,16 @@ func (tm *Issuer) CreateRefreshToken(accessToken *jwt.Token) (_ *jwt.Token, err
 		},
 	}
 
-	return jwt.NewWithClaims(signingMethod, claims), nil
+	// The organization and authentication methods are carried by the refresh token so
+	// that they can be added to the access token that is issued on reauthentication.
+	return jwt.NewWithClaims(signingMethod, newClaims(claims, org, amr)), nil
 }
 
 // CreateTokens creates and signs an access and refresh token in one step.
-func (tm *Issuer) CreateTokens(claims *auth.Claims) (signedAccessToken, signedRefreshToken string, err error) {
+func (tm *Issuer) CreateTokens(claims *auth.Claims, org ulid.ULID, amr ...string) (signedAccessToken, signedRefreshToken string, err error) {
 	var accessToken, refreshToken *jwt.Token
 
-	if accessToken, err = tm.CreateAccessToken(claims); err != nil {
+	if accessToken, err = tm.CreateAccessToken(claims, org, amr...); err != nil {
 		return "", "", fmt.Errorf("could not create access token: %w", err)
 	}
 
@@ -176,16 +202,35 @@ func (tm *Issuer) CreateTokens(claims *auth.Claims) (signedAccessToken, signedRe
 	return signedAccessToken, signedRefreshToken, nil
 }
 
+// Org returns the org claim of an access or refresh token issued by Quarterdeck or a
+// zero ULID if the token is not scoped to an organization. The signature is verified
+// but not the claims so that the org can be carried forward when tokens are reissued.
+func (tm *Issuer) Org(tks string) (_ ulid.ULID, err error) {
+	parser := jwt.NewParser(jwt.WithoutClaimsValidation())
+	claims := &quarterdeckClaims{Claims: &auth.Claims{}}
+	if _, err = parser.ParseWithClaims(tks, claims, tm.GetKey); err != nil {
+		return ulid.Zero, err
+	}
+
+	if claims.Org == "" {
+		return ulid.Zero, nil
+	}
+	return ulid.Parse(claims.Org)
+}
+
 // Keys returns the map of ulid to public key for use externally.
 func (tm *Issuer) Keys() (_ *JWKS, err error) {
-	if len(tm.publicKeys.Keys) == 0 {
+	jwks := tm.jwks()
+	if jwks.Len() == 0 {
 		return nil, errors.ErrNoSigningKeys
 	}
-	return tm.publicKeys, nil
+	return jwks, nil
 }
 
 // CurrentKey returns the ulid of the current key being used to sign tokens.
 func (tm *Issuer) CurrentKey() ulid.ULID {
+	tm.RLock()
+	defer tm.RUnlock()
 	return tm.keyID
 }
 
@@ -193,6 +238,9 @@ func (tm *Issuer) CurrentKey() ulid.ULID {
 // than the current key. The keyID must be a valid ULID and the ULID timestamp must
 // fall after the current key's timestamp.
 func (tm *Issuer) AddKey(keyID ulid.ULID, key SigningKey) (err error) {
+	tm.Lock()
+	defer tm.Unlock()
+
 	if err = tm.publicKeys.Add(keyID, key); err != nil {
 		return err
 	}
@@ -205,6 +253,56 @@ func (tm *Issuer) AddKey(keyID ulid.ULID, key SigningKey) (err error) {
 	return nil
 }
 
+// SetKeys replaces the keys of the issuer with the published keys in the key set and
+// signs new tokens with the specified key, which must be in the key set. If the key set
+// has not changed it is not replaced so that the JWKS cache headers are not modified.
+func (tm *Issuer) SetKeys(keyID ulid.ULID, key SigningKey, jwks *JWKS) error {
+	if len(jwks.Key(keyID.String())) == 0 {
+		return errors.ErrUnknownSigningKey
+	}
+
+	tm.Lock()
+	defer tm.Unlock()
+
+	if jwks.ETag() != tm.publicKeys.ETag() {
+		tm.publicKeys = jwks
+	}
+
+	tm.keyID = keyID
+	tm.key = key.PrivateKey()
+	return nil
+}
+
+// Returns the current key set of the issuer.
+func (tm *Issuer) jwks() *JWKS {
+	tm.RLock()
+	defer tm.RUnlock()
+	return tm.publicKeys
+}
+
+// SetDenylist configures the issuer to reject tokens whose jti is in the denylist.
+func (tm *Issuer) SetDenylist(denylist Denylist) {
+	tm.denylist = denylist
+}
+
+// Revoked checks the denylist (if configured) to determine if the token with the
+// specified claims has been revoked. If the denylist cannot be checked, an error is
+// returned so that callers can fail closed.
+func (tm *Issuer) Revoked(claims *auth.Claims) (_ bool, err error) {
+	if tm.denylist == nil {
+		return false, nil
+	}
+
+	var jti ulid.ULID
+	if jti, err = ulid.Parse(claims.ID); err != nil {
+		return false, errors.ErrUnparsableClaims
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), denylistTimeout)
+	defer cancel()
+	return tm.denylist.IsTokenRevoked(ctx, jti)
+}
+
 // Computes the refresh audience claim based on the issuer URL and a specific path to
 // better protect refresh tokens from being used in other contexts.
 func (tm *Issuer) RefreshAudience() string {
@@ -245,7 +343,7 @@ func (tm *Issuer) GetKey(token *jwt.Token) (key interface{}, err error) {
 	}
 
 	// Fetch the key from the list of managed keys
-	keys := tm.publicKeys.Key(keyID.String())
+	keys := tm.jwks().Key(keyID.String())
 	if len(keys) == 0 {
 		return nil, errors.ErrUnknownSigningKey
 	}
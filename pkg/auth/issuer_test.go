@@ -12,6 +12,7 @@ import (
 	"go.rtnl.ai/gimlet/auth"
 	. "go.rtnl.ai/quarterdeck/pkg/auth"
 	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/ulid"
 
 	"go.rtnl.ai/quarterdeck/pkg/config"
 )
@@ -40,11 +41,32 @@ func (s *TokenTestSuite) AuthConfig() config.AuthConfig {
 	}
 }
 
+// NewIssuer creates an issuer with the keys in the auth config added to it; outside of
+// tests the keys are imported into the database and loaded by the key manager.
+func (s *TokenTestSuite) NewIssuer(conf config.AuthConfig) (_ *Issuer, err error) {
+	var tm *Issuer
+	if tm, err = NewIssuer(conf); err != nil {
+		return nil, err
+	}
+
+	for kid, path := range conf.Keys {
+		var keypair SigningKey
+		if keypair, err = LoadKeys(path); err != nil {
+			return nil, err
+		}
+
+		if err = tm.AddKey(ulid.MustParse(kid), keypair); err != nil {
+			return nil, err
+		}
+	}
+	return tm, nil
+}
+
 func (s *TokenTestSuite) TestClaimsIssuer() {
 	require := s.Require()
 	conf := s.AuthConfig()
 
-	tm, err := NewIssuer(conf)
+	tm, err := s.NewIssuer(conf)
 	require.NoError(err, "could not initialize token manager")
 
 	s.Run("KeyLoading", func() {
@@ -62,7 +84,7 @@ func (s *TokenTestSuite) TestClaimsIssuer() {
 			Name:  "Kate Holland",
 		}
 
-		accessToken, err := tm.CreateAccessToken(creds)
+		accessToken, err := tm.CreateAccessToken(creds, ulid.Zero)
 		require.NoError(err, "could not create access token from claims")
 		require.IsType(&auth.Claims{}, accessToken.Claims)
 
@@ -123,19 +145,67 @@ func (s *TokenTestSuite) TestClaimsIssuer() {
 	})
 }
 
-func (s *TokenTestSuite) TestKeysGenerated() {
+func (s *TokenTestSuite) TestNoSigningKeys() {
 	require := s.Require()
 	conf := s.AuthConfig()
 	conf.Keys = nil
 
-	// Create the token manager
-	tm, err := NewIssuer(conf)
+	// Create the token manager without any keys
+	tm, err := s.NewIssuer(conf)
 	require.NoError(err, "could not initialize token manager")
 
-	// Check that the keys are generated
+	// Keys are not generated by the issuer, they are loaded by the key manager.
+	_, err = tm.Keys()
+	require.ErrorIs(err, errors.ErrNoSigningKeys)
+
+	token, err := tm.CreateAccessToken(&auth.Claims{Email: "kate@example.com"}, ulid.Zero)
+	require.NoError(err, "could not create access token from claims")
+
+	_, err = tm.Sign(token)
+	require.ErrorIs(err, errors.ErrNoSigningKeys)
+}
+
+func (s *TokenTestSuite) TestSetKeys() {
+	require := s.Require()
+	tm, err := s.NewIssuer(s.AuthConfig())
+	require.NoError(err, "could not initialize token manager")
+
+	etag := tm.ETag()
+	keypair, err := GenerateKeys()
+	require.NoError(err)
+
+	// The signing key must be in the key set.
+	keyID := ulid.Make()
+	err = tm.SetKeys(keyID, keypair, NewJWKS(24*time.Hour))
+	require.ErrorIs(err, errors.ErrUnknownSigningKey)
+
+	jwks := NewJWKS(24 * time.Hour)
+	require.NoError(jwks.Add(keyID, keypair))
+	require.NoError(tm.SetKeys(keyID, keypair, jwks))
+
+	// The keys of the issuer are replaced by the key set.
 	keys, err := tm.Keys()
-	require.NoError(err, "could not fetch jwks from issuer")
+	require.NoError(err)
 	require.Len(keys.Keys, 1)
+	require.Equal(keyID, tm.CurrentKey())
+	require.NotEqual(etag, tm.ETag())
+	require.Contains(tm.Directives(), "max-age=43200", "expected the max-age to be half of the publish window")
+
+	token, err := tm.CreateAccessToken(&auth.Claims{Email: "kate@example.com"}, ulid.Zero)
+	require.NoError(err)
+
+	tks, err := tm.Sign(token)
+	require.NoError(err)
+
+	_, err = tm.Verify(tks)
+	require.NoError(err, "could not verify token signed by the new key")
+
+	// An unchanged key set does not replace the current key set.
+	modified := tm.LastModified()
+	unchanged := NewJWKS(24 * time.Hour)
+	require.NoError(unchanged.Add(keyID, keypair))
+	require.NoError(tm.SetKeys(keyID, keypair, unchanged))
+	require.Equal(modified, tm.LastModified())
 }
 
 func (s *TokenTestSuite) TestValidTokens() {
@@ -143,7 +213,7 @@ func (s *TokenTestSuite) TestValidTokens() {
 	conf := s.AuthConfig()
 	conf.TokenOverlap = -1 * conf.AccessTokenTTL
 
-	tm, err := NewIssuer(conf)
+	tm, err := s.NewIssuer(conf)
 	require.NoError(err, "could not initialize token manager")
 
 	// Default creds
@@ -152,7 +222,7 @@ func (s *TokenTestSuite) TestValidTokens() {
 		Name:  "Kate Holland",
 	}
 
-	accessToken, refreshToken, err := tm.CreateTokens(creds)
+	accessToken, refreshToken, err := tm.CreateTokens(creds, ulid.Zero)
 	require.NoError(err)
 
 	_, err = tm.Verify(accessToken)
@@ -168,7 +238,7 @@ func (s *TokenTestSuite) TestInvalidTokens() {
 	require := s.Require()
 	conf := s.AuthConfig()
 
-	tm, err := NewIssuer(conf)
+	tm, err := s.NewIssuer(conf)
 	require.NoError(err, "could not initialize token manager")
 
 	// Manually create a token to validate with the token manager
@@ -342,19 +412,19 @@ func (s *TokenTestSuite) TestKeyRotation() {
 	conf := s.AuthConfig()
 	conf.Keys = testdata
 
-	oldTM, err := NewIssuer(conf)
+	oldTM, err := s.NewIssuer(conf)
 	require.NoError(err, "could not initialize old token manager")
 
 	// Create the "new" claims issuer with the new key
 	conf2 := s.AuthConfig()
-	newTM, err := NewIssuer(conf2)
+	newTM, err := s.NewIssuer(conf2)
 	require.NoError(err, "could not initialize new token manager")
 
 	// Create a valid token with the "old claims issuer"
 	token, err := oldTM.CreateAccessToken(&auth.Claims{
 		Email: "kate@example.com",
 		Name:  "Kate Holland",
-	})
+	}, ulid.Zero)
 	require.NoError(err)
 
 	tks, err := oldTM.Sign(token)
@@ -385,7 +455,7 @@ func (s *TokenTestSuite) TestParseExpiredToken() {
 		TokenOverlap:    -15 * time.Minute,
 	}
 
-	tm, err := NewIssuer(conf)
+	tm, err := s.NewIssuer(conf)
 	require.NoError(err, "could not initialize token manager")
 
 	// Default creds
@@ -394,7 +464,7 @@ func (s *TokenTestSuite) TestParseExpiredToken() {
 		Name:  "Kate Holland",
 	}
 
-	accessToken, err := tm.CreateAccessToken(creds)
+	accessToken, err := tm.CreateAccessToken(creds, ulid.Zero)
 	require.NoError(err, "could not create access token from claims")
 	require.IsType(&auth.Claims{}, accessToken.Claims)
 
@@ -439,7 +509,7 @@ func (s *TokenTestSuite) TestRefreshAudience() {
 		conf := s.AuthConfig()
 		conf.Issuer = "https://auth.rotational.app"
 
-		tm, err := NewIssuer(conf)
+		tm, err := s.NewIssuer(conf)
 		require.NoError(err, "could not initialize token manager")
 
 		audience := tm.RefreshAudience()
@@ -458,7 +528,7 @@ func (s *TokenTestSuite) TestRefreshAudience() {
 func (s *TokenTestSuite) TestGetKeyErrors() {
 	require := s.Require()
 	conf := s.AuthConfig()
-	tm, err := NewIssuer(conf)
+	tm, err := s.NewIssuer(conf)
 	require.NoError(err, "could not initialize token manager")
 
 	tests := []struct {
@@ -25,10 +25,32 @@ type JWKS struct {
 	jose.JSONWebKeySet
 	etag     string
 	modified time.Time
+	maxAge   time.Duration
+	swr      time.Duration
 	cc       string
 	ccinit   sync.Once
 }
 
+// NewJWKS creates an empty key set that clients are directed to refetch within the
+// publish window, so that clients that cache the key set have fetched a new signing key
+// before it begins signing tokens. Half of the window is used for the max-age and half
+// for the stale-while-revalidate directive so that a stale key set is never used for
+// longer than the window.
+func NewJWKS(publishWindow time.Duration) *JWKS {
+	return &JWKS{
+		JSONWebKeySet: jose.JSONWebKeySet{Keys: make([]jose.JSONWebKey, 0, 3)},
+		maxAge:        min(JWKSMaxAge, publishWindow/2),
+		swr:           min(JWKSStaleWhileRevalidate, publishWindow/2),
+	}
+}
+
+// Len returns the number of keys in the key set.
+func (j *JWKS) Len() int {
+	j.RLock()
+	defer j.RUnlock()
+	return len(j.Keys)
+}
+
 // Append a key to the JWKS. If a key with the same KeyID already exists, an error is returned.
 func (j *JWKS) Add(keyID ulid.ULID, key SigningKey) error {
 	j.Lock()
@@ -135,14 +157,19 @@ var _ cache.CacheController = (*JWKS)(nil)
 
 func (j *JWKS) Directives() string {
 	j.ccinit.Do(func() {
+		maxAge, swr := JWKSMaxAge, JWKSStaleWhileRevalidate
+		if j.maxAge > 0 {
+			maxAge, swr = j.maxAge, j.swr
+		}
+
 		builder := &httpcc.ResponseBuilder{
-			StaleWhileRevalidate: uint64(JWKSStaleWhileRevalidate.Seconds()),
+			StaleWhileRevalidate: uint64(swr.Seconds()),
 			MustRevalidate:       true,
 			ProxyRevalidate:      true,
 		}
 
-		builder.SetMaxAge(uint64(JWKSMaxAge.Seconds()))
-		builder.SetSMaxAge(uint64(JWKSMaxAge.Seconds()))
+		builder.SetMaxAge(uint64(maxAge.Seconds()))
+		builder.SetSMaxAge(uint64(maxAge.Seconds()))
 		j.cc = builder.String()
 	})
 	return j.cc
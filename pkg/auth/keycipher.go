@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/ulid"
+)
+
+// KeyCipher seals the private signing keys that are stored in the database with the
+// key encryption key using AES-256-GCM. The key ID is authenticated with the sealed
+// key so that a sealed private key cannot be swapped onto another key ID. Other secrets
+// stored in the database, such as TOTP secrets, are sealed in the same way with the ID
+// of the record that they belong to.
+type KeyCipher struct {
+	aead cipher.AEAD
+}
+
+// NewKeyCipher creates a cipher from a 32 byte key encryption key.
+func NewKeyCipher(kek []byte) (_ *KeyCipher, err error) {
+	if len(kek) == 0 {
+		return nil, errors.ErrNoKeyEncryptionKey
+	}
+
+	var block cipher.Block
+	if block, err = aes.NewCipher(kek); err != nil {
+		return nil, errors.Fmt("invalid key encryption key: %w", err)
+	}
+
+	c := &KeyCipher{}
+	if c.aead, err = cipher.NewGCM(block); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Seal returns the PKIX encoded public key and the sealed PKCS8 encoded private key of
+// the signing key so that they can be stored in the database.
+func (c *KeyCipher) Seal(keyID ulid.ULID, key SigningKey) (public, sealed []byte, err error) {
+	if public, err = x509.MarshalPKIXPublicKey(key.PublicKey()); err != nil {
+		return nil, nil, errors.Fmt("could not marshal public key: %w", err)
+	}
+
+	var private []byte
+	if private, err = x509.MarshalPKCS8PrivateKey(key.PrivateKey()); err != nil {
+		return nil, nil, errors.Fmt("could not marshal private key: %w", err)
+	}
+
+	nonce := make([]byte, c.aead.NonceSize(), c.aead.NonceSize()+len(private)+c.aead.Overhead())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, nil, err
+	}
+
+	sealed = c.aead.Seal(nonce, nonce, private, keyID[:])
+	return public, sealed, nil
+}
+
+// Open returns the signing key from the public key and sealed private key stored in the
+// database. If sealed is empty, e.g. because the key is only used to verify tokens, the
+// signing key only has a public key.
+func (c *KeyCipher) Open(keyID ulid.ULID, public, sealed []byte) (_ SigningKey, err error) {
+	var pub any
+	if pub, err = x509.ParsePKIXPublicKey(public); err != nil {
+		return nil, errors.Fmt("could not parse public key %s: %w", keyID, err)
+	}
+
+	k := &keys{}
+	var ok bool
+	if k.public, ok = pub.(ed25519.PublicKey); !ok {
+		return nil, errors.Fmt("public key %s is not an ed25519 public key", keyID)
+	}
+
+	if len(sealed) == 0 {
+		return k, nil
+	}
+
+	if len(sealed) < c.aead.NonceSize() {
+		return nil, errors.ErrDecryptSigningKey
+	}
+
+	var private []byte
+	nonce, ciphertext := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	if private, err = c.aead.Open(nil, nonce, ciphertext, keyID[:]); err != nil {
+		return nil, errors.ErrDecryptSigningKey
+	}
+
+	var prv any
+	if prv, err = x509.ParsePKCS8PrivateKey(private); err != nil {
+		return nil, errors.Fmt("could not parse private key %s: %w", keyID, err)
+	}
+
+	if k.private, ok = prv.(ed25519.PrivateKey); !ok {
+		return nil, errors.Fmt("private key %s is not an ed25519 private key", keyID)
+	}
+	return k, nil
+}
+
+// SealSecret encrypts a secret that must be stored in the database so that it can be
+// used later (e.g. a user's TOTP secret) and returns it base64 encoded. The ID of the
+// record the secret belongs to is authenticated with the secret.
+func (c *KeyCipher) SealSecret(id ulid.ULID, secret string) (_ string, err error) {
+	nonce := make([]byte, c.aead.NonceSize(), c.aead.NonceSize()+len(secret)+c.aead.Overhead())
+	if _, err = rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := c.aead.Seal(nonce, nonce, []byte(secret), id[:])
+	return base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// OpenSecret decrypts a secret sealed by SealSecret for the record with the same ID.
+func (c *KeyCipher) OpenSecret(id ulid.ULID, sealed string) (_ string, err error) {
+	var data []byte
+	if data, err = base64.RawStdEncoding.DecodeString(sealed); err != nil || len(data) < c.aead.NonceSize() {
+		return "", errors.ErrDecryptSecret
+	}
+
+	var secret []byte
+	nonce, ciphertext := data[:c.aead.NonceSize()], data[c.aead.NonceSize():]
+	if secret, err = c.aead.Open(nil, nonce, ciphertext, id[:]); err != nil {
+		return "", errors.ErrDecryptSecret
+	}
+	return string(secret), nil
+}
@@ -0,0 +1,98 @@
+package auth_test
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	. "go.rtnl.ai/quarterdeck/pkg/auth"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/ulid"
+)
+
+func TestKeyCipher(t *testing.T) {
+	kek := make([]byte, 32)
+	rand.Read(kek)
+
+	cipher, err := NewKeyCipher(kek)
+	require.NoError(t, err)
+
+	keypair, err := GenerateKeys()
+	require.NoError(t, err)
+
+	keyID := ulid.Make()
+	public, sealed, err := cipher.Seal(keyID, keypair)
+	require.NoError(t, err)
+	require.NotEmpty(t, public)
+	require.NotEmpty(t, sealed)
+
+	t.Run("Open", func(t *testing.T) {
+		cmpt, err := cipher.Open(keyID, public, sealed)
+		require.NoError(t, err)
+		require.Equal(t, keypair.PublicKey(), cmpt.PublicKey())
+		require.Equal(t, keypair.PrivateKey(), cmpt.PrivateKey())
+	})
+
+	t.Run("PublicOnly", func(t *testing.T) {
+		cmpt, err := cipher.Open(keyID, public, nil)
+		require.NoError(t, err)
+		require.Equal(t, keypair.PublicKey(), cmpt.PublicKey())
+		require.Nil(t, cmpt.PrivateKey())
+	})
+
+	t.Run("WrongKeyID", func(t *testing.T) {
+		_, err := cipher.Open(ulid.Make(), public, sealed)
+		require.ErrorIs(t, err, errors.ErrDecryptSigningKey)
+	})
+
+	t.Run("WrongKEK", func(t *testing.T) {
+		other := make([]byte, 32)
+		rand.Read(other)
+
+		wrong, err := NewKeyCipher(other)
+		require.NoError(t, err)
+
+		_, err = wrong.Open(keyID, public, sealed)
+		require.ErrorIs(t, err, errors.ErrDecryptSigningKey)
+	})
+
+	t.Run("Truncated", func(t *testing.T) {
+		_, err := cipher.Open(keyID, public, sealed[:4])
+		require.ErrorIs(t, err, errors.ErrDecryptSigningKey)
+	})
+
+	t.Run("NoKEK", func(t *testing.T) {
+		_, err := NewKeyCipher(nil)
+		require.ErrorIs(t, err, errors.ErrNoKeyEncryptionKey)
+
+		_, err = NewKeyCipher([]byte("too short"))
+		require.Error(t, err)
+	})
+}
+
+func TestSecretCipher(t *testing.T) {
+	kek := make([]byte, 32)
+	rand.Read(kek)
+
+	cipher, err := NewKeyCipher(kek)
+	require.NoError(t, err)
+
+	userID := ulid.Make()
+	sealed, err := cipher.SealSecret(userID, "JBSWY3DPEHPK3PXP")
+	require.NoError(t, err)
+	require.NotContains(t, sealed, "JBSWY3DPEHPK3PXP")
+
+	secret, err := cipher.OpenSecret(userID, sealed)
+	require.NoError(t, err)
+	require.Equal(t, "JBSWY3DPEHPK3PXP", secret)
+
+	other, err := cipher.SealSecret(userID, "JBSWY3DPEHPK3PXP")
+	require.NoError(t, err)
+	require.NotEqual(t, sealed, other, "each secret should be sealed with a unique nonce")
+
+	_, err = cipher.OpenSecret(ulid.Make(), sealed)
+	require.ErrorIs(t, err, errors.ErrDecryptSecret, "the secret must not be opened for another record")
+
+	_, err = cipher.OpenSecret(userID, "JBSWY3DPEHPK3PXP")
+	require.ErrorIs(t, err, errors.ErrDecryptSecret, "plaintext secrets must not be accepted")
+}
@@ -0,0 +1,222 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/base64"
+	"image/png"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/hotp"
+	"github.com/pquerna/otp/totp"
+	"go.rtnl.ai/gimlet/auth"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/ulid"
+)
+
+// Authentication method reference values for the amr claim so that downstream
+// applications can require a second factor for sensitive actions.
+// See: https://datatracker.ietf.org/doc/html/rfc8176#section-2
+const (
+	AMRPassword    = "pwd" // Password-based authentication
+	AMROTP         = "otp" // One-time password (e.g. a TOTP authenticator app)
+	AMRMFA         = "mfa" // Multiple-factor authentication
+	AMRHardwareKey = "hwk" // Proof-of-possession of a hardware-secured key (e.g. a passkey)
+)
+
+const (
+	mfaPath             = "/v1/login/mfa"
+	mfaChallengeTTL     = 5 * time.Minute
+	totpPeriod          = 30
+	totpSkew            = 1
+	totpQRCodeSize      = 256
+	recoveryCodeCount   = 10
+	recoveryCodeLength  = 10
+	recoveryCodeDivider = "-"
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+//===========================================================================
+// Authentication Methods
+//===========================================================================
+
+// AuthMethods returns the amr claim of an access or refresh token issued by
+// Quarterdeck. The signature is verified but not the claims so that the methods can
+// be carried forward when tokens are reissued during reauthentication.
+func (tm *Issuer) AuthMethods(tks string) (amr []string, err error) {
+	parser := jwt.NewParser(jwt.WithoutClaimsValidation())
+	claims := &quarterdeckClaims{Claims: &auth.Claims{}}
+	if _, err = parser.ParseWithClaims(tks, claims, tm.GetKey); err != nil {
+		return nil, err
+	}
+	return claims.AuthMethods, nil
+}
+
+//===========================================================================
+// MFA Challenge Tokens
+//===========================================================================
+
+// MFAChallengeClaims are issued after a user's password has been verified when the
+// user has a second factor enabled. The challenge must be presented along with a TOTP
+// or recovery code to complete the login; it carries the original login request
+// parameters so they cannot be changed between the two steps.
+type MFAChallengeClaims struct {
+	jwt.RegisteredClaims
+	ClientID string `json:"client_id,omitempty"`
+	Nonce    string `json:"nonce,omitempty"`
+	Next     string `json:"next,omitempty"`
+}
+
+// CreateMFAChallenge creates a short-lived signed token for the second step of a login.
+func (tm *Issuer) CreateMFAChallenge(userID ulid.ULID, clientID, nonce, next string) (string, error) {
+	now := time.Now()
+	claims := &auth.Claims{}
+	claims.SetSubjectID(auth.SubjectUser, userID)
+
+	challenge := &MFAChallengeClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        secureULID().String(),
+			Subject:   claims.Subject,
+			Audience:  jwt.ClaimStrings{tm.MFAAudience()},
+			Issuer:    tm.conf.Issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(mfaChallengeTTL)),
+		},
+		ClientID: clientID,
+		Nonce:    nonce,
+		Next:     next,
+	}
+
+	return tm.Sign(jwt.NewWithClaims(signingMethod, challenge))
+}
+
+// VerifyMFAChallenge verifies the signature, audience, and expiration of an MFA
+// challenge and returns the ID of the user who must complete the challenge.
+func (tm *Issuer) VerifyMFAChallenge(tks string) (claims *MFAChallengeClaims, userID ulid.ULID, err error) {
+	parser := jwt.NewParser(
+		jwt.WithValidMethods([]string{signingMethod.Alg()}),
+		jwt.WithAudience(tm.MFAAudience()),
+		jwt.WithIssuer(tm.conf.Issuer),
+		jwt.WithExpirationRequired(),
+	)
+
+	claims = &MFAChallengeClaims{}
+	if _, err = parser.ParseWithClaims(tks, claims, tm.GetKey); err != nil {
+		return nil, ulid.Zero, errors.ErrMFAChallengeFailed
+	}
+
+	subject := &auth.Claims{}
+	subject.Subject = claims.Subject
+
+	var sub auth.SubjectType
+	if sub, userID, err = subject.SubjectID(); err != nil || sub != auth.SubjectUser {
+		return nil, ulid.Zero, errors.ErrMFAChallengeFailed
+	}
+
+	return claims, userID, nil
+}
+
+// MFAAudience is the audience of MFA challenge tokens, which ensures that they cannot
+// be used as access tokens and can only be used to complete a login.
+func (tm *Issuer) MFAAudience() string {
+	aud, err := url.Parse(tm.conf.Issuer)
+	if err != nil {
+		// The issuer URL should have been validated in the config.
+		panic("could not parse issuer URL: " + err.Error())
+	}
+	return aud.ResolveReference(&url.URL{Path: mfaPath}).String()
+}
+
+//===========================================================================
+// TOTP
+//===========================================================================
+
+// GenerateTOTP creates a new random TOTP secret for the specified account (usually
+// the user's email address); the issuer is displayed in the user's authenticator app.
+func GenerateTOTP(issuer, account string) (*otp.Key, error) {
+	return totp.Generate(totp.GenerateOpts{
+		Issuer:      issuer,
+		AccountName: account,
+		Period:      totpPeriod,
+	})
+}
+
+// TOTPQRCode returns the provisioning URL of the TOTP key as a PNG QR code data URI
+// that can be rendered directly in an img tag and scanned by an authenticator app.
+func TOTPQRCode(key *otp.Key) (_ string, err error) {
+	img, err := key.Image(totpQRCodeSize, totpQRCodeSize)
+	if err != nil {
+		return "", err
+	}
+
+	buf := &bytes.Buffer{}
+	if err = png.Encode(buf, img); err != nil {
+		return "", err
+	}
+
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// ValidateTOTP checks the code against the secret allowing for one period of clock
+// skew in either direction and returns the time step that the code was generated for.
+// Codes for steps at or before the last step accepted for the user are rejected so that
+// each code can only be used once; the returned step must be stored as the new last step.
+func ValidateTOTP(code, secret string, lastStep int64) (step int64, valid bool) {
+	code = strings.TrimSpace(code)
+	current := time.Now().UTC().Unix() / totpPeriod
+
+	for step = current - totpSkew; step <= current+totpSkew; step++ {
+		if step <= lastStep {
+			continue
+		}
+
+		if valid, _ = hotp.ValidateCustom(code, uint64(step), secret, hotp.ValidateOpts{
+			Digits:    otp.DigitsSix,
+			Algorithm: otp.AlgorithmSHA1,
+		}); valid {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+//===========================================================================
+// Recovery Codes
+//===========================================================================
+
+// GenerateRecoveryCodes creates a set of one-time recovery codes to show to the user
+// and the hashes of those codes to store; the codes themselves must not be stored.
+func GenerateRecoveryCodes() (codes, hashes []string, err error) {
+	codes = make([]string, 0, recoveryCodeCount)
+	hashes = make([]string, 0, recoveryCodeCount)
+
+	for range recoveryCodeCount {
+		data := make([]byte, recoveryCodeLength)
+		if _, err = rand.Read(data); err != nil {
+			return nil, nil, err
+		}
+
+		code := strings.ToLower(recoveryCodeEncoding.EncodeToString(data))[:recoveryCodeLength]
+		code = code[:recoveryCodeLength/2] + recoveryCodeDivider + code[recoveryCodeLength/2:]
+
+		codes = append(codes, code)
+		hashes = append(hashes, HashRecoveryCode(code))
+	}
+
+	return codes, hashes, nil
+}
+
+// HashRecoveryCode normalizes a recovery code entered by the user (ignoring case,
+// whitespace, and dividers) and returns its hash for comparison with stored hashes.
+func HashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.Join(strings.Fields(code), ""))
+	code = strings.ReplaceAll(code, recoveryCodeDivider, "")
+	return HashOpaqueToken(code)
+}
@@ -0,0 +1,189 @@
+package auth_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/gimlet/auth"
+	. "go.rtnl.ai/quarterdeck/pkg/auth"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/ulid"
+)
+
+func (s *TokenTestSuite) TestAuthMethods() {
+	require := s.Require()
+	tm, err := s.NewIssuer(s.AuthConfig())
+	require.NoError(err, "could not initialize token manager")
+
+	s.Run("MFA", func() {
+		creds := &auth.Claims{Email: "kate@example.com"}
+		creds.SetSubjectID(auth.SubjectUser, ulid.Make())
+
+		accessToken, refreshToken, err := tm.CreateTokens(creds, ulid.Zero, AMRPassword, AMROTP, AMRMFA)
+		require.NoError(err)
+
+		claims, err := tm.Verify(accessToken)
+		require.NoError(err, "the amr claim should not interfere with verification")
+		require.Equal("kate@example.com", claims.Email)
+
+		amr, err := tm.AuthMethods(accessToken)
+		require.NoError(err)
+		require.Equal([]string{AMRPassword, AMROTP, AMRMFA}, amr)
+
+		amr, err = tm.AuthMethods(refreshToken)
+		require.NoError(err)
+		require.Equal([]string{AMRPassword, AMROTP, AMRMFA}, amr, "the refresh token should carry the amr claim")
+	})
+
+	s.Run("NoMethods", func() {
+		creds := &auth.Claims{Email: "kate@example.com"}
+		creds.SetSubjectID(auth.SubjectUser, ulid.Make())
+
+		accessToken, _, err := tm.CreateTokens(creds, ulid.Zero)
+		require.NoError(err)
+
+		amr, err := tm.AuthMethods(accessToken)
+		require.NoError(err)
+		require.Empty(amr)
+	})
+}
+
+func (s *TokenTestSuite) TestOrgClaim() {
+	require := s.Require()
+	tm, err := s.NewIssuer(s.AuthConfig())
+	require.NoError(err, "could not initialize token manager")
+
+	s.Run("Org", func() {
+		orgID := ulid.Make()
+		creds := &auth.Claims{Email: "kate@example.com"}
+		creds.SetSubjectID(auth.SubjectUser, ulid.Make())
+
+		accessToken, refreshToken, err := tm.CreateTokens(creds, orgID, AMRPassword)
+		require.NoError(err)
+
+		_, err = tm.Verify(accessToken)
+		require.NoError(err, "the org claim should not interfere with verification")
+
+		org, err := tm.Org(accessToken)
+		require.NoError(err)
+		require.Equal(orgID, org)
+
+		org, err = tm.Org(refreshToken)
+		require.NoError(err)
+		require.Equal(orgID, org, "the refresh token should carry the org claim")
+
+		amr, err := tm.AuthMethods(refreshToken)
+		require.NoError(err)
+		require.Equal([]string{AMRPassword}, amr, "the org claim should not replace the amr claim")
+	})
+
+	s.Run("NoOrg", func() {
+		creds := &auth.Claims{Email: "kate@example.com"}
+		creds.SetSubjectID(auth.SubjectUser, ulid.Make())
+
+		accessToken, _, err := tm.CreateTokens(creds, ulid.Zero)
+		require.NoError(err)
+
+		org, err := tm.Org(accessToken)
+		require.NoError(err)
+		require.True(org.IsZero())
+	})
+}
+
+func (s *TokenTestSuite) TestMFAChallenge() {
+	require := s.Require()
+	tm, err := s.NewIssuer(s.AuthConfig())
+	require.NoError(err, "could not initialize token manager")
+
+	userID := ulid.Make()
+	tks, err := tm.CreateMFAChallenge(userID, "cid", "nonce", "/dashboard")
+	require.NoError(err)
+
+	claims, subject, err := tm.VerifyMFAChallenge(tks)
+	require.NoError(err)
+	require.Equal(userID, subject)
+	require.Equal("cid", claims.ClientID)
+	require.Equal("nonce", claims.Nonce)
+	require.Equal("/dashboard", claims.Next)
+
+	s.Run("NotAnAccessToken", func() {
+		_, err := tm.Verify(tks)
+		require.Error(err, "the challenge should not be accepted as an access token")
+	})
+
+	s.Run("AccessTokenIsNotChallenge", func() {
+		creds := &auth.Claims{}
+		creds.SetSubjectID(auth.SubjectUser, userID)
+		accessToken, _, err := tm.CreateTokens(creds, ulid.Zero)
+		require.NoError(err)
+
+		_, _, err = tm.VerifyMFAChallenge(accessToken)
+		require.ErrorIs(err, errors.ErrMFAChallengeFailed)
+	})
+}
+
+func TestTOTP(t *testing.T) {
+	key, err := GenerateTOTP("Quarterdeck", "kate@example.com")
+	require.NoError(t, err)
+	require.Equal(t, "Quarterdeck", key.Issuer())
+	require.Equal(t, "kate@example.com", key.AccountName())
+
+	now := time.Now()
+	code, err := totp.GenerateCode(key.Secret(), now)
+	require.NoError(t, err)
+
+	step, valid := ValidateTOTP(code, key.Secret(), 0)
+	require.True(t, valid)
+	require.Equal(t, now.Unix()/30, step)
+
+	_, valid = ValidateTOTP(" "+code+" ", key.Secret(), 0)
+	require.True(t, valid, "whitespace should be ignored")
+
+	_, valid = ValidateTOTP(code, key.Secret(), step)
+	require.False(t, valid, "a code must not be accepted twice")
+
+	previous, err := totp.GenerateCode(key.Secret(), now.Add(-30*time.Second))
+	require.NoError(t, err)
+
+	prevStep, valid := ValidateTOTP(previous, key.Secret(), 0)
+	require.True(t, valid, "one period of skew should be allowed")
+	require.Equal(t, step-1, prevStep)
+
+	_, valid = ValidateTOTP(previous, key.Secret(), step)
+	require.False(t, valid, "codes before the last accepted code must be rejected")
+
+	stale, err := totp.GenerateCode(key.Secret(), now.Add(-5*time.Minute))
+	require.NoError(t, err)
+
+	_, valid = ValidateTOTP(stale, key.Secret(), 0)
+	require.False(t, valid)
+
+	_, valid = ValidateTOTP("", key.Secret(), 0)
+	require.False(t, valid)
+
+	qrcode, err := TOTPQRCode(key)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(qrcode, "data:image/png;base64,"))
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, hashes, err := GenerateRecoveryCodes()
+	require.NoError(t, err)
+	require.Len(t, codes, 10)
+	require.Len(t, hashes, 10)
+
+	seen := make(map[string]struct{})
+	for i, code := range codes {
+		require.Regexp(t, `^[a-z2-7]{5}-[a-z2-7]{5}$`, code)
+		require.Equal(t, hashes[i], HashRecoveryCode(code))
+		require.Equal(t, hashes[i], HashRecoveryCode(strings.ToUpper(strings.ReplaceAll(code, "-", " "))), "case, whitespace and dividers should be ignored")
+		require.NotContains(t, hashes, code, "codes must not be stored in plain text")
+
+		_, duplicate := seen[code]
+		require.False(t, duplicate, "recovery codes should be unique")
+		seen[code] = struct{}{}
+	}
+}
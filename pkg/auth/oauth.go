@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"regexp"
+
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+)
+
+// PKCE code challenge methods as defined by RFC 7636.
+const (
+	CodeChallengePlain = "plain"
+	CodeChallengeS256  = "S256"
+)
+
+// Length in bytes of the random data used to generate authorization codes.
+const authorizationCodeLength = 32
+
+// RFC 7636 Section 4.1: the code verifier is a high-entropy random string using the
+// unreserved characters [A-Z] / [a-z] / [0-9] / "-" / "." / "_" / "~" with a minimum
+// length of 43 characters and a maximum length of 128 characters.
+var codeVerifierRE = regexp.MustCompile(`^[A-Za-z0-9\-._~]{43,128}$`)
+
+// AuthorizationCode generates a new random, URL-safe authorization code that can be
+// delivered to an OAuth2 client. Only the hash of the code should be stored.
+func AuthorizationCode() (string, error) {
+	code := make([]byte, authorizationCodeLength)
+	if _, err := rand.Read(code); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(code), nil
+}
+
+// HashOpaqueToken returns the hex encoded SHA-256 hash of an opaque token (such as an
+// authorization code) so that the token can be looked up in the database without the
+// database storing the token itself.
+func HashOpaqueToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// ValidCodeChallengeMethod returns true if the method is a supported PKCE method.
+func ValidCodeChallengeMethod(method string) bool {
+	return method == CodeChallengeS256 || method == CodeChallengePlain
+}
+
+// VerifyCodeChallenge checks that the PKCE code verifier submitted to the token
+// endpoint matches the code challenge that was submitted to the authorization
+// endpoint using the specified code challenge method.
+func VerifyCodeChallenge(method, challenge, verifier string) (err error) {
+	if !codeVerifierRE.MatchString(verifier) {
+		return errors.ErrInvalidCodeVerifier
+	}
+
+	var computed string
+	switch method {
+	case CodeChallengeS256:
+		sum := sha256.Sum256([]byte(verifier))
+		computed = base64.RawURLEncoding.EncodeToString(sum[:])
+	case CodeChallengePlain, "":
+		computed = verifier
+	default:
+		return errors.ErrUnknownChallengeMethod
+	}
+
+	if subtle.ConstantTimeCompare([]byte(computed), []byte(challenge)) != 1 {
+		return errors.ErrInvalidCodeVerifier
+	}
+	return nil
+}
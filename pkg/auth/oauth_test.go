@@ -0,0 +1,57 @@
+package auth_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/quarterdeck/pkg/auth"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+)
+
+func TestAuthorizationCode(t *testing.T) {
+	code, err := auth.AuthorizationCode()
+	require.NoError(t, err)
+	require.Len(t, code, 43, "expected 32 bytes of base64 raw url encoded data")
+
+	other, err := auth.AuthorizationCode()
+	require.NoError(t, err)
+	require.NotEqual(t, code, other, "expected authorization codes to be random")
+
+	require.Len(t, auth.HashOpaqueToken(code), 64)
+	require.Equal(t, auth.HashOpaqueToken(code), auth.HashOpaqueToken(code))
+	require.NotEqual(t, auth.HashOpaqueToken(code), auth.HashOpaqueToken(other))
+}
+
+func TestVerifyCodeChallenge(t *testing.T) {
+	// Test vectors from RFC 7636 Appendix B
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gXk-kEjXk"
+	challenge := "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+
+	testCases := []struct {
+		method    string
+		challenge string
+		verifier  string
+		err       error
+	}{
+		{auth.CodeChallengeS256, challenge, verifier, nil},
+		{auth.CodeChallengePlain, verifier, verifier, nil},
+		{"", verifier, verifier, nil},
+		{auth.CodeChallengeS256, verifier, verifier, errors.ErrInvalidCodeVerifier},
+		{auth.CodeChallengePlain, challenge, verifier, errors.ErrInvalidCodeVerifier},
+		{auth.CodeChallengeS256, challenge, "", errors.ErrInvalidCodeVerifier},
+		{auth.CodeChallengeS256, challenge, "tooshort", errors.ErrInvalidCodeVerifier},
+		{auth.CodeChallengeS256, challenge, strings.Repeat("a", 129), errors.ErrInvalidCodeVerifier},
+		{auth.CodeChallengeS256, challenge, verifier + "!", errors.ErrInvalidCodeVerifier},
+		{"S512", challenge, verifier, errors.ErrUnknownChallengeMethod},
+	}
+
+	for i, tc := range testCases {
+		err := auth.VerifyCodeChallenge(tc.method, tc.challenge, tc.verifier)
+		if tc.err == nil {
+			require.NoError(t, err, "test case %d failed", i)
+		} else {
+			require.ErrorIs(t, err, tc.err, "test case %d failed", i)
+		}
+	}
+}
@@ -3,78 +3,136 @@ package permissions
 import (
 	"encoding/json"
 	"fmt"
+	"regexp"
 	"strings"
 )
 
-type Permission uint8
+// Permission is the title of a permission that authorizes users and api keys to
+// perform specific actions, e.g. users:view. Permissions are data-driven: the built-in
+// Quarterdeck permissions are defined below, but applications and OIDC clients can
+// create their own namespaced permissions (e.g. reports:export) at runtime, which are
+// issued in Quarterdeck tokens like any other permission.
+type Permission string
 
 type Permissions []Permission
 
-// These permissions are used to authorize access to various Quarterdeck resources and
-// their names should match the permissions defined in the Quarterdeck database.
+// Quarterdeck is the namespace of the built-in permissions; it is reserved and cannot
+// be used by applications or OIDC clients to create custom permissions.
+const Quarterdeck = "quarterdeck"
+
+// These permissions are used to authorize access to various Quarterdeck resources.
+// They are created as protected records when the server starts so they cannot be
+// deleted or renamed.
 //
-// NOTE: if adding or removing permissions from this list, ensure they are updated in
-// a database migration as well. Also ensure the AllPermissions array is also updated.
+// NOTE: if adding or removing permissions from this list, ensure that the Builtin
+// array and the descriptions are also updated.
 const (
-	Unknown         Permission = iota
-	UsersView                  // View users and their details (including invited users)
-	UsersManage                // Invite new users, update user details, reset passwords, and delete users
-	APIKeysView                // View the API keys created in the system
-	APIKeysManage              // Create and update API keys and their permissions
-	APIKeysRevoke              // Revoke API keys
-	RolesView                  // View the available roles in the system
-	PermissionsView            // View the available permissions in the system
-	ConfigView                 // View the configuration settings
-	ConfigManage               // Update the configuration settings
+	Unknown           Permission = ""
+	UsersView         Permission = "users:view"
+	UsersManage       Permission = "users:manage"
+	APIKeysView       Permission = "apikeys:view"
+	APIKeysManage     Permission = "apikeys:manage"
+	APIKeysRevoke     Permission = "apikeys:revoke"
+	RolesView         Permission = "roles:view"
+	PermissionsView   Permission = "permissions:view"
+	ConfigView        Permission = "config:view"
+	ConfigManage      Permission = "config:manage"
+	OIDCClientsView   Permission = "oidcclients:view"
+	OIDCClientsManage Permission = "oidcclients:manage"
 )
 
-var AllPermissions = [10]Permission{
+var Builtin = [11]Permission{
 	UsersView, UsersManage,
 	APIKeysView, APIKeysManage, APIKeysRevoke,
 	RolesView,
 	PermissionsView,
 	ConfigView, ConfigManage,
+	OIDCClientsView, OIDCClientsManage,
 }
 
-var names = [11]string{
-	"unknown",
-	"users:view", "users:manage",
-	"apikeys:view", "apikeys:manage", "apikeys:revoke",
-	"roles:view",
-	"permissions:view",
-	"config:view", "config:manage",
+var descriptions = map[Permission]string{
+	UsersView:         "View users and their details (including invited users)",
+	UsersManage:       "Invite new users, update user details, reset passwords, and delete users",
+	APIKeysView:       "View the API keys created in the system",
+	APIKeysManage:     "Create and update API keys and their permissions",
+	APIKeysRevoke:     "Revoke API keys",
+	RolesView:         "View the available roles in the system",
+	PermissionsView:   "View the available permissions in the system",
+	ConfigView:        "View the configuration settings",
+	ConfigManage:      "Update the configuration settings",
+	OIDCClientsView:   "View the OIDC clients registered in the system",
+	OIDCClientsManage: "Register, update, and delete OIDC clients",
 }
 
+// Permissions are lowercase resource:action pairs; the action may itself be namespaced
+// with additional colons (e.g. reports:export:csv).
+var format = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]*(:[a-z0-9][a-z0-9_.-]*)+$`)
+
+// Namespaces identify the application or OIDC client that owns a custom permission.
+var namespace = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// Parse a permission from a string, normalizing it and ensuring it is well formed.
+// Any well formed permission can be parsed, not just the built-in permissions.
 func Parse(p any) (Permission, error) {
 	switch v := p.(type) {
-	case uint8:
-		return Permission(v), nil
-	case int64:
-		return Permission(v), nil
 	case string:
-		v = strings.ToLower(strings.TrimSpace(v))
-		for i, name := range names {
-			if v == name {
-				return Permission(i), nil
-			}
+		perm := Permission(strings.ToLower(strings.TrimSpace(v)))
+		if err := perm.Validate(); err != nil {
+			return Unknown, err
 		}
-		return Unknown, fmt.Errorf("%q is not a valid permission name", v)
+		return perm, nil
 	case Permission:
-		return v, nil
+		return Parse(string(v))
 	default:
 		return Unknown, fmt.Errorf("cannot parse type %T as a Permission", p)
 	}
 }
 
-func (p Permission) String() string {
-	if idx := int(p); idx >= 0 && idx < len(names) {
-		return names[idx]
+// Validate that the permission is a resource:action pair.
+func (p Permission) Validate() error {
+	if !format.MatchString(string(p)) {
+		return fmt.Errorf("%q is not a valid permission name: must be a lowercase resource:action pair", string(p))
+	}
+	return nil
+}
+
+// ValidateNamespace checks that the namespace can be used for custom permissions.
+func ValidateNamespace(ns string) error {
+	if strings.EqualFold(ns, Quarterdeck) {
+		return fmt.Errorf("the %q namespace is reserved for built-in permissions", Quarterdeck)
+	}
+
+	if !namespace.MatchString(ns) {
+		return fmt.Errorf("%q is not a valid namespace: must be an application name or oidc client id", ns)
 	}
-	return names[0]
+	return nil
+}
+
+// InNamespace returns true if the permission belongs to the namespace. Built-in
+// permissions only belong to the quarterdeck namespace; custom permissions must be
+// prefixed by their namespace, e.g. reports:export belongs to the reports namespace.
+func (p Permission) InNamespace(ns string) bool {
+	if p.IsBuiltin() {
+		return strings.EqualFold(ns, Quarterdeck)
+	}
+
+	resource, _, _ := strings.Cut(string(p), ":")
+	return !strings.EqualFold(ns, Quarterdeck) && strings.EqualFold(resource, ns)
 }
 
-func (p Permission) MarshalJSON() ([]byte, error) {
-	return json.Marshal(p.String())
+// IsBuiltin returns true if the permission is one of the built-in Quarterdeck permissions.
+func (p Permission) IsBuiltin() bool {
+	_, ok := descriptions[p]
+	return ok
+}
+
+// Description returns the description of a built-in permission or an empty string.
+func (p Permission) Description() string {
+	return descriptions[p]
+}
+
+func (p Permission) String() string {
+	return string(p)
 }
 
 func (p *Permission) UnmarshalJSON(data []byte) (err error) {
@@ -0,0 +1,82 @@
+package permissions_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/quarterdeck/pkg/auth/permissions"
+)
+
+func TestParse(t *testing.T) {
+	validCases := []struct {
+		in       any
+		expected permissions.Permission
+	}{
+		{"users:view", permissions.UsersView},
+		{" Users:Manage ", permissions.UsersManage},
+		{permissions.ConfigView, permissions.ConfigView},
+		{"reports:export", "reports:export"},
+		{"reports:export:csv", "reports:export:csv"},
+		{"my-app.reports:read_all", "my-app.reports:read_all"},
+	}
+
+	for _, tc := range validCases {
+		actual, err := permissions.Parse(tc.in)
+		require.NoError(t, err, "could not parse %v", tc.in)
+		require.Equal(t, tc.expected, actual)
+	}
+
+	invalidCases := []any{
+		"", "users", "users:", ":view", "users view", "users::view", "-users:view", "users:view!", 42,
+	}
+
+	for _, tc := range invalidCases {
+		actual, err := permissions.Parse(tc)
+		require.Error(t, err, "expected %v to be invalid", tc)
+		require.Equal(t, permissions.Unknown, actual)
+	}
+}
+
+func TestValidateNamespace(t *testing.T) {
+	for _, ns := range []string{"reports", "reports-app", "Client_01.prod", "01JQ4W3W2Z"} {
+		require.NoError(t, permissions.ValidateNamespace(ns), "expected %q to be valid", ns)
+	}
+
+	for _, ns := range []string{"", "quarterdeck", "Quarterdeck", "my app", "-reports", "reports/app"} {
+		require.Error(t, permissions.ValidateNamespace(ns), "expected %q to be invalid", ns)
+	}
+}
+
+func TestInNamespace(t *testing.T) {
+	require.True(t, permissions.Permission("reports:export").InNamespace("reports"))
+	require.True(t, permissions.Permission("reports:export:csv").InNamespace("Reports"))
+	require.False(t, permissions.Permission("reports:export").InNamespace("billing"))
+	require.False(t, permissions.Permission("reports:export").InNamespace("reports-app"))
+	require.False(t, permissions.Permission("quarterdeck:export").InNamespace("quarterdeck"))
+
+	// Built-in permissions are only in the quarterdeck namespace.
+	require.True(t, permissions.UsersView.InNamespace(permissions.Quarterdeck))
+	require.False(t, permissions.UsersView.InNamespace("users"))
+}
+
+func TestBuiltin(t *testing.T) {
+	for _, permission := range permissions.Builtin {
+		require.NoError(t, permission.Validate())
+		require.True(t, permission.IsBuiltin())
+		require.NotEmpty(t, permission.Description(), "missing description for %s", permission)
+	}
+
+	custom := permissions.Permission("reports:export")
+	require.False(t, custom.IsBuiltin())
+	require.Empty(t, custom.Description())
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	var perms permissions.Permissions
+	require.NoError(t, json.Unmarshal([]byte(`["users:view", "Reports:Export"]`), &perms))
+	require.Equal(t, permissions.Permissions{permissions.UsersView, "reports:export"}, perms)
+	require.Equal(t, []string{"users:view", "reports:export"}, perms.String())
+
+	require.Error(t, json.Unmarshal([]byte(`["not a permission"]`), &perms))
+}
@@ -0,0 +1,337 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+
+	"go.rtnl.ai/quarterdeck/pkg/config"
+	"go.rtnl.ai/quarterdeck/pkg/enum"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/ulid"
+	"go.rtnl.ai/x/rlog"
+)
+
+// KeyStore stores the signing keys that are shared by every replica of Quarterdeck.
+type KeyStore interface {
+	ListSigningKeys(context.Context) ([]*models.SigningKey, error)
+	CreateSigningKey(context.Context, *models.SigningKey) (*models.SigningKey, error)
+	RetrieveSigningKey(context.Context, ulid.ULID) (*models.SigningKey, error)
+	ActivateSigningKey(context.Context, ulid.ULID, time.Time) error
+	RetireSigningKey(context.Context, ulid.ULID) error
+}
+
+// KeyManager manages the lifecycle of the signing keys stored in the database and
+// loads them into the issuer. A new key is created in the next state and published in
+// the JWKS for the publish window before it becomes the active key that signs tokens;
+// the previously active key is then retiring and remains published until every token
+// that it signed has expired, after which it is retired and its private key is deleted.
+//
+// Every replica runs the key manager; the store ensures that there is only ever one
+// next key and one active key, so when replicas rotate keys at the same time only one
+// of them succeeds and the others pick up its keys the next time they sync.
+type KeyManager struct {
+	conf   config.AuthConfig
+	issuer *Issuer
+	store  KeyStore
+	cipher *KeyCipher
+}
+
+// NewKeyManager creates a key manager that loads keys into the issuer. The key
+// encryption key must be configured to seal the private keys stored in the database.
+func NewKeyManager(conf config.AuthConfig, issuer *Issuer, store KeyStore) (_ *KeyManager, err error) {
+	m := &KeyManager{
+		conf:   conf,
+		issuer: issuer,
+		store:  store,
+	}
+
+	if m.cipher, err = NewKeyCipher(conf.GetKeyEncryptionKey()); err != nil {
+		if errors.Is(err, errors.ErrNoKeyEncryptionKey) {
+			return nil, errors.ConfigError(nil, errors.RequiredConfig("auth", "keyEncryptionKey"))
+		}
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// Import stores the PEM keys at the specified paths (a map of kid to path) in the
+// database if they have not already been imported, so that deployments that configured
+// static keys continue to verify the tokens that those keys signed. If there is no
+// active key, the newest imported key becomes the active key; the other keys are
+// retiring and are retired once the tokens they could have signed have expired.
+func (m *KeyManager) Import(ctx context.Context, paths map[string]string) (err error) {
+	if len(paths) == 0 {
+		return nil
+	}
+
+	var stored []*models.SigningKey
+	if stored, err = m.store.ListSigningKeys(ctx); err != nil {
+		return err
+	}
+
+	hasActive := slices.ContainsFunc(stored, func(k *models.SigningKey) bool { return k.State == enum.KeyStateActive })
+
+	keyIDs := make([]ulid.ULID, 0, len(paths))
+	for kid := range paths {
+		var keyID ulid.ULID
+		if keyID, err = ulid.Parse(kid); err != nil {
+			return errors.Fmt("could not parse %s as a key id: %w", kid, err)
+		}
+
+		if !slices.ContainsFunc(stored, func(k *models.SigningKey) bool { return k.ID == keyID }) {
+			keyIDs = append(keyIDs, keyID)
+		}
+	}
+
+	// Import the newest key first so that it is the active key if there is none.
+	slices.SortFunc(keyIDs, func(a, b ulid.ULID) int { return bytes.Compare(b[:], a[:]) })
+
+	now := time.Now()
+	for i, keyID := range keyIDs {
+		var keypair SigningKey
+		if keypair, err = LoadKeys(paths[keyID.String()]); err != nil {
+			return err
+		}
+
+		state := enum.KeyStateRetiring
+		if i == 0 && !hasActive {
+			state = enum.KeyStateActive
+		}
+
+		var key *models.SigningKey
+		if key, err = m.newKey(keyID, keypair, state, time.UnixMilli(int64(keyID.Time()))); err != nil {
+			return err
+		}
+
+		if state == enum.KeyStateRetiring {
+			key.Deactivated.Time, key.Deactivated.Valid = now, true
+			key.Retires.Time, key.Retires.Valid = m.retires(now), true
+		}
+
+		if _, err = m.store.CreateSigningKey(ctx, key); err != nil {
+			// Another replica imported the key or activated a key first.
+			if errors.Is(err, errors.ErrAlreadyExists) {
+				continue
+			}
+			return errors.Fmt("could not import signing key %s: %w", keyID, err)
+		}
+
+		rlog.InfoAttrs(ctx, "imported signing key", slog.String("kid", keyID.String()), slog.String("state", state.String()))
+	}
+
+	return nil
+}
+
+// Rotate creates a new key that is published in the JWKS for the publish window before
+// it becomes the active key. If immediate is true, the new key is activated right away;
+// clients that have cached the JWKS will not be able to verify the tokens it signs
+// until they refetch the JWKS, so immediate rotation should only be used if the active
+// key has been compromised. If a rotation is already scheduled then ErrRotationPending
+// is returned unless immediate is true, in which case the next key is activated.
+func (m *KeyManager) Rotate(ctx context.Context, immediate bool) (_ *models.SigningKey, err error) {
+	var stored []*models.SigningKey
+	if stored, err = m.store.ListSigningKeys(ctx); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	next := findKey(stored, enum.KeyStateNext)
+
+	switch {
+	case next != nil && !immediate:
+		return nil, errors.ErrRotationPending
+	case next == nil:
+		activates := now.Add(m.conf.KeyPublishWindow)
+		if immediate {
+			activates = now
+		}
+
+		if next, err = m.createKey(ctx, enum.KeyStateNext, activates); err != nil {
+			if errors.Is(err, errors.ErrAlreadyExists) {
+				return nil, errors.ErrRotationPending
+			}
+			return nil, err
+		}
+	}
+
+	if immediate {
+		if err = m.store.ActivateSigningKey(ctx, next.ID, m.retires(now)); err != nil && !errors.Is(err, errors.ErrNotFound) {
+			return nil, err
+		}
+	}
+
+	if err = m.Sync(ctx); err != nil {
+		return nil, err
+	}
+	return m.store.RetrieveSigningKey(ctx, next.ID)
+}
+
+// Retire retires a next or retiring key so that it is no longer published in the JWKS;
+// tokens signed by a retiring key will no longer be verified. The active key cannot be
+// retired, it must be replaced with an immediate rotation first.
+func (m *KeyManager) Retire(ctx context.Context, keyID ulid.ULID) (err error) {
+	if err = m.store.RetireSigningKey(ctx, keyID); err != nil {
+		return err
+	}
+	return m.Sync(ctx)
+}
+
+// Maintain advances the lifecycle of the signing keys and then syncs the keys into the
+// issuer. An active key is created if there is none, the next key is activated once its
+// publish window has elapsed, retiring keys are retired once their tokens have expired,
+// and if scheduled rotation is enabled a next key is created one publish window before
+// the active key is due to be rotated.
+func (m *KeyManager) Maintain(ctx context.Context) (err error) {
+	var stored []*models.SigningKey
+	if stored, err = m.store.ListSigningKeys(ctx); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, key := range stored {
+		if key.State == enum.KeyStateRetiring && key.IsDue(now) {
+			if err = m.store.RetireSigningKey(ctx, key.ID); err != nil {
+				return errors.Fmt("could not retire signing key %s: %w", key.ID, err)
+			}
+			rlog.InfoAttrs(ctx, "retired signing key", slog.String("kid", key.ID.String()))
+		}
+	}
+
+	active := findKey(stored, enum.KeyStateActive)
+	next := findKey(stored, enum.KeyStateNext)
+
+	switch {
+	case next != nil && (active == nil || next.IsDue(now)):
+		if err = m.store.ActivateSigningKey(ctx, next.ID, m.retires(now)); err != nil {
+			// Another replica has already activated the next key.
+			if !errors.Is(err, errors.ErrNotFound) {
+				return errors.Fmt("could not activate signing key %s: %w", next.ID, err)
+			}
+		} else {
+			rlog.InfoAttrs(ctx, "activated signing key", slog.String("kid", next.ID.String()))
+		}
+
+	case active == nil:
+		if next, err = m.createKey(ctx, enum.KeyStateActive, now); err != nil {
+			// Another replica has already created the active key.
+			if !errors.Is(err, errors.ErrAlreadyExists) {
+				return errors.Fmt("could not create signing key: %w", err)
+			}
+		} else {
+			rlog.InfoAttrs(ctx, "created active signing key", slog.String("kid", next.ID.String()))
+		}
+
+	case next == nil && m.conf.KeyRotationInterval > 0:
+		rotates := active.Activates.Add(m.conf.KeyRotationInterval)
+		if !now.Before(rotates.Add(-m.conf.KeyPublishWindow)) {
+			activates := rotates
+			if publish := now.Add(m.conf.KeyPublishWindow); activates.Before(publish) {
+				activates = publish
+			}
+
+			if next, err = m.createKey(ctx, enum.KeyStateNext, activates); err != nil {
+				// Another replica has already scheduled the rotation.
+				if !errors.Is(err, errors.ErrAlreadyExists) {
+					return errors.Fmt("could not create signing key: %w", err)
+				}
+			} else {
+				rlog.InfoAttrs(ctx, "scheduled signing key rotation",
+					slog.String("kid", next.ID.String()), slog.Time("activates", activates))
+			}
+		}
+	}
+
+	return m.Sync(ctx)
+}
+
+// Sync loads the published keys from the database into the issuer so that rotations by
+// other replicas are picked up. If there is no active key the issuer is not modified.
+func (m *KeyManager) Sync(ctx context.Context) (err error) {
+	var stored []*models.SigningKey
+	if stored, err = m.store.ListSigningKeys(ctx); err != nil {
+		return err
+	}
+
+	var (
+		signerID ulid.ULID
+		signer   SigningKey
+		jwks     = NewJWKS(m.conf.KeyPublishWindow)
+	)
+
+	for _, key := range stored {
+		if !key.IsPublished() {
+			continue
+		}
+
+		// Only the private key of the active key is decrypted since it is the only key
+		// that signs tokens.
+		var sealed []byte
+		if key.State == enum.KeyStateActive {
+			sealed = key.PrivateKey
+		}
+
+		var keypair SigningKey
+		if keypair, err = m.cipher.Open(key.ID, key.PublicKey, sealed); err != nil {
+			return err
+		}
+
+		if err = jwks.Add(key.ID, keypair); err != nil {
+			return err
+		}
+
+		if key.State == enum.KeyStateActive {
+			signerID, signer = key.ID, keypair
+		}
+	}
+
+	if signer == nil {
+		return errors.ErrNoSigningKeys
+	}
+	return m.issuer.SetKeys(signerID, signer, jwks)
+}
+
+// Generates a new key pair and stores it in the database in the specified state.
+func (m *KeyManager) createKey(ctx context.Context, state enum.KeyState, activates time.Time) (_ *models.SigningKey, err error) {
+	var keypair SigningKey
+	if keypair, err = GenerateKeys(); err != nil {
+		return nil, err
+	}
+
+	var key *models.SigningKey
+	if key, err = m.newKey(secureULID(), keypair, state, activates); err != nil {
+		return nil, err
+	}
+	return m.store.CreateSigningKey(ctx, key)
+}
+
+// Seals the key pair into a signing key model that can be stored in the database.
+func (m *KeyManager) newKey(keyID ulid.ULID, keypair SigningKey, state enum.KeyState, activates time.Time) (_ *models.SigningKey, err error) {
+	key := &models.SigningKey{State: state, Activates: activates.UTC()}
+	key.ID = keyID
+
+	if key.PublicKey, key.PrivateKey, err = m.cipher.Seal(keyID, keypair); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Returns when a key that stops signing at the specified time can be retired. Replicas
+// may continue to sign tokens with the key until they next sync, so the sync interval
+// is added to the lifetime of the tokens that it signed.
+func (m *KeyManager) retires(deactivated time.Time) time.Time {
+	return deactivated.Add(m.conf.TokenLifetime() + m.conf.KeySyncInterval).UTC()
+}
+
+func findKey(keys []*models.SigningKey, state enum.KeyState) *models.SigningKey {
+	for _, key := range keys {
+		if key.State == state {
+			return key
+		}
+	}
+	return nil
+}
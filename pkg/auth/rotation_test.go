@@ -0,0 +1,332 @@
+package auth_test
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/gimlet/auth"
+	. "go.rtnl.ai/quarterdeck/pkg/auth"
+	"go.rtnl.ai/quarterdeck/pkg/config"
+	"go.rtnl.ai/quarterdeck/pkg/enum"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/ulid"
+)
+
+const testKEK = "6a1e4c2f0d8b7a95e3f41c6b2d09a8e7f5c3b1a0d9e8f7c6b5a4938271605f4e"
+
+func TestKeyManager(t *testing.T) {
+	conf := config.AuthConfig{
+		Audience:         []string{"http://localhost:3000"},
+		Issuer:           "http://localhost:3001",
+		AccessTokenTTL:   1 * time.Hour,
+		RefreshTokenTTL:  2 * time.Hour,
+		TokenOverlap:     -15 * time.Minute,
+		KeyEncryptionKey: testKEK,
+		KeyPublishWindow: 24 * time.Hour,
+		KeySyncInterval:  time.Minute,
+	}
+
+	// newManager returns a key manager for a replica that shares the key store.
+	newManager := func(t *testing.T, conf config.AuthConfig, store KeyStore) (*KeyManager, *Issuer) {
+		issuer, err := NewIssuer(conf)
+		require.NoError(t, err)
+
+		manager, err := NewKeyManager(conf, issuer, store)
+		require.NoError(t, err)
+		return manager, issuer
+	}
+
+	t.Run("RequiresKEK", func(t *testing.T) {
+		conf := conf
+		conf.KeyEncryptionKey = ""
+
+		issuer, err := NewIssuer(conf)
+		require.NoError(t, err)
+
+		_, err = NewKeyManager(conf, issuer, &memKeyStore{})
+		require.EqualError(t, err, "invalid configuration: auth.keyEncryptionKey is required but not set")
+	})
+
+	t.Run("Bootstrap", func(t *testing.T) {
+		store := &memKeyStore{}
+		manager, issuer := newManager(t, conf, store)
+
+		// No keys can be synced until an active key exists.
+		require.ErrorIs(t, manager.Sync(context.Background()), errors.ErrNoSigningKeys)
+
+		require.NoError(t, manager.Maintain(context.Background()))
+		active := store.state(enum.KeyStateActive)
+		require.Len(t, active, 1)
+		require.Equal(t, active[0].ID, issuer.CurrentKey())
+
+		// Maintaining again does not create another key.
+		require.NoError(t, manager.Maintain(context.Background()))
+		require.Len(t, store.keys, 1)
+	})
+
+	t.Run("Rotate", func(t *testing.T) {
+		store := &memKeyStore{}
+		manager, issuer := newManager(t, conf, store)
+		replica, other := newManager(t, conf, store)
+
+		require.NoError(t, manager.Maintain(context.Background()))
+		require.NoError(t, replica.Sync(context.Background()))
+		active := issuer.CurrentKey()
+
+		// A rotation publishes the next key without signing with it.
+		next, err := manager.Rotate(context.Background(), false)
+		require.NoError(t, err)
+		require.Equal(t, enum.KeyStateNext, next.State)
+		require.WithinDuration(t, time.Now().Add(conf.KeyPublishWindow), next.Activates, time.Minute)
+		require.Equal(t, active, issuer.CurrentKey())
+
+		keys, err := issuer.Keys()
+		require.NoError(t, err)
+		require.Len(t, keys.Keys, 2, "expected the next key to be published")
+
+		// Only one rotation can be scheduled at a time.
+		_, err = replica.Rotate(context.Background(), false)
+		require.ErrorIs(t, err, errors.ErrRotationPending)
+
+		// The next key is activated once its publish window has elapsed.
+		store.shift(next.ID, -conf.KeyPublishWindow)
+		require.NoError(t, manager.Maintain(context.Background()))
+		require.Equal(t, next.ID, issuer.CurrentKey())
+
+		retiring := store.state(enum.KeyStateRetiring)
+		require.Len(t, retiring, 1)
+		require.Equal(t, active, retiring[0].ID)
+		require.WithinDuration(t, time.Now().Add(2*time.Hour+time.Minute), retiring[0].Retires.Time, time.Minute)
+
+		// The replica picks up the rotation when it syncs; tokens signed by either key verify.
+		tks := signToken(t, other)
+		require.NoError(t, replica.Sync(context.Background()))
+		require.Equal(t, next.ID, other.CurrentKey())
+
+		_, err = issuer.Verify(tks)
+		require.NoError(t, err, "tokens signed by the retiring key must still be verified")
+
+		// The retiring key is retired once the tokens it signed have expired.
+		store.expire(active)
+		require.NoError(t, manager.Maintain(context.Background()))
+		require.Len(t, store.state(enum.KeyStateRetiring), 0)
+		require.Empty(t, store.get(active).PrivateKey)
+
+		_, err = issuer.Verify(tks)
+		require.ErrorIs(t, err, errors.ErrUnknownSigningKey)
+	})
+
+	t.Run("RotateImmediately", func(t *testing.T) {
+		store := &memKeyStore{}
+		manager, issuer := newManager(t, conf, store)
+		require.NoError(t, manager.Maintain(context.Background()))
+		active := issuer.CurrentKey()
+
+		key, err := manager.Rotate(context.Background(), true)
+		require.NoError(t, err)
+		require.Equal(t, enum.KeyStateActive, key.State)
+		require.Equal(t, key.ID, issuer.CurrentKey())
+		require.Equal(t, enum.KeyStateRetiring, store.get(active).State)
+
+		// The active key cannot be retired but retiring keys can be retired early.
+		require.ErrorIs(t, manager.Retire(context.Background(), key.ID), errors.ErrActiveSigningKey)
+		require.NoError(t, manager.Retire(context.Background(), active))
+
+		keys, err := issuer.Keys()
+		require.NoError(t, err)
+		require.Len(t, keys.Keys, 1)
+	})
+
+	t.Run("Scheduled", func(t *testing.T) {
+		conf := conf
+		conf.KeyRotationInterval = 30 * 24 * time.Hour
+
+		store := &memKeyStore{}
+		manager, _ := newManager(t, conf, store)
+		require.NoError(t, manager.Maintain(context.Background()))
+		active := store.state(enum.KeyStateActive)[0]
+
+		// No rotation is scheduled until one publish window before the rotation is due.
+		require.NoError(t, manager.Maintain(context.Background()))
+		require.Empty(t, store.state(enum.KeyStateNext))
+
+		store.shift(active.ID, -(conf.KeyRotationInterval - conf.KeyPublishWindow))
+		require.NoError(t, manager.Maintain(context.Background()))
+
+		next := store.state(enum.KeyStateNext)
+		require.Len(t, next, 1)
+		require.WithinDuration(t, time.Now().Add(conf.KeyPublishWindow), next[0].Activates, time.Minute)
+	})
+
+	t.Run("Import", func(t *testing.T) {
+		store := &memKeyStore{}
+		manager, issuer := newManager(t, conf, store)
+
+		paths := map[string]string{
+			"01JYSHGWTSMK34J100N2Q0D21C": "testdata/01JYSHGWTSMK34J100N2Q0D21C.pem",
+			"01JYSW0C9QK2TN3MQ1T7F411DX": "testdata/01JYSW0C9QK2TN3MQ1T7F411DX.pem",
+		}
+
+		require.NoError(t, manager.Import(context.Background(), paths))
+		require.NoError(t, manager.Maintain(context.Background()))
+		require.Equal(t, "01JYSW0C9QK2TN3MQ1T7F411DX", issuer.CurrentKey().String(), "expected the newest key to be active")
+		require.Equal(t, enum.KeyStateRetiring, store.get(ulid.MustParse("01JYSHGWTSMK34J100N2Q0D21C")).State)
+
+		// Importing the keys again does not modify them.
+		require.NoError(t, manager.Import(context.Background(), paths))
+		require.Len(t, store.keys, 2)
+	})
+}
+
+func signToken(t *testing.T, issuer *Issuer) string {
+	claims := &auth.Claims{Email: "kate@example.com"}
+	claims.SetSubjectID(auth.SubjectUser, ulid.Make())
+
+	var (
+		token *jwt.Token
+		tks   string
+		err   error
+	)
+
+	token, err = issuer.CreateAccessToken(claims, ulid.Zero)
+	require.NoError(t, err)
+
+	tks, err = issuer.Sign(token)
+	require.NoError(t, err)
+	return tks
+}
+
+// memKeyStore is an in-memory key store that enforces the same constraints as the
+// database so that the key manager can be tested with multiple replicas.
+type memKeyStore struct {
+	sync.Mutex
+	keys []*models.SigningKey
+}
+
+var _ KeyStore = (*memKeyStore)(nil)
+
+func (s *memKeyStore) ListSigningKeys(context.Context) ([]*models.SigningKey, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	out := make([]*models.SigningKey, 0, len(s.keys))
+	for _, key := range s.keys {
+		cpy := *key
+		out = append(out, &cpy)
+	}
+
+	slices.SortFunc(out, func(a, b *models.SigningKey) int { return b.Activates.Compare(a.Activates) })
+	return out, nil
+}
+
+func (s *memKeyStore) CreateSigningKey(_ context.Context, key *models.SigningKey) (*models.SigningKey, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	for _, existing := range s.keys {
+		if existing.ID == key.ID || (existing.State == key.State && key.State != enum.KeyStateRetiring) {
+			return nil, errors.ErrAlreadyExists
+		}
+	}
+
+	cpy := *key
+	s.keys = append(s.keys, &cpy)
+	return key, nil
+}
+
+func (s *memKeyStore) RetrieveSigningKey(_ context.Context, id ulid.ULID) (*models.SigningKey, error) {
+	if key := s.get(id); key != nil {
+		return key, nil
+	}
+	return nil, errors.ErrNotFound
+}
+
+func (s *memKeyStore) ActivateSigningKey(_ context.Context, id ulid.ULID, retires time.Time) error {
+	s.Lock()
+	defer s.Unlock()
+
+	idx := slices.IndexFunc(s.keys, func(k *models.SigningKey) bool { return k.ID == id && k.State == enum.KeyStateNext })
+	if idx < 0 {
+		return errors.ErrNotFound
+	}
+
+	now := time.Now()
+	for _, key := range s.keys {
+		if key.State == enum.KeyStateActive {
+			key.State = enum.KeyStateRetiring
+			key.Deactivated = sql.NullTime{Time: now, Valid: true}
+			key.Retires = sql.NullTime{Time: retires, Valid: true}
+		}
+	}
+
+	s.keys[idx].State = enum.KeyStateActive
+	s.keys[idx].Activates = now
+	return nil
+}
+
+func (s *memKeyStore) RetireSigningKey(_ context.Context, id ulid.ULID) error {
+	s.Lock()
+	defer s.Unlock()
+
+	for _, key := range s.keys {
+		if key.ID == id {
+			switch key.State {
+			case enum.KeyStateActive:
+				return errors.ErrActiveSigningKey
+			case enum.KeyStateRetired:
+				return nil
+			}
+
+			key.State = enum.KeyStateRetired
+			key.PrivateKey = nil
+			return nil
+		}
+	}
+	return errors.ErrNotFound
+}
+
+func (s *memKeyStore) get(id ulid.ULID) *models.SigningKey {
+	s.Lock()
+	defer s.Unlock()
+	for _, key := range s.keys {
+		if key.ID == id {
+			cpy := *key
+			return &cpy
+		}
+	}
+	return nil
+}
+
+func (s *memKeyStore) state(state enum.KeyState) []*models.SigningKey {
+	keys, _ := s.ListSigningKeys(context.Background())
+	return slices.DeleteFunc(keys, func(k *models.SigningKey) bool { return k.State != state })
+}
+
+// Moves the activation time of the key so that time appears to have passed.
+func (s *memKeyStore) shift(id ulid.ULID, d time.Duration) {
+	s.Lock()
+	defer s.Unlock()
+	for _, key := range s.keys {
+		if key.ID == id {
+			key.Activates = key.Activates.Add(d)
+		}
+	}
+}
+
+// Moves the retirement time of the key into the past.
+func (s *memKeyStore) expire(id ulid.ULID) {
+	s.Lock()
+	defer s.Unlock()
+	for _, key := range s.keys {
+		if key.ID == id {
+			key.Retires = sql.NullTime{Time: time.Now().Add(-time.Second), Valid: true}
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package auth
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/golang-jwt/jwt/v5"
+	"go.rtnl.ai/quarterdeck/pkg/config"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+)
+
+// WebAuthn ceremonies; a challenge issued for one ceremony cannot be used for another.
+const (
+	WebAuthnRegistration = "registration"
+	WebAuthnLogin        = "login"
+)
+
+const (
+	WebAuthnChallengeCookie    = "webauthn_challenge"
+	WebAuthnChallengeCookieTTL = 300 * time.Second // 5 minutes; same as [webauthnChallengeTTL]
+
+	webauthnPath         = "/v1/webauthn"
+	webauthnChallengeTTL = 5 * time.Minute
+	webauthnDisplayName  = "Quarterdeck"
+)
+
+//===========================================================================
+// Relying Party
+//===========================================================================
+
+// NewWebAuthn creates the WebAuthn relying party for Quarterdeck. Passkeys are scoped
+// to the hostname of the issuer and can only be used from the issuer's origin, which is
+// where the login and profile pages are served from.
+func NewWebAuthn(conf config.AuthConfig) (*webauthn.WebAuthn, error) {
+	issuer, err := url.Parse(conf.Issuer)
+	if err != nil {
+		return nil, errors.Fmt("could not parse issuer url: %w", err)
+	}
+
+	origin := &url.URL{Scheme: issuer.Scheme, Host: issuer.Host}
+	return webauthn.New(&webauthn.Config{
+		RPID:          issuer.Hostname(),
+		RPDisplayName: webauthnDisplayName,
+		RPOrigins:     []string{origin.String()},
+	})
+}
+
+//===========================================================================
+// WebAuthn Challenge Tokens
+//===========================================================================
+
+// WebAuthnChallengeClaims carry the WebAuthn session data (including the random
+// challenge) between the begin and finish steps of a registration or login ceremony.
+// The token is signed so that the challenge cannot be modified by the client and is
+// stored in an http only cookie so that it cannot be read by client-side scripts. Login
+// challenges also carry the original login request parameters.
+type WebAuthnChallengeClaims struct {
+	jwt.RegisteredClaims
+	Ceremony string               `json:"ceremony"`
+	Session  webauthn.SessionData `json:"session"`
+	ClientID string               `json:"client_id,omitempty"`
+	Nonce    string               `json:"nonce,omitempty"`
+	Next     string               `json:"next,omitempty"`
+}
+
+// CreateWebAuthnChallenge creates a short-lived signed token for the session data
+// returned when a WebAuthn ceremony is started.
+func (tm *Issuer) CreateWebAuthnChallenge(ceremony string, session *webauthn.SessionData, clientID, nonce, next string) (string, error) {
+	now := time.Now()
+	challenge := &WebAuthnChallengeClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        secureULID().String(),
+			Audience:  jwt.ClaimStrings{tm.WebAuthnAudience()},
+			Issuer:    tm.conf.Issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(webauthnChallengeTTL)),
+		},
+		Ceremony: ceremony,
+		Session:  *session,
+		ClientID: clientID,
+		Nonce:    nonce,
+		Next:     next,
+	}
+
+	return tm.Sign(jwt.NewWithClaims(signingMethod, challenge))
+}
+
+// VerifyWebAuthnChallenge verifies the signature, audience, and expiration of a
+// WebAuthn challenge and that it was issued for the specified ceremony.
+func (tm *Issuer) VerifyWebAuthnChallenge(tks, ceremony string) (claims *WebAuthnChallengeClaims, err error) {
+	parser := jwt.NewParser(
+		jwt.WithValidMethods([]string{signingMethod.Alg()}),
+		jwt.WithAudience(tm.WebAuthnAudience()),
+		jwt.WithIssuer(tm.conf.Issuer),
+		jwt.WithExpirationRequired(),
+	)
+
+	claims = &WebAuthnChallengeClaims{}
+	if _, err = parser.ParseWithClaims(tks, claims, tm.GetKey); err != nil {
+		return nil, errors.ErrWebAuthnChallengeFailed
+	}
+
+	if claims.Ceremony != ceremony {
+		return nil, errors.ErrWebAuthnChallengeFailed
+	}
+
+	return claims, nil
+}
+
+// WebAuthnAudience is the audience of WebAuthn challenge tokens, which ensures that
+// they cannot be used as access tokens or MFA challenges.
+func (tm *Issuer) WebAuthnAudience() string {
+	aud, err := url.Parse(tm.conf.Issuer)
+	if err != nil {
+		// The issuer URL should have been validated in the config.
+		panic("could not parse issuer URL: " + err.Error())
+	}
+	return aud.ResolveReference(&url.URL{Path: webauthnPath}).String()
+}
+
+//===========================================================================
+// WebAuthn Challenge Cookies
+//===========================================================================
+
+// SetWebAuthnChallengeCookie stores the challenge token in an http only cookie; the
+// finish step of the ceremony must be submitted from the same browser.
+func SetWebAuthnChallengeCookie(c *gin.Context, token, domain string) {
+	SetSecureCookie(c, WebAuthnChallengeCookie, token, int(WebAuthnChallengeCookieTTL.Seconds()), domain, true)
+}
+
+func ClearWebAuthnChallengeCookie(c *gin.Context, domain string) {
+	ClearSecureCookie(c, WebAuthnChallengeCookie, domain, true)
+}
+
+//===========================================================================
+// Authenticator Flags
+//===========================================================================
+
+// WebAuthnFlags returns the authenticator data flags of a credential as a byte for
+// storage. The raw flags are only available when the credential is registered so the
+// byte is computed from the individual flags.
+func WebAuthnFlags(flags webauthn.CredentialFlags) uint8 {
+	var raw protocol.AuthenticatorFlags
+	if flags.UserPresent {
+		raw |= protocol.FlagUserPresent
+	}
+	if flags.UserVerified {
+		raw |= protocol.FlagUserVerified
+	}
+	if flags.BackupEligible {
+		raw |= protocol.FlagBackupEligible
+	}
+	if flags.BackupState {
+		raw |= protocol.FlagBackupState
+	}
+	return uint8(raw)
+}
+
+// WebAuthnCredentialFlags parses the stored authenticator data flags of a credential.
+func WebAuthnCredentialFlags(flags uint8) webauthn.CredentialFlags {
+	return webauthn.NewCredentialFlags(protocol.AuthenticatorFlags(flags))
+}
@@ -0,0 +1,93 @@
+package auth_test
+
+import (
+	"testing"
+
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/gimlet/auth"
+	. "go.rtnl.ai/quarterdeck/pkg/auth"
+	"go.rtnl.ai/quarterdeck/pkg/config"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/ulid"
+)
+
+func (s *TokenTestSuite) TestWebAuthnChallenge() {
+	require := s.Require()
+	tm, err := s.NewIssuer(s.AuthConfig())
+	require.NoError(err, "could not initialize token manager")
+
+	session := &webauthn.SessionData{
+		Challenge:        "dGhpcyBpcyBhIGNoYWxsZW5nZQ",
+		RelyingPartyID:   "localhost",
+		UserVerification: "preferred",
+	}
+
+	tks, err := tm.CreateWebAuthnChallenge(WebAuthnLogin, session, "cid", "nonce", "/dashboard")
+	require.NoError(err)
+
+	claims, err := tm.VerifyWebAuthnChallenge(tks, WebAuthnLogin)
+	require.NoError(err)
+	require.Equal(session.Challenge, claims.Session.Challenge)
+	require.Equal(session.RelyingPartyID, claims.Session.RelyingPartyID)
+	require.Equal("cid", claims.ClientID)
+	require.Equal("nonce", claims.Nonce)
+	require.Equal("/dashboard", claims.Next)
+
+	s.Run("WrongCeremony", func() {
+		_, err := tm.VerifyWebAuthnChallenge(tks, WebAuthnRegistration)
+		require.ErrorIs(err, errors.ErrWebAuthnChallengeFailed)
+	})
+
+	s.Run("NotAnAccessToken", func() {
+		_, err := tm.Verify(tks)
+		require.Error(err, "the challenge should not be accepted as an access token")
+	})
+
+	s.Run("MFAChallengeIsNotWebAuthnChallenge", func() {
+		challenge, err := tm.CreateMFAChallenge(ulid.Make(), "", "", "")
+		require.NoError(err)
+
+		_, err = tm.VerifyWebAuthnChallenge(challenge, WebAuthnLogin)
+		require.ErrorIs(err, errors.ErrWebAuthnChallengeFailed)
+	})
+
+	s.Run("AccessTokenIsNotChallenge", func() {
+		creds := &auth.Claims{}
+		creds.SetSubjectID(auth.SubjectUser, ulid.Make())
+		accessToken, _, err := tm.CreateTokens(creds, ulid.Zero)
+		require.NoError(err)
+
+		_, err = tm.VerifyWebAuthnChallenge(accessToken, WebAuthnLogin)
+		require.ErrorIs(err, errors.ErrWebAuthnChallengeFailed)
+	})
+}
+
+func TestNewWebAuthn(t *testing.T) {
+	rp, err := NewWebAuthn(config.AuthConfig{Issuer: "https://auth.example.com:8443/"})
+	require.NoError(t, err)
+	require.Equal(t, "auth.example.com", rp.Config.RPID)
+	require.Equal(t, []string{"https://auth.example.com:8443"}, rp.Config.RPOrigins)
+}
+
+func TestWebAuthnFlags(t *testing.T) {
+	testCases := []struct {
+		flags    webauthn.CredentialFlags
+		expected uint8
+	}{
+		{webauthn.CredentialFlags{}, 0x00},
+		{webauthn.CredentialFlags{UserPresent: true}, 0x01},
+		{webauthn.CredentialFlags{UserPresent: true, UserVerified: true}, 0x05},
+		{webauthn.CredentialFlags{UserPresent: true, UserVerified: true, BackupEligible: true, BackupState: true}, 0x1d},
+	}
+
+	for i, tc := range testCases {
+		require.Equal(t, tc.expected, WebAuthnFlags(tc.flags), "test case %d failed", i)
+
+		flags := WebAuthnCredentialFlags(tc.expected)
+		require.Equal(t, tc.flags.UserPresent, flags.UserPresent, "test case %d failed", i)
+		require.Equal(t, tc.flags.UserVerified, flags.UserVerified, "test case %d failed", i)
+		require.Equal(t, tc.flags.BackupEligible, flags.BackupEligible, "test case %d failed", i)
+		require.Equal(t, tc.flags.BackupState, flags.BackupState, "test case %d failed", i)
+	}
+}
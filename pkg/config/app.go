@@ -15,16 +15,15 @@ type AppConfig struct {
 	BaseURI      string        `split_words:"true" desc:"base URL for the application"`
 	WelcomeEmail EmailTemplate `split_words:"true"`
 
-	// Configures user syncing. Quarterdeck will attempt to post any new or modified
-	// users to each of the endpoints provided. A create/update for a user will be
-	// sent via an HTTP POST with the [api.User] JSON to the endpoints, and when a
-	// user is deleted the user's ID will be appended to each endpoint as a path
-	// parameter and sent via an HTTP DELETE. This is a "best effort" functionality,
-	// so failures will be logged but not handled at that time.
-	//
-	// TODO: this endpoint config and callback code can be removed in favor of the
-	// OIDC callback method in the future once OIDC is implemented more completely.
-	WebhookURI string `split_words:"true" required:"false" desc:"webhook endpoint for the application to recieve user sync events"`
+	// Configures user syncing. If a webhook endpoint is provided then Quarterdeck
+	// subscribes it to the user created, updated, and deleted webhook events when the
+	// server starts. Events are delivered as an HTTP POST of the JSON event and are
+	// signed with the webhook secret so that the application can verify them; if no
+	// secret is configured then a secret is generated and the events cannot be verified.
+	// Changing the secret updates the subscription when the server restarts. Further
+	// subscriptions can be registered with the webhooks API.
+	WebhookURI    string `split_words:"true" required:"false" desc:"webhook endpoint for the application to recieve user sync events"`
+	WebhookSecret string `split_words:"true" required:"false" desc:"the secret used to sign the events sent to the application webhook endpoint"`
 }
 
 func (c AppConfig) Validate() (err error) {
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"encoding/hex"
 	"net/url"
 	"time"
 
@@ -9,13 +10,29 @@ import (
 )
 
 const (
-	LoginPath         = "/login"
-	ResetPasswordPath = "/reset-password"
-	LoginRedirectPath = "/"
+	LoginPath          = "/login"
+	ResetPasswordPath  = "/reset-password"
+	ForgotPasswordPath = "/forgot-password"
+	AcceptInvitePath   = "/invite"
+	VerifyEmailPath    = "/verify-email"
+	ConfirmEmailPath   = "/confirm-email"
+	RevertEmailPath    = "/revert-email"
+	LoginRedirectPath  = "/"
+)
+
+// Defaults for the signing key lifecycle if they are not set in the configuration.
+const (
+	DefaultKeyPublishWindow = 24 * time.Hour
+	DefaultKeySyncInterval  = 1 * time.Minute
+	KeyEncryptionKeySize    = 32
 )
 
 type AuthConfig struct {
-	Keys                   map[string]string `required:"false" desc:"a map of keyID to key path for JWT signing and verification; if omitted keys will be generated"`
+	Keys                   map[string]string `required:"false" desc:"a map of keyID to key path of PEM signing keys to import into the database on startup; the newest key is activated if there is no active signing key"`
+	KeyEncryptionKey       string            `split_words:"true" required:"false" desc:"a hexadecimal 32 byte key used to encrypt the signing keys stored in the database; required to run the server"`
+	KeyRotationInterval    time.Duration     `split_words:"true" default:"0" desc:"how often the signing key is rotated on a schedule; 0 disables scheduled rotation so keys are only rotated on demand"`
+	KeyPublishWindow       time.Duration     `split_words:"true" default:"24h" desc:"how long a new signing key is published in the JWKS before it begins signing tokens"`
+	KeySyncInterval        time.Duration     `split_words:"true" default:"1m" desc:"how often the signing keys are reloaded from the database to pick up rotations by other replicas"`
 	Audience               []string          `default:"http://localhost:8000" desc:"the audience claim for JWT tokens; used to verify the token is intended for this service"`
 	Issuer                 string            `default:"http://localhost:8888" desc:"the issuer claim for JWT tokens; used to verify the token is issued by this service"`
 	LoginURL               string            `split_words:"true" default:"" desc:"specify an alternate login URL, by default it is the issuer + /login"`
@@ -27,6 +44,9 @@ type AuthConfig struct {
 	AccessTokenTTL         time.Duration     `split_words:"true" default:"1h" desc:"the duration for which access tokens are valid"`
 	RefreshTokenTTL        time.Duration     `split_words:"true" default:"2h" desc:"the duration for which refresh tokens are valid"`
 	TokenOverlap           time.Duration     `split_words:"true" default:"-15m" desc:"the duration before an access token expires that the refresh token is valid"`
+	LockoutThreshold       int64             `split_words:"true" default:"10" desc:"the number of consecutive failed login or api key authentication attempts before the account or key is temporarily locked; 0 disables lockout"`
+	LockoutDuration        time.Duration     `split_words:"true" default:"5m" desc:"the duration of the first lockout; the lockout doubles with each failed attempt after the threshold is reached"`
+	LockoutMaxDuration     time.Duration     `split_words:"true" default:"24h" desc:"the maximum duration of an exponential backoff lockout"`
 }
 
 func (c *AuthConfig) Validate() (err error) {
@@ -128,6 +148,51 @@ func (c *AuthConfig) Validate() (err error) {
 		err = errors.ConfigError(err, errors.InvalidConfig("auth", "tokenOverlap", "must be negative and not exceed the access duration"))
 	}
 
+	if c.KeyEncryptionKey != "" {
+		if kek, perr := hex.DecodeString(c.KeyEncryptionKey); perr != nil {
+			err = errors.ConfigError(err, errors.ConfigParseError("auth", "keyEncryptionKey", perr))
+		} else if len(kek) != KeyEncryptionKeySize {
+			err = errors.ConfigError(err, errors.InvalidConfig("auth", "keyEncryptionKey", "must be %d bytes", KeyEncryptionKeySize))
+		}
+	}
+
+	// If the key publish window and sync interval are not set, use the defaults.
+	if c.KeyPublishWindow == 0 {
+		c.KeyPublishWindow = DefaultKeyPublishWindow
+	}
+
+	if c.KeySyncInterval == 0 {
+		c.KeySyncInterval = DefaultKeySyncInterval
+	}
+
+	// Replicas must reload the signing keys at least once while a new key is published
+	// so that they can verify the tokens it signs as soon as it begins signing.
+	if c.KeyPublishWindow < 0 {
+		err = errors.ConfigError(err, errors.InvalidConfig("auth", "keyPublishWindow", "must not be negative"))
+	} else if c.KeySyncInterval < 0 || c.KeySyncInterval >= c.KeyPublishWindow {
+		err = errors.ConfigError(err, errors.InvalidConfig("auth", "keySyncInterval", "must be positive and less than the key publish window"))
+	}
+
+	if c.KeyRotationInterval < 0 {
+		err = errors.ConfigError(err, errors.InvalidConfig("auth", "keyRotationInterval", "must not be negative"))
+	} else if c.KeyRotationInterval > 0 && c.KeyRotationInterval <= c.KeyPublishWindow {
+		err = errors.ConfigError(err, errors.InvalidConfig("auth", "keyRotationInterval", "must be longer than the key publish window"))
+	}
+
+	if c.LockoutThreshold < 0 {
+		err = errors.ConfigError(err, errors.InvalidConfig("auth", "lockoutThreshold", "must not be negative"))
+	}
+
+	if c.LockoutThreshold > 0 {
+		if c.LockoutDuration <= 0 {
+			err = errors.ConfigError(err, errors.RequiredConfig("auth", "lockoutDuration"))
+		}
+
+		if c.LockoutMaxDuration < c.LockoutDuration {
+			err = errors.ConfigError(err, errors.InvalidConfig("auth", "lockoutMaxDuration", "must not be less than the lockout duration"))
+		}
+	}
+
 	return err
 }
 
@@ -143,9 +208,66 @@ func (c AuthConfig) validateIssuer() *errors.InvalidConfiguration {
 	return nil
 }
 
+// Returns the key encryption key used to encrypt the signing keys stored in the
+// database or nil if it is not configured.
+func (c AuthConfig) GetKeyEncryptionKey() []byte {
+	if c.KeyEncryptionKey == "" {
+		return nil
+	}
+
+	kek, _ := hex.DecodeString(c.KeyEncryptionKey)
+	return kek
+}
+
+// TokenLifetime is the longest that a token signed by a key can be valid for, which is
+// how long a key is published after it stops signing so that its tokens can be verified.
+func (c AuthConfig) TokenLifetime() time.Duration {
+	return max(c.AccessTokenTTL, c.RefreshTokenTTL)
+}
+
 // Returns the ResetPasswordURL as a [url.URL].
 func (c AuthConfig) GetResetPasswordURL() *url.URL {
 	u, _ := url.Parse(c.Issuer)
 	u.Path = ResetPasswordPath
 	return u
 }
+
+// Returns the URL of the forgot password page where a user can request a password
+// reset link as a [url.URL].
+func (c AuthConfig) GetForgotPasswordURL() *url.URL {
+	u, _ := url.Parse(c.Issuer)
+	u.Path = ForgotPasswordPath
+	return u
+}
+
+// Returns the URL of the accept invite page where an invited user can set their
+// name and password as a [url.URL].
+func (c AuthConfig) GetAcceptInviteURL() *url.URL {
+	u, _ := url.Parse(c.Issuer)
+	u.Path = AcceptInvitePath
+	return u
+}
+
+// Returns the URL of the verify email page where a user confirms their email address
+// as a [url.URL].
+func (c AuthConfig) GetVerifyEmailURL() *url.URL {
+	u, _ := url.Parse(c.Issuer)
+	u.Path = VerifyEmailPath
+	return u
+}
+
+// Returns the URL of the confirm email page where a user confirms the new email address
+// of their account after requesting an email change as a [url.URL].
+func (c AuthConfig) GetConfirmEmailURL() *url.URL {
+	u, _ := url.Parse(c.Issuer)
+	u.Path = ConfirmEmailPath
+	return u
+}
+
+// Returns the URL of the revert email page where a user restores the previous email
+// address of their account after an unwanted email change as a [url.URL].
+func (c AuthConfig) GetRevertEmailURL() *url.URL {
+	u, _ := url.Parse(c.Issuer)
+	u.Path = RevertEmailPath
+	return u
+}
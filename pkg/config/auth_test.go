@@ -63,6 +63,16 @@ func TestAuthConfigValidate(t *testing.T) {
 				RefreshTokenTTL:        48 * time.Hour,
 				TokenOverlap:           -12 * time.Hour,
 			},
+			{
+				Audience:           []string{"https://example.com"},
+				Issuer:             "https://auth.example.com",
+				AccessTokenTTL:     5 * time.Minute,
+				RefreshTokenTTL:    10 * time.Minute,
+				TokenOverlap:       -2 * time.Minute,
+				LockoutThreshold:   5,
+				LockoutDuration:    5 * time.Minute,
+				LockoutMaxDuration: 5 * time.Minute,
+			},
 		}
 
 		for i, conf := range tests {
@@ -248,6 +258,87 @@ func TestAuthConfigValidate(t *testing.T) {
 				},
 				errs: "invalid configuration: auth.tokenOverlap must be negative and not exceed the access duration",
 			},
+			{
+				conf: config.AuthConfig{
+					Audience:         []string{"https://example.com"},
+					Issuer:           "https://auth.example.com",
+					AccessTokenTTL:   5 * time.Minute,
+					RefreshTokenTTL:  10 * time.Minute,
+					TokenOverlap:     -2 * time.Minute,
+					LockoutThreshold: -1,
+				},
+				errs: "invalid configuration: auth.lockoutThreshold must not be negative",
+			},
+			{
+				conf: config.AuthConfig{
+					Audience:           []string{"https://example.com"},
+					Issuer:             "https://auth.example.com",
+					AccessTokenTTL:     5 * time.Minute,
+					RefreshTokenTTL:    10 * time.Minute,
+					TokenOverlap:       -2 * time.Minute,
+					LockoutThreshold:   5,
+					LockoutMaxDuration: time.Hour,
+				},
+				errs: "invalid configuration: auth.lockoutDuration is required but not set",
+			},
+			{
+				conf: config.AuthConfig{
+					Audience:           []string{"https://example.com"},
+					Issuer:             "https://auth.example.com",
+					AccessTokenTTL:     5 * time.Minute,
+					RefreshTokenTTL:    10 * time.Minute,
+					TokenOverlap:       -2 * time.Minute,
+					LockoutThreshold:   5,
+					LockoutDuration:    time.Hour,
+					LockoutMaxDuration: time.Minute,
+				},
+				errs: "invalid configuration: auth.lockoutMaxDuration must not be less than the lockout duration",
+			},
+			{
+				conf: config.AuthConfig{
+					Audience:         []string{"https://example.com"},
+					Issuer:           "https://auth.example.com",
+					AccessTokenTTL:   5 * time.Minute,
+					RefreshTokenTTL:  10 * time.Minute,
+					TokenOverlap:     -2 * time.Minute,
+					KeyEncryptionKey: "b94d27b9934d3e08a52e52d7da7dabfa",
+				},
+				errs: "invalid configuration: auth.keyEncryptionKey must be 32 bytes",
+			},
+			{
+				conf: config.AuthConfig{
+					Audience:         []string{"https://example.com"},
+					Issuer:           "https://auth.example.com",
+					AccessTokenTTL:   5 * time.Minute,
+					RefreshTokenTTL:  10 * time.Minute,
+					TokenOverlap:     -2 * time.Minute,
+					KeyEncryptionKey: "not a hexadecimal key",
+				},
+				errs: "invalid configuration: auth.keyEncryptionKey could not parse keyEncryptionKey: encoding/hex: invalid byte: U+006E 'n'",
+			},
+			{
+				conf: config.AuthConfig{
+					Audience:         []string{"https://example.com"},
+					Issuer:           "https://auth.example.com",
+					AccessTokenTTL:   5 * time.Minute,
+					RefreshTokenTTL:  10 * time.Minute,
+					TokenOverlap:     -2 * time.Minute,
+					KeyPublishWindow: time.Hour,
+					KeySyncInterval:  2 * time.Hour,
+				},
+				errs: "invalid configuration: auth.keySyncInterval must be positive and less than the key publish window",
+			},
+			{
+				conf: config.AuthConfig{
+					Audience:            []string{"https://example.com"},
+					Issuer:              "https://auth.example.com",
+					AccessTokenTTL:      5 * time.Minute,
+					RefreshTokenTTL:     10 * time.Minute,
+					TokenOverlap:        -2 * time.Minute,
+					KeyRotationInterval: 12 * time.Hour,
+				},
+				errs: "invalid configuration: auth.keyRotationInterval must be longer than the key publish window",
+			},
 		}
 
 		for i, test := range tests {
@@ -295,6 +386,9 @@ func TestAuthConfigDefaults(t *testing.T) {
 			require.Equal(t, "https://example.com/", config.LoginRedirect)
 			require.Equal(t, "https://example.com/", config.AuthenticateRedirect)
 			require.Equal(t, "https://example.com/", config.ReauthenticateRedirect)
+			require.Equal(t, 24*time.Hour, config.KeyPublishWindow)
+			require.Equal(t, 1*time.Minute, config.KeySyncInterval)
+			require.Equal(t, 10*time.Minute, config.TokenLifetime())
 		})
 
 		t.Run("WithTrailingSlash", func(t *testing.T) {
@@ -40,6 +40,7 @@ type Config struct {
 	Secure       secure.Config     `split_words:"true"`
 	Security     SecurityConfig    `split_words:"true"`
 	Email        commo.Config      `split_words:"true"`
+	Webhooks     WebhookConfig     `split_words:"true"`
 	RateLimit    ratelimit.Config  `split_words:"true"`
 	Telemetry    TelemetryConfig   `split_words:"true"`
 	processed    bool
@@ -156,6 +157,10 @@ func (c Config) Mark() (_ Config, err error) {
 		return c, err
 	}
 
+	if err = c.Webhooks.Validate(); err != nil {
+		return c, err
+	}
+
 	c.processed = true
 	return c, nil
 }
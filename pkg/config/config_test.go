@@ -26,6 +26,8 @@ var testEnv = map[string]string{
 	"QD_ALLOW_ORIGINS":                              "https://example.com,https://auth.example.com,https://db.example.com",
 	"QD_DATABASE_URL":                               "sqlite3:///test.db",
 	"QD_DATABASE_READ_ONLY":                         "true",
+	"QD_DATABASE_USER_RETENTION":                    "48h",
+	"QD_DATABASE_PURGE_INTERVAL":                    "30m",
 	"QD_AUTH_KEYS":                                  "01GECSDK5WJ7XWASQ0PMH6K41K:testdata/01GECSDK5WJ7XWASQ0PMH6K41K.pem,01GECSJGDCDN368D0EENX23C7R:testdata/01GECSJGDCDN368D0EENX23C7R.pem",
 	"QD_AUTH_AUDIENCE":                              "https://example.com,https://db.example.com",
 	"QD_AUTH_ISSUER":                                "https://auth.example.com",
@@ -37,6 +39,9 @@ var testEnv = map[string]string{
 	"QD_AUTH_ACCESS_TOKEN_TTL":                      "5m",
 	"QD_AUTH_REFRESH_TOKEN_TTL":                     "10m",
 	"QD_AUTH_TOKEN_OVERLAP":                         "-2m",
+	"QD_AUTH_LOCKOUT_THRESHOLD":                     "5",
+	"QD_AUTH_LOCKOUT_DURATION":                      "10m",
+	"QD_AUTH_LOCKOUT_MAX_DURATION":                  "12h",
 	"QD_CSRF_COOKIE_TTL":                            "20m",
 	"QD_CSRF_SECRET":                                "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9",
 	"QD_SECURE_CONTENT_TYPE_NOSNIFF":                "false",
@@ -62,6 +67,17 @@ var testEnv = map[string]string{
 	"QD_APP_WELCOME_EMAIL_TEXT_PATH":                           "/data/email_body.txt",
 	"QD_APP_WELCOME_EMAIL_HTML_PATH":                           "/data/email_body.txt",
 	"QD_APP_WEBHOOK_URI":                                       "http://localhost:8000/api/v1/users/sync",
+	"QD_APP_WEBHOOK_SECRET":                                    "supersecretsquirrel",
+	"QD_WEBHOOKS_POLL_INTERVAL":                                "5s",
+	"QD_WEBHOOKS_TIMEOUT":                                      "3s",
+	"QD_WEBHOOKS_MAX_ATTEMPTS":                                 "4",
+	"QD_WEBHOOKS_INITIAL_BACKOFF":                              "1m",
+	"QD_WEBHOOKS_MAX_BACKOFF":                                  "1h",
+	"QD_WEBHOOKS_BATCH_SIZE":                                   "25",
+	"QD_AUTH_KEY_ENCRYPTION_KEY":                               "6a1e4c2f0d8b7a95e3f41c6b2d09a8e7f5c3b1a0d9e8f7c6b5a4938271605f4e",
+	"QD_AUTH_KEY_ROTATION_INTERVAL":                            "720h",
+	"QD_AUTH_KEY_PUBLISH_WINDOW":                               "12h",
+	"QD_AUTH_KEY_SYNC_INTERVAL":                                "30s",
 	"QD_ORG_NAME":                                              "OrgName",
 	"QD_ORG_STREET_ADDRESS":                                    "Org Street Address",
 	"QD_ORG_HOMEPAGE_URI":                                      "http://example.com",
@@ -95,6 +111,8 @@ func TestConfigImport(t *testing.T) {
 	require.Equal(t, []string{"https://example.com", "https://auth.example.com", "https://db.example.com"}, conf.AllowOrigins)
 	require.Equal(t, testEnv["QD_DATABASE_URL"], conf.Database.URL)
 	require.True(t, conf.Database.ReadOnly)
+	require.Equal(t, 48*time.Hour, conf.Database.UserRetention)
+	require.Equal(t, 30*time.Minute, conf.Database.PurgeInterval)
 	require.Len(t, conf.Auth.Keys, 2)
 	require.Equal(t, []string{"https://example.com", "https://db.example.com"}, conf.Auth.Audience)
 	require.Equal(t, testEnv["QD_AUTH_ISSUER"], conf.Auth.Issuer)
@@ -106,6 +124,14 @@ func TestConfigImport(t *testing.T) {
 	require.Equal(t, 5*time.Minute, conf.Auth.AccessTokenTTL)
 	require.Equal(t, 10*time.Minute, conf.Auth.RefreshTokenTTL)
 	require.Equal(t, -2*time.Minute, conf.Auth.TokenOverlap)
+	require.Equal(t, int64(5), conf.Auth.LockoutThreshold)
+	require.Equal(t, 10*time.Minute, conf.Auth.LockoutDuration)
+	require.Equal(t, 12*time.Hour, conf.Auth.LockoutMaxDuration)
+	require.Equal(t, testEnv["QD_AUTH_KEY_ENCRYPTION_KEY"], conf.Auth.KeyEncryptionKey)
+	require.Len(t, conf.Auth.GetKeyEncryptionKey(), 32)
+	require.Equal(t, 720*time.Hour, conf.Auth.KeyRotationInterval)
+	require.Equal(t, 12*time.Hour, conf.Auth.KeyPublishWindow)
+	require.Equal(t, 30*time.Second, conf.Auth.KeySyncInterval)
 	require.Equal(t, 20*time.Minute, conf.CSRF.CookieTTL)
 	require.Equal(t, testEnv["QD_CSRF_SECRET"], conf.CSRF.Secret)
 	require.False(t, conf.Secure.ContentTypeNosniff)
@@ -141,6 +167,13 @@ func TestConfigImport(t *testing.T) {
 	require.Equal(t, testEnv["QD_APP_WELCOME_EMAIL_TEXT_PATH"], conf.App.WelcomeEmail.TextPath)
 	require.Equal(t, testEnv["QD_APP_WELCOME_EMAIL_HTML_PATH"], conf.App.WelcomeEmail.HTMLPath)
 	require.Equal(t, testEnv["QD_APP_WEBHOOK_URI"], conf.App.WebhookURI)
+	require.Equal(t, testEnv["QD_APP_WEBHOOK_SECRET"], conf.App.WebhookSecret)
+	require.Equal(t, 5*time.Second, conf.Webhooks.PollInterval)
+	require.Equal(t, 3*time.Second, conf.Webhooks.Timeout)
+	require.Equal(t, 4, conf.Webhooks.MaxAttempts)
+	require.Equal(t, 1*time.Minute, conf.Webhooks.InitialBackoff)
+	require.Equal(t, 1*time.Hour, conf.Webhooks.MaxBackoff)
+	require.Equal(t, 25, conf.Webhooks.BatchSize)
 	require.Equal(t, testEnv["QD_ORG_NAME"], conf.Org.Name)
 	require.Equal(t, testEnv["QD_ORG_STREET_ADDRESS"], conf.Org.StreetAddress)
 	require.Equal(t, testEnv["QD_ORG_HOMEPAGE_URI"], conf.Org.HomepageURI)
@@ -455,9 +488,18 @@ func TestIsZero(t *testing.T) {
 					},
 				},
 				App: config.AppConfig{
-					LogoURI:    "https://www.example.com/logo.png",
-					BaseURI:    "https://www.example.com",
-					WebhookURI: "https://www.example.com/api/v1/sync",
+					LogoURI:       "https://www.example.com/logo.png",
+					BaseURI:       "https://www.example.com",
+					WebhookURI:    "https://www.example.com/api/v1/sync",
+					WebhookSecret: "supersecretsquirrel",
+				},
+				Webhooks: config.WebhookConfig{
+					PollInterval:   10 * time.Second,
+					Timeout:        10 * time.Second,
+					MaxAttempts:    8,
+					InitialBackoff: 30 * time.Second,
+					MaxBackoff:     6 * time.Hour,
+					BatchSize:      50,
 				},
 				Org: config.OrgConfig{
 					HomepageURI: "https://www.example.com",
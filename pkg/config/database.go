@@ -1,6 +1,10 @@
 package config
 
+import "time"
+
 type DatabaseConfig struct {
-	URL      string `default:"sqlite3:////data/db/quarterdeck.db" desc:"the database connection URL, including the driver to use."`
-	ReadOnly bool   `split_words:"true" default:"false" desc:"if true, quarterdeck will not write to the database, only read from it"`
+	URL           string        `default:"sqlite3:////data/db/quarterdeck.db" desc:"the database connection URL, including the driver to use."`
+	ReadOnly      bool          `split_words:"true" default:"false" desc:"if true, quarterdeck will not write to the database, only read from it"`
+	UserRetention time.Duration `split_words:"true" default:"720h" desc:"the duration that deleted users can be restored before they are purged from the database"`
+	PurgeInterval time.Duration `split_words:"true" default:"1h" desc:"how often deleted users past the retention window are purged; set to 0 to disable the purge"`
 }
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"time"
+
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+)
+
+// Configures the delivery of webhook events. Events are queued in the database when
+// they occur and delivered by a background routine; failed deliveries are retried with
+// an exponential backoff until they succeed or the maximum number of attempts is made.
+type WebhookConfig struct {
+	PollInterval   time.Duration `split_words:"true" default:"10s" desc:"how often the delivery queue is checked for webhook deliveries that are due"`
+	Timeout        time.Duration `default:"10s" desc:"the timeout for a single webhook delivery http request"`
+	MaxAttempts    int           `split_words:"true" default:"8" desc:"the number of attempts made to deliver an event before the delivery fails"`
+	InitialBackoff time.Duration `split_words:"true" default:"30s" desc:"the delay before the first retry of a failed delivery; doubled with each attempt"`
+	MaxBackoff     time.Duration `split_words:"true" default:"6h" desc:"the maximum delay between retries of a failed delivery"`
+	BatchSize      int           `split_words:"true" default:"50" desc:"the maximum number of deliveries attempted each time the queue is checked"`
+}
+
+func (c WebhookConfig) Validate() (err error) {
+	if c.PollInterval <= 0 {
+		err = errors.ConfigError(err, errors.RequiredConfig("webhooks", "pollInterval"))
+	}
+
+	if c.Timeout <= 0 {
+		err = errors.ConfigError(err, errors.RequiredConfig("webhooks", "timeout"))
+	}
+
+	if c.MaxAttempts <= 0 {
+		err = errors.ConfigError(err, errors.RequiredConfig("webhooks", "maxAttempts"))
+	}
+
+	if c.InitialBackoff <= 0 {
+		err = errors.ConfigError(err, errors.RequiredConfig("webhooks", "initialBackoff"))
+	}
+
+	if c.MaxBackoff < c.InitialBackoff {
+		err = errors.ConfigError(err, errors.InvalidConfig("webhooks", "maxBackoff", "must be greater than or equal to the initial backoff"))
+	}
+
+	if c.BatchSize <= 0 {
+		err = errors.ConfigError(err, errors.RequiredConfig("webhooks", "batchSize"))
+	}
+
+	return err
+}
+
+// Backoff returns the delay before the next attempt of a delivery that has been
+// attempted the specified number of times.
+func (c WebhookConfig) Backoff(attempts int) time.Duration {
+	delay := c.InitialBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= c.MaxBackoff {
+			return c.MaxBackoff
+		}
+	}
+
+	if delay > c.MaxBackoff {
+		return c.MaxBackoff
+	}
+	return delay
+}
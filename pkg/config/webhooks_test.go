@@ -0,0 +1,77 @@
+package config_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/quarterdeck/pkg/config"
+)
+
+func validWebhookConfig() config.WebhookConfig {
+	return config.WebhookConfig{
+		PollInterval:   10 * time.Second,
+		Timeout:        10 * time.Second,
+		MaxAttempts:    8,
+		InitialBackoff: 30 * time.Second,
+		MaxBackoff:     6 * time.Hour,
+		BatchSize:      50,
+	}
+}
+
+func TestWebhookConfigValidate(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		conf := validWebhookConfig()
+		require.NoError(t, conf.Validate())
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		tests := []struct {
+			modify func(*config.WebhookConfig)
+			errs   string
+		}{
+			{
+				modify: func(c *config.WebhookConfig) { c.PollInterval = 0 },
+				errs:   "invalid configuration: webhooks.pollInterval is required but not set",
+			},
+			{
+				modify: func(c *config.WebhookConfig) { c.MaxAttempts = 0 },
+				errs:   "invalid configuration: webhooks.maxAttempts is required but not set",
+			},
+			{
+				modify: func(c *config.WebhookConfig) { c.MaxBackoff = time.Second },
+				errs:   "invalid configuration: webhooks.maxBackoff must be greater than or equal to the initial backoff",
+			},
+		}
+
+		for i, tc := range tests {
+			conf := validWebhookConfig()
+			tc.modify(&conf)
+			require.EqualError(t, conf.Validate(), tc.errs, "test case %d failed", i)
+		}
+	})
+}
+
+func TestWebhookConfigBackoff(t *testing.T) {
+	conf := config.WebhookConfig{
+		InitialBackoff: 30 * time.Second,
+		MaxBackoff:     5 * time.Minute,
+	}
+
+	tests := []struct {
+		attempts int
+		expected time.Duration
+	}{
+		{0, 30 * time.Second},
+		{1, 30 * time.Second},
+		{2, 1 * time.Minute},
+		{3, 2 * time.Minute},
+		{4, 4 * time.Minute},
+		{5, 5 * time.Minute},
+		{50, 5 * time.Minute},
+	}
+
+	for i, tc := range tests {
+		require.Equal(t, tc.expected, conf.Backoff(tc.attempts), "test case %d failed", i)
+	}
+}
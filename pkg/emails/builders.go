@@ -6,9 +6,10 @@ import (
 	"html/template"
 	"net/url"
 	texttemplate "text/template"
+	"time"
 
 	"go.rtnl.ai/commo"
-	"go.rtnl.ai/quarterdeck/pkg/store/v1/models"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
 	"go.rtnl.ai/x/vero"
 )
 
@@ -42,14 +43,13 @@ type WelcomeUserEmailData struct {
 
 // RoleTitle returns the first role title for the user, or empty if none.
 func RoleTitle(user *models.User) string {
-	roles, err := user.Roles()
-	if err != nil || len(roles) == 0 {
+	if len(user.Roles) == 0 {
 		return ""
 	}
-	return roles[0].Title
+	return user.Roles[0].Title
 }
 
-// VerifyURL returns the password-reset URL including the signed verification token.
+// VerifyURL returns the accept-invite URL including the signed verification token.
 func (d WelcomeUserEmailData) VerifyURL() string {
 	if d.PasswordResetURL == nil {
 		return ""
@@ -130,3 +130,122 @@ func NewResetPasswordEmail(recipient string, data ResetPasswordEmailData) (*comm
 	subject := fmt.Sprintf("%s password reset request", data.AppName)
 	return commo.New(recipient, subject, "reset_password", data)
 }
+
+// ============================================================================
+// Account locked email
+// ============================================================================
+
+// AccountLockedEmailData is used to complete the account_locked template.
+type AccountLockedEmailData struct {
+	EmailBaseData
+	ContactName      string    // the user's name, if available
+	LockedUntil      time.Time // when the user will be able to attempt to log in again
+	ResetPasswordURL *url.URL  // the forgot password page where the user can reset their password
+}
+
+// LockedUntilString formats the end of the lockout for display in the email.
+func (d AccountLockedEmailData) LockedUntilString() string {
+	return d.LockedUntil.UTC().Format("Jan 02, 2006 at 15:04 MST")
+}
+
+// NewAccountLockedEmail builds an account_locked commo email for the recipient.
+func NewAccountLockedEmail(recipient string, data AccountLockedEmailData) (*commo.Email, error) {
+	subject := fmt.Sprintf("Your %s account has been temporarily locked", data.AppName)
+	return commo.New(recipient, subject, "account_locked", data)
+}
+
+// ============================================================================
+// Verify email
+// ============================================================================
+
+// VerifyEmailData is used to complete the verify_email template.
+type VerifyEmailData struct {
+	EmailBaseData
+	ContactName    string                 // the user's name, if available
+	VerifyEmailURL *url.URL               // the verify email page url
+	Token          vero.VerificationToken // verification token for verify email link record
+}
+
+// VerifyURL returns the verify-email URL including the signed verification token.
+func (d VerifyEmailData) VerifyURL() string {
+	if d.VerifyEmailURL == nil {
+		return ""
+	}
+
+	params := make(url.Values, 1)
+	params.Set("token", d.Token.String())
+
+	d.VerifyEmailURL.RawQuery = params.Encode()
+	return d.VerifyEmailURL.String()
+}
+
+// NewVerifyEmail builds a verify_email commo email for the recipient.
+func NewVerifyEmail(recipient string, data VerifyEmailData) (*commo.Email, error) {
+	subject := fmt.Sprintf("Verify your %s email address", data.AppName)
+	return commo.New(recipient, subject, "verify_email", data)
+}
+
+// ============================================================================
+// Change email
+// ============================================================================
+
+// ChangeEmailData is used to complete the change_email template that is sent to the
+// new email address to confirm that the user owns it.
+type ChangeEmailData struct {
+	EmailBaseData
+	ContactName string                 // the user's name, if available
+	ConfirmURL  *url.URL               // the confirm email page url
+	Token       vero.VerificationToken // verification token for change email link record
+}
+
+// VerifyURL returns the confirm-email URL including the signed verification token.
+func (d ChangeEmailData) VerifyURL() string {
+	if d.ConfirmURL == nil {
+		return ""
+	}
+
+	params := make(url.Values, 1)
+	params.Set("token", d.Token.String())
+
+	d.ConfirmURL.RawQuery = params.Encode()
+	return d.ConfirmURL.String()
+}
+
+// NewChangeEmail builds a change_email commo email for the new email address.
+func NewChangeEmail(recipient string, data ChangeEmailData) (*commo.Email, error) {
+	subject := fmt.Sprintf("Confirm your new %s email address", data.AppName)
+	return commo.New(recipient, subject, "change_email", data)
+}
+
+// ============================================================================
+// Email change notice
+// ============================================================================
+
+// EmailChangeNoticeData is used to complete the email_change_notice template that is
+// sent to the old email address so that the user can revert an unwanted change.
+type EmailChangeNoticeData struct {
+	EmailBaseData
+	ContactName    string                 // the user's name, if available
+	NewEmail       string                 // the email address the account is being changed to
+	RevertEmailURL *url.URL               // the revert email page url
+	Token          vero.VerificationToken // verification token for revert email link record
+}
+
+// RevertURL returns the revert-email URL including the signed verification token.
+func (d EmailChangeNoticeData) RevertURL() string {
+	if d.RevertEmailURL == nil {
+		return ""
+	}
+
+	params := make(url.Values, 1)
+	params.Set("token", d.Token.String())
+
+	d.RevertEmailURL.RawQuery = params.Encode()
+	return d.RevertEmailURL.String()
+}
+
+// NewEmailChangeNotice builds an email_change_notice commo email for the old email address.
+func NewEmailChangeNotice(recipient string, data EmailChangeNoticeData) (*commo.Email, error) {
+	subject := fmt.Sprintf("Your %s email address is being changed", data.AppName)
+	return commo.New(recipient, subject, "email_change_notice", data)
+}
@@ -5,6 +5,7 @@ import (
 	"html/template"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"go.rtnl.ai/quarterdeck/pkg/emails"
@@ -114,3 +115,140 @@ func TestVerifyResetPasswordURL(t *testing.T) {
 
 	require.Equal(t, "https://resetpassword.example.com/reset-password?token=YWJjMTIz", invite.VerifyURL())
 }
+
+// TestAccountLockedEmail checks the account locked templates render the lockout.
+func TestAccountLockedEmail(t *testing.T) {
+	templates := emails.LoadTemplates()
+	data := emails.AccountLockedEmailData{
+		EmailBaseData: emails.EmailBaseData{
+			AppName: "TestApp",
+			OrgName: "TestOrg",
+		},
+		ContactName:      "Jannel",
+		LockedUntil:      time.Date(2025, 3, 14, 15, 9, 26, 0, time.UTC),
+		ResetPasswordURL: &url.URL{Scheme: "https", Host: "auth.example.com", Path: "/forgot-password"},
+	}
+
+	for _, name := range []string{"account_locked.html", "account_locked.txt"} {
+		tmpl, ok := templates[name]
+		require.True(t, ok, "%s template must exist", name)
+
+		var buf bytes.Buffer
+		if name == "account_locked.html" {
+			require.NoError(t, tmpl.ExecuteTemplate(&buf, "base", data))
+		} else {
+			require.NoError(t, tmpl.Execute(&buf, data))
+		}
+
+		require.Contains(t, buf.String(), "Mar 14, 2025 at 15:09 UTC", "%s must contain the end of the lockout", name)
+		require.Contains(t, buf.String(), "https://auth.example.com/forgot-password", "%s must contain the reset password link", name)
+	}
+}
+
+// TestVerifyEmail checks the verify email link format and that the templates render it.
+func TestVerifyEmail(t *testing.T) {
+	data := emails.VerifyEmailData{
+		EmailBaseData: emails.EmailBaseData{
+			AppName: "TestApp",
+			OrgName: "TestOrg",
+		},
+		ContactName: "Jannel",
+		VerifyEmailURL: &url.URL{
+			Scheme: "https",
+			Host:   "auth.example.com",
+			Path:   "/verify-email",
+		},
+		Token: vero.VerificationToken("abc123"),
+	}
+
+	expected := "https://auth.example.com/verify-email?token=YWJjMTIz"
+	require.Equal(t, expected, data.VerifyURL())
+
+	templates := emails.LoadTemplates()
+	for _, name := range []string{"verify_email.html", "verify_email.txt"} {
+		tmpl, ok := templates[name]
+		require.True(t, ok, "%s template must exist", name)
+
+		var buf bytes.Buffer
+		if name == "verify_email.html" {
+			require.NoError(t, tmpl.ExecuteTemplate(&buf, "base", data))
+		} else {
+			require.NoError(t, tmpl.Execute(&buf, data))
+		}
+
+		require.Contains(t, buf.String(), expected, "%s must contain the verify email link", name)
+	}
+}
+
+// TestChangeEmail checks the confirm email link format and that the templates render it.
+func TestChangeEmail(t *testing.T) {
+	data := emails.ChangeEmailData{
+		EmailBaseData: emails.EmailBaseData{
+			AppName: "TestApp",
+			OrgName: "TestOrg",
+		},
+		ContactName: "Jannel",
+		ConfirmURL: &url.URL{
+			Scheme: "https",
+			Host:   "auth.example.com",
+			Path:   "/confirm-email",
+		},
+		Token: vero.VerificationToken("abc123"),
+	}
+
+	expected := "https://auth.example.com/confirm-email?token=YWJjMTIz"
+	require.Equal(t, expected, data.VerifyURL())
+
+	templates := emails.LoadTemplates()
+	for _, name := range []string{"change_email.html", "change_email.txt"} {
+		tmpl, ok := templates[name]
+		require.True(t, ok, "%s template must exist", name)
+
+		var buf bytes.Buffer
+		if name == "change_email.html" {
+			require.NoError(t, tmpl.ExecuteTemplate(&buf, "base", data))
+		} else {
+			require.NoError(t, tmpl.Execute(&buf, data))
+		}
+
+		require.Contains(t, buf.String(), expected, "%s must contain the confirm email link", name)
+	}
+}
+
+// TestEmailChangeNotice checks the revert email link format and that the templates
+// render it along with the new email address.
+func TestEmailChangeNotice(t *testing.T) {
+	data := emails.EmailChangeNoticeData{
+		EmailBaseData: emails.EmailBaseData{
+			AppName: "TestApp",
+			OrgName: "TestOrg",
+		},
+		ContactName: "Jannel",
+		NewEmail:    "jannel@example.com",
+		RevertEmailURL: &url.URL{
+			Scheme: "https",
+			Host:   "auth.example.com",
+			Path:   "/revert-email",
+		},
+		Token: vero.VerificationToken("abc123"),
+	}
+
+	expected := "https://auth.example.com/revert-email?token=YWJjMTIz"
+	require.Equal(t, expected, data.RevertURL())
+
+	templates := emails.LoadTemplates()
+	for _, name := range []string{"email_change_notice.html", "email_change_notice.txt"} {
+		tmpl, ok := templates[name]
+		require.True(t, ok, "%s template must exist", name)
+
+		var buf bytes.Buffer
+		if name == "email_change_notice.html" {
+			require.NoError(t, tmpl.ExecuteTemplate(&buf, "base", data))
+		} else {
+			require.NoError(t, tmpl.Execute(&buf, data))
+		}
+
+		require.Contains(t, buf.String(), expected, "%s must contain the revert email link", name)
+		require.Contains(t, buf.String(), data.NewEmail, "%s must contain the new email address", name)
+	}
+}
@@ -0,0 +1,130 @@
+package enum
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// DeliveryStatus describes the progress of a webhook delivery. Pending deliveries are
+// retried with an exponential backoff until they are delivered or they fail after the
+// maximum number of attempts; failed deliveries can be manually redelivered.
+type DeliveryStatus uint8
+
+const (
+	DeliveryStatusUnknown DeliveryStatus = iota
+	DeliveryStatusPending
+	DeliveryStatusDelivered
+	DeliveryStatusFailed
+
+	// The terminator is used to determine the last value of the enum. It should be
+	// the last value in the list and is automatically incremented when enums are
+	// added above it.
+	// NOTE: you should not reorder the enums, just append them to the list above
+	// to add new values.
+	deliveryStatusTerminator
+)
+
+var deliveryStatusNames = [4]string{
+	"unknown", "pending", "delivered", "failed",
+}
+
+// Returns true if the provided delivery status is valid (e.g. parseable), false otherwise.
+func ValidDeliveryStatus(s interface{}) bool {
+	if status, err := ParseDeliveryStatus(s); err != nil || status >= deliveryStatusTerminator {
+		return false
+	}
+	return true
+}
+
+// Returns true if the delivery status is equal to one of the target statuses. Any parse
+// errors for the delivery status are returned.
+func CheckDeliveryStatus(s interface{}, targets ...DeliveryStatus) (_ bool, err error) {
+	var status DeliveryStatus
+	if status, err = ParseDeliveryStatus(s); err != nil {
+		return false, err
+	}
+
+	for _, target := range targets {
+		if status == target {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// Parse the delivery status from the provided value.
+func ParseDeliveryStatus(s interface{}) (DeliveryStatus, error) {
+	switch s := s.(type) {
+	case string:
+		s = strings.ToLower(s)
+		if s == "" {
+			return DeliveryStatusUnknown, nil
+		}
+
+		for i, name := range deliveryStatusNames {
+			if name == s {
+				return DeliveryStatus(i), nil
+			}
+		}
+		return DeliveryStatusUnknown, fmt.Errorf("invalid delivery status: %q", s)
+	case uint8:
+		return DeliveryStatus(s), nil
+	case DeliveryStatus:
+		return s, nil
+	default:
+		return DeliveryStatusUnknown, fmt.Errorf("cannot parse %T into a delivery status", s)
+	}
+}
+
+// Return a string representation of the delivery status.
+func (s DeliveryStatus) String() string {
+	if s >= deliveryStatusTerminator {
+		return deliveryStatusNames[0]
+	}
+	return deliveryStatusNames[s]
+}
+
+//===========================================================================
+// Serialization and Deserialization
+//===========================================================================
+
+func (s DeliveryStatus) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+func (s *DeliveryStatus) UnmarshalJSON(b []byte) (err error) {
+	var src string
+	if err = json.Unmarshal(b, &src); err != nil {
+		return err
+	}
+	if *s, err = ParseDeliveryStatus(src); err != nil {
+		return err
+	}
+	return nil
+}
+
+//===========================================================================
+// Database Interaction
+//===========================================================================
+
+func (s *DeliveryStatus) Scan(src interface{}) (err error) {
+	switch x := src.(type) {
+	case nil:
+		return nil
+	case string:
+		*s, err = ParseDeliveryStatus(x)
+		return err
+	case []byte:
+		*s, err = ParseDeliveryStatus(string(x))
+		return err
+	}
+
+	return fmt.Errorf("cannot scan %T into a delivery status", src)
+}
+
+func (s DeliveryStatus) Value() (driver.Value, error) {
+	return s.String(), nil
+}
@@ -0,0 +1,181 @@
+package enum_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/quarterdeck/pkg/enum"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+)
+
+func TestValidDeliveryStatus(t *testing.T) {
+	tests := []struct {
+		input  interface{}
+		assert require.BoolAssertionFunc
+	}{
+		{"", require.True},
+		{"unknown", require.True},
+		{"pending", require.True},
+		{"delivered", require.True},
+		{"failed", require.True},
+		{uint8(0), require.True},
+		{uint8(1), require.True},
+		{uint8(2), require.True},
+		{uint8(3), require.True},
+		{enum.DeliveryStatusUnknown, require.True},
+		{enum.DeliveryStatusPending, require.True},
+		{enum.DeliveryStatusDelivered, require.True},
+		{enum.DeliveryStatusFailed, require.True},
+		{"foo", require.False},
+		{true, require.False},
+		{uint8(99), require.False},
+	}
+
+	for i, tc := range tests {
+		tc.assert(t, enum.ValidDeliveryStatus(tc.input), "test case %d failed", i)
+	}
+}
+
+func TestCheckDeliveryStatus(t *testing.T) {
+	tests := []struct {
+		input   interface{}
+		targets []enum.DeliveryStatus
+		assert  require.BoolAssertionFunc
+		err     error
+	}{
+		{"", []enum.DeliveryStatus{enum.DeliveryStatusUnknown, enum.DeliveryStatusPending}, require.True, nil},
+		{"delivered", []enum.DeliveryStatus{enum.DeliveryStatusPending, enum.DeliveryStatusDelivered}, require.True, nil},
+		{"failed", []enum.DeliveryStatus{enum.DeliveryStatusPending, enum.DeliveryStatusDelivered}, require.False, nil},
+		{"foo", []enum.DeliveryStatus{enum.DeliveryStatusPending, enum.DeliveryStatusDelivered}, require.False, errors.New(`invalid delivery status: "foo"`)},
+		{"", []enum.DeliveryStatus{enum.DeliveryStatusPending, enum.DeliveryStatusDelivered}, require.False, nil},
+	}
+
+	for i, tc := range tests {
+		result, err := enum.CheckDeliveryStatus(tc.input, tc.targets...)
+		tc.assert(t, result, "test case %d failed", i)
+
+		if tc.err != nil {
+			require.Equal(t, tc.err, err, "test case %d failed", i)
+		} else {
+			require.NoError(t, err, "test case %d failed", i)
+		}
+	}
+}
+
+func TestParseDeliveryStatus(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		tests := []struct {
+			input    interface{}
+			expected enum.DeliveryStatus
+		}{
+			{"", enum.DeliveryStatusUnknown},
+			{"unknown", enum.DeliveryStatusUnknown},
+			{"pending", enum.DeliveryStatusPending},
+			{"delivered", enum.DeliveryStatusDelivered},
+			{"failed", enum.DeliveryStatusFailed},
+			{uint8(0), enum.DeliveryStatusUnknown},
+			{uint8(1), enum.DeliveryStatusPending},
+			{uint8(2), enum.DeliveryStatusDelivered},
+			{uint8(3), enum.DeliveryStatusFailed},
+			{enum.DeliveryStatusUnknown, enum.DeliveryStatusUnknown},
+			{enum.DeliveryStatusPending, enum.DeliveryStatusPending},
+			{enum.DeliveryStatusDelivered, enum.DeliveryStatusDelivered},
+			{enum.DeliveryStatusFailed, enum.DeliveryStatusFailed},
+		}
+
+		for i, test := range tests {
+			result, err := enum.ParseDeliveryStatus(test.input)
+			require.NoError(t, err, "test case %d failed", i)
+			require.Equal(t, test.expected, result, "test case %d failed", i)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		tests := []struct {
+			input interface{}
+			errs  string
+		}{
+			{"foo", "invalid delivery status: \"foo\""},
+			{true, "cannot parse bool into a delivery status"},
+		}
+
+		for i, test := range tests {
+			result, err := enum.ParseDeliveryStatus(test.input)
+			require.Equal(t, enum.DeliveryStatusUnknown, result, "test case %d failed", i)
+			require.EqualError(t, err, test.errs, "test case %d failed", i)
+		}
+	})
+}
+
+func TestDeliveryStatusString(t *testing.T) {
+	tests := []struct {
+		tt       enum.DeliveryStatus
+		expected string
+	}{
+		{enum.DeliveryStatusUnknown, "unknown"},
+		{enum.DeliveryStatusPending, "pending"},
+		{enum.DeliveryStatusDelivered, "delivered"},
+		{enum.DeliveryStatusFailed, "failed"},
+		{enum.DeliveryStatus(99), "unknown"},
+	}
+
+	for i, test := range tests {
+		result := test.tt.String()
+		require.Equal(t, test.expected, result, "test case %d failed", i)
+	}
+}
+
+func TestDeliveryStatusJSON(t *testing.T) {
+	tests := []enum.DeliveryStatus{
+		enum.DeliveryStatusUnknown, enum.DeliveryStatusPending, enum.DeliveryStatusDelivered, enum.DeliveryStatusFailed,
+	}
+
+	for _, tt := range tests {
+		data, err := json.Marshal(tt)
+		require.NoError(t, err)
+
+		var result enum.DeliveryStatus
+		err = json.Unmarshal(data, &result)
+		require.NoError(t, err)
+		require.Equal(t, tt, result)
+	}
+}
+
+func TestDeliveryStatusScan(t *testing.T) {
+	tests := []struct {
+		input    interface{}
+		expected enum.DeliveryStatus
+	}{
+		{nil, enum.DeliveryStatusUnknown},
+		{"", enum.DeliveryStatusUnknown},
+		{"unknown", enum.DeliveryStatusUnknown},
+		{"pending", enum.DeliveryStatusPending},
+		{"delivered", enum.DeliveryStatusDelivered},
+		{"failed", enum.DeliveryStatusFailed},
+		{[]byte(""), enum.DeliveryStatusUnknown},
+		{[]byte("unknown"), enum.DeliveryStatusUnknown},
+		{[]byte("pending"), enum.DeliveryStatusPending},
+		{[]byte("delivered"), enum.DeliveryStatusDelivered},
+		{[]byte("failed"), enum.DeliveryStatusFailed},
+	}
+
+	for i, test := range tests {
+		var tt enum.DeliveryStatus
+		err := tt.Scan(test.input)
+		require.NoError(t, err, "test case %d failed", i)
+		require.Equal(t, test.expected, tt, "test case %d failed", i)
+	}
+
+	var d enum.DeliveryStatus
+	err := d.Scan("foo")
+	require.EqualError(t, err, "invalid delivery status: \"foo\"")
+	err = d.Scan(true)
+	require.EqualError(t, err, "cannot scan bool into a delivery status")
+}
+
+func TestDeliveryStatusValue(t *testing.T) {
+	value, err := enum.DeliveryStatusDelivered.Value()
+	require.NoError(t, err)
+	require.Equal(t, "delivered", value)
+}
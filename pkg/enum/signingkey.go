@@ -0,0 +1,138 @@
+package enum
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// KeyState describes the lifecycle of a token signing key. A next key is published in
+// the JWKS before it begins signing so that clients can fetch it ahead of time; the
+// active key signs all new tokens; a retiring key no longer signs but is published
+// until every token it signed has expired, after which it is retired and removed.
+type KeyState uint8
+
+const (
+	KeyStateUnknown KeyState = iota
+	KeyStateNext
+	KeyStateActive
+	KeyStateRetiring
+	KeyStateRetired
+
+	// The terminator is used to determine the last value of the enum. It should be
+	// the last value in the list and is automatically incremented when enums are
+	// added above it.
+	// NOTE: you should not reorder the enums, just append them to the list above
+	// to add new values.
+	keyStateTerminator
+)
+
+var keyStateNames = [5]string{
+	"unknown", "next", "active", "retiring", "retired",
+}
+
+// Returns true if the provided key state is valid (e.g. parseable), false otherwise.
+func ValidKeyState(s interface{}) bool {
+	if state, err := ParseKeyState(s); err != nil || state >= keyStateTerminator {
+		return false
+	}
+	return true
+}
+
+// Returns true if the key state is equal to one of the target states. Any parse
+// errors for the key state are returned.
+func CheckKeyState(s interface{}, targets ...KeyState) (_ bool, err error) {
+	var state KeyState
+	if state, err = ParseKeyState(s); err != nil {
+		return false, err
+	}
+
+	for _, target := range targets {
+		if state == target {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// Parse the key state from the provided value.
+func ParseKeyState(s interface{}) (KeyState, error) {
+	switch s := s.(type) {
+	case string:
+		s = strings.ToLower(s)
+		if s == "" {
+			return KeyStateUnknown, nil
+		}
+
+		for i, name := range keyStateNames {
+			if name == s {
+				return KeyState(i), nil
+			}
+		}
+		return KeyStateUnknown, fmt.Errorf("invalid key state: %q", s)
+	case uint8:
+		return KeyState(s), nil
+	case KeyState:
+		return s, nil
+	default:
+		return KeyStateUnknown, fmt.Errorf("cannot parse %T into a key state", s)
+	}
+}
+
+// Return a string representation of the key state.
+func (s KeyState) String() string {
+	if s >= keyStateTerminator {
+		return keyStateNames[0]
+	}
+	return keyStateNames[s]
+}
+
+// Published returns true if keys in this state are published in the JWKS and are used
+// to verify tokens; only the active key is used to sign tokens.
+func (s KeyState) Published() bool {
+	return s == KeyStateNext || s == KeyStateActive || s == KeyStateRetiring
+}
+
+//===========================================================================
+// Serialization and Deserialization
+//===========================================================================
+
+func (s KeyState) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+func (s *KeyState) UnmarshalJSON(b []byte) (err error) {
+	var src string
+	if err = json.Unmarshal(b, &src); err != nil {
+		return err
+	}
+	if *s, err = ParseKeyState(src); err != nil {
+		return err
+	}
+	return nil
+}
+
+//===========================================================================
+// Database Interaction
+//===========================================================================
+
+func (s *KeyState) Scan(src interface{}) (err error) {
+	switch x := src.(type) {
+	case nil:
+		return nil
+	case string:
+		*s, err = ParseKeyState(x)
+		return err
+	case []byte:
+		*s, err = ParseKeyState(string(x))
+		return err
+	}
+
+	return fmt.Errorf("cannot scan %T into a key state", src)
+}
+
+func (s KeyState) Value() (driver.Value, error) {
+	return s.String(), nil
+}
@@ -0,0 +1,177 @@
+package enum_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/quarterdeck/pkg/enum"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+)
+
+func TestValidKeyState(t *testing.T) {
+	tests := []struct {
+		input  interface{}
+		assert require.BoolAssertionFunc
+	}{
+		{"", require.True},
+		{"unknown", require.True},
+		{"next", require.True},
+		{"active", require.True},
+		{"retiring", require.True},
+		{"retired", require.True},
+		{uint8(0), require.True},
+		{uint8(4), require.True},
+		{enum.KeyStateUnknown, require.True},
+		{enum.KeyStateNext, require.True},
+		{enum.KeyStateActive, require.True},
+		{enum.KeyStateRetiring, require.True},
+		{enum.KeyStateRetired, require.True},
+		{"foo", require.False},
+		{true, require.False},
+		{uint8(99), require.False},
+	}
+
+	for i, tc := range tests {
+		tc.assert(t, enum.ValidKeyState(tc.input), "test case %d failed", i)
+	}
+}
+
+func TestCheckKeyState(t *testing.T) {
+	tests := []struct {
+		input   interface{}
+		targets []enum.KeyState
+		assert  require.BoolAssertionFunc
+		err     error
+	}{
+		{"", []enum.KeyState{enum.KeyStateUnknown, enum.KeyStateNext}, require.True, nil},
+		{"active", []enum.KeyState{enum.KeyStateNext, enum.KeyStateActive}, require.True, nil},
+		{"retired", []enum.KeyState{enum.KeyStateNext, enum.KeyStateActive}, require.False, nil},
+		{"foo", []enum.KeyState{enum.KeyStateNext, enum.KeyStateActive}, require.False, errors.New(`invalid key state: "foo"`)},
+	}
+
+	for i, tc := range tests {
+		result, err := enum.CheckKeyState(tc.input, tc.targets...)
+		tc.assert(t, result, "test case %d failed", i)
+
+		if tc.err != nil {
+			require.Equal(t, tc.err, err, "test case %d failed", i)
+		} else {
+			require.NoError(t, err, "test case %d failed", i)
+		}
+	}
+}
+
+func TestParseKeyState(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		tests := []struct {
+			input    interface{}
+			expected enum.KeyState
+		}{
+			{"", enum.KeyStateUnknown},
+			{"unknown", enum.KeyStateUnknown},
+			{"next", enum.KeyStateNext},
+			{"ACTIVE", enum.KeyStateActive},
+			{"retiring", enum.KeyStateRetiring},
+			{"retired", enum.KeyStateRetired},
+			{uint8(1), enum.KeyStateNext},
+			{uint8(4), enum.KeyStateRetired},
+			{enum.KeyStateRetiring, enum.KeyStateRetiring},
+		}
+
+		for i, test := range tests {
+			result, err := enum.ParseKeyState(test.input)
+			require.NoError(t, err, "test case %d failed", i)
+			require.Equal(t, test.expected, result, "test case %d failed", i)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		tests := []struct {
+			input interface{}
+			errs  string
+		}{
+			{"foo", "invalid key state: \"foo\""},
+			{true, "cannot parse bool into a key state"},
+		}
+
+		for i, test := range tests {
+			result, err := enum.ParseKeyState(test.input)
+			require.Equal(t, enum.KeyStateUnknown, result, "test case %d failed", i)
+			require.EqualError(t, err, test.errs, "test case %d failed", i)
+		}
+	})
+}
+
+func TestKeyStateString(t *testing.T) {
+	tests := []struct {
+		tt       enum.KeyState
+		expected string
+	}{
+		{enum.KeyStateUnknown, "unknown"},
+		{enum.KeyStateNext, "next"},
+		{enum.KeyStateActive, "active"},
+		{enum.KeyStateRetiring, "retiring"},
+		{enum.KeyStateRetired, "retired"},
+		{enum.KeyState(99), "unknown"},
+	}
+
+	for i, test := range tests {
+		require.Equal(t, test.expected, test.tt.String(), "test case %d failed", i)
+	}
+}
+
+func TestKeyStatePublished(t *testing.T) {
+	require.False(t, enum.KeyStateUnknown.Published())
+	require.True(t, enum.KeyStateNext.Published())
+	require.True(t, enum.KeyStateActive.Published())
+	require.True(t, enum.KeyStateRetiring.Published())
+	require.False(t, enum.KeyStateRetired.Published())
+}
+
+func TestKeyStateJSON(t *testing.T) {
+	tests := []enum.KeyState{
+		enum.KeyStateUnknown, enum.KeyStateNext, enum.KeyStateActive, enum.KeyStateRetiring, enum.KeyStateRetired,
+	}
+
+	for _, tt := range tests {
+		data, err := json.Marshal(tt)
+		require.NoError(t, err)
+
+		var result enum.KeyState
+		err = json.Unmarshal(data, &result)
+		require.NoError(t, err)
+		require.Equal(t, tt, result)
+	}
+}
+
+func TestKeyStateScan(t *testing.T) {
+	tests := []struct {
+		input    interface{}
+		expected enum.KeyState
+	}{
+		{nil, enum.KeyStateUnknown},
+		{"", enum.KeyStateUnknown},
+		{"next", enum.KeyStateNext},
+		{"active", enum.KeyStateActive},
+		{[]byte("retiring"), enum.KeyStateRetiring},
+		{[]byte("retired"), enum.KeyStateRetired},
+	}
+
+	for i, test := range tests {
+		var tt enum.KeyState
+		err := tt.Scan(test.input)
+		require.NoError(t, err, "test case %d failed", i)
+		require.Equal(t, test.expected, tt, "test case %d failed", i)
+	}
+
+	var s enum.KeyState
+	require.EqualError(t, s.Scan("foo"), "invalid key state: \"foo\"")
+	require.EqualError(t, s.Scan(true), "cannot scan bool into a key state")
+}
+
+func TestKeyStateValue(t *testing.T) {
+	value, err := enum.KeyStateActive.Value()
+	require.NoError(t, err)
+	require.Equal(t, "active", value)
+}
@@ -20,6 +20,8 @@ const (
 	TokenTypeResetPassword
 	TokenTypeVerifyEmail
 	TokenTypeTeamInvite
+	TokenTypeChangeEmail
+	TokenTypeRevertEmail
 
 	// The terminator is used to determine the last value of the enum. It should be
 	// the last value in the list and is automatically incremented when enums are
@@ -29,8 +31,9 @@ const (
 	tokenTypeTerminator
 )
 
-var tokenTypeNames = [4]string{
-	"unknown", "reset_password", "verify_email", "team_invite",
+var tokenTypeNames = [6]string{
+	"unknown", "reset_password", "verify_email", "team_invite", "change_email",
+	"revert_email",
 }
 
 // Returns true if the provided token type is valid (e.g. parseable), false otherwise.
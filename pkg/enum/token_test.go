@@ -27,6 +27,8 @@ func TestValidTokenTypes(t *testing.T) {
 		{enum.TokenTypeResetPassword, require.True},
 		{enum.TokenTypeVerifyEmail, require.True},
 		{enum.TokenTypeTeamInvite, require.True},
+		{enum.TokenTypeChangeEmail, require.True},
+		{enum.TokenTypeRevertEmail, require.True},
 		{"foo", require.False},
 		{true, require.False},
 		{uint8(99), require.False},
@@ -76,10 +78,14 @@ func TestParseTokenType(t *testing.T) {
 			{"reset_password", enum.TokenTypeResetPassword},
 			{"verify_email", enum.TokenTypeVerifyEmail},
 			{"team_invite", enum.TokenTypeTeamInvite},
+			{"change_email", enum.TokenTypeChangeEmail},
+			{"revert_email", enum.TokenTypeRevertEmail},
 			{uint8(0), enum.TokenTypeUnknown},
 			{uint8(1), enum.TokenTypeResetPassword},
 			{uint8(2), enum.TokenTypeVerifyEmail},
 			{uint8(3), enum.TokenTypeTeamInvite},
+			{uint8(4), enum.TokenTypeChangeEmail},
+			{uint8(5), enum.TokenTypeRevertEmail},
 			{enum.TokenTypeUnknown, enum.TokenTypeUnknown},
 			{enum.TokenTypeResetPassword, enum.TokenTypeResetPassword},
 			{enum.TokenTypeVerifyEmail, enum.TokenTypeVerifyEmail},
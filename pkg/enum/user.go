@@ -0,0 +1,131 @@
+package enum
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// UserStatus determines whether a user account can be used to log in. Suspended and
+// deactivated users cannot log in but are still listed; deleted users are hidden and
+// can be restored until they are purged at the end of the retention period.
+type UserStatus uint8
+
+const (
+	UserStatusUnknown UserStatus = iota
+	UserStatusActive
+	UserStatusSuspended
+	UserStatusDeactivated
+	UserStatusDeleted
+
+	// The terminator is used to determine the last value of the enum. It should be
+	// the last value in the list and is automatically incremented when enums are
+	// added above it.
+	// NOTE: you should not reorder the enums, just append them to the list above
+	// to add new values.
+	userStatusTerminator
+)
+
+var userStatusNames = [5]string{
+	"unknown", "active", "suspended", "deactivated", "deleted",
+}
+
+// Returns true if the provided user status is valid (e.g. parseable), false otherwise.
+func ValidUserStatus(s interface{}) bool {
+	if status, err := ParseUserStatus(s); err != nil || status >= userStatusTerminator {
+		return false
+	}
+	return true
+}
+
+// Returns true if the user status is equal to one of the target statuses. Any parse
+// errors for the user status are returned.
+func CheckUserStatus(s interface{}, targets ...UserStatus) (_ bool, err error) {
+	var status UserStatus
+	if status, err = ParseUserStatus(s); err != nil {
+		return false, err
+	}
+
+	for _, target := range targets {
+		if status == target {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// Parse the user status from the provided value.
+func ParseUserStatus(s interface{}) (UserStatus, error) {
+	switch s := s.(type) {
+	case string:
+		s = strings.ToLower(s)
+		if s == "" {
+			return UserStatusUnknown, nil
+		}
+
+		for i, name := range userStatusNames {
+			if name == s {
+				return UserStatus(i), nil
+			}
+		}
+		return UserStatusUnknown, fmt.Errorf("invalid user status: %q", s)
+	case uint8:
+		return UserStatus(s), nil
+	case UserStatus:
+		return s, nil
+	default:
+		return UserStatusUnknown, fmt.Errorf("cannot parse %T into an user status", s)
+	}
+}
+
+// Return a string representation of the user status.
+func (s UserStatus) String() string {
+	if s >= userStatusTerminator {
+		return userStatusNames[0]
+	}
+	return userStatusNames[s]
+}
+
+//===========================================================================
+// Serialization and Deserialization
+//===========================================================================
+
+func (s UserStatus) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+func (s *UserStatus) UnmarshalJSON(b []byte) (err error) {
+	var src string
+	if err = json.Unmarshal(b, &src); err != nil {
+		return err
+	}
+	if *s, err = ParseUserStatus(src); err != nil {
+		return err
+	}
+	return nil
+}
+
+//===========================================================================
+// Database Interaction
+//===========================================================================
+
+func (s *UserStatus) Scan(src interface{}) (err error) {
+	switch x := src.(type) {
+	case nil:
+		return nil
+	case string:
+		*s, err = ParseUserStatus(x)
+		return err
+	case []byte:
+		*s, err = ParseUserStatus(string(x))
+		return err
+	}
+
+	return fmt.Errorf("cannot scan %T into an user status", src)
+}
+
+func (s UserStatus) Value() (driver.Value, error) {
+	return s.String(), nil
+}
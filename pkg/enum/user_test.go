@@ -0,0 +1,194 @@
+package enum_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/quarterdeck/pkg/enum"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+)
+
+func TestValidUserStatus(t *testing.T) {
+	tests := []struct {
+		input  interface{}
+		assert require.BoolAssertionFunc
+	}{
+		{"", require.True},
+		{"unknown", require.True},
+		{"active", require.True},
+		{"suspended", require.True},
+		{"deactivated", require.True},
+		{"deleted", require.True},
+		{uint8(0), require.True},
+		{uint8(1), require.True},
+		{uint8(2), require.True},
+		{uint8(3), require.True},
+		{uint8(4), require.True},
+		{enum.UserStatusUnknown, require.True},
+		{enum.UserStatusActive, require.True},
+		{enum.UserStatusSuspended, require.True},
+		{enum.UserStatusDeactivated, require.True},
+		{enum.UserStatusDeleted, require.True},
+		{"foo", require.False},
+		{true, require.False},
+		{uint8(99), require.False},
+	}
+
+	for i, tc := range tests {
+		tc.assert(t, enum.ValidUserStatus(tc.input), "test case %d failed", i)
+	}
+}
+
+func TestCheckUserStatus(t *testing.T) {
+	tests := []struct {
+		input   interface{}
+		targets []enum.UserStatus
+		assert  require.BoolAssertionFunc
+		err     error
+	}{
+		{"", []enum.UserStatus{enum.UserStatusUnknown, enum.UserStatusActive, enum.UserStatusDeactivated}, require.True, nil},
+		{"unknown", []enum.UserStatus{enum.UserStatusActive, enum.UserStatusSuspended, enum.UserStatusUnknown}, require.True, nil},
+		{"deleted", []enum.UserStatus{enum.UserStatusDeactivated, enum.UserStatusSuspended}, require.False, nil},
+		{"foo", []enum.UserStatus{enum.UserStatusActive, enum.UserStatusDeactivated}, require.False, errors.New(`invalid user status: "foo"`)},
+		{"", []enum.UserStatus{enum.UserStatusActive, enum.UserStatusDeactivated}, require.False, nil},
+		{"unknown", []enum.UserStatus{enum.UserStatusDeactivated, enum.UserStatusActive}, require.False, nil},
+		{"suspended", []enum.UserStatus{enum.UserStatusActive, enum.UserStatusDeactivated}, require.False, nil},
+	}
+
+	for i, tc := range tests {
+		result, err := enum.CheckUserStatus(tc.input, tc.targets...)
+		tc.assert(t, result, "test case %d failed", i)
+
+		if tc.err != nil {
+			require.Equal(t, tc.err, err, "test case %d failed", i)
+		} else {
+			require.NoError(t, err, "test case %d failed", i)
+		}
+	}
+}
+
+func TestParseUserStatus(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		tests := []struct {
+			input    interface{}
+			expected enum.UserStatus
+		}{
+			{"", enum.UserStatusUnknown},
+			{"unknown", enum.UserStatusUnknown},
+			{"active", enum.UserStatusActive},
+			{"suspended", enum.UserStatusSuspended},
+			{"deactivated", enum.UserStatusDeactivated},
+			{"deleted", enum.UserStatusDeleted},
+			{uint8(0), enum.UserStatusUnknown},
+			{uint8(1), enum.UserStatusActive},
+			{uint8(2), enum.UserStatusSuspended},
+			{uint8(3), enum.UserStatusDeactivated},
+			{uint8(4), enum.UserStatusDeleted},
+			{enum.UserStatusUnknown, enum.UserStatusUnknown},
+			{enum.UserStatusActive, enum.UserStatusActive},
+			{enum.UserStatusSuspended, enum.UserStatusSuspended},
+			{enum.UserStatusDeactivated, enum.UserStatusDeactivated},
+			{enum.UserStatusDeleted, enum.UserStatusDeleted},
+		}
+
+		for i, test := range tests {
+			result, err := enum.ParseUserStatus(test.input)
+			require.NoError(t, err, "test case %d failed", i)
+			require.Equal(t, test.expected, result, "test case %d failed", i)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		tests := []struct {
+			input interface{}
+			errs  string
+		}{
+			{"foo", "invalid user status: \"foo\""},
+			{true, "cannot parse bool into an user status"},
+		}
+
+		for i, test := range tests {
+			result, err := enum.ParseUserStatus(test.input)
+			require.Equal(t, enum.UserStatusUnknown, result, "test case %d failed", i)
+			require.EqualError(t, err, test.errs, "test case %d failed", i)
+		}
+	})
+}
+
+func TestUserStatusString(t *testing.T) {
+	tests := []struct {
+		tt       enum.UserStatus
+		expected string
+	}{
+		{enum.UserStatusUnknown, "unknown"},
+		{enum.UserStatusActive, "active"},
+		{enum.UserStatusSuspended, "suspended"},
+		{enum.UserStatusDeactivated, "deactivated"},
+		{enum.UserStatusDeleted, "deleted"},
+		{enum.UserStatus(99), "unknown"},
+	}
+
+	for i, test := range tests {
+		result := test.tt.String()
+		require.Equal(t, test.expected, result, "test case %d failed", i)
+	}
+}
+
+func TestUserStatusJSON(t *testing.T) {
+	tests := []enum.UserStatus{
+		enum.UserStatusUnknown, enum.UserStatusActive,
+		enum.UserStatusSuspended, enum.UserStatusDeactivated,
+		enum.UserStatusDeleted,
+	}
+
+	for _, tt := range tests {
+		data, err := json.Marshal(tt)
+		require.NoError(t, err)
+
+		var result enum.UserStatus
+		err = json.Unmarshal(data, &result)
+		require.NoError(t, err)
+		require.Equal(t, tt, result)
+	}
+}
+
+func TestUserStatusScan(t *testing.T) {
+	tests := []struct {
+		input    interface{}
+		expected enum.UserStatus
+	}{
+		{nil, enum.UserStatusUnknown},
+		{"", enum.UserStatusUnknown},
+		{"unknown", enum.UserStatusUnknown},
+		{"active", enum.UserStatusActive},
+		{"suspended", enum.UserStatusSuspended},
+		{"deactivated", enum.UserStatusDeactivated},
+		{"deleted", enum.UserStatusDeleted},
+		{[]byte(""), enum.UserStatusUnknown},
+		{[]byte("unknown"), enum.UserStatusUnknown},
+		{[]byte("active"), enum.UserStatusActive},
+		{[]byte("suspended"), enum.UserStatusSuspended},
+		{[]byte("deactivated"), enum.UserStatusDeactivated},
+		{[]byte("deleted"), enum.UserStatusDeleted},
+	}
+
+	for i, test := range tests {
+		var tt enum.UserStatus
+		err := tt.Scan(test.input)
+		require.NoError(t, err, "test case %d failed", i)
+		require.Equal(t, test.expected, tt, "test case %d failed", i)
+	}
+
+	var d enum.UserStatus
+	err := d.Scan("foo")
+	require.EqualError(t, err, "invalid user status: \"foo\"")
+	err = d.Scan(true)
+	require.EqualError(t, err, "cannot scan bool into an user status")
+}
+
+func TestUserStatusValue(t *testing.T) {
+	value, err := enum.UserStatusSuspended.Value()
+	require.NoError(t, err)
+	require.Equal(t, "suspended", value)
+}
@@ -0,0 +1,131 @@
+package enum
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// WebhookEvent identifies the kind of event that webhook subscriptions are registered
+// for; the name of the event is sent to the webhook with the payload.
+type WebhookEvent uint8
+
+const (
+	WebhookEventUnknown WebhookEvent = iota
+	WebhookEventUserCreated
+	WebhookEventUserUpdated
+	WebhookEventUserDeleted
+	WebhookEventAPIKeyRevoked
+	WebhookEventLogin
+
+	// The terminator is used to determine the last value of the enum. It should be
+	// the last value in the list and is automatically incremented when enums are
+	// added above it.
+	// NOTE: you should not reorder the enums, just append them to the list above
+	// to add new values.
+	webhookEventTerminator
+)
+
+var webhookEventNames = [6]string{
+	"unknown", "user.created", "user.updated", "user.deleted", "apikey.revoked", "login",
+}
+
+// Returns true if the provided webhook event is valid (e.g. parseable), false otherwise.
+func ValidWebhookEvent(s interface{}) bool {
+	if event, err := ParseWebhookEvent(s); err != nil || event >= webhookEventTerminator {
+		return false
+	}
+	return true
+}
+
+// Returns true if the webhook event is equal to one of the target events. Any parse
+// errors for the webhook event are returned.
+func CheckWebhookEvent(s interface{}, targets ...WebhookEvent) (_ bool, err error) {
+	var event WebhookEvent
+	if event, err = ParseWebhookEvent(s); err != nil {
+		return false, err
+	}
+
+	for _, target := range targets {
+		if event == target {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// Parse the webhook event from the provided value.
+func ParseWebhookEvent(s interface{}) (WebhookEvent, error) {
+	switch s := s.(type) {
+	case string:
+		s = strings.ToLower(s)
+		if s == "" {
+			return WebhookEventUnknown, nil
+		}
+
+		for i, name := range webhookEventNames {
+			if name == s {
+				return WebhookEvent(i), nil
+			}
+		}
+		return WebhookEventUnknown, fmt.Errorf("invalid webhook event: %q", s)
+	case uint8:
+		return WebhookEvent(s), nil
+	case WebhookEvent:
+		return s, nil
+	default:
+		return WebhookEventUnknown, fmt.Errorf("cannot parse %T into a webhook event", s)
+	}
+}
+
+// Return a string representation of the webhook event.
+func (s WebhookEvent) String() string {
+	if s >= webhookEventTerminator {
+		return webhookEventNames[0]
+	}
+	return webhookEventNames[s]
+}
+
+//===========================================================================
+// Serialization and Deserialization
+//===========================================================================
+
+func (s WebhookEvent) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+func (s *WebhookEvent) UnmarshalJSON(b []byte) (err error) {
+	var src string
+	if err = json.Unmarshal(b, &src); err != nil {
+		return err
+	}
+	if *s, err = ParseWebhookEvent(src); err != nil {
+		return err
+	}
+	return nil
+}
+
+//===========================================================================
+// Database Interaction
+//===========================================================================
+
+func (s *WebhookEvent) Scan(src interface{}) (err error) {
+	switch x := src.(type) {
+	case nil:
+		return nil
+	case string:
+		*s, err = ParseWebhookEvent(x)
+		return err
+	case []byte:
+		*s, err = ParseWebhookEvent(string(x))
+		return err
+	}
+
+	return fmt.Errorf("cannot scan %T into a webhook event", src)
+}
+
+func (s WebhookEvent) Value() (driver.Value, error) {
+	return s.String(), nil
+}
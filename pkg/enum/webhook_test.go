@@ -0,0 +1,199 @@
+package enum_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/quarterdeck/pkg/enum"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+)
+
+func TestValidWebhookEvent(t *testing.T) {
+	tests := []struct {
+		input  interface{}
+		assert require.BoolAssertionFunc
+	}{
+		{"", require.True},
+		{"unknown", require.True},
+		{"user.created", require.True},
+		{"user.updated", require.True},
+		{"user.deleted", require.True},
+		{"apikey.revoked", require.True},
+		{"login", require.True},
+		{uint8(0), require.True},
+		{uint8(1), require.True},
+		{uint8(2), require.True},
+		{uint8(3), require.True},
+		{uint8(4), require.True},
+		{uint8(5), require.True},
+		{enum.WebhookEventUnknown, require.True},
+		{enum.WebhookEventUserCreated, require.True},
+		{enum.WebhookEventUserUpdated, require.True},
+		{enum.WebhookEventUserDeleted, require.True},
+		{enum.WebhookEventAPIKeyRevoked, require.True},
+		{enum.WebhookEventLogin, require.True},
+		{"foo", require.False},
+		{true, require.False},
+		{uint8(99), require.False},
+	}
+
+	for i, tc := range tests {
+		tc.assert(t, enum.ValidWebhookEvent(tc.input), "test case %d failed", i)
+	}
+}
+
+func TestCheckWebhookEvent(t *testing.T) {
+	tests := []struct {
+		input   interface{}
+		targets []enum.WebhookEvent
+		assert  require.BoolAssertionFunc
+		err     error
+	}{
+		{"", []enum.WebhookEvent{enum.WebhookEventUnknown, enum.WebhookEventUserCreated}, require.True, nil},
+		{"user.updated", []enum.WebhookEvent{enum.WebhookEventUserCreated, enum.WebhookEventUserUpdated}, require.True, nil},
+		{"user.deleted", []enum.WebhookEvent{enum.WebhookEventUserCreated, enum.WebhookEventUserUpdated}, require.False, nil},
+		{"foo", []enum.WebhookEvent{enum.WebhookEventUserCreated, enum.WebhookEventUserUpdated}, require.False, errors.New(`invalid webhook event: "foo"`)},
+		{"", []enum.WebhookEvent{enum.WebhookEventUserCreated, enum.WebhookEventUserUpdated}, require.False, nil},
+	}
+
+	for i, tc := range tests {
+		result, err := enum.CheckWebhookEvent(tc.input, tc.targets...)
+		tc.assert(t, result, "test case %d failed", i)
+
+		if tc.err != nil {
+			require.Equal(t, tc.err, err, "test case %d failed", i)
+		} else {
+			require.NoError(t, err, "test case %d failed", i)
+		}
+	}
+}
+
+func TestParseWebhookEvent(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		tests := []struct {
+			input    interface{}
+			expected enum.WebhookEvent
+		}{
+			{"", enum.WebhookEventUnknown},
+			{"unknown", enum.WebhookEventUnknown},
+			{"user.created", enum.WebhookEventUserCreated},
+			{"user.updated", enum.WebhookEventUserUpdated},
+			{"user.deleted", enum.WebhookEventUserDeleted},
+			{"apikey.revoked", enum.WebhookEventAPIKeyRevoked},
+			{"login", enum.WebhookEventLogin},
+			{uint8(0), enum.WebhookEventUnknown},
+			{uint8(1), enum.WebhookEventUserCreated},
+			{uint8(2), enum.WebhookEventUserUpdated},
+			{uint8(3), enum.WebhookEventUserDeleted},
+			{uint8(4), enum.WebhookEventAPIKeyRevoked},
+			{uint8(5), enum.WebhookEventLogin},
+			{enum.WebhookEventUnknown, enum.WebhookEventUnknown},
+			{enum.WebhookEventUserCreated, enum.WebhookEventUserCreated},
+			{enum.WebhookEventUserUpdated, enum.WebhookEventUserUpdated},
+			{enum.WebhookEventUserDeleted, enum.WebhookEventUserDeleted},
+			{enum.WebhookEventAPIKeyRevoked, enum.WebhookEventAPIKeyRevoked},
+			{enum.WebhookEventLogin, enum.WebhookEventLogin},
+		}
+
+		for i, test := range tests {
+			result, err := enum.ParseWebhookEvent(test.input)
+			require.NoError(t, err, "test case %d failed", i)
+			require.Equal(t, test.expected, result, "test case %d failed", i)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		tests := []struct {
+			input interface{}
+			errs  string
+		}{
+			{"foo", "invalid webhook event: \"foo\""},
+			{true, "cannot parse bool into a webhook event"},
+		}
+
+		for i, test := range tests {
+			result, err := enum.ParseWebhookEvent(test.input)
+			require.Equal(t, enum.WebhookEventUnknown, result, "test case %d failed", i)
+			require.EqualError(t, err, test.errs, "test case %d failed", i)
+		}
+	})
+}
+
+func TestWebhookEventString(t *testing.T) {
+	tests := []struct {
+		tt       enum.WebhookEvent
+		expected string
+	}{
+		{enum.WebhookEventUnknown, "unknown"},
+		{enum.WebhookEventUserCreated, "user.created"},
+		{enum.WebhookEventUserUpdated, "user.updated"},
+		{enum.WebhookEventUserDeleted, "user.deleted"},
+		{enum.WebhookEventAPIKeyRevoked, "apikey.revoked"},
+		{enum.WebhookEventLogin, "login"},
+		{enum.WebhookEvent(99), "unknown"},
+	}
+
+	for i, test := range tests {
+		result := test.tt.String()
+		require.Equal(t, test.expected, result, "test case %d failed", i)
+	}
+}
+
+func TestWebhookEventJSON(t *testing.T) {
+	tests := []enum.WebhookEvent{
+		enum.WebhookEventUnknown, enum.WebhookEventUserCreated, enum.WebhookEventUserUpdated, enum.WebhookEventUserDeleted, enum.WebhookEventAPIKeyRevoked, enum.WebhookEventLogin,
+	}
+
+	for _, tt := range tests {
+		data, err := json.Marshal(tt)
+		require.NoError(t, err)
+
+		var result enum.WebhookEvent
+		err = json.Unmarshal(data, &result)
+		require.NoError(t, err)
+		require.Equal(t, tt, result)
+	}
+}
+
+func TestWebhookEventScan(t *testing.T) {
+	tests := []struct {
+		input    interface{}
+		expected enum.WebhookEvent
+	}{
+		{nil, enum.WebhookEventUnknown},
+		{"", enum.WebhookEventUnknown},
+		{"unknown", enum.WebhookEventUnknown},
+		{"user.created", enum.WebhookEventUserCreated},
+		{"user.updated", enum.WebhookEventUserUpdated},
+		{"user.deleted", enum.WebhookEventUserDeleted},
+		{"apikey.revoked", enum.WebhookEventAPIKeyRevoked},
+		{"login", enum.WebhookEventLogin},
+		{[]byte(""), enum.WebhookEventUnknown},
+		{[]byte("unknown"), enum.WebhookEventUnknown},
+		{[]byte("user.created"), enum.WebhookEventUserCreated},
+		{[]byte("user.updated"), enum.WebhookEventUserUpdated},
+		{[]byte("user.deleted"), enum.WebhookEventUserDeleted},
+		{[]byte("apikey.revoked"), enum.WebhookEventAPIKeyRevoked},
+		{[]byte("login"), enum.WebhookEventLogin},
+	}
+
+	for i, test := range tests {
+		var tt enum.WebhookEvent
+		err := tt.Scan(test.input)
+		require.NoError(t, err, "test case %d failed", i)
+		require.Equal(t, test.expected, tt, "test case %d failed", i)
+	}
+
+	var d enum.WebhookEvent
+	err := d.Scan("foo")
+	require.EqualError(t, err, "invalid webhook event: \"foo\"")
+	err = d.Scan(true)
+	require.EqualError(t, err, "cannot scan bool into a webhook event")
+}
+
+func TestWebhookEventValue(t *testing.T) {
+	value, err := enum.WebhookEventUserUpdated.Value()
+	require.NoError(t, err)
+	require.Equal(t, "user.updated", value)
+}
@@ -17,6 +17,14 @@ var (
 	ErrSQLiteForeignKeys = errors.New("could not enable sqlite foreign keys")
 	ErrSQLiteQueryOnly   = errors.New("could not set sqlite to query_only mode")
 
+	// Data migration, backup, and export errors
+	ErrSourceNotFound       = errors.New("the source database does not exist")
+	ErrSchemaOutdated       = errors.New("the database schema is not up to date, run the server to migrate it")
+	ErrVerifyMismatch       = errors.New("migrated rows do not match the source database")
+	ErrExportFormat         = errors.New("could not read quarterdeck export")
+	ErrInvalidBackup        = errors.New("backup is corrupt or is not a quarterdeck backup")
+	ErrReferentialIntegrity = errors.New("record refers to a record that does not exist")
+
 	// Database constraint errors
 	ErrReadOnly           = errors.New("cannot perform operation in read-only mode")
 	ErrMissingAssociation = errors.New("associated record(s) not cached on model")
@@ -31,6 +39,8 @@ var (
 	ErrAmbiguous          = errors.New("ambiguous query: more than one result returned")
 	ErrZeroValuedNotNull  = errors.New("query contains a not null field with a zero valued parameter")
 	ErrTypeMismatch       = errors.New("record type does not match target")
+	ErrProtected          = errors.New("protected records cannot be deleted or renamed")
+	ErrInvalidStatus      = errors.New("status is not valid for this record")
 
 	// Server related errors
 	ErrNotAccepted = errors.New("the accepted formats are not offered by the server")
@@ -43,6 +53,7 @@ var (
 	// Authentication errors
 	ErrFailedAuthentication = errors.New("login failed, please check your credentials")
 	ErrEmailNotVerified     = errors.New("please check your email to verify your account before logging in")
+	ErrInvitePending        = errors.New("the user must accept their invite to verify their email")
 	ErrUnknownSigningKey    = errors.New("unknown signing key")
 	ErrNoKeyID              = errors.New("token does not have kid in header")
 	ErrInvalidKeyID         = errors.New("invalid key id")
@@ -60,9 +71,46 @@ var (
 	ErrNoSigningKeys        = errors.New("claims issuer has no signing keys configured")
 	ErrNoLoginURL           = errors.New("no login URL configured to redirect the user to")
 	ErrExpiredToken         = errors.New("verification token is expired")
+	ErrLockedOut            = errors.New("too many failed attempts, please try again later")
+	ErrUserSuspended        = errors.New("your account has been suspended, please contact an administrator")
+	ErrUserDeactivated      = errors.New("your account has been deactivated, please contact an administrator")
+
+	// OAuth2 errors
+	ErrInvalidCodeVerifier    = errors.New("pkce code verifier does not match the code challenge")
+	ErrUnknownChallengeMethod = errors.New("unsupported pkce code challenge method")
+	ErrInvalidRedirectURI     = errors.New("redirect uri is not registered for this client")
+	ErrUnknownClient          = errors.New("unknown oauth client")
+	ErrTokenRevoked           = errors.New("token has been revoked")
+	ErrTokenReused            = errors.New("refresh token has already been used")
+
+	// Multi-factor authentication errors
+	ErrInvalidMFACode     = errors.New("the authentication code is incorrect or has expired")
+	ErrMFANotEnabled      = errors.New("multi-factor authentication is not enabled for this user")
+	ErrMFAAlreadyEnabled  = errors.New("multi-factor authentication is already enabled for this user")
+	ErrMFANotEnrolled     = errors.New("multi-factor authentication enrollment has not been started")
+	ErrMFAChallengeFailed = errors.New("multi-factor authentication challenge is invalid or has expired, please log in again")
+	ErrTOTPReused         = errors.New("the authentication code has already been used")
+
+	// WebAuthn (passkey) errors
+	ErrWebAuthnChallengeFailed = errors.New("passkey challenge is invalid or has expired, please try again")
+	ErrWebAuthnFailed          = errors.New("passkey could not be verified")
 
 	// Email errors
 	ErrEmptyWelcomeEmailBody = errors.New("welcome email body text or html is empty")
+
+	// Webhook errors
+	ErrInvalidSignature  = errors.New("webhook signature is missing or invalid")
+	ErrSignatureExpired  = errors.New("webhook signature timestamp is outside of the tolerance")
+	ErrDeliveryFailed    = errors.New("webhook endpoint did not accept the delivery")
+	ErrNoSubscribedEvent = errors.New("webhook is not subscribed to any events")
+	ErrWebhookInactive   = errors.New("webhook is inactive, the event was not delivered")
+
+	// Signing key management errors
+	ErrNoKeyEncryptionKey = errors.New("a key encryption key is required to store signing keys and secrets")
+	ErrDecryptSigningKey  = errors.New("could not decrypt signing key, is the key encryption key correct?")
+	ErrDecryptSecret      = errors.New("could not decrypt secret, is the key encryption key correct?")
+	ErrRotationPending    = errors.New("a signing key rotation is already scheduled")
+	ErrActiveSigningKey   = errors.New("the active signing key cannot be retired, rotate the signing key first")
 )
 
 // UnhandledProvider is returned when Open or LoadMigrations is called with an
@@ -0,0 +1,222 @@
+package scim
+
+// ServiceProviderConfig describes the SCIM features that Quarterdeck supports so that
+// identity providers can discover them (RFC 7643 §5).
+type ServiceProviderConfig struct {
+	Schemas               []string                `json:"schemas"`
+	DocumentationURI      string                  `json:"documentationUri,omitempty"`
+	Patch                 Supported               `json:"patch"`
+	Bulk                  BulkSupport             `json:"bulk"`
+	Filter                FilterSupport           `json:"filter"`
+	ChangePassword        Supported               `json:"changePassword"`
+	Sort                  Supported               `json:"sort"`
+	ETag                  Supported               `json:"etag"`
+	AuthenticationSchemes []*AuthenticationScheme `json:"authenticationSchemes"`
+	Meta                  *Meta                   `json:"meta"`
+}
+
+type Supported struct {
+	Supported bool `json:"supported"`
+}
+
+type BulkSupport struct {
+	Supported      bool `json:"supported"`
+	MaxOperations  int  `json:"maxOperations"`
+	MaxPayloadSize int  `json:"maxPayloadSize"`
+}
+
+type FilterSupport struct {
+	Supported  bool `json:"supported"`
+	MaxResults int  `json:"maxResults"`
+}
+
+type AuthenticationScheme struct {
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Primary     bool   `json:"primary,omitempty"`
+}
+
+// ResourceType describes a SCIM resource and the endpoint it is served from.
+type ResourceType struct {
+	Schemas     []string `json:"schemas"`
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Endpoint    string   `json:"endpoint"`
+	Description string   `json:"description"`
+	Schema      string   `json:"schema"`
+	Meta        *Meta    `json:"meta"`
+}
+
+// Schema describes the attributes of a SCIM resource that Quarterdeck supports.
+type Schema struct {
+	Schemas     []string     `json:"schemas"`
+	ID          string       `json:"id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Attributes  []*Attribute `json:"attributes"`
+	Meta        *Meta        `json:"meta"`
+}
+
+type Attribute struct {
+	Name           string       `json:"name"`
+	Type           string       `json:"type"`
+	MultiValued    bool         `json:"multiValued"`
+	Description    string       `json:"description,omitempty"`
+	Required       bool         `json:"required"`
+	CaseExact      bool         `json:"caseExact"`
+	Mutability     string       `json:"mutability"`
+	Returned       string       `json:"returned"`
+	Uniqueness     string       `json:"uniqueness"`
+	ReferenceTypes []string     `json:"referenceTypes,omitempty"`
+	SubAttributes  []*Attribute `json:"subAttributes,omitempty"`
+}
+
+// NewServiceProviderConfig returns the configuration of the SCIM service at the base URL.
+func NewServiceProviderConfig(base string) *ServiceProviderConfig {
+	return &ServiceProviderConfig{
+		Schemas:        []string{SchemaServiceProviderConfig},
+		Patch:          Supported{Supported: true},
+		Bulk:           BulkSupport{Supported: false},
+		Filter:         FilterSupport{Supported: true, MaxResults: MaxPageCount},
+		ChangePassword: Supported{Supported: false},
+		Sort:           Supported{Supported: false},
+		ETag:           Supported{Supported: true},
+		AuthenticationSchemes: []*AuthenticationScheme{
+			{
+				Type:        "oauthbearertoken",
+				Name:        "OAuth Bearer Token",
+				Description: "Authentication with an access token issued to an API key",
+				Primary:     true,
+			},
+		},
+		Meta: &Meta{
+			ResourceType: "ServiceProviderConfig",
+			Location:     base + "/ServiceProviderConfig",
+		},
+	}
+}
+
+// ResourceTypes returns the resource types served from the base URL.
+func ResourceTypes(base string) []*ResourceType {
+	return []*ResourceType{
+		{
+			Schemas:     []string{SchemaResourceType},
+			ID:          ResourceUser,
+			Name:        ResourceUser,
+			Endpoint:    EndpointUsers,
+			Description: "Quarterdeck user accounts",
+			Schema:      SchemaUser,
+			Meta:        &Meta{ResourceType: "ResourceType", Location: base + "/ResourceTypes/" + ResourceUser},
+		},
+		{
+			Schemas:     []string{SchemaResourceType},
+			ID:          ResourceGroup,
+			Name:        ResourceGroup,
+			Endpoint:    EndpointGroups,
+			Description: "Quarterdeck roles",
+			Schema:      SchemaGroup,
+			Meta:        &Meta{ResourceType: "ResourceType", Location: base + "/ResourceTypes/" + ResourceGroup},
+		},
+	}
+}
+
+// Schemas returns the schemas of the resources served from the base URL; only the
+// attributes that Quarterdeck supports are described.
+func Schemas(base string) []*Schema {
+	return []*Schema{
+		{
+			Schemas:     []string{SchemaSchema},
+			ID:          SchemaUser,
+			Name:        ResourceUser,
+			Description: "User Account",
+			Attributes: []*Attribute{
+				attribute("userName", "string", "Email address of the user", true, "readWrite", "server"),
+				{
+					Name:        "name",
+					Type:        "complex",
+					Description: "The name of the user",
+					Mutability:  "readWrite",
+					Returned:    "default",
+					Uniqueness:  "none",
+					SubAttributes: []*Attribute{
+						attribute("formatted", "string", "The full name of the user", false, "readWrite", "none"),
+						attribute("familyName", "string", "Combined with the given name if the full name is not specified", false, "writeOnly", "none"),
+						attribute("givenName", "string", "Combined with the family name if the full name is not specified", false, "writeOnly", "none"),
+					},
+				},
+				attribute("displayName", "string", "The full name of the user", false, "readWrite", "none"),
+				{
+					Name:        "emails",
+					Type:        "complex",
+					MultiValued: true,
+					Description: "The email address of the user, which is the userName",
+					Mutability:  "readOnly",
+					Returned:    "default",
+					Uniqueness:  "none",
+					SubAttributes: []*Attribute{
+						attribute("value", "string", "Email address", false, "readOnly", "none"),
+						attribute("type", "string", "The type of the email address", false, "readOnly", "none"),
+						attribute("primary", "boolean", "The primary email address", false, "readOnly", "none"),
+					},
+				},
+				attribute("active", "boolean", "If the user can log in", false, "readWrite", "none"),
+				references("groups", "The roles assigned to the user", "readOnly", "Group"),
+			},
+			Meta: &Meta{ResourceType: "Schema", Location: base + "/Schemas/" + SchemaUser},
+		},
+		{
+			Schemas:     []string{SchemaSchema},
+			ID:          SchemaGroup,
+			Name:        ResourceGroup,
+			Description: "Role",
+			Attributes: []*Attribute{
+				attribute("displayName", "string", "The title of the role", true, "readWrite", "server"),
+				references("members", "The users assigned the role", "readWrite", "User"),
+			},
+			Meta: &Meta{ResourceType: "Schema", Location: base + "/Schemas/" + SchemaGroup},
+		},
+	}
+}
+
+func attribute(name, typ, description string, required bool, mutability, uniqueness string) *Attribute {
+	attr := &Attribute{
+		Name:        name,
+		Type:        typ,
+		Description: description,
+		Required:    required,
+		Mutability:  mutability,
+		Returned:    "default",
+		Uniqueness:  uniqueness,
+	}
+
+	if mutability == "writeOnly" {
+		attr.Returned = "never"
+	}
+	return attr
+}
+
+func references(name, description, mutability, resource string) *Attribute {
+	return &Attribute{
+		Name:        name,
+		Type:        "complex",
+		MultiValued: true,
+		Description: description,
+		Mutability:  mutability,
+		Returned:    "default",
+		Uniqueness:  "none",
+		SubAttributes: []*Attribute{
+			attribute("value", "string", "The id of the "+resource, false, "immutable", "none"),
+			{
+				Name:           "$ref",
+				Type:           "reference",
+				Description:    "The URI of the " + resource,
+				Mutability:     "immutable",
+				Returned:       "default",
+				Uniqueness:     "none",
+				ReferenceTypes: []string{resource},
+			},
+			attribute("display", "string", "A human readable name of the "+resource, false, "readOnly", "none"),
+		},
+	}
+}
@@ -0,0 +1,34 @@
+package scim
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+)
+
+// Version computes a weak ETag of the resource from its JSON serialization so that the
+// version changes whenever any of the attributes that are returned change; the resource
+// meta version must be empty when the version is computed.
+func Version(resource any) (_ string, err error) {
+	var data []byte
+	if data, err = json.Marshal(resource); err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`, nil
+}
+
+// MatchVersion returns true if the If-Match or If-None-Match header matches the version
+// of the resource. The header is a list of ETags or * to match any version; ETags are
+// compared using the weak comparison function (RFC 9110 §8.8.3.2).
+func MatchVersion(header, version string) bool {
+	for _, etag := range strings.Split(header, ",") {
+		etag = strings.TrimSpace(etag)
+		if etag == "*" || strings.TrimPrefix(etag, "W/") == strings.TrimPrefix(version, "W/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,158 @@
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Filter is an equality filter on an attribute, e.g. userName eq "jane@example.com".
+// Equality filters are the only filters that identity providers use to look up users
+// and groups before provisioning them, so other operators and logical expressions are
+// rejected with an invalidFilter error.
+type Filter struct {
+	Attribute string
+	Value     string
+}
+
+// ParseFilter parses the filter query parameter; an empty filter returns nil.
+func ParseFilter(filter string) (_ *Filter, err error) {
+	if filter = strings.TrimSpace(filter); filter == "" {
+		return nil, nil
+	}
+
+	var out *Filter
+	if out, err = parseFilter(filter); err != nil {
+		return nil, BadRequest(ErrorInvalidFilter, err.Error())
+	}
+	return out, nil
+}
+
+func parseFilter(filter string) (out *Filter, err error) {
+	attr, rest, _ := strings.Cut(filter, " ")
+	op, value, _ := strings.Cut(strings.TrimSpace(rest), " ")
+
+	// The attribute may be a sub-attribute of a complex attribute, e.g. name.givenName
+	attr = trimSchema(attr)
+	for _, name := range strings.Split(attr, ".") {
+		if !validAttribute(name) {
+			return nil, fmt.Errorf("invalid attribute %q in filter", attr)
+		}
+	}
+
+	if !strings.EqualFold(op, "eq") {
+		return nil, fmt.Errorf("unsupported filter operator %q: only eq is supported", op)
+	}
+
+	// The comparison value is a JSON string, number, boolean, or null.
+	var compare any
+	if err = json.Unmarshal([]byte(strings.TrimSpace(value)), &compare); err != nil {
+		return nil, fmt.Errorf("invalid comparison value %q in filter", value)
+	}
+
+	out = &Filter{Attribute: attr}
+	switch v := compare.(type) {
+	case nil:
+		out.Value = ""
+	case string:
+		out.Value = v
+	case map[string]any, []any:
+		return nil, fmt.Errorf("invalid comparison value %q in filter", value)
+	default:
+		out.Value = fmt.Sprint(v)
+	}
+	return out, nil
+}
+
+// Is returns true if the filter is on the attribute; attribute names are case
+// insensitive.
+func (f *Filter) Is(attribute string) bool {
+	return strings.EqualFold(f.Attribute, attribute)
+}
+
+// matches returns true if the attribute of the complex value is equal to the filter
+// value; it is used to select the values of multi-valued attributes in PATCH paths.
+func (f *Filter) matches(item any) bool {
+	obj, ok := item.(map[string]any)
+	if !ok {
+		return false
+	}
+
+	value, ok := obj[lookup(obj, f.Attribute)]
+	if !ok || value == nil {
+		return false
+	}
+	return strings.EqualFold(fmt.Sprint(value), f.Value)
+}
+
+// Path is the target of a PATCH operation: an attribute, an optional value filter if the
+// attribute is multi-valued, and an optional sub-attribute, e.g. name.givenName or
+// members[value eq "01HZ..."].
+type Path struct {
+	Attribute    string
+	Filter       *Filter
+	SubAttribute string
+}
+
+// ParsePath parses the path of a PATCH operation; the attribute may be prefixed by the
+// URN of the core User or Group schema.
+func ParsePath(path string) (out *Path, err error) {
+	path = trimSchema(strings.TrimSpace(path))
+	out = &Path{}
+
+	var sub bool
+
+	if open := strings.IndexByte(path, '['); open >= 0 {
+		end := strings.LastIndexByte(path, ']')
+		if end < open {
+			return nil, BadRequest(ErrorInvalidPath, fmt.Sprintf("invalid path %q: unterminated value filter", path))
+		}
+
+		if out.Filter, err = parseFilter(path[open+1 : end]); err != nil {
+			return nil, BadRequest(ErrorInvalidPath, fmt.Sprintf("invalid path %q: %s", path, err))
+		}
+
+		out.Attribute = path[:open]
+		if rest := path[end+1:]; rest != "" {
+			if !strings.HasPrefix(rest, ".") {
+				return nil, BadRequest(ErrorInvalidPath, fmt.Sprintf("invalid path %q", path))
+			}
+			out.SubAttribute, sub = rest[1:], true
+		}
+	} else {
+		out.Attribute, out.SubAttribute, sub = strings.Cut(path, ".")
+	}
+
+	if !validAttribute(out.Attribute) || (sub && !validAttribute(out.SubAttribute)) {
+		return nil, BadRequest(ErrorInvalidPath, fmt.Sprintf("invalid path %q", path))
+	}
+	return out, nil
+}
+
+// trimSchema removes the core schema URN prefix from a fully qualified attribute name.
+func trimSchema(attr string) string {
+	for _, schema := range []string{SchemaUser, SchemaGroup} {
+		if len(attr) > len(schema) && strings.EqualFold(attr[:len(schema)+1], schema+":") {
+			return attr[len(schema)+1:]
+		}
+	}
+	return attr
+}
+
+// validAttribute returns true if the name is a valid SCIM attribute name (RFC 7643 §2.1)
+// or the $ref sub-attribute of a reference.
+func validAttribute(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for i, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '$':
+		case i > 0 && (r >= '0' && r <= '9' || r == '_' || r == '-'):
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,83 @@
+package scim_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/quarterdeck/pkg/scim"
+)
+
+func TestParseFilter(t *testing.T) {
+	testCases := []struct {
+		filter   string
+		expected *scim.Filter
+	}{
+		{"", nil},
+		{"   ", nil},
+		{`userName eq "jane@example.com"`, &scim.Filter{Attribute: "userName", Value: "jane@example.com"}},
+		{`userName EQ "jane@example.com"`, &scim.Filter{Attribute: "userName", Value: "jane@example.com"}},
+		{`displayName eq "Engineering Team"`, &scim.Filter{Attribute: "displayName", Value: "Engineering Team"}},
+		{`emails.value eq "jane@example.com"`, &scim.Filter{Attribute: "emails.value", Value: "jane@example.com"}},
+		{`urn:ietf:params:scim:schemas:core:2.0:User:userName eq "jane@example.com"`, &scim.Filter{Attribute: "userName", Value: "jane@example.com"}},
+		{`active eq true`, &scim.Filter{Attribute: "active", Value: "true"}},
+	}
+
+	for _, tc := range testCases {
+		filter, err := scim.ParseFilter(tc.filter)
+		require.NoError(t, err, "could not parse %q", tc.filter)
+		require.Equal(t, tc.expected, filter, "unexpected filter for %q", tc.filter)
+	}
+
+	for _, invalid := range []string{
+		`userName`,
+		`userName eq`,
+		`userName co "jane"`,
+		`userName eq "jane@example.com" and active eq true`,
+		`userName eq jane@example.com`,
+		`user name eq "jane@example.com"`,
+		`1userName eq "jane@example.com"`,
+	} {
+		_, err := scim.ParseFilter(invalid)
+		require.Error(t, err, "expected %q to be invalid", invalid)
+
+		var serr *scim.Error
+		require.ErrorAs(t, err, &serr)
+		require.Equal(t, scim.ErrorInvalidFilter, serr.ScimType)
+		require.Equal(t, 400, serr.StatusCode())
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	testCases := []struct {
+		path     string
+		expected *scim.Path
+	}{
+		{"active", &scim.Path{Attribute: "active"}},
+		{"name.givenName", &scim.Path{Attribute: "name", SubAttribute: "givenName"}},
+		{"urn:ietf:params:scim:schemas:core:2.0:User:name.familyName", &scim.Path{Attribute: "name", SubAttribute: "familyName"}},
+		{`members[value eq "01JPYRNYMEHNEZCS0JYX1CP57A"]`, &scim.Path{Attribute: "members", Filter: &scim.Filter{Attribute: "value", Value: "01JPYRNYMEHNEZCS0JYX1CP57A"}}},
+		{`emails[type eq "work"].value`, &scim.Path{Attribute: "emails", Filter: &scim.Filter{Attribute: "type", Value: "work"}, SubAttribute: "value"}},
+	}
+
+	for _, tc := range testCases {
+		path, err := scim.ParsePath(tc.path)
+		require.NoError(t, err, "could not parse %q", tc.path)
+		require.Equal(t, tc.expected, path, "unexpected path for %q", tc.path)
+	}
+
+	for _, invalid := range []string{
+		"",
+		"name.",
+		`members[value eq "01JPYRNYMEHNEZCS0JYX1CP57A"].`,
+		`members[value eq "01JPYRNYMEHNEZCS0JYX1CP57A"`,
+		`members[value co "01J"]`,
+		`emails[type eq "work"]value`,
+	} {
+		_, err := scim.ParsePath(invalid)
+		require.Error(t, err, "expected %q to be invalid", invalid)
+
+		var serr *scim.Error
+		require.ErrorAs(t, err, &serr)
+		require.Equal(t, scim.ErrorInvalidPath, serr.ScimType)
+	}
+}
@@ -0,0 +1,92 @@
+package scim
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/ulid"
+)
+
+// Group is the SCIM representation of a Quarterdeck role; the display name is the role
+// title and the members are the users that are assigned the role.
+type Group struct {
+	Schemas     []string    `json:"schemas"`
+	ID          string      `json:"id,omitempty"`
+	DisplayName string      `json:"displayName"`
+	Members     []Reference `json:"members,omitempty"`
+	Meta        *Meta       `json:"meta,omitempty"`
+}
+
+// GroupReadOnly are the group attributes that cannot be modified by a PATCH request.
+var GroupReadOnly = []string{"id", "meta"}
+
+// NewGroup converts the role and the users that are assigned the role into a SCIM group
+// that is served from the base URL.
+func NewGroup(role *models.Role, members []*models.User, base string) (out *Group, err error) {
+	id := strconv.FormatInt(role.ID, 10)
+	created, modified := role.Created, role.Modified
+
+	out = &Group{
+		Schemas:     []string{SchemaGroup},
+		ID:          id,
+		DisplayName: role.Title,
+		Meta: &Meta{
+			ResourceType: ResourceGroup,
+			Created:      &created,
+			LastModified: &modified,
+			Location:     base + EndpointGroups + "/" + id,
+		},
+	}
+
+	for _, member := range members {
+		out.Members = append(out.Members, Reference{
+			Value:   member.ID.String(),
+			Ref:     base + EndpointUsers + "/" + member.ID.String(),
+			Display: member.Email,
+		})
+	}
+
+	// Membership changes do not modify the role so the members are part of the version.
+	if out.Meta.Version, err = Version(out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Validate the group for create, replace, and patch requests. Role titles cannot
+// contain whitespace so display names with whitespace are rejected.
+func (g *Group) Validate() error {
+	if !slices.Contains(g.Schemas, SchemaGroup) {
+		return BadRequest(ErrorInvalidValue, "the Group schema is required")
+	}
+
+	g.DisplayName = strings.TrimSpace(g.DisplayName)
+	if g.DisplayName == "" {
+		return BadRequest(ErrorInvalidValue, "displayName is required")
+	}
+
+	if strings.ContainsAny(g.DisplayName, " \t\n") {
+		return BadRequest(ErrorInvalidValue, "displayName must not contain whitespace")
+	}
+
+	for _, member := range g.Members {
+		if _, err := ulid.Parse(member.Value); err != nil {
+			return BadRequest(ErrorInvalidValue, "members must be the ids of users")
+		}
+	}
+	return nil
+}
+
+// MemberIDs returns the user IDs of the members of the group without duplicates; the
+// group must be validated first.
+func (g *Group) MemberIDs() []ulid.ULID {
+	ids := make([]ulid.ULID, 0, len(g.Members))
+	for _, member := range g.Members {
+		if id, err := ulid.Parse(member.Value); err == nil && !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
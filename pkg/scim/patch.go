@@ -0,0 +1,329 @@
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// PATCH operations (RFC 7644 §3.5.2); operations are case insensitive since some
+// identity providers send them capitalized.
+const (
+	OpAdd     = "add"
+	OpRemove  = "remove"
+	OpReplace = "replace"
+)
+
+// PatchOp is the body of a PATCH request.
+type PatchOp struct {
+	Schemas    []string     `json:"schemas"`
+	Operations []*Operation `json:"Operations"`
+}
+
+// Operation modifies the attribute at the path; if the path is empty then the value is
+// an object whose keys are the paths to add or replace.
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Validate the PATCH request before it is applied.
+func (p *PatchOp) Validate() error {
+	if !slices.Contains(p.Schemas, SchemaPatchOp) {
+		return BadRequest(ErrorInvalidValue, "the PatchOp schema is required")
+	}
+
+	if len(p.Operations) == 0 {
+		return BadRequest(ErrorInvalidValue, "at least one operation is required")
+	}
+
+	for _, op := range p.Operations {
+		switch strings.ToLower(op.Op) {
+		case OpAdd, OpReplace:
+			if len(op.Value) == 0 {
+				return BadRequest(ErrorInvalidValue, fmt.Sprintf("a value is required to %s %q", strings.ToLower(op.Op), op.Path))
+			}
+		case OpRemove:
+			if op.Path == "" {
+				return BadRequest(ErrorNoTarget, "a path is required to remove an attribute")
+			}
+		default:
+			return BadRequest(ErrorInvalidSyntax, fmt.Sprintf("unknown patch operation %q", op.Op))
+		}
+	}
+	return nil
+}
+
+// Apply the operations in order to the resource, which must be a pointer to a User or a
+// Group. The resource is only modified if all of the operations succeed. The readOnly
+// attributes cannot be modified by the operations.
+func (p *PatchOp) Apply(resource any, readOnly ...string) (err error) {
+	var data []byte
+	if data, err = json.Marshal(resource); err != nil {
+		return err
+	}
+
+	doc := make(map[string]any)
+	if err = json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+
+	for _, op := range p.Operations {
+		if err = op.apply(doc, readOnly); err != nil {
+			return err
+		}
+	}
+
+	if data, err = json.Marshal(doc); err != nil {
+		return err
+	}
+
+	// Reset the resource so that removed attributes are not left unchanged.
+	patched := reflect.New(reflect.TypeOf(resource).Elem())
+	if err = json.Unmarshal(data, patched.Interface()); err != nil {
+		return BadRequest(ErrorInvalidValue, fmt.Sprintf("the patched resource is invalid: %s", err))
+	}
+
+	reflect.ValueOf(resource).Elem().Set(patched.Elem())
+	return nil
+}
+
+func (o *Operation) apply(doc map[string]any, readOnly []string) (err error) {
+	var value any
+	if len(o.Value) > 0 {
+		if err = json.Unmarshal(o.Value, &value); err != nil {
+			return BadRequest(ErrorInvalidValue, fmt.Sprintf("could not parse the value of the %s operation", strings.ToLower(o.Op)))
+		}
+	}
+
+	op := strings.ToLower(o.Op)
+	if o.Path != "" {
+		var path *Path
+		if path, err = ParsePath(o.Path); err != nil {
+			return err
+		}
+		return apply(doc, op, path, value, readOnly)
+	}
+
+	// Without a path the value is an object of the attributes to add or replace.
+	attrs, ok := value.(map[string]any)
+	if !ok {
+		return BadRequest(ErrorInvalidValue, "the value must be an object when a path is not specified")
+	}
+
+	for key, val := range attrs {
+		if strings.EqualFold(key, "schemas") {
+			continue
+		}
+
+		var path *Path
+		if path, err = ParsePath(key); err != nil {
+			return err
+		}
+
+		if err = apply(doc, op, path, val, readOnly); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func apply(doc map[string]any, op string, path *Path, value any, readOnly []string) error {
+	for _, attr := range readOnly {
+		if strings.EqualFold(attr, path.Attribute) {
+			return BadRequest(ErrorMutability, fmt.Sprintf("the %s attribute cannot be modified", path.Attribute))
+		}
+	}
+
+	key := lookup(doc, path.Attribute)
+	switch {
+	case path.Filter != nil:
+		return applyFilter(doc, key, op, path, value)
+	case path.SubAttribute != "":
+		parent, ok := doc[key].(map[string]any)
+		if !ok {
+			if _, exists := doc[key]; exists {
+				return BadRequest(ErrorInvalidPath, fmt.Sprintf("%s is not a complex attribute", path.Attribute))
+			}
+
+			if op == OpRemove {
+				return nil
+			}
+
+			parent = make(map[string]any)
+			doc[key] = parent
+		}
+
+		subkey := lookup(parent, path.SubAttribute)
+		if op == OpRemove {
+			delete(parent, subkey)
+		} else {
+			parent[subkey] = coerce(parent[subkey], value)
+		}
+		return nil
+	}
+
+	existing, exists := doc[key]
+	switch op {
+	case OpRemove:
+		// Some identity providers remove values from a multi-valued attribute by
+		// specifying the values rather than a value filter in the path.
+		if items, ok := existing.([]any); ok && value != nil {
+			doc[key] = slices.DeleteFunc(items, func(item any) bool {
+				return containsValue(value, item)
+			})
+			return nil
+		}
+		delete(doc, key)
+	case OpAdd:
+		// Values are added to multi-valued attributes unless they are already present.
+		items, isList := existing.([]any)
+		values, addList := value.([]any)
+		if isList || (!exists && addList) {
+			if !addList {
+				values = []any{value}
+			}
+
+			for _, val := range values {
+				if !containsValue(items, val) {
+					items = append(items, val)
+				}
+			}
+			doc[key] = items
+			return nil
+		}
+		doc[key] = merge(existing, value)
+	case OpReplace:
+		doc[key] = merge(existing, value)
+	}
+	return nil
+}
+
+// applyFilter applies the operation to the values of a multi-valued attribute that
+// match the value filter of the path.
+func applyFilter(doc map[string]any, key, op string, path *Path, value any) error {
+	if op == OpAdd {
+		return BadRequest(ErrorInvalidPath, "a value filter cannot be used to add values")
+	}
+
+	items, _ := doc[key].([]any)
+	if op == OpRemove {
+		doc[key] = slices.DeleteFunc(items, func(item any) bool {
+			if !path.Filter.matches(item) {
+				return false
+			}
+
+			// Only the sub-attribute is removed from the matching values.
+			if path.SubAttribute != "" {
+				obj := item.(map[string]any)
+				delete(obj, lookup(obj, path.SubAttribute))
+				return false
+			}
+			return true
+		})
+		return nil
+	}
+
+	var matched bool
+	for i, item := range items {
+		if !path.Filter.matches(item) {
+			continue
+		}
+
+		matched = true
+		if path.SubAttribute != "" {
+			obj := item.(map[string]any)
+			subkey := lookup(obj, path.SubAttribute)
+			obj[subkey] = coerce(obj[subkey], value)
+		} else {
+			items[i] = merge(item, value)
+		}
+	}
+
+	if !matched {
+		return BadRequest(ErrorNoTarget, fmt.Sprintf("no values of %s match the filter", path.Attribute))
+	}
+	return nil
+}
+
+// merge replaces the existing value; if both values are complex then the sub-attributes
+// of the existing value that are not in the new value are kept.
+func merge(existing, value any) any {
+	obj, ok := existing.(map[string]any)
+	if !ok {
+		return coerce(existing, value)
+	}
+
+	update, ok := value.(map[string]any)
+	if !ok {
+		return value
+	}
+
+	for key, val := range update {
+		key = lookup(obj, key)
+		obj[key] = coerce(obj[key], val)
+	}
+	return obj
+}
+
+// coerce converts string booleans to booleans when the existing value is a boolean
+// since some identity providers send "True" and "False" as the value of active.
+func coerce(existing, value any) any {
+	if _, ok := existing.(bool); ok {
+		if s, ok := value.(string); ok {
+			if b, err := strconv.ParseBool(s); err == nil {
+				return b
+			}
+		}
+	}
+	return value
+}
+
+// containsValue returns true if the value (or any of the values if it is a list) has
+// the same value sub-attribute as the item.
+func containsValue(values any, item any) bool {
+	list, ok := values.([]any)
+	if !ok {
+		list = []any{values}
+	}
+
+	value := valueOf(item)
+	if value == nil {
+		return false
+	}
+
+	filter := &Filter{Attribute: "value", Value: fmt.Sprint(value)}
+	for _, val := range list {
+		if filter.matches(val) {
+			return true
+		}
+	}
+	return false
+}
+
+// valueOf returns the value sub-attribute of a complex value or nil.
+func valueOf(item any) any {
+	if obj, ok := item.(map[string]any); ok {
+		return obj[lookup(obj, "value")]
+	}
+	return nil
+}
+
+// lookup returns the key in the object that matches the attribute name case
+// insensitively or the attribute name if the object does not have the attribute.
+func lookup(obj map[string]any, attr string) string {
+	if _, ok := obj[attr]; ok {
+		return attr
+	}
+
+	for key := range obj {
+		if strings.EqualFold(key, attr) {
+			return key
+		}
+	}
+	return attr
+}
@@ -0,0 +1,221 @@
+package scim_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/quarterdeck/pkg/scim"
+)
+
+func TestPatchOpValidate(t *testing.T) {
+	valid := &scim.PatchOp{
+		Schemas: []string{scim.SchemaPatchOp},
+		Operations: []*scim.Operation{
+			{Op: "Replace", Path: "active", Value: json.RawMessage(`false`)},
+			{Op: "remove", Path: "name.givenName"},
+		},
+	}
+	require.NoError(t, valid.Validate())
+
+	testCases := []struct {
+		name     string
+		patch    *scim.PatchOp
+		scimType string
+	}{
+		{"NoSchema", &scim.PatchOp{Operations: valid.Operations}, scim.ErrorInvalidValue},
+		{"NoOperations", &scim.PatchOp{Schemas: valid.Schemas}, scim.ErrorInvalidValue},
+		{"UnknownOp", &scim.PatchOp{Schemas: valid.Schemas, Operations: []*scim.Operation{{Op: "move", Path: "active"}}}, scim.ErrorInvalidSyntax},
+		{"NoValue", &scim.PatchOp{Schemas: valid.Schemas, Operations: []*scim.Operation{{Op: "add", Path: "active"}}}, scim.ErrorInvalidValue},
+		{"RemoveNoPath", &scim.PatchOp{Schemas: valid.Schemas, Operations: []*scim.Operation{{Op: "remove"}}}, scim.ErrorNoTarget},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var serr *scim.Error
+			require.ErrorAs(t, tc.patch.Validate(), &serr)
+			require.Equal(t, tc.scimType, serr.ScimType)
+		})
+	}
+}
+
+func TestPatchUser(t *testing.T) {
+	active := true
+	user := func() *scim.User {
+		return &scim.User{
+			Schemas:     []string{scim.SchemaUser},
+			ID:          "01JPYRNYMEHNEZCS0JYX1CP57A",
+			UserName:    "jane@example.com",
+			Name:        &scim.Name{Formatted: "Jane Doe"},
+			DisplayName: "Jane Doe",
+			Active:      &active,
+		}
+	}
+
+	patch := func(ops ...string) *scim.PatchOp {
+		p := &scim.PatchOp{Schemas: []string{scim.SchemaPatchOp}}
+		for _, op := range ops {
+			operation := &scim.Operation{}
+			require.NoError(t, json.Unmarshal([]byte(op), operation))
+			p.Operations = append(p.Operations, operation)
+		}
+		return p
+	}
+
+	t.Run("ReplaceActive", func(t *testing.T) {
+		patched := user()
+		require.NoError(t, patch(`{"op": "replace", "path": "active", "value": false}`).Apply(patched, scim.UserReadOnly...))
+		require.False(t, patched.IsActive())
+		require.Equal(t, "jane@example.com", patched.UserName)
+	})
+
+	t.Run("ReplaceActiveString", func(t *testing.T) {
+		patched := user()
+		require.NoError(t, patch(`{"op": "Replace", "path": "active", "value": "False"}`).Apply(patched, scim.UserReadOnly...))
+		require.False(t, patched.IsActive())
+	})
+
+	t.Run("ReplaceNoPath", func(t *testing.T) {
+		patched := user()
+		require.NoError(t, patch(`{"op": "replace", "value": {"active": false, "userName": "jdoe@example.com", "name.givenName": "Janet"}}`).Apply(patched, scim.UserReadOnly...))
+		require.False(t, patched.IsActive())
+		require.Equal(t, "jdoe@example.com", patched.UserName)
+		require.Equal(t, "Janet", patched.Name.GivenName)
+		require.Equal(t, "Jane Doe", patched.Name.Formatted, "expected the other name attributes to be unchanged")
+	})
+
+	t.Run("PatchedName", func(t *testing.T) {
+		original := user()
+		patched := user()
+		require.NoError(t, patch(
+			`{"op": "add", "path": "name.givenName", "value": "Janet"}`,
+			`{"op": "add", "path": "name.familyName", "value": "Smith"}`,
+		).Apply(patched, scim.UserReadOnly...))
+		require.Equal(t, "Janet Smith", patched.PatchedName(original))
+
+		patched = user()
+		require.NoError(t, patch(`{"op": "replace", "path": "displayName", "value": "J. Doe"}`).Apply(patched, scim.UserReadOnly...))
+		require.Equal(t, "J. Doe", patched.PatchedName(original))
+
+		patched = user()
+		require.NoError(t, patch(`{"op": "remove", "path": "name"}`, `{"op": "remove", "path": "displayName"}`).Apply(patched, scim.UserReadOnly...))
+		require.Empty(t, patched.PatchedName(original))
+
+		patched = user()
+		require.NoError(t, patch(`{"op": "replace", "path": "active", "value": false}`).Apply(patched, scim.UserReadOnly...))
+		require.Equal(t, "Jane Doe", patched.PatchedName(original))
+	})
+
+	t.Run("ReadOnly", func(t *testing.T) {
+		patched := user()
+		err := patch(
+			`{"op": "replace", "path": "active", "value": false}`,
+			`{"op": "replace", "path": "id", "value": "01JPYRNYMEHNEZCS0JYX1CP57B"}`,
+		).Apply(patched, scim.UserReadOnly...)
+
+		var serr *scim.Error
+		require.ErrorAs(t, err, &serr)
+		require.Equal(t, scim.ErrorMutability, serr.ScimType)
+		require.True(t, patched.IsActive(), "the user should not be modified if an operation fails")
+	})
+
+	t.Run("InvalidValue", func(t *testing.T) {
+		patched := user()
+		err := patch(`{"op": "replace", "path": "userName", "value": {"value": "jane"}}`).Apply(patched, scim.UserReadOnly...)
+
+		var serr *scim.Error
+		require.ErrorAs(t, err, &serr)
+		require.Equal(t, scim.ErrorInvalidValue, serr.ScimType)
+		require.Equal(t, "jane@example.com", patched.UserName)
+	})
+}
+
+func TestPatchGroup(t *testing.T) {
+	const (
+		jane = "01JPYRNYMEHNEZCS0JYX1CP57A"
+		john = "01JPYRNYMEHNEZCS0JYX1CP57B"
+		jack = "01JPYRNYMEHNEZCS0JYX1CP57C"
+	)
+
+	group := func() *scim.Group {
+		return &scim.Group{
+			Schemas:     []string{scim.SchemaGroup},
+			ID:          "4",
+			DisplayName: "engineering",
+			Members:     []scim.Reference{{Value: jane, Display: "jane@example.com"}, {Value: john, Display: "john@example.com"}},
+		}
+	}
+
+	members := func(g *scim.Group) []string {
+		ids := make([]string, 0, len(g.Members))
+		for _, member := range g.Members {
+			ids = append(ids, member.Value)
+		}
+		return ids
+	}
+
+	apply := func(g *scim.Group, ops string) error {
+		p := &scim.PatchOp{Schemas: []string{scim.SchemaPatchOp}}
+		require.NoError(t, json.Unmarshal([]byte(ops), &p.Operations))
+		return p.Apply(g, scim.GroupReadOnly...)
+	}
+
+	t.Run("AddMembers", func(t *testing.T) {
+		g := group()
+		require.NoError(t, apply(g, `[{"op": "add", "path": "members", "value": [{"value": "`+jack+`"}, {"value": "`+jane+`"}]}]`))
+		require.Equal(t, []string{jane, john, jack}, members(g), "expected existing members to be ignored")
+	})
+
+	t.Run("AddMembersNoPath", func(t *testing.T) {
+		g := &scim.Group{Schemas: []string{scim.SchemaGroup}, ID: "4", DisplayName: "engineering"}
+		require.NoError(t, apply(g, `[{"op": "add", "value": {"members": [{"value": "`+jack+`"}]}}]`))
+		require.Equal(t, []string{jack}, members(g))
+	})
+
+	t.Run("RemoveMemberFilter", func(t *testing.T) {
+		g := group()
+		require.NoError(t, apply(g, `[{"op": "remove", "path": "members[value eq \"`+jane+`\"]"}]`))
+		require.Equal(t, []string{john}, members(g))
+
+		// Removing a member that is not in the group is not an error.
+		require.NoError(t, apply(g, `[{"op": "remove", "path": "members[value eq \"`+jack+`\"]"}]`))
+		require.Equal(t, []string{john}, members(g))
+	})
+
+	t.Run("RemoveMemberValues", func(t *testing.T) {
+		g := group()
+		require.NoError(t, apply(g, `[{"op": "remove", "path": "members", "value": [{"value": "`+john+`"}]}]`))
+		require.Equal(t, []string{jane}, members(g))
+	})
+
+	t.Run("RemoveAllMembers", func(t *testing.T) {
+		g := group()
+		require.NoError(t, apply(g, `[{"op": "remove", "path": "members"}]`))
+		require.Empty(t, g.Members)
+	})
+
+	t.Run("ReplaceMembers", func(t *testing.T) {
+		g := group()
+		require.NoError(t, apply(g, `[{"op": "replace", "path": "members", "value": [{"value": "`+jack+`"}]}, {"op": "replace", "path": "displayName", "value": "platform"}]`))
+		require.Equal(t, []string{jack}, members(g))
+		require.Equal(t, "platform", g.DisplayName)
+	})
+
+	t.Run("ReplaceNoTarget", func(t *testing.T) {
+		g := group()
+		err := apply(g, `[{"op": "replace", "path": "members[value eq \"`+jack+`\"].display", "value": "jack"}]`)
+
+		var serr *scim.Error
+		require.ErrorAs(t, err, &serr)
+		require.Equal(t, scim.ErrorNoTarget, serr.ScimType)
+	})
+
+	t.Run("AddFilter", func(t *testing.T) {
+		g := group()
+		err := apply(g, `[{"op": "add", "path": "members[value eq \"`+jack+`\"]", "value": {"value": "`+jack+`"}}]`)
+
+		var serr *scim.Error
+		require.ErrorAs(t, err, &serr)
+		require.Equal(t, scim.ErrorInvalidPath, serr.ScimType)
+	})
+}
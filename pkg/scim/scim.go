@@ -0,0 +1,145 @@
+/*
+Package scim implements the resources and protocol messages of the SCIM 2.0 standard
+(RFC 7643 and RFC 7644) that Quarterdeck uses to let an upstream identity provider
+provision users and groups. SCIM Users map onto Quarterdeck users, where the userName is
+the email address of the user, and SCIM Groups map onto Quarterdeck roles.
+
+Only the subset of the standard that identity providers rely on is supported: equality
+filters, PATCH operations on simple and multi-valued attributes, and ETags; bulk
+operations, sorting, and changing passwords are not supported.
+*/
+package scim
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// MediaType is the content type of SCIM requests and responses.
+const MediaType = "application/scim+json"
+
+// Schema URNs of the SCIM resources and protocol messages.
+const (
+	SchemaUser                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SchemaGroup                 = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	SchemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	SchemaResourceType          = "urn:ietf:params:scim:schemas:core:2.0:ResourceType"
+	SchemaSchema                = "urn:ietf:params:scim:schemas:core:2.0:Schema"
+	SchemaListResponse          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SchemaPatchOp               = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SchemaError                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+)
+
+// Resource types and the endpoints they are served from, relative to the SCIM base URL.
+const (
+	ResourceUser   = "User"
+	ResourceGroup  = "Group"
+	EndpointUsers  = "/Users"
+	EndpointGroups = "/Groups"
+)
+
+// Query endpoints return DefaultPageCount resources unless the request specifies a
+// count; counts larger than MaxPageCount are reduced to MaxPageCount.
+const (
+	DefaultPageCount = 100
+	MaxPageCount     = 1000
+)
+
+// Error types that further describe a 400 or 409 error response (RFC 7644 §3.12).
+const (
+	ErrorInvalidFilter = "invalidFilter"
+	ErrorTooMany       = "tooMany"
+	ErrorUniqueness    = "uniqueness"
+	ErrorMutability    = "mutability"
+	ErrorInvalidSyntax = "invalidSyntax"
+	ErrorInvalidPath   = "invalidPath"
+	ErrorNoTarget      = "noTarget"
+	ErrorInvalidValue  = "invalidValue"
+)
+
+// Meta is the resource metadata common to all SCIM resources. The version is the ETag
+// of the resource that clients use for conditional requests.
+type Meta struct {
+	ResourceType string     `json:"resourceType"`
+	Created      *time.Time `json:"created,omitempty"`
+	LastModified *time.Time `json:"lastModified,omitempty"`
+	Location     string     `json:"location,omitempty"`
+	Version      string     `json:"version,omitempty"`
+}
+
+// ListResponse is returned by the query endpoints; startIndex is 1-based.
+type ListResponse struct {
+	Schemas      []string `json:"schemas"`
+	TotalResults int      `json:"totalResults"`
+	StartIndex   int      `json:"startIndex"`
+	ItemsPerPage int      `json:"itemsPerPage"`
+	Resources    []any    `json:"Resources"`
+}
+
+// NewListResponse returns the page of resources selected by the 1-based start index and
+// the count; a start index less than 1 is treated as 1 and a negative count as 0.
+func NewListResponse[T any](resources []T, startIndex, count int) *ListResponse {
+	if startIndex < 1 {
+		startIndex = 1
+	}
+
+	if count < 0 {
+		count = 0
+	}
+
+	out := &ListResponse{
+		Schemas:      []string{SchemaListResponse},
+		TotalResults: len(resources),
+		StartIndex:   startIndex,
+		Resources:    make([]any, 0),
+	}
+
+	for i := startIndex - 1; i < len(resources) && len(out.Resources) < count; i++ {
+		out.Resources = append(out.Resources, resources[i])
+	}
+
+	out.ItemsPerPage = len(out.Resources)
+	return out
+}
+
+// Error is the body of SCIM error responses; the status is the HTTP status code as a
+// string as required by RFC 7644.
+type Error struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
+
+// NewError creates an error response with the status code; scimType may be empty.
+func NewError(status int, scimType, detail string) *Error {
+	return &Error{
+		Schemas:  []string{SchemaError},
+		Status:   strconv.Itoa(status),
+		ScimType: scimType,
+		Detail:   detail,
+	}
+}
+
+// BadRequest creates an error with the 400 status and the SCIM error type.
+func BadRequest(scimType, detail string) *Error {
+	return NewError(http.StatusBadRequest, scimType, detail)
+}
+
+// Error implements the error interface so that errors can be returned by parsing and
+// validation functions and written as the response by the handlers.
+func (e *Error) Error() string {
+	if e.ScimType != "" {
+		return e.ScimType + ": " + e.Detail
+	}
+	return e.Detail
+}
+
+// StatusCode returns the HTTP status code of the error.
+func (e *Error) StatusCode() int {
+	if code, err := strconv.Atoi(e.Status); err == nil {
+		return code
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,79 @@
+package scim_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/quarterdeck/pkg/scim"
+)
+
+func TestNewListResponse(t *testing.T) {
+	resources := []string{"a", "b", "c", "d", "e"}
+
+	testCases := []struct {
+		startIndex int
+		count      int
+		expected   []any
+	}{
+		{1, 100, []any{"a", "b", "c", "d", "e"}},
+		{0, 2, []any{"a", "b"}},
+		{2, 2, []any{"b", "c"}},
+		{4, 10, []any{"d", "e"}},
+		{6, 10, []any{}},
+		{1, 0, []any{}},
+		{1, -1, []any{}},
+	}
+
+	for _, tc := range testCases {
+		out := scim.NewListResponse(resources, tc.startIndex, tc.count)
+		require.Equal(t, []string{scim.SchemaListResponse}, out.Schemas)
+		require.Equal(t, 5, out.TotalResults)
+		require.Equal(t, max(tc.startIndex, 1), out.StartIndex)
+		require.Equal(t, len(tc.expected), out.ItemsPerPage)
+		require.Equal(t, tc.expected, out.Resources)
+	}
+}
+
+func TestVersion(t *testing.T) {
+	group := &scim.Group{Schemas: []string{scim.SchemaGroup}, ID: "4", DisplayName: "engineering"}
+	version, err := scim.Version(group)
+	require.NoError(t, err)
+	require.Regexp(t, `^W/"[0-9a-f]{32}"$`, version)
+
+	again, err := scim.Version(group)
+	require.NoError(t, err)
+	require.Equal(t, version, again, "expected the version to be deterministic")
+
+	group.Members = []scim.Reference{{Value: "01JPYRNYMEHNEZCS0JYX1CP57A"}}
+	changed, err := scim.Version(group)
+	require.NoError(t, err)
+	require.NotEqual(t, version, changed, "expected the version to change with the resource")
+
+	require.True(t, scim.MatchVersion(version, version))
+	require.True(t, scim.MatchVersion(version[2:], version), "expected a weak comparison")
+	require.True(t, scim.MatchVersion(changed+", "+version, version))
+	require.True(t, scim.MatchVersion("*", version))
+	require.False(t, scim.MatchVersion(changed, version))
+	require.False(t, scim.MatchVersion("", version))
+}
+
+func TestUserValidate(t *testing.T) {
+	valid := &scim.User{Schemas: []string{scim.SchemaUser}, UserName: " jane@example.com "}
+	require.NoError(t, valid.Validate())
+	require.Equal(t, "jane@example.com", valid.UserName)
+
+	for _, invalid := range []*scim.User{
+		{UserName: "jane@example.com"},
+		{Schemas: []string{scim.SchemaUser}},
+		{Schemas: []string{scim.SchemaUser}, UserName: "jane"},
+		{Schemas: []string{scim.SchemaUser}, UserName: "Jane Doe <jane@example.com>"},
+	} {
+		var serr *scim.Error
+		require.ErrorAs(t, invalid.Validate(), &serr)
+		require.Equal(t, scim.ErrorInvalidValue, serr.ScimType)
+	}
+
+	require.True(t, (&scim.User{}).IsActive(), "expected users to be active unless specified")
+	require.Equal(t, "Jane Doe", (&scim.User{Name: &scim.Name{GivenName: "Jane", FamilyName: "Doe"}}).FullName())
+	require.Equal(t, "J. Doe", (&scim.User{Name: &scim.Name{}, DisplayName: "J. Doe"}).FullName())
+}
@@ -0,0 +1,156 @@
+package scim
+
+import (
+	"net/mail"
+	"slices"
+	"strconv"
+	"strings"
+
+	"go.rtnl.ai/quarterdeck/pkg/enum"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+)
+
+// User is the SCIM representation of a Quarterdeck user. The userName is the email
+// address of the user and is the only email address that is returned. A user is active
+// if they can log in; suspended, deactivated, and deleted users are not active.
+type User struct {
+	Schemas     []string    `json:"schemas"`
+	ID          string      `json:"id,omitempty"`
+	UserName    string      `json:"userName"`
+	Name        *Name       `json:"name,omitempty"`
+	DisplayName string      `json:"displayName,omitempty"`
+	Emails      []*Email    `json:"emails,omitempty"`
+	Active      *bool       `json:"active,omitempty"`
+	Groups      []Reference `json:"groups,omitempty"`
+	Meta        *Meta       `json:"meta,omitempty"`
+}
+
+// Name is the name of the user; Quarterdeck only stores the formatted name so the given
+// and family names are combined when the user is provisioned.
+type Name struct {
+	Formatted  string `json:"formatted,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+}
+
+type Email struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+// Reference is a group of a user or a member of a group.
+type Reference struct {
+	Value   string `json:"value"`
+	Ref     string `json:"$ref,omitempty"`
+	Display string `json:"display,omitempty"`
+}
+
+// UserReadOnly are the user attributes that cannot be modified by a PATCH request; the
+// groups of a user are modified by patching the members of the group.
+var UserReadOnly = []string{"id", "meta", "groups"}
+
+// NewUser converts the model into a SCIM user that is served from the base URL. The
+// groups of the user are only included if the roles were loaded with the user; they are
+// not part of the version since role assignments do not modify the user.
+func NewUser(model *models.User, base string) (out *User, err error) {
+	active := model.Status == enum.UserStatusActive
+	created, modified := model.Created, model.Modified
+
+	out = &User{
+		Schemas:  []string{SchemaUser},
+		ID:       model.ID.String(),
+		UserName: model.Email,
+		Emails:   []*Email{{Value: model.Email, Type: "work", Primary: true}},
+		Active:   &active,
+		Meta: &Meta{
+			ResourceType: ResourceUser,
+			Created:      &created,
+			LastModified: &modified,
+			Location:     base + EndpointUsers + "/" + model.ID.String(),
+		},
+	}
+
+	if model.Name.Valid && model.Name.String != "" {
+		out.Name = &Name{Formatted: model.Name.String}
+		out.DisplayName = model.Name.String
+	}
+
+	if out.Meta.Version, err = Version(out); err != nil {
+		return nil, err
+	}
+
+	for _, role := range model.Roles {
+		id := strconv.FormatInt(role.ID, 10)
+		out.Groups = append(out.Groups, Reference{
+			Value:   id,
+			Ref:     base + EndpointGroups + "/" + id,
+			Display: role.Title,
+		})
+	}
+	return out, nil
+}
+
+// Validate the user for create, replace, and patch requests. The id, meta, and groups
+// are ignored if they are specified since they cannot be modified.
+func (u *User) Validate() error {
+	if !slices.Contains(u.Schemas, SchemaUser) {
+		return BadRequest(ErrorInvalidValue, "the User schema is required")
+	}
+
+	u.UserName = strings.TrimSpace(u.UserName)
+	if u.UserName == "" {
+		return BadRequest(ErrorInvalidValue, "userName is required")
+	}
+
+	if addr, err := mail.ParseAddress(u.UserName); err != nil || addr.Address != u.UserName {
+		return BadRequest(ErrorInvalidValue, "userName must be an email address")
+	}
+
+	return nil
+}
+
+// FullName returns the formatted name of the user, the given and family names if the
+// formatted name is not specified, or the display name.
+func (u *User) FullName() string {
+	if u.Name != nil {
+		if name := strings.TrimSpace(u.Name.Formatted); name != "" {
+			return name
+		}
+
+		if name := strings.TrimSpace(u.Name.GivenName + " " + u.Name.FamilyName); name != "" {
+			return name
+		}
+	}
+	return strings.TrimSpace(u.DisplayName)
+}
+
+// PatchedName returns the full name of a user that was patched from the original user.
+// Since only the formatted name is stored, the name attribute that the patch changed
+// takes precedence over the others that still have their original values.
+func (u *User) PatchedName(original *User) string {
+	name, orig := u.Name, original.Name
+	if name == nil {
+		name = &Name{}
+	}
+
+	if orig == nil {
+		orig = &Name{}
+	}
+
+	switch {
+	case strings.TrimSpace(name.Formatted) != orig.Formatted:
+		return strings.TrimSpace(name.Formatted)
+	case name.GivenName != orig.GivenName || name.FamilyName != orig.FamilyName:
+		return strings.TrimSpace(name.GivenName + " " + name.FamilyName)
+	case u.DisplayName != original.DisplayName:
+		return strings.TrimSpace(u.DisplayName)
+	default:
+		return original.FullName()
+	}
+}
+
+// IsActive returns true unless the user is explicitly not active.
+func (u *User) IsActive() bool {
+	return u.Active == nil || *u.Active
+}
@@ -8,9 +8,10 @@ import (
 	"go.rtnl.ai/gimlet/auth"
 	"go.rtnl.ai/quarterdeck/pkg/api/v1"
 	"go.rtnl.ai/quarterdeck/pkg/auth/passwords"
+	"go.rtnl.ai/quarterdeck/pkg/enum"
 	"go.rtnl.ai/quarterdeck/pkg/errors"
-	"go.rtnl.ai/quarterdeck/pkg/store/v1/models"
-	"go.rtnl.ai/quarterdeck/pkg/store/v1/txn"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/txn"
 	"go.rtnl.ai/quarterdeck/pkg/web/htmx"
 	"go.rtnl.ai/quarterdeck/pkg/web/scene"
 	"go.rtnl.ai/ulid"
@@ -19,41 +20,48 @@ import (
 func (s *Server) ListAPIKeys(c *gin.Context) {
 	var (
 		err    error
-		in     *api.PageQuery
-		page   *models.Page
-		models *models.APIKeyList
+		in     *api.APIKeyPageQuery
+		cursor *api.Cursor
+		page   *api.Page
+		keys   []*models.APIKey
 		out    *api.APIKeyList
 	)
 
 	// PArse the URL parameters from the input request
-	in = &api.PageQuery{}
+	in = &api.APIKeyPageQuery{}
 	if err = c.BindQuery(in); err != nil {
 		c.Error(err)
 		c.JSON(http.StatusBadRequest, api.Error("invalid query parameters"))
 		return
 	}
 
-	// TODO: manage pagination mechanism
+	// Only list the API keys in the organization the requester is logged into.
+	if cursor, err = in.Cursor(s.currentOrg(c)); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadRequest, api.Error(err))
+		return
+	}
 
-	if models, err = s.store.ListAPIKeys(c.Request.Context(), page); err != nil {
+	if keys, err = listAll(s.store.ListAPIKeys(c.Request.Context(), cursor.Filter())); err != nil {
 		c.Error(err)
 		c.JSON(http.StatusInternalServerError, api.Error("could not process apikeys list request"))
 		return
 	}
+	keys, page = api.Paginate(cursor, keys, func(k *models.APIKey) ulid.ULID { return k.ID })
 
 	// Convert the database model to an API output
-	if out, err = api.NewAPIKeyList(models); err != nil {
+	if out, err = api.NewAPIKeyList(keys); err != nil {
 		c.Error(err)
 		c.JSON(http.StatusInternalServerError, api.Error("could not process apikeys list request"))
 		return
 	}
+	out.Page = page
 
 	c.JSON(http.StatusOK, out)
 }
 
 func (s *Server) CreateAPIKey(c *gin.Context) {
 	var (
-		tx          txn.Txn
 		err         error
 		in          *api.APIKey
 		key         *models.APIKey
@@ -111,41 +119,43 @@ func (s *Server) CreateAPIKey(c *gin.Context) {
 		return
 	}
 
-	// Create a transaction to handle the API key creation
-	if tx, err = s.store.Begin(c.Request.Context(), nil); err != nil {
-		c.Error(errors.Fmt("could not start write transaction for apikey: %w", err))
-		c.JSON(http.StatusInternalServerError, api.Error("could not process create apikey request"))
-		return
-	}
-	defer tx.Rollback()
-
-	// Set the owner of the API key
+	// Set the owner of the API key; keys belong to the organization the user is logged
+	// into and delegated keys cannot escape the organization of the key that created them.
 	switch subjectType {
 	case auth.SubjectUser:
 		key.CreatedBy = subjectID
-	case auth.SubjectAPIKey:
-		// Lookup the key being used in the database and set the created by to
-		// the owner of that key (e.g. the user that created that key).
-		var parent *models.APIKey
-		if parent, err = tx.RetrieveAPIKey(subjectID); err != nil {
-			c.Error(errors.Fmt("could not lookup parent API key: %w", err))
-			c.JSON(http.StatusInternalServerError, api.Error("could not process create apikey request"))
-			return
+		if orgID := s.currentOrg(c); !orgID.IsZero() {
+			key.OrgID = ulid.NullULID{ULID: orgID, Valid: true}
 		}
-		key.CreatedBy = parent.CreatedBy
+	case auth.SubjectAPIKey:
+		// The owner is set from the parent key in the transaction below.
 	default:
 		c.JSON(http.StatusForbidden, api.Error("only users and api keys can create api keys"))
 		return
 	}
 
-	if err = tx.CreateAPIKey(key); err != nil {
-		c.Error(errors.Fmt("could not create api key: %w", err))
-		c.JSON(http.StatusInternalServerError, api.Error("could not process create apikey request"))
-		return
-	}
+	// Create the API key in a transaction so that the parent key cannot be modified
+	// between the lookup and the creation of the delegated key.
+	err = s.store.WithTx(c.Request.Context(), nil, func(tx txn.Tx) (err error) {
+		if subjectType == auth.SubjectAPIKey {
+			// Lookup the key being used in the database and set the created by to
+			// the owner of that key (e.g. the user that created that key).
+			var parent *models.APIKey
+			if parent, err = tx.RetrieveAPIKey(subjectID); err != nil {
+				return errors.Fmt("could not lookup parent API key: %w", err)
+			}
+			key.CreatedBy = parent.CreatedBy
+			key.OrgID = parent.OrgID
+		}
+
+		if key, err = tx.CreateAPIKey(key); err != nil {
+			return errors.Fmt("could not create api key: %w", err)
+		}
+		return nil
+	})
 
-	if err = tx.Commit(); err != nil {
-		c.Error(errors.Fmt("could not commit create api key transaction: %w", err))
+	if err != nil {
+		c.Error(err)
 		c.JSON(http.StatusInternalServerError, api.Error("could not process create apikey request"))
 		return
 	}
@@ -157,6 +167,8 @@ func (s *Server) CreateAPIKey(c *gin.Context) {
 		return
 	}
 
+	s.audit(c, models.AuditCreate, models.AuditAPIKey, key.ID.String(), nil, out)
+
 	// Ensure the created apikey secret is returned to the user
 	out.Secret = secret
 
@@ -197,6 +209,11 @@ func (s *Server) APIKeyDetail(c *gin.Context) {
 		return
 	}
 
+	if !s.inCurrentOrg(c, key.OrgID.ULID) {
+		c.JSON(http.StatusNotFound, api.Error("apikey not found"))
+		return
+	}
+
 	if out, err = api.NewAPIKey(key); err != nil {
 		c.Error(err)
 		c.JSON(http.StatusInternalServerError, api.Error("could not process apikey detail request"))
@@ -246,6 +263,11 @@ func (s *Server) UpdateAPIKeyPreview(c *gin.Context) {
 		return
 	}
 
+	if !s.inCurrentOrg(c, apikey.OrgID.ULID) {
+		c.JSON(http.StatusNotFound, api.Error("apikey not found"))
+		return
+	}
+
 	if out, err = api.NewAPIKey(apikey); err != nil {
 		c.Error(err)
 		c.JSON(http.StatusInternalServerError, api.Error("unable to process apikey detail request"))
@@ -258,11 +280,12 @@ func (s *Server) UpdateAPIKeyPreview(c *gin.Context) {
 
 func (s *Server) UpdateAPIKey(c *gin.Context) {
 	var (
-		err   error
-		keyID ulid.ULID
-		key   *models.APIKey
-		in    *api.APIKey
-		out   *api.APIKey
+		err    error
+		keyID  ulid.ULID
+		key    *models.APIKey
+		in     *api.APIKey
+		before *api.APIKey
+		out    *api.APIKey
 	)
 
 	// Parse the key ID from the URL parameter
@@ -296,6 +319,11 @@ func (s *Server) UpdateAPIKey(c *gin.Context) {
 		return
 	}
 
+	// Retrieve the API key before it is updated to record the changes in the audit log
+	if before, err = s.retrieveAPIKey(c, keyID); err != nil {
+		return
+	}
+
 	// Update the API key in the database
 	if err = s.store.UpdateAPIKey(c.Request.Context(), key); err != nil {
 		if errors.Is(err, errors.ErrNotFound) {
@@ -308,13 +336,13 @@ func (s *Server) UpdateAPIKey(c *gin.Context) {
 		return
 	}
 
-	// Convert the model back to an API response
-	if out, err = api.NewAPIKey(key); err != nil {
-		c.Error(err)
-		c.JSON(http.StatusInternalServerError, api.Error("could not process update apikey request"))
+	// Reload the API key so that the response includes the fields that cannot be updated
+	if out, err = s.retrieveAPIKey(c, keyID); err != nil {
 		return
 	}
 
+	s.audit(c, models.AuditUpdate, models.AuditAPIKey, keyID.String(), before, out)
+
 	// Return successful JSON response or 204 with htmx trigger depending on the content negotiation
 	switch c.NegotiateFormat(binding.MIMEJSON, binding.MIMEHTML) {
 	case binding.MIMEJSON:
@@ -337,9 +365,25 @@ func (s *Server) DeleteAPIKey(c *gin.Context) {
 		return
 	}
 
-	// Delete the API key from the database
+	// Requesters logged into an organization can only delete the keys in it.
+	if !s.currentOrg(c).IsZero() {
+		if _, err = s.retrieveAPIKey(c, keyID); err != nil {
+			return
+		}
+	}
+
+	// Delete the API key from the database and queue the webhook event in the same
+	// transaction.
 	// TODO: for audit purposes we may simply want to move the API key to a revoked table.
-	if err = s.store.DeleteAPIKey(c.Request.Context(), keyID); err != nil {
+	var queued int
+	if err = s.store.WithTx(c.Request.Context(), nil, func(tx txn.Tx) (err error) {
+		if err = tx.DeleteAPIKey(keyID); err != nil {
+			return err
+		}
+
+		queued, err = publishTx(tx, enum.WebhookEventAPIKeyRevoked, deletedResource{ID: keyID})
+		return err
+	}); err != nil {
 		if errors.Is(err, errors.ErrNotFound) {
 			c.JSON(http.StatusNotFound, api.Error("apikey not found"))
 			return
@@ -350,6 +394,10 @@ func (s *Server) DeleteAPIKey(c *gin.Context) {
 		return
 	}
 
+	s.notifyQueued(queued)
+
+	s.audit(c, models.AuditDelete, models.AuditAPIKey, keyID.String(), nil, nil)
+
 	if htmx.IsHTMXRequest(c) {
 		htmx.SetTrigger(c, htmx.APIKeysUpdated)
 		c.Data(http.StatusNoContent, gin.MIMEHTML, nil)
@@ -358,3 +406,32 @@ func (s *Server) DeleteAPIKey(c *gin.Context) {
 
 	c.JSON(http.StatusOK, api.Reply{Success: true})
 }
+
+// retrieveAPIKey fetches the API key for an update or delete; keys that are not in the
+// organization the requester is logged into are not found. If an error is returned then
+// the response has already been written.
+func (s *Server) retrieveAPIKey(c *gin.Context, keyID ulid.ULID) (out *api.APIKey, err error) {
+	var key *models.APIKey
+	if key, err = s.store.RetrieveAPIKey(c.Request.Context(), keyID); err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			c.JSON(http.StatusNotFound, api.Error("apikey not found"))
+			return nil, err
+		}
+
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process update apikey request"))
+		return nil, err
+	}
+
+	if !s.inCurrentOrg(c, key.OrgID.ULID) {
+		c.JSON(http.StatusNotFound, api.Error("apikey not found"))
+		return nil, errors.ErrNotFound
+	}
+
+	if out, err = api.NewAPIKey(key); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process update apikey request"))
+		return nil, err
+	}
+	return out, nil
+}
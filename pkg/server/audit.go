@@ -0,0 +1,173 @@
+package server
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	gimauth "go.rtnl.ai/gimlet/auth"
+	"go.rtnl.ai/quarterdeck/pkg/api/v1"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/quarterdeck/pkg/web/scene"
+	"go.rtnl.ai/tidal"
+	"go.rtnl.ai/ulid"
+)
+
+const (
+	// HeaderRequestID is used to correlate audit events with the request that caused
+	// them; if the client does not supply a request ID then one is generated.
+	HeaderRequestID = "X-Request-ID"
+	requestIDKey    = "request_id"
+)
+
+//===========================================================================
+// Activity Handlers
+//===========================================================================
+
+// ListActivity returns a page of the audit log, most recent event first, optionally
+// filtered by actor, subject, action, and time range. Requesters logged into an
+// organization only see the events recorded in that organization.
+func (s *Server) ListActivity(c *gin.Context) {
+	var (
+		err    error
+		in     *api.AuditEventQuery
+		filter *tidal.CustomFilter
+		events []*models.AuditEvent
+		out    *api.AuditEventList
+	)
+
+	in = &api.AuditEventQuery{}
+	if err = c.BindQuery(in); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadRequest, api.Error("invalid query parameters"))
+		return
+	}
+
+	if err = in.Validate(); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadRequest, api.Error(err))
+		return
+	}
+
+	if filter, err = in.Filter(s.currentOrg(c)); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadRequest, api.Error("invalid query parameters"))
+		return
+	}
+
+	if events, err = listAll(s.store.ListAuditEvents(c.Request.Context(), filter)); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process activity list request"))
+		return
+	}
+
+	if out, err = api.NewAuditEventList(events, in.Size()); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process activity list request"))
+		return
+	}
+
+	// Content negotiation
+	c.Negotiate(http.StatusOK, gin.Negotiate{
+		Offered:  []string{binding.MIMEJSON, binding.MIMEHTML},
+		Data:     out,
+		HTMLName: "partials/activity/list.html",
+		HTMLData: scene.New(c).WithAPIData(out),
+	})
+}
+
+//===========================================================================
+// Audit Helpers
+//===========================================================================
+
+// audit records an action performed by the authenticated requester on the subject.
+// The before and after states of the subject are compared to record the fields that
+// were changed by the action; before is nil on create and after is nil on delete.
+// Audit failures are logged but do not fail the request since the action has already
+// been performed.
+func (s *Server) audit(c *gin.Context, action, subjectType, subjectID string, before, after any) {
+	event := auditEvent(c, action, subjectType, subjectID)
+
+	if claims, err := gimauth.GetClaims(c); err == nil {
+		if sub, actorID, err := claims.SubjectID(); err == nil {
+			switch sub {
+			case gimauth.SubjectUser:
+				event.ActorType = sql.NullString{String: models.AuditUser, Valid: true}
+			case gimauth.SubjectAPIKey:
+				event.ActorType = sql.NullString{String: models.AuditAPIKey, Valid: true}
+			}
+
+			if event.ActorType.Valid {
+				event.ActorID = ulid.NullULID{ULID: actorID, Valid: true}
+			}
+		}
+	}
+
+	var err error
+	if event.Diff, err = models.AuditDiff(before, after); err != nil {
+		c.Error(err)
+	}
+
+	s.recordAudit(c, event)
+}
+
+// auditLogin records a successful or failed login of a user or API key. The requester
+// is not yet authenticated so on success the subject is also recorded as the actor;
+// failed logins are recorded without an actor since the requester is unknown.
+func (s *Server) auditLogin(c *gin.Context, action, subjectType string, subjectID ulid.ULID) {
+	event := auditEvent(c, action, subjectType, subjectID.String())
+	if action == models.AuditLogin {
+		event.ActorType = sql.NullString{String: subjectType, Valid: true}
+		event.ActorID = ulid.NullULID{ULID: subjectID, Valid: true}
+	}
+	s.recordAudit(c, event)
+}
+
+func (s *Server) recordAudit(c *gin.Context, event *models.AuditEvent) {
+	if orgID := s.currentOrg(c); !orgID.IsZero() {
+		event.OrganizationID = ulid.NullULID{ULID: orgID, Valid: true}
+	}
+
+	if _, err := s.store.CreateAuditEvent(c.Request.Context(), event); err != nil {
+		c.Error(err)
+	}
+}
+
+// auditEvent creates an event for the subject with the client details of the request.
+func auditEvent(c *gin.Context, action, subjectType, subjectID string) *models.AuditEvent {
+	event := &models.AuditEvent{
+		Action:      action,
+		SubjectType: subjectType,
+		SubjectID:   subjectID,
+		RequestID:   sql.NullString{String: requestID(c), Valid: true},
+	}
+
+	if ip := c.ClientIP(); ip != "" {
+		event.ClientIP = sql.NullString{String: ip, Valid: true}
+	}
+
+	if ua := c.Request.UserAgent(); ua != "" {
+		event.UserAgent = sql.NullString{String: ua, Valid: true}
+	}
+
+	return event
+}
+
+// requestID returns the X-Request-ID of the request, generating one if the client did
+// not supply it. The ID is returned in the response headers so that clients can
+// correlate their requests with the audit log.
+func requestID(c *gin.Context) string {
+	if id := c.GetString(requestIDKey); id != "" {
+		return id
+	}
+
+	id := c.GetHeader(HeaderRequestID)
+	if id == "" {
+		id = ulid.MakeSecure().String()
+	}
+
+	c.Set(requestIDKey, id)
+	c.Header(HeaderRequestID, id)
+	return id
+}
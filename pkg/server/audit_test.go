@@ -0,0 +1,198 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/gimlet"
+	"go.rtnl.ai/gimlet/auth"
+	"go.rtnl.ai/quarterdeck/pkg/api/v1"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/mock"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/tidal"
+	"go.rtnl.ai/ulid"
+)
+
+func TestListActivity(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		actorID := ulid.MakeSecure()
+		next := ulid.MakeSecure()
+
+		mockStore.OnListAuditEvents = func(_ context.Context, filter tidal.ListFilter) (tidal.Cursor[*models.AuditEvent], error) {
+			custom, ok := filter.(*tidal.CustomFilter)
+			require.True(t, ok, "expected a custom filter for the activity query")
+			require.Contains(t, custom.Args, sql.Named("actor_id", actorID))
+			require.Contains(t, custom.Args, sql.Named("subject_type", "user"))
+			require.Contains(t, custom.Args, sql.Named("action", "login"))
+
+			// One more event than the page size is fetched to detect the next page.
+			return mock.NewCursor(
+				&models.AuditEvent{BaseModel: tidal.BaseModel{ID: next, Created: time.Now()}, Action: models.AuditLogin, SubjectType: models.AuditUser, SubjectID: actorID.String()},
+				&models.AuditEvent{BaseModel: tidal.BaseModel{ID: ulid.MakeSecure(), Created: time.Now()}, Action: models.AuditLogin, SubjectType: models.AuditUser, SubjectID: actorID.String()},
+			), nil
+		}
+
+		w, c := requestContext(t, http.MethodGet, "/v1/activity?page_size=1&subject_type=user&action=login&actor_id="+actorID.String(), nil, nil)
+		srv.ListActivity(c)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		out := &api.AuditEventList{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), out))
+		require.Len(t, out.Events, 1)
+		require.Equal(t, next.String(), out.Page.NextPageToken)
+	})
+
+	t.Run("Organization", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestOAuthServer(t, mockStore)
+
+		// Requesters logged into an organization only see the events recorded in it.
+		mockStore.OnListAuditEvents = func(_ context.Context, filter tidal.ListFilter) (tidal.Cursor[*models.AuditEvent], error) {
+			custom, ok := filter.(*tidal.CustomFilter)
+			require.True(t, ok, "expected a custom filter for the activity query")
+			require.Contains(t, custom.SQL, "organization_id = :org_id")
+			require.Contains(t, custom.Args, sql.Named("org_id", acmeOrgID))
+			return mock.NewCursor[*models.AuditEvent](), nil
+		}
+
+		w, c := requestContext(t, http.MethodGet, "/v1/activity", nil, nil)
+		authorizeOrg(t, srv, c, acmeOrgID)
+		srv.ListActivity(c)
+		require.Equal(t, http.StatusOK, w.Code)
+		mockStore.AssertCalls(t, mock.ListAuditEvents, 1)
+	})
+
+	t.Run("BadQuery", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		w, c := requestContext(t, http.MethodGet, "/v1/activity?actor_id=notaulid", nil, nil)
+		srv.ListActivity(c)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		mockStore.AssertCalls(t, mock.ListAuditEvents, 0)
+	})
+
+	t.Run("StoreError", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		mockStore.OnListAuditEvents = func(context.Context, tidal.ListFilter) (tidal.Cursor[*models.AuditEvent], error) {
+			return nil, errors.New("db error")
+		}
+
+		w, c := requestContext(t, http.MethodGet, "/v1/activity", nil, nil)
+		srv.ListActivity(c)
+		require.Equal(t, http.StatusInternalServerError, w.Code)
+		reply := parseReply(t, w)
+		require.Equal(t, "could not process activity list request", reply.Error)
+	})
+}
+
+func TestAudit(t *testing.T) {
+	t.Run("Authenticated", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		userID := ulid.MakeSecure()
+		claims := &auth.Claims{}
+		claims.SetSubjectID(auth.SubjectUser, userID)
+
+		var event *models.AuditEvent
+		mockStore.OnCreateAuditEvent = func(_ context.Context, in *models.AuditEvent) (*models.AuditEvent, error) {
+			event = in
+			return in, nil
+		}
+
+		w, c := requestContext(t, http.MethodDelete, "/v1/apikeys/foo", nil, nil)
+		c.Request.Header.Set("User-Agent", "quarterdeck-test")
+		c.Request.Header.Set(HeaderRequestID, "req-1234")
+		gimlet.Set(c, gimlet.KeyUserClaims, claims)
+
+		before := &api.APIKey{Description: "foo", Secret: "supersecret"}
+		after := &api.APIKey{Description: "bar", Secret: "supersecret"}
+		srv.audit(c, models.AuditUpdate, models.AuditAPIKey, "foo", before, after)
+
+		require.Equal(t, models.AuditUser, event.ActorType.String)
+		require.Equal(t, userID, event.ActorID.ULID)
+		require.Equal(t, "quarterdeck-test", event.UserAgent.String)
+		require.Equal(t, "req-1234", event.RequestID.String)
+		require.NotEmpty(t, event.ClientIP.String)
+		require.JSONEq(t, `{"description":{"from":"foo","to":"bar"}}`, event.Diff.String)
+		require.Equal(t, "req-1234", w.Header().Get(HeaderRequestID))
+		require.False(t, event.OrganizationID.Valid)
+	})
+
+	t.Run("Organization", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestOAuthServer(t, mockStore)
+
+		var event *models.AuditEvent
+		mockStore.OnCreateAuditEvent = func(_ context.Context, in *models.AuditEvent) (*models.AuditEvent, error) {
+			event = in
+			return in, nil
+		}
+
+		_, c := requestContext(t, http.MethodDelete, "/v1/apikeys/foo", nil, nil)
+		authorizeOrg(t, srv, c, globexOrgID)
+		srv.audit(c, models.AuditDelete, models.AuditAPIKey, "foo", nil, nil)
+
+		require.True(t, event.OrganizationID.Valid)
+		require.Equal(t, globexOrgID, event.OrganizationID.ULID)
+	})
+
+	t.Run("FailedLogin", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		var event *models.AuditEvent
+		mockStore.OnCreateAuditEvent = func(_ context.Context, in *models.AuditEvent) (*models.AuditEvent, error) {
+			event = in
+			return in, nil
+		}
+
+		w, c := requestContext(t, http.MethodPost, "/v1/login", nil, nil)
+		userID := ulid.MakeSecure()
+		srv.auditLogin(c, models.AuditLoginFailed, models.AuditUser, userID)
+
+		require.False(t, event.ActorType.Valid)
+		require.False(t, event.ActorID.Valid)
+		require.Equal(t, userID.String(), event.SubjectID)
+
+		// A request ID is generated if the client did not supply one.
+		require.NotEmpty(t, event.RequestID.String)
+		require.Equal(t, event.RequestID.String, w.Header().Get(HeaderRequestID))
+	})
+
+	t.Run("StoreError", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		mockStore.OnCreateAuditEvent = func(_ context.Context, in *models.AuditEvent) (*models.AuditEvent, error) {
+			return nil, errors.New("db error")
+		}
+
+		// Audit failures are recorded on the context but do not write a response.
+		w, c := requestContext(t, http.MethodPost, "/v1/login", nil, nil)
+		srv.auditLogin(c, models.AuditLogin, models.AuditUser, ulid.MakeSecure())
+		require.Len(t, c.Errors, 1)
+		require.False(t, c.Writer.Written())
+		require.Equal(t, http.StatusOK, w.Code)
+	})
+}
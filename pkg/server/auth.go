@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"slices"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -16,10 +17,13 @@ import (
 	"go.rtnl.ai/quarterdeck/pkg/api/v1"
 	"go.rtnl.ai/quarterdeck/pkg/auth"
 	"go.rtnl.ai/quarterdeck/pkg/auth/passwords"
+	"go.rtnl.ai/quarterdeck/pkg/enum"
 	"go.rtnl.ai/quarterdeck/pkg/errors"
-	"go.rtnl.ai/quarterdeck/pkg/store/v1/models"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/txn"
 	"go.rtnl.ai/quarterdeck/pkg/web/htmx"
 	"go.rtnl.ai/quarterdeck/pkg/web/scene"
+	"go.rtnl.ai/tidal"
 	"go.rtnl.ai/x/rlog"
 )
 
@@ -56,10 +60,10 @@ func (s *Server) PrepareLogin(c *gin.Context) {
 // user without resubmitting the password, but it is only valid for a limited time.
 func (s *Server) Login(c *gin.Context) {
 	var (
-		err  error
-		user *models.User
-		in   *api.LoginRequest
-		out  *api.LoginReply
+		err     error
+		user    *models.User
+		lockout *models.Lockout
+		in      *api.LoginRequest
 	)
 
 	if err = c.BindJSON(&in); err != nil {
@@ -75,11 +79,26 @@ func (s *Server) Login(c *gin.Context) {
 		return
 	}
 
+	// Refuse to check any more passwords for the email address if there have been too
+	// many failed login attempts to prevent brute-force attacks. Lockouts are tracked by
+	// email address whether or not the user exists to prevent enumeration attacks.
+	if lockout, err = s.checkLockout(c, models.LockoutUser, in.Email); err != nil {
+		if errors.Is(err, errors.ErrLockedOut) {
+			c.JSON(http.StatusTooManyRequests, api.Error(errors.ErrLockedOut))
+			return
+		}
+
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error(errors.ErrInternal))
+		return
+	}
+
 	// Retrieve the user by email
-	if user, err = s.store.RetrieveUser(c.Request.Context(), in.Email); err != nil {
+	if user, err = s.store.RetrieveUserByEmail(c.Request.Context(), in.Email); err != nil {
 		// Do not indicate whether or not the user exists to prevent enumeration attacks
 		// Simply indicate that the authentication failed.
 		if errors.Is(err, errors.ErrNotFound) {
+			s.recordFailedAttempt(c, models.LockoutUser, in.Email, nil)
 			c.JSON(http.StatusUnauthorized, api.Error(errors.ErrFailedAuthentication))
 			return
 		}
@@ -90,9 +109,10 @@ func (s *Server) Login(c *gin.Context) {
 		return
 	}
 
-	// User must be verified before they can log in.
-	// TODO: redirect to an email verification page where they can request a new verification email
+	// User must be verified before they can log in; the login page offers to resend
+	// the verification email when it receives the email-not-verified event.
 	if !user.EmailVerified {
+		htmx.SetTrigger(c, htmx.EmailNotVerified)
 		c.JSON(http.StatusUnauthorized, api.Error(errors.ErrEmailNotVerified))
 		return
 	}
@@ -107,15 +127,47 @@ func (s *Server) Login(c *gin.Context) {
 
 	if !verified {
 		// If the password is incorrect, return a failed authentication error.
+		s.recordFailedAttempt(c, models.LockoutUser, in.Email, user)
+		s.auditLogin(c, models.AuditLoginFailed, models.AuditUser, user.ID)
 		c.JSON(http.StatusUnauthorized, api.Error(errors.ErrFailedAuthentication))
 		return
 	}
 
-	// Update the user's last login time after successful authentication.
-	if err = s.store.UpdateLastLogin(c.Request.Context(), user.ID, time.Now()); err != nil {
-		// If we cannot update the last login time, still return the access tokens but
-		// log the error. This is not critical to the authentication process.
-		c.Error(err)
+	// The password is correct so clear any previous failed login attempts, unless the
+	// user has a second factor; those are only cleared once the challenge is completed
+	// so that a known password does not reset the lockout on the second factor.
+	if !user.MFAEnabled() {
+		s.resetLockout(c, lockout)
+	}
+
+	// Suspended, deactivated, and deleted users cannot log in even with the correct
+	// password; the status is only revealed once the password has been verified.
+	if err = s.checkUserStatus(c, user, http.StatusUnauthorized); err != nil {
+		return
+	}
+
+	// If the user has a second factor enabled then the password is only the first step
+	// of the login; the user must complete a short-lived challenge with their TOTP code.
+	if user.MFAEnabled() {
+		s.challengeMFA(c, user, in)
+		return
+	}
+
+	s.completeLogin(c, user, in.ClientID, in.Nonce, in.Next, auth.AMRPassword)
+}
+
+// completeLogin issues the access, refresh, and ID tokens for a user that has been
+// authenticated either by their password or by their password and a second factor. The
+// authentication methods are added to the tokens as the amr claim.
+func (s *Server) completeLogin(c *gin.Context, user *models.User, clientID, nonce, next string, amr ...string) {
+	var (
+		err error
+		out *api.LoginReply
+	)
+
+	// The status may have changed since the first factor was checked.
+	if err = s.checkUserStatus(c, user, http.StatusUnauthorized); err != nil {
+		return
 	}
 
 	// Prepare the login reply now that the user has been authenticated
@@ -124,15 +176,39 @@ func (s *Server) Login(c *gin.Context) {
 		out.LastLogin = user.LastLogin.Time
 	}
 
+	// Log the user into the first organization they joined (if any) so that the claims
+	// include the roles and permissions the user has in that organization.
+	if user, err = s.organizationMember(c.Request.Context(), user, ulid.Zero); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error(errors.ErrInternal))
+		return
+	}
+
 	// Create the access and refresh tokens for the user.
-	var claims *gimlet.Claims
-	if claims, err = user.Claims(); err != nil {
+	claims := user.Claims()
+	if out.AccessToken, out.RefreshToken, err = s.issueTokens(c, claims, user.OrgID, ulid.Zero, amr...); err != nil {
 		c.Error(err)
 		c.JSON(http.StatusInternalServerError, api.Error(errors.ErrInternal))
 		return
 	}
 
-	if out.AccessToken, out.RefreshToken, err = s.issuer.CreateTokens(claims); err != nil {
+	// Create an OpenID Connect ID token that describes this authentication event; if a
+	// client is specified then it must be a registered OIDC client.
+	if clientID != "" {
+		if _, err = s.store.RetrieveOIDCClientByClientID(c.Request.Context(), clientID); err != nil {
+			if errors.Is(err, errors.ErrNotFound) {
+				c.JSON(http.StatusBadRequest, api.Error(errors.ErrUnknownClient))
+				return
+			}
+
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, api.Error(errors.ErrInternal))
+			return
+		}
+	}
+
+	opts := auth.IDTokenOptions{ClientID: clientID, Nonce: nonce, EmailVerified: user.EmailVerified}
+	if out.IDToken, err = s.issuer.CreateIDToken(claims, out.AccessToken, opts); err != nil {
 		c.Error(err)
 		c.JSON(http.StatusInternalServerError, api.Error(errors.ErrInternal))
 		return
@@ -145,21 +221,36 @@ func (s *Server) Login(c *gin.Context) {
 		return
 	}
 
-	// Sync user
-	if apiUser, err := api.NewUser(user); err != nil {
-		// Only log this error
-		rlog.WarnAttrs(c.Request.Context(), "user login sync: could not convert model user to api user for sync",
-			slog.Any("err", err), slog.String("user_id", user.ID.String()))
-	} else {
-		s.syncUserPost(c, apiUser, &out.AccessToken, false)
+	s.auditLogin(c, models.AuditLogin, models.AuditUser, user.ID)
+
+	// Update the user's last login time and queue the login event in the same
+	// transaction after successful authentication.
+	var queued int
+	if err = s.store.WithTx(c.Request.Context(), nil, func(tx txn.Tx) (err error) {
+		if err = tx.UpdateLastLogin(user.ID, time.Now()); err != nil {
+			return err
+		}
+
+		var apiUser *api.User
+		if apiUser, err = api.NewUser(user); err != nil {
+			return err
+		}
+
+		queued, err = publishTx(tx, enum.WebhookEventLogin, apiUser)
+		return err
+	}); err != nil {
+		// If we cannot update the last login time, still return the access tokens but
+		// log the error. This is not critical to the authentication process.
+		c.Error(err)
 	}
+	s.notifyQueued(queued)
 
 	// Content negotiation and redirection if required.
 	switch c.NegotiateFormat(binding.MIMEJSON, binding.MIMEHTML) {
 	case binding.MIMEJSON:
 		c.JSON(http.StatusOK, out)
 	case binding.MIMEHTML:
-		location := in.Next
+		location := next
 		if location == "" {
 			location = s.conf.Auth.LoginRedirect
 		}
@@ -180,12 +271,13 @@ func (s *Server) Logout(c *gin.Context) {
 // Authenticate a user via their API key.
 func (s *Server) Authenticate(c *gin.Context) {
 	var (
-		err    error
-		ctx    context.Context
-		apiKey *models.APIKey
-		in     *api.AuthenticateRequest
-		out    *api.LoginReply
-		claims *gimlet.Claims
+		err     error
+		ctx     context.Context
+		apiKey  *models.APIKey
+		lockout *models.Lockout
+		in      *api.AuthenticateRequest
+		out     *api.LoginReply
+		claims  *gimlet.Claims
 	)
 
 	if err = c.BindJSON(&in); err != nil {
@@ -201,10 +293,24 @@ func (s *Server) Authenticate(c *gin.Context) {
 		return
 	}
 
+	// Refuse to check any more secrets for the client ID if there have been too many
+	// failed authentication attempts to prevent brute-force attacks.
+	if lockout, err = s.checkLockout(c, models.LockoutClient, in.ClientID); err != nil {
+		if errors.Is(err, errors.ErrLockedOut) {
+			c.JSON(http.StatusTooManyRequests, api.Error(errors.ErrLockedOut))
+			return
+		}
+
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error(errors.ErrInternal))
+		return
+	}
+
 	// Retrieve the API key from the database
 	ctx = c.Request.Context()
-	if apiKey, err = s.store.RetrieveAPIKey(ctx, in.ClientID); err != nil {
+	if apiKey, err = s.store.RetrieveAPIKeyByClientID(ctx, in.ClientID); err != nil {
 		if errors.Is(err, errors.ErrNotFound) {
+			s.recordFailedAttempt(c, models.LockoutClient, in.ClientID, nil)
 			c.JSON(http.StatusUnauthorized, api.Error(errors.ErrFailedAuthentication))
 			return
 		}
@@ -222,10 +328,13 @@ func (s *Server) Authenticate(c *gin.Context) {
 	}
 
 	if !verified {
+		s.recordFailedAttempt(c, models.LockoutClient, in.ClientID, nil)
+		s.auditLogin(c, models.AuditLoginFailed, models.AuditAPIKey, apiKey.ID)
 		c.JSON(http.StatusUnauthorized, api.Error(errors.ErrFailedAuthentication))
 		return
 	}
 
+	s.resetLockout(c, lockout)
 	if err = s.store.UpdateLastSeen(ctx, apiKey.ID, time.Now()); err != nil {
 		c.Error(err)
 		c.JSON(http.StatusInternalServerError, api.Error(errors.ErrInternal))
@@ -240,7 +349,7 @@ func (s *Server) Authenticate(c *gin.Context) {
 
 	// Create access and refresh tokens for the API key
 	claims = apiKey.Claims()
-	if out.AccessToken, out.RefreshToken, err = s.issuer.CreateTokens(claims); err != nil {
+	if out.AccessToken, out.RefreshToken, err = s.issueTokens(c, claims, apiKey.OrgID.ULID, ulid.Zero); err != nil {
 		c.Error(err)
 		c.JSON(http.StatusInternalServerError, api.Error(errors.ErrInternal))
 		return
@@ -253,6 +362,8 @@ func (s *Server) Authenticate(c *gin.Context) {
 		return
 	}
 
+	s.auditLogin(c, models.AuditLogin, models.AuditAPIKey, apiKey.ID)
+
 	// Content negotiation and redirection if required.
 	switch c.NegotiateFormat(binding.MIMEJSON, binding.MIMEHTML) {
 	case binding.MIMEJSON:
@@ -271,12 +382,14 @@ func (s *Server) Authenticate(c *gin.Context) {
 // Reauthenticate a user via their refresh token.
 func (s *Server) Reauthenticate(c *gin.Context) {
 	var (
-		err    error
-		claims *gimlet.Claims
-		sub    gimlet.SubjectType
-		subID  ulid.ULID
-		in     *api.ReauthenticateRequest
-		out    *api.LoginReply
+		err     error
+		claims  *gimlet.Claims
+		sub     gimlet.SubjectType
+		subID   ulid.ULID
+		orgID   ulid.ULID
+		refresh *models.RefreshToken
+		in      *api.ReauthenticateRequest
+		out     *api.LoginReply
 	)
 
 	if err = c.BindJSON(&in); err != nil {
@@ -299,7 +412,19 @@ func (s *Server) Reauthenticate(c *gin.Context) {
 		return
 	}
 
-	// TODO: Verify that this is a refresh token with the correct audience.
+	// Access tokens share the jti of their refresh token so only accept tokens that
+	// were issued for the refresh audience.
+	if !slices.Contains(claims.Audience, s.issuer.RefreshAudience()) {
+		c.JSON(http.StatusForbidden, api.Error(errors.ErrFailedAuthentication))
+		return
+	}
+
+	// Each refresh token can only be used once; rotate the refresh token or revoke the
+	// token family if the refresh token has already been used.
+	if refresh, err = s.useRefreshToken(c, claims); err != nil {
+		// Error logging is handled in useRefreshToken
+		return
+	}
 
 	// Parse the subject type and ID from the claims.
 	if sub, subID, err = claims.SubjectID(); err != nil {
@@ -308,15 +433,26 @@ func (s *Server) Reauthenticate(c *gin.Context) {
 		return
 	}
 
+	// Users remain logged into the organization of the refresh token so long as they are
+	// still a member of it.
+	if orgID, err = s.issuer.Org(in.RefreshToken); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusForbidden, api.Error(errors.ErrFailedAuthentication))
+		return
+	}
+
 	// Load claims based on the subject type.
+	var subjectType string
 	switch sub {
 	case gimlet.SubjectUser:
-		if claims, err = s.reauthenticateUser(c, subID); err != nil {
+		subjectType = models.AuditUser
+		if claims, orgID, err = s.reauthenticateUser(c, subID, orgID); err != nil {
 			// Error logging is handled in reauthenticateUser
 			return
 		}
 	case gimlet.SubjectAPIKey:
-		if claims, err = s.reauthenticateAPIKey(c, subID); err != nil {
+		subjectType = models.AuditAPIKey
+		if claims, orgID, err = s.reauthenticateAPIKey(c, subID); err != nil {
 			// Error logging is handled in reauthenticateAPIKey
 			return
 		}
@@ -326,9 +462,18 @@ func (s *Server) Reauthenticate(c *gin.Context) {
 		return
 	}
 
+	// Carry the authentication methods of the original login forward so that a user who
+	// logged in with a second factor does not lose the mfa amr claim on reauthentication.
+	var amr []string
+	if amr, err = s.issuer.AuthMethods(in.RefreshToken); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusForbidden, api.Error(errors.ErrFailedAuthentication))
+		return
+	}
+
 	// Create new access and refresh tokens
 	out = &api.LoginReply{}
-	if out.AccessToken, out.RefreshToken, err = s.issuer.CreateTokens(claims); err != nil {
+	if out.AccessToken, out.RefreshToken, err = s.issueTokens(c, claims, orgID, refresh.FamilyID, amr...); err != nil {
 		c.Error(err)
 		c.JSON(http.StatusInternalServerError, api.Error(errors.ErrInternal))
 		return
@@ -341,6 +486,8 @@ func (s *Server) Reauthenticate(c *gin.Context) {
 		return
 	}
 
+	s.auditLogin(c, models.AuditLogin, subjectType, subID)
+
 	// Content negotiation and redirection if required.
 	switch c.NegotiateFormat(binding.MIMEJSON, binding.MIMEHTML) {
 	case binding.MIMEJSON:
@@ -356,46 +503,214 @@ func (s *Server) Reauthenticate(c *gin.Context) {
 	}
 }
 
-func (s *Server) reauthenticateUser(c *gin.Context, userID ulid.ULID) (_ *gimlet.Claims, err error) {
+func (s *Server) reauthenticateUser(c *gin.Context, userID, orgID ulid.ULID) (_ *gimlet.Claims, _ ulid.ULID, err error) {
 	var user *models.User
 	if user, err = s.store.RetrieveUser(c.Request.Context(), userID); err != nil {
 		if errors.Is(err, errors.ErrNotFound) {
 			c.JSON(http.StatusForbidden, api.Error(errors.ErrFailedAuthentication))
-			return nil, err
+			return nil, ulid.Zero, err
 		}
 		c.Error(err)
 		c.JSON(http.StatusInternalServerError, api.Error(errors.ErrInternal))
-		return nil, err
+		return nil, ulid.Zero, err
+	}
+
+	if err = s.checkUserStatus(c, user, http.StatusForbidden); err != nil {
+		return nil, ulid.Zero, err
 	}
 
 	user.LastLogin = sql.NullTime{Time: time.Now(), Valid: true}
 	if err = s.store.UpdateLastLogin(c.Request.Context(), user.ID, user.LastLogin.Time); err != nil {
 		c.Error(err)
 		c.JSON(http.StatusInternalServerError, api.Error(errors.ErrInternal))
-		return nil, err
+		return nil, ulid.Zero, err
 	}
 
-	return user.Claims()
+	if user, err = s.organizationMember(c.Request.Context(), user, orgID); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error(errors.ErrInternal))
+		return nil, ulid.Zero, err
+	}
+
+	return user.Claims(), user.OrgID, nil
+}
+
+// checkUserStatus rejects users who have been suspended, deactivated, or deleted.
+// Deleted users are rejected with the specified status code as though they do not exist
+// to prevent enumeration, the others are forbidden. If an error is returned then the
+// response has already been written.
+func (s *Server) checkUserStatus(c *gin.Context, user *models.User, code int) (err error) {
+	if err = user.CheckStatus(); err != nil {
+		if errors.Is(err, errors.ErrFailedAuthentication) {
+			c.JSON(code, api.Error(errors.ErrFailedAuthentication))
+			return err
+		}
+
+		c.JSON(http.StatusForbidden, api.Error(err))
+		return err
+	}
+	return nil
 }
 
-func (s *Server) reauthenticateAPIKey(c *gin.Context, apiKeyID ulid.ULID) (_ *gimlet.Claims, err error) {
+func (s *Server) reauthenticateAPIKey(c *gin.Context, apiKeyID ulid.ULID) (_ *gimlet.Claims, _ ulid.ULID, err error) {
 	var apiKey *models.APIKey
 	if apiKey, err = s.store.RetrieveAPIKey(c.Request.Context(), apiKeyID); err != nil {
 		if errors.Is(err, errors.ErrNotFound) {
 			c.JSON(http.StatusForbidden, api.Error(errors.ErrFailedAuthentication))
-			return nil, err
+			return nil, ulid.Zero, err
 		}
 		c.Error(err)
 		c.JSON(http.StatusInternalServerError, api.Error(errors.ErrInternal))
-		return nil, err
+		return nil, ulid.Zero, err
 	}
 
 	apiKey.LastSeen = sql.NullTime{Time: time.Now(), Valid: true}
 	if err = s.store.UpdateLastSeen(c.Request.Context(), apiKey.ID, apiKey.LastSeen.Time); err != nil {
 		c.Error(err)
 		c.JSON(http.StatusInternalServerError, api.Error(errors.ErrInternal))
+		return nil, ulid.Zero, err
+	}
+
+	return apiKey.Claims(), apiKey.OrgID.ULID, nil
+}
+
+// issueTokens creates access and refresh tokens for the claims and records the refresh
+// token so that it can only be used once. A zero familyID starts a new token family
+// (e.g. on login); otherwise the refresh token is rotated into the existing family.
+// Token families issued to users are also tracked as sessions so that they can be
+// listed and revoked. The organization, if any, is added as the org claim and the
+// authentication methods, if any, are added as the amr claim.
+func (s *Server) issueTokens(c *gin.Context, claims *gimlet.Claims, orgID, familyID ulid.ULID, amr ...string) (accessToken, refreshToken string, err error) {
+	if accessToken, refreshToken, err = s.issuer.CreateTokens(claims, orgID, amr...); err != nil {
+		return "", "", err
+	}
+
+	var refreshClaims *gimlet.Claims
+	if refreshClaims, err = s.issuer.Parse(refreshToken); err != nil {
+		return "", "", err
+	}
+
+	record := &models.RefreshToken{
+		FamilyID:   familyID,
+		Subject:    refreshClaims.Subject,
+		Expiration: refreshClaims.ExpiresAt.Time,
+	}
+
+	if record.ID, err = ulid.Parse(refreshClaims.ID); err != nil {
+		return "", "", err
+	}
+
+	// The first refresh token issued on login starts the family.
+	if record.FamilyID.IsZero() {
+		record.FamilyID = record.ID
+	}
+
+	ctx := c.Request.Context()
+	if _, err = s.store.CreateRefreshToken(ctx, record); err != nil {
+		return "", "", err
+	}
+
+	if err = s.trackSession(c, record, familyID.IsZero()); err != nil {
+		return "", "", err
+	}
+
+	return accessToken, refreshToken, nil
+}
+
+// trackSession creates or refreshes the session for a refresh token family issued to a
+// user; refresh token families issued to API keys are not tracked as sessions.
+func (s *Server) trackSession(c *gin.Context, record *models.RefreshToken, login bool) (err error) {
+	var (
+		sub    gimlet.SubjectType
+		userID ulid.ULID
+	)
+
+	claims := &gimlet.Claims{}
+	claims.Subject = record.Subject
+	if sub, userID, err = claims.SubjectID(); err != nil {
+		return err
+	}
+
+	if sub != gimlet.SubjectUser {
+		return nil
+	}
+
+	ctx := c.Request.Context()
+	if !login {
+		// If the family predates session tracking then create the session for it.
+		if err = s.store.RefreshSession(ctx, record.FamilyID, record.ID, record.Expiration); !errors.Is(err, errors.ErrNotFound) {
+			return err
+		}
+	}
+
+	session := &models.Session{
+		BaseModel:  tidal.BaseModel{ID: record.FamilyID},
+		UserID:     userID,
+		JTI:        record.ID,
+		UserAgent:  sql.NullString{String: c.Request.UserAgent(), Valid: c.Request.UserAgent() != ""},
+		ClientIP:   sql.NullString{String: c.ClientIP(), Valid: c.ClientIP() != ""},
+		Expiration: record.Expiration,
+	}
+
+	_, err = s.store.CreateSession(ctx, session)
+	return err
+}
+
+// useRefreshToken marks the refresh token as used so that it cannot be exchanged again.
+// If the refresh token has already been used then it has most likely been stolen; since
+// it is not possible to tell which party is the legitimate client, the entire family is
+// revoked and the subject must log in again.
+func (s *Server) useRefreshToken(c *gin.Context, claims *gimlet.Claims) (refresh *models.RefreshToken, err error) {
+	var jti ulid.ULID
+	if jti, err = ulid.Parse(claims.ID); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusForbidden, api.Error(errors.ErrFailedAuthentication))
 		return nil, err
 	}
 
-	return apiKey.Claims(), nil
+	ctx := c.Request.Context()
+	if refresh, err = s.store.UseRefreshToken(ctx, jti); err != nil {
+		switch {
+		case errors.Is(err, errors.ErrTokenReused):
+			s.revokeRefreshTokenFamily(c, jti, claims.Subject)
+			c.JSON(http.StatusForbidden, api.Error(errors.ErrFailedAuthentication))
+		case errors.Is(err, errors.ErrNotFound), errors.Is(err, errors.ErrTokenRevoked), errors.Is(err, errors.ErrExpiredToken):
+			c.Error(err)
+			c.JSON(http.StatusForbidden, api.Error(errors.ErrFailedAuthentication))
+		default:
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, api.Error(errors.ErrInternal))
+		}
+		return nil, err
+	}
+
+	return refresh, nil
+}
+
+// revokeRefreshTokenFamily revokes every refresh token descended from the same login as
+// a refresh token that was presented more than once and logs the security event.
+func (s *Server) revokeRefreshTokenFamily(c *gin.Context, jti ulid.ULID, subject string) {
+	ctx := c.Request.Context()
+
+	var (
+		err     error
+		refresh *models.RefreshToken
+	)
+
+	if refresh, err = s.store.RetrieveRefreshToken(ctx, jti); err != nil {
+		c.Error(err)
+		return
+	}
+
+	rlog.WarnAttrs(ctx, "security event: refresh token reuse detected, revoking token family",
+		slog.String("subject", subject),
+		slog.String("jti", jti.String()),
+		slog.String("family_id", refresh.FamilyID.String()),
+		slog.String("client_ip", c.ClientIP()),
+		slog.String("user_agent", c.Request.UserAgent()),
+	)
+
+	if err = s.store.RevokeRefreshTokenFamily(ctx, refresh.FamilyID); err != nil {
+		c.Error(err)
+	}
 }
@@ -0,0 +1,154 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"go.rtnl.ai/quarterdeck/pkg/enum"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/txn"
+	"go.rtnl.ai/quarterdeck/pkg/webhooks"
+	"go.rtnl.ai/ulid"
+	"go.rtnl.ai/x/rlog"
+)
+
+// dispatchTimeout limits how long a single batch of webhook deliveries may take; the
+// deliveries in the batch are leased for this long so that other replicas do not
+// attempt them at the same time.
+const dispatchTimeout = 5 * time.Minute
+
+// publishTx queues the event for delivery to the webhooks that are subscribed to it in
+// the transaction that performs the action, so that the deliveries are queued if and
+// only if the action is committed. The number of queued deliveries is returned so that
+// the dispatcher can be notified with [Server.notifyQueued] after the commit.
+func publishTx(tx txn.Tx, event enum.WebhookEvent, data any) (queued int, err error) {
+	var (
+		out     *webhooks.Event
+		payload []byte
+	)
+
+	if out, err = webhooks.NewEvent(event, data); err != nil {
+		return 0, errors.Fmt("could not create %s webhook event: %w", event, err)
+	}
+
+	if payload, err = json.Marshal(out); err != nil {
+		return 0, errors.Fmt("could not marshal %s webhook event: %w", event, err)
+	}
+
+	if queued, err = tx.EnqueueWebhookEvent(out.ID, event, payload); err != nil {
+		return 0, errors.Fmt("could not queue %s webhook event: %w", event, err)
+	}
+	return queued, nil
+}
+
+// notifyQueued wakes the dispatcher if deliveries were queued by a transaction that
+// has been committed.
+func (s *Server) notifyQueued(queued int) {
+	if queued > 0 {
+		s.notifyWebhooks()
+	}
+}
+
+// notifyWebhooks wakes the dispatcher so that new deliveries are attempted without
+// waiting for the next poll; it does not block if the dispatcher is busy or not running.
+func (s *Server) notifyWebhooks() {
+	select {
+	case s.deliver <- struct{}{}:
+	default:
+	}
+}
+
+// runDispatcher delivers the queued webhook deliveries that are due every poll interval
+// or when it is notified of new deliveries until the context is canceled when the
+// server shuts down.
+func (s *Server) runDispatcher(ctx context.Context) {
+	ticker := time.NewTicker(s.conf.Webhooks.PollInterval)
+	defer ticker.Stop()
+
+	client := &http.Client{Timeout: s.conf.Webhooks.Timeout}
+	rlog.DebugAttrs(ctx, "webhook dispatcher started",
+		slog.Duration("interval", s.conf.Webhooks.PollInterval),
+		slog.Int("max_attempts", s.conf.Webhooks.MaxAttempts))
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.deliver:
+		}
+
+		// If the batch was full there may be more deliveries that are due.
+		if n := s.dispatchWebhooks(ctx, client); n >= s.conf.Webhooks.BatchSize {
+			s.notifyWebhooks()
+		}
+	}
+}
+
+// dispatchWebhooks claims a batch of the deliveries that are due, attempts them, and
+// records the result of each attempt, returning the number of deliveries that were
+// recorded. Failed deliveries are retried with an exponential backoff until the maximum
+// number of attempts has been made. Deliveries to inactive webhooks fail without an
+// attempt.
+func (s *Server) dispatchWebhooks(ctx context.Context, client *http.Client) int {
+	ctx, cancel := context.WithTimeout(ctx, dispatchTimeout)
+	defer cancel()
+
+	var (
+		err        error
+		deliveries []*models.WebhookDelivery
+	)
+
+	if deliveries, err = s.store.ClaimDueWebhookDeliveries(ctx, time.Now(), dispatchTimeout, s.conf.Webhooks.BatchSize); err != nil {
+		rlog.WarnAttrs(ctx, "could not claim due webhook deliveries", slog.Any("err", err))
+		return 0
+	}
+
+	var recorded int
+	hooks := make(map[ulid.ULID]*models.Webhook)
+	for _, delivery := range deliveries {
+		webhook, ok := hooks[delivery.WebhookID]
+		if !ok {
+			if webhook, err = s.store.RetrieveWebhook(ctx, delivery.WebhookID); err != nil {
+				rlog.WarnAttrs(ctx, "could not retrieve webhook for delivery", slog.Any("err", err),
+					slog.String("webhook_id", delivery.WebhookID.String()), slog.String("delivery_id", delivery.ID.String()))
+				continue
+			}
+			hooks[delivery.WebhookID] = webhook
+		}
+
+		if !webhook.Active {
+			delivery.Record(0, errors.ErrWebhookInactive, 0, 0)
+		} else {
+			code, serr := (&webhooks.Delivery{
+				ID:      delivery.ID,
+				URL:     webhook.URL,
+				Secret:  webhook.Secret,
+				Event:   delivery.Event,
+				Payload: delivery.Payload,
+			}).Send(ctx, client)
+
+			delivery.Record(code, serr, s.conf.Webhooks.Backoff(delivery.Attempts+1), s.conf.Webhooks.MaxAttempts)
+		}
+
+		if err = s.store.UpdateWebhookDelivery(ctx, delivery); err != nil {
+			rlog.WarnAttrs(ctx, "could not record webhook delivery attempt", slog.Any("err", err),
+				slog.String("delivery_id", delivery.ID.String()))
+			continue
+		}
+
+		recorded++
+		rlog.DebugAttrs(ctx, "webhook delivery attempted",
+			slog.String("webhook_id", webhook.ID.String()),
+			slog.String("delivery_id", delivery.ID.String()),
+			slog.String("event", delivery.Event.String()),
+			slog.String("status", delivery.Status.String()),
+			slog.Int("attempts", delivery.Attempts))
+	}
+
+	return recorded
+}
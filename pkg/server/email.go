@@ -0,0 +1,380 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.rtnl.ai/commo"
+	"go.rtnl.ai/quarterdeck/pkg/api/v1"
+	"go.rtnl.ai/quarterdeck/pkg/auth"
+	"go.rtnl.ai/quarterdeck/pkg/emails"
+	"go.rtnl.ai/quarterdeck/pkg/enum"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/txn"
+	"go.rtnl.ai/quarterdeck/pkg/web/htmx"
+	"go.rtnl.ai/quarterdeck/pkg/web/scene"
+	"go.rtnl.ai/ulid"
+	"go.rtnl.ai/x/vero"
+)
+
+const (
+	// changeEmailTokenTTL is how long the link sent to the new email address can be
+	// used to confirm an email change.
+	changeEmailTokenTTL = 24 * time.Hour
+
+	// revertEmailTokenTTL is how long the link sent to the old email address can be
+	// used to revert an email change; it is longer than the change link so that the
+	// user can recover their account after an unwanted change has been confirmed.
+	revertEmailTokenTTL = 7 * 24 * time.Hour
+
+	changeEmailTemplate = "partials/profile/changeEmail.html"
+)
+
+// ============================================================================
+// Change email
+// ============================================================================
+
+// ChangeEmail starts changing the email address of the user by emailing a confirmation
+// link to the new address and a notice with a link to revert the change to the current
+// address. The current email address remains active until the change is confirmed.
+func (s *Server) ChangeEmail(c *gin.Context) {
+	var (
+		err    error
+		in     *api.ProfileEmail
+		userID ulid.ULID
+		user   *models.User
+	)
+
+	in = &api.ProfileEmail{}
+	if err = c.BindJSON(in); err != nil {
+		c.Error(err)
+		changeEmailError(c, http.StatusBadRequest, "could not parse change email request")
+		return
+	}
+
+	if err = in.Validate(); err != nil {
+		changeEmailError(c, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	if userID, err = ulid.Parse(c.Param("userID")); err != nil {
+		changeEmailError(c, http.StatusNotFound, "user not found")
+		return
+	}
+
+	if err = s.checkUserInCurrentOrg(c, userID, "user not found"); err != nil {
+		return
+	}
+
+	if user, err = s.store.RetrieveUser(c.Request.Context(), userID); err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			changeEmailError(c, http.StatusNotFound, "user not found")
+			return
+		}
+
+		c.Error(err)
+		changeEmailError(c, http.StatusInternalServerError, "could not process change email request")
+		return
+	}
+
+	if in.Email == user.Email {
+		changeEmailError(c, http.StatusUnprocessableEntity, api.ValidationError(nil, api.IncorrectField("email", "must be different from the current email address")))
+		return
+	}
+
+	if err = s.sendChangeEmail(c.Request.Context(), user, in.Email); err != nil {
+		if errors.Is(err, errors.ErrAlreadyExists) {
+			changeEmailError(c, http.StatusConflict, "the email address is already in use")
+			return
+		}
+
+		c.Error(err)
+		changeEmailError(c, http.StatusInternalServerError, "could not send email change confirmation")
+		return
+	}
+
+	s.audit(c, models.AuditChangeEmail, models.AuditUser, user.ID.String(), nil, map[string]string{"pending_email": in.Email})
+
+	if htmx.IsWebRequest(c) {
+		c.HTML(http.StatusOK, changeEmailTemplate, gin.H{"Sent": in.Email})
+		return
+	}
+
+	c.JSON(http.StatusOK, api.Reply{Success: true})
+}
+
+// changeEmailError writes the error into the change email form for web requests so
+// that it is displayed to the user, otherwise it is written as a JSON error reply.
+func changeEmailError(c *gin.Context, code int, err any) {
+	if htmx.IsWebRequest(c) {
+		out := gin.H{"Error": api.Error(err).Error}
+		if verr, ok := err.(api.ValidationErrors); ok {
+			out = gin.H{"FieldErrors": verr.Map()}
+		}
+
+		c.HTML(code, changeEmailTemplate, out)
+		return
+	}
+
+	c.JSON(code, api.Error(err))
+}
+
+// sendChangeEmail creates the change email and revert email vero tokens for the user
+// and emails the confirmation link to the new address and the revert link to the
+// current address. Any pending email change for the user is replaced. Returns
+// ErrAlreadyExists if the new email address belongs to another user.
+func (s *Server) sendChangeEmail(ctx context.Context, user *models.User, email string) (err error) {
+	// Check the email address is not in use so that the user is not asked to confirm
+	// a change that cannot be applied; the store enforces this when it is applied.
+	if _, err = s.store.RetrieveUserByEmail(ctx, email); err == nil {
+		return errors.ErrAlreadyExists
+	} else if !errors.Is(err, errors.ErrNotFound) {
+		return err
+	}
+
+	// The tokens are rolled back if the emails cannot be sent.
+	return s.store.WithTx(ctx, nil, func(tx txn.Tx) error {
+		return s.sendChangeEmailTx(tx, user, email)
+	})
+}
+
+// sendChangeEmailTx creates and signs the vero tokens and sends the emails inside of
+// the transaction.
+func (s *Server) sendChangeEmailTx(tx txn.Tx, user *models.User, email string) (err error) {
+	var change, revert *models.VeroToken
+	if change, err = tx.CreateChangeEmailVeroToken(&models.VeroToken{
+		TokenType:  enum.TokenTypeChangeEmail,
+		ResourceID: ulid.NullULID{Valid: true, ULID: user.ID},
+		Email:      email,
+		Expiration: time.Now().Add(changeEmailTokenTTL),
+	}); err != nil {
+		return err
+	}
+
+	if revert, err = tx.CreateVeroToken(&models.VeroToken{
+		TokenType:  enum.TokenTypeRevertEmail,
+		ResourceID: ulid.NullULID{Valid: true, ULID: user.ID},
+		Email:      user.Email,
+		Expiration: time.Now().Add(revertEmailTokenTTL),
+	}); err != nil {
+		return err
+	}
+
+	baseData := emails.EmailBaseData{
+		AppName:        s.conf.App.Name,
+		AppLogoURL:     s.conf.App.LogoURL(),
+		OrgName:        s.conf.Org.Name,
+		OrgHomepageURL: s.conf.Org.HomepageURL(),
+		SupportEmail:   s.conf.Org.SupportEmail,
+	}
+
+	confirmURL := s.conf.Auth.GetConfirmEmailURL()
+	confirmURL.Host = s.conf.App.BaseURL().Host
+	changeData := emails.ChangeEmailData{
+		EmailBaseData: baseData,
+		ContactName:   user.Name.String,
+		ConfirmURL:    confirmURL,
+	}
+
+	if changeData.Token, err = signVeroToken(tx, change); err != nil {
+		return err
+	}
+
+	revertURL := s.conf.Auth.GetRevertEmailURL()
+	revertURL.Host = s.conf.App.BaseURL().Host
+	noticeData := emails.EmailChangeNoticeData{
+		EmailBaseData:  baseData,
+		ContactName:    user.Name.String,
+		NewEmail:       email,
+		RevertEmailURL: revertURL,
+	}
+
+	if noticeData.Token, err = signVeroToken(tx, revert); err != nil {
+		return err
+	}
+
+	var changeEmail, noticeEmail *commo.Email
+	if changeEmail, err = emails.NewChangeEmail(email, changeData); err != nil {
+		return err
+	}
+
+	if noticeEmail, err = emails.NewEmailChangeNotice(user.Email, noticeData); err != nil {
+		return err
+	}
+
+	// Send the notice to the current address first so that a change is never
+	// confirmable without the owner of the current address being notified.
+	if err = noticeEmail.Send(); err != nil {
+		return err
+	}
+
+	if err = changeEmail.Send(); err != nil {
+		return err
+	}
+
+	sentOn := sql.NullTime{Valid: true, Time: time.Now()}
+	for _, record := range []*models.VeroToken{change, revert} {
+		record.SentOn = sentOn
+		if err = tx.UpdateVeroToken(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// signVeroToken creates the HMAC verification token for the emailed link and saves
+// its signature on the vero token record.
+func signVeroToken(tx txn.Tx, record *models.VeroToken) (token vero.VerificationToken, err error) {
+	var verification *vero.Token
+	if verification, err = vero.New(record.ID[:], record.Expiration); err != nil {
+		return token, err
+	}
+
+	if token, record.Signature, err = verification.Sign(); err != nil {
+		return token, err
+	}
+
+	if err = tx.UpdateVeroToken(record); err != nil {
+		return token, err
+	}
+
+	return token, nil
+}
+
+// ============================================================================
+// Confirm and revert email change
+// ============================================================================
+
+// ConfirmEmail verifies the link emailed to the new email address and changes the
+// email address of the user to it. The link proves the user received email at the
+// new address so the email verified status of the user is kept. The token may be
+// submitted in the request body by API clients; the confirm email page submits it in
+// a cookie instead.
+func (s *Server) ConfirmEmail(c *gin.Context) {
+	var (
+		err       error
+		veroToken *models.VeroToken
+		before    *models.User
+	)
+
+	if veroToken, before, err = s.completeEmailChange(c, auth.ChangeEmailTokenCookie, enum.TokenTypeChangeEmail); err != nil {
+		return
+	}
+
+	auth.ClearChangeEmailTokenCookie(c, s.conf.Auth.GetConfirmEmailURL().Hostname())
+	s.audit(c, models.AuditConfirmEmail, models.AuditUser, before.ID.String(), map[string]string{"email": before.Email}, map[string]string{"email": veroToken.Email})
+
+	if htmx.IsWebRequest(c) {
+		c.HTML(http.StatusOK, "auth/email/confirmed.html", scene.New(c))
+		return
+	}
+
+	c.JSON(http.StatusOK, api.Reply{Success: true})
+}
+
+// RevertEmail verifies the link emailed to the old email address when an email change
+// was requested and restores the old email address of the user, cancelling the email
+// change if it has not been confirmed yet. Because the change may have been made by
+// someone else, all of the sessions of the user are revoked.
+func (s *Server) RevertEmail(c *gin.Context) {
+	var (
+		err       error
+		veroToken *models.VeroToken
+		before    *models.User
+	)
+
+	if veroToken, before, err = s.completeEmailChange(c, auth.RevertEmailTokenCookie, enum.TokenTypeRevertEmail); err != nil {
+		return
+	}
+
+	if err = s.store.RevokeUserSessions(c.Request.Context(), before.ID); err != nil {
+		s.Error(c, err)
+		return
+	}
+
+	auth.ClearRevertEmailTokenCookie(c, s.conf.Auth.GetRevertEmailURL().Hostname())
+	s.audit(c, models.AuditRevertEmail, models.AuditUser, before.ID.String(), map[string]string{"email": before.Email}, map[string]string{"email": veroToken.Email})
+
+	if htmx.IsWebRequest(c) {
+		c.HTML(http.StatusOK, "auth/email/reverted.html", scene.New(c).WithForgotPasswordURL())
+		return
+	}
+
+	c.JSON(http.StatusOK, api.Reply{Success: true})
+}
+
+// completeEmailChange verifies the emailed change or revert email link and applies its
+// email address to the user, returning the vero token and the user before the change.
+// If an error is returned the response has already been written to the client.
+func (s *Server) completeEmailChange(c *gin.Context, cookie string, tokenType enum.TokenType) (veroToken *models.VeroToken, user *models.User, err error) {
+	in := &api.URLVerification{}
+	if err = c.BindJSON(in); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadRequest, api.Error("could not parse email change request"))
+		return nil, nil, err
+	}
+
+	// Get the verification token from the cookie if it was not in the request
+	if in.Token == "" {
+		if in.Token, err = c.Cookie(cookie); err != nil {
+			// If no token is submitted, then slow down the request and send back a 403.
+			SlowDown()
+			c.JSON(http.StatusForbidden, api.Error("unable to process email change request"))
+			return nil, nil, err
+		}
+	}
+
+	if err = in.Validate(); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, api.Error(err))
+		return nil, nil, err
+	}
+
+	// Verify the VeroToken token
+	if veroToken, err = s.verifyVeroToken(c.Request.Context(), in); err != nil {
+		switch {
+		case errors.Is(err, errors.ErrNotFound), errors.Is(err, errors.ErrExpiredToken):
+			c.JSON(http.StatusBadRequest, api.Error("your link is invalid or expired"))
+		case errors.Is(err, errors.ErrNotAllowed):
+			// The slow down prevents brute force attacks on the email change endpoints.
+			SlowDown()
+			c.JSON(http.StatusForbidden, api.Error("unable to process email change request"))
+		default:
+			s.Error(c, err)
+		}
+		return nil, nil, err
+	}
+
+	// Other links (e.g. reset password links) cannot be used to change an email.
+	if veroToken.TokenType != tokenType {
+		SlowDown()
+		c.JSON(http.StatusForbidden, api.Error("unable to process email change request"))
+		return nil, nil, errors.ErrTypeMismatch
+	}
+
+	if user, err = s.store.RetrieveUser(c.Request.Context(), veroToken.ResourceID.ULID); err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			c.JSON(http.StatusBadRequest, api.Error("your link is invalid or expired"))
+			return nil, nil, err
+		}
+		s.Error(c, err)
+		return nil, nil, err
+	}
+
+	if err = s.store.CompleteEmailChange(c.Request.Context(), veroToken.ID); err != nil {
+		switch {
+		case errors.Is(err, errors.ErrNotFound), errors.Is(err, errors.ErrExpiredToken):
+			c.JSON(http.StatusBadRequest, api.Error("your link is invalid or expired"))
+		case errors.Is(err, errors.ErrAlreadyExists):
+			c.JSON(http.StatusConflict, api.Error("the email address is already in use"))
+		default:
+			s.Error(c, err)
+		}
+		return nil, nil, err
+	}
+
+	return veroToken, user, nil
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/mock"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/tidal"
+	"go.rtnl.ai/ulid"
+)
+
+func TestChangeEmail(t *testing.T) {
+	userID := ulid.MakeSecure()
+	setup := func(t *testing.T) (*mock.Store, *Server) {
+		mockStore := openMockStore(t)
+		t.Cleanup(func() { mockStore.Close() })
+
+		mockStore.OnRetrieveUser = func(_ context.Context, id ulid.ULID) (*models.User, error) {
+			if id == userID {
+				return &models.User{BaseModel: tidal.BaseModel{ID: userID}, Email: "jane@example.com", EmailVerified: true}, nil
+			}
+			return nil, errors.ErrNotFound
+		}
+
+		mockStore.OnRetrieveUserByEmail = func(_ context.Context, email string) (*models.User, error) {
+			if email == "john@example.com" {
+				return &models.User{BaseModel: tidal.BaseModel{ID: ulid.MakeSecure()}, Email: email}, nil
+			}
+			return nil, errors.ErrNotFound
+		}
+
+		return mockStore, newTestServer(mockStore)
+	}
+
+	change := func(t *testing.T, srv *Server, body string) int {
+		params := gin.Params{{Key: "userID", Value: userID.String()}}
+		w, c := requestContext(t, http.MethodPost, "/v1/users/"+userID.String()+"/email", []byte(body), params)
+		c.Request.Header.Set("Content-Type", "application/json")
+		srv.ChangeEmail(c)
+		return w.Code
+	}
+
+	t.Run("InvalidEmail", func(t *testing.T) {
+		mockStore, srv := setup(t)
+		require.Equal(t, http.StatusUnprocessableEntity, change(t, srv, `{"email":"notanemail"}`))
+		mockStore.AssertCalls(t, mock.RetrieveUser, 0)
+	})
+
+	t.Run("SameEmail", func(t *testing.T) {
+		mockStore, srv := setup(t)
+		require.Equal(t, http.StatusUnprocessableEntity, change(t, srv, `{"email":"jane@example.com"}`))
+		mockStore.AssertCalls(t, mock.WithTx, 0)
+	})
+
+	t.Run("EmailInUse", func(t *testing.T) {
+		mockStore, srv := setup(t)
+		require.Equal(t, http.StatusConflict, change(t, srv, `{"email":"john@example.com"}`))
+		mockStore.AssertCalls(t, mock.WithTx, 0)
+	})
+}
+
+func TestConfirmEmail(t *testing.T) {
+	t.Run("NoToken", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		w, c := requestContext(t, http.MethodPost, "/v1/confirm-email", []byte(`{}`), nil)
+		c.Request.Header.Set("Content-Type", "application/json")
+		srv.ConfirmEmail(c)
+
+		require.Equal(t, http.StatusForbidden, w.Code)
+		mockStore.AssertCalls(t, mock.RetrieveVeroToken, 0)
+	})
+
+	t.Run("InvalidToken", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		w, c := requestContext(t, http.MethodPost, "/v1/confirm-email", []byte(`{"token":"notavalidtoken"}`), nil)
+		c.Request.Header.Set("Content-Type", "application/json")
+		srv.ConfirmEmail(c)
+
+		require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		mockStore.AssertCalls(t, mock.RetrieveVeroToken, 0)
+	})
+}
+
+func TestRevertEmail(t *testing.T) {
+	t.Run("InvalidToken", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		w, c := requestContext(t, http.MethodPost, "/v1/revert-email", []byte(`{"token":"notavalidtoken"}`), nil)
+		c.Request.Header.Set("Content-Type", "application/json")
+		srv.RevertEmail(c)
+
+		require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		mockStore.AssertCalls(t, mock.CompleteEmailChange, 0)
+		mockStore.AssertCalls(t, mock.RevokeUserSessions, 0)
+	})
+}
@@ -27,6 +27,17 @@ func (s *Server) Error(c *gin.Context, err error) {
 	})
 }
 
+// Renders the "bad request page"; used when the user cannot be safely redirected back
+// to an OAuth client that sent an invalid request.
+func (s *Server) BadRequest(c *gin.Context, err error) {
+	c.Negotiate(http.StatusBadRequest, gin.Negotiate{
+		Offered:  []string{binding.MIMEJSON, binding.MIMEHTML},
+		HTMLName: "errors/status/400.html",
+		HTMLData: scene.New(c).Error(err),
+		JSONData: api.Error(err),
+	})
+}
+
 // Renders the "not found page"
 func (s *Server) NotFound(c *gin.Context) {
 	c.Negotiate(http.StatusNotFound, gin.Negotiate{
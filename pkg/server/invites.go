@@ -0,0 +1,327 @@
+package server
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.rtnl.ai/quarterdeck/pkg/api/v1"
+	"go.rtnl.ai/quarterdeck/pkg/auth"
+	"go.rtnl.ai/quarterdeck/pkg/auth/passwords"
+	"go.rtnl.ai/quarterdeck/pkg/enum"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/txn"
+	"go.rtnl.ai/quarterdeck/pkg/web/htmx"
+	"go.rtnl.ai/quarterdeck/pkg/web/scene"
+	"go.rtnl.ai/ulid"
+)
+
+// ============================================================================
+// Team invite management
+// ============================================================================
+
+// ListInvites returns the team invites that have been emailed to users who have not
+// yet accepted them, including expired invites so that they can be resent. Requesters
+// logged into an organization only see the invites of its members.
+func (s *Server) ListInvites(c *gin.Context) {
+	var (
+		err     error
+		tokens  []*models.VeroToken
+		members map[ulid.ULID]struct{}
+		out     *api.InviteList
+	)
+
+	if tokens, err = s.store.ListVeroTokensByType(c.Request.Context(), enum.TokenTypeTeamInvite); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process invites list request"))
+		return
+	}
+
+	if orgID := s.currentOrg(c); !orgID.IsZero() {
+		var users []*models.User
+		if users, err = s.store.ListOrganizationMembers(c.Request.Context(), orgID); err != nil {
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, api.Error("could not process invites list request"))
+			return
+		}
+
+		members = make(map[ulid.ULID]struct{}, len(users))
+		for _, user := range users {
+			members[user.ID] = struct{}{}
+		}
+	}
+
+	out = &api.InviteList{Invites: make([]*api.Invite, 0, len(tokens))}
+	for _, token := range tokens {
+		if members != nil {
+			if _, ok := members[token.ResourceID.ULID]; !ok {
+				continue
+			}
+		}
+
+		// The user is only used to add the name of the invitee to the invite, so if
+		// the user cannot be found the invite is still returned.
+		user, err := s.store.RetrieveUser(c.Request.Context(), token.ResourceID.ULID)
+		if err != nil && !errors.Is(err, errors.ErrNotFound) {
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, api.Error("could not process invites list request"))
+			return
+		}
+
+		invite, err := api.NewInvite(token, user)
+		if err != nil {
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, api.Error("could not process invites list request"))
+			return
+		}
+		out.Invites = append(out.Invites, invite)
+	}
+
+	c.JSON(http.StatusOK, out)
+}
+
+// ResendInvite emails the welcome message to the invited user again; a new invite is
+// created if the previous one has expired. Invites cannot be resent more often than
+// the [welcomeEmailResendCooldown] to prevent spamming the invitee.
+func (s *Server) ResendInvite(c *gin.Context) {
+	var (
+		err    error
+		invite *models.VeroToken
+		user   *models.User
+		out    *api.Invite
+	)
+
+	if invite, err = s.retrieveInvite(c); err != nil {
+		return
+	}
+
+	if welcomeEmailRateLimited(invite) {
+		c.JSON(http.StatusTooManyRequests, api.Error("the invite was sent too recently, please try again later"))
+		return
+	}
+
+	if user, err = s.store.RetrieveUser(c.Request.Context(), invite.ResourceID.ULID); err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			c.JSON(http.StatusNotFound, api.Error("invite not found"))
+			return
+		}
+
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process resend invite request"))
+		return
+	}
+
+	if user.EmailVerified {
+		c.JSON(http.StatusConflict, api.Error("the invite has already been accepted"))
+		return
+	}
+
+	if err = s.sendWelcomeEmail(c.Request.Context(), user); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not send invite email"))
+		return
+	}
+
+	// An expired invite is replaced when it is resent so fetch the current invite.
+	if invite, err = s.store.RetrieveVeroTokenByResource(c.Request.Context(), user.ID, enum.TokenTypeTeamInvite); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process resend invite request"))
+		return
+	}
+
+	if out, err = api.NewInvite(invite, user); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process resend invite request"))
+		return
+	}
+
+	s.audit(c, models.AuditResend, models.AuditInvite, invite.ID.String(), nil, nil)
+
+	if htmx.IsHTMXRequest(c) {
+		htmx.SetTrigger(c, htmx.InvitesUpdated)
+		c.Data(http.StatusNoContent, gin.MIMEHTML, nil)
+		return
+	}
+
+	c.JSON(http.StatusOK, out)
+}
+
+// RevokeInvite deletes the invite so that the emailed link can no longer be used to
+// set a password; the invited user is not deleted.
+func (s *Server) RevokeInvite(c *gin.Context) {
+	var (
+		err    error
+		invite *models.VeroToken
+	)
+
+	if invite, err = s.retrieveInvite(c); err != nil {
+		return
+	}
+
+	if err = s.store.DeleteVeroToken(c.Request.Context(), invite.ID); err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			c.JSON(http.StatusNotFound, api.Error("invite not found"))
+			return
+		}
+
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process revoke invite request"))
+		return
+	}
+
+	s.audit(c, models.AuditDelete, models.AuditInvite, invite.ID.String(), nil, nil)
+
+	if htmx.IsHTMXRequest(c) {
+		htmx.SetTrigger(c, htmx.InvitesUpdated)
+		c.Data(http.StatusNoContent, gin.MIMEHTML, nil)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.Reply{Success: true})
+}
+
+// retrieveInvite loads the team invite identified by the inviteID URL parameter; other
+// vero tokens (e.g. reset password links) and the invites of users outside of the
+// requester's organization are not found. If an error is returned the response has
+// already been written to the client.
+func (s *Server) retrieveInvite(c *gin.Context) (invite *models.VeroToken, err error) {
+	var inviteID ulid.ULID
+	if inviteID, err = ulid.Parse(c.Param("inviteID")); err != nil {
+		c.JSON(http.StatusNotFound, api.Error("invite not found"))
+		return nil, err
+	}
+
+	if invite, err = s.store.RetrieveVeroToken(c.Request.Context(), inviteID); err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			c.JSON(http.StatusNotFound, api.Error("invite not found"))
+			return nil, err
+		}
+
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not retrieve invite"))
+		return nil, err
+	}
+
+	if invite.TokenType != enum.TokenTypeTeamInvite {
+		c.JSON(http.StatusNotFound, api.Error("invite not found"))
+		return nil, errors.ErrNotFound
+	}
+
+	if err = s.checkUserInCurrentOrg(c, invite.ResourceID.ULID, "invite not found"); err != nil {
+		return nil, err
+	}
+
+	return invite, nil
+}
+
+// ============================================================================
+// Accept team invite
+// ============================================================================
+
+// AcceptInvite verifies the emailed invite link and sets the name and password of the
+// invited user. Because the user received the link by email, accepting the invite
+// also verifies their email address.
+func (s *Server) AcceptInvite(c *gin.Context) {
+	var (
+		derivedKey string
+		err        error
+		in         *api.AcceptInviteRequest
+		veroToken  *models.VeroToken
+		user       *models.User
+	)
+
+	// We do not allow JSON API requests to this endpoint. Returning a 406 error
+	// here is for the legitimate API users who need to not use this endpoint.
+	if !htmx.IsWebRequest(c) {
+		c.AbortWithStatusJSON(http.StatusNotAcceptable, api.Error("endpoint unavailable for API calls"))
+		return
+	}
+
+	in = &api.AcceptInviteRequest{}
+	if err = c.BindJSON(in); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadRequest, api.Error("could not parse accept invite request"))
+		return
+	}
+
+	// Get the verification token from the cookie
+	if in.Token, err = c.Cookie(auth.InviteTokenCookie); err != nil {
+		// If no cookie is submitted, then slow down the request and send back a 403.
+		SlowDown()
+		c.JSON(http.StatusForbidden, api.Error("unable to process accept invite request"))
+		return
+	}
+
+	if err = in.Validate(); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, api.Error(err))
+		return
+	}
+
+	// Verify the VeroToken token
+	if veroToken, err = s.verifyVeroToken(c.Request.Context(), &in.URLVerification); err != nil {
+		switch {
+		case errors.Is(err, errors.ErrNotFound), errors.Is(err, errors.ErrExpiredToken):
+			c.JSON(http.StatusBadRequest, api.Error("your invite link is invalid or expired, please ask an administrator to resend your invite"))
+			return
+		case errors.Is(err, errors.ErrNotAllowed):
+			// The slow down prevents brute force attacks on the accept invite endpoint.
+			SlowDown()
+			c.JSON(http.StatusForbidden, api.Error("unable to process accept invite request"))
+			return
+		default:
+			s.Error(c, err)
+			return
+		}
+	}
+
+	// Reset password links cannot be used to accept an invite.
+	if veroToken.TokenType != enum.TokenTypeTeamInvite {
+		SlowDown()
+		c.JSON(http.StatusForbidden, api.Error("unable to process accept invite request"))
+		return
+	}
+
+	if derivedKey, err = passwords.CreateDerivedKey(in.Password); err != nil {
+		s.Error(c, err)
+		return
+	}
+
+	err = s.store.WithTx(c.Request.Context(), nil, func(tx txn.Tx) (err error) {
+		// The invite is consumed before the user is updated so that if the link is used
+		// by concurrent requests only one of them can set the user's password.
+		if err = tx.ConsumeVeroToken(veroToken.ID, enum.TokenTypeTeamInvite); err != nil {
+			return err
+		}
+
+		if user, err = tx.RetrieveUser(veroToken.ResourceID.ULID); err != nil {
+			return err
+		}
+
+		user.Name = sql.NullString{Valid: true, String: in.Name}
+		if err = tx.UpdateUser(user); err != nil {
+			return err
+		}
+
+		if err = tx.UpdatePassword(user.ID, derivedKey); err != nil {
+			return err
+		}
+
+		return tx.VerifyEmail(user.ID)
+	})
+
+	if err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			c.JSON(http.StatusBadRequest, api.Error("your invite link is invalid or expired, please ask an administrator to resend your invite"))
+			return
+		}
+		s.Error(c, err)
+		return
+	}
+
+	auth.ClearInviteTokenCookie(c, s.conf.Auth.GetAcceptInviteURL().Hostname())
+	s.audit(c, models.AuditAccept, models.AuditInvite, veroToken.ID.String(), nil, nil)
+	s.audit(c, models.AuditPasswordChange, models.AuditUser, user.ID.String(), nil, nil)
+
+	c.HTML(http.StatusOK, "auth/invite/success.html", scene.New(c))
+}
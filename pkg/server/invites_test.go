@@ -0,0 +1,269 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/quarterdeck/pkg/api/v1"
+	"go.rtnl.ai/quarterdeck/pkg/enum"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/mock"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/tidal"
+	"go.rtnl.ai/ulid"
+)
+
+func TestListInvites(t *testing.T) {
+	mockStore := openMockStore(t)
+	defer mockStore.Close()
+	srv := newTestServer(mockStore)
+
+	invites := testInvites()
+	mockStore.OnListVeroTokensByType = func(_ context.Context, tokenType enum.TokenType) ([]*models.VeroToken, error) {
+		require.Equal(t, enum.TokenTypeTeamInvite, tokenType)
+		return invites, nil
+	}
+	mockStore.OnRetrieveUser = func(_ context.Context, id ulid.ULID) (*models.User, error) {
+		if id == invites[0].ResourceID.ULID {
+			return &models.User{BaseModel: tidal.BaseModel{ID: id}, Name: sql.NullString{Valid: true, String: "Jane Doe"}}, nil
+		}
+		return nil, errors.ErrNotFound
+	}
+
+	w, c := requestContext(t, http.MethodGet, "/v1/invites", nil, nil)
+	srv.ListInvites(c)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var out api.InviteList
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &out))
+	require.Len(t, out.Invites, 2)
+
+	require.Equal(t, invites[0].ID, out.Invites[0].ID)
+	require.Equal(t, "Jane Doe", out.Invites[0].Name)
+	require.NotNil(t, out.Invites[0].SentOn)
+	require.False(t, out.Invites[0].Expired)
+
+	// The invitee could not be found but the invite is still listed.
+	require.Equal(t, invites[1].ID, out.Invites[1].ID)
+	require.Empty(t, out.Invites[1].Name)
+	require.Nil(t, out.Invites[1].SentOn)
+	require.True(t, out.Invites[1].Expired)
+}
+
+func TestListInvitesOrganization(t *testing.T) {
+	mockStore := openMockStore(t)
+	defer mockStore.Close()
+	srv := newTestOAuthServer(t, mockStore)
+
+	// Requesters logged into an organization only see the invites of its members.
+	invites := testInvites()
+	mockStore.OnListVeroTokensByType = func(context.Context, enum.TokenType) ([]*models.VeroToken, error) {
+		return invites, nil
+	}
+	mockStore.OnListOrganizationMembers = func(_ context.Context, orgID ulid.ULID) ([]*models.User, error) {
+		require.Equal(t, acmeOrgID, orgID)
+		return []*models.User{{BaseModel: tidal.BaseModel{ID: invites[1].ResourceID.ULID}}}, nil
+	}
+	mockStore.OnRetrieveUser = func(context.Context, ulid.ULID) (*models.User, error) {
+		return nil, errors.ErrNotFound
+	}
+
+	w, c := requestContext(t, http.MethodGet, "/v1/invites", nil, nil)
+	authorizeOrg(t, srv, c, acmeOrgID)
+	srv.ListInvites(c)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var out api.InviteList
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &out))
+	require.Len(t, out.Invites, 1)
+	require.Equal(t, invites[1].ID, out.Invites[0].ID)
+}
+
+func TestResendInvite(t *testing.T) {
+	setup := func(t *testing.T, token *models.VeroToken) (*Server, gin.Params) {
+		mockStore := openMockStore(t)
+		t.Cleanup(func() { mockStore.Close() })
+
+		mockStore.OnRetrieveVeroToken = func(_ context.Context, id ulid.ULID) (*models.VeroToken, error) {
+			if token == nil || token.ID != id {
+				return nil, errors.ErrNotFound
+			}
+			return token, nil
+		}
+		mockStore.OnRetrieveUser = func(_ context.Context, id ulid.ULID) (*models.User, error) {
+			return &models.User{BaseModel: tidal.BaseModel{ID: id}, EmailVerified: true}, nil
+		}
+
+		inviteID := ulid.MakeSecure()
+		if token != nil {
+			inviteID = token.ID
+		}
+		return newTestServer(mockStore), gin.Params{{Key: "inviteID", Value: inviteID.String()}}
+	}
+
+	t.Run("RateLimited", func(t *testing.T) {
+		srv, params := setup(t, testInvites()[0])
+		w, c := requestContext(t, http.MethodPost, "/v1/invites/"+params[0].Value+"/resend", nil, params)
+		srv.ResendInvite(c)
+		require.Equal(t, http.StatusTooManyRequests, w.Code)
+	})
+
+	t.Run("Accepted", func(t *testing.T) {
+		// The invite was sent long enough ago to be resent but the user has verified
+		// their email address so the invite has already been accepted.
+		invite := testInvites()[0]
+		invite.SentOn.Time = time.Now().Add(-2 * welcomeEmailResendCooldown)
+
+		srv, params := setup(t, invite)
+		w, c := requestContext(t, http.MethodPost, "/v1/invites/"+params[0].Value+"/resend", nil, params)
+		srv.ResendInvite(c)
+		require.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("ResetPasswordToken", func(t *testing.T) {
+		token := testInvites()[1]
+		token.TokenType = enum.TokenTypeResetPassword
+
+		srv, params := setup(t, token)
+		w, c := requestContext(t, http.MethodPost, "/v1/invites/"+params[0].Value+"/resend", nil, params)
+		srv.ResendInvite(c)
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		srv, params := setup(t, nil)
+		w, c := requestContext(t, http.MethodPost, "/v1/invites/"+params[0].Value+"/resend", nil, params)
+		srv.ResendInvite(c)
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestRevokeInvite(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		invite := testInvites()[0]
+		mockStore.OnRetrieveVeroToken = func(context.Context, ulid.ULID) (*models.VeroToken, error) {
+			return invite, nil
+		}
+
+		var deleted ulid.ULID
+		mockStore.OnDeleteVeroToken = func(_ context.Context, id ulid.ULID) error {
+			deleted = id
+			return nil
+		}
+
+		mockStore.OnCreateAuditEvent = func(_ context.Context, event *models.AuditEvent) (*models.AuditEvent, error) {
+			require.Equal(t, models.AuditDelete, event.Action)
+			require.Equal(t, models.AuditInvite, event.SubjectType)
+			require.Equal(t, invite.ID.String(), event.SubjectID)
+			return event, nil
+		}
+
+		params := gin.Params{{Key: "inviteID", Value: invite.ID.String()}}
+		w, c := requestContext(t, http.MethodDelete, "/v1/invites/"+invite.ID.String(), nil, params)
+		srv.RevokeInvite(c)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.True(t, parseReply(t, w).Success)
+		require.Equal(t, invite.ID, deleted)
+	})
+
+	t.Run("ResetPasswordToken", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		token := testInvites()[0]
+		token.TokenType = enum.TokenTypeResetPassword
+		mockStore.OnRetrieveVeroToken = func(context.Context, ulid.ULID) (*models.VeroToken, error) {
+			return token, nil
+		}
+
+		params := gin.Params{{Key: "inviteID", Value: token.ID.String()}}
+		w, c := requestContext(t, http.MethodDelete, "/v1/invites/"+token.ID.String(), nil, params)
+		srv.RevokeInvite(c)
+
+		require.Equal(t, http.StatusNotFound, w.Code)
+		mockStore.AssertCalls(t, mock.DeleteVeroToken, 0)
+	})
+
+	t.Run("OtherOrganization", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestOAuthServer(t, mockStore)
+
+		invite := testInvites()[0]
+		mockStore.OnRetrieveVeroToken = func(context.Context, ulid.ULID) (*models.VeroToken, error) {
+			return invite, nil
+		}
+		mockStore.OnRetrieveOrganizationMember = func(_ context.Context, orgID, userID ulid.ULID) (*models.User, error) {
+			require.Equal(t, acmeOrgID, orgID)
+			require.Equal(t, invite.ResourceID.ULID, userID)
+			return nil, errors.ErrNotFound
+		}
+
+		params := gin.Params{{Key: "inviteID", Value: invite.ID.String()}}
+		w, c := requestContext(t, http.MethodDelete, "/v1/invites/"+invite.ID.String(), nil, params)
+		authorizeOrg(t, srv, c, acmeOrgID)
+		srv.RevokeInvite(c)
+
+		require.Equal(t, http.StatusNotFound, w.Code)
+		mockStore.AssertCalls(t, mock.DeleteVeroToken, 0)
+	})
+
+	t.Run("InvalidID", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		params := gin.Params{{Key: "inviteID", Value: "notanid"}}
+		w, c := requestContext(t, http.MethodDelete, "/v1/invites/notanid", nil, params)
+		srv.RevokeInvite(c)
+
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestAcceptInviteAPIRequest(t *testing.T) {
+	mockStore := openMockStore(t)
+	defer mockStore.Close()
+	srv := newTestServer(mockStore)
+
+	w, c := requestContext(t, http.MethodPost, "/v1/accept-invite", []byte(`{"name":"Jane Doe"}`), nil)
+	c.Request.Header.Set("Accept", "application/json")
+	srv.AcceptInvite(c)
+
+	require.Equal(t, http.StatusNotAcceptable, w.Code)
+}
+
+// testInvites returns a pending invite that was just sent and an expired invite that
+// was never sent.
+func testInvites() []*models.VeroToken {
+	now := time.Now()
+	return []*models.VeroToken{
+		{
+			BaseModel:  tidal.BaseModel{ID: ulid.MakeSecure(), Created: now.Add(-1 * time.Hour)},
+			TokenType:  enum.TokenTypeTeamInvite,
+			ResourceID: ulid.NullULID{Valid: true, ULID: ulid.MakeSecure()},
+			Email:      "jane@example.com",
+			Expiration: now.Add(welcomeEmailTokenTTL),
+			SentOn:     sql.NullTime{Valid: true, Time: now.Add(-1 * time.Minute)},
+		},
+		{
+			BaseModel:  tidal.BaseModel{ID: ulid.MakeSecure(), Created: now.Add(-72 * time.Hour)},
+			TokenType:  enum.TokenTypeTeamInvite,
+			ResourceID: ulid.NullULID{Valid: true, ULID: ulid.MakeSecure()},
+			Email:      "john@example.com",
+			Expiration: now.Add(-24 * time.Hour),
+		},
+	}
+}
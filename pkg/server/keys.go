@@ -0,0 +1,185 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.rtnl.ai/quarterdeck/pkg/api/v1"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/ulid"
+	"go.rtnl.ai/x/rlog"
+)
+
+// keySyncTimeout limits how long a single maintenance or sync of the signing keys may take.
+const keySyncTimeout = 30 * time.Second
+
+// ============================================================================
+// Signing key handlers
+// ============================================================================
+
+// ListSigningKeys returns the token signing keys in every state, newest first. Only the
+// metadata of the keys is returned; the key material is available from the JWKS.
+func (s *Server) ListSigningKeys(c *gin.Context) {
+	var (
+		err  error
+		keys []*models.SigningKey
+		out  *api.SigningKeyList
+	)
+
+	if keys, err = s.store.ListSigningKeys(c.Request.Context()); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process signing keys list request"))
+		return
+	}
+
+	if out, err = api.NewSigningKeyList(keys); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process signing keys list request"))
+		return
+	}
+
+	c.JSON(http.StatusOK, out)
+}
+
+// RotateSigningKey creates a new signing key that is published in the JWKS and becomes
+// the active key once the publish window has elapsed. An immediate rotation activates
+// the new key right away, e.g. if the active key has been compromised.
+func (s *Server) RotateSigningKey(c *gin.Context) {
+	var (
+		err   error
+		in    *api.RotateSigningKey
+		model *models.SigningKey
+		out   *api.SigningKey
+	)
+
+	in = &api.RotateSigningKey{}
+	if c.Request.ContentLength != 0 {
+		if err = c.BindJSON(in); err != nil {
+			c.Error(err)
+			c.JSON(http.StatusBadRequest, api.Error("could not parse rotate signing key request"))
+			return
+		}
+	}
+
+	if model, err = s.keys.Rotate(c.Request.Context(), in.Immediate); err != nil {
+		if errors.Is(err, errors.ErrRotationPending) {
+			c.JSON(http.StatusConflict, api.Error("a signing key rotation is already scheduled"))
+			return
+		}
+
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process rotate signing key request"))
+		return
+	}
+
+	if out, err = api.NewSigningKey(model); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process rotate signing key request"))
+		return
+	}
+
+	s.audit(c, models.AuditRotate, models.AuditSigningKey, model.ID.String(), nil, out)
+	c.JSON(http.StatusCreated, out)
+}
+
+// RetireSigningKey removes a next or retiring key from the JWKS and deletes its private
+// key; tokens signed by the key can no longer be verified. The active key cannot be
+// retired, it must be replaced by an immediate rotation first.
+func (s *Server) RetireSigningKey(c *gin.Context) {
+	var (
+		err   error
+		keyID ulid.ULID
+	)
+
+	if keyID, err = ulid.Parse(c.Param("keyID")); err != nil {
+		c.JSON(http.StatusNotFound, api.Error("signing key not found"))
+		return
+	}
+
+	if err = s.keys.Retire(c.Request.Context(), keyID); err != nil {
+		switch {
+		case errors.Is(err, errors.ErrNotFound):
+			c.JSON(http.StatusNotFound, api.Error("signing key not found"))
+		case errors.Is(err, errors.ErrActiveSigningKey):
+			c.JSON(http.StatusConflict, api.Error("the active signing key cannot be retired"))
+		default:
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, api.Error("could not process retire signing key request"))
+		}
+		return
+	}
+
+	s.audit(c, models.AuditRetire, models.AuditSigningKey, keyID.String(), nil, nil)
+	c.JSON(http.StatusOK, api.Reply{Success: true})
+}
+
+// ============================================================================
+// Signing key lifecycle
+// ============================================================================
+
+// ensureSigningKeys imports the statically configured keys into the database and
+// advances the key lifecycle so that the issuer has an active key before the server
+// starts; a read-only replica only loads the keys maintained by the primary.
+func (s *Server) ensureSigningKeys(ctx context.Context) (err error) {
+	if s.conf.Database.ReadOnly {
+		if err = s.keys.Sync(ctx); err != nil {
+			return fmt.Errorf("could not load signing keys: %w", err)
+		}
+		return nil
+	}
+
+	if err = s.keys.Import(ctx, s.conf.Auth.Keys); err != nil {
+		return fmt.Errorf("could not import signing keys: %w", err)
+	}
+
+	if err = s.keys.Maintain(ctx); err != nil {
+		return fmt.Errorf("could not maintain signing keys: %w", err)
+	}
+	return nil
+}
+
+// runKeySync periodically advances the signing key lifecycle and loads the keys into
+// the issuer so that rotations by other replicas are picked up without a restart,
+// until the context is canceled when the server shuts down.
+func (s *Server) runKeySync(ctx context.Context) {
+	ticker := time.NewTicker(s.conf.Auth.KeySyncInterval)
+	defer ticker.Stop()
+
+	rlog.DebugAttrs(ctx, "signing key sync started",
+		slog.Duration("interval", s.conf.Auth.KeySyncInterval),
+		slog.Duration("rotation", s.conf.Auth.KeyRotationInterval),
+		slog.Duration("publish_window", s.conf.Auth.KeyPublishWindow))
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.syncSigningKeys(ctx)
+		}
+	}
+}
+
+// syncSigningKeys maintains the signing keys unless the database is read-only, in
+// which case the keys are only loaded. Failures are logged and the issuer continues to
+// use the keys from the last successful sync.
+func (s *Server) syncSigningKeys(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, keySyncTimeout)
+	defer cancel()
+
+	var err error
+	if s.conf.Database.ReadOnly {
+		err = s.keys.Sync(ctx)
+	} else {
+		err = s.keys.Maintain(ctx)
+	}
+
+	if err != nil {
+		rlog.WarnAttrs(ctx, "could not sync signing keys", slog.Any("err", err))
+	}
+}
@@ -0,0 +1,237 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.rtnl.ai/commo"
+	"go.rtnl.ai/ulid"
+	"go.rtnl.ai/x/rlog"
+
+	"go.rtnl.ai/quarterdeck/pkg/api/v1"
+	"go.rtnl.ai/quarterdeck/pkg/emails"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+)
+
+//===========================================================================
+// Admin Unlock
+//===========================================================================
+
+// UnlockUser clears the failed login attempts of a user so that they can log in again
+// before their lockout expires.
+func (s *Server) UnlockUser(c *gin.Context) {
+	var (
+		err    error
+		userID ulid.ULID
+		user   *models.User
+	)
+
+	if userID, err = ulid.Parse(c.Param("userID")); err != nil {
+		c.JSON(http.StatusNotFound, api.Error("user not found"))
+		return
+	}
+
+	if err = s.checkUserInCurrentOrg(c, userID, "user not found"); err != nil {
+		return
+	}
+
+	if user, err = s.store.RetrieveUser(c.Request.Context(), userID); err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			c.JSON(http.StatusNotFound, api.Error("user not found"))
+			return
+		}
+
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process unlock user request"))
+		return
+	}
+
+	if err = s.store.ResetLockout(c.Request.Context(), models.LockoutUser, user.Email); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process unlock user request"))
+		return
+	}
+
+	s.audit(c, models.AuditUnlock, models.AuditUser, user.ID.String(), nil, nil)
+	c.JSON(http.StatusOK, api.Reply{Success: true})
+}
+
+// UnlockAPIKey clears the failed authentication attempts of an API key so that it can
+// authenticate again before its lockout expires.
+func (s *Server) UnlockAPIKey(c *gin.Context) {
+	var (
+		err    error
+		keyID  ulid.ULID
+		apikey *models.APIKey
+	)
+
+	if keyID, err = ulid.Parse(c.Param("keyID")); err != nil {
+		c.JSON(http.StatusNotFound, api.Error("api key not found"))
+		return
+	}
+
+	if apikey, err = s.store.RetrieveAPIKey(c.Request.Context(), keyID); err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			c.JSON(http.StatusNotFound, api.Error("api key not found"))
+			return
+		}
+
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process unlock api key request"))
+		return
+	}
+
+	if err = s.store.ResetLockout(c.Request.Context(), models.LockoutClient, apikey.ClientID); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process unlock api key request"))
+		return
+	}
+
+	s.audit(c, models.AuditUnlock, models.AuditAPIKey, apikey.ID.String(), nil, nil)
+	c.JSON(http.StatusOK, api.Reply{Success: true})
+}
+
+//===========================================================================
+// Lockout Helpers
+//===========================================================================
+
+// lockoutPolicy returns the exponential backoff policy from the auth configuration.
+func (s *Server) lockoutPolicy() models.LockoutPolicy {
+	return models.LockoutPolicy{
+		Threshold:   s.conf.Auth.LockoutThreshold,
+		Duration:    s.conf.Auth.LockoutDuration,
+		MaxDuration: s.conf.Auth.LockoutMaxDuration,
+	}
+}
+
+// checkLockout returns ErrLockedOut and sets the Retry-After header if there have been
+// too many failed attempts against the identifier; this must be checked before the
+// credentials are verified. Otherwise the failed attempts (if any) are returned so that
+// they can be reset on a successful authentication. If lockouts are disabled then no
+// store queries are made and nil is returned.
+func (s *Server) checkLockout(c *gin.Context, kind, identifier string) (lockout *models.Lockout, err error) {
+	if s.conf.Auth.LockoutThreshold <= 0 || identifier == "" {
+		return nil, nil
+	}
+
+	if lockout, err = s.store.RetrieveLockout(c.Request.Context(), kind, identifier); err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if lockout.IsLocked() {
+		retryAfter := int64(math.Ceil(lockout.RetryAfter().Seconds()))
+		c.Header("Retry-After", strconv.FormatInt(retryAfter, 10))
+		return nil, errors.ErrLockedOut
+	}
+
+	return lockout, nil
+}
+
+// checkClientLockout wraps checkLockout for the OAuth endpoints, which must respond with
+// an OAuth error. If the client is locked out or the lockout cannot be checked then an
+// error response is written and false is returned.
+func (s *Server) checkClientLockout(c *gin.Context, clientID string) (lockout *models.Lockout, ok bool) {
+	var err error
+	if lockout, err = s.checkLockout(c, models.LockoutClient, clientID); err != nil {
+		if errors.Is(err, errors.ErrLockedOut) {
+			c.JSON(http.StatusTooManyRequests, &api.OAuthError{Code: api.OAuthInvalidClient, Description: err.Error()})
+			return nil, false
+		}
+
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, &api.OAuthError{Code: api.OAuthServerError})
+		return nil, false
+	}
+	return lockout, true
+}
+
+// recordFailedAttempt increments the failed attempts against the identifier. If the
+// attempt locks out a user for the first time since their last successful login, the
+// user is notified by email. Errors are logged but not returned since the request has
+// already failed authentication.
+func (s *Server) recordFailedAttempt(c *gin.Context, kind, identifier string, user *models.User) {
+	if s.conf.Auth.LockoutThreshold <= 0 || identifier == "" {
+		return
+	}
+
+	var (
+		err     error
+		lockout *models.Lockout
+	)
+
+	ctx := c.Request.Context()
+	if lockout, err = s.store.RecordFailedAttempt(ctx, kind, identifier, s.lockoutPolicy()); err != nil {
+		c.Error(err)
+		return
+	}
+
+	if !lockout.IsLocked() {
+		return
+	}
+
+	rlog.WarnAttrs(ctx, "authentication locked out after too many failed attempts",
+		slog.String("kind", kind),
+		slog.String("identifier", lockout.Identifier),
+		slog.Int64("failed_attempts", lockout.FailedAttempts),
+		slog.Time("locked_until", lockout.LockedUntil.Time),
+	)
+
+	// Only notify the user when the lockout begins so that an attacker cannot use the
+	// lockout to flood the user's inbox. The email is sent in the background so that
+	// response times do not reveal whether or not the account exists.
+	if user != nil && lockout.FailedAttempts == s.conf.Auth.LockoutThreshold {
+		go s.sendAccountLockedEmail(context.WithoutCancel(ctx), user, lockout)
+	}
+}
+
+// resetLockout clears the failed attempts returned by checkLockout after a successful
+// authentication. Errors are logged but do not prevent the authentication.
+func (s *Server) resetLockout(c *gin.Context, lockout *models.Lockout) {
+	if lockout == nil {
+		return
+	}
+
+	if err := s.store.ResetLockout(c.Request.Context(), lockout.Kind, lockout.Identifier); err != nil {
+		c.Error(err)
+	}
+}
+
+// sendAccountLockedEmail notifies the user that their account has been locked.
+func (s *Server) sendAccountLockedEmail(ctx context.Context, user *models.User, lockout *models.Lockout) {
+	var (
+		err   error
+		email *commo.Email
+	)
+
+	forgotURL := s.conf.Auth.GetForgotPasswordURL()
+	forgotURL.Host = s.conf.App.BaseURL().Host
+	data := emails.AccountLockedEmailData{
+		ContactName:      user.Name.String,
+		LockedUntil:      lockout.LockedUntil.Time,
+		ResetPasswordURL: forgotURL,
+		EmailBaseData: emails.EmailBaseData{
+			AppName:        s.conf.App.Name,
+			AppLogoURL:     s.conf.App.LogoURL(),
+			OrgName:        s.conf.Org.Name,
+			OrgHomepageURL: s.conf.Org.HomepageURL(),
+			SupportEmail:   s.conf.Org.SupportEmail,
+		},
+	}
+
+	if email, err = emails.NewAccountLockedEmail(user.Email, data); err != nil {
+		rlog.WarnAttrs(ctx, "could not create account locked email", slog.Any("err", err), slog.String("user_id", user.ID.String()))
+		return
+	}
+
+	if err = email.Send(); err != nil {
+		rlog.WarnAttrs(ctx, "could not send account locked email", slog.Any("err", err), slog.String("user_id", user.ID.String()))
+	}
+}
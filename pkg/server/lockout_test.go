@@ -0,0 +1,287 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/quarterdeck/pkg/api/v1"
+	"go.rtnl.ai/quarterdeck/pkg/auth/passwords"
+	"go.rtnl.ai/quarterdeck/pkg/enum"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/mock"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/tidal"
+	"go.rtnl.ai/ulid"
+)
+
+func TestLoginLockout(t *testing.T) {
+	password := "supersecretsquirrel"
+	derivedKey, err := passwords.CreateDerivedKey(password)
+	require.NoError(t, err)
+
+	user := &models.User{
+		BaseModel:     tidal.BaseModel{ID: ulid.MakeSecure()},
+		Email:         "jane@example.com",
+		Password:      derivedKey,
+		EmailVerified: true,
+	}
+
+	newLockoutServer := func(t *testing.T, mockStore *mock.Store) *Server {
+		srv := newTestOAuthServer(t, mockStore)
+		srv.conf.Auth.LockoutThreshold = 3
+		srv.conf.Auth.LockoutDuration = 5 * time.Minute
+		srv.conf.Auth.LockoutMaxDuration = time.Hour
+		return srv
+	}
+
+	login := func(srv *Server, email, password string) (int, http.Header, []byte) {
+		body, err := json.Marshal(&api.LoginRequest{Email: email, Password: password})
+		require.NoError(t, err)
+
+		w, c := requestContext(t, http.MethodPost, "/v1/login", body, nil)
+		c.Request.Header.Set("Content-Type", "application/json")
+		srv.Login(c)
+		return w.Code, w.Header(), w.Body.Bytes()
+	}
+
+	t.Run("LockedOut", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newLockoutServer(t, mockStore)
+
+		mockStore.OnRetrieveLockout = func(_ context.Context, kind, identifier string) (*models.Lockout, error) {
+			require.Equal(t, models.LockoutUser, kind)
+			require.Equal(t, "jane@example.com", identifier)
+			return &models.Lockout{
+				Kind:           kind,
+				Identifier:     identifier,
+				FailedAttempts: 3,
+				LockedUntil:    sql.NullTime{Time: time.Now().Add(90 * time.Second), Valid: true},
+			}, nil
+		}
+
+		// Even the correct password must be rejected without checking it.
+		code, header, body := login(srv, "jane@example.com", password)
+		require.Equal(t, http.StatusTooManyRequests, code)
+		require.JSONEq(t, `{"success": false, "error": "too many failed attempts, please try again later"}`, string(body))
+
+		retryAfter, err := strconv.Atoi(header.Get("Retry-After"))
+		require.NoError(t, err)
+		require.InDelta(t, 90, retryAfter, 2)
+
+		mockStore.AssertCalls(t, mock.RetrieveUserByEmail, 0)
+		mockStore.AssertCalls(t, mock.RecordFailedAttempt, 0)
+	})
+
+	t.Run("WrongPassword", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newLockoutServer(t, mockStore)
+
+		mockStore.OnRetrieveLockout = func(context.Context, string, string) (*models.Lockout, error) {
+			return nil, errors.ErrNotFound
+		}
+		mockStore.OnRetrieveUserByEmail = func(context.Context, string) (*models.User, error) {
+			return user, nil
+		}
+		mockStore.OnRecordFailedAttempt = func(_ context.Context, kind, identifier string, policy models.LockoutPolicy) (*models.Lockout, error) {
+			require.Equal(t, models.LockoutUser, kind)
+			require.Equal(t, "jane@example.com", identifier)
+			require.Equal(t, models.LockoutPolicy{Threshold: 3, Duration: 5 * time.Minute, MaxDuration: time.Hour}, policy)
+			return &models.Lockout{Kind: kind, Identifier: identifier, FailedAttempts: 1}, nil
+		}
+		mockStore.OnCreateAuditEvent = func(_ context.Context, event *models.AuditEvent) (*models.AuditEvent, error) {
+			require.Equal(t, models.AuditLoginFailed, event.Action)
+			require.Equal(t, user.ID.String(), event.SubjectID)
+			require.False(t, event.ActorID.Valid)
+			return event, nil
+		}
+
+		code, _, _ := login(srv, "jane@example.com", "wrongpassword")
+		require.Equal(t, http.StatusUnauthorized, code)
+		mockStore.AssertCalls(t, mock.RecordFailedAttempt, 1)
+		mockStore.AssertCalls(t, mock.ResetLockout, 0)
+		mockStore.AssertCalls(t, mock.CreateAuditEvent, 1)
+	})
+
+	t.Run("UnknownUser", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newLockoutServer(t, mockStore)
+
+		mockStore.OnRetrieveLockout = func(context.Context, string, string) (*models.Lockout, error) {
+			return nil, errors.ErrNotFound
+		}
+		mockStore.OnRetrieveUserByEmail = func(context.Context, string) (*models.User, error) {
+			return nil, errors.ErrNotFound
+		}
+		mockStore.OnRecordFailedAttempt = func(_ context.Context, kind, identifier string, _ models.LockoutPolicy) (*models.Lockout, error) {
+			return &models.Lockout{Kind: kind, Identifier: identifier, FailedAttempts: 3, LockedUntil: sql.NullTime{Time: time.Now().Add(5 * time.Minute), Valid: true}}, nil
+		}
+
+		// Failed attempts are tracked for unknown emails to prevent enumeration.
+		code, _, _ := login(srv, "unknown@example.com", password)
+		require.Equal(t, http.StatusUnauthorized, code)
+		mockStore.AssertCalls(t, mock.RecordFailedAttempt, 1)
+		mockStore.AssertCalls(t, mock.CreateAuditEvent, 0)
+	})
+
+	t.Run("SuccessResets", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newLockoutServer(t, mockStore)
+
+		mockStore.OnRetrieveLockout = func(_ context.Context, kind, identifier string) (*models.Lockout, error) {
+			return &models.Lockout{
+				Kind:           kind,
+				Identifier:     identifier,
+				FailedAttempts: 2,
+				LastFailure:    sql.NullTime{Time: time.Now().Add(-1 * time.Minute), Valid: true},
+			}, nil
+		}
+		mockStore.OnRetrieveUserByEmail = func(context.Context, string) (*models.User, error) {
+			return user, nil
+		}
+		mockStore.OnResetLockout = func(_ context.Context, kind, identifier string) error {
+			require.Equal(t, models.LockoutUser, kind)
+			require.Equal(t, "jane@example.com", identifier)
+			return nil
+		}
+		mockStore.OnUpdateLastLogin = func(context.Context, ulid.ULID, time.Time) error { return nil }
+		mockStore.OnListUserOrganizations = func(context.Context, ulid.ULID) ([]*models.Organization, error) { return nil, nil }
+		mockStore.OnCreateRefreshToken = func(_ context.Context, in *models.RefreshToken) (*models.RefreshToken, error) { return in, nil }
+		mockStore.OnCreateSession = func(_ context.Context, in *models.Session) (*models.Session, error) { return in, nil }
+		mockStore.OnCreateAuditEvent = func(_ context.Context, in *models.AuditEvent) (*models.AuditEvent, error) { return in, nil }
+		mockStore.OnEnqueueWebhookEvent = func(context.Context, ulid.ULID, enum.WebhookEvent, []byte) (int, error) { return 0, nil }
+
+		code, _, _ := login(srv, "jane@example.com", password)
+		require.Equal(t, http.StatusOK, code)
+		mockStore.AssertCalls(t, mock.ResetLockout, 1)
+		mockStore.AssertCalls(t, mock.RecordFailedAttempt, 0)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestOAuthServer(t, mockStore)
+
+		mockStore.OnRetrieveUserByEmail = func(context.Context, string) (*models.User, error) {
+			return user, nil
+		}
+		mockStore.OnCreateAuditEvent = func(_ context.Context, in *models.AuditEvent) (*models.AuditEvent, error) { return in, nil }
+
+		code, _, _ := login(srv, "jane@example.com", "wrongpassword")
+		require.Equal(t, http.StatusUnauthorized, code)
+		mockStore.AssertCalls(t, mock.RetrieveLockout, 0)
+		mockStore.AssertCalls(t, mock.RecordFailedAttempt, 0)
+	})
+}
+
+func TestAuthenticateLockout(t *testing.T) {
+	mockStore := openMockStore(t)
+	defer mockStore.Close()
+	srv := newTestOAuthServer(t, mockStore)
+	srv.conf.Auth.LockoutThreshold = 3
+	srv.conf.Auth.LockoutDuration = 5 * time.Minute
+	srv.conf.Auth.LockoutMaxDuration = time.Hour
+
+	clientID := passwords.ClientID()
+	mockStore.OnRetrieveLockout = func(_ context.Context, kind, identifier string) (*models.Lockout, error) {
+		require.Equal(t, models.LockoutClient, kind)
+		require.Equal(t, clientID, identifier)
+		return &models.Lockout{
+			Kind:           kind,
+			Identifier:     identifier,
+			FailedAttempts: 4,
+			LockedUntil:    sql.NullTime{Time: time.Now().Add(10 * time.Minute), Valid: true},
+		}, nil
+	}
+
+	body, err := json.Marshal(&api.AuthenticateRequest{ClientID: clientID, ClientSecret: passwords.ClientSecret()})
+	require.NoError(t, err)
+
+	w, c := requestContext(t, http.MethodPost, "/v1/authenticate", body, nil)
+	c.Request.Header.Set("Content-Type", "application/json")
+	srv.Authenticate(c)
+
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.NotEmpty(t, w.Header().Get("Retry-After"))
+	mockStore.AssertCalls(t, mock.RetrieveAPIKeyByClientID, 0)
+}
+
+func TestUnlockUser(t *testing.T) {
+	user := &models.User{
+		BaseModel: tidal.BaseModel{ID: ulid.MakeSecure()},
+		Email:     "jane@example.com",
+	}
+
+	unlock := func(srv *Server, userID string) int {
+		w, c := requestContext(t, http.MethodPost, "/v1/users/"+userID+"/unlock", nil, gin.Params{{Key: "userID", Value: userID}})
+		srv.UnlockUser(c)
+		return w.Code
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestOAuthServer(t, mockStore)
+
+		mockStore.OnRetrieveUser = func(context.Context, ulid.ULID) (*models.User, error) {
+			return user, nil
+		}
+		mockStore.OnResetLockout = func(_ context.Context, kind, identifier string) error {
+			require.Equal(t, models.LockoutUser, kind)
+			require.Equal(t, user.Email, identifier)
+			return nil
+		}
+		mockStore.OnCreateAuditEvent = func(_ context.Context, event *models.AuditEvent) (*models.AuditEvent, error) {
+			require.Equal(t, models.AuditUnlock, event.Action)
+			require.Equal(t, models.AuditUser, event.SubjectType)
+			require.Equal(t, user.ID.String(), event.SubjectID)
+			return event, nil
+		}
+
+		require.Equal(t, http.StatusOK, unlock(srv, user.ID.String()))
+		mockStore.AssertCalls(t, mock.ResetLockout, 1)
+		mockStore.AssertCalls(t, mock.CreateAuditEvent, 1)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestOAuthServer(t, mockStore)
+
+		mockStore.OnRetrieveUser = func(context.Context, ulid.ULID) (*models.User, error) {
+			return nil, errors.ErrNotFound
+		}
+
+		require.Equal(t, http.StatusNotFound, unlock(srv, ulid.MakeSecure().String()))
+		require.Equal(t, http.StatusNotFound, unlock(srv, "notaulid"))
+		mockStore.AssertCalls(t, mock.ResetLockout, 0)
+	})
+
+	t.Run("OtherOrganization", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestOAuthServer(t, mockStore)
+
+		mockStore.OnRetrieveOrganizationMember = func(context.Context, ulid.ULID, ulid.ULID) (*models.User, error) {
+			return nil, errors.ErrNotFound
+		}
+
+		w, c := requestContext(t, http.MethodPost, "/v1/users/"+user.ID.String()+"/unlock", nil, gin.Params{{Key: "userID", Value: user.ID.String()}})
+		authorizeOrg(t, srv, c, acmeOrgID)
+		srv.UnlockUser(c)
+
+		require.Equal(t, http.StatusNotFound, w.Code)
+		mockStore.AssertCalls(t, mock.RetrieveUser, 0)
+		mockStore.AssertCalls(t, mock.ResetLockout, 0)
+	})
+}
@@ -0,0 +1,530 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	gimauth "go.rtnl.ai/gimlet/auth"
+	"go.rtnl.ai/quarterdeck/pkg/api/v1"
+	"go.rtnl.ai/quarterdeck/pkg/auth"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/txn"
+	"go.rtnl.ai/quarterdeck/pkg/web/htmx"
+	"go.rtnl.ai/quarterdeck/pkg/web/scene"
+	"go.rtnl.ai/ulid"
+)
+
+//===========================================================================
+// MFA Login
+//===========================================================================
+
+// challengeMFA responds to a login request whose password has been verified with a
+// short-lived MFA challenge rather than with tokens. For web requests the login form is
+// replaced by the MFA form so that the user can enter their code.
+func (s *Server) challengeMFA(c *gin.Context, user *models.User, in *api.LoginRequest) {
+	var (
+		err error
+		out *api.MFAChallenge
+	)
+
+	out = &api.MFAChallenge{MFARequired: true}
+	if out.MFAToken, err = s.issuer.CreateMFAChallenge(user.ID, in.ClientID, in.Nonce, in.Next); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error(errors.ErrInternal))
+		return
+	}
+
+	switch c.NegotiateFormat(binding.MIMEJSON, binding.MIMEHTML) {
+	case binding.MIMEJSON:
+		c.JSON(http.StatusOK, out)
+	case binding.MIMEHTML:
+		c.Header(htmx.HXRetarget, ".auth-form")
+		c.Header(htmx.HXReswap, "outerHTML")
+		c.HTML(http.StatusOK, "partials/auth/mfa.html", scene.New(c).WithAPIData(out))
+	default:
+		c.AbortWithError(http.StatusNotAcceptable, errors.ErrNotAccepted)
+	}
+}
+
+// LoginMFA completes the second step of a login for users with MFA enabled. The MFA
+// challenge issued by Login must be submitted with either a TOTP code from the user's
+// authenticator app or one of their unused recovery codes. Incorrect codes count
+// toward the same lockout as incorrect passwords and each challenge can only be used
+// to complete one login.
+func (s *Server) LoginMFA(c *gin.Context) {
+	var (
+		err       error
+		in        *api.MFALoginRequest
+		challenge *auth.MFAChallengeClaims
+		userID    ulid.ULID
+		user      *models.User
+		lockout   *models.Lockout
+		amr       []string
+	)
+
+	if err = c.BindJSON(&in); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadRequest, api.Error(errors.ErrBindJSON))
+		return
+	}
+
+	if err = in.Validate(); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadRequest, api.Error(err))
+		return
+	}
+
+	if challenge, userID, err = s.issuer.VerifyMFAChallenge(in.MFAToken); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusUnauthorized, api.Error(errors.ErrMFAChallengeFailed))
+		return
+	}
+
+	if user, err = s.store.RetrieveUser(c.Request.Context(), userID); err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			c.JSON(http.StatusUnauthorized, api.Error(errors.ErrFailedAuthentication))
+			return
+		}
+
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error(errors.ErrInternal))
+		return
+	}
+
+	// Refuse to check any more codes if there have been too many failed attempts so
+	// that the six digit TOTP codes cannot be brute-forced with a single challenge.
+	if lockout, err = s.checkLockout(c, models.LockoutUser, user.Email); err != nil {
+		if errors.Is(err, errors.ErrLockedOut) {
+			c.JSON(http.StatusTooManyRequests, api.Error(errors.ErrLockedOut))
+			return
+		}
+
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error(errors.ErrInternal))
+		return
+	}
+
+	// The challenge is denylisted once it has been completed so that it cannot be used
+	// to log in again, e.g. if the request is replayed with another code.
+	revoked := &models.RevokedToken{Expiration: challenge.ExpiresAt.Time}
+	if revoked.ID, err = ulid.Parse(challenge.ID); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusUnauthorized, api.Error(errors.ErrMFAChallengeFailed))
+		return
+	}
+
+	switch {
+	case !user.MFAEnabled():
+		err = errors.ErrInvalidMFACode
+	case in.RecoveryCode != "":
+		// Recovery codes can only be used once so the code and the challenge are
+		// consumed in the same transaction; a replayed challenge does not burn a code.
+		err = s.useRecoveryCode(c.Request.Context(), userID, in.RecoveryCode, revoked)
+		amr = []string{auth.AMRPassword, auth.AMRMFA}
+	default:
+		if err = s.verifyTOTP(c.Request.Context(), user, in.Code); err == nil {
+			err = s.store.ConsumeToken(c.Request.Context(), revoked)
+		}
+		amr = []string{auth.AMRPassword, auth.AMROTP, auth.AMRMFA}
+	}
+
+	if err != nil {
+		switch {
+		case errors.Is(err, errors.ErrInvalidMFACode):
+			s.recordFailedAttempt(c, models.LockoutUser, user.Email, user)
+			s.auditLogin(c, models.AuditLoginFailed, models.AuditUser, userID)
+			c.JSON(http.StatusUnauthorized, api.Error(errors.ErrInvalidMFACode))
+		case errors.Is(err, errors.ErrTokenRevoked):
+			c.JSON(http.StatusUnauthorized, api.Error(errors.ErrMFAChallengeFailed))
+		case errors.Is(err, errors.ErrNotFound):
+			c.JSON(http.StatusUnauthorized, api.Error(errors.ErrFailedAuthentication))
+		default:
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, api.Error(errors.ErrInternal))
+		}
+		return
+	}
+
+	// Both factors have been verified so clear the failed attempts of either step.
+	s.resetLockout(c, lockout)
+	s.completeLogin(c, user, challenge.ClientID, challenge.Nonce, challenge.Next, amr...)
+}
+
+// useRecoveryCode removes the recovery code from the user's unused codes and consumes
+// the MFA challenge, returning ErrInvalidMFACode if the code does not match one of the
+// user's unused codes or ErrTokenRevoked if the challenge has already been used.
+func (s *Server) useRecoveryCode(ctx context.Context, userID ulid.ULID, code string, challenge *models.RevokedToken) error {
+	return s.store.WithTx(ctx, nil, func(tx txn.Tx) (err error) {
+		if err = tx.ConsumeToken(challenge); err != nil {
+			return err
+		}
+
+		var user *models.User
+		if user, err = tx.RetrieveUser(userID); err != nil {
+			return err
+		}
+
+		if !user.MFAEnabled() || !user.UseRecoveryCode(auth.HashRecoveryCode(code)) {
+			return errors.ErrInvalidMFACode
+		}
+		return tx.UpdateMFA(user)
+	})
+}
+
+//===========================================================================
+// MFA Enrollment
+//===========================================================================
+
+// EnrollTOTP starts TOTP enrollment by generating a new secret for the user. The
+// secret is not used to authenticate the user until the enrollment is confirmed with a
+// valid code from the user's authenticator app.
+func (s *Server) EnrollTOTP(c *gin.Context) {
+	var (
+		err  error
+		user *models.User
+		out  *api.TOTPEnrollment
+	)
+
+	if user, err = s.mfaUser(c); err != nil {
+		return
+	}
+
+	if user.MFAEnabled() {
+		c.JSON(http.StatusConflict, api.Error(errors.ErrMFAAlreadyEnabled))
+		return
+	}
+
+	issuer := s.conf.Auth.Issuer
+	if u, err := url.Parse(issuer); err == nil && u.Hostname() != "" {
+		issuer = u.Hostname()
+	}
+
+	key, err := auth.GenerateTOTP(issuer, user.Email)
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process mfa enrollment request"))
+		return
+	}
+
+	out = &api.TOTPEnrollment{Secret: key.Secret(), URL: key.URL()}
+	if out.QRCode, err = auth.TOTPQRCode(key); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process mfa enrollment request"))
+		return
+	}
+
+	// Store the unconfirmed secret sealed with the key encryption key; any previous
+	// unconfirmed enrollment is replaced.
+	user.DisableTOTP()
+	if user.TOTPSecret.String, err = s.secrets.SealSecret(user.ID, out.Secret); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process mfa enrollment request"))
+		return
+	}
+
+	user.TOTPSecret.Valid = true
+	if err = s.store.UpdateMFA(c.Request.Context(), user); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process mfa enrollment request"))
+		return
+	}
+
+	c.Negotiate(http.StatusOK, gin.Negotiate{
+		Offered:  []string{binding.MIMEJSON, binding.MIMEHTML},
+		Data:     out,
+		HTMLName: "partials/profile/totpEnroll.html",
+		HTMLData: scene.New(c).WithAPIData(out),
+	})
+}
+
+// ConfirmTOTP enables MFA for the user once they have submitted a valid code from
+// their authenticator app and returns the user's recovery codes; this is the only time
+// that the recovery codes can be viewed.
+func (s *Server) ConfirmTOTP(c *gin.Context) {
+	var (
+		err  error
+		in   *api.MFACodeRequest
+		user *models.User
+		out  *api.RecoveryCodes
+	)
+
+	in = &api.MFACodeRequest{}
+	if err = c.BindJSON(in); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadRequest, api.Error(errors.ErrBindJSON))
+		return
+	}
+
+	if in.Code == "" {
+		c.JSON(http.StatusBadRequest, api.Error(api.ValidationError(nil, api.MissingField("code"))))
+		return
+	}
+
+	if user, err = s.mfaUser(c); err != nil {
+		return
+	}
+
+	if user.MFAEnabled() {
+		c.JSON(http.StatusConflict, api.Error(errors.ErrMFAAlreadyEnabled))
+		return
+	}
+
+	if !user.TOTPSecret.Valid || user.TOTPSecret.String == "" {
+		c.JSON(http.StatusBadRequest, api.Error(errors.ErrMFANotEnrolled))
+		return
+	}
+
+	if err = s.verifyTOTP(c.Request.Context(), user, in.Code); err != nil {
+		if errors.Is(err, errors.ErrInvalidMFACode) {
+			c.JSON(http.StatusBadRequest, api.Error(errors.ErrInvalidMFACode))
+			return
+		}
+
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process mfa confirmation request"))
+		return
+	}
+
+	var hashes []string
+	out = &api.RecoveryCodes{}
+	if out.Codes, hashes, err = auth.GenerateRecoveryCodes(); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process mfa confirmation request"))
+		return
+	}
+
+	user.EnableTOTP(hashes)
+	if err = s.store.UpdateMFA(c.Request.Context(), user); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process mfa confirmation request"))
+		return
+	}
+
+	c.Negotiate(http.StatusOK, gin.Negotiate{
+		Offered:  []string{binding.MIMEJSON, binding.MIMEHTML},
+		Data:     out,
+		HTMLName: "partials/profile/recoveryCodes.html",
+		HTMLData: scene.New(c).WithAPIData(out),
+	})
+}
+
+// DisableTOTP removes the user's second factor and recovery codes; the user must
+// submit a valid TOTP or recovery code to disable MFA.
+func (s *Server) DisableTOTP(c *gin.Context) {
+	var (
+		err  error
+		in   *api.MFACodeRequest
+		user *models.User
+	)
+
+	in = &api.MFACodeRequest{}
+	if err = c.BindJSON(in); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadRequest, api.Error(errors.ErrBindJSON))
+		return
+	}
+
+	if err = in.Validate(); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadRequest, api.Error(err))
+		return
+	}
+
+	if user, err = s.mfaUser(c); err != nil {
+		return
+	}
+
+	if !user.MFAEnabled() {
+		c.JSON(http.StatusBadRequest, api.Error(errors.ErrMFANotEnabled))
+		return
+	}
+
+	if err = s.verifySecondFactor(c.Request.Context(), user, in); err != nil {
+		if errors.Is(err, errors.ErrInvalidMFACode) {
+			c.JSON(http.StatusBadRequest, api.Error(errors.ErrInvalidMFACode))
+			return
+		}
+
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process disable mfa request"))
+		return
+	}
+
+	user.DisableTOTP()
+	if err = s.store.UpdateMFA(c.Request.Context(), user); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process disable mfa request"))
+		return
+	}
+
+	if htmx.IsHTMXRequest(c) {
+		c.Header(htmx.HXRefresh, "true")
+		c.Data(http.StatusNoContent, gin.MIMEHTML, nil)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.Reply{Success: true})
+}
+
+// RegenerateRecoveryCodes replaces all of the user's recovery codes with new codes,
+// e.g. if the user has lost their codes or has used most of them.
+func (s *Server) RegenerateRecoveryCodes(c *gin.Context) {
+	var (
+		err    error
+		in     *api.MFACodeRequest
+		user   *models.User
+		hashes []string
+		out    *api.RecoveryCodes
+	)
+
+	in = &api.MFACodeRequest{}
+	if err = c.BindJSON(in); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadRequest, api.Error(errors.ErrBindJSON))
+		return
+	}
+
+	if err = in.Validate(); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadRequest, api.Error(err))
+		return
+	}
+
+	if user, err = s.mfaUser(c); err != nil {
+		return
+	}
+
+	if !user.MFAEnabled() {
+		c.JSON(http.StatusBadRequest, api.Error(errors.ErrMFANotEnabled))
+		return
+	}
+
+	if err = s.verifySecondFactor(c.Request.Context(), user, in); err != nil {
+		if errors.Is(err, errors.ErrInvalidMFACode) {
+			c.JSON(http.StatusBadRequest, api.Error(errors.ErrInvalidMFACode))
+			return
+		}
+
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process recovery codes request"))
+		return
+	}
+
+	out = &api.RecoveryCodes{}
+	if out.Codes, hashes, err = auth.GenerateRecoveryCodes(); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process recovery codes request"))
+		return
+	}
+
+	user.RecoveryCodes = hashes
+	if err = s.store.UpdateMFA(c.Request.Context(), user); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process recovery codes request"))
+		return
+	}
+
+	c.Negotiate(http.StatusOK, gin.Negotiate{
+		Offered:  []string{binding.MIMEJSON, binding.MIMEHTML},
+		Data:     out,
+		HTMLName: "partials/profile/recoveryCodes.html",
+		HTMLData: scene.New(c).WithAPIData(out),
+	})
+}
+
+// mfaUser retrieves the user in the URL, ensuring that the requester is that user
+// since a user's second factor can only be managed by the user themselves. If an error
+// is returned then the response has already been written.
+func (s *Server) mfaUser(c *gin.Context) (user *models.User, err error) {
+	return s.selfUser(c, "multi-factor authentication can only be managed by the user")
+}
+
+// selfUser retrieves the user in the URL if the requester is that user, otherwise it
+// responds with a 403 and the forbidden message. If an error is returned then the
+// response has already been written.
+func (s *Server) selfUser(c *gin.Context, forbidden string) (user *models.User, err error) {
+	var (
+		claims    *gimauth.Claims
+		sub       gimauth.SubjectType
+		subjectID ulid.ULID
+		userID    ulid.ULID
+	)
+
+	if claims, err = gimauth.GetClaims(c); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusUnauthorized, api.Error("could not get user claims"))
+		return nil, err
+	}
+
+	if userID, err = ulid.Parse(c.Param("userID")); err != nil {
+		c.JSON(http.StatusNotFound, api.Error("user not found"))
+		return nil, err
+	}
+
+	if sub, subjectID, err = claims.SubjectID(); err != nil || sub != gimauth.SubjectUser || subjectID != userID {
+		c.JSON(http.StatusForbidden, api.Error(forbidden))
+		return nil, errors.ErrNotAuthorized
+	}
+
+	if user, err = s.store.RetrieveUser(c.Request.Context(), userID); err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			c.JSON(http.StatusNotFound, api.Error("user not found"))
+			return nil, err
+		}
+
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error(errors.ErrInternal))
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// verifySecondFactor checks the TOTP or recovery code in the request, returning
+// ErrInvalidMFACode if the code is incorrect. A valid recovery code is removed from the
+// user's unused codes so the user must be saved afterward.
+func (s *Server) verifySecondFactor(ctx context.Context, user *models.User, in *api.MFACodeRequest) error {
+	if in.RecoveryCode != "" {
+		if !user.UseRecoveryCode(auth.HashRecoveryCode(in.RecoveryCode)) {
+			return errors.ErrInvalidMFACode
+		}
+		return nil
+	}
+	return s.verifyTOTP(ctx, user, in.Code)
+}
+
+// verifyTOTP checks the code against the user's TOTP secret, which is stored sealed
+// with the key encryption key, and records the time step of the code so that it cannot
+// be used again. ErrInvalidMFACode is returned if the code is incorrect or was used.
+func (s *Server) verifyTOTP(ctx context.Context, user *models.User, code string) (err error) {
+	if !user.TOTPSecret.Valid || user.TOTPSecret.String == "" {
+		return errors.ErrInvalidMFACode
+	}
+
+	var secret string
+	if secret, err = s.secrets.OpenSecret(user.ID, user.TOTPSecret.String); err != nil {
+		return err
+	}
+
+	step, valid := auth.ValidateTOTP(code, secret, user.TOTPStep.Int64)
+	if !valid {
+		return errors.ErrInvalidMFACode
+	}
+
+	// The step is only updated if it is after the last accepted step so that the same
+	// code cannot be accepted by concurrent requests.
+	if err = s.store.UpdateTOTPStep(ctx, user.ID, step); err != nil {
+		if errors.Is(err, errors.ErrTOTPReused) {
+			return errors.ErrInvalidMFACode
+		}
+		return err
+	}
+
+	user.TOTPStep = sql.NullInt64{Int64: step, Valid: true}
+	return nil
+}
@@ -0,0 +1,507 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/gimlet"
+	gimauth "go.rtnl.ai/gimlet/auth"
+	"go.rtnl.ai/quarterdeck/pkg/api/v1"
+	"go.rtnl.ai/quarterdeck/pkg/auth"
+	"go.rtnl.ai/quarterdeck/pkg/auth/passwords"
+	"go.rtnl.ai/quarterdeck/pkg/enum"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/mock"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/tidal"
+	"go.rtnl.ai/ulid"
+)
+
+func TestLoginMFA(t *testing.T) {
+	password := "supersecretsquirrel"
+	derivedKey, err := passwords.CreateDerivedKey(password)
+	require.NoError(t, err)
+
+	key, err := auth.GenerateTOTP("localhost", "jane@example.com")
+	require.NoError(t, err)
+
+	// The TOTP secret is sealed by the server's key encryption key.
+	mfaUser := func(srv *Server) *models.User {
+		user := &models.User{
+			BaseModel:     tidal.BaseModel{ID: ulid.MakeSecure()},
+			Email:         "jane@example.com",
+			Password:      derivedKey,
+			EmailVerified: true,
+			TOTPEnabled:   sql.NullTime{Time: time.Now(), Valid: true},
+		}
+
+		sealed, err := srv.secrets.SealSecret(user.ID, key.Secret())
+		require.NoError(t, err)
+		user.TOTPSecret = sql.NullString{String: sealed, Valid: true}
+		return user
+	}
+
+	mockLogin := func(mockStore *mock.Store, user *models.User) {
+		mockStore.OnRetrieveUserByEmail = func(context.Context, string) (*models.User, error) {
+			return user, nil
+		}
+		mockStore.OnRetrieveUser = func(context.Context, ulid.ULID) (*models.User, error) {
+			return user, nil
+		}
+		mockStore.OnUpdateLastLogin = func(context.Context, ulid.ULID, time.Time) error { return nil }
+		mockStore.OnListUserOrganizations = func(context.Context, ulid.ULID) ([]*models.Organization, error) { return nil, nil }
+		mockStore.OnCreateRefreshToken = func(_ context.Context, in *models.RefreshToken) (*models.RefreshToken, error) { return in, nil }
+		mockStore.OnCreateSession = func(_ context.Context, in *models.Session) (*models.Session, error) { return in, nil }
+		mockStore.OnCreateAuditEvent = func(_ context.Context, in *models.AuditEvent) (*models.AuditEvent, error) { return in, nil }
+		mockStore.OnEnqueueWebhookEvent = func(context.Context, ulid.ULID, enum.WebhookEvent, []byte) (int, error) { return 0, nil }
+
+		// The store only accepts TOTP steps and challenges that have not been used.
+		mockStore.OnUpdateTOTPStep = func(_ context.Context, _ ulid.ULID, step int64) error {
+			if user.TOTPStep.Valid && step <= user.TOTPStep.Int64 {
+				return errors.ErrTOTPReused
+			}
+			user.TOTPStep = sql.NullInt64{Int64: step, Valid: true}
+			return nil
+		}
+
+		consumed := make(map[ulid.ULID]struct{})
+		mockStore.OnConsumeToken = func(_ context.Context, in *models.RevokedToken) error {
+			if _, ok := consumed[in.ID]; ok {
+				return errors.ErrTokenRevoked
+			}
+			consumed[in.ID] = struct{}{}
+			return nil
+		}
+	}
+
+	loginMFA := func(srv *Server, in *api.MFALoginRequest) *httpResponse {
+		body, err := json.Marshal(in)
+		require.NoError(t, err)
+
+		w, c := requestContext(t, http.MethodPost, "/v1/login/mfa", body, nil)
+		c.Request.Header.Set("Content-Type", "application/json")
+		srv.LoginMFA(c)
+		return &httpResponse{w.Code, w.Body.Bytes()}
+	}
+
+	t.Run("Challenge", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestOAuthServer(t, mockStore)
+		mockLogin(mockStore, mfaUser(srv))
+
+		body, err := json.Marshal(&api.LoginRequest{Email: "jane@example.com", Password: password})
+		require.NoError(t, err)
+
+		w, c := requestContext(t, http.MethodPost, "/v1/login", body, nil)
+		c.Request.Header.Set("Content-Type", "application/json")
+		srv.Login(c)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		out := &api.MFAChallenge{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), out))
+		require.True(t, out.MFARequired)
+		require.NotEmpty(t, out.MFAToken)
+
+		mockStore.AssertCalls(t, mock.CreateRefreshToken, 0)
+		mockStore.AssertCalls(t, mock.UpdateLastLogin, 0)
+		mockStore.AssertCalls(t, mock.CreateAuditEvent, 0)
+		require.Empty(t, w.Header().Values("Set-Cookie"), "no tokens should be issued until the challenge is completed")
+	})
+
+	t.Run("TOTP", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestOAuthServer(t, mockStore)
+
+		user := mfaUser(srv)
+		mockLogin(mockStore, user)
+
+		challenge, err := srv.issuer.CreateMFAChallenge(user.ID, "", "", "")
+		require.NoError(t, err)
+
+		code, err := totp.GenerateCode(key.Secret(), time.Now())
+		require.NoError(t, err)
+
+		rep := loginMFA(srv, &api.MFALoginRequest{MFAToken: challenge, Code: code})
+		require.Equal(t, http.StatusOK, rep.code)
+
+		out := &api.LoginReply{}
+		require.NoError(t, json.Unmarshal(rep.body, out))
+		require.NotEmpty(t, out.AccessToken)
+		require.NotEmpty(t, out.RefreshToken)
+
+		amr, err := srv.issuer.AuthMethods(out.AccessToken)
+		require.NoError(t, err)
+		require.Equal(t, []string{auth.AMRPassword, auth.AMROTP, auth.AMRMFA}, amr)
+		mockStore.AssertCalls(t, mock.UpdateTOTPStep, 1)
+		mockStore.AssertCalls(t, mock.ConsumeToken, 1)
+	})
+
+	t.Run("ReusedCode", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestOAuthServer(t, mockStore)
+
+		user := mfaUser(srv)
+		mockLogin(mockStore, user)
+
+		code, err := totp.GenerateCode(key.Secret(), time.Now())
+		require.NoError(t, err)
+
+		challenge, err := srv.issuer.CreateMFAChallenge(user.ID, "", "", "")
+		require.NoError(t, err)
+
+		rep := loginMFA(srv, &api.MFALoginRequest{MFAToken: challenge, Code: code})
+		require.Equal(t, http.StatusOK, rep.code)
+
+		// A code that has been accepted cannot be used again even with a new challenge.
+		challenge, err = srv.issuer.CreateMFAChallenge(user.ID, "", "", "")
+		require.NoError(t, err)
+
+		rep = loginMFA(srv, &api.MFALoginRequest{MFAToken: challenge, Code: code})
+		require.Equal(t, http.StatusUnauthorized, rep.code)
+		require.Contains(t, string(rep.body), errors.ErrInvalidMFACode.Error())
+		mockStore.AssertCalls(t, mock.CreateRefreshToken, 1)
+	})
+
+	t.Run("ReusedChallenge", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestOAuthServer(t, mockStore)
+
+		user := mfaUser(srv)
+		mockLogin(mockStore, user)
+
+		challenge, err := srv.issuer.CreateMFAChallenge(user.ID, "", "", "")
+		require.NoError(t, err)
+
+		code, err := totp.GenerateCode(key.Secret(), time.Now().Add(-30*time.Second))
+		require.NoError(t, err)
+
+		rep := loginMFA(srv, &api.MFALoginRequest{MFAToken: challenge, Code: code})
+		require.Equal(t, http.StatusOK, rep.code)
+
+		// A completed challenge cannot be used to log in again with another valid code.
+		code, err = totp.GenerateCode(key.Secret(), time.Now())
+		require.NoError(t, err)
+
+		rep = loginMFA(srv, &api.MFALoginRequest{MFAToken: challenge, Code: code})
+		require.Equal(t, http.StatusUnauthorized, rep.code)
+		require.Contains(t, string(rep.body), errors.ErrMFAChallengeFailed.Error())
+		mockStore.AssertCalls(t, mock.CreateRefreshToken, 1)
+	})
+
+	t.Run("ReusedChallengeRecoveryCode", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestOAuthServer(t, mockStore)
+
+		codes, hashes, err := auth.GenerateRecoveryCodes()
+		require.NoError(t, err)
+
+		user := mfaUser(srv)
+		user.EnableTOTP(hashes)
+		mockLogin(mockStore, user)
+		mockStore.OnUpdateMFA = func(context.Context, *models.User) error { return nil }
+
+		challenge, err := srv.issuer.CreateMFAChallenge(user.ID, "", "", "")
+		require.NoError(t, err)
+
+		rep := loginMFA(srv, &api.MFALoginRequest{MFAToken: challenge, RecoveryCode: codes[0]})
+		require.Equal(t, http.StatusOK, rep.code)
+
+		// A replayed challenge is rejected without using up another recovery code.
+		rep = loginMFA(srv, &api.MFALoginRequest{MFAToken: challenge, RecoveryCode: codes[1]})
+		require.Equal(t, http.StatusUnauthorized, rep.code)
+		require.Contains(t, string(rep.body), errors.ErrMFAChallengeFailed.Error())
+		require.Contains(t, user.RecoveryCodes, auth.HashRecoveryCode(codes[1]))
+		require.Len(t, user.RecoveryCodes, len(hashes)-1)
+		mockStore.AssertCalls(t, mock.UpdateMFA, 1)
+		mockStore.AssertCalls(t, mock.CreateRefreshToken, 1)
+	})
+
+	t.Run("InvalidCode", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestOAuthServer(t, mockStore)
+
+		user := mfaUser(srv)
+		mockLogin(mockStore, user)
+
+		challenge, err := srv.issuer.CreateMFAChallenge(user.ID, "", "", "")
+		require.NoError(t, err)
+
+		rep := loginMFA(srv, &api.MFALoginRequest{MFAToken: challenge, Code: "000000"})
+		require.Equal(t, http.StatusUnauthorized, rep.code)
+		require.Contains(t, string(rep.body), errors.ErrInvalidMFACode.Error())
+		mockStore.AssertCalls(t, mock.CreateRefreshToken, 0)
+		mockStore.AssertCalls(t, mock.UpdateTOTPStep, 0)
+		mockStore.AssertCalls(t, mock.ConsumeToken, 0)
+	})
+
+	t.Run("Lockout", func(t *testing.T) {
+		newLockoutServer := func(t *testing.T, mockStore *mock.Store, failed int64, locked bool) (*Server, *models.User) {
+			srv := newTestOAuthServer(t, mockStore)
+			srv.conf.Auth.LockoutThreshold = 3
+			srv.conf.Auth.LockoutDuration = 5 * time.Minute
+			srv.conf.Auth.LockoutMaxDuration = time.Hour
+
+			user := mfaUser(srv)
+			mockLogin(mockStore, user)
+
+			mockStore.OnRetrieveLockout = func(_ context.Context, kind, identifier string) (*models.Lockout, error) {
+				require.Equal(t, models.LockoutUser, kind)
+				require.Equal(t, "jane@example.com", identifier)
+				if failed == 0 {
+					return nil, errors.ErrNotFound
+				}
+
+				lockout := &models.Lockout{Kind: kind, Identifier: identifier, FailedAttempts: failed}
+				if locked {
+					lockout.LockedUntil = sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true}
+				}
+				return lockout, nil
+			}
+			mockStore.OnRecordFailedAttempt = func(_ context.Context, kind, identifier string, _ models.LockoutPolicy) (*models.Lockout, error) {
+				return &models.Lockout{Kind: kind, Identifier: identifier, FailedAttempts: failed + 1}, nil
+			}
+			mockStore.OnResetLockout = func(context.Context, string, string) error { return nil }
+			return srv, user
+		}
+
+		t.Run("LockedOut", func(t *testing.T) {
+			mockStore := openMockStore(t)
+			defer mockStore.Close()
+			srv, user := newLockoutServer(t, mockStore, 3, true)
+
+			challenge, err := srv.issuer.CreateMFAChallenge(user.ID, "", "", "")
+			require.NoError(t, err)
+
+			code, err := totp.GenerateCode(key.Secret(), time.Now())
+			require.NoError(t, err)
+
+			// Even the correct code must be rejected without checking it.
+			rep := loginMFA(srv, &api.MFALoginRequest{MFAToken: challenge, Code: code})
+			require.Equal(t, http.StatusTooManyRequests, rep.code)
+			mockStore.AssertCalls(t, mock.UpdateTOTPStep, 0)
+			mockStore.AssertCalls(t, mock.CreateRefreshToken, 0)
+		})
+
+		t.Run("WrongCode", func(t *testing.T) {
+			mockStore := openMockStore(t)
+			defer mockStore.Close()
+			srv, user := newLockoutServer(t, mockStore, 0, false)
+
+			challenge, err := srv.issuer.CreateMFAChallenge(user.ID, "", "", "")
+			require.NoError(t, err)
+
+			rep := loginMFA(srv, &api.MFALoginRequest{MFAToken: challenge, Code: "000000"})
+			require.Equal(t, http.StatusUnauthorized, rep.code)
+			mockStore.AssertCalls(t, mock.RecordFailedAttempt, 1)
+			mockStore.AssertCalls(t, mock.ResetLockout, 0)
+		})
+
+		t.Run("WrongRecoveryCode", func(t *testing.T) {
+			mockStore := openMockStore(t)
+			defer mockStore.Close()
+			srv, user := newLockoutServer(t, mockStore, 1, false)
+
+			challenge, err := srv.issuer.CreateMFAChallenge(user.ID, "", "", "")
+			require.NoError(t, err)
+
+			rep := loginMFA(srv, &api.MFALoginRequest{MFAToken: challenge, RecoveryCode: "abcde-fghij"})
+			require.Equal(t, http.StatusUnauthorized, rep.code)
+			mockStore.AssertCalls(t, mock.RecordFailedAttempt, 1)
+			mockStore.AssertCalls(t, mock.ResetLockout, 0)
+		})
+
+		t.Run("PasswordDoesNotReset", func(t *testing.T) {
+			mockStore := openMockStore(t)
+			defer mockStore.Close()
+			srv, _ := newLockoutServer(t, mockStore, 2, false)
+
+			body, err := json.Marshal(&api.LoginRequest{Email: "jane@example.com", Password: password})
+			require.NoError(t, err)
+
+			// The failed attempts are kept until the second factor has been verified.
+			w, c := requestContext(t, http.MethodPost, "/v1/login", body, nil)
+			c.Request.Header.Set("Content-Type", "application/json")
+			srv.Login(c)
+			require.Equal(t, http.StatusOK, w.Code)
+			mockStore.AssertCalls(t, mock.ResetLockout, 0)
+		})
+
+		t.Run("SuccessResets", func(t *testing.T) {
+			mockStore := openMockStore(t)
+			defer mockStore.Close()
+			srv, user := newLockoutServer(t, mockStore, 2, false)
+
+			challenge, err := srv.issuer.CreateMFAChallenge(user.ID, "", "", "")
+			require.NoError(t, err)
+
+			code, err := totp.GenerateCode(key.Secret(), time.Now())
+			require.NoError(t, err)
+
+			rep := loginMFA(srv, &api.MFALoginRequest{MFAToken: challenge, Code: code})
+			require.Equal(t, http.StatusOK, rep.code)
+			mockStore.AssertCalls(t, mock.ResetLockout, 1)
+			mockStore.AssertCalls(t, mock.RecordFailedAttempt, 0)
+		})
+	})
+
+	t.Run("InvalidChallenge", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestOAuthServer(t, mockStore)
+
+		// An access token must not be accepted in place of an MFA challenge.
+		claims := &gimauth.Claims{}
+		claims.SetSubjectID(gimauth.SubjectUser, ulid.MakeSecure())
+		accessToken, _, err := srv.issuer.CreateTokens(claims, ulid.Zero)
+		require.NoError(t, err)
+
+		rep := loginMFA(srv, &api.MFALoginRequest{MFAToken: accessToken, Code: "123456"})
+		require.Equal(t, http.StatusUnauthorized, rep.code)
+		mockStore.AssertCalls(t, mock.RetrieveUser, 0)
+	})
+
+	t.Run("BadRequest", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestOAuthServer(t, mockStore)
+
+		rep := loginMFA(srv, &api.MFALoginRequest{MFAToken: "token", Code: "123456", RecoveryCode: "abcde-fghij"})
+		require.Equal(t, http.StatusBadRequest, rep.code)
+	})
+}
+
+func TestTOTPEnrollment(t *testing.T) {
+	userID := ulid.MakeSecure()
+	params := gin.Params{{Key: "userID", Value: userID.String()}}
+
+	claims := &gimauth.Claims{}
+	claims.SetSubjectID(gimauth.SubjectUser, userID)
+
+	user := &models.User{BaseModel: tidal.BaseModel{ID: userID}, Email: "jane@example.com"}
+
+	mockStore := openMockStore(t)
+	defer mockStore.Close()
+	srv := newTestOAuthServer(t, mockStore)
+
+	mockStore.OnRetrieveUser = func(context.Context, ulid.ULID) (*models.User, error) {
+		return user, nil
+	}
+	mockStore.OnUpdateMFA = func(_ context.Context, in *models.User) error {
+		user = in
+		return nil
+	}
+	mockStore.OnUpdateTOTPStep = func(context.Context, ulid.ULID, int64) error {
+		return nil
+	}
+
+	// Enroll the user and ensure the secret is stored but MFA is not yet enabled.
+	w, c := requestContext(t, http.MethodPost, "/v1/users/"+userID.String()+"/mfa/totp", nil, params)
+	gimlet.Set(c, gimlet.KeyUserClaims, claims)
+	srv.EnrollTOTP(c)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	enrollment := &api.TOTPEnrollment{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), enrollment))
+	require.NotEmpty(t, enrollment.Secret)
+	require.Contains(t, enrollment.URL, "otpauth://totp/")
+	require.False(t, user.MFAEnabled(), "mfa should not be enabled until confirmed")
+
+	// The secret must be sealed with the key encryption key before it is stored.
+	require.NotEqual(t, enrollment.Secret, user.TOTPSecret.String)
+	secret, err := srv.secrets.OpenSecret(userID, user.TOTPSecret.String)
+	require.NoError(t, err)
+	require.Equal(t, enrollment.Secret, secret)
+
+	// An invalid code should not confirm the enrollment.
+	w, c = requestContext(t, http.MethodPost, "/v1/users/"+userID.String()+"/mfa/totp/confirm", mfaCodeBody(t, "000000", ""), params)
+	c.Request.Header.Set("Content-Type", "application/json")
+	gimlet.Set(c, gimlet.KeyUserClaims, claims)
+	srv.ConfirmTOTP(c)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.False(t, user.MFAEnabled())
+
+	// Confirm the enrollment with a valid code.
+	code, err := totp.GenerateCode(enrollment.Secret, time.Now())
+	require.NoError(t, err)
+
+	w, c = requestContext(t, http.MethodPost, "/v1/users/"+userID.String()+"/mfa/totp/confirm", mfaCodeBody(t, code, ""), params)
+	c.Request.Header.Set("Content-Type", "application/json")
+	gimlet.Set(c, gimlet.KeyUserClaims, claims)
+	srv.ConfirmTOTP(c)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	codes := &api.RecoveryCodes{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), codes))
+	require.Len(t, codes.Codes, 10)
+	require.True(t, user.MFAEnabled())
+	require.Len(t, user.RecoveryCodes, 10)
+	mockStore.AssertCalls(t, mock.UpdateTOTPStep, 1)
+
+	// Enrolling again should conflict now that MFA is enabled.
+	w, c = requestContext(t, http.MethodPost, "/v1/users/"+userID.String()+"/mfa/totp", nil, params)
+	gimlet.Set(c, gimlet.KeyUserClaims, claims)
+	srv.EnrollTOTP(c)
+	require.Equal(t, http.StatusConflict, w.Code)
+
+	// Disable MFA using a recovery code.
+	w, c = requestContext(t, http.MethodDelete, "/v1/users/"+userID.String()+"/mfa/totp", mfaCodeBody(t, "", codes.Codes[0]), params)
+	c.Request.Header.Set("Content-Type", "application/json")
+	gimlet.Set(c, gimlet.KeyUserClaims, claims)
+	srv.DisableTOTP(c)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.False(t, user.MFAEnabled())
+	require.Empty(t, user.RecoveryCodes)
+}
+
+func TestMFAOtherUser(t *testing.T) {
+	userID := ulid.MakeSecure()
+	params := gin.Params{{Key: "userID", Value: userID.String()}}
+
+	// Even administrators cannot manage another user's second factor.
+	claims := &gimauth.Claims{Permissions: []string{"users:manage"}}
+	claims.SetSubjectID(gimauth.SubjectUser, ulid.MakeSecure())
+
+	mockStore := openMockStore(t)
+	defer mockStore.Close()
+	srv := newTestOAuthServer(t, mockStore)
+
+	w, c := requestContext(t, http.MethodPost, "/v1/users/"+userID.String()+"/mfa/totp", nil, params)
+	gimlet.Set(c, gimlet.KeyUserClaims, claims)
+	srv.EnrollTOTP(c)
+	require.Equal(t, http.StatusForbidden, w.Code)
+
+	w, c = requestContext(t, http.MethodDelete, "/v1/users/"+userID.String()+"/mfa/totp", mfaCodeBody(t, "123456", ""), params)
+	c.Request.Header.Set("Content-Type", "application/json")
+	gimlet.Set(c, gimlet.KeyUserClaims, claims)
+	srv.DisableTOTP(c)
+	require.Equal(t, http.StatusForbidden, w.Code)
+
+	mockStore.AssertCalls(t, mock.RetrieveUser, 0)
+	mockStore.AssertCalls(t, mock.UpdateMFA, 0)
+}
+
+type httpResponse struct {
+	code int
+	body []byte
+}
+
+func mfaCodeBody(t *testing.T, code, recoveryCode string) []byte {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	require.NoError(t, json.NewEncoder(buf).Encode(&api.MFACodeRequest{Code: code, RecoveryCode: recoveryCode}))
+	return buf.Bytes()
+}
@@ -0,0 +1,721 @@
+package server
+
+import (
+	"database/sql"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/golang-jwt/jwt/v5"
+	gimlet "go.rtnl.ai/gimlet/auth"
+	"go.rtnl.ai/ulid"
+
+	"go.rtnl.ai/quarterdeck/pkg/api/v1"
+	"go.rtnl.ai/quarterdeck/pkg/auth"
+	"go.rtnl.ai/quarterdeck/pkg/auth/passwords"
+	"go.rtnl.ai/quarterdeck/pkg/enum"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/txn"
+	"go.rtnl.ai/tidal"
+)
+
+// Authorization codes are short lived; RFC 6749 recommends a maximum of 10 minutes.
+const authorizationCodeTTL = 10 * time.Minute
+
+// Authorize is the OAuth2 authorization endpoint for the authorization code flow. The
+// user must already be logged in to Quarterdeck (the authenticate middleware will send
+// them to the login page otherwise). If the client and redirect URI are valid, a one
+// time authorization code is issued and the user is redirected back to the client with
+// the code and state so that the client can exchange the code at the token endpoint.
+// See: https://datatracker.ietf.org/doc/html/rfc6749#section-4.1
+func (s *Server) Authorize(c *gin.Context) {
+	var (
+		err     error
+		in      *api.AuthorizeRequest
+		client  *models.OIDCClient
+		claims  *gimlet.Claims
+		sub     gimlet.SubjectType
+		userID  ulid.ULID
+		code    string
+		authErr *api.OAuthError
+	)
+
+	in = &api.AuthorizeRequest{}
+	if err = c.BindQuery(in); err != nil {
+		s.BadRequest(c, errors.New("could not parse authorization request"))
+		return
+	}
+
+	// If the client or redirect URI are invalid the user must not be redirected back to
+	// the client; instead an error page is displayed to the user.
+	// See: https://datatracker.ietf.org/doc/html/rfc6749#section-4.1.2.1
+	if client, err = s.store.RetrieveOIDCClientByClientID(c.Request.Context(), in.ClientID); err != nil {
+		if errors.Is(err, errors.ErrNotFound) || errors.Is(err, errors.ErrMissingID) {
+			s.BadRequest(c, errors.ErrUnknownClient)
+			return
+		}
+
+		s.Error(c, err)
+		return
+	}
+
+	if in.RedirectURI == "" || !slices.Contains(client.RedirectURIs, in.RedirectURI) {
+		s.BadRequest(c, errors.ErrInvalidRedirectURI)
+		return
+	}
+
+	// All other errors are returned to the client via the redirect URI.
+	if authErr = in.Validate(); authErr != nil {
+		s.authorizeRedirect(c, in, authErr.Params())
+		return
+	}
+
+	if claims, err = gimlet.GetClaims(c); err != nil {
+		c.Error(err)
+		s.authorizeRedirect(c, in, (&api.OAuthError{Code: api.OAuthAccessDenied}).Params())
+		return
+	}
+
+	// Only users can authorize OIDC clients; API keys must use their own credentials.
+	if sub, userID, err = claims.SubjectID(); err != nil || sub != gimlet.SubjectUser {
+		s.authorizeRedirect(c, in, (&api.OAuthError{Code: api.OAuthAccessDenied, Description: "only users can authorize clients"}).Params())
+		return
+	}
+
+	if code, err = auth.AuthorizationCode(); err != nil {
+		c.Error(err)
+		s.authorizeRedirect(c, in, (&api.OAuthError{Code: api.OAuthServerError}).Params())
+		return
+	}
+
+	// Only the hash of the code is stored so that a database leak cannot be used to
+	// exchange authorization codes for access tokens.
+	record := &models.AuthorizationCode{
+		Code:                auth.HashOpaqueToken(code),
+		ClientID:            client.ClientID,
+		UserID:              userID,
+		RedirectURI:         in.RedirectURI,
+		Scope:               sql.NullString{String: in.Scope, Valid: in.Scope != ""},
+		Nonce:               sql.NullString{String: in.Nonce, Valid: in.Nonce != ""},
+		CodeChallenge:       sql.NullString{String: in.CodeChallenge, Valid: in.CodeChallenge != ""},
+		CodeChallengeMethod: sql.NullString{String: in.CodeChallengeMethod, Valid: in.CodeChallengeMethod != ""},
+		Expiration:          time.Now().Add(authorizationCodeTTL),
+	}
+
+	if _, err = s.store.CreateAuthorizationCode(c.Request.Context(), record); err != nil {
+		c.Error(err)
+		s.authorizeRedirect(c, in, (&api.OAuthError{Code: api.OAuthServerError}).Params())
+		return
+	}
+
+	s.authorizeRedirect(c, in, url.Values{"code": []string{code}})
+}
+
+// Redirects the user back to the client's redirect URI with the specified params.
+func (s *Server) authorizeRedirect(c *gin.Context, in *api.AuthorizeRequest, params url.Values) {
+	location, err := in.Redirect(params)
+	if err != nil {
+		s.BadRequest(c, errors.ErrInvalidRedirectURI)
+		return
+	}
+	c.Redirect(http.StatusFound, location)
+}
+
+// Token is the OAuth2 token endpoint. Clients may either exchange an authorization
+// code for access, refresh, and ID tokens or, in the case of API keys, use their client
+// credentials to obtain an access token directly. Client credentials may be supplied
+// either with HTTP Basic auth or in the form body.
+// See: https://datatracker.ietf.org/doc/html/rfc6749#section-3.2
+func (s *Server) Token(c *gin.Context) {
+	var (
+		err     error
+		in      *api.TokenRequest
+		authErr *api.OAuthError
+	)
+
+	// The token endpoint response must never be cached.
+	// See: https://datatracker.ietf.org/doc/html/rfc6749#section-5.1
+	c.Header(HeaderCacheControl, "no-store")
+	c.Header("Pragma", "no-cache")
+
+	in = &api.TokenRequest{}
+	if err = c.ShouldBindWith(in, binding.Form); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadRequest, &api.OAuthError{Code: api.OAuthInvalidRequest, Description: "could not parse token request"})
+		return
+	}
+
+	if authErr = clientBasicAuth(c, &in.ClientID, &in.ClientSecret); authErr != nil {
+		c.JSON(http.StatusBadRequest, authErr)
+		return
+	}
+
+	if authErr = in.Validate(); authErr != nil {
+		c.JSON(authErr.StatusCode(), authErr)
+		return
+	}
+
+	switch in.GrantType {
+	case api.GrantTypeAuthorizationCode:
+		s.authorizationCodeGrant(c, in)
+	case api.GrantTypeClientCredentials:
+		s.clientCredentialsGrant(c, in)
+	default:
+		c.JSON(http.StatusBadRequest, &api.OAuthError{Code: api.OAuthUnsupportedGrantType})
+	}
+}
+
+// Exchanges an authorization code for tokens. OIDC clients are confidential clients so
+// they must always authenticate with their client secret, even if the code was issued
+// with a PKCE challenge, in which case the code verifier must also be sent.
+// Authorization codes can only be used once.
+// See: https://datatracker.ietf.org/doc/html/rfc6749#section-4.1.3
+func (s *Server) authorizationCodeGrant(c *gin.Context, in *api.TokenRequest) {
+	var (
+		err     error
+		out     *api.TokenReply
+		client  *models.OIDCClient
+		code    *models.AuthorizationCode
+		user    *models.User
+		claims  *gimlet.Claims
+		lockout *models.Lockout
+		ok      bool
+	)
+
+	// Authenticate the client; PKCE does not replace client authentication.
+	// See: https://datatracker.ietf.org/doc/html/rfc6749#section-3.2.1
+	if in.ClientSecret == "" {
+		c.JSON(http.StatusUnauthorized, &api.OAuthError{Code: api.OAuthInvalidClient, Description: "client authentication is required"})
+		return
+	}
+
+	if lockout, ok = s.checkClientLockout(c, in.ClientID); !ok {
+		return
+	}
+
+	if client, err = s.store.RetrieveOIDCClientByClientID(c.Request.Context(), in.ClientID); err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			c.JSON(http.StatusUnauthorized, &api.OAuthError{Code: api.OAuthInvalidClient})
+			return
+		}
+
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, &api.OAuthError{Code: api.OAuthServerError})
+		return
+	}
+
+	var verified bool
+	if verified, err = passwords.VerifyDerivedKey(client.Secret, in.ClientSecret); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, &api.OAuthError{Code: api.OAuthServerError})
+		return
+	}
+
+	if !verified {
+		s.recordFailedAttempt(c, models.LockoutClient, in.ClientID, nil)
+		c.JSON(http.StatusUnauthorized, &api.OAuthError{Code: api.OAuthInvalidClient})
+		return
+	}
+
+	s.resetLockout(c, lockout)
+
+	// Retrieve and delete the code in a single transaction so that it can only be used
+	// once, even if the remainder of the exchange fails.
+	err = s.store.WithTx(c.Request.Context(), nil, func(tx txn.Tx) (err error) {
+		if code, err = tx.RetrieveAuthorizationCode(auth.HashOpaqueToken(in.Code)); err != nil {
+			return err
+		}
+		return tx.DeleteAuthorizationCode(code.ID)
+	})
+
+	if err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			c.JSON(http.StatusBadRequest, &api.OAuthError{Code: api.OAuthInvalidGrant, Description: "authorization code is invalid"})
+			return
+		}
+
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, &api.OAuthError{Code: api.OAuthServerError})
+		return
+	}
+
+	// Validate the code against the token request
+	if code.IsExpired() {
+		c.JSON(http.StatusBadRequest, &api.OAuthError{Code: api.OAuthInvalidGrant, Description: "authorization code has expired"})
+		return
+	}
+
+	if code.ClientID != client.ClientID {
+		c.JSON(http.StatusBadRequest, &api.OAuthError{Code: api.OAuthInvalidGrant, Description: "authorization code was issued to another client"})
+		return
+	}
+
+	if code.RedirectURI != in.RedirectURI {
+		c.JSON(http.StatusBadRequest, &api.OAuthError{Code: api.OAuthInvalidGrant, Description: "redirect_uri does not match authorization request"})
+		return
+	}
+
+	// If the client sent a PKCE challenge with the authorization request then the code
+	// verifier must match it in addition to the client authentication above.
+	if code.CodeChallenge.Valid {
+		if err = auth.VerifyCodeChallenge(code.CodeChallengeMethod.String, code.CodeChallenge.String, in.CodeVerifier); err != nil {
+			c.JSON(http.StatusBadRequest, &api.OAuthError{Code: api.OAuthInvalidGrant, Description: err.Error()})
+			return
+		}
+	}
+
+	// Issue tokens for the user that authorized the client.
+	if user, err = s.store.RetrieveUser(c.Request.Context(), code.UserID); err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			c.JSON(http.StatusBadRequest, &api.OAuthError{Code: api.OAuthInvalidGrant, Description: "user no longer exists"})
+			return
+		}
+
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, &api.OAuthError{Code: api.OAuthServerError})
+		return
+	}
+
+	// The user may have been suspended or deleted since they authorized the client.
+	if err = user.CheckStatus(); err != nil {
+		c.JSON(http.StatusBadRequest, &api.OAuthError{Code: api.OAuthInvalidGrant, Description: "user is not active"})
+		return
+	}
+
+	// Clients that belong to an organization can only be authorized by its members;
+	// otherwise the user is logged into the first organization they joined (if any).
+	if user, err = s.organizationMember(c.Request.Context(), user, client.OrgID.ULID); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, &api.OAuthError{Code: api.OAuthServerError})
+		return
+	}
+
+	if client.OrgID.Valid && user.OrgID != client.OrgID.ULID {
+		c.JSON(http.StatusBadRequest, &api.OAuthError{Code: api.OAuthInvalidGrant, Description: "user is not a member of the client organization"})
+		return
+	}
+
+	claims = user.Claims()
+	claims.ClientID = client.ClientID
+
+	out = &api.TokenReply{
+		TokenType: api.TokenTypeBearer,
+		ExpiresIn: int64(s.conf.Auth.AccessTokenTTL.Seconds()),
+		Scope:     code.Scope.String,
+	}
+
+	if out.AccessToken, out.RefreshToken, err = s.issueTokens(c, claims, user.OrgID, ulid.Zero); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, &api.OAuthError{Code: api.OAuthServerError})
+		return
+	}
+
+	if code.HasScope(api.ScopeOpenID) {
+		opts := auth.IDTokenOptions{
+			ClientID:      client.ClientID,
+			Nonce:         code.Nonce.String,
+			AuthTime:      user.LastLogin.Time,
+			EmailVerified: user.EmailVerified,
+		}
+
+		if out.IDToken, err = s.issuer.CreateIDToken(claims, out.AccessToken, opts); err != nil {
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, &api.OAuthError{Code: api.OAuthServerError})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, out)
+}
+
+// Issues an access token directly to an API key that authenticates with its client ID
+// and secret. The scope of the token is derived from the permissions of the API key;
+// if a scope is requested it must be a subset of those permissions and the access
+// token is restricted to the requested permissions. No refresh token is issued since
+// the client can simply request a new access token with its credentials.
+// See: https://datatracker.ietf.org/doc/html/rfc6749#section-4.4
+func (s *Server) clientCredentialsGrant(c *gin.Context, in *api.TokenRequest) {
+	var (
+		err      error
+		out      *api.TokenReply
+		apiKey   *models.APIKey
+		claims   *gimlet.Claims
+		lockout  *models.Lockout
+		verified bool
+		ok       bool
+	)
+
+	if lockout, ok = s.checkClientLockout(c, in.ClientID); !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	if apiKey, err = s.store.RetrieveAPIKeyByClientID(ctx, in.ClientID); err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			s.recordFailedAttempt(c, models.LockoutClient, in.ClientID, nil)
+			c.JSON(http.StatusUnauthorized, &api.OAuthError{Code: api.OAuthInvalidClient})
+			return
+		}
+
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, &api.OAuthError{Code: api.OAuthServerError})
+		return
+	}
+
+	if verified, err = passwords.VerifyDerivedKey(apiKey.Secret, in.ClientSecret); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, &api.OAuthError{Code: api.OAuthServerError})
+		return
+	}
+
+	if !verified {
+		s.recordFailedAttempt(c, models.LockoutClient, in.ClientID, nil)
+		c.JSON(http.StatusUnauthorized, &api.OAuthError{Code: api.OAuthInvalidClient})
+		return
+	}
+
+	s.resetLockout(c, lockout)
+	if apiKey.Status() == enum.APIKeyStatusRevoked {
+		c.JSON(http.StatusUnauthorized, &api.OAuthError{Code: api.OAuthInvalidClient})
+		return
+	}
+
+	// Restrict the token to the requested scopes, which must be held by the key.
+	claims = apiKey.Claims()
+	if scopes := in.Scopes(); len(scopes) > 0 {
+		for _, scope := range scopes {
+			if !slices.Contains(claims.Permissions, scope) {
+				c.JSON(http.StatusBadRequest, &api.OAuthError{Code: api.OAuthInvalidScope, Description: "requested scope exceeds the permissions of the client"})
+				return
+			}
+		}
+		claims.Permissions = scopes
+	}
+
+	if err = s.store.UpdateLastSeen(ctx, apiKey.ID, time.Now()); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, &api.OAuthError{Code: api.OAuthServerError})
+		return
+	}
+
+	out = &api.TokenReply{
+		TokenType: api.TokenTypeBearer,
+		ExpiresIn: int64(s.conf.Auth.AccessTokenTTL.Seconds()),
+		Scope:     strings.Join(claims.Permissions, " "),
+	}
+
+	var accessToken *jwt.Token
+	if accessToken, err = s.issuer.CreateAccessToken(claims, apiKey.OrgID.ULID); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, &api.OAuthError{Code: api.OAuthServerError})
+		return
+	}
+
+	if out.AccessToken, err = s.issuer.Sign(accessToken); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, &api.OAuthError{Code: api.OAuthServerError})
+		return
+	}
+
+	c.JSON(http.StatusOK, out)
+}
+
+// Introspect is the OAuth2 token introspection endpoint that allows resource servers to
+// determine if a token is still active. In addition to verifying the signature and the
+// claims of the token (including checking the denylist), the user or API key that the
+// token was issued to must still exist and must not have been revoked.
+// See: https://datatracker.ietf.org/doc/html/rfc7662
+func (s *Server) Introspect(c *gin.Context) {
+	var (
+		err     error
+		in      *api.IntrospectionRequest
+		claims  *gimlet.Claims
+		authErr *api.OAuthError
+	)
+
+	c.Header(HeaderCacheControl, "no-store")
+
+	in = &api.IntrospectionRequest{}
+	if err = c.ShouldBindWith(in, binding.Form); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadRequest, &api.OAuthError{Code: api.OAuthInvalidRequest, Description: "could not parse introspection request"})
+		return
+	}
+
+	if authErr = clientBasicAuth(c, &in.ClientID, &in.ClientSecret); authErr != nil {
+		c.JSON(http.StatusBadRequest, authErr)
+		return
+	}
+
+	if authErr = in.Validate(); authErr != nil {
+		c.JSON(authErr.StatusCode(), authErr)
+		return
+	}
+
+	if _, ok := s.authenticateClient(c, in.ClientID, in.ClientSecret); !ok {
+		return
+	}
+
+	// Invalid, expired, or revoked tokens are simply reported as inactive.
+	if claims, err = s.issuer.Verify(in.Token); err != nil {
+		c.JSON(http.StatusOK, &api.IntrospectionReply{Active: false})
+		return
+	}
+
+	var username string
+	if username, err = s.activeSubject(c, claims); err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			c.JSON(http.StatusOK, &api.IntrospectionReply{Active: false})
+			return
+		}
+
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, &api.OAuthError{Code: api.OAuthServerError})
+		return
+	}
+
+	out := &api.IntrospectionReply{
+		Active:    true,
+		Scope:     strings.Join(claims.Permissions, " "),
+		ClientID:  claims.ClientID,
+		Username:  username,
+		TokenType: "access_token",
+		Subject:   claims.Subject,
+		Audience:  claims.Audience,
+		Issuer:    claims.Issuer,
+		JWTID:     claims.ID,
+	}
+
+	if slices.Contains(claims.Audience, s.issuer.RefreshAudience()) {
+		out.TokenType = "refresh_token"
+	}
+
+	if claims.ExpiresAt != nil {
+		out.ExpiresAt = claims.ExpiresAt.Unix()
+	}
+
+	if claims.IssuedAt != nil {
+		out.IssuedAt = claims.IssuedAt.Unix()
+	}
+
+	if claims.NotBefore != nil {
+		out.NotBefore = claims.NotBefore.Unix()
+	}
+
+	c.JSON(http.StatusOK, out)
+}
+
+// Revoke is the OAuth2 token revocation endpoint. The jti of the token is added to the
+// denylist so that it is rejected by the issuer until it expires. Because access and
+// refresh tokens that are issued together share a jti, revoking either token revokes
+// both of them. Clients can only revoke tokens that were issued to them; first-party
+// tokens issued by the login endpoint do not have a client ID and can be revoked by
+// whoever holds them, with or without client credentials, since possession of the
+// token is all that is required to use it.
+// See: https://datatracker.ietf.org/doc/html/rfc7009
+func (s *Server) Revoke(c *gin.Context) {
+	var (
+		err     error
+		in      *api.RevocationRequest
+		claims  *gimlet.Claims
+		client  *oauthClient
+		jti     ulid.ULID
+		authErr *api.OAuthError
+		ok      bool
+	)
+
+	in = &api.RevocationRequest{}
+	if err = c.ShouldBindWith(in, binding.Form); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadRequest, &api.OAuthError{Code: api.OAuthInvalidRequest, Description: "could not parse revocation request"})
+		return
+	}
+
+	if authErr = clientBasicAuth(c, &in.ClientID, &in.ClientSecret); authErr != nil {
+		c.JSON(http.StatusBadRequest, authErr)
+		return
+	}
+
+	if authErr = in.Validate(); authErr != nil {
+		c.JSON(authErr.StatusCode(), authErr)
+		return
+	}
+
+	if in.ClientID != "" {
+		if client, ok = s.authenticateClient(c, in.ClientID, in.ClientSecret); !ok {
+			return
+		}
+	}
+
+	// Invalid tokens do not cause an error since the purpose of the revocation request
+	// (invalidating the token) has already been achieved.
+	// See: https://datatracker.ietf.org/doc/html/rfc7009#section-2.2
+	if claims, err = s.issuer.Parse(in.Token); err != nil {
+		c.JSON(http.StatusOK, api.Reply{Success: true})
+		return
+	}
+
+	// Tokens issued to a client can only be revoked by that client.
+	if claims.ClientID != "" {
+		if client == nil {
+			c.JSON(http.StatusUnauthorized, &api.OAuthError{Code: api.OAuthInvalidClient, Description: "client authentication is required"})
+			return
+		}
+
+		if claims.ClientID != client.ClientID {
+			c.JSON(http.StatusBadRequest, &api.OAuthError{Code: api.OAuthUnauthorizedClient, Description: "token was not issued to this client"})
+			return
+		}
+	}
+
+	if jti, err = ulid.Parse(claims.ID); err != nil {
+		c.JSON(http.StatusOK, api.Reply{Success: true})
+		return
+	}
+
+	// The denylist entry must outlive both the access and refresh token.
+	revoked := &models.RevokedToken{BaseModel: tidal.BaseModel{ID: jti}, Expiration: time.Now().Add(s.conf.Auth.RefreshTokenTTL)}
+	if claims.IssuedAt != nil {
+		revoked.Expiration = claims.IssuedAt.Add(s.conf.Auth.RefreshTokenTTL)
+	}
+
+	if claims.ExpiresAt != nil && claims.ExpiresAt.After(revoked.Expiration) {
+		revoked.Expiration = claims.ExpiresAt.Time
+	}
+
+	if err = s.store.RevokeToken(c.Request.Context(), revoked); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, &api.OAuthError{Code: api.OAuthServerError})
+		return
+	}
+
+	c.JSON(http.StatusOK, api.Reply{Success: true})
+}
+
+// Client credentials may be supplied using HTTP Basic authentication instead of in the
+// form body, but a client must not use more than one authentication method.
+// See: https://datatracker.ietf.org/doc/html/rfc6749#section-2.3.1
+func clientBasicAuth(c *gin.Context, clientID, clientSecret *string) *api.OAuthError {
+	if basicID, basicSecret, ok := c.Request.BasicAuth(); ok {
+		if *clientID != "" && *clientSecret != "" {
+			return &api.OAuthError{Code: api.OAuthInvalidRequest, Description: "multiple client authentication methods used"}
+		}
+
+		*clientID, _ = url.QueryUnescape(basicID)
+		*clientSecret, _ = url.QueryUnescape(basicSecret)
+	}
+	return nil
+}
+
+// oauthClient is either an OIDC client or an API key that has authenticated with its
+// client credentials to the introspection or revocation endpoints.
+type oauthClient struct {
+	ClientID string
+	OIDC     *models.OIDCClient
+	APIKey   *models.APIKey
+}
+
+// Authenticates the caller as either an OIDC client or an API key. If the client
+// cannot be authenticated then an error response is written and false is returned.
+func (s *Server) authenticateClient(c *gin.Context, clientID, clientSecret string) (client *oauthClient, ok bool) {
+	var (
+		err      error
+		secret   string
+		verified bool
+		lockout  *models.Lockout
+	)
+
+	if lockout, ok = s.checkClientLockout(c, clientID); !ok {
+		return nil, false
+	}
+
+	ctx := c.Request.Context()
+	client = &oauthClient{ClientID: clientID}
+
+	if client.OIDC, err = s.store.RetrieveOIDCClientByClientID(ctx, clientID); err == nil {
+		secret = client.OIDC.Secret
+	} else if errors.Is(err, errors.ErrNotFound) {
+		if client.APIKey, err = s.store.RetrieveAPIKeyByClientID(ctx, clientID); err != nil {
+			if errors.Is(err, errors.ErrNotFound) {
+				s.recordFailedAttempt(c, models.LockoutClient, clientID, nil)
+				c.JSON(http.StatusUnauthorized, &api.OAuthError{Code: api.OAuthInvalidClient})
+				return nil, false
+			}
+
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, &api.OAuthError{Code: api.OAuthServerError})
+			return nil, false
+		}
+
+		if client.APIKey.Status() == enum.APIKeyStatusRevoked {
+			c.JSON(http.StatusUnauthorized, &api.OAuthError{Code: api.OAuthInvalidClient})
+			return nil, false
+		}
+		secret = client.APIKey.Secret
+	} else {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, &api.OAuthError{Code: api.OAuthServerError})
+		return nil, false
+	}
+
+	if verified, err = passwords.VerifyDerivedKey(secret, clientSecret); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, &api.OAuthError{Code: api.OAuthServerError})
+		return nil, false
+	}
+
+	if !verified {
+		s.recordFailedAttempt(c, models.LockoutClient, clientID, nil)
+		c.JSON(http.StatusUnauthorized, &api.OAuthError{Code: api.OAuthInvalidClient})
+		return nil, false
+	}
+
+	s.resetLockout(c, lockout)
+	return client, true
+}
+
+// Checks that the user or API key the token was issued to still exists and has not
+// been suspended, deleted, or revoked; returns the username (email) of users for
+// introspection. If the
+// subject is no longer active then ErrNotFound is returned.
+func (s *Server) activeSubject(c *gin.Context, claims *gimlet.Claims) (username string, err error) {
+	var (
+		sub   gimlet.SubjectType
+		subID ulid.ULID
+	)
+
+	if sub, subID, err = claims.SubjectID(); err != nil {
+		return "", errors.ErrNotFound
+	}
+
+	ctx := c.Request.Context()
+	switch sub {
+	case gimlet.SubjectUser:
+		var user *models.User
+		if user, err = s.store.RetrieveUser(ctx, subID); err != nil {
+			return "", err
+		}
+
+		if user.CheckStatus() != nil {
+			return "", errors.ErrNotFound
+		}
+		return user.Email, nil
+	case gimlet.SubjectAPIKey:
+		var apiKey *models.APIKey
+		if apiKey, err = s.store.RetrieveAPIKey(ctx, subID); err != nil {
+			return "", err
+		}
+
+		if apiKey.Status() == enum.APIKeyStatusRevoked {
+			return "", errors.ErrNotFound
+		}
+		return "", nil
+	default:
+		return "", errors.ErrNotFound
+	}
+}
@@ -0,0 +1,616 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/gimlet"
+	"go.rtnl.ai/gimlet/auth"
+	"go.rtnl.ai/quarterdeck/pkg/api/v1"
+	"go.rtnl.ai/quarterdeck/pkg/auth/passwords"
+	"go.rtnl.ai/quarterdeck/pkg/config"
+	"go.rtnl.ai/quarterdeck/pkg/enum"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/mock"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/tidal"
+	"go.rtnl.ai/ulid"
+
+	qdauth "go.rtnl.ai/quarterdeck/pkg/auth"
+)
+
+func TestAuthorize(t *testing.T) {
+	client := &models.OIDCClient{
+		BaseModel:    tidal.BaseModel{ID: ulid.MakeSecure()},
+		ClientName:   "Test",
+		ClientID:     "cid",
+		RedirectURIs: []string{"https://example.com/cb"},
+	}
+
+	onRetrieveClient := func(ctx context.Context, clientID string) (*models.OIDCClient, error) {
+		if clientID == client.ClientID {
+			return client, nil
+		}
+		return nil, errors.ErrNotFound
+	}
+
+	userClaims := func() *auth.Claims {
+		claims := &auth.Claims{}
+		claims.SetSubjectID(auth.SubjectUser, ulid.MakeSecure())
+		return claims
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		// prepare mocks
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		// set mock callbacks
+		var created *models.AuthorizationCode
+		mockStore.OnRetrieveOIDCClientByClientID = onRetrieveClient
+		mockStore.OnCreateAuthorizationCode = func(ctx context.Context, code *models.AuthorizationCode) (*models.AuthorizationCode, error) {
+			created = code
+			return code, nil
+		}
+
+		// build request and context
+		w, c := requestContext(t, http.MethodGet, "/oauth/authorize?response_type=code&client_id=cid&redirect_uri=https%3A%2F%2Fexample.com%2Fcb&scope=openid+email&state=xyz&nonce=abc&code_challenge=E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM&code_challenge_method=S256", nil, nil)
+		gimlet.Set(c, gimlet.KeyUserClaims, userClaims())
+
+		// execute handler
+		srv.Authorize(c)
+
+		// assert response
+		require.Equal(t, http.StatusFound, w.Code)
+		location, err := url.Parse(w.Header().Get("Location"))
+		require.NoError(t, err)
+		require.Equal(t, "example.com", location.Host)
+		require.Equal(t, "xyz", location.Query().Get("state"))
+
+		code := location.Query().Get("code")
+		require.NotEmpty(t, code)
+		mockStore.AssertCalls(t, mock.CreateAuthorizationCode, 1)
+		require.Equal(t, qdauth.HashOpaqueToken(code), created.Code, "expected only the code hash to be stored")
+		require.Equal(t, "cid", created.ClientID)
+		require.Equal(t, "abc", created.Nonce.String)
+		require.Equal(t, "S256", created.CodeChallengeMethod.String)
+	})
+
+	t.Run("UnknownClient", func(t *testing.T) {
+		// prepare mocks
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+		mockStore.OnRetrieveOIDCClientByClientID = onRetrieveClient
+
+		// build request and context
+		w, c := requestContext(t, http.MethodGet, "/oauth/authorize?response_type=code&client_id=unknown&redirect_uri=https%3A%2F%2Fexample.com%2Fcb", nil, nil)
+		gimlet.Set(c, gimlet.KeyUserClaims, userClaims())
+
+		// execute handler
+		srv.Authorize(c)
+
+		// assert response: the user must not be redirected
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		require.Empty(t, w.Header().Get("Location"))
+		reply := parseReply(t, w)
+		require.Equal(t, errors.ErrUnknownClient.Error(), reply.Error)
+	})
+
+	t.Run("UnregisteredRedirect", func(t *testing.T) {
+		// prepare mocks
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+		mockStore.OnRetrieveOIDCClientByClientID = onRetrieveClient
+
+		// build request and context
+		w, c := requestContext(t, http.MethodGet, "/oauth/authorize?response_type=code&client_id=cid&redirect_uri=https%3A%2F%2Fevil.com%2Fcb", nil, nil)
+		gimlet.Set(c, gimlet.KeyUserClaims, userClaims())
+
+		// execute handler
+		srv.Authorize(c)
+
+		// assert response: the user must not be redirected
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		require.Empty(t, w.Header().Get("Location"))
+		reply := parseReply(t, w)
+		require.Equal(t, errors.ErrInvalidRedirectURI.Error(), reply.Error)
+	})
+
+	t.Run("UnsupportedResponseType", func(t *testing.T) {
+		// prepare mocks
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+		mockStore.OnRetrieveOIDCClientByClientID = onRetrieveClient
+
+		// build request and context
+		w, c := requestContext(t, http.MethodGet, "/oauth/authorize?response_type=token&client_id=cid&redirect_uri=https%3A%2F%2Fexample.com%2Fcb&state=xyz", nil, nil)
+		gimlet.Set(c, gimlet.KeyUserClaims, userClaims())
+
+		// execute handler
+		srv.Authorize(c)
+
+		// assert response: errors are returned to the client via the redirect
+		require.Equal(t, http.StatusFound, w.Code)
+		location, err := url.Parse(w.Header().Get("Location"))
+		require.NoError(t, err)
+		require.Equal(t, "unsupported_response_type", location.Query().Get("error"))
+		require.Equal(t, "xyz", location.Query().Get("state"))
+		mockStore.AssertCalls(t, mock.CreateAuthorizationCode, 0)
+	})
+}
+
+func TestAuthorizationCodeGrant(t *testing.T) {
+	// OIDC clients are confidential so PKCE does not replace client authentication.
+	t.Run("PKCEWithoutSecret", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestOAuthServer(t, mockStore)
+
+		form := url.Values{
+			"grant_type":    {"authorization_code"},
+			"client_id":     {"cid"},
+			"code":          {"code"},
+			"redirect_uri":  {"https://example.com/cb"},
+			"code_verifier": {"dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"},
+		}
+
+		w, c := requestContext(t, http.MethodPost, "/oauth/token", []byte(form.Encode()), nil)
+		c.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		srv.Token(c)
+
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+		out := &api.OAuthError{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), out))
+		require.Equal(t, api.OAuthInvalidClient, out.Code)
+		mockStore.AssertCalls(t, mock.RetrieveOIDCClientByClientID, 0)
+		mockStore.AssertCalls(t, mock.RetrieveAuthorizationCode, 0)
+	})
+
+	// Users suspended after authorizing the client are not issued tokens.
+	t.Run("SuspendedUser", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestOAuthServer(t, mockStore)
+
+		secret := passwords.ClientSecret()
+		derivedKey, err := passwords.CreateDerivedKey(secret)
+		require.NoError(t, err)
+
+		userID := ulid.MakeSecure()
+		mockStore.OnRetrieveOIDCClientByClientID = func(context.Context, string) (*models.OIDCClient, error) {
+			return &models.OIDCClient{ClientID: "cid", Secret: derivedKey}, nil
+		}
+		mockStore.OnRetrieveAuthorizationCode = func(context.Context, string) (*models.AuthorizationCode, error) {
+			return &models.AuthorizationCode{ClientID: "cid", UserID: userID, RedirectURI: "https://example.com/cb", Expiration: time.Now().Add(time.Minute)}, nil
+		}
+		mockStore.OnDeleteAuthorizationCode = func(context.Context, ulid.ULID) error { return nil }
+		mockStore.OnRetrieveUser = func(context.Context, ulid.ULID) (*models.User, error) {
+			return &models.User{BaseModel: tidal.BaseModel{ID: userID}, Email: "kate@example.com", Status: enum.UserStatusSuspended}, nil
+		}
+
+		form := url.Values{
+			"grant_type":    {"authorization_code"},
+			"client_id":     {"cid"},
+			"client_secret": {secret},
+			"code":          {"code"},
+			"redirect_uri":  {"https://example.com/cb"},
+		}
+
+		w, c := requestContext(t, http.MethodPost, "/oauth/token", []byte(form.Encode()), nil)
+		c.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		srv.Token(c)
+
+		require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+		out := &api.OAuthError{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), out))
+		require.Equal(t, api.OAuthInvalidGrant, out.Code)
+		mockStore.AssertCalls(t, mock.CreateRefreshToken, 0)
+	})
+}
+
+func TestClientCredentialsGrant(t *testing.T) {
+	clientID := passwords.ClientID()
+	secret := passwords.ClientSecret()
+	derivedKey, err := passwords.CreateDerivedKey(secret)
+	require.NoError(t, err)
+
+	onRetrieveAPIKey := func(ctx context.Context, id string) (*models.APIKey, error) {
+		if id != clientID {
+			return nil, errors.ErrNotFound
+		}
+
+		return &models.APIKey{
+			BaseModel:   tidal.BaseModel{ID: ulid.MakeSecure()},
+			ClientID:    clientID,
+			Secret:      derivedKey,
+			Permissions: []models.Permission{{Title: "users:view"}, {Title: "apikeys:view"}},
+		}, nil
+	}
+
+	onUpdateLastSeen := func(ctx context.Context, id ulid.ULID, ts time.Time) error {
+		return nil
+	}
+
+	tokenRequest := func(t *testing.T, form url.Values) (*Server, *mock.Store, func() (int, map[string]any)) {
+		mockStore := openMockStore(t)
+		srv := newTestOAuthServer(t, mockStore)
+		mockStore.OnRetrieveAPIKeyByClientID = onRetrieveAPIKey
+		mockStore.OnUpdateLastSeen = onUpdateLastSeen
+
+		w, c := requestContext(t, http.MethodPost, "/oauth/token", []byte(form.Encode()), nil)
+		c.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		return srv, mockStore, func() (int, map[string]any) {
+			srv.Token(c)
+			out := make(map[string]any)
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &out))
+			return w.Code, out
+		}
+	}
+
+	t.Run("ClientSecretPost", func(t *testing.T) {
+		_, mockStore, do := tokenRequest(t, url.Values{
+			"grant_type":    {"client_credentials"},
+			"client_id":     {clientID},
+			"client_secret": {secret},
+		})
+		defer mockStore.Close()
+
+		code, out := do()
+		require.Equal(t, http.StatusOK, code)
+		require.NotEmpty(t, out["access_token"])
+		require.Equal(t, api.TokenTypeBearer, out["token_type"])
+		require.Equal(t, "users:view apikeys:view", out["scope"])
+		require.NotContains(t, out, "refresh_token")
+		mockStore.AssertCalls(t, mock.UpdateLastSeen, 1)
+	})
+
+	t.Run("ClientSecretBasic", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestOAuthServer(t, mockStore)
+		mockStore.OnRetrieveAPIKeyByClientID = onRetrieveAPIKey
+		mockStore.OnUpdateLastSeen = onUpdateLastSeen
+
+		form := url.Values{"grant_type": {"client_credentials"}, "scope": {"users:view"}}
+		w, c := requestContext(t, http.MethodPost, "/oauth/token", []byte(form.Encode()), nil)
+		c.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		c.Request.SetBasicAuth(clientID, secret)
+
+		srv.Token(c)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "no-store", w.Header().Get(HeaderCacheControl))
+
+		out := &api.TokenReply{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), out))
+		require.Equal(t, "users:view", out.Scope)
+
+		claims, err := srv.issuer.Verify(out.AccessToken)
+		require.NoError(t, err)
+		require.Equal(t, []string{"users:view"}, claims.Permissions)
+		require.Equal(t, clientID, claims.ClientID)
+	})
+
+	t.Run("InvalidScope", func(t *testing.T) {
+		_, mockStore, do := tokenRequest(t, url.Values{
+			"grant_type":    {"client_credentials"},
+			"client_id":     {clientID},
+			"client_secret": {secret},
+			"scope":         {"users:view users:manage"},
+		})
+		defer mockStore.Close()
+
+		code, out := do()
+		require.Equal(t, http.StatusBadRequest, code)
+		require.Equal(t, api.OAuthInvalidScope, out["error"])
+		mockStore.AssertCalls(t, mock.UpdateLastSeen, 0)
+	})
+
+	t.Run("BadSecret", func(t *testing.T) {
+		_, mockStore, do := tokenRequest(t, url.Values{
+			"grant_type":    {"client_credentials"},
+			"client_id":     {clientID},
+			"client_secret": {strings.ToUpper(secret)},
+		})
+		defer mockStore.Close()
+
+		code, out := do()
+		require.Equal(t, http.StatusUnauthorized, code)
+		require.Equal(t, api.OAuthInvalidClient, out["error"])
+	})
+
+	t.Run("UnknownClient", func(t *testing.T) {
+		_, mockStore, do := tokenRequest(t, url.Values{
+			"grant_type":    {"client_credentials"},
+			"client_id":     {"unknown"},
+			"client_secret": {secret},
+		})
+		defer mockStore.Close()
+
+		code, out := do()
+		require.Equal(t, http.StatusUnauthorized, code)
+		require.Equal(t, api.OAuthInvalidClient, out["error"])
+	})
+
+	t.Run("Revoked", func(t *testing.T) {
+		_, mockStore, do := tokenRequest(t, url.Values{
+			"grant_type":    {"client_credentials"},
+			"client_id":     {clientID},
+			"client_secret": {secret},
+		})
+		defer mockStore.Close()
+		mockStore.OnRetrieveAPIKeyByClientID = func(ctx context.Context, id string) (*models.APIKey, error) {
+			key, _ := onRetrieveAPIKey(ctx, id)
+			key.Revoked = sql.NullTime{Valid: true, Time: time.Now()}
+			return key, nil
+		}
+
+		code, out := do()
+		require.Equal(t, http.StatusUnauthorized, code)
+		require.Equal(t, api.OAuthInvalidClient, out["error"])
+	})
+
+	t.Run("UnsupportedGrantType", func(t *testing.T) {
+		_, mockStore, do := tokenRequest(t, url.Values{
+			"grant_type": {"password"},
+			"client_id":  {clientID},
+		})
+		defer mockStore.Close()
+
+		code, out := do()
+		require.Equal(t, http.StatusBadRequest, code)
+		require.Equal(t, api.OAuthUnsupportedGrantType, out["error"])
+	})
+}
+
+// newTestOAuthServer creates a test server with a token issuer for token endpoint tests.
+func newTestOAuthServer(t *testing.T, store *mock.Store) *Server {
+	t.Helper()
+	srv := newTestServer(store)
+	srv.conf.Auth = config.AuthConfig{
+		Audience:        []string{"http://localhost:8000"},
+		Issuer:          "http://localhost:8888",
+		AccessTokenTTL:  1 * time.Hour,
+		RefreshTokenTTL: 2 * time.Hour,
+		TokenOverlap:    -15 * time.Minute,
+
+		KeyEncryptionKey: "6a1e4c2f0d8b7a95e3f41c6b2d09a8e7f5c3b1a0d9e8f7c6b5a4938271605f4e",
+	}
+
+	var err error
+	srv.issuer, err = qdauth.NewIssuer(srv.conf.Auth)
+	require.NoError(t, err)
+
+	srv.secrets, err = qdauth.NewKeyCipher(srv.conf.Auth.GetKeyEncryptionKey())
+	require.NoError(t, err)
+
+	// Signing keys are managed in the database so a key must be added to the issuer.
+	key, err := qdauth.GenerateKeys()
+	require.NoError(t, err)
+	require.NoError(t, srv.issuer.AddKey(ulid.MakeSecure(), key))
+
+	srv.webauthn, err = qdauth.NewWebAuthn(srv.conf.Auth)
+	require.NoError(t, err)
+	return srv
+}
+
+func TestIntrospect(t *testing.T) {
+	clientID := passwords.ClientID()
+	secret := passwords.ClientSecret()
+	derivedKey, err := passwords.CreateDerivedKey(secret)
+	require.NoError(t, err)
+
+	userID, suspendedID := ulid.MakeSecure(), ulid.MakeSecure()
+
+	// The token func is called with the test server so that tokens are signed by its issuer.
+	introspect := func(t *testing.T, token func(*Server) string) (int, *api.IntrospectionReply) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestOAuthServer(t, mockStore)
+
+		mockStore.OnRetrieveOIDCClientByClientID = func(ctx context.Context, id string) (*models.OIDCClient, error) {
+			if id == clientID {
+				return &models.OIDCClient{ClientID: clientID, Secret: derivedKey}, nil
+			}
+			return nil, errors.ErrNotFound
+		}
+
+		mockStore.OnRetrieveUser = func(ctx context.Context, id ulid.ULID) (*models.User, error) {
+			switch id {
+			case userID:
+				return &models.User{BaseModel: tidal.BaseModel{ID: userID}, Email: "kate@example.com"}, nil
+			case suspendedID:
+				return &models.User{BaseModel: tidal.BaseModel{ID: suspendedID}, Email: "kate@example.com", Status: enum.UserStatusSuspended}, nil
+			}
+			return nil, errors.ErrNotFound
+		}
+
+		form := url.Values{"token": {token(srv)}}
+		w, c := requestContext(t, http.MethodPost, "/oauth/introspect", []byte(form.Encode()), nil)
+		c.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		c.Request.SetBasicAuth(clientID, secret)
+
+		srv.Introspect(c)
+		out := &api.IntrospectionReply{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), out))
+		return w.Code, out
+	}
+
+	userToken := func(subjectID ulid.ULID) func(*Server) string {
+		return func(srv *Server) string {
+			claims := &auth.Claims{Email: "kate@example.com", Permissions: []string{"users:view"}}
+			claims.SetSubjectID(auth.SubjectUser, subjectID)
+			accessToken, _, err := srv.issuer.CreateTokens(claims, ulid.Zero)
+			require.NoError(t, err)
+			return accessToken
+		}
+	}
+
+	t.Run("Active", func(t *testing.T) {
+		code, out := introspect(t, userToken(userID))
+		require.Equal(t, http.StatusOK, code)
+		require.True(t, out.Active)
+		require.Equal(t, "kate@example.com", out.Username)
+		require.Equal(t, "users:view", out.Scope)
+		require.Equal(t, "access_token", out.TokenType)
+		require.NotZero(t, out.ExpiresAt)
+	})
+
+	t.Run("DeletedUser", func(t *testing.T) {
+		code, out := introspect(t, userToken(ulid.MakeSecure()))
+		require.Equal(t, http.StatusOK, code)
+		require.False(t, out.Active)
+		require.Empty(t, out.Username)
+	})
+
+	t.Run("SuspendedUser", func(t *testing.T) {
+		code, out := introspect(t, userToken(suspendedID))
+		require.Equal(t, http.StatusOK, code)
+		require.False(t, out.Active)
+		require.Empty(t, out.Username)
+	})
+
+	t.Run("InvalidToken", func(t *testing.T) {
+		code, out := introspect(t, func(*Server) string { return "notatoken" })
+		require.Equal(t, http.StatusOK, code)
+		require.False(t, out.Active)
+	})
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestOAuthServer(t, mockStore)
+
+		form := url.Values{"token": {"notatoken"}}
+		w, c := requestContext(t, http.MethodPost, "/oauth/introspect", []byte(form.Encode()), nil)
+		c.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		srv.Introspect(c)
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func TestRevoke(t *testing.T) {
+	clientID := passwords.ClientID()
+	secret := passwords.ClientSecret()
+	derivedKey, err := passwords.CreateDerivedKey(secret)
+	require.NoError(t, err)
+
+	setup := func(t *testing.T) (*Server, *mock.Store) {
+		mockStore := openMockStore(t)
+		srv := newTestOAuthServer(t, mockStore)
+
+		mockStore.OnRetrieveOIDCClientByClientID = func(ctx context.Context, id string) (*models.OIDCClient, error) {
+			return nil, errors.ErrNotFound
+		}
+
+		mockStore.OnRetrieveAPIKeyByClientID = func(ctx context.Context, id string) (*models.APIKey, error) {
+			if id == clientID {
+				return &models.APIKey{ClientID: clientID, Secret: derivedKey}, nil
+			}
+			return nil, errors.ErrNotFound
+		}
+
+		return srv, mockStore
+	}
+
+	revoke := func(t *testing.T, srv *Server, token string) *httptest.ResponseRecorder {
+		form := url.Values{"token": {token}, "client_id": {clientID}, "client_secret": {secret}}
+		w, c := requestContext(t, http.MethodPost, "/oauth/revoke", []byte(form.Encode()), nil)
+		c.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		srv.Revoke(c)
+		return w
+	}
+
+	createToken := func(t *testing.T, srv *Server, clientID string) (string, *auth.Claims) {
+		claims := &auth.Claims{ClientID: clientID}
+		claims.SetSubjectID(auth.SubjectAPIKey, ulid.MakeSecure())
+		accessToken, _, err := srv.issuer.CreateTokens(claims, ulid.Zero)
+		require.NoError(t, err)
+		return accessToken, claims
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		srv, mockStore := setup(t)
+		defer mockStore.Close()
+
+		var revoked *models.RevokedToken
+		mockStore.OnRevokeToken = func(ctx context.Context, in *models.RevokedToken) error {
+			revoked = in
+			return nil
+		}
+
+		accessToken, claims := createToken(t, srv, clientID)
+		w := revoke(t, srv, accessToken)
+		require.Equal(t, http.StatusOK, w.Code)
+		mockStore.AssertCalls(t, mock.RevokeToken, 1)
+		require.Equal(t, claims.ID, revoked.ID.String())
+		require.True(t, revoked.Expiration.After(time.Now().Add(srv.conf.Auth.AccessTokenTTL)), "denylist entry must outlive the refresh token")
+	})
+
+	t.Run("OtherClient", func(t *testing.T) {
+		srv, mockStore := setup(t)
+		defer mockStore.Close()
+
+		accessToken, _ := createToken(t, srv, "otherclient")
+		w := revoke(t, srv, accessToken)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		mockStore.AssertCalls(t, mock.RevokeToken, 0)
+	})
+
+	t.Run("InvalidToken", func(t *testing.T) {
+		srv, mockStore := setup(t)
+		defer mockStore.Close()
+
+		w := revoke(t, srv, "notatoken")
+		require.Equal(t, http.StatusOK, w.Code)
+		mockStore.AssertCalls(t, mock.RevokeToken, 0)
+	})
+
+	// Tokens issued by the login endpoint do not have a client ID.
+	t.Run("FirstParty", func(t *testing.T) {
+		srv, mockStore := setup(t)
+		defer mockStore.Close()
+		mockStore.OnRevokeToken = func(ctx context.Context, in *models.RevokedToken) error {
+			return nil
+		}
+
+		accessToken, _ := createToken(t, srv, "")
+		w := revoke(t, srv, accessToken)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		// No client credentials are required to revoke a first-party token.
+		form := url.Values{"token": {accessToken}}
+		w, c := requestContext(t, http.MethodPost, "/oauth/revoke", []byte(form.Encode()), nil)
+		c.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		srv.Revoke(c)
+		require.Equal(t, http.StatusOK, w.Code)
+		mockStore.AssertCalls(t, mock.RevokeToken, 2)
+	})
+
+	// Tokens issued to a client cannot be revoked without the client's credentials.
+	t.Run("ClientTokenWithoutCredentials", func(t *testing.T) {
+		srv, mockStore := setup(t)
+		defer mockStore.Close()
+
+		accessToken, _ := createToken(t, srv, clientID)
+		form := url.Values{"token": {accessToken}}
+		w, c := requestContext(t, http.MethodPost, "/oauth/revoke", []byte(form.Encode()), nil)
+		c.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		srv.Revoke(c)
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+		mockStore.AssertCalls(t, mock.RevokeToken, 0)
+	})
+}
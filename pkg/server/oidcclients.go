@@ -8,37 +8,47 @@ import (
 	"go.rtnl.ai/quarterdeck/pkg/api/v1"
 	"go.rtnl.ai/quarterdeck/pkg/auth/passwords"
 	"go.rtnl.ai/quarterdeck/pkg/errors"
-	"go.rtnl.ai/quarterdeck/pkg/store/v1/models"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
 	"go.rtnl.ai/ulid"
 )
 
 func (s *Server) ListOIDCClients(c *gin.Context) {
 	var (
-		err  error
-		in   *api.PageQuery
-		list *models.OIDCClientList
-		out  *api.OIDCClientList
+		err    error
+		in     *api.OIDCClientPageQuery
+		cursor *api.Cursor
+		page   *api.Page
+		list   []*models.OIDCClient
+		out    *api.OIDCClientList
 	)
 
-	in = &api.PageQuery{}
+	in = &api.OIDCClientPageQuery{}
 	if err = c.BindQuery(in); err != nil {
 		c.Error(err)
 		c.JSON(http.StatusBadRequest, api.Error("invalid query parameters"))
 		return
 	}
 
-	list, err = s.store.ListOIDCClients(c.Request.Context(), in.PageModel())
-	if err != nil {
+	// Only list the clients in the organization the requester is logged into.
+	if cursor, err = in.Cursor(s.currentOrg(c)); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadRequest, api.Error(err))
+		return
+	}
+
+	if list, err = listAll(s.store.ListOIDCClients(c.Request.Context(), cursor.Filter())); err != nil {
 		c.Error(err)
 		c.JSON(http.StatusInternalServerError, api.Error("could not process oidc clients list request"))
 		return
 	}
+	list, page = api.Paginate(cursor, list, func(client *models.OIDCClient) ulid.ULID { return client.ID })
 
 	if out, err = api.NewOIDCClientList(list); err != nil {
 		c.Error(err)
 		c.JSON(http.StatusInternalServerError, api.Error("could not process oidc clients list request"))
 		return
 	}
+	out.Page = page
 
 	c.JSON(http.StatusOK, out)
 }
@@ -94,9 +104,13 @@ func (s *Server) CreateOIDCClient(c *gin.Context) {
 		return
 	}
 
+	// Clients belong to the organization of the user or API key that registered them.
 	switch subjectType {
 	case auth.SubjectUser:
 		client.CreatedBy = subjectID
+		if orgID := s.currentOrg(c); !orgID.IsZero() {
+			client.OrgID = ulid.NullULID{ULID: orgID, Valid: true}
+		}
 	case auth.SubjectAPIKey:
 		var parent *models.APIKey
 		if parent, err = s.store.RetrieveAPIKey(c.Request.Context(), subjectID); err != nil {
@@ -105,12 +119,13 @@ func (s *Server) CreateOIDCClient(c *gin.Context) {
 			return
 		}
 		client.CreatedBy = parent.CreatedBy
+		client.OrgID = parent.OrgID
 	default:
 		c.JSON(http.StatusForbidden, api.Error("only users and api keys can create oidc clients"))
 		return
 	}
 
-	if err = s.store.CreateOIDCClient(c.Request.Context(), client); err != nil {
+	if client, err = s.store.CreateOIDCClient(c.Request.Context(), client); err != nil {
 		c.Error(errors.Fmt("could not create oidc client: %w", err))
 		c.JSON(http.StatusInternalServerError, api.Error("could not process create oidc client request"))
 		return
@@ -121,6 +136,8 @@ func (s *Server) CreateOIDCClient(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, api.Error("could not process create oidc client request"))
 		return
 	}
+
+	s.audit(c, models.AuditCreate, models.AuditOIDCClient, client.ID.String(), nil, out)
 	out.Secret = secret
 
 	c.JSON(http.StatusCreated, out)
@@ -150,6 +167,11 @@ func (s *Server) OIDCClientDetail(c *gin.Context) {
 		return
 	}
 
+	if !s.inCurrentOrg(c, client.OrgID.ULID) {
+		c.JSON(http.StatusNotFound, api.Error("oidc client not found"))
+		return
+	}
+
 	if out, err = api.NewOIDCClient(client); err != nil {
 		c.Error(err)
 		c.JSON(http.StatusInternalServerError, api.Error("could not process oidc client detail request"))
@@ -165,6 +187,7 @@ func (s *Server) UpdateOIDCClient(c *gin.Context) {
 		id     ulid.ULID
 		in     *api.OIDCClient
 		client *models.OIDCClient
+		before *api.OIDCClient
 		out    *api.OIDCClient
 	)
 
@@ -198,6 +221,11 @@ func (s *Server) UpdateOIDCClient(c *gin.Context) {
 		return
 	}
 
+	// Retrieve the client before it is updated to record the changes in the audit log
+	if before, err = s.retrieveOIDCClient(c, id); err != nil {
+		return
+	}
+
 	if err = s.store.UpdateOIDCClient(c.Request.Context(), client); err != nil {
 		if errors.Is(err, errors.ErrNotFound) {
 			c.JSON(http.StatusNotFound, api.Error("oidc client not found"))
@@ -208,12 +236,12 @@ func (s *Server) UpdateOIDCClient(c *gin.Context) {
 		return
 	}
 
-	if out, err = api.NewOIDCClient(client); err != nil {
-		c.Error(err)
-		c.JSON(http.StatusInternalServerError, api.Error("could not process update oidc client request"))
+	// Reload the client so that the response includes the fields that cannot be updated
+	if out, err = s.retrieveOIDCClient(c, id); err != nil {
 		return
 	}
 
+	s.audit(c, models.AuditUpdate, models.AuditOIDCClient, id.String(), before, out)
 	c.JSON(http.StatusOK, out)
 }
 
@@ -228,6 +256,13 @@ func (s *Server) DeleteOIDCClient(c *gin.Context) {
 		return
 	}
 
+	// Requesters logged into an organization can only delete the clients in it.
+	if !s.currentOrg(c).IsZero() {
+		if _, err = s.retrieveOIDCClient(c, id); err != nil {
+			return
+		}
+	}
+
 	if err = s.store.DeleteOIDCClient(c.Request.Context(), id); err != nil {
 		if errors.Is(err, errors.ErrNotFound) {
 			c.JSON(http.StatusNotFound, api.Error("oidc client not found"))
@@ -238,5 +273,34 @@ func (s *Server) DeleteOIDCClient(c *gin.Context) {
 		return
 	}
 
+	s.audit(c, models.AuditDelete, models.AuditOIDCClient, id.String(), nil, nil)
 	c.JSON(http.StatusOK, api.Reply{Success: true})
 }
+
+// retrieveOIDCClient fetches the client for an update or delete; clients that are not in
+// the organization the requester is logged into are not found. If an error is returned
+// then the response has already been written.
+func (s *Server) retrieveOIDCClient(c *gin.Context, id ulid.ULID) (out *api.OIDCClient, err error) {
+	var client *models.OIDCClient
+	if client, err = s.store.RetrieveOIDCClient(c.Request.Context(), id); err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			c.JSON(http.StatusNotFound, api.Error("oidc client not found"))
+			return nil, err
+		}
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process update oidc client request"))
+		return nil, err
+	}
+
+	if !s.inCurrentOrg(c, client.OrgID.ULID) {
+		c.JSON(http.StatusNotFound, api.Error("oidc client not found"))
+		return nil, errors.ErrNotFound
+	}
+
+	if out, err = api.NewOIDCClient(client); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process update oidc client request"))
+		return nil, err
+	}
+	return out, nil
+}
@@ -15,9 +15,10 @@ import (
 	"go.rtnl.ai/gimlet/auth"
 	"go.rtnl.ai/quarterdeck/pkg/api/v1"
 	"go.rtnl.ai/quarterdeck/pkg/errors"
-	"go.rtnl.ai/quarterdeck/pkg/store/v1"
-	"go.rtnl.ai/quarterdeck/pkg/store/v1/mock"
-	"go.rtnl.ai/quarterdeck/pkg/store/v1/models"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/mock"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/tidal"
 	"go.rtnl.ai/ulid"
 )
 
@@ -30,18 +31,14 @@ func TestListOIDCClients(t *testing.T) {
 
 		// set mock callback
 		clientID := ulid.MakeSecure()
-		mockStore.OnListOIDCClients = func(ctx context.Context, page *models.Page) (*models.OIDCClientList, error) {
-			return &models.OIDCClientList{
-				OIDCClients: []*models.OIDCClient{
-					{
-						Model:        models.Model{ID: clientID, Created: time.Now(), Modified: time.Now()},
-						ClientName:   "Test",
-						RedirectURIs: []string{"https://example.com/cb"},
-						ClientID:     "cid",
-						CreatedBy:    ulid.MakeSecure(),
-					},
-				},
-			}, nil
+		mockStore.OnListOIDCClients = func(ctx context.Context, filter tidal.ListFilter) (tidal.Cursor[*models.OIDCClient], error) {
+			return mock.NewCursor(&models.OIDCClient{
+				BaseModel:    tidal.BaseModel{ID: clientID, Created: time.Now(), Modified: time.Now()},
+				ClientName:   "Test",
+				RedirectURIs: []string{"https://example.com/cb"},
+				ClientID:     "cid",
+				CreatedBy:    ulid.MakeSecure(),
+			}), nil
 		}
 
 		// build request and context
@@ -86,7 +83,7 @@ func TestListOIDCClients(t *testing.T) {
 		srv := newTestServer(mockStore)
 
 		// set mock callback
-		mockStore.OnListOIDCClients = func(ctx context.Context, page *models.Page) (*models.OIDCClientList, error) {
+		mockStore.OnListOIDCClients = func(ctx context.Context, filter tidal.ListFilter) (tidal.Cursor[*models.OIDCClient], error) {
 			return nil, errors.ErrNotFound
 		}
 
@@ -116,13 +113,19 @@ func TestCreateOIDCClient(t *testing.T) {
 		claims.SetSubjectID(auth.SubjectUser, userID)
 
 		// set mock callback
-		mockStore.OnCreateOIDCClient = func(ctx context.Context, in *models.OIDCClient) error {
+		mockStore.OnCreateOIDCClient = func(ctx context.Context, in *models.OIDCClient) (*models.OIDCClient, error) {
 			created = in
 			created.ID = ulid.MakeSecure()
 			created.Created = time.Now()
 			created.Modified = created.Created
 			created.CreatedBy = userID
-			return nil
+			return in, nil
+		}
+
+		var event *models.AuditEvent
+		mockStore.OnCreateAuditEvent = func(ctx context.Context, in *models.AuditEvent) (*models.AuditEvent, error) {
+			event = in
+			return in, nil
 		}
 
 		// build request and context
@@ -141,6 +144,16 @@ func TestCreateOIDCClient(t *testing.T) {
 		require.NotEmpty(t, out.ClientID)
 		mockStore.AssertCalls(t, mock.CreateOIDCClient, 1)
 		require.Equal(t, userID, created.CreatedBy)
+
+		// assert the creation was audited without the client secret
+		mockStore.AssertCalls(t, mock.CreateAuditEvent, 1)
+		require.Equal(t, models.AuditCreate, event.Action)
+		require.Equal(t, models.AuditOIDCClient, event.SubjectType)
+		require.Equal(t, created.ID.String(), event.SubjectID)
+		require.Equal(t, models.AuditUser, event.ActorType.String)
+		require.Equal(t, userID, event.ActorID.ULID)
+		require.Contains(t, event.Diff.String, "Test Client")
+		require.NotContains(t, event.Diff.String, out.Secret)
 	})
 
 	t.Run("SuccessAPIKey", func(t *testing.T) {
@@ -156,12 +169,17 @@ func TestCreateOIDCClient(t *testing.T) {
 
 		// set mock callbacks
 		var created *models.OIDCClient
-		mockStore.OnRetrieveAPIKey = func(ctx context.Context, id any) (*models.APIKey, error) {
-			return &models.APIKey{Model: models.Model{ID: apiKeyID}, CreatedBy: userID}, nil
+		mockStore.OnRetrieveAPIKey = func(ctx context.Context, id ulid.ULID) (*models.APIKey, error) {
+			return &models.APIKey{BaseModel: tidal.BaseModel{ID: apiKeyID}, CreatedBy: userID}, nil
 		}
-		mockStore.OnCreateOIDCClient = func(ctx context.Context, in *models.OIDCClient) error {
+		mockStore.OnCreateOIDCClient = func(ctx context.Context, in *models.OIDCClient) (*models.OIDCClient, error) {
 			created = in
-			return nil
+			return in, nil
+		}
+		mockStore.OnCreateAuditEvent = func(ctx context.Context, in *models.AuditEvent) (*models.AuditEvent, error) {
+			require.Equal(t, models.AuditAPIKey, in.ActorType.String)
+			require.Equal(t, apiKeyID, in.ActorID.ULID)
+			return in, nil
 		}
 
 		// build request and context
@@ -253,7 +271,7 @@ func TestCreateOIDCClient(t *testing.T) {
 		srv := newTestServer(mockStore)
 
 		// set mock callback
-		mockStore.OnRetrieveAPIKey = func(ctx context.Context, id any) (*models.APIKey, error) {
+		mockStore.OnRetrieveAPIKey = func(ctx context.Context, id ulid.ULID) (*models.APIKey, error) {
 			return nil, errors.ErrNotFound
 		}
 
@@ -302,8 +320,8 @@ func TestCreateOIDCClient(t *testing.T) {
 		srv := newTestServer(mockStore)
 
 		// set mock callback
-		mockStore.OnCreateOIDCClient = func(ctx context.Context, in *models.OIDCClient) error {
-			return errors.ErrNotFound
+		mockStore.OnCreateOIDCClient = func(ctx context.Context, in *models.OIDCClient) (*models.OIDCClient, error) {
+			return nil, errors.ErrNotFound
 		}
 
 		// build request and context
@@ -328,7 +346,7 @@ func TestOIDCClientDetail(t *testing.T) {
 		defer mockStore.Close()
 		clientID := ulid.MakeSecure()
 		client := &models.OIDCClient{
-			Model:        models.Model{ID: clientID, Created: time.Now(), Modified: time.Now()},
+			BaseModel:    tidal.BaseModel{ID: clientID, Created: time.Now(), Modified: time.Now()},
 			ClientName:   "Detail Client",
 			RedirectURIs: []string{"https://example.com/cb"},
 			ClientID:     "cid",
@@ -337,7 +355,7 @@ func TestOIDCClientDetail(t *testing.T) {
 		srv := newTestServer(mockStore)
 
 		// set mock callback
-		mockStore.OnRetrieveOIDCClient = func(ctx context.Context, id any) (*models.OIDCClient, error) {
+		mockStore.OnRetrieveOIDCClient = func(ctx context.Context, id ulid.ULID) (*models.OIDCClient, error) {
 			require.Equal(t, clientID, id)
 			return client, nil
 		}
@@ -380,7 +398,7 @@ func TestOIDCClientDetail(t *testing.T) {
 		srv := newTestServer(mockStore)
 
 		// set mock callback
-		mockStore.OnRetrieveOIDCClient = func(ctx context.Context, id any) (*models.OIDCClient, error) {
+		mockStore.OnRetrieveOIDCClient = func(ctx context.Context, id ulid.ULID) (*models.OIDCClient, error) {
 			return nil, errors.ErrNotFound
 		}
 
@@ -404,7 +422,7 @@ func TestOIDCClientDetail(t *testing.T) {
 		srv := newTestServer(mockStore)
 
 		// set mock callback
-		mockStore.OnRetrieveOIDCClient = func(ctx context.Context, id any) (*models.OIDCClient, error) {
+		mockStore.OnRetrieveOIDCClient = func(ctx context.Context, id ulid.ULID) (*models.OIDCClient, error) {
 			return nil, errors.Fmt("db error")
 		}
 
@@ -430,11 +448,23 @@ func TestUpdateOIDCClient(t *testing.T) {
 		var updated *models.OIDCClient
 		srv := newTestServer(mockStore)
 
+		mockStore.OnRetrieveOIDCClient = func(ctx context.Context, id ulid.ULID) (*models.OIDCClient, error) {
+			if updated != nil {
+				return updated, nil
+			}
+			return &models.OIDCClient{BaseModel: tidal.BaseModel{ID: clientID}, ClientName: "Original", RedirectURIs: []string{"https://example.com/cb"}}, nil
+		}
 		mockStore.OnUpdateOIDCClient = func(ctx context.Context, in *models.OIDCClient) error {
 			updated = in
 			return nil
 		}
 
+		var event *models.AuditEvent
+		mockStore.OnCreateAuditEvent = func(ctx context.Context, in *models.AuditEvent) (*models.AuditEvent, error) {
+			event = in
+			return in, nil
+		}
+
 		// build request and context
 		w, c := requestContext(t, http.MethodPut, "/v1/oidc/oidcclients/"+clientID.String(), validUpdateBody(clientID, "Updated"), gin.Params{{Key: "id", Value: clientID.String()}})
 		c.Request.Header.Set("Content-Type", "application/json")
@@ -447,6 +477,37 @@ func TestUpdateOIDCClient(t *testing.T) {
 		out := parseOIDCClient(t, w)
 		require.Equal(t, "Updated", out.ClientName)
 		require.Equal(t, clientID, updated.ID)
+		mockStore.AssertCalls(t, mock.RetrieveOIDCClient, 2)
+
+		// assert only the changed fields were audited
+		require.Equal(t, models.AuditUpdate, event.Action)
+		require.Equal(t, clientID.String(), event.SubjectID)
+		require.JSONEq(t, `{"client_name":{"from":"Original","to":"Updated"}}`, event.Diff.String)
+	})
+
+	t.Run("NotFoundRetrieve", func(t *testing.T) {
+		// prepare mocks
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		id := ulid.MakeSecure()
+		srv := newTestServer(mockStore)
+
+		mockStore.OnRetrieveOIDCClient = func(ctx context.Context, id ulid.ULID) (*models.OIDCClient, error) {
+			return nil, errors.ErrNotFound
+		}
+
+		// build request and context
+		w, c := requestContext(t, http.MethodPut, "/v1/oidc/oidcclients/"+id.String(), validUpdateBody(id, "Updated"), gin.Params{{Key: "id", Value: id.String()}})
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		// execute handler
+		srv.UpdateOIDCClient(c)
+
+		// assert response
+		require.Equal(t, http.StatusNotFound, w.Code)
+		reply := parseReply(t, w)
+		require.Equal(t, "oidc client not found", reply.Error)
+		mockStore.AssertCalls(t, mock.UpdateOIDCClient, 0)
 	})
 
 	t.Run("NotFoundBadID", func(t *testing.T) {
@@ -538,6 +599,9 @@ func TestUpdateOIDCClient(t *testing.T) {
 		id := ulid.MakeSecure()
 		srv := newTestServer(mockStore)
 
+		mockStore.OnRetrieveOIDCClient = func(ctx context.Context, id ulid.ULID) (*models.OIDCClient, error) {
+			return &models.OIDCClient{BaseModel: tidal.BaseModel{ID: id}, ClientName: "Original"}, nil
+		}
 		mockStore.OnUpdateOIDCClient = func(ctx context.Context, in *models.OIDCClient) error {
 			return errors.ErrNotFound
 		}
@@ -562,6 +626,9 @@ func TestUpdateOIDCClient(t *testing.T) {
 		id := ulid.MakeSecure()
 		srv := newTestServer(mockStore)
 
+		mockStore.OnRetrieveOIDCClient = func(ctx context.Context, id ulid.ULID) (*models.OIDCClient, error) {
+			return &models.OIDCClient{BaseModel: tidal.BaseModel{ID: id}, ClientName: "Original"}, nil
+		}
 		mockStore.OnUpdateOIDCClient = func(ctx context.Context, in *models.OIDCClient) error {
 			return errors.Fmt("db error")
 		}
@@ -588,10 +655,16 @@ func TestDeleteOIDCClient(t *testing.T) {
 		id := ulid.MakeSecure()
 		srv := newTestServer(mockStore)
 
-		// set mock callback
+		// set mock callbacks
 		mockStore.OnDeleteOIDCClient = func(ctx context.Context, id ulid.ULID) error {
 			return nil
 		}
+		mockStore.OnCreateAuditEvent = func(ctx context.Context, in *models.AuditEvent) (*models.AuditEvent, error) {
+			require.Equal(t, models.AuditDelete, in.Action)
+			require.Equal(t, id.String(), in.SubjectID)
+			require.False(t, in.Diff.Valid)
+			return in, nil
+		}
 
 		// build request and context
 		w, c := requestContext(t, http.MethodDelete, "/v1/oidc/oidcclients/"+id.String(), nil, gin.Params{{Key: "id", Value: id.String()}})
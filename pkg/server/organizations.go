@@ -0,0 +1,653 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	gimauth "go.rtnl.ai/gimlet/auth"
+	"go.rtnl.ai/quarterdeck/pkg/api/v1"
+	"go.rtnl.ai/quarterdeck/pkg/auth"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/quarterdeck/pkg/web/htmx"
+	"go.rtnl.ai/quarterdeck/pkg/web/scene"
+	"go.rtnl.ai/ulid"
+)
+
+// ============================================================================
+// Organization resource handlers
+// ============================================================================
+
+// ListOrganizations returns the organizations (tenants) of Quarterdeck; requesters that
+// are logged into an organization only see their own organization.
+func (s *Server) ListOrganizations(c *gin.Context) {
+	var (
+		err    error
+		in     *api.OrganizationPageQuery
+		cursor *api.Cursor
+		page   *api.Page
+		orgs   []*models.Organization
+		out    *api.OrganizationList
+	)
+
+	in = &api.OrganizationPageQuery{}
+	if err = c.BindQuery(in); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadRequest, api.Error("invalid query parameters"))
+		return
+	}
+
+	if cursor, err = in.Cursor(s.currentOrg(c)); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadRequest, api.Error(err))
+		return
+	}
+
+	if orgs, err = listAll(s.store.ListOrganizations(c.Request.Context(), cursor.Filter())); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process organizations list request"))
+		return
+	}
+	orgs, page = api.Paginate(cursor, orgs, func(o *models.Organization) ulid.ULID { return o.ID })
+
+	if out, err = api.NewOrganizationList(orgs); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process organizations list request"))
+		return
+	}
+	out.Page = page
+
+	c.JSON(http.StatusOK, out)
+}
+
+// CreateOrganization creates a new organization; members are added to it by assigning
+// them roles in the organization.
+func (s *Server) CreateOrganization(c *gin.Context) {
+	var (
+		err error
+		in  *api.Organization
+		org *models.Organization
+		out *api.Organization
+	)
+
+	in = &api.Organization{}
+	if err = c.BindJSON(in); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadRequest, api.Error("could not parse organization data"))
+		return
+	}
+
+	if err = in.Validate(); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusUnprocessableEntity, api.Error(err))
+		return
+	}
+
+	if org, err = in.Model(); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process organization data"))
+		return
+	}
+
+	if org, err = s.store.CreateOrganization(c.Request.Context(), org); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process create organization request"))
+		return
+	}
+
+	if out, err = api.NewOrganization(org); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process create organization request"))
+		return
+	}
+
+	s.audit(c, models.AuditCreate, models.AuditOrganization, org.ID.String(), nil, out)
+
+	switch c.NegotiateFormat(binding.MIMEJSON, binding.MIMEHTML) {
+	case binding.MIMEJSON:
+		c.JSON(http.StatusCreated, out)
+	case binding.MIMEHTML:
+		htmx.SetTrigger(c, htmx.OrganizationsUpdated)
+		c.Data(http.StatusNoContent, gin.MIMEHTML, nil)
+	}
+}
+
+// OrganizationDetail returns the organization; the HTML response is the form to edit
+// the settings of the organization.
+func (s *Server) OrganizationDetail(c *gin.Context) {
+	var (
+		err   error
+		orgID ulid.ULID
+		out   *api.Organization
+	)
+
+	if orgID, err = ulid.Parse(c.Param("orgID")); err != nil {
+		c.JSON(http.StatusNotFound, api.Error("organization not found"))
+		return
+	}
+
+	if out, err = s.retrieveOrganization(c, orgID); err != nil {
+		return
+	}
+
+	c.Negotiate(http.StatusOK, gin.Negotiate{
+		Offered:  []string{binding.MIMEJSON, binding.MIMEHTML},
+		Data:     out,
+		HTMLName: "partials/organizations/settings.html",
+		HTMLData: scene.New(c).WithAPIData(out),
+	})
+}
+
+// UpdateOrganization updates the name and contact details of the organization that are
+// used in place of the global organization configuration.
+func (s *Server) UpdateOrganization(c *gin.Context) {
+	var (
+		err    error
+		orgID  ulid.ULID
+		in     *api.Organization
+		org    *models.Organization
+		before *api.Organization
+		out    *api.Organization
+	)
+
+	if orgID, err = ulid.Parse(c.Param("orgID")); err != nil {
+		c.JSON(http.StatusNotFound, api.Error("organization not found"))
+		return
+	}
+
+	in = &api.Organization{}
+	if err = c.BindJSON(in); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadRequest, api.Error("could not parse organization data"))
+		return
+	}
+
+	if err = in.Validate(); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusUnprocessableEntity, api.Error(err))
+		return
+	}
+
+	// Set the organization ID only after validation
+	in.ID = orgID
+
+	if org, err = in.Model(); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process update organization request"))
+		return
+	}
+
+	// Retrieve the organization before it is updated to record the changes in the audit log
+	if before, err = s.retrieveOrganization(c, orgID); err != nil {
+		return
+	}
+
+	if err = s.store.UpdateOrganization(c.Request.Context(), org); err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			c.JSON(http.StatusNotFound, api.Error("organization not found"))
+			return
+		}
+
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process update organization request"))
+		return
+	}
+
+	if out, err = s.retrieveOrganization(c, orgID); err != nil {
+		return
+	}
+
+	s.audit(c, models.AuditUpdate, models.AuditOrganization, orgID.String(), before, out)
+
+	switch c.NegotiateFormat(binding.MIMEJSON, binding.MIMEHTML) {
+	case binding.MIMEJSON:
+		c.JSON(http.StatusOK, out)
+	case binding.MIMEHTML:
+		htmx.SetTrigger(c, htmx.OrganizationsUpdated)
+		c.Data(http.StatusNoContent, gin.MIMEHTML, nil)
+	}
+}
+
+// DeleteOrganization deletes the organization along with its memberships, API keys,
+// and OIDC clients.
+func (s *Server) DeleteOrganization(c *gin.Context) {
+	var (
+		err   error
+		orgID ulid.ULID
+	)
+
+	if orgID, err = ulid.Parse(c.Param("orgID")); err != nil {
+		c.JSON(http.StatusNotFound, api.Error("organization not found"))
+		return
+	}
+
+	if !s.inCurrentOrg(c, orgID) {
+		c.JSON(http.StatusNotFound, api.Error("organization not found"))
+		return
+	}
+
+	if err = s.store.DeleteOrganization(c.Request.Context(), orgID); err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			c.JSON(http.StatusNotFound, api.Error("organization not found"))
+			return
+		}
+
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process delete organization request"))
+		return
+	}
+
+	s.audit(c, models.AuditDelete, models.AuditOrganization, orgID.String(), nil, nil)
+
+	if htmx.IsHTMXRequest(c) {
+		htmx.SetTrigger(c, htmx.OrganizationsUpdated)
+		c.Data(http.StatusNoContent, gin.MIMEHTML, nil)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.Reply{Success: true})
+}
+
+// ============================================================================
+// Organization membership handlers
+// ============================================================================
+
+// ListOrganizationMembers returns the members of the organization with the roles they
+// have in the organization.
+func (s *Server) ListOrganizationMembers(c *gin.Context) {
+	var (
+		err     error
+		orgID   ulid.ULID
+		members []*models.User
+		out     *api.OrganizationMemberList
+	)
+
+	if orgID, err = ulid.Parse(c.Param("orgID")); err != nil {
+		c.JSON(http.StatusNotFound, api.Error("organization not found"))
+		return
+	}
+
+	if !s.inCurrentOrg(c, orgID) {
+		c.JSON(http.StatusNotFound, api.Error("organization not found"))
+		return
+	}
+
+	if members, err = s.store.ListOrganizationMembers(c.Request.Context(), orgID); err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			c.JSON(http.StatusNotFound, api.Error("organization not found"))
+			return
+		}
+
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process organization members request"))
+		return
+	}
+
+	if out, err = api.NewOrganizationMemberList(members); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process organization members request"))
+		return
+	}
+
+	c.JSON(http.StatusOK, out)
+}
+
+// ReplaceOrganizationMemberRoles replaces the roles the user has in the organization
+// with the roles in the request. Assigning roles to a user who is not a member adds
+// them to the organization and an empty list of roles removes them from it.
+func (s *Server) ReplaceOrganizationMemberRoles(c *gin.Context) {
+	var (
+		err     error
+		orgID   ulid.ULID
+		userID  ulid.ULID
+		in      *api.UserRoles
+		roleIDs []int64
+		out     *api.OrganizationMemberList
+		members []*models.User
+	)
+
+	if orgID, err = ulid.Parse(c.Param("orgID")); err != nil {
+		c.JSON(http.StatusNotFound, api.Error("organization not found"))
+		return
+	}
+
+	if userID, err = ulid.Parse(c.Param("userID")); err != nil {
+		c.JSON(http.StatusNotFound, api.Error("user not found"))
+		return
+	}
+
+	in = &api.UserRoles{}
+	if err = c.BindJSON(in); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadRequest, api.Error("could not parse organization member data"))
+		return
+	}
+
+	if err = in.Validate(); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusUnprocessableEntity, api.Error(err))
+		return
+	}
+
+	if !s.inCurrentOrg(c, orgID) {
+		c.JSON(http.StatusNotFound, api.Error("organization not found"))
+		return
+	}
+
+	if roleIDs, err = s.resolveRoleIDs(c, in.Roles); err != nil {
+		return
+	}
+
+	if err = s.store.ReplaceOrganizationMemberRoles(c.Request.Context(), orgID, userID, roleIDs); err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			c.JSON(http.StatusNotFound, api.Error("organization or user not found"))
+			return
+		}
+
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process organization member request"))
+		return
+	}
+
+	s.audit(c, models.AuditUpdate, models.AuditOrganization, orgID.String(), nil, map[string]any{"user_id": userID, "roles": in.Roles})
+
+	if members, err = s.store.ListOrganizationMembers(c.Request.Context(), orgID); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process organization member request"))
+		return
+	}
+
+	if out, err = api.NewOrganizationMemberList(members); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process organization member request"))
+		return
+	}
+
+	switch c.NegotiateFormat(binding.MIMEJSON, binding.MIMEHTML) {
+	case binding.MIMEJSON:
+		c.JSON(http.StatusOK, out)
+	case binding.MIMEHTML:
+		htmx.SetTrigger(c, htmx.UsersUpdated)
+		c.Data(http.StatusNoContent, gin.MIMEHTML, nil)
+	}
+}
+
+// ListUserOrganizations returns the organizations that the user is a member of; the
+// HTML response is the organization switcher in the navigation bar.
+func (s *Server) ListUserOrganizations(c *gin.Context) {
+	var (
+		err    error
+		userID ulid.ULID
+		orgs   []*models.Organization
+		out    *api.OrganizationList
+	)
+
+	if userID, err = ulid.Parse(c.Param("userID")); err != nil {
+		c.JSON(http.StatusNotFound, api.Error("user not found"))
+		return
+	}
+
+	if orgs, err = s.store.ListUserOrganizations(c.Request.Context(), userID); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process user organizations request"))
+		return
+	}
+
+	if out, err = api.NewOrganizationList(orgs); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process user organizations request"))
+		return
+	}
+
+	// Set CSRF cookies so that the user can switch organizations from the switcher.
+	if htmx.IsWebRequest(c) {
+		if err = s.csrf.SetDoubleCookieToken(c); err != nil {
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, api.Error("could not process user organizations request"))
+			return
+		}
+	}
+
+	c.Negotiate(http.StatusOK, gin.Negotiate{
+		Offered:  []string{binding.MIMEJSON, binding.MIMEHTML},
+		Data:     out,
+		HTMLName: "partials/organizations/switcher.html",
+		HTMLData: scene.New(c).WithAPIData(out).With(scene.CurrentOrg, s.currentOrg(c).String()),
+	})
+}
+
+// SwitchOrganization logs the user into another organization that they are a member of
+// by issuing new tokens with the roles and permissions they have in that organization.
+// The refresh token of the current session is used so that the session continues in
+// the new organization and the authentication methods of the login are carried over.
+func (s *Server) SwitchOrganization(c *gin.Context) {
+	var (
+		err     error
+		orgID   ulid.ULID
+		userID  ulid.ULID
+		sub     gimauth.SubjectType
+		claims  *gimauth.Claims
+		member  *models.User
+		refresh *models.RefreshToken
+		amr     []string
+		out     *api.LoginReply
+	)
+
+	if orgID, err = ulid.Parse(c.Param("orgID")); err != nil {
+		c.JSON(http.StatusNotFound, api.Error("organization not found"))
+		return
+	}
+
+	if claims, err = gimauth.GetClaims(c); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusUnauthorized, api.Error("could not get user claims"))
+		return
+	}
+
+	if sub, userID, err = claims.SubjectID(); err != nil || sub != gimauth.SubjectUser {
+		c.JSON(http.StatusForbidden, api.Error("only users can switch organizations"))
+		return
+	}
+
+	if member, err = s.store.RetrieveOrganizationMember(c.Request.Context(), orgID, userID); err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			c.JSON(http.StatusNotFound, api.Error("organization not found"))
+			return
+		}
+
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process switch organization request"))
+		return
+	}
+
+	// Access tokens share the jti of their refresh token, so the refresh token is used
+	// to ensure that it cannot be exchanged for tokens in the previous organization.
+	if refresh, err = s.useRefreshToken(c, claims); err != nil {
+		// Error logging is handled in useRefreshToken
+		return
+	}
+
+	if token, _ := gimauth.GetAccessToken(c); token != "" {
+		if amr, err = s.issuer.AuthMethods(token); err != nil {
+			c.Error(err)
+		}
+	}
+
+	claims = member.Claims()
+
+	out = &api.LoginReply{}
+	if out.AccessToken, out.RefreshToken, err = s.issueTokens(c, claims, orgID, refresh.FamilyID, amr...); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error(errors.ErrInternal))
+		return
+	}
+
+	if err = auth.SetAuthCookies(c, out.AccessToken, out.RefreshToken); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error(errors.ErrInternal))
+		return
+	}
+
+	switch c.NegotiateFormat(binding.MIMEJSON, binding.MIMEHTML) {
+	case binding.MIMEJSON:
+		c.JSON(http.StatusOK, out)
+	case binding.MIMEHTML:
+		htmx.Redirect(c, http.StatusSeeOther, "/")
+	default:
+		c.AbortWithError(http.StatusNotAcceptable, errors.ErrNotAccepted)
+	}
+}
+
+// InstanceOnly rejects requesters who are logged into an organization from routes that
+// manage resources shared by every organization (e.g. webhooks, which are delivered the
+// events of all users), since they could otherwise see or change other tenants' data.
+func (s *Server) InstanceOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !s.currentOrg(c).IsZero() {
+			c.AbortWithStatusJSON(http.StatusForbidden, api.Error("this resource cannot be managed from an organization"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// ============================================================================
+// Helpers
+// ============================================================================
+
+// currentOrg returns the organization the requester is logged into from the org claim
+// of their access token or a zero ULID if they are not logged into an organization.
+func (s *Server) currentOrg(c *gin.Context) ulid.ULID {
+	token, err := gimauth.GetAccessToken(c)
+	if err != nil || token == "" {
+		return ulid.Zero
+	}
+
+	orgID, err := s.issuer.Org(token)
+	if err != nil {
+		c.Error(err)
+		return ulid.Zero
+	}
+	return orgID
+}
+
+// inCurrentOrg returns true if a resource in the organization can be accessed by the
+// requester. Requesters who are logged into an organization can only access resources
+// in that organization; requesters without an organization are not restricted.
+func (s *Server) inCurrentOrg(c *gin.Context, orgID ulid.ULID) bool {
+	current := s.currentOrg(c)
+	return current.IsZero() || current == orgID
+}
+
+// checkUserInCurrentOrg ensures that the user can be accessed by the requester. Users
+// logged into an organization can only access the members of that organization, so the
+// user is reported as not found with the message if they are not a member; requesters
+// without an organization are not restricted. If an error is returned then the response
+// has already been written.
+func (s *Server) checkUserInCurrentOrg(c *gin.Context, userID ulid.ULID, notFound string) (err error) {
+	orgID := s.currentOrg(c)
+	if orgID.IsZero() {
+		return nil
+	}
+
+	if _, err = s.store.RetrieveOrganizationMember(c.Request.Context(), orgID, userID); err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			c.JSON(http.StatusNotFound, api.Error(notFound))
+			return err
+		}
+
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error(errors.ErrInternal))
+		return err
+	}
+	return nil
+}
+
+// organizationMember loads the roles and permissions the user has in the organization
+// they are logged into. The preferred organization is used if the user is a member of
+// it; otherwise the first organization the user joined is used. If the user is not a
+// member of any organization then the user is returned unchanged.
+func (s *Server) organizationMember(ctx context.Context, user *models.User, preferred ulid.ULID) (_ *models.User, err error) {
+	var member *models.User
+	if !preferred.IsZero() {
+		if member, err = s.store.RetrieveOrganizationMember(ctx, preferred, user.ID); err == nil {
+			return member, nil
+		}
+
+		if !errors.Is(err, errors.ErrNotFound) {
+			return nil, err
+		}
+	}
+
+	var orgs []*models.Organization
+	if orgs, err = s.store.ListUserOrganizations(ctx, user.ID); err != nil {
+		return nil, err
+	}
+
+	if len(orgs) == 0 {
+		return user, nil
+	}
+	return s.store.RetrieveOrganizationMember(ctx, orgs[0].ID, user.ID)
+}
+
+// retrieveOrganization fetches the organization if it can be accessed by the requester;
+// if an error is returned then the response has already been written.
+func (s *Server) retrieveOrganization(c *gin.Context, orgID ulid.ULID) (out *api.Organization, err error) {
+	if !s.inCurrentOrg(c, orgID) {
+		c.JSON(http.StatusNotFound, api.Error("organization not found"))
+		return nil, errors.ErrNotFound
+	}
+
+	var org *models.Organization
+	if org, err = s.store.RetrieveOrganization(c.Request.Context(), orgID); err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			c.JSON(http.StatusNotFound, api.Error("organization not found"))
+			return nil, err
+		}
+
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process organization request"))
+		return nil, err
+	}
+
+	if out, err = api.NewOrganization(org); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process organization request"))
+		return nil, err
+	}
+	return out, nil
+}
+
+// ensureDefaultOrganization creates an organization from the organization configuration
+// if no organizations exist so that there is always an organization whose settings can
+// be managed from the workspace settings page; a read-only replica relies on the primary
+// to create it.
+func (s *Server) ensureDefaultOrganization(ctx context.Context) (err error) {
+	if s.conf.Database.ReadOnly {
+		return nil
+	}
+
+	var orgs []*models.Organization
+	if orgs, err = listAll(s.store.ListOrganizations(ctx, nil)); err != nil {
+		return fmt.Errorf("could not list organizations: %w", err)
+	}
+
+	if len(orgs) > 0 {
+		return nil
+	}
+
+	org, _ := (&api.Organization{
+		Name:          s.conf.Org.Name,
+		StreetAddress: s.conf.Org.StreetAddress,
+		HomepageURI:   s.conf.Org.HomepageURI,
+		SupportEmail:  s.conf.Org.SupportEmail,
+	}).Model()
+
+	if _, err = s.store.CreateOrganization(ctx, org); err != nil {
+		return fmt.Errorf("could not create default organization: %w", err)
+	}
+	return nil
+}
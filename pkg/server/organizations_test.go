@@ -0,0 +1,389 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/gimlet"
+	"go.rtnl.ai/gimlet/auth"
+	"go.rtnl.ai/quarterdeck/pkg/api/v1"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/mock"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/tidal"
+	"go.rtnl.ai/ulid"
+)
+
+var (
+	acmeOrgID   = ulid.MustParse("01KC0020000000000000000001")
+	globexOrgID = ulid.MustParse("01KC0040000000000000000002")
+)
+
+func TestListOrganizations(t *testing.T) {
+	setup := func(t *testing.T) (*mock.Store, *Server) {
+		mockStore := openMockStore(t)
+		t.Cleanup(func() { mockStore.Close() })
+
+		mockStore.OnListOrganizations = func(ctx context.Context, filter tidal.ListFilter) (tidal.Cursor[*models.Organization], error) {
+			return mock.NewCursor(testOrganizations()...), nil
+		}
+		return mockStore, newTestOAuthServer(t, mockStore)
+	}
+
+	t.Run("Unscoped", func(t *testing.T) {
+		_, srv := setup(t)
+
+		w, c := requestContext(t, http.MethodGet, "/v1/organizations", nil, nil)
+		srv.ListOrganizations(c)
+
+		var out api.OrganizationList
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &out))
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Len(t, out.Organizations, 2)
+	})
+
+	t.Run("Scoped", func(t *testing.T) {
+		mockStore, srv := setup(t)
+		mockStore.OnListOrganizations = func(_ context.Context, filter tidal.ListFilter) (tidal.Cursor[*models.Organization], error) {
+			custom, ok := filter.(*tidal.CustomFilter)
+			require.True(t, ok, "expected a custom filter for the organizations query")
+			require.Contains(t, custom.SQL, "id = :org_id")
+			require.Contains(t, custom.Args, sql.Named("org_id", globexOrgID))
+			return mock.NewCursor(testOrganizations()[1]), nil
+		}
+
+		w, c := requestContext(t, http.MethodGet, "/v1/organizations", nil, nil)
+		authorizeOrg(t, srv, c, globexOrgID)
+		srv.ListOrganizations(c)
+
+		var out api.OrganizationList
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &out))
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Len(t, out.Organizations, 1)
+		require.Equal(t, globexOrgID, out.Organizations[0].ID)
+	})
+}
+
+func TestCreateOrganization(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		mockStore.OnCreateOrganization = func(ctx context.Context, in *models.Organization) (*models.Organization, error) {
+			require.Equal(t, "Initech", in.Name)
+			require.Equal(t, "help@initech.example.com", in.SupportEmail.String)
+			in.ID = ulid.MakeSecure()
+			in.Created = time.Now()
+			in.Modified = in.Created
+			return in, nil
+		}
+
+		var event *models.AuditEvent
+		mockStore.OnCreateAuditEvent = func(ctx context.Context, in *models.AuditEvent) (*models.AuditEvent, error) {
+			event = in
+			return in, nil
+		}
+
+		w, c := requestContext(t, http.MethodPost, "/v1/organizations", []byte(`{"name": " Initech ", "support_email": "help@initech.example.com"}`), nil)
+		c.Request.Header.Set("Content-Type", "application/json")
+		srv.CreateOrganization(c)
+
+		var out api.Organization
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &out))
+		require.Equal(t, http.StatusCreated, w.Code)
+		require.Equal(t, "Initech", out.Name)
+
+		require.NotNil(t, event)
+		require.Equal(t, models.AuditOrganization, event.SubjectType)
+		require.Equal(t, out.ID.String(), event.SubjectID)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		w, c := requestContext(t, http.MethodPost, "/v1/organizations", []byte(`{"support_email": "not an email"}`), nil)
+		c.Request.Header.Set("Content-Type", "application/json")
+		srv.CreateOrganization(c)
+
+		require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		mockStore.AssertCalls(t, mock.CreateOrganization, 0)
+	})
+}
+
+func TestUpdateOrganization(t *testing.T) {
+	params := gin.Params{{Key: "orgID", Value: acmeOrgID.String()}}
+
+	t.Run("Success", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestOAuthServer(t, mockStore)
+
+		org := testOrganizations()[0]
+		mockStore.OnRetrieveOrganization = func(ctx context.Context, id ulid.ULID) (*models.Organization, error) {
+			require.Equal(t, acmeOrgID, id)
+			return org, nil
+		}
+
+		mockStore.OnUpdateOrganization = func(ctx context.Context, in *models.Organization) error {
+			require.Equal(t, acmeOrgID, in.ID)
+			org = in
+			return nil
+		}
+
+		mockStore.OnCreateAuditEvent = func(ctx context.Context, in *models.AuditEvent) (*models.AuditEvent, error) {
+			return in, nil
+		}
+
+		w, c := requestContext(t, http.MethodPut, "/v1/organizations/"+acmeOrgID.String(), []byte(`{"name": "Acme Inc", "homepage_uri": "https://acme.example.com"}`), params)
+		c.Request.Header.Set("Content-Type", "application/json")
+		authorizeOrg(t, srv, c, acmeOrgID)
+		srv.UpdateOrganization(c)
+
+		var out api.Organization
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &out))
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "Acme Inc", out.Name)
+		require.Equal(t, "https://acme.example.com", out.HomepageURI)
+		mockStore.AssertCalls(t, mock.UpdateOrganization, 1)
+	})
+
+	t.Run("OtherOrganization", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestOAuthServer(t, mockStore)
+
+		w, c := requestContext(t, http.MethodPut, "/v1/organizations/"+acmeOrgID.String(), []byte(`{"name": "Acme Inc"}`), params)
+		c.Request.Header.Set("Content-Type", "application/json")
+		authorizeOrg(t, srv, c, globexOrgID)
+		srv.UpdateOrganization(c)
+
+		require.Equal(t, http.StatusNotFound, w.Code)
+		mockStore.AssertCalls(t, mock.UpdateOrganization, 0)
+	})
+}
+
+func TestEnsureDefaultOrganization(t *testing.T) {
+	setup := func(t *testing.T, existing ...*models.Organization) (*mock.Store, *Server) {
+		mockStore := openMockStore(t)
+		t.Cleanup(func() { mockStore.Close() })
+
+		mockStore.OnListOrganizations = func(context.Context, tidal.ListFilter) (tidal.Cursor[*models.Organization], error) {
+			return mock.NewCursor(existing...), nil
+		}
+		mockStore.OnCreateOrganization = func(_ context.Context, in *models.Organization) (*models.Organization, error) {
+			require.Equal(t, "Acme Corporation", in.Name)
+			return in, nil
+		}
+
+		srv := newTestServer(mockStore)
+		srv.conf.Org.Name = "Acme Corporation"
+		return mockStore, srv
+	}
+
+	t.Run("Create", func(t *testing.T) {
+		mockStore, srv := setup(t)
+		require.NoError(t, srv.ensureDefaultOrganization(context.Background()))
+		mockStore.AssertCalls(t, mock.CreateOrganization, 1)
+	})
+
+	t.Run("Exists", func(t *testing.T) {
+		mockStore, srv := setup(t, testOrganizations()...)
+		require.NoError(t, srv.ensureDefaultOrganization(context.Background()))
+		mockStore.AssertCalls(t, mock.CreateOrganization, 0)
+	})
+
+	t.Run("ReadOnly", func(t *testing.T) {
+		mockStore, srv := setup(t)
+		srv.conf.Database.ReadOnly = true
+		require.NoError(t, srv.ensureDefaultOrganization(context.Background()))
+		mockStore.AssertCalls(t, mock.ListOrganizations, 0)
+		mockStore.AssertCalls(t, mock.CreateOrganization, 0)
+	})
+}
+
+func TestReplaceOrganizationMemberRoles(t *testing.T) {
+	userID := ulid.MakeSecure()
+	params := gin.Params{{Key: "orgID", Value: acmeOrgID.String()}, {Key: "userID", Value: userID.String()}}
+
+	setup := func(t *testing.T) *mock.Store {
+		mockStore := openMockStore(t)
+		t.Cleanup(func() { mockStore.Close() })
+
+		mockStore.OnListRoles = func(ctx context.Context, filter tidal.ListFilter) (tidal.Cursor[*models.Role], error) {
+			return mock.NewCursor(testRoles()...), nil
+		}
+		mockStore.OnRetrieveRole = retrieveTestRole
+		return mockStore
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		mockStore := setup(t)
+		srv := newTestServer(mockStore)
+
+		mockStore.OnReplaceOrganizationMemberRoles = func(ctx context.Context, orgID, memberID ulid.ULID, roleIDs []int64) error {
+			require.Equal(t, acmeOrgID, orgID)
+			require.Equal(t, userID, memberID)
+			require.Equal(t, []int64{2}, roleIDs)
+			return nil
+		}
+
+		mockStore.OnListOrganizationMembers = func(ctx context.Context, orgID ulid.ULID) ([]*models.User, error) {
+			user := &models.User{
+				BaseModel: tidal.BaseModel{ID: userID},
+				Email:     "jane@example.com",
+				Roles:     []models.Role{{ID: 2, Title: "viewer"}},
+				OrgID:     orgID,
+			}
+			return []*models.User{user}, nil
+		}
+
+		mockStore.OnCreateAuditEvent = func(ctx context.Context, in *models.AuditEvent) (*models.AuditEvent, error) {
+			return in, nil
+		}
+
+		w, c := requestContext(t, http.MethodPut, "/v1/organizations/"+acmeOrgID.String()+"/members/"+userID.String(), []byte(`{"roles": ["Viewer"]}`), params)
+		c.Request.Header.Set("Content-Type", "application/json")
+		gimlet.Set(c, gimlet.KeyUserClaims, &auth.Claims{Permissions: []string{"users:manage"}})
+		srv.ReplaceOrganizationMemberRoles(c)
+
+		var out api.OrganizationMemberList
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &out))
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Len(t, out.Members, 1)
+		require.Len(t, out.Members[0].Roles, 1)
+		require.Equal(t, "viewer", out.Members[0].Roles[0].Title)
+	})
+
+	t.Run("UnknownRole", func(t *testing.T) {
+		mockStore := setup(t)
+		srv := newTestServer(mockStore)
+
+		w, c := requestContext(t, http.MethodPut, "/v1/organizations/"+acmeOrgID.String()+"/members/"+userID.String(), []byte(`{"roles": ["superuser"]}`), params)
+		c.Request.Header.Set("Content-Type", "application/json")
+		srv.ReplaceOrganizationMemberRoles(c)
+
+		require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		mockStore.AssertCalls(t, mock.ReplaceOrganizationMemberRoles, 0)
+	})
+
+	t.Run("Escalation", func(t *testing.T) {
+		mockStore := setup(t)
+		srv := newTestServer(mockStore)
+
+		w, c := requestContext(t, http.MethodPut, "/v1/organizations/"+acmeOrgID.String()+"/members/"+userID.String(), []byte(`{"roles": ["admin"]}`), params)
+		c.Request.Header.Set("Content-Type", "application/json")
+		gimlet.Set(c, gimlet.KeyUserClaims, &auth.Claims{Permissions: []string{"users:manage"}})
+		srv.ReplaceOrganizationMemberRoles(c)
+
+		require.Equal(t, http.StatusForbidden, w.Code)
+		mockStore.AssertCalls(t, mock.ReplaceOrganizationMemberRoles, 0)
+	})
+}
+
+func TestOrganizationMember(t *testing.T) {
+	user := &models.User{BaseModel: tidal.BaseModel{ID: ulid.MakeSecure()}, Email: "jane@example.com"}
+
+	member := func(orgID ulid.ULID) *models.User {
+		return &models.User{BaseModel: user.BaseModel, Email: user.Email, OrgID: orgID}
+	}
+
+	t.Run("Preferred", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		mockStore.OnRetrieveOrganizationMember = func(ctx context.Context, orgID, userID ulid.ULID) (*models.User, error) {
+			return member(orgID), nil
+		}
+
+		out, err := srv.organizationMember(context.Background(), user, globexOrgID)
+		require.NoError(t, err)
+		require.Equal(t, globexOrgID, out.OrgID)
+		mockStore.AssertCalls(t, mock.ListUserOrganizations, 0)
+	})
+
+	t.Run("NotPreferredMember", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		mockStore.OnRetrieveOrganizationMember = func(ctx context.Context, orgID, userID ulid.ULID) (*models.User, error) {
+			if orgID == globexOrgID {
+				return nil, errors.ErrNotFound
+			}
+			return member(orgID), nil
+		}
+
+		mockStore.OnListUserOrganizations = func(ctx context.Context, userID ulid.ULID) ([]*models.Organization, error) {
+			return testOrganizations()[:1], nil
+		}
+
+		out, err := srv.organizationMember(context.Background(), user, globexOrgID)
+		require.NoError(t, err)
+		require.Equal(t, acmeOrgID, out.OrgID, "expected the first organization the user joined")
+	})
+
+	t.Run("NoOrganizations", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		mockStore.OnListUserOrganizations = func(ctx context.Context, userID ulid.ULID) ([]*models.Organization, error) {
+			return nil, nil
+		}
+
+		out, err := srv.organizationMember(context.Background(), user, ulid.Zero)
+		require.NoError(t, err)
+		require.Same(t, user, out)
+		require.True(t, out.OrgID.IsZero())
+		mockStore.AssertCalls(t, mock.RetrieveOrganizationMember, 0)
+	})
+}
+
+func TestSwitchOrganization(t *testing.T) {
+	mockStore := openMockStore(t)
+	defer mockStore.Close()
+	srv := newTestOAuthServer(t, mockStore)
+
+	mockStore.OnRetrieveOrganizationMember = func(ctx context.Context, orgID, userID ulid.ULID) (*models.User, error) {
+		return nil, errors.ErrNotFound
+	}
+
+	params := gin.Params{{Key: "orgID", Value: globexOrgID.String()}}
+	w, c := requestContext(t, http.MethodPost, "/v1/organizations/"+globexOrgID.String()+"/switch", nil, params)
+	authorizeOrg(t, srv, c, acmeOrgID)
+	srv.SwitchOrganization(c)
+
+	require.Equal(t, http.StatusNotFound, w.Code, "users cannot switch into organizations they are not a member of")
+	mockStore.AssertCalls(t, mock.UseRefreshToken, 0)
+}
+
+// authorizeOrg adds user claims and a bearer access token logged into the organization
+// to the request as though the request had been authenticated.
+func authorizeOrg(t *testing.T, srv *Server, c *gin.Context, orgID ulid.ULID, permissions ...string) {
+	t.Helper()
+	claims := &auth.Claims{Name: "Jane Doe", Permissions: permissions}
+	claims.SetSubjectID(auth.SubjectUser, ulid.MakeSecure())
+
+	accessToken, _, err := srv.issuer.CreateTokens(claims, orgID)
+	require.NoError(t, err)
+
+	c.Request.Header.Set("Authorization", "Bearer "+accessToken)
+	gimlet.Set(c, gimlet.KeyUserClaims, claims)
+}
+
+func testOrganizations() []*models.Organization {
+	return []*models.Organization{
+		{BaseModel: tidal.BaseModel{ID: acmeOrgID, Created: time.Now(), Modified: time.Now()}, Name: "Acme Corporation"},
+		{BaseModel: tidal.BaseModel{ID: globexOrgID, Created: time.Now(), Modified: time.Now()}, Name: "Globex"},
+	}
+}
@@ -6,9 +6,12 @@ import (
 	"net/url"
 
 	"github.com/gin-gonic/gin"
+	gimauth "go.rtnl.ai/gimlet/auth"
 	"go.rtnl.ai/quarterdeck/pkg/api/v1"
 	"go.rtnl.ai/quarterdeck/pkg/auth"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
 	"go.rtnl.ai/quarterdeck/pkg/web/scene"
+	"go.rtnl.ai/ulid"
 	"go.rtnl.ai/x/rlog"
 )
 
@@ -68,6 +71,70 @@ func (s *Server) ResetPasswordPage(c *gin.Context) {
 	c.HTML(http.StatusOK, "auth/reset/password.html", scene.New(c))
 }
 
+// AcceptInvitePage allows a user who was invited to Quarterdeck by email to set their
+// name and password; the invite is verified when the form is submitted.
+func (s *Server) AcceptInvitePage(c *gin.Context) {
+	// Read the token string from the URL parameters.
+	in := &api.URLVerification{}
+	if err := c.BindQuery(in); err != nil {
+		rlog.DebugAttrs(c.Request.Context(), "could not parse query string", slog.Any("err", err))
+	}
+
+	// Set the token into a cookie so that it can be parsed when the form is submitted.
+	// NOTE: no verification is performed here, just on accept-invite.
+	auth.SetInviteTokenCookie(c, in.Token, s.conf.Auth.GetAcceptInviteURL().Hostname())
+
+	c.HTML(http.StatusOK, "auth/invite/accept.html", scene.New(c))
+}
+
+// VerifyEmailPage verifies the email address of the user from the emailed link; the
+// page submits the verification so that link scanners cannot verify the address.
+func (s *Server) VerifyEmailPage(c *gin.Context) {
+	// Read the token string from the URL parameters.
+	in := &api.URLVerification{}
+	if err := c.BindQuery(in); err != nil {
+		rlog.DebugAttrs(c.Request.Context(), "could not parse query string", slog.Any("err", err))
+	}
+
+	// Set the token into a cookie so that it can be parsed when the page submits it.
+	// NOTE: no verification is performed here, just on verify-email.
+	auth.SetVerifyEmailTokenCookie(c, in.Token, s.conf.Auth.GetVerifyEmailURL().Hostname())
+
+	c.HTML(http.StatusOK, "auth/verify/email.html", scene.New(c))
+}
+
+// ConfirmEmailPage confirms the new email address of the user from the emailed link;
+// the page submits the confirmation so that link scanners cannot change the address.
+func (s *Server) ConfirmEmailPage(c *gin.Context) {
+	// Read the token string from the URL parameters.
+	in := &api.URLVerification{}
+	if err := c.BindQuery(in); err != nil {
+		rlog.DebugAttrs(c.Request.Context(), "could not parse query string", slog.Any("err", err))
+	}
+
+	// Set the token into a cookie so that it can be parsed when the page submits it.
+	// NOTE: no verification is performed here, just on confirm-email.
+	auth.SetChangeEmailTokenCookie(c, in.Token, s.conf.Auth.GetConfirmEmailURL().Hostname())
+
+	c.HTML(http.StatusOK, "auth/email/confirm.html", scene.New(c))
+}
+
+// RevertEmailPage allows the user to restore the previous email address of their
+// account from the link emailed to it; the revert is submitted from a form on the page.
+func (s *Server) RevertEmailPage(c *gin.Context) {
+	// Read the token string from the URL parameters.
+	in := &api.URLVerification{}
+	if err := c.BindQuery(in); err != nil {
+		rlog.DebugAttrs(c.Request.Context(), "could not parse query string", slog.Any("err", err))
+	}
+
+	// Set the token into a cookie so that it can be parsed when the form is submitted.
+	// NOTE: no verification is performed here, just on revert-email.
+	auth.SetRevertEmailTokenCookie(c, in.Token, s.conf.Auth.GetRevertEmailURL().Hostname())
+
+	c.HTML(http.StatusOK, "auth/email/revert.html", scene.New(c))
+}
+
 //===========================================================================
 // Workspace Pages
 //===========================================================================
@@ -78,8 +145,31 @@ func (s *Server) Dashboard(c *gin.Context) {
 	c.HTML(http.StatusOK, "pages/home/index.html", scene.New(c).ForPage("dashboard"))
 }
 
+// WorkspaceSettingsPage manages the settings of the organization the user is logged
+// into; if the user is not logged into an organization the oldest organization is used.
 func (s *Server) WorkspaceSettingsPage(c *gin.Context) {
-	c.HTML(http.StatusOK, "pages/settings/index.html", scene.New(c).ForPage("settings"))
+	// Set CSRF cookies for the organization settings form.
+	if err := s.csrf.SetDoubleCookieToken(c); err != nil {
+		s.Error(c, err)
+		return
+	}
+
+	ctx := scene.New(c).ForPage("settings")
+	if orgID := s.currentOrg(c); !orgID.IsZero() {
+		ctx = ctx.With(scene.CurrentOrg, orgID.String())
+	} else {
+		orgs, err := listAll(s.store.ListOrganizations(c.Request.Context(), nil))
+		if err != nil {
+			s.Error(c, err)
+			return
+		}
+
+		if len(orgs) > 0 {
+			ctx = ctx.With(scene.CurrentOrg, orgs[0].ID.String())
+		}
+	}
+
+	c.HTML(http.StatusOK, "pages/settings/index.html", ctx)
 }
 
 func (s *Server) GovernancePage(c *gin.Context) {
@@ -103,6 +193,52 @@ func (s *Server) ProfileSettingsPage(c *gin.Context) {
 	c.HTML(http.StatusOK, "pages/profile/account.html", scene.New(c).WithForgotPasswordURL())
 }
 
+func (s *Server) ProfileSessionsPage(c *gin.Context) {
+	// Set CSRF cookies for the session revocation buttons.
+	if err := s.csrf.SetDoubleCookieToken(c); err != nil {
+		s.Error(c, err)
+		return
+	}
+	c.HTML(http.StatusOK, "pages/profile/sessions.html", scene.New(c))
+}
+
+// ProfileSecurityPage allows the user to set up or disable two-factor authentication
+// and to regenerate their recovery codes.
+func (s *Server) ProfileSecurityPage(c *gin.Context) {
+	var (
+		err    error
+		claims *gimauth.Claims
+		userID ulid.ULID
+		user   *models.User
+	)
+
+	// Set CSRF cookies for the MFA enrollment forms.
+	if err = s.csrf.SetDoubleCookieToken(c); err != nil {
+		s.Error(c, err)
+		return
+	}
+
+	if claims, err = gimauth.GetClaims(c); err != nil {
+		s.Error(c, err)
+		return
+	}
+
+	if _, userID, err = claims.SubjectID(); err != nil {
+		s.Error(c, err)
+		return
+	}
+
+	if user, err = s.store.RetrieveUser(c.Request.Context(), userID); err != nil {
+		s.Error(c, err)
+		return
+	}
+
+	ctx := scene.New(c)
+	ctx["MFAEnabled"] = user.MFAEnabled()
+	ctx["RecoveryCodesRemaining"] = len(user.RecoveryCodes)
+	c.HTML(http.StatusOK, "pages/profile/security.html", ctx)
+}
+
 func (s *Server) ProfileDeletePage(c *gin.Context) {
 	c.HTML(http.StatusOK, "pages/profile/delete.html", scene.New(c))
 }
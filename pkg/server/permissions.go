@@ -0,0 +1,294 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"go.rtnl.ai/quarterdeck/pkg/api/v1"
+	"go.rtnl.ai/quarterdeck/pkg/auth/permissions"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/quarterdeck/pkg/web/htmx"
+	"go.rtnl.ai/quarterdeck/pkg/web/scene"
+)
+
+// ============================================================================
+// Permission resource handlers
+// ============================================================================
+
+// ListPermissions returns all of the permissions that can be added to roles.
+func (s *Server) ListPermissions(c *gin.Context) {
+	var (
+		err         error
+		in          *api.PageQuery
+		permissions []*models.Permission
+		out         *api.PermissionList
+	)
+
+	in = &api.PageQuery{}
+	if err = c.BindQuery(in); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadRequest, api.Error("invalid query parameters"))
+		return
+	}
+
+	// Every permission is listed since the roles page offers all of them to be added;
+	// permissions have integer IDs and are not paginated by cursor.
+	if permissions, err = listAll(s.store.ListPermissions(c.Request.Context(), nil)); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process permissions list request"))
+		return
+	}
+
+	if out, err = api.NewPermissionList(permissions); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process permissions list request"))
+		return
+	}
+
+	// Content negotiation
+	c.Negotiate(http.StatusOK, gin.Negotiate{
+		Offered:  []string{binding.MIMEJSON, binding.MIMEHTML},
+		Data:     out,
+		HTMLName: "partials/permissions/list.html",
+		HTMLData: scene.New(c).WithAPIData(out),
+	})
+}
+
+func (s *Server) CreatePermission(c *gin.Context) {
+	var (
+		err        error
+		in         *api.Permission
+		permission *models.Permission
+		out        *api.Permission
+	)
+
+	in = &api.Permission{}
+	if err = c.BindJSON(in); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadRequest, api.Error("could not parse permission data"))
+		return
+	}
+
+	if err = in.Validate(); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusUnprocessableEntity, api.Error(err))
+		return
+	}
+
+	if permission, err = s.store.CreatePermission(c.Request.Context(), in.Model()); err != nil {
+		if errors.Is(err, errors.ErrAlreadyExists) {
+			c.JSON(http.StatusConflict, api.Error("a permission with this title already exists"))
+			return
+		}
+
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process create permission request"))
+		return
+	}
+
+	if out, err = api.NewPermission(permission); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process create permission request"))
+		return
+	}
+
+	s.audit(c, models.AuditCreate, models.AuditPermission, strconv.FormatInt(permission.ID, 10), nil, out)
+
+	switch c.NegotiateFormat(binding.MIMEJSON, binding.MIMEHTML) {
+	case binding.MIMEJSON:
+		c.JSON(http.StatusCreated, out)
+	case binding.MIMEHTML:
+		htmx.SetTrigger(c, htmx.PermissionsUpdated)
+		c.Data(http.StatusNoContent, gin.MIMEHTML, nil)
+	}
+}
+
+func (s *Server) PermissionDetail(c *gin.Context) {
+	var (
+		err          error
+		permissionID int64
+		out          *api.Permission
+	)
+
+	if permissionID, err = parseSequenceID(c, "permissionID"); err != nil {
+		c.JSON(http.StatusNotFound, api.Error("permission not found"))
+		return
+	}
+
+	if out, err = s.retrievePermission(c, permissionID); err != nil {
+		return
+	}
+
+	c.JSON(http.StatusOK, out)
+}
+
+func (s *Server) UpdatePermission(c *gin.Context) {
+	var (
+		err          error
+		permissionID int64
+		in           *api.Permission
+		before       *api.Permission
+		out          *api.Permission
+	)
+
+	if permissionID, err = parseSequenceID(c, "permissionID"); err != nil {
+		c.JSON(http.StatusNotFound, api.Error("permission not found"))
+		return
+	}
+
+	in = &api.Permission{}
+	if err = c.BindJSON(in); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadRequest, api.Error("could not parse permission data"))
+		return
+	}
+
+	if err = in.Validate(); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusUnprocessableEntity, api.Error(err))
+		return
+	}
+
+	// Set the permission ID only after validation
+	in.ID = int(permissionID)
+
+	// Retrieve the permission before it is updated to record the changes in the audit log
+	if before, err = s.retrievePermission(c, permissionID); err != nil {
+		return
+	}
+
+	if err = s.store.UpdatePermission(c.Request.Context(), in.Model()); err != nil {
+		switch {
+		case errors.Is(err, errors.ErrNotFound):
+			c.JSON(http.StatusNotFound, api.Error("permission not found"))
+		case errors.Is(err, errors.ErrAlreadyExists):
+			c.JSON(http.StatusConflict, api.Error("a permission with this title already exists"))
+		case errors.Is(err, errors.ErrProtected):
+			c.JSON(http.StatusForbidden, api.Error("built-in permissions cannot be renamed"))
+		default:
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, api.Error("could not process update permission request"))
+		}
+		return
+	}
+
+	if out, err = s.retrievePermission(c, permissionID); err != nil {
+		return
+	}
+
+	s.audit(c, models.AuditUpdate, models.AuditPermission, strconv.FormatInt(permissionID, 10), before, out)
+
+	switch c.NegotiateFormat(binding.MIMEJSON, binding.MIMEHTML) {
+	case binding.MIMEJSON:
+		c.JSON(http.StatusOK, out)
+	case binding.MIMEHTML:
+		htmx.SetTrigger(c, htmx.PermissionsUpdated)
+		c.Data(http.StatusNoContent, gin.MIMEHTML, nil)
+	}
+}
+
+// DeletePermission deletes the permission, removing it from any roles and API keys
+// that it was assigned to.
+func (s *Server) DeletePermission(c *gin.Context) {
+	var (
+		err          error
+		permissionID int64
+	)
+
+	if permissionID, err = parseSequenceID(c, "permissionID"); err != nil {
+		c.JSON(http.StatusNotFound, api.Error("permission not found"))
+		return
+	}
+
+	if err = s.store.DeletePermission(c.Request.Context(), permissionID); err != nil {
+		switch {
+		case errors.Is(err, errors.ErrNotFound):
+			c.JSON(http.StatusNotFound, api.Error("permission not found"))
+			return
+		case errors.Is(err, errors.ErrProtected):
+			c.JSON(http.StatusForbidden, api.Error("built-in permissions cannot be deleted"))
+			return
+		}
+
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process delete permission request"))
+		return
+	}
+
+	s.audit(c, models.AuditDelete, models.AuditPermission, strconv.FormatInt(permissionID, 10), nil, nil)
+
+	if htmx.IsHTMXRequest(c) {
+		// Roles are also reloaded since the permission is removed from them.
+		htmx.SetTrigger(c, htmx.PermissionsUpdated, htmx.RolesUpdated)
+		c.Data(http.StatusNoContent, gin.MIMEHTML, nil)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.Reply{Success: true})
+}
+
+// retrievePermission fetches the permission for an update; if an error is returned
+// then the response has already been written.
+func (s *Server) retrievePermission(c *gin.Context, permissionID int64) (out *api.Permission, err error) {
+	var permission *models.Permission
+	if permission, err = s.store.RetrievePermission(c.Request.Context(), permissionID); err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			c.JSON(http.StatusNotFound, api.Error("permission not found"))
+			return nil, err
+		}
+
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process permission request"))
+		return nil, err
+	}
+
+	if out, err = api.NewPermission(permission); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process permission request"))
+		return nil, err
+	}
+	return out, nil
+}
+
+// ensureBuiltinPermissions creates any built-in Quarterdeck permissions that are
+// missing from the database as protected records in the quarterdeck namespace. The
+// server refuses to start if a permission with a built-in title exists in another
+// namespace since it would grant Quarterdeck access to whoever can manage it. A
+// read-only replica only checks the existing permissions since the primary creates them.
+func (s *Server) ensureBuiltinPermissions(ctx context.Context) (err error) {
+	for _, permission := range permissions.Builtin {
+		var existing *models.Permission
+		if existing, err = s.store.RetrievePermissionByTitle(ctx, permission.String()); err == nil {
+			if !strings.EqualFold(existing.Namespace, permissions.Quarterdeck) {
+				return fmt.Errorf("the %s permission must be in the %s namespace but is in the %q namespace", permission, permissions.Quarterdeck, existing.Namespace)
+			}
+			continue
+		}
+
+		if !errors.Is(err, errors.ErrNotFound) {
+			return fmt.Errorf("could not retrieve %s permission: %w", permission, err)
+		}
+
+		if s.conf.Database.ReadOnly {
+			continue
+		}
+
+		model := &models.Permission{
+			Title:       permission.String(),
+			Description: permission.Description(),
+			Namespace:   permissions.Quarterdeck,
+			Protected:   true,
+		}
+
+		if _, err = s.store.CreatePermission(ctx, model); err != nil {
+			return fmt.Errorf("could not create %s permission: %w", permission, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/quarterdeck/pkg/api/v1"
+	"go.rtnl.ai/quarterdeck/pkg/auth/permissions"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/mock"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/tidal"
+)
+
+func TestListPermissions(t *testing.T) {
+	mockStore := openMockStore(t)
+	defer mockStore.Close()
+	srv := newTestServer(mockStore)
+
+	mockStore.OnListPermissions = func(ctx context.Context, filter tidal.ListFilter) (tidal.Cursor[*models.Permission], error) {
+		return mock.NewCursor(
+			&models.Permission{ID: 8, Title: "config:view", Created: time.Now(), Modified: time.Now()},
+			&models.Permission{ID: 9, Title: "config:manage", Created: time.Now(), Modified: time.Now()},
+		), nil
+	}
+
+	w, c := requestContext(t, http.MethodGet, "/v1/permissions", nil, nil)
+	srv.ListPermissions(c)
+
+	var out api.PermissionList
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &out))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, out.Permissions, 2)
+	require.Equal(t, 8, out.Permissions[0].ID)
+	mockStore.AssertCalls(t, mock.ListPermissions, 1)
+}
+
+func TestCreatePermission(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		mockStore.OnCreatePermission = func(ctx context.Context, in *models.Permission) (*models.Permission, error) {
+			require.Equal(t, "reports:export", in.Title)
+			require.Equal(t, "reports", in.Namespace)
+			require.False(t, in.Protected)
+			in.ID = 11
+			in.Created = time.Now()
+			in.Modified = in.Created
+			return in, nil
+		}
+
+		var event *models.AuditEvent
+		mockStore.OnCreateAuditEvent = func(ctx context.Context, in *models.AuditEvent) (*models.AuditEvent, error) {
+			event = in
+			return in, nil
+		}
+
+		w, c := requestContext(t, http.MethodPost, "/v1/permissions", []byte(`{"title": " reports:export ", "namespace": "reports", "description": "Export reports"}`), nil)
+		c.Request.Header.Set("Content-Type", "application/json")
+		srv.CreatePermission(c)
+
+		var out api.Permission
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &out))
+		require.Equal(t, http.StatusCreated, w.Code)
+		require.Equal(t, 11, out.ID)
+
+		require.NotNil(t, event)
+		require.Equal(t, models.AuditPermission, event.SubjectType)
+		require.Equal(t, "11", event.SubjectID)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		w, c := requestContext(t, http.MethodPost, "/v1/permissions", []byte(`{"id": 4}`), nil)
+		c.Request.Header.Set("Content-Type", "application/json")
+		srv.CreatePermission(c)
+
+		require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		require.Equal(t, "3 validation errors occurred", parseReply(t, w).Error)
+		mockStore.AssertCalls(t, mock.CreatePermission, 0)
+	})
+
+	t.Run("Conflict", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		mockStore.OnCreatePermission = func(ctx context.Context, in *models.Permission) (*models.Permission, error) {
+			return nil, errors.ErrAlreadyExists
+		}
+
+		w, c := requestContext(t, http.MethodPost, "/v1/permissions", []byte(`{"title": "reports:export", "namespace": "reports"}`), nil)
+		c.Request.Header.Set("Content-Type", "application/json")
+		srv.CreatePermission(c)
+
+		require.Equal(t, http.StatusConflict, w.Code)
+	})
+}
+
+func TestUpdatePermission(t *testing.T) {
+	mockStore := openMockStore(t)
+	defer mockStore.Close()
+	srv := newTestServer(mockStore)
+
+	permission := &models.Permission{ID: 11, Title: "reports:export", Namespace: "reports", Description: "Export reports"}
+	mockStore.OnRetrievePermission = func(ctx context.Context, id int64) (*models.Permission, error) {
+		require.Equal(t, int64(11), id)
+		return permission, nil
+	}
+
+	mockStore.OnUpdatePermission = func(ctx context.Context, in *models.Permission) error {
+		permission = in
+		return nil
+	}
+
+	var event *models.AuditEvent
+	mockStore.OnCreateAuditEvent = func(ctx context.Context, in *models.AuditEvent) (*models.AuditEvent, error) {
+		event = in
+		return in, nil
+	}
+
+	w, c := requestContext(t, http.MethodPut, "/v1/permissions/11", []byte(`{"title": "reports:export", "namespace": "reports", "description": "Export monthly reports"}`), gin.Params{{Key: "permissionID", Value: "11"}})
+	c.Request.Header.Set("Content-Type", "application/json")
+	srv.UpdatePermission(c)
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NotNil(t, event)
+	require.JSONEq(t, `{"description":{"from":"Export reports","to":"Export monthly reports"}}`, event.Diff.String)
+}
+
+func TestDeletePermission(t *testing.T) {
+	mockStore := openMockStore(t)
+	defer mockStore.Close()
+	srv := newTestServer(mockStore)
+
+	mockStore.OnDeletePermission = func(ctx context.Context, id int64) error {
+		switch id {
+		case 11:
+			return nil
+		case 1:
+			return errors.ErrProtected
+		default:
+			return errors.ErrNotFound
+		}
+	}
+
+	mockStore.OnCreateAuditEvent = func(ctx context.Context, in *models.AuditEvent) (*models.AuditEvent, error) {
+		return in, nil
+	}
+
+	w, c := requestContext(t, http.MethodDelete, "/v1/permissions/11", nil, gin.Params{{Key: "permissionID", Value: "11"}})
+	srv.DeletePermission(c)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w, c = requestContext(t, http.MethodDelete, "/v1/permissions/12", nil, gin.Params{{Key: "permissionID", Value: "12"}})
+	srv.DeletePermission(c)
+	require.Equal(t, http.StatusNotFound, w.Code)
+
+	w, c = requestContext(t, http.MethodDelete, "/v1/permissions/1", nil, gin.Params{{Key: "permissionID", Value: "1"}})
+	srv.DeletePermission(c)
+	require.Equal(t, http.StatusForbidden, w.Code)
+
+	mockStore.AssertCalls(t, mock.CreateAuditEvent, 1)
+}
+
+func TestEnsureBuiltinPermissions(t *testing.T) {
+	mockStore := openMockStore(t)
+	defer mockStore.Close()
+	srv := newTestServer(mockStore)
+
+	namespace := permissions.Quarterdeck
+	mockStore.OnRetrievePermissionByTitle = func(ctx context.Context, title string) (*models.Permission, error) {
+		if title == permissions.UsersView.String() {
+			return &models.Permission{ID: 1, Title: permissions.UsersView.String(), Namespace: namespace, Protected: true}, nil
+		}
+		return nil, errors.ErrNotFound
+	}
+
+	created := make(map[string]*models.Permission)
+	mockStore.OnCreatePermission = func(ctx context.Context, in *models.Permission) (*models.Permission, error) {
+		created[in.Title] = in
+		return in, nil
+	}
+
+	require.NoError(t, srv.ensureBuiltinPermissions(context.Background()))
+	require.Len(t, created, len(permissions.Builtin)-1)
+	require.NotContains(t, created, permissions.UsersView.String())
+
+	for title, permission := range created {
+		require.Equal(t, permissions.Quarterdeck, permission.Namespace, "expected %s to be in the quarterdeck namespace", title)
+		require.True(t, permission.Protected, "expected %s to be protected", title)
+		require.NotEmpty(t, permission.Description)
+	}
+
+	// A read-only replica does not create the missing permissions.
+	srv.conf.Database.ReadOnly = true
+	require.NoError(t, srv.ensureBuiltinPermissions(context.Background()))
+	mockStore.AssertCalls(t, mock.CreatePermission, len(permissions.Builtin)-1)
+
+	// A permission with a built-in title in another namespace prevents the server from
+	// starting rather than granting access to whoever manages that namespace.
+	namespace = "users"
+	require.ErrorContains(t, srv.ensureBuiltinPermissions(context.Background()), `the users:view permission must be in the quarterdeck namespace but is in the "users" namespace`)
+}
@@ -0,0 +1,326 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	gimauth "go.rtnl.ai/gimlet/auth"
+	"go.rtnl.ai/quarterdeck/pkg/api/v1"
+	"go.rtnl.ai/quarterdeck/pkg/auth/permissions"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/ulid"
+)
+
+// access describes who is allowed to make a request to a route.
+type access uint8
+
+const (
+	accessPublic          access = iota // No authentication is required
+	accessAuthenticated                 // Any authenticated user or api key
+	accessPermitted                     // The requester must have the policy permission
+	accessSelfOrPermitted               // The user in the :userID param or a requester with the policy permission
+	accessSelf                          // Only the user in the :userID param
+	accessAPIKeyPermitted               // Only api keys that have the policy permission
+)
+
+// policy authorizes requests to a route once the requester has been authenticated.
+type policy struct {
+	access     access
+	permission permissions.Permission
+}
+
+var (
+	public        = policy{access: accessPublic}
+	authenticated = policy{access: accessAuthenticated}
+	self          = policy{access: accessSelf}
+)
+
+// requires returns a policy that only allows requesters with the permission.
+func requires(permission permissions.Permission) policy {
+	return policy{access: accessPermitted, permission: permission}
+}
+
+// selfOr returns a policy that allows users to access their own resources and any
+// other requester that has the permission.
+func selfOr(permission permissions.Permission) policy {
+	return policy{access: accessSelfOrPermitted, permission: permission}
+}
+
+// apiKeyWith returns a policy that only allows api keys with the permission, e.g. for
+// services such as identity providers that provision users.
+func apiKeyWith(permission permissions.Permission) policy {
+	return policy{access: accessAPIKeyPermitted, permission: permission}
+}
+
+// routePolicies maps every route registered by setupRoutes (by its method and full
+// path) to the policy that authorizes it. A route that is not in this map or covered
+// by a prefix policy is rejected by the Authorization middleware.
+//
+// NOTE: when adding a route, also add its policy here; TestRoutePolicies will fail
+// if a registered route does not have a policy.
+var routePolicies = map[string]policy{
+	// Kubernetes probes and error pages
+	"GET /healthz":     public,
+	"GET /livez":       public,
+	"GET /readyz":      public,
+	"GET /not-found":   public,
+	"GET /not-allowed": public,
+	"GET /error":       public,
+
+	// Static files
+	"GET /static/*filepath":  public,
+	"HEAD /static/*filepath": public,
+
+	// Unauthenticated web UI
+	"GET /login":                            public,
+	"GET /logout":                           public,
+	"GET /forgot-password":                  public,
+	"GET /forgot-password/sent":             public,
+	"GET /reset-password":                   public,
+	"GET /invite":                           public,
+	"GET /verify-email":                     public,
+	"GET /confirm-email":                    public,
+	"GET /revert-email":                     public,
+	"GET /.well-known/jwks.json":            public,
+	"GET /.well-known/security.txt":         public,
+	"GET /.well-known/openid-configuration": public,
+
+	// Authenticated web UI; the pages load their data from the API so the API routes
+	// enforce the permissions needed to view it.
+	"GET /":                 authenticated,
+	"GET /settings":         authenticated,
+	"GET /governance":       authenticated,
+	"GET /activity":         authenticated,
+	"GET /apikeys":          authenticated,
+	"GET /profile":          authenticated,
+	"GET /profile/account":  authenticated,
+	"GET /profile/sessions": authenticated,
+	"GET /profile/security": authenticated,
+	"GET /profile/delete":   authenticated,
+	"GET /oauth/authorize":  authenticated,
+
+	// OAuth2 endpoints authenticate clients with their credentials
+	"POST /oauth/token":      public,
+	"POST /oauth/introspect": public,
+	"POST /oauth/revoke":     public,
+
+	// Unauthenticated API
+	"GET /v1/status":                public,
+	"GET /v1/docs/openapi.:ext":     public,
+	"GET /v1/docs":                  public,
+	"GET /v1/login":                 public,
+	"POST /v1/login":                public,
+	"POST /v1/login/mfa":            public,
+	"POST /v1/login/passkey/begin":  public,
+	"POST /v1/login/passkey/finish": public,
+	"POST /v1/authenticate":         public,
+	"POST /v1/reauthenticate":       public,
+	"POST /v1/forgot-password":      public,
+	"POST /v1/reset-password":       public,
+	"POST /v1/accept-invite":        public,
+	"POST /v1/verify-email":         public,
+	"POST /v1/verify-email/resend":  public,
+	"POST /v1/confirm-email":        public,
+	"POST /v1/revert-email":         public,
+
+	// Database statistics and audit log
+	"GET /v1/dbinfo":   requires(permissions.ConfigView),
+	"GET /v1/activity": requires(permissions.ConfigView),
+
+	// Users may view and update their own profile and password without permissions
+	"GET /v1/users":                                requires(permissions.UsersView),
+	"POST /v1/users":                               requires(permissions.UsersManage),
+	"GET /v1/users/:userID":                        selfOr(permissions.UsersView),
+	"PUT /v1/users/:userID":                        selfOr(permissions.UsersManage),
+	"DELETE /v1/users/:userID":                     requires(permissions.UsersManage),
+	"POST /v1/users/:userID/restore":               requires(permissions.UsersManage),
+	"PUT /v1/users/:userID/status":                 requires(permissions.UsersManage),
+	"DELETE /v1/users/:userID/purge":               requires(permissions.UsersManage),
+	"POST /v1/users/:userID/password":              selfOr(permissions.UsersManage),
+	"POST /v1/users/:userID/email":                 selfOr(permissions.UsersManage),
+	"GET /v1/users/:userID/sessions":               selfOr(permissions.UsersView),
+	"DELETE /v1/users/:userID/sessions":            selfOr(permissions.UsersManage),
+	"DELETE /v1/users/:userID/sessions/:sessionID": selfOr(permissions.UsersManage),
+	"POST /v1/users/:userID/mfa/totp":              self,
+	"POST /v1/users/:userID/mfa/totp/confirm":      self,
+	"DELETE /v1/users/:userID/mfa/totp":            self,
+	"POST /v1/users/:userID/mfa/recovery-codes":    self,
+	"GET /v1/users/:userID/passkeys":               self,
+	"POST /v1/users/:userID/passkeys":              self,
+	"POST /v1/users/:userID/passkeys/register":     self,
+	"DELETE /v1/users/:userID/passkeys/:passkeyID": self,
+	"POST /v1/users/:userID/unlock":                requires(permissions.UsersManage),
+	"GET /v1/users/:userID/roles":                  requires(permissions.RolesView),
+	"PUT /v1/users/:userID/roles":                  requires(permissions.UsersManage),
+	"GET /v1/users/:userID/organizations":          selfOr(permissions.UsersView),
+
+	// Team invites are managed along with the users that were invited
+	"GET /v1/invites":                   requires(permissions.UsersView),
+	"POST /v1/invites/:inviteID/resend": requires(permissions.UsersManage),
+	"DELETE /v1/invites/:inviteID":      requires(permissions.UsersManage),
+
+	// Roles and permissions
+	"GET /v1/roles":                                      requires(permissions.RolesView),
+	"POST /v1/roles":                                     requires(permissions.ConfigManage),
+	"GET /v1/roles/:roleID":                              requires(permissions.RolesView),
+	"PUT /v1/roles/:roleID":                              requires(permissions.ConfigManage),
+	"DELETE /v1/roles/:roleID":                           requires(permissions.ConfigManage),
+	"POST /v1/roles/:roleID/permissions":                 requires(permissions.ConfigManage),
+	"DELETE /v1/roles/:roleID/permissions/:permissionID": requires(permissions.ConfigManage),
+	"GET /v1/permissions":                                requires(permissions.PermissionsView),
+	"POST /v1/permissions":                               requires(permissions.ConfigManage),
+	"GET /v1/permissions/:permissionID":                  requires(permissions.PermissionsView),
+	"PUT /v1/permissions/:permissionID":                  requires(permissions.ConfigManage),
+	"DELETE /v1/permissions/:permissionID":               requires(permissions.ConfigManage),
+
+	// Organizations; any member can switch into an organization they belong to
+	"GET /v1/organizations":                        requires(permissions.ConfigView),
+	"POST /v1/organizations":                       requires(permissions.ConfigManage),
+	"GET /v1/organizations/:orgID":                 requires(permissions.ConfigView),
+	"PUT /v1/organizations/:orgID":                 requires(permissions.ConfigManage),
+	"DELETE /v1/organizations/:orgID":              requires(permissions.ConfigManage),
+	"GET /v1/organizations/:orgID/members":         requires(permissions.UsersView),
+	"PUT /v1/organizations/:orgID/members/:userID": requires(permissions.UsersManage),
+	"POST /v1/organizations/:orgID/switch":         authenticated,
+
+	// Webhooks are managed as part of the Quarterdeck configuration
+	"GET /v1/webhooks":                                              requires(permissions.ConfigView),
+	"POST /v1/webhooks":                                             requires(permissions.ConfigManage),
+	"GET /v1/webhooks/:webhookID":                                   requires(permissions.ConfigView),
+	"PUT /v1/webhooks/:webhookID":                                   requires(permissions.ConfigManage),
+	"DELETE /v1/webhooks/:webhookID":                                requires(permissions.ConfigManage),
+	"GET /v1/webhooks/:webhookID/deliveries":                        requires(permissions.ConfigView),
+	"POST /v1/webhooks/:webhookID/deliveries/:deliveryID/redeliver": requires(permissions.ConfigManage),
+
+	// Signing keys are managed as part of the Quarterdeck configuration
+	"GET /v1/signingkeys":                requires(permissions.ConfigView),
+	"POST /v1/signingkeys/rotate":        requires(permissions.ConfigManage),
+	"POST /v1/signingkeys/:keyID/retire": requires(permissions.ConfigManage),
+
+	// API keys
+	"GET /v1/apikeys":                requires(permissions.APIKeysView),
+	"POST /v1/apikeys":               requires(permissions.APIKeysManage),
+	"GET /v1/apikeys/:keyID":         requires(permissions.APIKeysView),
+	"PUT /v1/apikeys/:keyID":         requires(permissions.APIKeysManage),
+	"DELETE /v1/apikeys/:keyID":      requires(permissions.APIKeysRevoke),
+	"GET /v1/apikeys/:keyID/edit":    requires(permissions.APIKeysManage),
+	"POST /v1/apikeys/:keyID/unlock": requires(permissions.APIKeysManage),
+
+	// OIDC userinfo and client management
+	"GET /v1/oidc/userinfo":           authenticated,
+	"POST /v1/oidc/userinfo":          authenticated,
+	"GET /v1/oidc/oidcclients":        requires(permissions.OIDCClientsView),
+	"POST /v1/oidc/oidcclients":       requires(permissions.OIDCClientsManage),
+	"GET /v1/oidc/oidcclients/:id":    requires(permissions.OIDCClientsView),
+	"PUT /v1/oidc/oidcclients/:id":    requires(permissions.OIDCClientsManage),
+	"DELETE /v1/oidc/oidcclients/:id": requires(permissions.OIDCClientsManage),
+
+	// SCIM provisioning by an identity provider authenticated with an api key; groups
+	// are roles so managing them requires the same permission as managing roles.
+	"GET /scim/v2/ServiceProviderConfig": apiKeyWith(permissions.UsersView),
+	"GET /scim/v2/Schemas":               apiKeyWith(permissions.UsersView),
+	"GET /scim/v2/Schemas/:id":           apiKeyWith(permissions.UsersView),
+	"GET /scim/v2/ResourceTypes":         apiKeyWith(permissions.UsersView),
+	"GET /scim/v2/ResourceTypes/:id":     apiKeyWith(permissions.UsersView),
+	"GET /scim/v2/Users":                 apiKeyWith(permissions.UsersView),
+	"POST /scim/v2/Users":                apiKeyWith(permissions.UsersManage),
+	"GET /scim/v2/Users/:id":             apiKeyWith(permissions.UsersView),
+	"PUT /scim/v2/Users/:id":             apiKeyWith(permissions.UsersManage),
+	"PATCH /scim/v2/Users/:id":           apiKeyWith(permissions.UsersManage),
+	"DELETE /scim/v2/Users/:id":          apiKeyWith(permissions.UsersManage),
+	"GET /scim/v2/Groups":                apiKeyWith(permissions.RolesView),
+	"POST /scim/v2/Groups":               apiKeyWith(permissions.ConfigManage),
+	"GET /scim/v2/Groups/:id":            apiKeyWith(permissions.RolesView),
+	"PUT /scim/v2/Groups/:id":            apiKeyWith(permissions.ConfigManage),
+	"PATCH /scim/v2/Groups/:id":          apiKeyWith(permissions.ConfigManage),
+	"DELETE /scim/v2/Groups/:id":         apiKeyWith(permissions.ConfigManage),
+}
+
+// prefixPolicies authorize routes that are registered by other packages (e.g. the
+// documentation pages) by the path prefix of the group they are registered on.
+var prefixPolicies = map[string]policy{
+	"/docs": authenticated,
+}
+
+// lookupPolicy returns the policy for the route with the method and full path.
+func lookupPolicy(method, path string) (p policy, ok bool) {
+	if p, ok = routePolicies[method+" "+path]; ok {
+		return p, true
+	}
+
+	for prefix, p := range prefixPolicies {
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return p, true
+		}
+	}
+	return policy{}, false
+}
+
+// Authorization enforces the route policy after the requester has been authenticated.
+// Routes without a policy are rejected so that a new route is never unintentionally
+// available to every authenticated user and api key.
+func (s *Server) Authorization() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p, ok := lookupPolicy(c.Request.Method, c.FullPath())
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusForbidden, api.Error(errors.ErrNotAuthorized))
+			return
+		}
+
+		if p.access == accessPublic || p.access == accessAuthenticated {
+			c.Next()
+			return
+		}
+
+		claims, err := gimauth.GetClaims(c)
+		if err != nil {
+			c.Error(err)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, api.Error("could not get user claims"))
+			return
+		}
+
+		if p.allows(claims, c.Param("userID")) {
+			c.Next()
+			return
+		}
+
+		c.AbortWithStatusJSON(http.StatusForbidden, api.Error(errors.ErrNotAuthorized))
+	}
+}
+
+// allows returns true if the claims satisfy the policy; userID is the :userID param of
+// the request (if any) that the self-service policies are checked against.
+func (p policy) allows(claims *gimauth.Claims, userID string) bool {
+	switch p.access {
+	case accessPublic, accessAuthenticated:
+		return true
+	case accessPermitted:
+		return claims.HasPermission(p.permission.String())
+	case accessSelfOrPermitted:
+		return isSelf(claims, userID) || claims.HasPermission(p.permission.String())
+	case accessSelf:
+		return isSelf(claims, userID)
+	case accessAPIKeyPermitted:
+		return isAPIKey(claims) && claims.HasPermission(p.permission.String())
+	default:
+		return false
+	}
+}
+
+// isSelf returns true if the claims belong to the user with the specified ID.
+func isSelf(claims *gimauth.Claims, userID string) bool {
+	uid, err := ulid.Parse(userID)
+	if err != nil {
+		return false
+	}
+
+	sub, subjectID, err := claims.SubjectID()
+	return err == nil && sub == gimauth.SubjectUser && subjectID == uid
+}
+
+// isAPIKey returns true if the claims belong to an api key rather than a user.
+func isAPIKey(claims *gimauth.Claims) bool {
+	sub, _, err := claims.SubjectID()
+	return err == nil && sub == gimauth.SubjectAPIKey
+}
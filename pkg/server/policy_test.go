@@ -0,0 +1,105 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/gimlet"
+	"go.rtnl.ai/gimlet/auth"
+	"go.rtnl.ai/ulid"
+)
+
+// Every route registered by the server must have an authorization policy; if this test
+// fails then add the new route to routePolicies in policy.go.
+func TestRoutePolicies(t *testing.T) {
+	mockStore := openMockStore(t)
+	defer mockStore.Close()
+
+	srv := newTestOAuthServer(t, mockStore)
+	srv.router = gin.New()
+	require.NoError(t, srv.addRoutes(), "could not register routes")
+
+	routes := srv.router.Routes()
+	require.NotEmpty(t, routes)
+
+	for _, route := range routes {
+		_, ok := lookupPolicy(route.Method, route.Path)
+		require.True(t, ok, "route %s %s does not have an authorization policy", route.Method, route.Path)
+	}
+}
+
+func TestAuthorization(t *testing.T) {
+	userID := ulid.MakeSecure()
+	otherID := ulid.MakeSecure()
+	keyID := ulid.MakeSecure()
+
+	user := func(id ulid.ULID, permissions ...string) *auth.Claims {
+		claims := &auth.Claims{Permissions: permissions}
+		claims.SetSubjectID(auth.SubjectUser, id)
+		return claims
+	}
+
+	apikey := func(permissions ...string) *auth.Claims {
+		claims := &auth.Claims{Permissions: permissions}
+		claims.SetSubjectID(auth.SubjectAPIKey, keyID)
+		return claims
+	}
+
+	testCases := []struct {
+		name     string
+		method   string
+		route    string
+		path     string
+		claims   *auth.Claims
+		expected int
+	}{
+		{"NoPermissions", http.MethodGet, "/v1/users", "/v1/users", apikey(), http.StatusForbidden},
+		{"WrongPermission", http.MethodGet, "/v1/users", "/v1/users", apikey("apikeys:view"), http.StatusForbidden},
+		{"HasPermission", http.MethodGet, "/v1/users", "/v1/users", apikey("users:view"), http.StatusOK},
+		{"ViewCannotManage", http.MethodPost, "/v1/users", "/v1/users", user(userID, "users:view"), http.StatusForbidden},
+		{"Manage", http.MethodPost, "/v1/users", "/v1/users", user(userID, "users:manage"), http.StatusOK},
+		{"OwnProfile", http.MethodGet, "/v1/users/:userID", "/v1/users/" + userID.String(), user(userID), http.StatusOK},
+		{"UpdateOwnProfile", http.MethodPut, "/v1/users/:userID", "/v1/users/" + userID.String(), user(userID), http.StatusOK},
+		{"OtherProfile", http.MethodGet, "/v1/users/:userID", "/v1/users/" + otherID.String(), user(userID), http.StatusForbidden},
+		{"OtherProfilePermitted", http.MethodGet, "/v1/users/:userID", "/v1/users/" + otherID.String(), user(userID, "users:view"), http.StatusOK},
+		{"UpdateOtherProfile", http.MethodPut, "/v1/users/:userID", "/v1/users/" + otherID.String(), user(userID, "users:view"), http.StatusForbidden},
+		{"OwnPassword", http.MethodPost, "/v1/users/:userID/password", "/v1/users/" + userID.String() + "/password", user(userID), http.StatusOK},
+		{"OtherPassword", http.MethodPost, "/v1/users/:userID/password", "/v1/users/" + otherID.String() + "/password", user(userID), http.StatusForbidden},
+		{"APIKeyNotSelf", http.MethodGet, "/v1/users/:userID", "/v1/users/" + keyID.String(), apikey(), http.StatusForbidden},
+		{"DeleteSelf", http.MethodDelete, "/v1/users/:userID", "/v1/users/" + userID.String(), user(userID), http.StatusForbidden},
+		{"OwnPasskeys", http.MethodGet, "/v1/users/:userID/passkeys", "/v1/users/" + userID.String() + "/passkeys", user(userID), http.StatusOK},
+		{"OtherPasskeys", http.MethodGet, "/v1/users/:userID/passkeys", "/v1/users/" + otherID.String() + "/passkeys", user(userID, "users:manage"), http.StatusForbidden},
+		{"ViewAPIKeys", http.MethodGet, "/v1/apikeys", "/v1/apikeys", apikey("apikeys:view"), http.StatusOK},
+		{"CreateAPIKey", http.MethodPost, "/v1/apikeys", "/v1/apikeys", apikey("apikeys:view"), http.StatusForbidden},
+		{"RevokeAPIKey", http.MethodDelete, "/v1/apikeys/:keyID", "/v1/apikeys/" + keyID.String(), apikey("apikeys:revoke"), http.StatusOK},
+		{"ManageCannotRevoke", http.MethodDelete, "/v1/apikeys/:keyID", "/v1/apikeys/" + keyID.String(), apikey("apikeys:manage"), http.StatusForbidden},
+		{"ViewOIDCClients", http.MethodGet, "/v1/oidc/oidcclients", "/v1/oidc/oidcclients", user(userID, "oidcclients:view"), http.StatusOK},
+		{"CreateOIDCClient", http.MethodPost, "/v1/oidc/oidcclients", "/v1/oidc/oidcclients", user(userID, "oidcclients:view"), http.StatusForbidden},
+		{"UserInfo", http.MethodGet, "/v1/oidc/userinfo", "/v1/oidc/userinfo", apikey(), http.StatusOK},
+		{"SCIMAPIKey", http.MethodGet, "/scim/v2/Users", "/scim/v2/Users", apikey("users:view"), http.StatusOK},
+		{"SCIMAPIKeyCannotManage", http.MethodPost, "/scim/v2/Users", "/scim/v2/Users", apikey("users:view"), http.StatusForbidden},
+		{"SCIMUser", http.MethodGet, "/scim/v2/Users", "/scim/v2/Users", user(userID, "users:view"), http.StatusForbidden},
+		{"SCIMGroupsManage", http.MethodPatch, "/scim/v2/Groups/:id", "/scim/v2/Groups/1", apikey("users:manage"), http.StatusForbidden},
+		{"Docs", http.MethodGet, "/docs/getting-started", "/docs/getting-started", user(userID), http.StatusOK},
+		{"NoPolicy", http.MethodGet, "/v1/unregistered", "/v1/unregistered", user(userID, "config:manage"), http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := &Server{}
+			router := gin.New()
+			router.Handle(tc.method, tc.route, func(c *gin.Context) {
+				gimlet.Set(c, gimlet.KeyUserClaims, tc.claims)
+			}, srv.Authorization(), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+			require.Equal(t, tc.expected, w.Code)
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"go.rtnl.ai/quarterdeck/pkg/enum"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/txn"
+	"go.rtnl.ai/ulid"
+	"go.rtnl.ai/x/rlog"
+)
+
+// purgeTimeout limits how long a single purge of deleted users may take.
+const purgeTimeout = 1 * time.Minute
+
+// runPurge periodically purges users that were deleted longer ago than the retention
+// window until the context is canceled when the server shuts down.
+func (s *Server) runPurge(ctx context.Context) {
+	ticker := time.NewTicker(s.conf.Database.PurgeInterval)
+	defer ticker.Stop()
+
+	rlog.DebugAttrs(ctx, "deleted user purge started",
+		slog.Duration("interval", s.conf.Database.PurgeInterval),
+		slog.Duration("retention", s.conf.Database.UserRetention))
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.purgeDeletedUsers(ctx)
+		}
+	}
+}
+
+// purgeDeletedUsers permanently removes the users whose retention window has elapsed
+// and publishes the user deleted event for each of them.
+func (s *Server) purgeDeletedUsers(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, purgeTimeout)
+	defer cancel()
+
+	var (
+		err    error
+		purged []ulid.ULID
+		queued int
+	)
+
+	// The user deleted events are queued in the same transaction as the purge.
+	if err = s.store.WithTx(ctx, nil, func(tx txn.Tx) (err error) {
+		if purged, err = tx.PurgeUsers(time.Now().Add(-s.conf.Database.UserRetention)); err != nil {
+			return err
+		}
+
+		for _, userID := range purged {
+			var n int
+			if n, err = publishTx(tx, enum.WebhookEventUserDeleted, deletedResource{ID: userID}); err != nil {
+				return err
+			}
+			queued += n
+		}
+		return nil
+	}); err != nil {
+		rlog.WarnAttrs(ctx, "could not purge deleted users", slog.Any("err", err))
+		return
+	}
+	s.notifyQueued(queued)
+
+	for _, userID := range purged {
+		rlog.InfoAttrs(ctx, "purged deleted user", slog.String("user_id", userID.String()))
+	}
+}
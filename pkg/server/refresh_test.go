@@ -0,0 +1,211 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/gimlet/auth"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/mock"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/tidal"
+	"go.rtnl.ai/ulid"
+)
+
+func TestIssueTokens(t *testing.T) {
+	setup := func(t *testing.T) (*mock.Store, *Server, *gin.Context) {
+		mockStore := openMockStore(t)
+		t.Cleanup(func() { mockStore.Close() })
+
+		_, c := requestContext(t, http.MethodPost, "/v1/login", nil, nil)
+		c.Request.Header.Set("User-Agent", "Mozilla/5.0")
+		return mockStore, newTestOAuthServer(t, mockStore), c
+	}
+
+	userClaims := func() *auth.Claims {
+		claims := &auth.Claims{Name: "Jane Doe"}
+		claims.SetSubjectID(auth.SubjectUser, ulid.MakeSecure())
+		return claims
+	}
+
+	t.Run("Login", func(t *testing.T) {
+		mockStore, srv, c := setup(t)
+
+		var (
+			created *models.RefreshToken
+			session *models.Session
+		)
+
+		mockStore.OnCreateRefreshToken = func(_ context.Context, in *models.RefreshToken) (*models.RefreshToken, error) {
+			created = in
+			return in, nil
+		}
+		mockStore.OnCreateSession = func(_ context.Context, in *models.Session) (*models.Session, error) {
+			session = in
+			return in, nil
+		}
+
+		claims := userClaims()
+		_, refreshToken, err := srv.issueTokens(c, claims, ulid.Zero, ulid.Zero)
+		require.NoError(t, err)
+
+		refreshClaims, err := srv.issuer.Parse(refreshToken)
+		require.NoError(t, err)
+
+		require.NotNil(t, created)
+		require.Equal(t, refreshClaims.ID, created.ID.String())
+		require.Equal(t, created.ID, created.FamilyID, "the first refresh token should start the family")
+		require.Equal(t, claims.Subject, created.Subject)
+		require.Equal(t, refreshClaims.ExpiresAt.Time, created.Expiration)
+
+		require.NotNil(t, session, "a session should be created for a user login")
+		require.Equal(t, created.FamilyID, session.ID)
+		require.Equal(t, created.ID, session.JTI)
+		require.Equal(t, "Mozilla/5.0", session.UserAgent.String)
+		mockStore.AssertCalls(t, mock.RefreshSession, 0)
+	})
+
+	t.Run("Rotate", func(t *testing.T) {
+		mockStore, srv, c := setup(t)
+		familyID := ulid.MakeSecure()
+
+		var created *models.RefreshToken
+		mockStore.OnCreateRefreshToken = func(_ context.Context, in *models.RefreshToken) (*models.RefreshToken, error) {
+			created = in
+			return in, nil
+		}
+		mockStore.OnRefreshSession = func(_ context.Context, sessionID, jti ulid.ULID, _ time.Time) error {
+			require.Equal(t, familyID, sessionID)
+			require.Equal(t, created.ID, jti)
+			return nil
+		}
+
+		_, _, err := srv.issueTokens(c, userClaims(), ulid.Zero, familyID)
+		require.NoError(t, err)
+		require.Equal(t, familyID, created.FamilyID)
+		mockStore.AssertCalls(t, mock.RefreshSession, 1)
+		mockStore.AssertCalls(t, mock.CreateSession, 0)
+	})
+
+	t.Run("RotateUntracked", func(t *testing.T) {
+		mockStore, srv, c := setup(t)
+		familyID := ulid.MakeSecure()
+
+		mockStore.OnCreateRefreshToken = func(_ context.Context, in *models.RefreshToken) (*models.RefreshToken, error) { return in, nil }
+		mockStore.OnRefreshSession = func(context.Context, ulid.ULID, ulid.ULID, time.Time) error {
+			return errors.ErrNotFound
+		}
+		mockStore.OnCreateSession = func(_ context.Context, in *models.Session) (*models.Session, error) {
+			require.Equal(t, familyID, in.ID)
+			return in, nil
+		}
+
+		_, _, err := srv.issueTokens(c, userClaims(), ulid.Zero, familyID)
+		require.NoError(t, err)
+		mockStore.AssertCalls(t, mock.CreateSession, 1)
+	})
+
+	t.Run("APIKey", func(t *testing.T) {
+		mockStore, srv, c := setup(t)
+		mockStore.OnCreateRefreshToken = func(_ context.Context, in *models.RefreshToken) (*models.RefreshToken, error) { return in, nil }
+
+		claims := &auth.Claims{}
+		claims.SetSubjectID(auth.SubjectAPIKey, ulid.MakeSecure())
+
+		_, _, err := srv.issueTokens(c, claims, ulid.Zero, ulid.Zero)
+		require.NoError(t, err)
+		mockStore.AssertCalls(t, mock.CreateSession, 0)
+	})
+
+	t.Run("StoreError", func(t *testing.T) {
+		mockStore, srv, c := setup(t)
+		mockStore.OnCreateRefreshToken = func(_ context.Context, in *models.RefreshToken) (*models.RefreshToken, error) {
+			return nil, errors.ErrReadOnly
+		}
+
+		_, _, err := srv.issueTokens(c, userClaims(), ulid.Zero, ulid.Zero)
+		require.ErrorIs(t, err, errors.ErrReadOnly)
+	})
+}
+
+func TestUseRefreshToken(t *testing.T) {
+	jti := ulid.MakeSecure()
+	familyID := ulid.MakeSecure()
+
+	claims := &auth.Claims{}
+	claims.ID = jti.String()
+	claims.SetSubjectID(auth.SubjectUser, ulid.MakeSecure())
+
+	setup := func(t *testing.T) (*mock.Store, *Server) {
+		mockStore := openMockStore(t)
+		t.Cleanup(func() { mockStore.Close() })
+		return mockStore, newTestOAuthServer(t, mockStore)
+	}
+
+	t.Run("Rotate", func(t *testing.T) {
+		mockStore, srv := setup(t)
+		mockStore.OnUseRefreshToken = func(_ context.Context, id ulid.ULID) (*models.RefreshToken, error) {
+			require.Equal(t, jti, id)
+			return &models.RefreshToken{BaseModel: tidal.BaseModel{ID: id}, FamilyID: familyID}, nil
+		}
+
+		_, c := requestContext(t, http.MethodPost, "/v1/reauthenticate", nil, nil)
+		refresh, err := srv.useRefreshToken(c, claims)
+		require.NoError(t, err)
+		require.Equal(t, familyID, refresh.FamilyID)
+	})
+
+	t.Run("Reused", func(t *testing.T) {
+		mockStore, srv := setup(t)
+		mockStore.OnUseRefreshToken = func(context.Context, ulid.ULID) (*models.RefreshToken, error) {
+			return nil, errors.ErrTokenReused
+		}
+		mockStore.OnRetrieveRefreshToken = func(_ context.Context, id ulid.ULID) (*models.RefreshToken, error) {
+			return &models.RefreshToken{BaseModel: tidal.BaseModel{ID: id}, FamilyID: familyID}, nil
+		}
+
+		var revoked ulid.ULID
+		mockStore.OnRevokeRefreshTokenFamily = func(_ context.Context, id ulid.ULID) error {
+			revoked = id
+			return nil
+		}
+
+		w, c := requestContext(t, http.MethodPost, "/v1/reauthenticate", nil, nil)
+		_, err := srv.useRefreshToken(c, claims)
+		require.ErrorIs(t, err, errors.ErrTokenReused)
+		require.Equal(t, http.StatusForbidden, w.Code)
+		require.Equal(t, familyID, revoked, "the whole token family should be revoked")
+		mockStore.AssertCalls(t, mock.RevokeRefreshTokenFamily, 1)
+	})
+
+	t.Run("Unknown", func(t *testing.T) {
+		for _, serr := range []error{errors.ErrNotFound, errors.ErrTokenRevoked, errors.ErrExpiredToken} {
+			mockStore, srv := setup(t)
+			mockStore.OnUseRefreshToken = func(context.Context, ulid.ULID) (*models.RefreshToken, error) {
+				return nil, serr
+			}
+
+			w, c := requestContext(t, http.MethodPost, "/v1/reauthenticate", nil, nil)
+			_, err := srv.useRefreshToken(c, claims)
+			require.ErrorIs(t, err, serr)
+			require.Equal(t, http.StatusForbidden, w.Code)
+			mockStore.AssertCalls(t, mock.RevokeRefreshTokenFamily, 0)
+		}
+	})
+
+	t.Run("StoreError", func(t *testing.T) {
+		mockStore, srv := setup(t)
+		mockStore.OnUseRefreshToken = func(context.Context, ulid.ULID) (*models.RefreshToken, error) {
+			return nil, errors.ErrDatabase
+		}
+
+		w, c := requestContext(t, http.MethodPost, "/v1/reauthenticate", nil, nil)
+		_, err := srv.useRefreshToken(c, claims)
+		require.Error(t, err)
+		require.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
@@ -0,0 +1,581 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	gimauth "go.rtnl.ai/gimlet/auth"
+	"go.rtnl.ai/quarterdeck/pkg/api/v1"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/quarterdeck/pkg/web/htmx"
+	"go.rtnl.ai/quarterdeck/pkg/web/scene"
+	"go.rtnl.ai/ulid"
+)
+
+// ============================================================================
+// Role resource handlers
+// ============================================================================
+
+// ListRoles returns all of the roles that can be assigned to users.
+func (s *Server) ListRoles(c *gin.Context) {
+	var (
+		err   error
+		in    *api.PageQuery
+		roles []*models.Role
+		out   *api.RoleList
+	)
+
+	in = &api.PageQuery{}
+	if err = c.BindQuery(in); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadRequest, api.Error("invalid query parameters"))
+		return
+	}
+
+	// Roles are listed in full rather than by cursor since they have integer IDs and
+	// the set of roles is small.
+	if roles, err = listAll(s.store.ListRoles(c.Request.Context(), nil)); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process roles list request"))
+		return
+	}
+
+	if out, err = api.NewRoleList(roles); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process roles list request"))
+		return
+	}
+
+	// Content negotiation
+	c.Negotiate(http.StatusOK, gin.Negotiate{
+		Offered:  []string{binding.MIMEJSON, binding.MIMEHTML},
+		Data:     out,
+		HTMLName: "partials/roles/list.html",
+		HTMLData: scene.New(c).WithAPIData(out),
+	})
+}
+
+// CreateRole creates a new role along with any permissions specified by title.
+func (s *Server) CreateRole(c *gin.Context) {
+	var (
+		err  error
+		in   *api.Role
+		role *models.Role
+		out  *api.Role
+	)
+
+	in = &api.Role{}
+	if err = c.BindJSON(in); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadRequest, api.Error("could not parse role data"))
+		return
+	}
+
+	if err = in.Validate(); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusUnprocessableEntity, api.Error(err))
+		return
+	}
+
+	if err = s.checkGrantable(c, in.Permissions); err != nil {
+		return
+	}
+
+	if role, err = s.store.CreateRole(c.Request.Context(), in.Model()); err != nil {
+		switch {
+		case errors.Is(err, errors.ErrAlreadyExists):
+			c.JSON(http.StatusConflict, api.Error("a role with this title already exists"))
+		case errors.Is(err, errors.ErrNotFound):
+			c.JSON(http.StatusUnprocessableEntity, api.Error(api.IncorrectField("permissions", "unknown permission")))
+		default:
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, api.Error("could not process create role request"))
+		}
+		return
+	}
+
+	// The created role is returned by the store with its assigned permissions
+	if out, err = api.NewRole(role); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process create role request"))
+		return
+	}
+
+	s.audit(c, models.AuditCreate, models.AuditRole, strconv.FormatInt(role.ID, 10), nil, out)
+
+	switch c.NegotiateFormat(binding.MIMEJSON, binding.MIMEHTML) {
+	case binding.MIMEJSON:
+		c.JSON(http.StatusCreated, out)
+	case binding.MIMEHTML:
+		htmx.SetTrigger(c, htmx.RolesUpdated)
+		c.Data(http.StatusNoContent, gin.MIMEHTML, nil)
+	}
+}
+
+// RoleDetail returns the role and its permissions; the HTML response is a form to
+// manage the role that also lists the permissions that can be added to it.
+func (s *Server) RoleDetail(c *gin.Context) {
+	var (
+		err    error
+		roleID int64
+		out    *api.Role
+	)
+
+	if roleID, err = parseSequenceID(c, "roleID"); err != nil {
+		c.JSON(http.StatusNotFound, api.Error("role not found"))
+		return
+	}
+
+	if out, err = s.retrieveRole(c, roleID); err != nil {
+		return
+	}
+
+	s.renderRole(c, http.StatusOK, out)
+}
+
+// UpdateRole updates the title, description, and default status of a role; use the
+// role permissions endpoints to change the permissions of the role.
+func (s *Server) UpdateRole(c *gin.Context) {
+	var (
+		err    error
+		roleID int64
+		in     *api.Role
+		before *api.Role
+		out    *api.Role
+	)
+
+	if roleID, err = parseSequenceID(c, "roleID"); err != nil {
+		c.JSON(http.StatusNotFound, api.Error("role not found"))
+		return
+	}
+
+	in = &api.Role{}
+	if err = c.BindJSON(in); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadRequest, api.Error("could not parse role data"))
+		return
+	}
+
+	if err = in.Validate(); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusUnprocessableEntity, api.Error(err))
+		return
+	}
+
+	// Set the role ID only after validation
+	in.ID = int(roleID)
+
+	// Retrieve the role before it is updated to record the changes in the audit log
+	if before, err = s.retrieveRole(c, roleID); err != nil {
+		return
+	}
+
+	if err = s.store.UpdateRole(c.Request.Context(), in.Model()); err != nil {
+		switch {
+		case errors.Is(err, errors.ErrNotFound):
+			c.JSON(http.StatusNotFound, api.Error("role not found"))
+		case errors.Is(err, errors.ErrAlreadyExists):
+			c.JSON(http.StatusConflict, api.Error("a role with this title already exists"))
+		default:
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, api.Error("could not process update role request"))
+		}
+		return
+	}
+
+	if out, err = s.retrieveRole(c, roleID); err != nil {
+		return
+	}
+
+	s.audit(c, models.AuditUpdate, models.AuditRole, strconv.FormatInt(roleID, 10), before, out)
+
+	switch c.NegotiateFormat(binding.MIMEJSON, binding.MIMEHTML) {
+	case binding.MIMEJSON:
+		c.JSON(http.StatusOK, out)
+	case binding.MIMEHTML:
+		htmx.SetTrigger(c, htmx.RolesUpdated)
+		c.Data(http.StatusNoContent, gin.MIMEHTML, nil)
+	}
+}
+
+// DeleteRole deletes the role, removing it from any users it was assigned to.
+func (s *Server) DeleteRole(c *gin.Context) {
+	var (
+		err    error
+		roleID int64
+	)
+
+	if roleID, err = parseSequenceID(c, "roleID"); err != nil {
+		c.JSON(http.StatusNotFound, api.Error("role not found"))
+		return
+	}
+
+	if err = s.store.DeleteRole(c.Request.Context(), roleID); err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			c.JSON(http.StatusNotFound, api.Error("role not found"))
+			return
+		}
+
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process delete role request"))
+		return
+	}
+
+	s.audit(c, models.AuditDelete, models.AuditRole, strconv.FormatInt(roleID, 10), nil, nil)
+
+	if htmx.IsHTMXRequest(c) {
+		htmx.SetTrigger(c, htmx.RolesUpdated)
+		c.Data(http.StatusNoContent, gin.MIMEHTML, nil)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.Reply{Success: true})
+}
+
+// AddRolePermission adds the permission to the role; if the role already has the
+// permission then the role is returned unchanged.
+func (s *Server) AddRolePermission(c *gin.Context) {
+	var (
+		err    error
+		roleID int64
+		in     *api.RolePermission
+		before *api.Role
+		out    *api.Role
+	)
+
+	if roleID, err = parseSequenceID(c, "roleID"); err != nil {
+		c.JSON(http.StatusNotFound, api.Error("role not found"))
+		return
+	}
+
+	in = &api.RolePermission{}
+	if err = c.BindJSON(in); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadRequest, api.Error("could not parse role permission data"))
+		return
+	}
+
+	if err = in.Validate(); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusUnprocessableEntity, api.Error(err))
+		return
+	}
+
+	if before, err = s.retrieveRole(c, roleID); err != nil {
+		return
+	}
+
+	if before.HasPermission(in.Permission) {
+		s.renderRole(c, http.StatusOK, before)
+		return
+	}
+
+	if err = s.checkGrantable(c, []string{in.Permission}); err != nil {
+		return
+	}
+
+	if err = s.store.AddPermissionToRoleByTitle(c.Request.Context(), roleID, in.Permission); err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			c.JSON(http.StatusUnprocessableEntity, api.Error(api.IncorrectField("permission", "unknown permission")))
+			return
+		}
+
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process add role permission request"))
+		return
+	}
+
+	if out, err = s.retrieveRole(c, roleID); err != nil {
+		return
+	}
+
+	s.audit(c, models.AuditUpdate, models.AuditRole, strconv.FormatInt(roleID, 10), before, out)
+	htmx.SetTrigger(c, htmx.RolesUpdated)
+	s.renderRole(c, http.StatusOK, out)
+}
+
+// RemoveRolePermission removes the permission from the role.
+func (s *Server) RemoveRolePermission(c *gin.Context) {
+	var (
+		err          error
+		roleID       int64
+		permissionID int64
+		before       *api.Role
+		out          *api.Role
+	)
+
+	if roleID, err = parseSequenceID(c, "roleID"); err != nil {
+		c.JSON(http.StatusNotFound, api.Error("role not found"))
+		return
+	}
+
+	if permissionID, err = parseSequenceID(c, "permissionID"); err != nil {
+		c.JSON(http.StatusNotFound, api.Error("permission not found"))
+		return
+	}
+
+	if before, err = s.retrieveRole(c, roleID); err != nil {
+		return
+	}
+
+	if err = s.store.RemovePermissionFromRole(c.Request.Context(), roleID, permissionID); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process remove role permission request"))
+		return
+	}
+
+	if out, err = s.retrieveRole(c, roleID); err != nil {
+		return
+	}
+
+	s.audit(c, models.AuditUpdate, models.AuditRole, strconv.FormatInt(roleID, 10), before, out)
+	htmx.SetTrigger(c, htmx.RolesUpdated)
+	s.renderRole(c, http.StatusOK, out)
+}
+
+// ============================================================================
+// User role assignment handlers
+// ============================================================================
+
+// ListUserRoles returns the roles that are currently assigned to the user.
+func (s *Server) ListUserRoles(c *gin.Context) {
+	var (
+		err    error
+		userID ulid.ULID
+		user   *api.User
+	)
+
+	if userID, err = ulid.Parse(c.Param("userID")); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusNotFound, api.Error("user not found"))
+		return
+	}
+
+	if user, err = s.retrieveUser(c, userID); err != nil {
+		return
+	}
+
+	c.JSON(http.StatusOK, &api.RoleList{Page: &api.Page{}, Roles: user.Roles})
+}
+
+// ReplaceUserRoles replaces all of the roles assigned to the user with the roles in
+// the request; the user's permissions are updated the next time they authenticate.
+// These roles apply in every organization, so requesters logged into an organization
+// must assign roles to its members with [Server.ReplaceOrganizationMemberRoles].
+func (s *Server) ReplaceUserRoles(c *gin.Context) {
+	var (
+		err     error
+		userID  ulid.ULID
+		in      *api.UserRoles
+		roleIDs []int64
+		before  *api.User
+		out     *api.User
+	)
+
+	if userID, err = ulid.Parse(c.Param("userID")); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusNotFound, api.Error("user not found"))
+		return
+	}
+
+	if !s.currentOrg(c).IsZero() {
+		c.JSON(http.StatusForbidden, api.Error("roles of organization members must be replaced with the organization members endpoint"))
+		return
+	}
+
+	in = &api.UserRoles{}
+	if err = c.BindJSON(in); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadRequest, api.Error("could not parse user roles data"))
+		return
+	}
+
+	if err = in.Validate(); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusUnprocessableEntity, api.Error(err))
+		return
+	}
+
+	if roleIDs, err = s.resolveRoleIDs(c, in.Roles); err != nil {
+		return
+	}
+
+	if before, err = s.retrieveUser(c, userID); err != nil {
+		return
+	}
+
+	if err = s.store.ReplaceUserRoles(c.Request.Context(), userID, roleIDs); err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			c.JSON(http.StatusNotFound, api.Error("user not found"))
+			return
+		}
+
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process replace user roles request"))
+		return
+	}
+
+	if out, err = s.retrieveUser(c, userID); err != nil {
+		return
+	}
+
+	s.audit(c, models.AuditUpdate, models.AuditUser, userID.String(), before, out)
+
+	switch c.NegotiateFormat(binding.MIMEJSON, binding.MIMEHTML) {
+	case binding.MIMEJSON:
+		c.JSON(http.StatusOK, out)
+	case binding.MIMEHTML:
+		htmx.SetTrigger(c, htmx.UsersUpdated)
+		c.Data(http.StatusNoContent, gin.MIMEHTML, nil)
+	}
+}
+
+// ============================================================================
+// Helpers
+// ============================================================================
+
+// retrieveRole fetches the role with its permissions; if an error is returned then the
+// response has already been written.
+func (s *Server) retrieveRole(c *gin.Context, roleID int64) (out *api.Role, err error) {
+	var role *models.Role
+	if role, err = s.store.RetrieveRole(c.Request.Context(), roleID); err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			c.JSON(http.StatusNotFound, api.Error("role not found"))
+			return nil, err
+		}
+
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process role request"))
+		return nil, err
+	}
+
+	if out, err = api.NewRole(role); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process role request"))
+		return nil, err
+	}
+	return out, nil
+}
+
+// resolveRoleIDs resolves role titles to their IDs so that unknown roles can be reported
+// to the user. The requester must have every permission of the roles so that users
+// cannot grant more access than they have, e.g. by assigning themselves an admin role.
+// If an error is returned then the response has already been written.
+func (s *Server) resolveRoleIDs(c *gin.Context, titles []string) (roleIDs []int64, err error) {
+	var roles []*models.Role
+	if roles, err = listAll(s.store.ListRoles(c.Request.Context(), nil)); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process role assignment request"))
+		return nil, err
+	}
+
+	ids := make(map[string]int64, len(roles))
+	for _, role := range roles {
+		ids[strings.ToLower(role.Title)] = role.ID
+	}
+
+	roleIDs = make([]int64, 0, len(titles))
+	for _, title := range titles {
+		roleID, ok := ids[strings.ToLower(title)]
+		if !ok {
+			c.JSON(http.StatusUnprocessableEntity, api.Error(api.IncorrectField("roles", "unknown role "+title)))
+			return nil, errors.ErrNotFound
+		}
+		roleIDs = append(roleIDs, roleID)
+	}
+
+	var claims *gimauth.Claims
+	if claims, err = gimauth.GetClaims(c); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusUnauthorized, api.Error("could not get user claims"))
+		return nil, err
+	}
+
+	for _, roleID := range roleIDs {
+		var role *models.Role
+		if role, err = s.store.RetrieveRole(c.Request.Context(), roleID); err != nil {
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, api.Error("could not process role assignment request"))
+			return nil, err
+		}
+
+		for _, permission := range role.Permissions {
+			if !claims.HasPermission(permission.Title) {
+				c.JSON(http.StatusForbidden, api.Error("cannot assign the role "+role.Title+" which has permissions you do not have"))
+				return nil, errors.ErrNotAuthorized
+			}
+		}
+	}
+	return roleIDs, nil
+}
+
+// checkGrantable ensures that the requester has every permission they are granting to
+// a role so that users cannot create or extend a role with more access than they have
+// and then assign it to themselves. If an error is returned then the response has
+// already been written.
+func (s *Server) checkGrantable(c *gin.Context, permissions []string) (err error) {
+	if len(permissions) == 0 {
+		return nil
+	}
+
+	var claims *gimauth.Claims
+	if claims, err = gimauth.GetClaims(c); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusUnauthorized, api.Error("could not get user claims"))
+		return err
+	}
+
+	for _, permission := range permissions {
+		if !claims.HasPermission(permission) {
+			c.JSON(http.StatusForbidden, api.Error("cannot grant the permission "+permission+" which you do not have"))
+			return errors.ErrNotAuthorized
+		}
+	}
+	return nil
+}
+
+// renderRole negotiates the role response; the HTML form to manage the role also needs
+// the permissions that are available to be added to the role.
+func (s *Server) renderRole(c *gin.Context, code int, out *api.Role) {
+	if c.NegotiateFormat(binding.MIMEJSON, binding.MIMEHTML) != binding.MIMEHTML {
+		c.JSON(code, out)
+		return
+	}
+
+	var (
+		err         error
+		permissions []*models.Permission
+		available   *api.PermissionList
+	)
+
+	if permissions, err = listAll(s.store.ListPermissions(c.Request.Context(), nil)); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process role request"))
+		return
+	}
+
+	if available, err = api.NewPermissionList(permissions); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process role request"))
+		return
+	}
+
+	c.HTML(code, "partials/roles/detail.html", scene.New(c).WithAPIData(out).With(scene.AvailablePermissions, available))
+}
+
+// parseSequenceID parses a role or permission ID from the named URL parameter; these
+// IDs are database sequences so they must be positive integers.
+func parseSequenceID(c *gin.Context, param string) (id int64, err error) {
+	if id, err = strconv.ParseInt(c.Param(param), 10, 64); err != nil {
+		return 0, err
+	}
+
+	if id <= 0 {
+		return 0, errors.ErrMissingID
+	}
+	return id, nil
+}
@@ -0,0 +1,581 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/gimlet"
+	gimauth "go.rtnl.ai/gimlet/auth"
+	"go.rtnl.ai/quarterdeck/pkg/api/v1"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/mock"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/tidal"
+	"go.rtnl.ai/ulid"
+)
+
+func TestListRoles(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		mockStore.OnListRoles = func(ctx context.Context, filter tidal.ListFilter) (tidal.Cursor[*models.Role], error) {
+			return mock.NewCursor(testRoles()...), nil
+		}
+
+		w, c := requestContext(t, http.MethodGet, "/v1/roles", nil, nil)
+		srv.ListRoles(c)
+
+		var out api.RoleList
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &out))
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Len(t, out.Roles, 2)
+		require.Equal(t, "admin", out.Roles[0].Title)
+		require.True(t, out.Roles[1].IsDefault)
+		mockStore.AssertCalls(t, mock.ListRoles, 1)
+	})
+
+	t.Run("StoreError", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		mockStore.OnListRoles = func(ctx context.Context, filter tidal.ListFilter) (tidal.Cursor[*models.Role], error) {
+			return nil, errors.ErrInternal
+		}
+
+		w, c := requestContext(t, http.MethodGet, "/v1/roles", nil, nil)
+		srv.ListRoles(c)
+
+		require.Equal(t, http.StatusInternalServerError, w.Code)
+		require.Equal(t, "could not process roles list request", parseReply(t, w).Error)
+	})
+}
+
+func TestCreateRole(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		var created *models.Role
+		mockStore.OnCreateRole = func(ctx context.Context, in *models.Role) (*models.Role, error) {
+			created = in
+			created.ID = 42
+			created.Created = time.Now()
+			created.Modified = created.Created
+			return in, nil
+		}
+
+		var event *models.AuditEvent
+		mockStore.OnCreateAuditEvent = func(ctx context.Context, in *models.AuditEvent) (*models.AuditEvent, error) {
+			event = in
+			return in, nil
+		}
+
+		w, c := requestContext(t, http.MethodPost, "/v1/roles", []byte(`{"title": "auditor", "description": "Reviews the audit log", "permissions": ["config:view"]}`), nil)
+		c.Request.Header.Set("Content-Type", "application/json")
+		gimlet.Set(c, gimlet.KeyUserClaims, &gimauth.Claims{Permissions: []string{"config:view"}})
+		srv.CreateRole(c)
+
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		require.Len(t, created.Permissions, 1)
+		require.Equal(t, "config:view", created.Permissions[0].Title)
+
+		require.NotNil(t, event)
+		require.Equal(t, models.AuditCreate, event.Action)
+		require.Equal(t, models.AuditRole, event.SubjectType)
+		require.Equal(t, "42", event.SubjectID)
+		mockStore.AssertCalls(t, mock.CreateRole, 1)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		w, c := requestContext(t, http.MethodPost, "/v1/roles", []byte(`{"title": "two words"}`), nil)
+		c.Request.Header.Set("Content-Type", "application/json")
+		srv.CreateRole(c)
+
+		require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		require.Equal(t, "invalid field title: must not contain whitespace", parseReply(t, w).Error)
+		mockStore.AssertCalls(t, mock.CreateRole, 0)
+	})
+
+	t.Run("Conflict", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		mockStore.OnCreateRole = func(ctx context.Context, in *models.Role) (*models.Role, error) {
+			return nil, errors.ErrAlreadyExists
+		}
+
+		w, c := requestContext(t, http.MethodPost, "/v1/roles", []byte(`{"title": "admin"}`), nil)
+		c.Request.Header.Set("Content-Type", "application/json")
+		srv.CreateRole(c)
+
+		require.Equal(t, http.StatusConflict, w.Code)
+		require.Equal(t, "a role with this title already exists", parseReply(t, w).Error)
+	})
+
+	t.Run("UnknownPermission", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		mockStore.OnCreateRole = func(ctx context.Context, in *models.Role) (*models.Role, error) {
+			return nil, errors.Fmt("invalid permission: %w", errors.ErrNotFound)
+		}
+
+		w, c := requestContext(t, http.MethodPost, "/v1/roles", []byte(`{"title": "auditor", "permissions": ["foo:bar"]}`), nil)
+		c.Request.Header.Set("Content-Type", "application/json")
+		gimlet.Set(c, gimlet.KeyUserClaims, &gimauth.Claims{Permissions: []string{"foo:bar"}})
+		srv.CreateRole(c)
+
+		require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		require.Equal(t, "invalid field permissions: unknown permission", parseReply(t, w).Error)
+	})
+
+	t.Run("Escalation", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		w, c := requestContext(t, http.MethodPost, "/v1/roles", []byte(`{"title": "superuser", "permissions": ["config:view", "config:manage"]}`), nil)
+		c.Request.Header.Set("Content-Type", "application/json")
+		gimlet.Set(c, gimlet.KeyUserClaims, &gimauth.Claims{Permissions: []string{"config:view", "roles:manage"}})
+		srv.CreateRole(c)
+
+		require.Equal(t, http.StatusForbidden, w.Code)
+		require.Equal(t, "cannot grant the permission config:manage which you do not have", parseReply(t, w).Error)
+		mockStore.AssertCalls(t, mock.CreateRole, 0)
+	})
+}
+
+func TestRoleDetail(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		mockStore.OnRetrieveRole = func(ctx context.Context, id int64) (*models.Role, error) {
+			return testRoles()[0], nil
+		}
+
+		w, c := requestContext(t, http.MethodGet, "/v1/roles/1", nil, gin.Params{{Key: "roleID", Value: "1"}})
+		srv.RoleDetail(c)
+
+		var out api.Role
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &out))
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, []string{"config:view", "config:manage"}, out.Permissions)
+	})
+
+	t.Run("BadID", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		for _, param := range []string{"foo", "0", "-1"} {
+			w, c := requestContext(t, http.MethodGet, "/v1/roles/"+param, nil, gin.Params{{Key: "roleID", Value: param}})
+			srv.RoleDetail(c)
+			require.Equal(t, http.StatusNotFound, w.Code)
+		}
+
+		mockStore.AssertCalls(t, mock.RetrieveRole, 0)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		mockStore.OnRetrieveRole = func(ctx context.Context, id int64) (*models.Role, error) {
+			return nil, errors.ErrNotFound
+		}
+
+		w, c := requestContext(t, http.MethodGet, "/v1/roles/8", nil, gin.Params{{Key: "roleID", Value: "8"}})
+		srv.RoleDetail(c)
+
+		require.Equal(t, http.StatusNotFound, w.Code)
+		require.Equal(t, "role not found", parseReply(t, w).Error)
+	})
+}
+
+func TestUpdateRole(t *testing.T) {
+	mockStore := openMockStore(t)
+	defer mockStore.Close()
+	srv := newTestServer(mockStore)
+
+	role := testRoles()[1]
+	mockStore.OnRetrieveRole = func(ctx context.Context, id int64) (*models.Role, error) {
+		return role, nil
+	}
+
+	mockStore.OnUpdateRole = func(ctx context.Context, in *models.Role) error {
+		require.Equal(t, int64(2), in.ID)
+		require.False(t, in.IsDefault)
+		role = in
+		return nil
+	}
+
+	var event *models.AuditEvent
+	mockStore.OnCreateAuditEvent = func(ctx context.Context, in *models.AuditEvent) (*models.AuditEvent, error) {
+		event = in
+		return in, nil
+	}
+
+	w, c := requestContext(t, http.MethodPut, "/v1/roles/2", []byte(`{"title": "viewer", "description": "Read only access"}`), gin.Params{{Key: "roleID", Value: "2"}})
+	c.Request.Header.Set("Content-Type", "application/json")
+	srv.UpdateRole(c)
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	mockStore.AssertCalls(t, mock.RetrieveRole, 2)
+	mockStore.AssertCalls(t, mock.UpdateRole, 1)
+
+	require.NotNil(t, event)
+	require.Equal(t, models.AuditUpdate, event.Action)
+	require.JSONEq(t, `{"description":{"from":"Can view things","to":"Read only access"},"is_default":{"from":true}}`, event.Diff.String)
+}
+
+func TestDeleteRole(t *testing.T) {
+	mockStore := openMockStore(t)
+	defer mockStore.Close()
+	srv := newTestServer(mockStore)
+
+	mockStore.OnDeleteRole = func(ctx context.Context, id int64) error {
+		if id == 2 {
+			return nil
+		}
+		return errors.ErrNotFound
+	}
+
+	mockStore.OnCreateAuditEvent = func(ctx context.Context, in *models.AuditEvent) (*models.AuditEvent, error) {
+		return in, nil
+	}
+
+	w, c := requestContext(t, http.MethodDelete, "/v1/roles/2", nil, gin.Params{{Key: "roleID", Value: "2"}})
+	srv.DeleteRole(c)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.True(t, parseReply(t, w).Success)
+
+	w, c = requestContext(t, http.MethodDelete, "/v1/roles/3", nil, gin.Params{{Key: "roleID", Value: "3"}})
+	srv.DeleteRole(c)
+	require.Equal(t, http.StatusNotFound, w.Code)
+
+	mockStore.AssertCalls(t, mock.CreateAuditEvent, 1)
+}
+
+func TestRolePermissions(t *testing.T) {
+	t.Run("Add", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		role := testRoles()[1]
+		mockStore.OnRetrieveRole = func(ctx context.Context, id int64) (*models.Role, error) {
+			return role, nil
+		}
+
+		mockStore.OnAddPermissionToRoleByTitle = func(ctx context.Context, roleID int64, permission string) error {
+			require.Equal(t, int64(2), roleID)
+			require.Equal(t, "config:view", permission)
+
+			role = testRoles()[1]
+			role.Permissions = []models.Permission{{ID: 8, Title: "config:view"}}
+			return nil
+		}
+
+		mockStore.OnCreateAuditEvent = func(ctx context.Context, in *models.AuditEvent) (*models.AuditEvent, error) {
+			return in, nil
+		}
+
+		w, c := requestContext(t, http.MethodPost, "/v1/roles/2/permissions", []byte(`{"permission": "config:view"}`), gin.Params{{Key: "roleID", Value: "2"}})
+		c.Request.Header.Set("Content-Type", "application/json")
+		gimlet.Set(c, gimlet.KeyUserClaims, &gimauth.Claims{Permissions: []string{"config:view"}})
+		srv.AddRolePermission(c)
+
+		var out api.Role
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &out))
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, []string{"config:view"}, out.Permissions)
+		mockStore.AssertCalls(t, mock.AddPermissionToRoleByTitle, 1)
+		mockStore.AssertCalls(t, mock.CreateAuditEvent, 1)
+	})
+
+	t.Run("AddExisting", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		mockStore.OnRetrieveRole = func(ctx context.Context, id int64) (*models.Role, error) {
+			return testRoles()[0], nil
+		}
+
+		w, c := requestContext(t, http.MethodPost, "/v1/roles/1/permissions", []byte(`{"permission": "config:view"}`), gin.Params{{Key: "roleID", Value: "1"}})
+		c.Request.Header.Set("Content-Type", "application/json")
+		srv.AddRolePermission(c)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		mockStore.AssertCalls(t, mock.AddPermissionToRoleByTitle, 0)
+	})
+
+	t.Run("AddUnknown", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		mockStore.OnRetrieveRole = func(ctx context.Context, id int64) (*models.Role, error) {
+			return testRoles()[1], nil
+		}
+
+		mockStore.OnAddPermissionToRoleByTitle = func(ctx context.Context, roleID int64, permission string) error {
+			return errors.ErrNotFound
+		}
+
+		w, c := requestContext(t, http.MethodPost, "/v1/roles/2/permissions", []byte(`{"permission": "foo:bar"}`), gin.Params{{Key: "roleID", Value: "2"}})
+		c.Request.Header.Set("Content-Type", "application/json")
+		gimlet.Set(c, gimlet.KeyUserClaims, &gimauth.Claims{Permissions: []string{"foo:bar"}})
+		srv.AddRolePermission(c)
+
+		require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		require.Equal(t, "invalid field permission: unknown permission", parseReply(t, w).Error)
+	})
+
+	t.Run("AddEscalation", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		mockStore.OnRetrieveRole = func(ctx context.Context, id int64) (*models.Role, error) {
+			return testRoles()[1], nil
+		}
+
+		w, c := requestContext(t, http.MethodPost, "/v1/roles/2/permissions", []byte(`{"permission": "config:manage"}`), gin.Params{{Key: "roleID", Value: "2"}})
+		c.Request.Header.Set("Content-Type", "application/json")
+		gimlet.Set(c, gimlet.KeyUserClaims, &gimauth.Claims{Permissions: []string{"roles:manage"}})
+		srv.AddRolePermission(c)
+
+		require.Equal(t, http.StatusForbidden, w.Code)
+		mockStore.AssertCalls(t, mock.AddPermissionToRoleByTitle, 0)
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		role := testRoles()[0]
+		mockStore.OnRetrieveRole = func(ctx context.Context, id int64) (*models.Role, error) {
+			return role, nil
+		}
+
+		mockStore.OnRemovePermissionFromRole = func(ctx context.Context, roleID, permissionID int64) error {
+			require.Equal(t, int64(1), roleID)
+			require.Equal(t, int64(9), permissionID)
+
+			role = testRoles()[0]
+			role.Permissions = []models.Permission{{ID: 8, Title: "config:view"}}
+			return nil
+		}
+
+		var event *models.AuditEvent
+		mockStore.OnCreateAuditEvent = func(ctx context.Context, in *models.AuditEvent) (*models.AuditEvent, error) {
+			event = in
+			return in, nil
+		}
+
+		params := gin.Params{{Key: "roleID", Value: "1"}, {Key: "permissionID", Value: "9"}}
+		w, c := requestContext(t, http.MethodDelete, "/v1/roles/1/permissions/9", nil, params)
+		srv.RemoveRolePermission(c)
+
+		var out api.Role
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &out))
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, []string{"config:view"}, out.Permissions)
+
+		require.NotNil(t, event)
+		require.JSONEq(t, `{"permissions":{"from":["config:view","config:manage"],"to":["config:view"]}}`, event.Diff.String)
+	})
+}
+
+func TestReplaceUserRoles(t *testing.T) {
+	userID := ulid.MakeSecure()
+	params := gin.Params{{Key: "userID", Value: userID.String()}}
+
+	setup := func(t *testing.T) *mock.Store {
+		mockStore := openMockStore(t)
+		mockStore.OnListRoles = func(ctx context.Context, filter tidal.ListFilter) (tidal.Cursor[*models.Role], error) {
+			return mock.NewCursor(testRoles()...), nil
+		}
+
+		mockStore.OnRetrieveUser = func(ctx context.Context, id ulid.ULID) (*models.User, error) {
+			return &models.User{BaseModel: tidal.BaseModel{ID: userID}, Email: "jane@example.com", Roles: []models.Role{{ID: 1, Title: "admin"}}}, nil
+		}
+
+		mockStore.OnRetrieveRole = retrieveTestRole
+
+		mockStore.OnCreateAuditEvent = func(ctx context.Context, in *models.AuditEvent) (*models.AuditEvent, error) {
+			return in, nil
+		}
+		return mockStore
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		mockStore := setup(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		var replaced bool
+		mockStore.OnReplaceUserRoles = func(ctx context.Context, id ulid.ULID, roleIDs []int64) error {
+			require.Equal(t, userID, id)
+			require.Equal(t, []int64{2}, roleIDs)
+			replaced = true
+			return nil
+		}
+
+		mockStore.OnRetrieveUser = func(ctx context.Context, id ulid.ULID) (*models.User, error) {
+			user := &models.User{BaseModel: tidal.BaseModel{ID: userID}, Email: "jane@example.com"}
+			if replaced {
+				user.Roles = []models.Role{{ID: 2, Title: "viewer"}}
+			} else {
+				user.Roles = []models.Role{{ID: 1, Title: "admin"}}
+			}
+			return user, nil
+		}
+
+		w, c := requestContext(t, http.MethodPut, "/v1/users/"+userID.String()+"/roles", []byte(`{"roles": ["Viewer"]}`), params)
+		c.Request.Header.Set("Content-Type", "application/json")
+		gimlet.Set(c, gimlet.KeyUserClaims, &gimauth.Claims{Permissions: []string{"users:manage"}})
+		srv.ReplaceUserRoles(c)
+
+		var out api.User
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &out))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.Len(t, out.Roles, 1)
+		require.Equal(t, "viewer", out.Roles[0].Title)
+		mockStore.AssertCalls(t, mock.ReplaceUserRoles, 1)
+		mockStore.AssertCalls(t, mock.CreateAuditEvent, 1)
+	})
+
+	t.Run("UnknownRole", func(t *testing.T) {
+		mockStore := setup(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		w, c := requestContext(t, http.MethodPut, "/v1/users/"+userID.String()+"/roles", []byte(`{"roles": ["viewer", "superuser"]}`), params)
+		c.Request.Header.Set("Content-Type", "application/json")
+		srv.ReplaceUserRoles(c)
+
+		require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		require.Equal(t, "invalid field roles: unknown role superuser", parseReply(t, w).Error)
+		mockStore.AssertCalls(t, mock.ReplaceUserRoles, 0)
+	})
+
+	t.Run("Duplicate", func(t *testing.T) {
+		mockStore := setup(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		w, c := requestContext(t, http.MethodPut, "/v1/users/"+userID.String()+"/roles", []byte(`{"roles": ["viewer", "viewer"]}`), params)
+		c.Request.Header.Set("Content-Type", "application/json")
+		srv.ReplaceUserRoles(c)
+
+		require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		mockStore.AssertCalls(t, mock.ListRoles, 0)
+	})
+
+	t.Run("UserNotFound", func(t *testing.T) {
+		mockStore := setup(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		mockStore.OnRetrieveUser = func(ctx context.Context, id ulid.ULID) (*models.User, error) {
+			return nil, errors.ErrNotFound
+		}
+
+		w, c := requestContext(t, http.MethodPut, "/v1/users/"+userID.String()+"/roles", []byte(`{"roles": []}`), params)
+		c.Request.Header.Set("Content-Type", "application/json")
+		gimlet.Set(c, gimlet.KeyUserClaims, &gimauth.Claims{Permissions: []string{"users:manage"}})
+		srv.ReplaceUserRoles(c)
+
+		require.Equal(t, http.StatusNotFound, w.Code)
+		mockStore.AssertCalls(t, mock.ReplaceUserRoles, 0)
+	})
+
+	t.Run("Escalation", func(t *testing.T) {
+		mockStore := setup(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		// A user manager cannot assign a role with permissions they do not have.
+		claims := &gimauth.Claims{Permissions: []string{"users:manage", "config:view"}}
+		claims.SetSubjectID(gimauth.SubjectUser, userID)
+
+		w, c := requestContext(t, http.MethodPut, "/v1/users/"+userID.String()+"/roles", []byte(`{"roles": ["viewer", "admin"]}`), params)
+		c.Request.Header.Set("Content-Type", "application/json")
+		gimlet.Set(c, gimlet.KeyUserClaims, claims)
+		srv.ReplaceUserRoles(c)
+
+		require.Equal(t, http.StatusForbidden, w.Code)
+		require.Equal(t, "cannot assign the role admin which has permissions you do not have", parseReply(t, w).Error)
+		mockStore.AssertCalls(t, mock.ReplaceUserRoles, 0)
+
+		// The role can be assigned by a user that has all of its permissions.
+		claims.Permissions = append(claims.Permissions, "config:manage")
+		mockStore.OnReplaceUserRoles = func(context.Context, ulid.ULID, []int64) error { return nil }
+
+		w, c = requestContext(t, http.MethodPut, "/v1/users/"+userID.String()+"/roles", []byte(`{"roles": ["viewer", "admin"]}`), params)
+		c.Request.Header.Set("Content-Type", "application/json")
+		gimlet.Set(c, gimlet.KeyUserClaims, claims)
+		srv.ReplaceUserRoles(c)
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		mockStore.AssertCalls(t, mock.ReplaceUserRoles, 1)
+	})
+
+	t.Run("Organization", func(t *testing.T) {
+		mockStore := setup(t)
+		defer mockStore.Close()
+		srv := newTestOAuthServer(t, mockStore)
+
+		// Roles assigned to a user apply in every organization, so they cannot be
+		// replaced by a requester who is logged into an organization.
+		w, c := requestContext(t, http.MethodPut, "/v1/users/"+userID.String()+"/roles", []byte(`{"roles": ["viewer"]}`), params)
+		c.Request.Header.Set("Content-Type", "application/json")
+		authorizeOrg(t, srv, c, acmeOrgID)
+		srv.ReplaceUserRoles(c)
+
+		require.Equal(t, http.StatusForbidden, w.Code)
+		mockStore.AssertCalls(t, mock.RetrieveUser, 0)
+		mockStore.AssertCalls(t, mock.ReplaceUserRoles, 0)
+	})
+}
+
+// testRoles returns an admin role with its permissions loaded and a default viewer
+// role without its permissions loaded.
+func testRoles() []*models.Role {
+	admin := &models.Role{ID: 1, Title: "admin", Description: "Can do all the things", Created: time.Now(), Modified: time.Now()}
+	admin.Permissions = []models.Permission{{ID: 8, Title: "config:view"}, {ID: 9, Title: "config:manage"}}
+
+	viewer := &models.Role{ID: 2, Title: "viewer", Description: "Can view things", IsDefault: true, Created: time.Now(), Modified: time.Now()}
+	return []*models.Role{admin, viewer}
+}
+
+// retrieveTestRole mocks RetrieveRole with the roles from testRoles.
+func retrieveTestRole(_ context.Context, id int64) (*models.Role, error) {
+	for _, role := range testRoles() {
+		if role.ID == id {
+			return role, nil
+		}
+	}
+	return nil, errors.ErrNotFound
+}
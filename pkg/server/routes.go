@@ -10,7 +10,6 @@ import (
 	"go.rtnl.ai/gimlet/ratelimit"
 	"go.rtnl.ai/gimlet/secure"
 	"go.rtnl.ai/quarterdeck/pkg"
-	"go.rtnl.ai/quarterdeck/pkg/auth/permissions"
 	"go.rtnl.ai/quarterdeck/pkg/docs"
 	"go.rtnl.ai/quarterdeck/pkg/telemetry"
 	"go.rtnl.ai/quarterdeck/pkg/web"
@@ -72,15 +71,27 @@ func (s *Server) setupRoutes() (err error) {
 		}
 	}
 
+	return s.addRoutes()
+}
+
+// addRoutes registers the application routes; every route must have an authorization
+// policy defined in routePolicies (see policy.go).
+func (s *Server) addRoutes() (err error) {
 	// Instantiate per-route middleware
 	var authenticate gin.HandlerFunc
 	if authenticate, err = auth.Authenticate(s.issuer); err != nil {
 		return err
 	}
 
+	// Authorization middleware enforces the route policy after authentication
+	authorize := s.Authorization()
+
 	// CSRF protection middleware
 	csrf := csrf.DoubleCookie(s.csrf)
 
+	// Resources shared by every organization can only be managed outside of them
+	instanceOnly := s.InstanceOnly()
+
 	// NotFound and NotAllowed routes
 	s.router.NoRoute(s.NotFound)
 	s.router.NoMethod(s.NotAllowed)
@@ -104,6 +115,16 @@ func (s *Server) setupRoutes() (err error) {
 		uio.GET("/forgot-password/sent", s.ForgotPasswordSentPage)
 		uio.GET("/reset-password", s.ResetPasswordPage)
 
+		// UI for accepting a team invite
+		uio.GET("/invite", s.AcceptInvitePage)
+
+		// UI for verifying an email address
+		uio.GET("/verify-email", s.VerifyEmailPage)
+
+		// UI for confirming or reverting an email address change
+		uio.GET("/confirm-email", s.ConfirmEmailPage)
+		uio.GET("/revert-email", s.RevertEmailPage)
+
 		// The "well known" routes expose client security information and credentials.
 		wk := uio.Group("/.well-known")
 		{
@@ -114,7 +135,7 @@ func (s *Server) setupRoutes() (err error) {
 	}
 
 	// Web UI Routes (Authenticated)
-	uia := s.router.Group("", authenticate)
+	uia := s.router.Group("", authenticate, authorize)
 	{
 		uia.GET("/", s.Dashboard)
 		uia.GET("/settings", s.WorkspaceSettingsPage)
@@ -127,13 +148,49 @@ func (s *Server) setupRoutes() (err error) {
 		{
 			profile.GET("", s.ProfilePage)
 			profile.GET("/account", s.ProfileSettingsPage)
+			profile.GET("/sessions", s.ProfileSessionsPage)
+			profile.GET("/security", s.ProfileSecurityPage)
 			profile.GET("/delete", s.ProfileDeletePage)
 		}
 
+		// OAuth2 authorization endpoint for OIDC clients
+		uia.GET("/oauth/authorize", s.Authorize)
+
 		// Add documentation routes
 		docs.Routes(uia.Group("/docs"))
 	}
 
+	// OAuth2 token, introspection, and revocation endpoints; clients authenticate with
+	// their credentials rather than with cookies so CSRF is not required.
+	s.router.POST("/oauth/token", s.Token)
+	s.router.POST("/oauth/introspect", s.Introspect)
+	s.router.POST("/oauth/revoke", s.Revoke)
+
+	// SCIM 2.0 provisioning; identity providers authenticate with an access token issued
+	// to an api key rather than with cookies so CSRF is not required.
+	scimv2 := s.router.Group(scimPath, authenticate, authorize)
+	{
+		scimv2.GET("/ServiceProviderConfig", s.SCIMServiceProviderConfig)
+		scimv2.GET("/Schemas", s.ListSCIMSchemas)
+		scimv2.GET("/Schemas/:id", s.SCIMSchemaDetail)
+		scimv2.GET("/ResourceTypes", s.ListSCIMResourceTypes)
+		scimv2.GET("/ResourceTypes/:id", s.SCIMResourceTypeDetail)
+
+		scimv2.GET("/Users", s.ListSCIMUsers)
+		scimv2.POST("/Users", s.CreateSCIMUser)
+		scimv2.GET("/Users/:id", s.SCIMUserDetail)
+		scimv2.PUT("/Users/:id", s.ReplaceSCIMUser)
+		scimv2.PATCH("/Users/:id", s.PatchSCIMUser)
+		scimv2.DELETE("/Users/:id", s.DeleteSCIMUser)
+
+		scimv2.GET("/Groups", s.ListSCIMGroups)
+		scimv2.POST("/Groups", s.CreateSCIMGroup)
+		scimv2.GET("/Groups/:id", s.SCIMGroupDetail)
+		scimv2.PUT("/Groups/:id", s.ReplaceSCIMGroup)
+		scimv2.PATCH("/Groups/:id", s.PatchSCIMGroup)
+		scimv2.DELETE("/Groups/:id", s.DeleteSCIMGroup)
+	}
+
 	// Unauthenticated API Routes (Including Content Negotiated Partials)
 	v1o := s.router.Group("/v1")
 	{
@@ -147,19 +204,36 @@ func (s *Server) setupRoutes() (err error) {
 		// Authentication endpoints
 		v1o.GET("/login", s.PrepareLogin)
 		v1o.POST("/login", csrf, s.Login)
+		v1o.POST("/login/mfa", csrf, s.LoginMFA)
+		v1o.POST("/login/passkey/begin", csrf, s.BeginPasskeyLogin)
+		v1o.POST("/login/passkey/finish", csrf, s.FinishPasskeyLogin)
 		v1o.POST("/authenticate", s.Authenticate)
 		v1o.POST("/reauthenticate", s.Reauthenticate)
 
 		// API endpoints for forgot/reset password
 		v1o.POST("/forgot-password", s.ForgotPassword)
 		v1o.POST("/reset-password", s.ResetPassword)
+
+		// API endpoint for accepting a team invite
+		v1o.POST("/accept-invite", s.AcceptInvite)
+
+		// API endpoints for email verification
+		v1o.POST("/verify-email", s.VerifyEmail)
+		v1o.POST("/verify-email/resend", s.ResendVerifyEmail)
+
+		// API endpoints for confirming or reverting an email address change
+		v1o.POST("/confirm-email", s.ConfirmEmail)
+		v1o.POST("/revert-email", s.RevertEmail)
 	}
 
 	// Authenticated API Routes (Including Content Negotiated Partials)
-	v1a := s.router.Group("/v1", authenticate)
+	v1a := s.router.Group("/v1", authenticate, authorize)
 	{
 		// Database Statistics
-		v1a.GET("/dbinfo", auth.Authorize(permissions.ConfigView), s.DBInfo)
+		v1a.GET("/dbinfo", instanceOnly, s.DBInfo)
+
+		// Audit Log
+		v1a.GET("/activity", s.ListActivity)
 
 		// User account Management
 		users := v1a.Group("/users")
@@ -169,7 +243,89 @@ func (s *Server) setupRoutes() (err error) {
 			users.GET("/:userID", s.UserDetail)
 			users.PUT("/:userID", csrf, s.UpdateUser)
 			users.DELETE("/:userID", csrf, s.DeleteUser)
+			users.POST("/:userID/restore", csrf, s.RestoreUser)
+			users.PUT("/:userID/status", csrf, s.UpdateUserStatus)
+			users.DELETE("/:userID/purge", csrf, s.PurgeUser)
 			users.POST("/:userID/password", csrf, s.ChangePassword)
+			users.POST("/:userID/email", csrf, s.ChangeEmail)
+			users.GET("/:userID/sessions", s.ListSessions)
+			users.DELETE("/:userID/sessions", csrf, s.RevokeAllSessions)
+			users.DELETE("/:userID/sessions/:sessionID", csrf, s.RevokeSession)
+			users.POST("/:userID/mfa/totp", csrf, s.EnrollTOTP)
+			users.POST("/:userID/mfa/totp/confirm", csrf, s.ConfirmTOTP)
+			users.DELETE("/:userID/mfa/totp", csrf, s.DisableTOTP)
+			users.POST("/:userID/mfa/recovery-codes", csrf, s.RegenerateRecoveryCodes)
+			users.GET("/:userID/passkeys", s.ListPasskeys)
+			users.POST("/:userID/passkeys", csrf, s.FinishPasskeyRegistration)
+			users.POST("/:userID/passkeys/register", csrf, s.BeginPasskeyRegistration)
+			users.DELETE("/:userID/passkeys/:passkeyID", csrf, s.DeletePasskey)
+			users.POST("/:userID/unlock", csrf, s.UnlockUser)
+			users.GET("/:userID/roles", s.ListUserRoles)
+			users.PUT("/:userID/roles", csrf, s.ReplaceUserRoles)
+			users.GET("/:userID/organizations", s.ListUserOrganizations)
+		}
+
+		// Team Invite Management
+		invites := v1a.Group("/invites")
+		{
+			invites.GET("", s.ListInvites)
+			invites.POST("/:inviteID/resend", csrf, s.ResendInvite)
+			invites.DELETE("/:inviteID", csrf, s.RevokeInvite)
+		}
+
+		// Role Management
+		roles := v1a.Group("/roles")
+		{
+			roles.GET("", s.ListRoles)
+			roles.POST("", csrf, instanceOnly, s.CreateRole)
+			roles.GET("/:roleID", s.RoleDetail)
+			roles.PUT("/:roleID", csrf, instanceOnly, s.UpdateRole)
+			roles.DELETE("/:roleID", csrf, instanceOnly, s.DeleteRole)
+			roles.POST("/:roleID/permissions", csrf, instanceOnly, s.AddRolePermission)
+			roles.DELETE("/:roleID/permissions/:permissionID", csrf, instanceOnly, s.RemoveRolePermission)
+		}
+
+		// Permission Management
+		perms := v1a.Group("/permissions")
+		{
+			perms.GET("", s.ListPermissions)
+			perms.POST("", csrf, instanceOnly, s.CreatePermission)
+			perms.GET("/:permissionID", s.PermissionDetail)
+			perms.PUT("/:permissionID", csrf, instanceOnly, s.UpdatePermission)
+			perms.DELETE("/:permissionID", csrf, instanceOnly, s.DeletePermission)
+		}
+
+		// Organization (Tenant) Management
+		orgs := v1a.Group("/organizations")
+		{
+			orgs.GET("", s.ListOrganizations)
+			orgs.POST("", csrf, s.CreateOrganization)
+			orgs.GET("/:orgID", s.OrganizationDetail)
+			orgs.PUT("/:orgID", csrf, s.UpdateOrganization)
+			orgs.DELETE("/:orgID", csrf, s.DeleteOrganization)
+			orgs.GET("/:orgID/members", s.ListOrganizationMembers)
+			orgs.PUT("/:orgID/members/:userID", csrf, s.ReplaceOrganizationMemberRoles)
+			orgs.POST("/:orgID/switch", csrf, s.SwitchOrganization)
+		}
+
+		// Webhook Subscriptions and Delivery Log
+		hooks := v1a.Group("/webhooks", instanceOnly)
+		{
+			hooks.GET("", s.ListWebhooks)
+			hooks.POST("", csrf, s.CreateWebhook)
+			hooks.GET("/:webhookID", s.WebhookDetail)
+			hooks.PUT("/:webhookID", csrf, s.UpdateWebhook)
+			hooks.DELETE("/:webhookID", csrf, s.DeleteWebhook)
+			hooks.GET("/:webhookID/deliveries", s.ListWebhookDeliveries)
+			hooks.POST("/:webhookID/deliveries/:deliveryID/redeliver", csrf, s.RedeliverWebhookDelivery)
+		}
+
+		// Token Signing Key Rotation
+		signingkeys := v1a.Group("/signingkeys", instanceOnly)
+		{
+			signingkeys.GET("", s.ListSigningKeys)
+			signingkeys.POST("/rotate", csrf, s.RotateSigningKey)
+			signingkeys.POST("/:keyID/retire", csrf, s.RetireSigningKey)
 		}
 
 		// API Key Management
@@ -181,6 +337,7 @@ func (s *Server) setupRoutes() (err error) {
 			apikeys.PUT("/:keyID", csrf, s.UpdateAPIKey)
 			apikeys.DELETE("/:keyID", csrf, s.DeleteAPIKey)
 			apikeys.GET("/:keyID/edit", s.UpdateAPIKeyPreview)
+			apikeys.POST("/:keyID/unlock", csrf, s.UnlockAPIKey)
 		}
 
 		// OIDC Endpoints
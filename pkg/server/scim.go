@@ -0,0 +1,990 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.rtnl.ai/quarterdeck/pkg/api/v1"
+	"go.rtnl.ai/quarterdeck/pkg/auth/passwords"
+	"go.rtnl.ai/quarterdeck/pkg/enum"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/scim"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/txn"
+	"go.rtnl.ai/tidal"
+	"go.rtnl.ai/ulid"
+	"go.rtnl.ai/x/randstr"
+	"go.rtnl.ai/x/rlog"
+)
+
+// scimPath is the path of the SCIM service relative to the issuer.
+const scimPath = "/scim/v2"
+
+var (
+	errSCIMUserNotFound    = scim.NewError(http.StatusNotFound, "", "user not found")
+	errSCIMGroupNotFound   = scim.NewError(http.StatusNotFound, "", "group not found")
+	errSCIMVersionMismatch = scim.NewError(http.StatusPreconditionFailed, "", "the resource has been modified")
+)
+
+// ============================================================================
+// SCIM service discovery handlers
+// ============================================================================
+
+// SCIMServiceProviderConfig returns the SCIM features that are supported.
+func (s *Server) SCIMServiceProviderConfig(c *gin.Context) {
+	scimJSON(c, http.StatusOK, scim.NewServiceProviderConfig(s.scimBaseURL()))
+}
+
+// ListSCIMSchemas returns the schemas of the SCIM User and Group resources.
+func (s *Server) ListSCIMSchemas(c *gin.Context) {
+	schemas := scim.Schemas(s.scimBaseURL())
+	scimJSON(c, http.StatusOK, scim.NewListResponse(schemas, 1, len(schemas)))
+}
+
+// SCIMSchemaDetail returns the schema with the URN in the :id param.
+func (s *Server) SCIMSchemaDetail(c *gin.Context) {
+	for _, schema := range scim.Schemas(s.scimBaseURL()) {
+		if schema.ID == c.Param("id") {
+			scimJSON(c, http.StatusOK, schema)
+			return
+		}
+	}
+	scimError(c, scim.NewError(http.StatusNotFound, "", "schema not found"))
+}
+
+// ListSCIMResourceTypes returns the SCIM User and Group resource types.
+func (s *Server) ListSCIMResourceTypes(c *gin.Context) {
+	types := scim.ResourceTypes(s.scimBaseURL())
+	scimJSON(c, http.StatusOK, scim.NewListResponse(types, 1, len(types)))
+}
+
+// SCIMResourceTypeDetail returns the resource type with the name in the :id param.
+func (s *Server) SCIMResourceTypeDetail(c *gin.Context) {
+	for _, rtype := range scim.ResourceTypes(s.scimBaseURL()) {
+		if rtype.ID == c.Param("id") {
+			scimJSON(c, http.StatusOK, rtype)
+			return
+		}
+	}
+	scimError(c, scim.NewError(http.StatusNotFound, "", "resource type not found"))
+}
+
+// ============================================================================
+// SCIM user handlers
+// ============================================================================
+
+// ListSCIMUsers returns the users that have not been deleted; identity providers look up
+// users by userName (the email address) before they are provisioned.
+func (s *Server) ListSCIMUsers(c *gin.Context) {
+	var (
+		err    error
+		query  *scimQuery
+		filter *scim.Filter
+		users  []*models.User
+	)
+
+	if query, filter, err = parseSCIMQuery(c); err != nil {
+		scimError(c, err)
+		return
+	}
+
+	ctx := c.Request.Context()
+	switch {
+	case filter == nil:
+		if users, err = listAll(s.store.ListUsers(ctx, (&api.UserPage{}).Filter())); err != nil {
+			scimError(c, err)
+			return
+		}
+	case filter.Is("userName"), filter.Is("emails.value"):
+		var user *models.User
+		if user, err = s.store.RetrieveUserByEmail(ctx, filter.Value); err != nil && !errors.Is(err, errors.ErrNotFound) {
+			scimError(c, err)
+			return
+		}
+
+		if user != nil && user.Status != enum.UserStatusDeleted {
+			users = append(users, user)
+		}
+	case filter.Is("id"):
+		var user *models.User
+		if user, err = s.retrieveSCIMUser(ctx, filter.Value); err != nil && !errors.Is(err, errSCIMUserNotFound) {
+			scimError(c, err)
+			return
+		}
+
+		if user != nil {
+			users = append(users, user)
+		}
+	default:
+		scimError(c, scim.BadRequest(scim.ErrorInvalidFilter, "users can only be filtered by userName or id"))
+		return
+	}
+
+	base := s.scimBaseURL()
+	out := make([]*scim.User, 0, len(users))
+	for _, user := range users {
+		var resource *scim.User
+		if resource, err = scim.NewUser(user, base); err != nil {
+			scimError(c, err)
+			return
+		}
+		out = append(out, resource)
+	}
+
+	scimJSON(c, http.StatusOK, scim.NewListResponse(out, query.StartIndex, *query.Count))
+}
+
+// CreateSCIMUser provisions a user with an unguessable password; active users are sent
+// the same welcome email as users created by an administrator so that they can set a
+// password. Users that are provisioned as inactive are deactivated.
+func (s *Server) CreateSCIMUser(c *gin.Context) {
+	var (
+		err   error
+		in    *scim.User
+		model *models.User
+		out   *api.User
+	)
+
+	in = &scim.User{}
+	if err = c.ShouldBindJSON(in); err != nil {
+		c.Error(err)
+		scimError(c, scim.BadRequest(scim.ErrorInvalidSyntax, "could not parse user data"))
+		return
+	}
+
+	if err = in.Validate(); err != nil {
+		scimError(c, err)
+		return
+	}
+
+	ctx := c.Request.Context()
+	model = &models.User{
+		Name:  sql.NullString{String: in.FullName(), Valid: in.FullName() != ""},
+		Email: in.UserName,
+	}
+
+	if model.Password, err = passwords.CreateDerivedKey(randstr.Password(24)); err != nil {
+		scimError(c, err)
+		return
+	}
+
+	// The user is created, deactivated if inactive, and the webhook event queued in the
+	// same transaction so that the event is only delivered if the user is provisioned.
+	var queued int
+	if err = s.store.WithTx(ctx, nil, func(tx txn.Tx) (err error) {
+		if model, err = tx.CreateUser(model); err != nil {
+			return err
+		}
+
+		if !in.IsActive() {
+			if err = changeUserStatusTx(tx, model.ID, enum.UserStatusDeactivated); err != nil {
+				return err
+			}
+		}
+
+		// Reload so that the roles and status are current for the response.
+		model, out, queued, err = publishUserTx(tx, model.ID, enum.WebhookEventUserCreated)
+		return err
+	}); err != nil {
+		if errors.Is(err, errors.ErrAlreadyExists) {
+			scimError(c, scim.NewError(http.StatusConflict, scim.ErrorUniqueness, "userName is already in use"))
+			return
+		}
+
+		scimError(c, err)
+		return
+	}
+	s.notifyQueued(queued)
+
+	if in.IsActive() {
+		if err = s.sendWelcomeEmail(ctx, model); err != nil {
+			rlog.ErrorAttrs(ctx, "could not send provisioned user a welcome email",
+				slog.Any("err", err), slog.String("user_id", model.ID.String()))
+		}
+	}
+
+	s.audit(c, models.AuditCreate, models.AuditUser, model.ID.String(), nil, out)
+	s.renderSCIMUser(c, http.StatusCreated, model)
+}
+
+// SCIMUserDetail returns the user; deleted users are not found since they have been
+// deprovisioned.
+func (s *Server) SCIMUserDetail(c *gin.Context) {
+	var (
+		err  error
+		user *models.User
+	)
+
+	if user, err = s.retrieveSCIMUser(c.Request.Context(), c.Param("id")); err != nil {
+		scimError(c, err)
+		return
+	}
+
+	s.renderSCIMUser(c, http.StatusOK, user)
+}
+
+// ReplaceSCIMUser replaces the name, userName, and active status of the user.
+func (s *Server) ReplaceSCIMUser(c *gin.Context) {
+	var (
+		err  error
+		in   *scim.User
+		user *models.User
+	)
+
+	if user, err = s.retrieveSCIMUser(c.Request.Context(), c.Param("id")); err != nil {
+		scimError(c, err)
+		return
+	}
+
+	in = &scim.User{}
+	if err = c.ShouldBindJSON(in); err != nil {
+		c.Error(err)
+		scimError(c, scim.BadRequest(scim.ErrorInvalidSyntax, "could not parse user data"))
+		return
+	}
+
+	if err = in.Validate(); err != nil {
+		scimError(c, err)
+		return
+	}
+
+	if err = s.updateSCIMUser(c, user, in.FullName(), in.UserName, in.IsActive()); err != nil {
+		scimError(c, err)
+		return
+	}
+
+	if user, err = s.retrieveSCIMUser(c.Request.Context(), user.ID.String()); err != nil {
+		scimError(c, err)
+		return
+	}
+
+	s.renderSCIMUser(c, http.StatusOK, user)
+}
+
+// PatchSCIMUser applies the PATCH operations to the user, e.g. to deactivate the user by
+// replacing active with false.
+func (s *Server) PatchSCIMUser(c *gin.Context) {
+	var (
+		err      error
+		in       *scim.PatchOp
+		user     *models.User
+		original *scim.User
+	)
+
+	if user, err = s.retrieveSCIMUser(c.Request.Context(), c.Param("id")); err != nil {
+		scimError(c, err)
+		return
+	}
+
+	in = &scim.PatchOp{}
+	if err = c.ShouldBindJSON(in); err != nil {
+		c.Error(err)
+		scimError(c, scim.BadRequest(scim.ErrorInvalidSyntax, "could not parse patch operations"))
+		return
+	}
+
+	if err = in.Validate(); err != nil {
+		scimError(c, err)
+		return
+	}
+
+	if original, err = scim.NewUser(user, s.scimBaseURL()); err != nil {
+		scimError(c, err)
+		return
+	}
+
+	patched := *original
+	if err = in.Apply(&patched, scim.UserReadOnly...); err != nil {
+		scimError(c, err)
+		return
+	}
+
+	if err = patched.Validate(); err != nil {
+		scimError(c, err)
+		return
+	}
+
+	if err = s.updateSCIMUser(c, user, patched.PatchedName(original), patched.UserName, patched.IsActive()); err != nil {
+		scimError(c, err)
+		return
+	}
+
+	if user, err = s.retrieveSCIMUser(c.Request.Context(), user.ID.String()); err != nil {
+		scimError(c, err)
+		return
+	}
+
+	s.renderSCIMUser(c, http.StatusOK, user)
+}
+
+// DeleteSCIMUser deprovisions the user by deleting them in the same way as DeleteUser so
+// that the user can be restored until the retention window elapses.
+func (s *Server) DeleteSCIMUser(c *gin.Context) {
+	var (
+		err  error
+		user *models.User
+	)
+
+	if user, err = s.retrieveSCIMUser(c.Request.Context(), c.Param("id")); err != nil {
+		scimError(c, err)
+		return
+	}
+
+	if err = s.checkSCIMVersion(c, user); err != nil {
+		scimError(c, err)
+		return
+	}
+
+	if err = s.deleteUser(c, user); err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			scimError(c, errSCIMUserNotFound)
+			return
+		}
+
+		scimError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ============================================================================
+// SCIM group handlers
+// ============================================================================
+
+// ListSCIMGroups returns the roles and the users assigned to them; the members are not
+// returned if they are excluded with the excludedAttributes query parameter.
+func (s *Server) ListSCIMGroups(c *gin.Context) {
+	var (
+		err    error
+		query  *scimQuery
+		filter *scim.Filter
+		roles  []*models.Role
+	)
+
+	if query, filter, err = parseSCIMQuery(c); err != nil {
+		scimError(c, err)
+		return
+	}
+
+	ctx := c.Request.Context()
+	switch {
+	case filter == nil:
+		if roles, err = listAll(s.store.ListRoles(ctx, nil)); err != nil {
+			scimError(c, err)
+			return
+		}
+	case filter.Is("displayName"), filter.Is("id"):
+		var role *models.Role
+		if filter.Is("id") {
+			role, err = s.retrieveSCIMRole(ctx, filter.Value)
+		} else {
+			role, err = s.store.RetrieveRoleByTitle(ctx, filter.Value)
+		}
+
+		if err != nil && !errors.Is(err, errors.ErrNotFound) && !errors.Is(err, errSCIMGroupNotFound) {
+			scimError(c, err)
+			return
+		}
+
+		if role != nil {
+			roles = append(roles, role)
+		}
+	default:
+		scimError(c, scim.BadRequest(scim.ErrorInvalidFilter, "groups can only be filtered by displayName or id"))
+		return
+	}
+
+	out := make([]*scim.Group, 0, len(roles))
+	for _, role := range roles {
+		var group *scim.Group
+		if group, err = s.scimGroup(ctx, role); err != nil {
+			scimError(c, err)
+			return
+		}
+
+		if query.excludes("members") {
+			group.Members = nil
+		}
+		out = append(out, group)
+	}
+
+	scimJSON(c, http.StatusOK, scim.NewListResponse(out, query.StartIndex, *query.Count))
+}
+
+// CreateSCIMGroup creates a role without any permissions and assigns it to the members.
+func (s *Server) CreateSCIMGroup(c *gin.Context) {
+	var (
+		err   error
+		in    *scim.Group
+		role  *models.Role
+		group *scim.Group
+	)
+
+	in = &scim.Group{}
+	if err = c.ShouldBindJSON(in); err != nil {
+		c.Error(err)
+		scimError(c, scim.BadRequest(scim.ErrorInvalidSyntax, "could not parse group data"))
+		return
+	}
+
+	if err = in.Validate(); err != nil {
+		scimError(c, err)
+		return
+	}
+
+	ctx := c.Request.Context()
+	if role, err = s.store.CreateRole(ctx, &models.Role{Title: in.DisplayName}); err != nil {
+		if errors.Is(err, errors.ErrAlreadyExists) {
+			scimError(c, scim.NewError(http.StatusConflict, scim.ErrorUniqueness, "displayName is already in use"))
+			return
+		}
+
+		scimError(c, err)
+		return
+	}
+
+	if err = s.replaceSCIMGroupMembers(ctx, role.ID, nil, in.MemberIDs()); err != nil {
+		scimError(c, err)
+		return
+	}
+
+	if group, err = s.scimGroup(ctx, role); err != nil {
+		scimError(c, err)
+		return
+	}
+
+	s.audit(c, models.AuditCreate, models.AuditRole, group.ID, nil, group)
+	renderSCIMGroup(c, http.StatusCreated, group)
+}
+
+// SCIMGroupDetail returns the role and the users assigned to it.
+func (s *Server) SCIMGroupDetail(c *gin.Context) {
+	var (
+		err   error
+		role  *models.Role
+		group *scim.Group
+	)
+
+	if role, err = s.retrieveSCIMRole(c.Request.Context(), c.Param("id")); err != nil {
+		scimError(c, err)
+		return
+	}
+
+	if group, err = s.scimGroup(c.Request.Context(), role); err != nil {
+		scimError(c, err)
+		return
+	}
+
+	renderSCIMGroup(c, http.StatusOK, group)
+}
+
+// ReplaceSCIMGroup renames the role and replaces the users assigned to it.
+func (s *Server) ReplaceSCIMGroup(c *gin.Context) {
+	var (
+		err  error
+		in   *scim.Group
+		role *models.Role
+	)
+
+	if role, err = s.retrieveSCIMRole(c.Request.Context(), c.Param("id")); err != nil {
+		scimError(c, err)
+		return
+	}
+
+	in = &scim.Group{}
+	if err = c.ShouldBindJSON(in); err != nil {
+		c.Error(err)
+		scimError(c, scim.BadRequest(scim.ErrorInvalidSyntax, "could not parse group data"))
+		return
+	}
+
+	if err = in.Validate(); err != nil {
+		scimError(c, err)
+		return
+	}
+
+	s.updateSCIMGroup(c, role, func(*scim.Group) (*scim.Group, error) {
+		return in, nil
+	})
+}
+
+// PatchSCIMGroup applies the PATCH operations to the group, e.g. to add or remove
+// members or to rename the role.
+func (s *Server) PatchSCIMGroup(c *gin.Context) {
+	var (
+		err  error
+		in   *scim.PatchOp
+		role *models.Role
+	)
+
+	if role, err = s.retrieveSCIMRole(c.Request.Context(), c.Param("id")); err != nil {
+		scimError(c, err)
+		return
+	}
+
+	in = &scim.PatchOp{}
+	if err = c.ShouldBindJSON(in); err != nil {
+		c.Error(err)
+		scimError(c, scim.BadRequest(scim.ErrorInvalidSyntax, "could not parse patch operations"))
+		return
+	}
+
+	if err = in.Validate(); err != nil {
+		scimError(c, err)
+		return
+	}
+
+	s.updateSCIMGroup(c, role, func(original *scim.Group) (*scim.Group, error) {
+		patched := *original
+		if err := in.Apply(&patched, scim.GroupReadOnly...); err != nil {
+			return nil, err
+		}
+
+		if err := patched.Validate(); err != nil {
+			return nil, err
+		}
+		return &patched, nil
+	})
+}
+
+// DeleteSCIMGroup deletes the role, removing it from the users it was assigned to.
+func (s *Server) DeleteSCIMGroup(c *gin.Context) {
+	var (
+		err   error
+		role  *models.Role
+		group *scim.Group
+	)
+
+	ctx := c.Request.Context()
+	if role, err = s.retrieveSCIMRole(ctx, c.Param("id")); err != nil {
+		scimError(c, err)
+		return
+	}
+
+	if group, err = s.scimGroup(ctx, role); err != nil {
+		scimError(c, err)
+		return
+	}
+
+	if match := c.GetHeader("If-Match"); match != "" && !scim.MatchVersion(match, group.Meta.Version) {
+		scimError(c, errSCIMVersionMismatch)
+		return
+	}
+
+	if err = s.store.DeleteRole(ctx, role.ID); err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			scimError(c, errSCIMGroupNotFound)
+			return
+		}
+
+		scimError(c, err)
+		return
+	}
+
+	s.audit(c, models.AuditDelete, models.AuditRole, group.ID, nil, nil)
+	c.Status(http.StatusNoContent)
+}
+
+// ============================================================================
+// SCIM helpers
+// ============================================================================
+
+// scimQuery are the query parameters of SCIM list requests; the start index is 1-based.
+type scimQuery struct {
+	Filter             string `form:"filter"`
+	StartIndex         int    `form:"startIndex"`
+	Count              *int   `form:"count"`
+	ExcludedAttributes string `form:"excludedAttributes"`
+}
+
+// parseSCIMQuery parses the query parameters and the filter of a SCIM list request;
+// the count is the default page count if it is not specified and is limited to the
+// maximum page count.
+func parseSCIMQuery(c *gin.Context) (query *scimQuery, filter *scim.Filter, err error) {
+	query = &scimQuery{}
+	if err = c.ShouldBindQuery(query); err != nil {
+		c.Error(err)
+		return nil, nil, scim.BadRequest(scim.ErrorInvalidValue, "could not parse query parameters")
+	}
+
+	if query.Count == nil {
+		count := scim.DefaultPageCount
+		query.Count = &count
+	}
+
+	if *query.Count > scim.MaxPageCount {
+		*query.Count = scim.MaxPageCount
+	}
+
+	if filter, err = scim.ParseFilter(query.Filter); err != nil {
+		return nil, nil, err
+	}
+	return query, filter, nil
+}
+
+// excludes returns true if the attribute is in the excludedAttributes parameter.
+func (q *scimQuery) excludes(attr string) bool {
+	for _, excluded := range strings.Split(q.ExcludedAttributes, ",") {
+		if strings.EqualFold(strings.TrimSpace(excluded), attr) {
+			return true
+		}
+	}
+	return false
+}
+
+// scimBaseURL returns the URL of the SCIM service that resource locations are relative to.
+func (s *Server) scimBaseURL() string {
+	base, err := url.Parse(s.conf.Auth.Issuer)
+	if err != nil {
+		return scimPath
+	}
+	return base.ResolveReference(&url.URL{Path: scimPath}).String()
+}
+
+// scimJSON writes the response with the SCIM media type.
+func scimJSON(c *gin.Context, code int, obj any) {
+	c.Header("Content-Type", scim.MediaType+"; charset=utf-8")
+	c.JSON(code, obj)
+}
+
+// scimError writes a SCIM error response; errors that are not SCIM errors are logged and
+// returned as an internal error without any details.
+func scimError(c *gin.Context, err error) {
+	var serr *scim.Error
+	if !errors.As(err, &serr) {
+		c.Error(err)
+		serr = scim.NewError(http.StatusInternalServerError, "", "could not process SCIM request")
+	}
+	scimJSON(c, serr.StatusCode(), serr)
+}
+
+// retrieveSCIMUser fetches the user with the ID; deleted users are not found since they
+// have been deprovisioned.
+func (s *Server) retrieveSCIMUser(ctx context.Context, id string) (user *models.User, err error) {
+	var userID ulid.ULID
+	if userID, err = ulid.Parse(id); err != nil {
+		return nil, errSCIMUserNotFound
+	}
+
+	if user, err = s.store.RetrieveUser(ctx, userID); err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			return nil, errSCIMUserNotFound
+		}
+		return nil, err
+	}
+
+	if user.Status == enum.UserStatusDeleted {
+		return nil, errSCIMUserNotFound
+	}
+	return user, nil
+}
+
+// checkSCIMVersion returns a precondition failed error if the request has an If-Match
+// header that does not match the current version of the user.
+func (s *Server) checkSCIMVersion(c *gin.Context, user *models.User) error {
+	match := c.GetHeader("If-Match")
+	if match == "" {
+		return nil
+	}
+
+	current, err := scim.NewUser(user, s.scimBaseURL())
+	if err != nil {
+		return err
+	}
+
+	if !scim.MatchVersion(match, current.Meta.Version) {
+		return errSCIMVersionMismatch
+	}
+	return nil
+}
+
+// renderSCIMUser writes the user with its version in the ETag header. A GET request with
+// an If-None-Match header that matches the version is not modified.
+func (s *Server) renderSCIMUser(c *gin.Context, code int, user *models.User) {
+	out, err := scim.NewUser(user, s.scimBaseURL())
+	if err != nil {
+		scimError(c, err)
+		return
+	}
+
+	c.Header("ETag", out.Meta.Version)
+	if c.Request.Method == http.MethodGet {
+		if match := c.GetHeader("If-None-Match"); match != "" && scim.MatchVersion(match, out.Meta.Version) {
+			c.Status(http.StatusNotModified)
+			return
+		}
+	}
+
+	if code == http.StatusCreated {
+		c.Header("Location", out.Meta.Location)
+	}
+	scimJSON(c, code, out)
+}
+
+// updateSCIMUser applies a replace or patch request to the user after checking the
+// If-Match header. Email changes must be confirmed by the user in the same way as
+// UpdateUser; a user that is no longer active is deactivated and a deactivated user that
+// is active again is reactivated. Suspended users remain suspended since suspension is
+// managed by Quarterdeck administrators rather than the identity provider.
+func (s *Server) updateSCIMUser(c *gin.Context, user *models.User, name, email string, active bool) (err error) {
+	if err = s.checkSCIMVersion(c, user); err != nil {
+		return err
+	}
+
+	var (
+		ctx    = c.Request.Context()
+		before *api.User
+		after  *api.User
+	)
+
+	if before, err = api.NewUser(user); err != nil {
+		return err
+	}
+
+	if email != user.Email {
+		if err = s.sendChangeEmail(ctx, user, email); err != nil {
+			if errors.Is(err, errors.ErrAlreadyExists) {
+				return scim.NewError(http.StatusConflict, scim.ErrorUniqueness, "userName is already in use")
+			}
+			return err
+		}
+
+		s.audit(c, models.AuditChangeEmail, models.AuditUser, user.ID.String(), nil, map[string]string{"pending_email": email})
+	}
+
+	var status enum.UserStatus
+	switch {
+	case !active && user.Status == enum.UserStatusActive:
+		status = enum.UserStatusDeactivated
+	case active && user.Status == enum.UserStatusDeactivated:
+		status = enum.UserStatusActive
+	}
+
+	renamed := name != user.Name.String
+	if !renamed && status == enum.UserStatusUnknown {
+		return nil
+	}
+
+	// The changes are applied and the webhook event queued in the same transaction.
+	var queued int
+	if err = s.store.WithTx(ctx, nil, func(tx txn.Tx) (err error) {
+		if renamed {
+			model := &models.User{
+				BaseModel: tidal.BaseModel{ID: user.ID},
+				Name:      sql.NullString{String: name, Valid: name != ""},
+				Email:     user.Email,
+			}
+
+			if err = tx.UpdateUser(model); err != nil {
+				return err
+			}
+		}
+
+		if status != enum.UserStatusUnknown {
+			if err = changeUserStatusTx(tx, user.ID, status); err != nil {
+				return err
+			}
+		}
+
+		_, after, queued, err = publishUserTx(tx, user.ID, enum.WebhookEventUserUpdated)
+		return err
+	}); err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			return errSCIMUserNotFound
+		}
+		return err
+	}
+	s.notifyQueued(queued)
+
+	if status != enum.UserStatusUnknown {
+		s.audit(c, models.AuditStatusChange, models.AuditUser, user.ID.String(), map[string]string{"status": user.Status.String()}, map[string]string{"status": status.String()})
+	}
+
+	if renamed {
+		s.audit(c, models.AuditUpdate, models.AuditUser, user.ID.String(), before, after)
+	}
+	return nil
+}
+
+// retrieveSCIMRole fetches the role with the ID of a SCIM group.
+func (s *Server) retrieveSCIMRole(ctx context.Context, id string) (role *models.Role, err error) {
+	var roleID int64
+	if roleID, err = strconv.ParseInt(id, 10, 64); err != nil || roleID <= 0 {
+		return nil, errSCIMGroupNotFound
+	}
+
+	if role, err = s.store.RetrieveRole(ctx, roleID); err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			return nil, errSCIMGroupNotFound
+		}
+		return nil, err
+	}
+	return role, nil
+}
+
+// scimGroup converts the role into a SCIM group with the users assigned to the role.
+func (s *Server) scimGroup(ctx context.Context, role *models.Role) (_ *scim.Group, err error) {
+	var members []*models.User
+	if members, err = listAll(s.store.ListUsers(ctx, (&api.UserPage{Role: role.Title}).Filter())); err != nil {
+		return nil, err
+	}
+	return scim.NewGroup(role, members, s.scimBaseURL())
+}
+
+// renderSCIMGroup writes the group with its version in the ETag header. A GET request
+// with an If-None-Match header that matches the version is not modified.
+func renderSCIMGroup(c *gin.Context, code int, group *scim.Group) {
+	c.Header("ETag", group.Meta.Version)
+	if c.Request.Method == http.MethodGet {
+		if match := c.GetHeader("If-None-Match"); match != "" && scim.MatchVersion(match, group.Meta.Version) {
+			c.Status(http.StatusNotModified)
+			return
+		}
+	}
+
+	if code == http.StatusCreated {
+		c.Header("Location", group.Meta.Location)
+	}
+	scimJSON(c, code, group)
+}
+
+// updateSCIMGroup applies a replace or patch request to the role after checking the
+// If-Match header; the update function returns the group with the new display name and
+// members from the current group.
+func (s *Server) updateSCIMGroup(c *gin.Context, role *models.Role, update func(*scim.Group) (*scim.Group, error)) {
+	var (
+		err    error
+		ctx    = c.Request.Context()
+		before *scim.Group
+		in     *scim.Group
+		out    *scim.Group
+	)
+
+	if before, err = s.scimGroup(ctx, role); err != nil {
+		scimError(c, err)
+		return
+	}
+
+	if match := c.GetHeader("If-Match"); match != "" && !scim.MatchVersion(match, before.Meta.Version) {
+		scimError(c, errSCIMVersionMismatch)
+		return
+	}
+
+	if in, err = update(before); err != nil {
+		scimError(c, err)
+		return
+	}
+
+	if in.DisplayName != role.Title {
+		model := &models.Role{
+			ID:          role.ID,
+			Title:       in.DisplayName,
+			Description: role.Description,
+			IsDefault:   role.IsDefault,
+		}
+
+		if err = s.store.UpdateRole(ctx, model); err != nil {
+			switch {
+			case errors.Is(err, errors.ErrNotFound):
+				scimError(c, errSCIMGroupNotFound)
+			case errors.Is(err, errors.ErrAlreadyExists):
+				scimError(c, scim.NewError(http.StatusConflict, scim.ErrorUniqueness, "displayName is already in use"))
+			default:
+				scimError(c, err)
+			}
+			return
+		}
+		role.Title = in.DisplayName
+	}
+
+	current := make([]ulid.ULID, 0, len(before.Members))
+	for _, member := range before.Members {
+		current = append(current, ulid.MustParse(member.Value))
+	}
+
+	if err = s.replaceSCIMGroupMembers(ctx, role.ID, current, in.MemberIDs()); err != nil {
+		scimError(c, err)
+		return
+	}
+
+	if out, err = s.scimGroup(ctx, role); err != nil {
+		scimError(c, err)
+		return
+	}
+
+	s.audit(c, models.AuditUpdate, models.AuditRole, out.ID, before, out)
+	renderSCIMGroup(c, http.StatusOK, out)
+}
+
+// replaceSCIMGroupMembers assigns the role to the users that are members and removes it
+// from the users that are no longer members. The new members are checked before any
+// changes are made so that an unknown member does not partially update the group.
+func (s *Server) replaceSCIMGroupMembers(ctx context.Context, roleID int64, current, members []ulid.ULID) (err error) {
+	var (
+		added   []*models.User
+		removed []*models.User
+	)
+
+	for _, userID := range members {
+		if slices.Contains(current, userID) {
+			continue
+		}
+
+		var user *models.User
+		if user, err = s.retrieveSCIMUser(ctx, userID.String()); err != nil {
+			if errors.Is(err, errSCIMUserNotFound) {
+				return scim.BadRequest(scim.ErrorInvalidValue, "member "+userID.String()+" is not a user")
+			}
+			return err
+		}
+		added = append(added, user)
+	}
+
+	for _, userID := range current {
+		if slices.Contains(members, userID) {
+			continue
+		}
+
+		var user *models.User
+		if user, err = s.store.RetrieveUser(ctx, userID); err != nil {
+			return err
+		}
+		removed = append(removed, user)
+	}
+
+	for _, user := range added {
+		if err = s.assignRole(ctx, user, roleID, true); err != nil {
+			return err
+		}
+	}
+
+	for _, user := range removed {
+		if err = s.assignRole(ctx, user, roleID, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// assignRole adds the role to or removes it from the roles of the user.
+func (s *Server) assignRole(ctx context.Context, user *models.User, roleID int64, assigned bool) (err error) {
+	roleIDs := make([]int64, 0, len(user.Roles)+1)
+	for _, role := range user.Roles {
+		if role.ID != roleID {
+			roleIDs = append(roleIDs, role.ID)
+		}
+	}
+
+	if assigned {
+		roleIDs = append(roleIDs, roleID)
+	}
+	return s.store.ReplaceUserRoles(ctx, user.ID, roleIDs)
+}
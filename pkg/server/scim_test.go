@@ -0,0 +1,285 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/quarterdeck/pkg/enum"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/scim"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/mock"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/tidal"
+	"go.rtnl.ai/ulid"
+)
+
+func TestListSCIMUsers(t *testing.T) {
+	mockStore := openMockStore(t)
+	defer mockStore.Close()
+	srv := newTestServer(mockStore)
+
+	user := &models.User{
+		BaseModel: tidal.BaseModel{ID: ulid.MakeSecure()},
+		Name:      sql.NullString{String: "Jane Doe", Valid: true},
+		Email:     "jane@example.com",
+		Status:    enum.UserStatusActive,
+	}
+
+	mockStore.OnRetrieveUserByEmail = func(_ context.Context, email string) (*models.User, error) {
+		if email == user.Email {
+			return user, nil
+		}
+		return nil, errors.ErrNotFound
+	}
+
+	list := func(t *testing.T, filter string) (*httptest.ResponseRecorder, *scim.ListResponse) {
+		w, c := requestContext(t, http.MethodGet, "/scim/v2/Users?filter="+filter, nil, nil)
+		srv.ListSCIMUsers(c)
+
+		out := &scim.ListResponse{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), out))
+		return w, out
+	}
+
+	t.Run("UserName", func(t *testing.T) {
+		w, out := list(t, `userName%20eq%20%22jane@example.com%22`)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Contains(t, w.Header().Get("Content-Type"), scim.MediaType)
+		require.Equal(t, 1, out.TotalResults)
+		require.Len(t, out.Resources, 1)
+
+		resource := out.Resources[0].(map[string]any)
+		require.Equal(t, user.ID.String(), resource["id"])
+		require.Equal(t, user.Email, resource["userName"])
+		require.Equal(t, true, resource["active"])
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		w, out := list(t, `userName%20eq%20%22john@example.com%22`)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, 0, out.TotalResults)
+		require.Empty(t, out.Resources)
+	})
+
+	t.Run("Deleted", func(t *testing.T) {
+		user.Status = enum.UserStatusDeleted
+		defer func() { user.Status = enum.UserStatusActive }()
+
+		w, out := list(t, `userName%20eq%20%22jane@example.com%22`)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, 0, out.TotalResults, "deprovisioned users should not be found")
+	})
+
+	t.Run("InvalidFilter", func(t *testing.T) {
+		w, c := requestContext(t, http.MethodGet, "/scim/v2/Users?filter=userName%20co%20%22jane%22", nil, nil)
+		srv.ListSCIMUsers(c)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+
+		out := &scim.Error{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), out))
+		require.Equal(t, scim.ErrorInvalidFilter, out.ScimType)
+		require.Equal(t, "400", out.Status)
+	})
+}
+
+func TestSCIMUserDetail(t *testing.T) {
+	mockStore := openMockStore(t)
+	defer mockStore.Close()
+	srv := newTestServer(mockStore)
+
+	user := &models.User{BaseModel: tidal.BaseModel{ID: ulid.MakeSecure()}, Email: "jane@example.com", Status: enum.UserStatusActive}
+	mockStore.OnRetrieveUser = func(context.Context, ulid.ULID) (*models.User, error) {
+		return user, nil
+	}
+
+	params := gin.Params{{Key: "id", Value: user.ID.String()}}
+	w, c := requestContext(t, http.MethodGet, "/scim/v2/Users/"+user.ID.String(), nil, params)
+	srv.SCIMUserDetail(c)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	// A matching If-None-Match header means the identity provider has the current version.
+	w, c = requestContext(t, http.MethodGet, "/scim/v2/Users/"+user.ID.String(), nil, params)
+	c.Request.Header.Set("If-None-Match", etag)
+	srv.SCIMUserDetail(c)
+	require.Equal(t, http.StatusNotModified, w.Code)
+
+	// Deleted users have been deprovisioned so they are not found.
+	user.Status = enum.UserStatusDeleted
+	w, c = requestContext(t, http.MethodGet, "/scim/v2/Users/"+user.ID.String(), nil, params)
+	srv.SCIMUserDetail(c)
+	require.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestPatchSCIMUser(t *testing.T) {
+	setup := func(t *testing.T) (*mock.Store, *Server, *models.User) {
+		mockStore := openMockStore(t)
+		t.Cleanup(func() { mockStore.Close() })
+
+		user := &models.User{
+			BaseModel: tidal.BaseModel{ID: ulid.MakeSecure()},
+			Name:      sql.NullString{String: "Jane Doe", Valid: true},
+			Email:     "jane@example.com",
+			Status:    enum.UserStatusActive,
+		}
+
+		mockStore.OnRetrieveUser = func(context.Context, ulid.ULID) (*models.User, error) {
+			cp := *user
+			return &cp, nil
+		}
+		mockStore.OnUpdateUserStatus = func(_ context.Context, id ulid.ULID, status enum.UserStatus) error {
+			require.Equal(t, user.ID, id)
+			user.Status = status
+			return nil
+		}
+		mockStore.OnRevokeUserSessions = func(context.Context, ulid.ULID) error { return nil }
+		mockStore.OnCreateAuditEvent = func(_ context.Context, in *models.AuditEvent) (*models.AuditEvent, error) { return in, nil }
+		mockStore.OnEnqueueWebhookEvent = func(_ context.Context, _ ulid.ULID, event enum.WebhookEvent, _ []byte) (int, error) {
+			require.Equal(t, enum.WebhookEventUserUpdated, event)
+			return 1, nil
+		}
+
+		return mockStore, newTestServer(mockStore), user
+	}
+
+	patch := func(t *testing.T, srv *Server, user *models.User, body, ifMatch string) *httptest.ResponseRecorder {
+		params := gin.Params{{Key: "id", Value: user.ID.String()}}
+		w, c := requestContext(t, http.MethodPatch, "/scim/v2/Users/"+user.ID.String(), []byte(body), params)
+		c.Request.Header.Set("Content-Type", scim.MediaType)
+		if ifMatch != "" {
+			c.Request.Header.Set("If-Match", ifMatch)
+		}
+		srv.PatchSCIMUser(c)
+		return w
+	}
+
+	deactivate := `{"schemas":["` + scim.SchemaPatchOp + `"],"Operations":[{"op":"Replace","path":"active","value":"False"}]}`
+
+	t.Run("Deactivate", func(t *testing.T) {
+		mockStore, srv, user := setup(t)
+		w := patch(t, srv, user, deactivate, "")
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.Equal(t, enum.UserStatusDeactivated, user.Status)
+		mockStore.AssertCalls(t, mock.UpdateUserStatus, 1)
+		mockStore.AssertCalls(t, mock.RevokeUserSessions, 1)
+		mockStore.AssertCalls(t, mock.EnqueueWebhookEvent, 1)
+
+		out := &scim.User{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), out))
+		require.False(t, out.IsActive())
+	})
+
+	t.Run("VersionMismatch", func(t *testing.T) {
+		mockStore, srv, user := setup(t)
+		w := patch(t, srv, user, deactivate, `W/"0123456789abcdef0123456789abcdef"`)
+
+		require.Equal(t, http.StatusPreconditionFailed, w.Code)
+		require.Equal(t, enum.UserStatusActive, user.Status)
+		mockStore.AssertCalls(t, mock.UpdateUserStatus, 0)
+	})
+
+	t.Run("VersionMatch", func(t *testing.T) {
+		mockStore, srv, user := setup(t)
+		current, err := scim.NewUser(user, srv.scimBaseURL())
+		require.NoError(t, err)
+
+		w := patch(t, srv, user, deactivate, current.Meta.Version)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		mockStore.AssertCalls(t, mock.UpdateUserStatus, 1)
+	})
+
+	t.Run("ReadOnly", func(t *testing.T) {
+		mockStore, srv, user := setup(t)
+		w := patch(t, srv, user, `{"schemas":["`+scim.SchemaPatchOp+`"],"Operations":[{"op":"replace","path":"id","value":"foo"}]}`, "")
+
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		mockStore.AssertCalls(t, mock.UpdateUserStatus, 0)
+
+		out := &scim.Error{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), out))
+		require.Equal(t, scim.ErrorMutability, out.ScimType)
+	})
+}
+
+func TestDeleteSCIMUser(t *testing.T) {
+	mockStore := openMockStore(t)
+	defer mockStore.Close()
+	srv := newTestServer(mockStore)
+
+	userID := ulid.MakeSecure()
+	mockStore.OnRetrieveUser = func(context.Context, ulid.ULID) (*models.User, error) {
+		return &models.User{BaseModel: tidal.BaseModel{ID: userID}, Email: "jane@example.com", Status: enum.UserStatusActive}, nil
+	}
+	mockStore.OnUpdateUserStatus = func(_ context.Context, id ulid.ULID, status enum.UserStatus) error {
+		require.Equal(t, userID, id)
+		require.Equal(t, enum.UserStatusDeleted, status)
+		return nil
+	}
+	mockStore.OnRevokeUserSessions = func(context.Context, ulid.ULID) error { return nil }
+	mockStore.OnCreateAuditEvent = func(_ context.Context, event *models.AuditEvent) (*models.AuditEvent, error) {
+		require.Equal(t, models.AuditDelete, event.Action)
+		return event, nil
+	}
+
+	params := gin.Params{{Key: "id", Value: userID.String()}}
+	w, c := requestContext(t, http.MethodDelete, "/scim/v2/Users/"+userID.String(), nil, params)
+	srv.DeleteSCIMUser(c)
+
+	// Deprovisioning soft deletes the user in the same way as DeleteUser.
+	require.Equal(t, http.StatusNoContent, w.Code)
+	mockStore.AssertCalls(t, mock.UpdateUserStatus, 1)
+	mockStore.AssertCalls(t, mock.RevokeUserSessions, 1)
+	mockStore.AssertCalls(t, mock.CreateAuditEvent, 1)
+	mockStore.AssertCalls(t, mock.DeleteUser, 0)
+}
+
+func TestCreateSCIMGroup(t *testing.T) {
+	mockStore := openMockStore(t)
+	defer mockStore.Close()
+	srv := newTestServer(mockStore)
+
+	mockStore.OnCreateRole = func(_ context.Context, role *models.Role) (*models.Role, error) {
+		if role.Title == "observer" {
+			return nil, errors.ErrAlreadyExists
+		}
+		role.ID = 42
+		return role, nil
+	}
+	mockStore.OnListUsers = func(context.Context, tidal.ListFilter) (tidal.Cursor[*models.User], error) {
+		return mock.NewCursor[*models.User](), nil
+	}
+	mockStore.OnCreateAuditEvent = func(_ context.Context, in *models.AuditEvent) (*models.AuditEvent, error) { return in, nil }
+
+	create := func(t *testing.T, body string) *httptest.ResponseRecorder {
+		w, c := requestContext(t, http.MethodPost, "/scim/v2/Groups", []byte(body), nil)
+		c.Request.Header.Set("Content-Type", scim.MediaType)
+		srv.CreateSCIMGroup(c)
+		return w
+	}
+
+	w := create(t, `{"schemas":["`+scim.SchemaGroup+`"],"displayName":"engineering"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	require.Equal(t, "/scim/v2/Groups/42", w.Header().Get("Location"))
+	require.NotEmpty(t, w.Header().Get("ETag"))
+
+	out := &scim.Group{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), out))
+	require.Equal(t, "42", out.ID)
+	require.Equal(t, "engineering", out.DisplayName)
+
+	w = create(t, `{"schemas":["`+scim.SchemaGroup+`"],"displayName":"observer"}`)
+	require.Equal(t, http.StatusConflict, w.Code)
+
+	w = create(t, `{"schemas":["`+scim.SchemaGroup+`"],"displayName":"Engineering Team"}`)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	mockStore.AssertCalls(t, mock.CreateRole, 2)
+}
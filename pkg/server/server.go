@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-webauthn/webauthn/webauthn"
 	"go.rtnl.ai/commo"
 	"go.rtnl.ai/gimlet/csrf"
 	"go.rtnl.ai/quarterdeck/pkg"
@@ -21,8 +22,9 @@ import (
 	"go.rtnl.ai/quarterdeck/pkg/config"
 	"go.rtnl.ai/quarterdeck/pkg/emails"
 	"go.rtnl.ai/quarterdeck/pkg/errors"
-	"go.rtnl.ai/quarterdeck/pkg/store/v1"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2"
 	"go.rtnl.ai/quarterdeck/pkg/telemetry"
+	"go.rtnl.ai/tidal"
 	"go.rtnl.ai/x/probez"
 	"go.rtnl.ai/x/rlog"
 
@@ -44,21 +46,29 @@ const (
 type Server struct {
 	sync.RWMutex
 	probez.Handler
-	conf    config.Config
-	store   store.Store
-	srv     *http.Server
-	router  *gin.Engine
-	issuer  *auth.Issuer
-	csrf    csrf.TokenHandler
-	url     *url.URL
-	started time.Time
-	errc    chan error
+	conf     config.Config
+	store    store.Store
+	srv      *http.Server
+	router   *gin.Engine
+	issuer   *auth.Issuer
+	keys     *auth.KeyManager
+	secrets  *auth.KeyCipher
+	webauthn *webauthn.WebAuthn
+	csrf     csrf.TokenHandler
+	url      *url.URL
+	started  time.Time
+	errc     chan error
+	purge    context.CancelFunc
+	deliver  chan struct{}
+	dispatch context.CancelFunc
+	keysync  context.CancelFunc
 }
 
 func New(conf *config.Config) (s *Server, err error) {
 	// Create a new server instance and prepare to serve.
 	s = &Server{
-		errc: make(chan error, 1),
+		errc:    make(chan error, 1),
+		deliver: make(chan struct{}, 1),
 	}
 
 	if conf == nil {
@@ -98,11 +108,51 @@ func New(conf *config.Config) (s *Server, err error) {
 		return nil, err
 	}
 
+	// Ensure the built-in permissions exist as protected records so that they can be
+	// assigned to roles and api keys alongside any custom permissions.
+	if err = s.ensureBuiltinPermissions(context.Background()); err != nil {
+		return nil, err
+	}
+
+	// Ensure there is an organization whose settings can be managed from the web UI.
+	if err = s.ensureDefaultOrganization(context.Background()); err != nil {
+		return nil, err
+	}
+
+	// Subscribe the application webhook endpoint to user events if it is configured.
+	if err = s.ensureAppWebhook(context.Background()); err != nil {
+		return nil, err
+	}
+
 	// Initialize the claims issuer for JWT tokens.
 	if s.issuer, err = auth.NewIssuer(s.conf.Auth); err != nil {
 		return nil, err
 	}
 
+	// Tokens revoked before they expire are rejected by the issuer.
+	s.issuer.SetDenylist(s.store)
+
+	// Load the signing keys from the database into the issuer, creating or rotating
+	// them as necessary so that the issuer has an active key to sign tokens with.
+	if s.keys, err = auth.NewKeyManager(s.conf.Auth, s.issuer, s.store); err != nil {
+		return nil, err
+	}
+
+	if err = s.ensureSigningKeys(context.Background()); err != nil {
+		return nil, err
+	}
+
+	// Secrets that must be stored to be used later, such as TOTP secrets, are sealed
+	// with the same key encryption key as the signing keys.
+	if s.secrets, err = auth.NewKeyCipher(s.conf.Auth.GetKeyEncryptionKey()); err != nil {
+		return nil, err
+	}
+
+	// Initialize the WebAuthn relying party for passkey registration and login.
+	if s.webauthn, err = auth.NewWebAuthn(s.conf.Auth); err != nil {
+		return nil, err
+	}
+
 	// Initialize the CSRF token handler if enabled.
 	if s.csrf, err = csrf.NewTokenHandler(s.conf.CSRF.CookieTTL, "/", s.conf.CookieDomains(), s.conf.CSRF.GetSecret()); err != nil {
 		return nil, err
@@ -189,6 +239,27 @@ func (s *Server) Serve() (err error) {
 		}
 	}()
 
+	// Purge deleted users once their retention window has elapsed in the background.
+	if !s.conf.Maintenance && !s.conf.Database.ReadOnly && s.conf.Database.PurgeInterval > 0 {
+		var ctx context.Context
+		ctx, s.purge = context.WithCancel(context.Background())
+		go s.runPurge(ctx)
+	}
+
+	// Deliver queued webhook events to the subscribed endpoints in the background.
+	if !s.conf.Maintenance && !s.conf.Database.ReadOnly {
+		var ctx context.Context
+		ctx, s.dispatch = context.WithCancel(context.Background())
+		go s.runDispatcher(ctx)
+	}
+
+	// Rotate the signing keys and pick up rotations by other replicas in the background.
+	if !s.conf.Maintenance {
+		var ctx context.Context
+		ctx, s.keysync = context.WithCancel(context.Background())
+		go s.runKeySync(ctx)
+	}
+
 	s.Ready()
 	rlog.InfoAttrs(context.Background(), "quarterdeck server started",
 		slog.String("url", s.URL()),
@@ -214,6 +285,18 @@ func (s *Server) Shutdown() (err error) {
 	s.NotReady()
 	defer s.Unhealthy()
 
+	if s.purge != nil {
+		s.purge()
+	}
+
+	if s.dispatch != nil {
+		s.dispatch()
+	}
+
+	if s.keysync != nil {
+		s.keysync()
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
 	defer cancel()
 
@@ -259,6 +342,16 @@ func (s *Server) setURL(addr net.Addr) {
 	}
 }
 
+// listAll returns all of the models from a store list cursor and closes the cursor so
+// that its read transaction is released, e.g. listAll(s.store.ListRoles(ctx, nil)).
+func listAll[M tidal.Model](cursor tidal.Cursor[M], err error) ([]M, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close()
+	return cursor.List()
+}
+
 // ConfigureLogging sets the default global logger and log level based on the
 // configuration. If telemetry is enabled, the logger will be configured to fan-out
 // to the OpenTelemetry logger. Can be called multiple times to reconfigure the
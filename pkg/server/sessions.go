@@ -0,0 +1,180 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	gimauth "go.rtnl.ai/gimlet/auth"
+	"go.rtnl.ai/quarterdeck/pkg/api/v1"
+	"go.rtnl.ai/quarterdeck/pkg/auth"
+	"go.rtnl.ai/quarterdeck/pkg/auth/permissions"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/quarterdeck/pkg/web/htmx"
+	"go.rtnl.ai/quarterdeck/pkg/web/scene"
+	"go.rtnl.ai/ulid"
+)
+
+// ListSessions returns the active sessions of the specified user. Users may always
+// list their own sessions; listing the sessions of another user requires the
+// users:view permission.
+func (s *Server) ListSessions(c *gin.Context) {
+	var (
+		err      error
+		claims   *gimauth.Claims
+		userID   ulid.ULID
+		sessions []*models.Session
+		out      *api.SessionList
+	)
+
+	if claims, userID, err = s.sessionsUser(c, permissions.UsersView); err != nil {
+		return
+	}
+
+	if sessions, err = s.store.ListSessions(c.Request.Context(), userID); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process sessions list request"))
+		return
+	}
+
+	if out, err = api.NewSessionList(sessions, claims.ID); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process sessions list request"))
+		return
+	}
+
+	c.Negotiate(http.StatusOK, gin.Negotiate{
+		Offered:  []string{binding.MIMEJSON, binding.MIMEHTML},
+		Data:     out,
+		HTMLName: "partials/profile/sessions.html",
+		HTMLData: scene.New(c).WithAPIData(out),
+	})
+}
+
+// RevokeSession signs the user out of a single session by revoking the refresh token
+// family of the session; both the access and refresh tokens of the session will be
+// rejected from this point on.
+func (s *Server) RevokeSession(c *gin.Context) {
+	var (
+		err       error
+		userID    ulid.ULID
+		sessionID ulid.ULID
+		session   *models.Session
+	)
+
+	if _, userID, err = s.sessionsUser(c, permissions.UsersManage); err != nil {
+		return
+	}
+
+	if sessionID, err = ulid.Parse(c.Param("sessionID")); err != nil {
+		c.JSON(http.StatusNotFound, api.Error("session not found"))
+		return
+	}
+
+	// Ensure the session belongs to the user in the URL so that permission to manage
+	// one user's sessions cannot be used to revoke another user's sessions.
+	if session, err = s.store.RetrieveSession(c.Request.Context(), sessionID); err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			c.JSON(http.StatusNotFound, api.Error("session not found"))
+			return
+		}
+
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process revoke session request"))
+		return
+	}
+
+	if session.UserID != userID {
+		c.JSON(http.StatusNotFound, api.Error("session not found"))
+		return
+	}
+
+	if err = s.store.RevokeSession(c.Request.Context(), sessionID); err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			c.JSON(http.StatusNotFound, api.Error("session not found"))
+			return
+		}
+
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process revoke session request"))
+		return
+	}
+
+	if htmx.IsHTMXRequest(c) {
+		htmx.SetTrigger(c, htmx.SessionsUpdated)
+		c.Data(http.StatusNoContent, gin.MIMEHTML, nil)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.Reply{Success: true})
+}
+
+// RevokeAllSessions signs the user out everywhere by revoking all of their sessions,
+// including the session used to make the request.
+func (s *Server) RevokeAllSessions(c *gin.Context) {
+	var (
+		err    error
+		claims *gimauth.Claims
+		userID ulid.ULID
+	)
+
+	if claims, userID, err = s.sessionsUser(c, permissions.UsersManage); err != nil {
+		return
+	}
+
+	if err = s.store.RevokeUserSessions(c.Request.Context(), userID); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process revoke sessions request"))
+		return
+	}
+
+	// If the user signed themselves out everywhere then the current session is also
+	// revoked so log the user out and send them to the login page.
+	if sub, subjectID, _ := claims.SubjectID(); sub == gimauth.SubjectUser && subjectID == userID {
+		auth.ClearAuthCookies(c, s.conf.Auth.Audience)
+		if htmx.IsHTMXRequest(c) {
+			htmx.Redirect(c, http.StatusSeeOther, "/login")
+			return
+		}
+	}
+
+	if htmx.IsHTMXRequest(c) {
+		htmx.SetTrigger(c, htmx.SessionsUpdated)
+		c.Data(http.StatusNoContent, gin.MIMEHTML, nil)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.Reply{Success: true})
+}
+
+// sessionsUser parses the user ID from the URL and checks that the requester is either
+// that user or has the specified permission to access the sessions of other users in
+// their organization. If an error is returned then the response has already been written.
+func (s *Server) sessionsUser(c *gin.Context, permission permissions.Permission) (claims *gimauth.Claims, userID ulid.ULID, err error) {
+	if claims, err = gimauth.GetClaims(c); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusUnauthorized, api.Error("could not get user claims"))
+		return nil, ulid.Zero, err
+	}
+
+	if userID, err = ulid.Parse(c.Param("userID")); err != nil {
+		c.JSON(http.StatusNotFound, api.Error("user not found"))
+		return nil, ulid.Zero, err
+	}
+
+	if sub, subjectID, _ := claims.SubjectID(); sub == gimauth.SubjectUser && subjectID == userID {
+		return claims, userID, nil
+	}
+
+	if !claims.HasPermission(permission.String()) {
+		c.JSON(http.StatusForbidden, api.Error("not authorized to access the sessions of this user"))
+		return nil, ulid.Zero, errors.ErrNotAuthorized
+	}
+
+	if err = s.checkUserInCurrentOrg(c, userID, "user not found"); err != nil {
+		return nil, ulid.Zero, err
+	}
+
+	return claims, userID, nil
+}
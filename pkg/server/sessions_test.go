@@ -0,0 +1,220 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/gimlet"
+	"go.rtnl.ai/gimlet/auth"
+	"go.rtnl.ai/quarterdeck/pkg/api/v1"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/mock"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/tidal"
+	"go.rtnl.ai/ulid"
+)
+
+func TestListSessions(t *testing.T) {
+	userID := ulid.MakeSecure()
+	current := ulid.MakeSecure()
+
+	sessions := []*models.Session{
+		{BaseModel: tidal.BaseModel{ID: ulid.MakeSecure(), Created: time.Now()}, UserID: userID, JTI: current, Expiration: time.Now().Add(time.Hour)},
+		{BaseModel: tidal.BaseModel{ID: ulid.MakeSecure(), Created: time.Now()}, UserID: userID, JTI: ulid.MakeSecure(), Expiration: time.Now().Add(time.Hour)},
+	}
+
+	claimsFor := func(subjectID ulid.ULID, jti ulid.ULID, permissions ...string) *auth.Claims {
+		claims := &auth.Claims{Permissions: permissions}
+		claims.SetSubjectID(auth.SubjectUser, subjectID)
+		claims.ID = jti.String()
+		return claims
+	}
+
+	t.Run("Own", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		mockStore.OnListSessions = func(_ context.Context, in ulid.ULID) ([]*models.Session, error) {
+			require.Equal(t, userID, in)
+			return sessions, nil
+		}
+
+		w, c := requestContext(t, http.MethodGet, "/v1/users/"+userID.String()+"/sessions", nil, gin.Params{{Key: "userID", Value: userID.String()}})
+		gimlet.Set(c, gimlet.KeyUserClaims, claimsFor(userID, current))
+
+		srv.ListSessions(c)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		out := &api.SessionList{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), out))
+		require.Len(t, out.Sessions, 2)
+		require.True(t, out.Sessions[0].Current)
+		require.False(t, out.Sessions[1].Current)
+	})
+
+	t.Run("OtherUserWithPermission", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		mockStore.OnListSessions = func(context.Context, ulid.ULID) ([]*models.Session, error) {
+			return sessions, nil
+		}
+
+		w, c := requestContext(t, http.MethodGet, "/v1/users/"+userID.String()+"/sessions", nil, gin.Params{{Key: "userID", Value: userID.String()}})
+		gimlet.Set(c, gimlet.KeyUserClaims, claimsFor(ulid.MakeSecure(), ulid.MakeSecure(), "users:view"))
+
+		srv.ListSessions(c)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		out := &api.SessionList{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), out))
+		require.Len(t, out.Sessions, 2)
+		require.False(t, out.Sessions[0].Current)
+	})
+
+	t.Run("Forbidden", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		w, c := requestContext(t, http.MethodGet, "/v1/users/"+userID.String()+"/sessions", nil, gin.Params{{Key: "userID", Value: userID.String()}})
+		gimlet.Set(c, gimlet.KeyUserClaims, claimsFor(ulid.MakeSecure(), ulid.MakeSecure(), "apikeys:view"))
+
+		srv.ListSessions(c)
+		require.Equal(t, http.StatusForbidden, w.Code)
+		mockStore.AssertCalls(t, mock.ListSessions, 0)
+	})
+}
+
+func TestRevokeSession(t *testing.T) {
+	userID := ulid.MakeSecure()
+	sessionID := ulid.MakeSecure()
+
+	claims := &auth.Claims{}
+	claims.SetSubjectID(auth.SubjectUser, userID)
+
+	params := gin.Params{{Key: "userID", Value: userID.String()}, {Key: "sessionID", Value: sessionID.String()}}
+	path := "/v1/users/" + userID.String() + "/sessions/" + sessionID.String()
+
+	t.Run("Success", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		mockStore.OnRetrieveSession = func(context.Context, ulid.ULID) (*models.Session, error) {
+			return &models.Session{BaseModel: tidal.BaseModel{ID: sessionID}, UserID: userID}, nil
+		}
+		mockStore.OnRevokeSession = func(_ context.Context, in ulid.ULID) error {
+			require.Equal(t, sessionID, in)
+			return nil
+		}
+
+		w, c := requestContext(t, http.MethodDelete, path, nil, params)
+		gimlet.Set(c, gimlet.KeyUserClaims, claims)
+
+		srv.RevokeSession(c)
+		require.Equal(t, http.StatusOK, w.Code)
+		mockStore.AssertCalls(t, mock.RevokeSession, 1)
+	})
+
+	t.Run("OtherUsersSession", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		mockStore.OnRetrieveSession = func(context.Context, ulid.ULID) (*models.Session, error) {
+			return &models.Session{BaseModel: tidal.BaseModel{ID: sessionID}, UserID: ulid.MakeSecure()}, nil
+		}
+
+		w, c := requestContext(t, http.MethodDelete, path, nil, params)
+		gimlet.Set(c, gimlet.KeyUserClaims, claims)
+
+		srv.RevokeSession(c)
+		require.Equal(t, http.StatusNotFound, w.Code)
+		mockStore.AssertCalls(t, mock.RevokeSession, 0)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		mockStore.OnRetrieveSession = func(context.Context, ulid.ULID) (*models.Session, error) {
+			return nil, errors.ErrNotFound
+		}
+
+		w, c := requestContext(t, http.MethodDelete, path, nil, params)
+		gimlet.Set(c, gimlet.KeyUserClaims, claims)
+
+		srv.RevokeSession(c)
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestRevokeAllSessions(t *testing.T) {
+	userID := ulid.MakeSecure()
+	params := gin.Params{{Key: "userID", Value: userID.String()}}
+	path := "/v1/users/" + userID.String() + "/sessions"
+
+	t.Run("Self", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestOAuthServer(t, mockStore)
+
+		mockStore.OnRevokeUserSessions = func(_ context.Context, in ulid.ULID) error {
+			require.Equal(t, userID, in)
+			return nil
+		}
+
+		claims := &auth.Claims{}
+		claims.SetSubjectID(auth.SubjectUser, userID)
+
+		w, c := requestContext(t, http.MethodDelete, path, nil, params)
+		gimlet.Set(c, gimlet.KeyUserClaims, claims)
+
+		srv.RevokeAllSessions(c)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NotEmpty(t, w.Header().Values("Set-Cookie"), "expected the auth cookies to be cleared")
+	})
+
+	t.Run("Admin", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestOAuthServer(t, mockStore)
+
+		mockStore.OnRevokeUserSessions = func(context.Context, ulid.ULID) error { return nil }
+
+		claims := &auth.Claims{Permissions: []string{"users:manage"}}
+		claims.SetSubjectID(auth.SubjectUser, ulid.MakeSecure())
+
+		w, c := requestContext(t, http.MethodDelete, path, nil, params)
+		gimlet.Set(c, gimlet.KeyUserClaims, claims)
+
+		srv.RevokeAllSessions(c)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Empty(t, w.Header().Values("Set-Cookie"), "the admin should not be logged out")
+	})
+
+	t.Run("ViewOnly", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestOAuthServer(t, mockStore)
+
+		claims := &auth.Claims{Permissions: []string{"users:view"}}
+		claims.SetSubjectID(auth.SubjectUser, ulid.MakeSecure())
+
+		w, c := requestContext(t, http.MethodDelete, path, nil, params)
+		gimlet.Set(c, gimlet.KeyUserClaims, claims)
+
+		srv.RevokeAllSessions(c)
+		require.Equal(t, http.StatusForbidden, w.Code)
+		mockStore.AssertCalls(t, mock.RevokeUserSessions, 0)
+	})
+}
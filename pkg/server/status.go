@@ -9,7 +9,6 @@ import (
 	"go.rtnl.ai/gimlet/logger"
 	"go.rtnl.ai/quarterdeck/pkg"
 	"go.rtnl.ai/quarterdeck/pkg/api/v1"
-	"go.rtnl.ai/quarterdeck/pkg/store/v1"
 )
 
 const (
@@ -46,20 +45,13 @@ func (s *Server) Status(c *gin.Context) {
 	})
 }
 
-// DBInfo reports the database connection status and information if available,
-// otherwise returns a 501 Not Implemented http error.
+// DBInfo reports the database connection status and information.
 func (s *Server) DBInfo(c *gin.Context) {
 	// Reduce logging verbosity for the dbinfo endpoint
 	c.Set(logger.LogLevelKey, slog.LevelDebug)
 
-	db, ok := s.store.(store.Stats)
-	if !ok {
-		c.JSON(http.StatusNotImplemented, api.Error("store does not implement stats"))
-		return
-	}
-
 	// Render the database statistics
-	stats := db.Stats()
+	stats := s.store.Stats()
 	c.JSON(http.StatusOK, &api.DBInfo{
 		MaxOpenConnections: stats.MaxOpenConnections,
 		OpenConnections:    stats.OpenConnections,
@@ -1,15 +1,11 @@
 package server
 
 import (
-	"bytes"
 	"context"
 	"database/sql"
-	"encoding/json"
-	"fmt"
 	"log/slog"
 	"math/rand/v2"
 	"net/http"
-	"net/url"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -17,15 +13,14 @@ import (
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	"go.rtnl.ai/commo"
-	gimauth "go.rtnl.ai/gimlet/auth"
 	"go.rtnl.ai/quarterdeck/pkg/api/v1"
 	"go.rtnl.ai/quarterdeck/pkg/auth"
 	"go.rtnl.ai/quarterdeck/pkg/auth/passwords"
 	"go.rtnl.ai/quarterdeck/pkg/emails"
 	"go.rtnl.ai/quarterdeck/pkg/enum"
 	"go.rtnl.ai/quarterdeck/pkg/errors"
-	"go.rtnl.ai/quarterdeck/pkg/store/v1/models"
-	"go.rtnl.ai/quarterdeck/pkg/store/v1/txn"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/txn"
 	"go.rtnl.ai/quarterdeck/pkg/web/htmx"
 	"go.rtnl.ai/quarterdeck/pkg/web/scene"
 	"go.rtnl.ai/ulid"
@@ -41,13 +36,15 @@ var usersTracer = otel.Tracer("go.rtnl.ai/quarterdeck/pkg/server")
 // User resource handlers
 // ============================================================================
 
-// ListUsers returns a page of users, optionally filtered by role.
+// ListUsers returns a page of users, optionally filtered by role, status, last login,
+// and a search of the user's name and email.
 func (s *Server) ListUsers(c *gin.Context) {
 	var (
 		err        error
 		in         *api.UserPageQuery
-		page       *models.UserPage
-		userModels *models.UserList
+		cursor     *api.Cursor
+		page       *api.Page
+		userModels []*models.User
 		out        *api.UserList
 	)
 
@@ -59,19 +56,19 @@ func (s *Server) ListUsers(c *gin.Context) {
 		return
 	}
 
-	// Query page to model page
-	if page, err = in.UserPage().Model(); err != nil {
+	if cursor, err = in.Cursor(s.currentOrg(c)); err != nil {
 		c.Error(err)
-		c.JSON(http.StatusBadRequest, api.Error("invalid query parameters"))
+		c.JSON(http.StatusBadRequest, api.Error(err))
 		return
 	}
 
 	// List users
-	if userModels, err = s.store.ListUsers(c.Request.Context(), page); err != nil {
+	if userModels, err = listAll(s.store.ListUsers(c.Request.Context(), cursor.Filter())); err != nil {
 		c.Error(err)
 		c.JSON(http.StatusInternalServerError, api.Error("could not process users list request"))
 		return
 	}
+	userModels, page = api.Paginate(cursor, userModels, func(u *models.User) ulid.ULID { return u.ID })
 
 	// Convert the database model to an API output
 	if out, err = api.NewUserList(userModels); err != nil {
@@ -79,6 +76,7 @@ func (s *Server) ListUsers(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, api.Error("could not process users list request"))
 		return
 	}
+	out.Page = in.UserPage(page)
 
 	c.JSON(http.StatusOK, out)
 }
@@ -94,6 +92,7 @@ func (s *Server) CreateUser(c *gin.Context) {
 		user             *api.User
 		err              error
 		model            *models.User
+		action           = models.AuditCreate
 		welcomeAttempted bool
 		welcomeErr       error
 	)
@@ -127,20 +126,30 @@ func (s *Server) CreateUser(c *gin.Context) {
 		return
 	}
 
-	// Create the user, or upsert when the email already exists.
-	if err = s.store.CreateUser(ctx, model); err != nil {
-		if errors.Is(err, errors.ErrAlreadyExists) {
-			if model, err = s.upsertExistingUser(ctx, user); err != nil {
-				c.Error(errors.Join(err, errors.New("could not upsert existing user")))
-				c.JSON(http.StatusInternalServerError, api.Error("could not process create user request"))
-				return
-			}
-		} else {
-			c.Error(errors.Join(err, errors.New("could not create user")))
-			c.JSON(http.StatusInternalServerError, api.Error("could not process create user request"))
-			return
+	// Create the user, or upsert when the email already exists, and queue the webhook
+	// event in the same transaction so that it is only delivered if the user is saved.
+	var queued int
+	if err = s.store.WithTx(ctx, nil, func(tx txn.Tx) (err error) {
+		var created bool
+		if model, created, err = createOrUpsertUserTx(tx, user, model); err != nil {
+			return err
+		}
+
+		event := enum.WebhookEventUserCreated
+		if !created {
+			action = models.AuditUpdate
+			event = enum.WebhookEventUserUpdated
 		}
+
+		// Reload so roles and associations are current for the API response.
+		model, user, queued, err = publishUserTx(tx, model.ID, event)
+		return err
+	}); err != nil {
+		c.Error(errors.Join(err, errors.New("could not create user")))
+		c.JSON(http.StatusInternalServerError, api.Error("could not process create user request"))
+		return
 	}
+	s.notifyQueued(queued)
 
 	span.SetAttributes(attribute.String("user.id", model.ID.String()))
 
@@ -155,20 +164,7 @@ func (s *Server) CreateUser(c *gin.Context) {
 		}
 	}
 
-	// Reload so roles and associations are current for the API response.
-	if model, err = s.store.RetrieveUser(ctx, model.ID); err != nil {
-		c.Error(err)
-		c.JSON(http.StatusInternalServerError, api.Error("could not process create user request"))
-		return
-	}
-
-	if user, err = api.NewUser(model); err != nil {
-		c.Error(err)
-		c.JSON(http.StatusInternalServerError, api.Error("could not process create user request"))
-		return
-	}
-
-	s.syncUserPost(c, user, nil, true)
+	s.audit(c, action, models.AuditUser, model.ID.String(), nil, user)
 
 	if welcomeAttempted && welcomeErr != nil {
 		htmx.SetTrigger(c, htmx.EventUserCreated, htmx.EventInviteWelcomeEmailFailed)
@@ -195,6 +191,10 @@ func (s *Server) UserDetail(c *gin.Context) {
 		return
 	}
 
+	if err = s.checkUserInCurrentOrg(c, userID, "user not found"); err != nil {
+		return
+	}
+
 	// Retreive the user from DB
 	if user, err = s.store.RetrieveUser(c.Request.Context(), userID); err != nil {
 		if errors.Is(err, errors.ErrNotFound) {
@@ -218,13 +218,15 @@ func (s *Server) UserDetail(c *gin.Context) {
 	c.JSON(http.StatusOK, out)
 }
 
-// UpdateUser updates applicable user fields and syncs the record to endeavor.
+// UpdateUser updates applicable user fields and publishes the user updated event. Changing
+// the email address starts an email change that must be confirmed by the user.
 func (s *Server) UpdateUser(c *gin.Context) {
 	var (
 		user   *api.User
 		userID ulid.ULID
 		err    error
 		model  *models.User
+		before *api.User
 	)
 
 	// Parse the model from the POST request
@@ -258,8 +260,41 @@ func (s *Server) UpdateUser(c *gin.Context) {
 		return
 	}
 
-	// Update the user
-	if err = s.store.UpdateUser(c.Request.Context(), model); err != nil {
+	// Retrieve the user before they are updated to record the changes in the audit log
+	if before, err = s.retrieveUser(c, userID); err != nil {
+		return
+	}
+
+	// The email address is never overwritten directly; instead a confirmation link is
+	// sent to the new address and the change is applied once it is confirmed so that a
+	// mistyped email address cannot lock the user out of their account.
+	model.Email = before.Email
+	if user.Email != before.Email {
+		if err = s.sendChangeEmail(c.Request.Context(), model, user.Email); err != nil {
+			if errors.Is(err, errors.ErrAlreadyExists) {
+				c.JSON(http.StatusConflict, api.Error("the email address is already in use"))
+				return
+			}
+
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, api.Error("could not send email change confirmation"))
+			return
+		}
+
+		s.audit(c, models.AuditChangeEmail, models.AuditUser, userID.String(), nil, map[string]string{"pending_email": user.Email})
+	}
+
+	// Update the user and queue the webhook event in the same transaction; the user is
+	// reloaded so that the response includes the roles and fields that cannot be updated.
+	var queued int
+	if err = s.store.WithTx(c.Request.Context(), nil, func(tx txn.Tx) (err error) {
+		if err = tx.UpdateUser(model); err != nil {
+			return err
+		}
+
+		_, user, queued, err = publishUserTx(tx, userID, enum.WebhookEventUserUpdated)
+		return err
+	}); err != nil {
 		if errors.Is(err, errors.ErrNotFound) {
 			c.JSON(http.StatusNotFound, api.Error("user not found"))
 			return
@@ -269,54 +304,296 @@ func (s *Server) UpdateUser(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, api.Error("could not process update user request"))
 		return
 	}
+	s.notifyQueued(queued)
+
+	s.audit(c, models.AuditUpdate, models.AuditUser, userID.String(), before, user)
+
+	// TODO: negotiate HTMX response when UI pages are implemented for users
+	c.JSON(http.StatusOK, user)
+}
+
+// DeleteUser marks a user as deleted and revokes their sessions. The user can be
+// restored until the retention window elapses and the user is purged, at which point
+// the user deleted event is published.
+func (s *Server) DeleteUser(c *gin.Context) {
+	var (
+		err  error
+		user *models.User
+	)
+
+	if user, err = s.userForStatusChange(c); err != nil {
+		return
+	}
+
+	if user.Status == enum.UserStatusDeleted {
+		c.JSON(http.StatusNotFound, api.Error("user not found"))
+		return
+	}
+
+	if err = s.deleteUser(c, user); err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			c.JSON(http.StatusNotFound, api.Error("user not found"))
+			return
+		}
 
-	// Convert the model back to an API response
-	if user, err = api.NewUser(model); err != nil {
 		c.Error(err)
-		c.JSON(http.StatusInternalServerError, api.Error("could not process create user request"))
+		c.JSON(http.StatusInternalServerError, api.Error("could not process user status request"))
 		return
 	}
 
-	// Sync user
-	s.syncUserPost(c, user, nil, true)
+	// TODO: negotiate HTMX response when UI pages are implemented for users
+	c.JSON(http.StatusOK, api.Reply{Success: true})
+}
+
+// RestoreUser reactivates a deleted user that has not been purged yet. The user must
+// log in again since their sessions were revoked when they were deleted.
+func (s *Server) RestoreUser(c *gin.Context) {
+	var (
+		err  error
+		user *models.User
+		out  *api.User
+	)
+
+	if user, err = s.userForStatusChange(c); err != nil {
+		return
+	}
+
+	if user.Status != enum.UserStatusDeleted {
+		c.JSON(http.StatusConflict, api.Error("only deleted users can be restored"))
+		return
+	}
+
+	if out, err = s.setUserStatus(c, user, enum.UserStatusActive); err != nil {
+		return
+	}
+
+	s.audit(c, models.AuditRestore, models.AuditUser, user.ID.String(), map[string]string{"status": user.Status.String()}, map[string]string{"status": out.Status.String()})
 
 	// TODO: negotiate HTMX response when UI pages are implemented for users
-	c.JSON(http.StatusOK, user)
+	c.JSON(http.StatusOK, out)
 }
 
-// DeleteUser removes a user and notifies the endeavor webhook.
-func (s *Server) DeleteUser(c *gin.Context) {
+// UpdateUserStatus suspends, deactivates, or reactivates a user. Suspended and
+// deactivated users cannot log in, so their sessions are revoked. Deleted users must be
+// restored before their status can be changed.
+func (s *Server) UpdateUserStatus(c *gin.Context) {
 	var (
-		err    error
-		userID ulid.ULID
+		err  error
+		in   *api.UserStatusRequest
+		user *models.User
+		out  *api.User
 	)
 
-	// Parse the user ID from the URL parameter
-	if userID, err = ulid.Parse(c.Param("userID")); err != nil {
-		c.JSON(http.StatusNotFound, api.Error("user not found"))
+	in = &api.UserStatusRequest{}
+	if err = c.BindJSON(in); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadRequest, api.Error("could not parse user status request"))
 		return
 	}
 
-	// Delete the user from the database
-	// TODO: for audit purposes we may simply want to move the user to an 'inactive' or 'deleted' status.
-	if err = s.store.DeleteUser(c.Request.Context(), userID); err != nil {
+	if err = in.Validate(); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, api.Error(err))
+		return
+	}
+
+	if user, err = s.userForStatusChange(c); err != nil {
+		return
+	}
+
+	if user.Status == enum.UserStatusDeleted {
+		c.JSON(http.StatusConflict, api.Error("deleted users must be restored before their status can be changed"))
+		return
+	}
+
+	if out, err = s.setUserStatus(c, user, in.Status); err != nil {
+		return
+	}
+
+	s.audit(c, models.AuditStatusChange, models.AuditUser, user.ID.String(), map[string]string{"status": user.Status.String()}, map[string]string{"status": out.Status.String()})
+
+	// TODO: negotiate HTMX response when UI pages are implemented for users
+	c.JSON(http.StatusOK, out)
+}
+
+// PurgeUser permanently removes a deleted user without waiting for the retention
+// window to elapse and publishes the user deleted event.
+func (s *Server) PurgeUser(c *gin.Context) {
+	var (
+		err  error
+		user *models.User
+	)
+
+	if user, err = s.userForStatusChange(c); err != nil {
+		return
+	}
+
+	if user.Status != enum.UserStatusDeleted {
+		c.JSON(http.StatusConflict, api.Error("only deleted users can be purged"))
+		return
+	}
+
+	var queued int
+	if err = s.store.WithTx(c.Request.Context(), nil, func(tx txn.Tx) (err error) {
+		if err = tx.DeleteUser(user.ID); err != nil {
+			return err
+		}
+
+		queued, err = publishTx(tx, enum.WebhookEventUserDeleted, deletedResource{ID: user.ID})
+		return err
+	}); err != nil {
 		if errors.Is(err, errors.ErrNotFound) {
 			c.JSON(http.StatusNotFound, api.Error("user not found"))
 			return
 		}
 
 		c.Error(err)
-		c.JSON(http.StatusInternalServerError, api.Error("could not process delete user request"))
+		c.JSON(http.StatusInternalServerError, api.Error("could not process purge user request"))
 		return
 	}
+	s.notifyQueued(queued)
 
-	// Sync user
-	s.syncUserDelete(c, userID)
+	s.audit(c, models.AuditPurge, models.AuditUser, user.ID.String(), nil, nil)
 
 	// TODO: negotiate HTMX response when UI pages are implemented for users
 	c.JSON(http.StatusOK, api.Reply{Success: true})
 }
 
+// userForStatusChange parses the user ID from the URL and retrieves the user; if an
+// error is returned then the response has already been written.
+func (s *Server) userForStatusChange(c *gin.Context) (user *models.User, err error) {
+	var userID ulid.ULID
+	if userID, err = ulid.Parse(c.Param("userID")); err != nil {
+		c.JSON(http.StatusNotFound, api.Error("user not found"))
+		return nil, err
+	}
+
+	if err = s.checkUserInCurrentOrg(c, userID, "user not found"); err != nil {
+		return nil, err
+	}
+
+	if user, err = s.store.RetrieveUser(c.Request.Context(), userID); err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			c.JSON(http.StatusNotFound, api.Error("user not found"))
+			return nil, err
+		}
+
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process user status request"))
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// setUserStatus updates the status of the user, revokes their sessions if they can no
+// longer log in, and queues the user updated event in the same transaction, returning
+// the updated user; if an error is returned then the response has already been written.
+func (s *Server) setUserStatus(c *gin.Context, user *models.User, status enum.UserStatus) (out *api.User, err error) {
+	var queued int
+	if err = s.store.WithTx(c.Request.Context(), nil, func(tx txn.Tx) (err error) {
+		if err = changeUserStatusTx(tx, user.ID, status); err != nil {
+			return err
+		}
+
+		_, out, queued, err = publishUserTx(tx, user.ID, enum.WebhookEventUserUpdated)
+		return err
+	}); err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			c.JSON(http.StatusNotFound, api.Error("user not found"))
+			return nil, err
+		}
+
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process user status request"))
+		return nil, err
+	}
+	s.notifyQueued(queued)
+
+	return out, nil
+}
+
+// deleteUser marks the user as deleted, revokes their sessions, and records the deletion
+// in the audit log. Users are deprovisioned by SCIM with the same code path so that they
+// can be restored until the retention window elapses. The response is not written so
+// that the callers can respond in their own format.
+func (s *Server) deleteUser(c *gin.Context, user *models.User) (err error) {
+	if err = s.changeUserStatus(c.Request.Context(), user.ID, enum.UserStatusDeleted); err != nil {
+		return err
+	}
+
+	s.audit(c, models.AuditDelete, models.AuditUser, user.ID.String(), map[string]string{"status": user.Status.String()}, map[string]string{"status": enum.UserStatusDeleted.String()})
+	return nil
+}
+
+// changeUserStatus updates the status of the user and revokes their sessions unless the
+// user is active since users with any other status cannot log in.
+func (s *Server) changeUserStatus(ctx context.Context, userID ulid.ULID, status enum.UserStatus) (err error) {
+	return s.store.WithTx(ctx, nil, func(tx txn.Tx) error {
+		return changeUserStatusTx(tx, userID, status)
+	})
+}
+
+// changeUserStatusTx updates the status of the user and revokes their sessions inside
+// of the transaction.
+func changeUserStatusTx(tx txn.Tx, userID ulid.ULID, status enum.UserStatus) (err error) {
+	if err = tx.UpdateUserStatus(userID, status); err != nil {
+		return err
+	}
+
+	if status != enum.UserStatusActive {
+		if err = tx.RevokeUserSessions(userID); err != nil {
+			return errors.Join(err, errors.New("could not revoke user sessions"))
+		}
+	}
+
+	return nil
+}
+
+// publishUserTx reloads the user inside of the transaction and queues the event with the
+// user as its payload, returning the reloaded user and the number of queued deliveries.
+func publishUserTx(tx txn.Tx, userID ulid.ULID, event enum.WebhookEvent) (model *models.User, out *api.User, queued int, err error) {
+	if model, err = tx.RetrieveUser(userID); err != nil {
+		return nil, nil, 0, err
+	}
+
+	if out, err = api.NewUser(model); err != nil {
+		return nil, nil, 0, err
+	}
+
+	if queued, err = publishTx(tx, event, out); err != nil {
+		return nil, nil, 0, err
+	}
+	return model, out, queued, nil
+}
+
+// retrieveUser fetches the user for an update, which must be in the organization the
+// requester is logged into; if an error is returned then the response has already been
+// written.
+func (s *Server) retrieveUser(c *gin.Context, userID ulid.ULID) (out *api.User, err error) {
+	if err = s.checkUserInCurrentOrg(c, userID, "user not found"); err != nil {
+		return nil, err
+	}
+
+	var user *models.User
+	if user, err = s.store.RetrieveUser(c.Request.Context(), userID); err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			c.JSON(http.StatusNotFound, api.Error("user not found"))
+			return nil, err
+		}
+
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process update user request"))
+		return nil, err
+	}
+
+	if out, err = api.NewUser(user); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process update user request"))
+		return nil, err
+	}
+	return out, nil
+}
+
 // ============================================================================
 // Password and profile handlers
 // ============================================================================
@@ -383,13 +660,21 @@ func (s *Server) ChangePassword(c *gin.Context) {
 		return
 	}
 
-	// Set the password for the specified user
-	if err = s.store.UpdatePassword(c.Request.Context(), user.ID, derivedKey); err != nil {
+	// Set the password for the specified user and revoke all of their sessions, including
+	// the current one, since the user is logged out and must log in with the new password.
+	if err = s.store.WithTx(c.Request.Context(), nil, func(tx txn.Tx) (err error) {
+		if err = tx.UpdatePassword(user.ID, derivedKey); err != nil {
+			return err
+		}
+		return tx.RevokeUserSessions(user.ID)
+	}); err != nil {
 		c.Error(err)
 		c.HTML(http.StatusInternalServerError, template, gin.H{"Error": "could not change password"})
 		return
 	}
 
+	s.audit(c, models.AuditPasswordChange, models.AuditUser, user.ID.String(), nil, nil)
+
 	// Success! Log the user out and redirect to the login page.
 	auth.ClearAuthCookies(c, s.conf.Auth.Audience)
 
@@ -519,38 +804,42 @@ func (s *Server) ResetPassword(c *gin.Context) {
 		return
 	}
 
-	// Begin a transaction
-	var tx txn.Txn
-	if tx, err = s.store.Begin(c.Request.Context(), &sql.TxOptions{ReadOnly: false}); err != nil {
-		s.Error(c, err)
-		return
-	}
-	defer tx.Rollback()
+	// Set the password for the specified user in a single transaction
+	err = s.store.WithTx(c.Request.Context(), nil, func(tx txn.Tx) (err error) {
+		if err = tx.UpdatePassword(veroToken.ResourceID.ULID, derivedKey); err != nil {
+			return err
+		}
 
-	// Set the password for the specified user
-	if err = tx.UpdatePassword(veroToken.ResourceID.ULID, derivedKey); err != nil {
-		s.Error(c, err)
-		return
-	}
+		// Because we contacted the user via email to reset their password, this
+		// can count as an email verification if they are not yet verified
+		if err = tx.VerifyEmail(veroToken.ResourceID.ULID); err != nil {
+			return err
+		}
 
-	// Because we contacted the user via email to reset their password, this
-	// can count as an email verification if they are not yet verified
-	if err = tx.VerifyEmail(veroToken.ResourceID.ULID); err != nil {
+		// Sign the user out everywhere in case their password was reset because their
+		// account was compromised.
+		if err = tx.RevokeUserSessions(veroToken.ResourceID.ULID); err != nil {
+			return err
+		}
+
+		// Now that the password has been changed, delete the VeroToken record
+		if err = tx.DeleteVeroToken(veroToken.ID); err != nil {
+			// Do not return an error if we could not delete the record, just log it.
+			rlog.ErrorAttrs(c.Request.Context(), "could not delete reset password link record",
+				slog.Any("err", err), slog.String("link_id", veroToken.ID.String()))
+		}
+		return nil
+	})
+
+	if err != nil {
 		s.Error(c, err)
 		return
 	}
 
-	// Now that the password has been changed, delete the VeroToken record and
-	// clear its cookie
-	if err = tx.DeleteVeroToken(veroToken.ID); err != nil {
-		// Do not return an error if we could not delete the record, just log it.
-		rlog.ErrorAttrs(c.Request.Context(), "could not delete reset password link record",
-			slog.Any("err", err), slog.String("link_id", veroToken.ID.String()))
-	}
+	// Clear the reset password cookie now that the transaction is complete
 	auth.ClearResetPasswordTokenCookie(c, s.conf.Auth.GetResetPasswordURL().Hostname())
 
-	// Complete the transaction
-	tx.Commit()
+	s.audit(c, models.AuditPasswordChange, models.AuditUser, veroToken.ResourceID.ULID.String(), nil, nil)
 
 	// Signal to HTMX that the password has been changed successfully
 	c.HTML(http.StatusOK, "auth/reset/success.html", scene.New(c))
@@ -564,19 +853,20 @@ func (s *Server) ResetPassword(c *gin.Context) {
 const resetPasswordTokenTTL = 15 * time.Minute
 
 // sendResetPasswordEmail creates a vero token and emails a password-reset link.
-func (s *Server) sendResetPasswordEmail(c *gin.Context, emailOrUserID any) (err error) {
-	ctx := c.Request.Context()
-
-	// Begin a read-write transaction
-	var tx txn.Txn
-	if tx, err = s.store.Begin(ctx, &sql.TxOptions{ReadOnly: false}); err != nil {
-		return err
-	}
-	defer tx.Rollback()
+func (s *Server) sendResetPasswordEmail(c *gin.Context, email string) (err error) {
+	// Perform all of the work in a read-write transaction so that the token is not
+	// stored unless the email is sent to the user.
+	return s.store.WithTx(c.Request.Context(), nil, func(tx txn.Tx) error {
+		return s.sendResetPasswordEmailTx(tx, email)
+	})
+}
 
+// sendResetPasswordEmailTx creates the vero token and sends the email inside of the
+// transaction so that it is rolled back if the email cannot be sent.
+func (s *Server) sendResetPasswordEmailTx(tx txn.Tx, email string) (err error) {
 	// Lookup the user
 	var user *models.User
-	if user, err = tx.RetrieveUser(emailOrUserID); err != nil {
+	if user, err = tx.RetrieveUserByEmail(email); err != nil {
 		return err
 	}
 
@@ -594,7 +884,7 @@ func (s *Server) sendResetPasswordEmail(c *gin.Context, emailOrUserID any) (err
 	// (expired) record for the user and create a new one. ErrTooSoon will
 	// enable rate limiting to make sure the user cannot spam reset password
 	// requests.
-	if err = tx.CreateResetPasswordVeroToken(record); err != nil {
+	if record, err = tx.CreateResetPasswordVeroToken(record); err != nil {
 		return err
 	}
 
@@ -630,26 +920,19 @@ func (s *Server) sendResetPasswordEmail(c *gin.Context, emailOrUserID any) (err
 	}
 
 	// Build the email
-	var email *commo.Email
-	if email, err = emails.NewResetPasswordEmail(user.Email, emailData); err != nil {
+	var msg *commo.Email
+	if msg, err = emails.NewResetPasswordEmail(user.Email, emailData); err != nil {
 		return err
 	}
 
 	// Send the email to the user
-	if err = email.Send(); err != nil {
+	if err = msg.Send(); err != nil {
 		return err
 	}
 
 	// Update the VeroToken record in the database with a SentOn timestamp
 	record.SentOn = sql.NullTime{Valid: true, Time: time.Now()}
-	if err = tx.UpdateVeroToken(record); err != nil {
-		return err
-	}
-
-	// Commit the successful transaction
-	tx.Commit()
-
-	return nil
+	return tx.UpdateVeroToken(record)
 }
 
 // ============================================================================
@@ -694,116 +977,6 @@ func SlowDown() {
 	time.Sleep(delay)
 }
 
-// ============================================================================
-// Endeavor webhook sync
-// ============================================================================
-
-// syncUserPost POSTs the user JSON to the endeavor sync webhook.
-// When async is true the request runs in a background goroutine.
-func (s *Server) syncUserPost(c *gin.Context, user *api.User, accessToken *string, async bool) {
-	bearer := syncBearerToken(c, accessToken)
-	ctx := c.Request.Context()
-	if async {
-		ctx = context.WithoutCancel(ctx)
-		u := *user
-		go s.postUserSync(ctx, &u, bearer)
-		return
-	}
-	s.postUserSync(ctx, user, bearer)
-}
-
-// syncBearerToken returns the explicit token or the bearer token from the gin context.
-func syncBearerToken(c *gin.Context, accessToken *string) string {
-	if accessToken != nil && *accessToken != "" {
-		return *accessToken
-	}
-	token, _ := gimauth.GetAccessToken(c)
-	return token
-}
-
-// postUserSync performs the endeavor user sync HTTP POST.
-func (s *Server) postUserSync(ctx context.Context, user *api.User, bearer string) {
-	if bearer == "" {
-		rlog.WarnAttrs(ctx, "user sync post: missing access token",
-			slog.String("user_id", user.ID.String()))
-		return
-	}
-
-	u := s.conf.App.WebhookURL()
-	bodyBytes, err := json.Marshal(user)
-	if err != nil {
-		rlog.WarnAttrs(ctx, "user sync post: could not marshal user to json",
-			slog.Any("err", err), slog.String("endpoint_url", u.String()), slog.String("user_id", user.ID.String()))
-		return
-	}
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(bodyBytes))
-	if err != nil {
-		rlog.WarnAttrs(ctx, "user sync post: could not create new post request",
-			slog.Any("err", err), slog.String("endpoint_url", u.String()), slog.String("user_id", user.ID.String()))
-		return
-	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+bearer)
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		rlog.WarnAttrs(ctx, "user sync post: could not complete http post request",
-			slog.Any("err", err), slog.String("endpoint_url", u.String()), slog.String("user_id", user.ID.String()))
-		return
-	}
-	resp.Body.Close()
-
-	rlog.DebugAttrs(ctx, "user sync post successful",
-		slog.String("endpoint_url", u.String()), slog.String("user_id", user.ID.String()))
-}
-
-// syncUserDelete notifies endeavor that a user was deleted.
-func (s *Server) syncUserDelete(c *gin.Context, userID ulid.ULID) {
-	var (
-		req   *http.Request
-		idURL string
-		token string
-		resp  *http.Response
-		err   error
-	)
-
-	u := s.conf.App.WebhookURL()
-
-	// Create the URL by appending the userID onto the sync webhook url path
-	if idURL, err = url.JoinPath(u.String(), userID.String()); err != nil {
-		rlog.WarnAttrs(c.Request.Context(), "user sync delete: could not create sync url",
-			slog.Any("err", err), slog.String("endpoint_url", u.String()), slog.String("user_id", userID.String()))
-		return
-	}
-
-	// Create a DELETE request
-	if req, err = http.NewRequestWithContext(c.Request.Context(), http.MethodDelete, idURL, nil); err != nil {
-		rlog.WarnAttrs(c.Request.Context(), "user sync delete: could not create new delete request",
-			slog.Any("err", err), slog.String("endpoint_url", u.String()), slog.String("user_id", userID.String()))
-		return
-	}
-
-	// Add authorization token
-	if token, err = gimauth.GetAccessToken(c); err != nil || token == "" {
-		rlog.WarnAttrs(c.Request.Context(), "user sync delete: could not attain an access token from context",
-			slog.Any("err", err), slog.String("endpoint_url", u.String()), slog.String("user_id", userID.String()))
-		return
-	}
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
-
-	// Do request
-	if resp, err = http.DefaultClient.Do(req); err != nil {
-		rlog.WarnAttrs(c.Request.Context(), "user sync delete: could not complete http post request",
-			slog.Any("err", err), slog.String("endpoint_url", u.String()), slog.String("user_id", userID.String()))
-		return
-	}
-	resp.Body.Close()
-
-	rlog.DebugAttrs(c.Request.Context(), "user sync delete successful",
-		slog.String("endpoint_url", u.String()), slog.String("user_id", userID.String()))
-}
-
 // ============================================================================
 // Team invite and welcome email
 // ============================================================================
@@ -814,20 +987,27 @@ const welcomeEmailTokenTTL = 48 * time.Hour
 // welcomeEmailResendCooldown is the minimum time between welcome email sends.
 const welcomeEmailResendCooldown = 15 * time.Minute
 
-// upsertExistingUser updates an existing user when CreateUser is retried.
-func (s *Server) upsertExistingUser(ctx context.Context, in *api.User) (*models.User, error) {
-	existing, err := s.store.RetrieveUser(ctx, in.Email)
-	if err != nil {
-		return nil, err
-	}
-
-	// Only update the name for now.
-	existing.Name = sql.NullString{Valid: in.Name != "", String: in.Name}
-	if err = s.store.UpdateUser(ctx, existing); err != nil {
-		return nil, err
+// createOrUpsertUserTx creates the user or, when CreateUser is retried with the email
+// of an existing user, updates the existing user; created reports if the user is new.
+func createOrUpsertUserTx(tx txn.Tx, in *api.User, model *models.User) (_ *models.User, created bool, err error) {
+	var existing *models.User
+	switch existing, err = tx.RetrieveUserByEmail(in.Email); {
+	case err == nil:
+		// Only update the name for now.
+		existing.Name = sql.NullString{Valid: in.Name != "", String: in.Name}
+		if err = tx.UpdateUser(existing); err != nil {
+			return nil, false, err
+		}
+		model = existing
+	case errors.Is(err, errors.ErrNotFound):
+		if model, err = tx.CreateUser(model); err != nil {
+			return nil, false, err
+		}
+		created = true
+	default:
+		return nil, false, err
 	}
-
-	return s.store.RetrieveUser(ctx, existing.ID)
+	return model, created, nil
 }
 
 // teamInviteTokenValid reports whether a stored team-invite token can still be used.
@@ -864,12 +1044,15 @@ func (s *Server) sendWelcomeEmail(ctx context.Context, user *models.User) (err e
 	defer span.End()
 	span.SetAttributes(attribute.String("user.id", user.ID.String()))
 
-	var tx txn.Txn
-	if tx, err = s.store.Begin(ctx, &sql.TxOptions{ReadOnly: false}); err != nil {
-		return err
-	}
-	defer tx.Rollback()
+	// The token is rolled back if the welcome email cannot be sent.
+	return s.store.WithTx(ctx, nil, func(tx txn.Tx) error {
+		return s.sendWelcomeEmailTx(tx, user)
+	})
+}
 
+// sendWelcomeEmailTx creates or reuses the team-invite token and sends the welcome
+// email inside of the transaction.
+func (s *Server) sendWelcomeEmailTx(tx txn.Tx, user *models.User) (err error) {
 	// Create a new token or reuse an existing one.
 	record := &models.VeroToken{
 		TokenType:  enum.TokenTypeTeamInvite,
@@ -877,12 +1060,13 @@ func (s *Server) sendWelcomeEmail(ctx context.Context, user *models.User) (err e
 		Email:      user.Email,
 		Expiration: time.Now().Add(welcomeEmailTokenTTL),
 	}
-	if err = tx.CreateTeamInviteVeroToken(record); err != nil {
+	var created *models.VeroToken
+	if created, err = tx.CreateTeamInviteVeroToken(record); err != nil {
 		if !errors.Is(err, errors.ErrTooSoon) {
 			return err
 		}
 
-		existing, err := tx.RetrieveTeamInviteVeroToken(user.ID)
+		existing, err := tx.RetrieveVeroTokenByResource(user.ID, enum.TokenTypeTeamInvite)
 		if err != nil {
 			return err
 		}
@@ -892,7 +1076,7 @@ func (s *Server) sendWelcomeEmail(ctx context.Context, user *models.User) (err e
 			// If the existing token is valid, check if it was sent too
 			// recently. If it was, do not send a new email.
 			if welcomeEmailRateLimited(existing) {
-				return tx.Commit()
+				return nil
 			}
 			record = existing
 		default:
@@ -901,18 +1085,20 @@ func (s *Server) sendWelcomeEmail(ctx context.Context, user *models.User) (err e
 			if err = tx.DeleteVeroToken(existing.ID); err != nil {
 				return err
 			}
-			if err = tx.CreateTeamInviteVeroToken(record); err != nil {
+			if record, err = tx.CreateTeamInviteVeroToken(record); err != nil {
 				return err
 			}
 		}
+	} else {
+		record = created
 	}
 
-	resetURL := *s.conf.Auth.GetResetPasswordURL()
-	resetURL.Host = s.conf.App.BaseURL().Host
+	inviteURL := *s.conf.Auth.GetAcceptInviteURL()
+	inviteURL.Host = s.conf.App.BaseURL().Host
 	emailData := emails.WelcomeUserEmailData{
 		ContactName:          user.Name.String,
 		Role:                 emails.RoleTitle(user),
-		PasswordResetURL:     &resetURL,
+		PasswordResetURL:     &inviteURL,
 		WelcomeEmailBodyText: s.conf.App.WelcomeEmail.TextContent(),
 		WelcomeEmailBodyHTML: s.conf.App.WelcomeEmail.HTMLContent(),
 		EmailBaseData: emails.EmailBaseData{
@@ -951,9 +1137,5 @@ func (s *Server) sendWelcomeEmail(ctx context.Context, user *models.User) (err e
 	}
 
 	record.SentOn = sql.NullTime{Valid: true, Time: time.Now()}
-	if err = tx.UpdateVeroToken(record); err != nil {
-		return err
-	}
-
-	return tx.Commit()
+	return tx.UpdateVeroToken(record)
 }
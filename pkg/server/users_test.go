@@ -1,12 +1,24 @@
 package server
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/require"
-	"go.rtnl.ai/quarterdeck/pkg/store/v1/models"
+	"go.rtnl.ai/quarterdeck/pkg/api/v1"
+	"go.rtnl.ai/quarterdeck/pkg/auth/passwords"
+	"go.rtnl.ai/quarterdeck/pkg/enum"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/mock"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/tidal"
+	"go.rtnl.ai/ulid"
 )
 
 // TestWelcomeEmailRateLimited checks the resend cooldown uses SentOn.
@@ -29,3 +41,367 @@ func TestWelcomeEmailRateLimited(t *testing.T) {
 		require.False(t, welcomeEmailRateLimited(record))
 	})
 }
+
+func TestDeleteUser(t *testing.T) {
+	mockStore := openMockStore(t)
+	defer mockStore.Close()
+	srv := newTestServer(mockStore)
+
+	userID := ulid.MakeSecure()
+	mockStore.OnRetrieveUser = func(context.Context, ulid.ULID) (*models.User, error) {
+		return &models.User{BaseModel: tidal.BaseModel{ID: userID}, Status: enum.UserStatusActive}, nil
+	}
+	mockStore.OnUpdateUserStatus = func(_ context.Context, id ulid.ULID, status enum.UserStatus) error {
+		require.Equal(t, userID, id)
+		require.Equal(t, enum.UserStatusDeleted, status)
+		return nil
+	}
+	mockStore.OnRevokeUserSessions = func(context.Context, ulid.ULID) error { return nil }
+	mockStore.OnCreateAuditEvent = func(_ context.Context, event *models.AuditEvent) (*models.AuditEvent, error) {
+		require.Equal(t, models.AuditDelete, event.Action)
+		return event, nil
+	}
+
+	params := gin.Params{{Key: "userID", Value: userID.String()}}
+	w, c := requestContext(t, http.MethodDelete, "/v1/users/"+userID.String(), nil, params)
+	srv.DeleteUser(c)
+
+	// The user is only soft deleted so that they can be restored until they are purged.
+	require.Equal(t, http.StatusOK, w.Code)
+	mockStore.AssertCalls(t, mock.UpdateUserStatus, 1)
+	mockStore.AssertCalls(t, mock.RevokeUserSessions, 1)
+	mockStore.AssertCalls(t, mock.DeleteUser, 0)
+}
+
+// TestUsersOrganizationScope verifies that requesters logged into an organization can
+// only list and manage the members of that organization.
+func TestUsersOrganizationScope(t *testing.T) {
+	memberID, otherID := ulid.MakeSecure(), ulid.MakeSecure()
+
+	setup := func(t *testing.T) (*mock.Store, *Server) {
+		mockStore := openMockStore(t)
+		t.Cleanup(func() { mockStore.Close() })
+
+		mockStore.OnRetrieveOrganizationMember = func(_ context.Context, orgID, userID ulid.ULID) (*models.User, error) {
+			require.Equal(t, acmeOrgID, orgID)
+			if userID == memberID {
+				return &models.User{BaseModel: tidal.BaseModel{ID: userID}}, nil
+			}
+			return nil, errors.ErrNotFound
+		}
+		mockStore.OnRetrieveUser = func(_ context.Context, id ulid.ULID) (*models.User, error) {
+			return &models.User{BaseModel: tidal.BaseModel{ID: id}, Email: "jane@example.com", Status: enum.UserStatusActive}, nil
+		}
+		mockStore.OnUpdateUser = func(context.Context, *models.User) error { return nil }
+		mockStore.OnUpdateUserStatus = func(context.Context, ulid.ULID, enum.UserStatus) error { return nil }
+		mockStore.OnRevokeUserSessions = func(context.Context, ulid.ULID) error { return nil }
+		mockStore.OnCreateAuditEvent = func(_ context.Context, event *models.AuditEvent) (*models.AuditEvent, error) {
+			return event, nil
+		}
+		return mockStore, newTestOAuthServer(t, mockStore)
+	}
+
+	t.Run("ListUsers", func(t *testing.T) {
+		mockStore, srv := setup(t)
+		mockStore.OnListUsers = func(_ context.Context, filter tidal.ListFilter) (tidal.Cursor[*models.User], error) {
+			custom, ok := filter.(*tidal.CustomFilter)
+			require.True(t, ok, "expected a custom filter for the users query")
+			require.Contains(t, custom.SQL, "organization_members WHERE organization_id = :org_id")
+			require.Contains(t, custom.Args, sql.Named("org_id", acmeOrgID))
+			return mock.NewCursor(&models.User{BaseModel: tidal.BaseModel{ID: memberID}, Email: "jane@example.com"}), nil
+		}
+
+		w, c := requestContext(t, http.MethodGet, "/v1/users", nil, nil)
+		authorizeOrg(t, srv, c, acmeOrgID)
+		srv.ListUsers(c)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	})
+
+	t.Run("UserDetail", func(t *testing.T) {
+		mockStore, srv := setup(t)
+
+		params := gin.Params{{Key: "userID", Value: otherID.String()}}
+		w, c := requestContext(t, http.MethodGet, "/v1/users/"+otherID.String(), nil, params)
+		authorizeOrg(t, srv, c, acmeOrgID)
+		srv.UserDetail(c)
+
+		require.Equal(t, http.StatusNotFound, w.Code)
+		mockStore.AssertCalls(t, mock.RetrieveUser, 0)
+	})
+
+	t.Run("UpdateUser", func(t *testing.T) {
+		mockStore, srv := setup(t)
+
+		params := gin.Params{{Key: "userID", Value: otherID.String()}}
+		w, c := requestContext(t, http.MethodPut, "/v1/users/"+otherID.String(), []byte(`{"name": "Mallory", "email": "jane@example.com"}`), params)
+		c.Request.Header.Set("Content-Type", "application/json")
+		authorizeOrg(t, srv, c, acmeOrgID)
+		srv.UpdateUser(c)
+
+		require.Equal(t, http.StatusNotFound, w.Code)
+		mockStore.AssertCalls(t, mock.UpdateUser, 0)
+	})
+
+	t.Run("DeleteUser", func(t *testing.T) {
+		mockStore, srv := setup(t)
+
+		params := gin.Params{{Key: "userID", Value: otherID.String()}}
+		w, c := requestContext(t, http.MethodDelete, "/v1/users/"+otherID.String(), nil, params)
+		authorizeOrg(t, srv, c, acmeOrgID)
+		srv.DeleteUser(c)
+
+		require.Equal(t, http.StatusNotFound, w.Code)
+		mockStore.AssertCalls(t, mock.UpdateUserStatus, 0)
+		mockStore.AssertCalls(t, mock.RevokeUserSessions, 0)
+	})
+
+	t.Run("DeleteMember", func(t *testing.T) {
+		mockStore, srv := setup(t)
+
+		params := gin.Params{{Key: "userID", Value: memberID.String()}}
+		w, c := requestContext(t, http.MethodDelete, "/v1/users/"+memberID.String(), nil, params)
+		authorizeOrg(t, srv, c, acmeOrgID)
+		srv.DeleteUser(c)
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		mockStore.AssertCalls(t, mock.UpdateUserStatus, 1)
+	})
+
+	t.Run("RevokeAllSessions", func(t *testing.T) {
+		mockStore, srv := setup(t)
+
+		params := gin.Params{{Key: "userID", Value: otherID.String()}}
+		w, c := requestContext(t, http.MethodDelete, "/v1/users/"+otherID.String()+"/sessions", nil, params)
+		authorizeOrg(t, srv, c, acmeOrgID, "users:manage")
+		srv.RevokeAllSessions(c)
+
+		require.Equal(t, http.StatusNotFound, w.Code)
+		mockStore.AssertCalls(t, mock.RevokeUserSessions, 0)
+	})
+
+	t.Run("ChangeEmail", func(t *testing.T) {
+		mockStore, srv := setup(t)
+
+		params := gin.Params{{Key: "userID", Value: otherID.String()}}
+		w, c := requestContext(t, http.MethodPost, "/v1/users/"+otherID.String()+"/email", []byte(`{"email": "mallory@example.com"}`), params)
+		c.Request.Header.Set("Content-Type", "application/json")
+		authorizeOrg(t, srv, c, acmeOrgID, "users:manage")
+		srv.ChangeEmail(c)
+
+		require.Equal(t, http.StatusNotFound, w.Code)
+		mockStore.AssertCalls(t, mock.RetrieveUser, 0)
+	})
+}
+
+func TestRestoreUser(t *testing.T) {
+	setup := func(t *testing.T, status enum.UserStatus) (*mock.Store, *Server, gin.Params) {
+		mockStore := openMockStore(t)
+		t.Cleanup(func() { mockStore.Close() })
+
+		userID := ulid.MakeSecure()
+		mockStore.OnRetrieveUser = func(context.Context, ulid.ULID) (*models.User, error) {
+			return &models.User{BaseModel: tidal.BaseModel{ID: userID}, Status: status}, nil
+		}
+		mockStore.OnUpdateUserStatus = func(_ context.Context, _ ulid.ULID, in enum.UserStatus) error {
+			status = in
+			return nil
+		}
+		mockStore.OnCreateAuditEvent = func(_ context.Context, in *models.AuditEvent) (*models.AuditEvent, error) { return in, nil }
+		mockStore.OnEnqueueWebhookEvent = func(_ context.Context, _ ulid.ULID, event enum.WebhookEvent, _ []byte) (int, error) {
+			require.Equal(t, enum.WebhookEventUserUpdated, event)
+			return 1, nil
+		}
+
+		return mockStore, newTestServer(mockStore), gin.Params{{Key: "userID", Value: userID.String()}}
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		mockStore, srv, params := setup(t, enum.UserStatusDeleted)
+		w, c := requestContext(t, http.MethodPost, "/v1/users/"+params[0].Value+"/restore", nil, params)
+		srv.RestoreUser(c)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		mockStore.AssertCalls(t, mock.UpdateUserStatus, 1)
+		mockStore.AssertCalls(t, mock.RevokeUserSessions, 0)
+		mockStore.AssertCalls(t, mock.EnqueueWebhookEvent, 1)
+
+		out := &api.User{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), out))
+		require.Equal(t, enum.UserStatusActive, out.Status)
+	})
+
+	t.Run("NotDeleted", func(t *testing.T) {
+		mockStore, srv, params := setup(t, enum.UserStatusSuspended)
+		w, c := requestContext(t, http.MethodPost, "/v1/users/"+params[0].Value+"/restore", nil, params)
+		srv.RestoreUser(c)
+
+		require.Equal(t, http.StatusConflict, w.Code)
+		mockStore.AssertCalls(t, mock.UpdateUserStatus, 0)
+	})
+}
+
+func TestUpdateUserStatus(t *testing.T) {
+	setup := func(t *testing.T, status enum.UserStatus) (*mock.Store, *Server, gin.Params) {
+		mockStore := openMockStore(t)
+		t.Cleanup(func() { mockStore.Close() })
+
+		userID := ulid.MakeSecure()
+		mockStore.OnRetrieveUser = func(context.Context, ulid.ULID) (*models.User, error) {
+			return &models.User{BaseModel: tidal.BaseModel{ID: userID}, Status: status}, nil
+		}
+		mockStore.OnUpdateUserStatus = func(_ context.Context, _ ulid.ULID, in enum.UserStatus) error {
+			status = in
+			return nil
+		}
+		mockStore.OnRevokeUserSessions = func(context.Context, ulid.ULID) error { return nil }
+		mockStore.OnCreateAuditEvent = func(_ context.Context, in *models.AuditEvent) (*models.AuditEvent, error) { return in, nil }
+		mockStore.OnEnqueueWebhookEvent = func(_ context.Context, _ ulid.ULID, event enum.WebhookEvent, _ []byte) (int, error) {
+			require.Equal(t, enum.WebhookEventUserUpdated, event)
+			return 1, nil
+		}
+
+		return mockStore, newTestServer(mockStore), gin.Params{{Key: "userID", Value: userID.String()}}
+	}
+
+	update := func(t *testing.T, srv *Server, params gin.Params, body string) *httptest.ResponseRecorder {
+		w, c := requestContext(t, http.MethodPut, "/v1/users/"+params[0].Value+"/status", []byte(body), params)
+		c.Request.Header.Set("Content-Type", "application/json")
+		srv.UpdateUserStatus(c)
+		return w
+	}
+
+	t.Run("Suspend", func(t *testing.T) {
+		mockStore, srv, params := setup(t, enum.UserStatusActive)
+		w := update(t, srv, params, `{"status":"suspended"}`)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		mockStore.AssertCalls(t, mock.RevokeUserSessions, 1)
+		mockStore.AssertCalls(t, mock.EnqueueWebhookEvent, 1)
+
+		out := &api.User{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), out))
+		require.Equal(t, enum.UserStatusSuspended, out.Status)
+	})
+
+	t.Run("Reactivate", func(t *testing.T) {
+		mockStore, srv, params := setup(t, enum.UserStatusDeactivated)
+		w := update(t, srv, params, `{"status":"active"}`)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		mockStore.AssertCalls(t, mock.RevokeUserSessions, 0)
+	})
+
+	t.Run("EnqueueFailed", func(t *testing.T) {
+		// The webhook event is queued in the same transaction as the status change so the
+		// request fails rather than silently dropping the event.
+		mockStore, srv, params := setup(t, enum.UserStatusActive)
+		mockStore.OnEnqueueWebhookEvent = func(context.Context, ulid.ULID, enum.WebhookEvent, []byte) (int, error) {
+			return 0, errors.ErrReadOnly
+		}
+		w := update(t, srv, params, `{"status":"suspended"}`)
+
+		require.Equal(t, http.StatusInternalServerError, w.Code)
+		mockStore.AssertCalls(t, mock.CreateAuditEvent, 0)
+	})
+
+	t.Run("DeleteStatus", func(t *testing.T) {
+		mockStore, srv, params := setup(t, enum.UserStatusActive)
+		w := update(t, srv, params, `{"status":"deleted"}`)
+
+		require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		mockStore.AssertCalls(t, mock.UpdateUserStatus, 0)
+	})
+
+	t.Run("DeletedUser", func(t *testing.T) {
+		mockStore, srv, params := setup(t, enum.UserStatusDeleted)
+		w := update(t, srv, params, `{"status":"active"}`)
+
+		require.Equal(t, http.StatusConflict, w.Code)
+		mockStore.AssertCalls(t, mock.UpdateUserStatus, 0)
+	})
+}
+
+func TestLoginUserStatus(t *testing.T) {
+	password := "supersecretsquirrel"
+	derivedKey, err := passwords.CreateDerivedKey(password)
+	require.NoError(t, err)
+
+	login := func(t *testing.T, status enum.UserStatus) (int, []byte) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestOAuthServer(t, mockStore)
+
+		mockStore.OnRetrieveUserByEmail = func(context.Context, string) (*models.User, error) {
+			return &models.User{
+				BaseModel:     tidal.BaseModel{ID: ulid.MakeSecure()},
+				Email:         "jane@example.com",
+				Password:      derivedKey,
+				EmailVerified: true,
+				Status:        status,
+			}, nil
+		}
+
+		body, err := json.Marshal(&api.LoginRequest{Email: "jane@example.com", Password: password})
+		require.NoError(t, err)
+
+		w, c := requestContext(t, http.MethodPost, "/v1/login", body, nil)
+		c.Request.Header.Set("Content-Type", "application/json")
+		srv.Login(c)
+
+		mockStore.AssertCalls(t, mock.UpdateLastLogin, 0)
+		mockStore.AssertCalls(t, mock.CreateRefreshToken, 0)
+		return w.Code, w.Body.Bytes()
+	}
+
+	t.Run("Suspended", func(t *testing.T) {
+		code, body := login(t, enum.UserStatusSuspended)
+		require.Equal(t, http.StatusForbidden, code)
+		require.Contains(t, string(body), errors.ErrUserSuspended.Error())
+	})
+
+	t.Run("Deactivated", func(t *testing.T) {
+		code, body := login(t, enum.UserStatusDeactivated)
+		require.Equal(t, http.StatusForbidden, code)
+		require.Contains(t, string(body), errors.ErrUserDeactivated.Error())
+	})
+
+	t.Run("Deleted", func(t *testing.T) {
+		code, body := login(t, enum.UserStatusDeleted)
+		require.Equal(t, http.StatusUnauthorized, code)
+		require.Contains(t, string(body), errors.ErrFailedAuthentication.Error())
+	})
+}
+
+func TestChangePassword(t *testing.T) {
+	current := "supersecretsquirrel"
+	derivedKey, err := passwords.CreateDerivedKey(current)
+	require.NoError(t, err)
+
+	mockStore := openMockStore(t)
+	defer mockStore.Close()
+	srv := newTestServer(mockStore)
+
+	userID := ulid.MakeSecure()
+	mockStore.OnRetrieveUser = func(context.Context, ulid.ULID) (*models.User, error) {
+		return &models.User{BaseModel: tidal.BaseModel{ID: userID}, Password: derivedKey, Status: enum.UserStatusActive}, nil
+	}
+	mockStore.OnUpdatePassword = func(context.Context, ulid.ULID, string) error { return nil }
+	mockStore.OnRevokeUserSessions = func(_ context.Context, id ulid.ULID) error {
+		require.Equal(t, userID, id)
+		return nil
+	}
+	mockStore.OnCreateAuditEvent = func(_ context.Context, in *models.AuditEvent) (*models.AuditEvent, error) { return in, nil }
+
+	body, err := json.Marshal(&api.ProfilePassword{Current: current, Password: "c0rrect-H0rse-battery-staple", Confirm: "c0rrect-H0rse-battery-staple"})
+	require.NoError(t, err)
+
+	params := gin.Params{{Key: "userID", Value: userID.String()}}
+	w, c := requestContext(t, http.MethodPost, "/v1/users/"+userID.String()+"/password", body, params)
+	c.Request.Header.Set("Content-Type", "application/json")
+	srv.ChangePassword(c)
+
+	// Every session is revoked since the user must log in again with the new password.
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	mockStore.AssertCalls(t, mock.UpdatePassword, 1)
+	mockStore.AssertCalls(t, mock.RevokeUserSessions, 1)
+}
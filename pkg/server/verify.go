@@ -0,0 +1,247 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.rtnl.ai/commo"
+	"go.rtnl.ai/quarterdeck/pkg/api/v1"
+	"go.rtnl.ai/quarterdeck/pkg/auth"
+	"go.rtnl.ai/quarterdeck/pkg/emails"
+	"go.rtnl.ai/quarterdeck/pkg/enum"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/txn"
+	"go.rtnl.ai/quarterdeck/pkg/web/htmx"
+	"go.rtnl.ai/quarterdeck/pkg/web/scene"
+	"go.rtnl.ai/ulid"
+	"go.rtnl.ai/x/rlog"
+	"go.rtnl.ai/x/vero"
+)
+
+// verifyEmailTokenTTL is how long an email verification link remains valid. Because
+// only one unexpired link may exist for a user, it is also the minimum time between
+// verification emails.
+const verifyEmailTokenTTL = 1 * time.Hour
+
+// VerifyEmail verifies the emailed link and marks the email address of the user as
+// verified so that they can log in. The token may be submitted in the request body by
+// API clients; the verify email page submits it in a cookie instead. Invited users
+// verify their email by accepting their invite, which also sets their password.
+func (s *Server) VerifyEmail(c *gin.Context) {
+	var (
+		err       error
+		in        *api.URLVerification
+		veroToken *models.VeroToken
+	)
+
+	in = &api.URLVerification{}
+	if err = c.BindJSON(in); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadRequest, api.Error("could not parse verify email request"))
+		return
+	}
+
+	// Get the verification token from the cookie if it was not in the request
+	if in.Token == "" {
+		if in.Token, err = c.Cookie(auth.VerifyEmailTokenCookie); err != nil {
+			// If no token is submitted, then slow down the request and send back a 403.
+			SlowDown()
+			c.JSON(http.StatusForbidden, api.Error("unable to process verify email request"))
+			return
+		}
+	}
+
+	if err = in.Validate(); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, api.Error(err))
+		return
+	}
+
+	// Verify the VeroToken token
+	if veroToken, err = s.verifyVeroToken(c.Request.Context(), in); err != nil {
+		switch {
+		case errors.Is(err, errors.ErrNotFound), errors.Is(err, errors.ErrExpiredToken):
+			c.JSON(http.StatusBadRequest, api.Error("your verification link is invalid or expired, please request a new verification email"))
+			return
+		case errors.Is(err, errors.ErrNotAllowed):
+			// The slow down prevents brute force attacks on the verify email endpoint.
+			SlowDown()
+			c.JSON(http.StatusForbidden, api.Error("unable to process verify email request"))
+			return
+		default:
+			s.Error(c, err)
+			return
+		}
+	}
+
+	// Other links (e.g. reset password links) cannot be used to verify an email.
+	if veroToken.TokenType != enum.TokenTypeVerifyEmail {
+		SlowDown()
+		c.JSON(http.StatusForbidden, api.Error("unable to process verify email request"))
+		return
+	}
+
+	err = s.store.WithTx(c.Request.Context(), nil, func(tx txn.Tx) (err error) {
+		if err = checkNoPendingInvite(tx, veroToken.ResourceID.ULID); err != nil {
+			return err
+		}
+
+		if err = tx.VerifyEmail(veroToken.ResourceID.ULID); err != nil {
+			return err
+		}
+
+		// The email is verified so the link cannot be used again.
+		return tx.DeleteVeroToken(veroToken.ID)
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, errors.ErrNotFound):
+			c.JSON(http.StatusBadRequest, api.Error("your verification link is invalid or expired, please request a new verification email"))
+		case errors.Is(err, errors.ErrInvitePending):
+			c.JSON(http.StatusConflict, api.Error("please accept the invite that was emailed to you to verify your email"))
+		default:
+			s.Error(c, err)
+		}
+		return
+	}
+
+	auth.ClearVerifyEmailTokenCookie(c, s.conf.Auth.GetVerifyEmailURL().Hostname())
+	s.audit(c, models.AuditVerifyEmail, models.AuditUser, veroToken.ResourceID.ULID.String(), nil, nil)
+
+	if htmx.IsWebRequest(c) {
+		c.HTML(http.StatusOK, "auth/verify/success.html", scene.New(c))
+		return
+	}
+
+	c.JSON(http.StatusOK, api.Reply{Success: true})
+}
+
+// ResendVerifyEmail sends a new email verification link to the email address if it
+// belongs to a user who has not verified it yet and has not been invited. To prevent
+// enumeration of users the response is always successful, and to prevent spamming users
+// a new email is not sent while a previously sent link is still valid.
+func (s *Server) ResendVerifyEmail(c *gin.Context) {
+	var (
+		err  error
+		in   *api.ResendVerifyEmailRequest
+		user *models.User
+	)
+
+	in = &api.ResendVerifyEmailRequest{}
+	if err = c.BindJSON(in); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadRequest, api.Error("could not parse resend verification request"))
+		return
+	}
+
+	if in.Email != "" {
+		if user, err = s.store.RetrieveUserByEmail(c.Request.Context(), in.Email); err != nil {
+			if !errors.Is(err, errors.ErrNotFound) {
+				s.Error(c, err)
+				return
+			}
+
+			rlog.WarnAttrs(c.Request.Context(), "non-user email address provided for resend verification request",
+				slog.String("email", in.Email))
+		}
+
+		if user != nil && !user.EmailVerified {
+			if err = s.sendVerifyEmail(c.Request.Context(), user); err != nil && !errors.Is(err, errors.ErrTooSoon) && !errors.Is(err, errors.ErrInvitePending) {
+				s.Error(c, err)
+				return
+			}
+		}
+	}
+
+	if htmx.IsWebRequest(c) {
+		c.HTML(http.StatusOK, "partials/auth/verifySent.html", scene.New(c))
+		return
+	}
+
+	c.JSON(http.StatusOK, api.Reply{Success: true})
+}
+
+// sendVerifyEmail creates a verify email vero token and emails the verification link;
+// returns ErrTooSoon if an unexpired link has already been sent to the user and
+// ErrInvitePending if the user has been invited but has not accepted the invite.
+func (s *Server) sendVerifyEmail(ctx context.Context, user *models.User) (err error) {
+	// The token is rolled back if the verification email cannot be sent.
+	return s.store.WithTx(ctx, nil, func(tx txn.Tx) error {
+		return s.sendVerifyEmailTx(tx, user)
+	})
+}
+
+// sendVerifyEmailTx creates and signs the verify email vero token and sends the email
+// inside of the transaction.
+func (s *Server) sendVerifyEmailTx(tx txn.Tx, user *models.User) (err error) {
+	if err = checkNoPendingInvite(tx, user.ID); err != nil {
+		return err
+	}
+
+	var record *models.VeroToken
+	if record, err = tx.CreateVerifyEmailVeroToken(&models.VeroToken{
+		TokenType:  enum.TokenTypeVerifyEmail,
+		ResourceID: ulid.NullULID{Valid: true, ULID: user.ID},
+		Email:      user.Email,
+		Expiration: time.Now().Add(verifyEmailTokenTTL),
+	}); err != nil {
+		return err
+	}
+
+	verifyURL := s.conf.Auth.GetVerifyEmailURL()
+	verifyURL.Host = s.conf.App.BaseURL().Host
+	emailData := emails.VerifyEmailData{
+		ContactName:    user.Name.String,
+		VerifyEmailURL: verifyURL,
+		EmailBaseData: emails.EmailBaseData{
+			AppName:        s.conf.App.Name,
+			AppLogoURL:     s.conf.App.LogoURL(),
+			OrgName:        s.conf.Org.Name,
+			OrgHomepageURL: s.conf.Org.HomepageURL(),
+			SupportEmail:   s.conf.Org.SupportEmail,
+		},
+	}
+
+	var verification *vero.Token
+	if verification, err = vero.New(record.ID[:], record.Expiration); err != nil {
+		return err
+	}
+
+	if emailData.Token, record.Signature, err = verification.Sign(); err != nil {
+		return err
+	}
+
+	if err = tx.UpdateVeroToken(record); err != nil {
+		return err
+	}
+
+	var email *commo.Email
+	if email, err = emails.NewVerifyEmail(user.Email, emailData); err != nil {
+		return err
+	}
+
+	if err = email.Send(); err != nil {
+		return err
+	}
+
+	record.SentOn = sql.NullTime{Valid: true, Time: time.Now()}
+	return tx.UpdateVeroToken(record)
+}
+
+// checkNoPendingInvite returns ErrInvitePending if the user has an invite, whether or
+// not it has expired. Invited users have not set a password yet, so verifying their
+// email would mark the invite as accepted without the user being able to log in.
+func checkNoPendingInvite(tx txn.Tx, userID ulid.ULID) (err error) {
+	if _, err = tx.RetrieveVeroTokenByResource(userID, enum.TokenTypeTeamInvite); err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	return errors.ErrInvitePending
+}
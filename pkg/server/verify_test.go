@@ -0,0 +1,138 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/quarterdeck/pkg/api/v1"
+	"go.rtnl.ai/quarterdeck/pkg/auth/passwords"
+	"go.rtnl.ai/quarterdeck/pkg/enum"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/mock"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/quarterdeck/pkg/web/htmx"
+	"go.rtnl.ai/tidal"
+	"go.rtnl.ai/ulid"
+)
+
+func TestVerifyEmail(t *testing.T) {
+	t.Run("InvalidToken", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		w, c := requestContext(t, http.MethodPost, "/v1/verify-email", []byte(`{"token":"notavalidtoken"}`), nil)
+		c.Request.Header.Set("Content-Type", "application/json")
+		srv.VerifyEmail(c)
+
+		require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		mockStore.AssertCalls(t, mock.RetrieveVeroToken, 0)
+	})
+}
+
+func TestResendVerifyEmail(t *testing.T) {
+	resend := func(t *testing.T, srv *Server, email string) api.Reply {
+		body, err := json.Marshal(&api.ResendVerifyEmailRequest{Email: email})
+		require.NoError(t, err)
+
+		w, c := requestContext(t, http.MethodPost, "/v1/verify-email/resend", body, nil)
+		c.Request.Header.Set("Content-Type", "application/json")
+		srv.ResendVerifyEmail(c)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		return parseReply(t, w)
+	}
+
+	t.Run("UnknownEmail", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		mockStore.OnRetrieveUserByEmail = func(context.Context, string) (*models.User, error) {
+			return nil, errors.ErrNotFound
+		}
+
+		// The response must not reveal that the user does not exist.
+		require.True(t, resend(t, srv, "nobody@example.com").Success)
+		mockStore.AssertCalls(t, mock.WithTx, 0)
+	})
+
+	t.Run("AlreadyVerified", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		mockStore.OnRetrieveUserByEmail = func(_ context.Context, email string) (*models.User, error) {
+			return &models.User{BaseModel: tidal.BaseModel{ID: ulid.MakeSecure()}, Email: email, EmailVerified: true}, nil
+		}
+
+		require.True(t, resend(t, srv, "jane@example.com").Success)
+		mockStore.AssertCalls(t, mock.WithTx, 0)
+	})
+
+	t.Run("PendingInvite", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		user := &models.User{BaseModel: tidal.BaseModel{ID: ulid.MakeSecure()}, Email: "jane@example.com"}
+		mockStore.OnRetrieveUserByEmail = func(context.Context, string) (*models.User, error) {
+			return user, nil
+		}
+		mockStore.OnRetrieveVeroTokenByResource = func(_ context.Context, id ulid.ULID, tokenType enum.TokenType) (*models.VeroToken, error) {
+			require.Equal(t, user.ID, id)
+			require.Equal(t, enum.TokenTypeTeamInvite, tokenType)
+			return &models.VeroToken{TokenType: tokenType, ResourceID: ulid.NullULID{Valid: true, ULID: id}}, nil
+		}
+
+		// Invited users must accept their invite to verify their email and set a password.
+		require.True(t, resend(t, srv, user.Email).Success)
+		mockStore.AssertCalls(t, mock.CreateVerifyEmailVeroToken, 0)
+	})
+
+	t.Run("NoEmail", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		require.True(t, resend(t, srv, "").Success)
+		mockStore.AssertCalls(t, mock.RetrieveUserByEmail, 0)
+	})
+}
+
+func TestLoginEmailNotVerified(t *testing.T) {
+	password := "supersecretsquirrel"
+	derivedKey, err := passwords.CreateDerivedKey(password)
+	require.NoError(t, err)
+
+	mockStore := openMockStore(t)
+	defer mockStore.Close()
+	srv := newTestOAuthServer(t, mockStore)
+
+	mockStore.OnRetrieveLockout = func(context.Context, string, string) (*models.Lockout, error) {
+		return nil, errors.ErrNotFound
+	}
+	mockStore.OnRetrieveUserByEmail = func(context.Context, string) (*models.User, error) {
+		return &models.User{
+			BaseModel: tidal.BaseModel{ID: ulid.MakeSecure()},
+			Email:     "jane@example.com",
+			Password:  derivedKey,
+		}, nil
+	}
+
+	body, err := json.Marshal(&api.LoginRequest{Email: "jane@example.com", Password: password})
+	require.NoError(t, err)
+
+	// The login page is sent an event so that it can offer to resend the verification.
+	w, c := requestContext(t, http.MethodPost, "/v1/login", body, nil)
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Request.Header.Set(htmx.HXRequest, "true")
+	srv.Login(c)
+
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	require.Equal(t, htmx.EmailNotVerified, w.Header().Get(htmx.HXTrigger))
+	require.Equal(t, errors.ErrEmailNotVerified.Error(), parseReply(t, w).Error)
+}
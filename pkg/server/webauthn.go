@@ -0,0 +1,498 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"go.rtnl.ai/quarterdeck/pkg/api/v1"
+	"go.rtnl.ai/quarterdeck/pkg/auth"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/quarterdeck/pkg/web/htmx"
+	"go.rtnl.ai/quarterdeck/pkg/web/scene"
+	"go.rtnl.ai/tidal"
+	"go.rtnl.ai/ulid"
+)
+
+const passkeyForbidden = "passkeys can only be managed by the user"
+
+//===========================================================================
+// Passkey Login
+//===========================================================================
+
+// BeginPasskeyLogin starts a passwordless login by returning the WebAuthn assertion
+// options that must be passed to navigator.credentials.get() in the browser. The
+// challenge is not bound to a user so that any discoverable passkey can be used; the
+// session data is signed and stored in an http only cookie for the finish step.
+func (s *Server) BeginPasskeyLogin(c *gin.Context) {
+	var (
+		err       error
+		in        *api.PasskeyLoginRequest
+		options   *protocol.CredentialAssertion
+		session   *webauthn.SessionData
+		challenge string
+	)
+
+	in = &api.PasskeyLoginRequest{}
+	if c.Request.ContentLength != 0 {
+		if err = c.BindJSON(in); err != nil {
+			c.Error(err)
+			c.JSON(http.StatusBadRequest, api.Error(errors.ErrBindJSON))
+			return
+		}
+	}
+
+	if options, session, err = s.webauthn.BeginDiscoverableLogin(webauthn.WithUserVerification(protocol.VerificationRequired)); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error(errors.ErrInternal))
+		return
+	}
+
+	if challenge, err = s.issuer.CreateWebAuthnChallenge(auth.WebAuthnLogin, session, in.ClientID, in.Nonce, in.Next); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error(errors.ErrInternal))
+		return
+	}
+
+	auth.SetWebAuthnChallengeCookie(c, challenge, s.webauthn.Config.RPID)
+	c.JSON(http.StatusOK, options)
+}
+
+// FinishPasskeyLogin verifies the assertion returned by navigator.credentials.get()
+// against the challenge issued by BeginPasskeyLogin and the public key of the passkey.
+// On success the same tokens are issued as for a password login; because user
+// verification is required the passkey satisfies multi-factor authentication.
+func (s *Server) FinishPasskeyLogin(c *gin.Context) {
+	var (
+		err        error
+		body       []byte
+		parsed     *protocol.ParsedCredentialAssertionData
+		challenge  *auth.WebAuthnChallengeClaims
+		user       *passkeyUser
+		credential *webauthn.Credential
+	)
+
+	if challenge, err = s.webauthnChallenge(c, auth.WebAuthnLogin); err != nil {
+		c.JSON(http.StatusUnauthorized, api.Error(errors.ErrWebAuthnChallengeFailed))
+		return
+	}
+
+	if body, err = c.GetRawData(); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadRequest, api.Error(errors.ErrBindJSON))
+		return
+	}
+
+	if parsed, err = protocol.ParseCredentialRequestResponseBytes(body); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadRequest, api.Error(errors.ErrWebAuthnFailed))
+		return
+	}
+
+	// The passkey identifies the user with the user handle that was set at registration.
+	handler := func(rawID, userHandle []byte) (webauthn.User, error) {
+		var err error
+		if user, err = s.lookupPasskeyUser(c, rawID, userHandle); err != nil {
+			return nil, err
+		}
+		return user, nil
+	}
+
+	if _, credential, err = s.webauthn.ValidatePasskeyLogin(handler, challenge.Session, parsed); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusUnauthorized, api.Error(errors.ErrFailedAuthentication))
+		return
+	}
+
+	// A sign count that did not increase indicates the authenticator may have been cloned.
+	if credential.Authenticator.CloneWarning {
+		c.JSON(http.StatusUnauthorized, api.Error(errors.ErrFailedAuthentication))
+		return
+	}
+
+	// User must be verified before they can log in.
+	if !user.EmailVerified {
+		htmx.SetTrigger(c, htmx.EmailNotVerified)
+		c.JSON(http.StatusUnauthorized, api.Error(errors.ErrEmailNotVerified))
+		return
+	}
+
+	// Record the new sign count so that cloned authenticators can be detected.
+	passkey := user.passkey(credential.ID)
+	if passkey == nil {
+		c.JSON(http.StatusUnauthorized, api.Error(errors.ErrFailedAuthentication))
+		return
+	}
+
+	passkey.SignCount = credential.Authenticator.SignCount
+	passkey.Flags = auth.WebAuthnFlags(credential.Flags)
+	passkey.LastUsed = sql.NullTime{Time: time.Now(), Valid: true}
+
+	if err = s.store.UpdateWebAuthnCredential(c.Request.Context(), passkey); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error(errors.ErrInternal))
+		return
+	}
+
+	s.completeLogin(c, user.User, challenge.ClientID, challenge.Nonce, challenge.Next, auth.AMRHardwareKey, auth.AMRMFA)
+}
+
+// lookupPasskeyUser retrieves the owner of the passkey presented during login along
+// with all of their registered passkeys.
+func (s *Server) lookupPasskeyUser(c *gin.Context, rawID, userHandle []byte) (_ *passkeyUser, err error) {
+	var (
+		userID     ulid.ULID
+		credential *models.WebAuthnCredential
+		user       *models.User
+	)
+
+	if userID, err = ulid.Parse(string(userHandle)); err != nil {
+		return nil, errors.ErrFailedAuthentication
+	}
+
+	if credential, err = s.store.RetrieveWebAuthnCredential(c.Request.Context(), rawID); err != nil {
+		return nil, err
+	}
+
+	if credential.UserID != userID {
+		return nil, errors.ErrFailedAuthentication
+	}
+
+	if user, err = s.store.RetrieveUser(c.Request.Context(), userID); err != nil {
+		return nil, err
+	}
+
+	return s.passkeyUser(c, user)
+}
+
+//===========================================================================
+// Passkey Management
+//===========================================================================
+
+// ListPasskeys returns the passkeys registered by the user.
+func (s *Server) ListPasskeys(c *gin.Context) {
+	var (
+		err  error
+		user *models.User
+		list []*models.WebAuthnCredential
+		out  *api.WebAuthnCredentialList
+	)
+
+	if user, err = s.selfUser(c, passkeyForbidden); err != nil {
+		return
+	}
+
+	if list, err = s.listPasskeys(c.Request.Context(), user.ID); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process passkeys list request"))
+		return
+	}
+
+	if out, err = api.NewWebAuthnCredentialList(list); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process passkeys list request"))
+		return
+	}
+
+	c.Negotiate(http.StatusOK, gin.Negotiate{
+		Offered:  []string{binding.MIMEJSON, binding.MIMEHTML},
+		Data:     out,
+		HTMLName: "partials/profile/passkeys.html",
+		HTMLData: scene.New(c).WithAPIData(out),
+	})
+}
+
+// BeginPasskeyRegistration returns the WebAuthn creation options that must be passed to
+// navigator.credentials.create() in the browser to register a new passkey. Passkeys
+// that the user has already registered are excluded so that the same authenticator is
+// not registered twice.
+func (s *Server) BeginPasskeyRegistration(c *gin.Context) {
+	var (
+		err       error
+		model     *models.User
+		user      *passkeyUser
+		options   *protocol.CredentialCreation
+		session   *webauthn.SessionData
+		challenge string
+	)
+
+	if model, err = s.selfUser(c, passkeyForbidden); err != nil {
+		return
+	}
+
+	if user, err = s.passkeyUser(c, model); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error(errors.ErrInternal))
+		return
+	}
+
+	// Passkeys must be discoverable for passwordless login and must verify the user
+	// (e.g. with a biometric or PIN) so that they can be used in place of MFA.
+	opts := []webauthn.RegistrationOption{
+		webauthn.WithAuthenticatorSelection(protocol.AuthenticatorSelection{
+			RequireResidentKey: protocol.ResidentKeyRequired(),
+			ResidentKey:        protocol.ResidentKeyRequirementRequired,
+			UserVerification:   protocol.VerificationRequired,
+		}),
+		webauthn.WithExclusions(webauthn.Credentials(user.WebAuthnCredentials()).CredentialDescriptors()),
+	}
+
+	if options, session, err = s.webauthn.BeginRegistration(user, opts...); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error(errors.ErrInternal))
+		return
+	}
+
+	if challenge, err = s.issuer.CreateWebAuthnChallenge(auth.WebAuthnRegistration, session, "", "", ""); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error(errors.ErrInternal))
+		return
+	}
+
+	auth.SetWebAuthnChallengeCookie(c, challenge, s.webauthn.Config.RPID)
+	c.JSON(http.StatusOK, options)
+}
+
+// FinishPasskeyRegistration verifies the attestation returned by
+// navigator.credentials.create() against the challenge issued by
+// BeginPasskeyRegistration and stores the public key of the new passkey.
+func (s *Server) FinishPasskeyRegistration(c *gin.Context) {
+	var (
+		err        error
+		in         *api.PasskeyRegistration
+		model      *models.User
+		user       *passkeyUser
+		challenge  *auth.WebAuthnChallengeClaims
+		parsed     *protocol.ParsedCredentialCreationData
+		credential *webauthn.Credential
+		out        *api.WebAuthnCredential
+	)
+
+	if model, err = s.selfUser(c, passkeyForbidden); err != nil {
+		return
+	}
+
+	if err = c.BindJSON(&in); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadRequest, api.Error(errors.ErrBindJSON))
+		return
+	}
+
+	if err = in.Validate(); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadRequest, api.Error(err))
+		return
+	}
+
+	if challenge, err = s.webauthnChallenge(c, auth.WebAuthnRegistration); err != nil {
+		c.JSON(http.StatusBadRequest, api.Error(errors.ErrWebAuthnChallengeFailed))
+		return
+	}
+
+	if parsed, err = protocol.ParseCredentialCreationResponseBytes(in.Credential); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadRequest, api.Error(errors.ErrWebAuthnFailed))
+		return
+	}
+
+	if user, err = s.passkeyUser(c, model); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error(errors.ErrInternal))
+		return
+	}
+
+	// The challenge must have been issued to this user (checked by CreateCredential).
+	if credential, err = s.webauthn.CreateCredential(user, challenge.Session, parsed); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadRequest, api.Error(errors.ErrWebAuthnFailed))
+		return
+	}
+
+	passkey := &models.WebAuthnCredential{
+		UserID:          model.ID,
+		Name:            sql.NullString{String: in.Name, Valid: in.Name != ""},
+		CredentialID:    credential.ID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: sql.NullString{String: credential.AttestationType, Valid: credential.AttestationType != ""},
+		AAGUID:          credential.Authenticator.AAGUID,
+		SignCount:       credential.Authenticator.SignCount,
+		Transports:      make([]string, 0, len(credential.Transport)),
+		Flags:           auth.WebAuthnFlags(credential.Flags),
+	}
+
+	for _, transport := range credential.Transport {
+		passkey.Transports = append(passkey.Transports, string(transport))
+	}
+
+	if passkey, err = s.store.CreateWebAuthnCredential(c.Request.Context(), passkey); err != nil {
+		if errors.Is(err, errors.ErrAlreadyExists) {
+			c.JSON(http.StatusConflict, api.Error("this passkey has already been registered"))
+			return
+		}
+
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error(errors.ErrInternal))
+		return
+	}
+
+	if out, err = api.NewWebAuthnCredential(passkey); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error(errors.ErrInternal))
+		return
+	}
+
+	htmx.SetTrigger(c, htmx.PasskeysUpdated)
+	c.JSON(http.StatusCreated, out)
+}
+
+// DeletePasskey removes a passkey so that it can no longer be used to log in.
+func (s *Server) DeletePasskey(c *gin.Context) {
+	var (
+		err       error
+		user      *models.User
+		passkeyID ulid.ULID
+		list      []*models.WebAuthnCredential
+	)
+
+	if user, err = s.selfUser(c, passkeyForbidden); err != nil {
+		return
+	}
+
+	if passkeyID, err = ulid.Parse(c.Param("passkeyID")); err != nil {
+		c.JSON(http.StatusNotFound, api.Error("passkey not found"))
+		return
+	}
+
+	// Ensure the passkey belongs to the user in the URL.
+	if list, err = s.listPasskeys(c.Request.Context(), user.ID); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process delete passkey request"))
+		return
+	}
+
+	found := false
+	for _, passkey := range list {
+		if passkey.ID == passkeyID {
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		c.JSON(http.StatusNotFound, api.Error("passkey not found"))
+		return
+	}
+
+	if err = s.store.DeleteWebAuthnCredential(c.Request.Context(), passkeyID); err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			c.JSON(http.StatusNotFound, api.Error("passkey not found"))
+			return
+		}
+
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process delete passkey request"))
+		return
+	}
+
+	if htmx.IsHTMXRequest(c) {
+		htmx.SetTrigger(c, htmx.PasskeysUpdated)
+		c.Data(http.StatusNoContent, gin.MIMEHTML, nil)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.Reply{Success: true})
+}
+
+//===========================================================================
+// Helpers
+//===========================================================================
+
+// webauthnChallenge verifies the challenge cookie set when the ceremony was started;
+// the cookie is always cleared so that a challenge can only be used once.
+func (s *Server) webauthnChallenge(c *gin.Context, ceremony string) (_ *auth.WebAuthnChallengeClaims, err error) {
+	var cookie string
+	if cookie, err = c.Cookie(auth.WebAuthnChallengeCookie); err != nil {
+		return nil, errors.ErrWebAuthnChallengeFailed
+	}
+
+	auth.ClearWebAuthnChallengeCookie(c, s.webauthn.Config.RPID)
+	return s.issuer.VerifyWebAuthnChallenge(cookie, ceremony)
+}
+
+// passkeyUser loads the registered passkeys of the user for a WebAuthn ceremony.
+func (s *Server) passkeyUser(c *gin.Context, user *models.User) (_ *passkeyUser, err error) {
+	var list []*models.WebAuthnCredential
+	if list, err = s.listPasskeys(c.Request.Context(), user.ID); err != nil {
+		return nil, err
+	}
+	return &passkeyUser{User: user, passkeys: list}, nil
+}
+
+// listPasskeys returns the passkeys registered by the user in the order they were registered.
+func (s *Server) listPasskeys(ctx context.Context, userID ulid.ULID) ([]*models.WebAuthnCredential, error) {
+	return listAll(s.store.ListWebAuthnCredentials(ctx, (&tidal.Filter{}).Where("user_id", tidal.Eq, userID).OrderBy("created")))
+}
+
+// passkeyUser adapts a user and their passkeys to the [webauthn.User] interface. The
+// user handle stored on the authenticator is the string representation of the user ID.
+type passkeyUser struct {
+	*models.User
+	passkeys []*models.WebAuthnCredential
+}
+
+var _ webauthn.User = (*passkeyUser)(nil)
+
+func (u *passkeyUser) WebAuthnID() []byte {
+	return []byte(u.ID.String())
+}
+
+func (u *passkeyUser) WebAuthnName() string {
+	return u.Email
+}
+
+func (u *passkeyUser) WebAuthnDisplayName() string {
+	if u.Name.Valid && u.Name.String != "" {
+		return u.Name.String
+	}
+	return u.Email
+}
+
+func (u *passkeyUser) WebAuthnCredentials() []webauthn.Credential {
+	credentials := make([]webauthn.Credential, 0, len(u.passkeys))
+	for _, passkey := range u.passkeys {
+		credential := webauthn.Credential{
+			ID:              passkey.CredentialID,
+			PublicKey:       passkey.PublicKey,
+			AttestationType: passkey.AttestationType.String,
+			Transport:       make([]protocol.AuthenticatorTransport, 0, len(passkey.Transports)),
+			Flags:           auth.WebAuthnCredentialFlags(passkey.Flags),
+			Authenticator: webauthn.Authenticator{
+				AAGUID:    passkey.AAGUID,
+				SignCount: passkey.SignCount,
+			},
+		}
+
+		for _, transport := range passkey.Transports {
+			credential.Transport = append(credential.Transport, protocol.AuthenticatorTransport(transport))
+		}
+
+		credentials = append(credentials, credential)
+	}
+	return credentials
+}
+
+// passkey returns the stored passkey with the authenticator-assigned credential ID.
+func (u *passkeyUser) passkey(credentialID []byte) *models.WebAuthnCredential {
+	for _, passkey := range u.passkeys {
+		if string(passkey.CredentialID) == string(credentialID) {
+			return passkey
+		}
+	}
+	return nil
+}
@@ -0,0 +1,377 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/gimlet"
+	gimauth "go.rtnl.ai/gimlet/auth"
+	"go.rtnl.ai/quarterdeck/pkg/api/v1"
+	"go.rtnl.ai/quarterdeck/pkg/auth"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/mock"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/tidal"
+	"go.rtnl.ai/ulid"
+)
+
+func TestBeginPasskeyLogin(t *testing.T) {
+	mockStore := openMockStore(t)
+	defer mockStore.Close()
+	srv := newTestOAuthServer(t, mockStore)
+
+	body, err := json.Marshal(&api.PasskeyLoginRequest{Next: "/dashboard"})
+	require.NoError(t, err)
+
+	w, c := requestContext(t, http.MethodPost, "/v1/login/passkey/begin", body, nil)
+	c.Request.Header.Set("Content-Type", "application/json")
+	srv.BeginPasskeyLogin(c)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	options := &protocol.CredentialAssertion{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), options))
+	require.NotEmpty(t, options.Response.Challenge)
+	require.Equal(t, "localhost", options.Response.RelyingPartyID)
+	require.Equal(t, protocol.VerificationRequired, options.Response.UserVerification)
+	require.Empty(t, options.Response.AllowedCredentials, "login should allow any discoverable passkey")
+
+	cookie := webauthnChallengeCookie(t, w.Result().Cookies())
+	require.True(t, cookie.HttpOnly)
+
+	claims, err := srv.issuer.VerifyWebAuthnChallenge(cookie.Value, auth.WebAuthnLogin)
+	require.NoError(t, err)
+	require.Equal(t, "/dashboard", claims.Next)
+	require.Equal(t, options.Response.Challenge.String(), claims.Session.Challenge)
+
+	// No store calls are made until the passkey is presented.
+	mockStore.AssertCalls(t, mock.RetrieveWebAuthnCredential, 0)
+	mockStore.AssertCalls(t, mock.RetrieveUser, 0)
+}
+
+func TestFinishPasskeyLogin(t *testing.T) {
+	t.Run("NoChallenge", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestOAuthServer(t, mockStore)
+
+		w, c := requestContext(t, http.MethodPost, "/v1/login/passkey/finish", []byte("{}"), nil)
+		srv.FinishPasskeyLogin(c)
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+		require.Contains(t, w.Body.String(), errors.ErrWebAuthnChallengeFailed.Error())
+	})
+
+	t.Run("WrongCeremony", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestOAuthServer(t, mockStore)
+
+		challenge, err := srv.issuer.CreateWebAuthnChallenge(auth.WebAuthnRegistration, &webauthn.SessionData{Challenge: "challenge"}, "", "", "")
+		require.NoError(t, err)
+
+		w, c := requestContext(t, http.MethodPost, "/v1/login/passkey/finish", []byte("{}"), nil)
+		c.Request.AddCookie(&http.Cookie{Name: auth.WebAuthnChallengeCookie, Value: challenge})
+		srv.FinishPasskeyLogin(c)
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+		require.Contains(t, w.Body.String(), errors.ErrWebAuthnChallengeFailed.Error())
+	})
+
+	t.Run("InvalidAssertion", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestOAuthServer(t, mockStore)
+
+		challenge, err := srv.issuer.CreateWebAuthnChallenge(auth.WebAuthnLogin, &webauthn.SessionData{Challenge: "challenge"}, "", "", "")
+		require.NoError(t, err)
+
+		w, c := requestContext(t, http.MethodPost, "/v1/login/passkey/finish", []byte("{}"), nil)
+		c.Request.AddCookie(&http.Cookie{Name: auth.WebAuthnChallengeCookie, Value: challenge})
+		srv.FinishPasskeyLogin(c)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		require.Contains(t, w.Body.String(), errors.ErrWebAuthnFailed.Error())
+
+		// The challenge cookie must be cleared so that it cannot be replayed.
+		cookie := webauthnChallengeCookie(t, w.Result().Cookies())
+		require.Empty(t, cookie.Value)
+
+		mockStore.AssertCalls(t, mock.RetrieveWebAuthnCredential, 0)
+		mockStore.AssertCalls(t, mock.UpdateWebAuthnCredential, 0)
+	})
+}
+
+func TestListPasskeys(t *testing.T) {
+	userID := ulid.MakeSecure()
+	params := gin.Params{{Key: "userID", Value: userID.String()}}
+	user := &models.User{BaseModel: tidal.BaseModel{ID: userID}, Email: "jannel@example.com", EmailVerified: true}
+
+	t.Run("Own", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestOAuthServer(t, mockStore)
+
+		mockStore.OnRetrieveUser = func(context.Context, ulid.ULID) (*models.User, error) {
+			return user, nil
+		}
+
+		mockStore.OnListWebAuthnCredentials = func(_ context.Context, filter tidal.ListFilter) (tidal.Cursor[*models.WebAuthnCredential], error) {
+			require.Equal(t, (&tidal.Filter{}).Where("user_id", tidal.Eq, userID).OrderBy("created"), filter)
+			return mock.NewCursor(testPasskey(userID, 0x1d), testPasskey(userID, 0x05)), nil
+		}
+
+		claims := &gimauth.Claims{}
+		claims.SetSubjectID(gimauth.SubjectUser, userID)
+
+		w, c := requestContext(t, http.MethodGet, "/v1/users/"+userID.String()+"/passkeys", nil, params)
+		gimlet.Set(c, gimlet.KeyUserClaims, claims)
+		srv.ListPasskeys(c)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		out := &api.WebAuthnCredentialList{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), out))
+		require.Len(t, out.Credentials, 2)
+		require.True(t, out.Credentials[0].Synced)
+		require.False(t, out.Credentials[1].Synced)
+		require.NotContains(t, w.Body.String(), "public_key")
+	})
+
+	t.Run("OtherUser", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestOAuthServer(t, mockStore)
+
+		// Even administrators cannot view another user's passkeys.
+		claims := &gimauth.Claims{Permissions: []string{"users:manage"}}
+		claims.SetSubjectID(gimauth.SubjectUser, ulid.MakeSecure())
+
+		w, c := requestContext(t, http.MethodGet, "/v1/users/"+userID.String()+"/passkeys", nil, params)
+		gimlet.Set(c, gimlet.KeyUserClaims, claims)
+		srv.ListPasskeys(c)
+		require.Equal(t, http.StatusForbidden, w.Code)
+
+		mockStore.AssertCalls(t, mock.RetrieveUser, 0)
+		mockStore.AssertCalls(t, mock.ListWebAuthnCredentials, 0)
+	})
+}
+
+func TestBeginPasskeyRegistration(t *testing.T) {
+	userID := ulid.MakeSecure()
+	params := gin.Params{{Key: "userID", Value: userID.String()}}
+	user := &models.User{BaseModel: tidal.BaseModel{ID: userID}, Email: "jannel@example.com", EmailVerified: true}
+	existing := testPasskey(userID, 0x05)
+
+	mockStore := openMockStore(t)
+	defer mockStore.Close()
+	srv := newTestOAuthServer(t, mockStore)
+
+	mockStore.OnRetrieveUser = func(context.Context, ulid.ULID) (*models.User, error) {
+		return user, nil
+	}
+
+	mockStore.OnListWebAuthnCredentials = func(context.Context, tidal.ListFilter) (tidal.Cursor[*models.WebAuthnCredential], error) {
+		return mock.NewCursor(existing), nil
+	}
+
+	claims := &gimauth.Claims{}
+	claims.SetSubjectID(gimauth.SubjectUser, userID)
+
+	w, c := requestContext(t, http.MethodPost, "/v1/users/"+userID.String()+"/passkeys/register", nil, params)
+	gimlet.Set(c, gimlet.KeyUserClaims, claims)
+	srv.BeginPasskeyRegistration(c)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	options := &protocol.CredentialCreation{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), options))
+	require.Equal(t, base64.RawURLEncoding.EncodeToString([]byte(userID.String())), options.Response.User.ID, "user handle should be the user ID")
+	require.Equal(t, protocol.ResidentKeyRequirementRequired, options.Response.AuthenticatorSelection.ResidentKey)
+	require.Equal(t, protocol.VerificationRequired, options.Response.AuthenticatorSelection.UserVerification)
+	require.Len(t, options.Response.CredentialExcludeList, 1)
+	require.Equal(t, protocol.URLEncodedBase64(existing.CredentialID), options.Response.CredentialExcludeList[0].CredentialID)
+
+	cookie := webauthnChallengeCookie(t, w.Result().Cookies())
+	_, err := srv.issuer.VerifyWebAuthnChallenge(cookie.Value, auth.WebAuthnRegistration)
+	require.NoError(t, err)
+
+	_, err = srv.issuer.VerifyWebAuthnChallenge(cookie.Value, auth.WebAuthnLogin)
+	require.ErrorIs(t, err, errors.ErrWebAuthnChallengeFailed, "registration challenge should not be usable for login")
+}
+
+func TestFinishPasskeyRegistration(t *testing.T) {
+	userID := ulid.MakeSecure()
+	params := gin.Params{{Key: "userID", Value: userID.String()}}
+	user := &models.User{BaseModel: tidal.BaseModel{ID: userID}, Email: "jannel@example.com", EmailVerified: true}
+
+	claims := &gimauth.Claims{}
+	claims.SetSubjectID(gimauth.SubjectUser, userID)
+
+	body, err := json.Marshal(&api.PasskeyRegistration{Name: "Laptop", Credential: json.RawMessage(`{"id":"abc"}`)})
+	require.NoError(t, err)
+
+	t.Run("NoChallenge", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestOAuthServer(t, mockStore)
+
+		mockStore.OnRetrieveUser = func(context.Context, ulid.ULID) (*models.User, error) {
+			return user, nil
+		}
+
+		w, c := requestContext(t, http.MethodPost, "/v1/users/"+userID.String()+"/passkeys", body, params)
+		c.Request.Header.Set("Content-Type", "application/json")
+		gimlet.Set(c, gimlet.KeyUserClaims, claims)
+		srv.FinishPasskeyRegistration(c)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		require.Contains(t, w.Body.String(), errors.ErrWebAuthnChallengeFailed.Error())
+		mockStore.AssertCalls(t, mock.CreateWebAuthnCredential, 0)
+	})
+
+	t.Run("MissingCredential", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestOAuthServer(t, mockStore)
+
+		mockStore.OnRetrieveUser = func(context.Context, ulid.ULID) (*models.User, error) {
+			return user, nil
+		}
+
+		w, c := requestContext(t, http.MethodPost, "/v1/users/"+userID.String()+"/passkeys", []byte(`{"name":"Laptop"}`), params)
+		c.Request.Header.Set("Content-Type", "application/json")
+		gimlet.Set(c, gimlet.KeyUserClaims, claims)
+		srv.FinishPasskeyRegistration(c)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		mockStore.AssertCalls(t, mock.CreateWebAuthnCredential, 0)
+	})
+
+	t.Run("InvalidAttestation", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestOAuthServer(t, mockStore)
+
+		mockStore.OnRetrieveUser = func(context.Context, ulid.ULID) (*models.User, error) {
+			return user, nil
+		}
+
+		challenge, err := srv.issuer.CreateWebAuthnChallenge(auth.WebAuthnRegistration, &webauthn.SessionData{Challenge: "challenge"}, "", "", "")
+		require.NoError(t, err)
+
+		w, c := requestContext(t, http.MethodPost, "/v1/users/"+userID.String()+"/passkeys", body, params)
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Request.AddCookie(&http.Cookie{Name: auth.WebAuthnChallengeCookie, Value: challenge})
+		gimlet.Set(c, gimlet.KeyUserClaims, claims)
+		srv.FinishPasskeyRegistration(c)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		require.Contains(t, w.Body.String(), errors.ErrWebAuthnFailed.Error())
+		mockStore.AssertCalls(t, mock.CreateWebAuthnCredential, 0)
+	})
+}
+
+func TestDeletePasskey(t *testing.T) {
+	userID := ulid.MakeSecure()
+	user := &models.User{BaseModel: tidal.BaseModel{ID: userID}, Email: "jannel@example.com", EmailVerified: true}
+	passkey := testPasskey(userID, 0x05)
+
+	claims := &gimauth.Claims{}
+	claims.SetSubjectID(gimauth.SubjectUser, userID)
+
+	setup := func(t *testing.T) (*mock.Store, *Server) {
+		mockStore := openMockStore(t)
+		srv := newTestOAuthServer(t, mockStore)
+
+		mockStore.OnRetrieveUser = func(context.Context, ulid.ULID) (*models.User, error) {
+			return user, nil
+		}
+
+		mockStore.OnListWebAuthnCredentials = func(context.Context, tidal.ListFilter) (tidal.Cursor[*models.WebAuthnCredential], error) {
+			return mock.NewCursor(passkey), nil
+		}
+
+		mockStore.OnDeleteWebAuthnCredential = func(_ context.Context, in ulid.ULID) error {
+			require.Equal(t, passkey.ID, in)
+			return nil
+		}
+
+		return mockStore, srv
+	}
+
+	t.Run("Own", func(t *testing.T) {
+		mockStore, srv := setup(t)
+		defer mockStore.Close()
+
+		params := gin.Params{{Key: "userID", Value: userID.String()}, {Key: "passkeyID", Value: passkey.ID.String()}}
+		w, c := requestContext(t, http.MethodDelete, "/v1/users/"+userID.String()+"/passkeys/"+passkey.ID.String(), nil, params)
+		gimlet.Set(c, gimlet.KeyUserClaims, claims)
+		srv.DeletePasskey(c)
+		require.Equal(t, http.StatusOK, w.Code)
+		mockStore.AssertCalls(t, mock.DeleteWebAuthnCredential, 1)
+	})
+
+	t.Run("HTMX", func(t *testing.T) {
+		mockStore, srv := setup(t)
+		defer mockStore.Close()
+
+		params := gin.Params{{Key: "userID", Value: userID.String()}, {Key: "passkeyID", Value: passkey.ID.String()}}
+		w, c := requestContext(t, http.MethodDelete, "/v1/users/"+userID.String()+"/passkeys/"+passkey.ID.String(), nil, params)
+		c.Request.Header.Set("HX-Request", "true")
+		gimlet.Set(c, gimlet.KeyUserClaims, claims)
+		srv.DeletePasskey(c)
+		require.Equal(t, http.StatusNoContent, w.Code)
+		require.Equal(t, "passkeys-updated", w.Header().Get("HX-Trigger"))
+		mockStore.AssertCalls(t, mock.DeleteWebAuthnCredential, 1)
+	})
+
+	t.Run("NotOwned", func(t *testing.T) {
+		mockStore, srv := setup(t)
+		defer mockStore.Close()
+
+		otherID := ulid.MakeSecure().String()
+		params := gin.Params{{Key: "userID", Value: userID.String()}, {Key: "passkeyID", Value: otherID}}
+		w, c := requestContext(t, http.MethodDelete, "/v1/users/"+userID.String()+"/passkeys/"+otherID, nil, params)
+		gimlet.Set(c, gimlet.KeyUserClaims, claims)
+		srv.DeletePasskey(c)
+		require.Equal(t, http.StatusNotFound, w.Code)
+		mockStore.AssertCalls(t, mock.DeleteWebAuthnCredential, 0)
+	})
+
+	t.Run("OtherUser", func(t *testing.T) {
+		mockStore, srv := setup(t)
+		defer mockStore.Close()
+
+		other := &gimauth.Claims{Permissions: []string{"users:manage"}}
+		other.SetSubjectID(gimauth.SubjectUser, ulid.MakeSecure())
+
+		params := gin.Params{{Key: "userID", Value: userID.String()}, {Key: "passkeyID", Value: passkey.ID.String()}}
+		w, c := requestContext(t, http.MethodDelete, "/v1/users/"+userID.String()+"/passkeys/"+passkey.ID.String(), nil, params)
+		gimlet.Set(c, gimlet.KeyUserClaims, other)
+		srv.DeletePasskey(c)
+		require.Equal(t, http.StatusForbidden, w.Code)
+		mockStore.AssertCalls(t, mock.DeleteWebAuthnCredential, 0)
+	})
+}
+
+func testPasskey(userID ulid.ULID, flags uint8) *models.WebAuthnCredential {
+	return &models.WebAuthnCredential{
+		BaseModel:    tidal.BaseModel{ID: ulid.MakeSecure(), Created: time.Now()},
+		UserID:       userID,
+		CredentialID: []byte(ulid.MakeSecure().String()),
+		PublicKey:    []byte("public key"),
+		Transports:   []string{"internal", "hybrid"},
+		Flags:        flags,
+	}
+}
+
+func webauthnChallengeCookie(t *testing.T, cookies []*http.Cookie) *http.Cookie {
+	t.Helper()
+	for _, cookie := range cookies {
+		if cookie.Name == auth.WebAuthnChallengeCookie {
+			return cookie
+		}
+	}
+	require.Fail(t, "no webauthn challenge cookie was set")
+	return nil
+}
@@ -0,0 +1,404 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.rtnl.ai/quarterdeck/pkg/api/v1"
+	"go.rtnl.ai/quarterdeck/pkg/enum"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/quarterdeck/pkg/webhooks"
+	"go.rtnl.ai/ulid"
+	"go.rtnl.ai/x/rlog"
+)
+
+// ============================================================================
+// Webhook resource handlers
+// ============================================================================
+
+// ListWebhooks returns the webhooks that are subscribed to Quarterdeck events.
+func (s *Server) ListWebhooks(c *gin.Context) {
+	var (
+		err    error
+		in     *api.WebhookPageQuery
+		cursor *api.Cursor
+		page   *api.Page
+		hooks  []*models.Webhook
+		out    *api.WebhookList
+	)
+
+	in = &api.WebhookPageQuery{}
+	if err = c.BindQuery(in); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadRequest, api.Error("invalid query parameters"))
+		return
+	}
+
+	if cursor, err = in.Cursor(); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadRequest, api.Error(err))
+		return
+	}
+
+	if hooks, err = listAll(s.store.ListWebhooks(c.Request.Context(), cursor.Filter())); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process webhooks list request"))
+		return
+	}
+	hooks, page = api.Paginate(cursor, hooks, func(w *models.Webhook) ulid.ULID { return w.ID })
+
+	if out, err = api.NewWebhookList(hooks); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process webhooks list request"))
+		return
+	}
+	out.Page = page
+
+	c.JSON(http.StatusOK, out)
+}
+
+// CreateWebhook subscribes an endpoint to events. The secret used to sign deliveries to
+// the endpoint is generated by Quarterdeck and is only returned in this response.
+func (s *Server) CreateWebhook(c *gin.Context) {
+	var (
+		err   error
+		in    *api.Webhook
+		model *models.Webhook
+		out   *api.Webhook
+	)
+
+	in = &api.Webhook{}
+	if err = c.BindJSON(in); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadRequest, api.Error("could not parse webhook data"))
+		return
+	}
+
+	if err = in.Validate(); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusUnprocessableEntity, api.Error(err))
+		return
+	}
+
+	if model, err = in.Model(); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process webhook data"))
+		return
+	}
+
+	model.Secret = webhooks.NewSecret()
+	if model, err = s.store.CreateWebhook(c.Request.Context(), model); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process create webhook request"))
+		return
+	}
+
+	if out, err = api.NewWebhook(model); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process create webhook request"))
+		return
+	}
+
+	// The secret is not recorded in the audit log.
+	s.audit(c, models.AuditCreate, models.AuditWebhook, model.ID.String(), nil, out)
+
+	out.Secret = model.Secret
+	c.JSON(http.StatusCreated, out)
+}
+
+// WebhookDetail returns the webhook without its secret.
+func (s *Server) WebhookDetail(c *gin.Context) {
+	var (
+		err       error
+		webhookID ulid.ULID
+		out       *api.Webhook
+	)
+
+	if webhookID, err = ulid.Parse(c.Param("webhookID")); err != nil {
+		c.JSON(http.StatusNotFound, api.Error("webhook not found"))
+		return
+	}
+
+	if out, err = s.retrieveWebhook(c, webhookID); err != nil {
+		return
+	}
+
+	c.JSON(http.StatusOK, out)
+}
+
+// UpdateWebhook updates the url, description, events, and active status of a webhook.
+// If active is not specified then the webhook's active status is not changed. The
+// secret cannot be changed; the webhook must be recreated to rotate it.
+func (s *Server) UpdateWebhook(c *gin.Context) {
+	var (
+		err       error
+		webhookID ulid.ULID
+		in        *api.Webhook
+		model     *models.Webhook
+		before    *api.Webhook
+		out       *api.Webhook
+	)
+
+	if webhookID, err = ulid.Parse(c.Param("webhookID")); err != nil {
+		c.JSON(http.StatusNotFound, api.Error("webhook not found"))
+		return
+	}
+
+	in = &api.Webhook{}
+	if err = c.BindJSON(in); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusBadRequest, api.Error("could not parse webhook data"))
+		return
+	}
+
+	if err = in.Validate(); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusUnprocessableEntity, api.Error(err))
+		return
+	}
+
+	// Retrieve the webhook before it is updated to record the changes in the audit log
+	if before, err = s.retrieveWebhook(c, webhookID); err != nil {
+		return
+	}
+
+	// Set the webhook ID only after validation
+	in.ID = webhookID
+	if in.Active == nil {
+		in.Active = before.Active
+	}
+
+	if model, err = in.Model(); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process update webhook request"))
+		return
+	}
+
+	if err = s.store.UpdateWebhook(c.Request.Context(), model); err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			c.JSON(http.StatusNotFound, api.Error("webhook not found"))
+			return
+		}
+
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process update webhook request"))
+		return
+	}
+
+	if out, err = s.retrieveWebhook(c, webhookID); err != nil {
+		return
+	}
+
+	s.audit(c, models.AuditUpdate, models.AuditWebhook, webhookID.String(), before, out)
+	c.JSON(http.StatusOK, out)
+}
+
+// DeleteWebhook deletes the webhook along with its delivery log; pending deliveries to
+// the webhook are not attempted.
+func (s *Server) DeleteWebhook(c *gin.Context) {
+	var (
+		err       error
+		webhookID ulid.ULID
+	)
+
+	if webhookID, err = ulid.Parse(c.Param("webhookID")); err != nil {
+		c.JSON(http.StatusNotFound, api.Error("webhook not found"))
+		return
+	}
+
+	if err = s.store.DeleteWebhook(c.Request.Context(), webhookID); err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			c.JSON(http.StatusNotFound, api.Error("webhook not found"))
+			return
+		}
+
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process delete webhook request"))
+		return
+	}
+
+	s.audit(c, models.AuditDelete, models.AuditWebhook, webhookID.String(), nil, nil)
+	c.JSON(http.StatusOK, api.Reply{Success: true})
+}
+
+// ============================================================================
+// Webhook delivery log handlers
+// ============================================================================
+
+// ListWebhookDeliveries returns the delivery log of the webhook, most recent first.
+func (s *Server) ListWebhookDeliveries(c *gin.Context) {
+	var (
+		err        error
+		webhookID  ulid.ULID
+		deliveries []*models.WebhookDelivery
+		out        *api.WebhookDeliveryList
+	)
+
+	if webhookID, err = ulid.Parse(c.Param("webhookID")); err != nil {
+		c.JSON(http.StatusNotFound, api.Error("webhook not found"))
+		return
+	}
+
+	if deliveries, err = s.store.ListWebhookDeliveries(c.Request.Context(), webhookID); err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			c.JSON(http.StatusNotFound, api.Error("webhook not found"))
+			return
+		}
+
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process webhook deliveries request"))
+		return
+	}
+
+	if out, err = api.NewWebhookDeliveryList(deliveries); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process webhook deliveries request"))
+		return
+	}
+
+	c.JSON(http.StatusOK, out)
+}
+
+// RedeliverWebhookDelivery queues a new delivery of the same event to the webhook, e.g.
+// to retry a delivery that failed once the endpoint has been fixed. The redelivery has
+// the same event ID so that receivers can ignore events they have already handled.
+func (s *Server) RedeliverWebhookDelivery(c *gin.Context) {
+	var (
+		err        error
+		webhookID  ulid.ULID
+		deliveryID ulid.ULID
+		delivery   *models.WebhookDelivery
+		out        *api.WebhookDelivery
+	)
+
+	if webhookID, err = ulid.Parse(c.Param("webhookID")); err != nil {
+		c.JSON(http.StatusNotFound, api.Error("webhook not found"))
+		return
+	}
+
+	if deliveryID, err = ulid.Parse(c.Param("deliveryID")); err != nil {
+		c.JSON(http.StatusNotFound, api.Error("webhook delivery not found"))
+		return
+	}
+
+	if delivery, err = s.store.RetrieveWebhookDelivery(c.Request.Context(), deliveryID); err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			c.JSON(http.StatusNotFound, api.Error("webhook delivery not found"))
+			return
+		}
+
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process redeliver webhook request"))
+		return
+	}
+
+	if delivery.WebhookID != webhookID {
+		c.JSON(http.StatusNotFound, api.Error("webhook delivery not found"))
+		return
+	}
+
+	var redelivery *models.WebhookDelivery
+	if redelivery, err = s.store.CreateWebhookDelivery(c.Request.Context(), delivery.Redelivery()); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process redeliver webhook request"))
+		return
+	}
+
+	s.notifyWebhooks()
+
+	if out, err = api.NewWebhookDelivery(redelivery); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process redeliver webhook request"))
+		return
+	}
+
+	s.audit(c, models.AuditRedeliver, models.AuditWebhook, webhookID.String(), nil, map[string]string{"event_id": redelivery.EventID.String(), "delivery_id": redelivery.ID.String()})
+	c.JSON(http.StatusCreated, out)
+}
+
+// ============================================================================
+// Webhook helpers
+// ============================================================================
+
+// deletedResource is the data of the events published when a resource is deleted;
+// since the resource no longer exists only its ID is sent.
+type deletedResource struct {
+	ID ulid.ULID `json:"id"`
+}
+
+// retrieveWebhook fetches the webhook as an API response; if an error is returned then
+// the response has already been written.
+func (s *Server) retrieveWebhook(c *gin.Context, webhookID ulid.ULID) (out *api.Webhook, err error) {
+	var webhook *models.Webhook
+	if webhook, err = s.store.RetrieveWebhook(c.Request.Context(), webhookID); err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			c.JSON(http.StatusNotFound, api.Error("webhook not found"))
+			return nil, err
+		}
+
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process webhook request"))
+		return nil, err
+	}
+
+	if out, err = api.NewWebhook(webhook); err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, api.Error("could not process webhook request"))
+		return nil, err
+	}
+	return out, nil
+}
+
+// ensureAppWebhook subscribes the configured application webhook endpoint to the user
+// events that it uses to sync users if it is not already subscribed, so that existing
+// deployments that configure the endpoint continue to receive user sync events. If the
+// endpoint is subscribed then its secret is updated when the configured secret changes.
+// A read-only replica relies on the primary to subscribe the endpoint.
+func (s *Server) ensureAppWebhook(ctx context.Context) (err error) {
+	if s.conf.App.WebhookURI == "" || s.conf.Database.ReadOnly {
+		return nil
+	}
+
+	var hooks []*models.Webhook
+	if hooks, err = listAll(s.store.ListWebhooks(ctx, nil)); err != nil {
+		return fmt.Errorf("could not list webhooks: %w", err)
+	}
+
+	for _, webhook := range hooks {
+		if webhook.URL == s.conf.App.WebhookURI {
+			if s.conf.App.WebhookSecret == "" || webhook.Secret == s.conf.App.WebhookSecret {
+				return nil
+			}
+
+			if err = s.store.UpdateWebhookSecret(ctx, webhook.ID, s.conf.App.WebhookSecret); err != nil {
+				return fmt.Errorf("could not update application webhook secret: %w", err)
+			}
+			return nil
+		}
+	}
+
+	webhook, _ := (&api.Webhook{
+		URL:         s.conf.App.WebhookURI,
+		Description: "Application user sync",
+		Events:      []enum.WebhookEvent{enum.WebhookEventUserCreated, enum.WebhookEventUserUpdated, enum.WebhookEventUserDeleted},
+	}).Model()
+	webhook.Secret = s.conf.App.WebhookSecret
+
+	// Deployments that configured the endpoint before events were signed do not have a
+	// secret; a secret is generated so that they continue to receive user sync events,
+	// but the events cannot be verified by the application until a secret is configured.
+	if webhook.Secret == "" {
+		rlog.WarnAttrs(ctx, "no application webhook secret is configured, user sync events are signed with a generated secret",
+			slog.String("webhook_uri", s.conf.App.WebhookURI))
+		webhook.Secret = webhooks.NewSecret()
+	}
+
+	if _, err = s.store.CreateWebhook(ctx, webhook); err != nil {
+		return fmt.Errorf("could not create application webhook: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,485 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/quarterdeck/pkg/api/v1"
+	"go.rtnl.ai/quarterdeck/pkg/config"
+	"go.rtnl.ai/quarterdeck/pkg/enum"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/mock"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/txn"
+	"go.rtnl.ai/quarterdeck/pkg/webhooks"
+	"go.rtnl.ai/tidal"
+	"go.rtnl.ai/ulid"
+)
+
+func TestCreateWebhook(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		var secret string
+		mockStore.OnCreateWebhook = func(_ context.Context, in *models.Webhook) (*models.Webhook, error) {
+			require.Equal(t, "https://example.com/webhooks", in.URL)
+			require.True(t, in.Active, "webhooks should be active by default")
+			require.Len(t, in.Secret, webhooks.SecretLength*2)
+			secret = in.Secret
+
+			in.ID = ulid.MakeSecure()
+			in.Created = time.Now()
+			in.Modified = in.Created
+			return in, nil
+		}
+
+		var event *models.AuditEvent
+		mockStore.OnCreateAuditEvent = func(_ context.Context, in *models.AuditEvent) (*models.AuditEvent, error) {
+			event = in
+			return in, nil
+		}
+
+		w, c := requestContext(t, http.MethodPost, "/v1/webhooks", []byte(`{"url":"https://example.com/webhooks","events":["user.created","login"]}`), nil)
+		c.Request.Header.Set("Content-Type", "application/json")
+		srv.CreateWebhook(c)
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		out := &api.Webhook{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), out))
+		require.Equal(t, secret, out.Secret, "the secret should be returned when the webhook is created")
+		require.Equal(t, []enum.WebhookEvent{enum.WebhookEventUserCreated, enum.WebhookEventLogin}, out.Events)
+
+		require.NotNil(t, event)
+		require.Equal(t, models.AuditWebhook, event.SubjectType)
+		require.NotContains(t, event.Diff.String, secret, "the secret should not be recorded in the audit log")
+	})
+
+	t.Run("UnknownEvent", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		w, c := requestContext(t, http.MethodPost, "/v1/webhooks", []byte(`{"url":"https://example.com/webhooks","events":["user.exploded"]}`), nil)
+		c.Request.Header.Set("Content-Type", "application/json")
+		srv.CreateWebhook(c)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		mockStore.AssertCalls(t, mock.CreateWebhook, 0)
+	})
+
+	t.Run("NoEvents", func(t *testing.T) {
+		mockStore := openMockStore(t)
+		defer mockStore.Close()
+		srv := newTestServer(mockStore)
+
+		w, c := requestContext(t, http.MethodPost, "/v1/webhooks", []byte(`{"url":"https://example.com/webhooks"}`), nil)
+		c.Request.Header.Set("Content-Type", "application/json")
+		srv.CreateWebhook(c)
+		require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		mockStore.AssertCalls(t, mock.CreateWebhook, 0)
+	})
+}
+
+// TestWebhooksInstanceOnly verifies that webhooks, which receive the events of every
+// organization, cannot be managed by requesters logged into an organization.
+func TestWebhooksInstanceOnly(t *testing.T) {
+	mockStore := openMockStore(t)
+	defer mockStore.Close()
+	srv := newTestOAuthServer(t, mockStore)
+	middleware := srv.InstanceOnly()
+
+	w, c := requestContext(t, http.MethodGet, "/v1/webhooks", nil, nil)
+	authorizeOrg(t, srv, c, acmeOrgID)
+	middleware(c)
+	require.True(t, c.IsAborted())
+	require.Equal(t, http.StatusForbidden, w.Code)
+
+	_, c = requestContext(t, http.MethodGet, "/v1/webhooks", nil, nil)
+	middleware(c)
+	require.False(t, c.IsAborted())
+}
+
+func TestUpdateWebhook(t *testing.T) {
+	webhookID := ulid.MakeSecure()
+	setup := func(t *testing.T) (*mock.Store, *Server, *models.Webhook) {
+		mockStore := openMockStore(t)
+		t.Cleanup(func() { mockStore.Close() })
+
+		webhook := &models.Webhook{
+			BaseModel: tidal.BaseModel{ID: webhookID, Created: time.Now(), Modified: time.Now()},
+			URL:       "https://example.com/webhooks",
+			Events:    []enum.WebhookEvent{enum.WebhookEventLogin},
+			Secret:    "supersecretsquirrel",
+			Active:    false,
+		}
+
+		mockStore.OnRetrieveWebhook = func(context.Context, ulid.ULID) (*models.Webhook, error) {
+			out := *webhook
+			return &out, nil
+		}
+		mockStore.OnUpdateWebhook = func(_ context.Context, in *models.Webhook) error {
+			require.Equal(t, webhookID, in.ID)
+			webhook.Events = in.Events
+			webhook.Active = in.Active
+			return nil
+		}
+		mockStore.OnCreateAuditEvent = func(_ context.Context, in *models.AuditEvent) (*models.AuditEvent, error) { return in, nil }
+		return mockStore, newTestServer(mockStore), webhook
+	}
+
+	update := func(t *testing.T, srv *Server, body string) *httptest.ResponseRecorder {
+		params := gin.Params{{Key: "webhookID", Value: webhookID.String()}}
+		w, c := requestContext(t, http.MethodPut, "/v1/webhooks/"+webhookID.String(), []byte(body), params)
+		c.Request.Header.Set("Content-Type", "application/json")
+		srv.UpdateWebhook(c)
+		return w
+	}
+
+	t.Run("ActiveUnchanged", func(t *testing.T) {
+		_, srv, webhook := setup(t)
+		w := update(t, srv, `{"url":"https://example.com/webhooks","events":["login","user.deleted"]}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.False(t, webhook.Active, "the webhook should not be activated if active is not specified")
+		require.Equal(t, []enum.WebhookEvent{enum.WebhookEventLogin, enum.WebhookEventUserDeleted}, webhook.Events)
+	})
+
+	t.Run("Activate", func(t *testing.T) {
+		_, srv, webhook := setup(t)
+		w := update(t, srv, `{"url":"https://example.com/webhooks","events":["login"],"active":true}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.True(t, webhook.Active)
+
+		out := &api.Webhook{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), out))
+		require.Empty(t, out.Secret, "the secret should only be returned when the webhook is created")
+	})
+
+	t.Run("SecretReadOnly", func(t *testing.T) {
+		mockStore, srv, _ := setup(t)
+		w := update(t, srv, `{"url":"https://example.com/webhooks","events":["login"],"secret":"rotated"}`)
+		require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		mockStore.AssertCalls(t, mock.UpdateWebhook, 0)
+	})
+}
+
+func TestRedeliverWebhookDelivery(t *testing.T) {
+	webhookID := ulid.MakeSecure()
+	delivery := &models.WebhookDelivery{
+		BaseModel: tidal.BaseModel{ID: ulid.MakeSecure()},
+		WebhookID: webhookID,
+		EventID:   ulid.MakeSecure(),
+		Event:     enum.WebhookEventUserDeleted,
+		Payload:   []byte(`{"event":"user.deleted"}`),
+		Status:    enum.DeliveryStatusFailed,
+		Attempts:  8,
+	}
+
+	setup := func(t *testing.T) (*mock.Store, *Server) {
+		mockStore := openMockStore(t)
+		t.Cleanup(func() { mockStore.Close() })
+
+		mockStore.OnRetrieveWebhookDelivery = func(_ context.Context, id ulid.ULID) (*models.WebhookDelivery, error) {
+			if id != delivery.ID {
+				return nil, errors.ErrNotFound
+			}
+			return delivery, nil
+		}
+		mockStore.OnCreateWebhookDelivery = func(_ context.Context, in *models.WebhookDelivery) (*models.WebhookDelivery, error) {
+			in.ID = ulid.MakeSecure()
+			return in, nil
+		}
+		mockStore.OnCreateAuditEvent = func(_ context.Context, in *models.AuditEvent) (*models.AuditEvent, error) { return in, nil }
+
+		srv := newTestServer(mockStore)
+		srv.deliver = make(chan struct{}, 1)
+		return mockStore, srv
+	}
+
+	redeliver := func(t *testing.T, srv *Server, webhookID, deliveryID ulid.ULID) *httptest.ResponseRecorder {
+		params := gin.Params{{Key: "webhookID", Value: webhookID.String()}, {Key: "deliveryID", Value: deliveryID.String()}}
+		w, c := requestContext(t, http.MethodPost, "/v1/webhooks/"+webhookID.String()+"/deliveries/"+deliveryID.String()+"/redeliver", nil, params)
+		srv.RedeliverWebhookDelivery(c)
+		return w
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		mockStore, srv := setup(t)
+		w := redeliver(t, srv, webhookID, delivery.ID)
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		out := &api.WebhookDelivery{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), out))
+		require.NotEqual(t, delivery.ID, out.ID)
+		require.Equal(t, delivery.EventID, out.EventID, "the redelivery should have the same event ID")
+		require.Equal(t, enum.DeliveryStatusPending, out.Status)
+		require.Zero(t, out.Attempts)
+
+		mockStore.AssertCalls(t, mock.CreateWebhookDelivery, 1)
+		require.Len(t, srv.deliver, 1, "the dispatcher should be notified of the redelivery")
+	})
+
+	t.Run("WrongWebhook", func(t *testing.T) {
+		mockStore, srv := setup(t)
+		w := redeliver(t, srv, ulid.MakeSecure(), delivery.ID)
+		require.Equal(t, http.StatusNotFound, w.Code)
+		mockStore.AssertCalls(t, mock.CreateWebhookDelivery, 0)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		mockStore, srv := setup(t)
+		w := redeliver(t, srv, webhookID, ulid.MakeSecure())
+		require.Equal(t, http.StatusNotFound, w.Code)
+		mockStore.AssertCalls(t, mock.CreateWebhookDelivery, 0)
+	})
+}
+
+func TestPublishTx(t *testing.T) {
+	mockStore := openMockStore(t)
+	defer mockStore.Close()
+	srv := newTestServer(mockStore)
+	srv.deliver = make(chan struct{}, 1)
+
+	mockStore.OnEnqueueWebhookEvent = func(_ context.Context, eventID ulid.ULID, event enum.WebhookEvent, payload []byte) (int, error) {
+		require.Equal(t, enum.WebhookEventAPIKeyRevoked, event)
+
+		out := &webhooks.Event{}
+		require.NoError(t, json.Unmarshal(payload, out))
+		require.Equal(t, eventID, out.ID)
+		require.Equal(t, enum.WebhookEventAPIKeyRevoked, out.Event)
+		require.JSONEq(t, `{"id":"01KG0020000000000000000001"}`, string(out.Data))
+		return 1, nil
+	}
+
+	var queued int
+	err := mockStore.WithTx(context.Background(), nil, func(tx txn.Tx) (err error) {
+		queued, err = publishTx(tx, enum.WebhookEventAPIKeyRevoked, deletedResource{ID: ulid.MustParse("01KG0020000000000000000001")})
+		return err
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, queued)
+	mockStore.AssertCalls(t, mock.EnqueueWebhookEvent, 1)
+
+	// The dispatcher is only notified once the transaction has been committed.
+	require.Len(t, srv.deliver, 0)
+	srv.notifyQueued(queued)
+	require.Len(t, srv.deliver, 1)
+
+	// Failures to queue the event fail the transaction so that the action is rolled back.
+	mockStore.OnEnqueueWebhookEvent = func(context.Context, ulid.ULID, enum.WebhookEvent, []byte) (int, error) {
+		return 0, errors.ErrReadOnly
+	}
+	err = mockStore.WithTx(context.Background(), nil, func(tx txn.Tx) (err error) {
+		_, err = publishTx(tx, enum.WebhookEventLogin, nil)
+		return err
+	})
+	require.ErrorIs(t, err, errors.ErrReadOnly)
+	mockStore.AssertCalls(t, mock.EnqueueWebhookEvent, 2)
+}
+
+func TestDispatchWebhooks(t *testing.T) {
+	const secret = "supersecretsquirrel"
+
+	var status int
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.NoError(t, webhooks.Verify(secret, r.Header.Get(webhooks.HeaderSignature), body, webhooks.DefaultTolerance))
+		w.WriteHeader(status)
+	}))
+	defer endpoint.Close()
+
+	active := &models.Webhook{BaseModel: tidal.BaseModel{ID: ulid.MakeSecure()}, URL: endpoint.URL, Secret: secret, Active: true}
+	inactive := &models.Webhook{BaseModel: tidal.BaseModel{ID: ulid.MakeSecure()}, URL: endpoint.URL, Secret: secret, Active: false}
+
+	newDelivery := func(webhook *models.Webhook) *models.WebhookDelivery {
+		delivery := &models.WebhookDelivery{
+			BaseModel:   tidal.BaseModel{ID: ulid.MakeSecure()},
+			WebhookID:   webhook.ID,
+			EventID:     ulid.MakeSecure(),
+			Event:       enum.WebhookEventUserCreated,
+			Payload:     []byte(`{"event":"user.created"}`),
+			Status:      enum.DeliveryStatusPending,
+			NextAttempt: sql.NullTime{Valid: true, Time: time.Now()},
+		}
+		return delivery
+	}
+
+	dispatch := func(t *testing.T, deliveries ...*models.WebhookDelivery) (*mock.Store, int) {
+		mockStore := openMockStore(t)
+		t.Cleanup(func() { mockStore.Close() })
+
+		srv := newTestServer(mockStore)
+		srv.conf.Webhooks = config.WebhookConfig{
+			Timeout:        time.Second,
+			MaxAttempts:    2,
+			InitialBackoff: time.Minute,
+			MaxBackoff:     time.Hour,
+			BatchSize:      10,
+		}
+
+		mockStore.OnClaimDueWebhookDeliveries = func(_ context.Context, _ time.Time, lease time.Duration, limit int) ([]*models.WebhookDelivery, error) {
+			require.Equal(t, dispatchTimeout, lease)
+			require.Equal(t, 10, limit)
+			return deliveries, nil
+		}
+		mockStore.OnRetrieveWebhook = func(_ context.Context, id ulid.ULID) (*models.Webhook, error) {
+			switch id {
+			case active.ID:
+				return active, nil
+			case inactive.ID:
+				return inactive, nil
+			default:
+				return nil, errors.ErrNotFound
+			}
+		}
+		mockStore.OnUpdateWebhookDelivery = func(context.Context, *models.WebhookDelivery) error { return nil }
+
+		n := srv.dispatchWebhooks(context.Background(), &http.Client{Timeout: time.Second})
+		return mockStore, n
+	}
+
+	t.Run("Delivered", func(t *testing.T) {
+		status = http.StatusNoContent
+		first, second := newDelivery(active), newDelivery(active)
+
+		mockStore, n := dispatch(t, first, second)
+		require.Equal(t, 2, n)
+		mockStore.AssertCalls(t, mock.RetrieveWebhook, 1)
+		mockStore.AssertCalls(t, mock.UpdateWebhookDelivery, 2)
+
+		require.Equal(t, enum.DeliveryStatusDelivered, first.Status)
+		require.Equal(t, int64(http.StatusNoContent), first.ResponseCode.Int64)
+		require.True(t, first.Delivered.Valid)
+	})
+
+	t.Run("Retried", func(t *testing.T) {
+		status = http.StatusServiceUnavailable
+		delivery := newDelivery(active)
+
+		_, n := dispatch(t, delivery)
+		require.Equal(t, 1, n)
+		require.Equal(t, enum.DeliveryStatusPending, delivery.Status)
+		require.Equal(t, 1, delivery.Attempts)
+		require.Contains(t, delivery.Error.String, "503")
+		require.WithinDuration(t, time.Now().Add(time.Minute), delivery.NextAttempt.Time, 5*time.Second)
+
+		// The delivery fails once the maximum number of attempts has been made.
+		_, n = dispatch(t, delivery)
+		require.Equal(t, 1, n)
+		require.Equal(t, enum.DeliveryStatusFailed, delivery.Status)
+		require.False(t, delivery.NextAttempt.Valid)
+	})
+
+	t.Run("Inactive", func(t *testing.T) {
+		status = http.StatusOK
+		delivery := newDelivery(inactive)
+
+		_, n := dispatch(t, delivery)
+		require.Equal(t, 1, n)
+		require.Equal(t, enum.DeliveryStatusFailed, delivery.Status)
+		require.Equal(t, errors.ErrWebhookInactive.Error(), delivery.Error.String)
+		require.False(t, delivery.ResponseCode.Valid)
+	})
+
+	t.Run("MissingWebhook", func(t *testing.T) {
+		delivery := newDelivery(&models.Webhook{BaseModel: tidal.BaseModel{ID: ulid.MakeSecure()}})
+
+		mockStore, n := dispatch(t, delivery)
+		require.Zero(t, n)
+		mockStore.AssertCalls(t, mock.UpdateWebhookDelivery, 0)
+	})
+}
+
+func TestEnsureAppWebhook(t *testing.T) {
+	setup := func(t *testing.T, existing ...*models.Webhook) (*mock.Store, *Server) {
+		mockStore := openMockStore(t)
+		t.Cleanup(func() { mockStore.Close() })
+
+		mockStore.OnListWebhooks = func(context.Context, tidal.ListFilter) (tidal.Cursor[*models.Webhook], error) {
+			return mock.NewCursor(existing...), nil
+		}
+		mockStore.OnCreateWebhook = func(_ context.Context, in *models.Webhook) (*models.Webhook, error) {
+			require.Equal(t, "https://app.example.com/sync", in.URL)
+			require.Equal(t, "supersecretsquirrel", in.Secret)
+			require.True(t, in.Active)
+			require.Equal(t, []enum.WebhookEvent{enum.WebhookEventUserCreated, enum.WebhookEventUserUpdated, enum.WebhookEventUserDeleted}, in.Events)
+			return in, nil
+		}
+
+		srv := newTestServer(mockStore)
+		srv.conf.App = config.AppConfig{WebhookURI: "https://app.example.com/sync", WebhookSecret: "supersecretsquirrel"}
+		return mockStore, srv
+	}
+
+	t.Run("Create", func(t *testing.T) {
+		mockStore, srv := setup(t)
+		require.NoError(t, srv.ensureAppWebhook(context.Background()))
+		mockStore.AssertCalls(t, mock.CreateWebhook, 1)
+	})
+
+	t.Run("Exists", func(t *testing.T) {
+		mockStore, srv := setup(t, &models.Webhook{URL: "https://app.example.com/sync", Secret: "supersecretsquirrel"})
+		require.NoError(t, srv.ensureAppWebhook(context.Background()))
+		mockStore.AssertCalls(t, mock.CreateWebhook, 0)
+		mockStore.AssertCalls(t, mock.UpdateWebhookSecret, 0)
+	})
+
+	t.Run("SecretChanged", func(t *testing.T) {
+		webhookID := ulid.MakeSecure()
+		mockStore, srv := setup(t, &models.Webhook{BaseModel: tidal.BaseModel{ID: webhookID}, URL: "https://app.example.com/sync", Secret: "oldsecret"})
+		mockStore.OnUpdateWebhookSecret = func(_ context.Context, id ulid.ULID, secret string) error {
+			require.Equal(t, webhookID, id)
+			require.Equal(t, "supersecretsquirrel", secret)
+			return nil
+		}
+
+		require.NoError(t, srv.ensureAppWebhook(context.Background()))
+		mockStore.AssertCalls(t, mock.CreateWebhook, 0)
+		mockStore.AssertCalls(t, mock.UpdateWebhookSecret, 1)
+	})
+
+	t.Run("NoSecret", func(t *testing.T) {
+		// The secret is generated for deployments that configured the endpoint before
+		// events were signed and is not replaced on restart.
+		mockStore, srv := setup(t)
+		srv.conf.App.WebhookSecret = ""
+		mockStore.OnCreateWebhook = func(_ context.Context, in *models.Webhook) (*models.Webhook, error) {
+			require.NotEmpty(t, in.Secret)
+			return in, nil
+		}
+
+		require.NoError(t, srv.ensureAppWebhook(context.Background()))
+		mockStore.AssertCalls(t, mock.CreateWebhook, 1)
+
+		mockStore.OnListWebhooks = func(context.Context, tidal.ListFilter) (tidal.Cursor[*models.Webhook], error) {
+			return mock.NewCursor(&models.Webhook{URL: "https://app.example.com/sync", Secret: "generated"}), nil
+		}
+		require.NoError(t, srv.ensureAppWebhook(context.Background()))
+		mockStore.AssertCalls(t, mock.CreateWebhook, 1)
+		mockStore.AssertCalls(t, mock.UpdateWebhookSecret, 0)
+	})
+
+	t.Run("NotConfigured", func(t *testing.T) {
+		mockStore, srv := setup(t)
+		srv.conf.App.WebhookURI = ""
+		require.NoError(t, srv.ensureAppWebhook(context.Background()))
+		mockStore.AssertCalls(t, mock.ListWebhooks, 0)
+	})
+
+	t.Run("ReadOnly", func(t *testing.T) {
+		mockStore, srv := setup(t)
+		srv.conf.Database.ReadOnly = true
+		require.NoError(t, srv.ensureAppWebhook(context.Background()))
+		mockStore.AssertCalls(t, mock.ListWebhooks, 0)
+		mockStore.AssertCalls(t, mock.CreateWebhook, 0)
+	})
+}
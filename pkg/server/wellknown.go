@@ -43,7 +43,6 @@ func (s *Server) JWKS(c *gin.Context) {
 // Returns a JSON document with the OpenID configuration as defined by the OpenID
 // Connect standard" https://connect2id.com/learn/openid-connect. This document helps
 // clients understand how to authenticate with Quarterdeck.
-// TODO: once OpenID endpoints have been configured add them to this JSON response
 func (s *Server) OpenIDConfiguration(c *gin.Context) {
 	// Parse the token issuer for the OpenID configuration
 	base, err := url.Parse(s.conf.Auth.Issuer)
@@ -55,18 +54,21 @@ func (s *Server) OpenIDConfiguration(c *gin.Context) {
 
 	openid := &api.OpenIDConfiguration{
 		Issuer:                        base.String(),
-		AuthorizationEP:               base.ResolveReference(&url.URL{Path: "/login"}).String(),
-		TokenEP:                       base.ResolveReference(&url.URL{Path: "/v1/reauthenticate"}).String(),
+		AuthorizationEP:               base.ResolveReference(&url.URL{Path: "/oauth/authorize"}).String(),
+		TokenEP:                       base.ResolveReference(&url.URL{Path: "/oauth/token"}).String(),
 		JWKSURI:                       base.ResolveReference(&url.URL{Path: "/.well-known/jwks.json"}).String(),
 		UserInfoEP:                    base.ResolveReference(&url.URL{Path: "/v1/oidc/userinfo"}).String(),
+		RevocationEP:                  base.ResolveReference(&url.URL{Path: "/oauth/revoke"}).String(),
+		IntrospectionEP:               base.ResolveReference(&url.URL{Path: "/oauth/introspect"}).String(),
 		ScopesSupported:               []string{"openid", "profile", "email"},
-		ResponseTypesSupported:        []string{"token", "id_token"},
+		ResponseTypesSupported:        []string{"code"},
 		CodeChallengeMethodsSupported: []string{"S256", "plain"},
-		ResponseModesSupported:        []string{"query", "fragment", "form_post"},
+		ResponseModesSupported:        []string{"query"},
+		GrantTypesSupported:           []string{api.GrantTypeAuthorizationCode, api.GrantTypeClientCredentials},
 		SubjectTypesSupported:         []string{"public"},
-		IDTokenSigningAlgValues:       []string{"HS256", "RS256", "EdDSA"},
+		IDTokenSigningAlgValues:       []string{"EdDSA"},
 		TokenEndpointAuthMethods:      []string{"client_secret_basic", "client_secret_post"},
-		ClaimsSupported:               []string{"aud", "email", "exp", "iat", "iss", "sub"},
+		ClaimsSupported:               []string{"aud", "at_hash", "auth_time", "email", "email_verified", "exp", "iat", "iss", "name", "nonce", "sub"},
 		RequestURIParameterSupported:  false,
 	}
 
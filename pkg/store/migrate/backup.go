@@ -0,0 +1,237 @@
+package migrate
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	v1dsn "go.rtnl.ai/quarterdeck/pkg/store/v1/dsn"
+	"go.rtnl.ai/x/dsn"
+)
+
+// Backup writes a consistent snapshot of the database to the file at out while the
+// database is online. SQLite databases are copied with VACUUM INTO so the snapshot is
+// itself a sqlite database; Postgres databases are dumped with pg_dump in the custom
+// archive format, which must be on the PATH. The backup file must not already exist.
+func Backup(ctx context.Context, db *DB, out string) (err error) {
+	if _, err = os.Stat(out); err == nil {
+		return errors.Fmt("backup file %s already exists", out)
+	}
+
+	switch db.provider {
+	case dsn.SQLite3:
+		if db.v1 != nil {
+			return db.v1.Backup(ctx, out)
+		}
+
+		if _, err = db.v2.DB.ExecContext(ctx, "VACUUM INTO ?", out); err != nil {
+			return errors.Fmt("could not backup sqlite database: %w", err)
+		}
+		return nil
+	case dsn.Postgres:
+		uri, env, err := pgpassword(db.uri)
+		if err != nil {
+			return err
+		}
+		return pgexec(ctx, env, "pg_dump", "--format=custom", "--no-owner", "--file", out, "--dbname", uri)
+	default:
+		return errors.UnhandledProvider(db.provider)
+	}
+}
+
+// Restore replaces the contents of the database at uri with a backup created by
+// Backup. The backup is validated before the database is modified. SQLite databases
+// are replaced by renaming a copy of the backup over the database file, so the
+// database must not be in use while it is restored. Postgres backups are restored with
+// pg_restore in a single transaction, dropping the existing objects first.
+//
+// After the backup has been restored the database is opened so that any migrations
+// added since the backup was created are applied.
+func Restore(ctx context.Context, uri string, version Version, in string) (err error) {
+	var provider, path string
+	if provider, path, err = parseURI(uri, version); err != nil {
+		return err
+	}
+
+	switch provider {
+	case dsn.SQLite3:
+		if err = restoreSQLite(ctx, path, in); err != nil {
+			return err
+		}
+	case dsn.Postgres:
+		if err = pgexec(ctx, nil, "pg_restore", "--list", in); err != nil {
+			return errors.Join(errors.ErrInvalidBackup, err)
+		}
+
+		var (
+			conn string
+			env  []string
+		)
+
+		if conn, env, err = pgpassword(uri); err != nil {
+			return err
+		}
+
+		if err = pgexec(ctx, env, "pg_restore", "--clean", "--if-exists", "--no-owner", "--single-transaction", "--dbname", conn, in); err != nil {
+			return err
+		}
+	default:
+		return errors.UnhandledProvider(provider)
+	}
+
+	var db *DB
+	if db, err = OpenDB(uri, version, false); err != nil {
+		return err
+	}
+	return db.Close()
+}
+
+func restoreSQLite(ctx context.Context, path, in string) (err error) {
+	if err = checkSQLite(ctx, in); err != nil {
+		return err
+	}
+
+	// Copy the backup next to the database so that it can be renamed over the database
+	// atomically; the backup itself is left untouched so it can be restored again.
+	var tmp *os.File
+	if tmp, err = os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".restore-*"); err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	var src *os.File
+	if src, err = os.Open(in); err != nil {
+		tmp.Close()
+		return err
+	}
+	defer src.Close()
+
+	if _, err = io.Copy(tmp, src); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	// The write-ahead log and shared memory files belong to the database being
+	// replaced and would corrupt the restored database if they were left behind.
+	for _, suffix := range []string{"-wal", "-shm", "-journal"} {
+		if err = os.Remove(path + suffix); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// Checks that the file is an intact sqlite database that contains the quarterdeck tables.
+func checkSQLite(ctx context.Context, path string) (err error) {
+	if _, err = os.Stat(path); err != nil {
+		return errors.Join(errors.ErrSourceNotFound, err)
+	}
+
+	var conn *sql.DB
+	if conn, err = sql.Open("sqlite3", "file:"+path+"?mode=ro"); err != nil {
+		return errors.Join(errors.ErrInvalidBackup, err)
+	}
+	defer conn.Close()
+
+	var result string
+	if err = conn.QueryRowContext(ctx, "PRAGMA integrity_check").Scan(&result); err != nil {
+		return errors.Join(errors.ErrInvalidBackup, err)
+	}
+
+	if result != "ok" {
+		return errors.Fmt("%w: %s", errors.ErrInvalidBackup, result)
+	}
+
+	for _, name := range Tables() {
+		var count int
+		if err = conn.QueryRowContext(ctx, "SELECT count(*) FROM sqlite_master WHERE type='table' AND name=?", name).Scan(&count); err != nil {
+			return errors.Join(errors.ErrInvalidBackup, err)
+		}
+
+		if count == 0 {
+			return errors.Fmt("%w: missing table %s", errors.ErrInvalidBackup, name)
+		}
+	}
+	return nil
+}
+
+// Returns the provider and the path of sqlite databases from a v1 or v2 dsn.
+func parseURI(uri string, version Version) (provider, path string, err error) {
+	if version == V1 {
+		var conf *v1dsn.DSN
+		if conf, err = v1dsn.Parse(uri); err != nil {
+			return "", "", err
+		}
+
+		if conf.Scheme != v1dsn.SQLite && conf.Scheme != v1dsn.SQLite3 {
+			return "", "", errors.ErrUnknownScheme
+		}
+		return dsn.SQLite3, conf.Path, nil
+	}
+
+	var conf *dsn.DSN
+	if conf, err = dsn.Parse(uri); err != nil {
+		return "", "", errors.Join(errors.ErrDSNParse, err)
+	}
+	return conf.Provider, conf.Path, nil
+}
+
+// Removes the password from a postgres connection URI so that it is not visible in the
+// arguments of the client tools to other users of the host; the password is returned as
+// a PGPASSWORD environment variable for the tool instead.
+func pgpassword(uri string) (_ string, env []string, err error) {
+	var u *url.URL
+	if u, err = url.Parse(uri); err != nil {
+		return "", nil, errors.Join(errors.ErrDSNParse, err)
+	}
+
+	var password string
+	if u.User != nil {
+		var ok bool
+		if password, ok = u.User.Password(); ok {
+			u.User = url.User(u.User.Username())
+		}
+	}
+
+	if query := u.Query(); query.Has("password") {
+		password = query.Get("password")
+		query.Del("password")
+		u.RawQuery = query.Encode()
+	}
+
+	if password == "" {
+		return uri, nil, nil
+	}
+	return u.String(), []string{"PGPASSWORD=" + password}, nil
+}
+
+// Runs a postgres client tool with the additional environment variables, including its
+// output in the error if it fails.
+func pgexec(ctx context.Context, env []string, name string, args ...string) error {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stderr = &stderr
+
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+
+	if err := cmd.Run(); err != nil {
+		if msg := bytes.TrimSpace(stderr.Bytes()); len(msg) > 0 {
+			return errors.Fmt("%s failed: %w: %s", name, err, msg)
+		}
+		return errors.Fmt("%s failed: %w", name, err)
+	}
+	return nil
+}
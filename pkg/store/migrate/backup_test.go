@@ -0,0 +1,56 @@
+package migrate_test
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/migrate"
+)
+
+func TestBackupRestore(t *testing.T) {
+	ctx := context.Background()
+	source := createSource(t)
+	out := filepath.Join(t.TempDir(), "backup.db")
+
+	db, err := migrate.OpenV1(source, false)
+	require.NoError(t, err, "could not open v1 database")
+
+	require.NoError(t, migrate.Backup(ctx, db, out), "could not backup database")
+	require.ErrorContains(t, migrate.Backup(ctx, db, out), "already exists")
+	require.NoError(t, db.Close())
+
+	// Changes made after the backup is taken are reverted by the restore.
+	execSource(t, source, "DELETE FROM vero_tokens")
+	require.NoError(t, migrate.Restore(ctx, source, migrate.V1, out), "could not restore backup")
+
+	restored, err := migrate.OpenV1(source, true)
+	require.NoError(t, err, "could not open restored database")
+	defer restored.Close()
+
+	report, err := migrate.Export(ctx, restored, io.Discard, nil)
+	require.NoError(t, err, "could not export restored database")
+	require.Equal(t, 1, tableReport(t, report, "vero_tokens").Expired)
+}
+
+func TestRestoreInvalid(t *testing.T) {
+	ctx := context.Background()
+	target := createTarget(t)
+
+	t.Run("Missing", func(t *testing.T) {
+		err := migrate.Restore(ctx, target, migrate.V2, filepath.Join(t.TempDir(), "missing.db"))
+		require.ErrorIs(t, err, errors.ErrSourceNotFound)
+	})
+
+	t.Run("Corrupt", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "corrupt.db")
+		require.NoError(t, os.WriteFile(path, []byte("not a sqlite database"), 0600))
+
+		err := migrate.Restore(ctx, target, migrate.V2, path)
+		require.ErrorIs(t, err, errors.ErrInvalidBackup)
+	})
+}
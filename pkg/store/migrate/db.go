@@ -0,0 +1,238 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"time"
+
+	"go.rtnl.ai/quarterdeck/pkg/config"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	v1dsn "go.rtnl.ai/quarterdeck/pkg/store/v1/dsn"
+	"go.rtnl.ai/quarterdeck/pkg/store/v1/sqlite"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/backend"
+	"go.rtnl.ai/tidal"
+	"go.rtnl.ai/x/dsn"
+)
+
+// DB is a connection to a v1 or v2 store that the migrated tables can be read from and
+// written to with SQL. The v1 and v2 schemas of the migrated tables are the same, so
+// the same queries are used for both versions.
+type DB struct {
+	v1       *sqlite.Store
+	v2       *backend.Store
+	uri      string
+	provider string
+	path     string
+}
+
+// Version of the store schema that a database uses.
+type Version uint8
+
+const (
+	V1 Version = 1
+	V2 Version = 2
+)
+
+// The v1 sqlite transactions and tidal transactions both implement these interfaces.
+type querier interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// OpenDB connects to a v1 or a v2 database depending on the version.
+func OpenDB(uri string, version Version, readonly bool) (*DB, error) {
+	if version == V1 {
+		return OpenV1(uri, readonly)
+	}
+	return OpenV2(uri, readonly)
+}
+
+// OpenV1 connects to an existing v1 sqlite database. The v1 migrations are applied to
+// the database if it has not been migrated to the latest v1 schema unless the database
+// is opened read-only, in which case it is never written to and an error is returned if
+// the v1 server has not migrated it to the latest schema.
+func OpenV1(uri string, readonly bool) (db *DB, err error) {
+	var conf *v1dsn.DSN
+	if conf, err = v1dsn.Parse(uri); err != nil {
+		return nil, err
+	}
+
+	if conf.Scheme != v1dsn.SQLite && conf.Scheme != v1dsn.SQLite3 {
+		return nil, errors.ErrUnknownScheme
+	}
+
+	// Opening a sqlite database that does not exist creates it, which is never
+	// the intent since v1 databases are only migrated or backed up.
+	if _, err = os.Stat(conf.Path); err != nil {
+		return nil, errors.Join(errors.ErrSourceNotFound, err)
+	}
+
+	db = &DB{uri: uri, provider: dsn.SQLite3, path: conf.Path}
+	if readonly {
+		if db.v1, err = sqlite.OpenReadOnly(conf); err != nil {
+			return nil, err
+		}
+		return db, nil
+	}
+
+	if db.v1, err = sqlite.Open(conf); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// OpenV2 connects to a v2 sqlite or postgres database and applies the v2 migrations.
+func OpenV2(uri string, readonly bool) (db *DB, err error) {
+	var conf *dsn.DSN
+	if conf, err = dsn.Parse(uri); err != nil {
+		return nil, errors.Join(errors.ErrDSNParse, err)
+	}
+
+	if conf.Provider != dsn.SQLite3 && conf.Provider != dsn.Postgres {
+		return nil, errors.UnhandledProvider(conf.Provider)
+	}
+
+	var s store.Store
+	if s, err = store.Open(config.DatabaseConfig{URL: uri, ReadOnly: readonly}); err != nil {
+		return nil, err
+	}
+
+	var ok bool
+	db = &DB{uri: uri, provider: conf.Provider, path: conf.Path}
+	if db.v2, ok = s.(*backend.Store); !ok {
+		s.Close()
+		return nil, errors.UnhandledProvider(conf.Provider)
+	}
+	return db, nil
+}
+
+// Provider returns dsn.SQLite3 or dsn.Postgres.
+func (db *DB) Provider() string {
+	return db.provider
+}
+
+// Path returns the path to the database file if it is a sqlite database.
+func (db *DB) Path() string {
+	return db.path
+}
+
+// Close the connection to the database.
+func (db *DB) Close() error {
+	if db.v1 != nil {
+		return db.v1.Close()
+	}
+	return db.v2.Close()
+}
+
+// Runs fn in a read-only transaction so that all queries see a consistent snapshot.
+func (db *DB) read(ctx context.Context, fn func(querier) error) error {
+	if db.v2 != nil {
+		return db.v2.DB.WithReadTx(ctx, func(tx tidal.Tx) error {
+			return fn(tx)
+		})
+	}
+
+	tx, err := db.v1.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	return fn(tx)
+}
+
+// Runs fn in a write transaction that is committed if fn does not return an error.
+func (db *DB) write(ctx context.Context, fn func(execer) error) error {
+	if db.v2 != nil {
+		return db.v2.DB.WithTx(ctx, nil, func(tx tidal.Tx) error {
+			return fn(tx)
+		})
+	}
+
+	tx, err := db.v1.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Reads the digest of every row in the table that has not expired by the cutoff keyed
+// by the primary key of the row. If secrets is not nil the secret columns are opened
+// before the digest is computed, otherwise the digest is of the values as stored.
+func (db *DB) digests(ctx context.Context, t *table, cutoff time.Time, secrets SecretCipher) (digests map[string][]byte, err error) {
+	err = db.read(ctx, func(q querier) error {
+		digests, err = readDigests(q, t, cutoff, secrets)
+		return err
+	})
+	return digests, err
+}
+
+func readDigests(q querier, t *table, cutoff time.Time, secrets SecretCipher) (map[string][]byte, error) {
+	rows, err := q.Query(t.selectSQL())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	digests := make(map[string][]byte)
+	for rows.Next() {
+		rec, err := t.scan(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		if rec.Expired(cutoff) {
+			continue
+		}
+
+		if secrets != nil {
+			if err = rec.Open(secrets); err != nil {
+				return nil, err
+			}
+		}
+		digests[rec.Key()] = rec.Digest()
+	}
+	return digests, rows.Err()
+}
+
+// Writes the records to the table, updating any rows with the same primary key.
+func upsert(tx execer, t *table, records []*record) error {
+	query := t.upsertSQL()
+	for _, rec := range records {
+		if _, err := tx.Exec(query, rec.Params()...); err != nil {
+			return errors.Fmt("could not write %s row %s: %w", t.name, rec.Key(), err)
+		}
+	}
+	return nil
+}
+
+// Postgres sequences are not advanced when rows are inserted with an explicit ID, so
+// they are moved past the copied IDs to prevent conflicts when new rows are created.
+func (db *DB) resetSequences(ctx context.Context) error {
+	if db.provider != dsn.Postgres {
+		return nil
+	}
+
+	return db.write(ctx, func(tx execer) error {
+		for _, t := range tables {
+			if !t.serial {
+				continue
+			}
+
+			query := "SELECT setval(pg_get_serial_sequence('" + t.name + "', 'id'), COALESCE(MAX(id), 0) + 1, false) FROM " + t.name
+			if _, err := tx.Exec(query); err != nil {
+				return errors.Fmt("could not reset %s id sequence: %w", t.name, err)
+			}
+		}
+		return nil
+	})
+}
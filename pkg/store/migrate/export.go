@@ -0,0 +1,304 @@
+package migrate
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+)
+
+const (
+	// ExportFormat identifies a quarterdeck export in the header of the export.
+	ExportFormat = "quarterdeck.export"
+
+	// ExportVersion is incremented when the tables or columns in an export change so
+	// that older exports can be identified when they are imported.
+	ExportVersion = 1
+)
+
+// ExportHeader is the first line of an export and describes the rows that follow it.
+type ExportHeader struct {
+	Format  string    `json:"format"`
+	Version int       `json:"version"`
+	Created time.Time `json:"created"`
+	Tables  []string  `json:"tables"`
+}
+
+// ExportRow is a single row of a table in an export. The row is a JSON object keyed
+// by column name; passwords and client secrets are exported as the hashes that are
+// stored in the database and TOTP secrets are exported sealed by the key encryption key
+// of the v2 server, so the export can only be imported where that key is configured.
+type ExportRow struct {
+	Table string                     `json:"table"`
+	Row   map[string]json.RawMessage `json:"row"`
+}
+
+//===========================================================================
+// Export
+//===========================================================================
+
+// Export writes every row of the migrated tables in the database to w as newline
+// delimited JSON, starting with an ExportHeader. Expired vero tokens are not exported.
+// The database can be either a v1 or a v2 store; the plain text TOTP secrets of a v1
+// store are sealed with secrets, which is required if any user has a TOTP secret.
+func Export(ctx context.Context, db *DB, w io.Writer, secrets SecretCipher) (report *Report, err error) {
+	now := time.Now()
+	out := bufio.NewWriter(w)
+	encoder := json.NewEncoder(out)
+
+	header := &ExportHeader{Format: ExportFormat, Version: ExportVersion, Created: now.UTC(), Tables: Tables()}
+	if err = encoder.Encode(header); err != nil {
+		return nil, err
+	}
+
+	report = &Report{Exported: true, Tables: make([]*TableReport, 0, len(tables))}
+	err = db.read(ctx, func(q querier) error {
+		for i := range tables {
+			tr, err := exportTable(q, &tables[i], encoder, now, db.v1 != nil, secrets)
+			if err != nil {
+				return errors.Fmt("could not export %s: %w", tables[i].name, err)
+			}
+			report.Tables = append(report.Tables, tr)
+		}
+		return nil
+	})
+
+	if err != nil {
+		return report, err
+	}
+	return report, out.Flush()
+}
+
+func exportTable(q querier, t *table, encoder *json.Encoder, cutoff time.Time, seal bool, secrets SecretCipher) (report *TableReport, err error) {
+	report = &TableReport{Table: t.name}
+
+	rows, err := q.Query(t.selectSQL())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rec *record
+		if rec, err = t.scan(rows); err != nil {
+			return nil, err
+		}
+
+		if rec.Expired(cutoff) {
+			report.Expired++
+			continue
+		}
+
+		if seal {
+			if err = rec.Seal(secrets); err != nil {
+				return nil, errors.Fmt("could not seal secrets of row %s: %w", rec.Key(), err)
+			}
+		}
+
+		row := ExportRow{Table: t.name, Row: make(map[string]json.RawMessage, len(t.columns))}
+		for i, c := range t.columns {
+			if row.Row[c.name], err = json.Marshal(c.kind.encode(rec.values[i])); err != nil {
+				return nil, errors.Fmt("could not encode %s column of row %s: %w", c.name, rec.Key(), err)
+			}
+		}
+
+		if err = encoder.Encode(row); err != nil {
+			return nil, err
+		}
+		report.Source++
+	}
+
+	return report, rows.Err()
+}
+
+//===========================================================================
+// Import
+//===========================================================================
+
+// Import reads an export from r and writes the rows to the database, inserting rows
+// that are missing and updating rows with the same primary key. The export is read
+// completely and every foreign key is checked against the rows in the export and in
+// the database before anything is written; all rows are then written in a single
+// transaction. In dry-run mode the export is validated but nothing is written. The
+// sealed TOTP secrets of the export are opened with secrets if the database is a v1
+// store, which keeps them in plain text.
+func Import(ctx context.Context, db *DB, r io.Reader, dryRun bool, secrets SecretCipher) (report *Report, err error) {
+	var records map[*table][]*record
+	if records, err = readExport(r); err != nil {
+		return nil, err
+	}
+
+	if db.v1 != nil {
+		for t, recs := range records {
+			for _, rec := range recs {
+				if err = rec.Open(secrets); err != nil {
+					return nil, errors.Fmt("could not open secrets of %s row %s: %w", t.name, rec.Key(), err)
+				}
+			}
+		}
+	}
+
+	// Read the keys and digests of the rows that are already in the database to check
+	// references and to determine which rows need to be written.
+	existing := make(map[*table]map[string][]byte, len(tables))
+	for i := range tables {
+		t := &tables[i]
+		if existing[t], err = db.digests(ctx, t, time.Time{}, nil); err != nil {
+			return nil, errors.Fmt("could not read %s: %w", t.name, err)
+		}
+	}
+
+	if err = checkReferences(records, existing); err != nil {
+		return nil, err
+	}
+
+	report = &Report{DryRun: dryRun, Tables: make([]*TableReport, 0, len(tables))}
+	changed := make(map[*table][]*record, len(tables))
+
+	for i := range tables {
+		t := &tables[i]
+		tr := &TableReport{Table: t.name, Source: len(records[t])}
+
+		for _, rec := range records[t] {
+			digest, ok := existing[t][rec.Key()]
+			switch {
+			case !ok:
+				tr.Inserted++
+			case !bytes.Equal(digest, rec.Digest()):
+				tr.Updated++
+			default:
+				tr.Unchanged++
+				continue
+			}
+			changed[t] = append(changed[t], rec)
+		}
+		report.Tables = append(report.Tables, tr)
+	}
+
+	if dryRun {
+		return report, nil
+	}
+
+	err = db.write(ctx, func(tx execer) error {
+		for i := range tables {
+			if err := upsert(tx, &tables[i], changed[&tables[i]]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		return report, err
+	}
+	return report, db.resetSequences(ctx)
+}
+
+// Reads and validates the header and all of the rows of an export.
+func readExport(r io.Reader) (records map[*table][]*record, err error) {
+	decoder := json.NewDecoder(bufio.NewReader(r))
+
+	header := &ExportHeader{}
+	if err = decoder.Decode(header); err != nil {
+		return nil, errors.Join(errors.ErrExportFormat, err)
+	}
+
+	if header.Format != ExportFormat || header.Version != ExportVersion {
+		return nil, errors.Fmt("%w: %s version %d", errors.ErrExportFormat, header.Format, header.Version)
+	}
+
+	records = make(map[*table][]*record, len(tables))
+	keys := make(map[*table]map[string]struct{}, len(tables))
+
+	for line := 2; ; line++ {
+		row := ExportRow{}
+		if err = decoder.Decode(&row); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, errors.Fmt("%w: line %d: %w", errors.ErrExportFormat, line, err)
+		}
+
+		var rec *record
+		if rec, err = parseRow(row); err != nil {
+			return nil, errors.Fmt("%w: line %d: %w", errors.ErrExportFormat, line, err)
+		}
+
+		if keys[rec.table] == nil {
+			keys[rec.table] = make(map[string]struct{})
+		}
+
+		if _, ok := keys[rec.table][rec.Key()]; ok {
+			return nil, errors.Fmt("%w: line %d: duplicate %s row %s", errors.ErrExportFormat, line, row.Table, rec.Key())
+		}
+
+		keys[rec.table][rec.Key()] = struct{}{}
+		records[rec.table] = append(records[rec.table], rec)
+	}
+
+	return records, nil
+}
+
+// Parses a row of an export, which must have exactly the columns of the table.
+func parseRow(row ExportRow) (rec *record, err error) {
+	t := lookupTable(row.Table)
+	if t == nil {
+		return nil, errors.Fmt("unknown table %q", row.Table)
+	}
+
+	if len(row.Row) != len(t.columns) {
+		return nil, errors.Fmt("%s row has %d columns, expected %d", t.name, len(row.Row), len(t.columns))
+	}
+
+	rec = t.newRecord()
+	for i, c := range t.columns {
+		data, ok := row.Row[c.name]
+		if !ok {
+			return nil, errors.Fmt("%s row is missing the %s column", t.name, c.name)
+		}
+
+		if err = c.kind.decode(data, rec.values[i]); err != nil {
+			return nil, errors.Fmt("could not parse %s column %s: %w", t.name, c.name, err)
+		}
+	}
+	return rec, nil
+}
+
+// Checks that every foreign key refers to a row in the export or in the database.
+func checkReferences(records map[*table][]*record, existing map[*table]map[string][]byte) error {
+	exported := make(map[*table]map[string]struct{}, len(records))
+	for t, recs := range records {
+		exported[t] = make(map[string]struct{}, len(recs))
+		for _, rec := range recs {
+			exported[t][rec.Key()] = struct{}{}
+		}
+	}
+
+	for i := range tables {
+		t := &tables[i]
+		for _, rec := range records[t] {
+			for _, ref := range t.references {
+				key := rec.Canonical(ref.column)
+				if key == null {
+					continue
+				}
+
+				target := lookupTable(ref.table)
+				if _, ok := exported[target][key]; ok {
+					continue
+				}
+
+				if _, ok := existing[target][key]; ok {
+					continue
+				}
+
+				return errors.Fmt("%w: %s row %s refers to %s %s", errors.ErrReferentialIntegrity, t.name, rec.Key(), ref.table, key)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package migrate_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/migrate"
+)
+
+func TestExportImport(t *testing.T) {
+	ctx := context.Background()
+
+	// Export the v1 fixtures so that the export can be imported into a v2 database.
+	source, err := migrate.OpenV1(createSource(t), true)
+	require.NoError(t, err, "could not open v1 source")
+	defer source.Close()
+
+	export := &bytes.Buffer{}
+	report, err := migrate.Export(ctx, source, export, nil)
+	require.NoError(t, err, "could not export v1 source")
+	require.True(t, report.Exported)
+	require.Len(t, report.Tables, len(migrate.Tables()))
+	require.Greater(t, tableReport(t, report, "users").Source, 0)
+
+	header := &migrate.ExportHeader{}
+	line, err := bufio.NewReader(bytes.NewReader(export.Bytes())).ReadBytes('\n')
+	require.NoError(t, err, "could not read export header")
+	require.NoError(t, json.Unmarshal(line, header), "could not parse export header")
+	require.Equal(t, migrate.ExportFormat, header.Format)
+	require.Equal(t, migrate.ExportVersion, header.Version)
+	require.Equal(t, migrate.Tables(), header.Tables)
+
+	target, err := migrate.OpenV2(createTarget(t), false)
+	require.NoError(t, err, "could not open v2 target")
+	defer target.Close()
+
+	t.Run("DryRun", func(t *testing.T) {
+		report, err := migrate.Import(ctx, target, bytes.NewReader(export.Bytes()), true, nil)
+		require.NoError(t, err, "could not import in dry run mode")
+		require.True(t, report.DryRun)
+
+		for _, table := range report.Tables {
+			require.Equal(t, table.Source, table.Inserted, "expected all %s rows to be inserted", table.Table)
+		}
+	})
+
+	t.Run("Import", func(t *testing.T) {
+		report, err := migrate.Import(ctx, target, bytes.NewReader(export.Bytes()), false, nil)
+		require.NoError(t, err, "could not import export")
+		require.Greater(t, tableReport(t, report, "users").Inserted, 0)
+
+		// The exports of the source and target should be identical other than the header.
+		reexport := &bytes.Buffer{}
+		_, err = migrate.Export(ctx, target, reexport, nil)
+		require.NoError(t, err, "could not export v2 target")
+		require.Equal(t, rows(export), rows(reexport))
+	})
+
+	t.Run("Idempotent", func(t *testing.T) {
+		report, err := migrate.Import(ctx, target, bytes.NewReader(export.Bytes()), false, nil)
+		require.NoError(t, err, "could not reimport export")
+
+		for _, table := range report.Tables {
+			require.Equal(t, table.Source, table.Unchanged, "expected all %s rows to be unchanged", table.Table)
+		}
+	})
+}
+
+func TestExportSecrets(t *testing.T) {
+	ctx := context.Background()
+	path := createSource(t)
+	execSource(t, path, "UPDATE users SET totp_secret='JBSWY3DPEHPK3PXP' WHERE email='admin@example.com'")
+	secrets := secretCipher(t)
+
+	source, err := migrate.OpenV1(path, true)
+	require.NoError(t, err, "could not open v1 source")
+	defer source.Close()
+
+	t.Run("NoCipher", func(t *testing.T) {
+		_, err := migrate.Export(ctx, source, io.Discard, nil)
+		require.ErrorIs(t, err, errors.ErrNoKeyEncryptionKey)
+	})
+
+	// The plain text secrets of the v1 store are sealed when they are exported.
+	export := &bytes.Buffer{}
+	_, err = migrate.Export(ctx, source, export, secrets)
+	require.NoError(t, err, "could not export v1 source")
+	require.NotContains(t, export.String(), "JBSWY3DPEHPK3PXP", "expected the secret to be sealed")
+
+	t.Run("V2", func(t *testing.T) {
+		target, err := migrate.OpenV2(createTarget(t), false)
+		require.NoError(t, err, "could not open v2 target")
+		defer target.Close()
+
+		_, err = migrate.Import(ctx, target, bytes.NewReader(export.Bytes()), false, nil)
+		require.NoError(t, err, "could not import export")
+
+		// The v2 store keeps the sealed secrets so they are exported unchanged.
+		reexport := &bytes.Buffer{}
+		_, err = migrate.Export(ctx, target, reexport, nil)
+		require.NoError(t, err, "could not export v2 target")
+		require.Equal(t, rows(export), rows(reexport))
+	})
+
+	t.Run("V1", func(t *testing.T) {
+		target, err := migrate.OpenV1(createSource(t), false)
+		require.NoError(t, err, "could not open v1 target")
+		defer target.Close()
+
+		_, err = migrate.Import(ctx, target, bytes.NewReader(export.Bytes()), false, nil)
+		require.ErrorIs(t, err, errors.ErrNoKeyEncryptionKey)
+
+		report, err := migrate.Import(ctx, target, bytes.NewReader(export.Bytes()), false, secrets)
+		require.NoError(t, err, "could not import export")
+		require.Equal(t, 1, tableReport(t, report, "users").Updated)
+
+		// The secret is stored in plain text so it matches the opened secret of the export.
+		report, err = migrate.Import(ctx, target, bytes.NewReader(export.Bytes()), false, secrets)
+		require.NoError(t, err, "could not reimport export")
+		require.Zero(t, tableReport(t, report, "users").Updated)
+	})
+}
+
+func TestImportReferences(t *testing.T) {
+	ctx := context.Background()
+
+	source, err := migrate.OpenV1(createSource(t), true)
+	require.NoError(t, err, "could not open v1 source")
+	defer source.Close()
+
+	export := &bytes.Buffer{}
+	_, err = migrate.Export(ctx, source, export, nil)
+	require.NoError(t, err, "could not export v1 source")
+
+	// Remove the users from the export so that the api keys refer to missing users.
+	filtered := &bytes.Buffer{}
+	for _, line := range strings.SplitAfter(export.String(), "\n") {
+		if !strings.HasPrefix(line, `{"table":"users",`) {
+			filtered.WriteString(line)
+		}
+	}
+
+	target, err := migrate.OpenV2(createTarget(t), false)
+	require.NoError(t, err, "could not open v2 target")
+	defer target.Close()
+
+	_, err = migrate.Import(ctx, target, filtered, false, nil)
+	require.ErrorIs(t, err, errors.ErrReferentialIntegrity)
+}
+
+func TestImportFormat(t *testing.T) {
+	target, err := migrate.OpenV2(createTarget(t), false)
+	require.NoError(t, err, "could not open v2 target")
+	defer target.Close()
+
+	tests := []string{
+		"",
+		`{"format":"other","version":1}`,
+		`{"format":"quarterdeck.export","version":99}`,
+		`{"format":"quarterdeck.export","version":1}` + "\n" + `{"table":"unknown","row":{}}`,
+		`{"format":"quarterdeck.export","version":1}` + "\n" + `{"table":"roles","row":{"id":1}}`,
+	}
+
+	for i, tc := range tests {
+		_, err := migrate.Import(context.Background(), target, strings.NewReader(tc), false, nil)
+		require.ErrorIs(t, err, errors.ErrExportFormat, "test case %d failed", i)
+	}
+}
+
+// Returns the rows of an export without the header.
+func rows(export *bytes.Buffer) []string {
+	lines := strings.Split(strings.TrimSpace(export.String()), "\n")
+	return lines[1:]
+}
@@ -0,0 +1,260 @@
+/*
+Package migrate copies the identity data of a v1 SQLite store into a v2 store so that
+an existing deployment can be moved to the v2 schema while the v1 server is online.
+
+Rows are copied with their original IDs and timestamps and are written with an upsert
+by primary key, so the migration can be stopped at any point and run again to resume
+or to pick up changes made to the v1 database since the last run. Rows are never
+deleted from the target; rows deleted from the v1 database after they were copied are
+reported as a mismatch when the migration is verified. Each table is
+verified by comparing the row count and a checksum of the canonical row values of
+the source and target databases. TOTP secrets, which the v1 store keeps in plain text,
+are sealed with the key encryption key of the v2 server as they are copied.
+
+The same table definitions are used to export the identity data of either a v1 or a v2
+store as versioned, newline delimited JSON and to import it into another store, and the
+package also takes and restores online backups of the whole database.
+*/
+package migrate
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"time"
+
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/ulid"
+)
+
+// DefaultBatchSize is the number of rows written to the target in each transaction.
+const DefaultBatchSize = 500
+
+// Options configure how the migration is run.
+type Options struct {
+	DryRun    bool         // report the rows that would be copied without writing them
+	BatchSize int          // number of rows written per transaction, DefaultBatchSize if zero
+	Cutoff    time.Time    // vero tokens that expire before the cutoff are not copied, now if zero
+	Secrets   SecretCipher // seals secrets such as TOTP secrets that are plain text in v1
+}
+
+// SecretCipher seals the secrets that are stored in plain text in the v1 store before
+// they are written to the v2 store; it is implemented by auth.KeyCipher using the key
+// encryption key of the v2 server.
+type SecretCipher interface {
+	SealSecret(id ulid.ULID, secret string) (string, error)
+	OpenSecret(id ulid.ULID, sealed string) (string, error)
+}
+
+// Migrator copies rows from a v1 SQLite store into a v2 SQLite or Postgres store.
+type Migrator struct {
+	source *DB
+	target *DB
+	opts   Options
+}
+
+// Open the v1 source and the v2 target databases described by the DSNs. The source is
+// opened read-only, must already exist, and must have been migrated to the latest v1
+// schema by the v1 server; the v2 migrations are applied to the target when it is
+// opened so that the schema is ready to receive the copied rows.
+func Open(source, target string, opts Options) (m *Migrator, err error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+
+	if opts.Cutoff.IsZero() {
+		opts.Cutoff = time.Now()
+	}
+
+	m = &Migrator{opts: opts}
+	if m.source, err = OpenV1(source, true); err != nil {
+		return nil, err
+	}
+
+	if m.target, err = OpenV2(target, opts.DryRun); err != nil {
+		m.source.Close()
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// Close the connections to both the source and target databases.
+func (m *Migrator) Close() error {
+	return errors.Join(m.source.Close(), m.target.Close())
+}
+
+//===========================================================================
+// Migration
+//===========================================================================
+
+// Migrate copies every table from the source to the target, inserting rows that are
+// missing from the target and updating rows that have changed since they were copied.
+// In dry-run mode the report describes the changes without writing them.
+func (m *Migrator) Migrate(ctx context.Context) (report *Report, err error) {
+	report = &Report{DryRun: m.opts.DryRun, Tables: make([]*TableReport, 0, len(tables))}
+	err = m.source.read(ctx, func(src querier) (err error) {
+		for i := range tables {
+			var tr *TableReport
+			if tr, err = m.copyTable(ctx, src, &tables[i]); err != nil {
+				return errors.Fmt("could not migrate %s: %w", tables[i].name, err)
+			}
+			report.Tables = append(report.Tables, tr)
+		}
+		return nil
+	})
+
+	if err != nil {
+		return report, err
+	}
+
+	if !m.opts.DryRun {
+		if err = m.target.resetSequences(ctx); err != nil {
+			return report, err
+		}
+	}
+
+	return report, nil
+}
+
+func (m *Migrator) copyTable(ctx context.Context, src querier, t *table) (report *TableReport, err error) {
+	report = &TableReport{Table: t.name}
+
+	// Digests of the rows that have already been copied determine which source rows
+	// need to be written so that the migration resumes where it left off.
+	var copied map[string][]byte
+	if copied, err = m.target.digests(ctx, t, m.opts.Cutoff, m.opts.Secrets); err != nil {
+		return nil, err
+	}
+
+	var rows *sql.Rows
+	if rows, err = src.Query(t.selectSQL()); err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	batch := make([]*record, 0, m.opts.BatchSize)
+	for rows.Next() {
+		var rec *record
+		if rec, err = t.scan(rows); err != nil {
+			return nil, err
+		}
+
+		if rec.Expired(m.opts.Cutoff) {
+			report.Expired++
+			continue
+		}
+
+		report.Source++
+		digest, ok := copied[rec.Key()]
+		switch {
+		case !ok:
+			report.Inserted++
+		case !bytes.Equal(digest, rec.Digest()):
+			report.Updated++
+		default:
+			report.Unchanged++
+			continue
+		}
+
+		if m.opts.DryRun {
+			continue
+		}
+
+		if err = rec.Seal(m.opts.Secrets); err != nil {
+			return nil, errors.Fmt("could not seal %s row %s: %w", t.name, rec.Key(), err)
+		}
+
+		if batch = append(batch, rec); len(batch) >= m.opts.BatchSize {
+			if err = m.write(ctx, t, batch); err != nil {
+				return nil, err
+			}
+			batch = batch[:0]
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(batch) > 0 {
+		if err = m.write(ctx, t, batch); err != nil {
+			return nil, err
+		}
+	}
+
+	return report, nil
+}
+
+// Writes a batch of records to the target in a single transaction.
+func (m *Migrator) write(ctx context.Context, t *table, batch []*record) error {
+	return m.target.write(ctx, func(tx execer) error {
+		return upsert(tx, t, batch)
+	})
+}
+
+//===========================================================================
+// Verification
+//===========================================================================
+
+// Verify compares the row count and checksum of each table in the source and target.
+// The report is returned with ErrVerifyMismatch if any of the tables do not match.
+func (m *Migrator) Verify(ctx context.Context) (report *Report, err error) {
+	mismatched := make([]string, 0)
+	report = &Report{Verified: true, Tables: make([]*TableReport, 0, len(tables))}
+
+	err = m.source.read(ctx, func(src querier) (err error) {
+		for i := range tables {
+			t := &tables[i]
+			tr := &TableReport{Table: t.name}
+
+			var source, target map[string][]byte
+			if source, err = readDigests(src, t, m.opts.Cutoff, nil); err != nil {
+				return errors.Fmt("could not read %s from source: %w", t.name, err)
+			}
+
+			if target, err = m.target.digests(ctx, t, m.opts.Cutoff, m.opts.Secrets); err != nil {
+				return errors.Fmt("could not read %s from target: %w", t.name, err)
+			}
+
+			tr.Source, tr.SourceChecksum = len(source), checksum(source)
+			tr.Target, tr.TargetChecksum = len(target), checksum(target)
+			if !tr.Match() {
+				mismatched = append(mismatched, t.name)
+			}
+
+			report.Tables = append(report.Tables, tr)
+		}
+		return nil
+	})
+
+	if err != nil {
+		return report, err
+	}
+
+	if len(mismatched) > 0 {
+		return report, errors.Fmt("%w: %s", errors.ErrVerifyMismatch, strings.Join(mismatched, ", "))
+	}
+	return report, nil
+}
+
+// Checksum of a table is computed from the row digests ordered by primary key so that
+// it does not depend on the order rows are returned by the database.
+func checksum(digests map[string][]byte) string {
+	keys := make([]string, 0, len(digests))
+	for key := range digests {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	hash := sha256.New()
+	for _, key := range keys {
+		hash.Write([]byte(key))
+		hash.Write(digests[key])
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,299 @@
+package migrate_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/quarterdeck/pkg/auth"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/migrate"
+	"go.rtnl.ai/quarterdeck/pkg/store/v1/dsn"
+	"go.rtnl.ai/quarterdeck/pkg/store/v1/sqlite"
+	"go.rtnl.ai/ulid"
+)
+
+func TestMigrate(t *testing.T) {
+	ctx := context.Background()
+	source, target := createSource(t), createTarget(t)
+
+	// The v1 fixtures do not have passkeys or audit events.
+	execSource(t, source, "INSERT INTO webauthn_credentials (id, user_id, name, credential_id, public_key, attestation_type, aaguid, sign_count, transports, flags, last_used, created, modified) VALUES (x'019c1001000000000000000000000001', x'019545eb8b6e4c28bc6d4c684b20e9fd', 'YubiKey', x'a1b2c3d4e5f6', x'0102030405060708', 'none', NULL, 4, '[\"usb\", \"nfc\"]', 29, '2025-07-06T12:00:00Z', '2025-07-01T12:00:00Z', '2025-07-06T12:00:00Z')")
+	execSource(t, source, "INSERT INTO audit_events (id, actor_type, actor_id, action, subject_type, subject_id, client_ip, user_agent, request_id, diff, created, modified) VALUES (x'019c1002000000000000000000000002', 'user', x'019545eb8b6e4c28bc6d4c684b20e9fd', 'update', 'user', '01JPYRNYMEHNEZCS0JYX1CP57A', '192.0.2.1', 'curl/8.7.1', 'req-1', '{\"name\": [\"Gary\", \"Gary Redfield\"]}', '2025-07-06T12:00:00Z', '2025-07-06T12:00:00Z')")
+
+	t.Run("DryRun", func(t *testing.T) {
+		m, err := migrate.Open(source, target, migrate.Options{DryRun: true})
+		require.NoError(t, err, "could not open migrator in dry run mode")
+		defer m.Close()
+
+		report, err := m.Migrate(ctx)
+		require.NoError(t, err, "could not run dry run migration")
+		require.True(t, report.DryRun)
+		require.Len(t, report.Tables, len(migrate.Tables()))
+
+		for _, table := range report.Tables {
+			require.Equal(t, table.Source, table.Inserted, "expected all %s rows to be inserted", table.Table)
+			require.Zero(t, table.Updated)
+			require.Zero(t, table.Unchanged)
+		}
+
+		// Nothing should have been written to the target.
+		_, err = m.Verify(ctx)
+		require.ErrorIs(t, err, errors.ErrVerifyMismatch)
+	})
+
+	t.Run("Copy", func(t *testing.T) {
+		m, err := migrate.Open(source, target, migrate.Options{BatchSize: 2})
+		require.NoError(t, err, "could not open migrator")
+		defer m.Close()
+
+		report, err := m.Migrate(ctx)
+		require.NoError(t, err, "could not migrate source to target")
+		require.Greater(t, tableReport(t, report, "users").Inserted, 0)
+		require.Greater(t, tableReport(t, report, "api_keys").Inserted, 0)
+		require.Greater(t, tableReport(t, report, "oidc_clients").Inserted, 0)
+		require.Equal(t, 1, tableReport(t, report, "webauthn_credentials").Inserted)
+		require.Equal(t, 2, tableReport(t, report, "webhooks").Inserted)
+		require.Equal(t, 1, tableReport(t, report, "audit_events").Inserted)
+
+		report, err = m.Verify(ctx)
+		require.NoError(t, err, "expected target to match the source")
+		require.True(t, report.Verified)
+
+		for _, table := range report.Tables {
+			require.True(t, table.Match(), "expected %s to match", table.Table)
+			require.NotEmpty(t, table.SourceChecksum)
+		}
+	})
+
+	t.Run("Idempotent", func(t *testing.T) {
+		m, err := migrate.Open(source, target, migrate.Options{})
+		require.NoError(t, err, "could not open migrator")
+		defer m.Close()
+
+		report, err := m.Migrate(ctx)
+		require.NoError(t, err, "could not rerun migration")
+
+		for _, table := range report.Tables {
+			require.Equal(t, table.Source, table.Unchanged, "expected all %s rows to be unchanged", table.Table)
+			require.Zero(t, table.Inserted)
+			require.Zero(t, table.Updated)
+		}
+	})
+
+	t.Run("Resume", func(t *testing.T) {
+		// Simulate changes made to the v1 database while it is still online.
+		execSource(t, source, "UPDATE users SET name='Renamed User' WHERE email='admin@example.com'")
+		execSource(t, source, "DELETE FROM user_roles WHERE user_id IN (SELECT id FROM users WHERE email='admin@example.com')")
+
+		m, err := migrate.Open(source, target, migrate.Options{})
+		require.NoError(t, err, "could not open migrator")
+		defer m.Close()
+
+		// Rows deleted from the source are not deleted from the target.
+		_, err = m.Verify(ctx)
+		require.ErrorIs(t, err, errors.ErrVerifyMismatch)
+
+		report, err := m.Migrate(ctx)
+		require.NoError(t, err, "could not resume migration")
+		require.Equal(t, 1, tableReport(t, report, "users").Updated)
+
+		report, err = m.Verify(ctx)
+		require.ErrorIs(t, err, errors.ErrVerifyMismatch)
+		require.True(t, tableReport(t, report, "users").Match())
+		require.False(t, tableReport(t, report, "user_roles").Match())
+	})
+}
+
+func TestMigrateExpired(t *testing.T) {
+	ctx := context.Background()
+	source := createSource(t)
+
+	// The vero token fixture expired on 2024-11-16
+	t.Run("Unexpired", func(t *testing.T) {
+		cutoff := time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)
+		m, err := migrate.Open(source, createTarget(t), migrate.Options{Cutoff: cutoff})
+		require.NoError(t, err, "could not open migrator")
+		defer m.Close()
+
+		report, err := m.Migrate(ctx)
+		require.NoError(t, err, "could not migrate source to target")
+		require.Equal(t, 1, tableReport(t, report, "vero_tokens").Inserted)
+		require.Zero(t, tableReport(t, report, "vero_tokens").Expired)
+
+		_, err = m.Verify(ctx)
+		require.NoError(t, err, "expected target to match the source")
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		m, err := migrate.Open(source, createTarget(t), migrate.Options{})
+		require.NoError(t, err, "could not open migrator")
+		defer m.Close()
+
+		report, err := m.Migrate(ctx)
+		require.NoError(t, err, "could not migrate source to target")
+		require.Zero(t, tableReport(t, report, "vero_tokens").Inserted)
+		require.Equal(t, 1, tableReport(t, report, "vero_tokens").Expired)
+
+		_, err = m.Verify(ctx)
+		require.NoError(t, err, "expected target to match the source")
+	})
+}
+
+func TestMigrateSecrets(t *testing.T) {
+	ctx := context.Background()
+	source, target := createSource(t), createTarget(t)
+	execSource(t, source, "UPDATE users SET totp_secret='JBSWY3DPEHPK3PXP' WHERE email='admin@example.com'")
+
+	secrets := secretCipher(t)
+
+	t.Run("NoCipher", func(t *testing.T) {
+		m, err := migrate.Open(source, createTarget(t), migrate.Options{})
+		require.NoError(t, err, "could not open migrator")
+		defer m.Close()
+
+		_, err = m.Migrate(ctx)
+		require.ErrorIs(t, err, errors.ErrNoKeyEncryptionKey)
+	})
+
+	t.Run("Sealed", func(t *testing.T) {
+		m, err := migrate.Open(source, target, migrate.Options{Secrets: secrets})
+		require.NoError(t, err, "could not open migrator")
+		defer m.Close()
+
+		_, err = m.Migrate(ctx)
+		require.NoError(t, err, "could not migrate source to target")
+
+		_, err = m.Verify(ctx)
+		require.NoError(t, err, "expected the opened secrets to match the source")
+
+		// The secret is sealed with a random nonce but the row is not copied again.
+		report, err := m.Migrate(ctx)
+		require.NoError(t, err, "could not rerun migration")
+		require.Zero(t, tableReport(t, report, "users").Updated)
+
+		// The target stores the sealed secret, which is opened with the user ID.
+		db, err := migrate.OpenV2(target, true)
+		require.NoError(t, err, "could not open v2 target")
+		defer db.Close()
+
+		export := &bytes.Buffer{}
+		_, err = migrate.Export(ctx, db, export, nil)
+		require.NoError(t, err, "could not export v2 target")
+
+		var found bool
+		for _, line := range rows(export) {
+			row := &migrate.ExportRow{}
+			require.NoError(t, json.Unmarshal([]byte(line), row))
+			if row.Table != "users" || string(row.Row["email"]) != `"admin@example.com"` {
+				continue
+			}
+
+			var id, sealed string
+			require.NoError(t, json.Unmarshal(row.Row["id"], &id))
+			require.NoError(t, json.Unmarshal(row.Row["totp_secret"], &sealed))
+			require.NotEqual(t, "JBSWY3DPEHPK3PXP", sealed, "expected the secret to be sealed")
+
+			secret, err := secrets.OpenSecret(ulid.MustParse(id), sealed)
+			require.NoError(t, err, "could not open sealed secret")
+			require.Equal(t, "JBSWY3DPEHPK3PXP", secret)
+			found = true
+		}
+		require.True(t, found, "admin user not found in target")
+	})
+}
+
+func TestOpen(t *testing.T) {
+	source, target := createSource(t), createTarget(t)
+
+	t.Run("MissingSource", func(t *testing.T) {
+		_, err := migrate.Open("sqlite3:///"+filepath.Join(t.TempDir(), "missing.db"), target, migrate.Options{})
+		require.ErrorIs(t, err, errors.ErrSourceNotFound)
+	})
+
+	t.Run("SourceScheme", func(t *testing.T) {
+		_, err := migrate.Open("mock:///", target, migrate.Options{})
+		require.ErrorIs(t, err, errors.ErrUnknownScheme)
+	})
+
+	t.Run("TargetProvider", func(t *testing.T) {
+		_, err := migrate.Open(source, "mock:///", migrate.Options{})
+		require.ErrorAs(t, err, new(errors.UnhandledProvider))
+	})
+
+	t.Run("OutdatedSource", func(t *testing.T) {
+		outdated := createSource(t)
+		execSource(t, outdated, "DELETE FROM migrations WHERE id = (SELECT MAX(id) FROM migrations)")
+
+		// The source is not migrated when it is opened so it is still outdated.
+		for i := 0; i < 2; i++ {
+			_, err := migrate.Open(outdated, target, migrate.Options{})
+			require.ErrorIs(t, err, errors.ErrSchemaOutdated)
+		}
+	})
+}
+
+// Creates a v1 database in a temporary directory loaded with the v1 sqlite fixtures.
+func createSource(t *testing.T) string {
+	uri := "sqlite3:///" + filepath.Join(t.TempDir(), "v1.db")
+
+	paths, err := filepath.Glob("../v1/sqlite/testdata/*.sql")
+	require.NoError(t, err, "could not list v1 fixtures")
+	require.NotEmpty(t, paths, "no v1 fixtures found")
+
+	for _, path := range paths {
+		query, err := os.ReadFile(path)
+		require.NoError(t, err, "could not read fixture %s", path)
+		execSource(t, uri, string(query))
+	}
+
+	return uri
+}
+
+// Returns a cipher that seals secrets with a test key encryption key.
+func secretCipher(t *testing.T) *auth.KeyCipher {
+	kek, err := hex.DecodeString("6a1e4c2f0d8b7a95e3f41c6b2d09a8e7f5c3b1a0d9e8f7c6b5a4938271605f4e")
+	require.NoError(t, err)
+
+	secrets, err := auth.NewKeyCipher(kek)
+	require.NoError(t, err, "could not create secret cipher")
+	return secrets
+}
+
+func createTarget(t *testing.T) string {
+	return "sqlite3:///" + filepath.Join(t.TempDir(), "v2.db")
+}
+
+func execSource(t *testing.T, uri, query string) {
+	conf, err := dsn.Parse(uri)
+	require.NoError(t, err, "could not parse source dsn")
+
+	db, err := sqlite.Open(conf)
+	require.NoError(t, err, "could not open source database")
+	defer db.Close()
+
+	tx, err := db.BeginTx(context.Background(), nil)
+	require.NoError(t, err, "could not open transaction")
+	defer tx.Rollback()
+
+	_, err = tx.Exec(query)
+	require.NoError(t, err, "could not execute query on source")
+	require.NoError(t, tx.Commit(), "could not commit transaction")
+}
+
+func tableReport(t *testing.T, report *migrate.Report, table string) *migrate.TableReport {
+	for _, tr := range report.Tables {
+		if tr.Table == table {
+			return tr
+		}
+	}
+
+	require.Fail(t, "table not found in report", table)
+	return nil
+}
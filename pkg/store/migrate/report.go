@@ -0,0 +1,65 @@
+package migrate
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+// Report describes the rows copied by a migration or compared by a verification.
+type Report struct {
+	DryRun   bool
+	Verified bool
+	Exported bool
+	Tables   []*TableReport
+}
+
+// TableReport describes the result of migrating, verifying, exporting, or importing a
+// single table. Copy counts are only set by Migrate and Import; target counts and
+// checksums are only set by Verify.
+type TableReport struct {
+	Table          string
+	Source         int // number of unexpired rows in the source table or export
+	Inserted       int // rows that were missing from the target
+	Updated        int // rows that differed from the source in the target
+	Unchanged      int // rows that had already been copied to the target
+	Expired        int // rows that were not copied because they have expired
+	Target         int // number of unexpired rows in the target table
+	SourceChecksum string
+	TargetChecksum string
+}
+
+// Match returns true if the row count and checksum of the source and target are equal.
+func (t *TableReport) Match() bool {
+	return t.Source == t.Target && t.SourceChecksum == t.TargetChecksum
+}
+
+// Print the report as a table to the writer.
+func (r *Report) Print(w io.Writer) error {
+	tabs := tabwriter.NewWriter(w, 1, 0, 4, ' ', 0)
+	switch {
+	case r.Verified:
+		fmt.Fprintln(tabs, "TABLE\tSOURCE\tTARGET\tCHECKSUM\tMATCH")
+		for _, t := range r.Tables {
+			fmt.Fprintf(tabs, "%s\t%d\t%d\t%s\t%t\n", t.Table, t.Source, t.Target, short(t.SourceChecksum), t.Match())
+		}
+	case r.Exported:
+		fmt.Fprintln(tabs, "TABLE\tEXPORTED\tEXPIRED")
+		for _, t := range r.Tables {
+			fmt.Fprintf(tabs, "%s\t%d\t%d\n", t.Table, t.Source, t.Expired)
+		}
+	default:
+		fmt.Fprintln(tabs, "TABLE\tSOURCE\tINSERTED\tUPDATED\tUNCHANGED\tEXPIRED")
+		for _, t := range r.Tables {
+			fmt.Fprintf(tabs, "%s\t%d\t%d\t%d\t%d\t%d\n", t.Table, t.Source, t.Inserted, t.Updated, t.Unchanged, t.Expired)
+		}
+	}
+	return tabs.Flush()
+}
+
+func short(checksum string) string {
+	if len(checksum) > 12 {
+		return checksum[:12]
+	}
+	return checksum
+}
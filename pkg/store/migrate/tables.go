@@ -0,0 +1,685 @@
+package migrate
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/ulid"
+)
+
+//===========================================================================
+// Tables
+//===========================================================================
+
+// Tables are copied in this order so that foreign key references always exist in the
+// target database before the rows that refer to them are written.
+var tables = []table{
+	{
+		name:       "permissions",
+		primaryKey: []string{"id"},
+		serial:     true,
+		columns: []column{
+			{"id", integer},
+			{"title", text},
+			{"description", nullText},
+			{"created", timestamp},
+			{"modified", timestamp},
+			{"namespace", text},
+			{"protected", boolean},
+		},
+	},
+	{
+		name:       "roles",
+		primaryKey: []string{"id"},
+		serial:     true,
+		columns: []column{
+			{"id", integer},
+			{"title", text},
+			{"description", nullText},
+			{"is_default", boolean},
+			{"created", timestamp},
+			{"modified", timestamp},
+		},
+	},
+	{
+		name:       "role_permissions",
+		primaryKey: []string{"role_id", "permission_id"},
+		references: []reference{
+			{"role_id", "roles"},
+			{"permission_id", "permissions"},
+		},
+		columns: []column{
+			{"role_id", integer},
+			{"permission_id", integer},
+			{"created", timestamp},
+		},
+	},
+	{
+		name:       "users",
+		primaryKey: []string{"id"},
+		columns: []column{
+			{"id", ulidType},
+			{"name", nullText},
+			{"email", text},
+			{"password", text},
+			{"last_login", nullTimestamp},
+			{"email_verified", boolean},
+			{"created", timestamp},
+			{"modified", timestamp},
+			{"status", text},
+			{"deleted", nullTimestamp},
+			{"totp_secret", secret},
+			{"totp_enabled", nullTimestamp},
+			{"recovery_codes", jsonType},
+		},
+	},
+	{
+		name:       "user_roles",
+		primaryKey: []string{"user_id", "role_id"},
+		references: []reference{
+			{"user_id", "users"},
+			{"role_id", "roles"},
+		},
+		columns: []column{
+			{"user_id", ulidType},
+			{"role_id", integer},
+			{"created", timestamp},
+		},
+	},
+	{
+		name:       "organizations",
+		primaryKey: []string{"id"},
+		columns: []column{
+			{"id", ulidType},
+			{"name", text},
+			{"street_address", nullText},
+			{"homepage_uri", nullText},
+			{"support_email", nullText},
+			{"created", timestamp},
+			{"modified", timestamp},
+		},
+	},
+	{
+		name:       "organization_members",
+		primaryKey: []string{"organization_id", "user_id", "role_id"},
+		references: []reference{
+			{"organization_id", "organizations"},
+			{"user_id", "users"},
+			{"role_id", "roles"},
+		},
+		columns: []column{
+			{"organization_id", ulidType},
+			{"user_id", ulidType},
+			{"role_id", integer},
+			{"created", timestamp},
+		},
+	},
+	{
+		name:       "api_keys",
+		primaryKey: []string{"id"},
+		references: []reference{
+			{"created_by", "users"},
+			{"organization_id", "organizations"},
+		},
+		columns: []column{
+			{"id", ulidType},
+			{"description", nullText},
+			{"client_id", text},
+			{"secret", text},
+			{"created_by", ulidType},
+			{"last_seen", nullTimestamp},
+			{"revoked", nullTimestamp},
+			{"created", timestamp},
+			{"modified", timestamp},
+			{"organization_id", nullULID},
+		},
+	},
+	{
+		name:       "api_key_permissions",
+		primaryKey: []string{"api_key_id", "permission_id"},
+		references: []reference{
+			{"api_key_id", "api_keys"},
+			{"permission_id", "permissions"},
+		},
+		columns: []column{
+			{"api_key_id", ulidType},
+			{"permission_id", integer},
+			{"created", timestamp},
+		},
+	},
+	{
+		name:       "oidc_clients",
+		primaryKey: []string{"id"},
+		references: []reference{
+			{"created_by", "users"},
+			{"organization_id", "organizations"},
+		},
+		columns: []column{
+			{"id", ulidType},
+			{"client_name", text},
+			{"client_uri", nullText},
+			{"logo_uri", nullText},
+			{"policy_uri", nullText},
+			{"tos_uri", nullText},
+			{"redirect_uris", jsonType},
+			{"contacts", jsonType},
+			{"client_id", text},
+			{"secret", text},
+			{"created_by", ulidType},
+			{"created", timestamp},
+			{"modified", timestamp},
+			{"organization_id", nullULID},
+		},
+	},
+	{
+		name:       "vero_tokens",
+		primaryKey: []string{"id"},
+		expiration: "expiration",
+		columns: []column{
+			{"id", ulidType},
+			{"token_type", text},
+			{"resource_id", nullULID},
+			{"email", text},
+			{"expiration", timestamp},
+			{"signature", blob},
+			{"sent_on", nullTimestamp},
+			{"created", timestamp},
+			{"modified", timestamp},
+		},
+	},
+	{
+		name:       "webauthn_credentials",
+		primaryKey: []string{"id"},
+		references: []reference{
+			{"user_id", "users"},
+		},
+		columns: []column{
+			{"id", ulidType},
+			{"user_id", ulidType},
+			{"name", nullText},
+			{"credential_id", blob},
+			{"public_key", blob},
+			{"attestation_type", nullText},
+			{"aaguid", blob},
+			{"sign_count", integer},
+			{"transports", jsonType},
+			{"flags", integer},
+			{"last_used", nullTimestamp},
+			{"created", timestamp},
+			{"modified", timestamp},
+		},
+	},
+	{
+		// Deliveries are not copied so that events the v1 server has already queued are
+		// not sent a second time by the v2 server.
+		name:       "webhooks",
+		primaryKey: []string{"id"},
+		columns: []column{
+			{"id", ulidType},
+			{"url", text},
+			{"description", nullText},
+			{"events", jsonType},
+			{"secret", text},
+			{"active", boolean},
+			{"created", timestamp},
+			{"modified", timestamp},
+		},
+	},
+	{
+		// The organization of v2 audit events is not in the v1 schema and is left null.
+		name:       "audit_events",
+		primaryKey: []string{"id"},
+		columns: []column{
+			{"id", ulidType},
+			{"actor_type", nullText},
+			{"actor_id", nullULID},
+			{"action", text},
+			{"subject_type", text},
+			{"subject_id", text},
+			{"client_ip", nullText},
+			{"user_agent", nullText},
+			{"request_id", nullText},
+			{"diff", jsonType},
+			{"created", timestamp},
+			{"modified", timestamp},
+		},
+	},
+}
+
+// Tables returns the names of the migrated tables in the order they are copied.
+func Tables() []string {
+	names := make([]string, 0, len(tables))
+	for _, t := range tables {
+		names = append(names, t.name)
+	}
+	return names
+}
+
+// table describes how the rows of a table that exists in both the v1 and v2 schemas are
+// read, compared, and written.
+type table struct {
+	name       string
+	primaryKey []string
+	columns    []column
+	references []reference
+	serial     bool   // the primary key is generated by a sequence in Postgres
+	expiration string // rows that have expired by this column are not copied
+}
+
+// reference is a foreign key column that refers to the primary key of another table.
+type reference struct {
+	column string
+	table  string
+}
+
+// Returns the migrated table with the specified name or nil if there is no such table.
+func lookupTable(name string) *table {
+	for i := range tables {
+		if tables[i].name == name {
+			return &tables[i]
+		}
+	}
+	return nil
+}
+
+// selectSQL returns the query to read all of the migrated columns of the table.
+func (t table) selectSQL() string {
+	names := make([]string, 0, len(t.columns))
+	for _, c := range t.columns {
+		names = append(names, c.name)
+	}
+	return "SELECT " + strings.Join(names, ", ") + " FROM " + t.name
+}
+
+// upsertSQL returns the query to insert a row into the table or to update the row with
+// the same primary key if it already exists in the table.
+func (t table) upsertSQL() string {
+	var (
+		names   = make([]string, 0, len(t.columns))
+		params  = make([]string, 0, len(t.columns))
+		updates = make([]string, 0, len(t.columns))
+	)
+
+	for _, c := range t.columns {
+		names = append(names, c.name)
+		params = append(params, ":"+c.name)
+		if !t.isPrimaryKey(c.name) {
+			updates = append(updates, c.name+" = excluded."+c.name)
+		}
+	}
+
+	return "INSERT INTO " + t.name + " (" + strings.Join(names, ", ") + ") VALUES (" +
+		strings.Join(params, ", ") + ") ON CONFLICT (" + strings.Join(t.primaryKey, ", ") +
+		") DO UPDATE SET " + strings.Join(updates, ", ")
+}
+
+func (t table) isPrimaryKey(name string) bool {
+	for _, pk := range t.primaryKey {
+		if pk == name {
+			return true
+		}
+	}
+	return false
+}
+
+// scan reads a row from the table into a new record.
+func (t *table) scan(rows *sql.Rows) (r *record, err error) {
+	r = t.newRecord()
+	if err = rows.Scan(r.values...); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// newRecord returns a record with zero values for every column of the table.
+func (t *table) newRecord() *record {
+	r := &record{table: t, values: make([]any, 0, len(t.columns))}
+	for _, c := range t.columns {
+		r.values = append(r.values, c.kind.dest())
+	}
+	return r
+}
+
+//===========================================================================
+// Records
+//===========================================================================
+
+// record is a single row of a migrated table.
+type record struct {
+	table  *table
+	values []any
+}
+
+// Key returns a string that uniquely identifies the record in its table.
+func (r *record) Key() string {
+	parts := make([]string, 0, len(r.table.primaryKey))
+	for i, c := range r.table.columns {
+		if r.table.isPrimaryKey(c.name) {
+			parts = append(parts, c.kind.canonical(r.values[i]))
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// Digest returns a hash of the canonical values of the record. Values are normalized
+// so that the same row has the same digest whether it is read from SQLite or Postgres.
+func (r *record) Digest() []byte {
+	var (
+		size [8]byte
+		hash = sha256.New()
+	)
+
+	for i, c := range r.table.columns {
+		value := c.kind.canonical(r.values[i])
+		binary.BigEndian.PutUint64(size[:], uint64(len(value)))
+		hash.Write(size[:])
+		hash.Write([]byte(value))
+	}
+	return hash.Sum(nil)
+}
+
+// Expired returns true if the table has an expiration column and the record expired
+// before the cutoff.
+func (r *record) Expired(cutoff time.Time) bool {
+	if r.table.expiration == "" {
+		return false
+	}
+
+	for i, c := range r.table.columns {
+		if c.name == r.table.expiration {
+			if ts, ok := r.values[i].(*time.Time); ok {
+				return !ts.After(cutoff)
+			}
+		}
+	}
+	return false
+}
+
+// Canonical returns the normalized value of the column, which is the same as the key
+// of the row that the column refers to for foreign key columns.
+func (r *record) Canonical(name string) string {
+	for i, c := range r.table.columns {
+		if c.name == name {
+			return c.kind.canonical(r.values[i])
+		}
+	}
+	return null
+}
+
+// Seal encrypts the secret columns of the record so that they can be written to the v2
+// store, which keeps secrets sealed by the key encryption key; an error is returned if
+// the record has a secret and no cipher is available to seal it.
+func (r *record) Seal(secrets SecretCipher) error {
+	return r.transform(secrets, func(id ulid.ULID, value string) (string, error) {
+		return secrets.SealSecret(id, value)
+	})
+}
+
+// Open decrypts the secret columns of a record read from the v2 store so that it has the
+// same digest as the plain text record in the v1 store.
+func (r *record) Open(secrets SecretCipher) error {
+	return r.transform(secrets, func(id ulid.ULID, value string) (string, error) {
+		return secrets.OpenSecret(id, value)
+	})
+}
+
+func (r *record) transform(secrets SecretCipher, fn func(ulid.ULID, string) (string, error)) (err error) {
+	for i, c := range r.table.columns {
+		if c.kind != secret {
+			continue
+		}
+
+		value := r.values[i].(*sql.NullString)
+		if !value.Valid || value.String == "" {
+			continue
+		}
+
+		if secrets == nil {
+			return errors.ErrNoKeyEncryptionKey
+		}
+
+		var id ulid.ULID
+		if id, err = ulid.Parse(r.Key()); err != nil {
+			return err
+		}
+
+		if value.String, err = fn(id, value.String); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Params returns the named arguments used to write the record with the upsert query.
+func (r *record) Params() []any {
+	params := make([]any, 0, len(r.values))
+	for i, c := range r.table.columns {
+		params = append(params, sql.Named(c.name, c.kind.value(r.values[i])))
+	}
+	return params
+}
+
+//===========================================================================
+// Column Types
+//===========================================================================
+
+type column struct {
+	name string
+	kind kind
+}
+
+// kind describes how a column value is scanned, written, and normalized so that values
+// from the v1 and v2 databases can be compared.
+type kind uint8
+
+const (
+	integer kind = iota
+	boolean
+	text
+	nullText
+	ulidType
+	nullULID
+	timestamp
+	nullTimestamp
+	blob
+	jsonType
+	secret // nullable text that is sealed with the primary key of the row in the v2 store
+)
+
+// Canonical value of NULL columns so that they are distinct from zero length values.
+const null = "\x00NULL"
+
+func (k kind) dest() any {
+	switch k {
+	case integer:
+		return new(int64)
+	case boolean:
+		return new(bool)
+	case text:
+		return new(string)
+	case nullText, secret:
+		return new(sql.NullString)
+	case ulidType:
+		return new(ulid.ULID)
+	case nullULID:
+		return new(ulid.NullULID)
+	case timestamp:
+		return new(time.Time)
+	case nullTimestamp:
+		return new(sql.NullTime)
+	case blob, jsonType:
+		return new([]byte)
+	default:
+		panic("unhandled column kind")
+	}
+}
+
+func (k kind) value(dest any) any {
+	switch v := dest.(type) {
+	case *int64:
+		return *v
+	case *bool:
+		return *v
+	case *string:
+		return *v
+	case *sql.NullString:
+		return *v
+	case *ulid.ULID:
+		return *v
+	case *ulid.NullULID:
+		return *v
+	case *time.Time:
+		return *v
+	case *sql.NullTime:
+		return *v
+	case *[]byte:
+		// Empty JSON is not valid in Postgres so it is stored as NULL instead.
+		if len(*v) == 0 {
+			return nil
+		}
+		return *v
+	default:
+		panic("unhandled column value")
+	}
+}
+
+func (k kind) canonical(dest any) string {
+	switch v := dest.(type) {
+	case *int64:
+		return strconv.FormatInt(*v, 10)
+	case *bool:
+		return strconv.FormatBool(*v)
+	case *string:
+		return *v
+	case *sql.NullString:
+		if !v.Valid {
+			return null
+		}
+		return v.String
+	case *ulid.ULID:
+		return v.String()
+	case *ulid.NullULID:
+		if !v.Valid {
+			return null
+		}
+		return v.ULID.String()
+	case *time.Time:
+		return canonicalTime(*v)
+	case *sql.NullTime:
+		if !v.Valid {
+			return null
+		}
+		return canonicalTime(v.Time)
+	case *[]byte:
+		if len(*v) == 0 {
+			return null
+		}
+		if k == jsonType {
+			return canonicalJSON(*v)
+		}
+		return hex.EncodeToString(*v)
+	default:
+		panic("unhandled column value")
+	}
+}
+
+// encode returns the value of the column as it is written to an export.
+func (k kind) encode(dest any) any {
+	switch v := dest.(type) {
+	case *sql.NullString:
+		if !v.Valid {
+			return nil
+		}
+		return v.String
+	case *ulid.ULID:
+		return v.String()
+	case *ulid.NullULID:
+		if !v.Valid {
+			return nil
+		}
+		return v.ULID.String()
+	case *sql.NullTime:
+		if !v.Valid {
+			return nil
+		}
+		return v.Time
+	case *[]byte:
+		if len(*v) == 0 {
+			return nil
+		}
+		if k == jsonType {
+			return json.RawMessage(*v)
+		}
+		return *v
+	default:
+		return k.value(dest)
+	}
+}
+
+// decode parses the value of the column from an export into dest.
+func (k kind) decode(data json.RawMessage, dest any) (err error) {
+	if isNull := bytes.Equal(bytes.TrimSpace(data), []byte("null")); isNull {
+		switch k {
+		case nullText, nullULID, nullTimestamp, blob, jsonType, secret:
+			return nil
+		default:
+			return errors.ErrZeroValuedNotNull
+		}
+	}
+
+	switch v := dest.(type) {
+	case *sql.NullString:
+		v.Valid = true
+		return json.Unmarshal(data, &v.String)
+	case *ulid.ULID:
+		var s string
+		if err = json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*v, err = ulid.Parse(s)
+		return err
+	case *ulid.NullULID:
+		var s string
+		if err = json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		v.Valid = true
+		v.ULID, err = ulid.Parse(s)
+		return err
+	case *sql.NullTime:
+		v.Valid = true
+		return json.Unmarshal(data, &v.Time)
+	case *[]byte:
+		if k == jsonType {
+			*v = append([]byte(nil), data...)
+			return nil
+		}
+		return json.Unmarshal(data, v)
+	default:
+		return json.Unmarshal(data, dest)
+	}
+}
+
+// Postgres stores timestamps with microsecond precision.
+func canonicalTime(ts time.Time) string {
+	return ts.UTC().Truncate(time.Microsecond).Format(time.RFC3339Nano)
+}
+
+// Postgres reformats JSONB values so JSON is compared by its decoded value.
+func canonicalJSON(data []byte) string {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return string(bytes.TrimSpace(data))
+	}
+
+	out, _ := json.Marshal(value)
+	return string(out)
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/quarterdeck/pkg/enum"
 	"go.rtnl.ai/quarterdeck/pkg/errors"
 	"go.rtnl.ai/quarterdeck/pkg/store/v1/dsn"
 	"go.rtnl.ai/quarterdeck/pkg/store/v1/models"
@@ -34,14 +35,18 @@ type Store struct {
 	OnBegin func(context.Context, *sql.TxOptions) (txn.Txn, error)
 
 	// UserStore Callbacks
-	OnListUsers       func(context.Context, *models.UserPage) (*models.UserList, error)
-	OnCreateUser      func(context.Context, *models.User) error
-	OnRetrieveUser    func(context.Context, any) (*models.User, error)
-	OnUpdateUser      func(context.Context, *models.User) error
-	OnUpdatePassword  func(context.Context, ulid.ULID, string) error
-	OnUpdateLastLogin func(context.Context, ulid.ULID, time.Time) error
-	OnVerifyEmail     func(context.Context, ulid.ULID) error
-	OnDeleteUser      func(context.Context, ulid.ULID) error
+	OnListUsers        func(context.Context, *models.UserPage) (*models.UserList, error)
+	OnCreateUser       func(context.Context, *models.User) error
+	OnRetrieveUser     func(context.Context, any) (*models.User, error)
+	OnUpdateUser       func(context.Context, *models.User) error
+	OnUpdatePassword   func(context.Context, ulid.ULID, string) error
+	OnUpdateLastLogin  func(context.Context, ulid.ULID, time.Time) error
+	OnVerifyEmail      func(context.Context, ulid.ULID) error
+	OnUpdateMFA        func(context.Context, *models.User) error
+	OnReplaceUserRoles func(context.Context, ulid.ULID, []int64) error
+	OnUpdateUserStatus func(context.Context, ulid.ULID, enum.UserStatus) error
+	OnDeleteUser       func(context.Context, ulid.ULID) error
+	OnPurgeUsers       func(context.Context, time.Time) ([]ulid.ULID, error)
 
 	// RoleStore Callbacks
 	OnListRoles                func(context.Context, *models.Page) (*models.RoleList, error)
@@ -59,6 +64,17 @@ type Store struct {
 	OnUpdatePermission   func(context.Context, *models.Permission) error
 	OnDeletePermission   func(context.Context, int64) error
 
+	// OrganizationStore Callbacks
+	OnListOrganizations              func(context.Context, *models.Page) (*models.OrganizationList, error)
+	OnCreateOrganization             func(context.Context, *models.Organization) error
+	OnRetrieveOrganization           func(context.Context, ulid.ULID) (*models.Organization, error)
+	OnUpdateOrganization             func(context.Context, *models.Organization) error
+	OnDeleteOrganization             func(context.Context, ulid.ULID) error
+	OnListUserOrganizations          func(context.Context, ulid.ULID) ([]*models.Organization, error)
+	OnListOrganizationMembers        func(context.Context, ulid.ULID) ([]*models.User, error)
+	OnRetrieveOrganizationMember     func(context.Context, ulid.ULID, ulid.ULID) (*models.User, error)
+	OnReplaceOrganizationMemberRoles func(context.Context, ulid.ULID, ulid.ULID, []int64) error
+
 	// APIKeyStore Callbacks
 	OnListAPIKeys                func(context.Context, *models.Page) (*models.APIKeyList, error)
 	OnCreateAPIKey               func(context.Context, *models.APIKey) error
@@ -77,6 +93,11 @@ type Store struct {
 	OnUpdateOIDCClient   func(context.Context, *models.OIDCClient) error
 	OnDeleteOIDCClient   func(context.Context, ulid.ULID) error
 
+	// AuthorizationCodeStore Callbacks
+	OnCreateAuthorizationCode   func(context.Context, *models.AuthorizationCode) error
+	OnRetrieveAuthorizationCode func(context.Context, string) (*models.AuthorizationCode, error)
+	OnDeleteAuthorizationCode   func(context.Context, ulid.ULID) error
+
 	// VeroTokenStore Callbacks
 	OnCreateVeroToken              func(context.Context, *models.VeroToken) error
 	OnRetrieveVeroToken            func(context.Context, ulid.ULID) (*models.VeroToken, error)
@@ -84,7 +105,58 @@ type Store struct {
 	OnDeleteVeroToken              func(context.Context, ulid.ULID) error
 	OnCreateResetPasswordVeroToken func(context.Context, *models.VeroToken) error
 	OnCreateTeamInviteVeroToken    func(context.Context, *models.VeroToken) error
+	OnCreateVerifyEmailVeroToken   func(context.Context, *models.VeroToken) error
+	OnCreateChangeEmailVeroToken   func(context.Context, *models.VeroToken) error
+	OnCompleteEmailChange          func(context.Context, ulid.ULID) error
 	OnRetrieveTeamInviteVeroToken  func(context.Context, ulid.ULID) (*models.VeroToken, error)
+	OnListTeamInviteVeroTokens     func(context.Context) ([]*models.VeroToken, error)
+
+	// RevokedTokenStore Callbacks
+	OnRevokeToken    func(context.Context, *models.RevokedToken) error
+	OnIsTokenRevoked func(context.Context, ulid.ULID) (bool, error)
+
+	// RefreshTokenStore Callbacks
+	OnCreateRefreshToken       func(context.Context, *models.RefreshToken) error
+	OnRetrieveRefreshToken     func(context.Context, ulid.ULID) (*models.RefreshToken, error)
+	OnUseRefreshToken          func(context.Context, ulid.ULID) (*models.RefreshToken, error)
+	OnRevokeRefreshTokenFamily func(context.Context, ulid.ULID) error
+
+	// SessionStore Callbacks
+	OnListSessions       func(context.Context, ulid.ULID) (*models.SessionList, error)
+	OnCreateSession      func(context.Context, *models.Session) error
+	OnRetrieveSession    func(context.Context, ulid.ULID) (*models.Session, error)
+	OnRefreshSession     func(context.Context, ulid.ULID, ulid.ULID, time.Time) error
+	OnRevokeSession      func(context.Context, ulid.ULID) error
+	OnRevokeUserSessions func(context.Context, ulid.ULID) error
+
+	// WebAuthnCredentialStore Callbacks
+	OnListWebAuthnCredentials    func(context.Context, ulid.ULID) (*models.WebAuthnCredentialList, error)
+	OnCreateWebAuthnCredential   func(context.Context, *models.WebAuthnCredential) error
+	OnRetrieveWebAuthnCredential func(context.Context, []byte) (*models.WebAuthnCredential, error)
+	OnUpdateWebAuthnCredential   func(context.Context, *models.WebAuthnCredential) error
+	OnDeleteWebAuthnCredential   func(context.Context, ulid.ULID) error
+
+	// LockoutStore Callbacks
+	OnRetrieveLockout     func(context.Context, string, string) (*models.Lockout, error)
+	OnRecordFailedAttempt func(context.Context, string, string, models.LockoutPolicy) (*models.Lockout, error)
+	OnResetLockout        func(context.Context, string, string) error
+
+	// AuditEventStore Callbacks
+	OnListAuditEvents  func(context.Context, *models.AuditEventPage) (*models.AuditEventList, error)
+	OnCreateAuditEvent func(context.Context, *models.AuditEvent) error
+
+	// WebhookStore Callbacks
+	OnListWebhooks             func(context.Context, *models.Page) (*models.WebhookList, error)
+	OnCreateWebhook            func(context.Context, *models.Webhook) error
+	OnRetrieveWebhook          func(context.Context, ulid.ULID) (*models.Webhook, error)
+	OnUpdateWebhook            func(context.Context, *models.Webhook) error
+	OnDeleteWebhook            func(context.Context, ulid.ULID) error
+	OnEnqueueWebhookEvent      func(context.Context, ulid.ULID, enum.WebhookEvent, []byte) (int, error)
+	OnListWebhookDeliveries    func(context.Context, ulid.ULID, *models.Page) (*models.WebhookDeliveryList, error)
+	OnCreateWebhookDelivery    func(context.Context, *models.WebhookDelivery) error
+	OnRetrieveWebhookDelivery  func(context.Context, ulid.ULID) (*models.WebhookDelivery, error)
+	OnUpdateWebhookDelivery    func(context.Context, *models.WebhookDelivery) error
+	OnListDueWebhookDeliveries func(context.Context, time.Time, int) ([]*models.WebhookDelivery, error)
 }
 
 func Open(uri *dsn.DSN) (*Store, error) {
@@ -164,14 +236,18 @@ func (s *Store) Begin(ctx context.Context, opts *sql.TxOptions) (txn.Txn, error)
 //===========================================================================
 
 const (
-	ListUsers       = "ListUsers"
-	CreateUser      = "CreateUser"
-	RetrieveUser    = "RetrieveUser"
-	UpdateUser      = "UpdateUser"
-	UpdatePassword  = "UpdatePassword"
-	UpdateLastLogin = "UpdateLastLogin"
-	VerifyEmail     = "VerifyEmail"
-	DeleteUser      = "DeleteUser"
+	ListUsers        = "ListUsers"
+	CreateUser       = "CreateUser"
+	RetrieveUser     = "RetrieveUser"
+	UpdateUser       = "UpdateUser"
+	UpdatePassword   = "UpdatePassword"
+	UpdateLastLogin  = "UpdateLastLogin"
+	VerifyEmail      = "VerifyEmail"
+	UpdateMFA        = "UpdateMFA"
+	ReplaceUserRoles = "ReplaceUserRoles"
+	UpdateUserStatus = "UpdateUserStatus"
+	DeleteUser       = "DeleteUser"
+	PurgeUsers       = "PurgeUsers"
 )
 
 func (s *Store) ListUsers(ctx context.Context, page *models.UserPage) (*models.UserList, error) {
@@ -230,6 +306,30 @@ func (s *Store) VerifyEmail(ctx context.Context, id ulid.ULID) error {
 	panic(errors.Fmt("%s callback is not mocked", VerifyEmail))
 }
 
+func (s *Store) UpdateMFA(ctx context.Context, user *models.User) error {
+	s.calls[UpdateMFA]++
+	if s.OnUpdateMFA != nil {
+		return s.OnUpdateMFA(ctx, user)
+	}
+	panic(errors.Fmt("%s callback is not mocked", UpdateMFA))
+}
+
+func (s *Store) ReplaceUserRoles(ctx context.Context, id ulid.ULID, roleIDs []int64) error {
+	s.calls[ReplaceUserRoles]++
+	if s.OnReplaceUserRoles != nil {
+		return s.OnReplaceUserRoles(ctx, id, roleIDs)
+	}
+	panic(errors.Fmt("%s callback is not mocked", ReplaceUserRoles))
+}
+
+func (s *Store) UpdateUserStatus(ctx context.Context, id ulid.ULID, status enum.UserStatus) error {
+	s.calls[UpdateUserStatus]++
+	if s.OnUpdateUserStatus != nil {
+		return s.OnUpdateUserStatus(ctx, id, status)
+	}
+	panic(errors.Fmt("%s callback is not mocked", UpdateUserStatus))
+}
+
 func (s *Store) DeleteUser(ctx context.Context, id ulid.ULID) error {
 	s.calls[DeleteUser]++
 	if s.OnDeleteUser != nil {
@@ -238,6 +338,14 @@ func (s *Store) DeleteUser(ctx context.Context, id ulid.ULID) error {
 	panic(errors.Fmt("%s callback is not mocked", DeleteUser))
 }
 
+func (s *Store) PurgeUsers(ctx context.Context, deletedBefore time.Time) ([]ulid.ULID, error) {
+	s.calls[PurgeUsers]++
+	if s.OnPurgeUsers != nil {
+		return s.OnPurgeUsers(ctx, deletedBefore)
+	}
+	panic(errors.Fmt("%s callback is not mocked", PurgeUsers))
+}
+
 //===========================================================================
 // RoleStore
 //===========================================================================
@@ -360,6 +468,94 @@ func (s *Store) DeletePermission(ctx context.Context, id int64) error {
 	panic(errors.Fmt("%s callback is not mocked", DeletePermission))
 }
 
+//===========================================================================
+// OrganizationStore
+//===========================================================================
+
+const (
+	ListOrganizations              = "ListOrganizations"
+	CreateOrganization             = "CreateOrganization"
+	RetrieveOrganization           = "RetrieveOrganization"
+	UpdateOrganization             = "UpdateOrganization"
+	DeleteOrganization             = "DeleteOrganization"
+	ListUserOrganizations          = "ListUserOrganizations"
+	ListOrganizationMembers        = "ListOrganizationMembers"
+	RetrieveOrganizationMember     = "RetrieveOrganizationMember"
+	ReplaceOrganizationMemberRoles = "ReplaceOrganizationMemberRoles"
+)
+
+func (s *Store) ListOrganizations(ctx context.Context, page *models.Page) (*models.OrganizationList, error) {
+	s.calls[ListOrganizations]++
+	if s.OnListOrganizations != nil {
+		return s.OnListOrganizations(ctx, page)
+	}
+	panic(errors.Fmt("%s callback is not mocked", ListOrganizations))
+}
+
+func (s *Store) CreateOrganization(ctx context.Context, org *models.Organization) error {
+	s.calls[CreateOrganization]++
+	if s.OnCreateOrganization != nil {
+		return s.OnCreateOrganization(ctx, org)
+	}
+	panic(errors.Fmt("%s callback is not mocked", CreateOrganization))
+}
+
+func (s *Store) RetrieveOrganization(ctx context.Context, orgID ulid.ULID) (*models.Organization, error) {
+	s.calls[RetrieveOrganization]++
+	if s.OnRetrieveOrganization != nil {
+		return s.OnRetrieveOrganization(ctx, orgID)
+	}
+	panic(errors.Fmt("%s callback is not mocked", RetrieveOrganization))
+}
+
+func (s *Store) UpdateOrganization(ctx context.Context, org *models.Organization) error {
+	s.calls[UpdateOrganization]++
+	if s.OnUpdateOrganization != nil {
+		return s.OnUpdateOrganization(ctx, org)
+	}
+	panic(errors.Fmt("%s callback is not mocked", UpdateOrganization))
+}
+
+func (s *Store) DeleteOrganization(ctx context.Context, orgID ulid.ULID) error {
+	s.calls[DeleteOrganization]++
+	if s.OnDeleteOrganization != nil {
+		return s.OnDeleteOrganization(ctx, orgID)
+	}
+	panic(errors.Fmt("%s callback is not mocked", DeleteOrganization))
+}
+
+func (s *Store) ListUserOrganizations(ctx context.Context, userID ulid.ULID) ([]*models.Organization, error) {
+	s.calls[ListUserOrganizations]++
+	if s.OnListUserOrganizations != nil {
+		return s.OnListUserOrganizations(ctx, userID)
+	}
+	panic(errors.Fmt("%s callback is not mocked", ListUserOrganizations))
+}
+
+func (s *Store) ListOrganizationMembers(ctx context.Context, orgID ulid.ULID) ([]*models.User, error) {
+	s.calls[ListOrganizationMembers]++
+	if s.OnListOrganizationMembers != nil {
+		return s.OnListOrganizationMembers(ctx, orgID)
+	}
+	panic(errors.Fmt("%s callback is not mocked", ListOrganizationMembers))
+}
+
+func (s *Store) RetrieveOrganizationMember(ctx context.Context, orgID, userID ulid.ULID) (*models.User, error) {
+	s.calls[RetrieveOrganizationMember]++
+	if s.OnRetrieveOrganizationMember != nil {
+		return s.OnRetrieveOrganizationMember(ctx, orgID, userID)
+	}
+	panic(errors.Fmt("%s callback is not mocked", RetrieveOrganizationMember))
+}
+
+func (s *Store) ReplaceOrganizationMemberRoles(ctx context.Context, orgID, userID ulid.ULID, roleIDs []int64) error {
+	s.calls[ReplaceOrganizationMemberRoles]++
+	if s.OnReplaceOrganizationMemberRoles != nil {
+		return s.OnReplaceOrganizationMemberRoles(ctx, orgID, userID, roleIDs)
+	}
+	panic(errors.Fmt("%s callback is not mocked", ReplaceOrganizationMemberRoles))
+}
+
 //===========================================================================
 // APIKeyStore
 //===========================================================================
@@ -500,6 +696,40 @@ func (s *Store) DeleteOIDCClient(ctx context.Context, id ulid.ULID) error {
 	panic(errors.Fmt("%s callback is not mocked", DeleteOIDCClient))
 }
 
+//===========================================================================
+// AuthorizationCodeStore
+//===========================================================================
+
+const (
+	CreateAuthorizationCode   = "CreateAuthorizationCode"
+	RetrieveAuthorizationCode = "RetrieveAuthorizationCode"
+	DeleteAuthorizationCode   = "DeleteAuthorizationCode"
+)
+
+func (s *Store) CreateAuthorizationCode(ctx context.Context, in *models.AuthorizationCode) error {
+	s.calls[CreateAuthorizationCode]++
+	if s.OnCreateAuthorizationCode != nil {
+		return s.OnCreateAuthorizationCode(ctx, in)
+	}
+	panic(errors.Fmt("%s callback is not mocked", CreateAuthorizationCode))
+}
+
+func (s *Store) RetrieveAuthorizationCode(ctx context.Context, code string) (*models.AuthorizationCode, error) {
+	s.calls[RetrieveAuthorizationCode]++
+	if s.OnRetrieveAuthorizationCode != nil {
+		return s.OnRetrieveAuthorizationCode(ctx, code)
+	}
+	panic(errors.Fmt("%s callback is not mocked", RetrieveAuthorizationCode))
+}
+
+func (s *Store) DeleteAuthorizationCode(ctx context.Context, id ulid.ULID) error {
+	s.calls[DeleteAuthorizationCode]++
+	if s.OnDeleteAuthorizationCode != nil {
+		return s.OnDeleteAuthorizationCode(ctx, id)
+	}
+	panic(errors.Fmt("%s callback is not mocked", DeleteAuthorizationCode))
+}
+
 //===========================================================================
 // VeroTokenStore
 //===========================================================================
@@ -511,7 +741,11 @@ const (
 	DeleteVeroToken              = "DeleteVeroToken"
 	CreateResetPasswordVeroToken = "CreateResetPasswordVeroToken"
 	CreateTeamInviteVeroToken    = "CreateTeamInviteVeroToken"
+	CreateVerifyEmailVeroToken   = "CreateVerifyEmailVeroToken"
+	CreateChangeEmailVeroToken   = "CreateChangeEmailVeroToken"
+	CompleteEmailChange          = "CompleteEmailChange"
 	RetrieveTeamInviteVeroToken  = "RetrieveTeamInviteVeroToken"
+	ListTeamInviteVeroTokens     = "ListTeamInviteVeroTokens"
 )
 
 func (s *Store) CreateVeroToken(ctx context.Context, in *models.VeroToken) error {
@@ -562,6 +796,30 @@ func (s *Store) CreateTeamInviteVeroToken(ctx context.Context, in *models.VeroTo
 	panic(errors.Fmt("%s callback is not mocked", CreateVeroToken))
 }
 
+func (s *Store) CreateVerifyEmailVeroToken(ctx context.Context, in *models.VeroToken) error {
+	s.calls[CreateVerifyEmailVeroToken]++
+	if s.OnCreateVerifyEmailVeroToken != nil {
+		return s.OnCreateVerifyEmailVeroToken(ctx, in)
+	}
+	panic(errors.Fmt("%s callback is not mocked", CreateVerifyEmailVeroToken))
+}
+
+func (s *Store) CreateChangeEmailVeroToken(ctx context.Context, in *models.VeroToken) error {
+	s.calls[CreateChangeEmailVeroToken]++
+	if s.OnCreateChangeEmailVeroToken != nil {
+		return s.OnCreateChangeEmailVeroToken(ctx, in)
+	}
+	panic(errors.Fmt("%s callback is not mocked", CreateChangeEmailVeroToken))
+}
+
+func (s *Store) CompleteEmailChange(ctx context.Context, veroTokenID ulid.ULID) error {
+	s.calls[CompleteEmailChange]++
+	if s.OnCompleteEmailChange != nil {
+		return s.OnCompleteEmailChange(ctx, veroTokenID)
+	}
+	panic(errors.Fmt("%s callback is not mocked", CompleteEmailChange))
+}
+
 func (s *Store) RetrieveTeamInviteVeroToken(ctx context.Context, userID ulid.ULID) (*models.VeroToken, error) {
 	s.calls[RetrieveTeamInviteVeroToken]++
 	if s.OnRetrieveTeamInviteVeroToken != nil {
@@ -569,3 +827,357 @@ func (s *Store) RetrieveTeamInviteVeroToken(ctx context.Context, userID ulid.ULI
 	}
 	panic(errors.Fmt("%s callback is not mocked", RetrieveTeamInviteVeroToken))
 }
+
+func (s *Store) ListTeamInviteVeroTokens(ctx context.Context) ([]*models.VeroToken, error) {
+	s.calls[ListTeamInviteVeroTokens]++
+	if s.OnListTeamInviteVeroTokens != nil {
+		return s.OnListTeamInviteVeroTokens(ctx)
+	}
+	panic(errors.Fmt("%s callback is not mocked", ListTeamInviteVeroTokens))
+}
+
+//===========================================================================
+// RevokedTokenStore
+//===========================================================================
+
+const (
+	RevokeToken    = "RevokeToken"
+	IsTokenRevoked = "IsTokenRevoked"
+)
+
+func (s *Store) RevokeToken(ctx context.Context, in *models.RevokedToken) error {
+	s.calls[RevokeToken]++
+	if s.OnRevokeToken != nil {
+		return s.OnRevokeToken(ctx, in)
+	}
+	panic(errors.Fmt("%s callback is not mocked", RevokeToken))
+}
+
+func (s *Store) IsTokenRevoked(ctx context.Context, jti ulid.ULID) (bool, error) {
+	s.calls[IsTokenRevoked]++
+	if s.OnIsTokenRevoked != nil {
+		return s.OnIsTokenRevoked(ctx, jti)
+	}
+	panic(errors.Fmt("%s callback is not mocked", IsTokenRevoked))
+}
+
+//===========================================================================
+// RefreshTokenStore
+//===========================================================================
+
+const (
+	CreateRefreshToken       = "CreateRefreshToken"
+	RetrieveRefreshToken     = "RetrieveRefreshToken"
+	UseRefreshToken          = "UseRefreshToken"
+	RevokeRefreshTokenFamily = "RevokeRefreshTokenFamily"
+)
+
+func (s *Store) CreateRefreshToken(ctx context.Context, in *models.RefreshToken) error {
+	s.calls[CreateRefreshToken]++
+	if s.OnCreateRefreshToken != nil {
+		return s.OnCreateRefreshToken(ctx, in)
+	}
+	panic(errors.Fmt("%s callback is not mocked", CreateRefreshToken))
+}
+
+func (s *Store) RetrieveRefreshToken(ctx context.Context, jti ulid.ULID) (*models.RefreshToken, error) {
+	s.calls[RetrieveRefreshToken]++
+	if s.OnRetrieveRefreshToken != nil {
+		return s.OnRetrieveRefreshToken(ctx, jti)
+	}
+	panic(errors.Fmt("%s callback is not mocked", RetrieveRefreshToken))
+}
+
+func (s *Store) UseRefreshToken(ctx context.Context, jti ulid.ULID) (*models.RefreshToken, error) {
+	s.calls[UseRefreshToken]++
+	if s.OnUseRefreshToken != nil {
+		return s.OnUseRefreshToken(ctx, jti)
+	}
+	panic(errors.Fmt("%s callback is not mocked", UseRefreshToken))
+}
+
+func (s *Store) RevokeRefreshTokenFamily(ctx context.Context, familyID ulid.ULID) error {
+	s.calls[RevokeRefreshTokenFamily]++
+	if s.OnRevokeRefreshTokenFamily != nil {
+		return s.OnRevokeRefreshTokenFamily(ctx, familyID)
+	}
+	panic(errors.Fmt("%s callback is not mocked", RevokeRefreshTokenFamily))
+}
+
+//===========================================================================
+// SessionStore
+//===========================================================================
+
+const (
+	ListSessions       = "ListSessions"
+	CreateSession      = "CreateSession"
+	RetrieveSession    = "RetrieveSession"
+	RefreshSession     = "RefreshSession"
+	RevokeSession      = "RevokeSession"
+	RevokeUserSessions = "RevokeUserSessions"
+)
+
+func (s *Store) ListSessions(ctx context.Context, userID ulid.ULID) (*models.SessionList, error) {
+	s.calls[ListSessions]++
+	if s.OnListSessions != nil {
+		return s.OnListSessions(ctx, userID)
+	}
+	panic(errors.Fmt("%s callback is not mocked", ListSessions))
+}
+
+func (s *Store) CreateSession(ctx context.Context, in *models.Session) error {
+	s.calls[CreateSession]++
+	if s.OnCreateSession != nil {
+		return s.OnCreateSession(ctx, in)
+	}
+	panic(errors.Fmt("%s callback is not mocked", CreateSession))
+}
+
+func (s *Store) RetrieveSession(ctx context.Context, sessionID ulid.ULID) (*models.Session, error) {
+	s.calls[RetrieveSession]++
+	if s.OnRetrieveSession != nil {
+		return s.OnRetrieveSession(ctx, sessionID)
+	}
+	panic(errors.Fmt("%s callback is not mocked", RetrieveSession))
+}
+
+func (s *Store) RefreshSession(ctx context.Context, sessionID ulid.ULID, jti ulid.ULID, expiration time.Time) error {
+	s.calls[RefreshSession]++
+	if s.OnRefreshSession != nil {
+		return s.OnRefreshSession(ctx, sessionID, jti, expiration)
+	}
+	panic(errors.Fmt("%s callback is not mocked", RefreshSession))
+}
+
+func (s *Store) RevokeSession(ctx context.Context, sessionID ulid.ULID) error {
+	s.calls[RevokeSession]++
+	if s.OnRevokeSession != nil {
+		return s.OnRevokeSession(ctx, sessionID)
+	}
+	panic(errors.Fmt("%s callback is not mocked", RevokeSession))
+}
+
+func (s *Store) RevokeUserSessions(ctx context.Context, userID ulid.ULID) error {
+	s.calls[RevokeUserSessions]++
+	if s.OnRevokeUserSessions != nil {
+		return s.OnRevokeUserSessions(ctx, userID)
+	}
+	panic(errors.Fmt("%s callback is not mocked", RevokeUserSessions))
+}
+
+//===========================================================================
+// WebAuthnCredentialStore
+//===========================================================================
+
+const (
+	ListWebAuthnCredentials    = "ListWebAuthnCredentials"
+	CreateWebAuthnCredential   = "CreateWebAuthnCredential"
+	RetrieveWebAuthnCredential = "RetrieveWebAuthnCredential"
+	UpdateWebAuthnCredential   = "UpdateWebAuthnCredential"
+	DeleteWebAuthnCredential   = "DeleteWebAuthnCredential"
+)
+
+func (s *Store) ListWebAuthnCredentials(ctx context.Context, userID ulid.ULID) (*models.WebAuthnCredentialList, error) {
+	s.calls[ListWebAuthnCredentials]++
+	if s.OnListWebAuthnCredentials != nil {
+		return s.OnListWebAuthnCredentials(ctx, userID)
+	}
+	panic(errors.Fmt("%s callback is not mocked", ListWebAuthnCredentials))
+}
+
+func (s *Store) CreateWebAuthnCredential(ctx context.Context, in *models.WebAuthnCredential) error {
+	s.calls[CreateWebAuthnCredential]++
+	if s.OnCreateWebAuthnCredential != nil {
+		return s.OnCreateWebAuthnCredential(ctx, in)
+	}
+	panic(errors.Fmt("%s callback is not mocked", CreateWebAuthnCredential))
+}
+
+func (s *Store) RetrieveWebAuthnCredential(ctx context.Context, credentialID []byte) (*models.WebAuthnCredential, error) {
+	s.calls[RetrieveWebAuthnCredential]++
+	if s.OnRetrieveWebAuthnCredential != nil {
+		return s.OnRetrieveWebAuthnCredential(ctx, credentialID)
+	}
+	panic(errors.Fmt("%s callback is not mocked", RetrieveWebAuthnCredential))
+}
+
+func (s *Store) UpdateWebAuthnCredential(ctx context.Context, in *models.WebAuthnCredential) error {
+	s.calls[UpdateWebAuthnCredential]++
+	if s.OnUpdateWebAuthnCredential != nil {
+		return s.OnUpdateWebAuthnCredential(ctx, in)
+	}
+	panic(errors.Fmt("%s callback is not mocked", UpdateWebAuthnCredential))
+}
+
+func (s *Store) DeleteWebAuthnCredential(ctx context.Context, id ulid.ULID) error {
+	s.calls[DeleteWebAuthnCredential]++
+	if s.OnDeleteWebAuthnCredential != nil {
+		return s.OnDeleteWebAuthnCredential(ctx, id)
+	}
+	panic(errors.Fmt("%s callback is not mocked", DeleteWebAuthnCredential))
+}
+
+//===========================================================================
+// LockoutStore
+//===========================================================================
+
+const (
+	RetrieveLockout     = "RetrieveLockout"
+	RecordFailedAttempt = "RecordFailedAttempt"
+	ResetLockout        = "ResetLockout"
+)
+
+func (s *Store) RetrieveLockout(ctx context.Context, kind, identifier string) (*models.Lockout, error) {
+	s.calls[RetrieveLockout]++
+	if s.OnRetrieveLockout != nil {
+		return s.OnRetrieveLockout(ctx, kind, identifier)
+	}
+	panic(errors.Fmt("%s callback is not mocked", RetrieveLockout))
+}
+
+func (s *Store) RecordFailedAttempt(ctx context.Context, kind, identifier string, policy models.LockoutPolicy) (*models.Lockout, error) {
+	s.calls[RecordFailedAttempt]++
+	if s.OnRecordFailedAttempt != nil {
+		return s.OnRecordFailedAttempt(ctx, kind, identifier, policy)
+	}
+	panic(errors.Fmt("%s callback is not mocked", RecordFailedAttempt))
+}
+
+func (s *Store) ResetLockout(ctx context.Context, kind, identifier string) error {
+	s.calls[ResetLockout]++
+	if s.OnResetLockout != nil {
+		return s.OnResetLockout(ctx, kind, identifier)
+	}
+	panic(errors.Fmt("%s callback is not mocked", ResetLockout))
+}
+
+//===========================================================================
+// AuditEventStore
+//===========================================================================
+
+const (
+	ListAuditEvents  = "ListAuditEvents"
+	CreateAuditEvent = "CreateAuditEvent"
+)
+
+func (s *Store) ListAuditEvents(ctx context.Context, page *models.AuditEventPage) (*models.AuditEventList, error) {
+	s.calls[ListAuditEvents]++
+	if s.OnListAuditEvents != nil {
+		return s.OnListAuditEvents(ctx, page)
+	}
+	panic(errors.Fmt("%s callback is not mocked", ListAuditEvents))
+}
+
+func (s *Store) CreateAuditEvent(ctx context.Context, event *models.AuditEvent) error {
+	s.calls[CreateAuditEvent]++
+	if s.OnCreateAuditEvent != nil {
+		return s.OnCreateAuditEvent(ctx, event)
+	}
+	panic(errors.Fmt("%s callback is not mocked", CreateAuditEvent))
+}
+
+//===========================================================================
+// WebhookStore
+//===========================================================================
+
+const (
+	ListWebhooks             = "ListWebhooks"
+	CreateWebhook            = "CreateWebhook"
+	RetrieveWebhook          = "RetrieveWebhook"
+	UpdateWebhook            = "UpdateWebhook"
+	DeleteWebhook            = "DeleteWebhook"
+	EnqueueWebhookEvent      = "EnqueueWebhookEvent"
+	ListWebhookDeliveries    = "ListWebhookDeliveries"
+	CreateWebhookDelivery    = "CreateWebhookDelivery"
+	RetrieveWebhookDelivery  = "RetrieveWebhookDelivery"
+	UpdateWebhookDelivery    = "UpdateWebhookDelivery"
+	ListDueWebhookDeliveries = "ListDueWebhookDeliveries"
+)
+
+func (s *Store) ListWebhooks(ctx context.Context, page *models.Page) (*models.WebhookList, error) {
+	s.calls[ListWebhooks]++
+	if s.OnListWebhooks != nil {
+		return s.OnListWebhooks(ctx, page)
+	}
+	panic(errors.Fmt("%s callback is not mocked", ListWebhooks))
+}
+
+func (s *Store) CreateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	s.calls[CreateWebhook]++
+	if s.OnCreateWebhook != nil {
+		return s.OnCreateWebhook(ctx, webhook)
+	}
+	panic(errors.Fmt("%s callback is not mocked", CreateWebhook))
+}
+
+func (s *Store) RetrieveWebhook(ctx context.Context, webhookID ulid.ULID) (*models.Webhook, error) {
+	s.calls[RetrieveWebhook]++
+	if s.OnRetrieveWebhook != nil {
+		return s.OnRetrieveWebhook(ctx, webhookID)
+	}
+	panic(errors.Fmt("%s callback is not mocked", RetrieveWebhook))
+}
+
+func (s *Store) UpdateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	s.calls[UpdateWebhook]++
+	if s.OnUpdateWebhook != nil {
+		return s.OnUpdateWebhook(ctx, webhook)
+	}
+	panic(errors.Fmt("%s callback is not mocked", UpdateWebhook))
+}
+
+func (s *Store) DeleteWebhook(ctx context.Context, webhookID ulid.ULID) error {
+	s.calls[DeleteWebhook]++
+	if s.OnDeleteWebhook != nil {
+		return s.OnDeleteWebhook(ctx, webhookID)
+	}
+	panic(errors.Fmt("%s callback is not mocked", DeleteWebhook))
+}
+
+func (s *Store) EnqueueWebhookEvent(ctx context.Context, eventID ulid.ULID, event enum.WebhookEvent, payload []byte) (int, error) {
+	s.calls[EnqueueWebhookEvent]++
+	if s.OnEnqueueWebhookEvent != nil {
+		return s.OnEnqueueWebhookEvent(ctx, eventID, event, payload)
+	}
+	panic(errors.Fmt("%s callback is not mocked", EnqueueWebhookEvent))
+}
+
+func (s *Store) ListWebhookDeliveries(ctx context.Context, webhookID ulid.ULID, page *models.Page) (*models.WebhookDeliveryList, error) {
+	s.calls[ListWebhookDeliveries]++
+	if s.OnListWebhookDeliveries != nil {
+		return s.OnListWebhookDeliveries(ctx, webhookID, page)
+	}
+	panic(errors.Fmt("%s callback is not mocked", ListWebhookDeliveries))
+}
+
+func (s *Store) CreateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	s.calls[CreateWebhookDelivery]++
+	if s.OnCreateWebhookDelivery != nil {
+		return s.OnCreateWebhookDelivery(ctx, delivery)
+	}
+	panic(errors.Fmt("%s callback is not mocked", CreateWebhookDelivery))
+}
+
+func (s *Store) RetrieveWebhookDelivery(ctx context.Context, deliveryID ulid.ULID) (*models.WebhookDelivery, error) {
+	s.calls[RetrieveWebhookDelivery]++
+	if s.OnRetrieveWebhookDelivery != nil {
+		return s.OnRetrieveWebhookDelivery(ctx, deliveryID)
+	}
+	panic(errors.Fmt("%s callback is not mocked", RetrieveWebhookDelivery))
+}
+
+func (s *Store) UpdateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	s.calls[UpdateWebhookDelivery]++
+	if s.OnUpdateWebhookDelivery != nil {
+		return s.OnUpdateWebhookDelivery(ctx, delivery)
+	}
+	panic(errors.Fmt("%s callback is not mocked", UpdateWebhookDelivery))
+}
+
+func (s *Store) ListDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]*models.WebhookDelivery, error) {
+	s.calls[ListDueWebhookDeliveries]++
+	if s.OnListDueWebhookDeliveries != nil {
+		return s.OnListDueWebhookDeliveries(ctx, now, limit)
+	}
+	panic(errors.Fmt("%s callback is not mocked", ListDueWebhookDeliveries))
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/quarterdeck/pkg/enum"
 	"go.rtnl.ai/quarterdeck/pkg/errors"
 	"go.rtnl.ai/quarterdeck/pkg/store/v1/models"
 	"go.rtnl.ai/ulid"
@@ -30,14 +31,18 @@ type Tx struct {
 	OnRollback func() error
 
 	// UserTxn Callbacks
-	OnListUsers       func(*models.UserPage) (*models.UserList, error)
-	OnCreateUser      func(*models.User) error
-	OnRetrieveUser    func(any) (*models.User, error)
-	OnUpdateUser      func(*models.User) error
-	OnUpdatePassword  func(ulid.ULID, string) error
-	OnUpdateLastLogin func(ulid.ULID, time.Time) error
-	OnVerifyEmail     func(ulid.ULID) error
-	OnDeleteUser      func(ulid.ULID) error
+	OnListUsers        func(*models.UserPage) (*models.UserList, error)
+	OnCreateUser       func(*models.User) error
+	OnRetrieveUser     func(any) (*models.User, error)
+	OnUpdateUser       func(*models.User) error
+	OnUpdatePassword   func(ulid.ULID, string) error
+	OnUpdateLastLogin  func(ulid.ULID, time.Time) error
+	OnVerifyEmail      func(ulid.ULID) error
+	OnUpdateMFA        func(*models.User) error
+	OnReplaceUserRoles func(ulid.ULID, []int64) error
+	OnUpdateUserStatus func(ulid.ULID, enum.UserStatus) error
+	OnDeleteUser       func(ulid.ULID) error
+	OnPurgeUsers       func(time.Time) ([]ulid.ULID, error)
 
 	// RoleTxn Callbacks
 	OnListRoles                func(*models.Page) (*models.RoleList, error)
@@ -55,6 +60,17 @@ type Tx struct {
 	OnUpdatePermission   func(*models.Permission) error
 	OnDeletePermission   func(int64) error
 
+	// OrganizationTxn Callbacks
+	OnListOrganizations              func(*models.Page) (*models.OrganizationList, error)
+	OnCreateOrganization             func(*models.Organization) error
+	OnRetrieveOrganization           func(ulid.ULID) (*models.Organization, error)
+	OnUpdateOrganization             func(*models.Organization) error
+	OnDeleteOrganization             func(ulid.ULID) error
+	OnListUserOrganizations          func(ulid.ULID) ([]*models.Organization, error)
+	OnListOrganizationMembers        func(ulid.ULID) ([]*models.User, error)
+	OnRetrieveOrganizationMember     func(ulid.ULID, ulid.ULID) (*models.User, error)
+	OnReplaceOrganizationMemberRoles func(ulid.ULID, ulid.ULID, []int64) error
+
 	// APIKeyTxn Callbacks
 	OnListAPIKeys                func(*models.Page) (*models.APIKeyList, error)
 	OnCreateAPIKey               func(*models.APIKey) error
@@ -73,6 +89,11 @@ type Tx struct {
 	OnUpdateOIDCClient   func(*models.OIDCClient) error
 	OnDeleteOIDCClient   func(ulid.ULID) error
 
+	// AuthorizationCodeTxn Callbacks
+	OnCreateAuthorizationCode   func(*models.AuthorizationCode) error
+	OnRetrieveAuthorizationCode func(string) (*models.AuthorizationCode, error)
+	OnDeleteAuthorizationCode   func(ulid.ULID) error
+
 	// VeroTokenTxn Callbacks
 	OnCreateVeroToken              func(*models.VeroToken) error
 	OnRetrieveVeroToken            func(ulid.ULID) (*models.VeroToken, error)
@@ -80,7 +101,58 @@ type Tx struct {
 	OnDeleteVeroToken              func(ulid.ULID) error
 	OnCreateResetPasswordVeroToken func(*models.VeroToken) error
 	OnCreateTeamInviteVeroToken    func(*models.VeroToken) error
+	OnCreateVerifyEmailVeroToken   func(*models.VeroToken) error
+	OnCreateChangeEmailVeroToken   func(*models.VeroToken) error
+	OnCompleteEmailChange          func(ulid.ULID) error
 	OnRetrieveTeamInviteVeroToken  func(ulid.ULID) (*models.VeroToken, error)
+	OnListTeamInviteVeroTokens     func() ([]*models.VeroToken, error)
+
+	// RevokedTokenTxn Callbacks
+	OnRevokeToken    func(*models.RevokedToken) error
+	OnIsTokenRevoked func(ulid.ULID) (bool, error)
+
+	// RefreshTokenTxn Callbacks
+	OnCreateRefreshToken       func(*models.RefreshToken) error
+	OnRetrieveRefreshToken     func(ulid.ULID) (*models.RefreshToken, error)
+	OnUseRefreshToken          func(ulid.ULID) (*models.RefreshToken, error)
+	OnRevokeRefreshTokenFamily func(ulid.ULID) error
+
+	// SessionTxn Callbacks
+	OnListSessions       func(ulid.ULID) (*models.SessionList, error)
+	OnCreateSession      func(*models.Session) error
+	OnRetrieveSession    func(ulid.ULID) (*models.Session, error)
+	OnRefreshSession     func(ulid.ULID, ulid.ULID, time.Time) error
+	OnRevokeSession      func(ulid.ULID) error
+	OnRevokeUserSessions func(ulid.ULID) error
+
+	// WebAuthnCredentialTxn Callbacks
+	OnListWebAuthnCredentials    func(ulid.ULID) (*models.WebAuthnCredentialList, error)
+	OnCreateWebAuthnCredential   func(*models.WebAuthnCredential) error
+	OnRetrieveWebAuthnCredential func([]byte) (*models.WebAuthnCredential, error)
+	OnUpdateWebAuthnCredential   func(*models.WebAuthnCredential) error
+	OnDeleteWebAuthnCredential   func(ulid.ULID) error
+
+	// LockoutTxn Callbacks
+	OnRetrieveLockout     func(string, string) (*models.Lockout, error)
+	OnRecordFailedAttempt func(string, string, models.LockoutPolicy) (*models.Lockout, error)
+	OnResetLockout        func(string, string) error
+
+	// AuditEventTxn Callbacks
+	OnListAuditEvents  func(*models.AuditEventPage) (*models.AuditEventList, error)
+	OnCreateAuditEvent func(*models.AuditEvent) error
+
+	// WebhookTxn Callbacks
+	OnListWebhooks             func(*models.Page) (*models.WebhookList, error)
+	OnCreateWebhook            func(*models.Webhook) error
+	OnRetrieveWebhook          func(ulid.ULID) (*models.Webhook, error)
+	OnUpdateWebhook            func(*models.Webhook) error
+	OnDeleteWebhook            func(ulid.ULID) error
+	OnEnqueueWebhookEvent      func(ulid.ULID, enum.WebhookEvent, []byte) (int, error)
+	OnListWebhookDeliveries    func(ulid.ULID, *models.Page) (*models.WebhookDeliveryList, error)
+	OnCreateWebhookDelivery    func(*models.WebhookDelivery) error
+	OnRetrieveWebhookDelivery  func(ulid.ULID) (*models.WebhookDelivery, error)
+	OnUpdateWebhookDelivery    func(*models.WebhookDelivery) error
+	OnListDueWebhookDeliveries func(time.Time, int) ([]*models.WebhookDelivery, error)
 }
 
 //===========================================================================
@@ -238,6 +310,30 @@ func (tx *Tx) VerifyEmail(id ulid.ULID) error {
 	panic(errors.Fmt("%s callback is not mocked", VerifyEmail))
 }
 
+func (tx *Tx) UpdateMFA(user *models.User) error {
+	tx.calls[UpdateMFA]++
+	if tx.OnUpdateMFA != nil {
+		return tx.OnUpdateMFA(user)
+	}
+	panic(errors.Fmt("%s callback is not mocked", UpdateMFA))
+}
+
+func (tx *Tx) ReplaceUserRoles(id ulid.ULID, roleIDs []int64) error {
+	tx.calls[ReplaceUserRoles]++
+	if tx.OnReplaceUserRoles != nil {
+		return tx.OnReplaceUserRoles(id, roleIDs)
+	}
+	panic(errors.Fmt("%s callback is not mocked", ReplaceUserRoles))
+}
+
+func (tx *Tx) UpdateUserStatus(id ulid.ULID, status enum.UserStatus) error {
+	tx.calls[UpdateUserStatus]++
+	if tx.OnUpdateUserStatus != nil {
+		return tx.OnUpdateUserStatus(id, status)
+	}
+	panic(errors.Fmt("%s callback is not mocked", UpdateUserStatus))
+}
+
 func (tx *Tx) DeleteUser(id ulid.ULID) error {
 	tx.calls[DeleteUser]++
 	if tx.OnDeleteUser != nil {
@@ -246,6 +342,14 @@ func (tx *Tx) DeleteUser(id ulid.ULID) error {
 	panic(errors.Fmt("%s callback is not mocked", DeleteUser))
 }
 
+func (tx *Tx) PurgeUsers(deletedBefore time.Time) ([]ulid.ULID, error) {
+	tx.calls[PurgeUsers]++
+	if tx.OnPurgeUsers != nil {
+		return tx.OnPurgeUsers(deletedBefore)
+	}
+	panic(errors.Fmt("%s callback is not mocked", PurgeUsers))
+}
+
 //===========================================================================
 // RoleTxn Methods
 //===========================================================================
@@ -350,6 +454,82 @@ func (tx *Tx) DeletePermission(in int64) error {
 	panic(errors.Fmt("%s callback is not mocked", DeletePermission))
 }
 
+//===========================================================================
+// OrganizationTxn Methods
+//===========================================================================
+
+func (tx *Tx) ListOrganizations(in *models.Page) (*models.OrganizationList, error) {
+	tx.calls[ListOrganizations]++
+	if tx.OnListOrganizations != nil {
+		return tx.OnListOrganizations(in)
+	}
+	panic(errors.Fmt("%s callback is not mocked", ListOrganizations))
+}
+
+func (tx *Tx) CreateOrganization(in *models.Organization) error {
+	tx.calls[CreateOrganization]++
+	if tx.OnCreateOrganization != nil {
+		return tx.OnCreateOrganization(in)
+	}
+	panic(errors.Fmt("%s callback is not mocked", CreateOrganization))
+}
+
+func (tx *Tx) RetrieveOrganization(orgID ulid.ULID) (*models.Organization, error) {
+	tx.calls[RetrieveOrganization]++
+	if tx.OnRetrieveOrganization != nil {
+		return tx.OnRetrieveOrganization(orgID)
+	}
+	panic(errors.Fmt("%s callback is not mocked", RetrieveOrganization))
+}
+
+func (tx *Tx) UpdateOrganization(in *models.Organization) error {
+	tx.calls[UpdateOrganization]++
+	if tx.OnUpdateOrganization != nil {
+		return tx.OnUpdateOrganization(in)
+	}
+	panic(errors.Fmt("%s callback is not mocked", UpdateOrganization))
+}
+
+func (tx *Tx) DeleteOrganization(orgID ulid.ULID) error {
+	tx.calls[DeleteOrganization]++
+	if tx.OnDeleteOrganization != nil {
+		return tx.OnDeleteOrganization(orgID)
+	}
+	panic(errors.Fmt("%s callback is not mocked", DeleteOrganization))
+}
+
+func (tx *Tx) ListUserOrganizations(userID ulid.ULID) ([]*models.Organization, error) {
+	tx.calls[ListUserOrganizations]++
+	if tx.OnListUserOrganizations != nil {
+		return tx.OnListUserOrganizations(userID)
+	}
+	panic(errors.Fmt("%s callback is not mocked", ListUserOrganizations))
+}
+
+func (tx *Tx) ListOrganizationMembers(orgID ulid.ULID) ([]*models.User, error) {
+	tx.calls[ListOrganizationMembers]++
+	if tx.OnListOrganizationMembers != nil {
+		return tx.OnListOrganizationMembers(orgID)
+	}
+	panic(errors.Fmt("%s callback is not mocked", ListOrganizationMembers))
+}
+
+func (tx *Tx) RetrieveOrganizationMember(orgID, userID ulid.ULID) (*models.User, error) {
+	tx.calls[RetrieveOrganizationMember]++
+	if tx.OnRetrieveOrganizationMember != nil {
+		return tx.OnRetrieveOrganizationMember(orgID, userID)
+	}
+	panic(errors.Fmt("%s callback is not mocked", RetrieveOrganizationMember))
+}
+
+func (tx *Tx) ReplaceOrganizationMemberRoles(orgID, userID ulid.ULID, roleIDs []int64) error {
+	tx.calls[ReplaceOrganizationMemberRoles]++
+	if tx.OnReplaceOrganizationMemberRoles != nil {
+		return tx.OnReplaceOrganizationMemberRoles(orgID, userID, roleIDs)
+	}
+	panic(errors.Fmt("%s callback is not mocked", ReplaceOrganizationMemberRoles))
+}
+
 //===========================================================================
 // APIKeyTxn Methods
 //===========================================================================
@@ -470,6 +650,34 @@ func (tx *Tx) DeleteOIDCClient(in ulid.ULID) error {
 	panic(errors.Fmt("%s callback is not mocked", DeleteOIDCClient))
 }
 
+//===========================================================================
+// AuthorizationCodeTxn Methods
+//===========================================================================
+
+func (tx *Tx) CreateAuthorizationCode(in *models.AuthorizationCode) error {
+	tx.calls[CreateAuthorizationCode]++
+	if tx.OnCreateAuthorizationCode != nil {
+		return tx.OnCreateAuthorizationCode(in)
+	}
+	panic(errors.Fmt("%s callback is not mocked", CreateAuthorizationCode))
+}
+
+func (tx *Tx) RetrieveAuthorizationCode(code string) (*models.AuthorizationCode, error) {
+	tx.calls[RetrieveAuthorizationCode]++
+	if tx.OnRetrieveAuthorizationCode != nil {
+		return tx.OnRetrieveAuthorizationCode(code)
+	}
+	panic(errors.Fmt("%s callback is not mocked", RetrieveAuthorizationCode))
+}
+
+func (tx *Tx) DeleteAuthorizationCode(id ulid.ULID) error {
+	tx.calls[DeleteAuthorizationCode]++
+	if tx.OnDeleteAuthorizationCode != nil {
+		return tx.OnDeleteAuthorizationCode(id)
+	}
+	panic(errors.Fmt("%s callback is not mocked", DeleteAuthorizationCode))
+}
+
 //===========================================================================
 // VeroTokenTxn Methods
 //===========================================================================
@@ -522,6 +730,30 @@ func (tx *Tx) CreateTeamInviteVeroToken(in *models.VeroToken) error {
 	panic(errors.Fmt("%s callback is not mocked", CreateResetPasswordVeroToken))
 }
 
+func (tx *Tx) CreateVerifyEmailVeroToken(in *models.VeroToken) error {
+	tx.calls[CreateVerifyEmailVeroToken]++
+	if tx.OnCreateVerifyEmailVeroToken != nil {
+		return tx.OnCreateVerifyEmailVeroToken(in)
+	}
+	panic(errors.Fmt("%s callback is not mocked", CreateVerifyEmailVeroToken))
+}
+
+func (tx *Tx) CreateChangeEmailVeroToken(in *models.VeroToken) error {
+	tx.calls[CreateChangeEmailVeroToken]++
+	if tx.OnCreateChangeEmailVeroToken != nil {
+		return tx.OnCreateChangeEmailVeroToken(in)
+	}
+	panic(errors.Fmt("%s callback is not mocked", CreateChangeEmailVeroToken))
+}
+
+func (tx *Tx) CompleteEmailChange(veroTokenID ulid.ULID) error {
+	tx.calls[CompleteEmailChange]++
+	if tx.OnCompleteEmailChange != nil {
+		return tx.OnCompleteEmailChange(veroTokenID)
+	}
+	panic(errors.Fmt("%s callback is not mocked", CompleteEmailChange))
+}
+
 func (tx *Tx) RetrieveTeamInviteVeroToken(userID ulid.ULID) (*models.VeroToken, error) {
 	tx.calls[RetrieveTeamInviteVeroToken]++
 	if tx.OnRetrieveTeamInviteVeroToken != nil {
@@ -529,3 +761,303 @@ func (tx *Tx) RetrieveTeamInviteVeroToken(userID ulid.ULID) (*models.VeroToken,
 	}
 	panic(errors.Fmt("%s callback is not mocked", RetrieveTeamInviteVeroToken))
 }
+
+func (tx *Tx) ListTeamInviteVeroTokens() ([]*models.VeroToken, error) {
+	tx.calls[ListTeamInviteVeroTokens]++
+	if tx.OnListTeamInviteVeroTokens != nil {
+		return tx.OnListTeamInviteVeroTokens()
+	}
+	panic(errors.Fmt("%s callback is not mocked", ListTeamInviteVeroTokens))
+}
+
+//===========================================================================
+// RevokedTokenTxn Methods
+//===========================================================================
+
+func (tx *Tx) RevokeToken(in *models.RevokedToken) error {
+	tx.calls[RevokeToken]++
+	if tx.OnRevokeToken != nil {
+		return tx.OnRevokeToken(in)
+	}
+	panic(errors.Fmt("%s callback is not mocked", RevokeToken))
+}
+
+func (tx *Tx) IsTokenRevoked(jti ulid.ULID) (bool, error) {
+	tx.calls[IsTokenRevoked]++
+	if tx.OnIsTokenRevoked != nil {
+		return tx.OnIsTokenRevoked(jti)
+	}
+	panic(errors.Fmt("%s callback is not mocked", IsTokenRevoked))
+}
+
+//===========================================================================
+// RefreshTokenTxn Methods
+//===========================================================================
+
+func (tx *Tx) CreateRefreshToken(in *models.RefreshToken) error {
+	tx.calls[CreateRefreshToken]++
+	if tx.OnCreateRefreshToken != nil {
+		return tx.OnCreateRefreshToken(in)
+	}
+	panic(errors.Fmt("%s callback is not mocked", CreateRefreshToken))
+}
+
+func (tx *Tx) RetrieveRefreshToken(jti ulid.ULID) (*models.RefreshToken, error) {
+	tx.calls[RetrieveRefreshToken]++
+	if tx.OnRetrieveRefreshToken != nil {
+		return tx.OnRetrieveRefreshToken(jti)
+	}
+	panic(errors.Fmt("%s callback is not mocked", RetrieveRefreshToken))
+}
+
+func (tx *Tx) UseRefreshToken(jti ulid.ULID) (*models.RefreshToken, error) {
+	tx.calls[UseRefreshToken]++
+	if tx.OnUseRefreshToken != nil {
+		return tx.OnUseRefreshToken(jti)
+	}
+	panic(errors.Fmt("%s callback is not mocked", UseRefreshToken))
+}
+
+func (tx *Tx) RevokeRefreshTokenFamily(familyID ulid.ULID) error {
+	tx.calls[RevokeRefreshTokenFamily]++
+	if tx.OnRevokeRefreshTokenFamily != nil {
+		return tx.OnRevokeRefreshTokenFamily(familyID)
+	}
+	panic(errors.Fmt("%s callback is not mocked", RevokeRefreshTokenFamily))
+}
+
+//===========================================================================
+// SessionTxn Methods
+//===========================================================================
+
+func (tx *Tx) ListSessions(userID ulid.ULID) (*models.SessionList, error) {
+	tx.calls[ListSessions]++
+	if tx.OnListSessions != nil {
+		return tx.OnListSessions(userID)
+	}
+	panic(errors.Fmt("%s callback is not mocked", ListSessions))
+}
+
+func (tx *Tx) CreateSession(in *models.Session) error {
+	tx.calls[CreateSession]++
+	if tx.OnCreateSession != nil {
+		return tx.OnCreateSession(in)
+	}
+	panic(errors.Fmt("%s callback is not mocked", CreateSession))
+}
+
+func (tx *Tx) RetrieveSession(sessionID ulid.ULID) (*models.Session, error) {
+	tx.calls[RetrieveSession]++
+	if tx.OnRetrieveSession != nil {
+		return tx.OnRetrieveSession(sessionID)
+	}
+	panic(errors.Fmt("%s callback is not mocked", RetrieveSession))
+}
+
+func (tx *Tx) RefreshSession(sessionID ulid.ULID, jti ulid.ULID, expiration time.Time) error {
+	tx.calls[RefreshSession]++
+	if tx.OnRefreshSession != nil {
+		return tx.OnRefreshSession(sessionID, jti, expiration)
+	}
+	panic(errors.Fmt("%s callback is not mocked", RefreshSession))
+}
+
+func (tx *Tx) RevokeSession(sessionID ulid.ULID) error {
+	tx.calls[RevokeSession]++
+	if tx.OnRevokeSession != nil {
+		return tx.OnRevokeSession(sessionID)
+	}
+	panic(errors.Fmt("%s callback is not mocked", RevokeSession))
+}
+
+func (tx *Tx) RevokeUserSessions(userID ulid.ULID) error {
+	tx.calls[RevokeUserSessions]++
+	if tx.OnRevokeUserSessions != nil {
+		return tx.OnRevokeUserSessions(userID)
+	}
+	panic(errors.Fmt("%s callback is not mocked", RevokeUserSessions))
+}
+
+//===========================================================================
+// WebAuthnCredentialTxn Methods
+//===========================================================================
+
+func (tx *Tx) ListWebAuthnCredentials(userID ulid.ULID) (*models.WebAuthnCredentialList, error) {
+	tx.calls[ListWebAuthnCredentials]++
+	if tx.OnListWebAuthnCredentials != nil {
+		return tx.OnListWebAuthnCredentials(userID)
+	}
+	panic(errors.Fmt("%s callback is not mocked", ListWebAuthnCredentials))
+}
+
+func (tx *Tx) CreateWebAuthnCredential(in *models.WebAuthnCredential) error {
+	tx.calls[CreateWebAuthnCredential]++
+	if tx.OnCreateWebAuthnCredential != nil {
+		return tx.OnCreateWebAuthnCredential(in)
+	}
+	panic(errors.Fmt("%s callback is not mocked", CreateWebAuthnCredential))
+}
+
+func (tx *Tx) RetrieveWebAuthnCredential(credentialID []byte) (*models.WebAuthnCredential, error) {
+	tx.calls[RetrieveWebAuthnCredential]++
+	if tx.OnRetrieveWebAuthnCredential != nil {
+		return tx.OnRetrieveWebAuthnCredential(credentialID)
+	}
+	panic(errors.Fmt("%s callback is not mocked", RetrieveWebAuthnCredential))
+}
+
+func (tx *Tx) UpdateWebAuthnCredential(in *models.WebAuthnCredential) error {
+	tx.calls[UpdateWebAuthnCredential]++
+	if tx.OnUpdateWebAuthnCredential != nil {
+		return tx.OnUpdateWebAuthnCredential(in)
+	}
+	panic(errors.Fmt("%s callback is not mocked", UpdateWebAuthnCredential))
+}
+
+func (tx *Tx) DeleteWebAuthnCredential(id ulid.ULID) error {
+	tx.calls[DeleteWebAuthnCredential]++
+	if tx.OnDeleteWebAuthnCredential != nil {
+		return tx.OnDeleteWebAuthnCredential(id)
+	}
+	panic(errors.Fmt("%s callback is not mocked", DeleteWebAuthnCredential))
+}
+
+//===========================================================================
+// LockoutTxn Methods
+//===========================================================================
+
+func (tx *Tx) RetrieveLockout(kind, identifier string) (*models.Lockout, error) {
+	tx.calls[RetrieveLockout]++
+	if tx.OnRetrieveLockout != nil {
+		return tx.OnRetrieveLockout(kind, identifier)
+	}
+	panic(errors.Fmt("%s callback is not mocked", RetrieveLockout))
+}
+
+func (tx *Tx) RecordFailedAttempt(kind, identifier string, policy models.LockoutPolicy) (*models.Lockout, error) {
+	tx.calls[RecordFailedAttempt]++
+	if tx.OnRecordFailedAttempt != nil {
+		return tx.OnRecordFailedAttempt(kind, identifier, policy)
+	}
+	panic(errors.Fmt("%s callback is not mocked", RecordFailedAttempt))
+}
+
+func (tx *Tx) ResetLockout(kind, identifier string) error {
+	tx.calls[ResetLockout]++
+	if tx.OnResetLockout != nil {
+		return tx.OnResetLockout(kind, identifier)
+	}
+	panic(errors.Fmt("%s callback is not mocked", ResetLockout))
+}
+
+//===========================================================================
+// AuditEventTxn Methods
+//===========================================================================
+
+func (tx *Tx) ListAuditEvents(page *models.AuditEventPage) (*models.AuditEventList, error) {
+	tx.calls[ListAuditEvents]++
+	if tx.OnListAuditEvents != nil {
+		return tx.OnListAuditEvents(page)
+	}
+	panic(errors.Fmt("%s callback is not mocked", ListAuditEvents))
+}
+
+func (tx *Tx) CreateAuditEvent(event *models.AuditEvent) error {
+	tx.calls[CreateAuditEvent]++
+	if tx.OnCreateAuditEvent != nil {
+		return tx.OnCreateAuditEvent(event)
+	}
+	panic(errors.Fmt("%s callback is not mocked", CreateAuditEvent))
+}
+
+//===========================================================================
+// WebhookTxn Methods
+//===========================================================================
+
+func (tx *Tx) ListWebhooks(page *models.Page) (*models.WebhookList, error) {
+	tx.calls[ListWebhooks]++
+	if tx.OnListWebhooks != nil {
+		return tx.OnListWebhooks(page)
+	}
+	panic(errors.Fmt("%s callback is not mocked", ListWebhooks))
+}
+
+func (tx *Tx) CreateWebhook(webhook *models.Webhook) error {
+	tx.calls[CreateWebhook]++
+	if tx.OnCreateWebhook != nil {
+		return tx.OnCreateWebhook(webhook)
+	}
+	panic(errors.Fmt("%s callback is not mocked", CreateWebhook))
+}
+
+func (tx *Tx) RetrieveWebhook(webhookID ulid.ULID) (*models.Webhook, error) {
+	tx.calls[RetrieveWebhook]++
+	if tx.OnRetrieveWebhook != nil {
+		return tx.OnRetrieveWebhook(webhookID)
+	}
+	panic(errors.Fmt("%s callback is not mocked", RetrieveWebhook))
+}
+
+func (tx *Tx) UpdateWebhook(webhook *models.Webhook) error {
+	tx.calls[UpdateWebhook]++
+	if tx.OnUpdateWebhook != nil {
+		return tx.OnUpdateWebhook(webhook)
+	}
+	panic(errors.Fmt("%s callback is not mocked", UpdateWebhook))
+}
+
+func (tx *Tx) DeleteWebhook(webhookID ulid.ULID) error {
+	tx.calls[DeleteWebhook]++
+	if tx.OnDeleteWebhook != nil {
+		return tx.OnDeleteWebhook(webhookID)
+	}
+	panic(errors.Fmt("%s callback is not mocked", DeleteWebhook))
+}
+
+func (tx *Tx) EnqueueWebhookEvent(eventID ulid.ULID, event enum.WebhookEvent, payload []byte) (int, error) {
+	tx.calls[EnqueueWebhookEvent]++
+	if tx.OnEnqueueWebhookEvent != nil {
+		return tx.OnEnqueueWebhookEvent(eventID, event, payload)
+	}
+	panic(errors.Fmt("%s callback is not mocked", EnqueueWebhookEvent))
+}
+
+func (tx *Tx) ListWebhookDeliveries(webhookID ulid.ULID, page *models.Page) (*models.WebhookDeliveryList, error) {
+	tx.calls[ListWebhookDeliveries]++
+	if tx.OnListWebhookDeliveries != nil {
+		return tx.OnListWebhookDeliveries(webhookID, page)
+	}
+	panic(errors.Fmt("%s callback is not mocked", ListWebhookDeliveries))
+}
+
+func (tx *Tx) CreateWebhookDelivery(delivery *models.WebhookDelivery) error {
+	tx.calls[CreateWebhookDelivery]++
+	if tx.OnCreateWebhookDelivery != nil {
+		return tx.OnCreateWebhookDelivery(delivery)
+	}
+	panic(errors.Fmt("%s callback is not mocked", CreateWebhookDelivery))
+}
+
+func (tx *Tx) RetrieveWebhookDelivery(deliveryID ulid.ULID) (*models.WebhookDelivery, error) {
+	tx.calls[RetrieveWebhookDelivery]++
+	if tx.OnRetrieveWebhookDelivery != nil {
+		return tx.OnRetrieveWebhookDelivery(deliveryID)
+	}
+	panic(errors.Fmt("%s callback is not mocked", RetrieveWebhookDelivery))
+}
+
+func (tx *Tx) UpdateWebhookDelivery(delivery *models.WebhookDelivery) error {
+	tx.calls[UpdateWebhookDelivery]++
+	if tx.OnUpdateWebhookDelivery != nil {
+		return tx.OnUpdateWebhookDelivery(delivery)
+	}
+	panic(errors.Fmt("%s callback is not mocked", UpdateWebhookDelivery))
+}
+
+func (tx *Tx) ListDueWebhookDeliveries(now time.Time, limit int) ([]*models.WebhookDelivery, error) {
+	tx.calls[ListDueWebhookDeliveries]++
+	if tx.OnListDueWebhookDeliveries != nil {
+		return tx.OnListDueWebhookDeliveries(now, limit)
+	}
+	panic(errors.Fmt("%s callback is not mocked", ListDueWebhookDeliveries))
+}
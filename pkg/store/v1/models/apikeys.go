@@ -17,6 +17,7 @@ type APIKey struct {
 	CreatedBy   ulid.ULID
 	LastSeen    sql.NullTime
 	Revoked     sql.NullTime
+	OrgID       ulid.NullULID // the organization the key belongs to, if any
 	permissions []string
 }
 
@@ -41,6 +42,7 @@ func (k *APIKey) Scan(scanner Scanner) error {
 		&k.Revoked,
 		&k.Created,
 		&k.Modified,
+		&k.OrgID,
 	)
 }
 
@@ -55,6 +57,7 @@ func (k *APIKey) ScanSummary(scanner Scanner) error {
 		&k.Revoked,
 		&k.Created,
 		&k.Modified,
+		&k.OrgID,
 	)
 }
 
@@ -70,6 +73,7 @@ func (k *APIKey) Params() []any {
 		sql.Named("revoked", k.Revoked),
 		sql.Named("created", k.Created),
 		sql.Named("modified", k.Modified),
+		sql.Named("orgID", k.OrgID),
 	}
 }
 
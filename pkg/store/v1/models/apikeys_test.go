@@ -25,14 +25,15 @@ func TestAPIKeyParams(t *testing.T) {
 		CreatedBy:   ulid.MakeSecure(),
 		Revoked:     sql.NullTime{Valid: false},
 		LastSeen:    sql.NullTime{Valid: true, Time: time.Now()},
+		OrgID:       ulid.NullULID{Valid: true, ULID: ulid.MakeSecure()},
 	}
 
 	CheckParams(t, apikey.Params(),
 		[]string{
-			"id", "description", "clientID", "secret", "createdBy", "lastSeen", "revoked", "created", "modified",
+			"id", "description", "clientID", "secret", "createdBy", "lastSeen", "revoked", "created", "modified", "orgID",
 		},
 		[]any{
-			apikey.ID, apikey.Description, apikey.ClientID, apikey.Secret, apikey.CreatedBy, apikey.LastSeen, apikey.Revoked, apikey.Created, apikey.Modified,
+			apikey.ID, apikey.Description, apikey.ClientID, apikey.Secret, apikey.CreatedBy, apikey.LastSeen, apikey.Revoked, apikey.Created, apikey.Modified, apikey.OrgID,
 		},
 	)
 }
@@ -49,6 +50,7 @@ func TestAPIKeyScan(t *testing.T) {
 			time.Now().Add(-30 * time.Minute), // Revoked
 			time.Now().Add(-14 * time.Hour),   // Created
 			time.Now().Add(-30 * time.Minute), // Modified
+			ulid.MakeSecure().String(),        // OrgID
 		}
 		mockScanner := &mock.Scanner{}
 		mockScanner.SetData(data)
@@ -67,6 +69,7 @@ func TestAPIKeyScan(t *testing.T) {
 		require.Equal(t, data[6], model.Revoked.Time, "expected field Revoked to match data[6]")
 		require.Equal(t, data[7], model.Created, "expected field Created to match data[7]")
 		require.Equal(t, data[8], model.Modified, "expected field Modified to match data[8]")
+		require.Equal(t, data[9], model.OrgID.ULID.String(), "expected field OrgID to match data[9]")
 	})
 
 	t.Run("Nulls", func(t *testing.T) {
@@ -80,6 +83,7 @@ func TestAPIKeyScan(t *testing.T) {
 			nil,                        // Revoked
 			time.Now(),                 // Created
 			time.Time{},                // Modified (testing zero time)
+			nil,                        // OrgID (testing null ulid)
 		}
 		mockScanner := &mock.Scanner{}
 		mockScanner.SetData(data)
@@ -94,6 +98,7 @@ func TestAPIKeyScan(t *testing.T) {
 		require.False(t, model.LastSeen.Valid, "expected field LastSeen to be invalid (null)")
 		require.False(t, model.Revoked.Valid, "expected field Revoked to be invalid (null)")
 		require.True(t, model.Modified.IsZero(), "expected field Modified to be zero time")
+		require.False(t, model.OrgID.Valid, "expected field OrgID to be invalid (null)")
 	})
 
 	t.Run("Error", func(t *testing.T) {
@@ -117,6 +122,7 @@ func TestAPIKeyScanSummary(t *testing.T) {
 			nil,                               // Revoked
 			time.Now().Add(-14 * time.Hour),   // Created
 			time.Now().Add(-30 * time.Minute), // Modified
+			nil,                               // OrgID
 		}
 		mockScanner := &mock.Scanner{}
 		mockScanner.SetData(data)
@@ -135,6 +141,7 @@ func TestAPIKeyScanSummary(t *testing.T) {
 		require.False(t, model.Revoked.Valid, "expected field Revoked to be null")
 		require.Equal(t, data[6], model.Created, "expected field Created to match data[7]")
 		require.Equal(t, data[7], model.Modified, "expected field Modified to match data[8]")
+		require.False(t, model.OrgID.Valid, "expected field OrgID to be null")
 	})
 
 	t.Run("Error", func(t *testing.T) {
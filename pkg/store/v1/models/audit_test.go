@@ -0,0 +1,94 @@
+package models_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/ulid"
+
+	. "go.rtnl.ai/quarterdeck/pkg/store/v1/models"
+)
+
+func TestAuditEventParams(t *testing.T) {
+	event := &AuditEvent{
+		Model: Model{
+			ID:       modelID,
+			Created:  created,
+			Modified: modified,
+		},
+		ActorType:   sql.NullString{String: AuditUser, Valid: true},
+		ActorID:     ulid.NullULID{ULID: ulid.MustParse("01JPYRNYMEHNEZCS0JYX1CP57A"), Valid: true},
+		Action:      AuditUpdate,
+		SubjectType: AuditAPIKey,
+		SubjectID:   "01JQ0F4W3Z3R5Z4D0Y0KQ4M7ZB",
+		ClientIP:    sql.NullString{String: "192.168.1.1", Valid: true},
+		Diff:        sql.NullString{String: `{"description":{"from":"foo","to":"bar"}}`, Valid: true},
+	}
+
+	CheckParams(t, event.Params(),
+		[]string{
+			"id", "actorType", "actorID", "action", "subjectType", "subjectID", "clientIP", "userAgent", "requestID", "diff", "created", "modified",
+		},
+		[]any{
+			event.ID, event.ActorType, event.ActorID, event.Action, event.SubjectType, event.SubjectID, event.ClientIP, event.UserAgent, event.RequestID, event.Diff, event.Created, event.Modified,
+		},
+	)
+}
+
+func TestAuditDiff(t *testing.T) {
+	type resource struct {
+		Name   string   `json:"name"`
+		Secret string   `json:"secret,omitempty"`
+		Roles  []string `json:"roles"`
+	}
+
+	t.Run("Create", func(t *testing.T) {
+		diff, err := AuditDiff(nil, &resource{Name: "foo", Secret: "supersecret", Roles: []string{"admin"}})
+		require.NoError(t, err)
+		require.True(t, diff.Valid)
+		require.JSONEq(t, `{"name":{"to":"foo"},"roles":{"to":["admin"]}}`, diff.String)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		before := &resource{Name: "foo", Roles: []string{"admin"}}
+		after := &resource{Name: "foo", Secret: "supersecret", Roles: []string{"admin", "observer"}}
+
+		diff, err := AuditDiff(before, after)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"roles":{"from":["admin"],"to":["admin","observer"]}}`, diff.String)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		var after *resource
+		diff, err := AuditDiff(&resource{Name: "foo"}, after)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"name":{"from":"foo"}}`, diff.String)
+	})
+
+	t.Run("Timestamps", func(t *testing.T) {
+		type timestamped struct {
+			Name     string    `json:"name"`
+			Created  time.Time `json:"created"`
+			Modified time.Time `json:"modified"`
+		}
+
+		before := &timestamped{Name: "foo", Created: time.Now().Add(-1 * time.Hour), Modified: time.Now().Add(-1 * time.Hour)}
+		after := &timestamped{Name: "bar", Created: before.Created, Modified: time.Now()}
+
+		diff, err := AuditDiff(before, after)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"name":{"from":"foo","to":"bar"}}`, diff.String)
+	})
+
+	t.Run("NoChange", func(t *testing.T) {
+		diff, err := AuditDiff(&resource{Name: "foo"}, &resource{Name: "foo"})
+		require.NoError(t, err)
+		require.False(t, diff.Valid)
+
+		diff, err = AuditDiff(nil, nil)
+		require.NoError(t, err)
+		require.False(t, diff.Valid)
+	})
+}
@@ -0,0 +1,93 @@
+package models_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	. "go.rtnl.ai/quarterdeck/pkg/store/v1/models"
+)
+
+func TestLockoutParams(t *testing.T) {
+	lockout := &Lockout{
+		Model: Model{
+			ID:       modelID,
+			Created:  created,
+			Modified: modified,
+		},
+		Kind:           LockoutUser,
+		Identifier:     "jannel@example.com",
+		FailedAttempts: 3,
+	}
+
+	CheckParams(t, lockout.Params(),
+		[]string{
+			"id", "kind", "identifier", "failedAttempts", "lastFailure", "lockedUntil", "created", "modified",
+		},
+		[]any{
+			lockout.ID, lockout.Kind, lockout.Identifier, lockout.FailedAttempts, lockout.LastFailure, lockout.LockedUntil, lockout.Created, lockout.Modified,
+		},
+	)
+}
+
+func TestLockoutFail(t *testing.T) {
+	policy := LockoutPolicy{Threshold: 3, Duration: time.Minute, MaxDuration: 5 * time.Minute}
+	lockout := &Lockout{Kind: LockoutUser, Identifier: "jannel@example.com"}
+
+	require.False(t, lockout.Fail(policy))
+	require.False(t, lockout.Fail(policy))
+	require.False(t, lockout.IsLocked(), "should not be locked before the threshold")
+	require.Zero(t, lockout.RetryAfter())
+	require.True(t, lockout.LastFailure.Valid)
+
+	require.True(t, lockout.Fail(policy), "expected the threshold to lock out the identifier")
+	require.Equal(t, int64(3), lockout.FailedAttempts)
+	require.True(t, lockout.IsLocked())
+	require.InDelta(t, time.Minute, lockout.RetryAfter(), float64(time.Second))
+
+	// Further failures double the lockout duration
+	require.True(t, lockout.Fail(policy))
+	require.InDelta(t, 2*time.Minute, lockout.RetryAfter(), float64(time.Second))
+
+	// Expired lockouts are no longer locked
+	lockout.LockedUntil = sql.NullTime{Time: time.Now().Add(-time.Second), Valid: true}
+	require.False(t, lockout.IsLocked())
+
+	t.Run("Disabled", func(t *testing.T) {
+		lockout := &Lockout{}
+		for i := 0; i < 100; i++ {
+			require.False(t, lockout.Fail(LockoutPolicy{}))
+		}
+		require.False(t, lockout.IsLocked())
+		require.Equal(t, int64(100), lockout.FailedAttempts)
+	})
+}
+
+func TestLockoutBackoff(t *testing.T) {
+	policy := LockoutPolicy{Threshold: 5, Duration: time.Minute, MaxDuration: time.Hour}
+
+	testCases := []struct {
+		attempts int64
+		expected time.Duration
+	}{
+		{0, 0},
+		{4, 0},
+		{5, time.Minute},
+		{6, 2 * time.Minute},
+		{7, 4 * time.Minute},
+		{10, 32 * time.Minute},
+		{11, time.Hour},
+		{1000, time.Hour},
+	}
+
+	for i, tc := range testCases {
+		require.Equal(t, tc.expected, policy.Backoff(tc.attempts), "test case %d failed", i)
+	}
+}
+
+func TestNormalizeIdentifier(t *testing.T) {
+	require.Equal(t, "jannel@example.com", NormalizeIdentifier(LockoutUser, "  Jannel@Example.com "))
+	require.Equal(t, "ZnaBcDeFg", NormalizeIdentifier(LockoutClient, "ZnaBcDeFg "))
+}
@@ -0,0 +1,178 @@
+package models
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"go.rtnl.ai/ulid"
+)
+
+// AuthorizationCode is a one-time code issued to an OIDC client by the OAuth2
+// authorization endpoint after the user has authenticated. The client exchanges the
+// code (along with its credentials and PKCE verifier) for tokens at the token endpoint.
+// The Code field holds a hash of the code that was delivered to the client, never the
+// code itself.
+type AuthorizationCode struct {
+	Model
+	Code                string
+	ClientID            string
+	UserID              ulid.ULID
+	RedirectURI         string
+	Scope               sql.NullString
+	Nonce               sql.NullString
+	CodeChallenge       sql.NullString
+	CodeChallengeMethod sql.NullString
+	Expiration          time.Time
+}
+
+//===========================================================================
+// Scanning and Params
+//===========================================================================
+
+// Scan the AuthorizationCode struct from a database row.
+func (a *AuthorizationCode) Scan(scanner Scanner) error {
+	return scanner.Scan(
+		&a.ID,
+		&a.Code,
+		&a.ClientID,
+		&a.UserID,
+		&a.RedirectURI,
+		&a.Scope,
+		&a.Nonce,
+		&a.CodeChallenge,
+		&a.CodeChallengeMethod,
+		&a.Expiration,
+		&a.Created,
+		&a.Modified,
+	)
+}
+
+// Params returns all AuthorizationCode fields as named params to be used in a SQL query.
+func (a *AuthorizationCode) Params() []any {
+	return []any{
+		sql.Named("id", a.ID),
+		sql.Named("code", a.Code),
+		sql.Named("clientID", a.ClientID),
+		sql.Named("userID", a.UserID),
+		sql.Named("redirectURI", a.RedirectURI),
+		sql.Named("scope", a.Scope),
+		sql.Named("nonce", a.Nonce),
+		sql.Named("codeChallenge", a.CodeChallenge),
+		sql.Named("codeChallengeMethod", a.CodeChallengeMethod),
+		sql.Named("expiration", a.Expiration),
+		sql.Named("created", a.Created),
+		sql.Named("modified", a.Modified),
+	}
+}
+
+//===========================================================================
+// Helpers
+//===========================================================================
+
+// IsExpired returns true if the authorization code can no longer be exchanged.
+func (a *AuthorizationCode) IsExpired() bool {
+	return a.Expiration.IsZero() || time.Now().After(a.Expiration)
+}
+
+// Scopes returns the space delimited scope as a list of individual scopes.
+func (a *AuthorizationCode) Scopes() []string {
+	if !a.Scope.Valid {
+		return nil
+	}
+	return strings.Fields(a.Scope.String)
+}
+
+// HasScope returns true if the authorization code was granted the specified scope.
+func (a *AuthorizationCode) HasScope(scope string) bool {
+	for _, s := range a.Scopes() {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+//===========================================================================
+// Revoked Tokens
+//===========================================================================
+
+// RevokedToken is an entry in the denylist of access and refresh tokens that have been
+// revoked before they expired. The ID of the model is the jti claim of the token and
+// the expiration is the time after which the token (and therefore the entry) is no
+// longer valid in any case.
+type RevokedToken struct {
+	Model
+	Expiration time.Time
+}
+
+// Scan the RevokedToken struct from a database row.
+func (r *RevokedToken) Scan(scanner Scanner) error {
+	return scanner.Scan(
+		&r.ID,
+		&r.Expiration,
+		&r.Created,
+		&r.Modified,
+	)
+}
+
+// Params returns all RevokedToken fields as named params to be used in a SQL query.
+func (r *RevokedToken) Params() []any {
+	return []any{
+		sql.Named("id", r.ID),
+		sql.Named("expiration", r.Expiration),
+		sql.Named("created", r.Created),
+		sql.Named("modified", r.Modified),
+	}
+}
+
+//===========================================================================
+// Refresh Tokens
+//===========================================================================
+
+// RefreshToken tracks the use of a refresh token server-side so that each refresh
+// token can only be exchanged once. The ID of the model is the jti claim of the token
+// and the FamilyID is the jti of the first refresh token issued on login; every token
+// that is rotated from it shares the same family so that the whole chain can be
+// revoked if a used refresh token is ever presented again.
+type RefreshToken struct {
+	Model
+	FamilyID   ulid.ULID
+	Subject    string
+	UsedOn     sql.NullTime
+	RevokedOn  sql.NullTime
+	Expiration time.Time
+}
+
+// Scan the RefreshToken struct from a database row.
+func (r *RefreshToken) Scan(scanner Scanner) error {
+	return scanner.Scan(
+		&r.ID,
+		&r.FamilyID,
+		&r.Subject,
+		&r.UsedOn,
+		&r.RevokedOn,
+		&r.Expiration,
+		&r.Created,
+		&r.Modified,
+	)
+}
+
+// Params returns all RefreshToken fields as named params to be used in a SQL query.
+func (r *RefreshToken) Params() []any {
+	return []any{
+		sql.Named("id", r.ID),
+		sql.Named("familyID", r.FamilyID),
+		sql.Named("subject", r.Subject),
+		sql.Named("usedOn", r.UsedOn),
+		sql.Named("revokedOn", r.RevokedOn),
+		sql.Named("expiration", r.Expiration),
+		sql.Named("created", r.Created),
+		sql.Named("modified", r.Modified),
+	}
+}
+
+// IsExpired returns true if the refresh token can no longer be used.
+func (r *RefreshToken) IsExpired() bool {
+	return r.Expiration.IsZero() || time.Now().After(r.Expiration)
+}
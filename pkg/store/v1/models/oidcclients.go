@@ -10,6 +10,7 @@ import (
 type OIDCClient struct {
 	Model
 	CreatedBy ulid.ULID
+	OrgID     ulid.NullULID // the organization the client belongs to, if any
 
 	// OIDC spec descriptive fields
 
@@ -54,6 +55,7 @@ func (k *OIDCClient) Scan(scanner Scanner) (err error) {
 		&k.CreatedBy,
 		&k.Created,
 		&k.Modified,
+		&k.OrgID,
 	); err != nil {
 		return err
 	}
@@ -95,6 +97,7 @@ func (k *OIDCClient) ScanSummary(scanner Scanner) (err error) {
 		&k.CreatedBy,
 		&k.Created,
 		&k.Modified,
+		&k.OrgID,
 	); err != nil {
 		return err
 	}
@@ -151,5 +154,6 @@ func (k *OIDCClient) Params() []any {
 		sql.Named("createdBy", k.CreatedBy),
 		sql.Named("created", k.Created),
 		sql.Named("modified", k.Modified),
+		sql.Named("orgID", k.OrgID),
 	}
 }
@@ -32,6 +32,7 @@ func TestOIDCClientParams(t *testing.T) {
 		ClientID:     "XUiRZrNDUnLjeenQQmblpv",
 		Secret:       "$argon2id$v=19$m=65536,t=1,p=2$Bk7GvOXGHdfDdSZH1OUyIA==$1AcYMKcJwm/DngmCw9db/J7PbvPzav/i/kk+Z0EKd44=",
 		CreatedBy:    ulid.MakeSecure(),
+		OrgID:        ulid.NullULID{Valid: true, ULID: ulid.MakeSecure()},
 	}
 
 	redirectURIsJSON, _ := json.Marshal(redirectURIs)
@@ -41,12 +42,12 @@ func TestOIDCClientParams(t *testing.T) {
 		[]string{
 			"id", "clientName", "clientURI", "logoURI", "policyURI", "tosURI",
 			"redirectURIs", "contacts", "clientID", "secret", "createdBy",
-			"created", "modified",
+			"created", "modified", "orgID",
 		},
 		[]any{
 			client.ID, client.ClientName, client.ClientURI, client.LogoURI, client.PolicyURI, client.TOSURI,
 			string(redirectURIsJSON), string(contactsJSON), client.ClientID, client.Secret, client.CreatedBy,
-			client.Created, client.Modified,
+			client.Created, client.Modified, client.OrgID,
 		},
 	)
 }
@@ -70,6 +71,7 @@ func TestOIDCClientScan(t *testing.T) {
 			ulid.MakeSecure().String(),        // CreatedBy
 			time.Now().Add(-14 * time.Hour),   // Created
 			time.Now().Add(-30 * time.Minute), // Modified
+			ulid.MakeSecure().String(),        // OrgID
 		}
 		mockScanner := &mock.Scanner{}
 		mockScanner.SetData(data)
@@ -100,6 +102,7 @@ func TestOIDCClientScan(t *testing.T) {
 		require.Equal(t, data[10], model.CreatedBy.String(), "expected field CreatedBy to match data[10]")
 		require.Equal(t, data[11], model.Created, "expected field Created to match data[11]")
 		require.Equal(t, data[12], model.Modified, "expected field Modified to match data[12]")
+		require.Equal(t, data[13], model.OrgID.ULID.String(), "expected field OrgID to match data[13]")
 	})
 
 	t.Run("Nulls", func(t *testing.T) {
@@ -117,6 +120,7 @@ func TestOIDCClientScan(t *testing.T) {
 			ulid.MakeSecure().String(), // CreatedBy
 			time.Now(),                 // Created
 			time.Time{},                // Modified (testing zero time)
+			nil,                        // OrgID (testing null ulid)
 		}
 		mockScanner := &mock.Scanner{}
 		mockScanner.SetData(data)
@@ -131,6 +135,7 @@ func TestOIDCClientScan(t *testing.T) {
 		require.Nil(t, model.RedirectURIs, "expected RedirectURI nil when JSON null")
 		require.Nil(t, model.Contacts, "expected Contacts nil when JSON null")
 		require.True(t, model.Modified.IsZero(), "expected field Modified to be zero time")
+		require.False(t, model.OrgID.Valid, "expected OrgID invalid (null)")
 	})
 
 	t.Run("Error", func(t *testing.T) {
@@ -160,6 +165,7 @@ func TestOIDCClientScanSummary(t *testing.T) {
 		ulid.MakeSecure().String(),        // CreatedBy
 		time.Now().Add(-14 * time.Hour),   // Created
 		time.Now().Add(-30 * time.Minute), // Modified
+		nil,                               // OrgID
 	}
 	mockScanner := &mock.Scanner{}
 	mockScanner.SetData(data)
@@ -190,4 +196,5 @@ func TestOIDCClientScanSummary(t *testing.T) {
 	require.Equal(t, data[9], model.CreatedBy.String(), "expected field CreatedBy to match data[9]")
 	require.Equal(t, data[10], model.Created, "expected field Created to match data[10]")
 	require.Equal(t, data[11], model.Modified, "expected field Modified to match data[11]")
+	require.False(t, model.OrgID.Valid, "expected field OrgID to be null")
 }
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"database/sql"
+	"net/url"
+)
+
+// Organization is a tenant of Quarterdeck such as a customer workspace. Users are
+// members of one or more organizations with roles that only apply in that organization
+// and API keys and OIDC clients belong to a single organization. The details of the
+// organization are used in place of the global organization configuration.
+type Organization struct {
+	Model
+	Name          string
+	StreetAddress sql.NullString
+	HomepageURI   sql.NullString
+	SupportEmail  sql.NullString
+}
+
+type OrganizationList struct {
+	Page          *Page
+	Organizations []*Organization
+}
+
+//===========================================================================
+// Scanning and Params
+//===========================================================================
+
+// Scan the Organization struct from a database row.
+func (o *Organization) Scan(scanner Scanner) error {
+	return scanner.Scan(
+		&o.ID,
+		&o.Name,
+		&o.StreetAddress,
+		&o.HomepageURI,
+		&o.SupportEmail,
+		&o.Created,
+		&o.Modified,
+	)
+}
+
+// Params returns all Organization fields as named params to be used in a SQL query.
+func (o *Organization) Params() []any {
+	return []any{
+		sql.Named("id", o.ID),
+		sql.Named("name", o.Name),
+		sql.Named("streetAddress", o.StreetAddress),
+		sql.Named("homepageURI", o.HomepageURI),
+		sql.Named("supportEmail", o.SupportEmail),
+		sql.Named("created", o.Created),
+		sql.Named("modified", o.Modified),
+	}
+}
+
+//===========================================================================
+// Helper Methods
+//===========================================================================
+
+// HomepageURL returns the parsed homepage of the organization or nil if it is not set
+// or cannot be parsed.
+func (o Organization) HomepageURL() *url.URL {
+	if !o.HomepageURI.Valid || o.HomepageURI.String == "" {
+		return nil
+	}
+
+	u, err := url.Parse(o.HomepageURI.String)
+	if err != nil {
+		return nil
+	}
+	return u
+}
@@ -0,0 +1,104 @@
+package models_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/ulid"
+
+	"go.rtnl.ai/quarterdeck/pkg/store/v1/mock"
+	. "go.rtnl.ai/quarterdeck/pkg/store/v1/models"
+)
+
+func TestOrganizationParams(t *testing.T) {
+	org := &Organization{
+		Model: Model{
+			ID:       modelID,
+			Created:  created,
+			Modified: modified,
+		},
+		Name:          "Rotational Labs",
+		StreetAddress: sql.NullString{Valid: true, String: "202 N Cedar Ave, Owatonna, MN 55060"},
+		HomepageURI:   sql.NullString{Valid: true, String: "https://rotational.io"},
+		SupportEmail:  sql.NullString{Valid: true, String: "support@rotational.io"},
+	}
+
+	CheckParams(t, org.Params(),
+		[]string{
+			"id", "name", "streetAddress", "homepageURI", "supportEmail", "created", "modified",
+		},
+		[]any{
+			org.ID, org.Name, org.StreetAddress, org.HomepageURI, org.SupportEmail, org.Created, org.Modified,
+		},
+	)
+}
+
+func TestOrganizationScan(t *testing.T) {
+	t.Run("NotNull", func(t *testing.T) {
+		data := []any{
+			ulid.MakeSecure().String(),        // ID
+			"Rotational Labs",                 // Name
+			"202 N Cedar Ave, Owatonna, MN",   // StreetAddress
+			"https://rotational.io",           // HomepageURI
+			"support@rotational.io",           // SupportEmail
+			time.Now().Add(-14 * time.Hour),   // Created
+			time.Now().Add(-30 * time.Minute), // Modified
+		}
+		mockScanner := &mock.Scanner{}
+		mockScanner.SetData(data)
+
+		model := &Organization{}
+		err := model.Scan(mockScanner)
+		require.NoError(t, err, "expected no errors when scanning")
+		mockScanner.AssertScanned(t, len(data))
+
+		require.Equal(t, data[0], model.ID.String(), "expected field ID to match data[0]")
+		require.Equal(t, data[1], model.Name, "expected field Name to match data[1]")
+		require.Equal(t, data[2], model.StreetAddress.String, "expected field StreetAddress to match data[2]")
+		require.Equal(t, data[3], model.HomepageURI.String, "expected field HomepageURI to match data[3]")
+		require.Equal(t, data[4], model.SupportEmail.String, "expected field SupportEmail to match data[4]")
+		require.Equal(t, data[5], model.Created, "expected field Created to match data[5]")
+		require.Equal(t, data[6], model.Modified, "expected field Modified to match data[6]")
+	})
+
+	t.Run("Nulls", func(t *testing.T) {
+		data := []any{
+			ulid.MakeSecure().String(), // ID
+			"Rotational Labs",          // Name
+			nil,                        // StreetAddress
+			nil,                        // HomepageURI
+			nil,                        // SupportEmail
+			time.Now(),                 // Created
+			time.Time{},                // Modified (testing zero time)
+		}
+		mockScanner := &mock.Scanner{}
+		mockScanner.SetData(data)
+
+		model := &Organization{}
+		err := model.Scan(mockScanner)
+		require.NoError(t, err, "expected no errors when scanning")
+		mockScanner.AssertScanned(t, len(data))
+
+		require.False(t, model.StreetAddress.Valid, "expected field StreetAddress to be invalid (null)")
+		require.False(t, model.HomepageURI.Valid, "expected field HomepageURI to be invalid (null)")
+		require.False(t, model.SupportEmail.Valid, "expected field SupportEmail to be invalid (null)")
+		require.True(t, model.Modified.IsZero(), "expected field Modified to be zero time")
+		require.Nil(t, model.HomepageURL(), "expected no homepage URL when the homepage is null")
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mockScanner := &mock.Scanner{}
+		mockScanner.SetError(ErrModelScan)
+
+		model := &Organization{}
+		err := model.Scan(mockScanner)
+		require.ErrorIs(t, err, ErrModelScan, "expected error when scanning with mock scanner")
+	})
+}
+
+func TestOrganizationHomepageURL(t *testing.T) {
+	org := &Organization{HomepageURI: sql.NullString{Valid: true, String: "https://rotational.io/about"}}
+	require.Equal(t, "https://rotational.io/about", org.HomepageURL().String())
+}
@@ -22,12 +22,17 @@ type RoleList struct {
 	Roles []*Role
 }
 
+// Permission authorizes users and api keys to perform an action. Custom permissions are
+// namespaced by the application or OIDC client that created them; protected permissions
+// are the built-in Quarterdeck permissions which cannot be deleted or renamed.
 type Permission struct {
 	ID          int64
 	Title       string
 	Description string
 	Created     time.Time
 	Modified    time.Time
+	Namespace   string
+	Protected   bool
 }
 
 type PermissionList struct {
@@ -71,6 +76,8 @@ func (p *Permission) Scan(scanner Scanner) error {
 		&p.Description,
 		&p.Created,
 		&p.Modified,
+		&p.Namespace,
+		&p.Protected,
 	)
 }
 
@@ -82,6 +89,8 @@ func (p *Permission) Params() []any {
 		sql.Named("description", p.Description),
 		sql.Named("created", p.Created),
 		sql.Named("modified", p.Modified),
+		sql.Named("namespace", p.Namespace),
+		sql.Named("protected", p.Protected),
 	}
 }
 
@@ -32,11 +32,13 @@ func TestPermissionParams(t *testing.T) {
 		Description: "Read access to the dashboard",
 		Created:     created,
 		Modified:    modified,
+		Namespace:   "dashboard",
+		Protected:   false,
 	}
 
 	CheckParams(t, perm.Params(),
-		[]string{"id", "title", "description", "created", "modified"},
-		[]any{perm.ID, perm.Title, perm.Description, perm.Created, perm.Modified},
+		[]string{"id", "title", "description", "created", "modified", "namespace", "protected"},
+		[]any{perm.ID, perm.Title, perm.Description, perm.Created, perm.Modified, perm.Namespace, perm.Protected},
 	)
 }
 
@@ -85,6 +87,8 @@ func TestPermissionScan(t *testing.T) {
 			"Read access to the dashboard", // Description
 			created,                        // Created
 			modified,                       // Modified
+			"dashboard",                    // Namespace
+			true,                           // Protected
 		}
 
 		mockScanner := &mock.Scanner{}
@@ -98,6 +102,8 @@ func TestPermissionScan(t *testing.T) {
 		require.Equal(t, "Read access to the dashboard", permission.Description, "expected Description to match")
 		require.Equal(t, created, permission.Created, "expected Created to match")
 		require.Equal(t, modified, permission.Modified, "expected Modified to match")
+		require.Equal(t, "dashboard", permission.Namespace, "expected Namespace to match")
+		require.True(t, permission.Protected, "expected Protected to be true")
 	})
 
 	t.Run("Error", func(t *testing.T) {
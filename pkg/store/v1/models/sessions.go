@@ -0,0 +1,79 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"go.rtnl.ai/ulid"
+)
+
+// Session records a user login so that the places where an account is signed in can be
+// listed and revoked. The ID of the session is the family ID of the refresh tokens
+// issued on login and the JTI is the jti of the most recently issued token pair.
+type Session struct {
+	Model
+	UserID      ulid.ULID
+	JTI         ulid.ULID
+	UserAgent   sql.NullString
+	ClientIP    sql.NullString
+	LastRefresh sql.NullTime
+	Revoked     sql.NullTime
+	Expiration  time.Time
+}
+
+type SessionList struct {
+	Sessions []*Session
+}
+
+//===========================================================================
+// Scanning and Params
+//===========================================================================
+
+// Scan the Session struct from a database row.
+func (s *Session) Scan(scanner Scanner) error {
+	return scanner.Scan(
+		&s.ID,
+		&s.UserID,
+		&s.JTI,
+		&s.UserAgent,
+		&s.ClientIP,
+		&s.LastRefresh,
+		&s.Revoked,
+		&s.Expiration,
+		&s.Created,
+		&s.Modified,
+	)
+}
+
+// Params returns all Session fields as named params to be used in a SQL query.
+func (s *Session) Params() []any {
+	return []any{
+		sql.Named("id", s.ID),
+		sql.Named("userID", s.UserID),
+		sql.Named("jti", s.JTI),
+		sql.Named("userAgent", s.UserAgent),
+		sql.Named("clientIP", s.ClientIP),
+		sql.Named("lastRefresh", s.LastRefresh),
+		sql.Named("revoked", s.Revoked),
+		sql.Named("expiration", s.Expiration),
+		sql.Named("created", s.Created),
+		sql.Named("modified", s.Modified),
+	}
+}
+
+//===========================================================================
+// Helpers
+//===========================================================================
+
+// IsActive returns true if the session has not been revoked and has not expired.
+func (s *Session) IsActive() bool {
+	return !s.Revoked.Valid && time.Now().Before(s.Expiration)
+}
+
+// LastActive returns the time the session was last refreshed or when it was created.
+func (s *Session) LastActive() time.Time {
+	if s.LastRefresh.Valid {
+		return s.LastRefresh.Time
+	}
+	return s.Created
+}
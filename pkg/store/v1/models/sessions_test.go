@@ -0,0 +1,54 @@
+package models_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/ulid"
+
+	. "go.rtnl.ai/quarterdeck/pkg/store/v1/models"
+)
+
+func TestSessionParams(t *testing.T) {
+	session := &Session{
+		Model: Model{
+			ID:       modelID,
+			Created:  created,
+			Modified: modified,
+		},
+		UserID:     ulid.Make(),
+		JTI:        ulid.Make(),
+		Expiration: time.Now().Add(time.Hour),
+	}
+
+	CheckParams(t, session.Params(),
+		[]string{
+			"id", "userID", "jti", "userAgent", "clientIP", "lastRefresh", "revoked", "expiration", "created", "modified",
+		},
+		[]any{
+			session.ID, session.UserID, session.JTI, session.UserAgent, session.ClientIP, session.LastRefresh, session.Revoked, session.Expiration, session.Created, session.Modified,
+		},
+	)
+}
+
+func TestSessionIsActive(t *testing.T) {
+	session := &Session{Expiration: time.Now().Add(time.Hour)}
+	require.True(t, session.IsActive())
+
+	session.Revoked = sql.NullTime{Time: time.Now(), Valid: true}
+	require.False(t, session.IsActive(), "revoked sessions are not active")
+
+	session = &Session{Expiration: time.Now().Add(-time.Second)}
+	require.False(t, session.IsActive(), "expired sessions are not active")
+}
+
+func TestSessionLastActive(t *testing.T) {
+	session := &Session{Model: Model{Created: created}}
+	require.Equal(t, created, session.LastActive())
+
+	refreshed := time.Now()
+	session.LastRefresh = sql.NullTime{Time: refreshed, Valid: true}
+	require.Equal(t, refreshed, session.LastActive())
+}
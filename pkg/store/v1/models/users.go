@@ -2,9 +2,14 @@ package models
 
 import (
 	"database/sql"
+	"encoding/json"
+	"slices"
+	"time"
 
 	"go.rtnl.ai/gimlet/auth"
+	"go.rtnl.ai/quarterdeck/pkg/enum"
 	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/ulid"
 	"go.rtnl.ai/x/gravatar"
 )
 
@@ -25,8 +30,14 @@ type User struct {
 	Password      string
 	LastLogin     sql.NullTime
 	EmailVerified bool
+	TOTPSecret    sql.NullString
+	TOTPEnabled   sql.NullTime
+	RecoveryCodes []string // hashes of the unused one-time recovery codes
+	Status        enum.UserStatus
+	Deleted       sql.NullTime // when the user was deleted; purged after the retention period
 	roles         []*Role
 	permissions   []string
+	orgID         ulid.ULID
 }
 
 type UserList struct {
@@ -34,10 +45,12 @@ type UserList struct {
 	Users []*User
 }
 
-// UserPage allows a list of paginated users to be optionally filtered by role.
+// UserPage allows a list of paginated users to be optionally filtered by role and
+// status; deleted users are only listed when filtered by the deleted status.
 type UserPage struct {
 	Page
-	Role string `json:"role,omitempty"`
+	Role   string          `json:"role,omitempty"`
+	Status enum.UserStatus `json:"status,omitempty"`
 }
 
 func UserPageFrom(in *UserPage) (out *UserPage) {
@@ -52,6 +65,7 @@ func UserPageFrom(in *UserPage) (out *UserPage) {
 			out.PageSize = in.PageSize
 		}
 		out.Role = in.Role
+		out.Status = in.Status
 	}
 
 	return out
@@ -62,8 +76,10 @@ func UserPageFrom(in *UserPage) (out *UserPage) {
 //===========================================================================
 
 // Scan the User struct from a database row.
-func (u *User) Scan(scanner Scanner) error {
-	return scanner.Scan(
+func (u *User) Scan(scanner Scanner) (err error) {
+	var recoveryCodesJSON sql.NullString
+
+	if err = scanner.Scan(
 		&u.ID,
 		&u.Name,
 		&u.Email,
@@ -72,7 +88,23 @@ func (u *User) Scan(scanner Scanner) error {
 		&u.EmailVerified,
 		&u.Created,
 		&u.Modified,
-	)
+		&u.TOTPSecret,
+		&u.TOTPEnabled,
+		&recoveryCodesJSON,
+		&u.Status,
+		&u.Deleted,
+	); err != nil {
+		return err
+	}
+
+	u.RecoveryCodes = nil
+	if recoveryCodesJSON.Valid && recoveryCodesJSON.String != "" {
+		if err = json.Unmarshal([]byte(recoveryCodesJSON.String), &u.RecoveryCodes); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // ScanSummary scans a User struct from a database row, excluding the Password field.
@@ -85,6 +117,8 @@ func (u *User) ScanSummary(scanner Scanner) error {
 		&u.EmailVerified,
 		&u.Created,
 		&u.Modified,
+		&u.Status,
+		&u.Deleted,
 	)
 }
 
@@ -99,9 +133,25 @@ func (u User) Params() []any {
 		sql.Named("emailVerified", u.EmailVerified),
 		sql.Named("created", u.Created),
 		sql.Named("modified", u.Modified),
+		sql.Named("totpSecret", u.TOTPSecret),
+		sql.Named("totpEnabled", u.TOTPEnabled),
+		sql.Named("recoveryCodes", u.RecoveryCodesParam()),
+		sql.Named("status", u.Status),
+		sql.Named("deleted", u.Deleted),
 	}
 }
 
+// RecoveryCodesParam returns the recovery code hashes as a JSON array for storage in
+// the database or NULL if the user has no recovery codes.
+func (u User) RecoveryCodesParam() sql.NullString {
+	if len(u.RecoveryCodes) == 0 {
+		return sql.NullString{}
+	}
+
+	data, _ := json.Marshal(u.RecoveryCodes)
+	return sql.NullString{Valid: true, String: string(data)}
+}
+
 //===========================================================================
 // Associations
 //===========================================================================
@@ -130,6 +180,17 @@ func (u *User) SetPermissions(permissions []string) {
 	u.permissions = permissions
 }
 
+// OrgID returns the organization that the user's roles and permissions were loaded
+// for; if zero, then only the user's global roles and permissions were loaded.
+func (u User) OrgID() ulid.ULID {
+	return u.orgID
+}
+
+// SetOrgID sets the organization that the user's roles and permissions belong to.
+func (u *User) SetOrgID(orgID ulid.ULID) {
+	u.orgID = orgID
+}
+
 //===========================================================================
 // Helper Methods
 //===========================================================================
@@ -156,6 +217,54 @@ func (u User) Claims() (claims *auth.Claims, err error) {
 	return claims, nil
 }
 
+// CheckStatus returns an error if the status of the user does not allow them to log in
+// or to reauthenticate. Deleted users are treated as if they do not exist so that the
+// error does not reveal that the account was deleted.
+func (u User) CheckStatus() error {
+	switch u.Status {
+	case enum.UserStatusActive, enum.UserStatusUnknown:
+		// Users are created active so an unknown status has not been restricted.
+		return nil
+	case enum.UserStatusSuspended:
+		return errors.ErrUserSuspended
+	case enum.UserStatusDeactivated:
+		return errors.ErrUserDeactivated
+	default:
+		return errors.ErrFailedAuthentication
+	}
+}
+
+// MFAEnabled returns true if the user has confirmed enrollment of a TOTP second factor.
+func (u User) MFAEnabled() bool {
+	return u.TOTPEnabled.Valid && u.TOTPSecret.Valid && u.TOTPSecret.String != ""
+}
+
+// EnableTOTP marks the TOTP secret as confirmed and replaces any existing recovery
+// codes with the specified hashes.
+func (u *User) EnableTOTP(recoveryCodes []string) {
+	u.TOTPEnabled = sql.NullTime{Valid: true, Time: time.Now()}
+	u.RecoveryCodes = recoveryCodes
+}
+
+// DisableTOTP removes the second factor and all recovery codes from the user.
+func (u *User) DisableTOTP() {
+	u.TOTPSecret = sql.NullString{}
+	u.TOTPEnabled = sql.NullTime{}
+	u.RecoveryCodes = nil
+}
+
+// UseRecoveryCode removes the recovery code hash from the user's unused recovery
+// codes, returning false if the hash does not match an unused recovery code.
+func (u *User) UseRecoveryCode(hash string) bool {
+	idx := slices.Index(u.RecoveryCodes, hash)
+	if idx < 0 {
+		return false
+	}
+
+	u.RecoveryCodes = slices.Delete(u.RecoveryCodes, idx, idx+1)
+	return true
+}
+
 func (u User) Gravatar() string {
 	if u.Email == "" {
 		return ""
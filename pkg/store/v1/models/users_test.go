@@ -9,6 +9,7 @@ import (
 	"go.rtnl.ai/ulid"
 
 	"go.rtnl.ai/gimlet/auth"
+	"go.rtnl.ai/quarterdeck/pkg/enum"
 	"go.rtnl.ai/quarterdeck/pkg/errors"
 	"go.rtnl.ai/quarterdeck/pkg/store/v1/mock"
 	. "go.rtnl.ai/quarterdeck/pkg/store/v1/models"
@@ -31,18 +32,25 @@ func TestUserParams(t *testing.T) {
 			Created:  created,
 			Modified: modified,
 		},
-		Name:      sql.NullString{Valid: true, String: "Carol King"},
-		Email:     "cking@example.com",
-		Password:  "$argon2id$v=19$m=65536,t=1,p=2$GCSPNYPRVwBT9E559vqOnQ==$QMiOdjzXvvyNiQid3G7WY6E2zprY00UI4xJDCbd1HkM=",
-		LastLogin: sql.NullTime{Valid: false},
+		Name:          sql.NullString{Valid: true, String: "Carol King"},
+		Email:         "cking@example.com",
+		Password:      "$argon2id$v=19$m=65536,t=1,p=2$GCSPNYPRVwBT9E559vqOnQ==$QMiOdjzXvvyNiQid3G7WY6E2zprY00UI4xJDCbd1HkM=",
+		LastLogin:     sql.NullTime{Valid: false},
+		TOTPSecret:    sql.NullString{Valid: true, String: "JBSWY3DPEHPK3PXP"},
+		TOTPEnabled:   sql.NullTime{Valid: true, Time: modified},
+		RecoveryCodes: []string{"a", "b"},
+		Status:        enum.UserStatusDeleted,
+		Deleted:       sql.NullTime{Valid: true, Time: modified},
 	}
 
 	CheckParams(t, user.Params(),
 		[]string{
 			"id", "name", "email", "password", "lastLogin", "emailVerified", "created", "modified",
+			"totpSecret", "totpEnabled", "recoveryCodes", "status", "deleted",
 		},
 		[]any{
 			user.ID, user.Name, user.Email, user.Password, user.LastLogin, user.EmailVerified, user.Created, user.Modified,
+			user.TOTPSecret, user.TOTPEnabled, sql.NullString{Valid: true, String: `["a","b"]`}, user.Status, user.Deleted,
 		},
 	)
 }
@@ -58,6 +66,11 @@ func TestUserScan(t *testing.T) {
 			true,                            // EmailVerified
 			time.Now().Add(-14 * time.Hour), // Created
 			time.Now().Add(-1 * time.Hour),  // Modified
+			"JBSWY3DPEHPK3PXP",              // TOTPSecret
+			time.Now().Add(-2 * time.Hour),  // TOTPEnabled
+			`["a","b"]`,                     // RecoveryCodes
+			"deleted",                       // Status
+			time.Now().Add(-1 * time.Hour),  // Deleted
 		}
 		mockScanner := &mock.Scanner{}
 		mockScanner.SetData(data)
@@ -75,6 +88,12 @@ func TestUserScan(t *testing.T) {
 		require.Equal(t, data[5], model.EmailVerified, "expected field EmailVerified to match data[5]")
 		require.Equal(t, data[6], model.Created, "expected field Created to match data[6]")
 		require.Equal(t, data[7], model.Modified, "expected field Modified to match data[7]")
+		require.Equal(t, data[8], model.TOTPSecret.String, "expected field TOTPSecret to match data[8]")
+		require.Equal(t, data[9], model.TOTPEnabled.Time, "expected field TOTPEnabled to match data[9]")
+		require.Equal(t, []string{"a", "b"}, model.RecoveryCodes, "expected field RecoveryCodes to match data[10]")
+		require.Equal(t, enum.UserStatusDeleted, model.Status, "expected field Status to match data[11]")
+		require.Equal(t, data[12], model.Deleted.Time, "expected field Deleted to match data[12]")
+		require.True(t, model.MFAEnabled())
 	})
 
 	t.Run("Nulls", func(t *testing.T) {
@@ -87,6 +106,11 @@ func TestUserScan(t *testing.T) {
 			false,                      // EmailVerified
 			time.Now(),                 // Created
 			time.Time{},                // Modified (testing zero time)
+			nil,                        // TOTPSecret
+			nil,                        // TOTPEnabled
+			nil,                        // RecoveryCodes
+			"active",                   // Status
+			nil,                        // Deleted
 		}
 		mockScanner := &mock.Scanner{}
 		mockScanner.SetData(data)
@@ -100,6 +124,11 @@ func TestUserScan(t *testing.T) {
 		require.False(t, model.Name.Valid, "expected field Name to be invalid (null)")
 		require.False(t, model.LastLogin.Valid, "expected field LastLogin to be invalid (null)")
 		require.True(t, model.Modified.IsZero(), "expected field Modified to be zero time")
+		require.False(t, model.TOTPSecret.Valid, "expected field TOTPSecret to be invalid (null)")
+		require.False(t, model.TOTPEnabled.Valid, "expected field TOTPEnabled to be invalid (null)")
+		require.Nil(t, model.RecoveryCodes, "expected field RecoveryCodes to be nil")
+		require.False(t, model.Deleted.Valid, "expected field Deleted to be invalid (null)")
+		require.False(t, model.MFAEnabled())
 	})
 
 	t.Run("Error", func(t *testing.T) {
@@ -122,6 +151,8 @@ func TestUserScanSummary(t *testing.T) {
 			true,                          // EmailVerified
 			time.Now(),                    // Created
 			time.Now().Add(1 * time.Hour), // Modified
+			"suspended",                   // Status
+			nil,                           // Deleted
 		}
 		mockScanner := &mock.Scanner{}
 		mockScanner.SetData(data)
@@ -139,6 +170,8 @@ func TestUserScanSummary(t *testing.T) {
 		require.Equal(t, data[4], model.EmailVerified, "expected field EmailVerified to match data[4]")
 		require.Equal(t, data[5], model.Created, "expected field Created to match data[5]")
 		require.Equal(t, data[6], model.Modified, "expected field Modified to match data[6]")
+		require.Equal(t, enum.UserStatusSuspended, model.Status, "expected field Status to match data[7]")
+		require.False(t, model.Deleted.Valid, "expected field Deleted to be invalid (null)")
 	})
 
 	t.Run("Error", func(t *testing.T) {
@@ -260,3 +293,41 @@ func TestEmptyGravatar(t *testing.T) {
 
 	require.Equal(t, "", user.Gravatar(), "gravatar should be empty when email is not set")
 }
+
+func TestUserMFA(t *testing.T) {
+	user := &User{TOTPSecret: sql.NullString{Valid: true, String: "JBSWY3DPEHPK3PXP"}}
+	require.False(t, user.MFAEnabled(), "mfa should not be enabled until enrollment is confirmed")
+
+	user.EnableTOTP([]string{"a", "b", "c"})
+	require.True(t, user.MFAEnabled())
+	require.Equal(t, []string{"a", "b", "c"}, user.RecoveryCodes)
+
+	require.True(t, user.UseRecoveryCode("b"))
+	require.Equal(t, []string{"a", "c"}, user.RecoveryCodes)
+	require.False(t, user.UseRecoveryCode("b"), "recovery codes can only be used once")
+	require.False(t, user.UseRecoveryCode("d"))
+
+	user.DisableTOTP()
+	require.False(t, user.MFAEnabled())
+	require.False(t, user.TOTPSecret.Valid)
+	require.Nil(t, user.RecoveryCodes)
+	require.False(t, user.RecoveryCodesParam().Valid)
+}
+
+func TestUserCheckStatus(t *testing.T) {
+	tests := []struct {
+		status enum.UserStatus
+		err    error
+	}{
+		{enum.UserStatusUnknown, nil},
+		{enum.UserStatusActive, nil},
+		{enum.UserStatusSuspended, errors.ErrUserSuspended},
+		{enum.UserStatusDeactivated, errors.ErrUserDeactivated},
+		{enum.UserStatusDeleted, errors.ErrFailedAuthentication},
+	}
+
+	for _, tc := range tests {
+		user := &User{Status: tc.status}
+		require.ErrorIs(t, user.CheckStatus(), tc.err, "unexpected error for %s status", tc.status)
+	}
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"go.rtnl.ai/ulid"
+)
+
+// WebAuthnCredential is a passkey (or security key) registered by a user so that they
+// can log in without a password. The CredentialID is assigned by the authenticator and
+// is used to look up the credential during login; only the public key is stored.
+type WebAuthnCredential struct {
+	Model
+	UserID          ulid.ULID
+	Name            sql.NullString
+	CredentialID    []byte
+	PublicKey       []byte
+	AttestationType sql.NullString
+	AAGUID          []byte
+	SignCount       uint32
+	Transports      []string
+	Flags           uint8 // authenticator data flags (UP, UV, BE, BS)
+	LastUsed        sql.NullTime
+}
+
+type WebAuthnCredentialList struct {
+	Credentials []*WebAuthnCredential
+}
+
+//===========================================================================
+// Scanning and Params
+//===========================================================================
+
+// Scan the WebAuthnCredential struct from a database row.
+func (w *WebAuthnCredential) Scan(scanner Scanner) (err error) {
+	var transportsJSON sql.NullString
+
+	if err = scanner.Scan(
+		&w.ID,
+		&w.UserID,
+		&w.Name,
+		&w.CredentialID,
+		&w.PublicKey,
+		&w.AttestationType,
+		&w.AAGUID,
+		&w.SignCount,
+		&transportsJSON,
+		&w.Flags,
+		&w.LastUsed,
+		&w.Created,
+		&w.Modified,
+	); err != nil {
+		return err
+	}
+
+	w.Transports = nil
+	if transportsJSON.Valid && transportsJSON.String != "" {
+		if err = json.Unmarshal([]byte(transportsJSON.String), &w.Transports); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Params returns all WebAuthnCredential fields as named params to be used in a SQL query.
+func (w *WebAuthnCredential) Params() []any {
+	return []any{
+		sql.Named("id", w.ID),
+		sql.Named("userID", w.UserID),
+		sql.Named("name", w.Name),
+		sql.Named("credentialID", w.CredentialID),
+		sql.Named("publicKey", w.PublicKey),
+		sql.Named("attestationType", w.AttestationType),
+		sql.Named("aaguid", w.AAGUID),
+		sql.Named("signCount", w.SignCount),
+		sql.Named("transports", w.TransportsParam()),
+		sql.Named("flags", w.Flags),
+		sql.Named("lastUsed", w.LastUsed),
+		sql.Named("created", w.Created),
+		sql.Named("modified", w.Modified),
+	}
+}
+
+// TransportsParam returns the authenticator transports as a JSON array for storage in
+// the database or NULL if the transports are unknown.
+func (w *WebAuthnCredential) TransportsParam() sql.NullString {
+	if len(w.Transports) == 0 {
+		return sql.NullString{}
+	}
+
+	data, _ := json.Marshal(w.Transports)
+	return sql.NullString{Valid: true, String: string(data)}
+}
@@ -0,0 +1,45 @@
+package models_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/ulid"
+
+	. "go.rtnl.ai/quarterdeck/pkg/store/v1/models"
+)
+
+func TestWebAuthnCredentialParams(t *testing.T) {
+	credential := &WebAuthnCredential{
+		Model: Model{
+			ID:       modelID,
+			Created:  created,
+			Modified: modified,
+		},
+		UserID:       ulid.Make(),
+		Name:         sql.NullString{String: "MacBook Touch ID", Valid: true},
+		CredentialID: []byte{0x01, 0x02, 0x03},
+		PublicKey:    []byte{0xa5, 0x01, 0x02},
+		SignCount:    42,
+		Transports:   []string{"internal", "hybrid"},
+		Flags:        0x1d,
+	}
+
+	CheckParams(t, credential.Params(),
+		[]string{
+			"id", "userID", "name", "credentialID", "publicKey", "attestationType", "aaguid", "signCount", "transports", "flags", "lastUsed", "created", "modified",
+		},
+		[]any{
+			credential.ID, credential.UserID, credential.Name, credential.CredentialID, credential.PublicKey, credential.AttestationType, credential.AAGUID, credential.SignCount, sql.NullString{String: `["internal","hybrid"]`, Valid: true}, credential.Flags, credential.LastUsed, credential.Created, credential.Modified,
+		},
+	)
+}
+
+func TestWebAuthnCredentialTransportsParam(t *testing.T) {
+	credential := &WebAuthnCredential{}
+	require.False(t, credential.TransportsParam().Valid, "unknown transports should be stored as NULL")
+
+	credential.Transports = []string{"usb"}
+	require.Equal(t, sql.NullString{String: `["usb"]`, Valid: true}, credential.TransportsParam())
+}
@@ -0,0 +1,203 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"slices"
+	"time"
+
+	"go.rtnl.ai/quarterdeck/pkg/enum"
+	"go.rtnl.ai/ulid"
+)
+
+// Webhook is a subscription of an endpoint to one or more webhook events. Deliveries
+// to the endpoint are signed with the secret so that the receiver can verify that the
+// event was sent by Quarterdeck. Inactive webhooks are not sent any new events.
+type Webhook struct {
+	Model
+	URL         string
+	Description sql.NullString
+	Events      []enum.WebhookEvent
+	Secret      string
+	Active      bool
+}
+
+type WebhookList struct {
+	Page     *Page
+	Webhooks []*Webhook
+}
+
+// WebhookDelivery is an event queued for delivery to a webhook. Deliveries are the
+// transactional outbox of webhook events: they are created in the same transaction as
+// the action that caused the event, so an event is queued if and only if the action is
+// committed. Queued deliveries are attempted at least once until they succeed or fail,
+// after which they are kept as a delivery log.
+type WebhookDelivery struct {
+	Model
+	WebhookID    ulid.ULID
+	EventID      ulid.ULID // identifies the event, redeliveries have the same event ID
+	Event        enum.WebhookEvent
+	Payload      []byte
+	Status       enum.DeliveryStatus
+	Attempts     int
+	NextAttempt  sql.NullTime // when the delivery is due; null once delivered or failed
+	LastAttempt  sql.NullTime
+	ResponseCode sql.NullInt64
+	Error        sql.NullString
+	Delivered    sql.NullTime
+}
+
+type WebhookDeliveryList struct {
+	Page       *Page
+	Deliveries []*WebhookDelivery
+}
+
+//===========================================================================
+// Scanning and Params
+//===========================================================================
+
+// Scan the Webhook struct from a database row.
+func (w *Webhook) Scan(scanner Scanner) (err error) {
+	var events sql.NullString
+	if err = scanner.Scan(
+		&w.ID,
+		&w.URL,
+		&w.Description,
+		&events,
+		&w.Secret,
+		&w.Active,
+		&w.Created,
+		&w.Modified,
+	); err != nil {
+		return err
+	}
+
+	w.Events = nil
+	if events.Valid && events.String != "" {
+		if err = json.Unmarshal([]byte(events.String), &w.Events); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Params returns all Webhook fields as named params to be used in a SQL query.
+func (w *Webhook) Params() []any {
+	events := w.Events
+	if events == nil {
+		events = []enum.WebhookEvent{}
+	}
+	eventsJSON, _ := json.Marshal(events)
+
+	return []any{
+		sql.Named("id", w.ID),
+		sql.Named("url", w.URL),
+		sql.Named("description", w.Description),
+		sql.Named("events", string(eventsJSON)),
+		sql.Named("secret", w.Secret),
+		sql.Named("active", w.Active),
+		sql.Named("created", w.Created),
+		sql.Named("modified", w.Modified),
+	}
+}
+
+// Scan the WebhookDelivery struct from a database row.
+func (d *WebhookDelivery) Scan(scanner Scanner) error {
+	return scanner.Scan(
+		&d.ID,
+		&d.WebhookID,
+		&d.EventID,
+		&d.Event,
+		&d.Payload,
+		&d.Status,
+		&d.Attempts,
+		&d.NextAttempt,
+		&d.LastAttempt,
+		&d.ResponseCode,
+		&d.Error,
+		&d.Delivered,
+		&d.Created,
+		&d.Modified,
+	)
+}
+
+// Params returns all WebhookDelivery fields as named params to be used in a SQL query.
+func (d *WebhookDelivery) Params() []any {
+	return []any{
+		sql.Named("id", d.ID),
+		sql.Named("webhookID", d.WebhookID),
+		sql.Named("eventID", d.EventID),
+		sql.Named("event", d.Event),
+		sql.Named("payload", string(d.Payload)),
+		sql.Named("status", d.Status),
+		sql.Named("attempts", d.Attempts),
+		sql.Named("nextAttempt", d.NextAttempt),
+		sql.Named("lastAttempt", d.LastAttempt),
+		sql.Named("responseCode", d.ResponseCode),
+		sql.Named("error", d.Error),
+		sql.Named("delivered", d.Delivered),
+		sql.Named("created", d.Created),
+		sql.Named("modified", d.Modified),
+	}
+}
+
+//===========================================================================
+// Helper Methods
+//===========================================================================
+
+// Subscribed returns true if the webhook is active and subscribed to the event.
+func (w Webhook) Subscribed(event enum.WebhookEvent) bool {
+	return w.Active && slices.Contains(w.Events, event)
+}
+
+// NewDelivery creates a pending delivery of the event to the webhook that is due now.
+func (w Webhook) NewDelivery(eventID ulid.ULID, event enum.WebhookEvent, payload []byte) *WebhookDelivery {
+	return &WebhookDelivery{
+		WebhookID:   w.ID,
+		EventID:     eventID,
+		Event:       event,
+		Payload:     payload,
+		Status:      enum.DeliveryStatusPending,
+		NextAttempt: sql.NullTime{Valid: true, Time: time.Now()},
+	}
+}
+
+// Record the result of a delivery attempt. If the attempt failed then the delivery is
+// retried after the backoff unless the maximum number of attempts has been made, in
+// which case the delivery has failed and must be redelivered manually.
+func (d *WebhookDelivery) Record(code int, err error, backoff time.Duration, maxAttempts int) {
+	now := time.Now()
+	d.Attempts++
+	d.LastAttempt = sql.NullTime{Valid: true, Time: now}
+	d.ResponseCode = sql.NullInt64{Valid: code != 0, Int64: int64(code)}
+
+	if err == nil {
+		d.Status = enum.DeliveryStatusDelivered
+		d.Delivered = sql.NullTime{Valid: true, Time: now}
+		d.NextAttempt = sql.NullTime{}
+		d.Error = sql.NullString{}
+		return
+	}
+
+	d.Error = sql.NullString{Valid: true, String: err.Error()}
+	if d.Attempts >= maxAttempts {
+		d.Status = enum.DeliveryStatusFailed
+		d.NextAttempt = sql.NullTime{}
+		return
+	}
+
+	d.Status = enum.DeliveryStatusPending
+	d.NextAttempt = sql.NullTime{Valid: true, Time: now.Add(backoff)}
+}
+
+// Redelivery creates a new pending delivery of the same event to the same webhook.
+func (d *WebhookDelivery) Redelivery() *WebhookDelivery {
+	return &WebhookDelivery{
+		WebhookID:   d.WebhookID,
+		EventID:     d.EventID,
+		Event:       d.Event,
+		Payload:     d.Payload,
+		Status:      enum.DeliveryStatusPending,
+		NextAttempt: sql.NullTime{Valid: true, Time: time.Now()},
+	}
+}
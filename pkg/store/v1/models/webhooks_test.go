@@ -0,0 +1,253 @@
+package models_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/ulid"
+
+	"go.rtnl.ai/quarterdeck/pkg/enum"
+	"go.rtnl.ai/quarterdeck/pkg/store/v1/mock"
+	. "go.rtnl.ai/quarterdeck/pkg/store/v1/models"
+)
+
+func TestWebhookParams(t *testing.T) {
+	webhook := &Webhook{
+		Model: Model{
+			ID:       modelID,
+			Created:  created,
+			Modified: modified,
+		},
+		URL:         "https://example.com/webhooks",
+		Description: sql.NullString{Valid: true, String: "user sync"},
+		Events:      []enum.WebhookEvent{enum.WebhookEventUserCreated, enum.WebhookEventUserDeleted},
+		Secret:      "supersecretsquirrel",
+		Active:      true,
+	}
+
+	CheckParams(t, webhook.Params(),
+		[]string{
+			"id", "url", "description", "events", "secret", "active", "created", "modified",
+		},
+		[]any{
+			webhook.ID, webhook.URL, webhook.Description, `["user.created","user.deleted"]`, webhook.Secret, webhook.Active, webhook.Created, webhook.Modified,
+		},
+	)
+
+	// Webhooks without events are stored with an empty list
+	webhook.Events = nil
+	CheckParams(t, webhook.Params()[3:4], []string{"events"}, []any{"[]"})
+}
+
+func TestWebhookScan(t *testing.T) {
+	t.Run("NotNull", func(t *testing.T) {
+		data := []any{
+			ulid.MakeSecure().String(),          // ID
+			"https://example.com/webhooks",      // URL
+			"user sync",                         // Description
+			`["user.updated","apikey.revoked"]`, // Events
+			"supersecretsquirrel",               // Secret
+			true,                                // Active
+			time.Now().Add(-14 * time.Hour),     // Created
+			time.Now().Add(-30 * time.Minute),   // Modified
+		}
+		mockScanner := &mock.Scanner{}
+		mockScanner.SetData(data)
+
+		model := &Webhook{}
+		err := model.Scan(mockScanner)
+		require.NoError(t, err, "expected no errors when scanning")
+		mockScanner.AssertScanned(t, len(data))
+
+		require.Equal(t, data[0], model.ID.String(), "expected field ID to match data[0]")
+		require.Equal(t, data[1], model.URL, "expected field URL to match data[1]")
+		require.Equal(t, data[2], model.Description.String, "expected field Description to match data[2]")
+		require.Equal(t, []enum.WebhookEvent{enum.WebhookEventUserUpdated, enum.WebhookEventAPIKeyRevoked}, model.Events, "expected field Events to match data[3]")
+		require.Equal(t, data[4], model.Secret, "expected field Secret to match data[4]")
+		require.Equal(t, data[5], model.Active, "expected field Active to match data[5]")
+		require.Equal(t, data[6], model.Created, "expected field Created to match data[6]")
+		require.Equal(t, data[7], model.Modified, "expected field Modified to match data[7]")
+	})
+
+	t.Run("Nulls", func(t *testing.T) {
+		data := []any{
+			ulid.MakeSecure().String(),     // ID
+			"https://example.com/webhooks", // URL
+			nil,                            // Description
+			nil,                            // Events
+			"supersecretsquirrel",          // Secret
+			false,                          // Active
+			time.Now(),                     // Created
+			time.Time{},                    // Modified (testing zero time)
+		}
+		mockScanner := &mock.Scanner{}
+		mockScanner.SetData(data)
+
+		model := &Webhook{}
+		err := model.Scan(mockScanner)
+		require.NoError(t, err, "expected no errors when scanning")
+		mockScanner.AssertScanned(t, len(data))
+
+		require.False(t, model.Description.Valid, "expected field Description to be invalid (null)")
+		require.Empty(t, model.Events, "expected no events when the events are null")
+		require.True(t, model.Modified.IsZero(), "expected field Modified to be zero time")
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mockScanner := &mock.Scanner{}
+		mockScanner.SetError(ErrModelScan)
+
+		model := &Webhook{}
+		err := model.Scan(mockScanner)
+		require.ErrorIs(t, err, ErrModelScan, "expected error when scanning with mock scanner")
+	})
+}
+
+func TestWebhookSubscribed(t *testing.T) {
+	webhook := &Webhook{
+		Events: []enum.WebhookEvent{enum.WebhookEventUserCreated, enum.WebhookEventLogin},
+		Active: true,
+	}
+
+	require.True(t, webhook.Subscribed(enum.WebhookEventUserCreated))
+	require.True(t, webhook.Subscribed(enum.WebhookEventLogin))
+	require.False(t, webhook.Subscribed(enum.WebhookEventUserDeleted))
+
+	webhook.Active = false
+	require.False(t, webhook.Subscribed(enum.WebhookEventUserCreated), "inactive webhooks are not subscribed")
+}
+
+func TestWebhookDeliveryParams(t *testing.T) {
+	delivery := &WebhookDelivery{
+		Model: Model{
+			ID:       modelID,
+			Created:  created,
+			Modified: modified,
+		},
+		WebhookID:    ulid.MakeSecure(),
+		EventID:      ulid.MakeSecure(),
+		Event:        enum.WebhookEventUserUpdated,
+		Payload:      []byte(`{"event":"user.updated"}`),
+		Status:       enum.DeliveryStatusPending,
+		Attempts:     2,
+		NextAttempt:  sql.NullTime{Valid: true, Time: modified.Add(time.Minute)},
+		LastAttempt:  sql.NullTime{Valid: true, Time: modified},
+		ResponseCode: sql.NullInt64{Valid: true, Int64: 503},
+		Error:        sql.NullString{Valid: true, String: "service unavailable"},
+	}
+
+	CheckParams(t, delivery.Params(),
+		[]string{
+			"id", "webhookID", "eventID", "event", "payload", "status", "attempts", "nextAttempt",
+			"lastAttempt", "responseCode", "error", "delivered", "created", "modified",
+		},
+		[]any{
+			delivery.ID, delivery.WebhookID, delivery.EventID, delivery.Event, `{"event":"user.updated"}`,
+			delivery.Status, delivery.Attempts, delivery.NextAttempt, delivery.LastAttempt,
+			delivery.ResponseCode, delivery.Error, delivery.Delivered, delivery.Created, delivery.Modified,
+		},
+	)
+}
+
+func TestWebhookDeliveryScan(t *testing.T) {
+	t.Run("NotNull", func(t *testing.T) {
+		data := []any{
+			ulid.MakeSecure().String(),        // ID
+			ulid.MakeSecure().String(),        // WebhookID
+			ulid.MakeSecure().String(),        // EventID
+			"user.created",                    // Event
+			`{"event":"user.created"}`,        // Payload
+			"delivered",                       // Status
+			int64(1),                          // Attempts
+			time.Now().Add(-10 * time.Minute), // NextAttempt
+			time.Now().Add(-10 * time.Minute), // LastAttempt
+			int64(204),                        // ResponseCode
+			"",                                // Error
+			time.Now().Add(-10 * time.Minute), // Delivered
+			time.Now().Add(-14 * time.Hour),   // Created
+			time.Now().Add(-30 * time.Minute), // Modified
+		}
+		mockScanner := &mock.Scanner{}
+		mockScanner.SetData(data)
+
+		model := &WebhookDelivery{}
+		err := model.Scan(mockScanner)
+		require.NoError(t, err, "expected no errors when scanning")
+		mockScanner.AssertScanned(t, len(data))
+
+		require.Equal(t, data[0], model.ID.String(), "expected field ID to match data[0]")
+		require.Equal(t, data[1], model.WebhookID.String(), "expected field WebhookID to match data[1]")
+		require.Equal(t, data[2], model.EventID.String(), "expected field EventID to match data[2]")
+		require.Equal(t, enum.WebhookEventUserCreated, model.Event, "expected field Event to match data[3]")
+		require.Equal(t, []byte(`{"event":"user.created"}`), model.Payload, "expected field Payload to match data[4]")
+		require.Equal(t, enum.DeliveryStatusDelivered, model.Status, "expected field Status to match data[5]")
+		require.Equal(t, 1, model.Attempts, "expected field Attempts to match data[6]")
+		require.Equal(t, data[7], model.NextAttempt.Time, "expected field NextAttempt to match data[7]")
+		require.Equal(t, data[8], model.LastAttempt.Time, "expected field LastAttempt to match data[8]")
+		require.Equal(t, data[9], model.ResponseCode.Int64, "expected field ResponseCode to match data[9]")
+		require.Equal(t, data[10], model.Error.String, "expected field Error to match data[10]")
+		require.Equal(t, data[11], model.Delivered.Time, "expected field Delivered to match data[11]")
+		require.Equal(t, data[12], model.Created, "expected field Created to match data[12]")
+		require.Equal(t, data[13], model.Modified, "expected field Modified to match data[13]")
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mockScanner := &mock.Scanner{}
+		mockScanner.SetError(ErrModelScan)
+
+		model := &WebhookDelivery{}
+		err := model.Scan(mockScanner)
+		require.ErrorIs(t, err, ErrModelScan, "expected error when scanning with mock scanner")
+	})
+}
+
+func TestWebhookDeliveryRecord(t *testing.T) {
+	webhook := &Webhook{Model: Model{ID: ulid.MakeSecure()}}
+
+	t.Run("Delivered", func(t *testing.T) {
+		delivery := webhook.NewDelivery(ulid.MakeSecure(), enum.WebhookEventLogin, []byte(`{}`))
+		require.Equal(t, webhook.ID, delivery.WebhookID)
+		require.Equal(t, enum.DeliveryStatusPending, delivery.Status)
+		require.True(t, delivery.NextAttempt.Valid)
+
+		delivery.Record(200, nil, time.Minute, 3)
+		require.Equal(t, enum.DeliveryStatusDelivered, delivery.Status)
+		require.Equal(t, 1, delivery.Attempts)
+		require.True(t, delivery.Delivered.Valid)
+		require.False(t, delivery.NextAttempt.Valid)
+		require.Equal(t, int64(200), delivery.ResponseCode.Int64)
+	})
+
+	t.Run("Retried", func(t *testing.T) {
+		delivery := webhook.NewDelivery(ulid.MakeSecure(), enum.WebhookEventLogin, []byte(`{}`))
+		delivery.Record(0, errors.New("connection refused"), time.Minute, 3)
+
+		require.Equal(t, enum.DeliveryStatusPending, delivery.Status)
+		require.Equal(t, 1, delivery.Attempts)
+		require.False(t, delivery.ResponseCode.Valid)
+		require.Equal(t, "connection refused", delivery.Error.String)
+		require.WithinDuration(t, time.Now().Add(time.Minute), delivery.NextAttempt.Time, time.Second)
+	})
+
+	t.Run("Failed", func(t *testing.T) {
+		delivery := webhook.NewDelivery(ulid.MakeSecure(), enum.WebhookEventLogin, []byte(`{}`))
+		for i := 0; i < 3; i++ {
+			delivery.Record(500, errors.New("internal server error"), time.Minute, 3)
+		}
+
+		require.Equal(t, enum.DeliveryStatusFailed, delivery.Status)
+		require.Equal(t, 3, delivery.Attempts)
+		require.False(t, delivery.NextAttempt.Valid)
+		require.False(t, delivery.Delivered.Valid)
+
+		redelivery := delivery.Redelivery()
+		require.True(t, redelivery.ID.IsZero())
+		require.Equal(t, delivery.EventID, redelivery.EventID)
+		require.Equal(t, delivery.Payload, redelivery.Payload)
+		require.Equal(t, enum.DeliveryStatusPending, redelivery.Status)
+		require.Zero(t, redelivery.Attempts)
+	})
+}
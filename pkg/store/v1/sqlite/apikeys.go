@@ -15,7 +15,7 @@ import (
 //===========================================================================
 
 const (
-	listAPIKeysSQL = "SELECT id, description, client_id, created_by, last_seen, revoked, created, modified FROM api_keys WHERE revoked IS NULL ORDER BY created DESC"
+	listAPIKeysSQL = "SELECT id, description, client_id, created_by, last_seen, revoked, created, modified, organization_id FROM api_keys WHERE revoked IS NULL"
 )
 
 func (s *Store) ListAPIKeys(ctx context.Context, page *models.Page) (out *models.APIKeyList, err error) {
@@ -37,14 +37,20 @@ func (s *Store) ListAPIKeys(ctx context.Context, page *models.Page) (out *models
 }
 
 func (tx *Tx) ListAPIKeys(page *models.Page) (out *models.APIKeyList, err error) {
-	// TODO: handle pagination
 	out = &models.APIKeyList{
 		APIKeys: make([]*models.APIKey, 0),
 		Page:    models.PageFrom(page),
 	}
 
+	query := listAPIKeysSQL
+	where, order, params := pageQuery(page, out.Page.PageSize, "id")
+	if where != "" {
+		query += " AND " + where
+	}
+	query += order
+
 	var rows *sql.Rows
-	if rows, err = tx.Query(listAPIKeysSQL); err != nil {
+	if rows, err = tx.Query(query, params...); err != nil {
 		return nil, dbe(err)
 	}
 	defer rows.Close()
@@ -61,11 +67,12 @@ func (tx *Tx) ListAPIKeys(page *models.Page) (out *models.APIKeyList, err error)
 		return nil, dbe(err)
 	}
 
+	out.APIKeys = paginate(page, out.Page, out.APIKeys, func(k *models.APIKey) ulid.ULID { return k.ID })
 	return out, nil
 }
 
 const (
-	createAPIKeySQL = "INSERT INTO api_keys (id, description, client_id, secret, created_by, last_seen, revoked, created, modified) VALUES (:id, :description, :clientID, :secret, :createdBy, :lastSeen, :revoked, :created, :modified)"
+	createAPIKeySQL = "INSERT INTO api_keys (id, description, client_id, secret, created_by, last_seen, revoked, created, modified, organization_id) VALUES (:id, :description, :clientID, :secret, :createdBy, :lastSeen, :revoked, :created, :modified, :orgID)"
 )
 
 func (s *Store) CreateAPIKey(ctx context.Context, key *models.APIKey) (err error) {
@@ -0,0 +1,164 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v1/models"
+	"go.rtnl.ai/ulid"
+)
+
+const (
+	listAuditEventsSQL = "SELECT id, actor_type, actor_id, action, subject_type, subject_id, client_ip, user_agent, request_id, diff, created, modified FROM audit_events"
+)
+
+// ListAuditEvents returns a page of the audit log, most recent event first. Events are
+// paginated by ID (which is ordered by the time the event was created); if there are
+// more events than the page size then the NextPageID of the returned page is set and
+// can be used to fetch the next (older) page of events.
+func (s *Store) ListAuditEvents(ctx context.Context, page *models.AuditEventPage) (out *models.AuditEventList, err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, &sql.TxOptions{ReadOnly: true}); err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if out, err = tx.ListAuditEvents(page); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (tx *Tx) ListAuditEvents(page *models.AuditEventPage) (out *models.AuditEventList, err error) {
+	out = &models.AuditEventList{
+		Page:   models.AuditEventPageFrom(page),
+		Events: make([]*models.AuditEvent, 0),
+	}
+
+	// The next page ID is set from the results so it must be cleared from the query.
+	query, params := auditEventsQuery(out.Page)
+	out.Page.NextPageID = ulid.Zero
+
+	var rows *sql.Rows
+	if rows, err = tx.Query(query, params...); err != nil {
+		return nil, dbe(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		// One more event than the page size is fetched to determine if there is a next page.
+		if uint32(len(out.Events)) == out.Page.PageSize {
+			out.Page.NextPageID = out.Events[len(out.Events)-1].ID
+			break
+		}
+
+		event := &models.AuditEvent{}
+		if err = event.Scan(rows); err != nil {
+			return nil, err
+		}
+		out.Events = append(out.Events, event)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, dbe(err)
+	}
+
+	return out, nil
+}
+
+// Builds the filtered and paginated list audit events query from the page.
+func auditEventsQuery(page *models.AuditEventPage) (query string, params []any) {
+	where := make([]string, 0, 7)
+
+	if !page.NextPageID.IsZero() {
+		where = append(where, "id < :nextPageID")
+		params = append(params, sql.Named("nextPageID", page.NextPageID))
+	}
+
+	if !page.ActorID.IsZero() {
+		where = append(where, "actor_id = :actorID")
+		params = append(params, sql.Named("actorID", page.ActorID))
+	}
+
+	if page.SubjectType != "" {
+		where = append(where, "subject_type = :subjectType")
+		params = append(params, sql.Named("subjectType", page.SubjectType))
+	}
+
+	if page.SubjectID != "" {
+		where = append(where, "subject_id = :subjectID")
+		params = append(params, sql.Named("subjectID", page.SubjectID))
+	}
+
+	if page.Action != "" {
+		where = append(where, "action = :action")
+		params = append(params, sql.Named("action", page.Action))
+	}
+
+	if !page.Since.IsZero() {
+		where = append(where, "created >= :since")
+		params = append(params, sql.Named("since", page.Since))
+	}
+
+	if !page.Until.IsZero() {
+		where = append(where, "created < :until")
+		params = append(params, sql.Named("until", page.Until))
+	}
+
+	var sb strings.Builder
+	sb.WriteString(listAuditEventsSQL)
+	if len(where) > 0 {
+		sb.WriteString(" WHERE ")
+		sb.WriteString(strings.Join(where, " AND "))
+	}
+	sb.WriteString(" ORDER BY id DESC LIMIT :limit")
+	params = append(params, sql.Named("limit", page.PageSize+1))
+
+	return sb.String(), params
+}
+
+const (
+	createAuditEventSQL = "INSERT INTO audit_events (id, actor_type, actor_id, action, subject_type, subject_id, client_ip, user_agent, request_id, diff, created, modified) VALUES (:id, :actorType, :actorID, :action, :subjectType, :subjectID, :clientIP, :userAgent, :requestID, :diff, :created, :modified)"
+)
+
+func (s *Store) CreateAuditEvent(ctx context.Context, event *models.AuditEvent) (err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, nil); err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = tx.CreateAuditEvent(event); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (tx *Tx) CreateAuditEvent(event *models.AuditEvent) (err error) {
+	if !event.ID.IsZero() {
+		return errors.ErrNoIDOnCreate
+	}
+
+	if event.Action == "" || event.SubjectType == "" || event.SubjectID == "" {
+		return errors.ErrZeroValuedNotNull
+	}
+
+	event.ID = ulid.MakeSecure()
+	event.Created = time.Now()
+	event.Modified = event.Created
+
+	if _, err = tx.Exec(createAuditEventSQL, event.Params()...); err != nil {
+		return dbe(err)
+	}
+
+	return nil
+}
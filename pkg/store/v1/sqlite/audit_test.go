@@ -0,0 +1,116 @@
+package sqlite_test
+
+import (
+	"database/sql"
+	"time"
+
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v1/models"
+	"go.rtnl.ai/ulid"
+)
+
+func (s *storeTestSuite) TestAuditEvents() {
+	s.Run("Empty", func() {
+		require := s.Require()
+		out, err := s.db.ListAuditEvents(s.Context(), nil)
+		require.NoError(err)
+		require.Len(out.Events, 0)
+		require.True(out.Page.NextPageID.IsZero())
+		require.Equal(models.DefaultPageSize, out.Page.PageSize)
+	})
+
+	s.Run("CreateInvalid", func() {
+		if s.ReadOnly() {
+			s.T().Skip("skipping create audit event test in read-only mode")
+		}
+
+		require := s.Require()
+
+		event := &models.AuditEvent{Model: models.Model{ID: ulid.MakeSecure()}, Action: models.AuditCreate, SubjectType: models.AuditUser, SubjectID: "foo"}
+		require.ErrorIs(s.db.CreateAuditEvent(s.Context(), event), errors.ErrNoIDOnCreate)
+
+		event = &models.AuditEvent{Action: models.AuditCreate, SubjectType: models.AuditUser}
+		require.ErrorIs(s.db.CreateAuditEvent(s.Context(), event), errors.ErrZeroValuedNotNull)
+	})
+
+	s.Run("ListAndFilter", func() {
+		if s.ReadOnly() {
+			s.T().Skip("skipping list audit events test in read-only mode")
+		}
+
+		require := s.Require()
+		actorID := ulid.MustParse("01JPYRNYMEHNEZCS0JYX1CP57A")
+		start := time.Now().Add(-1 * time.Second)
+
+		// Create 5 user events by the actor and 2 anonymous failed logins.
+		for i := 0; i < 5; i++ {
+			event := &models.AuditEvent{
+				ActorType:   sql.NullString{String: models.AuditUser, Valid: true},
+				ActorID:     ulid.NullULID{ULID: actorID, Valid: true},
+				Action:      models.AuditUpdate,
+				SubjectType: models.AuditAPIKey,
+				SubjectID:   ulid.MakeSecure().String(),
+				ClientIP:    sql.NullString{String: "127.0.0.1", Valid: true},
+				Diff:        sql.NullString{String: `{"description":{"from":"foo","to":"bar"}}`, Valid: true},
+			}
+			require.NoError(s.db.CreateAuditEvent(s.Context(), event))
+			require.False(event.ID.IsZero())
+			time.Sleep(2 * time.Millisecond)
+		}
+
+		for i := 0; i < 2; i++ {
+			event := &models.AuditEvent{
+				Action:      models.AuditLoginFailed,
+				SubjectType: models.AuditUser,
+				SubjectID:   actorID.String(),
+			}
+			require.NoError(s.db.CreateAuditEvent(s.Context(), event))
+			time.Sleep(2 * time.Millisecond)
+		}
+
+		// Paginate through all of the events, most recent first.
+		page := &models.AuditEventPage{Page: models.Page{PageSize: 3}}
+		out, err := s.db.ListAuditEvents(s.Context(), page)
+		require.NoError(err)
+		require.Len(out.Events, 3)
+		require.Equal(models.AuditLoginFailed, out.Events[0].Action)
+		require.False(out.Events[0].ActorID.Valid)
+		require.Equal(out.Events[2].ID, out.Page.NextPageID)
+
+		seen := len(out.Events)
+		for !out.Page.NextPageID.IsZero() {
+			prev := out.Events[len(out.Events)-1].ID
+			page.NextPageID = out.Page.NextPageID
+			out, err = s.db.ListAuditEvents(s.Context(), page)
+			require.NoError(err)
+
+			for _, event := range out.Events {
+				require.Less(event.ID.String(), prev.String(), "events should be ordered most recent first")
+				prev = event.ID
+			}
+			seen += len(out.Events)
+		}
+		require.Equal(7, seen)
+
+		// Filter by the actor
+		out, err = s.db.ListAuditEvents(s.Context(), &models.AuditEventPage{ActorID: actorID})
+		require.NoError(err)
+		require.Len(out.Events, 5)
+		require.Equal(actorID, out.Events[0].ActorID.ULID)
+		require.JSONEq(`{"description":{"from":"foo","to":"bar"}}`, out.Events[0].Diff.String)
+
+		// Filter by the subject and action
+		out, err = s.db.ListAuditEvents(s.Context(), &models.AuditEventPage{SubjectType: models.AuditUser, SubjectID: actorID.String(), Action: models.AuditLoginFailed})
+		require.NoError(err)
+		require.Len(out.Events, 2)
+
+		// Filter by the time range
+		out, err = s.db.ListAuditEvents(s.Context(), &models.AuditEventPage{Since: start, Until: time.Now().Add(time.Second)})
+		require.NoError(err)
+		require.Len(out.Events, 7)
+
+		out, err = s.db.ListAuditEvents(s.Context(), &models.AuditEventPage{Until: start})
+		require.NoError(err)
+		require.Len(out.Events, 0)
+	})
+}
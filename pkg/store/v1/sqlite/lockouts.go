@@ -0,0 +1,140 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v1/models"
+	"go.rtnl.ai/ulid"
+)
+
+const (
+	retrieveLockoutSQL = "SELECT id, kind, identifier, failed_attempts, last_failure, locked_until, created, modified FROM lockouts WHERE kind=:kind AND identifier=:identifier"
+)
+
+func (s *Store) RetrieveLockout(ctx context.Context, kind, identifier string) (out *models.Lockout, err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, &sql.TxOptions{ReadOnly: true}); err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if out, err = tx.RetrieveLockout(kind, identifier); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// RetrieveLockout returns the failed attempts against the identifier; ErrNotFound is
+// returned if there have been no failed attempts since the last reset.
+func (tx *Tx) RetrieveLockout(kind, identifier string) (out *models.Lockout, err error) {
+	if kind == "" || identifier == "" {
+		return nil, errors.ErrMissingID
+	}
+
+	identifier = models.NormalizeIdentifier(kind, identifier)
+
+	out = &models.Lockout{}
+	if err = out.Scan(tx.QueryRow(retrieveLockoutSQL, sql.Named("kind", kind), sql.Named("identifier", identifier))); err != nil {
+		return nil, dbe(err)
+	}
+
+	return out, nil
+}
+
+const (
+	createLockoutSQL = "INSERT INTO lockouts (id, kind, identifier, failed_attempts, last_failure, locked_until, created, modified) VALUES (:id, :kind, :identifier, :failedAttempts, :lastFailure, :lockedUntil, :created, :modified)"
+	updateLockoutSQL = "UPDATE lockouts SET failed_attempts=:failedAttempts, last_failure=:lastFailure, locked_until=:lockedUntil, modified=:modified WHERE id=:id"
+)
+
+func (s *Store) RecordFailedAttempt(ctx context.Context, kind, identifier string, policy models.LockoutPolicy) (out *models.Lockout, err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, nil); err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if out, err = tx.RecordFailedAttempt(kind, identifier, policy); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// RecordFailedAttempt increments the failed attempts against the identifier and locks
+// it out according to the policy. The lockout is read and written in the same
+// transaction so that concurrent attempts are all counted.
+func (tx *Tx) RecordFailedAttempt(kind, identifier string, policy models.LockoutPolicy) (out *models.Lockout, err error) {
+	if out, err = tx.RetrieveLockout(kind, identifier); err != nil {
+		if !errors.Is(err, errors.ErrNotFound) {
+			return nil, err
+		}
+
+		out = &models.Lockout{
+			Model: models.Model{
+				ID:      ulid.MakeSecure(),
+				Created: time.Now(),
+			},
+			Kind:       kind,
+			Identifier: models.NormalizeIdentifier(kind, identifier),
+		}
+	}
+
+	out.Fail(policy)
+	out.Modified = time.Now()
+
+	query := updateLockoutSQL
+	if out.FailedAttempts == 1 {
+		query = createLockoutSQL
+	}
+
+	if _, err = tx.Exec(query, out.Params()...); err != nil {
+		return nil, dbe(err)
+	}
+
+	return out, nil
+}
+
+const (
+	resetLockoutSQL = "DELETE FROM lockouts WHERE kind=:kind AND identifier=:identifier"
+)
+
+func (s *Store) ResetLockout(ctx context.Context, kind, identifier string) (err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, nil); err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = tx.ResetLockout(kind, identifier); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ResetLockout clears the failed attempts against the identifier, unlocking it if it
+// is locked. Resetting an identifier without any failed attempts is not an error.
+func (tx *Tx) ResetLockout(kind, identifier string) (err error) {
+	if kind == "" || identifier == "" {
+		return errors.ErrMissingID
+	}
+
+	identifier = models.NormalizeIdentifier(kind, identifier)
+	if _, err = tx.Exec(resetLockoutSQL, sql.Named("kind", kind), sql.Named("identifier", identifier)); err != nil {
+		return dbe(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package sqlite_test
+
+import (
+	"time"
+
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v1/models"
+)
+
+func (s *storeTestSuite) TestLockouts() {
+	policy := models.LockoutPolicy{Threshold: 3, Duration: time.Minute, MaxDuration: time.Hour}
+
+	s.Run("MissingID", func() {
+		require := s.Require()
+
+		_, err := s.db.RetrieveLockout(s.Context(), "", "jannel@example.com")
+		require.ErrorIs(err, errors.ErrMissingID)
+
+		_, err = s.db.RetrieveLockout(s.Context(), models.LockoutUser, "")
+		require.ErrorIs(err, errors.ErrMissingID)
+
+		if s.ReadOnly() {
+			return
+		}
+
+		_, err = s.db.RecordFailedAttempt(s.Context(), models.LockoutUser, "", policy)
+		require.ErrorIs(err, errors.ErrMissingID)
+
+		err = s.db.ResetLockout(s.Context(), "", "jannel@example.com")
+		require.ErrorIs(err, errors.ErrMissingID)
+	})
+
+	s.Run("NotFound", func() {
+		_, err := s.db.RetrieveLockout(s.Context(), models.LockoutUser, "nobody@example.com")
+		s.Require().ErrorIs(err, errors.ErrNotFound)
+	})
+
+	s.Run("Lifecycle", func() {
+		if s.ReadOnly() {
+			s.T().Skip("skipping lockout lifecycle test in read-only mode")
+		}
+
+		require := s.Require()
+
+		// Failed attempts against an email address are tracked case-insensitively.
+		for i := int64(1); i < policy.Threshold; i++ {
+			lockout, err := s.db.RecordFailedAttempt(s.Context(), models.LockoutUser, "Jannel@Example.com", policy)
+			require.NoError(err)
+			require.Equal(i, lockout.FailedAttempts)
+			require.False(lockout.IsLocked())
+		}
+
+		lockout, err := s.db.RecordFailedAttempt(s.Context(), models.LockoutUser, "jannel@example.com", policy)
+		require.NoError(err)
+		require.True(lockout.IsLocked())
+
+		cmpt, err := s.db.RetrieveLockout(s.Context(), models.LockoutUser, "jannel@example.com")
+		require.NoError(err)
+		require.Equal(lockout.ID, cmpt.ID)
+		require.Equal(policy.Threshold, cmpt.FailedAttempts)
+		require.True(cmpt.IsLocked())
+		require.Equal(1, s.Count("lockouts"))
+
+		// The same identifier for a different kind is tracked separately.
+		other, err := s.db.RecordFailedAttempt(s.Context(), models.LockoutClient, "jannel@example.com", policy)
+		require.NoError(err)
+		require.Equal(int64(1), other.FailedAttempts)
+		require.Equal(2, s.Count("lockouts"))
+
+		require.NoError(s.db.ResetLockout(s.Context(), models.LockoutUser, "JANNEL@example.com"))
+		_, err = s.db.RetrieveLockout(s.Context(), models.LockoutUser, "jannel@example.com")
+		require.ErrorIs(err, errors.ErrNotFound)
+		require.Equal(1, s.Count("lockouts"))
+
+		// Resetting an identifier without any failed attempts is not an error.
+		require.NoError(s.db.ResetLockout(s.Context(), models.LockoutUser, "jannel@example.com"))
+	})
+}
@@ -0,0 +1,27 @@
+-- OAuth2 authorization codes issued by the /oauth/authorize endpoint to registered
+-- OIDC clients. Codes are one-time use and short lived; only a SHA-256 hash of the code
+-- is stored so that a database leak cannot be used to redeem outstanding codes. The
+-- PKCE code challenge (if any) is stored so it can be verified on token exchange.
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS oauth_authorization_codes (
+    id                      TEXT PRIMARY KEY,
+    code                    TEXT NOT NULL UNIQUE,
+    client_id               TEXT NOT NULL,
+    user_id                 TEXT NOT NULL,
+    redirect_uri            TEXT NOT NULL,
+    scope                   TEXT,
+    nonce                   TEXT,
+    code_challenge          TEXT,
+    code_challenge_method   TEXT,
+    expiration              DATETIME NOT NULL,
+    created                 DATETIME NOT NULL,
+    modified                DATETIME NOT NULL,
+    FOREIGN KEY (client_id) REFERENCES oidc_clients (client_id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_oauth_authorization_codes_expiration
+    ON oauth_authorization_codes (expiration);
+
+COMMIT;
@@ -0,0 +1,25 @@
+-- Refresh tokens are tracked server-side so that each refresh token can be used only
+-- once. Every reauthentication rotates to a new refresh token in the same family; the
+-- family is the chain of refresh tokens descended from a single login. If a refresh
+-- token that has already been used is presented again, the entire family is revoked.
+-- The id of the row is the jti claim of the refresh token.
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id                      TEXT PRIMARY KEY,
+    family_id               TEXT NOT NULL,
+    subject                 TEXT NOT NULL,
+    used_on                 DATETIME,
+    revoked_on              DATETIME,
+    expiration              DATETIME NOT NULL,
+    created                 DATETIME NOT NULL,
+    modified                DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family
+    ON refresh_tokens (family_id);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_expiration
+    ON refresh_tokens (expiration);
+
+COMMIT;
@@ -0,0 +1,24 @@
+-- Sessions record each login so that a user (or an administrator) can see where an
+-- account is signed in and revoke those sign ins. The id of a session is the family id
+-- of the refresh tokens issued on login; the jti is that of the most recently issued
+-- access and refresh tokens and is updated each time the session is refreshed.
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS sessions (
+    id                      TEXT PRIMARY KEY,
+    user_id                 TEXT NOT NULL,
+    jti                     TEXT NOT NULL,
+    user_agent              TEXT,
+    client_ip               TEXT,
+    last_refresh            DATETIME,
+    revoked                 DATETIME,
+    expiration              DATETIME NOT NULL,
+    created                 DATETIME NOT NULL,
+    modified                DATETIME NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id
+    ON sessions (user_id);
+
+COMMIT;
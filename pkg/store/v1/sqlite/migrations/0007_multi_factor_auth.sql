@@ -0,0 +1,11 @@
+-- Multi-factor authentication for user logins. The TOTP secret must be stored so that
+-- codes can be verified; the TOTP is only enabled once the user has confirmed enrollment
+-- with a valid code. Recovery codes are one-time use and only their hashes are stored
+-- as a JSON array so that each code can be removed when it is used.
+BEGIN;
+
+ALTER TABLE users ADD COLUMN totp_secret TEXT;
+ALTER TABLE users ADD COLUMN totp_enabled DATETIME;
+ALTER TABLE users ADD COLUMN recovery_codes TEXT;
+
+COMMIT;
@@ -0,0 +1,27 @@
+-- WebAuthn credentials (passkeys) that allow users to log in without a password. The
+-- credential_id is the ID assigned by the authenticator and is used to look up the
+-- credential during login; only the public key of the credential is stored. The flags
+-- are the authenticator data flags (user present, verified, backup eligible and state).
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS webauthn_credentials (
+    id                      TEXT PRIMARY KEY,
+    user_id                 TEXT NOT NULL,
+    name                    TEXT,
+    credential_id           BLOB NOT NULL UNIQUE,
+    public_key              BLOB NOT NULL,
+    attestation_type        TEXT,
+    aaguid                  BLOB,
+    sign_count              INTEGER DEFAULT 0 NOT NULL,
+    transports              TEXT,
+    flags                   INTEGER DEFAULT 0 NOT NULL,
+    last_used               DATETIME,
+    created                 DATETIME NOT NULL,
+    modified                DATETIME NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_webauthn_credentials_user_id
+    ON webauthn_credentials (user_id);
+
+COMMIT;
@@ -0,0 +1,20 @@
+-- Lockouts track consecutive failed authentication attempts against the email address
+-- of a user or the client ID of an API key or OAuth client so that brute-force attacks
+-- can be throttled with an exponential backoff. Attempts are tracked by identifier
+-- rather than by foreign key so that attempts against accounts that do not exist are
+-- treated the same way.
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS lockouts (
+    id                      TEXT PRIMARY KEY,
+    kind                    TEXT NOT NULL,
+    identifier              TEXT NOT NULL,
+    failed_attempts         INTEGER NOT NULL DEFAULT 0,
+    last_failure            DATETIME,
+    locked_until            DATETIME,
+    created                 DATETIME NOT NULL,
+    modified                DATETIME NOT NULL,
+    UNIQUE (kind, identifier)
+);
+
+COMMIT;
@@ -0,0 +1,28 @@
+-- The audit log records who created, changed, or deleted users, API keys, and OIDC
+-- clients and who logged in (or failed to) along with the request that did it. Events
+-- do not reference the actor or subject by foreign key so that the history of a
+-- resource is retained after it has been deleted.
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS audit_events (
+    id                      TEXT PRIMARY KEY,
+    actor_type              TEXT,
+    actor_id                TEXT,
+    action                  TEXT NOT NULL,
+    subject_type            TEXT NOT NULL,
+    subject_id              TEXT NOT NULL,
+    client_ip               TEXT,
+    user_agent              TEXT,
+    request_id              TEXT,
+    diff                    TEXT,
+    created                 DATETIME NOT NULL,
+    modified                DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_actor
+    ON audit_events (actor_id);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_subject
+    ON audit_events (subject_type, subject_id);
+
+COMMIT;
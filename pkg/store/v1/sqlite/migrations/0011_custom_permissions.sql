@@ -0,0 +1,20 @@
+-- Permissions are data-driven so that applications and OIDC clients can create their
+-- own permissions at runtime. Custom permissions are namespaced by the application or
+-- OIDC client that owns them; the built-in permissions are in the quarterdeck namespace
+-- and are protected so that they cannot be deleted or renamed.
+BEGIN;
+
+ALTER TABLE permissions ADD COLUMN namespace TEXT NOT NULL DEFAULT 'quarterdeck';
+ALTER TABLE permissions ADD COLUMN protected BOOLEAN NOT NULL DEFAULT false;
+
+-- Protect any built-in permissions that have already been created.
+UPDATE permissions SET protected=true WHERE title IN (
+    'users:view', 'users:manage',
+    'apikeys:view', 'apikeys:manage', 'apikeys:revoke',
+    'roles:view',
+    'permissions:view',
+    'config:view', 'config:manage',
+    'oidcclients:view', 'oidcclients:manage'
+);
+
+COMMIT;
@@ -0,0 +1,44 @@
+-- Organizations allow a single Quarterdeck instance to serve several tenants (e.g.
+-- customer workspaces). Users are members of one or more organizations with roles that
+-- only apply in that organization; API keys and OIDC clients belong to an organization.
+-- API keys and OIDC clients without an organization are not scoped to a tenant.
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS organizations (
+    id              TEXT PRIMARY KEY,
+    name            TEXT NOT NULL,
+    street_address  TEXT,
+    homepage_uri    TEXT,
+    support_email   TEXT,
+    created         DATETIME NOT NULL,
+    modified        DATETIME NOT NULL
+);
+
+-- A user is a member of an organization as long as they have at least one role in it.
+CREATE TABLE IF NOT EXISTS organization_members (
+    organization_id TEXT NOT NULL,
+    user_id         TEXT NOT NULL,
+    role_id         INTEGER NOT NULL,
+    created         DATETIME NOT NULL,
+    PRIMARY KEY (organization_id, user_id, role_id),
+    FOREIGN KEY (organization_id) REFERENCES organizations (id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    FOREIGN KEY (role_id) REFERENCES roles (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_organization_members_user
+    ON organization_members (user_id);
+
+ALTER TABLE api_keys ADD COLUMN organization_id TEXT REFERENCES organizations (id) ON DELETE CASCADE;
+ALTER TABLE oidc_clients ADD COLUMN organization_id TEXT REFERENCES organizations (id) ON DELETE CASCADE;
+
+-- Allows the permissions a user has in an organization to be selected by their roles.
+DROP VIEW IF EXISTS organization_member_permissions;
+CREATE VIEW organization_member_permissions AS
+    SELECT DISTINCT om.organization_id, om.user_id, p.title AS permission
+        FROM organization_members om
+        JOIN role_permissions rp ON rp.role_id = om.role_id
+        JOIN permissions p ON p.id = rp.permission_id
+;
+
+COMMIT;
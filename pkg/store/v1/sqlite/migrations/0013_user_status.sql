@@ -0,0 +1,11 @@
+-- User statuses allow accounts to be suspended or deactivated without deleting them.
+-- Deleted users are kept with the time they were deleted so that they can be restored
+-- until the retention period has passed and they are purged.
+BEGIN;
+
+ALTER TABLE users ADD COLUMN status TEXT NOT NULL DEFAULT 'active';
+ALTER TABLE users ADD COLUMN deleted DATETIME;
+
+CREATE INDEX IF NOT EXISTS idx_users_status ON users (status, deleted);
+
+COMMIT;
@@ -0,0 +1,42 @@
+-- Webhooks subscribe endpoints to Quarterdeck events. Events are queued as deliveries
+-- in the same transaction that enqueues them so that they are not lost if the endpoint
+-- is unavailable; deliveries are retried with backoff and kept as a delivery log.
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS webhooks (
+    id              TEXT PRIMARY KEY,
+    url             TEXT NOT NULL,
+    description     TEXT,
+    events          TEXT NOT NULL,
+    secret          TEXT NOT NULL,
+    active          BOOLEAN NOT NULL DEFAULT true,
+    created         DATETIME NOT NULL,
+    modified        DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id              TEXT PRIMARY KEY,
+    webhook_id      TEXT NOT NULL,
+    event_id        TEXT NOT NULL,
+    event           TEXT NOT NULL,
+    payload         TEXT NOT NULL,
+    status          TEXT NOT NULL DEFAULT 'pending',
+    attempts        INTEGER NOT NULL DEFAULT 0,
+    next_attempt    DATETIME,
+    last_attempt    DATETIME,
+    response_code   INTEGER,
+    error           TEXT,
+    delivered       DATETIME,
+    created         DATETIME NOT NULL,
+    modified        DATETIME NOT NULL,
+    FOREIGN KEY (webhook_id) REFERENCES webhooks (id) ON DELETE CASCADE
+);
+
+-- Allows the dispatcher to select the pending deliveries that are due.
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due
+    ON webhook_deliveries (status, next_attempt);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook
+    ON webhook_deliveries (webhook_id);
+
+COMMIT;
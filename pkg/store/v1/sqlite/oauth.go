@@ -0,0 +1,411 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v1/models"
+	"go.rtnl.ai/ulid"
+)
+
+//===========================================================================
+// Authorization Code Store
+//===========================================================================
+
+const (
+	createAuthorizationCodeSQL = "INSERT INTO oauth_authorization_codes (id, code, client_id, user_id, redirect_uri, scope, nonce, code_challenge, code_challenge_method, expiration, created, modified) VALUES (:id, :code, :clientID, :userID, :redirectURI, :scope, :nonce, :codeChallenge, :codeChallengeMethod, :expiration, :created, :modified)"
+)
+
+func (s *Store) CreateAuthorizationCode(ctx context.Context, code *models.AuthorizationCode) (err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, nil); err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = tx.CreateAuthorizationCode(code); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (tx *Tx) CreateAuthorizationCode(code *models.AuthorizationCode) (err error) {
+	if !code.ID.IsZero() {
+		return errors.ErrNoIDOnCreate
+	}
+
+	if code.Code == "" || code.ClientID == "" || code.UserID.IsZero() || code.RedirectURI == "" {
+		return errors.ErrZeroValuedNotNull
+	}
+
+	code.ID = ulid.MakeSecure()
+	code.Created = time.Now()
+	code.Modified = code.Created
+
+	if _, err = tx.Exec(createAuthorizationCodeSQL, code.Params()...); err != nil {
+		return dbe(err)
+	}
+
+	return nil
+}
+
+const (
+	retrieveAuthorizationCodeSQL = "SELECT id, code, client_id, user_id, redirect_uri, scope, nonce, code_challenge, code_challenge_method, expiration, created, modified FROM oauth_authorization_codes WHERE code=:code"
+)
+
+// RetrieveAuthorizationCode looks up an authorization code by the hash of the code.
+func (s *Store) RetrieveAuthorizationCode(ctx context.Context, code string) (out *models.AuthorizationCode, err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, &sql.TxOptions{ReadOnly: true}); err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if out, err = tx.RetrieveAuthorizationCode(code); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (tx *Tx) RetrieveAuthorizationCode(code string) (out *models.AuthorizationCode, err error) {
+	if code == "" {
+		return nil, errors.ErrMissingID
+	}
+
+	out = &models.AuthorizationCode{}
+	if err = out.Scan(tx.QueryRow(retrieveAuthorizationCodeSQL, sql.Named("code", code))); err != nil {
+		return nil, dbe(err)
+	}
+
+	return out, nil
+}
+
+const (
+	deleteAuthorizationCodeSQL = "DELETE FROM oauth_authorization_codes WHERE id=:id"
+	deleteExpiredAuthCodesSQL  = "DELETE FROM oauth_authorization_codes WHERE expiration < :now"
+)
+
+func (s *Store) DeleteAuthorizationCode(ctx context.Context, id ulid.ULID) (err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, nil); err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = tx.DeleteAuthorizationCode(id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteAuthorizationCode removes the code so that it cannot be exchanged again; any
+// expired codes are also cleaned up at the same time.
+func (tx *Tx) DeleteAuthorizationCode(id ulid.ULID) (err error) {
+	if id.IsZero() {
+		return errors.ErrMissingID
+	}
+
+	var result sql.Result
+	if result, err = tx.Exec(deleteAuthorizationCodeSQL, sql.Named("id", id)); err != nil {
+		return dbe(err)
+	}
+
+	if nRows, _ := result.RowsAffected(); nRows == 0 {
+		return errors.ErrNotFound
+	}
+
+	if _, err = tx.Exec(deleteExpiredAuthCodesSQL, sql.Named("now", time.Now())); err != nil {
+		return dbe(err)
+	}
+
+	return nil
+}
+
+//===========================================================================
+// Revoked Token Store
+//===========================================================================
+
+const (
+	revokeTokenSQL          = "INSERT INTO revoked_tokens (id, expiration, created, modified) VALUES (:id, :expiration, :created, :modified) ON CONFLICT (id) DO NOTHING"
+	deleteExpiredRevokedSQL = "DELETE FROM revoked_tokens WHERE expiration < :now"
+)
+
+func (s *Store) RevokeToken(ctx context.Context, in *models.RevokedToken) (err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, nil); err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = tx.RevokeToken(in); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RevokeToken adds the jti of the token to the denylist; revoking a token that has
+// already been revoked is not an error. Entries for tokens that have expired are
+// removed from the denylist at the same time.
+func (tx *Tx) RevokeToken(in *models.RevokedToken) (err error) {
+	if in.ID.IsZero() {
+		return errors.ErrMissingID
+	}
+
+	if in.Expiration.IsZero() {
+		return errors.ErrZeroValuedNotNull
+	}
+
+	in.Created = time.Now()
+	in.Modified = in.Created
+
+	if _, err = tx.Exec(revokeTokenSQL, in.Params()...); err != nil {
+		return dbe(err)
+	}
+
+	if _, err = tx.Exec(deleteExpiredRevokedSQL, sql.Named("now", in.Created)); err != nil {
+		return dbe(err)
+	}
+
+	return nil
+}
+
+const (
+	isTokenRevokedSQL = "SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE id=:id)"
+)
+
+func (s *Store) IsTokenRevoked(ctx context.Context, jti ulid.ULID) (revoked bool, err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, &sql.TxOptions{ReadOnly: true}); err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if revoked, err = tx.IsTokenRevoked(jti); err != nil {
+		return false, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return false, err
+	}
+
+	return revoked, nil
+}
+
+func (tx *Tx) IsTokenRevoked(jti ulid.ULID) (revoked bool, err error) {
+	if jti.IsZero() {
+		return false, errors.ErrMissingID
+	}
+
+	if err = tx.QueryRow(isTokenRevokedSQL, sql.Named("id", jti)).Scan(&revoked); err != nil {
+		return false, dbe(err)
+	}
+
+	return revoked, nil
+}
+
+//===========================================================================
+// Refresh Token Store
+//===========================================================================
+
+const (
+	createRefreshTokenSQL    = "INSERT INTO refresh_tokens (id, family_id, subject, used_on, revoked_on, expiration, created, modified) VALUES (:id, :familyID, :subject, :usedOn, :revokedOn, :expiration, :created, :modified)"
+	deleteExpiredRefreshSQL  = "DELETE FROM refresh_tokens WHERE expiration < :now"
+	retrieveRefreshTokenSQL  = "SELECT id, family_id, subject, used_on, revoked_on, expiration, created, modified FROM refresh_tokens WHERE id=:id"
+	useRefreshTokenSQL       = "UPDATE refresh_tokens SET used_on=:now, modified=:now WHERE id=:id AND used_on IS NULL AND revoked_on IS NULL"
+	refreshFamilyExistsSQL   = "SELECT EXISTS (SELECT 1 FROM refresh_tokens WHERE family_id=:familyID)"
+	revokeRefreshFamilySQL   = "UPDATE refresh_tokens SET revoked_on=:now, modified=:now WHERE family_id=:familyID AND revoked_on IS NULL"
+	denylistRefreshFamilySQL = "INSERT INTO revoked_tokens (id, expiration, created, modified) SELECT id, expiration, :now, :now FROM refresh_tokens WHERE family_id=:familyID AND expiration > :now ON CONFLICT (id) DO NOTHING"
+)
+
+func (s *Store) CreateRefreshToken(ctx context.Context, in *models.RefreshToken) (err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, nil); err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = tx.CreateRefreshToken(in); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CreateRefreshToken records a newly issued refresh token. The ID must be the jti of
+// the refresh token; if no family is specified the token starts a new family. Refresh
+// tokens that have expired are removed beforehand.
+func (tx *Tx) CreateRefreshToken(in *models.RefreshToken) (err error) {
+	if in.ID.IsZero() {
+		return errors.ErrMissingID
+	}
+
+	if in.Subject == "" || in.Expiration.IsZero() {
+		return errors.ErrZeroValuedNotNull
+	}
+
+	if in.FamilyID.IsZero() {
+		in.FamilyID = in.ID
+	}
+
+	in.Created = time.Now()
+	in.Modified = in.Created
+
+	if _, err = tx.Exec(deleteExpiredRefreshSQL, sql.Named("now", in.Created)); err != nil {
+		return dbe(err)
+	}
+
+	if _, err = tx.Exec(createRefreshTokenSQL, in.Params()...); err != nil {
+		return dbe(err)
+	}
+
+	return nil
+}
+
+func (s *Store) RetrieveRefreshToken(ctx context.Context, jti ulid.ULID) (out *models.RefreshToken, err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, &sql.TxOptions{ReadOnly: true}); err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if out, err = tx.RetrieveRefreshToken(jti); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (tx *Tx) RetrieveRefreshToken(jti ulid.ULID) (out *models.RefreshToken, err error) {
+	if jti.IsZero() {
+		return nil, errors.ErrMissingID
+	}
+
+	out = &models.RefreshToken{}
+	if err = out.Scan(tx.QueryRow(retrieveRefreshTokenSQL, sql.Named("id", jti))); err != nil {
+		return nil, dbe(err)
+	}
+
+	return out, nil
+}
+
+func (s *Store) UseRefreshToken(ctx context.Context, jti ulid.ULID) (out *models.RefreshToken, err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, nil); err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if out, err = tx.UseRefreshToken(jti); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// UseRefreshToken marks the refresh token as used so that it cannot be exchanged
+// again. If the token has already been used ErrTokenReused is returned and if its
+// family has been revoked ErrTokenRevoked is returned; in both cases the caller should
+// treat the request as a failed authentication.
+func (tx *Tx) UseRefreshToken(jti ulid.ULID) (out *models.RefreshToken, err error) {
+	if out, err = tx.RetrieveRefreshToken(jti); err != nil {
+		return nil, err
+	}
+
+	if out.RevokedOn.Valid {
+		return nil, errors.ErrTokenRevoked
+	}
+
+	if out.UsedOn.Valid {
+		return nil, errors.ErrTokenReused
+	}
+
+	if out.IsExpired() {
+		return nil, errors.ErrExpiredToken
+	}
+
+	// The update is conditional so that only one concurrent request can use the token.
+	now := time.Now()
+	var result sql.Result
+	if result, err = tx.Exec(useRefreshTokenSQL, sql.Named("id", jti), sql.Named("now", now)); err != nil {
+		return nil, dbe(err)
+	}
+
+	var rows int64
+	if rows, err = result.RowsAffected(); err != nil {
+		return nil, dbe(err)
+	}
+
+	if rows == 0 {
+		return nil, errors.ErrTokenReused
+	}
+
+	out.UsedOn = sql.NullTime{Time: now, Valid: true}
+	out.Modified = now
+	return out, nil
+}
+
+func (s *Store) RevokeRefreshTokenFamily(ctx context.Context, familyID ulid.ULID) (err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, nil); err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = tx.RevokeRefreshTokenFamily(familyID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RevokeRefreshTokenFamily revokes every refresh token in the family and adds their
+// jtis to the denylist so that the access tokens issued alongside them are also
+// rejected. Revoking a family that has already been revoked is not an error.
+func (tx *Tx) RevokeRefreshTokenFamily(familyID ulid.ULID) (err error) {
+	if familyID.IsZero() {
+		return errors.ErrMissingID
+	}
+
+	now := time.Now()
+	params := []any{sql.Named("familyID", familyID), sql.Named("now", now)}
+
+	var result sql.Result
+	if result, err = tx.Exec(revokeRefreshFamilySQL, params...); err != nil {
+		return dbe(err)
+	}
+
+	if nRows, _ := result.RowsAffected(); nRows == 0 {
+		var exists bool
+		if err = tx.QueryRow(refreshFamilyExistsSQL, params[0]).Scan(&exists); err != nil {
+			return dbe(err)
+		}
+
+		if !exists {
+			return errors.ErrNotFound
+		}
+	}
+
+	if _, err = tx.Exec(denylistRefreshFamilySQL, params...); err != nil {
+		return dbe(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,235 @@
+package sqlite_test
+
+import (
+	"database/sql"
+	"time"
+
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v1/models"
+	"go.rtnl.ai/ulid"
+)
+
+func (s *storeTestSuite) TestAuthorizationCodes() {
+	s.Run("NoIDOnCreate", func() {
+		code := &models.AuthorizationCode{
+			Model:       models.Model{ID: ulid.Make()},
+			Code:        "hashedcode",
+			ClientID:    minimalClientID,
+			UserID:      ulid.MustParse(keyholderUserULID),
+			RedirectURI: "https://example.com/cb",
+			Expiration:  time.Now().Add(10 * time.Minute),
+		}
+		err := s.db.CreateAuthorizationCode(s.Context(), code)
+		s.Require().ErrorIs(err, errors.ErrNoIDOnCreate)
+	})
+
+	s.Run("RequiredFields", func() {
+		code := &models.AuthorizationCode{
+			ClientID:    minimalClientID,
+			UserID:      ulid.MustParse(keyholderUserULID),
+			RedirectURI: "https://example.com/cb",
+			Expiration:  time.Now().Add(10 * time.Minute),
+		}
+		err := s.db.CreateAuthorizationCode(s.Context(), code)
+		s.Require().ErrorIs(err, errors.ErrZeroValuedNotNull)
+	})
+
+	s.Run("ReadOnly", func() {
+		if !s.ReadOnly() {
+			s.T().Skip("skipping read-only error test in read-write mode")
+		}
+		code := &models.AuthorizationCode{
+			Code:        "hashedcode",
+			ClientID:    minimalClientID,
+			UserID:      ulid.MustParse(keyholderUserULID),
+			RedirectURI: "https://example.com/cb",
+			Expiration:  time.Now().Add(10 * time.Minute),
+		}
+		err := s.db.CreateAuthorizationCode(s.Context(), code)
+		s.Require().ErrorIs(err, errors.ErrReadOnly)
+	})
+
+	s.Run("Lifecycle", func() {
+		if s.ReadOnly() {
+			s.T().Skip("skipping create test in read-only mode")
+		}
+		require := s.Require()
+
+		code := &models.AuthorizationCode{
+			Code:                "6a0d8a1b6c2f4e3d",
+			ClientID:            minimalClientID,
+			UserID:              ulid.MustParse(keyholderUserULID),
+			RedirectURI:         "https://example.com/cb",
+			Scope:               sql.NullString{Valid: true, String: "openid email profile"},
+			Nonce:               sql.NullString{Valid: true, String: "n-0S6_WzA2Mj"},
+			CodeChallenge:       sql.NullString{Valid: true, String: "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"},
+			CodeChallengeMethod: sql.NullString{Valid: true, String: "S256"},
+			Expiration:          time.Now().Add(10 * time.Minute),
+		}
+
+		require.NoError(s.db.CreateAuthorizationCode(s.Context(), code))
+		require.False(code.ID.IsZero())
+		require.Equal(1, s.Count("oauth_authorization_codes"))
+
+		got, err := s.db.RetrieveAuthorizationCode(s.Context(), code.Code)
+		require.NoError(err)
+		require.Equal(code.ID, got.ID)
+		require.Equal(code.ClientID, got.ClientID)
+		require.Equal(code.UserID, got.UserID)
+		require.Equal(code.RedirectURI, got.RedirectURI)
+		require.Equal(code.Nonce, got.Nonce)
+		require.Equal(code.CodeChallenge, got.CodeChallenge)
+		require.Equal(code.CodeChallengeMethod, got.CodeChallengeMethod)
+		require.True(got.HasScope("openid"))
+		require.False(got.IsExpired())
+
+		require.NoError(s.db.DeleteAuthorizationCode(s.Context(), code.ID))
+		require.Equal(0, s.Count("oauth_authorization_codes"))
+
+		_, err = s.db.RetrieveAuthorizationCode(s.Context(), code.Code)
+		require.ErrorIs(err, errors.ErrNotFound)
+
+		err = s.db.DeleteAuthorizationCode(s.Context(), code.ID)
+		require.ErrorIs(err, errors.ErrNotFound)
+	})
+}
+
+func (s *storeTestSuite) TestRevokedTokens() {
+	s.Run("MissingID", func() {
+		err := s.db.RevokeToken(s.Context(), &models.RevokedToken{Expiration: time.Now().Add(time.Hour)})
+		s.Require().ErrorIs(err, errors.ErrMissingID)
+
+		_, err = s.db.IsTokenRevoked(s.Context(), ulid.Zero)
+		s.Require().ErrorIs(err, errors.ErrMissingID)
+	})
+
+	s.Run("RequiredFields", func() {
+		err := s.db.RevokeToken(s.Context(), &models.RevokedToken{Model: models.Model{ID: ulid.Make()}})
+		s.Require().ErrorIs(err, errors.ErrZeroValuedNotNull)
+	})
+
+	s.Run("Lifecycle", func() {
+		if s.ReadOnly() {
+			s.T().Skip("skipping create test in read-only mode")
+		}
+		require := s.Require()
+
+		jti := ulid.Make()
+		revoked, err := s.db.IsTokenRevoked(s.Context(), jti)
+		require.NoError(err)
+		require.False(revoked)
+
+		token := &models.RevokedToken{Model: models.Model{ID: jti}, Expiration: time.Now().Add(time.Hour)}
+		require.NoError(s.db.RevokeToken(s.Context(), token))
+
+		revoked, err = s.db.IsTokenRevoked(s.Context(), jti)
+		require.NoError(err)
+		require.True(revoked)
+
+		// Revoking the token again should not be an error
+		require.NoError(s.db.RevokeToken(s.Context(), token))
+		require.Equal(1, s.Count("revoked_tokens"))
+
+		// Revoking a token cleans up expired entries
+		expired := &models.RevokedToken{Model: models.Model{ID: ulid.Make()}, Expiration: time.Now().Add(-time.Minute)}
+		require.NoError(s.db.RevokeToken(s.Context(), expired))
+		require.Equal(1, s.Count("revoked_tokens"))
+	})
+}
+
+func (s *storeTestSuite) TestRefreshTokens() {
+	s.Run("MissingID", func() {
+		err := s.db.CreateRefreshToken(s.Context(), &models.RefreshToken{Subject: "u01", Expiration: time.Now().Add(time.Hour)})
+		s.Require().ErrorIs(err, errors.ErrMissingID)
+
+		_, err = s.db.RetrieveRefreshToken(s.Context(), ulid.Zero)
+		s.Require().ErrorIs(err, errors.ErrMissingID)
+
+		_, err = s.db.UseRefreshToken(s.Context(), ulid.Zero)
+		s.Require().ErrorIs(err, errors.ErrMissingID)
+
+		err = s.db.RevokeRefreshTokenFamily(s.Context(), ulid.Zero)
+		s.Require().ErrorIs(err, errors.ErrMissingID)
+	})
+
+	s.Run("RequiredFields", func() {
+		err := s.db.CreateRefreshToken(s.Context(), &models.RefreshToken{Model: models.Model{ID: ulid.Make()}, Expiration: time.Now().Add(time.Hour)})
+		s.Require().ErrorIs(err, errors.ErrZeroValuedNotNull)
+
+		err = s.db.CreateRefreshToken(s.Context(), &models.RefreshToken{Model: models.Model{ID: ulid.Make()}, Subject: "u01"})
+		s.Require().ErrorIs(err, errors.ErrZeroValuedNotNull)
+	})
+
+	s.Run("NotFound", func() {
+		_, err := s.db.RetrieveRefreshToken(s.Context(), ulid.Make())
+		s.Require().ErrorIs(err, errors.ErrNotFound)
+
+		if s.ReadOnly() {
+			return
+		}
+
+		_, err = s.db.UseRefreshToken(s.Context(), ulid.Make())
+		s.Require().ErrorIs(err, errors.ErrNotFound)
+
+		err = s.db.RevokeRefreshTokenFamily(s.Context(), ulid.Make())
+		s.Require().ErrorIs(err, errors.ErrNotFound)
+	})
+
+	s.Run("Rotation", func() {
+		if s.ReadOnly() {
+			s.T().Skip("skipping create test in read-only mode")
+		}
+		require := s.Require()
+
+		// The first token in a family starts the family.
+		first := &models.RefreshToken{Model: models.Model{ID: ulid.Make()}, Subject: "u01JPYRNYMEHNEZCS0JYX1CP57A", Expiration: time.Now().Add(time.Hour)}
+		require.NoError(s.db.CreateRefreshToken(s.Context(), first))
+		require.Equal(first.ID, first.FamilyID)
+		require.False(first.Created.IsZero())
+
+		used, err := s.db.UseRefreshToken(s.Context(), first.ID)
+		require.NoError(err)
+		require.True(used.UsedOn.Valid)
+		require.Equal(first.FamilyID, used.FamilyID)
+
+		// Rotate to the next token in the family.
+		second := &models.RefreshToken{Model: models.Model{ID: ulid.Make()}, FamilyID: used.FamilyID, Subject: used.Subject, Expiration: time.Now().Add(time.Hour)}
+		require.NoError(s.db.CreateRefreshToken(s.Context(), second))
+
+		// The first token cannot be used again.
+		_, err = s.db.UseRefreshToken(s.Context(), first.ID)
+		require.ErrorIs(err, errors.ErrTokenReused)
+
+		// Revoking the family revokes the second token and denylists both jtis.
+		require.NoError(s.db.RevokeRefreshTokenFamily(s.Context(), first.FamilyID))
+
+		_, err = s.db.UseRefreshToken(s.Context(), second.ID)
+		require.ErrorIs(err, errors.ErrTokenRevoked)
+
+		for _, jti := range []ulid.ULID{first.ID, second.ID} {
+			revoked, err := s.db.IsTokenRevoked(s.Context(), jti)
+			require.NoError(err)
+			require.True(revoked)
+		}
+
+		// Revoking the family again is not an error.
+		require.NoError(s.db.RevokeRefreshTokenFamily(s.Context(), first.FamilyID))
+	})
+
+	s.Run("Expired", func() {
+		if s.ReadOnly() {
+			s.T().Skip("skipping create test in read-only mode")
+		}
+		require := s.Require()
+
+		token := &models.RefreshToken{Model: models.Model{ID: ulid.Make()}, Subject: "u01JPYRNYMEHNEZCS0JYX1CP57A", Expiration: time.Now().Add(-time.Minute)}
+		require.NoError(s.db.CreateRefreshToken(s.Context(), token))
+
+		// Expired tokens are cleaned up when the next refresh token is created
+		next := &models.RefreshToken{Model: models.Model{ID: ulid.Make()}, Subject: token.Subject, Expiration: time.Now().Add(time.Hour)}
+		require.NoError(s.db.CreateRefreshToken(s.Context(), next))
+
+		_, err := s.db.RetrieveRefreshToken(s.Context(), token.ID)
+		require.ErrorIs(err, errors.ErrNotFound)
+	})
+}
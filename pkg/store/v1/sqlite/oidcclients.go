@@ -15,7 +15,7 @@ import (
 //===========================================================================
 
 const (
-	listOIDCClientsSQL = "SELECT id, client_name, client_uri, logo_uri, policy_uri, tos_uri, redirect_uris, contacts, client_id, created_by, created, modified FROM oidc_clients ORDER BY created DESC"
+	listOIDCClientsSQL = "SELECT id, client_name, client_uri, logo_uri, policy_uri, tos_uri, redirect_uris, contacts, client_id, created_by, created, modified, organization_id FROM oidc_clients"
 )
 
 func (tx *Tx) ListOIDCClients(page *models.Page) (out *models.OIDCClientList, err error) {
@@ -24,8 +24,15 @@ func (tx *Tx) ListOIDCClients(page *models.Page) (out *models.OIDCClientList, er
 		Page:        models.PageFrom(page),
 	}
 
+	query := listOIDCClientsSQL
+	where, order, params := pageQuery(page, out.Page.PageSize, "id")
+	if where != "" {
+		query += " WHERE " + where
+	}
+	query += order
+
 	var rows *sql.Rows
-	if rows, err = tx.Query(listOIDCClientsSQL); err != nil {
+	if rows, err = tx.Query(query, params...); err != nil {
 		return nil, dbe(err)
 	}
 	defer rows.Close()
@@ -42,11 +49,12 @@ func (tx *Tx) ListOIDCClients(page *models.Page) (out *models.OIDCClientList, er
 		return nil, dbe(err)
 	}
 
+	out.OIDCClients = paginate(page, out.Page, out.OIDCClients, func(c *models.OIDCClient) ulid.ULID { return c.ID })
 	return out, nil
 }
 
 const (
-	createOIDCClientSQL = "INSERT INTO oidc_clients (id, client_name, client_uri, logo_uri, policy_uri, tos_uri, redirect_uris, contacts, client_id, secret, created_by, created, modified) VALUES (:id, :clientName, :clientURI, :logoURI, :policyURI, :tosURI, :redirectURIs, :contacts, :clientID, :secret, :createdBy, :created, :modified)"
+	createOIDCClientSQL = "INSERT INTO oidc_clients (id, client_name, client_uri, logo_uri, policy_uri, tos_uri, redirect_uris, contacts, client_id, secret, created_by, created, modified, organization_id) VALUES (:id, :clientName, :clientURI, :logoURI, :policyURI, :tosURI, :redirectURIs, :contacts, :clientID, :secret, :createdBy, :created, :modified, :orgID)"
 )
 
 func (tx *Tx) CreateOIDCClient(client *models.OIDCClient) (err error) {
@@ -70,8 +78,8 @@ func (tx *Tx) CreateOIDCClient(client *models.OIDCClient) (err error) {
 }
 
 const (
-	retrieveOIDCClientByClientIDSQL = "SELECT id, client_name, client_uri, logo_uri, policy_uri, tos_uri, redirect_uris, contacts, client_id, secret, created_by, created, modified FROM oidc_clients WHERE client_id=:clientID"
-	retrieveOIDCClientByIDSQL       = "SELECT id, client_name, client_uri, logo_uri, policy_uri, tos_uri, redirect_uris, contacts, client_id, secret, created_by, created, modified FROM oidc_clients WHERE id=:id"
+	retrieveOIDCClientByClientIDSQL = "SELECT id, client_name, client_uri, logo_uri, policy_uri, tos_uri, redirect_uris, contacts, client_id, secret, created_by, created, modified, organization_id FROM oidc_clients WHERE client_id=:clientID"
+	retrieveOIDCClientByIDSQL       = "SELECT id, client_name, client_uri, logo_uri, policy_uri, tos_uri, redirect_uris, contacts, client_id, secret, created_by, created, modified, organization_id FROM oidc_clients WHERE id=:id"
 )
 
 func (tx *Tx) RetrieveOIDCClient(id any) (client *models.OIDCClient, err error) {
@@ -0,0 +1,468 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v1/models"
+	"go.rtnl.ai/ulid"
+)
+
+//===========================================================================
+// Organizations Store
+//===========================================================================
+
+const listOrganizationsSQL = "SELECT * FROM organizations ORDER BY created ASC"
+
+func (s *Store) ListOrganizations(ctx context.Context, page *models.Page) (out *models.OrganizationList, err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, &sql.TxOptions{ReadOnly: true}); err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if out, err = tx.ListOrganizations(page); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (tx *Tx) ListOrganizations(page *models.Page) (out *models.OrganizationList, err error) {
+	out = &models.OrganizationList{
+		Page:          models.PageFrom(page),
+		Organizations: make([]*models.Organization, 0),
+	}
+
+	if out.Organizations, err = tx.queryOrganizations(listOrganizationsSQL); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+const createOrganizationSQL = "INSERT INTO organizations (id, name, street_address, homepage_uri, support_email, created, modified) VALUES (:id, :name, :streetAddress, :homepageURI, :supportEmail, :created, :modified)"
+
+func (s *Store) CreateOrganization(ctx context.Context, org *models.Organization) (err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, nil); err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = tx.CreateOrganization(org); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (tx *Tx) CreateOrganization(org *models.Organization) (err error) {
+	if !org.ID.IsZero() {
+		return errors.ErrNoIDOnCreate
+	}
+
+	if org.Name == "" {
+		return errors.ErrZeroValuedNotNull
+	}
+
+	org.ID = ulid.MakeSecure()
+	org.Created = time.Now()
+	org.Modified = org.Created
+
+	if _, err = tx.Exec(createOrganizationSQL, org.Params()...); err != nil {
+		return dbe(err)
+	}
+	return nil
+}
+
+const retrieveOrganizationSQL = "SELECT * FROM organizations WHERE id=:id"
+
+func (s *Store) RetrieveOrganization(ctx context.Context, orgID ulid.ULID) (out *models.Organization, err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, &sql.TxOptions{ReadOnly: true}); err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if out, err = tx.RetrieveOrganization(orgID); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (tx *Tx) RetrieveOrganization(orgID ulid.ULID) (out *models.Organization, err error) {
+	out = &models.Organization{}
+	if err = out.Scan(tx.QueryRow(retrieveOrganizationSQL, sql.Named("id", orgID))); err != nil {
+		return nil, dbe(err)
+	}
+	return out, nil
+}
+
+const updateOrganizationSQL = "UPDATE organizations SET name=:name, street_address=:streetAddress, homepage_uri=:homepageURI, support_email=:supportEmail, modified=:modified WHERE id=:id"
+
+func (s *Store) UpdateOrganization(ctx context.Context, org *models.Organization) (err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, nil); err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = tx.UpdateOrganization(org); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (tx *Tx) UpdateOrganization(org *models.Organization) (err error) {
+	if org.ID.IsZero() {
+		return errors.ErrMissingID
+	}
+
+	if org.Name == "" {
+		return errors.ErrZeroValuedNotNull
+	}
+
+	org.Modified = time.Now()
+
+	var result sql.Result
+	if result, err = tx.Exec(updateOrganizationSQL, org.Params()...); err != nil {
+		return dbe(err)
+	}
+
+	if nRows, _ := result.RowsAffected(); nRows == 0 {
+		return errors.ErrNotFound
+	}
+	return nil
+}
+
+const deleteOrganizationSQL = "DELETE FROM organizations WHERE id=:id"
+
+// DeleteOrganization removes the organization along with its memberships, API keys, and
+// OIDC clients (which are deleted by the foreign key cascade).
+func (s *Store) DeleteOrganization(ctx context.Context, orgID ulid.ULID) (err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, nil); err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = tx.DeleteOrganization(orgID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (tx *Tx) DeleteOrganization(orgID ulid.ULID) (err error) {
+	if orgID.IsZero() {
+		return errors.ErrMissingID
+	}
+
+	var result sql.Result
+	if result, err = tx.Exec(deleteOrganizationSQL, sql.Named("id", orgID)); err != nil {
+		return dbe(err)
+	}
+
+	if nRows, _ := result.RowsAffected(); nRows == 0 {
+		return errors.ErrNotFound
+	}
+	return nil
+}
+
+//===========================================================================
+// Organization Members
+//===========================================================================
+
+const listUserOrganizationsSQL = "SELECT o.* FROM organizations o WHERE o.id IN (SELECT organization_id FROM organization_members WHERE user_id=:userID) ORDER BY o.created ASC"
+
+// ListUserOrganizations returns the organizations that the user is a member of in the
+// order that the organizations were created.
+func (s *Store) ListUserOrganizations(ctx context.Context, userID ulid.ULID) (out []*models.Organization, err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, &sql.TxOptions{ReadOnly: true}); err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if out, err = tx.ListUserOrganizations(userID); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (tx *Tx) ListUserOrganizations(userID ulid.ULID) ([]*models.Organization, error) {
+	return tx.queryOrganizations(listUserOrganizationsSQL, sql.Named("userID", userID))
+}
+
+const (
+	listOrganizationMembersSQL = "SELECT id, name, email, last_login, email_verified, created, modified, status, deleted FROM users WHERE id IN (SELECT user_id FROM organization_members WHERE organization_id=:orgID) AND status<>'deleted' ORDER BY created DESC"
+	memberRolesSQL             = "SELECT r.id, r.title, r.description, r.is_default, r.created, r.modified FROM organization_members om JOIN roles r ON om.role_id = r.id WHERE om.organization_id=:orgID AND om.user_id=:userID"
+	memberPermissionsSQL       = "SELECT permission FROM organization_member_permissions WHERE organization_id=:orgID AND user_id=:userID"
+)
+
+// ListOrganizationMembers returns a summary of the users who are members of the
+// organization; the roles of each user are the roles they have in the organization.
+func (s *Store) ListOrganizationMembers(ctx context.Context, orgID ulid.ULID) (out []*models.User, err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, &sql.TxOptions{ReadOnly: true}); err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if out, err = tx.ListOrganizationMembers(orgID); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (tx *Tx) ListOrganizationMembers(orgID ulid.ULID) (out []*models.User, err error) {
+	if _, err = tx.RetrieveOrganization(orgID); err != nil {
+		return nil, err
+	}
+
+	var rows *sql.Rows
+	if rows, err = tx.Query(listOrganizationMembersSQL, sql.Named("orgID", orgID)); err != nil {
+		return nil, dbe(err)
+	}
+	defer rows.Close()
+
+	out = make([]*models.User, 0)
+	for rows.Next() {
+		user := &models.User{}
+		if err = user.ScanSummary(rows); err != nil {
+			return nil, err
+		}
+		user.SetOrgID(orgID)
+		out = append(out, user)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, dbe(err)
+	}
+	rows.Close()
+
+	for _, user := range out {
+		var roles []*models.Role
+		if roles, err = tx.memberRoles(orgID, user.ID); err != nil {
+			return nil, err
+		}
+		user.SetRoles(roles)
+	}
+
+	return out, nil
+}
+
+// RetrieveOrganizationMember returns the user with the roles and permissions that they
+// have in the organization in addition to their global roles and permissions. If the
+// user is not a member of the organization then ErrNotFound is returned.
+func (s *Store) RetrieveOrganizationMember(ctx context.Context, orgID, userID ulid.ULID) (out *models.User, err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, &sql.TxOptions{ReadOnly: true}); err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if out, err = tx.RetrieveOrganizationMember(orgID, userID); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (tx *Tx) RetrieveOrganizationMember(orgID, userID ulid.ULID) (out *models.User, err error) {
+	if out, err = tx.RetrieveUser(userID); err != nil {
+		return nil, err
+	}
+
+	var roles []*models.Role
+	if roles, err = tx.memberRoles(orgID, userID); err != nil {
+		return nil, err
+	}
+
+	if len(roles) == 0 {
+		return nil, errors.ErrNotFound
+	}
+
+	var permissions []string
+	if permissions, err = tx.memberPermissions(orgID, userID); err != nil {
+		return nil, err
+	}
+
+	// The user's global roles were loaded by RetrieveUser so they can be merged.
+	global, _ := out.Roles()
+	for _, role := range roles {
+		if !containsRole(global, role.ID) {
+			global = append(global, role)
+		}
+	}
+
+	merged := out.Permissions()
+	for _, permission := range permissions {
+		if !containsString(merged, permission) {
+			merged = append(merged, permission)
+		}
+	}
+
+	out.SetRoles(global)
+	out.SetPermissions(merged)
+	out.SetOrgID(orgID)
+	return out, nil
+}
+
+const (
+	removeMemberRolesSQL = "DELETE FROM organization_members WHERE organization_id=:orgID AND user_id=:userID"
+	addMemberRoleSQL     = "INSERT INTO organization_members (organization_id, user_id, role_id, created) VALUES (:orgID, :userID, :roleID, :created)"
+)
+
+// ReplaceOrganizationMemberRoles replaces the roles that the user has in the
+// organization; if no roles are specified then the user is removed from the
+// organization. A user is added to the organization when they are given their first role.
+func (s *Store) ReplaceOrganizationMemberRoles(ctx context.Context, orgID, userID ulid.ULID, roleIDs []int64) (err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, nil); err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = tx.ReplaceOrganizationMemberRoles(orgID, userID, roleIDs); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (tx *Tx) ReplaceOrganizationMemberRoles(orgID, userID ulid.ULID, roleIDs []int64) (err error) {
+	if orgID.IsZero() || userID.IsZero() {
+		return errors.ErrMissingID
+	}
+
+	// Ensure the organization and user exist so that unknown IDs are not silently ignored.
+	if _, err = tx.RetrieveOrganization(orgID); err != nil {
+		return err
+	}
+
+	var exists bool
+	if err = tx.QueryRow(userExistsSQL, sql.Named("id", userID)).Scan(&exists); err != nil {
+		return dbe(err)
+	}
+
+	if !exists {
+		return errors.ErrNotFound
+	}
+
+	if _, err = tx.Exec(removeMemberRolesSQL, sql.Named("orgID", orgID), sql.Named("userID", userID)); err != nil {
+		return dbe(err)
+	}
+
+	now := time.Now()
+	for _, roleID := range roleIDs {
+		if _, err = tx.Exec(addMemberRoleSQL, sql.Named("orgID", orgID), sql.Named("userID", userID), sql.Named("roleID", roleID), sql.Named("created", now)); err != nil {
+			return dbe(err)
+		}
+	}
+
+	return nil
+}
+
+//===========================================================================
+// Helpers
+//===========================================================================
+
+func (tx *Tx) queryOrganizations(query string, args ...any) (out []*models.Organization, err error) {
+	var rows *sql.Rows
+	if rows, err = tx.Query(query, args...); err != nil {
+		return nil, dbe(err)
+	}
+	defer rows.Close()
+
+	out = make([]*models.Organization, 0)
+	for rows.Next() {
+		org := &models.Organization{}
+		if err = org.Scan(rows); err != nil {
+			return nil, err
+		}
+		out = append(out, org)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, dbe(err)
+	}
+	return out, nil
+}
+
+func (tx *Tx) memberRoles(orgID, userID ulid.ULID) (roles []*models.Role, err error) {
+	var rows *sql.Rows
+	if rows, err = tx.Query(memberRolesSQL, sql.Named("orgID", orgID), sql.Named("userID", userID)); err != nil {
+		return nil, dbe(err)
+	}
+	defer rows.Close()
+
+	roles = make([]*models.Role, 0)
+	for rows.Next() {
+		role := &models.Role{}
+		if err = role.Scan(rows); err != nil {
+			return nil, dbe(err)
+		}
+		roles = append(roles, role)
+	}
+
+	return roles, rows.Err()
+}
+
+func (tx *Tx) memberPermissions(orgID, userID ulid.ULID) (permissions []string, err error) {
+	var rows *sql.Rows
+	if rows, err = tx.Query(memberPermissionsSQL, sql.Named("orgID", orgID), sql.Named("userID", userID)); err != nil {
+		return nil, dbe(err)
+	}
+	defer rows.Close()
+
+	permissions = make([]string, 0)
+	for rows.Next() {
+		var permission string
+		if err = rows.Scan(&permission); err != nil {
+			return nil, dbe(err)
+		}
+		permissions = append(permissions, permission)
+	}
+
+	return permissions, rows.Err()
+}
+
+func containsRole(roles []*models.Role, roleID int64) bool {
+	for _, role := range roles {
+		if role.ID == roleID {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,283 @@
+package sqlite_test
+
+import (
+	"database/sql"
+	"time"
+
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v1/models"
+	"go.rtnl.ai/ulid"
+)
+
+var (
+	acmeOrgID    = ulid.MustParse("01KC0020000000000000000001") // Acme from testdata, complete details
+	globexOrgID  = ulid.MustParse("01KC0040000000000000000002") // Globex from testdata, name only
+	viewerUserID = ulid.MustParse("01JVWFBDXBNG6JNQZ36K2A8RT3") // keyholder in Acme, editor in Globex
+	editorUserID = ulid.MustParse("01JQNPQ1CHG36SV7NRQKTZB20R") // viewer in Acme, not a member of Globex
+)
+
+func (s *storeTestSuite) TestListOrganizations() {
+	require := s.Require()
+	out, err := s.db.ListOrganizations(s.Context(), nil)
+	require.NoError(err, "should be able to list organizations")
+	require.NotNil(out.Page, "should return a page object")
+	require.Len(out.Organizations, 2, "expected 2 fixture organizations")
+	require.Equal(acmeOrgID, out.Organizations[0].ID, "organizations should be ordered by created")
+	require.Equal(globexOrgID, out.Organizations[1].ID, "organizations should be ordered by created")
+}
+
+func (s *storeTestSuite) TestCreateOrganization() {
+	s.Run("NoIDOnCreate", func() {
+		org := &models.Organization{Model: models.Model{ID: ulid.Make()}, Name: "Initech"}
+		err := s.db.CreateOrganization(s.Context(), org)
+		s.Require().ErrorIs(err, errors.ErrNoIDOnCreate)
+	})
+
+	s.Run("RequiresName", func() {
+		err := s.db.CreateOrganization(s.Context(), &models.Organization{})
+		s.Require().ErrorIs(err, errors.ErrZeroValuedNotNull)
+	})
+
+	s.Run("ReadOnly", func() {
+		if !s.ReadOnly() {
+			s.T().Skip("skipping create read-only error test in read-write mode")
+		}
+
+		err := s.db.CreateOrganization(s.Context(), &models.Organization{Name: "Initech"})
+		s.Require().ErrorIs(err, errors.ErrReadOnly)
+	})
+
+	s.Run("Success", func() {
+		if s.ReadOnly() {
+			s.T().Skip("skipping create test in read-only mode")
+		}
+
+		require := s.Require()
+		org := &models.Organization{
+			Name:         "Initech",
+			SupportEmail: sql.NullString{Valid: true, String: "help@initech.example.com"},
+		}
+
+		err := s.db.CreateOrganization(s.Context(), org)
+		require.NoError(err)
+		require.False(org.ID.IsZero())
+		require.WithinDuration(time.Now(), org.Created, 3*time.Second)
+
+		got, err := s.db.RetrieveOrganization(s.Context(), org.ID)
+		require.NoError(err)
+		require.Equal(org.Name, got.Name)
+		require.Equal(org.SupportEmail, got.SupportEmail)
+		require.False(got.StreetAddress.Valid)
+		require.False(got.HomepageURI.Valid)
+	})
+}
+
+func (s *storeTestSuite) TestRetrieveOrganization() {
+	s.Run("Complete", func() {
+		require := s.Require()
+		org, err := s.db.RetrieveOrganization(s.Context(), acmeOrgID)
+		require.NoError(err)
+		require.Equal("Acme Corporation", org.Name)
+		require.Equal("1 Acme Way, Springfield, USA", org.StreetAddress.String)
+		require.Equal("https://acme.example.com", org.HomepageURI.String)
+		require.Equal("support@acme.example.com", org.SupportEmail.String)
+		require.False(org.Created.IsZero())
+		require.False(org.Modified.IsZero())
+	})
+
+	s.Run("NameOnly", func() {
+		require := s.Require()
+		org, err := s.db.RetrieveOrganization(s.Context(), globexOrgID)
+		require.NoError(err)
+		require.Equal("Globex", org.Name)
+		require.False(org.StreetAddress.Valid)
+		require.False(org.HomepageURI.Valid)
+		require.False(org.SupportEmail.Valid)
+	})
+
+	s.Run("NotFound", func() {
+		_, err := s.db.RetrieveOrganization(s.Context(), ulid.Make())
+		s.Require().ErrorIs(err, errors.ErrNotFound)
+	})
+}
+
+func (s *storeTestSuite) TestUpdateOrganization() {
+	s.Run("MissingID", func() {
+		err := s.db.UpdateOrganization(s.Context(), &models.Organization{Name: "Globex"})
+		s.Require().ErrorIs(err, errors.ErrMissingID)
+	})
+
+	s.Run("NotFound", func() {
+		if s.ReadOnly() {
+			s.T().Skip("skipping update test in read-only mode")
+		}
+
+		org := &models.Organization{Model: models.Model{ID: ulid.Make()}, Name: "Globex"}
+		err := s.db.UpdateOrganization(s.Context(), org)
+		s.Require().ErrorIs(err, errors.ErrNotFound)
+	})
+
+	s.Run("Success", func() {
+		if s.ReadOnly() {
+			s.T().Skip("skipping update test in read-only mode")
+		}
+
+		require := s.Require()
+		org, err := s.db.RetrieveOrganization(s.Context(), globexOrgID)
+		require.NoError(err)
+
+		org.Name = "Globex Corporation"
+		org.HomepageURI = sql.NullString{Valid: true, String: "https://globex.example.com"}
+		require.NoError(s.db.UpdateOrganization(s.Context(), org))
+
+		got, err := s.db.RetrieveOrganization(s.Context(), globexOrgID)
+		require.NoError(err)
+		require.Equal("Globex Corporation", got.Name)
+		require.Equal("https://globex.example.com", got.HomepageURI.String)
+		require.True(got.Modified.After(got.Created))
+	})
+}
+
+func (s *storeTestSuite) TestDeleteOrganization() {
+	s.Run("NotFound", func() {
+		if s.ReadOnly() {
+			s.T().Skip("skipping delete test in read-only mode")
+		}
+
+		err := s.db.DeleteOrganization(s.Context(), ulid.Make())
+		s.Require().ErrorIs(err, errors.ErrNotFound)
+	})
+
+	s.Run("Cascade", func() {
+		if s.ReadOnly() {
+			s.T().Skip("skipping delete test in read-only mode")
+		}
+
+		require := s.Require()
+		nKeys := s.Count("api_keys")
+		nClients := s.Count("oidc_clients")
+		nMembers := s.Count("organization_members")
+
+		require.NoError(s.db.DeleteOrganization(s.Context(), acmeOrgID))
+		require.Equal(nKeys-1, s.Count("api_keys"), "the acme api key should be deleted")
+		require.Equal(nClients-1, s.Count("oidc_clients"), "the acme oidc client should be deleted")
+		require.Equal(nMembers-2, s.Count("organization_members"), "the acme memberships should be deleted")
+	})
+}
+
+func (s *storeTestSuite) TestListUserOrganizations() {
+	require := s.Require()
+	orgs, err := s.db.ListUserOrganizations(s.Context(), viewerUserID)
+	require.NoError(err)
+	require.Len(orgs, 2)
+	require.Equal(acmeOrgID, orgs[0].ID)
+	require.Equal(globexOrgID, orgs[1].ID)
+
+	orgs, err = s.db.ListUserOrganizations(s.Context(), ulid.MustParse("01JN2YQ2VE9GMBRVACD15J1TFX"))
+	require.NoError(err)
+	require.Empty(orgs, "the admin is not a member of any organization")
+}
+
+func (s *storeTestSuite) TestListOrganizationMembers() {
+	s.Run("Members", func() {
+		require := s.Require()
+		members, err := s.db.ListOrganizationMembers(s.Context(), acmeOrgID)
+		require.NoError(err)
+		require.Len(members, 2)
+
+		for _, member := range members {
+			require.Equal(acmeOrgID, member.OrgID())
+			roles, err := member.Roles()
+			require.NoError(err)
+			require.Len(roles, 1, "only the organization roles should be loaded")
+
+			switch member.ID {
+			case viewerUserID:
+				require.Equal("keyholder", roles[0].Title)
+			case editorUserID:
+				require.Equal("viewer", roles[0].Title)
+			default:
+				require.Fail("unexpected organization member", member.ID.String())
+			}
+		}
+	})
+
+	s.Run("NotFound", func() {
+		_, err := s.db.ListOrganizationMembers(s.Context(), ulid.Make())
+		s.Require().ErrorIs(err, errors.ErrNotFound)
+	})
+}
+
+func (s *storeTestSuite) TestRetrieveOrganizationMember() {
+	s.Run("Merged", func() {
+		require := s.Require()
+		user, err := s.db.RetrieveOrganizationMember(s.Context(), acmeOrgID, viewerUserID)
+		require.NoError(err)
+		require.Equal(acmeOrgID, user.OrgID())
+
+		roles, err := user.Roles()
+		require.NoError(err)
+		titles := make([]string, 0, len(roles))
+		for _, role := range roles {
+			titles = append(titles, role.Title)
+		}
+		require.ElementsMatch([]string{"viewer", "keyholder"}, titles)
+		require.ElementsMatch([]string{"content:view", "users:view", "keys:create", "keys:revoke", "keys:view"}, user.Permissions())
+	})
+
+	s.Run("NotMember", func() {
+		_, err := s.db.RetrieveOrganizationMember(s.Context(), globexOrgID, editorUserID)
+		s.Require().ErrorIs(err, errors.ErrNotFound)
+	})
+
+	s.Run("UnknownUser", func() {
+		_, err := s.db.RetrieveOrganizationMember(s.Context(), acmeOrgID, ulid.Make())
+		s.Require().ErrorIs(err, errors.ErrNotFound)
+	})
+}
+
+func (s *storeTestSuite) TestReplaceOrganizationMemberRoles() {
+	s.Run("NotFound", func() {
+		if s.ReadOnly() {
+			s.T().Skip("skipping replace test in read-only mode")
+		}
+
+		require := s.Require()
+		err := s.db.ReplaceOrganizationMemberRoles(s.Context(), ulid.Make(), viewerUserID, []int64{3})
+		require.ErrorIs(err, errors.ErrNotFound, "unknown organization")
+
+		err = s.db.ReplaceOrganizationMemberRoles(s.Context(), acmeOrgID, ulid.Make(), []int64{3})
+		require.ErrorIs(err, errors.ErrNotFound, "unknown user")
+	})
+
+	s.Run("AddMember", func() {
+		if s.ReadOnly() {
+			s.T().Skip("skipping replace test in read-only mode")
+		}
+
+		require := s.Require()
+		require.NoError(s.db.ReplaceOrganizationMemberRoles(s.Context(), globexOrgID, editorUserID, []int64{1, 3}))
+
+		user, err := s.db.RetrieveOrganizationMember(s.Context(), globexOrgID, editorUserID)
+		require.NoError(err)
+		roles, _ := user.Roles()
+		require.Len(roles, 3, "expected the global editor role and the admin and viewer organization roles")
+	})
+
+	s.Run("RemoveMember", func() {
+		if s.ReadOnly() {
+			s.T().Skip("skipping replace test in read-only mode")
+		}
+
+		require := s.Require()
+		require.NoError(s.db.ReplaceOrganizationMemberRoles(s.Context(), acmeOrgID, viewerUserID, nil))
+
+		_, err := s.db.RetrieveOrganizationMember(s.Context(), acmeOrgID, viewerUserID)
+		require.ErrorIs(err, errors.ErrNotFound)
+
+		orgs, err := s.db.ListUserOrganizations(s.Context(), viewerUserID)
+		require.NoError(err)
+		require.Len(orgs, 1)
+		require.Equal(globexOrgID, orgs[0].ID)
+	})
+}
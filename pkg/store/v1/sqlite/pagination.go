@@ -0,0 +1,62 @@
+package sqlite
+
+import (
+	"database/sql"
+	"slices"
+
+	"go.rtnl.ai/quarterdeck/pkg/store/v1/models"
+	"go.rtnl.ai/ulid"
+)
+
+// Returns the condition, order, and parameters that select the page of rows before the
+// NextPageID or after the PrevPageID of the query. Rows are listed by ID (which is
+// ordered by the time the row was created), most recent first. Previous pages are
+// selected in ascending order so the rows closest to the PrevPageID are returned and
+// are reversed by paginate. One more row than the page size is selected to determine
+// if there is another page. The condition is empty if this is the first page.
+func pageQuery(in *models.Page, size uint32, column string) (where, order string, params []any) {
+	order = " ORDER BY " + column + " DESC LIMIT :limit"
+	switch {
+	case in == nil:
+	case !in.NextPageID.IsZero():
+		where = column + " < :nextPageID"
+		params = append(params, sql.Named("nextPageID", in.NextPageID))
+	case !in.PrevPageID.IsZero():
+		where = column + " > :prevPageID"
+		order = " ORDER BY " + column + " ASC LIMIT :limit"
+		params = append(params, sql.Named("prevPageID", in.PrevPageID))
+	}
+
+	params = append(params, sql.Named("limit", size+1))
+	return where, order, params
+}
+
+// Trims the rows selected with pageQuery to the page size, restores the order of a
+// previous page, and sets the IDs of the pages before and after the rows on out.
+func paginate[M any](in, out *models.Page, rows []M, id func(M) ulid.ULID) []M {
+	prev := in != nil && in.NextPageID.IsZero() && !in.PrevPageID.IsZero()
+	first := in == nil || (in.NextPageID.IsZero() && in.PrevPageID.IsZero())
+
+	more := uint32(len(rows)) > out.PageSize
+	if more {
+		rows = rows[:out.PageSize]
+	}
+
+	if prev {
+		slices.Reverse(rows)
+	}
+
+	out.NextPageID, out.PrevPageID = ulid.Zero, ulid.Zero
+	if len(rows) == 0 {
+		return rows
+	}
+
+	if more || prev {
+		out.NextPageID = id(rows[len(rows)-1])
+	}
+
+	if (prev && more) || (!prev && !first) {
+		out.PrevPageID = id(rows[0])
+	}
+	return rows
+}
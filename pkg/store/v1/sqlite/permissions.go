@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"time"
 
+	"go.rtnl.ai/quarterdeck/pkg/auth/permissions"
 	"go.rtnl.ai/quarterdeck/pkg/errors"
 	"go.rtnl.ai/quarterdeck/pkg/store/v1/models"
 )
@@ -430,7 +431,7 @@ func (tx *Tx) ListPermissions(page *models.Page) (out *models.PermissionList, er
 	return out, nil
 }
 
-const createPermissionSQL = `INSERT INTO permissions (title, description, created, modified) VALUES (:title, :description, :created, :modified)`
+const createPermissionSQL = `INSERT INTO permissions (title, description, created, modified, namespace, protected) VALUES (:title, :description, :created, :modified, :namespace, :protected)`
 
 func (s *Store) CreatePermission(ctx context.Context, permission *models.Permission) (err error) {
 	var tx *Tx
@@ -455,6 +456,10 @@ func (tx *Tx) CreatePermission(permission *models.Permission) (err error) {
 		return errors.ErrZeroValuedNotNull
 	}
 
+	if permission.Namespace == "" {
+		permission.Namespace = permissions.Quarterdeck
+	}
+
 	permission.Created = time.Now()
 	permission.Modified = permission.Created
 
@@ -544,7 +549,7 @@ func (tx *Tx) RetrievePermission(titleOrID any) (permission *models.Permission,
 	return permission, nil
 }
 
-const updatePermissionSQL = `UPDATE permissions SET title=:title, description=:description, modified=:modified WHERE id=:id`
+const updatePermissionSQL = `UPDATE permissions SET title=:title, description=:description, namespace=:namespace, modified=:modified WHERE id=:id`
 
 func (s *Store) UpdatePermission(ctx context.Context, permission *models.Permission) (err error) {
 	var tx *Tx
@@ -560,11 +565,29 @@ func (s *Store) UpdatePermission(ctx context.Context, permission *models.Permiss
 	return tx.Commit()
 }
 
+// UpdatePermission updates the title, description, and namespace of the permission;
+// protected permissions cannot be renamed or moved to another namespace but their
+// description can be updated. Whether or not a permission is protected cannot be
+// changed once it has been created.
 func (tx *Tx) UpdatePermission(permission *models.Permission) (err error) {
 	if permission.ID == 0 {
 		return errors.ErrMissingID
 	}
 
+	var current *models.Permission
+	if current, err = tx.RetrievePermission(permission.ID); err != nil {
+		return err
+	}
+
+	if permission.Namespace == "" {
+		permission.Namespace = current.Namespace
+	}
+
+	if current.Protected && (permission.Title != current.Title || permission.Namespace != current.Namespace) {
+		return errors.ErrProtected
+	}
+
+	permission.Protected = current.Protected
 	permission.Modified = time.Now()
 
 	var result sql.Result
@@ -594,11 +617,22 @@ func (s *Store) DeletePermission(ctx context.Context, permissionID int64) (err e
 	return tx.Commit()
 }
 
+// DeletePermission deletes the permission and removes it from all roles and api keys;
+// protected permissions cannot be deleted.
 func (tx *Tx) DeletePermission(permissionID int64) (err error) {
 	if permissionID == 0 {
 		return errors.ErrMissingID
 	}
 
+	var current *models.Permission
+	if current, err = tx.RetrievePermission(permissionID); err != nil {
+		return err
+	}
+
+	if current.Protected {
+		return errors.ErrProtected
+	}
+
 	var result sql.Result
 	if result, err = tx.Exec(deletePermissionSQL, sql.Named("id", permissionID)); err != nil {
 		return dbe(err)
@@ -340,6 +340,26 @@ func (s *storeTestSuite) TestCreatePermission() {
 		require.WithinDuration(time.Now(), perm.Created, 1*time.Second)
 		require.WithinDuration(time.Now(), perm.Modified, 1*time.Second)
 		require.Equal(count+1, s.Count("permissions"), "should have one more permission after creation")
+		require.Equal("quarterdeck", perm.Namespace, "namespace should default to quarterdeck")
+	})
+
+	s.Run("Namespaced", func() {
+		if s.ReadOnly() {
+			s.T().Skip("skipping create test in read-only mode")
+		}
+
+		perm := &models.Permission{
+			Title:     "reports:export",
+			Namespace: "reporting",
+		}
+
+		require := s.Require()
+		require.NoError(s.db.CreatePermission(s.Context(), perm), "creating a namespaced permission should not error")
+
+		cmpt, err := s.db.RetrievePermission(s.Context(), "reports:export")
+		require.NoError(err, "retrieving created permission should not error")
+		require.Equal("reporting", cmpt.Namespace)
+		require.False(cmpt.Protected)
 	})
 
 	s.Run("UniqueTitle", func() {
@@ -365,6 +385,7 @@ func (s *storeTestSuite) TestRetrievePermission() {
 		Description: "Permission to view content",
 		Created:     time.Date(2025, 2, 14, 11, 21, 42, 0, time.UTC),
 		Modified:    time.Date(2025, 2, 14, 11, 21, 42, 0, time.UTC),
+		Namespace:   "quarterdeck",
 	}
 
 	s.Run("RetrieveByID", func() {
@@ -458,6 +479,27 @@ func (s *storeTestSuite) TestUpdatePermission() {
 		err = s.db.UpdatePermission(s.Context(), perm)
 		require.ErrorIs(err, errors.ErrNotFound, "updating non-existent permission should return not found error")
 	})
+
+	s.Run("Protected", func() {
+		protected := &models.Permission{Title: "widgets:view", Protected: true}
+		require.NoError(s.db.CreatePermission(s.Context(), protected), "could not create protected permission")
+
+		// The description of a protected permission can be updated
+		update := &models.Permission{ID: protected.ID, Title: "widgets:view", Description: "View widgets"}
+		require.NoError(s.db.UpdatePermission(s.Context(), update), "should be able to update the description")
+
+		cmpt, err := s.db.RetrievePermission(s.Context(), protected.ID)
+		require.NoError(err, "retrieving updated permission should not error")
+		require.Equal("View widgets", cmpt.Description)
+		require.True(cmpt.Protected, "permission should still be protected")
+
+		// But it cannot be renamed or moved to another namespace
+		update = &models.Permission{ID: protected.ID, Title: "widgets:read"}
+		require.ErrorIs(s.db.UpdatePermission(s.Context(), update), errors.ErrProtected)
+
+		update = &models.Permission{ID: protected.ID, Title: "widgets:view", Namespace: "widgets"}
+		require.ErrorIs(s.db.UpdatePermission(s.Context(), update), errors.ErrProtected)
+	})
 }
 
 func (s *storeTestSuite) TestDeletePermission() {
@@ -479,4 +521,11 @@ func (s *storeTestSuite) TestDeletePermission() {
 
 	err = s.db.DeletePermission(s.Context(), permID)
 	require.ErrorIs(err, errors.ErrNotFound, "deleting non-existent permission should return not found error")
+
+	protected := &models.Permission{Title: "widgets:manage", Protected: true}
+	require.NoError(s.db.CreatePermission(s.Context(), protected), "could not create protected permission")
+
+	err = s.db.DeletePermission(s.Context(), protected.ID)
+	require.ErrorIs(err, errors.ErrProtected, "deleting a protected permission should return protected error")
+	require.Equal(permCount, s.Count("permissions"), "protected permission should not be deleted")
 }
@@ -60,6 +60,25 @@ func (s *Store) InitializeSchema(empty bool) (err error) {
 	return nil
 }
 
+// CheckSchema returns an error if migrations have not been applied to the database,
+// which is used instead of InitializeSchema when the database cannot be written to.
+func (s *Store) CheckSchema() (err error) {
+	lastApplied := -1
+	if err = s.conn.QueryRow(lastAppliedSQL).Scan(&lastApplied); err != nil {
+		return errors.Join(errors.ErrSchemaOutdated, errors.Fmt("could not fetch last applied migration: %s", err))
+	}
+
+	var migrations []*Migration
+	if migrations, err = Migrations(); err != nil {
+		return err
+	}
+
+	if latest := migrations[len(migrations)-1].ID; lastApplied < latest {
+		return errors.Fmt("%w: migration %d applied but %d is required", errors.ErrSchemaOutdated, lastApplied, latest)
+	}
+	return nil
+}
+
 // Migrations contains the SQL commands from the migrations directory and is used to
 // ensure that the database has the most current and up to date schema.
 //
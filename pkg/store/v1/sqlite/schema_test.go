@@ -25,6 +25,66 @@ func TestMigrations(t *testing.T) {
 			Name: "Oidc Clients",
 			Path: "0002_oidc_clients.sql",
 		},
+		{
+			ID:   3,
+			Name: "Oauth Authorization Codes",
+			Path: "0003_oauth_authorization_codes.sql",
+		},
+		{
+			ID:   4,
+			Name: "Revoked Tokens",
+			Path: "0004_revoked_tokens.sql",
+		},
+		{
+			ID:   5,
+			Name: "Refresh Tokens",
+			Path: "0005_refresh_tokens.sql",
+		},
+		{
+			ID:   6,
+			Name: "Sessions",
+			Path: "0006_sessions.sql",
+		},
+		{
+			ID:   7,
+			Name: "Multi Factor Auth",
+			Path: "0007_multi_factor_auth.sql",
+		},
+		{
+			ID:   8,
+			Name: "Webauthn Credentials",
+			Path: "0008_webauthn_credentials.sql",
+		},
+		{
+			ID:   9,
+			Name: "Lockouts",
+			Path: "0009_lockouts.sql",
+		},
+		{
+			ID:   10,
+			Name: "Audit Events",
+			Path: "0010_audit_events.sql",
+		},
+		{
+			ID:   11,
+			Name: "Custom Permissions",
+			Path: "0011_custom_permissions.sql",
+		},
+		{
+			ID:   12,
+			Name: "Organizations",
+			Path: "0012_organizations.sql",
+		},
+		{
+			ID:   13,
+			Name: "User Status",
+			Path: "0013_user_status.sql",
+		},
+		{
+			ID:   14,
+			Name: "Webhooks",
+			Path: "0014_webhooks.sql",
+		},
 	}
 
 	migrations, err := sqlite.Migrations()
@@ -0,0 +1,271 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v1/models"
+	"go.rtnl.ai/ulid"
+)
+
+const (
+	listSessionsSQL = "SELECT id, user_id, jti, user_agent, client_ip, last_refresh, revoked, expiration, created, modified FROM sessions WHERE user_id=:userID AND revoked IS NULL AND expiration > :now ORDER BY COALESCE(last_refresh, created) DESC"
+)
+
+// ListSessions returns the active (unrevoked and unexpired) sessions of the user, most
+// recently active first.
+func (s *Store) ListSessions(ctx context.Context, userID ulid.ULID) (out *models.SessionList, err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, &sql.TxOptions{ReadOnly: true}); err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if out, err = tx.ListSessions(userID); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (tx *Tx) ListSessions(userID ulid.ULID) (out *models.SessionList, err error) {
+	if userID.IsZero() {
+		return nil, errors.ErrMissingID
+	}
+
+	out = &models.SessionList{
+		Sessions: make([]*models.Session, 0),
+	}
+
+	var rows *sql.Rows
+	if rows, err = tx.Query(listSessionsSQL, sql.Named("userID", userID), sql.Named("now", time.Now())); err != nil {
+		return nil, dbe(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		session := &models.Session{}
+		if err = session.Scan(rows); err != nil {
+			return nil, err
+		}
+		out.Sessions = append(out.Sessions, session)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, dbe(err)
+	}
+
+	return out, nil
+}
+
+const (
+	createSessionSQL = "INSERT INTO sessions (id, user_id, jti, user_agent, client_ip, last_refresh, revoked, expiration, created, modified) VALUES (:id, :userID, :jti, :userAgent, :clientIP, :lastRefresh, :revoked, :expiration, :created, :modified)"
+)
+
+func (s *Store) CreateSession(ctx context.Context, in *models.Session) (err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, nil); err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = tx.CreateSession(in); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CreateSession records a new login; the ID of the session must be the family ID of
+// the refresh token that was issued on login.
+func (tx *Tx) CreateSession(in *models.Session) (err error) {
+	if in.ID.IsZero() {
+		return errors.ErrMissingID
+	}
+
+	if in.UserID.IsZero() || in.JTI.IsZero() || in.Expiration.IsZero() {
+		return errors.ErrZeroValuedNotNull
+	}
+
+	in.Created = time.Now()
+	in.Modified = in.Created
+
+	if _, err = tx.Exec(createSessionSQL, in.Params()...); err != nil {
+		return dbe(err)
+	}
+
+	return nil
+}
+
+const (
+	retrieveSessionSQL = "SELECT id, user_id, jti, user_agent, client_ip, last_refresh, revoked, expiration, created, modified FROM sessions WHERE id=:id"
+)
+
+func (s *Store) RetrieveSession(ctx context.Context, sessionID ulid.ULID) (out *models.Session, err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, &sql.TxOptions{ReadOnly: true}); err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if out, err = tx.RetrieveSession(sessionID); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (tx *Tx) RetrieveSession(sessionID ulid.ULID) (out *models.Session, err error) {
+	if sessionID.IsZero() {
+		return nil, errors.ErrMissingID
+	}
+
+	out = &models.Session{}
+	if err = out.Scan(tx.QueryRow(retrieveSessionSQL, sql.Named("id", sessionID))); err != nil {
+		return nil, dbe(err)
+	}
+
+	return out, nil
+}
+
+const (
+	refreshSessionSQL = "UPDATE sessions SET jti=:jti, last_refresh=:now, expiration=:expiration, modified=:now WHERE id=:id"
+)
+
+func (s *Store) RefreshSession(ctx context.Context, sessionID, jti ulid.ULID, expiration time.Time) (err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, nil); err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = tx.RefreshSession(sessionID, jti, expiration); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RefreshSession records the jti and expiration of the tokens issued when the session
+// was reauthenticated.
+func (tx *Tx) RefreshSession(sessionID, jti ulid.ULID, expiration time.Time) (err error) {
+	if sessionID.IsZero() || jti.IsZero() {
+		return errors.ErrMissingID
+	}
+
+	params := []any{
+		sql.Named("id", sessionID),
+		sql.Named("jti", jti),
+		sql.Named("expiration", expiration),
+		sql.Named("now", time.Now()),
+	}
+
+	var result sql.Result
+	if result, err = tx.Exec(refreshSessionSQL, params...); err != nil {
+		return dbe(err)
+	}
+
+	if nRows, _ := result.RowsAffected(); nRows == 0 {
+		return errors.ErrNotFound
+	}
+
+	return nil
+}
+
+const (
+	revokeSessionSQL      = "UPDATE sessions SET revoked=:now, modified=:now WHERE id=:id AND revoked IS NULL"
+	listUserSessionIDsSQL = "SELECT id FROM sessions WHERE user_id=:userID AND revoked IS NULL"
+)
+
+func (s *Store) RevokeSession(ctx context.Context, sessionID ulid.ULID) (err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, nil); err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = tx.RevokeSession(sessionID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RevokeSession marks the session as revoked and revokes its refresh token family so
+// that the session can no longer be reauthenticated and the tokens that were issued to
+// it are added to the denylist. Revoking a revoked session is not an error.
+func (tx *Tx) RevokeSession(sessionID ulid.ULID) (err error) {
+	if _, err = tx.RetrieveSession(sessionID); err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec(revokeSessionSQL, sql.Named("id", sessionID), sql.Named("now", time.Now())); err != nil {
+		return dbe(err)
+	}
+
+	if err = tx.RevokeRefreshTokenFamily(sessionID); err != nil && !errors.Is(err, errors.ErrNotFound) {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) RevokeUserSessions(ctx context.Context, userID ulid.ULID) (err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, nil); err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = tx.RevokeUserSessions(userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RevokeUserSessions revokes every session of the user ("sign out everywhere").
+func (tx *Tx) RevokeUserSessions(userID ulid.ULID) (err error) {
+	if userID.IsZero() {
+		return errors.ErrMissingID
+	}
+
+	var rows *sql.Rows
+	if rows, err = tx.Query(listUserSessionIDsSQL, sql.Named("userID", userID)); err != nil {
+		return dbe(err)
+	}
+
+	sessionIDs := make([]ulid.ULID, 0)
+	for rows.Next() {
+		var sessionID ulid.ULID
+		if err = rows.Scan(&sessionID); err != nil {
+			rows.Close()
+			return dbe(err)
+		}
+		sessionIDs = append(sessionIDs, sessionID)
+	}
+
+	if err = rows.Err(); err != nil {
+		rows.Close()
+		return dbe(err)
+	}
+	rows.Close()
+
+	for _, sessionID := range sessionIDs {
+		if err = tx.RevokeSession(sessionID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package sqlite_test
+
+import (
+	"database/sql"
+	"time"
+
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v1/models"
+	"go.rtnl.ai/ulid"
+)
+
+func (s *storeTestSuite) TestSessions() {
+	userID := ulid.MustParse("01JPYRNYMEHNEZCS0JYX1CP57A")
+
+	s.Run("MissingID", func() {
+		require := s.Require()
+
+		_, err := s.db.ListSessions(s.Context(), ulid.Zero)
+		require.ErrorIs(err, errors.ErrMissingID)
+
+		_, err = s.db.RetrieveSession(s.Context(), ulid.Zero)
+		require.ErrorIs(err, errors.ErrMissingID)
+
+		if s.ReadOnly() {
+			return
+		}
+
+		err = s.db.CreateSession(s.Context(), &models.Session{UserID: userID, JTI: ulid.Make(), Expiration: time.Now().Add(time.Hour)})
+		require.ErrorIs(err, errors.ErrMissingID)
+
+		err = s.db.RefreshSession(s.Context(), ulid.Zero, ulid.Make(), time.Now().Add(time.Hour))
+		require.ErrorIs(err, errors.ErrMissingID)
+
+		err = s.db.RevokeSession(s.Context(), ulid.Zero)
+		require.ErrorIs(err, errors.ErrMissingID)
+
+		err = s.db.RevokeUserSessions(s.Context(), ulid.Zero)
+		require.ErrorIs(err, errors.ErrMissingID)
+	})
+
+	s.Run("RequiredFields", func() {
+		if s.ReadOnly() {
+			s.T().Skip("skipping create test in read-only mode")
+		}
+
+		err := s.db.CreateSession(s.Context(), &models.Session{Model: models.Model{ID: ulid.Make()}, UserID: userID, Expiration: time.Now().Add(time.Hour)})
+		s.Require().ErrorIs(err, errors.ErrZeroValuedNotNull)
+	})
+
+	s.Run("NotFound", func() {
+		_, err := s.db.RetrieveSession(s.Context(), ulid.Make())
+		s.Require().ErrorIs(err, errors.ErrNotFound)
+
+		if s.ReadOnly() {
+			return
+		}
+
+		err = s.db.RefreshSession(s.Context(), ulid.Make(), ulid.Make(), time.Now().Add(time.Hour))
+		s.Require().ErrorIs(err, errors.ErrNotFound)
+
+		err = s.db.RevokeSession(s.Context(), ulid.Make())
+		s.Require().ErrorIs(err, errors.ErrNotFound)
+	})
+
+	s.Run("Lifecycle", func() {
+		if s.ReadOnly() {
+			s.T().Skip("skipping create test in read-only mode")
+		}
+		require := s.Require()
+
+		// Create two sessions for the user, each with its own refresh token family.
+		sessions := make([]*models.Session, 0, 2)
+		for i := 0; i < 2; i++ {
+			jti := ulid.Make()
+			token := &models.RefreshToken{Model: models.Model{ID: jti}, Subject: "u" + userID.String(), Expiration: time.Now().Add(time.Hour)}
+			require.NoError(s.db.CreateRefreshToken(s.Context(), token))
+
+			session := &models.Session{
+				Model:      models.Model{ID: token.FamilyID},
+				UserID:     userID,
+				JTI:        jti,
+				UserAgent:  sql.NullString{String: "Mozilla/5.0", Valid: true},
+				ClientIP:   sql.NullString{String: "192.0.2.1", Valid: true},
+				Expiration: token.Expiration,
+			}
+			require.NoError(s.db.CreateSession(s.Context(), session))
+			sessions = append(sessions, session)
+		}
+
+		out, err := s.db.ListSessions(s.Context(), userID)
+		require.NoError(err)
+		require.Len(out.Sessions, 2)
+
+		// Refreshing a session updates the jti and last refresh timestamp.
+		jti := ulid.Make()
+		require.NoError(s.db.RefreshSession(s.Context(), sessions[0].ID, jti, time.Now().Add(2*time.Hour)))
+
+		session, err := s.db.RetrieveSession(s.Context(), sessions[0].ID)
+		require.NoError(err)
+		require.Equal(jti, session.JTI)
+		require.True(session.LastRefresh.Valid)
+		require.True(session.IsActive())
+
+		// Revoking a session revokes its refresh token family.
+		require.NoError(s.db.RevokeSession(s.Context(), sessions[0].ID))
+
+		session, err = s.db.RetrieveSession(s.Context(), sessions[0].ID)
+		require.NoError(err)
+		require.True(session.Revoked.Valid)
+		require.False(session.IsActive())
+
+		revoked, err := s.db.IsTokenRevoked(s.Context(), sessions[0].JTI)
+		require.NoError(err)
+		require.True(revoked)
+
+		out, err = s.db.ListSessions(s.Context(), userID)
+		require.NoError(err)
+		require.Len(out.Sessions, 1)
+		require.Equal(sessions[1].ID, out.Sessions[0].ID)
+
+		// Revoking a session again is not an error
+		require.NoError(s.db.RevokeSession(s.Context(), sessions[0].ID))
+
+		// Sign out everywhere
+		require.NoError(s.db.RevokeUserSessions(s.Context(), userID))
+
+		out, err = s.db.ListSessions(s.Context(), userID)
+		require.NoError(err)
+		require.Len(out.Sessions, 0)
+
+		revoked, err = s.db.IsTokenRevoked(s.Context(), sessions[1].JTI)
+		require.NoError(err)
+		require.True(revoked)
+	})
+}
@@ -74,6 +74,46 @@ func Open(uri *dsn.DSN) (_ *Store, err error) {
 	return s, nil
 }
 
+// OpenReadOnly connects to an existing database without ever writing to it; the file is
+// opened in read-only mode and the migrations are not applied, so an error is returned
+// if the schema of the database is not up to date rather than migrating it.
+func OpenReadOnly(uri *dsn.DSN) (_ *Store, err error) {
+	if uri.Scheme != dsn.SQLite && uri.Scheme != dsn.SQLite3 {
+		return nil, errors.ErrUnknownScheme
+	}
+
+	if uri.Path == "" {
+		return nil, errors.ErrPathRequired
+	}
+
+	// The file must exist since a read-only connection cannot create the database.
+	if _, err = os.Stat(uri.Path); err != nil {
+		return nil, err
+	}
+
+	s := &Store{readonly: true}
+	if s.conn, err = sql.Open("sqlite3", "file:"+uri.Path+"?mode=ro"); err != nil {
+		return nil, err
+	}
+
+	if err = s.conn.Ping(); err != nil {
+		s.conn.Close()
+		return nil, err
+	}
+
+	if _, err = s.conn.Exec("PRAGMA query_only = on;"); err != nil {
+		s.conn.Close()
+		return nil, errors.Fmt("could not set database to readonly mode: %w", err)
+	}
+
+	if err = s.CheckSchema(); err != nil {
+		s.conn.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
 //===========================================================================
 // Store methods
 //===========================================================================
@@ -106,6 +146,15 @@ func (s *Store) Stats() sql.DBStats {
 	return s.conn.Stats()
 }
 
+// Backup writes a consistent snapshot of the database to path with VACUUM INTO. The
+// database remains online and can be written to while the snapshot is taken.
+func (s *Store) Backup(ctx context.Context, path string) error {
+	if _, err := s.conn.ExecContext(ctx, "VACUUM INTO ?", path); err != nil {
+		return dbe(err)
+	}
+	return nil
+}
+
 //===========================================================================
 // Tx methods
 //===========================================================================
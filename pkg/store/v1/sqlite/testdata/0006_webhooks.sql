@@ -0,0 +1,17 @@
+-- Webhooks: an active webhook subscribed to user events and an inactive webhook.
+-- User Sync: x'019c0001000000000000000000000001' (01KG0020000000000000000001)
+-- Logins: x'019c0002000000000000000000000002' (01KG0040000000000000000002)
+INSERT INTO webhooks (id, url, description, events, secret, active, created, modified) VALUES
+    (x'019c0001000000000000000000000001', 'https://hooks.example.com/quarterdeck', 'User sync', '["user.created","user.updated","user.deleted"]', 'q5gHn2Wsk7tYbQ8xLz3Rv9MdPc4Ja6Ue', true, '2025-07-01T12:00:00Z', '2025-07-01T12:00:00Z'),
+    (x'019c0002000000000000000000000002', 'https://audit.example.com/logins', NULL, '["login"]', 'Tn8Vb3Xq6Lk2Mz9Hd5Rc7Wp4Fy1Gs0Ja', false, '2025-07-02T12:00:00Z', '2025-07-02T12:00:00Z')
+;
+
+-- The user sync webhook has a delivered, a pending (due), and a failed delivery.
+-- Delivered: x'019c0101000000000000000000000001' (01KG0G20000000000000000001)
+-- Pending: x'019c0102000000000000000000000002' (01KG0G40000000000000000002)
+-- Failed: x'019c0103000000000000000000000003' (01KG0G60000000000000000003)
+INSERT INTO webhook_deliveries (id, webhook_id, event_id, event, payload, status, attempts, next_attempt, last_attempt, response_code, error, delivered, created, modified) VALUES
+    (x'019c0101000000000000000000000001', x'019c0001000000000000000000000001', x'019c01f0000000000000000000000001', 'user.created', '{"event":"user.created"}', 'delivered', 1, NULL, '2025-07-03T12:00:01Z', 200, NULL, '2025-07-03T12:00:01Z', '2025-07-03T12:00:00Z', '2025-07-03T12:00:01Z'),
+    (x'019c0102000000000000000000000002', x'019c0001000000000000000000000001', x'019c01f0000000000000000000000002', 'user.updated', '{"event":"user.updated"}', 'pending', 2, '2025-07-04T12:01:30Z', '2025-07-04T12:00:30Z', 503, 'webhook endpoint did not accept the delivery: 503 Service Unavailable', NULL, '2025-07-04T12:00:00Z', '2025-07-04T12:00:30Z'),
+    (x'019c0103000000000000000000000003', x'019c0001000000000000000000000001', x'019c01f0000000000000000000000003', 'user.deleted', '{"event":"user.deleted"}', 'failed', 8, NULL, '2025-07-05T18:00:00Z', NULL, 'connection refused', NULL, '2025-07-05T12:00:00Z', '2025-07-05T18:00:00Z')
+;
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"time"
 
+	"go.rtnl.ai/quarterdeck/pkg/enum"
 	"go.rtnl.ai/quarterdeck/pkg/errors"
 	"go.rtnl.ai/quarterdeck/pkg/store/v1/models"
 	"go.rtnl.ai/ulid"
@@ -14,9 +15,10 @@ import (
 // Users Store
 //===========================================================================
 
+// Deleted users are excluded from the list unless the users are filtered by status.
 const (
-	listUsersSQL   = "SELECT id, name, email, last_login, email_verified, created, modified FROM users ORDER BY created DESC"
-	filterUsersSQL = "SELECT u.id, u.name, u.email, u.last_login, u.email_verified, u.created, u.modified FROM users u JOIN user_roles ur ON u.id=ur.user_id JOIN roles r ON ur.role_id=r.id WHERE r.title=:role COLLATE NOCASE ORDER BY u.created DESC"
+	listUsersSQL   = "SELECT id, name, email, last_login, email_verified, created, modified, status, deleted FROM users WHERE ((:status='' AND status<>'deleted') OR status=:status)"
+	filterUsersSQL = "SELECT u.id, u.name, u.email, u.last_login, u.email_verified, u.created, u.modified, u.status, u.deleted FROM users u JOIN user_roles ur ON u.id=ur.user_id JOIN roles r ON ur.role_id=r.id WHERE r.title=:role COLLATE NOCASE AND ((:status='' AND u.status<>'deleted') OR u.status=:status)"
 )
 
 func (s *Store) ListUsers(ctx context.Context, page *models.UserPage) (out *models.UserList, err error) {
@@ -37,21 +39,39 @@ func (s *Store) ListUsers(ctx context.Context, page *models.UserPage) (out *mode
 }
 
 func (tx *Tx) ListUsers(page *models.UserPage) (out *models.UserList, err error) {
-	// TODO: handle pagination
 	out = &models.UserList{
 		Users: make([]*models.User, 0),
 		Page:  models.UserPageFrom(page),
 	}
 
+	// An unknown status is not used as a filter.
+	status := sql.Named("status", "")
+	if out.Page.Status != enum.UserStatusUnknown {
+		status = sql.Named("status", out.Page.Status.String())
+	}
+
+	var in *models.Page
+	if page != nil {
+		in = &page.Page
+	}
+
+	query, params := listUsersSQL, []any{status}
+	column := "id"
+	if out.Page.Role != "" {
+		query, column = filterUsersSQL, "u.id"
+		params = append(params, sql.Named("role", out.Page.Role))
+	}
+
+	where, order, pageParams := pageQuery(in, out.Page.PageSize, column)
+	if where != "" {
+		query += " AND " + where
+	}
+	query += order
+	params = append(params, pageParams...)
+
 	var rows *sql.Rows
-	if page != nil && page.Role != "" {
-		if rows, err = tx.Query(filterUsersSQL, sql.Named("role", page.Role)); err != nil {
-			return nil, dbe(err)
-		}
-	} else {
-		if rows, err = tx.Query(listUsersSQL); err != nil {
-			return nil, dbe(err)
-		}
+	if rows, err = tx.Query(query, params...); err != nil {
+		return nil, dbe(err)
 	}
 	defer rows.Close()
 
@@ -68,12 +88,13 @@ func (tx *Tx) ListUsers(page *models.UserPage) (out *models.UserList, err error)
 		return nil, dbe(err)
 	}
 
+	out.Users = paginate(in, &out.Page.Page, out.Users, func(u *models.User) ulid.ULID { return u.ID })
 	return out, nil
 }
 
 const (
 	defaultRolesSQL = "SELECT id FROM roles WHERE is_default='t'"
-	createUserSQL   = "INSERT INTO users (id, name, email, password, last_login, email_verified, created, modified) VALUES (:id, :name, :email, :password, :lastLogin, :emailVerified, :created, :modified)"
+	createUserSQL   = "INSERT INTO users (id, name, email, password, last_login, email_verified, created, modified, status) VALUES (:id, :name, :email, :password, :lastLogin, :emailVerified, :created, :modified, :status)"
 )
 
 func (s *Store) CreateUser(ctx context.Context, user *models.User) (err error) {
@@ -99,6 +120,10 @@ func (tx *Tx) CreateUser(user *models.User) (err error) {
 	user.Created = time.Now()
 	user.Modified = user.Created
 
+	if user.Status == enum.UserStatusUnknown {
+		user.Status = enum.UserStatusActive
+	}
+
 	if _, err = tx.Exec(createUserSQL, user.Params()...); err != nil {
 		return dbe(err)
 	}
@@ -396,6 +421,95 @@ func (tx *Tx) VerifyEmail(userID ulid.ULID) (err error) {
 	return nil
 }
 
+const (
+	updateMFASQL = "UPDATE users SET totp_secret=:totpSecret, totp_enabled=:totpEnabled, recovery_codes=:recoveryCodes, modified=:modified WHERE id=:id"
+)
+
+func (s *Store) UpdateMFA(ctx context.Context, user *models.User) (err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, nil); err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = tx.UpdateMFA(user); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UpdateMFA stores the TOTP secret, enrollment confirmation, and recovery code hashes
+// of the user; all other user fields are ignored.
+func (tx *Tx) UpdateMFA(user *models.User) (err error) {
+	if user.ID.IsZero() {
+		return errors.ErrMissingID
+	}
+
+	user.Modified = time.Now()
+
+	var result sql.Result
+	if result, err = tx.Exec(updateMFASQL, user.Params()...); err != nil {
+		return dbe(err)
+	}
+
+	if nRows, _ := result.RowsAffected(); nRows == 0 {
+		return errors.ErrNotFound
+	}
+
+	return nil
+}
+
+const (
+	updateUserStatusSQL = "UPDATE users SET status=:status, deleted=:deleted, modified=:modified WHERE id=:id"
+)
+
+func (s *Store) UpdateUserStatus(ctx context.Context, userID ulid.ULID, status enum.UserStatus) (err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, nil); err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = tx.UpdateUserStatus(userID, status); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UpdateUserStatus sets the status of the user; if the status is deleted then the user
+// is marked as deleted now so that they are purged after the retention period,
+// otherwise the deleted timestamp is cleared (e.g. when a deleted user is restored).
+func (tx *Tx) UpdateUserStatus(userID ulid.ULID, status enum.UserStatus) (err error) {
+	if userID.IsZero() {
+		return errors.ErrMissingID
+	}
+
+	if status == enum.UserStatusUnknown || !enum.ValidUserStatus(status) {
+		return errors.ErrInvalidStatus
+	}
+
+	now := time.Now()
+	params := []any{
+		sql.Named("id", userID),
+		sql.Named("status", status),
+		sql.Named("deleted", sql.NullTime{Valid: status == enum.UserStatusDeleted, Time: now}),
+		sql.Named("modified", now),
+	}
+
+	var result sql.Result
+	if result, err = tx.Exec(updateUserStatusSQL, params...); err != nil {
+		return dbe(err)
+	}
+
+	if nRows, _ := result.RowsAffected(); nRows == 0 {
+		return errors.ErrNotFound
+	}
+
+	return nil
+}
+
 const (
 	addRoleToUserSQL = "INSERT INTO user_roles (user_id, role_id, created) VALUES (:userID, :roleID, :created)"
 )
@@ -477,6 +591,55 @@ func (tx *Tx) RemoveRoleFromUser(userID ulid.ULID, role any) (err error) {
 	return nil
 }
 
+const (
+	userExistsSQL      = "SELECT EXISTS (SELECT 1 FROM users WHERE id=:id)"
+	removeUserRolesSQL = "DELETE FROM user_roles WHERE user_id=:userID"
+)
+
+// ReplaceUserRoles removes all of the roles currently assigned to the user and assigns
+// the specified roles in their place.
+func (s *Store) ReplaceUserRoles(ctx context.Context, userID ulid.ULID, roleIDs []int64) (err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, nil); err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = tx.ReplaceUserRoles(userID, roleIDs); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (tx *Tx) ReplaceUserRoles(userID ulid.ULID, roleIDs []int64) (err error) {
+	if userID.IsZero() {
+		return errors.ErrMissingID
+	}
+
+	// Ensure the user exists so that an unknown user is not silently ignored.
+	var exists bool
+	if err = tx.QueryRow(userExistsSQL, sql.Named("id", userID)).Scan(&exists); err != nil {
+		return dbe(err)
+	}
+
+	if !exists {
+		return errors.ErrNotFound
+	}
+
+	if _, err = tx.Exec(removeUserRolesSQL, sql.Named("userID", userID)); err != nil {
+		return dbe(err)
+	}
+
+	for _, roleID := range roleIDs {
+		if err = tx.AddRoleToUser(userID, roleID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 const (
 	deleteUserSQL = "DELETE FROM users WHERE id=:id"
 )
@@ -511,3 +674,49 @@ func (tx *Tx) DeleteUser(userID ulid.ULID) (err error) {
 
 	return nil
 }
+
+const (
+	purgeUsersSQL = "DELETE FROM users WHERE status='deleted' AND deleted < :deletedBefore RETURNING id"
+)
+
+func (s *Store) PurgeUsers(ctx context.Context, deletedBefore time.Time) (userIDs []ulid.ULID, err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, nil); err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if userIDs, err = tx.PurgeUsers(deletedBefore); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return userIDs, nil
+}
+
+// PurgeUsers permanently deletes the users that were deleted before the specified
+// time and returns their IDs; users that have not been deleted are never purged.
+func (tx *Tx) PurgeUsers(deletedBefore time.Time) (userIDs []ulid.ULID, err error) {
+	var rows *sql.Rows
+	if rows, err = tx.Query(purgeUsersSQL, sql.Named("deletedBefore", deletedBefore)); err != nil {
+		return nil, dbe(err)
+	}
+	defer rows.Close()
+
+	userIDs = make([]ulid.ULID, 0)
+	for rows.Next() {
+		var userID ulid.ULID
+		if err = rows.Scan(&userID); err != nil {
+			return nil, dbe(err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, dbe(err)
+	}
+
+	return userIDs, nil
+}
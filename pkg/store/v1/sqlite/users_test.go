@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"time"
 
+	"go.rtnl.ai/quarterdeck/pkg/enum"
 	"go.rtnl.ai/quarterdeck/pkg/errors"
 	"go.rtnl.ai/quarterdeck/pkg/store/v1/models"
 	"go.rtnl.ai/ulid"
@@ -25,6 +26,40 @@ func (s *storeTestSuite) TestUserListWithRoleFilter() {
 	require.Len(out.Users, 2, "should return the two fixture users with the admin role")
 }
 
+func (s *storeTestSuite) TestUserListPagination() {
+	require := s.Require()
+	all, err := s.db.ListUsers(s.Context(), nil)
+	require.NoError(err, "should be able to list users")
+	require.Len(all.Users, 5)
+
+	page := &models.UserPage{Page: models.Page{PageSize: 2}}
+	out, err := s.db.ListUsers(s.Context(), page)
+	require.NoError(err, "should be able to list the first page")
+	require.Equal(all.Users[:2], out.Users, "should return the two most recent users")
+	require.False(out.Page.NextPageID.IsZero(), "should have a next page")
+	require.True(out.Page.PrevPageID.IsZero(), "should not have a previous page")
+
+	page.NextPageID = out.Page.NextPageID
+	out, err = s.db.ListUsers(s.Context(), page)
+	require.NoError(err, "should be able to list the second page")
+	require.Equal(all.Users[2:4], out.Users, "should return the next two users")
+	require.False(out.Page.NextPageID.IsZero(), "should have a next page")
+	require.False(out.Page.PrevPageID.IsZero(), "should have a previous page")
+
+	page.NextPageID = out.Page.NextPageID
+	out, err = s.db.ListUsers(s.Context(), page)
+	require.NoError(err, "should be able to list the last page")
+	require.Equal(all.Users[4:], out.Users, "should return the last user")
+	require.True(out.Page.NextPageID.IsZero(), "should not have a next page")
+
+	page.NextPageID, page.PrevPageID = ulid.Zero, out.Page.PrevPageID
+	out, err = s.db.ListUsers(s.Context(), page)
+	require.NoError(err, "should be able to list the previous page")
+	require.Equal(all.Users[2:4], out.Users, "should return the users before the last page")
+	require.Equal(all.Users[3].ID, out.Page.NextPageID)
+	require.Equal(all.Users[2].ID, out.Page.PrevPageID)
+}
+
 func (s *storeTestSuite) TestCreateUser() {
 	s.Run("NoIDOnCreate", func() {
 		user := &models.User{
@@ -260,6 +295,34 @@ func (s *storeTestSuite) TestUpdateUser() {
 		require.WithinDuration(cmpt.Modified, time.Now(), time.Minute)
 	})
 
+	s.Run("UpdateMFA", func() {
+		user, err := s.db.RetrieveUser(s.Context(), userID)
+		require.NoError(err, "could not retrieve user for mfa test")
+		require.False(user.MFAEnabled(), "test user should not have mfa enabled")
+
+		user.TOTPSecret = sql.NullString{Valid: true, String: "JBSWY3DPEHPK3PXP"}
+		user.EnableTOTP([]string{"hash1", "hash2"})
+		require.NoError(s.db.UpdateMFA(s.Context(), user), "should be able to enable mfa")
+
+		cmpt, err := s.db.RetrieveUser(s.Context(), userID)
+		require.NoError(err, "should be able to retrieve updated user after mfa change")
+		require.True(cmpt.MFAEnabled(), "mfa should be enabled")
+		require.Equal("JBSWY3DPEHPK3PXP", cmpt.TOTPSecret.String)
+		require.Equal([]string{"hash1", "hash2"}, cmpt.RecoveryCodes)
+
+		cmpt.DisableTOTP()
+		require.NoError(s.db.UpdateMFA(s.Context(), cmpt), "should be able to disable mfa")
+
+		cmpt, err = s.db.RetrieveUser(s.Context(), userID)
+		require.NoError(err, "should be able to retrieve updated user after mfa change")
+		require.False(cmpt.MFAEnabled(), "mfa should be disabled")
+		require.False(cmpt.TOTPSecret.Valid)
+		require.Nil(cmpt.RecoveryCodes)
+
+		err = s.db.UpdateMFA(s.Context(), &models.User{Model: models.Model{ID: ulid.MakeSecure()}})
+		require.ErrorIs(err, errors.ErrNotFound)
+	})
+
 	s.Run("AddRole", func() {
 		// Ensure the user does not have the keyholder role before running these tests.
 		user, err := s.db.RetrieveUser(s.Context(), userID)
@@ -396,6 +459,53 @@ func (s *storeTestSuite) TestUpdateUser() {
 		})
 	})
 
+	s.Run("ReplaceRoles", func() {
+		s.Run("Happy", func() {
+			err := s.db.ReplaceUserRoles(s.Context(), userID, []int64{2, 4})
+			require.NoError(err, "should be able to replace user roles")
+
+			cmpt, err := s.db.RetrieveUser(s.Context(), userID)
+			require.NoError(err, "should be able to retrieve updated user after replacing roles")
+
+			roles, _ := cmpt.Roles()
+			require.Len(roles, 2, "expected the user to have exactly the replaced roles")
+			for _, role := range roles {
+				require.Contains([]int64{2, 4}, role.ID, "unexpected role assigned to user")
+			}
+			s.ResetDB()
+		})
+
+		s.Run("Empty", func() {
+			err := s.db.ReplaceUserRoles(s.Context(), userID, nil)
+			require.NoError(err, "should be able to remove all user roles")
+
+			cmpt, err := s.db.RetrieveUser(s.Context(), userID)
+			require.NoError(err, "should be able to retrieve updated user after replacing roles")
+
+			roles, _ := cmpt.Roles()
+			require.Empty(roles, "should not find any roles after replacing with an empty list")
+			s.ResetDB()
+		})
+
+		s.Run("UnknownRole", func() {
+			err := s.db.ReplaceUserRoles(s.Context(), userID, []int64{2, 9999})
+			require.ErrorIs(err, errors.ErrNotFound, "should not be able to assign a role that does not exist")
+
+			// The transaction should be rolled back so the original roles are retained.
+			cmpt, err := s.db.RetrieveUser(s.Context(), userID)
+			require.NoError(err, "should be able to retrieve user after failed replace")
+
+			roles, _ := cmpt.Roles()
+			require.Len(roles, 1, "expected the original roles to be retained")
+			require.Equal("admin", roles[0].Title)
+		})
+
+		s.Run("UnknownUser", func() {
+			err := s.db.ReplaceUserRoles(s.Context(), ulid.Make(), []int64{2})
+			require.ErrorIs(err, errors.ErrNotFound, "should return not found for a user that does not exist")
+		})
+	})
+
 	s.Run("NotFound", func() {
 		user.ID = ulid.Make() // Set a new ID that does not exist
 		err = s.db.UpdateUser(s.Context(), user)
@@ -423,3 +533,92 @@ func (s *storeTestSuite) TestDeleteUser() {
 	err = s.db.DeleteUser(s.Context(), userID)
 	require.ErrorIs(err, errors.ErrNotFound)
 }
+
+func (s *storeTestSuite) TestUpdateUserStatus() {
+	if s.ReadOnly() {
+		s.T().Skip("skipping update test in read-only mode")
+	}
+
+	userID := ulid.MustParse("01JPYRNYMEHNEZCS0JYX1CP57A")
+
+	s.Run("Suspend", func() {
+		require := s.Require()
+		err := s.db.UpdateUserStatus(s.Context(), userID, enum.UserStatusSuspended)
+		require.NoError(err, "should be able to suspend the user")
+
+		user, err := s.db.RetrieveUser(s.Context(), userID)
+		require.NoError(err, "should be able to retrieve the suspended user")
+		require.Equal(enum.UserStatusSuspended, user.Status)
+		require.False(user.Deleted.Valid, "a suspended user should not be deleted")
+
+		out, err := s.db.ListUsers(s.Context(), &models.UserPage{Status: enum.UserStatusSuspended})
+		require.NoError(err, "should be able to list suspended users")
+		require.Len(out.Users, 1, "should return only the suspended user")
+		require.Equal(userID, out.Users[0].ID)
+	})
+
+	s.Run("DeleteAndRestore", func() {
+		require := s.Require()
+		err := s.db.UpdateUserStatus(s.Context(), userID, enum.UserStatusDeleted)
+		require.NoError(err, "should be able to delete the user")
+
+		user, err := s.db.RetrieveUser(s.Context(), userID)
+		require.NoError(err, "a deleted user should be retrievable until it is purged")
+		require.Equal(enum.UserStatusDeleted, user.Status)
+		require.True(user.Deleted.Valid, "the deleted timestamp should be set")
+
+		out, err := s.db.ListUsers(s.Context(), nil)
+		require.NoError(err, "should be able to list users")
+		require.Len(out.Users, 4, "deleted users should not be listed")
+
+		out, err = s.db.ListUsers(s.Context(), &models.UserPage{Status: enum.UserStatusDeleted})
+		require.NoError(err, "should be able to list deleted users")
+		require.Len(out.Users, 1, "should return only the deleted user")
+
+		err = s.db.UpdateUserStatus(s.Context(), userID, enum.UserStatusActive)
+		require.NoError(err, "should be able to restore the user")
+
+		user, err = s.db.RetrieveUser(s.Context(), userID)
+		require.NoError(err, "should be able to retrieve the restored user")
+		require.Equal(enum.UserStatusActive, user.Status)
+		require.False(user.Deleted.Valid, "the deleted timestamp should be cleared on restore")
+	})
+
+	s.Run("InvalidStatus", func() {
+		err := s.db.UpdateUserStatus(s.Context(), userID, enum.UserStatusUnknown)
+		s.Require().ErrorIs(err, errors.ErrInvalidStatus)
+	})
+
+	s.Run("NotFound", func() {
+		err := s.db.UpdateUserStatus(s.Context(), ulid.MakeSecure(), enum.UserStatusSuspended)
+		s.Require().ErrorIs(err, errors.ErrNotFound)
+	})
+}
+
+func (s *storeTestSuite) TestPurgeUsers() {
+	if s.ReadOnly() {
+		s.T().Skip("skipping purge test in read-only mode")
+	}
+
+	require := s.Require()
+	userCount := s.Count("users")
+
+	deletedID := ulid.MustParse("01JPYRNYMEHNEZCS0JYX1CP57A")
+	suspendedID := ulid.MustParse("01JQNPQ1CHG36SV7NRQKTZB20R")
+	require.NoError(s.db.UpdateUserStatus(s.Context(), deletedID, enum.UserStatusDeleted))
+	require.NoError(s.db.UpdateUserStatus(s.Context(), suspendedID, enum.UserStatusSuspended))
+
+	// Users deleted after the cutoff are still within the retention period.
+	purged, err := s.db.PurgeUsers(s.Context(), time.Now().Add(-1*time.Hour))
+	require.NoError(err, "should be able to purge users")
+	require.Empty(purged, "no users should have been purged")
+	require.Equal(userCount, s.Count("users"))
+
+	purged, err = s.db.PurgeUsers(s.Context(), time.Now().Add(1*time.Hour))
+	require.NoError(err, "should be able to purge users")
+	require.Equal([]ulid.ULID{deletedID}, purged, "only the deleted user should be purged")
+	require.Equal(userCount-1, s.Count("users"))
+
+	_, err = s.db.RetrieveUser(s.Context(), deletedID)
+	require.ErrorIs(err, errors.ErrNotFound, "the purged user should no longer exist")
+}
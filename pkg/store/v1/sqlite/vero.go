@@ -294,6 +294,122 @@ func (tx *Tx) CreateTeamInviteVeroToken(token *models.VeroToken) (err error) {
 	return nil
 }
 
+// Creates a [models.VeroToken] of the type [enum.TokenTypeVerifyEmail]
+// ensuring that there is at most one unexpired verify email token for a user.
+func (s *Store) CreateVerifyEmailVeroToken(ctx context.Context, token *models.VeroToken) (err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, nil); err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = tx.CreateVerifyEmailVeroToken(token); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Creates a [models.VeroToken] of the type [enum.TokenTypeVerifyEmail]
+// ensuring that there is at most one unexpired verify email token for a user.
+func (tx *Tx) CreateVerifyEmailVeroToken(token *models.VeroToken) (err error) {
+	// Check that there is no ID, but we do not need to create the ID or set
+	// the Created/Modified times because those will be done in CreateVeroToken
+	// at the end of this function.
+	if !token.ID.IsZero() {
+		return errors.ErrNoIDOnCreate
+	}
+
+	// Ensure the token type is for verify email
+	if token.TokenType != enum.TokenTypeVerifyEmail {
+		return errors.ErrTypeMismatch
+	}
+
+	// Ensure the resource ID is set
+	if !token.ResourceID.Valid || (token.ResourceID.ULID == ulid.Zero) {
+		return errors.ErrMissingReference
+	}
+
+	// Get any existing verify email tokens
+	existing := &models.VeroToken{}
+	if err = existing.Scan(tx.QueryRow(
+		retrieveResetPasswordTokenSQL,
+		sql.Named("resourceID", token.ResourceID),
+		sql.Named("tokenType", enum.TokenTypeVerifyEmail),
+	)); err != nil {
+		if err != sql.ErrNoRows {
+			return dbe(err)
+		}
+	}
+
+	// Ensure any current token is not expired
+	if !existing.Expiration.IsZero() {
+		// If the existing link is not expired, then return ErrTooSoon
+		if !existing.IsExpired() {
+			return errors.ErrTooSoon
+		}
+
+		// Delete the existing token if it is expired
+		if err = tx.DeleteVeroToken(existing.ID); err != nil {
+			return err
+		}
+	}
+
+	// Create the token
+	if err = tx.CreateVeroToken(token); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+const (
+	listTeamInviteTokensSQL = "SELECT * FROM vero_tokens WHERE token_type=:tokenType ORDER BY created DESC"
+)
+
+// ListTeamInviteVeroTokens returns the team-invite VeroTokens that have not been
+// accepted or revoked (including expired invites), most recently created first.
+func (s *Store) ListTeamInviteVeroTokens(ctx context.Context) (out []*models.VeroToken, err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, &sql.TxOptions{ReadOnly: true}); err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if out, err = tx.ListTeamInviteVeroTokens(); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ListTeamInviteVeroTokens returns the team-invite VeroTokens that have not been
+// accepted or revoked (including expired invites), most recently created first.
+func (tx *Tx) ListTeamInviteVeroTokens() (out []*models.VeroToken, err error) {
+	var rows *sql.Rows
+	if rows, err = tx.Query(listTeamInviteTokensSQL, sql.Named("tokenType", enum.TokenTypeTeamInvite)); err != nil {
+		return nil, dbe(err)
+	}
+	defer rows.Close()
+
+	out = make([]*models.VeroToken, 0)
+	for rows.Next() {
+		token := &models.VeroToken{}
+		if err = token.Scan(rows); err != nil {
+			return nil, err
+		}
+		out = append(out, token)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, dbe(err)
+	}
+	return out, nil
+}
+
 // RetrieveTeamInviteVeroToken returns the current team-invite VeroToken for a user, if any.
 func (s *Store) RetrieveTeamInviteVeroToken(ctx context.Context, userID ulid.ULID) (out *models.VeroToken, err error) {
 	var tx *Tx
@@ -331,3 +447,131 @@ func (tx *Tx) RetrieveTeamInviteVeroToken(userID ulid.ULID) (out *models.VeroTok
 	}
 	return out, nil
 }
+
+// Creates a [models.VeroToken] of the type [enum.TokenTypeChangeEmail] replacing any
+// pending email change for the user so that only the most recently requested address
+// can be confirmed (e.g. if the user corrects a typo in the new address).
+func (s *Store) CreateChangeEmailVeroToken(ctx context.Context, token *models.VeroToken) (err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, nil); err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = tx.CreateChangeEmailVeroToken(token); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Creates a [models.VeroToken] of the type [enum.TokenTypeChangeEmail] replacing any
+// pending email change for the user so that only the most recently requested address
+// can be confirmed (e.g. if the user corrects a typo in the new address).
+func (tx *Tx) CreateChangeEmailVeroToken(token *models.VeroToken) (err error) {
+	if !token.ID.IsZero() {
+		return errors.ErrNoIDOnCreate
+	}
+
+	// Ensure the token type is for change email
+	if token.TokenType != enum.TokenTypeChangeEmail {
+		return errors.ErrTypeMismatch
+	}
+
+	// Ensure the resource ID is set
+	if !token.ResourceID.Valid || (token.ResourceID.ULID == ulid.Zero) {
+		return errors.ErrMissingReference
+	}
+
+	// Delete any existing change email token whether or not it has expired.
+	if _, err = tx.Exec(
+		deleteVeroByResourceSQL,
+		sql.Named("resourceID", token.ResourceID),
+		sql.Named("tokenType", enum.TokenTypeChangeEmail),
+	); err != nil {
+		return dbe(err)
+	}
+
+	// Create the token
+	if err = tx.CreateVeroToken(token); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+const (
+	deleteVeroByResourceSQL = "DELETE FROM vero_tokens WHERE resource_id=:resourceID AND token_type=:tokenType"
+	updateUserEmailSQL      = "UPDATE users SET email=:email, modified=:modified WHERE id=:id"
+)
+
+// CompleteEmailChange sets the email address of the user to the address of the change
+// email or revert email VeroToken and deletes the token so that it cannot be used
+// again. The email verified flag of the user is not modified since the user received
+// the token at the address. Reverting an email change also deletes any pending email
+// change for the user so that it can no longer be confirmed.
+func (s *Store) CompleteEmailChange(ctx context.Context, veroTokenID ulid.ULID) (err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, nil); err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = tx.CompleteEmailChange(veroTokenID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CompleteEmailChange sets the email address of the user to the address of the change
+// email or revert email VeroToken and deletes the token so that it cannot be used
+// again. The email verified flag of the user is not modified since the user received
+// the token at the address. Reverting an email change also deletes any pending email
+// change for the user so that it can no longer be confirmed.
+func (tx *Tx) CompleteEmailChange(veroTokenID ulid.ULID) (err error) {
+	var token *models.VeroToken
+	if token, err = tx.RetrieveVeroToken(veroTokenID); err != nil {
+		return err
+	}
+
+	if token.TokenType != enum.TokenTypeChangeEmail && token.TokenType != enum.TokenTypeRevertEmail {
+		return errors.ErrTypeMismatch
+	}
+
+	if token.IsExpired() {
+		return errors.ErrExpiredToken
+	}
+
+	if !token.ResourceID.Valid || (token.ResourceID.ULID == ulid.Zero) {
+		return errors.ErrMissingReference
+	}
+
+	params := []any{
+		sql.Named("id", token.ResourceID.ULID),
+		sql.Named("email", token.Email),
+		sql.Named("modified", time.Now()),
+	}
+
+	// A unique constraint violation means another user has the email address.
+	var result sql.Result
+	if result, err = tx.Exec(updateUserEmailSQL, params...); err != nil {
+		return dbe(err)
+	}
+
+	if nRows, _ := result.RowsAffected(); nRows == 0 {
+		return errors.ErrNotFound
+	}
+
+	if token.TokenType == enum.TokenTypeRevertEmail {
+		if _, err = tx.Exec(
+			deleteVeroByResourceSQL,
+			sql.Named("resourceID", token.ResourceID),
+			sql.Named("tokenType", enum.TokenTypeChangeEmail),
+		); err != nil {
+			return dbe(err)
+		}
+	}
+
+	return tx.DeleteVeroToken(token.ID)
+}
@@ -394,3 +394,206 @@ func (s *storeTestSuite) TestRetrieveTeamInviteVeroToken() {
 	_, err = s.db.RetrieveTeamInviteVeroToken(s.Context(), ulid.Make())
 	require.ErrorIs(err, errors.ErrNotFound)
 }
+
+func (s *storeTestSuite) TestListTeamInviteVeroTokens() {
+	require := s.Require()
+	if s.ReadOnly() {
+		s.T().Skip("skipping team invite list test in read-only mode")
+	}
+
+	invites, err := s.db.ListTeamInviteVeroTokens(s.Context())
+	require.NoError(err)
+	require.Len(invites, 0, "the fixture reset password token should not be listed")
+
+	token := &models.VeroToken{
+		TokenType:  enum.TokenTypeTeamInvite,
+		ResourceID: ulid.NullULID{Valid: true, ULID: ulid.MakeSecure()},
+		Email:      "invite@example.com",
+		Expiration: time.Now().Add(48 * time.Hour),
+	}
+	require.NoError(s.db.CreateTeamInviteVeroToken(s.Context(), token))
+
+	invites, err = s.db.ListTeamInviteVeroTokens(s.Context())
+	require.NoError(err)
+	require.Len(invites, 1)
+	require.Equal(token.ID, invites[0].ID)
+	require.Equal(enum.TokenTypeTeamInvite, invites[0].TokenType)
+}
+
+// Ensure that at most one unexpired verify email token can be created for a user.
+func (s *storeTestSuite) TestCreateVerifyEmailVeroToken() {
+	require := s.Require()
+	if s.ReadOnly() {
+		s.T().Skip("skipping verify email create test in read-only mode")
+	}
+
+	userID := ulid.MakeSecure()
+	token := &models.VeroToken{
+		TokenType:  enum.TokenTypeVerifyEmail,
+		ResourceID: ulid.NullULID{Valid: true, ULID: userID},
+		Email:      "verify@example.com",
+		Expiration: time.Now().Add(24 * time.Hour),
+	}
+
+	require.NoError(s.db.CreateVerifyEmailVeroToken(s.Context(), token))
+	require.False(token.ID.IsZero())
+
+	err := s.db.CreateVerifyEmailVeroToken(s.Context(), &models.VeroToken{
+		TokenType:  enum.TokenTypeVerifyEmail,
+		ResourceID: ulid.NullULID{Valid: true, ULID: userID},
+		Email:      "verify@example.com",
+		Expiration: time.Now().Add(24 * time.Hour),
+	})
+	require.ErrorIs(err, errors.ErrTooSoon)
+
+	err = s.db.CreateVerifyEmailVeroToken(s.Context(), &models.VeroToken{
+		TokenType:  enum.TokenTypeResetPassword,
+		ResourceID: ulid.NullULID{Valid: true, ULID: userID},
+	})
+	require.ErrorIs(err, errors.ErrTypeMismatch)
+}
+
+func (s *storeTestSuite) TestCreateChangeEmailVeroToken() {
+	require := s.Require()
+	if s.ReadOnly() {
+		s.T().Skip("skipping change email create test in read-only mode")
+	}
+
+	userID := ulid.MakeSecure()
+	first := &models.VeroToken{
+		TokenType:  enum.TokenTypeChangeEmail,
+		ResourceID: ulid.NullULID{Valid: true, ULID: userID},
+		Email:      "typo@exmaple.com",
+		Expiration: time.Now().Add(24 * time.Hour),
+	}
+	require.NoError(s.db.CreateChangeEmailVeroToken(s.Context(), first))
+	require.False(first.ID.IsZero())
+
+	// A new change request replaces the pending request even if it has not expired.
+	second := &models.VeroToken{
+		TokenType:  enum.TokenTypeChangeEmail,
+		ResourceID: ulid.NullULID{Valid: true, ULID: userID},
+		Email:      "fixed@example.com",
+		Expiration: time.Now().Add(24 * time.Hour),
+	}
+	require.NoError(s.db.CreateChangeEmailVeroToken(s.Context(), second))
+
+	_, err := s.db.RetrieveVeroToken(s.Context(), first.ID)
+	require.ErrorIs(err, errors.ErrNotFound)
+
+	cmpt, err := s.db.RetrieveVeroToken(s.Context(), second.ID)
+	require.NoError(err)
+	require.Equal("fixed@example.com", cmpt.Email)
+
+	err = s.db.CreateChangeEmailVeroToken(s.Context(), &models.VeroToken{
+		TokenType:  enum.TokenTypeVerifyEmail,
+		ResourceID: ulid.NullULID{Valid: true, ULID: userID},
+	})
+	require.ErrorIs(err, errors.ErrTypeMismatch)
+
+	err = s.db.CreateChangeEmailVeroToken(s.Context(), &models.VeroToken{
+		TokenType: enum.TokenTypeChangeEmail,
+	})
+	require.ErrorIs(err, errors.ErrMissingReference)
+}
+
+func (s *storeTestSuite) TestCompleteEmailChange() {
+	if s.ReadOnly() {
+		s.T().Skip("skipping complete email change test in read-only mode")
+	}
+
+	userID := ulid.MustParse("01JQNPQ1CHG36SV7NRQKTZB20R")
+
+	s.Run("Confirm", func() {
+		require := s.Require()
+		require.NoError(s.db.VerifyEmail(s.Context(), userID))
+
+		token := &models.VeroToken{
+			TokenType:  enum.TokenTypeChangeEmail,
+			ResourceID: ulid.NullULID{Valid: true, ULID: userID},
+			Email:      "new.editor@example.com",
+			Expiration: time.Now().Add(24 * time.Hour),
+		}
+		require.NoError(s.db.CreateChangeEmailVeroToken(s.Context(), token))
+		require.NoError(s.db.CompleteEmailChange(s.Context(), token.ID))
+
+		user, err := s.db.RetrieveUser(s.Context(), userID)
+		require.NoError(err)
+		require.Equal("new.editor@example.com", user.Email)
+		require.True(user.EmailVerified, "email verified should be preserved")
+
+		_, err = s.db.RetrieveVeroToken(s.Context(), token.ID)
+		require.ErrorIs(err, errors.ErrNotFound, "the token should be deleted")
+	})
+
+	s.Run("Revert", func() {
+		require := s.Require()
+
+		pending := &models.VeroToken{
+			TokenType:  enum.TokenTypeChangeEmail,
+			ResourceID: ulid.NullULID{Valid: true, ULID: userID},
+			Email:      "attacker@example.com",
+			Expiration: time.Now().Add(24 * time.Hour),
+		}
+		require.NoError(s.db.CreateChangeEmailVeroToken(s.Context(), pending))
+
+		revert := &models.VeroToken{
+			TokenType:  enum.TokenTypeRevertEmail,
+			ResourceID: ulid.NullULID{Valid: true, ULID: userID},
+			Email:      "editor@example.com",
+			Expiration: time.Now().Add(24 * time.Hour),
+		}
+		require.NoError(s.db.CreateVeroToken(s.Context(), revert))
+		require.NoError(s.db.CompleteEmailChange(s.Context(), revert.ID))
+
+		user, err := s.db.RetrieveUser(s.Context(), userID)
+		require.NoError(err)
+		require.Equal("editor@example.com", user.Email)
+
+		_, err = s.db.RetrieveVeroToken(s.Context(), pending.ID)
+		require.ErrorIs(err, errors.ErrNotFound, "the pending email change should be deleted")
+	})
+
+	s.Run("AlreadyExists", func() {
+		require := s.Require()
+
+		token := &models.VeroToken{
+			TokenType:  enum.TokenTypeChangeEmail,
+			ResourceID: ulid.NullULID{Valid: true, ULID: userID},
+			Email:      "admin@example.com",
+			Expiration: time.Now().Add(24 * time.Hour),
+		}
+		require.NoError(s.db.CreateChangeEmailVeroToken(s.Context(), token))
+		require.ErrorIs(s.db.CompleteEmailChange(s.Context(), token.ID), errors.ErrAlreadyExists)
+
+		user, err := s.db.RetrieveUser(s.Context(), userID)
+		require.NoError(err)
+		require.Equal("editor@example.com", user.Email)
+	})
+
+	s.Run("Expired", func() {
+		require := s.Require()
+
+		token := &models.VeroToken{
+			TokenType:  enum.TokenTypeChangeEmail,
+			ResourceID: ulid.NullULID{Valid: true, ULID: userID},
+			Email:      "expired@example.com",
+			Expiration: time.Now().Add(-1 * time.Hour),
+		}
+		require.NoError(s.db.CreateChangeEmailVeroToken(s.Context(), token))
+		require.ErrorIs(s.db.CompleteEmailChange(s.Context(), token.ID), errors.ErrExpiredToken)
+	})
+
+	s.Run("TypeMismatch", func() {
+		require := s.Require()
+
+		token := &models.VeroToken{
+			TokenType:  enum.TokenTypeResetPassword,
+			ResourceID: ulid.NullULID{Valid: true, ULID: userID},
+			Email:      "editor@example.com",
+			Expiration: time.Now().Add(24 * time.Hour),
+		}
+		require.NoError(s.db.CreateVeroToken(s.Context(), token))
+		require.ErrorIs(s.db.CompleteEmailChange(s.Context(), token.ID), errors.ErrTypeMismatch)
+	})
+}
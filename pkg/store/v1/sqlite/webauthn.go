@@ -0,0 +1,216 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v1/models"
+	"go.rtnl.ai/ulid"
+)
+
+const (
+	listWebAuthnCredentialsSQL = "SELECT id, user_id, name, credential_id, public_key, attestation_type, aaguid, sign_count, transports, flags, last_used, created, modified FROM webauthn_credentials WHERE user_id=:userID ORDER BY created ASC"
+)
+
+// ListWebAuthnCredentials returns all of the passkeys registered by the user, oldest first.
+func (s *Store) ListWebAuthnCredentials(ctx context.Context, userID ulid.ULID) (out *models.WebAuthnCredentialList, err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, &sql.TxOptions{ReadOnly: true}); err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if out, err = tx.ListWebAuthnCredentials(userID); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (tx *Tx) ListWebAuthnCredentials(userID ulid.ULID) (out *models.WebAuthnCredentialList, err error) {
+	if userID.IsZero() {
+		return nil, errors.ErrMissingID
+	}
+
+	out = &models.WebAuthnCredentialList{
+		Credentials: make([]*models.WebAuthnCredential, 0),
+	}
+
+	var rows *sql.Rows
+	if rows, err = tx.Query(listWebAuthnCredentialsSQL, sql.Named("userID", userID)); err != nil {
+		return nil, dbe(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		credential := &models.WebAuthnCredential{}
+		if err = credential.Scan(rows); err != nil {
+			return nil, err
+		}
+		out.Credentials = append(out.Credentials, credential)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, dbe(err)
+	}
+
+	return out, nil
+}
+
+const (
+	createWebAuthnCredentialSQL = "INSERT INTO webauthn_credentials (id, user_id, name, credential_id, public_key, attestation_type, aaguid, sign_count, transports, flags, last_used, created, modified) VALUES (:id, :userID, :name, :credentialID, :publicKey, :attestationType, :aaguid, :signCount, :transports, :flags, :lastUsed, :created, :modified)"
+)
+
+func (s *Store) CreateWebAuthnCredential(ctx context.Context, in *models.WebAuthnCredential) (err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, nil); err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = tx.CreateWebAuthnCredential(in); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CreateWebAuthnCredential stores a newly registered passkey. The credential ID is
+// assigned by the authenticator and must be unique across all users.
+func (tx *Tx) CreateWebAuthnCredential(in *models.WebAuthnCredential) (err error) {
+	if !in.ID.IsZero() {
+		return errors.ErrNoIDOnCreate
+	}
+
+	if in.UserID.IsZero() || len(in.CredentialID) == 0 || len(in.PublicKey) == 0 {
+		return errors.ErrZeroValuedNotNull
+	}
+
+	in.ID = ulid.MakeSecure()
+	in.Created = time.Now()
+	in.Modified = in.Created
+
+	if _, err = tx.Exec(createWebAuthnCredentialSQL, in.Params()...); err != nil {
+		return dbe(err)
+	}
+
+	return nil
+}
+
+const (
+	retrieveWebAuthnCredentialSQL = "SELECT id, user_id, name, credential_id, public_key, attestation_type, aaguid, sign_count, transports, flags, last_used, created, modified FROM webauthn_credentials WHERE credential_id=:credentialID"
+)
+
+func (s *Store) RetrieveWebAuthnCredential(ctx context.Context, credentialID []byte) (out *models.WebAuthnCredential, err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, &sql.TxOptions{ReadOnly: true}); err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if out, err = tx.RetrieveWebAuthnCredential(credentialID); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// RetrieveWebAuthnCredential looks up a passkey by the authenticator-assigned
+// credential ID that is presented during login.
+func (tx *Tx) RetrieveWebAuthnCredential(credentialID []byte) (out *models.WebAuthnCredential, err error) {
+	if len(credentialID) == 0 {
+		return nil, errors.ErrMissingID
+	}
+
+	out = &models.WebAuthnCredential{}
+	if err = out.Scan(tx.QueryRow(retrieveWebAuthnCredentialSQL, sql.Named("credentialID", credentialID))); err != nil {
+		return nil, dbe(err)
+	}
+
+	return out, nil
+}
+
+const (
+	updateWebAuthnCredentialSQL = "UPDATE webauthn_credentials SET name=:name, sign_count=:signCount, flags=:flags, last_used=:lastUsed, modified=:modified WHERE id=:id"
+)
+
+func (s *Store) UpdateWebAuthnCredential(ctx context.Context, in *models.WebAuthnCredential) (err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, nil); err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = tx.UpdateWebAuthnCredential(in); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UpdateWebAuthnCredential updates the name of the passkey and the authenticator state
+// (sign count, flags, and last used timestamp) that changes after each login. The
+// credential ID, public key, and owner of the passkey cannot be modified.
+func (tx *Tx) UpdateWebAuthnCredential(in *models.WebAuthnCredential) (err error) {
+	if in.ID.IsZero() {
+		return errors.ErrMissingID
+	}
+
+	in.Modified = time.Now()
+
+	var result sql.Result
+	if result, err = tx.Exec(updateWebAuthnCredentialSQL, in.Params()...); err != nil {
+		return dbe(err)
+	}
+
+	if nRows, _ := result.RowsAffected(); nRows == 0 {
+		return errors.ErrNotFound
+	}
+
+	return nil
+}
+
+const (
+	deleteWebAuthnCredentialSQL = "DELETE FROM webauthn_credentials WHERE id=:id"
+)
+
+func (s *Store) DeleteWebAuthnCredential(ctx context.Context, id ulid.ULID) (err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, nil); err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = tx.DeleteWebAuthnCredential(id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (tx *Tx) DeleteWebAuthnCredential(id ulid.ULID) (err error) {
+	if id.IsZero() {
+		return errors.ErrMissingID
+	}
+
+	var result sql.Result
+	if result, err = tx.Exec(deleteWebAuthnCredentialSQL, sql.Named("id", id)); err != nil {
+		return dbe(err)
+	}
+
+	if nRows, _ := result.RowsAffected(); nRows == 0 {
+		return errors.ErrNotFound
+	}
+
+	return nil
+}
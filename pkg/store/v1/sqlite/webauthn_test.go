@@ -0,0 +1,113 @@
+package sqlite_test
+
+import (
+	"database/sql"
+	"time"
+
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v1/models"
+	"go.rtnl.ai/ulid"
+)
+
+func (s *storeTestSuite) TestWebAuthnCredentials() {
+	userID := ulid.MustParse("01JPYRNYMEHNEZCS0JYX1CP57A")
+
+	s.Run("MissingID", func() {
+		require := s.Require()
+
+		_, err := s.db.ListWebAuthnCredentials(s.Context(), ulid.Zero)
+		require.ErrorIs(err, errors.ErrMissingID)
+
+		_, err = s.db.RetrieveWebAuthnCredential(s.Context(), nil)
+		require.ErrorIs(err, errors.ErrMissingID)
+
+		if s.ReadOnly() {
+			return
+		}
+
+		err = s.db.UpdateWebAuthnCredential(s.Context(), &models.WebAuthnCredential{})
+		require.ErrorIs(err, errors.ErrMissingID)
+
+		err = s.db.DeleteWebAuthnCredential(s.Context(), ulid.Zero)
+		require.ErrorIs(err, errors.ErrMissingID)
+	})
+
+	s.Run("CreateErrors", func() {
+		if s.ReadOnly() {
+			s.T().Skip("skipping create test in read-only mode")
+		}
+		require := s.Require()
+
+		err := s.db.CreateWebAuthnCredential(s.Context(), &models.WebAuthnCredential{Model: models.Model{ID: ulid.Make()}, UserID: userID, CredentialID: []byte{1}, PublicKey: []byte{2}})
+		require.ErrorIs(err, errors.ErrNoIDOnCreate)
+
+		err = s.db.CreateWebAuthnCredential(s.Context(), &models.WebAuthnCredential{UserID: userID, PublicKey: []byte{2}})
+		require.ErrorIs(err, errors.ErrZeroValuedNotNull)
+	})
+
+	s.Run("NotFound", func() {
+		_, err := s.db.RetrieveWebAuthnCredential(s.Context(), []byte("unknown"))
+		s.Require().ErrorIs(err, errors.ErrNotFound)
+
+		if s.ReadOnly() {
+			return
+		}
+
+		err = s.db.UpdateWebAuthnCredential(s.Context(), &models.WebAuthnCredential{Model: models.Model{ID: ulid.Make()}})
+		s.Require().ErrorIs(err, errors.ErrNotFound)
+
+		err = s.db.DeleteWebAuthnCredential(s.Context(), ulid.Make())
+		s.Require().ErrorIs(err, errors.ErrNotFound)
+	})
+
+	s.Run("Lifecycle", func() {
+		if s.ReadOnly() {
+			s.T().Skip("skipping create test in read-only mode")
+		}
+		require := s.Require()
+
+		credential := &models.WebAuthnCredential{
+			UserID:          userID,
+			Name:            sql.NullString{String: "YubiKey", Valid: true},
+			CredentialID:    []byte("credential-lifecycle"),
+			PublicKey:       []byte("public-key"),
+			AttestationType: sql.NullString{String: "none", Valid: true},
+			AAGUID:          make([]byte, 16),
+			Transports:      []string{"usb", "nfc"},
+			Flags:           0x1d,
+		}
+		require.NoError(s.db.CreateWebAuthnCredential(s.Context(), credential))
+		require.False(credential.ID.IsZero())
+
+		// The credential ID must be unique across all users.
+		duplicate := &models.WebAuthnCredential{UserID: userID, CredentialID: credential.CredentialID, PublicKey: []byte("other")}
+		require.ErrorIs(s.db.CreateWebAuthnCredential(s.Context(), duplicate), errors.ErrAlreadyExists)
+
+		out, err := s.db.ListWebAuthnCredentials(s.Context(), userID)
+		require.NoError(err)
+		require.Len(out.Credentials, 1)
+
+		cmp, err := s.db.RetrieveWebAuthnCredential(s.Context(), credential.CredentialID)
+		require.NoError(err)
+		require.Equal(credential.ID, cmp.ID)
+		require.Equal(credential.PublicKey, cmp.PublicKey)
+		require.Equal([]string{"usb", "nfc"}, cmp.Transports)
+		require.Equal(uint8(0x1d), cmp.Flags)
+		require.False(cmp.LastUsed.Valid)
+
+		// Record a login with the credential.
+		cmp.SignCount = 42
+		cmp.LastUsed = sql.NullTime{Time: time.Now(), Valid: true}
+		require.NoError(s.db.UpdateWebAuthnCredential(s.Context(), cmp))
+
+		cmp, err = s.db.RetrieveWebAuthnCredential(s.Context(), credential.CredentialID)
+		require.NoError(err)
+		require.Equal(uint32(42), cmp.SignCount)
+		require.True(cmp.LastUsed.Valid)
+
+		require.NoError(s.db.DeleteWebAuthnCredential(s.Context(), credential.ID))
+
+		_, err = s.db.RetrieveWebAuthnCredential(s.Context(), credential.CredentialID)
+		require.ErrorIs(err, errors.ErrNotFound)
+	})
+}
@@ -0,0 +1,446 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"go.rtnl.ai/quarterdeck/pkg/enum"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v1/models"
+	"go.rtnl.ai/ulid"
+)
+
+//===========================================================================
+// Webhooks Store
+//===========================================================================
+
+const listWebhooksSQL = "SELECT * FROM webhooks ORDER BY created ASC"
+
+func (s *Store) ListWebhooks(ctx context.Context, page *models.Page) (out *models.WebhookList, err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, &sql.TxOptions{ReadOnly: true}); err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if out, err = tx.ListWebhooks(page); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (tx *Tx) ListWebhooks(page *models.Page) (out *models.WebhookList, err error) {
+	out = &models.WebhookList{
+		Page:     models.PageFrom(page),
+		Webhooks: make([]*models.Webhook, 0),
+	}
+
+	if out.Webhooks, err = tx.queryWebhooks(listWebhooksSQL); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+const createWebhookSQL = "INSERT INTO webhooks (id, url, description, events, secret, active, created, modified) VALUES (:id, :url, :description, :events, :secret, :active, :created, :modified)"
+
+func (s *Store) CreateWebhook(ctx context.Context, webhook *models.Webhook) (err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, nil); err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = tx.CreateWebhook(webhook); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (tx *Tx) CreateWebhook(webhook *models.Webhook) (err error) {
+	if !webhook.ID.IsZero() {
+		return errors.ErrNoIDOnCreate
+	}
+
+	if webhook.URL == "" || webhook.Secret == "" {
+		return errors.ErrZeroValuedNotNull
+	}
+
+	if len(webhook.Events) == 0 {
+		return errors.ErrNoSubscribedEvent
+	}
+
+	webhook.ID = ulid.MakeSecure()
+	webhook.Created = time.Now()
+	webhook.Modified = webhook.Created
+
+	if _, err = tx.Exec(createWebhookSQL, webhook.Params()...); err != nil {
+		return dbe(err)
+	}
+	return nil
+}
+
+const retrieveWebhookSQL = "SELECT * FROM webhooks WHERE id=:id"
+
+func (s *Store) RetrieveWebhook(ctx context.Context, webhookID ulid.ULID) (out *models.Webhook, err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, &sql.TxOptions{ReadOnly: true}); err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if out, err = tx.RetrieveWebhook(webhookID); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (tx *Tx) RetrieveWebhook(webhookID ulid.ULID) (out *models.Webhook, err error) {
+	out = &models.Webhook{}
+	if err = out.Scan(tx.QueryRow(retrieveWebhookSQL, sql.Named("id", webhookID))); err != nil {
+		return nil, dbe(err)
+	}
+	return out, nil
+}
+
+// The secret of a webhook cannot be updated, the webhook must be recreated instead.
+const updateWebhookSQL = "UPDATE webhooks SET url=:url, description=:description, events=:events, active=:active, modified=:modified WHERE id=:id"
+
+func (s *Store) UpdateWebhook(ctx context.Context, webhook *models.Webhook) (err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, nil); err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = tx.UpdateWebhook(webhook); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (tx *Tx) UpdateWebhook(webhook *models.Webhook) (err error) {
+	if webhook.ID.IsZero() {
+		return errors.ErrMissingID
+	}
+
+	if webhook.URL == "" {
+		return errors.ErrZeroValuedNotNull
+	}
+
+	if len(webhook.Events) == 0 {
+		return errors.ErrNoSubscribedEvent
+	}
+
+	webhook.Modified = time.Now()
+
+	var result sql.Result
+	if result, err = tx.Exec(updateWebhookSQL, webhook.Params()...); err != nil {
+		return dbe(err)
+	}
+
+	if nRows, _ := result.RowsAffected(); nRows == 0 {
+		return errors.ErrNotFound
+	}
+	return nil
+}
+
+const deleteWebhookSQL = "DELETE FROM webhooks WHERE id=:id"
+
+// DeleteWebhook removes the webhook along with its delivery log (which is deleted by
+// the foreign key cascade).
+func (s *Store) DeleteWebhook(ctx context.Context, webhookID ulid.ULID) (err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, nil); err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = tx.DeleteWebhook(webhookID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (tx *Tx) DeleteWebhook(webhookID ulid.ULID) (err error) {
+	if webhookID.IsZero() {
+		return errors.ErrMissingID
+	}
+
+	var result sql.Result
+	if result, err = tx.Exec(deleteWebhookSQL, sql.Named("id", webhookID)); err != nil {
+		return dbe(err)
+	}
+
+	if nRows, _ := result.RowsAffected(); nRows == 0 {
+		return errors.ErrNotFound
+	}
+	return nil
+}
+
+const activeWebhooksSQL = "SELECT * FROM webhooks WHERE active=true ORDER BY created ASC"
+
+// EnqueueWebhookEvent queues a pending delivery of the event for every active webhook
+// that is subscribed to it and returns the number of deliveries that were queued.
+func (s *Store) EnqueueWebhookEvent(ctx context.Context, eventID ulid.ULID, event enum.WebhookEvent, payload []byte) (n int, err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, nil); err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if n, err = tx.EnqueueWebhookEvent(eventID, event, payload); err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+func (tx *Tx) EnqueueWebhookEvent(eventID ulid.ULID, event enum.WebhookEvent, payload []byte) (n int, err error) {
+	if eventID.IsZero() {
+		return 0, errors.ErrMissingID
+	}
+
+	var webhooks []*models.Webhook
+	if webhooks, err = tx.queryWebhooks(activeWebhooksSQL); err != nil {
+		return 0, err
+	}
+
+	for _, webhook := range webhooks {
+		if !webhook.Subscribed(event) {
+			continue
+		}
+
+		if err = tx.CreateWebhookDelivery(webhook.NewDelivery(eventID, event, payload)); err != nil {
+			return 0, err
+		}
+		n++
+	}
+
+	return n, nil
+}
+
+//===========================================================================
+// Webhook Deliveries
+//===========================================================================
+
+const listWebhookDeliveriesSQL = "SELECT * FROM webhook_deliveries WHERE webhook_id=:webhookID ORDER BY created DESC"
+
+// ListWebhookDeliveries returns the delivery log of the webhook, most recent first.
+func (s *Store) ListWebhookDeliveries(ctx context.Context, webhookID ulid.ULID, page *models.Page) (out *models.WebhookDeliveryList, err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, &sql.TxOptions{ReadOnly: true}); err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if out, err = tx.ListWebhookDeliveries(webhookID, page); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (tx *Tx) ListWebhookDeliveries(webhookID ulid.ULID, page *models.Page) (out *models.WebhookDeliveryList, err error) {
+	// Ensure the webhook exists so that an unknown webhook is not an empty log.
+	if _, err = tx.RetrieveWebhook(webhookID); err != nil {
+		return nil, err
+	}
+
+	out = &models.WebhookDeliveryList{
+		Page:       models.PageFrom(page),
+		Deliveries: make([]*models.WebhookDelivery, 0),
+	}
+
+	if out.Deliveries, err = tx.queryWebhookDeliveries(listWebhookDeliveriesSQL, sql.Named("webhookID", webhookID)); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+const createWebhookDeliverySQL = "INSERT INTO webhook_deliveries (id, webhook_id, event_id, event, payload, status, attempts, next_attempt, last_attempt, response_code, error, delivered, created, modified) VALUES (:id, :webhookID, :eventID, :event, :payload, :status, :attempts, :nextAttempt, :lastAttempt, :responseCode, :error, :delivered, :created, :modified)"
+
+func (s *Store) CreateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) (err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, nil); err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = tx.CreateWebhookDelivery(delivery); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (tx *Tx) CreateWebhookDelivery(delivery *models.WebhookDelivery) (err error) {
+	if !delivery.ID.IsZero() {
+		return errors.ErrNoIDOnCreate
+	}
+
+	if delivery.WebhookID.IsZero() || delivery.EventID.IsZero() {
+		return errors.ErrMissingReference
+	}
+
+	delivery.ID = ulid.MakeSecure()
+	delivery.Created = time.Now()
+	delivery.Modified = delivery.Created
+
+	if _, err = tx.Exec(createWebhookDeliverySQL, delivery.Params()...); err != nil {
+		return dbe(err)
+	}
+	return nil
+}
+
+const retrieveWebhookDeliverySQL = "SELECT * FROM webhook_deliveries WHERE id=:id"
+
+func (s *Store) RetrieveWebhookDelivery(ctx context.Context, deliveryID ulid.ULID) (out *models.WebhookDelivery, err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, &sql.TxOptions{ReadOnly: true}); err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if out, err = tx.RetrieveWebhookDelivery(deliveryID); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (tx *Tx) RetrieveWebhookDelivery(deliveryID ulid.ULID) (out *models.WebhookDelivery, err error) {
+	out = &models.WebhookDelivery{}
+	if err = out.Scan(tx.QueryRow(retrieveWebhookDeliverySQL, sql.Named("id", deliveryID))); err != nil {
+		return nil, dbe(err)
+	}
+	return out, nil
+}
+
+// Only the outcome of delivery attempts can be updated; the event is immutable.
+const updateWebhookDeliverySQL = "UPDATE webhook_deliveries SET status=:status, attempts=:attempts, next_attempt=:nextAttempt, last_attempt=:lastAttempt, response_code=:responseCode, error=:error, delivered=:delivered, modified=:modified WHERE id=:id"
+
+func (s *Store) UpdateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) (err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, nil); err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = tx.UpdateWebhookDelivery(delivery); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (tx *Tx) UpdateWebhookDelivery(delivery *models.WebhookDelivery) (err error) {
+	if delivery.ID.IsZero() {
+		return errors.ErrMissingID
+	}
+
+	delivery.Modified = time.Now()
+
+	var result sql.Result
+	if result, err = tx.Exec(updateWebhookDeliverySQL, delivery.Params()...); err != nil {
+		return dbe(err)
+	}
+
+	if nRows, _ := result.RowsAffected(); nRows == 0 {
+		return errors.ErrNotFound
+	}
+	return nil
+}
+
+const listDueWebhookDeliveriesSQL = "SELECT * FROM webhook_deliveries WHERE status='pending' AND next_attempt <= :now ORDER BY next_attempt ASC LIMIT :limit"
+
+// ListDueWebhookDeliveries returns up to limit pending deliveries whose next attempt
+// is due at the specified time, the deliveries that have been due longest first.
+func (s *Store) ListDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) (out []*models.WebhookDelivery, err error) {
+	var tx *Tx
+	if tx, err = s.BeginTx(ctx, &sql.TxOptions{ReadOnly: true}); err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if out, err = tx.ListDueWebhookDeliveries(now, limit); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (tx *Tx) ListDueWebhookDeliveries(now time.Time, limit int) ([]*models.WebhookDelivery, error) {
+	return tx.queryWebhookDeliveries(listDueWebhookDeliveriesSQL, sql.Named("now", now), sql.Named("limit", limit))
+}
+
+//===========================================================================
+// Helpers
+//===========================================================================
+
+func (tx *Tx) queryWebhooks(query string, args ...any) (out []*models.Webhook, err error) {
+	var rows *sql.Rows
+	if rows, err = tx.Query(query, args...); err != nil {
+		return nil, dbe(err)
+	}
+	defer rows.Close()
+
+	out = make([]*models.Webhook, 0)
+	for rows.Next() {
+		webhook := &models.Webhook{}
+		if err = webhook.Scan(rows); err != nil {
+			return nil, err
+		}
+		out = append(out, webhook)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, dbe(err)
+	}
+	return out, nil
+}
+
+func (tx *Tx) queryWebhookDeliveries(query string, args ...any) (out []*models.WebhookDelivery, err error) {
+	var rows *sql.Rows
+	if rows, err = tx.Query(query, args...); err != nil {
+		return nil, dbe(err)
+	}
+	defer rows.Close()
+
+	out = make([]*models.WebhookDelivery, 0)
+	for rows.Next() {
+		delivery := &models.WebhookDelivery{}
+		if err = delivery.Scan(rows); err != nil {
+			return nil, err
+		}
+		out = append(out, delivery)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, dbe(err)
+	}
+	return out, nil
+}
@@ -0,0 +1,267 @@
+package sqlite_test
+
+import (
+	"time"
+
+	"go.rtnl.ai/quarterdeck/pkg/enum"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v1/models"
+	"go.rtnl.ai/ulid"
+)
+
+var (
+	userSyncWebhookID = ulid.MustParse("01KG0020000000000000000001") // active, subscribed to user events
+	loginsWebhookID   = ulid.MustParse("01KG0040000000000000000002") // inactive, subscribed to logins
+	pendingDeliveryID = ulid.MustParse("01KG0G40000000000000000002") // pending user sync delivery that is due
+	failedDeliveryID  = ulid.MustParse("01KG0G60000000000000000003") // failed user sync delivery
+)
+
+func (s *storeTestSuite) TestListWebhooks() {
+	require := s.Require()
+	out, err := s.db.ListWebhooks(s.Context(), nil)
+	require.NoError(err, "should be able to list webhooks")
+	require.NotNil(out.Page, "should return a page object")
+	require.Len(out.Webhooks, 2, "expected 2 fixture webhooks")
+	require.Equal(userSyncWebhookID, out.Webhooks[0].ID, "webhooks should be ordered by created")
+	require.Equal(loginsWebhookID, out.Webhooks[1].ID, "webhooks should be ordered by created")
+}
+
+func (s *storeTestSuite) TestCreateWebhook() {
+	s.Run("NoIDOnCreate", func() {
+		webhook := &models.Webhook{Model: models.Model{ID: ulid.Make()}, URL: "https://example.com", Secret: "secret"}
+		err := s.db.CreateWebhook(s.Context(), webhook)
+		s.Require().ErrorIs(err, errors.ErrNoIDOnCreate)
+	})
+
+	s.Run("RequiresURLAndSecret", func() {
+		err := s.db.CreateWebhook(s.Context(), &models.Webhook{Secret: "secret", Events: []enum.WebhookEvent{enum.WebhookEventLogin}})
+		s.Require().ErrorIs(err, errors.ErrZeroValuedNotNull)
+
+		err = s.db.CreateWebhook(s.Context(), &models.Webhook{URL: "https://example.com", Events: []enum.WebhookEvent{enum.WebhookEventLogin}})
+		s.Require().ErrorIs(err, errors.ErrZeroValuedNotNull)
+	})
+
+	s.Run("RequiresEvents", func() {
+		err := s.db.CreateWebhook(s.Context(), &models.Webhook{URL: "https://example.com", Secret: "secret"})
+		s.Require().ErrorIs(err, errors.ErrNoSubscribedEvent)
+	})
+
+	s.Run("ReadOnly", func() {
+		if !s.ReadOnly() {
+			s.T().Skip("skipping create read-only error test in read-write mode")
+		}
+
+		err := s.db.CreateWebhook(s.Context(), &models.Webhook{URL: "https://example.com", Secret: "secret", Events: []enum.WebhookEvent{enum.WebhookEventLogin}})
+		s.Require().ErrorIs(err, errors.ErrReadOnly)
+	})
+
+	s.Run("Success", func() {
+		if s.ReadOnly() {
+			s.T().Skip("skipping create test in read-only mode")
+		}
+
+		require := s.Require()
+		webhook := &models.Webhook{
+			URL:    "https://example.com/webhooks",
+			Events: []enum.WebhookEvent{enum.WebhookEventAPIKeyRevoked},
+			Secret: "supersecretsquirrel",
+			Active: true,
+		}
+
+		err := s.db.CreateWebhook(s.Context(), webhook)
+		require.NoError(err)
+		require.False(webhook.ID.IsZero())
+		require.WithinDuration(time.Now(), webhook.Created, 3*time.Second)
+
+		got, err := s.db.RetrieveWebhook(s.Context(), webhook.ID)
+		require.NoError(err)
+		require.Equal(webhook.URL, got.URL)
+		require.Equal(webhook.Events, got.Events)
+		require.Equal(webhook.Secret, got.Secret)
+		require.True(got.Active)
+		require.False(got.Description.Valid)
+	})
+}
+
+func (s *storeTestSuite) TestRetrieveWebhook() {
+	s.Run("Complete", func() {
+		require := s.Require()
+		webhook, err := s.db.RetrieveWebhook(s.Context(), userSyncWebhookID)
+		require.NoError(err)
+		require.Equal("https://hooks.example.com/quarterdeck", webhook.URL)
+		require.Equal("User sync", webhook.Description.String)
+		require.Equal([]enum.WebhookEvent{enum.WebhookEventUserCreated, enum.WebhookEventUserUpdated, enum.WebhookEventUserDeleted}, webhook.Events)
+		require.NotEmpty(webhook.Secret)
+		require.True(webhook.Active)
+	})
+
+	s.Run("NotFound", func() {
+		_, err := s.db.RetrieveWebhook(s.Context(), ulid.Make())
+		s.Require().ErrorIs(err, errors.ErrNotFound)
+	})
+}
+
+func (s *storeTestSuite) TestUpdateWebhook() {
+	if s.ReadOnly() {
+		s.T().Skip("skipping update test in read-only mode")
+	}
+
+	s.Run("Success", func() {
+		require := s.Require()
+		webhook, err := s.db.RetrieveWebhook(s.Context(), loginsWebhookID)
+		require.NoError(err)
+
+		secret := webhook.Secret
+		webhook.Active = true
+		webhook.Events = append(webhook.Events, enum.WebhookEventAPIKeyRevoked)
+		webhook.Secret = "cannot be changed"
+		require.NoError(s.db.UpdateWebhook(s.Context(), webhook))
+
+		got, err := s.db.RetrieveWebhook(s.Context(), loginsWebhookID)
+		require.NoError(err)
+		require.True(got.Active)
+		require.Equal([]enum.WebhookEvent{enum.WebhookEventLogin, enum.WebhookEventAPIKeyRevoked}, got.Events)
+		require.Equal(secret, got.Secret, "the secret should not be updated")
+	})
+
+	s.Run("NotFound", func() {
+		webhook := &models.Webhook{Model: models.Model{ID: ulid.Make()}, URL: "https://example.com", Events: []enum.WebhookEvent{enum.WebhookEventLogin}}
+		err := s.db.UpdateWebhook(s.Context(), webhook)
+		s.Require().ErrorIs(err, errors.ErrNotFound)
+	})
+}
+
+func (s *storeTestSuite) TestDeleteWebhook() {
+	if s.ReadOnly() {
+		s.T().Skip("skipping delete test in read-only mode")
+	}
+
+	require := s.Require()
+	require.Equal(3, s.Count("webhook_deliveries"))
+
+	require.NoError(s.db.DeleteWebhook(s.Context(), userSyncWebhookID))
+	require.Equal(1, s.Count("webhooks"))
+	require.Equal(0, s.Count("webhook_deliveries"), "deliveries should be deleted with the webhook")
+
+	err := s.db.DeleteWebhook(s.Context(), userSyncWebhookID)
+	require.ErrorIs(err, errors.ErrNotFound)
+}
+
+func (s *storeTestSuite) TestEnqueueWebhookEvent() {
+	if s.ReadOnly() {
+		s.T().Skip("skipping enqueue test in read-only mode")
+	}
+
+	require := s.Require()
+
+	// Only the active user sync webhook is subscribed to user events.
+	eventID := ulid.MakeSecure()
+	n, err := s.db.EnqueueWebhookEvent(s.Context(), eventID, enum.WebhookEventUserUpdated, []byte(`{"event":"user.updated"}`))
+	require.NoError(err)
+	require.Equal(1, n)
+	require.Equal(4, s.Count("webhook_deliveries"))
+
+	// The logins webhook is inactive so no deliveries are queued.
+	n, err = s.db.EnqueueWebhookEvent(s.Context(), ulid.MakeSecure(), enum.WebhookEventLogin, []byte(`{"event":"login"}`))
+	require.NoError(err)
+	require.Zero(n)
+	require.Equal(4, s.Count("webhook_deliveries"))
+
+	due, err := s.db.ListDueWebhookDeliveries(s.Context(), time.Now().Add(time.Second), 10)
+	require.NoError(err)
+	require.Len(due, 2, "expected the fixture and the queued delivery to be due")
+	require.Equal(pendingDeliveryID, due[0].ID, "the longest due delivery should be first")
+	require.Equal(eventID, due[1].EventID)
+	require.Equal(userSyncWebhookID, due[1].WebhookID)
+	require.Equal(enum.DeliveryStatusPending, due[1].Status)
+}
+
+func (s *storeTestSuite) TestListWebhookDeliveries() {
+	s.Run("Success", func() {
+		require := s.Require()
+		out, err := s.db.ListWebhookDeliveries(s.Context(), userSyncWebhookID, nil)
+		require.NoError(err)
+		require.NotNil(out.Page)
+		require.Len(out.Deliveries, 3)
+		require.Equal(failedDeliveryID, out.Deliveries[0].ID, "deliveries should be most recent first")
+	})
+
+	s.Run("Empty", func() {
+		out, err := s.db.ListWebhookDeliveries(s.Context(), loginsWebhookID, nil)
+		s.Require().NoError(err)
+		s.Require().Empty(out.Deliveries)
+	})
+
+	s.Run("NotFound", func() {
+		_, err := s.db.ListWebhookDeliveries(s.Context(), ulid.Make(), nil)
+		s.Require().ErrorIs(err, errors.ErrNotFound)
+	})
+}
+
+func (s *storeTestSuite) TestListDueWebhookDeliveries() {
+	require := s.Require()
+	due, err := s.db.ListDueWebhookDeliveries(s.Context(), time.Now(), 10)
+	require.NoError(err)
+	require.Len(due, 1, "only the pending fixture delivery is due")
+	require.Equal(pendingDeliveryID, due[0].ID)
+	require.Equal(2, due[0].Attempts)
+	require.Equal(int64(503), due[0].ResponseCode.Int64)
+
+	due, err = s.db.ListDueWebhookDeliveries(s.Context(), time.Date(2025, 7, 4, 12, 0, 0, 0, time.UTC), 10)
+	require.NoError(err)
+	require.Empty(due, "no deliveries were due before the next attempt")
+}
+
+func (s *storeTestSuite) TestUpdateWebhookDelivery() {
+	if s.ReadOnly() {
+		s.T().Skip("skipping update test in read-only mode")
+	}
+
+	s.Run("Delivered", func() {
+		require := s.Require()
+		delivery, err := s.db.RetrieveWebhookDelivery(s.Context(), pendingDeliveryID)
+		require.NoError(err)
+
+		delivery.Record(204, nil, time.Minute, 8)
+		require.NoError(s.db.UpdateWebhookDelivery(s.Context(), delivery))
+
+		got, err := s.db.RetrieveWebhookDelivery(s.Context(), pendingDeliveryID)
+		require.NoError(err)
+		require.Equal(enum.DeliveryStatusDelivered, got.Status)
+		require.Equal(3, got.Attempts)
+		require.True(got.Delivered.Valid)
+		require.False(got.NextAttempt.Valid)
+		require.False(got.Error.Valid)
+
+		due, err := s.db.ListDueWebhookDeliveries(s.Context(), time.Now(), 10)
+		require.NoError(err)
+		require.Empty(due)
+	})
+
+	s.Run("Redelivery", func() {
+		require := s.Require()
+		delivery, err := s.db.RetrieveWebhookDelivery(s.Context(), failedDeliveryID)
+		require.NoError(err)
+		require.Equal(enum.DeliveryStatusFailed, delivery.Status)
+
+		redelivery := delivery.Redelivery()
+		require.NoError(s.db.CreateWebhookDelivery(s.Context(), redelivery))
+		require.NotEqual(delivery.ID, redelivery.ID)
+
+		got, err := s.db.RetrieveWebhookDelivery(s.Context(), redelivery.ID)
+		require.NoError(err)
+		require.Equal(delivery.EventID, got.EventID)
+		require.Equal(delivery.Payload, got.Payload)
+		require.Equal(enum.DeliveryStatusPending, got.Status)
+	})
+
+	s.Run("NotFound", func() {
+		err := s.db.UpdateWebhookDelivery(s.Context(), &models.WebhookDelivery{Model: models.Model{ID: ulid.Make()}})
+		s.Require().ErrorIs(err, errors.ErrNotFound)
+	})
+
+	s.Run("MissingReference", func() {
+		err := s.db.CreateWebhookDelivery(s.Context(), &models.WebhookDelivery{Event: enum.WebhookEventLogin})
+		s.Require().ErrorIs(err, errors.ErrMissingReference)
+	})
+}
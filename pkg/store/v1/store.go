@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"go.rtnl.ai/quarterdeck/pkg/config"
+	"go.rtnl.ai/quarterdeck/pkg/enum"
 	"go.rtnl.ai/quarterdeck/pkg/errors"
 	"go.rtnl.ai/quarterdeck/pkg/store/v1/dsn"
 	"go.rtnl.ai/quarterdeck/pkg/store/v1/mock"
@@ -51,9 +52,18 @@ type Store interface {
 	UserStore
 	RoleStore
 	PermissionStore
+	OrganizationStore
 	APIKeyStore
 	OIDCClientStore
+	AuthorizationCodeStore
 	VeroTokenStore
+	RevokedTokenStore
+	RefreshTokenStore
+	SessionStore
+	WebAuthnCredentialStore
+	LockoutStore
+	AuditEventStore
+	WebhookStore
 }
 
 // The Stats interface exposes database statistics if it is available from the backend.
@@ -69,7 +79,11 @@ type UserStore interface {
 	UpdatePassword(context.Context, ulid.ULID, string) error
 	UpdateLastLogin(context.Context, ulid.ULID, time.Time) error
 	VerifyEmail(context.Context, ulid.ULID) error
+	UpdateMFA(context.Context, *models.User) error
+	ReplaceUserRoles(context.Context, ulid.ULID, []int64) error
+	UpdateUserStatus(context.Context, ulid.ULID, enum.UserStatus) error
 	DeleteUser(context.Context, ulid.ULID) error
+	PurgeUsers(context.Context, time.Time) ([]ulid.ULID, error)
 }
 
 type RoleStore interface {
@@ -90,6 +104,18 @@ type PermissionStore interface {
 	DeletePermission(context.Context, int64) error
 }
 
+type OrganizationStore interface {
+	ListOrganizations(context.Context, *models.Page) (*models.OrganizationList, error)
+	CreateOrganization(context.Context, *models.Organization) error
+	RetrieveOrganization(context.Context, ulid.ULID) (*models.Organization, error)
+	UpdateOrganization(context.Context, *models.Organization) error
+	DeleteOrganization(context.Context, ulid.ULID) error
+	ListUserOrganizations(context.Context, ulid.ULID) ([]*models.Organization, error)
+	ListOrganizationMembers(context.Context, ulid.ULID) ([]*models.User, error)
+	RetrieveOrganizationMember(context.Context, ulid.ULID, ulid.ULID) (*models.User, error)
+	ReplaceOrganizationMemberRoles(context.Context, ulid.ULID, ulid.ULID, []int64) error
+}
+
 type APIKeyStore interface {
 	ListAPIKeys(context.Context, *models.Page) (*models.APIKeyList, error)
 	CreateAPIKey(context.Context, *models.APIKey) error
@@ -110,6 +136,12 @@ type OIDCClientStore interface {
 	DeleteOIDCClient(context.Context, ulid.ULID) error
 }
 
+type AuthorizationCodeStore interface {
+	CreateAuthorizationCode(context.Context, *models.AuthorizationCode) error
+	RetrieveAuthorizationCode(context.Context, string) (*models.AuthorizationCode, error)
+	DeleteAuthorizationCode(context.Context, ulid.ULID) error
+}
+
 type VeroTokenStore interface {
 	CreateVeroToken(context.Context, *models.VeroToken) error
 	RetrieveVeroToken(context.Context, ulid.ULID) (*models.VeroToken, error)
@@ -117,5 +149,63 @@ type VeroTokenStore interface {
 	DeleteVeroToken(context.Context, ulid.ULID) error
 	CreateResetPasswordVeroToken(context.Context, *models.VeroToken) error
 	CreateTeamInviteVeroToken(context.Context, *models.VeroToken) error
+	CreateVerifyEmailVeroToken(context.Context, *models.VeroToken) error
+	CreateChangeEmailVeroToken(context.Context, *models.VeroToken) error
+	CompleteEmailChange(context.Context, ulid.ULID) error
 	RetrieveTeamInviteVeroToken(context.Context, ulid.ULID) (*models.VeroToken, error)
+	ListTeamInviteVeroTokens(context.Context) ([]*models.VeroToken, error)
+}
+
+type RevokedTokenStore interface {
+	RevokeToken(context.Context, *models.RevokedToken) error
+	IsTokenRevoked(context.Context, ulid.ULID) (bool, error)
+}
+
+type RefreshTokenStore interface {
+	CreateRefreshToken(context.Context, *models.RefreshToken) error
+	RetrieveRefreshToken(context.Context, ulid.ULID) (*models.RefreshToken, error)
+	UseRefreshToken(context.Context, ulid.ULID) (*models.RefreshToken, error)
+	RevokeRefreshTokenFamily(context.Context, ulid.ULID) error
+}
+
+type SessionStore interface {
+	ListSessions(context.Context, ulid.ULID) (*models.SessionList, error)
+	CreateSession(context.Context, *models.Session) error
+	RetrieveSession(context.Context, ulid.ULID) (*models.Session, error)
+	RefreshSession(context.Context, ulid.ULID, ulid.ULID, time.Time) error
+	RevokeSession(context.Context, ulid.ULID) error
+	RevokeUserSessions(context.Context, ulid.ULID) error
+}
+
+type WebAuthnCredentialStore interface {
+	ListWebAuthnCredentials(context.Context, ulid.ULID) (*models.WebAuthnCredentialList, error)
+	CreateWebAuthnCredential(context.Context, *models.WebAuthnCredential) error
+	RetrieveWebAuthnCredential(context.Context, []byte) (*models.WebAuthnCredential, error)
+	UpdateWebAuthnCredential(context.Context, *models.WebAuthnCredential) error
+	DeleteWebAuthnCredential(context.Context, ulid.ULID) error
+}
+
+type LockoutStore interface {
+	RetrieveLockout(context.Context, string, string) (*models.Lockout, error)
+	RecordFailedAttempt(context.Context, string, string, models.LockoutPolicy) (*models.Lockout, error)
+	ResetLockout(context.Context, string, string) error
+}
+
+type AuditEventStore interface {
+	ListAuditEvents(context.Context, *models.AuditEventPage) (*models.AuditEventList, error)
+	CreateAuditEvent(context.Context, *models.AuditEvent) error
+}
+
+type WebhookStore interface {
+	ListWebhooks(context.Context, *models.Page) (*models.WebhookList, error)
+	CreateWebhook(context.Context, *models.Webhook) error
+	RetrieveWebhook(context.Context, ulid.ULID) (*models.Webhook, error)
+	UpdateWebhook(context.Context, *models.Webhook) error
+	DeleteWebhook(context.Context, ulid.ULID) error
+	EnqueueWebhookEvent(context.Context, ulid.ULID, enum.WebhookEvent, []byte) (int, error)
+	ListWebhookDeliveries(context.Context, ulid.ULID, *models.Page) (*models.WebhookDeliveryList, error)
+	CreateWebhookDelivery(context.Context, *models.WebhookDelivery) error
+	RetrieveWebhookDelivery(context.Context, ulid.ULID) (*models.WebhookDelivery, error)
+	UpdateWebhookDelivery(context.Context, *models.WebhookDelivery) error
+	ListDueWebhookDeliveries(context.Context, time.Time, int) ([]*models.WebhookDelivery, error)
 }
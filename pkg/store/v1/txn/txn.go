@@ -3,6 +3,7 @@ package txn
 import (
 	"time"
 
+	"go.rtnl.ai/quarterdeck/pkg/enum"
 	"go.rtnl.ai/quarterdeck/pkg/store/v1/models"
 	"go.rtnl.ai/ulid"
 )
@@ -19,9 +20,18 @@ type Txn interface {
 	UserTxn
 	RoleTxn
 	PermissionTxn
+	OrganizationTxn
 	APIKeyTxn
 	OIDCClientTxn
+	AuthorizationCodeTxn
 	VeroTokenTxn
+	RevokedTokenTxn
+	RefreshTokenTxn
+	SessionTxn
+	WebAuthnCredentialTxn
+	LockoutTxn
+	AuditEventTxn
+	WebhookTxn
 }
 
 type UserTxn interface {
@@ -32,7 +42,11 @@ type UserTxn interface {
 	UpdatePassword(ulid.ULID, string) error
 	UpdateLastLogin(ulid.ULID, time.Time) error
 	VerifyEmail(ulid.ULID) error
+	UpdateMFA(*models.User) error
+	ReplaceUserRoles(ulid.ULID, []int64) error
+	UpdateUserStatus(ulid.ULID, enum.UserStatus) error
 	DeleteUser(ulid.ULID) error
+	PurgeUsers(time.Time) ([]ulid.ULID, error)
 }
 
 type RoleTxn interface {
@@ -53,6 +67,18 @@ type PermissionTxn interface {
 	DeletePermission(int64) error
 }
 
+type OrganizationTxn interface {
+	ListOrganizations(*models.Page) (*models.OrganizationList, error)
+	CreateOrganization(*models.Organization) error
+	RetrieveOrganization(ulid.ULID) (*models.Organization, error)
+	UpdateOrganization(*models.Organization) error
+	DeleteOrganization(ulid.ULID) error
+	ListUserOrganizations(ulid.ULID) ([]*models.Organization, error)
+	ListOrganizationMembers(ulid.ULID) ([]*models.User, error)
+	RetrieveOrganizationMember(ulid.ULID, ulid.ULID) (*models.User, error)
+	ReplaceOrganizationMemberRoles(ulid.ULID, ulid.ULID, []int64) error
+}
+
 type APIKeyTxn interface {
 	ListAPIKeys(*models.Page) (*models.APIKeyList, error)
 	CreateAPIKey(*models.APIKey) error
@@ -73,6 +99,12 @@ type OIDCClientTxn interface {
 	DeleteOIDCClient(ulid.ULID) error
 }
 
+type AuthorizationCodeTxn interface {
+	CreateAuthorizationCode(*models.AuthorizationCode) error
+	RetrieveAuthorizationCode(string) (*models.AuthorizationCode, error)
+	DeleteAuthorizationCode(ulid.ULID) error
+}
+
 type VeroTokenTxn interface {
 	CreateVeroToken(*models.VeroToken) error
 	RetrieveVeroToken(ulid.ULID) (*models.VeroToken, error)
@@ -80,5 +112,63 @@ type VeroTokenTxn interface {
 	DeleteVeroToken(ulid.ULID) error
 	CreateResetPasswordVeroToken(*models.VeroToken) error
 	CreateTeamInviteVeroToken(*models.VeroToken) error
+	CreateVerifyEmailVeroToken(*models.VeroToken) error
+	CreateChangeEmailVeroToken(*models.VeroToken) error
+	CompleteEmailChange(ulid.ULID) error
 	RetrieveTeamInviteVeroToken(ulid.ULID) (*models.VeroToken, error)
+	ListTeamInviteVeroTokens() ([]*models.VeroToken, error)
+}
+
+type RevokedTokenTxn interface {
+	RevokeToken(*models.RevokedToken) error
+	IsTokenRevoked(ulid.ULID) (bool, error)
+}
+
+type RefreshTokenTxn interface {
+	CreateRefreshToken(*models.RefreshToken) error
+	RetrieveRefreshToken(ulid.ULID) (*models.RefreshToken, error)
+	UseRefreshToken(ulid.ULID) (*models.RefreshToken, error)
+	RevokeRefreshTokenFamily(ulid.ULID) error
+}
+
+type SessionTxn interface {
+	ListSessions(ulid.ULID) (*models.SessionList, error)
+	CreateSession(*models.Session) error
+	RetrieveSession(ulid.ULID) (*models.Session, error)
+	RefreshSession(ulid.ULID, ulid.ULID, time.Time) error
+	RevokeSession(ulid.ULID) error
+	RevokeUserSessions(ulid.ULID) error
+}
+
+type WebAuthnCredentialTxn interface {
+	ListWebAuthnCredentials(ulid.ULID) (*models.WebAuthnCredentialList, error)
+	CreateWebAuthnCredential(*models.WebAuthnCredential) error
+	RetrieveWebAuthnCredential([]byte) (*models.WebAuthnCredential, error)
+	UpdateWebAuthnCredential(*models.WebAuthnCredential) error
+	DeleteWebAuthnCredential(ulid.ULID) error
+}
+
+type LockoutTxn interface {
+	RetrieveLockout(string, string) (*models.Lockout, error)
+	RecordFailedAttempt(string, string, models.LockoutPolicy) (*models.Lockout, error)
+	ResetLockout(string, string) error
+}
+
+type AuditEventTxn interface {
+	ListAuditEvents(*models.AuditEventPage) (*models.AuditEventList, error)
+	CreateAuditEvent(*models.AuditEvent) error
+}
+
+type WebhookTxn interface {
+	ListWebhooks(*models.Page) (*models.WebhookList, error)
+	CreateWebhook(*models.Webhook) error
+	RetrieveWebhook(ulid.ULID) (*models.Webhook, error)
+	UpdateWebhook(*models.Webhook) error
+	DeleteWebhook(ulid.ULID) error
+	EnqueueWebhookEvent(ulid.ULID, enum.WebhookEvent, []byte) (int, error)
+	ListWebhookDeliveries(ulid.ULID, *models.Page) (*models.WebhookDeliveryList, error)
+	CreateWebhookDelivery(*models.WebhookDelivery) error
+	RetrieveWebhookDelivery(ulid.ULID) (*models.WebhookDelivery, error)
+	UpdateWebhookDelivery(*models.WebhookDelivery) error
+	ListDueWebhookDeliveries(time.Time, int) ([]*models.WebhookDelivery, error)
 }
@@ -17,7 +17,7 @@ var apiKeys = tidal.New[*models.APIKey]("api_keys")
 var apiKeyPermissions = tidal.New[*models.APIKeyPermission]("api_key_permissions")
 
 const (
-	apiKeyPermissionsSQL            = `SELECT p.id, p.title, p.description, p.created, p.modified FROM api_key_permissions akp JOIN permissions p ON p.id = akp.permission_id WHERE akp.api_key_id = :api_key_id ORDER BY p.title`
+	apiKeyPermissionsSQL            = `SELECT p.id, p.title, p.description, p.namespace, p.protected, p.created, p.modified FROM api_key_permissions akp JOIN permissions p ON p.id = akp.permission_id WHERE akp.api_key_id = :api_key_id ORDER BY p.title`
 	updateAPIKeyLastSeenSQL         = `UPDATE api_keys SET last_seen = :last_seen, modified = :modified WHERE id = :id`
 	deleteAPIKeyPermissionSQL       = `DELETE FROM api_key_permissions WHERE api_key_id = :api_key_id AND permission_id = :permission_id`
 	deleteAPIKeyPermissionsByKeySQL = `DELETE FROM api_key_permissions WHERE api_key_id = :api_key_id`
@@ -161,6 +161,9 @@ func (t *tx) CreateAPIKeyFor(key *models.APIKey, creator ulid.ULID) (*models.API
 		key.CreatedBy = parent.CreatedBy
 	}
 
+	// Delegated keys cannot escape the organization of the key that created them.
+	key.OrgID = parent.OrgID
+
 	return t.createAPIKey(key)
 }
 
@@ -0,0 +1,72 @@
+package backend
+
+import (
+	"context"
+	"database/sql"
+
+	qerrors "go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/txn"
+	"go.rtnl.ai/tidal"
+	"go.rtnl.ai/ulid"
+)
+
+var auditEvents = tidal.New[*models.AuditEvent]("audit_events")
+
+//===========================================================================
+// Store Methods
+//===========================================================================
+
+// ListAuditEvents returns a cursor over the audit log; events are immutable so there
+// are no update or delete methods. Use the filter to order the events (e.g. by -id for
+// the most recent first) and to page through them.
+func (s *Store) ListAuditEvents(ctx context.Context, filter tidal.ListFilter) (tidal.Cursor[*models.AuditEvent], error) {
+	return list(s, ctx, auditEvents, filter)
+}
+
+func (s *Store) CreateAuditEvent(ctx context.Context, event *models.AuditEvent) (*models.AuditEvent, error) {
+	var created *models.AuditEvent
+	err := s.WithTx(ctx, nil, func(t txn.Tx) (err error) {
+		created, err = t.CreateAuditEvent(event)
+		return err
+	})
+	return created, err
+}
+
+//===========================================================================
+// Tx Methods
+//===========================================================================
+
+// ListAuditEvents returns a cursor over audit events matching filter.
+// [tidal.Cursor.Close] rolls back the transaction; use [tidal.Cursor.CloseRows] to
+// release the result set and continue using this transaction.
+func (t *tx) ListAuditEvents(filter tidal.ListFilter) (tidal.Cursor[*models.AuditEvent], error) {
+	return listInTx(t, auditEvents, filter)
+}
+
+func (t *tx) CreateAuditEvent(event *models.AuditEvent) (*models.AuditEvent, error) {
+	if err := t.requireWrite(); err != nil {
+		return nil, err
+	}
+	if !event.ID.IsZero() {
+		return nil, qerrors.ErrNoIDOnCreate
+	}
+
+	if _, err := auditEvents.Create(t.tx, event); err != nil {
+		return nil, tidalErr(err)
+	}
+
+	return t.retrieveAuditEvent(event.ID)
+}
+
+//===========================================================================
+// Helpers
+//===========================================================================
+
+func (t *tx) retrieveAuditEvent(id ulid.ULID) (*models.AuditEvent, error) {
+	event, err := auditEvents.Retrieve(t.tx, sql.Named("id", id))
+	if err != nil {
+		return nil, tidalErr(err)
+	}
+	return event, nil
+}
@@ -0,0 +1,72 @@
+package backend_test
+
+import (
+	"database/sql"
+
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/tidal"
+	"go.rtnl.ai/ulid"
+)
+
+//=============================================================================
+// Audit Event Store Tests
+//=============================================================================
+
+// TestAuditEvents verifies audit events can be recorded and listed by subject.
+func (s *storeSuite) TestAuditEvents() {
+	require := s.Require()
+	actorID := ulid.MustParse(keyholderUserULID)
+	subjectID := ulid.MakeSecure().String()
+
+	// Assert: the ID is assigned by the store.
+	_, err := s.store.CreateAuditEvent(s.Context(), &models.AuditEvent{
+		BaseModel:   tidal.BaseModel{ID: ulid.MakeSecure()},
+		Action:      models.AuditCreate,
+		SubjectType: models.AuditAPIKey,
+		SubjectID:   subjectID,
+	})
+	require.ErrorIs(err, errors.ErrNoIDOnCreate)
+
+	// Setup: record an update by the user and a failed login by an anonymous actor.
+	created, err := s.store.CreateAuditEvent(s.Context(), &models.AuditEvent{
+		ActorType:   sql.NullString{String: models.AuditUser, Valid: true},
+		ActorID:     ulid.NullULID{ULID: actorID, Valid: true},
+		Action:      models.AuditUpdate,
+		SubjectType: models.AuditAPIKey,
+		SubjectID:   subjectID,
+		ClientIP:    sql.NullString{String: "127.0.0.1", Valid: true},
+		RequestID:   sql.NullString{String: "req-123", Valid: true},
+		Diff:        sql.NullString{String: `{"description": {"from": "foo", "to": "bar"}}`, Valid: true},
+	})
+	require.NoError(err)
+	require.False(created.ID.IsZero())
+	require.Equal(actorID, created.ActorID.ULID)
+	require.JSONEq(`{"description": {"from": "foo", "to": "bar"}}`, created.Diff.String)
+
+	_, err = s.store.CreateAuditEvent(s.Context(), &models.AuditEvent{
+		Action:      models.AuditLoginFailed,
+		SubjectType: models.AuditUser,
+		SubjectID:   actorID.String(),
+	})
+	require.NoError(err)
+
+	// Action: list the events of the API key.
+	cursor, err := s.store.ListAuditEvents(s.Context(), (&tidal.Filter{}).Where("subject_id", tidal.Eq, subjectID).OrderBy("-id"))
+	require.NoError(err)
+	events, err := cursor.List()
+	require.NoError(err)
+	require.NoError(cursor.Close())
+	require.Len(events, 1)
+	require.Equal(created.ID, events[0].ID)
+	require.Equal("req-123", events[0].RequestID.String)
+
+	// Action: list the anonymous events.
+	cursor, err = s.store.ListAuditEvents(s.Context(), (&tidal.Filter{}).Where("actor_id", tidal.IsNull, nil))
+	require.NoError(err)
+	events, err = cursor.List()
+	require.NoError(err)
+	require.NoError(cursor.Close())
+	require.Len(events, 1)
+	require.Equal(models.AuditLoginFailed, events[0].Action)
+}
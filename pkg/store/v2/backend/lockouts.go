@@ -0,0 +1,118 @@
+package backend
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	qerrors "go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/txn"
+	"go.rtnl.ai/tidal"
+)
+
+var lockouts = tidal.New[*models.Lockout]("lockouts")
+
+const (
+	retrieveLockoutSQL = `SELECT id, kind, identifier, failed_attempts, last_failure, locked_until, created, modified FROM lockouts WHERE kind = :kind AND identifier = :identifier`
+	resetLockoutSQL    = `DELETE FROM lockouts WHERE kind = :kind AND identifier = :identifier`
+)
+
+//===========================================================================
+// Store Methods
+//===========================================================================
+
+func (s *Store) RetrieveLockout(ctx context.Context, kind, identifier string) (*models.Lockout, error) {
+	var lockout *models.Lockout
+	err := s.WithReadTx(ctx, func(t txn.Tx) (err error) {
+		lockout, err = t.RetrieveLockout(kind, identifier)
+		return err
+	})
+	return lockout, err
+}
+
+func (s *Store) RecordFailedAttempt(ctx context.Context, kind, identifier string, policy models.LockoutPolicy) (*models.Lockout, error) {
+	var lockout *models.Lockout
+	err := s.WithTx(ctx, nil, func(t txn.Tx) (err error) {
+		lockout, err = t.RecordFailedAttempt(kind, identifier, policy)
+		return err
+	})
+	return lockout, err
+}
+
+func (s *Store) ResetLockout(ctx context.Context, kind, identifier string) error {
+	return s.WithTx(ctx, nil, func(t txn.Tx) error {
+		return t.ResetLockout(kind, identifier)
+	})
+}
+
+//===========================================================================
+// Tx Methods
+//===========================================================================
+
+// RetrieveLockout returns the failed attempts against the identifier; ErrNotFound is
+// returned if there have been no failed attempts since the last reset.
+func (t *tx) RetrieveLockout(kind, identifier string) (*models.Lockout, error) {
+	if kind == "" || identifier == "" {
+		return nil, qerrors.ErrMissingID
+	}
+
+	lockout := &models.Lockout{}
+	row := t.tx.QueryRow(
+		retrieveLockoutSQL,
+		sql.Named("kind", kind),
+		sql.Named("identifier", models.NormalizeIdentifier(kind, identifier)),
+	)
+	if err := lockout.Scan(tidal.Retrieve, row); err != nil {
+		return nil, tidalErr(err)
+	}
+	return lockout, nil
+}
+
+// RecordFailedAttempt increments the failed attempts against the identifier and locks
+// it out according to the policy. The lockout is read and written in the same
+// transaction so that concurrent attempts are all counted.
+func (t *tx) RecordFailedAttempt(kind, identifier string, policy models.LockoutPolicy) (*models.Lockout, error) {
+	if err := t.requireWrite(); err != nil {
+		return nil, err
+	}
+
+	lockout, err := t.RetrieveLockout(kind, identifier)
+	if err != nil {
+		if !qerrors.Is(err, qerrors.ErrNotFound) {
+			return nil, err
+		}
+
+		lockout = &models.Lockout{Kind: kind, Identifier: models.NormalizeIdentifier(kind, identifier)}
+		lockout.Fail(policy)
+		if _, err = lockouts.Create(t.tx, lockout); err != nil {
+			return nil, tidalErr(err)
+		}
+		return lockout, nil
+	}
+
+	lockout.Fail(policy)
+	lockout.Modified = time.Now().UTC()
+	if err = lockouts.Update(t.tx, lockout); err != nil {
+		return nil, tidalErr(err)
+	}
+	return lockout, nil
+}
+
+// ResetLockout clears the failed attempts against the identifier, unlocking it if it
+// is locked. Resetting an identifier without any failed attempts is not an error.
+func (t *tx) ResetLockout(kind, identifier string) error {
+	if err := t.requireWrite(); err != nil {
+		return err
+	}
+	if kind == "" || identifier == "" {
+		return qerrors.ErrMissingID
+	}
+
+	_, err := t.tx.Exec(
+		resetLockoutSQL,
+		sql.Named("kind", kind),
+		sql.Named("identifier", models.NormalizeIdentifier(kind, identifier)),
+	)
+	return tidalErr(err)
+}
@@ -0,0 +1,47 @@
+package backend_test
+
+import (
+	"time"
+
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+)
+
+//=============================================================================
+// Lockout Store Tests
+//=============================================================================
+
+// TestLockouts verifies failed attempts are counted per normalized identifier and can be reset.
+func (s *storeSuite) TestLockouts() {
+	require := s.Require()
+	policy := models.LockoutPolicy{Threshold: 3, Duration: time.Minute, MaxDuration: time.Hour}
+
+	_, err := s.store.RetrieveLockout(s.Context(), models.LockoutUser, "gary@example.com")
+	require.ErrorIs(err, errors.ErrNotFound)
+
+	// Action: fail to authenticate with variations of the same email address.
+	for i, email := range []string{"gary@example.com", " Gary@Example.com", "GARY@example.com"} {
+		lockout, err := s.store.RecordFailedAttempt(s.Context(), models.LockoutUser, email, policy)
+		require.NoError(err)
+		require.Equal(int64(i+1), lockout.FailedAttempts)
+		require.Equal("gary@example.com", lockout.Identifier)
+	}
+
+	// Assert: the threshold locks out the identifier.
+	lockout, err := s.store.RetrieveLockout(s.Context(), models.LockoutUser, "gary@example.com")
+	require.NoError(err)
+	require.Equal(int64(3), lockout.FailedAttempts)
+	require.True(lockout.IsLocked())
+
+	// Client IDs are tracked separately from email addresses.
+	_, err = s.store.RetrieveLockout(s.Context(), models.LockoutClient, "gary@example.com")
+	require.ErrorIs(err, errors.ErrNotFound)
+
+	// Assert: resetting the lockout clears the failed attempts; resetting twice is not an error.
+	require.NoError(s.store.ResetLockout(s.Context(), models.LockoutUser, "Gary@example.com"))
+	require.NoError(s.store.ResetLockout(s.Context(), models.LockoutUser, "gary@example.com"))
+	_, err = s.store.RetrieveLockout(s.Context(), models.LockoutUser, "gary@example.com")
+	require.ErrorIs(err, errors.ErrNotFound)
+
+	require.ErrorIs(s.store.ResetLockout(s.Context(), models.LockoutUser, ""), errors.ErrMissingID)
+}
@@ -0,0 +1,206 @@
+package backend
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	qerrors "go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/txn"
+	"go.rtnl.ai/tidal"
+	"go.rtnl.ai/ulid"
+)
+
+var authorizationCodes = tidal.New[*models.AuthorizationCode]("oauth_authorization_codes")
+
+// Revoked tokens are keyed by the jti of the revoked tokens rather than a generated ID
+// and revoking a token twice is not an error, so they are inserted directly.
+const (
+	deleteExpiredAuthCodesSQL = `DELETE FROM oauth_authorization_codes WHERE expiration < :now`
+	revokeTokenSQL            = `INSERT INTO revoked_tokens (id, expiration, created, modified) VALUES (:id, :expiration, :created, :modified) ON CONFLICT (id) DO NOTHING`
+	deleteExpiredRevokedSQL   = `DELETE FROM revoked_tokens WHERE expiration < :now`
+	isTokenRevokedSQL         = `SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE id = :id)`
+)
+
+//===========================================================================
+// Store Methods
+//===========================================================================
+
+func (s *Store) CreateAuthorizationCode(ctx context.Context, code *models.AuthorizationCode) (*models.AuthorizationCode, error) {
+	var created *models.AuthorizationCode
+	err := s.WithTx(ctx, nil, func(t txn.Tx) (err error) {
+		created, err = t.CreateAuthorizationCode(code)
+		return err
+	})
+	return created, err
+}
+
+func (s *Store) RetrieveAuthorizationCode(ctx context.Context, code string) (*models.AuthorizationCode, error) {
+	var out *models.AuthorizationCode
+	err := s.WithReadTx(ctx, func(t txn.Tx) (err error) {
+		out, err = t.RetrieveAuthorizationCode(code)
+		return err
+	})
+	return out, err
+}
+
+func (s *Store) DeleteAuthorizationCode(ctx context.Context, id ulid.ULID) error {
+	return s.WithTx(ctx, nil, func(t txn.Tx) error {
+		return t.DeleteAuthorizationCode(id)
+	})
+}
+
+func (s *Store) RevokeToken(ctx context.Context, token *models.RevokedToken) error {
+	return s.WithTx(ctx, nil, func(t txn.Tx) error {
+		return t.RevokeToken(token)
+	})
+}
+
+func (s *Store) ConsumeToken(ctx context.Context, token *models.RevokedToken) error {
+	return s.WithTx(ctx, nil, func(t txn.Tx) error {
+		return t.ConsumeToken(token)
+	})
+}
+
+func (s *Store) IsTokenRevoked(ctx context.Context, jti ulid.ULID) (bool, error) {
+	var revoked bool
+	err := s.WithReadTx(ctx, func(t txn.Tx) (err error) {
+		revoked, err = t.IsTokenRevoked(jti)
+		return err
+	})
+	return revoked, err
+}
+
+//===========================================================================
+// Tx Methods
+//===========================================================================
+
+func (t *tx) CreateAuthorizationCode(code *models.AuthorizationCode) (*models.AuthorizationCode, error) {
+	if err := t.requireWrite(); err != nil {
+		return nil, err
+	}
+	if !code.ID.IsZero() {
+		return nil, qerrors.ErrNoIDOnCreate
+	}
+	if code.Code == "" || code.ClientID == "" || code.UserID.IsZero() || code.RedirectURI == "" {
+		return nil, qerrors.ErrZeroValuedNotNull
+	}
+
+	if _, err := authorizationCodes.Create(t.tx, code); err != nil {
+		return nil, tidalErr(err)
+	}
+
+	return t.retrieveAuthorizationCode(code.ID)
+}
+
+// RetrieveAuthorizationCode looks up an authorization code by the hash of the code, not the model ID.
+func (t *tx) RetrieveAuthorizationCode(code string) (*models.AuthorizationCode, error) {
+	if code == "" {
+		return nil, qerrors.ErrMissingID
+	}
+	return retrieveBy(t, authorizationCodes, "code", code)
+}
+
+// DeleteAuthorizationCode deletes the code once it has been exchanged; any expired
+// codes are cleaned up at the same time.
+func (t *tx) DeleteAuthorizationCode(id ulid.ULID) error {
+	if err := t.requireWrite(); err != nil {
+		return err
+	}
+	if id.IsZero() {
+		return qerrors.ErrMissingID
+	}
+
+	result, err := authorizationCodes.Delete(t.tx, sql.Named("id", id))
+	if err != nil {
+		return tidalErr(err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return qerrors.ErrNotFound
+	}
+
+	if _, err = t.tx.Exec(deleteExpiredAuthCodesSQL, sql.Named("now", time.Now().UTC())); err != nil {
+		return tidalErr(err)
+	}
+	return nil
+}
+
+// RevokeToken adds the jti to the denylist until the tokens expire; revoking a token
+// that is already revoked is not an error. Expired entries are cleaned up at the same time.
+func (t *tx) RevokeToken(token *models.RevokedToken) error {
+	_, err := t.revokeToken(token)
+	return err
+}
+
+// ConsumeToken adds the jti of a single-use token to the denylist until it expires. If
+// the jti is already on the denylist then the token has been used and ErrTokenRevoked
+// is returned; the insert is conditional so only one concurrent request can succeed.
+func (t *tx) ConsumeToken(token *models.RevokedToken) error {
+	revoked, err := t.revokeToken(token)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return qerrors.ErrTokenRevoked
+	}
+	return nil
+}
+
+func (t *tx) IsTokenRevoked(jti ulid.ULID) (revoked bool, err error) {
+	if err = t.tx.QueryRow(isTokenRevokedSQL, sql.Named("id", jti)).Scan(&revoked); err != nil {
+		return false, tidalErr(err)
+	}
+	return revoked, nil
+}
+
+//===========================================================================
+// Helpers
+//===========================================================================
+
+// revokeToken inserts the jti into the denylist and returns false if it was already
+// denylisted. Expired entries are cleaned up at the same time.
+func (t *tx) revokeToken(token *models.RevokedToken) (revoked bool, err error) {
+	if err = t.requireWrite(); err != nil {
+		return false, err
+	}
+	if token.ID.IsZero() {
+		return false, qerrors.ErrMissingID
+	}
+	if token.Expiration.IsZero() {
+		return false, qerrors.ErrZeroValuedNotNull
+	}
+
+	now := time.Now().UTC()
+	token.Created = now
+	token.Modified = now
+
+	params := token.Params(tidal.Create)
+	args := make([]any, len(params))
+	for i, param := range params {
+		args[i] = param
+	}
+
+	var result sql.Result
+	if result, err = t.tx.Exec(revokeTokenSQL, args...); err != nil {
+		return false, tidalErr(err)
+	}
+
+	var rows int64
+	if rows, err = result.RowsAffected(); err != nil {
+		return false, tidalErr(err)
+	}
+
+	if _, err = t.tx.Exec(deleteExpiredRevokedSQL, sql.Named("now", now)); err != nil {
+		return false, tidalErr(err)
+	}
+	return rows > 0, nil
+}
+
+func (t *tx) retrieveAuthorizationCode(id ulid.ULID) (*models.AuthorizationCode, error) {
+	code, err := authorizationCodes.Retrieve(t.tx, sql.Named("id", id))
+	if err != nil {
+		return nil, tidalErr(err)
+	}
+	return code, nil
+}
@@ -0,0 +1,131 @@
+package backend_test
+
+import (
+	"database/sql"
+	"time"
+
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/ulid"
+)
+
+//=============================================================================
+// Authorization Code Store Tests
+//=============================================================================
+
+// TestAuthorizationCodes verifies codes are created, retrieved by hash, and deleted once exchanged.
+func (s *storeSuite) TestAuthorizationCodes() {
+	require := s.Require()
+	userID := ulid.MustParse("01JN2YQ2VE9GMBRVACD15J1TFX")
+
+	// Setup: an expired code that should be cleaned up when another code is deleted.
+	expired, err := s.store.CreateAuthorizationCode(s.Context(), &models.AuthorizationCode{
+		Code:        "expired-hash",
+		ClientID:    "OidcClient2Minimal",
+		UserID:      userID,
+		RedirectURI: "https://example.com/cb",
+		Expiration:  time.Now().Add(-time.Minute),
+	})
+	require.NoError(err)
+
+	created, err := s.store.CreateAuthorizationCode(s.Context(), &models.AuthorizationCode{
+		Code:          "code-hash",
+		ClientID:      "OidcClient2Minimal",
+		UserID:        userID,
+		RedirectURI:   "https://example.com/cb",
+		Scope:         sql.NullString{String: "openid email", Valid: true},
+		CodeChallenge: sql.NullString{String: "challenge", Valid: true},
+		Expiration:    time.Now().Add(10 * time.Minute),
+	})
+	require.NoError(err)
+	require.False(created.ID.IsZero())
+
+	// Action: retrieve the code by its hash.
+	retrieved, err := s.store.RetrieveAuthorizationCode(s.Context(), "code-hash")
+	require.NoError(err)
+	require.Equal(created.ID, retrieved.ID)
+	require.True(retrieved.HasScope("email"))
+	require.Equal("challenge", retrieved.CodeChallenge.String)
+	require.False(retrieved.IsExpired())
+
+	// Assert: deleting the code also deletes expired codes; codes can only be deleted once.
+	require.NoError(s.store.DeleteAuthorizationCode(s.Context(), created.ID))
+	_, err = s.store.RetrieveAuthorizationCode(s.Context(), "code-hash")
+	require.ErrorIs(err, errors.ErrNotFound)
+	_, err = s.store.RetrieveAuthorizationCode(s.Context(), expired.Code)
+	require.ErrorIs(err, errors.ErrNotFound)
+
+	require.ErrorIs(s.store.DeleteAuthorizationCode(s.Context(), created.ID), errors.ErrNotFound)
+}
+
+// TestCreateAuthorizationCode verifies required fields and caller-supplied IDs are rejected.
+func (s *storeSuite) TestCreateAuthorizationCode() {
+	require := s.Require()
+
+	code := &models.AuthorizationCode{Code: "hash", ClientID: "OidcClient2Minimal", RedirectURI: "https://example.com/cb"}
+	_, err := s.store.CreateAuthorizationCode(s.Context(), code)
+	require.ErrorIs(err, errors.ErrZeroValuedNotNull)
+
+	code.UserID = ulid.MustParse("01JN2YQ2VE9GMBRVACD15J1TFX")
+	code.ID = ulid.MakeSecure()
+	_, err = s.store.CreateAuthorizationCode(s.Context(), code)
+	require.ErrorIs(err, errors.ErrNoIDOnCreate)
+}
+
+//=============================================================================
+// Revoked Token Store Tests
+//=============================================================================
+
+// TestRevokeToken verifies revoked tokens are denylisted and revoking twice is not an error.
+func (s *storeSuite) TestRevokeToken() {
+	require := s.Require()
+
+	token := &models.RevokedToken{Expiration: time.Now().Add(time.Hour)}
+	token.ID = ulid.MakeSecure()
+
+	revoked, err := s.store.IsTokenRevoked(s.Context(), token.ID)
+	require.NoError(err)
+	require.False(revoked)
+
+	require.NoError(s.store.RevokeToken(s.Context(), token))
+	require.NoError(s.store.RevokeToken(s.Context(), token))
+
+	revoked, err = s.store.IsTokenRevoked(s.Context(), token.ID)
+	require.NoError(err)
+	require.True(revoked)
+
+	require.ErrorIs(s.store.RevokeToken(s.Context(), &models.RevokedToken{Expiration: time.Now()}), errors.ErrMissingID)
+}
+
+// TestConsumeToken verifies a single-use token can only be consumed once.
+func (s *storeSuite) TestConsumeToken() {
+	require := s.Require()
+
+	token := &models.RevokedToken{Expiration: time.Now().Add(time.Hour)}
+	token.ID = ulid.MakeSecure()
+
+	require.NoError(s.store.ConsumeToken(s.Context(), token))
+	require.ErrorIs(s.store.ConsumeToken(s.Context(), token), errors.ErrTokenRevoked)
+
+	revoked, err := s.store.IsTokenRevoked(s.Context(), token.ID)
+	require.NoError(err)
+	require.True(revoked)
+
+	require.ErrorIs(s.store.ConsumeToken(s.Context(), &models.RevokedToken{Expiration: time.Now()}), errors.ErrMissingID)
+}
+
+// TestRevokeRefreshTokenFamilyDenylist verifies revoking a family denylists its unexpired tokens.
+func (s *storeSuite) TestRevokeRefreshTokenFamilyDenylist() {
+	require := s.Require()
+
+	token := &models.RefreshToken{Subject: "u01JPYRNYMEHNEZCS0JYX1CP57A", Expiration: time.Now().Add(time.Hour)}
+	token.ID = ulid.MakeSecure()
+	_, err := s.store.CreateRefreshToken(s.Context(), token)
+	require.NoError(err)
+
+	require.NoError(s.store.RevokeRefreshTokenFamily(s.Context(), token.ID))
+
+	revoked, err := s.store.IsTokenRevoked(s.Context(), token.ID)
+	require.NoError(err)
+	require.True(revoked, "the access token issued with the refresh token should be revoked")
+}
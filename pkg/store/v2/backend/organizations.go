@@ -0,0 +1,337 @@
+package backend
+
+import (
+	"context"
+	"database/sql"
+
+	qerrors "go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/txn"
+	"go.rtnl.ai/tidal"
+	"go.rtnl.ai/ulid"
+)
+
+var organizations = tidal.New[*models.Organization]("organizations")
+var organizationMembers = tidal.New[*models.OrganizationMember]("organization_members")
+
+const (
+	userOrganizationsSQL        = `SELECT o.id, o.name, o.street_address, o.homepage_uri, o.support_email, o.created, o.modified FROM organizations o WHERE o.id IN (SELECT organization_id FROM organization_members WHERE user_id = :user_id) ORDER BY o.created`
+	organizationMembersSQL      = `SELECT u.id, u.name, u.email, u.last_login, u.email_verified, u.created, u.modified, u.status, u.deleted FROM users u WHERE u.id IN (SELECT user_id FROM organization_members WHERE organization_id = :organization_id) AND u.status <> 'deleted' ORDER BY u.created DESC`
+	memberRolesSQL              = `SELECT r.id, r.title, r.description, r.is_default, r.created, r.modified FROM organization_members om JOIN roles r ON om.role_id = r.id WHERE om.organization_id = :organization_id AND om.user_id = :user_id`
+	memberPermissionsSQL        = `SELECT DISTINCT p.id, p.title, p.description, p.namespace, p.protected, p.created, p.modified FROM organization_member_permissions op JOIN permissions p ON p.title = op.permission WHERE op.organization_id = :organization_id AND op.user_id = :user_id ORDER BY p.title`
+	deleteOrganizationMemberSQL = `DELETE FROM organization_members WHERE organization_id = :organization_id AND user_id = :user_id`
+)
+
+//===========================================================================
+// Store Methods
+//===========================================================================
+
+func (s *Store) ListOrganizations(ctx context.Context, filter tidal.ListFilter) (tidal.Cursor[*models.Organization], error) {
+	return list(s, ctx, organizations, filter)
+}
+
+func (s *Store) CreateOrganization(ctx context.Context, org *models.Organization) (*models.Organization, error) {
+	var created *models.Organization
+	err := s.WithTx(ctx, nil, func(t txn.Tx) (err error) {
+		created, err = t.CreateOrganization(org)
+		return err
+	})
+	return created, err
+}
+
+func (s *Store) RetrieveOrganization(ctx context.Context, id ulid.ULID) (*models.Organization, error) {
+	var org *models.Organization
+	err := s.WithReadTx(ctx, func(t txn.Tx) (err error) {
+		org, err = t.RetrieveOrganization(id)
+		return err
+	})
+	return org, err
+}
+
+func (s *Store) UpdateOrganization(ctx context.Context, org *models.Organization) error {
+	return s.WithTx(ctx, nil, func(t txn.Tx) error {
+		return t.UpdateOrganization(org)
+	})
+}
+
+func (s *Store) DeleteOrganization(ctx context.Context, id ulid.ULID) error {
+	return s.WithTx(ctx, nil, func(t txn.Tx) error {
+		return t.DeleteOrganization(id)
+	})
+}
+
+func (s *Store) ListUserOrganizations(ctx context.Context, userID ulid.ULID) ([]*models.Organization, error) {
+	var orgs []*models.Organization
+	err := s.WithReadTx(ctx, func(t txn.Tx) (err error) {
+		orgs, err = t.ListUserOrganizations(userID)
+		return err
+	})
+	return orgs, err
+}
+
+func (s *Store) ListOrganizationMembers(ctx context.Context, orgID ulid.ULID) ([]*models.User, error) {
+	var members []*models.User
+	err := s.WithReadTx(ctx, func(t txn.Tx) (err error) {
+		members, err = t.ListOrganizationMembers(orgID)
+		return err
+	})
+	return members, err
+}
+
+func (s *Store) RetrieveOrganizationMember(ctx context.Context, orgID, userID ulid.ULID) (*models.User, error) {
+	var member *models.User
+	err := s.WithReadTx(ctx, func(t txn.Tx) (err error) {
+		member, err = t.RetrieveOrganizationMember(orgID, userID)
+		return err
+	})
+	return member, err
+}
+
+func (s *Store) ReplaceOrganizationMemberRoles(ctx context.Context, orgID, userID ulid.ULID, roleIDs []int64) error {
+	return s.WithTx(ctx, nil, func(t txn.Tx) error {
+		return t.ReplaceOrganizationMemberRoles(orgID, userID, roleIDs)
+	})
+}
+
+//===========================================================================
+// Tx Methods
+//===========================================================================
+
+// ListOrganizations returns a cursor over organizations matching filter. [tidal.Cursor.Close]
+// rolls back the transaction; use [tidal.Cursor.CloseRows] to release the result set and
+// continue using this transaction.
+func (t *tx) ListOrganizations(filter tidal.ListFilter) (tidal.Cursor[*models.Organization], error) {
+	return listInTx(t, organizations, filter)
+}
+
+func (t *tx) CreateOrganization(org *models.Organization) (*models.Organization, error) {
+	if err := t.requireWrite(); err != nil {
+		return nil, err
+	}
+	if !org.ID.IsZero() {
+		return nil, qerrors.ErrNoIDOnCreate
+	}
+
+	if _, err := organizations.Create(t.tx, org); err != nil {
+		return nil, tidalErr(err)
+	}
+
+	return t.RetrieveOrganization(org.ID)
+}
+
+func (t *tx) RetrieveOrganization(id ulid.ULID) (*models.Organization, error) {
+	org, err := organizations.Retrieve(t.tx, sql.Named("id", id))
+	return org, tidalErr(err)
+}
+
+func (t *tx) UpdateOrganization(org *models.Organization) error {
+	if err := t.requireWrite(); err != nil {
+		return err
+	}
+	return tidalErr(organizations.Update(t.tx, org))
+}
+
+// DeleteOrganization removes the organization along with its memberships, API keys,
+// and OIDC clients (which are deleted by the foreign key cascade).
+func (t *tx) DeleteOrganization(id ulid.ULID) error {
+	if err := t.requireWrite(); err != nil {
+		return err
+	}
+	if id.IsZero() {
+		return qerrors.ErrMissingID
+	}
+	result, err := organizations.Delete(t.tx, sql.Named("id", id))
+	if err != nil {
+		return tidalErr(err)
+	}
+	n, _ := result.RowsAffected()
+	if n == 0 {
+		return qerrors.ErrNotFound
+	}
+	return nil
+}
+
+// ListUserOrganizations returns the organizations the user is a member of in the
+// order that the organizations were created.
+func (t *tx) ListUserOrganizations(userID ulid.ULID) ([]*models.Organization, error) {
+	rows, err := t.tx.Query(userOrganizationsSQL, sql.Named("user_id", userID))
+	if err != nil {
+		return nil, tidalErr(err)
+	}
+	defer rows.Close()
+
+	orgs := make([]*models.Organization, 0)
+	for rows.Next() {
+		org := &models.Organization{}
+		if err = org.Scan(tidal.Retrieve, rows); err != nil {
+			return nil, tidalErr(err)
+		}
+		orgs = append(orgs, org)
+	}
+	return orgs, tidalErr(rows.Err())
+}
+
+// ListOrganizationMembers returns the users who are members of the organization; the
+// roles and permissions of each user are only the ones they have in the organization.
+func (t *tx) ListOrganizationMembers(orgID ulid.ULID) ([]*models.User, error) {
+	if _, err := t.RetrieveOrganization(orgID); err != nil {
+		return nil, err
+	}
+
+	rows, err := t.tx.Query(organizationMembersSQL, sql.Named("organization_id", orgID))
+	if err != nil {
+		return nil, tidalErr(err)
+	}
+	defer rows.Close()
+
+	members := make([]*models.User, 0)
+	for rows.Next() {
+		member := &models.User{OrgID: orgID}
+		if err = member.Scan(tidal.List, rows); err != nil {
+			return nil, tidalErr(err)
+		}
+		members = append(members, member)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, tidalErr(err)
+	}
+	rows.Close()
+
+	for _, member := range members {
+		if member.Roles, err = t.memberRoles(orgID, member.ID); err != nil {
+			return nil, err
+		}
+		if member.Permissions, err = t.memberPermissions(orgID, member.ID); err != nil {
+			return nil, err
+		}
+	}
+	return members, nil
+}
+
+// RetrieveOrganizationMember returns the user with the roles and permissions they have
+// in the organization in addition to their global roles and permissions. If the user
+// is not a member of the organization then ErrNotFound is returned.
+func (t *tx) RetrieveOrganizationMember(orgID, userID ulid.ULID) (*models.User, error) {
+	user, err := t.retrieveUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	roles, err := t.memberRoles(orgID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(roles) == 0 {
+		return nil, qerrors.ErrNotFound
+	}
+
+	perms, err := t.memberPermissions(orgID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, role := range roles {
+		if !hasRole(user.Roles, role.ID) {
+			user.Roles = append(user.Roles, role)
+		}
+	}
+
+	for _, perm := range perms {
+		if !hasPermission(user.Permissions, perm.ID) {
+			user.Permissions = append(user.Permissions, perm)
+		}
+	}
+
+	user.OrgID = orgID
+	return user, nil
+}
+
+// ReplaceOrganizationMemberRoles replaces the roles the user has in the organization;
+// if no roles are specified then the user is removed from the organization.
+func (t *tx) ReplaceOrganizationMemberRoles(orgID, userID ulid.ULID, roleIDs []int64) error {
+	if err := t.requireWrite(); err != nil {
+		return err
+	}
+	if orgID.IsZero() || userID.IsZero() {
+		return qerrors.ErrMissingID
+	}
+
+	// Ensure the organization and user exist so unknown IDs are not silently ignored.
+	if _, err := t.RetrieveOrganization(orgID); err != nil {
+		return err
+	}
+	if _, err := users.Retrieve(t.tx, sql.Named("id", userID)); err != nil {
+		return tidalErr(err)
+	}
+
+	if _, err := t.tx.Exec(deleteOrganizationMemberSQL, sql.Named("organization_id", orgID), sql.Named("user_id", userID)); err != nil {
+		return tidalErr(err)
+	}
+
+	for _, roleID := range roleIDs {
+		junction := &models.OrganizationMember{OrganizationID: orgID, UserID: userID, RoleID: roleID}
+		if _, err := organizationMembers.Create(t.tx, junction); err != nil {
+			return tidalErr(err)
+		}
+	}
+	return nil
+}
+
+//===========================================================================
+// Helpers
+//===========================================================================
+
+func (t *tx) memberRoles(orgID, userID ulid.ULID) ([]models.Role, error) {
+	rows, err := t.tx.Query(memberRolesSQL, sql.Named("organization_id", orgID), sql.Named("user_id", userID))
+	if err != nil {
+		return nil, tidalErr(err)
+	}
+	defer rows.Close()
+
+	roles := make([]models.Role, 0)
+	for rows.Next() {
+		role := models.Role{}
+		if err = role.Scan(tidal.Retrieve, rows); err != nil {
+			return nil, tidalErr(err)
+		}
+		roles = append(roles, role)
+	}
+	return roles, tidalErr(rows.Err())
+}
+
+func (t *tx) memberPermissions(orgID, userID ulid.ULID) ([]models.Permission, error) {
+	rows, err := t.tx.Query(memberPermissionsSQL, sql.Named("organization_id", orgID), sql.Named("user_id", userID))
+	if err != nil {
+		return nil, tidalErr(err)
+	}
+	defer rows.Close()
+
+	permissions := make([]models.Permission, 0)
+	for rows.Next() {
+		permission := models.Permission{}
+		if err = permission.Scan(tidal.Retrieve, rows); err != nil {
+			return nil, tidalErr(err)
+		}
+		permissions = append(permissions, permission)
+	}
+	return permissions, tidalErr(rows.Err())
+}
+
+func hasRole(roles []models.Role, id int64) bool {
+	for _, role := range roles {
+		if role.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func hasPermission(permissions []models.Permission, id int64) bool {
+	for _, permission := range permissions {
+		if permission.ID == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,164 @@
+package backend_test
+
+import (
+	"database/sql"
+
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/ulid"
+)
+
+var (
+	// Fixture organizations and members from suitetest/testdata/0005_organizations.sql.
+	acmeOrgID    = ulid.MustParse("01KC0020000000000000000001")
+	globexOrgID  = ulid.MustParse("01KC0040000000000000000002")
+	viewerUserID = ulid.MustParse("01JVWFBDXBNG6JNQZ36K2A8RT3") // keyholder in Acme, editor in Globex
+	editorUserID = ulid.MustParse("01JQNPQ1CHG36SV7NRQKTZB20R") // viewer in Acme
+)
+
+//=============================================================================
+// Organization Store Tests
+//=============================================================================
+
+// TestListOrganizations verifies the list cursor returns both fixture organizations.
+func (s *storeSuite) TestListOrganizations() {
+	require := s.Require()
+	cursor, err := s.store.ListOrganizations(s.Context(), nil)
+	require.NoError(err)
+	defer func() { require.NoError(cursor.Close()) }()
+
+	orgs, err := cursor.List()
+	require.NoError(err)
+	require.Len(orgs, 2)
+}
+
+// TestOrganizationCRUD verifies create, retrieve, update, and delete of an organization.
+func (s *storeSuite) TestOrganizationCRUD() {
+	require := s.Require()
+
+	_, err := s.store.CreateOrganization(s.Context(), &models.Organization{})
+	require.ErrorIs(err, errors.ErrZeroValuedNotNull, "name is required")
+
+	created, err := s.store.CreateOrganization(s.Context(), &models.Organization{
+		Name:         "Initech",
+		SupportEmail: sql.NullString{Valid: true, String: "help@initech.example.com"},
+	})
+	require.NoError(err)
+	require.False(created.ID.IsZero())
+	require.Equal("help@initech.example.com", created.SupportEmail.String)
+
+	created.HomepageURI = sql.NullString{Valid: true, String: "https://initech.example.com"}
+	require.NoError(s.store.UpdateOrganization(s.Context(), created))
+
+	got, err := s.store.RetrieveOrganization(s.Context(), created.ID)
+	require.NoError(err)
+	require.Equal("https://initech.example.com", got.HomepageURI.String)
+
+	require.NoError(s.store.DeleteOrganization(s.Context(), created.ID))
+	_, err = s.store.RetrieveOrganization(s.Context(), created.ID)
+	require.ErrorIs(err, errors.ErrNotFound)
+	require.ErrorIs(s.store.DeleteOrganization(s.Context(), created.ID), errors.ErrNotFound)
+}
+
+// TestDeleteOrganizationCascade verifies that keys, clients, and memberships are deleted with the organization.
+func (s *storeSuite) TestDeleteOrganizationCascade() {
+	require := s.Require()
+	nKeys := s.count("api_keys")
+	nClients := s.count("oidc_clients")
+	nMembers := s.count("organization_members")
+
+	require.NoError(s.store.DeleteOrganization(s.Context(), acmeOrgID))
+	require.Equal(nKeys-1, s.count("api_keys"))
+	require.Equal(nClients-1, s.count("oidc_clients"))
+	require.Equal(nMembers-2, s.count("organization_members"))
+}
+
+// TestOrganizationScopedResources verifies fixture keys and clients carry their organization.
+func (s *storeSuite) TestOrganizationScopedResources() {
+	require := s.Require()
+
+	key, err := s.store.RetrieveAPIKeyByClientID(s.Context(), "ISoIuDiGkpVpAyCrLGYrKU")
+	require.NoError(err)
+	require.True(key.OrgID.Valid)
+	require.Equal(acmeOrgID, key.OrgID.ULID)
+
+	client, err := s.store.RetrieveOIDCClientByClientID(s.Context(), minimalClientID)
+	require.NoError(err)
+	require.False(client.OrgID.Valid)
+}
+
+// TestListUserOrganizations verifies memberships are listed in creation order.
+func (s *storeSuite) TestListUserOrganizations() {
+	require := s.Require()
+	orgs, err := s.store.ListUserOrganizations(s.Context(), viewerUserID)
+	require.NoError(err)
+	require.Len(orgs, 2)
+	require.Equal(acmeOrgID, orgs[0].ID)
+	require.Equal(globexOrgID, orgs[1].ID)
+
+	orgs, err = s.store.ListUserOrganizations(s.Context(), ulid.MustParse("01JN2YQ2VE9GMBRVACD15J1TFX"))
+	require.NoError(err)
+	require.Empty(orgs)
+}
+
+// TestListOrganizationMembers verifies members are loaded with only their organization roles.
+func (s *storeSuite) TestListOrganizationMembers() {
+	require := s.Require()
+	members, err := s.store.ListOrganizationMembers(s.Context(), acmeOrgID)
+	require.NoError(err)
+	require.Len(members, 2)
+
+	for _, member := range members {
+		require.Equal(acmeOrgID, member.OrgID)
+		require.Len(member.Roles, 1)
+		switch member.ID {
+		case viewerUserID:
+			require.Equal("keyholder", member.Roles[0].Title)
+		case editorUserID:
+			require.Equal("viewer", member.Roles[0].Title)
+		default:
+			require.Fail("unexpected organization member", member.ID.String())
+		}
+	}
+
+	_, err = s.store.ListOrganizationMembers(s.Context(), ulid.Make())
+	require.ErrorIs(err, errors.ErrNotFound)
+}
+
+// TestRetrieveOrganizationMember verifies global and organization roles are merged.
+func (s *storeSuite) TestRetrieveOrganizationMember() {
+	require := s.Require()
+	user, err := s.store.RetrieveOrganizationMember(s.Context(), acmeOrgID, viewerUserID)
+	require.NoError(err)
+	require.Equal(acmeOrgID, user.OrgID)
+
+	titles := make([]string, 0, len(user.Roles))
+	for _, role := range user.Roles {
+		titles = append(titles, role.Title)
+	}
+	require.ElementsMatch([]string{"viewer", "keyholder"}, titles)
+	require.ElementsMatch([]string{"content:view", "users:view", "keys:create", "keys:revoke", "keys:view"}, models.PermissionTitles(user.Permissions))
+
+	_, err = s.store.RetrieveOrganizationMember(s.Context(), globexOrgID, editorUserID)
+	require.ErrorIs(err, errors.ErrNotFound, "the editor is not a member of globex")
+}
+
+// TestReplaceOrganizationMemberRoles verifies members can be added, changed, and removed.
+func (s *storeSuite) TestReplaceOrganizationMemberRoles() {
+	require := s.Require()
+
+	err := s.store.ReplaceOrganizationMemberRoles(s.Context(), ulid.Make(), viewerUserID, []int64{3})
+	require.ErrorIs(err, errors.ErrNotFound, "unknown organization")
+
+	err = s.store.ReplaceOrganizationMemberRoles(s.Context(), acmeOrgID, ulid.Make(), []int64{3})
+	require.ErrorIs(err, errors.ErrNotFound, "unknown user")
+
+	require.NoError(s.store.ReplaceOrganizationMemberRoles(s.Context(), globexOrgID, editorUserID, []int64{1, 3}))
+	user, err := s.store.RetrieveOrganizationMember(s.Context(), globexOrgID, editorUserID)
+	require.NoError(err)
+	require.Len(user.Roles, 3, "expected the global editor role and the admin and viewer organization roles")
+
+	require.NoError(s.store.ReplaceOrganizationMemberRoles(s.Context(), acmeOrgID, viewerUserID, nil))
+	_, err = s.store.RetrieveOrganizationMember(s.Context(), acmeOrgID, viewerUserID)
+	require.ErrorIs(err, errors.ErrNotFound)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 
+	qpermissions "go.rtnl.ai/quarterdeck/pkg/auth/permissions"
 	qerrors "go.rtnl.ai/quarterdeck/pkg/errors"
 	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
 	"go.rtnl.ai/quarterdeck/pkg/store/v2/txn"
@@ -77,6 +78,9 @@ func (t *tx) CreatePermission(permission *models.Permission) (*models.Permission
 	if permission.ID != 0 {
 		return nil, qerrors.ErrNoIDOnCreate
 	}
+	if permission.Namespace == "" {
+		permission.Namespace = qpermissions.Quarterdeck
+	}
 
 	result, err := permissions.Create(t.tx, permission)
 	if err != nil {
@@ -99,10 +103,25 @@ func (t *tx) RetrievePermissionByTitle(title string) (*models.Permission, error)
 	return t.retrievePermissionByTitle(title)
 }
 
+// UpdatePermission updates the title, description, and namespace of the permission;
+// protected permissions cannot be renamed or moved to another namespace.
 func (t *tx) UpdatePermission(permission *models.Permission) error {
 	if err := t.requireWrite(); err != nil {
 		return err
 	}
+
+	current, err := t.RetrievePermission(permission.ID)
+	if err != nil {
+		return err
+	}
+	if permission.Namespace == "" {
+		permission.Namespace = current.Namespace
+	}
+	if current.Protected && (permission.Title != current.Title || permission.Namespace != current.Namespace) {
+		return qerrors.ErrProtected
+	}
+
+	permission.Protected = current.Protected
 	return tidalErr(permissions.Update(t.tx, permission))
 }
 
@@ -113,6 +132,15 @@ func (t *tx) DeletePermission(id int64) error {
 	if id == 0 {
 		return qerrors.ErrMissingID
 	}
+
+	current, err := t.RetrievePermission(id)
+	if err != nil {
+		return err
+	}
+	if current.Protected {
+		return qerrors.ErrProtected
+	}
+
 	result, err := permissions.Delete(t.tx, sql.Named("id", id))
 	if err != nil {
 		return tidalErr(err)
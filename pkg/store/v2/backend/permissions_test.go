@@ -68,6 +68,7 @@ func (s *storeSuite) TestRetrievePermission() {
 		ID:          2,
 		Title:       "content:view",
 		Description: "Permission to view content",
+		Namespace:   "quarterdeck",
 		Created:     time.Date(2025, 2, 14, 11, 21, 42, 0, time.UTC),
 		Modified:    time.Date(2025, 2, 14, 11, 21, 42, 0, time.UTC),
 	}
@@ -121,6 +122,28 @@ func (s *storeSuite) TestUpdatePermission() {
 		err = s.store.UpdatePermission(s.Context(), perm)
 		require.ErrorIs(err, errors.ErrNotFound)
 	})
+
+	s.Run("Protected", func() {
+		protected, err := s.store.CreatePermission(s.Context(), &models.Permission{Title: "widgets:view", Protected: true})
+		require.NoError(err)
+		require.True(protected.Protected)
+
+		// The description of a protected permission can be updated
+		err = s.store.UpdatePermission(s.Context(), &models.Permission{ID: protected.ID, Title: "widgets:view", Description: "View widgets"})
+		require.NoError(err)
+
+		cmpt, err := s.store.RetrievePermission(s.Context(), protected.ID)
+		require.NoError(err)
+		require.Equal("View widgets", cmpt.Description)
+		require.True(cmpt.Protected)
+
+		// But it cannot be renamed or moved to another namespace
+		err = s.store.UpdatePermission(s.Context(), &models.Permission{ID: protected.ID, Title: "widgets:read"})
+		require.ErrorIs(err, errors.ErrProtected)
+
+		err = s.store.UpdatePermission(s.Context(), &models.Permission{ID: protected.ID, Title: "widgets:view", Namespace: "widgets"})
+		require.ErrorIs(err, errors.ErrProtected)
+	})
 }
 
 // TestDeletePermission verifies cascade to role_permissions and idempotent not-found.
@@ -139,4 +162,11 @@ func (s *storeSuite) TestDeletePermission() {
 
 	err = s.store.DeletePermission(s.Context(), permID)
 	require.ErrorIs(err, errors.ErrNotFound)
+
+	protected, err := s.store.CreatePermission(s.Context(), &models.Permission{Title: "widgets:manage", Protected: true})
+	require.NoError(err)
+
+	err = s.store.DeletePermission(s.Context(), protected.ID)
+	require.ErrorIs(err, errors.ErrProtected)
+	require.Equal(permCount, s.count("permissions"))
 }
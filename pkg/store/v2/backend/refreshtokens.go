@@ -0,0 +1,193 @@
+package backend
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/txn"
+	"go.rtnl.ai/tidal"
+	"go.rtnl.ai/ulid"
+)
+
+var refreshTokens = tidal.New[*models.RefreshToken]("refresh_tokens")
+
+// Refresh tokens are keyed by the jti of the token rather than a generated ID, so they
+// are inserted directly instead of with the tidal CRUD create.
+const (
+	createRefreshTokenSQL    = `INSERT INTO refresh_tokens (id, family_id, subject, used_on, revoked_on, expiration, created, modified) VALUES (:id, :family_id, :subject, :used_on, :revoked_on, :expiration, :created, :modified)`
+	deleteExpiredRefreshSQL  = `DELETE FROM refresh_tokens WHERE expiration < :now`
+	useRefreshTokenSQL       = `UPDATE refresh_tokens SET used_on = :now, modified = :now WHERE id = :id AND used_on IS NULL AND revoked_on IS NULL`
+	revokeRefreshFamilySQL   = `UPDATE refresh_tokens SET revoked_on = :now, modified = :now WHERE family_id = :family_id AND revoked_on IS NULL`
+	refreshFamilyExistsSQL   = `SELECT EXISTS (SELECT 1 FROM refresh_tokens WHERE family_id = :family_id)`
+	denylistRefreshFamilySQL = `INSERT INTO revoked_tokens (id, expiration, created, modified) SELECT id, expiration, :now, :now FROM refresh_tokens WHERE family_id = :family_id AND expiration > :now ON CONFLICT (id) DO NOTHING`
+)
+
+//===========================================================================
+// Store Methods
+//===========================================================================
+
+func (s *Store) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) (*models.RefreshToken, error) {
+	var created *models.RefreshToken
+	err := s.WithTx(ctx, nil, func(t txn.Tx) (err error) {
+		created, err = t.CreateRefreshToken(token)
+		return err
+	})
+	return created, err
+}
+
+func (s *Store) RetrieveRefreshToken(ctx context.Context, jti ulid.ULID) (*models.RefreshToken, error) {
+	var token *models.RefreshToken
+	err := s.WithReadTx(ctx, func(t txn.Tx) (err error) {
+		token, err = t.RetrieveRefreshToken(jti)
+		return err
+	})
+	return token, err
+}
+
+func (s *Store) UseRefreshToken(ctx context.Context, jti ulid.ULID) (*models.RefreshToken, error) {
+	var token *models.RefreshToken
+	err := s.WithTx(ctx, nil, func(t txn.Tx) (err error) {
+		token, err = t.UseRefreshToken(jti)
+		return err
+	})
+	return token, err
+}
+
+func (s *Store) RevokeRefreshTokenFamily(ctx context.Context, familyID ulid.ULID) error {
+	return s.WithTx(ctx, nil, func(t txn.Tx) error {
+		return t.RevokeRefreshTokenFamily(familyID)
+	})
+}
+
+//===========================================================================
+// Tx Methods
+//===========================================================================
+
+func (t *tx) CreateRefreshToken(token *models.RefreshToken) (*models.RefreshToken, error) {
+	if err := t.requireWrite(); err != nil {
+		return nil, err
+	}
+	if token.ID.IsZero() {
+		return nil, errors.ErrMissingID
+	}
+	if token.Subject == "" || token.Expiration.IsZero() {
+		return nil, errors.ErrZeroValuedNotNull
+	}
+
+	if token.FamilyID.IsZero() {
+		token.FamilyID = token.ID
+	}
+
+	now := time.Now().UTC()
+	token.Created = now
+	token.Modified = now
+
+	params := token.Params(tidal.Create)
+	args := make([]any, len(params))
+	for i, param := range params {
+		args[i] = param
+	}
+
+	if _, err := t.tx.Exec(deleteExpiredRefreshSQL, sql.Named("now", now)); err != nil {
+		return nil, tidalErr(err)
+	}
+
+	if _, err := t.tx.Exec(createRefreshTokenSQL, args...); err != nil {
+		return nil, tidalErr(err)
+	}
+
+	return t.retrieveRefreshToken(token.ID)
+}
+
+func (t *tx) RetrieveRefreshToken(jti ulid.ULID) (*models.RefreshToken, error) {
+	return t.retrieveRefreshToken(jti)
+}
+
+func (t *tx) UseRefreshToken(jti ulid.ULID) (*models.RefreshToken, error) {
+	if err := t.requireWrite(); err != nil {
+		return nil, err
+	}
+
+	token, err := t.retrieveRefreshToken(jti)
+	if err != nil {
+		return nil, err
+	}
+
+	if token.RevokedOn.Valid {
+		return nil, errors.ErrTokenRevoked
+	}
+	if token.UsedOn.Valid {
+		return nil, errors.ErrTokenReused
+	}
+	if token.IsExpired() {
+		return nil, errors.ErrExpiredToken
+	}
+
+	// The update is conditional so that if a concurrent request used the token after it
+	// was retrieved above then only one of the requests succeeds and reuse is detected.
+	now := time.Now().UTC()
+	var result sql.Result
+	if result, err = t.tx.Exec(useRefreshTokenSQL, sql.Named("id", jti), sql.Named("now", now)); err != nil {
+		return nil, tidalErr(err)
+	}
+
+	var rows int64
+	if rows, err = result.RowsAffected(); err != nil {
+		return nil, tidalErr(err)
+	}
+
+	if rows == 0 {
+		return nil, errors.ErrTokenReused
+	}
+
+	token.UsedOn = sql.NullTime{Time: now, Valid: true}
+	token.Modified = now
+	return token, nil
+}
+
+func (t *tx) RevokeRefreshTokenFamily(familyID ulid.ULID) error {
+	if err := t.requireWrite(); err != nil {
+		return err
+	}
+	if familyID.IsZero() {
+		return errors.ErrMissingID
+	}
+
+	now := time.Now().UTC()
+	result, err := t.tx.Exec(revokeRefreshFamilySQL, sql.Named("family_id", familyID), sql.Named("now", now))
+	if err != nil {
+		return tidalErr(err)
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		var exists bool
+		if err = t.tx.QueryRow(refreshFamilyExistsSQL, sql.Named("family_id", familyID)).Scan(&exists); err != nil {
+			return tidalErr(err)
+		}
+		if !exists {
+			return errors.ErrNotFound
+		}
+	}
+
+	// Access tokens share the jti of the refresh token they were issued with, so the
+	// unexpired tokens of the family are denylisted to revoke the access tokens too.
+	if _, err = t.tx.Exec(denylistRefreshFamilySQL, sql.Named("family_id", familyID), sql.Named("now", now)); err != nil {
+		return tidalErr(err)
+	}
+	return nil
+}
+
+//===========================================================================
+// Helpers
+//===========================================================================
+
+func (t *tx) retrieveRefreshToken(jti ulid.ULID) (*models.RefreshToken, error) {
+	token, err := refreshTokens.Retrieve(t.tx, sql.Named("id", jti))
+	if err != nil {
+		return nil, tidalErr(err)
+	}
+	return token, nil
+}
@@ -0,0 +1,169 @@
+package backend_test
+
+import (
+	"sync"
+	"time"
+
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/ulid"
+	"go.rtnl.ai/x/dsn"
+)
+
+//=============================================================================
+// Refresh Token Store Tests
+//=============================================================================
+
+// TestRefreshTokenRotation verifies a family can be rotated, reuse is detected, and the family revoked.
+func (s *storeSuite) TestRefreshTokenRotation() {
+	require := s.Require()
+
+	// Setup: first refresh token issued on login starts a new family.
+	first := &models.RefreshToken{Subject: "u01JPYRNYMEHNEZCS0JYX1CP57A", Expiration: time.Now().Add(time.Hour)}
+	first.ID = ulid.MakeSecure()
+
+	created, err := s.store.CreateRefreshToken(s.Context(), first)
+	require.NoError(err)
+	require.Equal(first.ID, created.FamilyID)
+	require.False(created.UsedOn.Valid)
+	require.False(created.RevokedOn.Valid)
+
+	// Action: use the first token and rotate to a second token in the same family.
+	used, err := s.store.UseRefreshToken(s.Context(), first.ID)
+	require.NoError(err)
+	require.True(used.UsedOn.Valid)
+
+	second := &models.RefreshToken{FamilyID: used.FamilyID, Subject: used.Subject, Expiration: time.Now().Add(time.Hour)}
+	second.ID = ulid.MakeSecure()
+	_, err = s.store.CreateRefreshToken(s.Context(), second)
+	require.NoError(err)
+
+	// Assert: the first token cannot be used again.
+	_, err = s.store.UseRefreshToken(s.Context(), first.ID)
+	require.ErrorIs(err, errors.ErrTokenReused)
+
+	// Assert: revoking the family prevents the second token from being used.
+	require.NoError(s.store.RevokeRefreshTokenFamily(s.Context(), first.ID))
+
+	_, err = s.store.UseRefreshToken(s.Context(), second.ID)
+	require.ErrorIs(err, errors.ErrTokenRevoked)
+
+	retrieved, err := s.store.RetrieveRefreshToken(s.Context(), second.ID)
+	require.NoError(err)
+	require.True(retrieved.RevokedOn.Valid)
+	require.False(retrieved.UsedOn.Valid)
+
+	// Revoking a family that is already revoked is not an error.
+	require.NoError(s.store.RevokeRefreshTokenFamily(s.Context(), first.ID))
+}
+
+// TestUseRefreshTokenConcurrently verifies only one of several concurrent uses of a token succeeds.
+func (s *storeSuite) TestUseRefreshTokenConcurrently() {
+	if s.DSN().Provider == dsn.SQLite3 {
+		s.T().Skip("sqlite serializes write transactions")
+	}
+
+	require := s.Require()
+
+	token := &models.RefreshToken{Subject: "u01JPYRNYMEHNEZCS0JYX1CP57A", Expiration: time.Now().Add(time.Hour)}
+	token.ID = ulid.MakeSecure()
+
+	_, err := s.store.CreateRefreshToken(s.Context(), token)
+	require.NoError(err)
+
+	// Action: use the token from several goroutines at the same time.
+	const n = 8
+	var (
+		wg    sync.WaitGroup
+		start = make(chan struct{})
+		errs  = make([]error, n)
+	)
+
+	for i := range n {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			_, errs[i] = s.store.UseRefreshToken(s.Context(), token.ID)
+		}(i)
+	}
+
+	close(start)
+	wg.Wait()
+
+	// Assert: exactly one use succeeded and every other use was detected as reuse.
+	succeeded := 0
+	for _, err := range errs {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		require.ErrorIs(err, errors.ErrTokenReused)
+	}
+	require.Equal(1, succeeded)
+}
+
+// TestCreateRefreshToken verifies validation and cleanup of expired refresh tokens.
+func (s *storeSuite) TestCreateRefreshToken() {
+	require := s.Require()
+
+	s.Run("MissingID", func() {
+		_, err := s.store.CreateRefreshToken(s.Context(), &models.RefreshToken{Subject: "u01JPYRNYMEHNEZCS0JYX1CP57A", Expiration: time.Now().Add(time.Hour)})
+		require.ErrorIs(err, errors.ErrMissingID)
+	})
+
+	s.Run("ZeroValuedNotNull", func() {
+		token := &models.RefreshToken{Expiration: time.Now().Add(time.Hour)}
+		token.ID = ulid.MakeSecure()
+		_, err := s.store.CreateRefreshToken(s.Context(), token)
+		require.ErrorIs(err, errors.ErrZeroValuedNotNull)
+
+		token = &models.RefreshToken{Subject: "u01JPYRNYMEHNEZCS0JYX1CP57A"}
+		token.ID = ulid.MakeSecure()
+		_, err = s.store.CreateRefreshToken(s.Context(), token)
+		require.ErrorIs(err, errors.ErrZeroValuedNotNull)
+	})
+
+	s.Run("AlreadyExists", func() {
+		token := &models.RefreshToken{Subject: "u01JPYRNYMEHNEZCS0JYX1CP57A", Expiration: time.Now().Add(time.Hour)}
+		token.ID = ulid.MakeSecure()
+		_, err := s.store.CreateRefreshToken(s.Context(), token)
+		require.NoError(err)
+
+		_, err = s.store.CreateRefreshToken(s.Context(), token)
+		require.ErrorIs(err, errors.ErrAlreadyExists)
+	})
+
+	s.Run("ExpiredCleanup", func() {
+		expired := &models.RefreshToken{Subject: "u01JPYRNYMEHNEZCS0JYX1CP57A", Expiration: time.Now().Add(-time.Minute)}
+		expired.ID = ulid.MakeSecure()
+		_, err := s.store.CreateRefreshToken(s.Context(), expired)
+		require.NoError(err)
+
+		// Expired tokens are removed when the next refresh token is created.
+		token := &models.RefreshToken{Subject: "u01JPYRNYMEHNEZCS0JYX1CP57A", Expiration: time.Now().Add(time.Hour)}
+		token.ID = ulid.MakeSecure()
+		_, err = s.store.CreateRefreshToken(s.Context(), token)
+		require.NoError(err)
+
+		_, err = s.store.RetrieveRefreshToken(s.Context(), expired.ID)
+		require.ErrorIs(err, errors.ErrNotFound)
+	})
+}
+
+// TestRefreshTokenNotFound verifies lookups and updates of unknown tokens and families.
+func (s *storeSuite) TestRefreshTokenNotFound() {
+	require := s.Require()
+
+	_, err := s.store.RetrieveRefreshToken(s.Context(), ulid.MakeSecure())
+	require.ErrorIs(err, errors.ErrNotFound)
+
+	_, err = s.store.UseRefreshToken(s.Context(), ulid.MakeSecure())
+	require.ErrorIs(err, errors.ErrNotFound)
+
+	err = s.store.RevokeRefreshTokenFamily(s.Context(), ulid.MakeSecure())
+	require.ErrorIs(err, errors.ErrNotFound)
+
+	err = s.store.RevokeRefreshTokenFamily(s.Context(), ulid.Zero)
+	require.ErrorIs(err, errors.ErrMissingID)
+}
@@ -14,7 +14,7 @@ var roles = tidal.New[*models.Role]("roles")
 var rolePermissions = tidal.New[*models.RolePermission]("role_permissions")
 
 const (
-	rolePermissionsSQL      = `SELECT p.id, p.title, p.description, p.created, p.modified FROM role_permissions rp JOIN permissions p ON p.id = rp.permission_id WHERE rp.role_id = :role_id`
+	rolePermissionsSQL      = `SELECT p.id, p.title, p.description, p.namespace, p.protected, p.created, p.modified FROM role_permissions rp JOIN permissions p ON p.id = rp.permission_id WHERE rp.role_id = :role_id`
 	deleteRolePermissionSQL = `DELETE FROM role_permissions WHERE role_id = :role_id AND permission_id = :permission_id`
 )
 
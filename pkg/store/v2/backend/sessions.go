@@ -0,0 +1,235 @@
+package backend
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	qerrors "go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/txn"
+	"go.rtnl.ai/tidal"
+	"go.rtnl.ai/ulid"
+)
+
+var sessions = tidal.New[*models.Session]("sessions")
+
+// Sessions are keyed by the family ID of the refresh tokens issued on login rather than
+// a generated ID, so they are inserted directly instead of with the tidal CRUD create.
+const (
+	listSessionsSQL       = `SELECT id, user_id, jti, user_agent, client_ip, last_refresh, revoked, expiration, created, modified FROM sessions WHERE user_id = :user_id AND revoked IS NULL AND expiration > :now ORDER BY COALESCE(last_refresh, created) DESC`
+	createSessionSQL      = `INSERT INTO sessions (id, user_id, jti, user_agent, client_ip, last_refresh, revoked, expiration, created, modified) VALUES (:id, :user_id, :jti, :user_agent, :client_ip, :last_refresh, :revoked, :expiration, :created, :modified)`
+	refreshSessionSQL     = `UPDATE sessions SET jti = :jti, last_refresh = :now, expiration = :expiration, modified = :now WHERE id = :id`
+	revokeSessionSQL      = `UPDATE sessions SET revoked = :now, modified = :now WHERE id = :id AND revoked IS NULL`
+	listUserSessionIDsSQL = `SELECT id FROM sessions WHERE user_id = :user_id AND revoked IS NULL`
+)
+
+//===========================================================================
+// Store Methods
+//===========================================================================
+
+func (s *Store) ListSessions(ctx context.Context, userID ulid.ULID) ([]*models.Session, error) {
+	var out []*models.Session
+	err := s.WithReadTx(ctx, func(t txn.Tx) (err error) {
+		out, err = t.ListSessions(userID)
+		return err
+	})
+	return out, err
+}
+
+func (s *Store) CreateSession(ctx context.Context, session *models.Session) (*models.Session, error) {
+	var created *models.Session
+	err := s.WithTx(ctx, nil, func(t txn.Tx) (err error) {
+		created, err = t.CreateSession(session)
+		return err
+	})
+	return created, err
+}
+
+func (s *Store) RetrieveSession(ctx context.Context, id ulid.ULID) (*models.Session, error) {
+	var session *models.Session
+	err := s.WithReadTx(ctx, func(t txn.Tx) (err error) {
+		session, err = t.RetrieveSession(id)
+		return err
+	})
+	return session, err
+}
+
+func (s *Store) RefreshSession(ctx context.Context, id, jti ulid.ULID, expiration time.Time) error {
+	return s.WithTx(ctx, nil, func(t txn.Tx) error {
+		return t.RefreshSession(id, jti, expiration)
+	})
+}
+
+func (s *Store) RevokeSession(ctx context.Context, id ulid.ULID) error {
+	return s.WithTx(ctx, nil, func(t txn.Tx) error {
+		return t.RevokeSession(id)
+	})
+}
+
+func (s *Store) RevokeUserSessions(ctx context.Context, userID ulid.ULID) error {
+	return s.WithTx(ctx, nil, func(t txn.Tx) error {
+		return t.RevokeUserSessions(userID)
+	})
+}
+
+//===========================================================================
+// Tx Methods
+//===========================================================================
+
+// ListSessions returns the active sessions of the user, most recently active first.
+func (t *tx) ListSessions(userID ulid.ULID) ([]*models.Session, error) {
+	if userID.IsZero() {
+		return nil, qerrors.ErrMissingID
+	}
+
+	rows, err := t.tx.Query(listSessionsSQL, sql.Named("user_id", userID), sql.Named("now", time.Now().UTC()))
+	if err != nil {
+		return nil, tidalErr(err)
+	}
+	defer rows.Close()
+
+	out := make([]*models.Session, 0)
+	for rows.Next() {
+		session := &models.Session{}
+		if err = session.Scan(tidal.List, rows); err != nil {
+			return nil, tidalErr(err)
+		}
+		out = append(out, session)
+	}
+	return out, tidalErr(rows.Err())
+}
+
+func (t *tx) CreateSession(session *models.Session) (*models.Session, error) {
+	if err := t.requireWrite(); err != nil {
+		return nil, err
+	}
+	if session.ID.IsZero() {
+		return nil, qerrors.ErrMissingID
+	}
+	if session.UserID.IsZero() || session.JTI.IsZero() || session.Expiration.IsZero() {
+		return nil, qerrors.ErrZeroValuedNotNull
+	}
+
+	now := time.Now().UTC()
+	session.Created = now
+	session.Modified = now
+
+	params := session.Params(tidal.Create)
+	args := make([]any, len(params))
+	for i, param := range params {
+		args[i] = param
+	}
+
+	if _, err := t.tx.Exec(createSessionSQL, args...); err != nil {
+		return nil, tidalErr(err)
+	}
+
+	return t.retrieveSession(session.ID)
+}
+
+func (t *tx) RetrieveSession(id ulid.ULID) (*models.Session, error) {
+	if id.IsZero() {
+		return nil, qerrors.ErrMissingID
+	}
+	return t.retrieveSession(id)
+}
+
+// RefreshSession records the token pair issued when the session was refreshed.
+func (t *tx) RefreshSession(id, jti ulid.ULID, expiration time.Time) error {
+	if err := t.requireWrite(); err != nil {
+		return err
+	}
+	if id.IsZero() || jti.IsZero() {
+		return qerrors.ErrMissingID
+	}
+
+	result, err := t.tx.Exec(
+		refreshSessionSQL,
+		sql.Named("id", id),
+		sql.Named("jti", jti),
+		sql.Named("expiration", expiration.UTC()),
+		sql.Named("now", time.Now().UTC()),
+	)
+	if err != nil {
+		return tidalErr(err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return qerrors.ErrNotFound
+	}
+	return nil
+}
+
+// RevokeSession marks the session revoked and revokes the refresh token family that was
+// issued on login so that the session can no longer be refreshed.
+func (t *tx) RevokeSession(id ulid.ULID) error {
+	if err := t.requireWrite(); err != nil {
+		return err
+	}
+	if _, err := t.RetrieveSession(id); err != nil {
+		return err
+	}
+
+	if _, err := t.tx.Exec(revokeSessionSQL, sql.Named("id", id), sql.Named("now", time.Now().UTC())); err != nil {
+		return tidalErr(err)
+	}
+
+	if err := t.RevokeRefreshTokenFamily(id); err != nil && !qerrors.Is(err, qerrors.ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// RevokeUserSessions revokes every session of the user, e.g. when the password changes.
+func (t *tx) RevokeUserSessions(userID ulid.ULID) error {
+	if err := t.requireWrite(); err != nil {
+		return err
+	}
+	if userID.IsZero() {
+		return qerrors.ErrMissingID
+	}
+
+	sessionIDs, err := t.userSessionIDs(userID)
+	if err != nil {
+		return err
+	}
+
+	for _, sessionID := range sessionIDs {
+		if err = t.RevokeSession(sessionID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//===========================================================================
+// Helpers
+//===========================================================================
+
+func (t *tx) retrieveSession(id ulid.ULID) (*models.Session, error) {
+	session, err := sessions.Retrieve(t.tx, sql.Named("id", id))
+	if err != nil {
+		return nil, tidalErr(err)
+	}
+	return session, nil
+}
+
+// userSessionIDs reads the IDs of the unrevoked sessions of the user so that the rows
+// are closed before the sessions are revoked in the same transaction.
+func (t *tx) userSessionIDs(userID ulid.ULID) ([]ulid.ULID, error) {
+	rows, err := t.tx.Query(listUserSessionIDsSQL, sql.Named("user_id", userID))
+	if err != nil {
+		return nil, tidalErr(err)
+	}
+	defer rows.Close()
+
+	sessionIDs := make([]ulid.ULID, 0)
+	for rows.Next() {
+		var sessionID ulid.ULID
+		if err = rows.Scan(&sessionID); err != nil {
+			return nil, tidalErr(err)
+		}
+		sessionIDs = append(sessionIDs, sessionID)
+	}
+	return sessionIDs, tidalErr(rows.Err())
+}
@@ -0,0 +1,93 @@
+package backend_test
+
+import (
+	"database/sql"
+	"time"
+
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/ulid"
+)
+
+//=============================================================================
+// Session Store Tests
+//=============================================================================
+
+// TestSessions verifies sessions are listed, refreshed, and revoked along with their refresh token family.
+func (s *storeSuite) TestSessions() {
+	require := s.Require()
+	userID := ulid.MustParse("01JPYRNYMEHNEZCS0JYX1CP57A")
+
+	// Setup: two logins by the same user, each with a refresh token family.
+	logins := make([]*models.Session, 0, 2)
+	for _, agent := range []string{"Firefox", "Safari"} {
+		token := &models.RefreshToken{Subject: "u" + userID.String(), Expiration: time.Now().Add(time.Hour)}
+		token.ID = ulid.MakeSecure()
+		_, err := s.store.CreateRefreshToken(s.Context(), token)
+		require.NoError(err)
+
+		session := &models.Session{
+			UserID:     userID,
+			JTI:        token.ID,
+			UserAgent:  sql.NullString{String: agent, Valid: true},
+			Expiration: token.Expiration,
+		}
+		session.ID = token.ID
+
+		created, err := s.store.CreateSession(s.Context(), session)
+		require.NoError(err)
+		require.True(created.IsActive())
+		logins = append(logins, created)
+	}
+
+	// Action: refresh the first session so that it is the most recently active.
+	jti := ulid.MakeSecure()
+	require.NoError(s.store.RefreshSession(s.Context(), logins[0].ID, jti, time.Now().Add(2*time.Hour)))
+
+	sessions, err := s.store.ListSessions(s.Context(), userID)
+	require.NoError(err)
+	require.Len(sessions, 2)
+	require.Equal(logins[0].ID, sessions[0].ID, "expected the refreshed session first")
+	require.Equal(jti, sessions[0].JTI)
+	require.True(sessions[0].LastRefresh.Valid)
+
+	// Assert: revoking a session revokes its refresh token family.
+	require.NoError(s.store.RevokeSession(s.Context(), logins[0].ID))
+
+	session, err := s.store.RetrieveSession(s.Context(), logins[0].ID)
+	require.NoError(err)
+	require.False(session.IsActive())
+
+	token, err := s.store.RetrieveRefreshToken(s.Context(), logins[0].ID)
+	require.NoError(err)
+	require.True(token.RevokedOn.Valid)
+
+	sessions, err = s.store.ListSessions(s.Context(), userID)
+	require.NoError(err)
+	require.Len(sessions, 1)
+
+	// Assert: revoking all of the user's sessions revokes the remaining session.
+	require.NoError(s.store.RevokeUserSessions(s.Context(), userID))
+	sessions, err = s.store.ListSessions(s.Context(), userID)
+	require.NoError(err)
+	require.Empty(sessions)
+}
+
+// TestSessionErrors verifies validation and not found errors of the session store.
+func (s *storeSuite) TestSessionErrors() {
+	require := s.Require()
+
+	_, err := s.store.CreateSession(s.Context(), &models.Session{UserID: ulid.MakeSecure(), JTI: ulid.MakeSecure(), Expiration: time.Now()})
+	require.ErrorIs(err, errors.ErrMissingID)
+
+	session := &models.Session{JTI: ulid.MakeSecure(), Expiration: time.Now()}
+	session.ID = ulid.MakeSecure()
+	_, err = s.store.CreateSession(s.Context(), session)
+	require.ErrorIs(err, errors.ErrZeroValuedNotNull)
+
+	_, err = s.store.RetrieveSession(s.Context(), ulid.MakeSecure())
+	require.ErrorIs(err, errors.ErrNotFound)
+
+	require.ErrorIs(s.store.RefreshSession(s.Context(), ulid.MakeSecure(), ulid.MakeSecure(), time.Now()), errors.ErrNotFound)
+	require.ErrorIs(s.store.RevokeSession(s.Context(), ulid.MakeSecure()), errors.ErrNotFound)
+}
@@ -0,0 +1,200 @@
+package backend
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"go.rtnl.ai/quarterdeck/pkg/enum"
+	qerrors "go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/txn"
+	"go.rtnl.ai/tidal"
+	"go.rtnl.ai/ulid"
+)
+
+var signingKeys = tidal.New[*models.SigningKey]("signing_keys")
+
+// Signing keys are keyed by the kid of the tokens they sign, which is bound to the
+// sealed private key, so they are inserted directly instead of with the tidal CRUD
+// create. State changes are made with conditional updates so that replicas that
+// rotate keys at the same time cannot both succeed.
+const (
+	listSigningKeysSQL      = `SELECT id, state, public_key, private_key, activates, deactivated, retires, created, modified FROM signing_keys ORDER BY activates DESC, id DESC`
+	createSigningKeySQL     = `INSERT INTO signing_keys (id, state, public_key, private_key, activates, deactivated, retires, created, modified) VALUES (:id, :state, :public_key, :private_key, :activates, :deactivated, :retires, :created, :modified)`
+	deactivateSigningKeySQL = `UPDATE signing_keys SET state = 'retiring', deactivated = :now, retires = :retires, modified = :now WHERE state = 'active'`
+	activateSigningKeySQL   = `UPDATE signing_keys SET state = 'active', activates = :now, modified = :now WHERE id = :id AND state = 'next'`
+	retireSigningKeySQL     = `UPDATE signing_keys SET state = 'retired', private_key = NULL, deactivated = COALESCE(deactivated, :now), retires = :now, modified = :now WHERE id = :id AND state IN ('next', 'retiring')`
+)
+
+//===========================================================================
+// Store Methods
+//===========================================================================
+
+func (s *Store) ListSigningKeys(ctx context.Context) ([]*models.SigningKey, error) {
+	var out []*models.SigningKey
+	err := s.WithReadTx(ctx, func(t txn.Tx) (err error) {
+		out, err = t.ListSigningKeys()
+		return err
+	})
+	return out, err
+}
+
+func (s *Store) CreateSigningKey(ctx context.Context, key *models.SigningKey) (*models.SigningKey, error) {
+	var created *models.SigningKey
+	err := s.WithTx(ctx, nil, func(t txn.Tx) (err error) {
+		created, err = t.CreateSigningKey(key)
+		return err
+	})
+	return created, err
+}
+
+func (s *Store) RetrieveSigningKey(ctx context.Context, id ulid.ULID) (*models.SigningKey, error) {
+	var key *models.SigningKey
+	err := s.WithReadTx(ctx, func(t txn.Tx) (err error) {
+		key, err = t.RetrieveSigningKey(id)
+		return err
+	})
+	return key, err
+}
+
+func (s *Store) ActivateSigningKey(ctx context.Context, id ulid.ULID, retires time.Time) error {
+	return s.WithTx(ctx, nil, func(t txn.Tx) error {
+		return t.ActivateSigningKey(id, retires)
+	})
+}
+
+func (s *Store) RetireSigningKey(ctx context.Context, id ulid.ULID) error {
+	return s.WithTx(ctx, nil, func(t txn.Tx) error {
+		return t.RetireSigningKey(id)
+	})
+}
+
+//===========================================================================
+// Tx Methods
+//===========================================================================
+
+// ListSigningKeys returns every signing key including retired keys, newest first.
+func (t *tx) ListSigningKeys() ([]*models.SigningKey, error) {
+	rows, err := t.tx.Query(listSigningKeysSQL)
+	if err != nil {
+		return nil, tidalErr(err)
+	}
+	defer rows.Close()
+
+	out := make([]*models.SigningKey, 0)
+	for rows.Next() {
+		key := &models.SigningKey{}
+		if err = key.Scan(tidal.List, rows); err != nil {
+			return nil, tidalErr(err)
+		}
+		out = append(out, key)
+	}
+	return out, tidalErr(rows.Err())
+}
+
+// CreateSigningKey stores a key in the next, active, or retiring state. Returns
+// ErrAlreadyExists if the key exists or if there is already a key in the next or active
+// state that the new key would replace.
+func (t *tx) CreateSigningKey(key *models.SigningKey) (*models.SigningKey, error) {
+	if err := t.requireWrite(); err != nil {
+		return nil, err
+	}
+	if key.ID.IsZero() {
+		return nil, qerrors.ErrMissingID
+	}
+	if !key.IsPublished() {
+		return nil, qerrors.ErrInvalidStatus
+	}
+	if len(key.PublicKey) == 0 || len(key.PrivateKey) == 0 || key.Activates.IsZero() {
+		return nil, qerrors.ErrZeroValuedNotNull
+	}
+
+	now := time.Now().UTC()
+	key.Created = now
+	key.Modified = now
+
+	params := key.Params(tidal.Create)
+	args := make([]any, len(params))
+	for i, param := range params {
+		args[i] = param
+	}
+
+	if _, err := t.tx.Exec(createSigningKeySQL, args...); err != nil {
+		return nil, tidalErr(err)
+	}
+
+	return t.retrieveSigningKey(key.ID)
+}
+
+func (t *tx) RetrieveSigningKey(id ulid.ULID) (*models.SigningKey, error) {
+	if id.IsZero() {
+		return nil, qerrors.ErrMissingID
+	}
+	return t.retrieveSigningKey(id)
+}
+
+// ActivateSigningKey makes the next key the active key; the previously active key
+// becomes a retiring key that is retired at the specified time. Returns ErrNotFound if
+// the key is not the next key, e.g. because another replica has already activated it.
+func (t *tx) ActivateSigningKey(id ulid.ULID, retires time.Time) error {
+	if err := t.requireWrite(); err != nil {
+		return err
+	}
+	if id.IsZero() {
+		return qerrors.ErrMissingID
+	}
+
+	// The active key must be deactivated first since only one key can be active.
+	now := time.Now().UTC()
+	if _, err := t.tx.Exec(deactivateSigningKeySQL, sql.Named("now", now), sql.Named("retires", retires.UTC())); err != nil {
+		return tidalErr(err)
+	}
+
+	result, err := t.tx.Exec(activateSigningKeySQL, sql.Named("id", id), sql.Named("now", now))
+	if err != nil {
+		return tidalErr(err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return qerrors.ErrNotFound
+	}
+	return nil
+}
+
+// RetireSigningKey removes a next or retiring key from the JWKS and clears its private
+// key. Retiring a key that is already retired is not an error; the active key cannot be
+// retired until another key has been activated.
+func (t *tx) RetireSigningKey(id ulid.ULID) error {
+	if err := t.requireWrite(); err != nil {
+		return err
+	}
+
+	key, err := t.RetrieveSigningKey(id)
+	if err != nil {
+		return err
+	}
+
+	switch key.State {
+	case enum.KeyStateRetired:
+		return nil
+	case enum.KeyStateActive:
+		return qerrors.ErrActiveSigningKey
+	}
+
+	if _, err = t.tx.Exec(retireSigningKeySQL, sql.Named("id", id), sql.Named("now", time.Now().UTC())); err != nil {
+		return tidalErr(err)
+	}
+	return nil
+}
+
+//===========================================================================
+// Helpers
+//===========================================================================
+
+func (t *tx) retrieveSigningKey(id ulid.ULID) (*models.SigningKey, error) {
+	key, err := signingKeys.Retrieve(t.tx, sql.Named("id", id))
+	if err != nil {
+		return nil, tidalErr(err)
+	}
+	return key, nil
+}
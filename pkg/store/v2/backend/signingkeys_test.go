@@ -0,0 +1,114 @@
+package backend_test
+
+import (
+	"database/sql"
+	"time"
+
+	"go.rtnl.ai/quarterdeck/pkg/enum"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/ulid"
+)
+
+//=============================================================================
+// Signing Key Store Tests
+//=============================================================================
+
+// TestSigningKeys verifies signing keys are rotated through the next, active, retiring,
+// and retired states.
+func (s *storeSuite) TestSigningKeys() {
+	require := s.Require()
+	ctx := s.Context()
+
+	// Setup: an active key and a next key that is scheduled to replace it.
+	active, err := s.store.CreateSigningKey(ctx, newSigningKey(enum.KeyStateActive, time.Now()))
+	require.NoError(err)
+	require.Equal(enum.KeyStateActive, active.State)
+
+	next, err := s.store.CreateSigningKey(ctx, newSigningKey(enum.KeyStateNext, time.Now().Add(time.Hour)))
+	require.NoError(err)
+
+	// Assert: only one key can be next or active at a time.
+	_, err = s.store.CreateSigningKey(ctx, newSigningKey(enum.KeyStateNext, time.Now().Add(time.Hour)))
+	require.ErrorIs(err, errors.ErrAlreadyExists)
+
+	_, err = s.store.CreateSigningKey(ctx, newSigningKey(enum.KeyStateActive, time.Now()))
+	require.ErrorIs(err, errors.ErrAlreadyExists)
+
+	keys, err := s.store.ListSigningKeys(ctx)
+	require.NoError(err)
+	require.Len(keys, 2)
+	require.Equal(next.ID, keys[0].ID, "expected the next key first")
+
+	// Action: activate the next key; the active key becomes a retiring key.
+	retires := time.Now().Add(2 * time.Hour).Truncate(time.Second)
+	require.NoError(s.store.ActivateSigningKey(ctx, next.ID, retires))
+
+	cmp, err := s.store.RetrieveSigningKey(ctx, next.ID)
+	require.NoError(err)
+	require.Equal(enum.KeyStateActive, cmp.State)
+
+	cmp, err = s.store.RetrieveSigningKey(ctx, active.ID)
+	require.NoError(err)
+	require.Equal(enum.KeyStateRetiring, cmp.State)
+	require.True(cmp.Deactivated.Valid)
+	require.True(cmp.Retires.Time.Equal(retires))
+	require.NotEmpty(cmp.PrivateKey)
+
+	// Assert: a key that is no longer next cannot be activated again.
+	require.ErrorIs(s.store.ActivateSigningKey(ctx, next.ID, retires), errors.ErrNotFound)
+
+	// Action: retire the retiring key; its private key is cleared.
+	require.NoError(s.store.RetireSigningKey(ctx, active.ID))
+
+	cmp, err = s.store.RetrieveSigningKey(ctx, active.ID)
+	require.NoError(err)
+	require.Equal(enum.KeyStateRetired, cmp.State)
+	require.Empty(cmp.PrivateKey)
+	require.False(cmp.IsPublished())
+
+	// Assert: retiring a retired key is not an error, but the active key cannot be retired.
+	require.NoError(s.store.RetireSigningKey(ctx, active.ID))
+	require.ErrorIs(s.store.RetireSigningKey(ctx, next.ID), errors.ErrActiveSigningKey)
+}
+
+// TestSigningKeyErrors verifies validation and not found errors of the signing key store.
+func (s *storeSuite) TestSigningKeyErrors() {
+	require := s.Require()
+	ctx := s.Context()
+
+	key := newSigningKey(enum.KeyStateNext, time.Now())
+	key.ID = ulid.Zero
+	_, err := s.store.CreateSigningKey(ctx, key)
+	require.ErrorIs(err, errors.ErrMissingID)
+
+	_, err = s.store.CreateSigningKey(ctx, newSigningKey(enum.KeyStateRetired, time.Now()))
+	require.ErrorIs(err, errors.ErrInvalidStatus)
+
+	key = newSigningKey(enum.KeyStateNext, time.Now())
+	key.PrivateKey = nil
+	_, err = s.store.CreateSigningKey(ctx, key)
+	require.ErrorIs(err, errors.ErrZeroValuedNotNull)
+
+	_, err = s.store.RetrieveSigningKey(ctx, ulid.MakeSecure())
+	require.ErrorIs(err, errors.ErrNotFound)
+
+	require.ErrorIs(s.store.ActivateSigningKey(ctx, ulid.MakeSecure(), time.Now()), errors.ErrNotFound)
+	require.ErrorIs(s.store.RetireSigningKey(ctx, ulid.MakeSecure()), errors.ErrNotFound)
+}
+
+func newSigningKey(state enum.KeyState, activates time.Time) *models.SigningKey {
+	key := &models.SigningKey{
+		State:      state,
+		PublicKey:  []byte("public key"),
+		PrivateKey: []byte("sealed private key"),
+		Activates:  activates.UTC(),
+	}
+
+	if state == enum.KeyStateRetiring {
+		key.Retires = sql.NullTime{Time: activates.Add(time.Hour).UTC(), Valid: true}
+	}
+
+	key.ID = ulid.MakeSecure()
+	return key
+}
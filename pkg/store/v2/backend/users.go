@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"time"
 
+	"go.rtnl.ai/quarterdeck/pkg/enum"
 	qerrors "go.rtnl.ai/quarterdeck/pkg/errors"
 	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
 	"go.rtnl.ai/quarterdeck/pkg/store/v2/txn"
@@ -17,11 +18,16 @@ var userRoles = tidal.New[*models.UserRole]("user_roles")
 
 const (
 	userRolesSQL               = `SELECT r.id, r.title, r.description, r.is_default, r.created, r.modified FROM user_roles ur JOIN roles r ON ur.role_id = r.id WHERE ur.user_id = :user_id`
-	userPermissionsSQL         = `SELECT DISTINCT p.id, p.title, p.description, p.created, p.modified FROM user_permissions up JOIN permissions p ON p.title = up.permission WHERE up.user_id = :user_id ORDER BY p.title`
+	userPermissionsSQL         = `SELECT DISTINCT p.id, p.title, p.description, p.namespace, p.protected, p.created, p.modified FROM user_permissions up JOIN permissions p ON p.title = up.permission WHERE up.user_id = :user_id ORDER BY p.title`
 	defaultRolesSQL            = `SELECT id FROM roles WHERE is_default = 't' OR is_default = true`
 	updateUserPasswordSQL      = `UPDATE users SET password = :password, modified = :modified WHERE id = :id`
 	updateUserLastLoginSQL     = `UPDATE users SET last_login = :last_login, modified = :modified WHERE id = :id`
 	updateUserEmailVerifiedSQL = `UPDATE users SET email_verified = :email_verified, modified = :modified WHERE id = :id`
+	updateUserEmailSQL         = `UPDATE users SET email = :email, modified = :modified WHERE id = :id`
+	updateUserStatusSQL        = `UPDATE users SET status = :status, deleted = :deleted, modified = :modified WHERE id = :id`
+	updateUserMFASQL           = `UPDATE users SET totp_secret = :totp_secret, totp_enabled = :totp_enabled, recovery_codes = :recovery_codes, modified = :modified WHERE id = :id`
+	updateUserTOTPStepSQL      = `UPDATE users SET totp_step = :totp_step, modified = :modified WHERE id = :id AND (totp_step IS NULL OR totp_step < :totp_step)`
+	purgeUsersSQL              = `DELETE FROM users WHERE status = 'deleted' AND deleted < :deleted_before RETURNING id`
 	deleteUserRoleSQL          = `DELETE FROM user_roles WHERE user_id = :user_id AND role_id = :role_id`
 	deleteUserRolesByUserSQL   = `DELETE FROM user_roles WHERE user_id = :user_id`
 )
@@ -85,12 +91,39 @@ func (s *Store) VerifyEmail(ctx context.Context, userID ulid.ULID) error {
 	})
 }
 
+func (s *Store) UpdateMFA(ctx context.Context, user *models.User) error {
+	return s.WithTx(ctx, nil, func(t txn.Tx) error {
+		return t.UpdateMFA(user)
+	})
+}
+
+func (s *Store) UpdateTOTPStep(ctx context.Context, userID ulid.ULID, step int64) error {
+	return s.WithTx(ctx, nil, func(t txn.Tx) error {
+		return t.UpdateTOTPStep(userID, step)
+	})
+}
+
+func (s *Store) UpdateUserStatus(ctx context.Context, userID ulid.ULID, status enum.UserStatus) error {
+	return s.WithTx(ctx, nil, func(t txn.Tx) error {
+		return t.UpdateUserStatus(userID, status)
+	})
+}
+
 func (s *Store) DeleteUser(ctx context.Context, userID ulid.ULID) error {
 	return s.WithTx(ctx, nil, func(t txn.Tx) error {
 		return t.DeleteUser(userID)
 	})
 }
 
+func (s *Store) PurgeUsers(ctx context.Context, deletedBefore time.Time) ([]ulid.ULID, error) {
+	var userIDs []ulid.ULID
+	err := s.WithTx(ctx, nil, func(t txn.Tx) (err error) {
+		userIDs, err = t.PurgeUsers(deletedBefore)
+		return err
+	})
+	return userIDs, err
+}
+
 func (s *Store) AddRoleToUser(ctx context.Context, userID ulid.ULID, roleID int64) error {
 	return s.WithTx(ctx, nil, func(t txn.Tx) error {
 		return t.AddRoleToUser(userID, roleID)
@@ -140,6 +173,10 @@ func (t *tx) CreateUser(user *models.User) (*models.User, error) {
 		return nil, qerrors.ErrNoIDOnCreate
 	}
 
+	if user.Status == enum.UserStatusUnknown {
+		user.Status = enum.UserStatusActive
+	}
+
 	if _, err := users.Create(t.tx, user); err != nil {
 		return nil, tidalErr(err)
 	}
@@ -245,6 +282,95 @@ func (t *tx) VerifyEmail(userID ulid.ULID) error {
 	return nil
 }
 
+// UpdateMFA stores the TOTP secret, enrollment confirmation, and recovery code hashes
+// of the user; all other user fields are ignored.
+func (t *tx) UpdateMFA(user *models.User) error {
+	if err := t.requireWrite(); err != nil {
+		return err
+	}
+	if user.ID.IsZero() {
+		return qerrors.ErrMissingID
+	}
+
+	user.Modified = time.Now().UTC()
+	result, err := t.tx.Exec(
+		updateUserMFASQL,
+		sql.Named("id", user.ID),
+		sql.Named("totp_secret", user.TOTPSecret),
+		sql.Named("totp_enabled", user.TOTPEnabled),
+		sql.Named("recovery_codes", user.RecoveryCodes),
+		sql.Named("modified", user.Modified),
+	)
+	if err != nil {
+		return tidalErr(err)
+	}
+	n, _ := result.RowsAffected()
+	if n == 0 {
+		return qerrors.ErrNotFound
+	}
+	return nil
+}
+
+// UpdateTOTPStep records the time step of the last TOTP code accepted for the user so
+// that the code cannot be used again. The update is conditional so that if the step is
+// not after the last accepted step, e.g. because a concurrent request used the same
+// code, ErrTOTPReused is returned.
+func (t *tx) UpdateTOTPStep(userID ulid.ULID, step int64) error {
+	if err := t.requireWrite(); err != nil {
+		return err
+	}
+	if userID.IsZero() {
+		return qerrors.ErrMissingID
+	}
+
+	result, err := t.tx.Exec(
+		updateUserTOTPStepSQL,
+		sql.Named("id", userID),
+		sql.Named("totp_step", step),
+		sql.Named("modified", time.Now().UTC()),
+	)
+	if err != nil {
+		return tidalErr(err)
+	}
+	n, _ := result.RowsAffected()
+	if n == 0 {
+		return qerrors.ErrTOTPReused
+	}
+	return nil
+}
+
+// UpdateUserStatus sets the status of the user; if the status is deleted then the user
+// is marked as deleted now so that they are purged after the retention period,
+// otherwise the deleted timestamp is cleared (e.g. when a deleted user is restored).
+func (t *tx) UpdateUserStatus(userID ulid.ULID, status enum.UserStatus) error {
+	if err := t.requireWrite(); err != nil {
+		return err
+	}
+	if userID.IsZero() {
+		return qerrors.ErrMissingID
+	}
+	if status == enum.UserStatusUnknown || !enum.ValidUserStatus(status) {
+		return qerrors.ErrInvalidStatus
+	}
+
+	now := time.Now().UTC()
+	result, err := t.tx.Exec(
+		updateUserStatusSQL,
+		sql.Named("id", userID),
+		sql.Named("status", status),
+		sql.Named("deleted", sql.NullTime{Time: now, Valid: status == enum.UserStatusDeleted}),
+		sql.Named("modified", now),
+	)
+	if err != nil {
+		return tidalErr(err)
+	}
+	n, _ := result.RowsAffected()
+	if n == 0 {
+		return qerrors.ErrNotFound
+	}
+	return nil
+}
+
 func (t *tx) DeleteUser(userID ulid.ULID) error {
 	if err := t.requireWrite(); err != nil {
 		return err
@@ -263,6 +389,29 @@ func (t *tx) DeleteUser(userID ulid.ULID) error {
 	return nil
 }
 
+// PurgeUsers permanently deletes the users that were deleted before the specified
+// time and returns their IDs; users that have not been deleted are never purged.
+func (t *tx) PurgeUsers(deletedBefore time.Time) ([]ulid.ULID, error) {
+	if err := t.requireWrite(); err != nil {
+		return nil, err
+	}
+	rows, err := t.tx.Query(purgeUsersSQL, sql.Named("deleted_before", deletedBefore.UTC()))
+	if err != nil {
+		return nil, tidalErr(err)
+	}
+	defer rows.Close()
+
+	userIDs := make([]ulid.ULID, 0)
+	for rows.Next() {
+		var userID ulid.ULID
+		if err = rows.Scan(&userID); err != nil {
+			return nil, tidalErr(err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, tidalErr(rows.Err())
+}
+
 func (t *tx) AddRoleToUser(userID ulid.ULID, roleID int64) error {
 	if err := t.requireWrite(); err != nil {
 		return err
@@ -4,6 +4,8 @@ import (
 	"database/sql"
 	"time"
 
+	"go.rtnl.ai/quarterdeck/pkg/api/v1"
+	"go.rtnl.ai/quarterdeck/pkg/enum"
 	"go.rtnl.ai/quarterdeck/pkg/errors"
 	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
 	"go.rtnl.ai/quarterdeck/pkg/store/v2/suitetest"
@@ -41,6 +43,62 @@ func (s *storeSuite) TestUserListWithRoleFilter() {
 	s.Len(users, 2)
 }
 
+// TestUserListPagination verifies that the API cursor filters page through every user
+// forwards and backwards without skipping or repeating users.
+func (s *storeSuite) TestUserListPagination() {
+	for _, sort := range []string{"-created", "email"} {
+		require := s.Require()
+		list := func(q *api.UserPageQuery) ([]*models.User, *api.Page) {
+			cursor, err := q.Cursor(ulid.Zero)
+			require.NoError(err)
+
+			rows, err := s.store.ListUsers(s.Context(), cursor.Filter())
+			require.NoError(err)
+			defer func() { require.NoError(rows.Close()) }()
+
+			users, err := rows.List()
+			require.NoError(err)
+			return api.Paginate(cursor, users, func(u *models.User) ulid.ULID { return u.ID })
+		}
+
+		// Page forwards through all of the users.
+		q := &api.UserPageQuery{PageQuery: api.PageQuery{PageSize: 2}, Sort: sort}
+		forward := make([]ulid.ULID, 0, 5)
+		var page *api.Page
+		for {
+			var users []*models.User
+			users, page = list(q)
+			for _, user := range users {
+				forward = append(forward, user.ID)
+			}
+
+			if page.NextPageToken == "" {
+				break
+			}
+			q.NextPageToken = page.NextPageToken
+		}
+		require.Len(forward, 5, "expected all users to be listed sorted by %s", sort)
+
+		// Page backwards from the last page to the first page.
+		backward := make([]ulid.ULID, 0, 5)
+		q = &api.UserPageQuery{PageQuery: api.PageQuery{PageSize: 2}, Sort: sort}
+		for page.PrevPageToken != "" {
+			var users []*models.User
+			q.PrevPageToken = page.PrevPageToken
+			users, page = list(q)
+			require.NotEmpty(page.NextPageToken, "a previous page should always have a next page")
+
+			ids := make([]ulid.ULID, 0, len(users))
+			for _, user := range users {
+				ids = append(ids, user.ID)
+			}
+			backward = append(ids, backward...)
+		}
+		require.Equal(forward[:len(backward)], backward, "expected previous pages to match the pages listed forwards")
+		require.Len(backward, 4, "expected all pages before the last page to be listed")
+	}
+}
+
 // TestCreateUser verifies validation, default role assignment, and duplicate email rejection.
 func (s *storeSuite) TestCreateUser() {
 	s.Run("NoIDOnCreate", func() {
@@ -417,3 +475,102 @@ func (s *storeSuite) TestDeleteUser() {
 	err = s.store.DeleteUser(s.Context(), userID)
 	require.ErrorIs(err, errors.ErrNotFound)
 }
+
+// TestUpdateUserStatus verifies deleting sets the deleted timestamp and restoring clears it.
+func (s *storeSuite) TestUpdateUserStatus() {
+	require := s.Require()
+	userID := ulid.MustParse("01JPYRNYMEHNEZCS0JYX1CP57A")
+
+	require.NoError(s.store.UpdateUserStatus(s.Context(), userID, enum.UserStatusDeleted))
+
+	user, err := s.store.RetrieveUser(s.Context(), userID)
+	require.NoError(err)
+	require.Equal(enum.UserStatusDeleted, user.Status)
+	require.True(user.Deleted.Valid)
+
+	require.NoError(s.store.UpdateUserStatus(s.Context(), userID, enum.UserStatusActive))
+
+	user, err = s.store.RetrieveUser(s.Context(), userID)
+	require.NoError(err)
+	require.Equal(enum.UserStatusActive, user.Status)
+	require.False(user.Deleted.Valid)
+
+	err = s.store.UpdateUserStatus(s.Context(), userID, enum.UserStatusUnknown)
+	require.ErrorIs(err, errors.ErrInvalidStatus)
+
+	err = s.store.UpdateUserStatus(s.Context(), ulid.MakeSecure(), enum.UserStatusSuspended)
+	require.ErrorIs(err, errors.ErrNotFound)
+}
+
+// TestUpdateMFA verifies the TOTP secret and recovery codes are stored and cleared.
+func (s *storeSuite) TestUpdateMFA() {
+	require := s.Require()
+	userID := ulid.MustParse("01JPYRNYMEHNEZCS0JYX1CP57A")
+
+	user, err := s.store.RetrieveUser(s.Context(), userID)
+	require.NoError(err)
+	require.False(user.MFAEnabled())
+
+	user.TOTPSecret = sql.NullString{String: "JBSWY3DPEHPK3PXP", Valid: true}
+	user.EnableTOTP([]string{"hash1", "hash2"})
+	require.NoError(s.store.UpdateMFA(s.Context(), user))
+
+	user, err = s.store.RetrieveUser(s.Context(), userID)
+	require.NoError(err)
+	require.True(user.MFAEnabled())
+	require.Equal([]string{"hash1", "hash2"}, []string(user.RecoveryCodes))
+
+	user.DisableTOTP()
+	require.NoError(s.store.UpdateMFA(s.Context(), user))
+
+	user, err = s.store.RetrieveUser(s.Context(), userID)
+	require.NoError(err)
+	require.False(user.MFAEnabled())
+	require.Empty(user.RecoveryCodes)
+
+	err = s.store.UpdateMFA(s.Context(), &models.User{})
+	require.ErrorIs(err, errors.ErrMissingID)
+
+	missing := &models.User{}
+	missing.ID = ulid.MakeSecure()
+	err = s.store.UpdateMFA(s.Context(), missing)
+	require.ErrorIs(err, errors.ErrNotFound)
+}
+
+// TestUpdateTOTPStep verifies a TOTP step is only accepted if it is after the last accepted step.
+func (s *storeSuite) TestUpdateTOTPStep() {
+	require := s.Require()
+	userID := ulid.MustParse("01JPYRNYMEHNEZCS0JYX1CP57A")
+
+	require.NoError(s.store.UpdateTOTPStep(s.Context(), userID, 58000000))
+	require.ErrorIs(s.store.UpdateTOTPStep(s.Context(), userID, 58000000), errors.ErrTOTPReused)
+	require.ErrorIs(s.store.UpdateTOTPStep(s.Context(), userID, 57999999), errors.ErrTOTPReused)
+	require.NoError(s.store.UpdateTOTPStep(s.Context(), userID, 58000001))
+
+	user, err := s.store.RetrieveUser(s.Context(), userID)
+	require.NoError(err)
+	require.Equal(int64(58000001), user.TOTPStep.Int64)
+
+	require.ErrorIs(s.store.UpdateTOTPStep(s.Context(), ulid.Zero, 58000002), errors.ErrMissingID)
+}
+
+// TestPurgeUsers verifies only users deleted before the cutoff are purged.
+func (s *storeSuite) TestPurgeUsers() {
+	require := s.Require()
+	userCount := s.count("users")
+
+	deletedID := ulid.MustParse("01JPYRNYMEHNEZCS0JYX1CP57A")
+	suspendedID := ulid.MustParse("01JQNPQ1CHG36SV7NRQKTZB20R")
+	require.NoError(s.store.UpdateUserStatus(s.Context(), deletedID, enum.UserStatusDeleted))
+	require.NoError(s.store.UpdateUserStatus(s.Context(), suspendedID, enum.UserStatusSuspended))
+
+	purged, err := s.store.PurgeUsers(s.Context(), time.Now().Add(-1*time.Hour))
+	require.NoError(err)
+	require.Empty(purged)
+	require.Equal(userCount, s.count("users"))
+
+	purged, err = s.store.PurgeUsers(s.Context(), time.Now().Add(1*time.Hour))
+	require.NoError(err)
+	require.Equal([]ulid.ULID{deletedID}, purged)
+	require.Equal(userCount-1, s.count("users"))
+}
@@ -15,7 +15,12 @@ import (
 
 var veroTokens = tidal.New[*models.VeroToken]("vero_tokens")
 
-const retrieveVeroByResourceSQL = `SELECT id, token_type, resource_id, email, expiration, signature, sent_on, created, modified FROM vero_tokens WHERE resource_id = :resource_id AND token_type = :token_type`
+const (
+	retrieveVeroByResourceSQL = `SELECT id, token_type, resource_id, email, expiration, signature, sent_on, created, modified FROM vero_tokens WHERE resource_id = :resource_id AND token_type = :token_type`
+	listVeroByTypeSQL         = `SELECT id, token_type, resource_id, email, expiration, signature, sent_on, created, modified FROM vero_tokens WHERE token_type = :token_type ORDER BY created DESC`
+	deleteVeroByResourceSQL   = `DELETE FROM vero_tokens WHERE resource_id = :resource_id AND token_type = :token_type`
+	consumeVeroSQL            = `DELETE FROM vero_tokens WHERE id = :id AND token_type = :token_type AND expiration > :now`
+)
 
 //===========================================================================
 // Store Methods
@@ -48,6 +53,24 @@ func (s *Store) CreateTeamInviteVeroToken(ctx context.Context, token *models.Ver
 	return created, err
 }
 
+func (s *Store) CreateVerifyEmailVeroToken(ctx context.Context, token *models.VeroToken) (*models.VeroToken, error) {
+	var created *models.VeroToken
+	err := s.WithTx(ctx, nil, func(t txn.Tx) (err error) {
+		created, err = t.CreateVerifyEmailVeroToken(token)
+		return err
+	})
+	return created, err
+}
+
+func (s *Store) CreateChangeEmailVeroToken(ctx context.Context, token *models.VeroToken) (*models.VeroToken, error) {
+	var created *models.VeroToken
+	err := s.WithTx(ctx, nil, func(t txn.Tx) (err error) {
+		created, err = t.CreateChangeEmailVeroToken(token)
+		return err
+	})
+	return created, err
+}
+
 func (s *Store) RetrieveVeroToken(ctx context.Context, id ulid.ULID) (*models.VeroToken, error) {
 	var token *models.VeroToken
 	err := s.WithReadTx(ctx, func(t txn.Tx) (err error) {
@@ -66,6 +89,15 @@ func (s *Store) RetrieveVeroTokenByResource(ctx context.Context, resourceID ulid
 	return token, err
 }
 
+func (s *Store) ListVeroTokensByType(ctx context.Context, tokenType enum.TokenType) ([]*models.VeroToken, error) {
+	var tokens []*models.VeroToken
+	err := s.WithReadTx(ctx, func(t txn.Tx) (err error) {
+		tokens, err = t.ListVeroTokensByType(tokenType)
+		return err
+	})
+	return tokens, err
+}
+
 func (s *Store) UpdateVeroToken(ctx context.Context, token *models.VeroToken) error {
 	return s.WithTx(ctx, nil, func(t txn.Tx) error {
 		return t.UpdateVeroToken(token)
@@ -78,12 +110,24 @@ func (s *Store) DeleteVeroToken(ctx context.Context, id ulid.ULID) error {
 	})
 }
 
+func (s *Store) ConsumeVeroToken(ctx context.Context, id ulid.ULID, tokenType enum.TokenType) error {
+	return s.WithTx(ctx, nil, func(t txn.Tx) error {
+		return t.ConsumeVeroToken(id, tokenType)
+	})
+}
+
 func (s *Store) CompletePasswordReset(ctx context.Context, veroTokenID ulid.ULID, newPassword string) error {
 	return s.WithTx(ctx, nil, func(t txn.Tx) error {
 		return t.CompletePasswordReset(veroTokenID, newPassword)
 	})
 }
 
+func (s *Store) CompleteEmailChange(ctx context.Context, veroTokenID ulid.ULID) error {
+	return s.WithTx(ctx, nil, func(t txn.Tx) error {
+		return t.CompleteEmailChange(veroTokenID)
+	})
+}
+
 //===========================================================================
 // Tx Methods
 //===========================================================================
@@ -111,6 +155,37 @@ func (t *tx) CreateTeamInviteVeroToken(token *models.VeroToken) (*models.VeroTok
 	return t.createResourceVeroToken(token, enum.TokenTypeTeamInvite)
 }
 
+func (t *tx) CreateVerifyEmailVeroToken(token *models.VeroToken) (*models.VeroToken, error) {
+	return t.createResourceVeroToken(token, enum.TokenTypeVerifyEmail)
+}
+
+// CreateChangeEmailVeroToken replaces any pending email change for the user, whether
+// or not it has expired, so that only the most recently requested address can be confirmed.
+func (t *tx) CreateChangeEmailVeroToken(token *models.VeroToken) (*models.VeroToken, error) {
+	if err := t.requireWrite(); err != nil {
+		return nil, err
+	}
+	if !token.ID.IsZero() {
+		return nil, errors.ErrNoIDOnCreate
+	}
+	if token.TokenType != enum.TokenTypeChangeEmail {
+		return nil, errors.ErrTypeMismatch
+	}
+	if !token.ResourceID.Valid || token.ResourceID.ULID.IsZero() {
+		return nil, errors.ErrMissingReference
+	}
+
+	if err := t.deleteVeroTokensByResource(token.ResourceID.ULID, enum.TokenTypeChangeEmail); err != nil {
+		return nil, err
+	}
+
+	if _, err := veroTokens.Create(t.tx, token); err != nil {
+		return nil, tidalErr(err)
+	}
+
+	return t.retrieveVeroToken(token.ID)
+}
+
 func (t *tx) RetrieveVeroToken(id ulid.ULID) (*models.VeroToken, error) {
 	return t.retrieveVeroToken(id)
 }
@@ -119,6 +194,26 @@ func (t *tx) RetrieveVeroTokenByResource(resourceID ulid.ULID, tokenType enum.To
 	return t.findVeroToken(resourceID, tokenType)
 }
 
+// ListVeroTokensByType returns the tokens of the type that have not been used or
+// deleted (including expired tokens), most recently created first.
+func (t *tx) ListVeroTokensByType(tokenType enum.TokenType) ([]*models.VeroToken, error) {
+	rows, err := t.tx.Query(listVeroByTypeSQL, sql.Named("token_type", tokenType))
+	if err != nil {
+		return nil, tidalErr(err)
+	}
+	defer rows.Close()
+
+	tokens := make([]*models.VeroToken, 0)
+	for rows.Next() {
+		token := &models.VeroToken{}
+		if err = token.Scan(tidal.Retrieve, rows); err != nil {
+			return nil, tidalErr(err)
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, tidalErr(rows.Err())
+}
+
 func (t *tx) UpdateVeroToken(token *models.VeroToken) error {
 	if err := t.requireWrite(); err != nil {
 		return err
@@ -144,6 +239,37 @@ func (t *tx) DeleteVeroToken(id ulid.ULID) error {
 	return nil
 }
 
+// ConsumeVeroToken deletes the token only if it has the type and has not expired. The
+// delete is conditional so that if concurrent requests use the same token only one of
+// them succeeds; the others get ErrNotFound and must roll back their transaction.
+func (t *tx) ConsumeVeroToken(id ulid.ULID, tokenType enum.TokenType) error {
+	if err := t.requireWrite(); err != nil {
+		return err
+	}
+	if id.IsZero() {
+		return errors.ErrMissingID
+	}
+
+	result, err := t.tx.Exec(
+		consumeVeroSQL,
+		sql.Named("id", id),
+		sql.Named("token_type", tokenType),
+		sql.Named("now", time.Now().UTC()),
+	)
+	if err != nil {
+		return tidalErr(err)
+	}
+
+	var n int64
+	if n, err = result.RowsAffected(); err != nil {
+		return tidalErr(err)
+	}
+	if n == 0 {
+		return errors.ErrNotFound
+	}
+	return nil
+}
+
 func (t *tx) CompletePasswordReset(veroTokenID ulid.ULID, newPassword string) error {
 	if err := t.requireWrite(); err != nil {
 		return err
@@ -181,6 +307,52 @@ func (t *tx) CompletePasswordReset(veroTokenID ulid.ULID, newPassword string) er
 	return t.deleteVeroToken(token.ID)
 }
 
+// CompleteEmailChange sets the user's email to the address of a change-email or
+// revert-email token without modifying email_verified, then deletes the token.
+// Reverting also deletes any pending email change for the user.
+func (t *tx) CompleteEmailChange(veroTokenID ulid.ULID) error {
+	if err := t.requireWrite(); err != nil {
+		return err
+	}
+
+	token, err := t.retrieveVeroToken(veroTokenID)
+	if err != nil {
+		return err
+	}
+
+	if token.TokenType != enum.TokenTypeChangeEmail && token.TokenType != enum.TokenTypeRevertEmail {
+		return errors.ErrTypeMismatch
+	}
+	if token.IsExpired() {
+		return errors.ErrExpiredToken
+	}
+	if !token.ResourceID.Valid || token.ResourceID.ULID.IsZero() {
+		return errors.ErrMissingReference
+	}
+
+	result, err := t.tx.Exec(
+		updateUserEmailSQL,
+		sql.Named("id", token.ResourceID.ULID),
+		sql.Named("email", token.Email),
+		sql.Named("modified", time.Now().UTC()),
+	)
+	if err != nil {
+		return tidalErr(err)
+	}
+	n, _ := result.RowsAffected()
+	if n == 0 {
+		return errors.ErrNotFound
+	}
+
+	if token.TokenType == enum.TokenTypeRevertEmail {
+		if err = t.deleteVeroTokensByResource(token.ResourceID.ULID, enum.TokenTypeChangeEmail); err != nil {
+			return err
+		}
+	}
+
+	return t.deleteVeroToken(token.ID)
+}
+
 //===========================================================================
 // Helpers
 //===========================================================================
@@ -244,3 +416,12 @@ func (t *tx) deleteVeroToken(id ulid.ULID) error {
 	_, err := veroTokens.Delete(t.tx, sql.Named("id", id))
 	return tidalErr(err)
 }
+
+func (t *tx) deleteVeroTokensByResource(resourceID ulid.ULID, tokenType enum.TokenType) error {
+	_, err := t.tx.Exec(
+		deleteVeroByResourceSQL,
+		sql.Named("resource_id", ulid.NullULID{Valid: true, ULID: resourceID}),
+		sql.Named("token_type", tokenType),
+	)
+	return tidalErr(err)
+}
@@ -195,6 +195,49 @@ func (s *storeSuite) TestDeleteVeroToken() {
 	})
 }
 
+// TestConsumeVeroToken verifies a token can only be consumed once, before it expires, and
+// only as the type it was created for.
+func (s *storeSuite) TestConsumeVeroToken() {
+	require := s.Require()
+
+	create := func(expiration time.Time) *models.VeroToken {
+		token, err := s.store.CreateTeamInviteVeroToken(s.Context(), &models.VeroToken{
+			TokenType:  enum.TokenTypeTeamInvite,
+			ResourceID: ulid.NullULID{Valid: true, ULID: ulid.MakeSecure()},
+			Email:      "invite@example.com",
+			Expiration: expiration,
+		})
+		require.NoError(err)
+		return token
+	}
+
+	s.Run("HappyPath", func() {
+		token := create(time.Now().Add(48 * time.Hour))
+		require.NoError(s.store.ConsumeVeroToken(s.Context(), token.ID, enum.TokenTypeTeamInvite))
+
+		// The token has been deleted so it cannot be consumed again.
+		require.ErrorIs(s.store.ConsumeVeroToken(s.Context(), token.ID, enum.TokenTypeTeamInvite), errors.ErrNotFound)
+		_, err := s.store.RetrieveVeroToken(s.Context(), token.ID)
+		require.ErrorIs(err, errors.ErrNotFound)
+	})
+
+	s.Run("WrongType", func() {
+		token := create(time.Now().Add(48 * time.Hour))
+		require.ErrorIs(s.store.ConsumeVeroToken(s.Context(), token.ID, enum.TokenTypeResetPassword), errors.ErrNotFound)
+		_, err := s.store.RetrieveVeroToken(s.Context(), token.ID)
+		require.NoError(err, "the token should not be deleted")
+	})
+
+	s.Run("Expired", func() {
+		token := create(time.Now().Add(-time.Hour))
+		require.ErrorIs(s.store.ConsumeVeroToken(s.Context(), token.ID, enum.TokenTypeTeamInvite), errors.ErrNotFound)
+	})
+
+	s.Run("NoID", func() {
+		require.ErrorIs(s.store.ConsumeVeroToken(s.Context(), ulid.Zero, enum.TokenTypeTeamInvite), errors.ErrMissingID)
+	})
+}
+
 // TestCreateResetPasswordVeroToken verifies reset-password-specific validation and rate limiting.
 func (s *storeSuite) TestCreateResetPasswordVeroToken() {
 	require := s.Require()
@@ -398,3 +441,185 @@ func (s *storeSuite) TestRetrieveTeamInviteVeroToken() {
 	_, err = s.store.RetrieveVeroTokenByResource(s.Context(), ulid.Make(), enum.TokenTypeTeamInvite)
 	require.ErrorIs(err, errors.ErrNotFound)
 }
+
+func (s *storeSuite) TestListVeroTokensByType() {
+	require := s.Require()
+
+	// Setup: the fixtures only contain a reset-password token.
+	invites, err := s.store.ListVeroTokensByType(s.Context(), enum.TokenTypeTeamInvite)
+	require.NoError(err)
+	require.Empty(invites)
+
+	// Action: create a team-invite token for a new user resource.
+	created, err := s.store.CreateTeamInviteVeroToken(s.Context(), &models.VeroToken{
+		TokenType:  enum.TokenTypeTeamInvite,
+		ResourceID: ulid.NullULID{Valid: true, ULID: ulid.MakeSecure()},
+		Email:      "invite@example.com",
+		Expiration: time.Now().Add(48 * time.Hour),
+	})
+	require.NoError(err)
+
+	// Assert: only the team-invite token is listed.
+	invites, err = s.store.ListVeroTokensByType(s.Context(), enum.TokenTypeTeamInvite)
+	require.NoError(err)
+	require.Len(invites, 1)
+	require.Equal(created.ID, invites[0].ID)
+}
+
+// TestCreateVerifyEmailVeroToken verifies at most one unexpired verify-email token per user.
+func (s *storeSuite) TestCreateVerifyEmailVeroToken() {
+	require := s.Require()
+
+	// Setup + action: create a verify-email token for a new user resource.
+	userID := ulid.MakeSecure()
+	created, err := s.store.CreateVerifyEmailVeroToken(s.Context(), &models.VeroToken{
+		TokenType:  enum.TokenTypeVerifyEmail,
+		ResourceID: ulid.NullULID{Valid: true, ULID: userID},
+		Email:      "verify@example.com",
+		Expiration: time.Now().Add(24 * time.Hour),
+	})
+	require.NoError(err)
+
+	got, err := s.store.RetrieveVeroTokenByResource(s.Context(), userID, enum.TokenTypeVerifyEmail)
+	require.NoError(err)
+	require.Equal(created.ID, got.ID)
+
+	// Assert: a second unexpired token cannot be created.
+	_, err = s.store.CreateVerifyEmailVeroToken(s.Context(), &models.VeroToken{
+		TokenType:  enum.TokenTypeVerifyEmail,
+		ResourceID: ulid.NullULID{Valid: true, ULID: userID},
+		Email:      "verify@example.com",
+		Expiration: time.Now().Add(24 * time.Hour),
+	})
+	require.ErrorIs(err, errors.ErrTooSoon)
+}
+
+// TestCreateChangeEmailVeroToken verifies a new email change replaces the pending one.
+func (s *storeSuite) TestCreateChangeEmailVeroToken() {
+	require := s.Require()
+
+	// Setup: a pending email change for a new user resource.
+	userID := ulid.MakeSecure()
+	first, err := s.store.CreateChangeEmailVeroToken(s.Context(), &models.VeroToken{
+		TokenType:  enum.TokenTypeChangeEmail,
+		ResourceID: ulid.NullULID{Valid: true, ULID: userID},
+		Email:      "typo@exmaple.com",
+		Expiration: time.Now().Add(24 * time.Hour),
+	})
+	require.NoError(err)
+
+	// Action: request another email change before the first one expires.
+	second, err := s.store.CreateChangeEmailVeroToken(s.Context(), &models.VeroToken{
+		TokenType:  enum.TokenTypeChangeEmail,
+		ResourceID: ulid.NullULID{Valid: true, ULID: userID},
+		Email:      "fixed@example.com",
+		Expiration: time.Now().Add(24 * time.Hour),
+	})
+	require.NoError(err)
+
+	// Assert: only the most recent email change remains.
+	_, err = s.store.RetrieveVeroToken(s.Context(), first.ID)
+	require.ErrorIs(err, errors.ErrNotFound)
+
+	got, err := s.store.RetrieveVeroTokenByResource(s.Context(), userID, enum.TokenTypeChangeEmail)
+	require.NoError(err)
+	require.Equal(second.ID, got.ID)
+	require.Equal("fixed@example.com", got.Email)
+
+	_, err = s.store.CreateChangeEmailVeroToken(s.Context(), &models.VeroToken{
+		TokenType:  enum.TokenTypeVerifyEmail,
+		ResourceID: ulid.NullULID{Valid: true, ULID: userID},
+	})
+	require.ErrorIs(err, errors.ErrTypeMismatch)
+}
+
+// TestCompleteEmailChange verifies email change and revert, token cleanup, and error cases.
+func (s *storeSuite) TestCompleteEmailChange() {
+	require := s.Require()
+	userID := ulid.MustParse("01JPYRNYMEHNEZCS0JYX1CP57A")
+
+	s.Run("TypeMismatch", func() {
+		// Setup: reset-password token (wrong type for email change).
+		created, err := s.store.CreateVeroToken(s.Context(), &models.VeroToken{
+			TokenType:  enum.TokenTypeResetPassword,
+			ResourceID: ulid.NullULID{ULID: userID, Valid: true},
+			Email:      "gary@example.com",
+			Expiration: time.Now().Add(24 * time.Hour),
+		})
+		require.NoError(err)
+
+		err = s.store.CompleteEmailChange(s.Context(), created.ID)
+		require.ErrorIs(err, errors.ErrTypeMismatch)
+	})
+
+	s.Run("Expired", func() {
+		created, err := s.store.CreateChangeEmailVeroToken(s.Context(), &models.VeroToken{
+			TokenType:  enum.TokenTypeChangeEmail,
+			ResourceID: ulid.NullULID{ULID: userID, Valid: true},
+			Email:      "expired@example.com",
+			Expiration: time.Now().Add(-time.Hour),
+		})
+		require.NoError(err)
+
+		err = s.store.CompleteEmailChange(s.Context(), created.ID)
+		require.ErrorIs(err, errors.ErrExpiredToken)
+	})
+
+	s.Run("AlreadyExists", func() {
+		// Setup: change to the email address of another fixture user.
+		created, err := s.store.CreateChangeEmailVeroToken(s.Context(), &models.VeroToken{
+			TokenType:  enum.TokenTypeChangeEmail,
+			ResourceID: ulid.NullULID{ULID: userID, Valid: true},
+			Email:      "admin@example.com",
+			Expiration: time.Now().Add(24 * time.Hour),
+		})
+		require.NoError(err)
+
+		err = s.store.CompleteEmailChange(s.Context(), created.ID)
+		require.ErrorIs(err, errors.ErrAlreadyExists)
+	})
+
+	s.Run("HappyPath", func() {
+		before, err := s.store.RetrieveUser(s.Context(), userID)
+		require.NoError(err)
+
+		// Setup: a pending email change and a revert link to the old address.
+		change, err := s.store.CreateChangeEmailVeroToken(s.Context(), &models.VeroToken{
+			TokenType:  enum.TokenTypeChangeEmail,
+			ResourceID: ulid.NullULID{ULID: userID, Valid: true},
+			Email:      "gary.redfield@example.com",
+			Expiration: time.Now().Add(24 * time.Hour),
+		})
+		require.NoError(err)
+
+		revert, err := s.store.CreateVeroToken(s.Context(), &models.VeroToken{
+			TokenType:  enum.TokenTypeRevertEmail,
+			ResourceID: ulid.NullULID{ULID: userID, Valid: true},
+			Email:      "gary@example.com",
+			Expiration: time.Now().Add(24 * time.Hour),
+		})
+		require.NoError(err)
+
+		// Action: confirm the email change.
+		require.NoError(s.store.CompleteEmailChange(s.Context(), change.ID))
+
+		// Assert: email updated, email_verified preserved, and token removed.
+		user, err := s.store.RetrieveUser(s.Context(), userID)
+		require.NoError(err)
+		require.Equal("gary.redfield@example.com", user.Email)
+		require.Equal(before.EmailVerified, user.EmailVerified)
+
+		_, err = s.store.RetrieveVeroToken(s.Context(), change.ID)
+		require.ErrorIs(err, errors.ErrNotFound)
+
+		// Action: revert the email change from the old address.
+		require.NoError(s.store.CompleteEmailChange(s.Context(), revert.ID))
+
+		user, err = s.store.RetrieveUser(s.Context(), userID)
+		require.NoError(err)
+		require.Equal("gary@example.com", user.Email)
+
+		_, err = s.store.RetrieveVeroToken(s.Context(), revert.ID)
+		require.ErrorIs(err, errors.ErrNotFound)
+	})
+}
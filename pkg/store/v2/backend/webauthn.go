@@ -0,0 +1,122 @@
+package backend
+
+import (
+	"context"
+	"database/sql"
+
+	qerrors "go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/txn"
+	"go.rtnl.ai/tidal"
+	"go.rtnl.ai/ulid"
+)
+
+var webauthnCredentials = tidal.New[*models.WebAuthnCredential]("webauthn_credentials")
+
+//===========================================================================
+// Store Methods
+//===========================================================================
+
+func (s *Store) ListWebAuthnCredentials(ctx context.Context, filter tidal.ListFilter) (tidal.Cursor[*models.WebAuthnCredential], error) {
+	return list(s, ctx, webauthnCredentials, filter)
+}
+
+func (s *Store) CreateWebAuthnCredential(ctx context.Context, credential *models.WebAuthnCredential) (*models.WebAuthnCredential, error) {
+	var created *models.WebAuthnCredential
+	err := s.WithTx(ctx, nil, func(t txn.Tx) (err error) {
+		created, err = t.CreateWebAuthnCredential(credential)
+		return err
+	})
+	return created, err
+}
+
+func (s *Store) RetrieveWebAuthnCredential(ctx context.Context, credentialID []byte) (*models.WebAuthnCredential, error) {
+	var credential *models.WebAuthnCredential
+	err := s.WithReadTx(ctx, func(t txn.Tx) (err error) {
+		credential, err = t.RetrieveWebAuthnCredential(credentialID)
+		return err
+	})
+	return credential, err
+}
+
+func (s *Store) UpdateWebAuthnCredential(ctx context.Context, credential *models.WebAuthnCredential) error {
+	return s.WithTx(ctx, nil, func(t txn.Tx) error {
+		return t.UpdateWebAuthnCredential(credential)
+	})
+}
+
+func (s *Store) DeleteWebAuthnCredential(ctx context.Context, id ulid.ULID) error {
+	return s.WithTx(ctx, nil, func(t txn.Tx) error {
+		return t.DeleteWebAuthnCredential(id)
+	})
+}
+
+//===========================================================================
+// Tx Methods
+//===========================================================================
+
+// ListWebAuthnCredentials returns a cursor over passkeys matching filter.
+// [tidal.Cursor.Close] rolls back the transaction; use [tidal.Cursor.CloseRows] to
+// release the result set and continue using this transaction.
+func (t *tx) ListWebAuthnCredentials(filter tidal.ListFilter) (tidal.Cursor[*models.WebAuthnCredential], error) {
+	return listInTx(t, webauthnCredentials, filter)
+}
+
+func (t *tx) CreateWebAuthnCredential(credential *models.WebAuthnCredential) (*models.WebAuthnCredential, error) {
+	if err := t.requireWrite(); err != nil {
+		return nil, err
+	}
+	if !credential.ID.IsZero() {
+		return nil, qerrors.ErrNoIDOnCreate
+	}
+
+	if _, err := webauthnCredentials.Create(t.tx, credential); err != nil {
+		return nil, tidalErr(err)
+	}
+
+	return t.retrieveWebAuthnCredential(credential.ID)
+}
+
+func (t *tx) RetrieveWebAuthnCredential(credentialID []byte) (*models.WebAuthnCredential, error) {
+	if len(credentialID) == 0 {
+		return nil, qerrors.ErrMissingID
+	}
+	return retrieveBy(t, webauthnCredentials, "credential_id", credentialID)
+}
+
+func (t *tx) UpdateWebAuthnCredential(credential *models.WebAuthnCredential) error {
+	if err := t.requireWrite(); err != nil {
+		return err
+	}
+	return tidalErr(webauthnCredentials.Update(t.tx, credential))
+}
+
+func (t *tx) DeleteWebAuthnCredential(id ulid.ULID) error {
+	if err := t.requireWrite(); err != nil {
+		return err
+	}
+	if id.IsZero() {
+		return qerrors.ErrMissingID
+	}
+	result, err := webauthnCredentials.Delete(t.tx, sql.Named("id", id))
+	if err != nil {
+		return tidalErr(err)
+	}
+	n, _ := result.RowsAffected()
+	if n == 0 {
+		return qerrors.ErrNotFound
+	}
+	return nil
+}
+
+//===========================================================================
+// Helpers
+//===========================================================================
+
+func (t *tx) retrieveWebAuthnCredential(id ulid.ULID) (*models.WebAuthnCredential, error) {
+	credential, err := webauthnCredentials.Retrieve(t.tx, sql.Named("id", id))
+	if err != nil {
+		return nil, tidalErr(err)
+	}
+	return credential, nil
+}
@@ -0,0 +1,106 @@
+package backend_test
+
+import (
+	"database/sql"
+	"time"
+
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/tidal"
+	"go.rtnl.ai/ulid"
+)
+
+//=============================================================================
+// WebAuthn Credential Store Tests
+//=============================================================================
+
+// TestWebAuthnCredentialLifecycle verifies a passkey can be registered, used to log in, and removed.
+func (s *storeSuite) TestWebAuthnCredentialLifecycle() {
+	require := s.Require()
+	userID := ulid.MustParse(keyholderUserULID)
+
+	// Setup: register a passkey for the user.
+	credential := &models.WebAuthnCredential{
+		UserID:          userID,
+		Name:            sql.NullString{String: "YubiKey", Valid: true},
+		CredentialID:    []byte("credential-lifecycle"),
+		PublicKey:       []byte("public-key"),
+		AttestationType: sql.NullString{String: "none", Valid: true},
+		Transports:      []string{"usb", "nfc"},
+		Flags:           0x1d,
+	}
+
+	created, err := s.store.CreateWebAuthnCredential(s.Context(), credential)
+	require.NoError(err)
+	require.False(created.ID.IsZero())
+	require.Equal([]string{"usb", "nfc"}, []string(created.Transports))
+	require.False(created.LastUsed.Valid)
+
+	// Assert: the credential ID is unique across all users.
+	duplicate := &models.WebAuthnCredential{UserID: userID, CredentialID: credential.CredentialID, PublicKey: []byte("other")}
+	_, err = s.store.CreateWebAuthnCredential(s.Context(), duplicate)
+	require.ErrorIs(err, errors.ErrAlreadyExists)
+
+	// Action: list the passkeys belonging to the user.
+	cursor, err := s.store.ListWebAuthnCredentials(s.Context(), (&tidal.Filter{}).Where("user_id", tidal.Eq, userID))
+	require.NoError(err)
+	credentials, err := cursor.List()
+	require.NoError(err)
+	require.NoError(cursor.Close())
+	require.Len(credentials, 1)
+	require.Equal(created.ID, credentials[0].ID)
+
+	// Action: record a login with the passkey.
+	retrieved, err := s.store.RetrieveWebAuthnCredential(s.Context(), credential.CredentialID)
+	require.NoError(err)
+	require.Equal(created.ID, retrieved.ID)
+	require.Equal(credential.PublicKey, retrieved.PublicKey)
+
+	retrieved.SignCount = 42
+	retrieved.LastUsed = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+	require.NoError(s.store.UpdateWebAuthnCredential(s.Context(), retrieved))
+
+	retrieved, err = s.store.RetrieveWebAuthnCredential(s.Context(), credential.CredentialID)
+	require.NoError(err)
+	require.Equal(uint32(42), retrieved.SignCount)
+	require.True(retrieved.LastUsed.Valid)
+
+	// Action: remove the passkey.
+	require.NoError(s.store.DeleteWebAuthnCredential(s.Context(), created.ID))
+
+	_, err = s.store.RetrieveWebAuthnCredential(s.Context(), credential.CredentialID)
+	require.ErrorIs(err, errors.ErrNotFound)
+}
+
+// TestWebAuthnCredentialErrors verifies validation and not found errors.
+func (s *storeSuite) TestWebAuthnCredentialErrors() {
+	require := s.Require()
+
+	s.Run("NoIDOnCreate", func() {
+		credential := &models.WebAuthnCredential{UserID: ulid.MustParse(keyholderUserULID), CredentialID: []byte("id"), PublicKey: []byte("key")}
+		credential.ID = ulid.MakeSecure()
+		_, err := s.store.CreateWebAuthnCredential(s.Context(), credential)
+		require.ErrorIs(err, errors.ErrNoIDOnCreate)
+	})
+
+	s.Run("ZeroValuedNotNull", func() {
+		_, err := s.store.CreateWebAuthnCredential(s.Context(), &models.WebAuthnCredential{UserID: ulid.MustParse(keyholderUserULID), PublicKey: []byte("key")})
+		require.ErrorIs(err, errors.ErrZeroValuedNotNull)
+	})
+
+	s.Run("MissingID", func() {
+		_, err := s.store.RetrieveWebAuthnCredential(s.Context(), nil)
+		require.ErrorIs(err, errors.ErrMissingID)
+
+		err = s.store.DeleteWebAuthnCredential(s.Context(), ulid.Zero)
+		require.ErrorIs(err, errors.ErrMissingID)
+	})
+
+	s.Run("NotFound", func() {
+		_, err := s.store.RetrieveWebAuthnCredential(s.Context(), []byte("unknown"))
+		require.ErrorIs(err, errors.ErrNotFound)
+
+		err = s.store.DeleteWebAuthnCredential(s.Context(), ulid.MakeSecure())
+		require.ErrorIs(err, errors.ErrNotFound)
+	})
+}
@@ -0,0 +1,320 @@
+package backend
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"go.rtnl.ai/quarterdeck/pkg/enum"
+	qerrors "go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/txn"
+	"go.rtnl.ai/tidal"
+	"go.rtnl.ai/ulid"
+)
+
+var webhooks = tidal.New[*models.Webhook]("webhooks")
+var webhookDeliveries = tidal.New[*models.WebhookDelivery]("webhook_deliveries")
+
+const (
+	updateWebhookSecretSQL = `UPDATE webhooks SET secret = :secret, modified = :modified WHERE id = :id`
+	activeWebhooksSQL      = `SELECT id, url, description, events, secret, active, created, modified FROM webhooks WHERE active = true ORDER BY created`
+	webhookDeliveriesSQL   = `SELECT id, webhook_id, event_id, event, payload, status, attempts, next_attempt, last_attempt, response_code, error, delivered, created, modified FROM webhook_deliveries WHERE webhook_id = :webhook_id ORDER BY created DESC`
+	claimDeliveriesSQL     = `UPDATE webhook_deliveries SET next_attempt = :lease, modified = :now WHERE status = 'pending' AND next_attempt <= :now AND id IN (SELECT id FROM webhook_deliveries WHERE status = 'pending' AND next_attempt <= :now ORDER BY next_attempt LIMIT :limit) RETURNING id, webhook_id, event_id, event, payload, status, attempts, next_attempt, last_attempt, response_code, error, delivered, created, modified`
+)
+
+//===========================================================================
+// Store Methods
+//===========================================================================
+
+func (s *Store) ListWebhooks(ctx context.Context, filter tidal.ListFilter) (tidal.Cursor[*models.Webhook], error) {
+	return list(s, ctx, webhooks, filter)
+}
+
+func (s *Store) CreateWebhook(ctx context.Context, webhook *models.Webhook) (*models.Webhook, error) {
+	var created *models.Webhook
+	err := s.WithTx(ctx, nil, func(t txn.Tx) (err error) {
+		created, err = t.CreateWebhook(webhook)
+		return err
+	})
+	return created, err
+}
+
+func (s *Store) RetrieveWebhook(ctx context.Context, id ulid.ULID) (*models.Webhook, error) {
+	var webhook *models.Webhook
+	err := s.WithReadTx(ctx, func(t txn.Tx) (err error) {
+		webhook, err = t.RetrieveWebhook(id)
+		return err
+	})
+	return webhook, err
+}
+
+func (s *Store) UpdateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	return s.WithTx(ctx, nil, func(t txn.Tx) error {
+		return t.UpdateWebhook(webhook)
+	})
+}
+
+func (s *Store) UpdateWebhookSecret(ctx context.Context, id ulid.ULID, secret string) error {
+	return s.WithTx(ctx, nil, func(t txn.Tx) error {
+		return t.UpdateWebhookSecret(id, secret)
+	})
+}
+
+func (s *Store) DeleteWebhook(ctx context.Context, id ulid.ULID) error {
+	return s.WithTx(ctx, nil, func(t txn.Tx) error {
+		return t.DeleteWebhook(id)
+	})
+}
+
+func (s *Store) EnqueueWebhookEvent(ctx context.Context, eventID ulid.ULID, event enum.WebhookEvent, payload []byte) (int, error) {
+	var n int
+	err := s.WithTx(ctx, nil, func(t txn.Tx) (err error) {
+		n, err = t.EnqueueWebhookEvent(eventID, event, payload)
+		return err
+	})
+	return n, err
+}
+
+func (s *Store) CreateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) (*models.WebhookDelivery, error) {
+	var created *models.WebhookDelivery
+	err := s.WithTx(ctx, nil, func(t txn.Tx) (err error) {
+		created, err = t.CreateWebhookDelivery(delivery)
+		return err
+	})
+	return created, err
+}
+
+func (s *Store) RetrieveWebhookDelivery(ctx context.Context, id ulid.ULID) (*models.WebhookDelivery, error) {
+	var delivery *models.WebhookDelivery
+	err := s.WithReadTx(ctx, func(t txn.Tx) (err error) {
+		delivery, err = t.RetrieveWebhookDelivery(id)
+		return err
+	})
+	return delivery, err
+}
+
+func (s *Store) UpdateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return s.WithTx(ctx, nil, func(t txn.Tx) error {
+		return t.UpdateWebhookDelivery(delivery)
+	})
+}
+
+func (s *Store) ListWebhookDeliveries(ctx context.Context, webhookID ulid.ULID) ([]*models.WebhookDelivery, error) {
+	var deliveries []*models.WebhookDelivery
+	err := s.WithReadTx(ctx, func(t txn.Tx) (err error) {
+		deliveries, err = t.ListWebhookDeliveries(webhookID)
+		return err
+	})
+	return deliveries, err
+}
+
+func (s *Store) ClaimDueWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*models.WebhookDelivery, error) {
+	var deliveries []*models.WebhookDelivery
+	err := s.WithTx(ctx, nil, func(t txn.Tx) (err error) {
+		deliveries, err = t.ClaimDueWebhookDeliveries(now, lease, limit)
+		return err
+	})
+	return deliveries, err
+}
+
+//===========================================================================
+// Tx Methods
+//===========================================================================
+
+// ListWebhooks returns a cursor over webhooks matching filter. [tidal.Cursor.Close]
+// rolls back the transaction; use [tidal.Cursor.CloseRows] to release the result set
+// and continue using this transaction.
+func (t *tx) ListWebhooks(filter tidal.ListFilter) (tidal.Cursor[*models.Webhook], error) {
+	return listInTx(t, webhooks, filter)
+}
+
+func (t *tx) CreateWebhook(webhook *models.Webhook) (*models.Webhook, error) {
+	if err := t.requireWrite(); err != nil {
+		return nil, err
+	}
+	if !webhook.ID.IsZero() {
+		return nil, qerrors.ErrNoIDOnCreate
+	}
+
+	if _, err := webhooks.Create(t.tx, webhook); err != nil {
+		return nil, tidalErr(err)
+	}
+
+	return t.RetrieveWebhook(webhook.ID)
+}
+
+func (t *tx) RetrieveWebhook(id ulid.ULID) (*models.Webhook, error) {
+	webhook, err := webhooks.Retrieve(t.tx, sql.Named("id", id))
+	return webhook, tidalErr(err)
+}
+
+func (t *tx) UpdateWebhook(webhook *models.Webhook) error {
+	if err := t.requireWrite(); err != nil {
+		return err
+	}
+	return tidalErr(webhooks.Update(t.tx, webhook))
+}
+
+// UpdateWebhookSecret replaces the secret of the webhook; the secret is not updated by
+// UpdateWebhook so that it cannot be changed by the webhooks API.
+func (t *tx) UpdateWebhookSecret(id ulid.ULID, secret string) error {
+	if err := t.requireWrite(); err != nil {
+		return err
+	}
+	if secret == "" {
+		return qerrors.ErrZeroValuedNotNull
+	}
+	result, err := t.tx.Exec(
+		updateWebhookSecretSQL,
+		sql.Named("id", id),
+		sql.Named("secret", secret),
+		sql.Named("modified", time.Now().UTC()),
+	)
+	if err != nil {
+		return tidalErr(err)
+	}
+	n, _ := result.RowsAffected()
+	if n == 0 {
+		return qerrors.ErrNotFound
+	}
+	return nil
+}
+
+// DeleteWebhook removes the webhook along with its delivery log (which is deleted by
+// the foreign key cascade).
+func (t *tx) DeleteWebhook(id ulid.ULID) error {
+	if err := t.requireWrite(); err != nil {
+		return err
+	}
+	if id.IsZero() {
+		return qerrors.ErrMissingID
+	}
+	result, err := webhooks.Delete(t.tx, sql.Named("id", id))
+	if err != nil {
+		return tidalErr(err)
+	}
+	n, _ := result.RowsAffected()
+	if n == 0 {
+		return qerrors.ErrNotFound
+	}
+	return nil
+}
+
+// EnqueueWebhookEvent queues a pending delivery of the event for every active webhook
+// that is subscribed to it and returns the number of deliveries that were queued.
+func (t *tx) EnqueueWebhookEvent(eventID ulid.ULID, event enum.WebhookEvent, payload []byte) (int, error) {
+	if err := t.requireWrite(); err != nil {
+		return 0, err
+	}
+	if eventID.IsZero() {
+		return 0, qerrors.ErrMissingID
+	}
+
+	rows, err := t.tx.Query(activeWebhooksSQL)
+	if err != nil {
+		return 0, tidalErr(err)
+	}
+	defer rows.Close()
+
+	subscribed := make([]*models.Webhook, 0)
+	for rows.Next() {
+		webhook := &models.Webhook{}
+		if err = webhook.Scan(tidal.Retrieve, rows); err != nil {
+			return 0, tidalErr(err)
+		}
+		if webhook.Subscribed(event) {
+			subscribed = append(subscribed, webhook)
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return 0, tidalErr(err)
+	}
+	rows.Close()
+
+	for _, webhook := range subscribed {
+		if _, err = webhookDeliveries.Create(t.tx, webhook.NewDelivery(eventID, event, payload)); err != nil {
+			return 0, tidalErr(err)
+		}
+	}
+	return len(subscribed), nil
+}
+
+func (t *tx) CreateWebhookDelivery(delivery *models.WebhookDelivery) (*models.WebhookDelivery, error) {
+	if err := t.requireWrite(); err != nil {
+		return nil, err
+	}
+	if !delivery.ID.IsZero() {
+		return nil, qerrors.ErrNoIDOnCreate
+	}
+
+	if _, err := webhookDeliveries.Create(t.tx, delivery); err != nil {
+		return nil, tidalErr(err)
+	}
+
+	return t.RetrieveWebhookDelivery(delivery.ID)
+}
+
+func (t *tx) RetrieveWebhookDelivery(id ulid.ULID) (*models.WebhookDelivery, error) {
+	delivery, err := webhookDeliveries.Retrieve(t.tx, sql.Named("id", id))
+	return delivery, tidalErr(err)
+}
+
+func (t *tx) UpdateWebhookDelivery(delivery *models.WebhookDelivery) error {
+	if err := t.requireWrite(); err != nil {
+		return err
+	}
+	return tidalErr(webhookDeliveries.Update(t.tx, delivery))
+}
+
+// ListWebhookDeliveries returns the delivery log of the webhook, most recent first; if
+// the webhook does not exist then ErrNotFound is returned rather than an empty log.
+func (t *tx) ListWebhookDeliveries(webhookID ulid.ULID) ([]*models.WebhookDelivery, error) {
+	if _, err := t.RetrieveWebhook(webhookID); err != nil {
+		return nil, err
+	}
+	return t.queryWebhookDeliveries(webhookDeliveriesSQL, sql.Named("webhook_id", webhookID))
+}
+
+// ClaimDueWebhookDeliveries leases up to limit pending deliveries whose next attempt is
+// due at the specified time, the deliveries that have been due longest first. Claiming
+// moves the next attempt of the deliveries to the end of the lease so that they are not
+// claimed again by another dispatcher while they are being attempted; if the attempt is
+// not recorded (e.g. the server stops) the delivery is due again once the lease expires.
+// The update rechecks that each delivery is still due so that if dispatchers race to
+// claim the same deliveries only one of them gets each delivery.
+func (t *tx) ClaimDueWebhookDeliveries(now time.Time, lease time.Duration, limit int) ([]*models.WebhookDelivery, error) {
+	if err := t.requireWrite(); err != nil {
+		return nil, err
+	}
+
+	now = now.UTC()
+	return t.queryWebhookDeliveries(claimDeliveriesSQL,
+		sql.Named("now", now),
+		sql.Named("lease", now.Add(lease)),
+		sql.Named("limit", limit),
+	)
+}
+
+//===========================================================================
+// Helpers
+//===========================================================================
+
+func (t *tx) queryWebhookDeliveries(query string, args ...any) ([]*models.WebhookDelivery, error) {
+	rows, err := t.tx.Query(query, args...)
+	if err != nil {
+		return nil, tidalErr(err)
+	}
+	defer rows.Close()
+
+	deliveries := make([]*models.WebhookDelivery, 0)
+	for rows.Next() {
+		delivery := &models.WebhookDelivery{}
+		if err = delivery.Scan(tidal.Retrieve, rows); err != nil {
+			return nil, tidalErr(err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, tidalErr(rows.Err())
+}
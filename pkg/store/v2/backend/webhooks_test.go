@@ -0,0 +1,140 @@
+package backend_test
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"go.rtnl.ai/quarterdeck/pkg/enum"
+	qerrors "go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/ulid"
+)
+
+//=============================================================================
+// Webhook Store Tests
+//=============================================================================
+
+// TestWebhookCRUD verifies create, retrieve, update, and delete of a webhook.
+func (s *storeSuite) TestWebhookCRUD() {
+	require := s.Require()
+
+	_, err := s.store.CreateWebhook(s.Context(), &models.Webhook{URL: "https://example.com/webhooks", Secret: "supersecretsquirrel"})
+	require.ErrorIs(err, qerrors.ErrNoSubscribedEvent, "events are required")
+
+	created, err := s.store.CreateWebhook(s.Context(), &models.Webhook{
+		URL:         "https://example.com/webhooks",
+		Description: sql.NullString{Valid: true, String: "user sync"},
+		Events:      models.WebhookEvents{enum.WebhookEventUserCreated},
+		Secret:      "supersecretsquirrel",
+		Active:      true,
+	})
+	require.NoError(err)
+	require.False(created.ID.IsZero())
+	require.Equal(models.WebhookEvents{enum.WebhookEventUserCreated}, created.Events)
+
+	cursor, err := s.store.ListWebhooks(s.Context(), nil)
+	require.NoError(err)
+	all, err := cursor.List()
+	require.NoError(err)
+	require.NoError(cursor.Close())
+	require.Len(all, 1)
+
+	created.Events = append(created.Events, enum.WebhookEventUserDeleted)
+	created.Active = false
+	created.Secret = "cannot be changed"
+	require.NoError(s.store.UpdateWebhook(s.Context(), created))
+
+	got, err := s.store.RetrieveWebhook(s.Context(), created.ID)
+	require.NoError(err)
+	require.False(got.Active)
+	require.Equal(models.WebhookEvents{enum.WebhookEventUserCreated, enum.WebhookEventUserDeleted}, got.Events)
+	require.Equal("supersecretsquirrel", got.Secret, "the secret should not be updated")
+
+	require.NoError(s.store.UpdateWebhookSecret(s.Context(), created.ID, "rotatedsecret"))
+	got, err = s.store.RetrieveWebhook(s.Context(), created.ID)
+	require.NoError(err)
+	require.Equal("rotatedsecret", got.Secret)
+	require.ErrorIs(s.store.UpdateWebhookSecret(s.Context(), ulid.MakeSecure(), "rotatedsecret"), qerrors.ErrNotFound)
+
+	require.NoError(s.store.DeleteWebhook(s.Context(), created.ID))
+	_, err = s.store.RetrieveWebhook(s.Context(), created.ID)
+	require.ErrorIs(err, qerrors.ErrNotFound)
+	require.ErrorIs(s.store.DeleteWebhook(s.Context(), created.ID), qerrors.ErrNotFound)
+}
+
+// TestEnqueueWebhookEvent verifies events are queued only for active, subscribed
+// webhooks and that due deliveries are recorded and redelivered.
+func (s *storeSuite) TestEnqueueWebhookEvent() {
+	require := s.Require()
+
+	subscribed, err := s.store.CreateWebhook(s.Context(), &models.Webhook{
+		URL:    "https://example.com/users",
+		Events: models.WebhookEvents{enum.WebhookEventUserCreated, enum.WebhookEventUserDeleted},
+		Secret: "supersecretsquirrel",
+		Active: true,
+	})
+	require.NoError(err)
+
+	_, err = s.store.CreateWebhook(s.Context(), &models.Webhook{
+		URL:    "https://example.com/inactive",
+		Events: models.WebhookEvents{enum.WebhookEventUserCreated},
+		Secret: "supersecretsquirrel",
+		Active: false,
+	})
+	require.NoError(err)
+
+	eventID := ulid.MakeSecure()
+	n, err := s.store.EnqueueWebhookEvent(s.Context(), eventID, enum.WebhookEventUserCreated, []byte(`{"event":"user.created"}`))
+	require.NoError(err)
+	require.Equal(1, n, "only the active subscribed webhook should receive the event")
+
+	n, err = s.store.EnqueueWebhookEvent(s.Context(), ulid.MakeSecure(), enum.WebhookEventLogin, []byte(`{"event":"login"}`))
+	require.NoError(err)
+	require.Zero(n)
+
+	due, err := s.store.ClaimDueWebhookDeliveries(s.Context(), time.Now().Add(time.Second), time.Minute, 10)
+	require.NoError(err)
+	require.Len(due, 1)
+	require.Equal(subscribed.ID, due[0].WebhookID)
+	require.Equal(eventID, due[0].EventID)
+	require.Equal([]byte(`{"event":"user.created"}`), due[0].Payload)
+
+	// A claimed delivery is leased so it cannot be claimed again until the lease expires.
+	claimed, err := s.store.ClaimDueWebhookDeliveries(s.Context(), time.Now().Add(time.Second), time.Minute, 10)
+	require.NoError(err)
+	require.Empty(claimed)
+
+	claimed, err = s.store.ClaimDueWebhookDeliveries(s.Context(), time.Now().Add(2*time.Minute), time.Minute, 10)
+	require.NoError(err)
+	require.Len(claimed, 1, "the delivery should be due again once the lease has expired")
+
+	// A failed attempt is retried after the backoff so it is no longer due.
+	delivery := due[0]
+	delivery.Record(0, errors.New("connection refused"), time.Hour, 1)
+	require.NoError(s.store.UpdateWebhookDelivery(s.Context(), delivery))
+
+	due, err = s.store.ClaimDueWebhookDeliveries(s.Context(), time.Now().Add(3*time.Minute), time.Minute, 10)
+	require.NoError(err)
+	require.Empty(due)
+
+	got, err := s.store.RetrieveWebhookDelivery(s.Context(), delivery.ID)
+	require.NoError(err)
+	require.Equal(enum.DeliveryStatusFailed, got.Status)
+	require.Equal("connection refused", got.Error.String)
+
+	redelivery, err := s.store.CreateWebhookDelivery(s.Context(), got.Redelivery())
+	require.NoError(err)
+	require.Equal(eventID, redelivery.EventID)
+
+	log, err := s.store.ListWebhookDeliveries(s.Context(), subscribed.ID)
+	require.NoError(err)
+	require.Len(log, 2)
+
+	_, err = s.store.ListWebhookDeliveries(s.Context(), ulid.MakeSecure())
+	require.ErrorIs(err, qerrors.ErrNotFound)
+
+	// Deleting the webhook deletes its delivery log.
+	require.NoError(s.store.DeleteWebhook(s.Context(), subscribed.ID))
+	require.Zero(s.count("webhook_deliveries"))
+}
@@ -0,0 +1,15 @@
+-- Refresh token families (Postgres). The id is the jti claim of the refresh token and
+-- the family_id is the jti of the first refresh token issued on login.
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id BYTEA PRIMARY KEY,
+    family_id BYTEA NOT NULL,
+    subject TEXT NOT NULL,
+    used_on TIMESTAMPTZ DEFAULT NULL,
+    revoked_on TIMESTAMPTZ DEFAULT NULL,
+    expiration TIMESTAMPTZ NOT NULL,
+    created TIMESTAMPTZ NOT NULL,
+    modified TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family ON refresh_tokens (family_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_expiration ON refresh_tokens (expiration);
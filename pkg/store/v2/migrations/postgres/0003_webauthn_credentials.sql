@@ -0,0 +1,19 @@
+-- WebAuthn credentials (Postgres). The credential_id is assigned by the authenticator
+-- and is used to look up the passkey during login; only the public key is stored.
+CREATE TABLE IF NOT EXISTS webauthn_credentials (
+    id BYTEA PRIMARY KEY,
+    user_id BYTEA NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT,
+    credential_id BYTEA NOT NULL UNIQUE,
+    public_key BYTEA NOT NULL,
+    attestation_type TEXT,
+    aaguid BYTEA,
+    sign_count BIGINT DEFAULT 0 NOT NULL,
+    transports JSONB,
+    flags SMALLINT DEFAULT 0 NOT NULL,
+    last_used TIMESTAMPTZ,
+    created TIMESTAMPTZ NOT NULL,
+    modified TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_webauthn_credentials_user ON webauthn_credentials (user_id);
@@ -0,0 +1,36 @@
+-- Organizations (Postgres). Organizations allow a single Quarterdeck instance to serve
+-- several tenants (e.g. customer workspaces). Users are members of one or more
+-- organizations with roles that only apply in that organization; API keys and OIDC
+-- clients belong to an organization or are not scoped to a tenant if it is null.
+
+CREATE TABLE IF NOT EXISTS organizations (
+    id BYTEA PRIMARY KEY,
+    name TEXT NOT NULL,
+    street_address TEXT,
+    homepage_uri TEXT,
+    support_email TEXT,
+    created TIMESTAMPTZ NOT NULL,
+    modified TIMESTAMPTZ NOT NULL
+);
+
+-- A user is a member of an organization as long as they have at least one role in it.
+CREATE TABLE IF NOT EXISTS organization_members (
+    organization_id BYTEA NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+    user_id BYTEA NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    role_id BIGINT NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+    created TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (organization_id, user_id, role_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_organization_members_user ON organization_members (user_id);
+
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS organization_id BYTEA REFERENCES organizations (id) ON DELETE CASCADE;
+ALTER TABLE oidc_clients ADD COLUMN IF NOT EXISTS organization_id BYTEA REFERENCES organizations (id) ON DELETE CASCADE;
+
+CREATE OR REPLACE VIEW organization_member_permissions AS
+SELECT DISTINCT om.organization_id,
+    om.user_id,
+    p.title AS permission
+FROM organization_members om
+    JOIN role_permissions rp ON rp.role_id = om.role_id
+    JOIN permissions p ON p.id = rp.permission_id;
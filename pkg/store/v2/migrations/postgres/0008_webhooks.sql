@@ -0,0 +1,35 @@
+-- Webhooks (Postgres). Webhooks subscribe endpoints to Quarterdeck events. Events are
+-- queued as deliveries in the same transaction that enqueues them so that they are not
+-- lost if the endpoint is unavailable; deliveries are retried with backoff and kept as
+-- a delivery log.
+
+CREATE TABLE IF NOT EXISTS webhooks (
+    id BYTEA PRIMARY KEY,
+    url TEXT NOT NULL,
+    description TEXT,
+    events JSONB NOT NULL,
+    secret TEXT NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT true,
+    created TIMESTAMPTZ NOT NULL,
+    modified TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id BYTEA PRIMARY KEY,
+    webhook_id BYTEA NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    event_id BYTEA NOT NULL,
+    event TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt TIMESTAMPTZ,
+    last_attempt TIMESTAMPTZ,
+    response_code INTEGER,
+    error TEXT,
+    delivered TIMESTAMPTZ,
+    created TIMESTAMPTZ NOT NULL,
+    modified TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries (status, next_attempt);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook ON webhook_deliveries (webhook_id);
@@ -0,0 +1,20 @@
+-- OAuth2 authorization codes (Postgres) issued by the /oauth/authorize endpoint to
+-- registered OIDC clients. Codes are one-time use and short lived; only a SHA-256 hash
+-- of the code is stored so that a database leak cannot be used to redeem outstanding
+-- codes. The PKCE code challenge (if any) is stored so it can be verified on exchange.
+CREATE TABLE IF NOT EXISTS oauth_authorization_codes (
+    id BYTEA PRIMARY KEY,
+    code TEXT NOT NULL UNIQUE,
+    client_id TEXT NOT NULL REFERENCES oidc_clients (client_id) ON DELETE CASCADE,
+    user_id BYTEA NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    redirect_uri TEXT NOT NULL,
+    scope TEXT,
+    nonce TEXT,
+    code_challenge TEXT,
+    code_challenge_method TEXT,
+    expiration TIMESTAMPTZ NOT NULL,
+    created TIMESTAMPTZ NOT NULL,
+    modified TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_oauth_authorization_codes_expiration ON oauth_authorization_codes (expiration);
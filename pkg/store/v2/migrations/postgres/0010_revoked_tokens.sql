@@ -0,0 +1,11 @@
+-- Revoked tokens (Postgres). A denylist of the jti claims of access and refresh tokens
+-- that have been revoked before they expire. Access and refresh tokens issued together
+-- share a jti so revoking either revokes the pair. Rows can be removed once expired.
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    id BYTEA PRIMARY KEY,
+    expiration TIMESTAMPTZ NOT NULL,
+    created TIMESTAMPTZ NOT NULL,
+    modified TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expiration ON revoked_tokens (expiration);
@@ -0,0 +1,18 @@
+-- Sessions (Postgres) record each login so that a user (or an administrator) can see
+-- where an account is signed in and revoke those sign ins. The id of a session is the
+-- family id of the refresh tokens issued on login; the jti is that of the most recently
+-- issued access and refresh tokens and is updated each time the session is refreshed.
+CREATE TABLE IF NOT EXISTS sessions (
+    id BYTEA PRIMARY KEY,
+    user_id BYTEA NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    jti BYTEA NOT NULL,
+    user_agent TEXT,
+    client_ip TEXT,
+    last_refresh TIMESTAMPTZ,
+    revoked TIMESTAMPTZ,
+    expiration TIMESTAMPTZ NOT NULL,
+    created TIMESTAMPTZ NOT NULL,
+    modified TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions (user_id);
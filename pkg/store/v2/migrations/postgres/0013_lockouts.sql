@@ -0,0 +1,16 @@
+-- Lockouts (Postgres) track consecutive failed authentication attempts against the
+-- email address of a user or the client ID of an API key or OAuth client so that
+-- brute-force attacks can be throttled with an exponential backoff. Attempts are tracked
+-- by identifier rather than by foreign key so that attempts against accounts that do
+-- not exist are treated the same way.
+CREATE TABLE IF NOT EXISTS lockouts (
+    id BYTEA PRIMARY KEY,
+    kind TEXT NOT NULL,
+    identifier TEXT NOT NULL,
+    failed_attempts BIGINT NOT NULL DEFAULT 0,
+    last_failure TIMESTAMPTZ,
+    locked_until TIMESTAMPTZ,
+    created TIMESTAMPTZ NOT NULL,
+    modified TIMESTAMPTZ NOT NULL,
+    UNIQUE (kind, identifier)
+);
@@ -0,0 +1,5 @@
+-- Audit event organizations (Postgres). Events record the organization the actor was
+-- logged into so that requesters in an organization only see its activity.
+
+ALTER TABLE audit_events ADD COLUMN IF NOT EXISTS organization_id BYTEA;
+CREATE INDEX IF NOT EXISTS idx_audit_events_organization ON audit_events (organization_id);
@@ -0,0 +1,16 @@
+-- Refresh token families (SQLite). The id is the jti claim of the refresh token and
+-- the family_id is the jti of the first refresh token issued on login.
+
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id              TEXT PRIMARY KEY,
+    family_id       TEXT NOT NULL,
+    subject         TEXT NOT NULL,
+    used_on         DATETIME DEFAULT NULL,
+    revoked_on      DATETIME DEFAULT NULL,
+    expiration      DATETIME NOT NULL,
+    created         DATETIME NOT NULL,
+    modified        DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family ON refresh_tokens (family_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_expiration ON refresh_tokens (expiration);
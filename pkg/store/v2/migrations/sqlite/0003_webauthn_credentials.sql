@@ -0,0 +1,21 @@
+-- WebAuthn credentials (SQLite). The credential_id is assigned by the authenticator and
+-- is used to look up the passkey during login; only the public key is stored.
+
+CREATE TABLE IF NOT EXISTS webauthn_credentials (
+    id                  TEXT PRIMARY KEY,
+    user_id             TEXT NOT NULL,
+    name                TEXT,
+    credential_id       BLOB NOT NULL UNIQUE,
+    public_key          BLOB NOT NULL,
+    attestation_type    TEXT,
+    aaguid              BLOB,
+    sign_count          INTEGER DEFAULT 0 NOT NULL,
+    transports          BLOB,
+    flags               INTEGER DEFAULT 0 NOT NULL,
+    last_used           DATETIME,
+    created             DATETIME NOT NULL,
+    modified            DATETIME NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_webauthn_credentials_user ON webauthn_credentials (user_id);
@@ -0,0 +1,20 @@
+-- Audit events (SQLite). Events do not reference the actor or subject by foreign key so
+-- that the history of a resource is retained after it has been deleted.
+
+CREATE TABLE IF NOT EXISTS audit_events (
+    id                  TEXT PRIMARY KEY,
+    actor_type          TEXT,
+    actor_id            TEXT,
+    action              TEXT NOT NULL,
+    subject_type        TEXT NOT NULL,
+    subject_id          TEXT NOT NULL,
+    client_ip           TEXT,
+    user_agent          TEXT,
+    request_id          TEXT,
+    diff                TEXT,
+    created             DATETIME NOT NULL,
+    modified            DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_actor ON audit_events (actor_id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_subject ON audit_events (subject_type, subject_id);
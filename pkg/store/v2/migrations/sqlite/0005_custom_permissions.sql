@@ -0,0 +1,15 @@
+-- Custom permissions (SQLite). Permissions are namespaced by the application or OIDC
+-- client that created them; the built-in permissions are in the quarterdeck namespace
+-- and are protected so that they cannot be deleted or renamed.
+
+ALTER TABLE permissions ADD COLUMN namespace TEXT NOT NULL DEFAULT 'quarterdeck';
+ALTER TABLE permissions ADD COLUMN protected BOOLEAN NOT NULL DEFAULT false;
+
+UPDATE permissions SET protected=true WHERE title IN (
+    'users:view', 'users:manage',
+    'apikeys:view', 'apikeys:manage', 'apikeys:revoke',
+    'roles:view',
+    'permissions:view',
+    'config:view', 'config:manage',
+    'oidcclients:view', 'oidcclients:manage'
+);
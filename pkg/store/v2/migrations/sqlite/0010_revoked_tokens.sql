@@ -0,0 +1,12 @@
+-- Revoked tokens (SQLite). A denylist of the jti claims of access and refresh tokens
+-- that have been revoked before they expire. Access and refresh tokens issued together
+-- share a jti so revoking either revokes the pair. Rows can be removed once expired.
+
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    id              TEXT PRIMARY KEY,
+    expiration      DATETIME NOT NULL,
+    created         DATETIME NOT NULL,
+    modified        DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expiration ON revoked_tokens (expiration);
@@ -0,0 +1,8 @@
+-- Multi-factor authentication (SQLite). The TOTP secret must be stored so that codes
+-- can be verified; the TOTP is only enabled once the user has confirmed enrollment with
+-- a valid code. Recovery codes are one-time use and only their hashes are stored so that
+-- each code can be removed when it is used.
+
+ALTER TABLE users ADD COLUMN totp_secret TEXT;
+ALTER TABLE users ADD COLUMN totp_enabled DATETIME;
+ALTER TABLE users ADD COLUMN recovery_codes BLOB;
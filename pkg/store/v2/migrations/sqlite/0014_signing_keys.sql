@@ -0,0 +1,24 @@
+-- Signing Keys (SQLite) are the keys used to sign and verify the JWT tokens issued by
+-- Quarterdeck. Keys are shared by every replica and are rotated through the next,
+-- active, retiring, and retired states; the private key is encrypted with the key
+-- encryption key of the server and is cleared when the key is retired. At most one key
+-- can be next and one key can be active at a time so that replicas that rotate keys
+-- concurrently cannot both succeed.
+
+CREATE TABLE IF NOT EXISTS signing_keys (
+    id                  TEXT PRIMARY KEY,
+    state               TEXT NOT NULL,
+    public_key          BLOB NOT NULL,
+    private_key         BLOB,
+    activates           DATETIME NOT NULL,
+    deactivated         DATETIME,
+    retires             DATETIME,
+    created             DATETIME NOT NULL,
+    modified            DATETIME NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_signing_keys_next
+    ON signing_keys (state) WHERE state = 'next';
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_signing_keys_active
+    ON signing_keys (state) WHERE state = 'active';
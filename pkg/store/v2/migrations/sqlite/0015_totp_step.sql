@@ -0,0 +1,4 @@
+-- TOTP replay protection (SQLite). The time step of the last TOTP code accepted for a
+-- user is stored so that a code cannot be used again within its validity window.
+
+ALTER TABLE users ADD COLUMN totp_step INTEGER;
@@ -0,0 +1,38 @@
+package mock
+
+import (
+	"go.rtnl.ai/tidal"
+)
+
+// Cursor allows mocking the list methods of the store by returning the models in a
+// slice from `List()`. The embedded cursor is nil, so any cursor method other than
+// `List()` or `Close()` will panic if it is called by the code under test.
+type Cursor[M tidal.Model] struct {
+	tidal.Cursor[M]
+	items  []M
+	closed bool
+}
+
+// NewCursor returns a cursor over the specified models, e.g. for an OnList callback.
+func NewCursor[M tidal.Model](items ...M) *Cursor[M] {
+	return &Cursor[M]{items: items}
+}
+
+// List returns all of the models in the cursor or an empty slice if there are none.
+func (c *Cursor[M]) List() ([]M, error) {
+	if c.items == nil {
+		return make([]M, 0), nil
+	}
+	return c.items, nil
+}
+
+// Close marks the cursor as closed so that tests can assert the cursor was released.
+func (c *Cursor[M]) Close() error {
+	c.closed = true
+	return nil
+}
+
+// Closed returns true if the cursor was closed.
+func (c *Cursor[M]) Closed() bool {
+	return c.closed
+}
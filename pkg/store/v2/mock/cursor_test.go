@@ -0,0 +1,29 @@
+package mock_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/mock"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/tidal"
+)
+
+// TestCursor verifies the mock Cursor lists its models and records that it was closed.
+func TestCursor(t *testing.T) {
+	roles := []*models.Role{{ID: 1, Title: "admin"}, {ID: 2, Title: "viewer"}}
+
+	var cursor tidal.Cursor[*models.Role] = mock.NewCursor(roles...)
+	out, err := cursor.List()
+	require.NoError(t, err)
+	require.Equal(t, roles, out)
+
+	require.NoError(t, cursor.Close())
+	require.True(t, cursor.(*mock.Cursor[*models.Role]).Closed())
+
+	// An empty cursor lists an empty slice rather than nil.
+	out, err = mock.NewCursor[*models.Role]().List()
+	require.NoError(t, err)
+	require.NotNil(t, out)
+	require.Empty(t, out)
+}
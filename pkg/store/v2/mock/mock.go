@@ -22,6 +22,7 @@ const (
 	Close                 = "Close"
 	Stats                 = "Stats"
 	CompletePasswordReset = "CompletePasswordReset"
+	CompleteEmailChange   = "CompleteEmailChange"
 	CreateAPIKeyFor       = "CreateAPIKeyFor"
 )
 
@@ -38,6 +39,7 @@ type Store struct {
 
 	// Composite callbacks
 	OnCompletePasswordReset  func(context.Context, ulid.ULID, string) error
+	OnCompleteEmailChange    func(context.Context, ulid.ULID) error
 	OnCreateAPIKeyForCreator func(context.Context, *models.APIKey, ulid.ULID) (*models.APIKey, error)
 
 	// UserStore callbacks
@@ -49,7 +51,11 @@ type Store struct {
 	OnUpdatePassword            func(context.Context, ulid.ULID, string) error
 	OnUpdateLastLogin           func(context.Context, ulid.ULID, time.Time) error
 	OnVerifyEmail               func(context.Context, ulid.ULID) error
+	OnUpdateMFA                 func(context.Context, *models.User) error
+	OnUpdateTOTPStep            func(context.Context, ulid.ULID, int64) error
+	OnUpdateUserStatus          func(context.Context, ulid.ULID, enum.UserStatus) error
 	OnDeleteUser                func(context.Context, ulid.ULID) error
+	OnPurgeUsers                func(context.Context, time.Time) ([]ulid.ULID, error)
 	OnAddRoleToUser             func(context.Context, ulid.ULID, int64) error
 	OnAddRoleToUserByTitle      func(context.Context, ulid.ULID, string) error
 	OnRemoveRoleFromUser        func(context.Context, ulid.ULID, int64) error
@@ -75,6 +81,17 @@ type Store struct {
 	OnUpdatePermission          func(context.Context, *models.Permission) error
 	OnDeletePermission          func(context.Context, int64) error
 
+	// OrganizationStore callbacks
+	OnListOrganizations              func(context.Context, tidal.ListFilter) (tidal.Cursor[*models.Organization], error)
+	OnCreateOrganization             func(context.Context, *models.Organization) (*models.Organization, error)
+	OnRetrieveOrganization           func(context.Context, ulid.ULID) (*models.Organization, error)
+	OnUpdateOrganization             func(context.Context, *models.Organization) error
+	OnDeleteOrganization             func(context.Context, ulid.ULID) error
+	OnListUserOrganizations          func(context.Context, ulid.ULID) ([]*models.Organization, error)
+	OnListOrganizationMembers        func(context.Context, ulid.ULID) ([]*models.User, error)
+	OnRetrieveOrganizationMember     func(context.Context, ulid.ULID, ulid.ULID) (*models.User, error)
+	OnReplaceOrganizationMemberRoles func(context.Context, ulid.ULID, ulid.ULID, []int64) error
+
 	// APIKeyStore callbacks
 	OnListAPIKeys                  func(context.Context, tidal.ListFilter) (tidal.Cursor[*models.APIKey], error)
 	OnCreateAPIKey                 func(context.Context, *models.APIKey) (*models.APIKey, error)
@@ -105,6 +122,71 @@ type Store struct {
 	OnDeleteVeroToken              func(context.Context, ulid.ULID) error
 	OnCreateResetPasswordVeroToken func(context.Context, *models.VeroToken) (*models.VeroToken, error)
 	OnCreateTeamInviteVeroToken    func(context.Context, *models.VeroToken) (*models.VeroToken, error)
+	OnCreateVerifyEmailVeroToken   func(context.Context, *models.VeroToken) (*models.VeroToken, error)
+	OnCreateChangeEmailVeroToken   func(context.Context, *models.VeroToken) (*models.VeroToken, error)
+	OnListVeroTokensByType         func(context.Context, enum.TokenType) ([]*models.VeroToken, error)
+	OnConsumeVeroToken             func(context.Context, ulid.ULID, enum.TokenType) error
+
+	// RefreshTokenStore callbacks
+	OnCreateRefreshToken       func(context.Context, *models.RefreshToken) (*models.RefreshToken, error)
+	OnRetrieveRefreshToken     func(context.Context, ulid.ULID) (*models.RefreshToken, error)
+	OnUseRefreshToken          func(context.Context, ulid.ULID) (*models.RefreshToken, error)
+	OnRevokeRefreshTokenFamily func(context.Context, ulid.ULID) error
+
+	// AuthorizationCodeStore callbacks
+	OnCreateAuthorizationCode   func(context.Context, *models.AuthorizationCode) (*models.AuthorizationCode, error)
+	OnRetrieveAuthorizationCode func(context.Context, string) (*models.AuthorizationCode, error)
+	OnDeleteAuthorizationCode   func(context.Context, ulid.ULID) error
+
+	// RevokedTokenStore callbacks
+	OnRevokeToken    func(context.Context, *models.RevokedToken) error
+	OnConsumeToken   func(context.Context, *models.RevokedToken) error
+	OnIsTokenRevoked func(context.Context, ulid.ULID) (bool, error)
+
+	// SessionStore callbacks
+	OnListSessions       func(context.Context, ulid.ULID) ([]*models.Session, error)
+	OnCreateSession      func(context.Context, *models.Session) (*models.Session, error)
+	OnRetrieveSession    func(context.Context, ulid.ULID) (*models.Session, error)
+	OnRefreshSession     func(context.Context, ulid.ULID, ulid.ULID, time.Time) error
+	OnRevokeSession      func(context.Context, ulid.ULID) error
+	OnRevokeUserSessions func(context.Context, ulid.ULID) error
+
+	// WebAuthnCredentialStore callbacks
+	OnListWebAuthnCredentials    func(context.Context, tidal.ListFilter) (tidal.Cursor[*models.WebAuthnCredential], error)
+	OnCreateWebAuthnCredential   func(context.Context, *models.WebAuthnCredential) (*models.WebAuthnCredential, error)
+	OnRetrieveWebAuthnCredential func(context.Context, []byte) (*models.WebAuthnCredential, error)
+	OnUpdateWebAuthnCredential   func(context.Context, *models.WebAuthnCredential) error
+	OnDeleteWebAuthnCredential   func(context.Context, ulid.ULID) error
+
+	// LockoutStore callbacks
+	OnRetrieveLockout     func(context.Context, string, string) (*models.Lockout, error)
+	OnRecordFailedAttempt func(context.Context, string, string, models.LockoutPolicy) (*models.Lockout, error)
+	OnResetLockout        func(context.Context, string, string) error
+
+	// AuditEventStore callbacks
+	OnListAuditEvents  func(context.Context, tidal.ListFilter) (tidal.Cursor[*models.AuditEvent], error)
+	OnCreateAuditEvent func(context.Context, *models.AuditEvent) (*models.AuditEvent, error)
+
+	// WebhookStore callbacks
+	OnListWebhooks              func(context.Context, tidal.ListFilter) (tidal.Cursor[*models.Webhook], error)
+	OnCreateWebhook             func(context.Context, *models.Webhook) (*models.Webhook, error)
+	OnRetrieveWebhook           func(context.Context, ulid.ULID) (*models.Webhook, error)
+	OnUpdateWebhook             func(context.Context, *models.Webhook) error
+	OnUpdateWebhookSecret       func(context.Context, ulid.ULID, string) error
+	OnDeleteWebhook             func(context.Context, ulid.ULID) error
+	OnEnqueueWebhookEvent       func(context.Context, ulid.ULID, enum.WebhookEvent, []byte) (int, error)
+	OnCreateWebhookDelivery     func(context.Context, *models.WebhookDelivery) (*models.WebhookDelivery, error)
+	OnRetrieveWebhookDelivery   func(context.Context, ulid.ULID) (*models.WebhookDelivery, error)
+	OnUpdateWebhookDelivery     func(context.Context, *models.WebhookDelivery) error
+	OnListWebhookDeliveries     func(context.Context, ulid.ULID) ([]*models.WebhookDelivery, error)
+	OnClaimDueWebhookDeliveries func(context.Context, time.Time, time.Duration, int) ([]*models.WebhookDelivery, error)
+
+	// SigningKeyStore callbacks
+	OnListSigningKeys    func(context.Context) ([]*models.SigningKey, error)
+	OnCreateSigningKey   func(context.Context, *models.SigningKey) (*models.SigningKey, error)
+	OnRetrieveSigningKey func(context.Context, ulid.ULID) (*models.SigningKey, error)
+	OnActivateSigningKey func(context.Context, ulid.ULID, time.Time) error
+	OnRetireSigningKey   func(context.Context, ulid.ULID) error
 }
 
 func Open(uri *dsn.DSN) (*Store, error) {
@@ -174,6 +256,14 @@ func (s *Store) CompletePasswordReset(ctx context.Context, veroTokenID ulid.ULID
 	panic(errors.Fmt("%s callback is not mocked", CompletePasswordReset))
 }
 
+func (s *Store) CompleteEmailChange(ctx context.Context, veroTokenID ulid.ULID) error {
+	s.calls[CompleteEmailChange]++
+	if s.OnCompleteEmailChange != nil {
+		return s.OnCompleteEmailChange(ctx, veroTokenID)
+	}
+	panic(errors.Fmt("%s callback is not mocked", CompleteEmailChange))
+}
+
 func (s *Store) CreateAPIKeyFor(ctx context.Context, key *models.APIKey, creatorAPIKeyID ulid.ULID) (*models.APIKey, error) {
 	s.calls[CreateAPIKeyFor]++
 	if s.OnCreateAPIKeyForCreator != nil {
@@ -195,7 +285,11 @@ const (
 	UpdatePassword            = "UpdatePassword"
 	UpdateLastLogin           = "UpdateLastLogin"
 	VerifyEmail               = "VerifyEmail"
+	UpdateMFA                 = "UpdateMFA"
+	UpdateTOTPStep            = "UpdateTOTPStep"
+	UpdateUserStatus          = "UpdateUserStatus"
 	DeleteUser                = "DeleteUser"
+	PurgeUsers                = "PurgeUsers"
 	AddRoleToUser             = "AddRoleToUser"
 	AddRoleToUserByTitle      = "AddRoleToUserByTitle"
 	RemoveRoleFromUser        = "RemoveRoleFromUser"
@@ -267,6 +361,30 @@ func (s *Store) VerifyEmail(ctx context.Context, id ulid.ULID) error {
 	panic(errors.Fmt("%s callback is not mocked", VerifyEmail))
 }
 
+func (s *Store) UpdateMFA(ctx context.Context, user *models.User) error {
+	s.calls[UpdateMFA]++
+	if s.OnUpdateMFA != nil {
+		return s.OnUpdateMFA(ctx, user)
+	}
+	panic(errors.Fmt("%s callback is not mocked", UpdateMFA))
+}
+
+func (s *Store) UpdateTOTPStep(ctx context.Context, userID ulid.ULID, step int64) error {
+	s.calls[UpdateTOTPStep]++
+	if s.OnUpdateTOTPStep != nil {
+		return s.OnUpdateTOTPStep(ctx, userID, step)
+	}
+	panic(errors.Fmt("%s callback is not mocked", UpdateTOTPStep))
+}
+
+func (s *Store) UpdateUserStatus(ctx context.Context, id ulid.ULID, status enum.UserStatus) error {
+	s.calls[UpdateUserStatus]++
+	if s.OnUpdateUserStatus != nil {
+		return s.OnUpdateUserStatus(ctx, id, status)
+	}
+	panic(errors.Fmt("%s callback is not mocked", UpdateUserStatus))
+}
+
 func (s *Store) DeleteUser(ctx context.Context, id ulid.ULID) error {
 	s.calls[DeleteUser]++
 	if s.OnDeleteUser != nil {
@@ -275,6 +393,14 @@ func (s *Store) DeleteUser(ctx context.Context, id ulid.ULID) error {
 	panic(errors.Fmt("%s callback is not mocked", DeleteUser))
 }
 
+func (s *Store) PurgeUsers(ctx context.Context, deletedBefore time.Time) ([]ulid.ULID, error) {
+	s.calls[PurgeUsers]++
+	if s.OnPurgeUsers != nil {
+		return s.OnPurgeUsers(ctx, deletedBefore)
+	}
+	panic(errors.Fmt("%s callback is not mocked", PurgeUsers))
+}
+
 func (s *Store) AddRoleToUser(ctx context.Context, userID ulid.ULID, roleID int64) error {
 	s.calls[AddRoleToUser]++
 	if s.OnAddRoleToUser != nil {
@@ -464,6 +590,94 @@ func (s *Store) DeletePermission(ctx context.Context, id int64) error {
 	panic(errors.Fmt("%s callback is not mocked", DeletePermission))
 }
 
+//===========================================================================
+// OrganizationStore
+//===========================================================================
+
+const (
+	ListOrganizations              = "ListOrganizations"
+	CreateOrganization             = "CreateOrganization"
+	RetrieveOrganization           = "RetrieveOrganization"
+	UpdateOrganization             = "UpdateOrganization"
+	DeleteOrganization             = "DeleteOrganization"
+	ListUserOrganizations          = "ListUserOrganizations"
+	ListOrganizationMembers        = "ListOrganizationMembers"
+	RetrieveOrganizationMember     = "RetrieveOrganizationMember"
+	ReplaceOrganizationMemberRoles = "ReplaceOrganizationMemberRoles"
+)
+
+func (s *Store) ListOrganizations(ctx context.Context, filter tidal.ListFilter) (tidal.Cursor[*models.Organization], error) {
+	s.calls[ListOrganizations]++
+	if s.OnListOrganizations != nil {
+		return s.OnListOrganizations(ctx, filter)
+	}
+	panic(errors.Fmt("%s callback is not mocked", ListOrganizations))
+}
+
+func (s *Store) CreateOrganization(ctx context.Context, org *models.Organization) (*models.Organization, error) {
+	s.calls[CreateOrganization]++
+	if s.OnCreateOrganization != nil {
+		return s.OnCreateOrganization(ctx, org)
+	}
+	panic(errors.Fmt("%s callback is not mocked", CreateOrganization))
+}
+
+func (s *Store) RetrieveOrganization(ctx context.Context, id ulid.ULID) (*models.Organization, error) {
+	s.calls[RetrieveOrganization]++
+	if s.OnRetrieveOrganization != nil {
+		return s.OnRetrieveOrganization(ctx, id)
+	}
+	panic(errors.Fmt("%s callback is not mocked", RetrieveOrganization))
+}
+
+func (s *Store) UpdateOrganization(ctx context.Context, org *models.Organization) error {
+	s.calls[UpdateOrganization]++
+	if s.OnUpdateOrganization != nil {
+		return s.OnUpdateOrganization(ctx, org)
+	}
+	panic(errors.Fmt("%s callback is not mocked", UpdateOrganization))
+}
+
+func (s *Store) DeleteOrganization(ctx context.Context, id ulid.ULID) error {
+	s.calls[DeleteOrganization]++
+	if s.OnDeleteOrganization != nil {
+		return s.OnDeleteOrganization(ctx, id)
+	}
+	panic(errors.Fmt("%s callback is not mocked", DeleteOrganization))
+}
+
+func (s *Store) ListUserOrganizations(ctx context.Context, userID ulid.ULID) ([]*models.Organization, error) {
+	s.calls[ListUserOrganizations]++
+	if s.OnListUserOrganizations != nil {
+		return s.OnListUserOrganizations(ctx, userID)
+	}
+	panic(errors.Fmt("%s callback is not mocked", ListUserOrganizations))
+}
+
+func (s *Store) ListOrganizationMembers(ctx context.Context, orgID ulid.ULID) ([]*models.User, error) {
+	s.calls[ListOrganizationMembers]++
+	if s.OnListOrganizationMembers != nil {
+		return s.OnListOrganizationMembers(ctx, orgID)
+	}
+	panic(errors.Fmt("%s callback is not mocked", ListOrganizationMembers))
+}
+
+func (s *Store) RetrieveOrganizationMember(ctx context.Context, orgID, userID ulid.ULID) (*models.User, error) {
+	s.calls[RetrieveOrganizationMember]++
+	if s.OnRetrieveOrganizationMember != nil {
+		return s.OnRetrieveOrganizationMember(ctx, orgID, userID)
+	}
+	panic(errors.Fmt("%s callback is not mocked", RetrieveOrganizationMember))
+}
+
+func (s *Store) ReplaceOrganizationMemberRoles(ctx context.Context, orgID, userID ulid.ULID, roleIDs []int64) error {
+	s.calls[ReplaceOrganizationMemberRoles]++
+	if s.OnReplaceOrganizationMemberRoles != nil {
+		return s.OnReplaceOrganizationMemberRoles(ctx, orgID, userID, roleIDs)
+	}
+	panic(errors.Fmt("%s callback is not mocked", ReplaceOrganizationMemberRoles))
+}
+
 //===========================================================================
 // APIKeyStore
 //===========================================================================
@@ -652,6 +866,10 @@ const (
 	DeleteVeroToken              = "DeleteVeroToken"
 	CreateResetPasswordVeroToken = "CreateResetPasswordVeroToken"
 	CreateTeamInviteVeroToken    = "CreateTeamInviteVeroToken"
+	CreateVerifyEmailVeroToken   = "CreateVerifyEmailVeroToken"
+	CreateChangeEmailVeroToken   = "CreateChangeEmailVeroToken"
+	ListVeroTokensByType         = "ListVeroTokensByType"
+	ConsumeVeroToken             = "ConsumeVeroToken"
 )
 
 func (s *Store) CreateVeroToken(ctx context.Context, token *models.VeroToken) (*models.VeroToken, error) {
@@ -709,3 +927,485 @@ func (s *Store) CreateTeamInviteVeroToken(ctx context.Context, token *models.Ver
 	}
 	panic(errors.Fmt("%s callback is not mocked", CreateTeamInviteVeroToken))
 }
+
+func (s *Store) CreateVerifyEmailVeroToken(ctx context.Context, token *models.VeroToken) (*models.VeroToken, error) {
+	s.calls[CreateVerifyEmailVeroToken]++
+	if s.OnCreateVerifyEmailVeroToken != nil {
+		return s.OnCreateVerifyEmailVeroToken(ctx, token)
+	}
+	panic(errors.Fmt("%s callback is not mocked", CreateVerifyEmailVeroToken))
+}
+
+func (s *Store) CreateChangeEmailVeroToken(ctx context.Context, token *models.VeroToken) (*models.VeroToken, error) {
+	s.calls[CreateChangeEmailVeroToken]++
+	if s.OnCreateChangeEmailVeroToken != nil {
+		return s.OnCreateChangeEmailVeroToken(ctx, token)
+	}
+	panic(errors.Fmt("%s callback is not mocked", CreateChangeEmailVeroToken))
+}
+
+func (s *Store) ListVeroTokensByType(ctx context.Context, tokenType enum.TokenType) ([]*models.VeroToken, error) {
+	s.calls[ListVeroTokensByType]++
+	if s.OnListVeroTokensByType != nil {
+		return s.OnListVeroTokensByType(ctx, tokenType)
+	}
+	panic(errors.Fmt("%s callback is not mocked", ListVeroTokensByType))
+}
+
+func (s *Store) ConsumeVeroToken(ctx context.Context, id ulid.ULID, tokenType enum.TokenType) error {
+	s.calls[ConsumeVeroToken]++
+	if s.OnConsumeVeroToken != nil {
+		return s.OnConsumeVeroToken(ctx, id, tokenType)
+	}
+	panic(errors.Fmt("%s callback is not mocked", ConsumeVeroToken))
+}
+
+//===========================================================================
+// RefreshTokenStore
+//===========================================================================
+
+const (
+	CreateRefreshToken       = "CreateRefreshToken"
+	RetrieveRefreshToken     = "RetrieveRefreshToken"
+	UseRefreshToken          = "UseRefreshToken"
+	RevokeRefreshTokenFamily = "RevokeRefreshTokenFamily"
+)
+
+func (s *Store) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) (*models.RefreshToken, error) {
+	s.calls[CreateRefreshToken]++
+	if s.OnCreateRefreshToken != nil {
+		return s.OnCreateRefreshToken(ctx, token)
+	}
+	panic(errors.Fmt("%s callback is not mocked", CreateRefreshToken))
+}
+
+func (s *Store) RetrieveRefreshToken(ctx context.Context, jti ulid.ULID) (*models.RefreshToken, error) {
+	s.calls[RetrieveRefreshToken]++
+	if s.OnRetrieveRefreshToken != nil {
+		return s.OnRetrieveRefreshToken(ctx, jti)
+	}
+	panic(errors.Fmt("%s callback is not mocked", RetrieveRefreshToken))
+}
+
+func (s *Store) UseRefreshToken(ctx context.Context, jti ulid.ULID) (*models.RefreshToken, error) {
+	s.calls[UseRefreshToken]++
+	if s.OnUseRefreshToken != nil {
+		return s.OnUseRefreshToken(ctx, jti)
+	}
+	panic(errors.Fmt("%s callback is not mocked", UseRefreshToken))
+}
+
+func (s *Store) RevokeRefreshTokenFamily(ctx context.Context, familyID ulid.ULID) error {
+	s.calls[RevokeRefreshTokenFamily]++
+	if s.OnRevokeRefreshTokenFamily != nil {
+		return s.OnRevokeRefreshTokenFamily(ctx, familyID)
+	}
+	panic(errors.Fmt("%s callback is not mocked", RevokeRefreshTokenFamily))
+}
+
+//===========================================================================
+// AuthorizationCodeStore
+//===========================================================================
+
+const (
+	CreateAuthorizationCode   = "CreateAuthorizationCode"
+	RetrieveAuthorizationCode = "RetrieveAuthorizationCode"
+	DeleteAuthorizationCode   = "DeleteAuthorizationCode"
+)
+
+func (s *Store) CreateAuthorizationCode(ctx context.Context, code *models.AuthorizationCode) (*models.AuthorizationCode, error) {
+	s.calls[CreateAuthorizationCode]++
+	if s.OnCreateAuthorizationCode != nil {
+		return s.OnCreateAuthorizationCode(ctx, code)
+	}
+	panic(errors.Fmt("%s callback is not mocked", CreateAuthorizationCode))
+}
+
+func (s *Store) RetrieveAuthorizationCode(ctx context.Context, code string) (*models.AuthorizationCode, error) {
+	s.calls[RetrieveAuthorizationCode]++
+	if s.OnRetrieveAuthorizationCode != nil {
+		return s.OnRetrieveAuthorizationCode(ctx, code)
+	}
+	panic(errors.Fmt("%s callback is not mocked", RetrieveAuthorizationCode))
+}
+
+func (s *Store) DeleteAuthorizationCode(ctx context.Context, id ulid.ULID) error {
+	s.calls[DeleteAuthorizationCode]++
+	if s.OnDeleteAuthorizationCode != nil {
+		return s.OnDeleteAuthorizationCode(ctx, id)
+	}
+	panic(errors.Fmt("%s callback is not mocked", DeleteAuthorizationCode))
+}
+
+//===========================================================================
+// RevokedTokenStore
+//===========================================================================
+
+const (
+	RevokeToken    = "RevokeToken"
+	ConsumeToken   = "ConsumeToken"
+	IsTokenRevoked = "IsTokenRevoked"
+)
+
+func (s *Store) RevokeToken(ctx context.Context, token *models.RevokedToken) error {
+	s.calls[RevokeToken]++
+	if s.OnRevokeToken != nil {
+		return s.OnRevokeToken(ctx, token)
+	}
+	panic(errors.Fmt("%s callback is not mocked", RevokeToken))
+}
+
+func (s *Store) ConsumeToken(ctx context.Context, token *models.RevokedToken) error {
+	s.calls[ConsumeToken]++
+	if s.OnConsumeToken != nil {
+		return s.OnConsumeToken(ctx, token)
+	}
+	panic(errors.Fmt("%s callback is not mocked", ConsumeToken))
+}
+
+func (s *Store) IsTokenRevoked(ctx context.Context, jti ulid.ULID) (bool, error) {
+	s.calls[IsTokenRevoked]++
+	if s.OnIsTokenRevoked != nil {
+		return s.OnIsTokenRevoked(ctx, jti)
+	}
+	panic(errors.Fmt("%s callback is not mocked", IsTokenRevoked))
+}
+
+//===========================================================================
+// SessionStore
+//===========================================================================
+
+const (
+	ListSessions       = "ListSessions"
+	CreateSession      = "CreateSession"
+	RetrieveSession    = "RetrieveSession"
+	RefreshSession     = "RefreshSession"
+	RevokeSession      = "RevokeSession"
+	RevokeUserSessions = "RevokeUserSessions"
+)
+
+func (s *Store) ListSessions(ctx context.Context, userID ulid.ULID) ([]*models.Session, error) {
+	s.calls[ListSessions]++
+	if s.OnListSessions != nil {
+		return s.OnListSessions(ctx, userID)
+	}
+	panic(errors.Fmt("%s callback is not mocked", ListSessions))
+}
+
+func (s *Store) CreateSession(ctx context.Context, session *models.Session) (*models.Session, error) {
+	s.calls[CreateSession]++
+	if s.OnCreateSession != nil {
+		return s.OnCreateSession(ctx, session)
+	}
+	panic(errors.Fmt("%s callback is not mocked", CreateSession))
+}
+
+func (s *Store) RetrieveSession(ctx context.Context, id ulid.ULID) (*models.Session, error) {
+	s.calls[RetrieveSession]++
+	if s.OnRetrieveSession != nil {
+		return s.OnRetrieveSession(ctx, id)
+	}
+	panic(errors.Fmt("%s callback is not mocked", RetrieveSession))
+}
+
+func (s *Store) RefreshSession(ctx context.Context, id, jti ulid.ULID, expiration time.Time) error {
+	s.calls[RefreshSession]++
+	if s.OnRefreshSession != nil {
+		return s.OnRefreshSession(ctx, id, jti, expiration)
+	}
+	panic(errors.Fmt("%s callback is not mocked", RefreshSession))
+}
+
+func (s *Store) RevokeSession(ctx context.Context, id ulid.ULID) error {
+	s.calls[RevokeSession]++
+	if s.OnRevokeSession != nil {
+		return s.OnRevokeSession(ctx, id)
+	}
+	panic(errors.Fmt("%s callback is not mocked", RevokeSession))
+}
+
+func (s *Store) RevokeUserSessions(ctx context.Context, userID ulid.ULID) error {
+	s.calls[RevokeUserSessions]++
+	if s.OnRevokeUserSessions != nil {
+		return s.OnRevokeUserSessions(ctx, userID)
+	}
+	panic(errors.Fmt("%s callback is not mocked", RevokeUserSessions))
+}
+
+//===========================================================================
+// WebAuthnCredentialStore
+//===========================================================================
+
+const (
+	ListWebAuthnCredentials    = "ListWebAuthnCredentials"
+	CreateWebAuthnCredential   = "CreateWebAuthnCredential"
+	RetrieveWebAuthnCredential = "RetrieveWebAuthnCredential"
+	UpdateWebAuthnCredential   = "UpdateWebAuthnCredential"
+	DeleteWebAuthnCredential   = "DeleteWebAuthnCredential"
+)
+
+func (s *Store) ListWebAuthnCredentials(ctx context.Context, filter tidal.ListFilter) (tidal.Cursor[*models.WebAuthnCredential], error) {
+	s.calls[ListWebAuthnCredentials]++
+	if s.OnListWebAuthnCredentials != nil {
+		return s.OnListWebAuthnCredentials(ctx, filter)
+	}
+	panic(errors.Fmt("%s callback is not mocked", ListWebAuthnCredentials))
+}
+
+func (s *Store) CreateWebAuthnCredential(ctx context.Context, credential *models.WebAuthnCredential) (*models.WebAuthnCredential, error) {
+	s.calls[CreateWebAuthnCredential]++
+	if s.OnCreateWebAuthnCredential != nil {
+		return s.OnCreateWebAuthnCredential(ctx, credential)
+	}
+	panic(errors.Fmt("%s callback is not mocked", CreateWebAuthnCredential))
+}
+
+func (s *Store) RetrieveWebAuthnCredential(ctx context.Context, credentialID []byte) (*models.WebAuthnCredential, error) {
+	s.calls[RetrieveWebAuthnCredential]++
+	if s.OnRetrieveWebAuthnCredential != nil {
+		return s.OnRetrieveWebAuthnCredential(ctx, credentialID)
+	}
+	panic(errors.Fmt("%s callback is not mocked", RetrieveWebAuthnCredential))
+}
+
+func (s *Store) UpdateWebAuthnCredential(ctx context.Context, credential *models.WebAuthnCredential) error {
+	s.calls[UpdateWebAuthnCredential]++
+	if s.OnUpdateWebAuthnCredential != nil {
+		return s.OnUpdateWebAuthnCredential(ctx, credential)
+	}
+	panic(errors.Fmt("%s callback is not mocked", UpdateWebAuthnCredential))
+}
+
+func (s *Store) DeleteWebAuthnCredential(ctx context.Context, id ulid.ULID) error {
+	s.calls[DeleteWebAuthnCredential]++
+	if s.OnDeleteWebAuthnCredential != nil {
+		return s.OnDeleteWebAuthnCredential(ctx, id)
+	}
+	panic(errors.Fmt("%s callback is not mocked", DeleteWebAuthnCredential))
+}
+
+//===========================================================================
+// LockoutStore
+//===========================================================================
+
+const (
+	RetrieveLockout     = "RetrieveLockout"
+	RecordFailedAttempt = "RecordFailedAttempt"
+	ResetLockout        = "ResetLockout"
+)
+
+func (s *Store) RetrieveLockout(ctx context.Context, kind, identifier string) (*models.Lockout, error) {
+	s.calls[RetrieveLockout]++
+	if s.OnRetrieveLockout != nil {
+		return s.OnRetrieveLockout(ctx, kind, identifier)
+	}
+	panic(errors.Fmt("%s callback is not mocked", RetrieveLockout))
+}
+
+func (s *Store) RecordFailedAttempt(ctx context.Context, kind, identifier string, policy models.LockoutPolicy) (*models.Lockout, error) {
+	s.calls[RecordFailedAttempt]++
+	if s.OnRecordFailedAttempt != nil {
+		return s.OnRecordFailedAttempt(ctx, kind, identifier, policy)
+	}
+	panic(errors.Fmt("%s callback is not mocked", RecordFailedAttempt))
+}
+
+func (s *Store) ResetLockout(ctx context.Context, kind, identifier string) error {
+	s.calls[ResetLockout]++
+	if s.OnResetLockout != nil {
+		return s.OnResetLockout(ctx, kind, identifier)
+	}
+	panic(errors.Fmt("%s callback is not mocked", ResetLockout))
+}
+
+//===========================================================================
+// AuditEventStore
+//===========================================================================
+
+const (
+	ListAuditEvents  = "ListAuditEvents"
+	CreateAuditEvent = "CreateAuditEvent"
+)
+
+func (s *Store) ListAuditEvents(ctx context.Context, filter tidal.ListFilter) (tidal.Cursor[*models.AuditEvent], error) {
+	s.calls[ListAuditEvents]++
+	if s.OnListAuditEvents != nil {
+		return s.OnListAuditEvents(ctx, filter)
+	}
+	panic(errors.Fmt("%s callback is not mocked", ListAuditEvents))
+}
+
+func (s *Store) CreateAuditEvent(ctx context.Context, event *models.AuditEvent) (*models.AuditEvent, error) {
+	s.calls[CreateAuditEvent]++
+	if s.OnCreateAuditEvent != nil {
+		return s.OnCreateAuditEvent(ctx, event)
+	}
+	panic(errors.Fmt("%s callback is not mocked", CreateAuditEvent))
+}
+
+//===========================================================================
+// WebhookStore
+//===========================================================================
+
+const (
+	ListWebhooks              = "ListWebhooks"
+	CreateWebhook             = "CreateWebhook"
+	RetrieveWebhook           = "RetrieveWebhook"
+	UpdateWebhook             = "UpdateWebhook"
+	UpdateWebhookSecret       = "UpdateWebhookSecret"
+	DeleteWebhook             = "DeleteWebhook"
+	EnqueueWebhookEvent       = "EnqueueWebhookEvent"
+	CreateWebhookDelivery     = "CreateWebhookDelivery"
+	RetrieveWebhookDelivery   = "RetrieveWebhookDelivery"
+	UpdateWebhookDelivery     = "UpdateWebhookDelivery"
+	ListWebhookDeliveries     = "ListWebhookDeliveries"
+	ClaimDueWebhookDeliveries = "ClaimDueWebhookDeliveries"
+)
+
+func (s *Store) ListWebhooks(ctx context.Context, filter tidal.ListFilter) (tidal.Cursor[*models.Webhook], error) {
+	s.calls[ListWebhooks]++
+	if s.OnListWebhooks != nil {
+		return s.OnListWebhooks(ctx, filter)
+	}
+	panic(errors.Fmt("%s callback is not mocked", ListWebhooks))
+}
+
+func (s *Store) CreateWebhook(ctx context.Context, webhook *models.Webhook) (*models.Webhook, error) {
+	s.calls[CreateWebhook]++
+	if s.OnCreateWebhook != nil {
+		return s.OnCreateWebhook(ctx, webhook)
+	}
+	panic(errors.Fmt("%s callback is not mocked", CreateWebhook))
+}
+
+func (s *Store) RetrieveWebhook(ctx context.Context, id ulid.ULID) (*models.Webhook, error) {
+	s.calls[RetrieveWebhook]++
+	if s.OnRetrieveWebhook != nil {
+		return s.OnRetrieveWebhook(ctx, id)
+	}
+	panic(errors.Fmt("%s callback is not mocked", RetrieveWebhook))
+}
+
+func (s *Store) UpdateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	s.calls[UpdateWebhook]++
+	if s.OnUpdateWebhook != nil {
+		return s.OnUpdateWebhook(ctx, webhook)
+	}
+	panic(errors.Fmt("%s callback is not mocked", UpdateWebhook))
+}
+
+func (s *Store) UpdateWebhookSecret(ctx context.Context, id ulid.ULID, secret string) error {
+	s.calls[UpdateWebhookSecret]++
+	if s.OnUpdateWebhookSecret != nil {
+		return s.OnUpdateWebhookSecret(ctx, id, secret)
+	}
+	panic(errors.Fmt("%s callback is not mocked", UpdateWebhookSecret))
+}
+
+func (s *Store) DeleteWebhook(ctx context.Context, id ulid.ULID) error {
+	s.calls[DeleteWebhook]++
+	if s.OnDeleteWebhook != nil {
+		return s.OnDeleteWebhook(ctx, id)
+	}
+	panic(errors.Fmt("%s callback is not mocked", DeleteWebhook))
+}
+
+func (s *Store) EnqueueWebhookEvent(ctx context.Context, eventID ulid.ULID, event enum.WebhookEvent, payload []byte) (int, error) {
+	s.calls[EnqueueWebhookEvent]++
+	if s.OnEnqueueWebhookEvent != nil {
+		return s.OnEnqueueWebhookEvent(ctx, eventID, event, payload)
+	}
+	panic(errors.Fmt("%s callback is not mocked", EnqueueWebhookEvent))
+}
+
+func (s *Store) CreateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) (*models.WebhookDelivery, error) {
+	s.calls[CreateWebhookDelivery]++
+	if s.OnCreateWebhookDelivery != nil {
+		return s.OnCreateWebhookDelivery(ctx, delivery)
+	}
+	panic(errors.Fmt("%s callback is not mocked", CreateWebhookDelivery))
+}
+
+func (s *Store) RetrieveWebhookDelivery(ctx context.Context, id ulid.ULID) (*models.WebhookDelivery, error) {
+	s.calls[RetrieveWebhookDelivery]++
+	if s.OnRetrieveWebhookDelivery != nil {
+		return s.OnRetrieveWebhookDelivery(ctx, id)
+	}
+	panic(errors.Fmt("%s callback is not mocked", RetrieveWebhookDelivery))
+}
+
+func (s *Store) UpdateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	s.calls[UpdateWebhookDelivery]++
+	if s.OnUpdateWebhookDelivery != nil {
+		return s.OnUpdateWebhookDelivery(ctx, delivery)
+	}
+	panic(errors.Fmt("%s callback is not mocked", UpdateWebhookDelivery))
+}
+
+func (s *Store) ListWebhookDeliveries(ctx context.Context, webhookID ulid.ULID) ([]*models.WebhookDelivery, error) {
+	s.calls[ListWebhookDeliveries]++
+	if s.OnListWebhookDeliveries != nil {
+		return s.OnListWebhookDeliveries(ctx, webhookID)
+	}
+	panic(errors.Fmt("%s callback is not mocked", ListWebhookDeliveries))
+}
+
+func (s *Store) ClaimDueWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*models.WebhookDelivery, error) {
+	s.calls[ClaimDueWebhookDeliveries]++
+	if s.OnClaimDueWebhookDeliveries != nil {
+		return s.OnClaimDueWebhookDeliveries(ctx, now, lease, limit)
+	}
+	panic(errors.Fmt("%s callback is not mocked", ClaimDueWebhookDeliveries))
+}
+
+//===========================================================================
+// SigningKeyStore
+//===========================================================================
+
+const (
+	ListSigningKeys    = "ListSigningKeys"
+	CreateSigningKey   = "CreateSigningKey"
+	RetrieveSigningKey = "RetrieveSigningKey"
+	ActivateSigningKey = "ActivateSigningKey"
+	RetireSigningKey   = "RetireSigningKey"
+)
+
+func (s *Store) ListSigningKeys(ctx context.Context) ([]*models.SigningKey, error) {
+	s.calls[ListSigningKeys]++
+	if s.OnListSigningKeys != nil {
+		return s.OnListSigningKeys(ctx)
+	}
+	panic(errors.Fmt("%s callback is not mocked", ListSigningKeys))
+}
+
+func (s *Store) CreateSigningKey(ctx context.Context, key *models.SigningKey) (*models.SigningKey, error) {
+	s.calls[CreateSigningKey]++
+	if s.OnCreateSigningKey != nil {
+		return s.OnCreateSigningKey(ctx, key)
+	}
+	panic(errors.Fmt("%s callback is not mocked", CreateSigningKey))
+}
+
+func (s *Store) RetrieveSigningKey(ctx context.Context, id ulid.ULID) (*models.SigningKey, error) {
+	s.calls[RetrieveSigningKey]++
+	if s.OnRetrieveSigningKey != nil {
+		return s.OnRetrieveSigningKey(ctx, id)
+	}
+	panic(errors.Fmt("%s callback is not mocked", RetrieveSigningKey))
+}
+
+func (s *Store) ActivateSigningKey(ctx context.Context, id ulid.ULID, retires time.Time) error {
+	s.calls[ActivateSigningKey]++
+	if s.OnActivateSigningKey != nil {
+		return s.OnActivateSigningKey(ctx, id, retires)
+	}
+	panic(errors.Fmt("%s callback is not mocked", ActivateSigningKey))
+}
+
+func (s *Store) RetireSigningKey(ctx context.Context, id ulid.ULID) error {
+	s.calls[RetireSigningKey]++
+	if s.OnRetireSigningKey != nil {
+		return s.OnRetireSigningKey(ctx, id)
+	}
+	panic(errors.Fmt("%s callback is not mocked", RetireSigningKey))
+}
@@ -125,6 +125,27 @@ func (t *Txn) VerifyEmail(userID ulid.ULID) error {
 	return t.store.VerifyEmail(t.ctx, userID)
 }
 
+func (t *Txn) UpdateMFA(user *models.User) error {
+	if err := t.requireWrite(); err != nil {
+		return err
+	}
+	return t.store.UpdateMFA(t.ctx, user)
+}
+
+func (t *Txn) UpdateTOTPStep(userID ulid.ULID, step int64) error {
+	if err := t.requireWrite(); err != nil {
+		return err
+	}
+	return t.store.UpdateTOTPStep(t.ctx, userID, step)
+}
+
+func (t *Txn) UpdateUserStatus(userID ulid.ULID, status enum.UserStatus) error {
+	if err := t.requireWrite(); err != nil {
+		return err
+	}
+	return t.store.UpdateUserStatus(t.ctx, userID, status)
+}
+
 func (t *Txn) DeleteUser(userID ulid.ULID) error {
 	if err := t.requireWrite(); err != nil {
 		return err
@@ -132,6 +153,13 @@ func (t *Txn) DeleteUser(userID ulid.ULID) error {
 	return t.store.DeleteUser(t.ctx, userID)
 }
 
+func (t *Txn) PurgeUsers(deletedBefore time.Time) ([]ulid.ULID, error) {
+	if err := t.requireWrite(); err != nil {
+		return nil, err
+	}
+	return t.store.PurgeUsers(t.ctx, deletedBefore)
+}
+
 func (t *Txn) AddRoleToUser(userID ulid.ULID, roleID int64) error {
 	if err := t.requireWrite(); err != nil {
 		return err
@@ -262,6 +290,58 @@ func (t *Txn) DeletePermission(id int64) error {
 	return t.store.DeletePermission(t.ctx, id)
 }
 
+//===========================================================================
+// OrganizationStore
+//===========================================================================
+
+func (t *Txn) ListOrganizations(filter tidal.ListFilter) (tidal.Cursor[*models.Organization], error) {
+	return t.store.ListOrganizations(t.ctx, filter)
+}
+
+func (t *Txn) CreateOrganization(org *models.Organization) (*models.Organization, error) {
+	if err := t.requireWrite(); err != nil {
+		return nil, err
+	}
+	return t.store.CreateOrganization(t.ctx, org)
+}
+
+func (t *Txn) RetrieveOrganization(id ulid.ULID) (*models.Organization, error) {
+	return t.store.RetrieveOrganization(t.ctx, id)
+}
+
+func (t *Txn) UpdateOrganization(org *models.Organization) error {
+	if err := t.requireWrite(); err != nil {
+		return err
+	}
+	return t.store.UpdateOrganization(t.ctx, org)
+}
+
+func (t *Txn) DeleteOrganization(id ulid.ULID) error {
+	if err := t.requireWrite(); err != nil {
+		return err
+	}
+	return t.store.DeleteOrganization(t.ctx, id)
+}
+
+func (t *Txn) ListUserOrganizations(userID ulid.ULID) ([]*models.Organization, error) {
+	return t.store.ListUserOrganizations(t.ctx, userID)
+}
+
+func (t *Txn) ListOrganizationMembers(orgID ulid.ULID) ([]*models.User, error) {
+	return t.store.ListOrganizationMembers(t.ctx, orgID)
+}
+
+func (t *Txn) RetrieveOrganizationMember(orgID, userID ulid.ULID) (*models.User, error) {
+	return t.store.RetrieveOrganizationMember(t.ctx, orgID, userID)
+}
+
+func (t *Txn) ReplaceOrganizationMemberRoles(orgID, userID ulid.ULID, roleIDs []int64) error {
+	if err := t.requireWrite(); err != nil {
+		return err
+	}
+	return t.store.ReplaceOrganizationMemberRoles(t.ctx, orgID, userID, roleIDs)
+}
+
 //===========================================================================
 // APIKeyStore
 //===========================================================================
@@ -410,6 +490,20 @@ func (t *Txn) CreateTeamInviteVeroToken(token *models.VeroToken) (*models.VeroTo
 	return t.store.CreateTeamInviteVeroToken(t.ctx, token)
 }
 
+func (t *Txn) CreateVerifyEmailVeroToken(token *models.VeroToken) (*models.VeroToken, error) {
+	if err := t.requireWrite(); err != nil {
+		return nil, err
+	}
+	return t.store.CreateVerifyEmailVeroToken(t.ctx, token)
+}
+
+func (t *Txn) CreateChangeEmailVeroToken(token *models.VeroToken) (*models.VeroToken, error) {
+	if err := t.requireWrite(); err != nil {
+		return nil, err
+	}
+	return t.store.CreateChangeEmailVeroToken(t.ctx, token)
+}
+
 func (t *Txn) RetrieveVeroToken(id ulid.ULID) (*models.VeroToken, error) {
 	return t.store.RetrieveVeroToken(t.ctx, id)
 }
@@ -418,6 +512,10 @@ func (t *Txn) RetrieveVeroTokenByResource(resourceID ulid.ULID, tokenType enum.T
 	return t.store.RetrieveVeroTokenByResource(t.ctx, resourceID, tokenType)
 }
 
+func (t *Txn) ListVeroTokensByType(tokenType enum.TokenType) ([]*models.VeroToken, error) {
+	return t.store.ListVeroTokensByType(t.ctx, tokenType)
+}
+
 func (t *Txn) UpdateVeroToken(token *models.VeroToken) error {
 	if err := t.requireWrite(); err != nil {
 		return err
@@ -432,9 +530,315 @@ func (t *Txn) DeleteVeroToken(id ulid.ULID) error {
 	return t.store.DeleteVeroToken(t.ctx, id)
 }
 
+func (t *Txn) ConsumeVeroToken(id ulid.ULID, tokenType enum.TokenType) error {
+	if err := t.requireWrite(); err != nil {
+		return err
+	}
+	return t.store.ConsumeVeroToken(t.ctx, id, tokenType)
+}
+
 func (t *Txn) CompletePasswordReset(veroTokenID ulid.ULID, newPassword string) error {
 	if err := t.requireWrite(); err != nil {
 		return err
 	}
 	return t.store.CompletePasswordReset(t.ctx, veroTokenID, newPassword)
 }
+
+func (t *Txn) CompleteEmailChange(veroTokenID ulid.ULID) error {
+	if err := t.requireWrite(); err != nil {
+		return err
+	}
+	return t.store.CompleteEmailChange(t.ctx, veroTokenID)
+}
+
+//===========================================================================
+// RefreshTokenStore
+//===========================================================================
+
+func (t *Txn) CreateRefreshToken(token *models.RefreshToken) (*models.RefreshToken, error) {
+	if err := t.requireWrite(); err != nil {
+		return nil, err
+	}
+	return t.store.CreateRefreshToken(t.ctx, token)
+}
+
+func (t *Txn) RetrieveRefreshToken(jti ulid.ULID) (*models.RefreshToken, error) {
+	return t.store.RetrieveRefreshToken(t.ctx, jti)
+}
+
+func (t *Txn) UseRefreshToken(jti ulid.ULID) (*models.RefreshToken, error) {
+	if err := t.requireWrite(); err != nil {
+		return nil, err
+	}
+	return t.store.UseRefreshToken(t.ctx, jti)
+}
+
+func (t *Txn) RevokeRefreshTokenFamily(familyID ulid.ULID) error {
+	if err := t.requireWrite(); err != nil {
+		return err
+	}
+	return t.store.RevokeRefreshTokenFamily(t.ctx, familyID)
+}
+
+//===========================================================================
+// AuthorizationCodeStore
+//===========================================================================
+
+func (t *Txn) CreateAuthorizationCode(code *models.AuthorizationCode) (*models.AuthorizationCode, error) {
+	if err := t.requireWrite(); err != nil {
+		return nil, err
+	}
+	return t.store.CreateAuthorizationCode(t.ctx, code)
+}
+
+func (t *Txn) RetrieveAuthorizationCode(code string) (*models.AuthorizationCode, error) {
+	return t.store.RetrieveAuthorizationCode(t.ctx, code)
+}
+
+func (t *Txn) DeleteAuthorizationCode(id ulid.ULID) error {
+	if err := t.requireWrite(); err != nil {
+		return err
+	}
+	return t.store.DeleteAuthorizationCode(t.ctx, id)
+}
+
+//===========================================================================
+// RevokedTokenStore
+//===========================================================================
+
+func (t *Txn) RevokeToken(token *models.RevokedToken) error {
+	if err := t.requireWrite(); err != nil {
+		return err
+	}
+	return t.store.RevokeToken(t.ctx, token)
+}
+
+func (t *Txn) ConsumeToken(token *models.RevokedToken) error {
+	if err := t.requireWrite(); err != nil {
+		return err
+	}
+	return t.store.ConsumeToken(t.ctx, token)
+}
+
+func (t *Txn) IsTokenRevoked(jti ulid.ULID) (bool, error) {
+	return t.store.IsTokenRevoked(t.ctx, jti)
+}
+
+//===========================================================================
+// SessionStore
+//===========================================================================
+
+func (t *Txn) ListSessions(userID ulid.ULID) ([]*models.Session, error) {
+	return t.store.ListSessions(t.ctx, userID)
+}
+
+func (t *Txn) CreateSession(session *models.Session) (*models.Session, error) {
+	if err := t.requireWrite(); err != nil {
+		return nil, err
+	}
+	return t.store.CreateSession(t.ctx, session)
+}
+
+func (t *Txn) RetrieveSession(id ulid.ULID) (*models.Session, error) {
+	return t.store.RetrieveSession(t.ctx, id)
+}
+
+func (t *Txn) RefreshSession(id, jti ulid.ULID, expiration time.Time) error {
+	if err := t.requireWrite(); err != nil {
+		return err
+	}
+	return t.store.RefreshSession(t.ctx, id, jti, expiration)
+}
+
+func (t *Txn) RevokeSession(id ulid.ULID) error {
+	if err := t.requireWrite(); err != nil {
+		return err
+	}
+	return t.store.RevokeSession(t.ctx, id)
+}
+
+func (t *Txn) RevokeUserSessions(userID ulid.ULID) error {
+	if err := t.requireWrite(); err != nil {
+		return err
+	}
+	return t.store.RevokeUserSessions(t.ctx, userID)
+}
+
+//===========================================================================
+// WebAuthnCredentialStore
+//===========================================================================
+
+func (t *Txn) ListWebAuthnCredentials(filter tidal.ListFilter) (tidal.Cursor[*models.WebAuthnCredential], error) {
+	return t.store.ListWebAuthnCredentials(t.ctx, filter)
+}
+
+func (t *Txn) CreateWebAuthnCredential(credential *models.WebAuthnCredential) (*models.WebAuthnCredential, error) {
+	if err := t.requireWrite(); err != nil {
+		return nil, err
+	}
+	return t.store.CreateWebAuthnCredential(t.ctx, credential)
+}
+
+func (t *Txn) RetrieveWebAuthnCredential(credentialID []byte) (*models.WebAuthnCredential, error) {
+	return t.store.RetrieveWebAuthnCredential(t.ctx, credentialID)
+}
+
+func (t *Txn) UpdateWebAuthnCredential(credential *models.WebAuthnCredential) error {
+	if err := t.requireWrite(); err != nil {
+		return err
+	}
+	return t.store.UpdateWebAuthnCredential(t.ctx, credential)
+}
+
+func (t *Txn) DeleteWebAuthnCredential(id ulid.ULID) error {
+	if err := t.requireWrite(); err != nil {
+		return err
+	}
+	return t.store.DeleteWebAuthnCredential(t.ctx, id)
+}
+
+//===========================================================================
+// LockoutStore
+//===========================================================================
+
+func (t *Txn) RetrieveLockout(kind, identifier string) (*models.Lockout, error) {
+	return t.store.RetrieveLockout(t.ctx, kind, identifier)
+}
+
+func (t *Txn) RecordFailedAttempt(kind, identifier string, policy models.LockoutPolicy) (*models.Lockout, error) {
+	if err := t.requireWrite(); err != nil {
+		return nil, err
+	}
+	return t.store.RecordFailedAttempt(t.ctx, kind, identifier, policy)
+}
+
+func (t *Txn) ResetLockout(kind, identifier string) error {
+	if err := t.requireWrite(); err != nil {
+		return err
+	}
+	return t.store.ResetLockout(t.ctx, kind, identifier)
+}
+
+//===========================================================================
+// AuditEventStore
+//===========================================================================
+
+func (t *Txn) ListAuditEvents(filter tidal.ListFilter) (tidal.Cursor[*models.AuditEvent], error) {
+	return t.store.ListAuditEvents(t.ctx, filter)
+}
+
+func (t *Txn) CreateAuditEvent(event *models.AuditEvent) (*models.AuditEvent, error) {
+	if err := t.requireWrite(); err != nil {
+		return nil, err
+	}
+	return t.store.CreateAuditEvent(t.ctx, event)
+}
+
+//===========================================================================
+// WebhookStore
+//===========================================================================
+
+func (t *Txn) ListWebhooks(filter tidal.ListFilter) (tidal.Cursor[*models.Webhook], error) {
+	return t.store.ListWebhooks(t.ctx, filter)
+}
+
+func (t *Txn) CreateWebhook(webhook *models.Webhook) (*models.Webhook, error) {
+	if err := t.requireWrite(); err != nil {
+		return nil, err
+	}
+	return t.store.CreateWebhook(t.ctx, webhook)
+}
+
+func (t *Txn) RetrieveWebhook(id ulid.ULID) (*models.Webhook, error) {
+	return t.store.RetrieveWebhook(t.ctx, id)
+}
+
+func (t *Txn) UpdateWebhook(webhook *models.Webhook) error {
+	if err := t.requireWrite(); err != nil {
+		return err
+	}
+	return t.store.UpdateWebhook(t.ctx, webhook)
+}
+
+func (t *Txn) UpdateWebhookSecret(id ulid.ULID, secret string) error {
+	if err := t.requireWrite(); err != nil {
+		return err
+	}
+	return t.store.UpdateWebhookSecret(t.ctx, id, secret)
+}
+
+func (t *Txn) DeleteWebhook(id ulid.ULID) error {
+	if err := t.requireWrite(); err != nil {
+		return err
+	}
+	return t.store.DeleteWebhook(t.ctx, id)
+}
+
+func (t *Txn) EnqueueWebhookEvent(eventID ulid.ULID, event enum.WebhookEvent, payload []byte) (int, error) {
+	if err := t.requireWrite(); err != nil {
+		return 0, err
+	}
+	return t.store.EnqueueWebhookEvent(t.ctx, eventID, event, payload)
+}
+
+func (t *Txn) CreateWebhookDelivery(delivery *models.WebhookDelivery) (*models.WebhookDelivery, error) {
+	if err := t.requireWrite(); err != nil {
+		return nil, err
+	}
+	return t.store.CreateWebhookDelivery(t.ctx, delivery)
+}
+
+func (t *Txn) RetrieveWebhookDelivery(id ulid.ULID) (*models.WebhookDelivery, error) {
+	return t.store.RetrieveWebhookDelivery(t.ctx, id)
+}
+
+func (t *Txn) UpdateWebhookDelivery(delivery *models.WebhookDelivery) error {
+	if err := t.requireWrite(); err != nil {
+		return err
+	}
+	return t.store.UpdateWebhookDelivery(t.ctx, delivery)
+}
+
+func (t *Txn) ListWebhookDeliveries(webhookID ulid.ULID) ([]*models.WebhookDelivery, error) {
+	return t.store.ListWebhookDeliveries(t.ctx, webhookID)
+}
+
+func (t *Txn) ClaimDueWebhookDeliveries(now time.Time, lease time.Duration, limit int) ([]*models.WebhookDelivery, error) {
+	if err := t.requireWrite(); err != nil {
+		return nil, err
+	}
+	return t.store.ClaimDueWebhookDeliveries(t.ctx, now, lease, limit)
+}
+
+//===========================================================================
+// SigningKeyStore
+//===========================================================================
+
+func (t *Txn) ListSigningKeys() ([]*models.SigningKey, error) {
+	return t.store.ListSigningKeys(t.ctx)
+}
+
+func (t *Txn) CreateSigningKey(key *models.SigningKey) (*models.SigningKey, error) {
+	if err := t.requireWrite(); err != nil {
+		return nil, err
+	}
+	return t.store.CreateSigningKey(t.ctx, key)
+}
+
+func (t *Txn) RetrieveSigningKey(id ulid.ULID) (*models.SigningKey, error) {
+	return t.store.RetrieveSigningKey(t.ctx, id)
+}
+
+func (t *Txn) ActivateSigningKey(id ulid.ULID, retires time.Time) error {
+	if err := t.requireWrite(); err != nil {
+		return err
+	}
+	return t.store.ActivateSigningKey(t.ctx, id, retires)
+}
+
+func (t *Txn) RetireSigningKey(id ulid.ULID) error {
+	if err := t.requireWrite(); err != nil {
+		return err
+	}
+	return t.store.RetireSigningKey(t.ctx, id)
+}
@@ -20,6 +20,7 @@ type APIKey struct {
 	ClientID    string
 	Secret      string
 	CreatedBy   ulid.ULID
+	OrgID       ulid.NullULID // the organization the key belongs to, if any
 	LastSeen    sql.NullTime
 	Revoked     sql.NullTime
 	Permissions []Permission
@@ -36,6 +37,7 @@ func (k *APIKey) Fields(op tidal.Operation) []string {
 			"description",
 			"client_id",
 			"created_by",
+			"organization_id",
 			"last_seen",
 			"revoked",
 			"created",
@@ -54,6 +56,7 @@ func (k *APIKey) Fields(op tidal.Operation) []string {
 			"client_id",
 			"secret",
 			"created_by",
+			"organization_id",
 			"last_seen",
 			"revoked",
 			"created",
@@ -77,6 +80,7 @@ func (k *APIKey) Params(op tidal.Operation) []sql.NamedArg {
 			sql.Named("client_id", k.ClientID),
 			sql.Named("secret", k.Secret),
 			sql.Named("created_by", k.CreatedBy),
+			sql.Named("organization_id", k.OrgID),
 			sql.Named("last_seen", k.LastSeen),
 			sql.Named("revoked", k.Revoked),
 			sql.Named("created", k.Created),
@@ -93,6 +97,7 @@ func (k *APIKey) Scan(op tidal.Operation, s tidal.Scanner) error {
 			&k.Description,
 			&k.ClientID,
 			&k.CreatedBy,
+			&k.OrgID,
 			&k.LastSeen,
 			&k.Revoked,
 			&k.Created,
@@ -105,6 +110,7 @@ func (k *APIKey) Scan(op tidal.Operation, s tidal.Scanner) error {
 			&k.ClientID,
 			&k.Secret,
 			&k.CreatedBy,
+			&k.OrgID,
 			&k.LastSeen,
 			&k.Revoked,
 			&k.Created,
@@ -55,6 +55,7 @@ func TestAPIKeyScan(t *testing.T) {
 			"Test api keys for development",
 			"XUiRZrNDUnLjeenQQmblpv",
 			ulid.MakeSecure().String(),
+			ulid.MakeSecure().String(),
 			time.Now().Add(-1 * time.Hour),
 			nil,
 			time.Now().Add(-14 * time.Hour),
@@ -75,10 +76,12 @@ func TestAPIKeyScan(t *testing.T) {
 		require.Equal(t, data[2], model.ClientID)
 		require.Zero(t, model.Secret)
 		require.Equal(t, data[3], model.CreatedBy.String())
-		require.Equal(t, data[4], model.LastSeen.Time)
+		require.True(t, model.OrgID.Valid)
+		require.Equal(t, data[4], model.OrgID.ULID.String())
+		require.Equal(t, data[5], model.LastSeen.Time)
 		require.False(t, model.Revoked.Valid)
-		require.Equal(t, data[6], model.Created)
-		require.Equal(t, data[7], model.Modified)
+		require.Equal(t, data[7], model.Created)
+		require.Equal(t, data[8], model.Modified)
 	})
 
 	t.Run("Nulls", func(t *testing.T) {
@@ -91,6 +94,7 @@ func TestAPIKeyScan(t *testing.T) {
 			ulid.MakeSecure().String(),
 			nil,
 			nil,
+			nil,
 			time.Now(),
 			time.Time{},
 		}
@@ -105,6 +109,7 @@ func TestAPIKeyScan(t *testing.T) {
 
 		// Assert: null SQL values produce invalid Null* fields and zero modified.
 		require.False(t, model.Description.Valid)
+		require.False(t, model.OrgID.Valid)
 		require.False(t, model.LastSeen.Valid)
 		require.False(t, model.Revoked.Valid)
 		require.True(t, model.Modified.IsZero())
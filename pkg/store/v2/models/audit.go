@@ -0,0 +1,215 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"reflect"
+
+	qerrors "go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/tidal"
+	"go.rtnl.ai/ulid"
+)
+
+// Audit resource types identify the kind of actor that performed an audited action and
+// the kind of subject that the action was performed on.
+const (
+	AuditUser         = "user"
+	AuditAPIKey       = "apikey"
+	AuditOIDCClient   = "oidc_client"
+	AuditRole         = "role"
+	AuditPermission   = "permission"
+	AuditOrganization = "organization"
+	AuditInvite       = "invite"
+	AuditWebhook      = "webhook"
+	AuditSigningKey   = "signing_key"
+)
+
+// Audit actions describe what the actor did to the subject of the event.
+const (
+	AuditCreate         = "create"
+	AuditUpdate         = "update"
+	AuditDelete         = "delete"
+	AuditLogin          = "login"
+	AuditLoginFailed    = "login_failed"
+	AuditPasswordChange = "password_change"
+	AuditUnlock         = "unlock"
+	AuditResend         = "resend"
+	AuditAccept         = "accept"
+	AuditVerifyEmail    = "verify_email"
+	AuditChangeEmail    = "change_email"
+	AuditConfirmEmail   = "confirm_email"
+	AuditRevertEmail    = "revert_email"
+	AuditStatusChange   = "status_change"
+	AuditRestore        = "restore"
+	AuditPurge          = "purge"
+	AuditRedeliver      = "redeliver"
+	AuditRotate         = "rotate"
+	AuditRetire         = "retire"
+)
+
+// AuditEvent records who did what to which resource and from where. Events are
+// immutable once created and do not reference the actor or subject by foreign key so
+// that the history of a resource is retained after it is deleted. The Diff is a JSON
+// object of the fields that were changed by the action; secrets are never recorded.
+type AuditEvent struct {
+	tidal.BaseModel
+	ActorType      sql.NullString // empty if the actor was not authenticated (e.g. a failed login)
+	ActorID        ulid.NullULID
+	OrganizationID ulid.NullULID // the organization the actor was logged into, if any
+	Action         string
+	SubjectType    string
+	SubjectID      string
+	ClientIP       sql.NullString
+	UserAgent      sql.NullString
+	RequestID      sql.NullString
+	Diff           sql.NullString
+}
+
+var _ tidal.Model = (*AuditEvent)(nil)
+var _ tidal.Validator = (*AuditEvent)(nil)
+
+func (e *AuditEvent) Fields(op tidal.Operation) []string {
+	return []string{
+		"id",
+		"actor_type",
+		"actor_id",
+		"organization_id",
+		"action",
+		"subject_type",
+		"subject_id",
+		"client_ip",
+		"user_agent",
+		"request_id",
+		"diff",
+		"created",
+		"modified",
+	}
+}
+
+func (e *AuditEvent) Params(op tidal.Operation) []sql.NamedArg {
+	return []sql.NamedArg{
+		sql.Named("id", e.ID),
+		sql.Named("actor_type", e.ActorType),
+		sql.Named("actor_id", e.ActorID),
+		sql.Named("organization_id", e.OrganizationID),
+		sql.Named("action", e.Action),
+		sql.Named("subject_type", e.SubjectType),
+		sql.Named("subject_id", e.SubjectID),
+		sql.Named("client_ip", e.ClientIP),
+		sql.Named("user_agent", e.UserAgent),
+		sql.Named("request_id", e.RequestID),
+		sql.Named("diff", e.Diff),
+		sql.Named("created", e.Created),
+		sql.Named("modified", e.Modified),
+	}
+}
+
+func (e *AuditEvent) Scan(op tidal.Operation, s tidal.Scanner) error {
+	return s.Scan(
+		&e.ID,
+		&e.ActorType,
+		&e.ActorID,
+		&e.OrganizationID,
+		&e.Action,
+		&e.SubjectType,
+		&e.SubjectID,
+		&e.ClientIP,
+		&e.UserAgent,
+		&e.RequestID,
+		&e.Diff,
+		&e.Created,
+		&e.Modified,
+	)
+}
+
+// Validates that Action, SubjectType, and SubjectID are set on create; default
+// [tidal.BaseModel.Validate] runs first.
+func (e *AuditEvent) Validate(op tidal.Operation) error {
+	if err := e.BaseModel.Validate(op); err != nil {
+		return err
+	}
+	if op == tidal.Create {
+		if e.Action == "" || e.SubjectType == "" || e.SubjectID == "" {
+			return qerrors.ErrZeroValuedNotNull
+		}
+	}
+	return nil
+}
+
+//===========================================================================
+// Diffs
+//===========================================================================
+
+// AuditChange is the value of a field before and after an audited action.
+type AuditChange struct {
+	From any `json:"from,omitempty"`
+	To   any `json:"to,omitempty"`
+}
+
+// Fields that are never recorded in an audit diff: secrets must not be stored in the
+// audit log and timestamps are already recorded by the event itself.
+var auditRedacted = map[string]struct{}{
+	"password":      {},
+	"secret":        {},
+	"client_secret": {},
+	"created":       {},
+	"modified":      {},
+}
+
+// AuditDiff compares the JSON representations of a resource before and after an
+// action and returns a JSON object of the fields that changed, mapped to their old and
+// new values. Either before or after may be nil when a resource is created or deleted.
+// If nothing changed then an invalid (null) string is returned.
+func AuditDiff(before, after any) (diff sql.NullString, err error) {
+	var from, to map[string]any
+	if from, err = auditFields(before); err != nil {
+		return diff, err
+	}
+
+	if to, err = auditFields(after); err != nil {
+		return diff, err
+	}
+
+	changes := make(map[string]AuditChange)
+	for key, val := range from {
+		if other := to[key]; !reflect.DeepEqual(val, other) {
+			changes[key] = AuditChange{From: val, To: other}
+		}
+	}
+
+	for key, val := range to {
+		if _, ok := from[key]; !ok && val != nil {
+			changes[key] = AuditChange{To: val}
+		}
+	}
+
+	for key := range auditRedacted {
+		delete(changes, key)
+	}
+
+	if len(changes) == 0 {
+		return diff, nil
+	}
+
+	var data []byte
+	if data, err = json.Marshal(changes); err != nil {
+		return diff, err
+	}
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
+
+func auditFields(obj any) (fields map[string]any, err error) {
+	if obj == nil || (reflect.ValueOf(obj).Kind() == reflect.Pointer && reflect.ValueOf(obj).IsNil()) {
+		return nil, nil
+	}
+
+	var data []byte
+	if data, err = json.Marshal(obj); err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
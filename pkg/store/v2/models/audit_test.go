@@ -0,0 +1,112 @@
+package models_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	. "go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/tidal"
+	tsuite "go.rtnl.ai/tidal/suite"
+	"go.rtnl.ai/ulid"
+)
+
+//=============================================================================
+// Database Conformance Tests
+//=============================================================================
+
+// TestAuditEventCRUDConformance verifies AuditEvent satisfies tidal CRUD shape and round-trip expectations against audit_events.
+func (s *modelSuite) TestAuditEventCRUDConformance() {
+	tsuite.ConformsCRUD(&s.DatabaseSuite, tsuite.CRUDConformance[*AuditEvent]{
+		Table: "audit_events",
+		Create: func() *AuditEvent {
+			return &AuditEvent{
+				ActorType:      sql.NullString{String: AuditUser, Valid: true},
+				ActorID:        ulid.NullULID{ULID: fixtureAdminUserID, Valid: true},
+				OrganizationID: ulid.NullULID{ULID: ulid.MakeSecure(), Valid: true},
+				Action:         AuditUpdate,
+				SubjectType:    AuditAPIKey,
+				SubjectID:      ulid.MakeSecure().String(),
+				ClientIP:       sql.NullString{String: "127.0.0.1", Valid: true},
+				Diff:           sql.NullString{String: `{"description": {"from": "foo", "to": "bar"}}`, Valid: true},
+			}
+		},
+		Update: func(e *AuditEvent) {
+			e.RequestID = sql.NullString{String: ulid.MakeSecure().String(), Valid: true}
+		},
+		Phases: []tsuite.CRUDPhase{tsuite.CRUDShape, tsuite.CRUDScan, tsuite.CRUDRoundTrip},
+	})
+}
+
+//=============================================================================
+// Unit Tests
+//=============================================================================
+
+// TestAuditEventValidate verifies required fields are checked on create.
+func TestAuditEventValidate(t *testing.T) {
+	model := &AuditEvent{Action: AuditLoginFailed, SubjectType: AuditUser}
+	require.ErrorIs(t, model.Validate(tidal.Create), errors.ErrZeroValuedNotNull)
+
+	model.SubjectID = fixtureAdminUserID.String()
+	require.NoError(t, model.Validate(tidal.Create))
+	require.Len(t, model.Params(tidal.Create), len(model.Fields(tidal.Create)))
+}
+
+// TestAuditDiff verifies only changed fields are recorded and secrets and timestamps are redacted.
+func TestAuditDiff(t *testing.T) {
+	type resource struct {
+		Name   string   `json:"name"`
+		Secret string   `json:"secret,omitempty"`
+		Roles  []string `json:"roles"`
+	}
+
+	t.Run("Create", func(t *testing.T) {
+		diff, err := AuditDiff(nil, &resource{Name: "foo", Secret: "supersecret", Roles: []string{"admin"}})
+		require.NoError(t, err)
+		require.True(t, diff.Valid)
+		require.JSONEq(t, `{"name":{"to":"foo"},"roles":{"to":["admin"]}}`, diff.String)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		before := &resource{Name: "foo", Roles: []string{"admin"}}
+		after := &resource{Name: "foo", Secret: "supersecret", Roles: []string{"admin", "observer"}}
+
+		diff, err := AuditDiff(before, after)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"roles":{"from":["admin"],"to":["admin","observer"]}}`, diff.String)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		var after *resource
+		diff, err := AuditDiff(&resource{Name: "foo"}, after)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"name":{"from":"foo"}}`, diff.String)
+	})
+
+	t.Run("Timestamps", func(t *testing.T) {
+		type timestamped struct {
+			Name     string    `json:"name"`
+			Created  time.Time `json:"created"`
+			Modified time.Time `json:"modified"`
+		}
+
+		before := &timestamped{Name: "foo", Created: time.Now().Add(-1 * time.Hour), Modified: time.Now().Add(-1 * time.Hour)}
+		after := &timestamped{Name: "bar", Created: before.Created, Modified: time.Now()}
+
+		diff, err := AuditDiff(before, after)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"name":{"from":"foo","to":"bar"}}`, diff.String)
+	})
+
+	t.Run("NoChange", func(t *testing.T) {
+		diff, err := AuditDiff(&resource{Name: "foo"}, &resource{Name: "foo"})
+		require.NoError(t, err)
+		require.False(t, diff.Valid)
+
+		diff, err = AuditDiff(nil, nil)
+		require.NoError(t, err)
+		require.False(t, diff.Valid)
+	})
+}
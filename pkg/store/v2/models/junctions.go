@@ -139,3 +139,52 @@ func (ap *APIKeyPermission) Prepare(op tidal.Operation) {
 		ap.Created = time.Now().UTC()
 	}
 }
+
+//===========================================================================
+// OrganizationMember Junction Table
+//===========================================================================
+
+// OrganizationMember is a row in the organization_members junction table; a user is a
+// member of an organization as long as they have at least one role in it.
+type OrganizationMember struct {
+	OrganizationID ulid.ULID
+	UserID         ulid.ULID
+	RoleID         int64
+	Created        time.Time
+}
+
+var _ tidal.Model = (*OrganizationMember)(nil)
+var _ tidal.Preparer = (*OrganizationMember)(nil)
+
+func (om *OrganizationMember) Fields(op tidal.Operation) []string {
+	return []string{
+		"organization_id",
+		"user_id",
+		"role_id",
+		"created",
+	}
+}
+
+func (om *OrganizationMember) Params(op tidal.Operation) []sql.NamedArg {
+	return []sql.NamedArg{
+		sql.Named("organization_id", om.OrganizationID),
+		sql.Named("user_id", om.UserID),
+		sql.Named("role_id", om.RoleID),
+		sql.Named("created", om.Created),
+	}
+}
+
+func (om *OrganizationMember) Scan(op tidal.Operation, s tidal.Scanner) error {
+	return s.Scan(
+		&om.OrganizationID,
+		&om.UserID,
+		&om.RoleID,
+		&om.Created,
+	)
+}
+
+func (om *OrganizationMember) Prepare(op tidal.Operation) {
+	if op == tidal.Create && om.Created.IsZero() {
+		om.Created = time.Now().UTC()
+	}
+}
@@ -69,6 +69,25 @@ func (s *modelSuite) TestAPIKeyPermissionCRUDConformance() {
 	})
 }
 
+// TestOrganizationMemberCRUDConformance verifies OrganizationMember tidal shape and scan.
+func (s *modelSuite) TestOrganizationMemberCRUDConformance() {
+	tsuite.ConformsCRUD(&s.DatabaseSuite, tsuite.CRUDConformance[*OrganizationMember]{
+		Table: "organization_members",
+		Create: func() *OrganizationMember {
+			return &OrganizationMember{
+				OrganizationID: ulid.MakeSecure(),
+				UserID:         ulid.MakeSecure(),
+				RoleID:         1,
+			}
+		},
+		Update: func(_ *OrganizationMember) {},
+		// CRUDRoundTrip skipped: This table uses a composite primary key (not a single id),
+		// so inserting requires valid related organization_id, user_id, and role_id records.
+		// Membership is already tested in backend/organizations_test.
+		Phases: []tsuite.CRUDPhase{tsuite.CRUDShape, tsuite.CRUDScan},
+	})
+}
+
 //=============================================================================
 // Unit Tests
 //=============================================================================
@@ -93,6 +112,12 @@ func TestJunctionPrepare(t *testing.T) {
 		require.False(t, ap.Created.IsZero())
 	})
 
+	t.Run("OrganizationMember", func(t *testing.T) {
+		om := &OrganizationMember{OrganizationID: ulid.MakeSecure(), UserID: ulid.MakeSecure(), RoleID: 4}
+		om.Prepare(tidal.Create)
+		require.False(t, om.Created.IsZero())
+	})
+
 	t.Run("PreservesExistingCreated", func(t *testing.T) {
 		stamp := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
 		ur := &UserRole{Created: stamp}
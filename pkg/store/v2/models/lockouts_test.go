@@ -0,0 +1,118 @@
+package models_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	. "go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/tidal"
+	tsuite "go.rtnl.ai/tidal/suite"
+	"go.rtnl.ai/ulid"
+)
+
+//=============================================================================
+// Database Conformance Tests
+//=============================================================================
+
+// TestLockoutCRUDConformance verifies Lockout satisfies tidal CRUD shape and round-trip expectations against lockouts.
+func (s *modelSuite) TestLockoutCRUDConformance() {
+	tsuite.ConformsCRUD(&s.DatabaseSuite, tsuite.CRUDConformance[*Lockout]{
+		Table: "lockouts",
+		Create: func() *Lockout {
+			return &Lockout{
+				Kind:           LockoutUser,
+				Identifier:     ulid.MakeSecure().String() + "@example.com",
+				FailedAttempts: 1,
+				LastFailure:    sql.NullTime{Time: time.Now().UTC(), Valid: true},
+			}
+		},
+		Update: func(l *Lockout) {
+			l.FailedAttempts++
+			l.LockedUntil = sql.NullTime{Time: time.Now().Add(time.Minute).UTC(), Valid: true}
+		},
+		Phases: []tsuite.CRUDPhase{tsuite.CRUDShape, tsuite.CRUDScan, tsuite.CRUDRoundTrip},
+	})
+}
+
+//=============================================================================
+// Unit Tests
+//=============================================================================
+
+// TestLockoutParams verifies the kind and identifier of a lockout cannot be updated.
+func TestLockoutParams(t *testing.T) {
+	lockout := &Lockout{Kind: LockoutUser, Identifier: "jannel@example.com", FailedAttempts: 3}
+	lockout.ID = modelID
+
+	require.Len(t, lockout.Params(tidal.Create), len(lockout.Fields(tidal.Create)))
+	require.Len(t, lockout.Params(tidal.Update), len(lockout.Fields(tidal.Update)))
+
+	for _, param := range lockout.Params(tidal.Update) {
+		require.NotEqual(t, "kind", param.Name, "the kind cannot be updated")
+		require.NotEqual(t, "identifier", param.Name, "the identifier cannot be updated")
+	}
+}
+
+// TestLockoutFail verifies failed attempts lock out the identifier once the threshold is reached.
+func TestLockoutFail(t *testing.T) {
+	policy := LockoutPolicy{Threshold: 3, Duration: time.Minute, MaxDuration: 5 * time.Minute}
+	lockout := &Lockout{Kind: LockoutUser, Identifier: "jannel@example.com"}
+
+	require.False(t, lockout.Fail(policy))
+	require.False(t, lockout.Fail(policy))
+	require.False(t, lockout.IsLocked(), "should not be locked before the threshold")
+	require.Zero(t, lockout.RetryAfter())
+	require.True(t, lockout.LastFailure.Valid)
+
+	require.True(t, lockout.Fail(policy), "expected the threshold to lock out the identifier")
+	require.Equal(t, int64(3), lockout.FailedAttempts)
+	require.True(t, lockout.IsLocked())
+	require.InDelta(t, time.Minute, lockout.RetryAfter(), float64(time.Second))
+
+	// Further failures double the lockout duration
+	require.True(t, lockout.Fail(policy))
+	require.InDelta(t, 2*time.Minute, lockout.RetryAfter(), float64(time.Second))
+
+	// Expired lockouts are no longer locked
+	lockout.LockedUntil = sql.NullTime{Time: time.Now().Add(-time.Second), Valid: true}
+	require.False(t, lockout.IsLocked())
+
+	t.Run("Disabled", func(t *testing.T) {
+		lockout := &Lockout{}
+		for i := 0; i < 100; i++ {
+			require.False(t, lockout.Fail(LockoutPolicy{}))
+		}
+		require.False(t, lockout.IsLocked())
+		require.Equal(t, int64(100), lockout.FailedAttempts)
+	})
+}
+
+// TestLockoutBackoff verifies the lockout duration doubles after the threshold up to the maximum.
+func TestLockoutBackoff(t *testing.T) {
+	policy := LockoutPolicy{Threshold: 5, Duration: time.Minute, MaxDuration: time.Hour}
+
+	testCases := []struct {
+		attempts int64
+		expected time.Duration
+	}{
+		{0, 0},
+		{4, 0},
+		{5, time.Minute},
+		{6, 2 * time.Minute},
+		{7, 4 * time.Minute},
+		{10, 32 * time.Minute},
+		{11, time.Hour},
+		{1000, time.Hour},
+	}
+
+	for i, tc := range testCases {
+		require.Equal(t, tc.expected, policy.Backoff(tc.attempts), "test case %d failed", i)
+	}
+}
+
+// TestNormalizeIdentifier verifies email addresses are case-insensitive but client IDs are not.
+func TestNormalizeIdentifier(t *testing.T) {
+	require.Equal(t, "jannel@example.com", NormalizeIdentifier(LockoutUser, "  Jannel@Example.com "))
+	require.Equal(t, "ZnaBcDeFg", NormalizeIdentifier(LockoutClient, "ZnaBcDeFg "))
+}
@@ -0,0 +1,137 @@
+package models
+
+import (
+	"database/sql"
+	"slices"
+	"strings"
+	"time"
+
+	"go.rtnl.ai/tidal"
+	"go.rtnl.ai/ulid"
+)
+
+// AuthorizationCode is a one-time code issued to an OIDC client by the OAuth2
+// authorization endpoint after the user has authenticated. The client exchanges the
+// code (along with its credentials and PKCE verifier) for tokens at the token endpoint.
+// The Code field holds a hash of the code that was delivered to the client, never the
+// code itself.
+type AuthorizationCode struct {
+	tidal.BaseModel
+	Code                string
+	ClientID            string
+	UserID              ulid.ULID
+	RedirectURI         string
+	Scope               sql.NullString
+	Nonce               sql.NullString
+	CodeChallenge       sql.NullString
+	CodeChallengeMethod sql.NullString
+	Expiration          time.Time
+}
+
+var _ tidal.Model = (*AuthorizationCode)(nil)
+
+func (a *AuthorizationCode) Fields(op tidal.Operation) []string {
+	return []string{
+		"id",
+		"code",
+		"client_id",
+		"user_id",
+		"redirect_uri",
+		"scope",
+		"nonce",
+		"code_challenge",
+		"code_challenge_method",
+		"expiration",
+		"created",
+		"modified",
+	}
+}
+
+func (a *AuthorizationCode) Params(op tidal.Operation) []sql.NamedArg {
+	return []sql.NamedArg{
+		sql.Named("id", a.ID),
+		sql.Named("code", a.Code),
+		sql.Named("client_id", a.ClientID),
+		sql.Named("user_id", a.UserID),
+		sql.Named("redirect_uri", a.RedirectURI),
+		sql.Named("scope", a.Scope),
+		sql.Named("nonce", a.Nonce),
+		sql.Named("code_challenge", a.CodeChallenge),
+		sql.Named("code_challenge_method", a.CodeChallengeMethod),
+		sql.Named("expiration", a.Expiration),
+		sql.Named("created", a.Created),
+		sql.Named("modified", a.Modified),
+	}
+}
+
+func (a *AuthorizationCode) Scan(op tidal.Operation, s tidal.Scanner) error {
+	return s.Scan(
+		&a.ID,
+		&a.Code,
+		&a.ClientID,
+		&a.UserID,
+		&a.RedirectURI,
+		&a.Scope,
+		&a.Nonce,
+		&a.CodeChallenge,
+		&a.CodeChallengeMethod,
+		&a.Expiration,
+		&a.Created,
+		&a.Modified,
+	)
+}
+
+// IsExpired returns true if the authorization code can no longer be exchanged.
+func (a *AuthorizationCode) IsExpired() bool {
+	return a.Expiration.IsZero() || time.Now().After(a.Expiration)
+}
+
+// Scopes returns the space-delimited scopes that were granted with the code.
+func (a *AuthorizationCode) Scopes() []string {
+	if !a.Scope.Valid {
+		return nil
+	}
+	return strings.Fields(a.Scope.String)
+}
+
+// HasScope returns true if the scope was granted with the code.
+func (a *AuthorizationCode) HasScope(scope string) bool {
+	return slices.Contains(a.Scopes(), scope)
+}
+
+// RevokedToken is an entry in the denylist of access and refresh tokens that have been
+// revoked before they expire; the ID is the jti claim of the revoked tokens. The entry
+// can be removed once the tokens have expired.
+type RevokedToken struct {
+	tidal.BaseModel
+	Expiration time.Time
+}
+
+var _ tidal.Model = (*RevokedToken)(nil)
+
+func (r *RevokedToken) Fields(op tidal.Operation) []string {
+	return []string{
+		"id",
+		"expiration",
+		"created",
+		"modified",
+	}
+}
+
+func (r *RevokedToken) Params(op tidal.Operation) []sql.NamedArg {
+	return []sql.NamedArg{
+		sql.Named("id", r.ID),
+		sql.Named("expiration", r.Expiration),
+		sql.Named("created", r.Created),
+		sql.Named("modified", r.Modified),
+	}
+}
+
+func (r *RevokedToken) Scan(op tidal.Operation, s tidal.Scanner) error {
+	return s.Scan(
+		&r.ID,
+		&r.Expiration,
+		&r.Created,
+		&r.Modified,
+	)
+}
@@ -0,0 +1,83 @@
+package models_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	. "go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/tidal"
+	tsuite "go.rtnl.ai/tidal/suite"
+	"go.rtnl.ai/ulid"
+)
+
+//=============================================================================
+// Database Conformance Tests
+//=============================================================================
+
+// TestAuthorizationCodeCRUDConformance verifies AuthorizationCode satisfies tidal CRUD shape and round-trip expectations against oauth_authorization_codes.
+func (s *modelSuite) TestAuthorizationCodeCRUDConformance() {
+	tsuite.ConformsCRUD(&s.DatabaseSuite, tsuite.CRUDConformance[*AuthorizationCode]{
+		Table: "oauth_authorization_codes",
+		Create: func() *AuthorizationCode {
+			return &AuthorizationCode{
+				Code:        "code-" + ulid.MakeSecure().String(),
+				ClientID:    "OidcClient2Minimal",
+				UserID:      fixtureAdminUserID,
+				RedirectURI: "https://example.com/cb",
+				Scope:       sql.NullString{String: "openid email", Valid: true},
+				Expiration:  time.Now().Add(10 * time.Minute).UTC(),
+			}
+		},
+		Update: func(a *AuthorizationCode) {
+			a.Nonce = sql.NullString{String: "nonce", Valid: true}
+		},
+		Phases: []tsuite.CRUDPhase{tsuite.CRUDShape, tsuite.CRUDScan, tsuite.CRUDRoundTrip},
+	})
+}
+
+// TestRevokedTokenCRUDConformance verifies RevokedToken satisfies tidal CRUD shape and round-trip expectations against revoked_tokens.
+func (s *modelSuite) TestRevokedTokenCRUDConformance() {
+	tsuite.ConformsCRUD(&s.DatabaseSuite, tsuite.CRUDConformance[*RevokedToken]{
+		Table: "revoked_tokens",
+		Create: func() *RevokedToken {
+			return &RevokedToken{Expiration: time.Now().Add(time.Hour).UTC()}
+		},
+		Update: func(r *RevokedToken) {
+			r.Expiration = r.Expiration.Add(time.Hour)
+		},
+		Phases: []tsuite.CRUDPhase{tsuite.CRUDShape, tsuite.CRUDScan, tsuite.CRUDRoundTrip},
+	})
+}
+
+//=============================================================================
+// Unit Tests
+//=============================================================================
+
+// TestAuthorizationCodeParams verifies params align with fields for every operation.
+func TestAuthorizationCodeParams(t *testing.T) {
+	model := &AuthorizationCode{Code: "hash", ClientID: "client", UserID: modelID}
+	for _, op := range []tidal.Operation{tidal.Create, tidal.Retrieve, tidal.Update} {
+		require.Len(t, model.Params(op), len(model.Fields(op)))
+	}
+}
+
+// TestAuthorizationCodeIsExpired verifies IsExpired treats past, future, and zero expiration correctly.
+func TestAuthorizationCodeIsExpired(t *testing.T) {
+	require.True(t, (&AuthorizationCode{Expiration: time.Now().Add(-1 * time.Second)}).IsExpired())
+	require.False(t, (&AuthorizationCode{Expiration: time.Now().Add(1 * time.Second)}).IsExpired())
+	require.True(t, (&AuthorizationCode{}).IsExpired(), "zero value expiration should be expired")
+}
+
+// TestAuthorizationCodeScopes verifies the granted scopes are split on whitespace.
+func TestAuthorizationCodeScopes(t *testing.T) {
+	code := &AuthorizationCode{}
+	require.Nil(t, code.Scopes())
+	require.False(t, code.HasScope("openid"))
+
+	code.Scope = sql.NullString{String: "openid  email profile", Valid: true}
+	require.Equal(t, []string{"openid", "email", "profile"}, code.Scopes())
+	require.True(t, code.HasScope("email"))
+	require.False(t, code.HasScope("offline_access"))
+}
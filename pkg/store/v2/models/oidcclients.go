@@ -12,7 +12,8 @@ import (
 type OIDCClient struct {
 	tidal.BaseModel
 
-	CreatedBy ulid.ULID // ID of the user who created the client
+	CreatedBy ulid.ULID     // ID of the user who created the client
+	OrgID     ulid.NullULID // Organization the client belongs to, if any
 
 	// OIDC spec descriptive fields
 
@@ -47,6 +48,7 @@ func (c *OIDCClient) Fields(op tidal.Operation) []string {
 			"contacts",
 			"client_id",
 			"created_by",
+			"organization_id",
 			"created",
 			"modified",
 		}
@@ -75,6 +77,7 @@ func (c *OIDCClient) Fields(op tidal.Operation) []string {
 			"client_id",
 			"secret",
 			"created_by",
+			"organization_id",
 			"created",
 			"modified",
 		}
@@ -108,6 +111,7 @@ func (c *OIDCClient) Params(op tidal.Operation) []sql.NamedArg {
 			sql.Named("client_id", c.ClientID),
 			sql.Named("secret", c.Secret),
 			sql.Named("created_by", c.CreatedBy),
+			sql.Named("organization_id", c.OrgID),
 			sql.Named("created", c.Created),
 			sql.Named("modified", c.Modified),
 		}
@@ -128,6 +132,7 @@ func (c *OIDCClient) Scan(op tidal.Operation, s tidal.Scanner) error {
 			&c.Contacts,
 			&c.ClientID,
 			&c.CreatedBy,
+			&c.OrgID,
 			&c.Created,
 			&c.Modified,
 		)
@@ -144,6 +149,7 @@ func (c *OIDCClient) Scan(op tidal.Operation, s tidal.Scanner) error {
 			&c.ClientID,
 			&c.Secret,
 			&c.CreatedBy,
+			&c.OrgID,
 			&c.Created,
 			&c.Modified,
 		)
@@ -64,7 +64,7 @@ func equalOIDCClientConformance(a, b *OIDCClient) bool {
 	if !a.RedirectURIs.Equal(b.RedirectURIs) || !a.Contacts.Equal(b.Contacts) {
 		return false
 	}
-	if a.ClientID != b.ClientID || a.CreatedBy != b.CreatedBy {
+	if a.ClientID != b.ClientID || a.CreatedBy != b.CreatedBy || a.OrgID != b.OrgID {
 		return false
 	}
 
@@ -104,6 +104,7 @@ func TestOIDCClientScan(t *testing.T) {
 			contactsJSON,
 			"XUiRZrNDUnLjeenQQmblpv",
 			ulid.MakeSecure().String(),
+			nil,
 			time.Now().Add(-14 * time.Hour),
 			time.Now().Add(-30 * time.Minute),
 		}
@@ -119,6 +120,7 @@ func TestOIDCClientScan(t *testing.T) {
 		// Assert: secret stays zero and client_id maps correctly.
 		require.Zero(t, model.Secret)
 		require.Equal(t, data[8], model.ClientID)
+		require.False(t, model.OrgID.Valid)
 	})
 
 	t.Run("Error", func(t *testing.T) {
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"database/sql"
+	"net/url"
+
+	qerrors "go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/tidal"
+)
+
+// Organization is a tenant of Quarterdeck such as a customer workspace. Users are
+// members of one or more organizations with roles that only apply in that organization
+// and API keys and OIDC clients belong to a single organization. The details of the
+// organization are used in place of the global organization configuration.
+type Organization struct {
+	tidal.BaseModel
+	Name          string
+	StreetAddress sql.NullString
+	HomepageURI   sql.NullString
+	SupportEmail  sql.NullString
+}
+
+var _ tidal.Model = (*Organization)(nil)
+var _ tidal.Validator = (*Organization)(nil)
+
+func (o *Organization) Fields(op tidal.Operation) []string {
+	return []string{
+		"id",
+		"name",
+		"street_address",
+		"homepage_uri",
+		"support_email",
+		"created",
+		"modified",
+	}
+}
+
+func (o *Organization) Params(op tidal.Operation) []sql.NamedArg {
+	return []sql.NamedArg{
+		sql.Named("id", o.ID),
+		sql.Named("name", o.Name),
+		sql.Named("street_address", o.StreetAddress),
+		sql.Named("homepage_uri", o.HomepageURI),
+		sql.Named("support_email", o.SupportEmail),
+		sql.Named("created", o.Created),
+		sql.Named("modified", o.Modified),
+	}
+}
+
+func (o *Organization) Scan(op tidal.Operation, s tidal.Scanner) error {
+	return s.Scan(
+		&o.ID,
+		&o.Name,
+		&o.StreetAddress,
+		&o.HomepageURI,
+		&o.SupportEmail,
+		&o.Created,
+		&o.Modified,
+	)
+}
+
+// Validates that the Name is set on create and update; default
+// [tidal.BaseModel.Validate] runs first.
+func (o *Organization) Validate(op tidal.Operation) error {
+	if err := o.BaseModel.Validate(op); err != nil {
+		return err
+	}
+	if (op == tidal.Create || op == tidal.Update) && o.Name == "" {
+		return qerrors.ErrZeroValuedNotNull
+	}
+	return nil
+}
+
+// HomepageURL returns the parsed homepage of the organization or nil if it is not set
+// or cannot be parsed.
+func (o *Organization) HomepageURL() *url.URL {
+	if !o.HomepageURI.Valid || o.HomepageURI.String == "" {
+		return nil
+	}
+
+	u, err := url.Parse(o.HomepageURI.String)
+	if err != nil {
+		return nil
+	}
+	return u
+}
@@ -0,0 +1,92 @@
+package models_test
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	qerrors "go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/mock"
+	. "go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/tidal"
+	tsuite "go.rtnl.ai/tidal/suite"
+	"go.rtnl.ai/ulid"
+)
+
+//=============================================================================
+// Database Conformance Tests
+//=============================================================================
+
+// TestOrganizationCRUDConformance verifies Organization satisfies tidal CRUD shape expectations against the organizations table.
+func (s *modelSuite) TestOrganizationCRUDConformance() {
+	tsuite.ConformsCRUD(&s.DatabaseSuite, tsuite.CRUDConformance[*Organization]{
+		Table: "organizations",
+		Create: func() *Organization {
+			return &Organization{
+				Name:         fmt.Sprintf("Conformance Organization %s", ulid.MakeSecure().String()),
+				SupportEmail: sql.NullString{Valid: true, String: "support@example.com"},
+			}
+		},
+		Update: func(o *Organization) {
+			o.HomepageURI = sql.NullString{Valid: true, String: "https://example.com"}
+		},
+		FieldMap: map[string]string{
+			"homepage_uri": "HomepageURI",
+		},
+		Phases: []tsuite.CRUDPhase{tsuite.CRUDShape, tsuite.CRUDScan, tsuite.CRUDRoundTrip},
+	})
+}
+
+//=============================================================================
+// Unit Tests
+//=============================================================================
+
+// TestOrganizationScan verifies Scan handles nullable columns and propagates scanner errors.
+func TestOrganizationScan(t *testing.T) {
+	t.Run("Nulls", func(t *testing.T) {
+		data := []any{
+			ulid.MakeSecure().String(),
+			"Globex",
+			nil,
+			nil,
+			nil,
+			created,
+			modified,
+		}
+		mockScanner := &mock.Scanner{}
+		mockScanner.SetData(data)
+
+		model := &Organization{}
+		err := model.Scan(tidal.Retrieve, mockScanner)
+		require.NoError(t, err)
+		mockScanner.AssertScanned(t, len(data))
+
+		require.Equal(t, "Globex", model.Name)
+		require.False(t, model.StreetAddress.Valid)
+		require.False(t, model.HomepageURI.Valid)
+		require.False(t, model.SupportEmail.Valid)
+		require.Nil(t, model.HomepageURL())
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mockScanner := &mock.Scanner{}
+		mockScanner.SetError(ErrModelScan)
+
+		model := &Organization{}
+		err := model.Scan(tidal.Retrieve, mockScanner)
+		require.ErrorIs(t, err, ErrModelScan)
+	})
+}
+
+// TestOrganizationValidate verifies that the name is required on create and update.
+func TestOrganizationValidate(t *testing.T) {
+	org := &Organization{}
+	require.ErrorIs(t, org.Validate(tidal.Create), qerrors.ErrZeroValuedNotNull)
+
+	org.Name = "Acme Corporation"
+	require.NoError(t, org.Validate(tidal.Create))
+
+	org.HomepageURI = sql.NullString{Valid: true, String: "https://acme.example.com"}
+	require.Equal(t, "acme.example.com", org.HomepageURL().Host)
+}
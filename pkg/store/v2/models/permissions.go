@@ -7,10 +7,15 @@ import (
 	"go.rtnl.ai/tidal"
 )
 
+// Permission authorizes users and api keys to perform an action. Custom permissions are
+// namespaced by the application or OIDC client that created them; protected permissions
+// are the built-in Quarterdeck permissions which cannot be deleted or renamed.
 type Permission struct {
 	ID          int64
 	Title       string
 	Description string
+	Namespace   string
+	Protected   bool
 	Created     time.Time
 	Modified    time.Time
 }
@@ -24,14 +29,26 @@ func (p *Permission) Fields(op tidal.Operation) []string {
 		return []string{
 			"title",
 			"description",
+			"namespace",
+			"protected",
 			"created",
 			"modified",
 		}
+	case tidal.Update:
+		return []string{
+			"id",
+			"title",
+			"description",
+			"namespace",
+			"modified",
+		}
 	default:
 		return []string{
 			"id",
 			"title",
 			"description",
+			"namespace",
+			"protected",
 			"created",
 			"modified",
 		}
@@ -45,12 +62,15 @@ func (p *Permission) Params(op tidal.Operation) []sql.NamedArg {
 			sql.Named("id", p.ID),
 			sql.Named("title", p.Title),
 			sql.Named("description", p.Description),
+			sql.Named("namespace", p.Namespace),
 			sql.Named("modified", p.Modified),
 		}
 	default:
 		return []sql.NamedArg{
 			sql.Named("title", p.Title),
 			sql.Named("description", p.Description),
+			sql.Named("namespace", p.Namespace),
+			sql.Named("protected", p.Protected),
 			sql.Named("created", p.Created),
 			sql.Named("modified", p.Modified),
 		}
@@ -62,6 +82,8 @@ func (p *Permission) Scan(op tidal.Operation, s tidal.Scanner) error {
 		&p.ID,
 		&p.Title,
 		&p.Description,
+		&p.Namespace,
+		&p.Protected,
 		&p.Created,
 		&p.Modified,
 	)
@@ -24,6 +24,7 @@ func (s *modelSuite) TestPermissionCRUDConformance() {
 			return &Permission{
 				Title:       fmt.Sprintf("conformance:%s", ulid.MakeSecure().String()),
 				Description: "Conformance permission",
+				Namespace:   "conformance",
 			}
 		},
 		Update: func(p *Permission) {
@@ -47,6 +48,8 @@ func TestPermissionScan(t *testing.T) {
 			int64(120),
 			"dashboard:read",
 			"Read access to the dashboard",
+			"dashboard",
+			true,
 			created,
 			modified,
 		}
@@ -63,6 +66,8 @@ func TestPermissionScan(t *testing.T) {
 		require.Equal(t, int64(120), permission.ID)
 		require.Equal(t, "dashboard:read", permission.Title)
 		require.Equal(t, "Read access to the dashboard", permission.Description)
+		require.Equal(t, "dashboard", permission.Namespace)
+		require.True(t, permission.Protected)
 		require.Equal(t, created, permission.Created)
 		require.Equal(t, modified, permission.Modified)
 	})
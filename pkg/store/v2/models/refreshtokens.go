@@ -0,0 +1,68 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"go.rtnl.ai/tidal"
+	"go.rtnl.ai/ulid"
+)
+
+// RefreshTokens are tracked server-side so that each refresh token can only be
+// exchanged once. The ID is the jti claim of the refresh token and the FamilyID is the
+// jti of the first refresh token issued on login; every refresh token rotated from it
+// shares the family so that the whole chain can be revoked if a used refresh token is
+// ever presented again.
+type RefreshToken struct {
+	tidal.BaseModel
+	FamilyID   ulid.ULID
+	Subject    string
+	UsedOn     sql.NullTime
+	RevokedOn  sql.NullTime
+	Expiration time.Time
+}
+
+var _ tidal.Model = (*RefreshToken)(nil)
+
+func (r *RefreshToken) Fields(op tidal.Operation) []string {
+	return []string{
+		"id",
+		"family_id",
+		"subject",
+		"used_on",
+		"revoked_on",
+		"expiration",
+		"created",
+		"modified",
+	}
+}
+
+func (r *RefreshToken) Params(op tidal.Operation) []sql.NamedArg {
+	return []sql.NamedArg{
+		sql.Named("id", r.ID),
+		sql.Named("family_id", r.FamilyID),
+		sql.Named("subject", r.Subject),
+		sql.Named("used_on", r.UsedOn),
+		sql.Named("revoked_on", r.RevokedOn),
+		sql.Named("expiration", r.Expiration),
+		sql.Named("created", r.Created),
+		sql.Named("modified", r.Modified),
+	}
+}
+
+func (r *RefreshToken) Scan(op tidal.Operation, s tidal.Scanner) error {
+	return s.Scan(
+		&r.ID,
+		&r.FamilyID,
+		&r.Subject,
+		&r.UsedOn,
+		&r.RevokedOn,
+		&r.Expiration,
+		&r.Created,
+		&r.Modified,
+	)
+}
+
+func (r *RefreshToken) IsExpired() bool {
+	return r.Expiration.IsZero() || time.Now().After(r.Expiration)
+}
@@ -0,0 +1,87 @@
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/mock"
+	. "go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/tidal"
+	tsuite "go.rtnl.ai/tidal/suite"
+	"go.rtnl.ai/ulid"
+)
+
+//=============================================================================
+// Database Conformance Tests
+//=============================================================================
+
+// TestRefreshTokenCRUDConformance verifies RefreshToken satisfies tidal CRUD shape and round-trip expectations against refresh_tokens.
+func (s *modelSuite) TestRefreshTokenCRUDConformance() {
+	tsuite.ConformsCRUD(&s.DatabaseSuite, tsuite.CRUDConformance[*RefreshToken]{
+		Table: "refresh_tokens",
+		Create: func() *RefreshToken {
+			return &RefreshToken{
+				FamilyID:   ulid.MakeSecure(),
+				Subject:    "u" + ulid.MakeSecure().String(),
+				Expiration: time.Now().Add(24 * time.Hour).UTC(),
+			}
+		},
+		Update: func(r *RefreshToken) {
+			r.Subject = "u" + ulid.MakeSecure().String()
+		},
+		Phases: []tsuite.CRUDPhase{tsuite.CRUDShape, tsuite.CRUDScan, tsuite.CRUDRoundTrip},
+	})
+}
+
+//=============================================================================
+// Unit Tests
+//=============================================================================
+
+// TestRefreshTokenScan verifies Scan maps the family and subject and propagates scanner errors.
+func TestRefreshTokenScan(t *testing.T) {
+	t.Run("NotNull", func(t *testing.T) {
+		// Setup: full refresh_tokens row with used_on and revoked_on populated.
+		data := []any{
+			ulid.MakeSecure().String(),
+			ulid.MakeSecure().String(),
+			"u01JPYRNYMEHNEZCS0JYX1CP57A",
+			time.Now().Add(-30 * time.Minute),
+			time.Now().Add(-10 * time.Minute),
+			time.Now().Add(5 * time.Hour),
+			time.Now().Add(-1 * time.Hour),
+			time.Now().Add(-1 * time.Hour),
+		}
+		mockScanner := &mock.Scanner{}
+		mockScanner.SetData(data)
+
+		// Action: scan row using Retrieve shape.
+		model := &RefreshToken{}
+		err := model.Scan(tidal.Retrieve, mockScanner)
+		require.NoError(t, err)
+		mockScanner.AssertScanned(t, len(data))
+
+		// Assert: family, subject, and nullable timestamps map correctly.
+		require.Equal(t, data[0], model.ID.String())
+		require.Equal(t, data[1], model.FamilyID.String())
+		require.Equal(t, data[2], model.Subject)
+		require.True(t, model.UsedOn.Valid)
+		require.True(t, model.RevokedOn.Valid)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mockScanner := &mock.Scanner{}
+		mockScanner.SetError(ErrModelScan)
+
+		model := &RefreshToken{}
+		err := model.Scan(tidal.Retrieve, mockScanner)
+		require.ErrorIs(t, err, ErrModelScan)
+	})
+}
+
+// TestRefreshTokenIsExpired verifies IsExpired treats past, future, and zero expiration correctly.
+func TestRefreshTokenIsExpired(t *testing.T) {
+	require.True(t, (&RefreshToken{Expiration: time.Now().Add(-1 * time.Second)}).IsExpired())
+	require.False(t, (&RefreshToken{Expiration: time.Now().Add(1 * time.Second)}).IsExpired())
+	require.True(t, (&RefreshToken{Expiration: time.Time{}}).IsExpired(), "zero value expiration should be expired")
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"go.rtnl.ai/tidal"
+	"go.rtnl.ai/ulid"
+)
+
+// Session records a user login so that the places where an account is signed in can be
+// listed and revoked. The ID of the session is the family ID of the refresh tokens
+// issued on login and the JTI is the jti of the most recently issued token pair.
+type Session struct {
+	tidal.BaseModel
+	UserID      ulid.ULID
+	JTI         ulid.ULID
+	UserAgent   sql.NullString
+	ClientIP    sql.NullString
+	LastRefresh sql.NullTime
+	Revoked     sql.NullTime
+	Expiration  time.Time
+}
+
+var _ tidal.Model = (*Session)(nil)
+
+func (s *Session) Fields(op tidal.Operation) []string {
+	return []string{
+		"id",
+		"user_id",
+		"jti",
+		"user_agent",
+		"client_ip",
+		"last_refresh",
+		"revoked",
+		"expiration",
+		"created",
+		"modified",
+	}
+}
+
+func (s *Session) Params(op tidal.Operation) []sql.NamedArg {
+	return []sql.NamedArg{
+		sql.Named("id", s.ID),
+		sql.Named("user_id", s.UserID),
+		sql.Named("jti", s.JTI),
+		sql.Named("user_agent", s.UserAgent),
+		sql.Named("client_ip", s.ClientIP),
+		sql.Named("last_refresh", s.LastRefresh),
+		sql.Named("revoked", s.Revoked),
+		sql.Named("expiration", s.Expiration),
+		sql.Named("created", s.Created),
+		sql.Named("modified", s.Modified),
+	}
+}
+
+func (s *Session) Scan(op tidal.Operation, scanner tidal.Scanner) error {
+	return scanner.Scan(
+		&s.ID,
+		&s.UserID,
+		&s.JTI,
+		&s.UserAgent,
+		&s.ClientIP,
+		&s.LastRefresh,
+		&s.Revoked,
+		&s.Expiration,
+		&s.Created,
+		&s.Modified,
+	)
+}
+
+// IsActive returns true if the session has not been revoked and has not expired.
+func (s *Session) IsActive() bool {
+	return !s.Revoked.Valid && time.Now().Before(s.Expiration)
+}
+
+// LastActive returns the last time the session was refreshed or when it was created.
+func (s *Session) LastActive() time.Time {
+	if s.LastRefresh.Valid {
+		return s.LastRefresh.Time
+	}
+	return s.Created
+}
@@ -0,0 +1,72 @@
+package models_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	. "go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/tidal"
+	tsuite "go.rtnl.ai/tidal/suite"
+	"go.rtnl.ai/ulid"
+)
+
+//=============================================================================
+// Database Conformance Tests
+//=============================================================================
+
+// TestSessionCRUDConformance verifies Session satisfies tidal CRUD shape and round-trip expectations against sessions.
+func (s *modelSuite) TestSessionCRUDConformance() {
+	tsuite.ConformsCRUD(&s.DatabaseSuite, tsuite.CRUDConformance[*Session]{
+		Table: "sessions",
+		Create: func() *Session {
+			return &Session{
+				UserID:     fixtureAdminUserID,
+				JTI:        ulid.MakeSecure(),
+				UserAgent:  sql.NullString{String: "Mozilla/5.0", Valid: true},
+				ClientIP:   sql.NullString{String: "192.0.2.1", Valid: true},
+				Expiration: time.Now().Add(24 * time.Hour).UTC(),
+			}
+		},
+		Update: func(r *Session) {
+			r.JTI = ulid.MakeSecure()
+			r.LastRefresh = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+		},
+		Phases: []tsuite.CRUDPhase{tsuite.CRUDShape, tsuite.CRUDScan, tsuite.CRUDRoundTrip},
+	})
+}
+
+//=============================================================================
+// Unit Tests
+//=============================================================================
+
+// TestSessionParams verifies params align with fields for every operation.
+func TestSessionParams(t *testing.T) {
+	model := &Session{UserID: modelID, JTI: modelID, Expiration: modified}
+	for _, op := range []tidal.Operation{tidal.Create, tidal.Retrieve, tidal.Update} {
+		require.Len(t, model.Params(op), len(model.Fields(op)))
+	}
+}
+
+// TestSessionIsActive verifies revoked and expired sessions are not active.
+func TestSessionIsActive(t *testing.T) {
+	session := &Session{Expiration: time.Now().Add(time.Hour)}
+	require.True(t, session.IsActive())
+
+	session.Revoked = sql.NullTime{Time: time.Now(), Valid: true}
+	require.False(t, session.IsActive(), "revoked sessions are not active")
+
+	session = &Session{Expiration: time.Now().Add(-time.Second)}
+	require.False(t, session.IsActive(), "expired sessions are not active")
+}
+
+// TestSessionLastActive verifies LastActive falls back to the created timestamp.
+func TestSessionLastActive(t *testing.T) {
+	session := &Session{BaseModel: tidal.BaseModel{Created: created}}
+	require.Equal(t, created, session.LastActive())
+
+	refreshed := time.Now()
+	session.LastRefresh = sql.NullTime{Time: refreshed, Valid: true}
+	require.Equal(t, refreshed, session.LastActive())
+}
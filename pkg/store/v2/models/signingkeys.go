@@ -0,0 +1,102 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"go.rtnl.ai/quarterdeck/pkg/enum"
+	qerrors "go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/tidal"
+)
+
+// SigningKey is a key pair used to sign and verify the JWT tokens issued by Quarterdeck.
+// The ID of the key is the kid in the header of the tokens it signs. The public key is
+// stored in PKIX DER form; the private key is sealed with the key encryption key of the
+// server (so it is never stored in plaintext) and is cleared when the key is retired.
+//
+// Activates is when a next key begins signing (or when the active key began signing),
+// Deactivated is when the key stopped signing, and Retires is when every token signed
+// by the key has expired so that it can be removed from the JWKS.
+type SigningKey struct {
+	tidal.BaseModel
+	State       enum.KeyState
+	PublicKey   []byte
+	PrivateKey  []byte
+	Activates   time.Time
+	Deactivated sql.NullTime
+	Retires     sql.NullTime
+}
+
+var _ tidal.Model = (*SigningKey)(nil)
+
+func (k *SigningKey) Fields(op tidal.Operation) []string {
+	return []string{
+		"id",
+		"state",
+		"public_key",
+		"private_key",
+		"activates",
+		"deactivated",
+		"retires",
+		"created",
+		"modified",
+	}
+}
+
+func (k *SigningKey) Params(op tidal.Operation) []sql.NamedArg {
+	return []sql.NamedArg{
+		sql.Named("id", k.ID),
+		sql.Named("state", k.State),
+		sql.Named("public_key", k.PublicKey),
+		sql.Named("private_key", k.PrivateKey),
+		sql.Named("activates", k.Activates),
+		sql.Named("deactivated", k.Deactivated),
+		sql.Named("retires", k.Retires),
+		sql.Named("created", k.Created),
+		sql.Named("modified", k.Modified),
+	}
+}
+
+func (k *SigningKey) Scan(op tidal.Operation, s tidal.Scanner) error {
+	return s.Scan(
+		&k.ID,
+		&k.State,
+		&k.PublicKey,
+		&k.PrivateKey,
+		&k.Activates,
+		&k.Deactivated,
+		&k.Retires,
+		&k.Created,
+		&k.Modified,
+	)
+}
+
+func (k *SigningKey) Validate(op tidal.Operation) error {
+	if err := k.BaseModel.Validate(op); err != nil {
+		return err
+	}
+	if op == tidal.Create {
+		if len(k.PublicKey) == 0 || k.Activates.IsZero() {
+			return qerrors.ErrZeroValuedNotNull
+		}
+	}
+	return nil
+}
+
+// IsPublished returns true if the public key should be included in the JWKS.
+func (k *SigningKey) IsPublished() bool {
+	return k.State.Published()
+}
+
+// IsDue returns true if a next key is ready to begin signing or if a retiring key is
+// ready to be retired at the specified time.
+func (k *SigningKey) IsDue(now time.Time) bool {
+	switch k.State {
+	case enum.KeyStateNext:
+		return !now.Before(k.Activates)
+	case enum.KeyStateRetiring:
+		return k.Retires.Valid && !now.Before(k.Retires.Time)
+	default:
+		return false
+	}
+}
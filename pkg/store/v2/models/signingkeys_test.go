@@ -0,0 +1,105 @@
+package models_test
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/quarterdeck/pkg/enum"
+	qerrors "go.rtnl.ai/quarterdeck/pkg/errors"
+	. "go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/tidal"
+	tsuite "go.rtnl.ai/tidal/suite"
+)
+
+//=============================================================================
+// Database Conformance Tests
+//=============================================================================
+
+// TestSigningKeyCRUDConformance verifies SigningKey satisfies tidal CRUD shape and round-trip expectations against signing_keys.
+func (s *modelSuite) TestSigningKeyCRUDConformance() {
+	tsuite.ConformsCRUD(&s.DatabaseSuite, tsuite.CRUDConformance[*SigningKey]{
+		Table: "signing_keys",
+		Create: func() *SigningKey {
+			return &SigningKey{
+				State:      enum.KeyStateNext,
+				PublicKey:  randomBytes(44),
+				PrivateKey: randomBytes(76),
+				Activates:  time.Now().Add(24 * time.Hour).UTC(),
+			}
+		},
+		Update: func(k *SigningKey) {
+			k.State = enum.KeyStateRetiring
+			k.Deactivated = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+			k.Retires = sql.NullTime{Time: time.Now().Add(2 * time.Hour).UTC(), Valid: true}
+		},
+		Phases: []tsuite.CRUDPhase{tsuite.CRUDShape, tsuite.CRUDScan, tsuite.CRUDRoundTrip},
+	})
+}
+
+//=============================================================================
+// Unit Tests
+//=============================================================================
+
+// TestSigningKeyParams verifies params align with fields for every operation.
+func TestSigningKeyParams(t *testing.T) {
+	model := &SigningKey{State: enum.KeyStateActive, PublicKey: []byte("public"), Activates: modified}
+	for _, op := range []tidal.Operation{tidal.Create, tidal.Retrieve, tidal.Update, tidal.List} {
+		require.Len(t, model.Params(op), len(model.Fields(op)))
+	}
+}
+
+// TestSigningKeyValidate verifies the public key and activation time are required on create.
+func TestSigningKeyValidate(t *testing.T) {
+	key := &SigningKey{State: enum.KeyStateNext}
+	require.ErrorIs(t, key.Validate(tidal.Create), qerrors.ErrZeroValuedNotNull)
+
+	key.PublicKey = []byte("public")
+	require.ErrorIs(t, key.Validate(tidal.Create), qerrors.ErrZeroValuedNotNull)
+
+	key.Activates = created
+	require.NoError(t, key.Validate(tidal.Create))
+}
+
+// TestSigningKeyIsPublished verifies only next, active, and retiring keys are published.
+func TestSigningKeyIsPublished(t *testing.T) {
+	tests := map[enum.KeyState]bool{
+		enum.KeyStateUnknown:  false,
+		enum.KeyStateNext:     true,
+		enum.KeyStateActive:   true,
+		enum.KeyStateRetiring: true,
+		enum.KeyStateRetired:  false,
+	}
+
+	for state, expected := range tests {
+		require.Equal(t, expected, (&SigningKey{State: state}).IsPublished(), state.String())
+	}
+}
+
+// TestSigningKeyIsDue verifies next keys are due when they activate and retiring keys
+// are due when they retire.
+func TestSigningKeyIsDue(t *testing.T) {
+	now := time.Now()
+
+	key := &SigningKey{State: enum.KeyStateNext, Activates: now.Add(time.Hour)}
+	require.False(t, key.IsDue(now), "next key is not due before it activates")
+	require.True(t, key.IsDue(now.Add(time.Hour)), "next key is due when it activates")
+
+	key = &SigningKey{State: enum.KeyStateRetiring}
+	require.False(t, key.IsDue(now), "retiring key without a retirement time is not due")
+
+	key.Retires = sql.NullTime{Time: now.Add(time.Hour), Valid: true}
+	require.False(t, key.IsDue(now), "retiring key is not due before it retires")
+	require.True(t, key.IsDue(now.Add(2*time.Hour)), "retiring key is due after it retires")
+
+	key = &SigningKey{State: enum.KeyStateActive, Activates: now.Add(-time.Hour)}
+	require.False(t, key.IsDue(now), "active keys are never due")
+}
+
+func randomBytes(n int) []byte {
+	data := make([]byte, n)
+	rand.Read(data)
+	return data
+}
@@ -2,9 +2,15 @@ package models
 
 import (
 	"database/sql"
+	"slices"
+	"time"
 
 	"go.rtnl.ai/gimlet/auth"
+	"go.rtnl.ai/quarterdeck/pkg/enum"
+	qerrors "go.rtnl.ai/quarterdeck/pkg/errors"
 	"go.rtnl.ai/tidal"
+	"go.rtnl.ai/tidal/fields"
+	"go.rtnl.ai/ulid"
 	"go.rtnl.ai/x/gravatar"
 )
 
@@ -23,8 +29,18 @@ type User struct {
 	Password      string
 	LastLogin     sql.NullTime
 	EmailVerified bool
+	Status        enum.UserStatus
+	Deleted       sql.NullTime // when the user was deleted; purged after the retention period
+	TOTPSecret    sql.NullString
+	TOTPEnabled   sql.NullTime
+	TOTPStep      sql.NullInt64      // time step of the last accepted TOTP code
+	RecoveryCodes fields.StringArray // hashes of the unused one-time recovery codes
 	Roles         []Role
 	Permissions   []Permission
+
+	// OrgID is the organization that Roles and Permissions were loaded for; if zero
+	// then only the user's global roles and permissions were loaded. It is not stored.
+	OrgID ulid.ULID
 }
 
 var _ tidal.Model = (*User)(nil)
@@ -40,6 +56,8 @@ func (u *User) Fields(op tidal.Operation) []string {
 			"email_verified",
 			"created",
 			"modified",
+			"status",
+			"deleted",
 		}
 	case tidal.Update:
 		return []string{
@@ -58,6 +76,12 @@ func (u *User) Fields(op tidal.Operation) []string {
 			"email_verified",
 			"created",
 			"modified",
+			"status",
+			"deleted",
+			"totp_secret",
+			"totp_enabled",
+			"recovery_codes",
+			"totp_step",
 		}
 	}
 }
@@ -81,6 +105,12 @@ func (u *User) Params(op tidal.Operation) []sql.NamedArg {
 			sql.Named("email_verified", u.EmailVerified),
 			sql.Named("created", u.Created),
 			sql.Named("modified", u.Modified),
+			sql.Named("status", u.Status),
+			sql.Named("deleted", u.Deleted),
+			sql.Named("totp_secret", u.TOTPSecret),
+			sql.Named("totp_enabled", u.TOTPEnabled),
+			sql.Named("recovery_codes", u.RecoveryCodes),
+			sql.Named("totp_step", u.TOTPStep),
 		}
 	}
 }
@@ -96,6 +126,8 @@ func (u *User) Scan(op tidal.Operation, s tidal.Scanner) error {
 			&u.EmailVerified,
 			&u.Created,
 			&u.Modified,
+			&u.Status,
+			&u.Deleted,
 		)
 	default:
 		return s.Scan(
@@ -107,6 +139,12 @@ func (u *User) Scan(op tidal.Operation, s tidal.Scanner) error {
 			&u.EmailVerified,
 			&u.Created,
 			&u.Modified,
+			&u.Status,
+			&u.Deleted,
+			&u.TOTPSecret,
+			&u.TOTPEnabled,
+			&u.RecoveryCodes,
+			&u.TOTPStep,
 		)
 	}
 }
@@ -128,6 +166,54 @@ func (u User) Claims() *auth.Claims {
 	return claims
 }
 
+// CheckStatus returns an error if the status of the user does not allow them to log in
+// or to reauthenticate. Deleted users are treated as if they do not exist so that the
+// error does not reveal that the account was deleted.
+func (u User) CheckStatus() error {
+	switch u.Status {
+	case enum.UserStatusActive, enum.UserStatusUnknown:
+		// Users are created active so an unknown status has not been restricted.
+		return nil
+	case enum.UserStatusSuspended:
+		return qerrors.ErrUserSuspended
+	case enum.UserStatusDeactivated:
+		return qerrors.ErrUserDeactivated
+	default:
+		return qerrors.ErrFailedAuthentication
+	}
+}
+
+// MFAEnabled returns true if the user has confirmed enrollment of a TOTP second factor.
+func (u User) MFAEnabled() bool {
+	return u.TOTPEnabled.Valid && u.TOTPSecret.Valid && u.TOTPSecret.String != ""
+}
+
+// EnableTOTP marks the TOTP secret as confirmed and replaces any existing recovery
+// codes with the specified recovery code hashes.
+func (u *User) EnableTOTP(recoveryCodes []string) {
+	u.TOTPEnabled = sql.NullTime{Valid: true, Time: time.Now()}
+	u.RecoveryCodes = recoveryCodes
+}
+
+// DisableTOTP removes the second factor and all recovery codes from the user.
+func (u *User) DisableTOTP() {
+	u.TOTPSecret = sql.NullString{}
+	u.TOTPEnabled = sql.NullTime{}
+	u.RecoveryCodes = nil
+}
+
+// UseRecoveryCode removes the recovery code hash from the user's unused recovery
+// codes, returning false if the hash does not match an unused recovery code.
+func (u *User) UseRecoveryCode(hash string) bool {
+	idx := slices.Index(u.RecoveryCodes, hash)
+	if idx < 0 {
+		return false
+	}
+
+	u.RecoveryCodes = slices.Delete(u.RecoveryCodes, idx, idx+1)
+	return true
+}
+
 func (u User) Gravatar() string {
 	if u.Email == "" {
 		return ""
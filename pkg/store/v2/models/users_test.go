@@ -8,6 +8,8 @@ import (
 
 	"github.com/stretchr/testify/require"
 	"go.rtnl.ai/gimlet/auth"
+	"go.rtnl.ai/quarterdeck/pkg/enum"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
 	"go.rtnl.ai/quarterdeck/pkg/store/v2/mock"
 	. "go.rtnl.ai/quarterdeck/pkg/store/v2/models"
 	"go.rtnl.ai/tidal"
@@ -55,6 +57,8 @@ func TestUserScan(t *testing.T) {
 			true,
 			time.Now(),
 			time.Now().Add(1 * time.Hour),
+			"suspended",
+			nil,
 		}
 		mockScanner := &mock.Scanner{}
 		mockScanner.SetData(data)
@@ -74,6 +78,8 @@ func TestUserScan(t *testing.T) {
 		require.Equal(t, data[4], model.EmailVerified)
 		require.Equal(t, data[5], model.Created)
 		require.Equal(t, data[6], model.Modified)
+		require.Equal(t, enum.UserStatusSuspended, model.Status)
+		require.False(t, model.Deleted.Valid)
 	})
 
 	t.Run("Nulls", func(t *testing.T) {
@@ -87,6 +93,12 @@ func TestUserScan(t *testing.T) {
 			false,
 			time.Now(),
 			time.Time{},
+			"active",
+			nil,
+			nil,
+			nil,
+			nil,
+			nil,
 		}
 		mockScanner := &mock.Scanner{}
 		mockScanner.SetData(data)
@@ -101,6 +113,11 @@ func TestUserScan(t *testing.T) {
 		require.False(t, model.Name.Valid)
 		require.False(t, model.LastLogin.Valid)
 		require.True(t, model.Modified.IsZero())
+		require.False(t, model.Deleted.Valid)
+		require.False(t, model.TOTPSecret.Valid)
+		require.False(t, model.TOTPEnabled.Valid)
+		require.Empty(t, model.RecoveryCodes)
+		require.False(t, model.TOTPStep.Valid)
 	})
 
 	t.Run("Error", func(t *testing.T) {
@@ -164,3 +181,42 @@ func TestEmptyGravatar(t *testing.T) {
 
 	require.Equal(t, "", user.Gravatar())
 }
+
+// TestUserMFA verifies TOTP enrollment, one-time use of recovery codes, and removal of the second factor.
+func TestUserMFA(t *testing.T) {
+	user := &User{TOTPSecret: sql.NullString{Valid: true, String: "JBSWY3DPEHPK3PXP"}}
+	require.False(t, user.MFAEnabled(), "mfa should not be enabled until enrollment is confirmed")
+
+	user.EnableTOTP([]string{"a", "b", "c"})
+	require.True(t, user.MFAEnabled())
+	require.Equal(t, []string{"a", "b", "c"}, []string(user.RecoveryCodes))
+
+	require.True(t, user.UseRecoveryCode("b"))
+	require.Equal(t, []string{"a", "c"}, []string(user.RecoveryCodes))
+	require.False(t, user.UseRecoveryCode("b"), "recovery codes can only be used once")
+	require.False(t, user.UseRecoveryCode("d"))
+
+	user.DisableTOTP()
+	require.False(t, user.MFAEnabled())
+	require.False(t, user.TOTPSecret.Valid)
+	require.Nil(t, user.RecoveryCodes)
+}
+
+// TestUserCheckStatus verifies only active users are allowed to log in.
+func TestUserCheckStatus(t *testing.T) {
+	tests := []struct {
+		status enum.UserStatus
+		err    error
+	}{
+		{enum.UserStatusUnknown, nil},
+		{enum.UserStatusActive, nil},
+		{enum.UserStatusSuspended, errors.ErrUserSuspended},
+		{enum.UserStatusDeactivated, errors.ErrUserDeactivated},
+		{enum.UserStatusDeleted, errors.ErrFailedAuthentication},
+	}
+
+	for _, tc := range tests {
+		user := &User{Status: tc.status}
+		require.ErrorIs(t, user.CheckStatus(), tc.err, "unexpected error for %s status", tc.status)
+	}
+}
@@ -0,0 +1,122 @@
+package models
+
+import (
+	"database/sql"
+
+	qerrors "go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/tidal"
+	"go.rtnl.ai/tidal/fields"
+	"go.rtnl.ai/ulid"
+)
+
+// WebAuthnCredential is a passkey (or security key) registered by a user so that they
+// can log in without a password. The CredentialID is assigned by the authenticator and
+// is used to look up the credential during login; only the public key is stored.
+type WebAuthnCredential struct {
+	tidal.BaseModel
+	UserID          ulid.ULID
+	Name            sql.NullString
+	CredentialID    []byte
+	PublicKey       []byte
+	AttestationType sql.NullString
+	AAGUID          []byte
+	SignCount       uint32
+	Transports      fields.StringArray // authenticator transports (usb, nfc, ble, internal, hybrid)
+	Flags           uint8              // authenticator data flags (UP, UV, BE, BS)
+	LastUsed        sql.NullTime
+}
+
+var _ tidal.Model = (*WebAuthnCredential)(nil)
+var _ tidal.Validator = (*WebAuthnCredential)(nil)
+
+func (w *WebAuthnCredential) Fields(op tidal.Operation) []string {
+	switch op {
+	case tidal.Update:
+		return []string{
+			"id",
+			"name",
+			"sign_count",
+			"flags",
+			"last_used",
+			"modified",
+		}
+	default:
+		return []string{
+			"id",
+			"user_id",
+			"name",
+			"credential_id",
+			"public_key",
+			"attestation_type",
+			"aaguid",
+			"sign_count",
+			"transports",
+			"flags",
+			"last_used",
+			"created",
+			"modified",
+		}
+	}
+}
+
+func (w *WebAuthnCredential) Params(op tidal.Operation) []sql.NamedArg {
+	switch op {
+	case tidal.Update:
+		return []sql.NamedArg{
+			sql.Named("id", w.ID),
+			sql.Named("name", w.Name),
+			sql.Named("sign_count", w.SignCount),
+			sql.Named("flags", w.Flags),
+			sql.Named("last_used", w.LastUsed),
+			sql.Named("modified", w.Modified),
+		}
+	default:
+		return []sql.NamedArg{
+			sql.Named("id", w.ID),
+			sql.Named("user_id", w.UserID),
+			sql.Named("name", w.Name),
+			sql.Named("credential_id", w.CredentialID),
+			sql.Named("public_key", w.PublicKey),
+			sql.Named("attestation_type", w.AttestationType),
+			sql.Named("aaguid", w.AAGUID),
+			sql.Named("sign_count", w.SignCount),
+			sql.Named("transports", w.Transports),
+			sql.Named("flags", w.Flags),
+			sql.Named("last_used", w.LastUsed),
+			sql.Named("created", w.Created),
+			sql.Named("modified", w.Modified),
+		}
+	}
+}
+
+func (w *WebAuthnCredential) Scan(op tidal.Operation, s tidal.Scanner) error {
+	return s.Scan(
+		&w.ID,
+		&w.UserID,
+		&w.Name,
+		&w.CredentialID,
+		&w.PublicKey,
+		&w.AttestationType,
+		&w.AAGUID,
+		&w.SignCount,
+		&w.Transports,
+		&w.Flags,
+		&w.LastUsed,
+		&w.Created,
+		&w.Modified,
+	)
+}
+
+// Validates that UserID, CredentialID, and PublicKey are set on create; default
+// [tidal.BaseModel.Validate] runs first.
+func (w *WebAuthnCredential) Validate(op tidal.Operation) error {
+	if err := w.BaseModel.Validate(op); err != nil {
+		return err
+	}
+	if op == tidal.Create {
+		if w.UserID.IsZero() || len(w.CredentialID) == 0 || len(w.PublicKey) == 0 {
+			return qerrors.ErrZeroValuedNotNull
+		}
+	}
+	return nil
+}
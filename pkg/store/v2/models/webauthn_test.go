@@ -0,0 +1,71 @@
+package models_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	. "go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/tidal"
+	tsuite "go.rtnl.ai/tidal/suite"
+	"go.rtnl.ai/ulid"
+)
+
+//=============================================================================
+// Database Conformance Tests
+//=============================================================================
+
+// TestWebAuthnCredentialCRUDConformance verifies WebAuthnCredential satisfies tidal CRUD shape and round-trip expectations against webauthn_credentials.
+func (s *modelSuite) TestWebAuthnCredentialCRUDConformance() {
+	tsuite.ConformsCRUD(&s.DatabaseSuite, tsuite.CRUDConformance[*WebAuthnCredential]{
+		Table: "webauthn_credentials",
+		Create: func() *WebAuthnCredential {
+			return &WebAuthnCredential{
+				UserID:       fixtureAdminUserID,
+				Name:         sql.NullString{String: "YubiKey", Valid: true},
+				CredentialID: []byte(ulid.MakeSecure().String()),
+				PublicKey:    []byte("public-key"),
+				Transports:   []string{"usb", "nfc"},
+				Flags:        0x05,
+			}
+		},
+		Update: func(w *WebAuthnCredential) {
+			w.Name = sql.NullString{String: "Security Key", Valid: true}
+			w.SignCount++
+		},
+		Phases: []tsuite.CRUDPhase{tsuite.CRUDShape, tsuite.CRUDScan, tsuite.CRUDRoundTrip},
+	})
+}
+
+//=============================================================================
+// Unit Tests
+//=============================================================================
+
+// TestWebAuthnCredentialParams verifies update params are limited to the mutable authenticator state.
+func TestWebAuthnCredentialParams(t *testing.T) {
+	model := &WebAuthnCredential{
+		UserID:       fixtureAdminUserID,
+		CredentialID: []byte("credential"),
+		PublicKey:    []byte("public-key"),
+		SignCount:    7,
+	}
+	model.ID = modelID
+
+	require.Len(t, model.Params(tidal.Create), len(model.Fields(tidal.Create)))
+	require.Len(t, model.Params(tidal.Update), len(model.Fields(tidal.Update)))
+
+	for _, param := range model.Params(tidal.Update) {
+		require.NotEqual(t, "public_key", param.Name, "the public key cannot be updated")
+		require.NotEqual(t, "credential_id", param.Name, "the credential id cannot be updated")
+	}
+}
+
+// TestWebAuthnCredentialValidate verifies required fields are checked on create.
+func TestWebAuthnCredentialValidate(t *testing.T) {
+	model := &WebAuthnCredential{UserID: fixtureAdminUserID, PublicKey: []byte("public-key")}
+	require.ErrorIs(t, model.Validate(tidal.Create), errors.ErrZeroValuedNotNull)
+
+	model.CredentialID = []byte("credential")
+	require.NoError(t, model.Validate(tidal.Create))
+}
@@ -0,0 +1,337 @@
+package models
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"slices"
+	"time"
+
+	"go.rtnl.ai/quarterdeck/pkg/enum"
+	qerrors "go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/tidal"
+	"go.rtnl.ai/ulid"
+)
+
+// Webhook is a subscription of an endpoint to one or more webhook events. Deliveries
+// to the endpoint are signed with the secret so that the receiver can verify that the
+// event was sent by Quarterdeck. Inactive webhooks are not sent any new events.
+type Webhook struct {
+	tidal.BaseModel
+	URL         string
+	Description sql.NullString
+	Events      WebhookEvents
+	Secret      string // only updated by UpdateWebhookSecret, not by UpdateWebhook
+	Active      bool
+}
+
+var _ tidal.Model = (*Webhook)(nil)
+var _ tidal.Validator = (*Webhook)(nil)
+
+func (w *Webhook) Fields(op tidal.Operation) []string {
+	switch op {
+	case tidal.Update:
+		return []string{
+			"id",
+			"url",
+			"description",
+			"events",
+			"active",
+			"modified",
+		}
+	default:
+		return []string{
+			"id",
+			"url",
+			"description",
+			"events",
+			"secret",
+			"active",
+			"created",
+			"modified",
+		}
+	}
+}
+
+func (w *Webhook) Params(op tidal.Operation) []sql.NamedArg {
+	switch op {
+	case tidal.Update:
+		return []sql.NamedArg{
+			sql.Named("id", w.ID),
+			sql.Named("url", w.URL),
+			sql.Named("description", w.Description),
+			sql.Named("events", w.Events),
+			sql.Named("active", w.Active),
+			sql.Named("modified", w.Modified),
+		}
+	default:
+		return []sql.NamedArg{
+			sql.Named("id", w.ID),
+			sql.Named("url", w.URL),
+			sql.Named("description", w.Description),
+			sql.Named("events", w.Events),
+			sql.Named("secret", w.Secret),
+			sql.Named("active", w.Active),
+			sql.Named("created", w.Created),
+			sql.Named("modified", w.Modified),
+		}
+	}
+}
+
+func (w *Webhook) Scan(op tidal.Operation, s tidal.Scanner) error {
+	return s.Scan(
+		&w.ID,
+		&w.URL,
+		&w.Description,
+		&w.Events,
+		&w.Secret,
+		&w.Active,
+		&w.Created,
+		&w.Modified,
+	)
+}
+
+// Validates that the URL and events are set on create and update and that the secret
+// is set on create; default [tidal.BaseModel.Validate] runs first.
+func (w *Webhook) Validate(op tidal.Operation) error {
+	if err := w.BaseModel.Validate(op); err != nil {
+		return err
+	}
+
+	if op == tidal.Create || op == tidal.Update {
+		if w.URL == "" || (op == tidal.Create && w.Secret == "") {
+			return qerrors.ErrZeroValuedNotNull
+		}
+
+		if len(w.Events) == 0 {
+			return qerrors.ErrNoSubscribedEvent
+		}
+	}
+	return nil
+}
+
+// Subscribed returns true if the webhook is active and subscribed to the event.
+func (w *Webhook) Subscribed(event enum.WebhookEvent) bool {
+	return w.Active && slices.Contains(w.Events, event)
+}
+
+// NewDelivery creates a pending delivery of the event to the webhook that is due now.
+func (w *Webhook) NewDelivery(eventID ulid.ULID, event enum.WebhookEvent, payload []byte) *WebhookDelivery {
+	return &WebhookDelivery{
+		WebhookID:   w.ID,
+		EventID:     eventID,
+		Event:       event,
+		Payload:     payload,
+		Status:      enum.DeliveryStatusPending,
+		NextAttempt: sql.NullTime{Valid: true, Time: time.Now().UTC()},
+	}
+}
+
+//===========================================================================
+// Webhook Deliveries
+//===========================================================================
+
+// WebhookDelivery is an event queued for delivery to a webhook. Deliveries are the
+// transactional outbox of webhook events: they are created in the same transaction as
+// the action that caused the event, so an event is queued if and only if the action is
+// committed. Queued deliveries are attempted at least once until they succeed or fail,
+// after which they are kept as a delivery log.
+type WebhookDelivery struct {
+	tidal.BaseModel
+	WebhookID    ulid.ULID
+	EventID      ulid.ULID // identifies the event, redeliveries have the same event ID
+	Event        enum.WebhookEvent
+	Payload      []byte
+	Status       enum.DeliveryStatus
+	Attempts     int
+	NextAttempt  sql.NullTime // when the delivery is due; null once delivered or failed
+	LastAttempt  sql.NullTime
+	ResponseCode sql.NullInt64
+	Error        sql.NullString
+	Delivered    sql.NullTime
+}
+
+var _ tidal.Model = (*WebhookDelivery)(nil)
+var _ tidal.Validator = (*WebhookDelivery)(nil)
+
+func (d *WebhookDelivery) Fields(op tidal.Operation) []string {
+	switch op {
+	case tidal.Update:
+		return []string{
+			"id",
+			"status",
+			"attempts",
+			"next_attempt",
+			"last_attempt",
+			"response_code",
+			"error",
+			"delivered",
+			"modified",
+		}
+	default:
+		return []string{
+			"id",
+			"webhook_id",
+			"event_id",
+			"event",
+			"payload",
+			"status",
+			"attempts",
+			"next_attempt",
+			"last_attempt",
+			"response_code",
+			"error",
+			"delivered",
+			"created",
+			"modified",
+		}
+	}
+}
+
+func (d *WebhookDelivery) Params(op tidal.Operation) []sql.NamedArg {
+	switch op {
+	case tidal.Update:
+		return []sql.NamedArg{
+			sql.Named("id", d.ID),
+			sql.Named("status", d.Status),
+			sql.Named("attempts", d.Attempts),
+			sql.Named("next_attempt", d.NextAttempt),
+			sql.Named("last_attempt", d.LastAttempt),
+			sql.Named("response_code", d.ResponseCode),
+			sql.Named("error", d.Error),
+			sql.Named("delivered", d.Delivered),
+			sql.Named("modified", d.Modified),
+		}
+	default:
+		return []sql.NamedArg{
+			sql.Named("id", d.ID),
+			sql.Named("webhook_id", d.WebhookID),
+			sql.Named("event_id", d.EventID),
+			sql.Named("event", d.Event),
+			sql.Named("payload", string(d.Payload)),
+			sql.Named("status", d.Status),
+			sql.Named("attempts", d.Attempts),
+			sql.Named("next_attempt", d.NextAttempt),
+			sql.Named("last_attempt", d.LastAttempt),
+			sql.Named("response_code", d.ResponseCode),
+			sql.Named("error", d.Error),
+			sql.Named("delivered", d.Delivered),
+			sql.Named("created", d.Created),
+			sql.Named("modified", d.Modified),
+		}
+	}
+}
+
+func (d *WebhookDelivery) Scan(op tidal.Operation, s tidal.Scanner) error {
+	return s.Scan(
+		&d.ID,
+		&d.WebhookID,
+		&d.EventID,
+		&d.Event,
+		&d.Payload,
+		&d.Status,
+		&d.Attempts,
+		&d.NextAttempt,
+		&d.LastAttempt,
+		&d.ResponseCode,
+		&d.Error,
+		&d.Delivered,
+		&d.Created,
+		&d.Modified,
+	)
+}
+
+// Validates that the webhook and event are referenced on create; default
+// [tidal.BaseModel.Validate] runs first.
+func (d *WebhookDelivery) Validate(op tidal.Operation) error {
+	if err := d.BaseModel.Validate(op); err != nil {
+		return err
+	}
+	if op == tidal.Create && (d.WebhookID.IsZero() || d.EventID.IsZero()) {
+		return qerrors.ErrMissingReference
+	}
+	return nil
+}
+
+// Record the result of a delivery attempt. If the attempt failed then the delivery is
+// retried after the backoff unless the maximum number of attempts has been made, in
+// which case the delivery has failed and must be redelivered manually.
+func (d *WebhookDelivery) Record(code int, err error, backoff time.Duration, maxAttempts int) {
+	now := time.Now().UTC()
+	d.Attempts++
+	d.LastAttempt = sql.NullTime{Valid: true, Time: now}
+	d.ResponseCode = sql.NullInt64{Valid: code != 0, Int64: int64(code)}
+
+	if err == nil {
+		d.Status = enum.DeliveryStatusDelivered
+		d.Delivered = sql.NullTime{Valid: true, Time: now}
+		d.NextAttempt = sql.NullTime{}
+		d.Error = sql.NullString{}
+		return
+	}
+
+	d.Error = sql.NullString{Valid: true, String: err.Error()}
+	if d.Attempts >= maxAttempts {
+		d.Status = enum.DeliveryStatusFailed
+		d.NextAttempt = sql.NullTime{}
+		return
+	}
+
+	d.Status = enum.DeliveryStatusPending
+	d.NextAttempt = sql.NullTime{Valid: true, Time: now.Add(backoff)}
+}
+
+// Redelivery creates a new pending delivery of the same event to the same webhook.
+func (d *WebhookDelivery) Redelivery() *WebhookDelivery {
+	return &WebhookDelivery{
+		WebhookID:   d.WebhookID,
+		EventID:     d.EventID,
+		Event:       d.Event,
+		Payload:     d.Payload,
+		Status:      enum.DeliveryStatusPending,
+		NextAttempt: sql.NullTime{Valid: true, Time: time.Now().UTC()},
+	}
+}
+
+//===========================================================================
+// Webhook Events
+//===========================================================================
+
+// WebhookEvents is the list of events a webhook is subscribed to, stored as JSON.
+type WebhookEvents []enum.WebhookEvent
+
+// Scan the JSON list of events from the database; null is an empty list.
+func (e *WebhookEvents) Scan(src any) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*e = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return qerrors.Fmt("cannot scan %T into webhook events", src)
+	}
+
+	if len(data) == 0 {
+		*e = nil
+		return nil
+	}
+	return json.Unmarshal(data, (*[]enum.WebhookEvent)(e))
+}
+
+// Value returns the events as a JSON list; nil is stored as an empty list.
+func (e WebhookEvents) Value() (driver.Value, error) {
+	events := []enum.WebhookEvent(e)
+	if events == nil {
+		events = []enum.WebhookEvent{}
+	}
+
+	data, err := json.Marshal(events)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
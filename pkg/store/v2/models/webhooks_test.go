@@ -0,0 +1,214 @@
+package models_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/quarterdeck/pkg/enum"
+	qerrors "go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/store/v2/mock"
+	. "go.rtnl.ai/quarterdeck/pkg/store/v2/models"
+	"go.rtnl.ai/tidal"
+	tsuite "go.rtnl.ai/tidal/suite"
+	"go.rtnl.ai/ulid"
+)
+
+//=============================================================================
+// Database Conformance Tests
+//=============================================================================
+
+// TestWebhookCRUDConformance verifies Webhook satisfies tidal CRUD shape expectations against the webhooks table.
+func (s *modelSuite) TestWebhookCRUDConformance() {
+	tsuite.ConformsCRUD(&s.DatabaseSuite, tsuite.CRUDConformance[*Webhook]{
+		Table: "webhooks",
+		Create: func() *Webhook {
+			return &Webhook{
+				URL:    "https://example.com/webhooks/" + ulid.MakeSecure().String(),
+				Events: WebhookEvents{enum.WebhookEventUserCreated},
+				Secret: "supersecretsquirrel",
+				Active: true,
+			}
+		},
+		Update: func(w *Webhook) {
+			w.Events = append(w.Events, enum.WebhookEventLogin)
+			w.Active = false
+		},
+		FieldMap: map[string]string{
+			"url": "URL",
+		},
+		Phases: []tsuite.CRUDPhase{tsuite.CRUDShape, tsuite.CRUDScan, tsuite.CRUDRoundTrip},
+	})
+}
+
+//=============================================================================
+// Unit Tests
+//=============================================================================
+
+// TestWebhookScan verifies Scan decodes the JSON events and handles nullable columns.
+func TestWebhookScan(t *testing.T) {
+	t.Run("NotNull", func(t *testing.T) {
+		data := []any{
+			ulid.MakeSecure().String(),
+			"https://example.com/webhooks",
+			"user sync",
+			[]byte(`["user.created","apikey.revoked"]`),
+			"supersecretsquirrel",
+			true,
+			created,
+			modified,
+		}
+		mockScanner := &mock.Scanner{}
+		mockScanner.SetData(data)
+
+		model := &Webhook{}
+		err := model.Scan(tidal.Retrieve, mockScanner)
+		require.NoError(t, err)
+		mockScanner.AssertScanned(t, len(data))
+
+		require.Equal(t, "https://example.com/webhooks", model.URL)
+		require.Equal(t, "user sync", model.Description.String)
+		require.Equal(t, WebhookEvents{enum.WebhookEventUserCreated, enum.WebhookEventAPIKeyRevoked}, model.Events)
+		require.True(t, model.Active)
+	})
+
+	t.Run("Nulls", func(t *testing.T) {
+		data := []any{
+			ulid.MakeSecure().String(),
+			"https://example.com/webhooks",
+			nil,
+			nil,
+			"supersecretsquirrel",
+			false,
+			created,
+			modified,
+		}
+		mockScanner := &mock.Scanner{}
+		mockScanner.SetData(data)
+
+		model := &Webhook{}
+		err := model.Scan(tidal.Retrieve, mockScanner)
+		require.NoError(t, err)
+		require.False(t, model.Description.Valid)
+		require.Empty(t, model.Events)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mockScanner := &mock.Scanner{}
+		mockScanner.SetError(ErrModelScan)
+
+		model := &Webhook{}
+		err := model.Scan(tidal.Retrieve, mockScanner)
+		require.ErrorIs(t, err, ErrModelScan)
+	})
+}
+
+// TestWebhookValidate verifies the URL, secret, and events are required.
+func TestWebhookValidate(t *testing.T) {
+	webhook := &Webhook{Secret: "supersecretsquirrel", Events: WebhookEvents{enum.WebhookEventLogin}}
+	require.ErrorIs(t, webhook.Validate(tidal.Create), qerrors.ErrZeroValuedNotNull)
+
+	webhook.URL = "https://example.com/webhooks"
+	require.NoError(t, webhook.Validate(tidal.Create))
+
+	webhook.Events = nil
+	require.ErrorIs(t, webhook.Validate(tidal.Create), qerrors.ErrNoSubscribedEvent)
+
+	webhook.Events = WebhookEvents{enum.WebhookEventLogin}
+	webhook.Secret = ""
+	require.ErrorIs(t, webhook.Validate(tidal.Create), qerrors.ErrZeroValuedNotNull)
+}
+
+// TestWebhookEventsValue verifies the events are stored as a JSON list.
+func TestWebhookEventsValue(t *testing.T) {
+	value, err := WebhookEvents(nil).Value()
+	require.NoError(t, err)
+	require.Equal(t, "[]", value)
+
+	value, err = WebhookEvents{enum.WebhookEventUserDeleted, enum.WebhookEventLogin}.Value()
+	require.NoError(t, err)
+	require.Equal(t, `["user.deleted","login"]`, value)
+
+	var events WebhookEvents
+	require.NoError(t, events.Scan(value))
+	require.Equal(t, WebhookEvents{enum.WebhookEventUserDeleted, enum.WebhookEventLogin}, events)
+	require.Error(t, events.Scan(42))
+}
+
+// TestWebhookDeliveryScan verifies Scan handles nullable columns and propagates scanner errors.
+func TestWebhookDeliveryScan(t *testing.T) {
+	t.Run("Nulls", func(t *testing.T) {
+		data := []any{
+			ulid.MakeSecure().String(),
+			ulid.MakeSecure().String(),
+			ulid.MakeSecure().String(),
+			"user.updated",
+			[]byte(`{"event":"user.updated"}`),
+			"pending",
+			int64(0),
+			created,
+			nil,
+			nil,
+			nil,
+			nil,
+			created,
+			modified,
+		}
+		mockScanner := &mock.Scanner{}
+		mockScanner.SetData(data)
+
+		model := &WebhookDelivery{}
+		err := model.Scan(tidal.Retrieve, mockScanner)
+		require.NoError(t, err)
+		mockScanner.AssertScanned(t, len(data))
+
+		require.Equal(t, enum.WebhookEventUserUpdated, model.Event)
+		require.Equal(t, enum.DeliveryStatusPending, model.Status)
+		require.True(t, model.NextAttempt.Valid)
+		require.False(t, model.LastAttempt.Valid)
+		require.False(t, model.ResponseCode.Valid)
+		require.False(t, model.Error.Valid)
+		require.False(t, model.Delivered.Valid)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mockScanner := &mock.Scanner{}
+		mockScanner.SetError(ErrModelScan)
+
+		model := &WebhookDelivery{}
+		err := model.Scan(tidal.Retrieve, mockScanner)
+		require.ErrorIs(t, err, ErrModelScan)
+	})
+}
+
+// TestWebhookDeliveryRecord verifies delivery attempts are retried until they fail.
+func TestWebhookDeliveryRecord(t *testing.T) {
+	webhook := &Webhook{BaseModel: tidal.BaseModel{ID: modelID}, Active: true, Events: WebhookEvents{enum.WebhookEventLogin}}
+	require.True(t, webhook.Subscribed(enum.WebhookEventLogin))
+	require.False(t, webhook.Subscribed(enum.WebhookEventUserCreated))
+
+	delivery := webhook.NewDelivery(ulid.MakeSecure(), enum.WebhookEventLogin, []byte(`{}`))
+	require.ErrorIs(t, (&WebhookDelivery{}).Validate(tidal.Create), qerrors.ErrMissingReference)
+	require.NoError(t, delivery.Validate(tidal.Create))
+
+	delivery.Record(503, errors.New("service unavailable"), time.Minute, 2)
+	require.Equal(t, enum.DeliveryStatusPending, delivery.Status)
+	require.Equal(t, int64(503), delivery.ResponseCode.Int64)
+	require.WithinDuration(t, time.Now().Add(time.Minute), delivery.NextAttempt.Time, time.Second)
+
+	delivery.Record(0, errors.New("connection refused"), time.Minute, 2)
+	require.Equal(t, enum.DeliveryStatusFailed, delivery.Status)
+	require.False(t, delivery.NextAttempt.Valid)
+	require.False(t, delivery.ResponseCode.Valid)
+
+	redelivery := delivery.Redelivery()
+	require.Equal(t, delivery.EventID, redelivery.EventID)
+	require.Equal(t, enum.DeliveryStatusPending, redelivery.Status)
+
+	redelivery.Record(200, nil, time.Minute, 2)
+	require.Equal(t, enum.DeliveryStatusDelivered, redelivery.Status)
+	require.Equal(t, sql.NullString{}, redelivery.Error)
+	require.True(t, redelivery.Delivered.Valid)
+}
@@ -29,9 +29,19 @@ type Store interface {
 	UserStore
 	RoleStore
 	PermissionStore
+	OrganizationStore
 	APIKeyStore
 	OIDCClientStore
 	VeroTokenStore
+	RefreshTokenStore
+	AuthorizationCodeStore
+	RevokedTokenStore
+	SessionStore
+	WebAuthnCredentialStore
+	LockoutStore
+	AuditEventStore
+	WebhookStore
+	SigningKeyStore
 }
 
 // Check that [backend.Store] implements [Store].
@@ -46,7 +56,13 @@ type UserStore interface {
 	UpdatePassword(ctx context.Context, userID ulid.ULID, password string) error
 	UpdateLastLogin(ctx context.Context, userID ulid.ULID, lastLogin time.Time) error
 	VerifyEmail(ctx context.Context, userID ulid.ULID) error
+	// UpdateMFA stores the TOTP secret, enrollment, and recovery codes; other fields are ignored.
+	UpdateMFA(ctx context.Context, user *models.User) error
+	// UpdateTOTPStep returns ErrTOTPReused if the step is not after the last accepted step.
+	UpdateTOTPStep(ctx context.Context, userID ulid.ULID, step int64) error
+	UpdateUserStatus(ctx context.Context, userID ulid.ULID, status enum.UserStatus) error
 	DeleteUser(ctx context.Context, userID ulid.ULID) error
+	PurgeUsers(ctx context.Context, deletedBefore time.Time) ([]ulid.ULID, error)
 	AddRoleToUser(ctx context.Context, userID ulid.ULID, roleID int64) error
 	AddRoleToUserByTitle(ctx context.Context, userID ulid.ULID, title string) error
 	RemoveRoleFromUser(ctx context.Context, userID ulid.ULID, roleID int64) error
@@ -75,6 +91,22 @@ type PermissionStore interface {
 	DeletePermission(ctx context.Context, id int64) error
 }
 
+type OrganizationStore interface {
+	ListOrganizations(ctx context.Context, filter tidal.ListFilter) (tidal.Cursor[*models.Organization], error)
+	CreateOrganization(ctx context.Context, org *models.Organization) (*models.Organization, error)
+	RetrieveOrganization(ctx context.Context, id ulid.ULID) (*models.Organization, error)
+	UpdateOrganization(ctx context.Context, org *models.Organization) error
+	// DeleteOrganization also deletes the organization's memberships, API keys, and OIDC clients.
+	DeleteOrganization(ctx context.Context, id ulid.ULID) error
+	ListUserOrganizations(ctx context.Context, userID ulid.ULID) ([]*models.Organization, error)
+	// ListOrganizationMembers loads only the roles and permissions members have in the organization.
+	ListOrganizationMembers(ctx context.Context, orgID ulid.ULID) ([]*models.User, error)
+	// RetrieveOrganizationMember merges the user's global and organization roles; ErrNotFound if not a member.
+	RetrieveOrganizationMember(ctx context.Context, orgID, userID ulid.ULID) (*models.User, error)
+	// ReplaceOrganizationMemberRoles removes the user from the organization if roleIDs is empty.
+	ReplaceOrganizationMemberRoles(ctx context.Context, orgID, userID ulid.ULID, roleIDs []int64) error
+}
+
 type APIKeyStore interface {
 	ListAPIKeys(ctx context.Context, filter tidal.ListFilter) (tidal.Cursor[*models.APIKey], error)
 	CreateAPIKey(ctx context.Context, key *models.APIKey) (*models.APIKey, error)
@@ -113,6 +145,114 @@ type VeroTokenStore interface {
 	CreateResetPasswordVeroToken(ctx context.Context, token *models.VeroToken) (*models.VeroToken, error)
 	// CreateTeamInviteVeroToken allows at most one unexpired team-invite token per resource.
 	CreateTeamInviteVeroToken(ctx context.Context, token *models.VeroToken) (*models.VeroToken, error)
+	// CreateVerifyEmailVeroToken allows at most one unexpired verify-email token per resource.
+	CreateVerifyEmailVeroToken(ctx context.Context, token *models.VeroToken) (*models.VeroToken, error)
+	// CreateChangeEmailVeroToken replaces any pending change-email token for the resource.
+	CreateChangeEmailVeroToken(ctx context.Context, token *models.VeroToken) (*models.VeroToken, error)
+	// ListVeroTokensByType returns the outstanding tokens of the type, most recently created first.
+	ListVeroTokensByType(ctx context.Context, tokenType enum.TokenType) ([]*models.VeroToken, error)
+	// ConsumeVeroToken deletes an unexpired token of the type, returning ErrNotFound if it was already used.
+	ConsumeVeroToken(ctx context.Context, id ulid.ULID, tokenType enum.TokenType) error
 	// CompletePasswordReset validates the token, sets the password, and deletes the token.
 	CompletePasswordReset(ctx context.Context, veroTokenID ulid.ULID, newPassword string) error
+	// CompleteEmailChange validates the token, sets the email (preserving email_verified), and deletes the token.
+	CompleteEmailChange(ctx context.Context, veroTokenID ulid.ULID) error
+}
+
+type RefreshTokenStore interface {
+	// CreateRefreshToken records an issued refresh token by its jti; a zero FamilyID starts a new family.
+	CreateRefreshToken(ctx context.Context, token *models.RefreshToken) (*models.RefreshToken, error)
+	RetrieveRefreshToken(ctx context.Context, jti ulid.ULID) (*models.RefreshToken, error)
+	// UseRefreshToken marks the token used; returns ErrTokenReused if it was already used.
+	UseRefreshToken(ctx context.Context, jti ulid.ULID) (*models.RefreshToken, error)
+	// RevokeRefreshTokenFamily revokes every refresh token descended from the same login.
+	RevokeRefreshTokenFamily(ctx context.Context, familyID ulid.ULID) error
+}
+
+type AuthorizationCodeStore interface {
+	CreateAuthorizationCode(ctx context.Context, code *models.AuthorizationCode) (*models.AuthorizationCode, error)
+	// RetrieveAuthorizationCode looks up an authorization code by the hash of the code, not the model ID.
+	RetrieveAuthorizationCode(ctx context.Context, code string) (*models.AuthorizationCode, error)
+	// DeleteAuthorizationCode also deletes any expired authorization codes.
+	DeleteAuthorizationCode(ctx context.Context, id ulid.ULID) error
+}
+
+type RevokedTokenStore interface {
+	// RevokeToken denylists the jti until expiration; revoking a token twice is not an error.
+	RevokeToken(ctx context.Context, token *models.RevokedToken) error
+	// ConsumeToken denylists a single-use jti, returning ErrTokenRevoked if it was already used.
+	ConsumeToken(ctx context.Context, token *models.RevokedToken) error
+	IsTokenRevoked(ctx context.Context, jti ulid.ULID) (bool, error)
+}
+
+type SessionStore interface {
+	// ListSessions returns the active sessions of the user, most recently active first.
+	ListSessions(ctx context.Context, userID ulid.ULID) ([]*models.Session, error)
+	// CreateSession records a login; the ID is the family ID of the refresh tokens issued on login.
+	CreateSession(ctx context.Context, session *models.Session) (*models.Session, error)
+	RetrieveSession(ctx context.Context, id ulid.ULID) (*models.Session, error)
+	// RefreshSession records the jti and expiration of the token pair issued on refresh.
+	RefreshSession(ctx context.Context, id, jti ulid.ULID, expiration time.Time) error
+	// RevokeSession also revokes the refresh token family of the session.
+	RevokeSession(ctx context.Context, id ulid.ULID) error
+	RevokeUserSessions(ctx context.Context, userID ulid.ULID) error
+}
+
+type WebAuthnCredentialStore interface {
+	ListWebAuthnCredentials(ctx context.Context, filter tidal.ListFilter) (tidal.Cursor[*models.WebAuthnCredential], error)
+	CreateWebAuthnCredential(ctx context.Context, credential *models.WebAuthnCredential) (*models.WebAuthnCredential, error)
+	// RetrieveWebAuthnCredential looks up a passkey by the authenticator-assigned credential ID, not the model ID.
+	RetrieveWebAuthnCredential(ctx context.Context, credentialID []byte) (*models.WebAuthnCredential, error)
+	// UpdateWebAuthnCredential updates the name and authenticator state (sign count, flags, last used).
+	UpdateWebAuthnCredential(ctx context.Context, credential *models.WebAuthnCredential) error
+	DeleteWebAuthnCredential(ctx context.Context, id ulid.ULID) error
+}
+
+type LockoutStore interface {
+	// RetrieveLockout returns ErrNotFound if there are no failed attempts since the last reset.
+	RetrieveLockout(ctx context.Context, kind, identifier string) (*models.Lockout, error)
+	// RecordFailedAttempt increments the failed attempts and locks out the identifier per the policy.
+	RecordFailedAttempt(ctx context.Context, kind, identifier string, policy models.LockoutPolicy) (*models.Lockout, error)
+	ResetLockout(ctx context.Context, kind, identifier string) error
+}
+
+type AuditEventStore interface {
+	// ListAuditEvents returns a cursor over the audit log; events are immutable once created.
+	ListAuditEvents(ctx context.Context, filter tidal.ListFilter) (tidal.Cursor[*models.AuditEvent], error)
+	CreateAuditEvent(ctx context.Context, event *models.AuditEvent) (*models.AuditEvent, error)
+}
+
+type WebhookStore interface {
+	ListWebhooks(ctx context.Context, filter tidal.ListFilter) (tidal.Cursor[*models.Webhook], error)
+	CreateWebhook(ctx context.Context, webhook *models.Webhook) (*models.Webhook, error)
+	RetrieveWebhook(ctx context.Context, id ulid.ULID) (*models.Webhook, error)
+	// UpdateWebhook updates the URL, description, events, and active flag but not the secret.
+	UpdateWebhook(ctx context.Context, webhook *models.Webhook) error
+	// UpdateWebhookSecret replaces the secret that the webhook's deliveries are signed with.
+	UpdateWebhookSecret(ctx context.Context, id ulid.ULID, secret string) error
+	// DeleteWebhook also deletes the webhook's delivery log.
+	DeleteWebhook(ctx context.Context, id ulid.ULID) error
+	// EnqueueWebhookEvent queues a delivery for every active webhook subscribed to the event
+	// and returns the number of deliveries queued.
+	EnqueueWebhookEvent(ctx context.Context, eventID ulid.ULID, event enum.WebhookEvent, payload []byte) (int, error)
+	CreateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) (*models.WebhookDelivery, error)
+	RetrieveWebhookDelivery(ctx context.Context, id ulid.ULID) (*models.WebhookDelivery, error)
+	// UpdateWebhookDelivery records the outcome of a delivery attempt; the event is immutable.
+	UpdateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	// ListWebhookDeliveries returns the delivery log of the webhook, most recent first.
+	ListWebhookDeliveries(ctx context.Context, webhookID ulid.ULID) ([]*models.WebhookDelivery, error)
+	// ClaimDueWebhookDeliveries leases up to limit pending deliveries due at now, longest due first.
+	ClaimDueWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*models.WebhookDelivery, error)
+}
+
+type SigningKeyStore interface {
+	// ListSigningKeys returns every signing key including retired keys, newest first.
+	ListSigningKeys(ctx context.Context) ([]*models.SigningKey, error)
+	// CreateSigningKey requires the kid as the ID; returns ErrAlreadyExists if there is already a next or active key.
+	CreateSigningKey(ctx context.Context, key *models.SigningKey) (*models.SigningKey, error)
+	RetrieveSigningKey(ctx context.Context, id ulid.ULID) (*models.SigningKey, error)
+	// ActivateSigningKey activates the next key; the active key retires at the specified time.
+	ActivateSigningKey(ctx context.Context, id ulid.ULID, retires time.Time) error
+	// RetireSigningKey returns ErrActiveSigningKey if the key is the active key.
+	RetireSigningKey(ctx context.Context, id ulid.ULID) error
 }
@@ -28,7 +28,22 @@ type openSuite struct {
 // TestMigrationsSQLite verifies SQLite migrations load with expected IDs, names, and SQL.
 func TestMigrationsSQLite(t *testing.T) {
 	expectedMigrations := map[int]string{
-		1: "Primary Schema",
+		1:  "Primary Schema",
+		2:  "Refresh Tokens",
+		3:  "Webauthn Credentials",
+		4:  "Audit Events",
+		5:  "Custom Permissions",
+		6:  "Organizations",
+		7:  "User Status",
+		8:  "Webhooks",
+		9:  "Authorization Codes",
+		10: "Revoked Tokens",
+		11: "Sessions",
+		12: "Multi Factor Auth",
+		13: "Lockouts",
+		14: "Signing Keys",
+		15: "Totp Step",
+		16: "Audit Organizations",
 	}
 	testMigrations(t, dsn.SQLite3, expectedMigrations)
 }
@@ -36,7 +51,22 @@ func TestMigrationsSQLite(t *testing.T) {
 // TestMigrationsPostgres verifies Postgres migrations load with expected IDs, names, and SQL.
 func TestMigrationsPostgres(t *testing.T) {
 	expectedMigrations := map[int]string{
-		1: "Primary Schema",
+		1:  "Primary Schema",
+		2:  "Refresh Tokens",
+		3:  "Webauthn Credentials",
+		4:  "Audit Events",
+		5:  "Custom Permissions",
+		6:  "Organizations",
+		7:  "User Status",
+		8:  "Webhooks",
+		9:  "Authorization Codes",
+		10: "Revoked Tokens",
+		11: "Sessions",
+		12: "Multi Factor Auth",
+		13: "Lockouts",
+		14: "Signing Keys",
+		15: "Totp Step",
+		16: "Audit Organizations",
 	}
 	testMigrations(t, dsn.Postgres, expectedMigrations)
 }
@@ -0,0 +1,17 @@
+-- Organizations: one with complete details and one with only a name.
+INSERT INTO organizations (id, name, street_address, homepage_uri, support_email, created, modified) VALUES
+    (decode('019b0001000000000000000000000001', 'hex'), 'Acme Corporation', '1 Acme Way, Springfield, USA', 'https://acme.example.com', 'support@acme.example.com', '2025-06-01T12:00:00Z', '2025-06-01T12:00:00Z'),
+    (decode('019b0002000000000000000000000002', 'hex'), 'Globex', NULL, NULL, NULL, '2025-06-02T12:00:00Z', '2025-06-02T12:00:00Z')
+;
+
+-- The viewer user is a keyholder in Acme and an editor in Globex; the editor user is
+-- a viewer in Acme. No other users are members of an organization.
+INSERT INTO organization_members (organization_id, user_id, role_id, created) VALUES
+    (decode('019b0001000000000000000000000001', 'hex'), decode('0196f8f5b7abac0d2adfe334c4a46343', 'hex'), 4, '2025-06-03T12:00:00Z'),
+    (decode('019b0002000000000000000000000002', 'hex'), decode('0196f8f5b7abac0d2adfe334c4a46343', 'hex'), 2, '2025-06-04T12:00:00Z'),
+    (decode('019b0001000000000000000000000001', 'hex'), decode('0195eb6b859180cd9d9eb8bcf5f58818', 'hex'), 3, '2025-06-05T12:00:00Z')
+;
+
+-- The full permission keys and the full metadata OIDC client belong to Acme.
+UPDATE api_keys SET organization_id=decode('019b0001000000000000000000000001', 'hex') WHERE id=decode('01958e2a1a7dcbe8175e13db6a2ce94a', 'hex');
+UPDATE oidc_clients SET organization_id=decode('019b0001000000000000000000000001', 'hex') WHERE id=decode('019a0001000000000000000000000001', 'hex');
@@ -30,7 +30,13 @@ type Tx interface {
 	UpdatePassword(userID ulid.ULID, password string) error
 	UpdateLastLogin(userID ulid.ULID, lastLogin time.Time) error
 	VerifyEmail(userID ulid.ULID) error
+	// UpdateMFA stores the TOTP secret, enrollment, and recovery codes; other fields are ignored.
+	UpdateMFA(user *models.User) error
+	// UpdateTOTPStep returns ErrTOTPReused if the step is not after the last accepted step.
+	UpdateTOTPStep(userID ulid.ULID, step int64) error
+	UpdateUserStatus(userID ulid.ULID, status enum.UserStatus) error
 	DeleteUser(userID ulid.ULID) error
+	PurgeUsers(deletedBefore time.Time) ([]ulid.ULID, error)
 	AddRoleToUser(userID ulid.ULID, roleID int64) error
 	AddRoleToUserByTitle(userID ulid.ULID, title string) error
 	RemoveRoleFromUser(userID ulid.ULID, roleID int64) error
@@ -64,6 +70,23 @@ type Tx interface {
 	// continue using this transaction.
 	ListPermissions(filter tidal.ListFilter) (tidal.Cursor[*models.Permission], error)
 
+	CreateOrganization(org *models.Organization) (*models.Organization, error)
+	RetrieveOrganization(id ulid.ULID) (*models.Organization, error)
+	UpdateOrganization(org *models.Organization) error
+	// DeleteOrganization also deletes the organization's memberships, API keys, and OIDC clients.
+	DeleteOrganization(id ulid.ULID) error
+	ListUserOrganizations(userID ulid.ULID) ([]*models.Organization, error)
+	// ListOrganizationMembers loads only the roles and permissions members have in the organization.
+	ListOrganizationMembers(orgID ulid.ULID) ([]*models.User, error)
+	// RetrieveOrganizationMember merges the user's global and organization roles; ErrNotFound if not a member.
+	RetrieveOrganizationMember(orgID, userID ulid.ULID) (*models.User, error)
+	// ReplaceOrganizationMemberRoles removes the user from the organization if roleIDs is empty.
+	ReplaceOrganizationMemberRoles(orgID, userID ulid.ULID, roleIDs []int64) error
+	// ListOrganizations returns a cursor over organizations matching filter. [tidal.Cursor.Close]
+	// rolls back the transaction; use [tidal.Cursor.CloseRows] to release the result set and
+	// continue using this transaction.
+	ListOrganizations(filter tidal.ListFilter) (tidal.Cursor[*models.Organization], error)
+
 	CreateAPIKey(key *models.APIKey) (*models.APIKey, error)
 	RetrieveAPIKey(id ulid.ULID) (*models.APIKey, error)
 	RetrieveAPIKeyByClientID(clientID string) (*models.APIKey, error)
@@ -103,8 +126,103 @@ type Tx interface {
 	CreateResetPasswordVeroToken(token *models.VeroToken) (*models.VeroToken, error)
 	// CreateTeamInviteVeroToken allows at most one unexpired team-invite token per resource.
 	CreateTeamInviteVeroToken(token *models.VeroToken) (*models.VeroToken, error)
+	// CreateVerifyEmailVeroToken allows at most one unexpired verify-email token per resource.
+	CreateVerifyEmailVeroToken(token *models.VeroToken) (*models.VeroToken, error)
+	// CreateChangeEmailVeroToken replaces any pending change-email token for the resource.
+	CreateChangeEmailVeroToken(token *models.VeroToken) (*models.VeroToken, error)
+	// ListVeroTokensByType returns the outstanding tokens of the type, most recently created first.
+	ListVeroTokensByType(tokenType enum.TokenType) ([]*models.VeroToken, error)
+	// ConsumeVeroToken deletes an unexpired token of the type, returning ErrNotFound if it was already used.
+	ConsumeVeroToken(id ulid.ULID, tokenType enum.TokenType) error
 	// CompletePasswordReset validates the token, sets the password, and deletes the token.
 	CompletePasswordReset(veroTokenID ulid.ULID, newPassword string) error
+	// CompleteEmailChange validates the token, sets the email (preserving email_verified), and deletes the token.
+	CompleteEmailChange(veroTokenID ulid.ULID) error
+
+	// CreateRefreshToken records an issued refresh token by its jti; a zero FamilyID starts a new family.
+	CreateRefreshToken(token *models.RefreshToken) (*models.RefreshToken, error)
+	RetrieveRefreshToken(jti ulid.ULID) (*models.RefreshToken, error)
+	// UseRefreshToken marks the token used; returns ErrTokenReused if it was already used.
+	UseRefreshToken(jti ulid.ULID) (*models.RefreshToken, error)
+	// RevokeRefreshTokenFamily revokes every refresh token descended from the same login.
+	RevokeRefreshTokenFamily(familyID ulid.ULID) error
+
+	CreateAuthorizationCode(code *models.AuthorizationCode) (*models.AuthorizationCode, error)
+	// RetrieveAuthorizationCode looks up an authorization code by the hash of the code, not the model ID.
+	RetrieveAuthorizationCode(code string) (*models.AuthorizationCode, error)
+	// DeleteAuthorizationCode also deletes any expired authorization codes.
+	DeleteAuthorizationCode(id ulid.ULID) error
+
+	// RevokeToken denylists the jti until expiration; revoking a token twice is not an error.
+	RevokeToken(token *models.RevokedToken) error
+	// ConsumeToken denylists a single-use jti, returning ErrTokenRevoked if it was already used.
+	ConsumeToken(token *models.RevokedToken) error
+	IsTokenRevoked(jti ulid.ULID) (bool, error)
+
+	// ListSessions returns the active sessions of the user, most recently active first.
+	ListSessions(userID ulid.ULID) ([]*models.Session, error)
+	// CreateSession records a login; the ID is the family ID of the refresh tokens issued on login.
+	CreateSession(session *models.Session) (*models.Session, error)
+	RetrieveSession(id ulid.ULID) (*models.Session, error)
+	// RefreshSession records the jti and expiration of the token pair issued on refresh.
+	RefreshSession(id, jti ulid.ULID, expiration time.Time) error
+	// RevokeSession also revokes the refresh token family of the session.
+	RevokeSession(id ulid.ULID) error
+	RevokeUserSessions(userID ulid.ULID) error
+
+	CreateWebAuthnCredential(credential *models.WebAuthnCredential) (*models.WebAuthnCredential, error)
+	// RetrieveWebAuthnCredential looks up a passkey by the authenticator-assigned credential ID, not the model ID.
+	RetrieveWebAuthnCredential(credentialID []byte) (*models.WebAuthnCredential, error)
+	// UpdateWebAuthnCredential updates the name and authenticator state (sign count, flags, last used).
+	UpdateWebAuthnCredential(credential *models.WebAuthnCredential) error
+	DeleteWebAuthnCredential(id ulid.ULID) error
+	// ListWebAuthnCredentials returns a cursor over passkeys matching filter. [tidal.Cursor.Close]
+	// rolls back the transaction; use [tidal.Cursor.CloseRows] to release the result set and
+	// continue using this transaction.
+	ListWebAuthnCredentials(filter tidal.ListFilter) (tidal.Cursor[*models.WebAuthnCredential], error)
+
+	// RetrieveLockout returns ErrNotFound if there are no failed attempts since the last reset.
+	RetrieveLockout(kind, identifier string) (*models.Lockout, error)
+	// RecordFailedAttempt increments the failed attempts and locks out the identifier per the policy.
+	RecordFailedAttempt(kind, identifier string, policy models.LockoutPolicy) (*models.Lockout, error)
+	ResetLockout(kind, identifier string) error
+
+	CreateAuditEvent(event *models.AuditEvent) (*models.AuditEvent, error)
+	// ListAuditEvents returns a cursor over audit events matching filter. [tidal.Cursor.Close]
+	// rolls back the transaction; use [tidal.Cursor.CloseRows] to release the result set and
+	// continue using this transaction.
+	ListAuditEvents(filter tidal.ListFilter) (tidal.Cursor[*models.AuditEvent], error)
+
+	CreateWebhook(webhook *models.Webhook) (*models.Webhook, error)
+	RetrieveWebhook(id ulid.ULID) (*models.Webhook, error)
+	UpdateWebhook(webhook *models.Webhook) error
+	// UpdateWebhookSecret replaces the secret that the webhook's deliveries are signed with.
+	UpdateWebhookSecret(id ulid.ULID, secret string) error
+	// DeleteWebhook also deletes the webhook's delivery log.
+	DeleteWebhook(id ulid.ULID) error
+	// EnqueueWebhookEvent queues a delivery for every active webhook subscribed to the event.
+	EnqueueWebhookEvent(eventID ulid.ULID, event enum.WebhookEvent, payload []byte) (int, error)
+	CreateWebhookDelivery(delivery *models.WebhookDelivery) (*models.WebhookDelivery, error)
+	RetrieveWebhookDelivery(id ulid.ULID) (*models.WebhookDelivery, error)
+	UpdateWebhookDelivery(delivery *models.WebhookDelivery) error
+	// ListWebhookDeliveries returns the delivery log of the webhook, most recent first.
+	ListWebhookDeliveries(webhookID ulid.ULID) ([]*models.WebhookDelivery, error)
+	// ClaimDueWebhookDeliveries leases up to limit pending deliveries due at now, longest due first.
+	ClaimDueWebhookDeliveries(now time.Time, lease time.Duration, limit int) ([]*models.WebhookDelivery, error)
+	// ListWebhooks returns a cursor over webhooks matching filter. [tidal.Cursor.Close]
+	// rolls back the transaction; use [tidal.Cursor.CloseRows] to release the result set and
+	// continue using this transaction.
+	ListWebhooks(filter tidal.ListFilter) (tidal.Cursor[*models.Webhook], error)
+
+	// ListSigningKeys returns every signing key including retired keys, newest first.
+	ListSigningKeys() ([]*models.SigningKey, error)
+	// CreateSigningKey requires the kid as the ID; returns ErrAlreadyExists if there is already a next or active key.
+	CreateSigningKey(key *models.SigningKey) (*models.SigningKey, error)
+	RetrieveSigningKey(id ulid.ULID) (*models.SigningKey, error)
+	// ActivateSigningKey activates the next key; the active key retires at the specified time.
+	ActivateSigningKey(id ulid.ULID, retires time.Time) error
+	// RetireSigningKey returns ErrActiveSigningKey if the key is the active key.
+	RetireSigningKey(id ulid.ULID) error
 }
 
 // StoreTx is the interface for Store transactional methods.
@@ -49,6 +49,13 @@ const (
 	CounterpartiesUpdated  = "counterparties-updated"
 	UsersUpdated           = "users-updated"
 	APIKeysUpdated         = "apikeys-updated"
+	SessionsUpdated        = "sessions-updated"
+	PasskeysUpdated        = "passkeys-updated"
+	RolesUpdated           = "roles-updated"
+	PermissionsUpdated     = "permissions-updated"
+	OrganizationsUpdated   = "organizations-updated"
+	InvitesUpdated         = "invites-updated"
+	EmailNotVerified       = "email-not-verified"
 )
 
 // Redirect determines if the request is an HTMX request, if so, it sets the HX-Redirect
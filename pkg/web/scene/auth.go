@@ -9,6 +9,7 @@ import (
 type LoginScene struct {
 	Scene
 	LoginURL          string
+	PasskeyLoginURL   string
 	ForgotPasswordURL string
 	Next              string
 }
@@ -28,6 +29,7 @@ func (s Scene) Login(c *gin.Context) *LoginScene {
 	return &LoginScene{
 		Scene:             s,
 		LoginURL:          loginURL.String(),
+		PasskeyLoginURL:   passkeyLoginURL.String(),
 		ForgotPasswordURL: forgotPasswordURL.String(),
 		Next:              c.Query("next"),
 	}
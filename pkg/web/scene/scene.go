@@ -30,6 +30,7 @@ var (
 	// Authentication URLs
 	issuer                  *url.URL
 	loginURL                *url.URL
+	passkeyLoginURL         *url.URL
 	issuerForgotPasswordURL *url.URL
 )
 
@@ -45,6 +46,12 @@ const (
 	UserID          = "UserID"
 	APIData         = "APIData"
 	Parent          = "Parent"
+
+	// Permissions that can be added to a role when managing the role.
+	AvailablePermissions = "AvailablePermissions"
+
+	// The organization the user is logged into for the organization switcher.
+	CurrentOrg = "CurrentOrg"
 )
 
 type Scene map[string]interface{}
@@ -167,6 +174,105 @@ func (s Scene) APIKey() *api.APIKey {
 	return nil
 }
 
+func (s Scene) SessionList() *api.SessionList {
+	if data, ok := s[APIData]; ok {
+		if out, ok := data.(*api.SessionList); ok {
+			return out
+		}
+	}
+	return nil
+}
+
+func (s Scene) MFAChallenge() *api.MFAChallenge {
+	if data, ok := s[APIData]; ok {
+		if out, ok := data.(*api.MFAChallenge); ok {
+			return out
+		}
+	}
+	return nil
+}
+
+func (s Scene) TOTPEnrollment() *api.TOTPEnrollment {
+	if data, ok := s[APIData]; ok {
+		if out, ok := data.(*api.TOTPEnrollment); ok {
+			return out
+		}
+	}
+	return nil
+}
+
+func (s Scene) RecoveryCodes() *api.RecoveryCodes {
+	if data, ok := s[APIData]; ok {
+		if out, ok := data.(*api.RecoveryCodes); ok {
+			return out
+		}
+	}
+	return nil
+}
+
+func (s Scene) WebAuthnCredentialList() *api.WebAuthnCredentialList {
+	if data, ok := s[APIData]; ok {
+		if out, ok := data.(*api.WebAuthnCredentialList); ok {
+			return out
+		}
+	}
+	return nil
+}
+
+func (s Scene) AuditEventList() *api.AuditEventList {
+	if data, ok := s[APIData]; ok {
+		if out, ok := data.(*api.AuditEventList); ok {
+			return out
+		}
+	}
+	return nil
+}
+
+func (s Scene) RoleList() *api.RoleList {
+	if data, ok := s[APIData]; ok {
+		if out, ok := data.(*api.RoleList); ok {
+			return out
+		}
+	}
+	return nil
+}
+
+func (s Scene) Role() *api.Role {
+	if data, ok := s[APIData]; ok {
+		if out, ok := data.(*api.Role); ok {
+			return out
+		}
+	}
+	return nil
+}
+
+func (s Scene) PermissionList() *api.PermissionList {
+	if data, ok := s[APIData]; ok {
+		if out, ok := data.(*api.PermissionList); ok {
+			return out
+		}
+	}
+	return nil
+}
+
+func (s Scene) OrganizationList() *api.OrganizationList {
+	if data, ok := s[APIData]; ok {
+		if out, ok := data.(*api.OrganizationList); ok {
+			return out
+		}
+	}
+	return nil
+}
+
+func (s Scene) Organization() *api.Organization {
+	if data, ok := s[APIData]; ok {
+		if out, ok := data.(*api.Organization); ok {
+			return out
+		}
+	}
+	return nil
+}
+
 //===========================================================================
 // Set Global Scene for Context
 //===========================================================================
@@ -186,5 +292,6 @@ func Configure(conf *config.Config) {
 
 	issuer, _ = url.Parse(conf.Auth.Issuer)
 	loginURL = issuer.ResolveReference(&url.URL{Path: "/v1/login"})
+	passkeyLoginURL = issuer.ResolveReference(&url.URL{Path: "/v1/login/passkey"})
 	issuerForgotPasswordURL = issuer.ResolveReference(&url.URL{Path: "/forgot-password"})
 }
@@ -1,6 +1,28 @@
 import { isRequestFor, isRequestMatch } from '../htmx/helpers.js';
 import { activateCopyButtons } from '../common/clipboard.js';
 
+/*
+The API keys are listed a page at a time, so every page is fetched by following the
+next page token so that the table can be searched and sorted in the browser.
+*/
+async function fetchAPIKeys() {
+  const apikeys = [];
+  let token = "";
+
+  do {
+    const params = new URLSearchParams({ page_size: 100 });
+    if (token) {
+      params.set("next_page_token", token);
+    }
+
+    const rep = await $.getJSON("/v1/apikeys?" + params.toString());
+    apikeys.push(...rep.apikeys);
+    token = rep.page?.next_page_token;
+  } while (token);
+
+  return apikeys;
+}
+
 /*
 When the document is ready, initialize the DataTable for the API keys list.
 */
@@ -53,14 +75,15 @@ $(document).ready(function() {
         searchable: false,
       }
     ],
-    ajax: {
-      url: "/v1/apikeys",
-      dataSrc: "apikeys",
-      error: function (xhr, error, thrown) {
-        const message = xhr.responseJSON?.error;
-        notyf.error("Error: " + message  || 'An unknown error occurred');
-        console.error(xhr.responseJSON);
-      },
+    ajax: function(data, callback, settings) {
+      fetchAPIKeys()
+        .then(apikeys => callback({ data: apikeys }))
+        .catch(xhr => {
+          const message = xhr.responseJSON?.error;
+          notyf.error("Error: " + message  || 'An unknown error occurred');
+          console.error(xhr.responseJSON);
+          callback({ data: [] });
+        });
     },
   });
 
@@ -0,0 +1,58 @@
+/*
+Handles passwordless login with a passkey. The login form is loaded by htmx so the
+click handler is delegated from the document body. The server responds to a successful
+login with an HX-Redirect header that is followed here.
+*/
+document.body.addEventListener('click', async (e) => {
+  const button = e.target.closest('[data-passkey-login]');
+  if (!button) return;
+
+  e.preventDefault();
+  if (!window.PublicKeyCredential) {
+    notyf.error('Passkeys are not supported by this browser');
+    return;
+  }
+
+  const url = button.dataset.passkeyLogin;
+  button.disabled = true;
+
+  try {
+    let rep = await post(`${url}/begin`, { next: button.dataset.next });
+    const options = await rep.json();
+    if (!rep.ok) {
+      throw new Error(options?.error);
+    }
+
+    const credential = await navigator.credentials.get({
+      publicKey: PublicKeyCredential.parseRequestOptionsFromJSON(options.publicKey),
+    });
+
+    rep = await post(`${url}/finish`, credential.toJSON());
+    if (!rep.ok) {
+      const error = await rep.json();
+      throw new Error(error?.error);
+    }
+
+    window.location.href = rep.headers.get('HX-Redirect') || '/';
+  } catch (err) {
+    // The user cancelled the browser prompt or the ceremony timed out.
+    if (err.name !== 'NotAllowedError') {
+      notyf.error(err.message || 'Could not sign in with passkey');
+    }
+    button.disabled = false;
+  }
+});
+
+function post(url, data) {
+  return fetch(url, {
+    method: 'POST',
+    credentials: 'same-origin',
+    headers: {
+      'Accept': 'text/html',
+      'Content-Type': 'application/json',
+      'HX-Request': 'true',
+      'X-CSRF-Token': getCookie('csrf_token'),
+    },
+    body: JSON.stringify(data),
+  });
+}
@@ -0,0 +1,12 @@
+/*
+Offers to resend the verification email when a login fails because the user has not
+verified their email address yet. The server sends the email-not-verified event with
+the error response; the login form is loaded by htmx so the elements are looked up
+when the event is received.
+*/
+document.body.addEventListener('email-not-verified', () => {
+  const resend = document.getElementById('resendVerification');
+  if (resend) {
+    resend.classList.remove('d-none');
+  }
+});
@@ -0,0 +1,38 @@
+import { isRequestFor, isRequestMatch } from '../htmx/helpers.js';
+
+/*
+Post-event handling after htmx has settled the DOM.
+*/
+document.body.addEventListener("htmx:afterSettle", function(e) {
+  // After fetching the role detail, display the roleDetailModal.
+  if (isRequestMatch(e, /^\/v1\/roles\/\d+$/gm, "get")) {
+    const roleDetailModal = bootstrap.Modal.getOrCreateInstance("#roleDetailModal", {});
+    roleDetailModal.show();
+    return;
+  }
+});
+
+/*
+Reset the create forms and notify the user after a successful request; errors are
+handled globally by the htmx:responseError handler.
+*/
+document.body.addEventListener("htmx:afterRequest", function(e) {
+  if (!e.detail.successful) return;
+
+  if (isRequestFor(e, "/v1/roles", "post")) {
+    document.getElementById("createRoleForm").reset();
+    notyf.success("Role created");
+    return;
+  }
+
+  if (isRequestFor(e, "/v1/permissions", "post")) {
+    document.getElementById("createPermissionForm").reset();
+    notyf.success("Permission created");
+    return;
+  }
+
+  if (isRequestMatch(e, /^\/v1\/roles\/\d+$/gm, "put")) {
+    notyf.success("Role updated");
+    return;
+  }
+});
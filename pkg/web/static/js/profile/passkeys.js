@@ -0,0 +1,59 @@
+/*
+Registers a new passkey for the user: the server creates the registration options and
+a challenge cookie, the browser creates the credential with the user's authenticator,
+and the credential is sent back to the server to be verified and stored.
+*/
+const form = document.getElementById('registerPasskeyForm');
+
+form?.addEventListener('submit', async (e) => {
+  e.preventDefault();
+  if (!window.PublicKeyCredential) {
+    notyf.error('Passkeys are not supported by this browser');
+    return;
+  }
+
+  const url = `/v1/users/${form.dataset.userId}/passkeys`;
+  const button = form.querySelector('button[type="submit"]');
+  button.disabled = true;
+
+  try {
+    const options = await postJSON(`${url}/register`);
+    const credential = await navigator.credentials.create({
+      publicKey: PublicKeyCredential.parseCreationOptionsFromJSON(options.publicKey),
+    });
+
+    await postJSON(url, {
+      name: form.elements.name.value,
+      credential: credential.toJSON(),
+    });
+
+    form.reset();
+    notyf.success('Passkey added');
+    htmx.trigger(document.body, 'passkeys-updated');
+  } catch (err) {
+    // The user cancelled the browser prompt or the ceremony timed out.
+    if (err.name === 'NotAllowedError') return;
+    notyf.error(err.message || 'Could not add passkey');
+  } finally {
+    button.disabled = false;
+  }
+});
+
+async function postJSON(url, data) {
+  const rep = await fetch(url, {
+    method: 'POST',
+    credentials: 'same-origin',
+    headers: {
+      'Accept': 'application/json',
+      'Content-Type': 'application/json',
+      'X-CSRF-Token': getCookie('csrf_token'),
+    },
+    body: JSON.stringify(data || {}),
+  });
+
+  const body = await rep.json();
+  if (!rep.ok) {
+    throw new Error(body?.error || 'An unknown error occurred');
+  }
+  return body;
+}
@@ -0,0 +1,14 @@
+import { isRequestMatch } from '../htmx/helpers.js';
+
+/*
+Notify the user after the organization settings are saved; errors are handled globally
+by the htmx:responseError handler.
+*/
+document.body.addEventListener("htmx:afterRequest", function(e) {
+  if (!e.detail.successful) return;
+
+  if (isRequestMatch(e, /^\/v1\/organizations\/[0-9A-HJKMNP-TV-Z]{26}$/gm, "put")) {
+    notyf.success("Organization settings saved");
+    return;
+  }
+});
@@ -0,0 +1,179 @@
+/*
+Package webhooks signs and delivers Quarterdeck events to the endpoints that have
+subscribed to them. Each delivery is an HTTP POST of the JSON [Event] with headers that
+identify the event and the delivery, and a signature header that is an HMAC-SHA256 of
+the timestamp and the request body using the secret of the webhook subscription.
+
+Receivers should use [Verify] to check the signature before handling the event; the
+timestamp is part of the signature so that old deliveries cannot be replayed.
+*/
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.rtnl.ai/quarterdeck/pkg/enum"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/ulid"
+)
+
+// Headers sent with each webhook delivery.
+const (
+	HeaderEvent     = "X-Quarterdeck-Event"
+	HeaderDelivery  = "X-Quarterdeck-Delivery"
+	HeaderSignature = "X-Quarterdeck-Signature"
+)
+
+// DefaultTolerance is the maximum age of a signature accepted by [Verify].
+const DefaultTolerance = 5 * time.Minute
+
+// SecretLength is the number of random bytes in a generated webhook secret.
+const SecretLength = 32
+
+// maxErrorBody limits how much of the response body is recorded when a delivery fails.
+const maxErrorBody = 512
+
+// Event is the JSON body of a webhook delivery. The ID identifies the event rather than
+// the delivery so that receivers can ignore events they have already handled when an
+// event is redelivered.
+type Event struct {
+	ID      ulid.ULID         `json:"id"`
+	Event   enum.WebhookEvent `json:"event"`
+	Created time.Time         `json:"created"`
+	Data    json.RawMessage   `json:"data"`
+}
+
+// NewEvent creates an event with the JSON serialized data as its payload.
+func NewEvent(event enum.WebhookEvent, data any) (out *Event, err error) {
+	out = &Event{
+		ID:      ulid.MakeSecure(),
+		Event:   event,
+		Created: time.Now().UTC(),
+	}
+
+	if out.Data, err = json.Marshal(data); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+//===========================================================================
+// Signatures
+//===========================================================================
+
+// NewSecret generates a random hex encoded secret to sign the deliveries to a webhook.
+func NewSecret() string {
+	secret := make([]byte, SecretLength)
+	if _, err := rand.Read(secret); err != nil {
+		panic(fmt.Errorf("could not generate webhook secret: %w", err))
+	}
+	return hex.EncodeToString(secret)
+}
+
+// Sign returns the signature header for the body sent at the specified time in the
+// form t=<unix timestamp>,v1=<hex encoded hmac>.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	ts := timestamp.Unix()
+	return fmt.Sprintf("t=%d,v1=%s", ts, hex.EncodeToString(mac(secret, ts, body)))
+}
+
+// Verify the signature header of a webhook delivery, ensuring that the body was signed
+// with the secret less than the tolerance ago.
+func Verify(secret, header string, body []byte, tolerance time.Duration) (err error) {
+	var (
+		ts  int64
+		sig []byte
+	)
+
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			if ts, err = strconv.ParseInt(value, 10, 64); err != nil {
+				return errors.ErrInvalidSignature
+			}
+		case "v1":
+			if sig, err = hex.DecodeString(value); err != nil {
+				return errors.ErrInvalidSignature
+			}
+		}
+	}
+
+	if ts == 0 || len(sig) == 0 {
+		return errors.ErrInvalidSignature
+	}
+
+	if !hmac.Equal(sig, mac(secret, ts, body)) {
+		return errors.ErrInvalidSignature
+	}
+
+	if age := time.Since(time.Unix(ts, 0)); age > tolerance || age < -tolerance {
+		return errors.ErrSignatureExpired
+	}
+	return nil
+}
+
+func mac(secret string, ts int64, body []byte) []byte {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(strconv.FormatInt(ts, 10)))
+	h.Write([]byte("."))
+	h.Write(body)
+	return h.Sum(nil)
+}
+
+//===========================================================================
+// Delivery
+//===========================================================================
+
+// Delivery is a signed HTTP POST of an event to a webhook endpoint.
+type Delivery struct {
+	ID      ulid.ULID
+	URL     string
+	Secret  string
+	Event   enum.WebhookEvent
+	Payload []byte
+}
+
+// Send the delivery with the client, returning the HTTP status code of the response
+// if one was received. Any response other than a 2xx is an error.
+func (d *Delivery) Send(ctx context.Context, client *http.Client) (code int, err error) {
+	var req *http.Request
+	if req, err = http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(d.Payload)); err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, d.Event.String())
+	req.Header.Set(HeaderDelivery, d.ID.String())
+	req.Header.Set(HeaderSignature, Sign(d.Secret, time.Now(), d.Payload))
+
+	var rep *http.Response
+	if rep, err = client.Do(req); err != nil {
+		return 0, err
+	}
+	defer rep.Body.Close()
+
+	if rep.StatusCode < 200 || rep.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(rep.Body, maxErrorBody))
+		if len(body) > 0 {
+			return rep.StatusCode, fmt.Errorf("%w: %s: %s", errors.ErrDeliveryFailed, rep.Status, bytes.TrimSpace(body))
+		}
+		return rep.StatusCode, fmt.Errorf("%w: %s", errors.ErrDeliveryFailed, rep.Status)
+	}
+
+	// Drain the body so the connection can be reused.
+	_, _ = io.Copy(io.Discard, rep.Body)
+	return rep.StatusCode, nil
+}
@@ -0,0 +1,125 @@
+package webhooks_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.rtnl.ai/quarterdeck/pkg/enum"
+	"go.rtnl.ai/quarterdeck/pkg/errors"
+	"go.rtnl.ai/quarterdeck/pkg/webhooks"
+	"go.rtnl.ai/ulid"
+)
+
+func TestNewEvent(t *testing.T) {
+	event, err := webhooks.NewEvent(enum.WebhookEventUserDeleted, map[string]string{"id": "01JPYRNYMEHNEZCS0JYX1CP57A"})
+	require.NoError(t, err)
+	require.False(t, event.ID.IsZero())
+	require.Equal(t, enum.WebhookEventUserDeleted, event.Event)
+	require.JSONEq(t, `{"id": "01JPYRNYMEHNEZCS0JYX1CP57A"}`, string(event.Data))
+
+	data, err := json.Marshal(event)
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"event":"user.deleted"`)
+}
+
+func TestNewSecret(t *testing.T) {
+	secret := webhooks.NewSecret()
+	require.Len(t, secret, webhooks.SecretLength*2)
+	require.NotEqual(t, secret, webhooks.NewSecret())
+}
+
+func TestSignatures(t *testing.T) {
+	secret := "supersecretsquirrel"
+	body := []byte(`{"event":"login"}`)
+
+	t.Run("Valid", func(t *testing.T) {
+		header := webhooks.Sign(secret, time.Now(), body)
+		require.NoError(t, webhooks.Verify(secret, header, body, webhooks.DefaultTolerance))
+	})
+
+	t.Run("Deterministic", func(t *testing.T) {
+		ts := time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC)
+		require.Equal(t, webhooks.Sign(secret, ts, body), webhooks.Sign(secret, ts, body))
+		require.NotEqual(t, webhooks.Sign(secret, ts, body), webhooks.Sign("othersecret", ts, body))
+		require.Regexp(t, `^t=1741953600,v1=[0-9a-f]{64}$`, webhooks.Sign(secret, ts, body))
+	})
+
+	t.Run("WrongSecret", func(t *testing.T) {
+		header := webhooks.Sign("othersecret", time.Now(), body)
+		require.ErrorIs(t, webhooks.Verify(secret, header, body, webhooks.DefaultTolerance), errors.ErrInvalidSignature)
+	})
+
+	t.Run("ModifiedBody", func(t *testing.T) {
+		header := webhooks.Sign(secret, time.Now(), body)
+		require.ErrorIs(t, webhooks.Verify(secret, header, []byte(`{"event":"user.deleted"}`), webhooks.DefaultTolerance), errors.ErrInvalidSignature)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		header := webhooks.Sign(secret, time.Now().Add(-1*time.Hour), body)
+		require.ErrorIs(t, webhooks.Verify(secret, header, body, webhooks.DefaultTolerance), errors.ErrSignatureExpired)
+	})
+
+	t.Run("Malformed", func(t *testing.T) {
+		for _, header := range []string{"", "t=abc,v1=00", "t=1741953600", "v1=abcdef", "t=1741953600,v1=nothex"} {
+			require.ErrorIs(t, webhooks.Verify(secret, header, body, webhooks.DefaultTolerance), errors.ErrInvalidSignature, "expected %q to be invalid", header)
+		}
+	})
+}
+
+func TestDeliverySend(t *testing.T) {
+	secret := "supersecretsquirrel"
+	delivery := &webhooks.Delivery{
+		ID:      ulid.MakeSecure(),
+		Secret:  secret,
+		Event:   enum.WebhookEventUserCreated,
+		Payload: []byte(`{"event":"user.created"}`),
+	}
+
+	t.Run("Delivered", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			require.Equal(t, delivery.Payload, body)
+			require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			require.Equal(t, "user.created", r.Header.Get(webhooks.HeaderEvent))
+			require.Equal(t, delivery.ID.String(), r.Header.Get(webhooks.HeaderDelivery))
+			require.NoError(t, webhooks.Verify(secret, r.Header.Get(webhooks.HeaderSignature), body, webhooks.DefaultTolerance))
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer srv.Close()
+
+		delivery.URL = srv.URL
+		code, err := delivery.Send(context.Background(), srv.Client())
+		require.NoError(t, err)
+		require.Equal(t, http.StatusNoContent, code)
+	})
+
+	t.Run("Rejected", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "try again later", http.StatusServiceUnavailable)
+		}))
+		defer srv.Close()
+
+		delivery.URL = srv.URL
+		code, err := delivery.Send(context.Background(), srv.Client())
+		require.ErrorIs(t, err, errors.ErrDeliveryFailed)
+		require.Contains(t, err.Error(), "try again later")
+		require.Equal(t, http.StatusServiceUnavailable, code)
+	})
+
+	t.Run("Unreachable", func(t *testing.T) {
+		srv := httptest.NewServer(http.NotFoundHandler())
+		srv.Close()
+
+		delivery.URL = srv.URL
+		code, err := delivery.Send(context.Background(), http.DefaultClient)
+		require.Error(t, err)
+		require.Zero(t, code)
+	})
+}